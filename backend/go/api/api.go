@@ -0,0 +1,12 @@
+// Package api holds the OpenAPI specification generated from the handlers'
+// swag annotations. Regenerate it with `make openapi` after changing an
+// annotation; `make openapi-check` fails when the committed file is stale.
+package api
+
+import _ "embed"
+
+// OpenAPISpec is the generated specification (Swagger 2.0), served at
+// /openapi.json
+//
+//go:embed openapi.json
+var OpenAPISpec []byte
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// routerAnnotation matches swag's `@Router /path [method]` comments
+var routerAnnotation = regexp.MustCompile(`// @Router (\S+) \[(\w+)\]`)
+
+func TestOpenAPISpec_DocumentsEveryAnnotatedRoute(t *testing.T) {
+	// Arrange
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(OpenAPISpec, &spec))
+
+	files, err := filepath.Glob("../internal/http/handlers/*.go")
+	require.NoError(t, err)
+
+	// Act
+	var routes []string
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(file)
+		require.NoError(t, err)
+		for _, match := range routerAnnotation.FindAllStringSubmatch(string(src), -1) {
+			routes = append(routes, match[2]+" "+match[1])
+		}
+	}
+
+	// Assert
+	require.NotEmpty(t, routes)
+	for _, route := range routes {
+		method, path, _ := strings.Cut(route, " ")
+		assert.Contains(t, spec.Paths[path], method, "%s is annotated but missing from openapi.json; run `make openapi`", route)
+	}
+}
+
+// TestOpenAPISpec_UpToDate regenerates the specification and fails if it
+// differs from the committed file. It needs the swag CLI, found through the
+// SWAG environment variable or PATH; `make openapi-check` installs it.
+func TestOpenAPISpec_UpToDate(t *testing.T) {
+	swag := os.Getenv("SWAG")
+	if swag == "" {
+		var err error
+		if swag, err = exec.LookPath("swag"); err != nil {
+			t.Skip("swag not installed; run `make openapi-check`")
+		}
+	}
+
+	// Arrange
+	out := t.TempDir()
+	cmd := exec.Command(swag, "init",
+		"--dir", "..",
+		"--generalInfo", "cmd/api/main.go",
+		"--parseInternal",
+		"--outputTypes", "json",
+		"--output", out,
+		"--quiet",
+	)
+
+	// Act
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	// Assert
+	generated, err := os.ReadFile(filepath.Join(out, "swagger.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, string(generated), string(OpenAPISpec), "openapi.json is stale; run `make openapi`")
+}
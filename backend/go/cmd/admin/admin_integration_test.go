@@ -0,0 +1,345 @@
+//go:build integration
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/config"
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+	"github.com/provemyself/backend/internal/webhook"
+)
+
+// testApp is the app of a new SQLite database and local storage in a
+// temporary directory, with the audit lines the commands write
+type testApp struct {
+	*app
+	database    *store.Database
+	storagePath string
+	audit       *bytes.Buffer
+	ctx         context.Context
+}
+
+func newTestApp(t *testing.T) *testApp {
+	t.Helper()
+
+	dir := t.TempDir()
+	audit := &bytes.Buffer{}
+	ctx := zerolog.New(audit).WithContext(context.Background())
+
+	database, err := store.NewDatabase(ctx, store.DatabaseConfig{
+		URL:            "sqlite://" + filepath.Join(dir, "admin.db"),
+		MaxOpenConns:   5,
+		MaxIdleConns:   5,
+		ConnectTimeout: 30 * time.Second,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+	require.NoError(t, database.Migrate(ctx))
+
+	storagePath := filepath.Join(dir, "files")
+	a := newApp(database, store.NewLocalStorage(storagePath, "/files"), &config.Config{
+		MaxFileSize:                 1 << 20,
+		WebhookTimeout:              5 * time.Second,
+		WebhookDisableAfter:         10,
+		WebhookAllowPrivateNetworks: true,
+	})
+	a.operator = "alice"
+	a.now = func() time.Time { return time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC) }
+	return &testApp{app: a, database: database, storagePath: storagePath, audit: audit, ctx: ctx}
+}
+
+// run runs the command line args, returning its output
+func (a *testApp) run(args ...string) (string, error) {
+	var out bytes.Buffer
+	err := run(a.ctx, a.app, args, &out)
+	return out.String(), err
+}
+
+// auditEvents returns the audit events written so far
+func (a *testApp) auditEvents(t *testing.T) []map[string]interface{} {
+	t.Helper()
+
+	var events []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(a.audit.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		if _, ok := event["audit"]; ok {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// seedProject creates a project with two items
+func (a *testApp) seedProject(t *testing.T) *core.Project {
+	t.Helper()
+
+	ctx := core.WithAccessScope(a.ctx, core.AccessScope{UserID: "seed", Role: core.UserRoleAdmin})
+	project, err := a.projects.Create(ctx, "Geography", stringPtr("Capitals of Europe"), []string{"geo"})
+	require.NoError(t, err)
+	_, err = a.items.CreateMany(ctx, project.ID, []core.ItemInput{
+		{Type: types.ItemTypeChoice, Title: "Capital of France", Content: types.ChoiceContent{Choices: []types.Choice{
+			{ID: "a", Text: "Paris", Correct: true}, {ID: "b", Text: "Lyon"},
+		}}, Position: 0, Points: intPtr(2)},
+		{Type: types.ItemTypeTextEntry, Title: "Capital of Spain", Content: types.TextEntryContent{CorrectAnswer: stringPtr("Madrid")}, Position: 1, Required: true},
+	})
+	require.NoError(t, err)
+	return project
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestProject_ExportImportRoundTrip(t *testing.T) {
+	// Arrange
+	a := newTestApp(t)
+	source := a.seedProject(t)
+	archivePath := filepath.Join(t.TempDir(), "archive.json")
+
+	// Act
+	exported, exportErr := a.run("project", "export", source.ID, "--output", archivePath)
+	imported, importErr := a.run("project", "import", archivePath, "--json")
+
+	// Assert
+	require.NoError(t, exportErr)
+	require.NoError(t, importErr)
+	assert.Contains(t, exported, "with 2 item(s) to "+archivePath)
+
+	data, err := os.ReadFile(archivePath)
+	require.NoError(t, err)
+	var archive types.ProjectArchive
+	require.NoError(t, json.Unmarshal(data, &archive))
+	assert.Equal(t, types.ProjectArchiveVersion, archive.Version)
+	assert.Equal(t, a.now(), archive.ExportedAt)
+
+	var report importReport
+	require.NoError(t, json.Unmarshal([]byte(imported), &report))
+	assert.Equal(t, source.ID, report.SourceProjectID)
+	assert.NotEqual(t, source.ID, report.ProjectID)
+	assert.Equal(t, 2, report.Items)
+
+	ctx := core.WithAccessScope(a.ctx, core.AccessScope{UserID: "check", Role: core.UserRoleAdmin})
+	project, err := a.projects.GetByID(ctx, report.ProjectID)
+	require.NoError(t, err)
+	assert.Equal(t, "Geography", project.Title)
+	require.NotNil(t, project.Description)
+	assert.Equal(t, "Capitals of Europe", *project.Description)
+	assert.Equal(t, []string{"geo"}, project.Tags)
+	assert.Nil(t, project.PublishedAt, "imported as a draft")
+	items, err := a.items.ListByProject(ctx, project.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "Capital of France", items[0].Title)
+	require.NotNil(t, items[0].Points)
+	assert.Equal(t, 2, *items[0].Points)
+	assert.Equal(t, "Capital of Spain", items[1].Title)
+	assert.True(t, items[1].Required)
+	var content types.TextEntryContent
+	require.NoError(t, json.Unmarshal(items[1].Content, &content))
+	require.NotNil(t, content.CorrectAnswer)
+	assert.Equal(t, "Madrid", *content.CorrectAnswer)
+
+	events := a.auditEvents(t)
+	require.Len(t, events, 2)
+	assert.Equal(t, "project_exported", events[0]["audit"])
+	assert.Equal(t, "cli:alice", events[0]["user_id"])
+	assert.Equal(t, "project_imported", events[1]["audit"])
+	assert.Equal(t, report.ProjectID, events[1]["project_id"])
+}
+
+func TestProject_ExportToStdout(t *testing.T) {
+	// Arrange
+	a := newTestApp(t)
+	source := a.seedProject(t)
+
+	// Act
+	out, err := a.run("project", "export", source.ID)
+
+	// Assert
+	require.NoError(t, err)
+	var archive types.ProjectArchive
+	require.NoError(t, json.Unmarshal([]byte(out), &archive))
+	assert.Equal(t, source.ID, archive.Project.ID)
+	assert.Len(t, archive.Items, 2)
+}
+
+func TestProject_ImportRejectsOtherVersions(t *testing.T) {
+	// Arrange
+	a := newTestApp(t)
+	a.stdin = strings.NewReader(`{"version": 2, "project": {"title": "From the future"}, "items": []}`)
+
+	// Act
+	_, err := a.run("project", "import", "-")
+
+	// Assert
+	assert.ErrorContains(t, err, "unsupported archive version 2")
+}
+
+func TestDryRun_ChangesNothing(t *testing.T) {
+	// Arrange
+	a := newTestApp(t)
+	source := a.seedProject(t)
+	archive, err := a.run("project", "export", source.ID)
+	require.NoError(t, err)
+	a.audit.Reset()
+
+	orphan := filepath.Join(a.storagePath, "projects", uuid.NewString(), "map.png")
+	require.NoError(t, os.MkdirAll(filepath.Dir(orphan), 0o755))
+	require.NoError(t, os.WriteFile(orphan, []byte("png"), 0o600))
+
+	// Act
+	a.stdin = strings.NewReader(archive)
+	imported, importErr := a.run("project", "import", "-", "--dry-run")
+	deleted, deleteErr := a.run("project", "delete", source.ID, "--dry-run")
+	reconciled, reconcileErr := a.run("storage", "reconcile", "--dry-run")
+
+	// Assert
+	require.NoError(t, importErr)
+	require.NoError(t, deleteErr)
+	require.NoError(t, reconcileErr)
+	assert.Contains(t, imported, "nothing was created")
+	assert.Contains(t, deleted, "with 2 item(s) would be deleted")
+	assert.Contains(t, reconciled, "map.png")
+
+	ctx := core.WithAccessScope(a.ctx, core.AccessScope{UserID: "check", Role: core.UserRoleAdmin})
+	page, err := a.projects.List(ctx, core.ListOptions{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, page.Total, "the import was rolled back")
+	require.Len(t, page.Projects, 1)
+	assert.Equal(t, source.ID, page.Projects[0].ID, "the project was not deleted")
+	assert.FileExists(t, orphan)
+	assert.Empty(t, a.auditEvents(t))
+}
+
+func TestProject_Delete(t *testing.T) {
+	// Arrange
+	a := newTestApp(t)
+	source := a.seedProject(t)
+
+	// Act
+	out, err := a.run("project", "delete", source.ID, "--operator", "bob")
+	_, againErr := a.run("project", "delete", source.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Contains(t, out, "Deleted project "+source.ID)
+	assert.ErrorIs(t, againErr, core.ErrProjectNotFound)
+
+	events := a.auditEvents(t)
+	require.Len(t, events, 1)
+	assert.Equal(t, "project_deleted", events[0]["audit"])
+	assert.Equal(t, "cli:bob", events[0]["user_id"])
+	assert.Equal(t, source.ID, events[0]["project_id"])
+}
+
+func TestStorage_Reconcile(t *testing.T) {
+	// Arrange
+	a := newTestApp(t)
+	source := a.seedProject(t)
+
+	kept := filepath.Join(a.storagePath, "projects", source.ID, "map.png")
+	orphan := filepath.Join(a.storagePath, "projects", uuid.NewString(), "map.png")
+	for _, path := range []string{kept, orphan} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("png"), 0o600))
+	}
+
+	// Act
+	out, err := a.run("storage", "reconcile", "--json")
+
+	// Assert
+	require.NoError(t, err)
+	var report reconcileReport
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	assert.Len(t, report.Files, 1)
+	assert.Equal(t, 1, report.Deleted)
+	assert.FileExists(t, kept)
+	assert.NoFileExists(t, orphan)
+
+	events := a.auditEvents(t)
+	require.Len(t, events, 1)
+	assert.Equal(t, "storage_reconciled", events[0]["audit"])
+}
+
+func TestWebhooks_RedeliverDeadLetters(t *testing.T) {
+	// Arrange
+	a := newTestApp(t)
+	var received []string
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get(webhook.DeliveryHeader))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	created, err := a.webhooks.Create(a.ctx, &core.Webhook{
+		UserID:     "carol",
+		OwnerEmail: "carol@example.com",
+		URL:        endpoint.URL,
+		Secret:     "whsec-0123456789abcdef",
+		Events:     []core.EventType{core.EventProjectCreated},
+		Active:     true,
+	})
+	require.NoError(t, err)
+	failed, err := a.webhooks.CreateDelivery(a.ctx, &core.WebhookDelivery{
+		WebhookID: created.ID,
+		EventID:   uuid.NewString(),
+		EventType: core.EventProjectCreated,
+		Payload:   []byte(`{"type":"project.created"}`),
+		Status:    core.WebhookDeliveryFailed,
+	})
+	require.NoError(t, err)
+
+	// Act
+	dryRun, dryRunErr := a.run("webhooks", "redeliver", "--dead-letter", "--dry-run")
+	receivedOnDryRun := len(received)
+	out, err := a.run("webhooks", "redeliver", "--dead-letter", "--json")
+	again, againErr := a.run("webhooks", "redeliver", "--dead-letter")
+
+	// Assert
+	require.NoError(t, dryRunErr)
+	assert.Contains(t, dryRun, failed.ID)
+	assert.Contains(t, dryRun, "nothing was sent")
+	assert.Zero(t, receivedOnDryRun)
+
+	require.NoError(t, err)
+	var report redeliverReport
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	require.Len(t, report.Deliveries, 1)
+	assert.Equal(t, failed.ID, report.Deliveries[0].DeliveryID)
+	assert.Equal(t, string(core.WebhookDeliverySucceeded), report.Deliveries[0].Status)
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Len(t, received, 1)
+
+	require.NoError(t, againErr)
+	assert.Contains(t, again, "No dead letters.", "the redelivered event is no dead letter")
+
+	events := a.auditEvents(t)
+	require.Len(t, events, 1)
+	assert.Equal(t, "webhooks_redelivered", events[0]["audit"])
+	assert.Equal(t, float64(1), events[0]["succeeded"])
+}
@@ -0,0 +1,404 @@
+// Command admin runs operator tasks against the API's database and file
+// storage, independently of the API process. It reads the same
+// configuration as the API and builds the same stores and services, so
+// business rules apply as they do to API requests. Commands that change
+// data write an audit line attributed to "cli:<operator>" and take
+// --dry-run to report what they would do without doing it.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"os/user"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/config"
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/logging"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+	"github.com/provemyself/backend/internal/webhook"
+)
+
+const usage = `Usage: admin <command> [flags] [arguments]
+
+Commands:
+  project export PROJECT_ID         write the project and its items as a JSON archive, to --output or stdout
+  project import FILE               create a draft project from an archive of project export; FILE - reads stdin
+  project delete PROJECT_ID         delete the project
+  webhooks redeliver --dead-letter  send again the failed deliveries whose event never reached an active webhook (--limit, default 100)
+  storage reconcile                 delete the stored project files whose project row is gone
+  integrity check                   report rows and stored files left behind by a deleted parent; exits 2 if any are found
+
+Flags:
+  --json           print the outcome as JSON
+  --operator NAME  who runs the command, for the audit log; defaults to the OS user
+  --org ID         act in the organization ID rather than on the projects of no organization
+  --dry-run        with import, delete, redeliver and reconcile: report what would be done, change nothing
+`
+
+// errUsage reports a malformed command line
+var errUsage = errors.New("invalid arguments")
+
+// errOrphans makes integrity exit with code 2
+var errOrphans = errors.New("orphans found")
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// orphanedFiles is the part of *store.IntegrityStore storage reconcile uses
+type orphanedFiles interface {
+	OrphanedFiles(ctx context.Context) ([]string, error)
+}
+
+// resender is the part of *webhook.Dispatcher webhooks redeliver uses
+type resender interface {
+	Resend(ctx context.Context, webhook *core.Webhook, previous *core.WebhookDelivery) (*core.WebhookDelivery, error)
+}
+
+// app holds what the commands work with
+type app struct {
+	integrity  core.IntegrityChecker
+	orphans    orphanedFiles
+	files      *core.StorageService
+	tx         core.Transactor
+	projects   *core.ProjectService
+	items      *core.ItemService
+	webhooks   core.WebhookStore
+	dispatcher resender
+
+	// operator is who runs commands without --operator
+	operator string
+	stdin    io.Reader
+	now      func() time.Time
+}
+
+func main() {
+	logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to load configuration")
+	}
+	logLevel, _ := logging.ParseLevel(cfg.LogLevel)
+	// Logs go to stderr, leaving stdout to the command's output
+	logger = logging.New(logging.Config{
+		Level:  logLevel,
+		Pretty: cfg.IsDevelopment(),
+		Output: os.Stderr,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx = logger.WithContext(ctx)
+
+	database, err := store.NewDatabase(ctx, cfg.Database())
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialize database")
+	}
+	defer database.Close()
+
+	var storage core.Storage
+	if cfg.StorageType == "local" {
+		storage = store.NewLocalStorage(cfg.StoragePath, "/files")
+	}
+
+	a := newApp(database, storage, cfg)
+	err = run(ctx, a, os.Args[1:], os.Stdout)
+	switch {
+	case err == nil:
+	case errors.Is(err, errUsage):
+		fmt.Fprint(os.Stderr, usage)
+		logger.Error().Err(err).Msg("admin failed")
+		os.Exit(64)
+	case errors.Is(err, errOrphans):
+		os.Exit(2)
+	default:
+		logger.Error().Err(err).Msg("admin failed")
+		os.Exit(1)
+	}
+}
+
+// newApp builds the stores and services as the API does. Project events
+// are not published: the CLI runs no webhook workers. storage may be nil,
+// in which case stored files are left alone.
+func newApp(database *store.Database, storage core.Storage, cfg *config.Config) *app {
+	var files *core.StorageService
+	if storage != nil {
+		files = core.NewStorageService(storage, core.StorageConfig{
+			MaxFileSize:      cfg.MaxFileSize,
+			AllowedFileTypes: cfg.AllowedFileTypes,
+		})
+	}
+
+	projectStore := store.NewProjectStore(database)
+	projects := core.NewProjectService(projectStore, files)
+	projects.SetOrganizations(store.NewOrganizationStore(database))
+	itemStore := store.NewItemStore(database)
+	projects.SetItems(itemStore)
+	items := core.NewItemService(itemStore, projectStore)
+	items.SetTransactor(database)
+
+	integrity := store.NewIntegrityStore(database, storage)
+	webhooks := store.NewWebhookStore(database)
+	return &app{
+		integrity: integrity,
+		orphans:   integrity,
+		tx:        database,
+		projects:  projects,
+		items:     items,
+		webhooks:  webhooks,
+		// Owners of webhooks disabled by a redelivery are not emailed:
+		// the CLI runs no email queue
+		dispatcher: webhook.NewDispatcher(webhooks, nil, cfg.Webhooks()),
+		operator:   defaultOperator(),
+		stdin:      os.Stdin,
+		now:        time.Now,
+		files:      files,
+	}
+}
+
+// defaultOperator returns the OS user, "" if it is unknown
+func defaultOperator() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
+
+// options are the flags of a command line
+type options struct {
+	json     bool
+	operator string
+	orgID    string
+	dryRun   bool
+
+	// project export
+	output string
+	// webhooks redeliver
+	deadLetter bool
+	limit      int
+}
+
+// actor is who the audit log attributes the command to
+func (o *options) actor() string {
+	return "cli:" + o.operator
+}
+
+// report is the outcome of a command, printed as text or, with --json, as
+// JSON
+type report interface {
+	printText(out io.Writer)
+}
+
+// command is a leaf command such as "project export"
+type command struct {
+	name string
+	// args is the number of arguments the command takes
+	args int
+	// destructive commands take --dry-run
+	destructive bool
+	// flags registers the command's own flags
+	flags func(fs *flag.FlagSet, opts *options)
+	run   func(ctx context.Context, a *app, opts *options, args []string, out io.Writer) (report, error)
+}
+
+var commands = []*command{
+	{
+		name: "project export",
+		args: 1,
+		flags: func(fs *flag.FlagSet, opts *options) {
+			fs.StringVar(&opts.output, "output", "", "write the archive to this file rather than stdout")
+		},
+		run: projectExport,
+	},
+	{name: "project import", args: 1, destructive: true, run: projectImport},
+	{name: "project delete", args: 1, destructive: true, run: projectDelete},
+	{
+		name:        "webhooks redeliver",
+		destructive: true,
+		flags: func(fs *flag.FlagSet, opts *options) {
+			fs.BoolVar(&opts.deadLetter, "dead-letter", false, "redeliver the dead letters")
+			fs.IntVar(&opts.limit, "limit", 100, "redeliver at most this many deliveries")
+		},
+		run: webhooksRedeliver,
+	},
+	{name: "storage reconcile", destructive: true, run: storageReconcile},
+	{name: "integrity check", run: integrityCheck},
+}
+
+// aliases maps the commands of earlier versions to their current name
+var aliases = map[string]string{
+	"integrity": "integrity check",
+}
+
+// lookup finds the command args start with, returning its arguments
+func lookup(args []string) (*command, []string, error) {
+	if len(args) == 0 {
+		return nil, nil, fmt.Errorf("%w: missing command", errUsage)
+	}
+	if len(args) >= 2 {
+		for _, cmd := range commands {
+			if cmd.name == args[0]+" "+args[1] {
+				return cmd, args[2:], nil
+			}
+		}
+	}
+	if name, ok := aliases[args[0]]; ok {
+		for _, cmd := range commands {
+			if cmd.name == name {
+				return cmd, args[1:], nil
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: unknown command %q", errUsage, strings.Join(args[:min(len(args), 2)], " "))
+}
+
+// run executes the command named by args
+func run(ctx context.Context, a *app, args []string, out io.Writer) error {
+	cmd, args, err := lookup(args)
+	if err != nil {
+		return err
+	}
+
+	opts := &options{operator: a.operator}
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&opts.json, "json", false, "print the outcome as JSON")
+	fs.StringVar(&opts.operator, "operator", opts.operator, "who runs the command")
+	fs.StringVar(&opts.orgID, "org", "", "organization to act in")
+	if cmd.destructive {
+		fs.BoolVar(&opts.dryRun, "dry-run", false, "report what would be done, change nothing")
+	}
+	if cmd.flags != nil {
+		cmd.flags(fs, opts)
+	}
+	args, err = parseInterspersed(fs, args)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", errUsage, cmd.name, err)
+	}
+	if len(args) != cmd.args {
+		return fmt.Errorf("%w: %s takes %d argument(s), got %d", errUsage, cmd.name, cmd.args, len(args))
+	}
+	if opts.operator == "" {
+		return fmt.Errorf("%w: the OS user is unknown, pass --operator", errUsage)
+	}
+
+	// The CLI reaches every organization, as operators' tokens do
+	ctx = core.WithAccessScope(ctx, core.AccessScope{UserID: opts.actor(), Role: core.UserRoleAdmin})
+	if opts.orgID != "" {
+		ctx = core.WithOrgID(ctx, opts.orgID)
+	}
+
+	result, err := cmd.run(ctx, a, opts, args, out)
+	if result != nil {
+		if opts.json {
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			if encodeErr := encoder.Encode(result); encodeErr != nil {
+				return encodeErr
+			}
+		} else {
+			result.printText(out)
+		}
+	}
+	return err
+}
+
+// parseInterspersed parses the flags of args wherever they are among the
+// arguments, and returns the arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// audit starts an audit line of event attributed to the command's actor.
+// Audit lines are written whatever the log level.
+func audit(ctx context.Context, opts *options, event string) *zerolog.Event {
+	line := log.Ctx(ctx).Log().
+		Str("audit", event).
+		Str("user_id", opts.actor())
+	if opts.orgID != "" {
+		line = line.Str("org_id", opts.orgID)
+	}
+	return line
+}
+
+// integrityReport is the outcome of integrity check
+type integrityReport struct {
+	types.IntegrityResponse
+	results []core.IntegrityResult
+}
+
+func (r *integrityReport) printText(out io.Writer) {
+	printIntegrity(out, r.results)
+}
+
+func integrityCheck(ctx context.Context, a *app, opts *options, args []string, out io.Writer) (report, error) {
+	results, err := a.integrity.CheckIntegrity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &integrityReport{
+		IntegrityResponse: types.IntegrityResponse{Checks: make([]types.IntegrityCheckResponse, len(results))},
+		results:           results,
+	}
+	for i, result := range results {
+		r.Orphans += result.Orphans
+		r.Checks[i] = types.IntegrityCheckResponse{
+			Check:       result.Check,
+			Description: result.Description,
+			Orphans:     result.Orphans,
+			SampleIDs:   result.SampleIDs,
+		}
+	}
+	if r.Orphans > 0 {
+		return r, errOrphans
+	}
+	return r, nil
+}
+
+// printIntegrity writes a table of the checks and returns the total number
+// of orphans they found
+func printIntegrity(out io.Writer, results []core.IntegrityResult) int {
+	total := 0
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "CHECK\tORPHANS\tSAMPLE")
+	for _, result := range results {
+		total += result.Orphans
+		sample := "-"
+		if len(result.SampleIDs) > 0 {
+			sample = strings.Join(result.SampleIDs, ", ")
+		}
+		fmt.Fprintf(table, "%s\t%d\t%s\n", result.Check, result.Orphans, sample)
+	}
+	table.Flush()
+
+	if total > 0 {
+		fmt.Fprintf(out, "\n%d orphan(s) found; a table or file referencing a parent is missing its ON DELETE CASCADE or cleanup.\n", total)
+	}
+	return total
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// fakeChecker counts the checks it is asked to run
+type fakeChecker struct {
+	runs    int
+	results []core.IntegrityResult
+}
+
+func (c *fakeChecker) CheckIntegrity(ctx context.Context) ([]core.IntegrityResult, error) {
+	c.runs++
+	return c.results, nil
+}
+
+func TestRun_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no command", nil},
+		{"unknown command", []string{"fsck"}},
+		{"integrity with arguments", []string{"integrity", "items"}},
+		{"unknown flag", []string{"integrity", "check", "--force"}},
+		{"dry run of a check", []string{"integrity", "check", "--dry-run"}},
+		{"export without a project", []string{"project", "export"}},
+		{"delete of two projects", []string{"project", "delete", "p-1", "p-2"}},
+		{"redeliver without --dead-letter", []string{"webhooks", "redeliver"}},
+		{"redeliver with no limit", []string{"webhooks", "redeliver", "--dead-letter", "--limit", "0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			checker := &fakeChecker{}
+
+			// Act
+			err := run(context.Background(), &app{integrity: checker, operator: "alice"}, tt.args, &bytes.Buffer{})
+
+			// Assert
+			assert.ErrorIs(t, err, errUsage)
+			assert.Zero(t, checker.runs)
+		})
+	}
+}
+
+func TestRun_Integrity(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		results         []core.IntegrityResult
+		expectedOrphans bool
+		expectedLines   []string
+	}{
+		{
+			name: "no orphans",
+			args: []string{"integrity", "check"},
+			results: []core.IntegrityResult{
+				{Check: "items.project_id"},
+				{Check: "storage.projects"},
+			},
+			expectedLines: []string{
+				"CHECK             ORPHANS  SAMPLE",
+				"items.project_id  0        -",
+				"storage.projects  0        -",
+			},
+		},
+		{
+			name: "orphans, with the command of earlier versions",
+			args: []string{"integrity"},
+			results: []core.IntegrityResult{
+				{Check: "items.project_id", Orphans: 2, SampleIDs: []string{"item-1", "item-2"}},
+				{Check: "storage.projects"},
+			},
+			expectedOrphans: true,
+			expectedLines: []string{
+				"items.project_id  2        item-1, item-2",
+				"2 orphan(s) found",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			checker := &fakeChecker{results: tt.results}
+			var out bytes.Buffer
+
+			// Act
+			err := run(context.Background(), &app{integrity: checker, operator: "alice"}, tt.args, &out)
+
+			// Assert
+			if tt.expectedOrphans {
+				assert.ErrorIs(t, err, errOrphans)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, 1, checker.runs)
+			for _, line := range tt.expectedLines {
+				assert.True(t, strings.Contains(out.String(), line), "output lacks %q:\n%s", line, out.String())
+			}
+		})
+	}
+}
+
+func TestRun_RequiresOperator(t *testing.T) {
+	// Arrange
+	checker := &fakeChecker{}
+
+	// Act
+	err := run(context.Background(), &app{integrity: checker}, []string{"integrity", "check"}, &bytes.Buffer{})
+
+	// Assert
+	assert.ErrorIs(t, err, errUsage)
+	assert.Zero(t, checker.runs)
+}
+
+func TestRun_JSON(t *testing.T) {
+	// Arrange
+	checker := &fakeChecker{results: []core.IntegrityResult{
+		{Check: "items.project_id", Description: "items of a missing project", Orphans: 1, SampleIDs: []string{"item-1"}},
+	}}
+	var out bytes.Buffer
+
+	// Act
+	err := run(context.Background(), &app{integrity: checker, operator: "alice"}, []string{"integrity", "check", "--json"}, &out)
+
+	// Assert
+	assert.ErrorIs(t, err, errOrphans)
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.Equal(t, float64(1), got["orphans"])
+	checks := got["checks"].([]interface{})
+	require.Len(t, checks, 1)
+	assert.Equal(t, "items.project_id", checks[0].(map[string]interface{})["check"])
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// exportReport is the outcome of a project export to a file
+type exportReport struct {
+	ProjectID string `json:"project_id"`
+	Title     string `json:"title"`
+	Items     int    `json:"items"`
+	Output    string `json:"output"`
+}
+
+func (r *exportReport) printText(out io.Writer) {
+	fmt.Fprintf(out, "Exported project %s (%s) with %d item(s) to %s.\n", r.ProjectID, r.Title, r.Items, r.Output)
+}
+
+// projectExport writes the archive of a project to --output or, without
+// it, as the command's output
+func projectExport(ctx context.Context, a *app, opts *options, args []string, out io.Writer) (report, error) {
+	project, err := a.projects.GetByID(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	items, err := a.items.ListByProject(ctx, project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := types.ProjectArchive{
+		Version:    types.ProjectArchiveVersion,
+		ExportedAt: a.now().UTC(),
+		Project: types.ProjectResponse{
+			ID:          project.ID,
+			Title:       project.Title,
+			Description: project.Description,
+			Tags:        project.Tags,
+			CreatedAt:   project.CreatedAt,
+			UpdatedAt:   project.UpdatedAt,
+			Version:     project.Version,
+			PublishedAt: project.PublishedAt,
+		},
+		Items: make([]types.ItemResponse, len(items)),
+	}
+	for i, item := range items {
+		archive.Items[i] = types.ItemResponse{
+			ID:          item.ID,
+			ProjectID:   item.ProjectID,
+			Type:        item.Type,
+			Title:       item.Title,
+			Content:     item.Content,
+			Position:    item.Position,
+			Required:    item.Required,
+			Points:      item.Points,
+			Explanation: item.Explanation,
+			CreatedAt:   item.CreatedAt,
+			UpdatedAt:   item.UpdatedAt,
+			Version:     item.Version,
+		}
+	}
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode archive: %w", err)
+	}
+	data = append(data, '\n')
+
+	if opts.output == "" {
+		if _, err := out.Write(data); err != nil {
+			return nil, err
+		}
+	} else if err := os.WriteFile(opts.output, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	audit(ctx, opts, "project_exported").
+		Str("project_id", project.ID).
+		Int("items", len(items)).
+		Msg("project exported")
+
+	if opts.output == "" {
+		return nil, nil
+	}
+	return &exportReport{ProjectID: project.ID, Title: project.Title, Items: len(items), Output: opts.output}, nil
+}
+
+// importReport is the outcome of a project import
+type importReport struct {
+	DryRun bool `json:"dry_run"`
+	// ProjectID is the created project, empty for a dry run
+	ProjectID       string `json:"project_id,omitempty"`
+	SourceProjectID string `json:"source_project_id"`
+	Title           string `json:"title"`
+	Items           int    `json:"items"`
+}
+
+func (r *importReport) printText(out io.Writer) {
+	if r.DryRun {
+		fmt.Fprintf(out, "Dry run: project %s (%s) with %d item(s) can be imported; nothing was created.\n", r.SourceProjectID, r.Title, r.Items)
+		return
+	}
+	fmt.Fprintf(out, "Imported project %s (%s) with %d item(s) as draft %s.\n", r.SourceProjectID, r.Title, r.Items, r.ProjectID)
+}
+
+// projectImport creates a draft project with the items of an archive, all
+// or nothing. A dry run creates them in a transaction it rolls back, so the
+// archive passes every check a real import makes.
+func projectImport(ctx context.Context, a *app, opts *options, args []string, out io.Writer) (report, error) {
+	archive, err := readArchive(a, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := make([]core.ItemInput, len(archive.Items))
+	for i, item := range archive.Items {
+		inputs[i] = core.ItemInput{
+			Type:        item.Type,
+			Title:       item.Title,
+			Content:     item.Content,
+			Position:    item.Position,
+			Required:    item.Required,
+			Points:      item.Points,
+			Explanation: item.Explanation,
+		}
+	}
+
+	var project *core.Project
+	err = a.tx.InTx(ctx, "admin.project_import", func(ctx context.Context) error {
+		var err error
+		project, err = a.projects.Create(ctx, archive.Project.Title, archive.Project.Description, archive.Project.Tags)
+		if err != nil {
+			return err
+		}
+		if len(inputs) > 0 {
+			if _, err := a.items.CreateMany(ctx, project.ID, inputs); err != nil {
+				return err
+			}
+		}
+		if opts.dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, fmt.Errorf("failed to import project: %w", err)
+	}
+
+	r := &importReport{
+		DryRun:          opts.dryRun,
+		SourceProjectID: archive.Project.ID,
+		Title:           archive.Project.Title,
+		Items:           len(inputs),
+	}
+	if opts.dryRun {
+		return r, nil
+	}
+	r.ProjectID = project.ID
+	audit(ctx, opts, "project_imported").
+		Str("project_id", project.ID).
+		Str("source_project_id", archive.Project.ID).
+		Int("items", len(inputs)).
+		Msg("project imported")
+	return r, nil
+}
+
+// readArchive reads and checks the archive at path, or on stdin for "-"
+func readArchive(a *app, path string) (*types.ProjectArchive, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(a.stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	var archive types.ProjectArchive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, fmt.Errorf("failed to decode archive: %w", err)
+	}
+	if archive.Version != types.ProjectArchiveVersion {
+		return nil, fmt.Errorf("unsupported archive version %d, expected %d", archive.Version, types.ProjectArchiveVersion)
+	}
+	return &archive, nil
+}
+
+// deleteReport is the outcome of a project delete
+type deleteReport struct {
+	DryRun    bool   `json:"dry_run"`
+	ProjectID string `json:"project_id"`
+	Title     string `json:"title"`
+	Items     int    `json:"items"`
+}
+
+func (r *deleteReport) printText(out io.Writer) {
+	if r.DryRun {
+		fmt.Fprintf(out, "Dry run: project %s (%s) with %d item(s) would be deleted; nothing was deleted.\n", r.ProjectID, r.Title, r.Items)
+		return
+	}
+	fmt.Fprintf(out, "Deleted project %s (%s) with %d item(s).\n", r.ProjectID, r.Title, r.Items)
+}
+
+// projectDelete deletes a project, as DELETE /api/v1/projects/{id} does
+func projectDelete(ctx context.Context, a *app, opts *options, args []string, out io.Writer) (report, error) {
+	project, err := a.projects.GetByID(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	count, err := a.items.CountByProject(ctx, project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &deleteReport{DryRun: opts.dryRun, ProjectID: project.ID, Title: project.Title, Items: count}
+	if opts.dryRun {
+		return r, nil
+	}
+	if err := a.projects.Delete(ctx, project.ID); err != nil {
+		return nil, err
+	}
+	audit(ctx, opts, "project_deleted").
+		Str("project_id", project.ID).
+		Int("items", count).
+		Msg("project deleted")
+	return r, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rs/zerolog/log"
+)
+
+// reconcileReport is the outcome of storage reconcile
+type reconcileReport struct {
+	DryRun bool `json:"dry_run"`
+	// Files are the orphaned files found
+	Files   []string `json:"files"`
+	Deleted int      `json:"deleted"`
+	// Failed are the files that could not be deleted
+	Failed []string `json:"failed"`
+}
+
+func (r *reconcileReport) printText(out io.Writer) {
+	for _, key := range r.Files {
+		fmt.Fprintln(out, key)
+	}
+	switch {
+	case len(r.Files) == 0:
+		fmt.Fprintln(out, "No orphaned files.")
+	case r.DryRun:
+		fmt.Fprintf(out, "\nDry run: %d orphaned file(s) would be deleted; nothing was deleted.\n", len(r.Files))
+	default:
+		fmt.Fprintf(out, "\n%d orphaned file(s) deleted, %d failed.\n", r.Deleted, len(r.Failed))
+	}
+}
+
+// storageReconcile deletes the stored project files whose project row is
+// gone, the orphans integrity check reports as storage.projects
+func storageReconcile(ctx context.Context, a *app, opts *options, args []string, out io.Writer) (report, error) {
+	if a.files == nil {
+		return nil, errors.New("storage reconcile needs STORAGE_TYPE=local")
+	}
+
+	keys, err := a.orphans.OrphanedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &reconcileReport{DryRun: opts.dryRun, Files: keys, Failed: []string{}}
+	if r.Files == nil {
+		r.Files = []string{}
+	}
+	if opts.dryRun || len(keys) == 0 {
+		return r, nil
+	}
+
+	for _, key := range keys {
+		if err := a.files.DeleteFile(ctx, key); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("key", key).Msg("failed to delete orphaned file")
+			r.Failed = append(r.Failed, key)
+			continue
+		}
+		r.Deleted++
+	}
+	audit(ctx, opts, "storage_reconciled").
+		Int("deleted", r.Deleted).
+		Int("failed", len(r.Failed)).
+		Msg("orphaned files deleted")
+
+	if len(r.Failed) > 0 {
+		return r, fmt.Errorf("failed to delete %d of %d orphaned files", len(r.Failed), len(keys))
+	}
+	return r, nil
+}
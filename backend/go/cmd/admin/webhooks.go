@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// redelivery is a dead letter and, unless it is a dry run, the outcome of
+// sending it again
+type redelivery struct {
+	WebhookID  string `json:"webhook_id"`
+	URL        string `json:"url"`
+	EventID    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	DeliveryID string `json:"delivery_id"`
+	// RedeliveryID and Status are the new delivery's
+	RedeliveryID string `json:"redelivery_id,omitempty"`
+	Status       string `json:"status,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// redeliverReport is the outcome of webhooks redeliver
+type redeliverReport struct {
+	DryRun     bool         `json:"dry_run"`
+	Deliveries []redelivery `json:"deliveries"`
+	Succeeded  int          `json:"succeeded"`
+	Failed     int          `json:"failed"`
+}
+
+func (r *redeliverReport) printText(out io.Writer) {
+	if len(r.Deliveries) == 0 {
+		fmt.Fprintln(out, "No dead letters.")
+		return
+	}
+
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "WEBHOOK\tURL\tEVENT\tDELIVERY\tSTATUS")
+	for _, d := range r.Deliveries {
+		status := d.Status
+		if r.DryRun {
+			status = "-"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n", d.WebhookID, d.URL, d.EventType, d.DeliveryID, status)
+	}
+	table.Flush()
+
+	if r.DryRun {
+		fmt.Fprintf(out, "\nDry run: %d dead letter(s) would be redelivered; nothing was sent.\n", len(r.Deliveries))
+		return
+	}
+	fmt.Fprintf(out, "\n%d redelivered, %d failed again.\n", r.Succeeded, r.Failed)
+}
+
+// webhooksRedeliver sends the dead letters again, one at a time and with
+// the usual retries. It fails if any of them fails again.
+func webhooksRedeliver(ctx context.Context, a *app, opts *options, args []string, out io.Writer) (report, error) {
+	if !opts.deadLetter {
+		return nil, fmt.Errorf("%w: webhooks redeliver needs --dead-letter; redeliver single deliveries through the API", errUsage)
+	}
+	if opts.limit < 1 {
+		return nil, fmt.Errorf("%w: --limit must be positive, got %d", errUsage, opts.limit)
+	}
+
+	deadLetters, err := a.webhooks.ListDeadLetters(ctx, opts.limit)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &redeliverReport{DryRun: opts.dryRun, Deliveries: make([]redelivery, len(deadLetters))}
+	for i, deadLetter := range deadLetters {
+		r.Deliveries[i] = redelivery{
+			WebhookID:  deadLetter.Webhook.ID,
+			URL:        deadLetter.Webhook.URL,
+			EventID:    deadLetter.Delivery.EventID,
+			EventType:  string(deadLetter.Delivery.EventType),
+			DeliveryID: deadLetter.Delivery.ID,
+		}
+	}
+	if opts.dryRun || len(deadLetters) == 0 {
+		return r, nil
+	}
+
+	for i, deadLetter := range deadLetters {
+		sent, err := a.dispatcher.Resend(ctx, deadLetter.Webhook, deadLetter.Delivery)
+		if err != nil {
+			return r, err
+		}
+		d := &r.Deliveries[i]
+		d.RedeliveryID = sent.ID
+		d.Status = string(sent.Status)
+		d.Error = sent.Error
+		if sent.Status == core.WebhookDeliverySucceeded {
+			r.Succeeded++
+		} else {
+			r.Failed++
+		}
+	}
+	audit(ctx, opts, "webhooks_redelivered").
+		Int("succeeded", r.Succeeded).
+		Int("failed", r.Failed).
+		Msg("webhook dead letters redelivered")
+
+	if r.Failed > 0 {
+		return r, fmt.Errorf("%d of %d redeliveries failed", r.Failed, len(deadLetters))
+	}
+	return r, nil
+}
@@ -2,8 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,25 +11,43 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
-	"github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog"
 
+	"github.com/provemyself/backend/api"
+	"github.com/provemyself/backend/internal/breaker"
+	"github.com/provemyself/backend/internal/collab"
 	"github.com/provemyself/backend/internal/config"
 	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/email"
+	"github.com/provemyself/backend/internal/http/debug"
 	"github.com/provemyself/backend/internal/http/handlers"
-	"github.com/provemyself/backend/internal/middleware"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/jobs"
+	"github.com/provemyself/backend/internal/lifecycle"
+	"github.com/provemyself/backend/internal/logging"
+	"github.com/provemyself/backend/internal/lti"
+	"github.com/provemyself/backend/internal/metrics"
+	"github.com/provemyself/backend/internal/qti"
+	"github.com/provemyself/backend/internal/scorm"
 	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/tracing"
+	"github.com/provemyself/backend/internal/webhook"
+	"github.com/provemyself/backend/internal/xapi"
 )
 
+// @title ProveMySelf API
+// @version 0.1.0
+// @description API for authoring quiz projects and their items.
+// @BasePath /
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT sent as "Bearer <token>"
 func main() {
-	// Setup logger
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	logger := zerolog.New(os.Stdout).With().
-		Timestamp().
-		Caller().
-		Logger()
+	// Bootstrap logger, replaced once configuration is loaded
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
 
 	// Load configuration
 	cfg, err := config.Load()
@@ -37,106 +55,533 @@ func main() {
 		logger.Fatal().Err(err).Msg("failed to load configuration")
 	}
 
-	// Initialize validator
-	validate := validator.New()
+	// Setup logger: pretty console output in development, JSON elsewhere.
+	// The level was validated by config.Load.
+	logLevel, _ := logging.ParseLevel(cfg.LogLevel)
+	logger = logging.New(logging.Config{
+		Level:  logLevel,
+		Pretty: cfg.IsDevelopment(),
+	})
+
+	// Initialize tracing (no-op unless an OTLP endpoint is configured)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Endpoint:    cfg.OTLPEndpoint,
+		ServiceName: cfg.TracingServiceName,
+		Environment: cfg.Environment,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialize tracing")
+	}
+
+	// Initialize validator, shared by the handlers
+	validate := httpmiddleware.NewValidator()
+
+	// Initialize metrics
+	registry := metrics.NewRegistry()
+	httpMetrics := metrics.NewHTTPMetrics(registry)
+	breakerMetrics := metrics.NewBreakerMetrics(registry)
+	jobMetrics := metrics.NewJobMetrics(registry)
+	rateLimitMetrics := metrics.NewRateLimitMetrics(registry)
+	responseCacheMetrics := metrics.NewResponseCacheMetrics(registry)
+	storeMetrics := metrics.NewStoreMetrics(registry)
 
 	// Initialize database
-	database, err := store.NewDatabase(cfg.DatabaseURL)
+	database, err := store.NewDatabase(context.Background(), cfg.Database())
 	if err != nil {
 		logger.Fatal().Err(err).Msg("failed to initialize database")
 	}
 	defer database.Close()
+	database.Instrument(cfg.DBSlowQueryThreshold, metrics.NewQueryMetrics(registry))
+	metrics.RegisterPoolStats(registry, database.Dialect().Name(), database.PoolStats)
 
-	// Run database migrations
-	ctx := context.Background()
-	if err := database.Migrate(ctx); err != nil {
-		logger.Fatal().Err(err).Msg("failed to run database migrations")
+	// Initialize stores
+	projectStore := storeMetrics.WrapProjectStore(store.NewProjectStore(database))
+	items := store.NewItemStore(database)
+	items.SetRevisionLimit(cfg.ItemRevisionLimit)
+	itemStore := storeMetrics.WrapItemStore(items)
+	orgStore := store.NewOrganizationStore(database)
+	idempotencyStore := store.NewIdempotencyStore(database)
+
+	// Initialize storage. Asset URLs are signed with ASSET_URL_SECRET;
+	// without it, as outside production, with a secret of this process, so
+	// URLs signed before a restart stop working.
+	assetURLSecret := []byte(cfg.AssetURLSecret)
+	if len(assetURLSecret) == 0 {
+		assetURLSecret = make([]byte, 32)
+		if _, err := rand.Read(assetURLSecret); err != nil {
+			logger.Fatal().Err(err).Msg("failed to generate asset URL secret")
+		}
+		logger.Warn().Msg("ASSET_URL_SECRET is not set, signing asset URLs with a secret generated at startup")
 	}
+	assetSigner := core.NewAssetURLSigner(assetURLSecret)
 
-	// Initialize stores
-	projectStore := store.NewProjectStore(database)
-	itemStore := store.NewItemStore(database)
+	var storage core.Storage
+	var storageBreaker *breaker.Breaker
+	var files *core.StorageService
+	if cfg.StorageType == "local" {
+		// Storage calls fail fast while the breaker is open; readiness
+		// reports that as degraded rather than taking the pod out
+		storageBreaker = breaker.New(breaker.Config{
+			Name:             "storage",
+			FailureThreshold: cfg.BreakerFailureThreshold,
+			CoolDown:         cfg.BreakerCoolDown,
+			IsFailure:        breaker.IsStorageFailure,
+			OnStateChange:    breakerMetrics.ObserveStateChange,
+		})
+		localStorage := store.NewLocalStorage(cfg.StoragePath, "/files")
+		localStorage.SetSigner(assetSigner, "/assets")
+		storage = breaker.WrapStorage(localStorage, storageBreaker)
+		files = core.NewStorageService(storage, core.StorageConfig{
+			MaxFileSize:      cfg.MaxFileSize,
+			AllowedFileTypes: cfg.AllowedFileTypes,
+		})
+	}
 
-	// Initialize services
-	projectService := core.NewProjectService(projectStore)
+	// Initialize services. Purged projects take their stored assets with
+	// them.
+	projectService := core.NewProjectService(projectStore, files)
+	projectService.SetOrganizations(orgStore)
+	projectService.SetItems(itemStore)
 	itemService := core.NewItemService(itemStore, projectStore)
+	itemService.SetTransactor(database)
+	itemLocks := collab.NewFeed()
+	itemService.SetLocks(store.NewItemLockStore(database), itemLocks)
+	attemptStore := store.NewAttemptStore(database)
+	attemptService := core.NewAttemptService(attemptStore, projectStore, itemStore)
+	analyticsService := core.NewAnalyticsService(store.NewStatsStore(database), projectStore, itemStore)
+
+	// Initialize LTI. Without a key file the tool signs with a key of this
+	// process, which platforms no longer trust after a restart.
+	var ltiKey *lti.Key
+	if cfg.LTIPrivateKeyFile != "" {
+		ltiKey, err = lti.LoadKey(cfg.LTIPrivateKeyFile)
+	} else {
+		ltiKey, err = lti.GenerateKey()
+		if cfg.EnableLTIIntegration {
+			logger.Warn().Msg("LTI_PRIVATE_KEY_FILE is not set, signing LTI messages with a key generated at startup")
+		}
+	}
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialize LTI key")
+	}
+	ltiStore := store.NewLTIStore(database)
+	ltiService := lti.NewService(ltiStore, projectStore, ltiKey, cfg.LTI())
 
 	// Initialize middleware
-	loggingMiddleware := middleware.NewLoggingMiddleware()
-	healthMiddleware := middleware.NewHealthMiddleware()
-	errorHandler := middleware.NewErrorHandler()
-	validationMiddleware := middleware.NewValidationMiddleware()
+	maintenance := httpmiddleware.NewMaintenance(store.NewMaintenanceStore(database), httpmiddleware.MaintenanceConfig{
+		Forced:     cfg.MaintenanceMode,
+		Message:    cfg.MaintenanceMessage,
+		AllowReads: cfg.MaintenanceAllowReads,
+		RetryAfter: cfg.MaintenanceRetryAfter,
+	})
+	var responseCache *httpmiddleware.ResponseCache
+	if cfg.ResponseCacheEnabled {
+		responseCache = httpmiddleware.NewResponseCache(cfg.ResponseCacheMaxEntries, cfg.ResponseCacheTTL, responseCacheMetrics)
+	}
+	rateLimiter := httpmiddleware.NewRateLimiter(cfg.RateLimitRequests, time.Duration(cfg.RateLimitWindow)*time.Second, rateLimitMetrics)
+	loggingMiddleware := httpmiddleware.NewLoggingMiddleware(logger, uint32(cfg.LogSampling))
+	readiness := httpmiddleware.NewReadiness(
+		httpmiddleware.PhaseMigrationsDone,
+		httpmiddleware.PhaseStorageChecked,
+		httpmiddleware.PhaseWorkersStarted,
+	)
+	healthMiddleware := httpmiddleware.NewHealthMiddleware(readiness)
+	healthMiddleware.ReportSchemaVersion(database.SchemaVersion)
+	errorHandler := httpmiddleware.NewErrorHandler(httpMetrics)
+
+	// Runtime settings overlay the environment with overrides stored in the
+	// database. Components that cache a setting are told when it changes.
+	settings := config.NewDynamic(cfg, store.NewSettingsStore(database))
+	settings.Subscribe(func(s config.Settings) {
+		// Stored levels are validated before they are applied
+		level, _ := logging.ParseLevel(s.LogLevel)
+		logging.SetLevel(level)
+	}, config.SettingLogLevel)
+	settings.Subscribe(func(s config.Settings) {
+		rateLimiter.SetLimit(s.RateLimitRequests, time.Duration(s.RateLimitWindow)*time.Second)
+	}, config.SettingRateLimitRequests, config.SettingRateLimitWindow)
+	settings.Subscribe(func(s config.Settings) {
+		maintenance.SetMessage(s.MaintenanceMessage)
+	}, config.SettingMaintenanceMessage)
+
+	// The configuration is reloaded on SIGHUP and when CONFIG_FILE changes.
+	// Components that can take a new value subscribe to its field; changes
+	// to any other field are logged as needing a restart.
+	watcher := config.NewWatcher(cfg, config.Load)
+	watcher.Subscribe(func(c *config.Config) {
+		settings.SetDefaults(context.Background(), c)
+	}, config.DynamicFields...)
+	corsOrigins := httpmiddleware.NewOrigins(cfg.CORSOrigins)
+	watcher.Subscribe(func(c *config.Config) {
+		corsOrigins.Set(c.CORSOrigins)
+	}, "CORSOrigins")
+
+	// Writes committed through any replica invalidate this replica's caches
+	changes := store.NewChangeListener(database)
+	changes.Subscribe(settings, core.ChangeEntitySettings)
+	changes.Subscribe(itemLocks, core.ChangeEntityItemLocks)
+	changes.Subscribe(analyticsService, core.ChangeEntityAttempts, core.ChangeEntityProject)
+	if responseCache != nil {
+		changes.Subscribe(responseCache, core.ChangeEntityProject)
+	}
+
+	// Initialize email. Without an SMTP host, messages are written to
+	// EMAIL_OUTBOX_DIR as .eml files instead of being sent.
+	var emailSender email.Sender = email.NewFileSender(cfg.EmailOutboxDir)
+	if cfg.SMTPHost != "" {
+		emailSender = email.NewSMTPSender(cfg.SMTP())
+	} else {
+		logger.Info().Str("dir", cfg.EmailOutboxDir).Msg("SMTP_HOST is not set, writing emails to files")
+	}
+	emailQueue := email.NewQueue(emailSender, cfg.EmailQueue())
+	mailer := email.NewMailer(email.DefaultTemplates(), emailQueue, cfg.FromEmail)
+
+	// Initialize webhooks. Project events are delivered by a pool of
+	// workers from a queue in this replica's memory; deliveries still
+	// queued at shutdown are recorded as failed and can be redelivered.
+	webhookStore := store.NewWebhookStore(database)
+	webhookDispatcher := webhook.NewDispatcher(webhookStore, mailer, cfg.Webhooks())
+	projectService.SetEvents(webhookDispatcher)
+
+	// Initialize real-time collaboration. Rooms live in this replica's
+	// memory, so a project's editors must be routed to the same replica.
+	collabHub := collab.NewHub(store.NewCollabStore(database), cfg.Collab(), metrics.NewCollabMetrics(registry))
+
+	// Initialize background jobs. They run once across the cluster, guarded
+	// by Postgres advisory locks, unless registered per replica.
+	scheduler := jobs.NewScheduler(jobs.LockerFunc(database.TryAdvisoryLock), jobMetrics)
+
+	// Every replica polls the shared maintenance switch
+	err = scheduler.Register(jobs.Func("maintenance.refresh", maintenance.Refresh), jobs.Every(cfg.MaintenancePollInterval), jobs.Options{
+		Timeout:    cfg.MaintenancePollInterval,
+		PerReplica: true,
+		RunAtStart: true,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to register job")
+	}
+
+	// Every replica polls the runtime setting overrides
+	err = scheduler.Register(jobs.Func("settings.refresh", settings.Refresh), jobs.Every(cfg.SettingsPollInterval), jobs.Options{
+		Timeout:    cfg.SettingsPollInterval,
+		PerReplica: true,
+		RunAtStart: true,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to register job")
+	}
+
+	// Every replica reloads its configuration when the file changes
+	if cfg.ConfigFile != "" && cfg.ConfigPollInterval > 0 {
+		err = scheduler.Register(jobs.Func("config.reload", watcher.Poll), jobs.Every(cfg.ConfigPollInterval), jobs.Options{
+			Timeout:    cfg.ConfigPollInterval,
+			PerReplica: true,
+		})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to register job")
+		}
+	}
+
+	// Every replica forgets its idle rate limit keys
+	err = scheduler.Register(jobs.Func("ratelimit.prune", func(ctx context.Context) error {
+		rateLimiter.Prune()
+		return nil
+	}), jobs.Every(time.Minute), jobs.Options{
+		PerReplica: true,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to register job")
+	}
+
+	// The schedule was validated by config.Load
+	purgeSchedule, _ := jobs.ParseSchedule(cfg.IdempotencyPurgeSchedule)
+	err = scheduler.Register(jobs.PurgeExpiredIdempotencyKeys(idempotencyStore), purgeSchedule, jobs.Options{
+		Timeout: cfg.JobTimeout,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to register job")
+	}
+
+	err = scheduler.Register(jobs.PurgeExpiredLTISessions(ltiStore), jobs.Every(time.Hour), jobs.Options{
+		Timeout: cfg.JobTimeout,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to register job")
+	}
+
+	err = scheduler.Register(jobs.PurgeWebhookDeliveries(webhookStore, cfg.WebhookDeliveryRetention), jobs.Every(time.Hour), jobs.Options{
+		Timeout: cfg.JobTimeout,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to register job")
+	}
+
+	err = scheduler.Register(jobs.PurgeDeletedItems(itemStore, cfg.DeletedItemRetention), jobs.Every(time.Hour), jobs.Options{
+		Timeout: cfg.JobTimeout,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to register job")
+	}
+
+	err = scheduler.Register(jobs.PurgePreviewAttempts(attemptStore, cfg.PreviewAttemptRetention), jobs.Every(time.Hour), jobs.Options{
+		Timeout: cfg.JobTimeout,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to register job")
+	}
+
+	if cfg.AttemptRetentionDays > 0 {
+		// The schedule was validated by config.Load
+		retentionSchedule, _ := jobs.ParseSchedule(cfg.AttemptRetentionSchedule)
+		retention := time.Duration(cfg.AttemptRetentionDays) * 24 * time.Hour
+		err = scheduler.Register(jobs.ApplyAttemptRetention(attemptService, retention, core.RetentionMode(cfg.AttemptRetentionMode)), retentionSchedule, jobs.Options{
+			Timeout: cfg.JobTimeout,
+		})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to register job")
+		}
+	}
+
+	// xAPI backfills are sent to the LRS by a job run on demand, on the
+	// replica that took the request, which keeps their progress
+	lrsClient := xapi.NewClient(cfg.LRSEndpoint, cfg.LRSAuthToken, &http.Client{Timeout: 30 * time.Second})
+	watcher.Subscribe(func(c *config.Config) {
+		lrsClient.SetEndpoint(c.LRSEndpoint)
+	}, "LRSEndpoint")
+	xapiExporter := xapi.NewExporter(attemptStore, xapi.NewBuilder(cfg.LTIToolURL), lrsClient)
+	err = scheduler.Register(jobs.Func(xapi.ExportJob, xapiExporter.Run), jobs.OnDemand(), jobs.Options{
+		Timeout:    cfg.JobTimeout,
+		PerReplica: true,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to register job")
+	}
+	xapiExporter.SetTrigger(func() error {
+		return scheduler.Trigger(xapi.ExportJob)
+	})
 
 	// Initialize handlers
-	healthHandler := handlers.NewHealthHandler(database)
+	healthDependencies := []handlers.HealthDependency{
+		{
+			Checker:  httpmiddleware.NewDatabaseHealthChecker("database", database.HealthCheck),
+			Critical: true,
+			Details:  func() interface{} { return database.PoolStats() },
+		},
+	}
+	readinessChecks := []httpmiddleware.HealthChecker{
+		httpmiddleware.NewDatabaseHealthChecker("database", database.HealthCheck),
+	}
+	if database.HasReplica() {
+		// Reads fall back to the primary, so a failing replica only
+		// degrades the service
+		replicaChecker := httpmiddleware.NewDatabaseHealthChecker("database_replica", database.ReplicaHealthCheck)
+		healthDependencies = append(healthDependencies, handlers.HealthDependency{Checker: replicaChecker})
+		readinessChecks = append(readinessChecks, replicaChecker)
+	}
+	var assetHandler *handlers.AssetHandler
+	if storage != nil {
+		assetHandler = handlers.NewAssetHandler(core.NewAssetService(projectStore, storage, cfg.AssetURLMaxTTL), assetSigner, validate)
+
+		healthDependencies = append(healthDependencies, handlers.HealthDependency{
+			Checker: httpmiddleware.NewStorageHealthChecker("storage", storage.HealthCheck),
+			Details: func() interface{} {
+				return map[string]string{"breaker": storageBreaker.State().String()}
+			},
+		})
+		readinessChecks = append(readinessChecks, storageBreaker)
+	}
+	if cfg.LRSEndpoint != "" {
+		lrsChecker := httpmiddleware.NewHTTPHealthChecker("lrs", cfg.LRSEndpoint)
+		watcher.Subscribe(func(c *config.Config) {
+			lrsChecker.SetURL(c.LRSEndpoint)
+		}, "LRSEndpoint")
+		healthDependencies = append(healthDependencies, handlers.HealthDependency{Checker: lrsChecker})
+	}
+	healthHandler := handlers.NewHealthHandler(cfg.HealthCacheTTL, healthDependencies...)
 	projectHandler := handlers.NewProjectHandler(projectService, validate)
+	projectHandler.SetItems(itemService)
+	attemptHandler := handlers.NewAttemptHandler(attemptService)
+	attemptHandler.SetAnalytics(analyticsService)
 	itemHandler := handlers.NewItemHandler(itemService, validate)
+	itemHandler.SetLocks(itemService, itemLocks, cfg.StreamKeepAlive)
+	// Imported files go through the upload checks; without storage, items
+	// using them are skipped
+	var importFiles qti.AssetStore
+	if files != nil {
+		importFiles = files
+	}
+	itemHandler.SetImporters(map[string]handlers.ItemImporter{
+		"qti": qti.NewImporter(importFiles, cfg.LTIToolURL),
+	})
+	adminHandler := handlers.NewAdminHandler(maintenance, validate)
+	jobsHandler := handlers.NewJobsHandler(scheduler, cfg.StreamKeepAlive)
+	settingsHandler := handlers.NewSettingsHandler(settings, validate)
+	rateLimitHandler := handlers.NewRateLimitHandler(rateLimiter)
+	integrityHandler := handlers.NewIntegrityHandler(store.NewIntegrityStore(database, storage))
+	collabHandler := handlers.NewCollabHandler(projectService, collabHub, func() bool {
+		return settings.Settings().EnableCollaboration
+	}, handlers.CollabOptions{
+		AllowOrigin:     corsOrigins.Allowed,
+		MaxMessageBytes: cfg.CollabMaxMessageBytes,
+		WriteTimeout:    cfg.CollabWriteTimeout,
+	})
+	ltiHandler := handlers.NewLTIHandler(ltiService, ltiStore, func() bool {
+		return settings.Settings().EnableLTIIntegration
+	}, cfg.LTIPlayerURL, validate)
+	exportHandler := handlers.NewExportHandler(projectService, itemService, map[string]handlers.ProjectExporter{
+		"qti":       qti.NewExporter(storage),
+		"scorm":     scorm.NewExporter(storage, scorm.Version12),
+		"scorm2004": scorm.NewExporter(storage, scorm.Version2004),
+	})
+	var seedHandler *handlers.SeedHandler
+	if cfg.IsDevelopment() {
+		seedHandler = handlers.NewSeedHandler(core.NewSeedService(cfg.Environment, projectService, itemService, orgStore, storage))
+	}
 
 	// Setup router
 	r := chi.NewRouter()
 
 	// Core middleware stack
 	r.Use(loggingMiddleware.RequestID)
+	r.Use(httpmiddleware.Localize)
+	r.Use(tracing.Middleware)
+	r.Use(httpMetrics.Middleware)
 	r.Use(loggingMiddleware.UserContext)
 	r.Use(loggingMiddleware.RequestLogger)
 	r.Use(errorHandler.Recovery)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(chimiddleware.RealIP)
+	if cfg.EnableCompression {
+		r.Use(httpmiddleware.Compress(cfg.CompressionLevel, httpmiddleware.DefaultCompressionMinSize))
+	}
+	r.Use(httpmiddleware.RequestSizeLimit(cfg.MaxRequestBodyBytes))
 
 	// CORS configuration
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:3001"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowOriginFunc:  corsOrigins.AllowOriginFunc,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Org-ID", handlers.LTISessionHeader, "traceparent", "tracestate", "If-Match", "If-None-Match", httpmiddleware.IdempotencyKeyHeader},
+		ExposedHeaders:   []string{"Link", "Deprecation", "Sunset", "X-Content-Language", "ETag", "Retry-After", httpmiddleware.IdempotentReplayedHeader},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
 	// Health and monitoring endpoints (outside API versioning)
-	r.Get("/health", healthHandler.GetHealth)
-	r.Get("/health/live", healthMiddleware.LivenessProbe)
-	r.Get("/health/ready", healthMiddleware.ReadinessProbe([]middleware.HealthChecker{
-		middleware.NewDatabaseHealthChecker("database", database.HealthCheck),
-	}))
-	r.Get("/metrics", healthMiddleware.Metrics)
-
-	// API routes
-	r.Route("/api/v1", func(r chi.Router) {
-		// Projects
-		r.Route("/projects", func(r chi.Router) {
-			r.Get("/", projectHandler.ListProjects)
-			r.Post("/", projectHandler.CreateProject)
-			r.Get("/{projectId}", projectHandler.GetProject)
-			r.Put("/{projectId}", projectHandler.UpdateProject)
-			r.Delete("/{projectId}", projectHandler.DeleteProject)
-			r.Post("/{projectId}/publish", projectHandler.PublishProject)
-
-			// Items nested under projects
-			r.Route("/{projectId}/items", func(r chi.Router) {
-				r.Get("/", itemHandler.ListItems)
-				r.Post("/", itemHandler.CreateItem)
-				r.Get("/{itemId}", itemHandler.GetItem)
-				r.Put("/{itemId}", itemHandler.UpdateItem)
-				r.Delete("/{itemId}", itemHandler.DeleteItem)
-				
-				// Bulk operations and position management
-				r.Post("/bulk", itemHandler.BulkCreateItems)
-				r.Put("/positions", itemHandler.UpdateItemPositions)
-			})
-		})
+	r.Group(func(r chi.Router) {
+		r.Use(httpmiddleware.Timeout(cfg.TimeoutDefault))
+
+		r.Get("/health", healthHandler.GetHealth)
+		r.Get("/health/live", healthMiddleware.LivenessProbe)
+		r.Get("/health/ready", healthMiddleware.ReadinessProbe(readinessChecks))
+		r.Handle("/metrics", metrics.Handler(registry))
+		// Deprecated: the JSON metrics dump is kept for one release; scrape /metrics instead
+		r.Get("/metrics/debug", healthMiddleware.Metrics)
 	})
 
+	// API reference, off in production unless ENABLE_API_DOCS is set
+	if cfg.EnableAPIDocs {
+		docsHandler := handlers.NewDocsHandler(api.OpenAPISpec)
+		r.Get("/openapi.json", docsHandler.GetSpec)
+		r.Get("/docs", docsHandler.GetDocs)
+	}
+
+	// LTI tool endpoints (see routes.go)
+	mountLTI(r, cfg, ltiHandler, maintenance)
+
+	// Assets, to signed URLs (see routes.go)
+	if assetHandler != nil {
+		mountAssets(r, cfg, assetHandler, maintenance)
+	}
+
+	// API routes, one group per version (see routes.go)
+	mountAPI(r, cfg, apiHandlers{
+		projects:     projectHandler,
+		items:        itemHandler,
+		admin:        adminHandler,
+		jobs:         jobsHandler,
+		settings:     settingsHandler,
+		orgs:         handlers.NewOrganizationHandler(orgStore, validate),
+		collab:       collabHandler,
+		lti:          ltiHandler,
+		exports:      exportHandler,
+		webhooks:     handlers.NewWebhookHandler(webhookStore, webhookDispatcher, validate),
+		public:       handlers.NewPublicHandler(projectService, attemptService, validate),
+		attempts:     attemptHandler,
+		participants: handlers.NewParticipantHandler(attemptService),
+		xapi:         handlers.NewXAPIHandler(xapiExporter),
+		assets:       assetHandler,
+
+		memberships: orgStore,
+		maintenance: maintenance,
+		rateLimiter: rateLimiter,
+		rateLimits:  rateLimitHandler,
+		integrity:   integrityHandler,
+		seed:        seedHandler,
+
+		responseCache:     responseCache,
+		publishedVersions: projectService,
+		idempotency:       idempotencyStore,
+	}, apiDeprecations)
+
 	// Server configuration
-	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%s", cfg.Port),
-		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+	srv := newServer(cfg, r)
+
+	// Background components are started and stopped by the lifecycle
+	// manager. Tracing is registered first so it is flushed last.
+	lc := lifecycle.New(cfg.WorkerStopTimeout)
+	lc.Append(lifecycle.Hook{Name: "tracing", Stop: shutdownTracing})
+
+	// The email queue stops after the scheduler, since jobs may queue
+	// emails until they return; stopping it sends what is still queued
+	lc.Append(lifecycle.Worker("email queue", emailQueue.Run))
+
+	// The webhook dispatcher stops before the email queue, since disabling
+	// a webhook emails its owner
+	lc.Append(lifecycle.Worker("webhook dispatcher", webhookDispatcher.Run))
+
+	// Stopping the scheduler cancels running jobs and waits for them
+	lc.Append(lifecycle.Worker("job scheduler", scheduler.Run))
+	lc.Append(lifecycle.Worker("change listener", changes.Run))
+	lc.Append(lifecycle.Worker("config watcher", watcher.Run))
+
+	// The server's shutdown does not wait for upgraded connections;
+	// stopping the hub disconnects them and saves every changed document
+	lc.Append(lifecycle.Worker("collab hub", collabHub.Run))
+
+	// Debug endpoints run on their own server so 30s profiles aren't cut off
+	// by the API write timeout
+	if cfg.EnableDebugEndpoints {
+		debugSrv := debug.NewServer(fmt.Sprintf(":%s", cfg.DebugPort), debug.Config{
+			JWTSecret:  cfg.JWTSecret,
+			AllowedIPs: cfg.DebugAllowedIPs,
+		})
+
+		lc.Append(lifecycle.Hook{
+			Name: "debug server",
+			Start: func(ctx context.Context) error {
+				go func() {
+					logger.Info().
+						Str("addr", debugSrv.Addr).
+						Msg("starting debug server")
+
+					if err := debugSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+						logger.Error().Err(err).Msg("debug server failed")
+					}
+				}()
+				return nil
+			},
+			Stop: debugSrv.Shutdown,
+		})
 	}
 
-	// Start server
+	// The root context is cancelled on SIGINT/SIGTERM, which tells workers
+	// to stop picking up new work
+	rootCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	// Start server. It listens before startup finishes so liveness answers
+	// during long migrations; readiness reports not_ready until every
+	// startup phase has completed.
 	go func() {
 		logger.Info().
 			Str("addr", srv.Addr).
@@ -147,19 +592,58 @@ func main() {
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	// Run database migrations, unless an explicit cmd/migrate step owns them
+	if cfg.MigrateOnStartup {
+		if err := database.Migrate(rootCtx); err != nil {
+			logger.Fatal().Err(err).Msg("failed to run database migrations")
+		}
+	} else if version, dirty, err := database.SchemaVersion(rootCtx); err != nil || dirty || version < database.LatestMigrationVersion() {
+		logger.Warn().
+			Err(err).
+			Uint("schema_version", version).
+			Bool("dirty", dirty).
+			Uint("latest_version", database.LatestMigrationVersion()).
+			Msg("MIGRATE_ON_STARTUP is off and the schema is not up to date; run cmd/migrate up")
+	}
+	readiness.Complete(httpmiddleware.PhaseMigrationsDone)
 
-	logger.Info().Msg("shutting down server")
+	// Storage is not critical, so a failed check is only logged
+	if storage != nil {
+		if err := storage.HealthCheck(rootCtx); err != nil {
+			logger.Warn().Err(err).Msg("storage check failed at startup")
+		}
+	}
+	readiness.Complete(httpmiddleware.PhaseStorageChecked)
+
+	if err := lc.Start(rootCtx); err != nil {
+		logger.Fatal().Err(err).Msg("failed to start background components")
+	}
+	readiness.Complete(httpmiddleware.PhaseWorkersStarted)
+	logger.Info().Msg("startup complete, ready for traffic")
+
+	<-rootCtx.Done()
+	stopSignals()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Fail readiness first so load balancers stop sending traffic before
+	// connections start draining
+	healthMiddleware.MarkShuttingDown()
+	logger.Info().
+		Dur("drain_delay", cfg.ShutdownDrainDelay).
+		Msg("shutting down, readiness now reports not_ready")
+	time.Sleep(cfg.ShutdownDrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal().Err(err).Msg("server forced to shutdown")
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error().Err(err).Msg("server forced to shutdown")
+	}
+
+	// Workers stop only after the HTTP server has drained, since in-flight
+	// requests may still enqueue work for them
+	if err := lc.Stop(shutdownCtx); err != nil {
+		logger.Error().Err(err).Msg("some components failed to stop cleanly")
 	}
 
 	logger.Info().Msg("server exited")
-}
\ No newline at end of file
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/provemyself/backend/internal/config"
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/handlers"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/streaming"
+)
+
+// newServer configures the API server. The write timeout bounds whole
+// responses; streaming routes push their own deadline forward instead.
+func newServer(cfg *config.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         fmt.Sprintf(":%s", cfg.Port),
+		Handler:      handler,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: cfg.MaxRequestTimeout() + 5*time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+}
+
+// apiDeprecations flags API versions and routes slated for removal. Matching
+// requests get Deprecation, Sunset and successor-version Link headers, and
+// 410 once the sunset date has passed. For example:
+//
+//	{
+//		Prefix:    "/api/v1",
+//		Since:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+//		Sunset:    time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+//		Successor: "/api/v2",
+//	}
+var apiDeprecations = []httpmiddleware.Deprecation{}
+
+// apiVersion is a mounted API version. Versions share handlers while
+// behavior is identical; when a response changes, the new version wraps the
+// affected operation with an adapter instead of branching in the handler.
+type apiVersion struct {
+	prefix string
+	// adapters wrap handlers by operation name, e.g. "projects.list"
+	adapters map[string]func(http.HandlerFunc) http.HandlerFunc
+}
+
+// apiVersions lists every served version, oldest first
+var apiVersions = []apiVersion{
+	{prefix: "/api/v1"},
+	{prefix: "/api/v2"},
+}
+
+// apiHandlers are the handlers shared by every API version
+type apiHandlers struct {
+	projects *handlers.ProjectHandler
+	items    *handlers.ItemHandler
+	admin    *handlers.AdminHandler
+	jobs     *handlers.JobsHandler
+	settings *handlers.SettingsHandler
+	orgs     *handlers.OrganizationHandler
+	collab   *handlers.CollabHandler
+	lti      *handlers.LTIHandler
+	exports  *handlers.ExportHandler
+	webhooks *handlers.WebhookHandler
+	public   *handlers.PublicHandler
+	attempts *handlers.AttemptHandler
+	// participants erases the data kept of learners
+	participants *handlers.ParticipantHandler
+	// xapi backfills xAPI statements to the LRS or as a download
+	xapi *handlers.XAPIHandler
+	// assets, if set, mounts the asset URL signing endpoint
+	assets *handlers.AssetHandler
+
+	// memberships checks X-Org-ID against the user's organizations
+	memberships httpmiddleware.MembershipChecker
+
+	// maintenance guards every route but the admin endpoints
+	maintenance *httpmiddleware.Maintenance
+	// rateLimiter, if set, limits requests to every version per client
+	rateLimiter *httpmiddleware.RateLimiter
+	rateLimits  *handlers.RateLimitHandler
+	integrity   *handlers.IntegrityHandler
+	// seed, if set, mounts the development fixture endpoint
+	seed *handlers.SeedHandler
+	// responseCache, if set, serves repeated anonymous reads of published
+	// projects, at the versions publishedVersions looks up
+	responseCache     *httpmiddleware.ResponseCache
+	publishedVersions httpmiddleware.PublishedVersions
+	// idempotency, if set, keeps the responses to learners' attempt writes
+	// for retries sent with the same Idempotency-Key
+	idempotency core.IdempotencyStore
+}
+
+// cacheReads serves a published project read from the response cache, if
+// enabled
+func (h apiHandlers) cacheReads(next http.Handler) http.Handler {
+	if h.responseCache == nil {
+		return next
+	}
+	return h.responseCache.Cache(h.publishedVersions)(next)
+}
+
+// invalidateReads drops a project's cached reads once a write to it
+// succeeds, if the response cache is enabled
+func (h apiHandlers) invalidateReads(next http.Handler) http.Handler {
+	if h.responseCache == nil {
+		return next
+	}
+	return h.responseCache.Invalidate(next)
+}
+
+// idempotent replays the response to a request retried with the same
+// Idempotency-Key, if an idempotency store is set
+func (h apiHandlers) idempotent(next http.Handler) http.Handler {
+	if h.idempotency == nil {
+		return next
+	}
+	return httpmiddleware.Idempotency(h.idempotency, httpmiddleware.DefaultIdempotencyTTL)(next)
+}
+
+func (v apiVersion) handler(operation string, h http.HandlerFunc) http.HandlerFunc {
+	if adapt, ok := v.adapters[operation]; ok {
+		return adapt(h)
+	}
+	return h
+}
+
+// mountAPI mounts every API version on r behind the deprecation table and
+// the rate limiter
+func mountAPI(r chi.Router, cfg *config.Config, h apiHandlers, deprecations []httpmiddleware.Deprecation) {
+	r.Group(func(r chi.Router) {
+		r.Use(httpmiddleware.Deprecate(deprecations))
+		if h.rateLimiter != nil {
+			r.Use(h.rateLimiter.RateLimit)
+		}
+
+		for _, version := range apiVersions {
+			r.Route(version.prefix, func(r chi.Router) {
+				version.mount(r, cfg, h)
+			})
+		}
+	})
+}
+
+// mountLTI mounts the LTI tool endpoints. Platforms are registered with
+// their URLs, so they stay outside API versioning.
+func mountLTI(r chi.Router, cfg *config.Config, h *handlers.LTIHandler, maintenance *httpmiddleware.Maintenance) {
+	r.Group(func(r chi.Router) {
+		r.Use(maintenance.Middleware)
+		r.Use(httpmiddleware.Timeout(cfg.TimeoutDefault))
+
+		r.Get("/.well-known/jwks.json", h.GetKeySet)
+		// Platforms initiate logins with either method
+		r.Get("/lti/login", h.Login)
+		r.Post("/lti/login", h.Login)
+		r.Post("/lti/launch", h.Launch)
+		r.Get("/lti/session", h.GetSession)
+	})
+}
+
+// mountAssets mounts the asset downloads of signed URLs. The URLs are
+// handed out by the API, so they stay outside API versioning; they are
+// streamed, so outside any Timeout group.
+func mountAssets(r chi.Router, cfg *config.Config, h *handlers.AssetHandler, maintenance *httpmiddleware.Maintenance) {
+	r.Group(func(r chi.Router) {
+		r.Use(maintenance.Middleware)
+		r.Use(streaming.Middleware(cfg.StreamWriteTimeout))
+
+		r.Get("/assets/*", h.ServeAsset)
+	})
+}
+
+// mount registers the version's routes. Timeouts are applied per route group
+// rather than globally so a route can be given a longer budget than its
+// parent. Streaming routes (SSE, exports, downloads) go in a "Streaming"
+// group behind streaming.Middleware and outside any Timeout group, or the
+// server's WriteTimeout cuts them off; these groups are the only place they
+// are registered. WebSocket routes go in a "WebSocket" group behind neither,
+// since both wrap the response writer without letting it be hijacked; the
+// connection sets its own deadlines once upgraded.
+func (v apiVersion) mount(r chi.Router, cfg *config.Config, h apiHandlers) {
+	// Projects, scoped to the caller's organization
+	r.With(
+		h.maintenance.Middleware,
+		httpmiddleware.OptionalAuth(cfg.JWTSecret),
+		httpmiddleware.OrgScope(h.memberships),
+	).Route("/projects", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(httpmiddleware.Timeout(cfg.TimeoutDefault))
+
+			r.Get("/", v.handler("projects.list", h.projects.ListProjects))
+			r.Get("/trash", v.handler("projects.trash", h.projects.ListTrash))
+			r.Post("/", v.handler("projects.create", h.projects.CreateProject))
+			r.Get("/{projectId}", v.handler("projects.get", h.projects.GetProject))
+			r.With(h.invalidateReads).Put("/{projectId}", v.handler("projects.update", h.projects.UpdateProject))
+			r.With(h.invalidateReads).Delete("/{projectId}", v.handler("projects.delete", h.projects.DeleteProject))
+			r.With(h.invalidateReads).Post("/{projectId}/restore", v.handler("projects.restore", h.projects.RestoreProject))
+			r.With(h.invalidateReads).Delete("/{projectId}/purge", v.handler("projects.purge", h.projects.PurgeProject))
+			r.With(h.invalidateReads).Post("/{projectId}/publish", v.handler("projects.publish", h.projects.PublishProject))
+			r.With(h.invalidateReads).Put("/{projectId}/results-policy", v.handler("projects.update_results_policy", h.projects.UpdateResultsPolicy))
+			r.Get("/{projectId}/publications", v.handler("projects.list_publications", h.projects.ListPublications))
+			r.Get("/{projectId}/publications/{version}", v.handler("projects.get_publication", h.projects.GetPublication))
+			r.Post("/{projectId}/duplicate", v.handler("projects.duplicate", h.projects.DuplicateProject))
+			r.Get("/{projectId}/attempts", v.handler("projects.list_attempts", h.attempts.ListAttempts))
+			r.Get("/{projectId}/stats", v.handler("projects.stats", h.attempts.GetStats))
+			r.Get("/{projectId}/analytics", v.handler("projects.analytics", h.attempts.GetAnalytics))
+			if h.assets != nil {
+				r.Post("/{projectId}/assets/{key}/signed-url", v.handler("projects.sign_asset_url", h.assets.CreateSignedURL))
+			}
+		})
+
+		// Previews are taken by their author
+		r.Group(func(r chi.Router) {
+			r.Use(httpmiddleware.AuthenticateJWT(cfg.JWTSecret))
+			r.Use(httpmiddleware.Timeout(cfg.TimeoutDefault))
+
+			r.Post("/{projectId}/preview-attempts", v.handler("projects.start_preview", h.attempts.StartPreview))
+		})
+
+		// Streaming
+		r.Group(func(r chi.Router) {
+			r.Use(streaming.Middleware(cfg.StreamWriteTimeout))
+
+			r.Get("/{projectId}/export", v.handler("projects.export", h.exports.ExportProject))
+		})
+
+		// Items nested under projects
+		r.Route("/{projectId}/items", func(r chi.Router) {
+			r.Group(func(r chi.Router) {
+				r.Use(httpmiddleware.Timeout(cfg.TimeoutDefault))
+
+				r.Get("/", v.handler("items.list", h.items.ListItems))
+				r.Post("/", v.handler("items.create", h.items.CreateItem))
+				r.Get("/{itemId}", v.handler("items.get", h.items.GetItem))
+				r.Put("/{itemId}", v.handler("items.update", h.items.UpdateItem))
+				r.Patch("/{itemId}", v.handler("items.patch", h.items.PatchItem))
+				r.Delete("/{itemId}", v.handler("items.delete", h.items.DeleteItem))
+				r.Post("/{itemId}/duplicate", v.handler("items.duplicate", h.items.DuplicateItem))
+				r.Post("/{itemId}/restore", v.handler("items.restore", h.items.RestoreItem))
+				r.Get("/{itemId}/revisions", v.handler("items.list_revisions", h.items.ListItemRevisions))
+				r.Get("/{itemId}/answers/distribution", v.handler("items.answer_distribution", h.attempts.GetAnswerDistribution))
+				r.Post("/{itemId}/revisions/{revision}/restore", v.handler("items.restore_revision", h.items.RestoreItemRevision))
+				r.Put("/positions", v.handler("items.update_positions", h.items.UpdateItemPositions))
+			})
+
+			// Bulk operations get a larger body limit and a longer budget
+			r.With(
+				httpmiddleware.RequestSizeLimit(cfg.MaxBulkRequestBodyBytes),
+				httpmiddleware.Timeout(cfg.TimeoutBulk),
+			).Post("/bulk", v.handler("items.bulk_create", h.items.BulkCreateItems))
+
+			// Imports upload a package and the files in it
+			r.With(
+				httpmiddleware.RequestSizeLimit(cfg.MaxImportBodyBytes),
+				httpmiddleware.Timeout(cfg.TimeoutUpload),
+			).Post("/import", v.handler("items.import", h.items.ImportItems))
+
+			// Edit locks are held by a user
+			r.Group(func(r chi.Router) {
+				r.Use(httpmiddleware.AuthenticateJWT(cfg.JWTSecret))
+				r.Use(httpmiddleware.Timeout(cfg.TimeoutDefault))
+
+				r.Post("/{itemId}/lock", v.handler("items.lock", h.items.LockItem))
+				r.Put("/{itemId}/lock", v.handler("items.refresh_lock", h.items.RefreshItemLock))
+				r.Delete("/{itemId}/lock", v.handler("items.unlock", h.items.UnlockItem))
+			})
+
+			// Streaming
+			r.Group(func(r chi.Router) {
+				r.Use(streaming.Middleware(cfg.StreamWriteTimeout))
+
+				r.Get("/locks/events", v.handler("items.stream_locks", h.items.StreamItemLocks))
+			})
+		})
+
+		// WebSocket
+		r.Group(func(r chi.Router) {
+			r.Use(httpmiddleware.AuthenticateJWT(cfg.JWTSecret))
+
+			r.Get("/{projectId}/collab", v.handler("projects.collab", h.collab.Collab))
+		})
+	})
+
+	// Published projects, for learners of any organization to take without
+	// signing in, and their attempts at them. A signed-in learner's attempts
+	// record the user.
+	r.With(
+		h.maintenance.Middleware,
+		httpmiddleware.OptionalAuth(cfg.JWTSecret),
+		httpmiddleware.Timeout(cfg.TimeoutDefault),
+	).Route("/public", func(r chi.Router) {
+		r.With(h.cacheReads).Get("/projects/{projectId}", v.handler("public.get_project", h.public.GetProject))
+		r.With(h.idempotent).Post("/projects/{projectId}/attempts", v.handler("public.start_attempt", h.public.StartAttempt))
+		r.Get("/attempts/{attemptId}", v.handler("public.get_attempt", h.public.GetAttempt))
+		r.With(h.idempotent).Put("/attempts/{attemptId}/answers/{itemId}", v.handler("public.save_answer", h.public.SaveAnswer))
+		r.Post("/attempts/{attemptId}/submit", v.handler("public.submit_attempt", h.public.SubmitAttempt))
+	})
+
+	// The data kept of a learner, erased by them or an operator
+	r.With(
+		h.maintenance.Middleware,
+		httpmiddleware.AuthenticateJWT(cfg.JWTSecret),
+		httpmiddleware.Timeout(cfg.TimeoutBulk),
+	).Delete("/participants/{participantId}/data", v.handler("participants.erase_data", h.participants.EraseData))
+
+	// The signed-in user's webhooks. X-Org-ID picks the organization a new
+	// webhook delivers the project events of.
+	r.With(
+		h.maintenance.Middleware,
+		httpmiddleware.AuthenticateJWT(cfg.JWTSecret),
+		httpmiddleware.OrgScope(h.memberships),
+		httpmiddleware.Timeout(cfg.TimeoutDefault),
+	).Route("/me/webhooks", func(r chi.Router) {
+		r.Get("/", v.handler("webhooks.list", h.webhooks.ListWebhooks))
+		r.Post("/", v.handler("webhooks.create", h.webhooks.CreateWebhook))
+		r.Get("/{webhookId}", v.handler("webhooks.get", h.webhooks.GetWebhook))
+		r.Put("/{webhookId}", v.handler("webhooks.update", h.webhooks.UpdateWebhook))
+		r.Delete("/{webhookId}", v.handler("webhooks.delete", h.webhooks.DeleteWebhook))
+		r.Get("/{webhookId}/deliveries", v.handler("webhooks.list_deliveries", h.webhooks.ListDeliveries))
+		r.Post("/{webhookId}/deliveries/{deliveryId}/redeliver", v.handler("webhooks.redeliver", h.webhooks.RedeliverWebhook))
+	})
+
+	// Operator endpoints
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(httpmiddleware.AuthenticateJWT(cfg.JWTSecret))
+		r.Use(httpmiddleware.RequireRole("admin"))
+
+		r.Group(func(r chi.Router) {
+			r.Use(httpmiddleware.Timeout(cfg.TimeoutDefault))
+
+			r.Get("/log-level", v.handler("admin.get_log_level", h.admin.GetLogLevel))
+			r.Put("/log-level", v.handler("admin.set_log_level", h.admin.SetLogLevel))
+			r.Get("/maintenance", v.handler("admin.get_maintenance", h.admin.GetMaintenance))
+			r.Put("/maintenance", v.handler("admin.set_maintenance", h.admin.SetMaintenance))
+			r.Get("/settings", v.handler("admin.get_settings", h.settings.GetSettings))
+			r.Put("/settings", v.handler("admin.update_settings", h.settings.UpdateSettings))
+			r.Get("/rate-limits/top", v.handler("admin.rate_limit_top", h.rateLimits.TopKeys))
+			r.Get("/integrity", v.handler("admin.check_integrity", h.integrity.CheckIntegrity))
+			r.Get("/organizations", v.handler("admin.list_organizations", h.orgs.ListOrganizations))
+			r.Post("/organizations", v.handler("admin.create_organization", h.orgs.CreateOrganization))
+			r.Get("/organizations/{orgId}", v.handler("admin.get_organization", h.orgs.GetOrganization))
+			r.Put("/organizations/{orgId}", v.handler("admin.update_organization", h.orgs.UpdateOrganization))
+			r.Delete("/organizations/{orgId}", v.handler("admin.delete_organization", h.orgs.DeleteOrganization))
+			r.Get("/organizations/{orgId}/members", v.handler("admin.list_organization_members", h.orgs.ListMembers))
+			r.Put("/organizations/{orgId}/members/{userId}", v.handler("admin.put_organization_member", h.orgs.PutMember))
+			r.Delete("/organizations/{orgId}/members/{userId}", v.handler("admin.remove_organization_member", h.orgs.RemoveMember))
+			r.Get("/jobs", v.handler("admin.list_jobs", h.jobs.ListJobs))
+			r.Post("/jobs/{name}/run", v.handler("admin.run_job", h.jobs.RunJob))
+			r.Get("/lti/platforms", v.handler("admin.list_lti_platforms", h.lti.ListPlatforms))
+			r.Post("/lti/platforms", v.handler("admin.create_lti_platform", h.lti.CreatePlatform))
+			r.Delete("/lti/platforms/{platformId}", v.handler("admin.delete_lti_platform", h.lti.DeletePlatform))
+			r.Get("/xapi/export", v.handler("admin.xapi_export_status", h.xapi.ExportStatus))
+			if h.seed != nil {
+				r.Post("/seed", v.handler("admin.seed", h.seed.Seed))
+			}
+		})
+
+		// Streaming
+		r.Group(func(r chi.Router) {
+			r.Use(streaming.Middleware(cfg.StreamWriteTimeout))
+
+			r.Get("/jobs/events", v.handler("admin.stream_jobs", h.jobs.StreamJobs))
+			r.Post("/xapi/export", v.handler("admin.xapi_export", h.xapi.Export))
+		})
+	})
+}
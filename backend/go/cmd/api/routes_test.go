@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/config"
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/handlers"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// listedProjects serves a fixed project list; other methods are unused
+type listedProjects struct {
+	handlers.ProjectService
+	projects []*core.Project
+}
+
+func (s listedProjects) List(ctx context.Context, opts core.ListOptions) (*core.ProjectPage, error) {
+	return &core.ProjectPage{Projects: s.projects, Total: len(s.projects)}, nil
+}
+
+func newTestAPI(t *testing.T, deprecations []httpmiddleware.Deprecation) *httptest.Server {
+	t.Helper()
+
+	cfg := &config.Config{
+		TimeoutDefault:          time.Second,
+		TimeoutBulk:             time.Second,
+		MaxBulkRequestBodyBytes: 1 << 20,
+	}
+	validate := httpmiddleware.NewValidator()
+	projects := listedProjects{projects: []*core.Project{
+		{ID: "project-1", Title: "Capitals of Europe"},
+	}}
+
+	r := chi.NewRouter()
+	mountAPI(r, cfg, apiHandlers{
+		projects: handlers.NewProjectHandler(projects, validate),
+		items:    handlers.NewItemHandler(nil, validate),
+		admin:    handlers.NewAdminHandler(nil, validate),
+		jobs:     handlers.NewJobsHandler(nil, time.Second),
+
+		maintenance: httpmiddleware.NewMaintenance(store.NewMemoryMaintenanceStore(), httpmiddleware.MaintenanceConfig{}),
+	}, deprecations)
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMountAPI_EveryVersionServesProjectList(t *testing.T) {
+	// Arrange
+	server := newTestAPI(t, nil)
+
+	for _, version := range apiVersions {
+		t.Run(version.prefix, func(t *testing.T) {
+			// Act
+			resp, err := http.Get(server.URL + version.prefix + "/projects")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			// Assert
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Empty(t, resp.Header.Get("Deprecation"))
+
+			var body types.ProjectListResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			require.Len(t, body.Projects, 1)
+			assert.Equal(t, "project-1", body.Projects[0].ID)
+		})
+	}
+}
+
+func TestMountAPI_FlaggedVersionCarriesDeprecationHeaders(t *testing.T) {
+	// Arrange
+	server := newTestAPI(t, []httpmiddleware.Deprecation{{
+		Prefix:    "/api/v1",
+		Since:     time.Now().Add(-time.Hour),
+		Sunset:    time.Now().AddDate(0, 6, 0),
+		Successor: "/api/v2",
+	}})
+
+	// Act
+	v1, err := http.Get(server.URL + "/api/v1/projects")
+	require.NoError(t, err)
+	defer v1.Body.Close()
+	v2, err := http.Get(server.URL + "/api/v2/projects")
+	require.NoError(t, err)
+	defer v2.Body.Close()
+
+	// Assert
+	assert.Equal(t, http.StatusOK, v1.StatusCode)
+	assert.NotEmpty(t, v1.Header.Get("Deprecation"))
+	assert.NotEmpty(t, v1.Header.Get("Sunset"))
+	assert.Equal(t, `</api/v2/projects>; rel="successor-version"`, v1.Header.Get("Link"))
+
+	assert.Equal(t, http.StatusOK, v2.StatusCode)
+	assert.Empty(t, v2.Header.Get("Deprecation"))
+}
+
+func TestMountAPI_SunsetVersionIsGone(t *testing.T) {
+	// Arrange
+	server := newTestAPI(t, []httpmiddleware.Deprecation{{
+		Prefix:    "/api/v1",
+		Since:     time.Now().AddDate(0, -6, 0),
+		Sunset:    time.Now().Add(-time.Hour),
+		Successor: "/api/v2",
+	}})
+
+	// Act
+	resp, err := http.Get(server.URL + "/api/v1/projects")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Assert
+	assert.Equal(t, http.StatusGone, resp.StatusCode)
+}
+
+func TestMountAPI_MaintenanceSparesAdminRoutes(t *testing.T) {
+	// Arrange
+	cfg := &config.Config{
+		TimeoutDefault:          time.Second,
+		TimeoutBulk:             time.Second,
+		MaxBulkRequestBodyBytes: 1 << 20,
+	}
+	maintenance := httpmiddleware.NewMaintenance(store.NewMemoryMaintenanceStore(), httpmiddleware.MaintenanceConfig{Forced: true})
+	validate := httpmiddleware.NewValidator()
+
+	r := chi.NewRouter()
+	mountAPI(r, cfg, apiHandlers{
+		projects:    handlers.NewProjectHandler(listedProjects{}, validate),
+		items:       handlers.NewItemHandler(nil, validate),
+		admin:       handlers.NewAdminHandler(maintenance, validate),
+		jobs:        handlers.NewJobsHandler(nil, time.Second),
+		maintenance: maintenance,
+	}, nil)
+
+	// Act
+	projects := httptest.NewRecorder()
+	r.ServeHTTP(projects, httptest.NewRequest(http.MethodGet, "/api/v2/projects", nil))
+
+	toggle := httptest.NewRequest(http.MethodGet, "/api/v1/admin/maintenance", nil)
+	toggle.Header.Set("Authorization", "Bearer admin-token")
+	admin := httptest.NewRecorder()
+	r.ServeHTTP(admin, toggle)
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, projects.Code)
+	assert.Equal(t, http.StatusOK, admin.Code)
+}
+
+// seededFixtures pretends to seed the fixtures
+type seededFixtures struct{}
+
+func (seededFixtures) Seed(ctx context.Context) (*core.SeedResult, error) {
+	return &core.SeedResult{OrganizationID: core.SeedOrganizationID}, nil
+}
+
+func TestMountAPI_SeedOnlyMountedWhenSet(t *testing.T) {
+	tests := []struct {
+		name           string
+		seed           *handlers.SeedHandler
+		expectedStatus int
+	}{
+		{"not development", nil, http.StatusNotFound},
+		{"development", handlers.NewSeedHandler(seededFixtures{}), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			cfg := &config.Config{
+				TimeoutDefault:          time.Second,
+				TimeoutBulk:             time.Second,
+				MaxBulkRequestBodyBytes: 1 << 20,
+			}
+			validate := httpmiddleware.NewValidator()
+
+			r := chi.NewRouter()
+			mountAPI(r, cfg, apiHandlers{
+				projects: handlers.NewProjectHandler(listedProjects{}, validate),
+				items:    handlers.NewItemHandler(nil, validate),
+				admin:    handlers.NewAdminHandler(nil, validate),
+				jobs:     handlers.NewJobsHandler(nil, time.Second),
+				seed:     tt.seed,
+
+				maintenance: httpmiddleware.NewMaintenance(store.NewMemoryMaintenanceStore(), httpmiddleware.MaintenanceConfig{}),
+			}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/seed", nil)
+			req.Header.Set("Authorization", "Bearer admin-token")
+			rec := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(rec, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+// countedAttempts counts the attempts it starts and the answers it saves;
+// other methods are unused
+type countedAttempts struct {
+	handlers.AttemptService
+	started, saved int
+}
+
+func (s *countedAttempts) Start(ctx context.Context, projectID, participantID string) (*core.Attempt, error) {
+	s.started++
+	return &core.Attempt{ID: fmt.Sprintf("attempt-%d", s.started), ProjectID: projectID, Answers: []*core.Answer{}}, nil
+}
+
+func (s *countedAttempts) SaveAnswer(ctx context.Context, attemptID, itemID string, response json.RawMessage, sequence int64) (*core.Answer, error) {
+	s.saved++
+	return &core.Answer{AttemptID: attemptID, ItemID: itemID, Response: response, Sequence: sequence}, nil
+}
+
+// newAttemptsAPI mounts the API with attempts behind the public endpoints
+// and an idempotency store in memory
+func newAttemptsAPI(attempts *countedAttempts) http.Handler {
+	cfg := &config.Config{
+		TimeoutDefault:          time.Second,
+		TimeoutBulk:             time.Second,
+		MaxBulkRequestBodyBytes: 1 << 20,
+	}
+	validate := httpmiddleware.NewValidator()
+
+	r := chi.NewRouter()
+	mountAPI(r, cfg, apiHandlers{
+		projects: handlers.NewProjectHandler(listedProjects{}, validate),
+		items:    handlers.NewItemHandler(nil, validate),
+		admin:    handlers.NewAdminHandler(nil, validate),
+		jobs:     handlers.NewJobsHandler(nil, time.Second),
+		public:   handlers.NewPublicHandler(nil, attempts, validate),
+
+		maintenance: httpmiddleware.NewMaintenance(store.NewMemoryMaintenanceStore(), httpmiddleware.MaintenanceConfig{}),
+		idempotency: store.NewMemoryIdempotencyStore(),
+	}, nil)
+	return r
+}
+
+// sendWithKey sends a request with an Idempotency-Key to handler
+func sendWithKey(handler http.Handler, method, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(httpmiddleware.IdempotencyKeyHeader, key)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMountAPI_RetriedAttemptWritesAreReplayed(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		count          func(*countedAttempts) int
+	}{
+		{"start attempt", http.MethodPost, "/api/v1/public/projects/project-1/attempts", "", http.StatusCreated, func(s *countedAttempts) int { return s.started }},
+		{"save answer", http.MethodPut, "/api/v1/public/attempts/attempt-1/answers/item-1", `{"answer":{"choice_id":"a"},"sequence":1}`, http.StatusOK, func(s *countedAttempts) int { return s.saved }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			attempts := &countedAttempts{}
+			api := newAttemptsAPI(attempts)
+
+			// Act
+			first := sendWithKey(api, tt.method, tt.path, "retry-1", tt.body)
+			retry := sendWithKey(api, tt.method, tt.path, "retry-1", tt.body)
+			other := sendWithKey(api, tt.method, tt.path, "retry-2", tt.body)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, first.Code)
+			assert.Empty(t, first.Header().Get(httpmiddleware.IdempotentReplayedHeader))
+			assert.Equal(t, tt.expectedStatus, retry.Code)
+			assert.Equal(t, "true", retry.Header().Get(httpmiddleware.IdempotentReplayedHeader))
+			assert.Equal(t, first.Body.String(), retry.Body.String())
+			assert.Equal(t, tt.expectedStatus, other.Code)
+			assert.Equal(t, 2, tt.count(attempts), "the retry isn't served again, another key is")
+		})
+	}
+}
+
+func TestMountAPI_IdempotencyKeyReusedWithAnotherBody(t *testing.T) {
+	// Arrange
+	attempts := &countedAttempts{}
+	api := newAttemptsAPI(attempts)
+	path := "/api/v1/public/attempts/attempt-1/answers/item-1"
+
+	// Act
+	first := sendWithKey(api, http.MethodPut, path, "save-1", `{"answer":{"choice_id":"a"},"sequence":1}`)
+	reused := sendWithKey(api, http.MethodPut, path, "save-1", `{"answer":{"choice_id":"b"},"sequence":2}`)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusConflict, reused.Code)
+	var body types.ErrorResponse
+	require.NoError(t, json.Unmarshal(reused.Body.Bytes(), &body))
+	assert.Equal(t, "idempotency_conflict", body.Error.Code)
+	assert.Equal(t, 1, attempts.saved)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/config"
+	"github.com/provemyself/backend/internal/http/handlers"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/jobs"
+	"github.com/provemyself/backend/internal/store"
+)
+
+// streamHold is how long the integration test keeps an event stream open,
+// past the production write timeout of 15s and the test server's own
+const streamHold = 16 * time.Second
+
+// newStreamingServer serves the API through newServer with short timeouts:
+// every non-streaming response must finish within its write timeout of 6s
+func newStreamingServer(t *testing.T, jobsHandler *handlers.JobsHandler) *httptest.Server {
+	t.Helper()
+
+	cfg := &config.Config{
+		TimeoutDefault:          time.Second,
+		TimeoutBulk:             time.Second,
+		TimeoutUpload:           time.Second,
+		StreamWriteTimeout:      2 * time.Second,
+		MaxBulkRequestBodyBytes: 1 << 20,
+	}
+	validate := httpmiddleware.NewValidator()
+
+	r := chi.NewRouter()
+	mountAPI(r, cfg, apiHandlers{
+		projects: handlers.NewProjectHandler(listedProjects{}, validate),
+		items:    handlers.NewItemHandler(nil, validate),
+		admin:    handlers.NewAdminHandler(nil, validate),
+		jobs:     jobsHandler,
+
+		maintenance: httpmiddleware.NewMaintenance(store.NewMemoryMaintenanceStore(), httpmiddleware.MaintenanceConfig{}),
+	}, nil)
+	// The same handler outside the streaming group, to show what the server's
+	// write timeout does to it
+	r.Get("/unprotected/jobs/events", jobsHandler.StreamJobs)
+
+	server := httptest.NewUnstartedServer(r)
+	server.Config = newServer(cfg, r)
+	server.Start()
+	t.Cleanup(server.Close)
+
+	require.Less(t, server.Config.WriteTimeout, streamHold)
+	return server
+}
+
+// readStream reads SSE lines until the stream ends or hold passes, and
+// returns the event names and the number of keep-alive comments seen
+func readStream(t *testing.T, url string, hold time.Duration) (events []string, keepAlives int, ended time.Duration) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), hold)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	req.Header.Set("Accept", "text/event-stream")
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			events = append(events, strings.TrimPrefix(line, "event: "))
+		case line == ": keep-alive":
+			keepAlives++
+		}
+	}
+	return events, keepAlives, time.Since(start)
+}
+
+func TestStreaming_EventStreamOutlivesWriteTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("holds a connection open for 16s")
+	}
+
+	// Arrange
+	scheduler := jobs.NewScheduler(nil, nil)
+	require.NoError(t, scheduler.Register(jobs.Func("items.purge_deleted", func(ctx context.Context) error {
+		return nil
+	}), jobs.Every(time.Hour), jobs.Options{}))
+	server := newStreamingServer(t, handlers.NewJobsHandler(scheduler, time.Second))
+
+	// Act
+	events, keepAlives, ended := readStream(t, server.URL+"/api/v1/admin/jobs/events", streamHold)
+
+	// Assert
+	assert.Equal(t, []string{"jobs"}, events)
+	assert.GreaterOrEqual(t, ended, streamHold, "stream ended before the client hung up")
+	// One keep-alive per second after the first event, allowing for jitter
+	assert.GreaterOrEqual(t, keepAlives, int(streamHold/time.Second)-2)
+}
+
+func TestStreaming_UnprotectedStreamIsCutOff(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for the server's write timeout")
+	}
+
+	// Arrange
+	scheduler := jobs.NewScheduler(nil, nil)
+	server := newStreamingServer(t, handlers.NewJobsHandler(scheduler, time.Second))
+
+	// Act
+	_, _, ended := readStream(t, server.URL+"/unprotected/jobs/events", streamHold)
+
+	// Assert
+	assert.Less(t, ended, streamHold)
+	assert.GreaterOrEqual(t, ended, server.Config.WriteTimeout)
+}
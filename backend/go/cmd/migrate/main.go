@@ -0,0 +1,201 @@
+// Command migrate applies, inspects and rolls back the database schema
+// migrations embedded in the API, independently of the API process, e.g.
+// from an init container with MIGRATE_ON_STARTUP=false on the API. It reads
+// the same DATABASE_URL and DB_* settings as the API.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/provemyself/backend/internal/config"
+	"github.com/provemyself/backend/internal/logging"
+	"github.com/provemyself/backend/internal/store"
+)
+
+const usage = `Usage: migrate <command> [arguments]
+
+Commands:
+  up              apply every pending migration
+  down N          roll back the last N migrations
+  goto VERSION    migrate up or down to VERSION
+  force VERSION   mark VERSION applied and clean, after repairing a dirty schema by hand
+  status          list the migrations and when they were applied; exits 2 if the schema is dirty
+  drop --force    drop every table in the database
+`
+
+// errUsage reports a malformed command line
+var errUsage = errors.New("invalid arguments")
+
+// errDirty makes status exit with code 2
+var errDirty = errors.New("schema is dirty")
+
+// migrator is the part of *store.Migrator the commands use
+type migrator interface {
+	Up(ctx context.Context) error
+	Down(ctx context.Context, steps int) error
+	Goto(ctx context.Context, version uint) error
+	Force(ctx context.Context, version uint) error
+	Drop(ctx context.Context) error
+	Status(ctx context.Context) (*store.MigrationStatus, error)
+}
+
+func main() {
+	logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to load configuration")
+	}
+	logLevel, _ := logging.ParseLevel(cfg.LogLevel)
+	logger = logging.New(logging.Config{
+		Level:  logLevel,
+		Pretty: cfg.IsDevelopment(),
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	database, err := store.NewDatabase(ctx, cfg.Database())
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialize database")
+	}
+	defer database.Close()
+
+	m, err := database.Migrator(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to prepare migrations")
+	}
+	defer m.Close()
+
+	err = run(ctx, m, os.Args[1:], os.Stdout)
+	switch {
+	case err == nil:
+	case errors.Is(err, errUsage):
+		fmt.Fprint(os.Stderr, usage)
+		logger.Error().Err(err).Msg("migrate failed")
+		os.Exit(64)
+	case errors.Is(err, errDirty):
+		os.Exit(2)
+	default:
+		logger.Error().Err(err).Msg("migrate failed")
+		os.Exit(1)
+	}
+}
+
+// run executes the command named by args
+func run(ctx context.Context, m migrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing command", errUsage)
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "up":
+		if err := expectArgs(command, args, 0); err != nil {
+			return err
+		}
+		return m.Up(ctx)
+	case "down":
+		if err := expectArgs(command, args, 1); err != nil {
+			return err
+		}
+		steps, err := strconv.Atoi(args[0])
+		if err != nil || steps < 1 {
+			return fmt.Errorf("%w: down takes a positive number of migrations, got %q", errUsage, args[0])
+		}
+		return m.Down(ctx, steps)
+	case "goto", "force":
+		if err := expectArgs(command, args, 1); err != nil {
+			return err
+		}
+		version, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s takes a version number, got %q", errUsage, command, args[0])
+		}
+		if command == "force" {
+			return m.Force(ctx, uint(version))
+		}
+		return m.Goto(ctx, uint(version))
+	case "status":
+		if err := expectArgs(command, args, 0); err != nil {
+			return err
+		}
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatus(out, status)
+		if status.Dirty {
+			return errDirty
+		}
+		return nil
+	case "drop":
+		if len(args) != 1 || args[0] != "--force" {
+			return fmt.Errorf("%w: drop deletes all data and requires --force", errUsage)
+		}
+		return m.Drop(ctx)
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
+}
+
+func expectArgs(command string, args []string, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("%w: %s takes %d argument(s), got %d", errUsage, command, n, len(args))
+	}
+	return nil
+}
+
+// printStatus writes the schema version, a table of the migrations and, for
+// a dirty schema, how to repair it
+func printStatus(out io.Writer, status *store.MigrationStatus) {
+	fmt.Fprintf(out, "Schema version: %d (latest %d)\n\n", status.Version, status.Latest)
+
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, migration := range status.Migrations {
+		state, appliedAt := "pending", "-"
+		switch {
+		case migration.Version == status.Version && status.Dirty:
+			state = "DIRTY"
+		case migration.Applied:
+			state = "applied"
+			appliedAt = "unknown"
+			if migration.AppliedAt != nil {
+				appliedAt = migration.AppliedAt.UTC().Format(time.RFC3339)
+			}
+		}
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\n", migration.Version, migration.Name, state, appliedAt)
+	}
+	table.Flush()
+
+	if status.Dirty {
+		fmt.Fprintf(out, "\nMigration %d failed halfway and the schema is dirty; no migration will run until it is repaired.\n", status.Version)
+		fmt.Fprintf(out, "Inspect the database against migration %d, then either:\n", status.Version)
+		fmt.Fprintf(out, "  - finish its changes by hand and run: migrate force %d\n", status.Version)
+		fmt.Fprintf(out, "  - undo its changes by hand and run:   migrate force %d\n", previousVersion(status))
+	}
+}
+
+// previousVersion returns the version before the dirty one, 0 if it was the
+// first
+func previousVersion(status *store.MigrationStatus) uint {
+	var previous uint
+	for _, migration := range status.Migrations {
+		if migration.Version < status.Version {
+			previous = migration.Version
+		}
+	}
+	return previous
+}
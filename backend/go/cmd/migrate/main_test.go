@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/store"
+)
+
+// recordingMigrator records the operations it is asked to perform
+type recordingMigrator struct {
+	calls  []string
+	status *store.MigrationStatus
+}
+
+func (m *recordingMigrator) Up(ctx context.Context) error {
+	m.calls = append(m.calls, "up")
+	return nil
+}
+
+func (m *recordingMigrator) Down(ctx context.Context, steps int) error {
+	m.calls = append(m.calls, fmt.Sprintf("down %d", steps))
+	return nil
+}
+
+func (m *recordingMigrator) Goto(ctx context.Context, version uint) error {
+	m.calls = append(m.calls, fmt.Sprintf("goto %d", version))
+	return nil
+}
+
+func (m *recordingMigrator) Force(ctx context.Context, version uint) error {
+	m.calls = append(m.calls, fmt.Sprintf("force %d", version))
+	return nil
+}
+
+func (m *recordingMigrator) Drop(ctx context.Context) error {
+	m.calls = append(m.calls, "drop")
+	return nil
+}
+
+func (m *recordingMigrator) Status(ctx context.Context) (*store.MigrationStatus, error) {
+	m.calls = append(m.calls, "status")
+	return m.status, nil
+}
+
+func TestRun_Commands(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expectedCall  string
+		expectedUsage bool
+	}{
+		{"up", []string{"up"}, "up", false},
+		{"down", []string{"down", "2"}, "down 2", false},
+		{"goto", []string{"goto", "3"}, "goto 3", false},
+		{"force", []string{"force", "1"}, "force 1", false},
+		{"drop", []string{"drop", "--force"}, "drop", false},
+		{"no command", nil, "", true},
+		{"unknown command", []string{"sideways"}, "", true},
+		{"down without count", []string{"down"}, "", true},
+		{"down by zero", []string{"down", "0"}, "", true},
+		{"goto non-numeric version", []string{"goto", "latest"}, "", true},
+		{"drop without --force", []string{"drop"}, "", true},
+		{"up with extra arguments", []string{"up", "3"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			m := &recordingMigrator{}
+
+			// Act
+			err := run(context.Background(), m, tt.args, &bytes.Buffer{})
+
+			// Assert
+			if tt.expectedUsage {
+				assert.ErrorIs(t, err, errUsage)
+				assert.Empty(t, m.calls)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{tt.expectedCall}, m.calls)
+		})
+	}
+}
+
+func TestRun_Status(t *testing.T) {
+	appliedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		status        *store.MigrationStatus
+		expectedDirty bool
+		expectedLines []string
+	}{
+		{
+			name: "clean schema with a pending migration",
+			status: &store.MigrationStatus{Version: 1, Latest: 2, Migrations: []store.MigrationInfo{
+				{Version: 1, Name: "initial_schema", Applied: true, AppliedAt: &appliedAt},
+				{Version: 2, Name: "add_attempts"},
+			}},
+			expectedLines: []string{
+				"Schema version: 1 (latest 2)",
+				"1        initial_schema  applied  2026-10-01T12:00:00Z",
+				"2        add_attempts    pending  -",
+			},
+		},
+		{
+			name: "dirty schema",
+			status: &store.MigrationStatus{Version: 2, Dirty: true, Latest: 2, Migrations: []store.MigrationInfo{
+				{Version: 1, Name: "initial_schema", Applied: true},
+				{Version: 2, Name: "add_attempts"},
+			}},
+			expectedDirty: true,
+			expectedLines: []string{
+				"1        initial_schema  applied  unknown",
+				"2        add_attempts    DIRTY    -",
+				"Migration 2 failed halfway",
+				"finish its changes by hand and run: migrate force 2",
+				"undo its changes by hand and run:   migrate force 1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			m := &recordingMigrator{status: tt.status}
+			var out bytes.Buffer
+
+			// Act
+			err := run(context.Background(), m, []string{"status"}, &out)
+
+			// Assert
+			if tt.expectedDirty {
+				assert.ErrorIs(t, err, errDirty)
+			} else {
+				assert.NoError(t, err)
+			}
+			for _, line := range tt.expectedLines {
+				assert.True(t, strings.Contains(out.String(), line), "output lacks %q:\n%s", line, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Command seed fills a development database with the documented fixtures
+// (see docs/api/README.md), through the same services as the API. Seeding
+// again only creates what is missing. It reads the same ENVIRONMENT,
+// DATABASE_URL, DB_* and STORAGE_* settings as the API, migrates when
+// MIGRATE_ON_STARTUP is set, and refuses to run in production.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog"
+
+	"github.com/provemyself/backend/internal/config"
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/logging"
+	"github.com/provemyself/backend/internal/store"
+)
+
+func main() {
+	logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to load configuration")
+	}
+	logLevel, _ := logging.ParseLevel(cfg.LogLevel)
+	logger = logging.New(logging.Config{
+		Level:  logLevel,
+		Pretty: cfg.IsDevelopment(),
+	})
+	if cfg.IsProduction() {
+		logger.Fatal().Err(core.ErrSeedInProduction).Msg("refusing to seed")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx = logger.WithContext(ctx)
+
+	database, err := store.NewDatabase(ctx, cfg.Database())
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialize database")
+	}
+	defer database.Close()
+
+	if cfg.MigrateOnStartup {
+		if err := database.Migrate(ctx); err != nil {
+			logger.Fatal().Err(err).Msg("failed to run database migrations")
+		}
+	}
+
+	projectStore := store.NewProjectStore(database)
+	orgStore := store.NewOrganizationStore(database)
+	projectService := core.NewProjectService(projectStore, nil)
+	projectService.SetOrganizations(orgStore)
+	itemStore := store.NewItemStore(database)
+	projectService.SetItems(itemStore)
+	itemService := core.NewItemService(itemStore, projectStore)
+	itemService.SetTransactor(database)
+
+	var storage core.Storage
+	if cfg.StorageType == "local" {
+		storage = store.NewLocalStorage(cfg.StoragePath, "/files")
+	}
+
+	seeder := core.NewSeedService(cfg.Environment, projectService, itemService, orgStore, storage)
+	if err := run(ctx, seeder, os.Stdout); err != nil {
+		logger.Error().Err(err).Msg("seed failed")
+		os.Exit(1)
+	}
+}
+
+// run seeds the fixtures and prints them
+func run(ctx context.Context, seeder core.Seeder, out io.Writer) error {
+	result, err := seeder.Seed(ctx)
+	if err != nil {
+		return err
+	}
+	printSeed(out, result)
+	return nil
+}
+
+// printSeed writes tables of the fixture users and projects
+func printSeed(out io.Writer, result *core.SeedResult) {
+	fmt.Fprintf(out, "Organization %s\n\n", result.OrganizationID)
+
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "USER\tEMAIL\tROLE")
+	for _, user := range result.Users {
+		fmt.Fprintf(table, "%s\t%s\t%s\n", user.ID, user.Email, user.Role)
+	}
+	fmt.Fprintln(table)
+	fmt.Fprintln(table, "PROJECT\tTITLE\tITEMS\tPUBLISHED")
+	for _, project := range result.Projects {
+		fmt.Fprintf(table, "%s\t%s\t%d\t%t\n", project.ID, project.Title, project.Items, project.Published)
+	}
+	table.Flush()
+
+	if len(result.Assets) == 0 {
+		fmt.Fprintln(out, "\nNo sample files uploaded: STORAGE_TYPE is not local, so the items showing them were skipped.")
+	}
+	fmt.Fprintf(out, "\n%d record(s) created.\n", result.Created)
+}
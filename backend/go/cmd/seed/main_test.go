@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// fakeSeeder counts the seeding runs
+type fakeSeeder struct {
+	runs   int
+	result *core.SeedResult
+	err    error
+}
+
+func (s *fakeSeeder) Seed(ctx context.Context) (*core.SeedResult, error) {
+	s.runs++
+	return s.result, s.err
+}
+
+func TestRun_PrintsFixtures(t *testing.T) {
+	tests := []struct {
+		name          string
+		result        *core.SeedResult
+		expectedLines []string
+	}{
+		{
+			name: "with sample files",
+			result: &core.SeedResult{
+				OrganizationID: core.SeedOrganizationID,
+				Users:          core.SeedUsers,
+				Projects: []core.SeededProject{
+					{ID: "5eed0000-0000-4000-8000-000000001003", Title: "World Capitals", Items: 11, Published: true},
+				},
+				Assets:  []string{"projects/5eed0000-0000-4000-8000-000000001002/assets/plant-cell.svg"},
+				Created: 42,
+			},
+			expectedLines: []string{
+				"Organization " + core.SeedOrganizationID,
+				"dev@example.com      admin",
+				"5eed0000-0000-4000-8000-000000001003  World Capitals  11     true",
+				"42 record(s) created.",
+			},
+		},
+		{
+			name:   "already seeded, without storage",
+			result: &core.SeedResult{OrganizationID: core.SeedOrganizationID},
+			expectedLines: []string{
+				"No sample files uploaded",
+				"0 record(s) created.",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			seeder := &fakeSeeder{result: tt.result}
+			var out bytes.Buffer
+
+			// Act
+			err := run(context.Background(), seeder, &out)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, 1, seeder.runs)
+			for _, line := range tt.expectedLines {
+				assert.Contains(t, out.String(), line)
+			}
+		})
+	}
+}
+
+func TestRun_SeedFailed(t *testing.T) {
+	// Arrange
+	seeder := &fakeSeeder{err: core.ErrSeedInProduction}
+	var out bytes.Buffer
+
+	// Act
+	err := run(context.Background(), seeder, &out)
+
+	// Assert
+	assert.True(t, errors.Is(err, core.ErrSeedInProduction))
+	assert.Empty(t, out.String())
+}
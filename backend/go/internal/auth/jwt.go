@@ -17,6 +17,7 @@ type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+	OrgID  string `json:"org_id,omitempty"` // active organization, if any
 	Exp    int64  `json:"exp"`
 	Iat    int64  `json:"iat"`
 	Iss    string `json:"iss"`
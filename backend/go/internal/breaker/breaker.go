@@ -0,0 +1,225 @@
+// Package breaker implements a circuit breaker for calls to external
+// dependencies (object storage, the LRS). After a run of consecutive
+// failures the breaker opens and fails calls immediately for a cool-down
+// period instead of letting every request wait for the dependency's timeout.
+// It then lets a single probe call through (half-open) and closes again if
+// the probe succeeds.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// State is a breaker state
+type State int
+
+const (
+	// StateClosed lets every call through
+	StateClosed State = iota
+	// StateHalfOpen lets a single probe call through
+	StateHalfOpen
+	// StateOpen fails calls immediately
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half_open"
+	case StateOpen:
+		return "open"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// ErrOpen is wrapped by every OpenError
+var ErrOpen = errors.New("circuit breaker is open")
+
+// OpenError is returned for calls rejected by an open breaker
+type OpenError struct {
+	Name string
+	// RetryAfter is the time left until the breaker lets a probe through
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Name, ErrOpen)
+}
+
+// Unwrap returns ErrOpen
+func (e *OpenError) Unwrap() error {
+	return ErrOpen
+}
+
+// Degraded marks the error as degrading rather than failing readiness: the
+// breaker is protecting the service from a failing dependency.
+func (e *OpenError) Degraded() bool {
+	return true
+}
+
+// Config contains breaker settings
+type Config struct {
+	Name string
+	// FailureThreshold is the number of consecutive failures that opens the
+	// breaker
+	FailureThreshold int
+	// CoolDown is how long the breaker stays open before probing
+	CoolDown time.Duration
+	// IsFailure decides whether an error counts against the dependency.
+	// Defaults to every error except context cancellation.
+	IsFailure func(err error) bool
+	// OnStateChange is called after every transition, e.g. to update metrics
+	OnStateChange func(name string, from, to State)
+}
+
+// Breaker is a circuit breaker. It is safe for concurrent use.
+type Breaker struct {
+	cfg Config
+	now func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// New creates a closed breaker
+func New(cfg Config) *Breaker {
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = func(err error) bool {
+			return !errors.Is(err, context.Canceled)
+		}
+	}
+	return &Breaker{cfg: cfg, now: time.Now}
+}
+
+// Name returns the breaker's name
+func (b *Breaker) Name() string {
+	return b.cfg.Name
+}
+
+// State returns the current state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refreshLocked()
+	return b.state
+}
+
+// Execute runs fn unless the breaker is open, in which case it returns an
+// *OpenError without calling fn
+func (b *Breaker) Execute(fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	err := fn()
+	b.record(err)
+	return err
+}
+
+// HealthCheck reports an *OpenError while the breaker is open, so it can be
+// used as a readiness check (see middleware.HealthChecker)
+func (b *Breaker) HealthCheck(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refreshLocked()
+
+	if b.state == StateOpen {
+		return b.openErrorLocked()
+	}
+	return nil
+}
+
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refreshLocked()
+
+	switch b.state {
+	case StateOpen:
+		return b.openErrorLocked()
+	case StateHalfOpen:
+		// Only one probe at a time; everyone else keeps failing fast
+		if b.probing {
+			return &OpenError{Name: b.cfg.Name}
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	failed := err != nil && b.cfg.IsFailure(err)
+
+	if b.state == StateHalfOpen {
+		b.probing = false
+		if failed {
+			b.transitionLocked(StateOpen)
+		} else {
+			b.transitionLocked(StateClosed)
+		}
+		return
+	}
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == StateClosed && b.failures >= b.cfg.FailureThreshold {
+		b.transitionLocked(StateOpen)
+	}
+}
+
+// refreshLocked moves an open breaker to half-open once the cool-down passed
+func (b *Breaker) refreshLocked() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cfg.CoolDown {
+		b.transitionLocked(StateHalfOpen)
+	}
+}
+
+func (b *Breaker) openErrorLocked() *OpenError {
+	return &OpenError{
+		Name:       b.cfg.Name,
+		RetryAfter: b.cfg.CoolDown - b.now().Sub(b.openedAt),
+	}
+}
+
+func (b *Breaker) transitionLocked(to State) {
+	from := b.state
+	b.state = to
+	b.failures = 0
+
+	if to == StateOpen {
+		b.openedAt = b.now()
+	}
+
+	event := log.Info()
+	if to == StateOpen {
+		event = log.Warn().Dur("cool_down", b.cfg.CoolDown)
+	}
+	event.
+		Str("breaker", b.cfg.Name).
+		Str("from", from.String()).
+		Str("to", to.String()).
+		Msg("circuit breaker state changed")
+
+	if b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(b.cfg.Name, from, to)
+	}
+}
@@ -0,0 +1,196 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// fakeClock is advanced manually by tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// scriptedBackend fails while down is set and counts calls
+type scriptedBackend struct {
+	down  bool
+	calls int
+}
+
+func (b *scriptedBackend) call() error {
+	b.calls++
+	if b.down {
+		return errors.New("connection timed out")
+	}
+	return nil
+}
+
+type transition struct {
+	from, to State
+}
+
+func newTestBreaker(clock *fakeClock, transitions *[]transition) *Breaker {
+	b := New(Config{
+		Name:             "storage",
+		FailureThreshold: 3,
+		CoolDown:         30 * time.Second,
+		OnStateChange: func(name string, from, to State) {
+			*transitions = append(*transitions, transition{from, to})
+		},
+	})
+	b.now = clock.Now
+	return b
+}
+
+func TestBreaker_FullCycle(t *testing.T) {
+	// Arrange
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	var transitions []transition
+	b := newTestBreaker(clock, &transitions)
+	backend := &scriptedBackend{down: true}
+
+	// Act & Assert: closed, failures below the threshold still reach the backend
+	for i := 0; i < 2; i++ {
+		require.Error(t, b.Execute(backend.call))
+	}
+	assert.Equal(t, StateClosed, b.State())
+
+	// The third consecutive failure opens the breaker
+	require.Error(t, b.Execute(backend.call))
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, 3, backend.calls)
+
+	// Open: calls fail fast without touching the backend
+	clock.Advance(10 * time.Second)
+	err := b.Execute(backend.call)
+	var openErr *OpenError
+	require.ErrorAs(t, err, &openErr)
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.Equal(t, 20*time.Second, openErr.RetryAfter)
+	assert.Equal(t, 3, backend.calls)
+	assert.Error(t, b.HealthCheck(context.Background()))
+
+	// Half-open after the cool-down: a failed probe re-opens it
+	clock.Advance(20 * time.Second)
+	assert.Equal(t, StateHalfOpen, b.State())
+	require.Error(t, b.Execute(backend.call))
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, 4, backend.calls)
+
+	// A successful probe closes it again
+	clock.Advance(30 * time.Second)
+	backend.down = false
+	require.NoError(t, b.Execute(backend.call))
+	assert.Equal(t, StateClosed, b.State())
+	assert.NoError(t, b.HealthCheck(context.Background()))
+
+	assert.Equal(t, []transition{
+		{StateClosed, StateOpen},
+		{StateOpen, StateHalfOpen},
+		{StateHalfOpen, StateOpen},
+		{StateOpen, StateHalfOpen},
+		{StateHalfOpen, StateClosed},
+	}, transitions)
+}
+
+func TestBreaker_SuccessResetsFailureCount(t *testing.T) {
+	// Arrange
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	var transitions []transition
+	b := newTestBreaker(clock, &transitions)
+	backend := &scriptedBackend{}
+
+	// Act
+	for i := 0; i < 5; i++ {
+		backend.down = i%2 == 0
+		b.Execute(backend.call)
+	}
+
+	// Assert
+	assert.Equal(t, StateClosed, b.State())
+	assert.Empty(t, transitions)
+}
+
+func TestBreaker_HalfOpenAllowsOneProbe(t *testing.T) {
+	// Arrange
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	var transitions []transition
+	b := newTestBreaker(clock, &transitions)
+	backend := &scriptedBackend{down: true}
+	for i := 0; i < 3; i++ {
+		b.Execute(backend.call)
+	}
+	clock.Advance(30 * time.Second)
+
+	// Act
+	var concurrent error
+	err := b.Execute(func() error {
+		concurrent = b.Execute(backend.call)
+		return nil
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.ErrorIs(t, concurrent, ErrOpen)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_IgnoresCancelledCalls(t *testing.T) {
+	// Arrange
+	b := New(Config{Name: "storage", FailureThreshold: 1, CoolDown: time.Minute})
+
+	// Act
+	b.Execute(func() error { return context.Canceled })
+
+	// Assert
+	assert.Equal(t, StateClosed, b.State())
+}
+
+// fakeStorage is a core.Storage whose Delete returns err
+type fakeStorage struct {
+	core.Storage
+	err error
+}
+
+func (s *fakeStorage) Delete(ctx context.Context, key string) error {
+	return s.err
+}
+
+func (s *fakeStorage) Download(ctx context.Context, key string) (io.ReadCloser, *core.StorageMetadata, error) {
+	return nil, nil, core.ErrFileNotFound
+}
+
+func TestWrapStorage(t *testing.T) {
+	// Arrange
+	b := New(Config{Name: "storage", FailureThreshold: 2, CoolDown: time.Minute, IsFailure: IsStorageFailure})
+	backend := &fakeStorage{err: errors.New("s3: request timeout")}
+	storage := WrapStorage(backend, b)
+
+	// Act: missing files don't count against the backend
+	for i := 0; i < 5; i++ {
+		_, _, err := storage.Download(context.Background(), "missing.png")
+		require.ErrorIs(t, err, core.ErrFileNotFound)
+	}
+	require.Equal(t, StateClosed, b.State())
+
+	storage.Delete(context.Background(), "a.png")
+	storage.Delete(context.Background(), "b.png")
+	err := storage.Delete(context.Background(), "c.png")
+
+	// Assert
+	assert.Equal(t, StateOpen, b.State())
+	assert.ErrorIs(t, err, core.ErrStorageUnavailable)
+	var openErr *OpenError
+	assert.ErrorAs(t, err, &openErr)
+}
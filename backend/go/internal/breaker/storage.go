@@ -0,0 +1,114 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// IsStorageFailure counts errors against the storage backend, excluding
+// caller mistakes (missing files, rejected uploads) and cancelled requests
+func IsStorageFailure(err error) bool {
+	return !errors.Is(err, core.ErrFileNotFound) &&
+		!errors.Is(err, core.ErrFileTooBig) &&
+		!errors.Is(err, core.ErrInvalidFileType) &&
+		!errors.Is(err, context.Canceled)
+}
+
+// WrapStorage guards a storage backend with b. Calls rejected by the open
+// breaker fail with core.ErrStorageUnavailable wrapping the *OpenError.
+// HealthCheck bypasses the breaker so health reports the backend itself.
+func WrapStorage(storage core.Storage, b *Breaker) core.Storage {
+	return &guardedStorage{next: storage, breaker: b}
+}
+
+// guardedStorage implements core.Storage by delegating to another backend
+type guardedStorage struct {
+	next    core.Storage
+	breaker *Breaker
+}
+
+func (s *guardedStorage) execute(fn func() error) error {
+	err := s.breaker.Execute(fn)
+
+	var openErr *OpenError
+	if errors.As(err, &openErr) {
+		return fmt.Errorf("%w: %w", core.ErrStorageUnavailable, openErr)
+	}
+	return err
+}
+
+func (s *guardedStorage) Upload(ctx context.Context, key string, reader io.Reader, opts core.UploadOptions) (*core.StorageMetadata, error) {
+	var metadata *core.StorageMetadata
+	err := s.execute(func() error {
+		var err error
+		metadata, err = s.next.Upload(ctx, key, reader, opts)
+		return err
+	})
+	return metadata, err
+}
+
+func (s *guardedStorage) Download(ctx context.Context, key string) (io.ReadCloser, *core.StorageMetadata, error) {
+	var reader io.ReadCloser
+	var metadata *core.StorageMetadata
+	err := s.execute(func() error {
+		var err error
+		reader, metadata, err = s.next.Download(ctx, key)
+		return err
+	})
+	return reader, metadata, err
+}
+
+func (s *guardedStorage) Delete(ctx context.Context, key string) error {
+	return s.execute(func() error {
+		return s.next.Delete(ctx, key)
+	})
+}
+
+func (s *guardedStorage) Exists(ctx context.Context, key string) (bool, error) {
+	var exists bool
+	err := s.execute(func() error {
+		var err error
+		exists, err = s.next.Exists(ctx, key)
+		return err
+	})
+	return exists, err
+}
+
+func (s *guardedStorage) GetURL(ctx context.Context, key string) (string, error) {
+	var url string
+	err := s.execute(func() error {
+		var err error
+		url, err = s.next.GetURL(ctx, key)
+		return err
+	})
+	return url, err
+}
+
+func (s *guardedStorage) GetSignedURL(ctx context.Context, key string, expiration time.Duration) (string, error) {
+	var url string
+	err := s.execute(func() error {
+		var err error
+		url, err = s.next.GetSignedURL(ctx, key, expiration)
+		return err
+	})
+	return url, err
+}
+
+func (s *guardedStorage) List(ctx context.Context, prefix string, limit int) ([]*core.StorageMetadata, error) {
+	var files []*core.StorageMetadata
+	err := s.execute(func() error {
+		var err error
+		files, err = s.next.List(ctx, prefix, limit)
+		return err
+	})
+	return files, err
+}
+
+func (s *guardedStorage) HealthCheck(ctx context.Context) error {
+	return s.next.HealthCheck(ctx)
+}
@@ -0,0 +1,84 @@
+package collab
+
+import (
+	"context"
+	"sync"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// Feed tells editors when the item locks of the project they watch change.
+// Signals carry no data: subscribers reload the locks, so signals that
+// arrive while one is pending coalesce into it. Feed is a
+// core.ItemLockPublisher for changes made on this replica and a
+// core.ChangeSubscriber for changes made on the others. It is safe for
+// concurrent use.
+type Feed struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]struct{}
+}
+
+// NewFeed creates a feed without subscribers
+func NewFeed() *Feed {
+	return &Feed{subscribers: make(map[string]map[chan struct{}]struct{})}
+}
+
+// Subscribe returns a channel signalled whenever the project's item locks
+// change, and a function that stops the signals
+func (f *Feed) Subscribe(projectID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	f.mu.Lock()
+	if f.subscribers[projectID] == nil {
+		f.subscribers[projectID] = make(map[chan struct{}]struct{})
+	}
+	f.subscribers[projectID][ch] = struct{}{}
+	f.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			delete(f.subscribers[projectID], ch)
+			if len(f.subscribers[projectID]) == 0 {
+				delete(f.subscribers, projectID)
+			}
+		})
+	}
+}
+
+// PublishItemLocks signals the project's subscribers
+func (f *Feed) PublishItemLocks(projectID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subscribers[projectID] {
+		signal(ch)
+	}
+}
+
+// ApplyChange signals the subscribers of the project whose locks changed
+func (f *Feed) ApplyChange(ctx context.Context, change core.Change) error {
+	f.PublishItemLocks(change.ID)
+	return nil
+}
+
+// Resync signals every subscriber, since changes may have been missed
+func (f *Feed) Resync(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, subscribers := range f.subscribers {
+		for ch := range subscribers {
+			signal(ch)
+		}
+	}
+	return nil
+}
+
+// signal wakes ch unless a signal is already pending
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
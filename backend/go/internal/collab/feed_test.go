@@ -0,0 +1,65 @@
+package collab
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// signalled reports whether ch has a signal pending, taking it
+func signalled(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestFeed_SignalsTheProjectsSubscribers(t *testing.T) {
+	feed := NewFeed()
+	p1, cancel := feed.Subscribe("p1")
+	defer cancel()
+	p2, cancel2 := feed.Subscribe("p2")
+	defer cancel2()
+
+	feed.PublishItemLocks("p1")
+	feed.PublishItemLocks("p1")
+
+	assert.True(t, signalled(p1))
+	assert.False(t, signalled(p1), "pending signals coalesce")
+	assert.False(t, signalled(p2))
+}
+
+func TestFeed_AppliesChangesFromOtherReplicas(t *testing.T) {
+	feed := NewFeed()
+	p1, cancel := feed.Subscribe("p1")
+	defer cancel()
+	p2, cancel2 := feed.Subscribe("p2")
+	defer cancel2()
+
+	assert.NoError(t, feed.ApplyChange(context.Background(), core.Change{
+		Entity: core.ChangeEntityItemLocks, ID: "p2", Action: core.ChangeActionUpdated,
+	}))
+	assert.False(t, signalled(p1))
+	assert.True(t, signalled(p2))
+
+	assert.NoError(t, feed.Resync(context.Background()))
+	assert.True(t, signalled(p1))
+	assert.True(t, signalled(p2))
+}
+
+func TestFeed_CancelStopsSignals(t *testing.T) {
+	feed := NewFeed()
+	ch, cancel := feed.Subscribe("p1")
+
+	cancel()
+	cancel()
+	feed.PublishItemLocks("p1")
+
+	assert.False(t, signalled(ch))
+	assert.Empty(t, feed.subscribers)
+}
@@ -0,0 +1,291 @@
+// Package collab relays real-time editing sessions between the Yjs clients
+// of a project, speaking the y-websocket protocol. The server never
+// interprets the CRDT: a project's room keeps the document as the list of
+// Yjs updates its peers sent, replays that list to joining peers and
+// broadcasts every new update to the others. Applying an update twice is
+// harmless in Yjs, so the list only ever needs to cover the document.
+//
+// The list is compacted when a peer answers the room's sync step 1 with
+// its whole document: that answer replaces every update the peer had been
+// sent. Rooms are saved through core.CollabStore periodically and when
+// their last peer leaves, and dropped from memory once they have been
+// empty for the idle timeout. Awareness messages (cursors, presence) are
+// relayed but never saved.
+//
+// Rooms live in the memory of one replica, so every editor of a project
+// must reach the same replica for them to see each other.
+package collab
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// Defaults for the zero values of Config
+const (
+	DefaultPersistInterval = 30 * time.Second
+	DefaultIdleTimeout     = 5 * time.Minute
+	DefaultSendBuffer      = 256
+	DefaultReadTimeout     = time.Minute
+)
+
+// persistTimeout bounds a single save
+const persistTimeout = 10 * time.Second
+
+// Config tunes a Hub
+type Config struct {
+	// PersistInterval is how often changed rooms are saved
+	PersistInterval time.Duration
+	// IdleTimeout is how long an empty room stays in memory, so quick
+	// reconnects don't reload it
+	IdleTimeout time.Duration
+	// SendBuffer is how many messages may wait for a slow peer before it
+	// is disconnected
+	SendBuffer int
+	// ReadTimeout disconnects a peer that sends nothing for that long.
+	// y-websocket clients renew their awareness state every 15 seconds.
+	ReadTimeout time.Duration
+}
+
+// Observer is told about room and connection counts, e.g. to feed metrics
+type Observer interface {
+	ObserveRooms(n int)
+	ObserveConnections(n int)
+	ObserveMessage(kind string)
+	ObserveSlowPeer()
+}
+
+// Transport is one peer's connection, carrying whole binary messages. Read
+// is only called from one goroutine and Write from another; the messages
+// Read returns are kept, so each needs a buffer of its own.
+type Transport interface {
+	// Read returns the next message, waiting at most timeout
+	Read(timeout time.Duration) ([]byte, error)
+	Write(message []byte) error
+	Close() error
+}
+
+// ErrClosed is returned by Serve once the hub has stopped
+var ErrClosed = errors.New("collaboration hub is closed")
+
+// Hub holds the rooms of the projects being edited on this replica. It is
+// safe for concurrent use.
+type Hub struct {
+	store    core.CollabStore
+	cfg      Config
+	observer Observer
+
+	mu     sync.Mutex
+	rooms  map[string]*room
+	peers  int
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewHub creates a hub saving documents to store. observer may be nil.
+func NewHub(store core.CollabStore, cfg Config, observer Observer) *Hub {
+	if cfg.PersistInterval <= 0 {
+		cfg.PersistInterval = DefaultPersistInterval
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = DefaultIdleTimeout
+	}
+	if cfg.SendBuffer < 1 {
+		cfg.SendBuffer = DefaultSendBuffer
+	}
+	if cfg.ReadTimeout <= 0 {
+		cfg.ReadTimeout = DefaultReadTimeout
+	}
+	return &Hub{
+		store:    store,
+		cfg:      cfg,
+		observer: observer,
+		rooms:    make(map[string]*room),
+	}
+}
+
+// Serve joins conn to the project's room and relays its messages until
+// the connection fails or the hub stops. The caller must have checked the
+// peer may edit the project. conn is closed when Serve returns.
+func (h *Hub) Serve(ctx context.Context, projectID string, conn Transport) error {
+	defer conn.Close()
+
+	r, err := h.join(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	p := newPeer(conn, h.cfg.SendBuffer)
+	r.add(p)
+	h.countPeers(1)
+	defer func() {
+		h.leave(r, p)
+		h.countPeers(-1)
+		h.wg.Done()
+	}()
+
+	go p.writeLoop()
+
+	for {
+		message, err := conn.Read(h.cfg.ReadTimeout)
+		if err != nil {
+			p.close()
+			return nil
+		}
+		if err := r.handle(p, message); err != nil {
+			log.Ctx(ctx).Debug().Err(err).Str("project_id", projectID).Msg("dropping collaboration peer")
+			p.close()
+			return nil
+		}
+	}
+}
+
+// Rooms returns how many rooms are in memory
+func (h *Hub) Rooms() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.rooms)
+}
+
+// Run saves changed rooms every PersistInterval and drops idle ones until
+// ctx is done. It then disconnects every peer, waits for them to leave and
+// saves what changed.
+func (h *Hub) Run(ctx context.Context) error {
+	ticker := time.NewTicker(h.cfg.PersistInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.shutdown(context.WithoutCancel(ctx))
+			return ctx.Err()
+		case <-ticker.C:
+			h.sweep(ctx, time.Now())
+		}
+	}
+}
+
+// join returns the project's room, loading it if needed. The room is
+// pinned in memory until the peer leaves.
+func (h *Hub) join(ctx context.Context, projectID string) (*room, error) {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil, ErrClosed
+	}
+	r, ok := h.rooms[projectID]
+	if !ok {
+		r = newRoom(projectID, h.observer)
+		h.rooms[projectID] = r
+		h.observeRooms()
+	}
+	r.pins++
+	h.wg.Add(1)
+	h.mu.Unlock()
+
+	if err := r.load(ctx, h.store); err != nil {
+		h.mu.Lock()
+		r.pins--
+		if h.rooms[projectID] == r && r.pins == 0 {
+			// Let the next peer retry the load
+			delete(h.rooms, projectID)
+			h.observeRooms()
+		}
+		h.mu.Unlock()
+		h.wg.Done()
+		return nil, err
+	}
+	return r, nil
+}
+
+// leave removes the peer from its room and saves the room if it was the
+// last one
+func (h *Hub) leave(r *room, p *peer) {
+	empty := r.remove(p, time.Now())
+
+	h.mu.Lock()
+	r.pins--
+	h.mu.Unlock()
+
+	if empty {
+		h.persist(context.Background(), r)
+	}
+}
+
+// sweep saves changed rooms and drops those empty for the idle timeout
+func (h *Hub) sweep(ctx context.Context, now time.Time) {
+	h.mu.Lock()
+	rooms := make([]*room, 0, len(h.rooms))
+	for _, r := range h.rooms {
+		rooms = append(rooms, r)
+	}
+	h.mu.Unlock()
+
+	for _, r := range rooms {
+		h.persist(ctx, r)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, r := range h.rooms {
+		if r.pins == 0 && r.idle(now, h.cfg.IdleTimeout) {
+			delete(h.rooms, id)
+		}
+	}
+	h.observeRooms()
+}
+
+// shutdown disconnects every peer and saves every changed room
+func (h *Hub) shutdown(ctx context.Context) {
+	h.mu.Lock()
+	h.closed = true
+	rooms := make([]*room, 0, len(h.rooms))
+	for _, r := range h.rooms {
+		rooms = append(rooms, r)
+	}
+	h.mu.Unlock()
+
+	for _, r := range rooms {
+		r.disconnectAll()
+	}
+	h.wg.Wait()
+
+	for _, r := range rooms {
+		h.persist(ctx, r)
+	}
+}
+
+// persist saves the room if it changed since its last save. A failed save
+// leaves the room marked as changed, so the next sweep retries it.
+func (h *Hub) persist(ctx context.Context, r *room) {
+	ctx, cancel := context.WithTimeout(ctx, persistTimeout)
+	defer cancel()
+
+	if err := r.persist(ctx, h.store); err != nil {
+		log.Error().Err(err).Str("project_id", r.projectID).Msg("failed to save collaboration document")
+	}
+}
+
+func (h *Hub) countPeers(delta int) {
+	h.mu.Lock()
+	h.peers += delta
+	peers := h.peers
+	h.mu.Unlock()
+
+	if h.observer != nil {
+		h.observer.ObserveConnections(peers)
+	}
+}
+
+// observeRooms reports the room count; the caller holds h.mu
+func (h *Hub) observeRooms() {
+	if h.observer != nil {
+		h.observer.ObserveRooms(len(h.rooms))
+	}
+}
@@ -0,0 +1,475 @@
+package collab
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitTimeout bounds every wait for a message or a goroutine
+const waitTimeout = 2 * time.Second
+
+// memStore is a core.CollabStore in memory
+type memStore struct {
+	mu      sync.Mutex
+	states  map[string][]byte
+	saves   int
+	loadErr error
+}
+
+func newMemStore() *memStore {
+	return &memStore{states: make(map[string][]byte)}
+}
+
+func (s *memStore) Load(ctx context.Context, projectID string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loadErr != nil {
+		return nil, s.loadErr
+	}
+	return s.states[projectID], nil
+}
+
+func (s *memStore) Save(ctx context.Context, projectID string, state []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[projectID] = state
+	s.saves++
+	return nil
+}
+
+// saved returns the updates saved for the project
+func (s *memStore) saved(t *testing.T, projectID string) [][]byte {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	updates, err := decodeState(s.states[projectID])
+	require.NoError(t, err)
+	return updates
+}
+
+// fakeConn is a Transport whose other end is driven by the test. Writes
+// block once out is full, like a peer that stops reading.
+type fakeConn struct {
+	in     chan []byte
+	out    chan []byte
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newFakeConn(buffer int) *fakeConn {
+	return &fakeConn{
+		in:     make(chan []byte, 16),
+		out:    make(chan []byte, buffer),
+		closed: make(chan struct{}),
+	}
+}
+
+func (c *fakeConn) Read(timeout time.Duration) ([]byte, error) {
+	select {
+	case message := <-c.in:
+		return message, nil
+	case <-c.closed:
+		return nil, errors.New("closed")
+	case <-time.After(timeout):
+		return nil, errors.New("read timeout")
+	}
+}
+
+func (c *fakeConn) Write(message []byte) error {
+	select {
+	case c.out <- message:
+		return nil
+	case <-c.closed:
+		return errors.New("closed")
+	}
+}
+
+func (c *fakeConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+// send delivers a message from the client
+func (c *fakeConn) send(message []byte) {
+	c.in <- message
+}
+
+// receive returns the next message sent to the client
+func (c *fakeConn) receive(t *testing.T) []byte {
+	t.Helper()
+	select {
+	case message := <-c.out:
+		return message
+	case <-time.After(waitTimeout):
+		t.Fatal("no message received")
+		return nil
+	}
+}
+
+// assertSilent checks no message is waiting for the client
+func (c *fakeConn) assertSilent(t *testing.T) {
+	t.Helper()
+	select {
+	case message := <-c.out:
+		t.Fatalf("unexpected message %v", message)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func (c *fakeConn) waitClosed(t *testing.T) {
+	t.Helper()
+	select {
+	case <-c.closed:
+	case <-time.After(waitTimeout):
+		t.Fatal("connection not closed")
+	}
+}
+
+// countingObserver records what the hub reports
+type countingObserver struct {
+	mu          sync.Mutex
+	rooms       int
+	connections int
+	messages    map[string]int
+	slowPeers   int
+}
+
+func (o *countingObserver) ObserveRooms(n int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.rooms = n
+}
+
+func (o *countingObserver) ObserveConnections(n int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.connections = n
+}
+
+func (o *countingObserver) ObserveMessage(kind string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.messages == nil {
+		o.messages = make(map[string]int)
+	}
+	o.messages[kind]++
+}
+
+func (o *countingObserver) ObserveSlowPeer() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.slowPeers++
+}
+
+func (o *countingObserver) slow() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.slowPeers
+}
+
+// session is a connection served by the hub
+type session struct {
+	conn *fakeConn
+	done chan error
+}
+
+// connect serves a new connection and reads the document the hub sends it,
+// up to the room's sync step 1
+func connect(t *testing.T, hub *Hub, projectID string) (*session, [][]byte) {
+	t.Helper()
+	s := &session{conn: newFakeConn(64), done: make(chan error, 1)}
+	go func() { s.done <- hub.Serve(context.Background(), projectID, s.conn) }()
+
+	var updates [][]byte
+	for {
+		message := s.conn.receive(t)
+		if string(message) == string(syncMessage(syncStep1, emptyStateVector)) {
+			return s, updates
+		}
+		d := decoder{buf: message}
+		messageType, _ := d.uint()
+		require.EqualValues(t, messageSync, messageType, "message before sync step 1: %v", message)
+		syncType, _ := d.uint()
+		require.EqualValues(t, syncStep2, syncType)
+		update, err := d.bytes()
+		require.NoError(t, err)
+		updates = append(updates, update)
+	}
+}
+
+// disconnect closes the session's connection and waits for Serve to return
+func (s *session) disconnect(t *testing.T) {
+	t.Helper()
+	s.conn.Close()
+	select {
+	case err := <-s.done:
+		require.NoError(t, err)
+	case <-time.After(waitTimeout):
+		t.Fatal("Serve did not return")
+	}
+}
+
+// awarenessMessage encodes an awareness message for one client
+func awarenessMessage(clientID, clock uint64, state string) []byte {
+	update := appendUint(nil, 1)
+	update = appendUint(update, clientID)
+	update = appendUint(update, clock)
+	update = appendString(update, state)
+	return appendBytes(appendUint(nil, messageAwareness), update)
+}
+
+func TestHub_Serve_RelaysUpdatesToOtherPeers(t *testing.T) {
+	// Arrange
+	hub := NewHub(newMemStore(), Config{}, nil)
+	alice, document := connect(t, hub, "p1")
+	bob, _ := connect(t, hub, "p1")
+	update := []byte{1, 2, 3}
+
+	// Act
+	alice.conn.send(syncMessage(syncUpdate, update))
+
+	// Assert
+	assert.Equal(t, [][]byte{emptyUpdate}, document, "an empty room sends an empty update")
+	assert.Equal(t, syncMessage(syncUpdate, update), bob.conn.receive(t))
+	alice.conn.assertSilent(t)
+
+	alice.disconnect(t)
+	bob.disconnect(t)
+}
+
+func TestHub_Serve_SendsTheDocumentToJoiningPeers(t *testing.T) {
+	// Arrange
+	hub := NewHub(newMemStore(), Config{}, nil)
+	alice, _ := connect(t, hub, "p1")
+	alice.conn.send(syncMessage(syncUpdate, []byte{1}))
+	alice.conn.send(syncMessage(syncUpdate, []byte{2}))
+	// A sync step 1 is answered once the updates before it are applied
+	alice.conn.send(syncMessage(syncStep1, emptyStateVector))
+	assert.Equal(t, syncMessage(syncStep2, emptyUpdate), alice.conn.receive(t))
+
+	// Act
+	bob, document := connect(t, hub, "p1")
+
+	// Assert
+	assert.Equal(t, [][]byte{{1}, {2}}, document)
+
+	alice.disconnect(t)
+	bob.disconnect(t)
+}
+
+func TestHub_Serve_CompactsOnTheSyncStep2Answer(t *testing.T) {
+	// Arrange
+	store := newMemStore()
+	store.states["p1"] = encodeState([][]byte{{1}, {2}, {3}})
+	hub := NewHub(store, Config{}, nil)
+	alice, document := connect(t, hub, "p1")
+	require.Equal(t, [][]byte{{1}, {2}, {3}}, document)
+
+	// Act
+	alice.conn.send(syncMessage(syncStep2, []byte{9}))
+	alice.conn.send(syncMessage(syncStep1, emptyStateVector))
+	alice.conn.receive(t)
+	bob, document := connect(t, hub, "p1")
+
+	// Assert
+	assert.Equal(t, [][]byte{{9}}, document)
+
+	alice.disconnect(t)
+	bob.disconnect(t)
+	assert.Equal(t, [][]byte{{9}}, store.saved(t, "p1"))
+}
+
+func TestHub_Serve_KeepsUpdatesSentAfterTheSnapshot(t *testing.T) {
+	// Arrange
+	hub := NewHub(newMemStore(), Config{}, nil)
+	alice, _ := connect(t, hub, "p1")
+	alice.conn.send(syncMessage(syncUpdate, []byte{1}))
+	alice.conn.send(syncMessage(syncStep1, emptyStateVector))
+	alice.conn.receive(t)
+	bob, _ := connect(t, hub, "p1")
+
+	// Act: alice edits while bob's answer is on its way
+	alice.conn.send(syncMessage(syncUpdate, []byte{2}))
+	require.Equal(t, syncMessage(syncUpdate, []byte{2}), bob.conn.receive(t))
+	bob.conn.send(syncMessage(syncStep2, []byte{7}))
+	bob.conn.send(syncMessage(syncStep1, emptyStateVector))
+	bob.conn.receive(t)
+	carol, document := connect(t, hub, "p1")
+
+	// Assert
+	assert.Equal(t, [][]byte{{7}, {2}}, document)
+
+	alice.disconnect(t)
+	bob.disconnect(t)
+	carol.disconnect(t)
+}
+
+func TestHub_Serve_SavesWhenTheLastPeerLeaves(t *testing.T) {
+	// Arrange
+	store := newMemStore()
+	hub := NewHub(store, Config{}, nil)
+	alice, _ := connect(t, hub, "p1")
+	bob, _ := connect(t, hub, "p1")
+	alice.conn.send(syncMessage(syncUpdate, []byte{1}))
+	bob.conn.receive(t)
+
+	// Act
+	alice.disconnect(t)
+	unsaved := store.saved(t, "p1")
+	bob.disconnect(t)
+
+	// Assert
+	assert.Empty(t, unsaved, "the room is saved only once empty")
+	assert.Equal(t, [][]byte{{1}}, store.saved(t, "p1"))
+}
+
+func TestHub_Serve_RelaysAwareness(t *testing.T) {
+	// Arrange
+	hub := NewHub(newMemStore(), Config{}, nil)
+	alice, _ := connect(t, hub, "p1")
+	bob, _ := connect(t, hub, "p1")
+	presence := awarenessMessage(42, 3, `{"user":{"name":"Alice"}}`)
+
+	// Act
+	alice.conn.send(presence)
+
+	// Assert
+	assert.Equal(t, presence, bob.conn.receive(t))
+	assert.Equal(t, presence, alice.conn.receive(t), "the sender hears its own renewal")
+
+	carol, _ := connect(t, hub, "p1")
+	assert.Equal(t, presence, carol.conn.receive(t), "a joining peer gets the others' presence")
+
+	carol.conn.send([]byte{messageQueryAwareness})
+	assert.Equal(t, presence, carol.conn.receive(t))
+
+	alice.disconnect(t)
+	removal := awarenessRemoval([]awarenessEntry{{clientID: 42, clock: 3}})
+	assert.Equal(t, removal, bob.conn.receive(t))
+	assert.Equal(t, removal, carol.conn.receive(t))
+	entries, err := parseAwareness(removal[2:])
+	require.NoError(t, err)
+	assert.Equal(t, []awarenessEntry{{clientID: 42, clock: 4}}, entries)
+
+	bob.disconnect(t)
+	carol.disconnect(t)
+}
+
+func TestHub_Serve_DisconnectsSlowPeers(t *testing.T) {
+	// Arrange
+	observer := &countingObserver{}
+	hub := NewHub(newMemStore(), Config{SendBuffer: 1}, observer)
+	alice, _ := connect(t, hub, "p1")
+	// bob never reads: its writes block once the backlog is written
+	bob := &session{conn: newFakeConn(2), done: make(chan error, 1)}
+	go func() { bob.done <- hub.Serve(context.Background(), "p1", bob.conn) }()
+	require.Eventually(t, func() bool { return len(bob.conn.out) == 2 }, waitTimeout, 10*time.Millisecond)
+
+	// Act
+	for i := byte(0); i < 3; i++ {
+		alice.conn.send(syncMessage(syncUpdate, []byte{i}))
+	}
+
+	// Assert
+	bob.conn.waitClosed(t)
+	assert.Equal(t, 1, observer.slow())
+
+	alice.disconnect(t)
+	select {
+	case <-bob.done:
+	case <-time.After(waitTimeout):
+		t.Fatal("Serve did not return")
+	}
+}
+
+func TestHub_Serve_DropsMalformedPeers(t *testing.T) {
+	// Arrange
+	hub := NewHub(newMemStore(), Config{}, nil)
+	alice, _ := connect(t, hub, "p1")
+
+	// Act
+	alice.conn.send([]byte{messageSync, syncUpdate, 0x05, 1})
+
+	// Assert
+	alice.conn.waitClosed(t)
+	select {
+	case err := <-alice.done:
+		assert.NoError(t, err)
+	case <-time.After(waitTimeout):
+		t.Fatal("Serve did not return")
+	}
+}
+
+func TestHub_Serve_LoadFailure(t *testing.T) {
+	// Arrange
+	store := newMemStore()
+	store.loadErr = errors.New("connection refused")
+	hub := NewHub(store, Config{}, nil)
+	conn := newFakeConn(1)
+
+	// Act
+	err := hub.Serve(context.Background(), "p1", conn)
+
+	// Assert
+	assert.ErrorContains(t, err, "connection refused")
+	conn.waitClosed(t)
+	assert.Zero(t, hub.Rooms(), "the next peer retries the load")
+}
+
+func TestHub_Sweep(t *testing.T) {
+	// Arrange
+	store := newMemStore()
+	hub := NewHub(store, Config{IdleTimeout: time.Minute}, nil)
+	alice, _ := connect(t, hub, "p1")
+	bob, _ := connect(t, hub, "p2")
+	alice.conn.send(syncMessage(syncUpdate, []byte{1}))
+	alice.disconnect(t)
+	bob.conn.send(syncMessage(syncUpdate, []byte{2}))
+	bob.conn.send(syncMessage(syncStep1, emptyStateVector))
+	bob.conn.receive(t)
+
+	// Act
+	hub.sweep(context.Background(), time.Now())
+	kept := hub.Rooms()
+	hub.sweep(context.Background(), time.Now().Add(time.Minute))
+
+	// Assert
+	assert.Equal(t, 2, kept, "p1 was empty for less than the idle timeout")
+	assert.Equal(t, 1, hub.Rooms(), "p2 has a peer")
+	assert.Equal(t, [][]byte{{2}}, store.saved(t, "p2"), "changed rooms are saved")
+
+	bob.disconnect(t)
+}
+
+func TestHub_Run_DisconnectsAndSavesOnStop(t *testing.T) {
+	// Arrange
+	store := newMemStore()
+	hub := NewHub(store, Config{}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- hub.Run(ctx) }()
+	alice, _ := connect(t, hub, "p1")
+	alice.conn.send(syncMessage(syncUpdate, []byte{1}))
+	alice.conn.send(syncMessage(syncStep1, emptyStateVector))
+	alice.conn.receive(t)
+
+	// Act
+	cancel()
+
+	// Assert
+	select {
+	case err := <-stopped:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(waitTimeout):
+		t.Fatal("Run did not return")
+	}
+	alice.conn.waitClosed(t)
+	assert.Equal(t, [][]byte{{1}}, store.saved(t, "p1"))
+	assert.ErrorIs(t, hub.Serve(context.Background(), "p1", newFakeConn(1)), ErrClosed)
+}
@@ -0,0 +1,85 @@
+package collab
+
+import "sync"
+
+// peer is one connection in a room
+type peer struct {
+	conn Transport
+	// send buffers messages for the write loop; a peer that lets it fill
+	// up is disconnected rather than slowing down the room
+	send chan []byte
+	done chan struct{}
+
+	closeOnce sync.Once
+
+	// backlog is the document and presence sent before anything in send
+	backlog [][]byte
+
+	// The fields below are guarded by the room's mutex.
+
+	// snapshotFrom is how many of the room's updates the peer was sent
+	// before the room's sync step 1, or -1 once that no longer applies
+	snapshotFrom int
+	// clients maps the awareness client IDs the peer announced to their
+	// last clock
+	clients map[uint64]uint64
+	// awareness is the peer's last awareness message, sent to peers that
+	// join or ask
+	awareness []byte
+}
+
+func newPeer(conn Transport, buffer int) *peer {
+	return &peer{
+		conn:         conn,
+		send:         make(chan []byte, buffer),
+		done:         make(chan struct{}),
+		snapshotFrom: -1,
+	}
+}
+
+// enqueue queues message without blocking. It disconnects the peer and
+// returns false if the buffer is full.
+func (p *peer) enqueue(message []byte) bool {
+	select {
+	case <-p.done:
+		return true
+	case p.send <- message:
+		return true
+	default:
+		p.close()
+		return false
+	}
+}
+
+// writeLoop writes the backlog, then queued messages until the peer is
+// closed or a write fails
+func (p *peer) writeLoop() {
+	for _, message := range p.backlog {
+		if err := p.conn.Write(message); err != nil {
+			p.close()
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case message := <-p.send:
+			if err := p.conn.Write(message); err != nil {
+				p.close()
+				return
+			}
+		}
+	}
+}
+
+// close disconnects the peer, which ends its read loop in Hub.Serve. The
+// connection is closed in the background: closing may wait for a write in
+// progress, and close is called with the room's mutex held.
+func (p *peer) close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+		go p.conn.Close()
+	})
+}
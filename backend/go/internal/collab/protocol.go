@@ -0,0 +1,179 @@
+package collab
+
+import (
+	"errors"
+	"fmt"
+)
+
+// y-websocket message types, the first varuint of every message
+const (
+	messageSync           = 0
+	messageAwareness      = 1
+	messageAuth           = 2
+	messageQueryAwareness = 3
+)
+
+// Sync message types, the varuint following messageSync
+const (
+	syncStep1  = 0
+	syncStep2  = 1
+	syncUpdate = 2
+)
+
+// stateFormat is the version byte leading a persisted document state
+const stateFormat = 1
+
+// errMalformed is returned for a message that does not follow the framing
+var errMalformed = errors.New("malformed collaboration message")
+
+// emptyUpdate is a Yjs update with no structs and an empty delete set
+var emptyUpdate = []byte{0, 0}
+
+// emptyStateVector is a Yjs state vector with no clients: it asks a peer
+// for its whole document
+var emptyStateVector = []byte{0}
+
+// decoder reads the lib0 encoding used by the y-protocols
+type decoder struct {
+	buf []byte
+	pos int
+}
+
+// uint reads a variable-length unsigned integer
+func (d *decoder) uint() (uint64, error) {
+	var n uint64
+	for shift := 0; shift < 64; shift += 7 {
+		if d.pos >= len(d.buf) {
+			return 0, errMalformed
+		}
+		b := d.buf[d.pos]
+		d.pos++
+		n |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return n, nil
+		}
+	}
+	return 0, errMalformed
+}
+
+// bytes reads a length-prefixed byte array
+func (d *decoder) bytes() ([]byte, error) {
+	n, err := d.uint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.buf)-d.pos) {
+		return nil, errMalformed
+	}
+	b := d.buf[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+func (d *decoder) done() bool {
+	return d.pos == len(d.buf)
+}
+
+func appendUint(buf []byte, n uint64) []byte {
+	for n >= 0x80 {
+		buf = append(buf, byte(n)|0x80)
+		n >>= 7
+	}
+	return append(buf, byte(n))
+}
+
+func appendBytes(buf, b []byte) []byte {
+	buf = appendUint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func appendString(buf []byte, s string) []byte {
+	return appendBytes(buf, []byte(s))
+}
+
+// syncMessage encodes a sync message of the given type
+func syncMessage(syncType uint64, payload []byte) []byte {
+	buf := make([]byte, 0, len(payload)+8)
+	buf = appendUint(buf, messageSync)
+	buf = appendUint(buf, syncType)
+	return appendBytes(buf, payload)
+}
+
+// awarenessEntry is one client's state in an awareness update
+type awarenessEntry struct {
+	clientID uint64
+	clock    uint64
+}
+
+// parseAwareness reads the client IDs and clocks of an awareness update;
+// the states themselves are JSON the server relays untouched
+func parseAwareness(update []byte) ([]awarenessEntry, error) {
+	d := decoder{buf: update}
+	n, err := d.uint()
+	if err != nil {
+		return nil, err
+	}
+	var entries []awarenessEntry
+	for i := uint64(0); i < n; i++ {
+		clientID, err := d.uint()
+		if err != nil {
+			return nil, err
+		}
+		clock, err := d.uint()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := d.bytes(); err != nil {
+			return nil, err
+		}
+		entries = append(entries, awarenessEntry{clientID: clientID, clock: clock})
+	}
+	return entries, nil
+}
+
+// awarenessRemoval encodes an awareness message marking the clients as
+// gone, as a client does when it disconnects cleanly
+func awarenessRemoval(entries []awarenessEntry) []byte {
+	update := appendUint(nil, uint64(len(entries)))
+	for _, entry := range entries {
+		update = appendUint(update, entry.clientID)
+		update = appendUint(update, entry.clock+1)
+		update = appendString(update, "null")
+	}
+	buf := appendUint(nil, messageAwareness)
+	return appendBytes(buf, update)
+}
+
+// encodeState encodes a document as the list of updates it was built from
+func encodeState(updates [][]byte) []byte {
+	size := 1
+	for _, update := range updates {
+		size += len(update) + 5
+	}
+	buf := make([]byte, 0, size)
+	buf = append(buf, stateFormat)
+	for _, update := range updates {
+		buf = appendBytes(buf, update)
+	}
+	return buf
+}
+
+// decodeState decodes a persisted document; nil decodes as empty
+func decodeState(state []byte) ([][]byte, error) {
+	if len(state) == 0 {
+		return nil, nil
+	}
+	if state[0] != stateFormat {
+		return nil, fmt.Errorf("unknown collaboration state format %d", state[0])
+	}
+	d := decoder{buf: state, pos: 1}
+	var updates [][]byte
+	for !d.done() {
+		update, err := d.bytes()
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
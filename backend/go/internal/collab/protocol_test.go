@@ -0,0 +1,64 @@
+package collab
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder_Uint(t *testing.T) {
+	tests := []struct {
+		name     string
+		buf      []byte
+		expected uint64
+		wantErr  bool
+	}{
+		{name: "one byte", buf: []byte{0x7f}, expected: 127},
+		{name: "two bytes", buf: []byte{0x80, 0x01}, expected: 128},
+		{name: "client ID", buf: appendUint(nil, 2903402817), expected: 2903402817},
+		{name: "truncated", buf: []byte{0x80}, wantErr: true},
+		{name: "too long", buf: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := decoder{buf: tt.buf}
+			n, err := d.uint()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, errMalformed)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, n)
+			assert.True(t, d.done())
+		})
+	}
+}
+
+func TestDecoder_Bytes_RejectsLengthsPastTheEnd(t *testing.T) {
+	d := decoder{buf: []byte{3, 1, 2}}
+	_, err := d.bytes()
+	assert.ErrorIs(t, err, errMalformed)
+}
+
+func TestState_RoundTrip(t *testing.T) {
+	updates := [][]byte{{1, 2, 3}, make([]byte, 300), {}}
+
+	decoded, err := decodeState(encodeState(updates))
+
+	require.NoError(t, err)
+	assert.Equal(t, updates, decoded)
+}
+
+func TestDecodeState(t *testing.T) {
+	updates, err := decodeState(nil)
+	require.NoError(t, err)
+	assert.Empty(t, updates, "a project without saved state is empty")
+
+	_, err = decodeState([]byte{2, 0})
+	assert.ErrorContains(t, err, "unknown collaboration state format 2")
+
+	_, err = decodeState([]byte{stateFormat, 5, 1})
+	assert.ErrorIs(t, err, errMalformed)
+}
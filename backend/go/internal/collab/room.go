@@ -0,0 +1,296 @@
+package collab
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// Message kinds reported to the Observer
+const (
+	kindUpdate         = "update"
+	kindSync           = "sync"
+	kindAwareness      = "awareness"
+	kindQueryAwareness = "query_awareness"
+	kindOther          = "other"
+	// kindSlowPeer is a peer disconnected for a full send buffer
+	kindSlowPeer = "slow_peer"
+)
+
+// room is the editing session of one project
+type room struct {
+	projectID string
+	observer  Observer
+
+	loadOnce sync.Once
+	loadErr  error
+
+	// persistMu serializes saves, so an older state never overwrites a
+	// newer one
+	persistMu sync.Mutex
+
+	mu sync.Mutex
+	// updates is the document, as the updates that built it
+	updates [][]byte
+	// version counts changes to updates; saved is the version last saved
+	version uint64
+	saved   uint64
+	peers   map[*peer]struct{}
+	// emptySince is when the last peer left
+	emptySince time.Time
+
+	// pins counts the peers joining or in the room; guarded by the hub's
+	// mutex
+	pins int
+}
+
+func newRoom(projectID string, observer Observer) *room {
+	return &room{
+		projectID: projectID,
+		observer:  observer,
+		peers:     make(map[*peer]struct{}),
+	}
+}
+
+// load reads the saved document the first time it is called
+func (r *room) load(ctx context.Context, store core.CollabStore) error {
+	r.loadOnce.Do(func() {
+		state, err := store.Load(ctx, r.projectID)
+		if err != nil {
+			r.loadErr = fmt.Errorf("failed to load collaboration document: %w", err)
+			return
+		}
+		updates, err := decodeState(state)
+		if err != nil {
+			r.loadErr = err
+			return
+		}
+		r.mu.Lock()
+		r.updates = updates
+		r.mu.Unlock()
+	})
+	return r.loadErr
+}
+
+// add sends the document to a new peer, then asks for the peer's own
+// document with sync step 1. The peer answers with sync step 2 after
+// applying everything it was sent, so its answer covers the document.
+func (r *room) add(p *peer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	backlog := make([][]byte, 0, len(r.updates)+len(r.peers)+1)
+	if len(r.updates) == 0 {
+		backlog = append(backlog, syncMessage(syncStep2, emptyUpdate))
+	}
+	for _, update := range r.updates {
+		backlog = append(backlog, syncMessage(syncStep2, update))
+	}
+	backlog = append(backlog, syncMessage(syncStep1, emptyStateVector))
+	for other := range r.peers {
+		if other.awareness != nil {
+			backlog = append(backlog, other.awareness)
+		}
+	}
+
+	p.backlog = backlog
+	p.snapshotFrom = len(r.updates)
+	r.peers[p] = struct{}{}
+}
+
+// remove takes the peer out of the room, tells the others its clients are
+// gone and reports whether the room is now empty
+func (r *room) remove(p *peer, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.peers, p)
+	if len(p.clients) > 0 {
+		entries := make([]awarenessEntry, 0, len(p.clients))
+		for clientID, clock := range p.clients {
+			entries = append(entries, awarenessEntry{clientID: clientID, clock: clock})
+		}
+		r.broadcastLocked(awarenessRemoval(entries), nil)
+	}
+
+	if len(r.peers) > 0 {
+		return false
+	}
+	r.emptySince = now
+	return true
+}
+
+// handle processes one message from p
+func (r *room) handle(p *peer, message []byte) error {
+	d := decoder{buf: message}
+	messageType, err := d.uint()
+	if err != nil {
+		return err
+	}
+
+	switch messageType {
+	case messageSync:
+		syncType, err := d.uint()
+		if err != nil {
+			return err
+		}
+		payload, err := d.bytes()
+		if err != nil {
+			return err
+		}
+		switch syncType {
+		case syncStep1:
+			// Everything the room has was sent when the peer joined or
+			// broadcast since, so an empty step 2 completes its sync
+			r.observe(kindSync)
+			p.enqueue(syncMessage(syncStep2, emptyUpdate))
+		case syncStep2, syncUpdate:
+			r.observe(kindUpdate)
+			r.apply(p, payload, syncType == syncStep2)
+		default:
+			return errMalformed
+		}
+
+	case messageAwareness:
+		update, err := d.bytes()
+		if err != nil {
+			return err
+		}
+		entries, err := parseAwareness(update)
+		if err != nil {
+			return err
+		}
+		r.observe(kindAwareness)
+		r.relayAwareness(p, entries, message)
+
+	case messageQueryAwareness:
+		r.observe(kindQueryAwareness)
+		r.mu.Lock()
+		for other := range r.peers {
+			if other != p && other.awareness != nil {
+				p.enqueue(other.awareness)
+			}
+		}
+		r.mu.Unlock()
+
+	default:
+		// Auth and unknown messages are ignored, like y-websocket does
+		r.observe(kindOther)
+	}
+	return nil
+}
+
+// apply adds an update from p to the document and sends it to the other
+// peers. The peer's first sync step 2 answers the room's sync step 1: it
+// holds the whole document the peer had been sent, so it replaces those
+// updates.
+func (r *room) apply(p *peer, update []byte, step2 bool) {
+	if len(update) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if step2 && p.snapshotFrom >= 0 && p.snapshotFrom <= len(r.updates) {
+		rest := r.updates[p.snapshotFrom:]
+		updates := make([][]byte, 0, len(rest)+1)
+		r.updates = append(append(updates, update), rest...)
+		// Other peers' snapshots were taken against the old list
+		for other := range r.peers {
+			other.snapshotFrom = -1
+		}
+	} else {
+		r.updates = append(r.updates, update)
+	}
+	p.snapshotFrom = -1
+	r.version++
+
+	r.broadcastLocked(syncMessage(syncUpdate, update), p)
+}
+
+// relayAwareness records the peer's clients and sends the message to every
+// peer, the sender included: y-websocket clients reconnect when they hear
+// nothing for 30 seconds, and their own renewals keep a lone client alive
+func (r *room) relayAwareness(p *peer, entries []awarenessEntry, message []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p.clients == nil {
+		p.clients = make(map[uint64]uint64)
+	}
+	for _, entry := range entries {
+		p.clients[entry.clientID] = entry.clock
+	}
+	p.awareness = message
+
+	r.broadcastLocked(message, nil)
+}
+
+// broadcastLocked queues message for every peer but except. Peers whose
+// buffer is full are disconnected. The caller holds r.mu.
+func (r *room) broadcastLocked(message []byte, except *peer) {
+	for p := range r.peers {
+		if p == except {
+			continue
+		}
+		if !p.enqueue(message) {
+			r.observe(kindSlowPeer)
+		}
+	}
+}
+
+// disconnectAll closes every peer's connection
+func (r *room) disconnectAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for p := range r.peers {
+		p.close()
+	}
+}
+
+// idle reports whether the room has been empty and saved since before
+// now minus timeout
+func (r *room) idle(now time.Time, timeout time.Duration) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.peers) == 0 && r.version == r.saved && now.Sub(r.emptySince) >= timeout
+}
+
+// persist saves the document if it changed since the last save
+func (r *room) persist(ctx context.Context, store core.CollabStore) error {
+	r.persistMu.Lock()
+	defer r.persistMu.Unlock()
+
+	r.mu.Lock()
+	if r.version == r.saved {
+		r.mu.Unlock()
+		return nil
+	}
+	version := r.version
+	state := encodeState(r.updates)
+	r.mu.Unlock()
+
+	if err := store.Save(ctx, r.projectID, state); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.saved = version
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *room) observe(kind string) {
+	if r.observer == nil {
+		return
+	}
+	if kind == kindSlowPeer {
+		r.observer.ObserveSlowPeer()
+		return
+	}
+	r.observer.ObserveMessage(kind)
+}
@@ -2,96 +2,377 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/provemyself/backend/internal/collab"
+	"github.com/provemyself/backend/internal/email"
+	"github.com/provemyself/backend/internal/http/streaming"
+	"github.com/provemyself/backend/internal/jobs"
+	"github.com/provemyself/backend/internal/logging"
+	"github.com/provemyself/backend/internal/lti"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/webhook"
 )
 
 type Config struct {
+	// ConfigFile is the file of KEY=VALUE lines read on top of the
+	// environment, which the Watcher polls every ConfigPollInterval; 0
+	// leaves reloads to SIGHUP
+	ConfigFile         string
+	ConfigPollInterval time.Duration
+
 	// Application
 	Environment string
 	Port        string
 	LogLevel    string
+	// LogSampling keeps every nth request log line; 0 or 1 logs all of them
+	LogSampling int
 
 	// Database
+	// DatabaseURL is a Postgres URL, or sqlite://path for a SQLite file
 	DatabaseURL string
+	// DatabaseReplicaURL, if set, is a read replica of DatabaseURL serving
+	// listings, search and aggregations
+	DatabaseReplicaURL string
+	// DBSlowQueryThreshold is the duration at which statements are logged
+	// as slow; 0 disables the log
+	DBSlowQueryThreshold time.Duration
+	DBMaxOpenConns       int
+	DBMaxIdleConns       int
+	DBConnMaxLifetime    time.Duration
+	DBConnMaxIdleTime    time.Duration
+	// DBConnectTimeout bounds how long startup retries connecting
+	DBConnectTimeout time.Duration
+	// MigrateOnStartup applies pending migrations when the API starts. With
+	// it off, migrations are run explicitly with cmd/migrate.
+	MigrateOnStartup bool
+	// DBWriteLegacyTags also writes project tags to the JSONB column
+	// migration 10 replaces (see store.DatabaseConfig.WriteLegacyTags)
+	DBWriteLegacyTags bool
 
 	// Storage
 	StorageType string
 	StoragePath string
 	S3Bucket    string
 	S3Region    string
+	// AssetURLSecret signs the expiring URLs assets are downloaded with,
+	// and production requires it; without it a secret is generated at each
+	// start. Signed URLs last at most AssetURLMaxTTL.
+	AssetURLSecret string
+	AssetURLMaxTTL time.Duration
 
 	// xAPI
 	LRSEndpoint  string
 	LRSAuthToken string
 
-	// Real-time Collaboration
-	YjsProviderURL string
+	// Real-time Collaboration, relayed by the collab WebSocket
+	YjsProviderURL        string
+	CollabPersistInterval time.Duration
+	CollabIdleTimeout     time.Duration
+	CollabSendBuffer      int
+	CollabReadTimeout     time.Duration
+	CollabWriteTimeout    time.Duration
+	CollabMaxMessageBytes int
 
 	// Security
 	JWTSecret   string
 	CORSOrigins []string
 
-	// Email
+	// Email. Without SMTPHost, emails are written to EmailOutboxDir as .eml
+	// files instead of being sent.
 	SMTPHost     string
 	SMTPPort     int
 	SMTPUser     string
 	SMTPPassword string
+	SMTPTimeout  time.Duration
 	FromEmail    string
+	// EmailOutboxDir must not be inside StoragePath, which is served
+	EmailOutboxDir    string
+	EmailQueueSize    int
+	EmailMaxAttempts  int
+	EmailRetryBackoff time.Duration
 
 	// Feature Flags
 	EnableCollaboration  bool
 	EnableAnalytics      bool
 	EnableLTIIntegration bool
 
-	// Rate Limiting
+	// LTI 1.3 tool. LTIToolURL is the API's public base URL, which the
+	// platforms are registered with; launched participants are sent to
+	// LTIPlayerURL followed by the project ID. Without LTIPrivateKeyFile,
+	// a signing key is generated at each start.
+	LTIToolURL        string
+	LTIPlayerURL      string
+	LTIPrivateKeyFile string
+	LTISessionTTL     time.Duration
+
+	// Webhooks. Deliveries are attempted by WebhookWorkers workers, retried
+	// WebhookMaxRetries times with backoff doubling from
+	// WebhookRetryBackoff, and kept for WebhookDeliveryRetention. A webhook
+	// is disabled after WebhookDisableAfter deliveries in a row failed.
+	WebhookWorkers           int
+	WebhookQueueSize         int
+	WebhookTimeout           time.Duration
+	WebhookMaxRetries        int
+	WebhookRetryBackoff      time.Duration
+	WebhookDisableAfter      int
+	WebhookDeliveryRetention time.Duration
+	// WebhookAllowPrivateNetworks lets webhooks target loopback and
+	// private addresses, for local development
+	WebhookAllowPrivateNetworks bool
+
+	// Rate Limiting, per client IP: RateLimitRequests per RateLimitWindow
+	// seconds. 0 requests turns it off.
 	RateLimitRequests int
 	RateLimitWindow   int
 
+	// SettingsPollInterval is how often each replica reads the runtime
+	// setting overrides (see Dynamic)
+	SettingsPollInterval time.Duration
+
 	// File Upload
 	MaxFileSize      int64
 	AllowedFileTypes []string
+
+	// Request Body Limits
+	MaxRequestBodyBytes     int64
+	MaxBulkRequestBodyBytes int64
+	// MaxImportBodyBytes bounds an imported package, which is held in
+	// memory while it is read
+	MaxImportBodyBytes int64
+
+	// Request timeouts, applied per route group
+	TimeoutDefault time.Duration
+	TimeoutBulk    time.Duration
+	TimeoutUpload  time.Duration
+
+	// Streaming routes (server-sent events, exports) are exempt from the
+	// server's write timeout; each write gets StreamWriteTimeout instead
+	StreamWriteTimeout time.Duration
+	StreamKeepAlive    time.Duration
+
+	// Health checks
+	HealthCacheTTL time.Duration
+
+	// Response cache for anonymous reads of published projects
+	ResponseCacheEnabled    bool
+	ResponseCacheMaxEntries int
+	ResponseCacheTTL        time.Duration
+
+	// Maintenance mode. MaintenanceMode forces it on; otherwise it is
+	// toggled at runtime through the admin API.
+	MaintenanceMode         bool
+	MaintenanceMessage      string
+	MaintenanceAllowReads   bool
+	MaintenancePollInterval time.Duration
+	MaintenanceRetryAfter   time.Duration
+
+	// Background jobs. Schedules take "@every <duration>", a descriptor
+	// such as "@hourly" or a five-field cron expression (UTC).
+	JobTimeout               time.Duration
+	IdempotencyPurgeSchedule string
+	// DeletedItemRetention is how long deleted items can be restored
+	// before an hourly job purges them
+	DeletedItemRetention time.Duration
+	// PreviewAttemptRetention is how long authors' previews are kept before
+	// an hourly job purges them
+	PreviewAttemptRetention time.Duration
+	// AttemptRetentionDays is how many days learners' attempts are kept
+	// before a job on AttemptRetentionSchedule erases them; 0 keeps them
+	// for good. AttemptRetentionMode is "delete" to delete them with their
+	// answers and results, or "anonymize" to keep their scores for the
+	// projects' stats but strip who took them.
+	AttemptRetentionDays     int
+	AttemptRetentionMode     string
+	AttemptRetentionSchedule string
+
+	// Item history. Every update or delete of an item keeps the item as it
+	// was; each item keeps its newest ItemRevisionLimit revisions.
+	ItemRevisionLimit int
+
+	// Circuit breakers around external dependencies
+	BreakerFailureThreshold int
+	BreakerCoolDown         time.Duration
+
+	// Shutdown
+	ShutdownDrainDelay time.Duration
+	ShutdownTimeout    time.Duration
+	WorkerStopTimeout  time.Duration
+
+	// Response compression
+	EnableCompression bool
+	CompressionLevel  int
+
+	// API reference (/openapi.json, /docs)
+	EnableAPIDocs bool
+
+	// Debug endpoints (pprof, expvar)
+	EnableDebugEndpoints bool
+	DebugPort            string
+	DebugAllowedIPs      []string
+
+	// Tracing
+	OTLPEndpoint       string
+	TracingServiceName string
+	TracingSampleRatio float64
 }
 
+// Load reads the configuration from the environment and, if CONFIG_FILE
+// names one, from a file of KEY=VALUE lines whose values take precedence
+// over the environment's. Settings in the file can be changed without a
+// restart (see Watcher).
 func Load() (*Config, error) {
-	cfg := &Config{
-		Environment: getEnv("ENVIRONMENT", "development"),
-		Port:        getEnv("PORT", "8080"),
-		LogLevel:    getEnv("LOG_LEVEL", "info"),
-
-		DatabaseURL: getEnv("DATABASE_URL", ""),
-
-		StorageType: getEnv("STORAGE_TYPE", "local"),
-		StoragePath: getEnv("STORAGE_PATH", "./storage"),
-		S3Bucket:    getEnv("S3_BUCKET", ""),
-		S3Region:    getEnv("S3_REGION", ""),
-
-		LRSEndpoint:  getEnv("LRS_ENDPOINT", ""),
-		LRSAuthToken: getEnv("LRS_AUTH_TOKEN", ""),
-
-		YjsProviderURL: getEnv("YLOG_PROVIDER_URL", ""),
-
-		JWTSecret:   getEnv("JWT_SECRET", ""),
-		CORSOrigins: strings.Split(getEnv("CORS_ORIGINS", "http://localhost:3000,http://localhost:3001"), ","),
-
-		SMTPHost:     getEnv("SMTP_HOST", ""),
-		SMTPPort:     getEnvInt("SMTP_PORT", 587),
-		SMTPUser:     getEnv("SMTP_USER", ""),
-		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
-		FromEmail:    getEnv("FROM_EMAIL", "noreply@provemyself.com"),
-
-		EnableCollaboration:  getEnvBool("ENABLE_COLLABORATION", true),
-		EnableAnalytics:      getEnvBool("ENABLE_ANALYTICS", true),
-		EnableLTIIntegration: getEnvBool("ENABLE_LTI_INTEGRATION", false),
-
-		RateLimitRequests: getEnvInt("RATE_LIMIT_REQUESTS", 100),
-		RateLimitWindow:   getEnvInt("RATE_LIMIT_WINDOW", 60),
+	src := source{}
+	configFile := os.Getenv("CONFIG_FILE")
+	if configFile != "" {
+		values, err := readConfigFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+		src.file = values
+	}
 
-		MaxFileSize:      int64(getEnvInt("MAX_FILE_SIZE", 10485760)), // 10MB default
-		AllowedFileTypes: strings.Split(getEnv("ALLOWED_FILE_TYPES", "image/jpeg,image/png,image/gif,image/webp"), ","),
+	cfg := &Config{
+		ConfigFile:         configFile,
+		ConfigPollInterval: src.getEnvDuration("CONFIG_POLL_INTERVAL", 30*time.Second),
+
+		Environment: src.getEnv("ENVIRONMENT", "development"),
+		Port:        src.getEnv("PORT", "8080"),
+		LogLevel:    src.getEnv("LOG_LEVEL", "info"),
+		LogSampling: src.getEnvInt("LOG_SAMPLING", 0),
+
+		DatabaseURL:          src.getEnv("DATABASE_URL", ""),
+		DatabaseReplicaURL:   src.getEnv("DATABASE_REPLICA_URL", ""),
+		DBSlowQueryThreshold: src.getEnvDuration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		DBMaxOpenConns:       src.getEnvInt("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:       src.getEnvInt("DB_MAX_IDLE_CONNS", 5),
+		DBConnMaxLifetime:    src.getEnvDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+		DBConnMaxIdleTime:    src.getEnvDuration("DB_CONN_MAX_IDLE_TIME", time.Minute),
+		DBConnectTimeout:     src.getEnvDuration("DB_CONNECT_TIMEOUT", 30*time.Second),
+		MigrateOnStartup:     src.getEnvBool("MIGRATE_ON_STARTUP", true),
+		DBWriteLegacyTags:    src.getEnvBool("DB_WRITE_LEGACY_TAGS", true),
+
+		StorageType: src.getEnv("STORAGE_TYPE", "local"),
+		StoragePath: src.getEnv("STORAGE_PATH", "./storage"),
+		S3Bucket:    src.getEnv("S3_BUCKET", ""),
+		S3Region:    src.getEnv("S3_REGION", ""),
+
+		AssetURLSecret: src.getEnv("ASSET_URL_SECRET", ""),
+		AssetURLMaxTTL: src.getEnvDuration("ASSET_URL_MAX_TTL", 24*time.Hour),
+
+		LRSEndpoint:  src.getEnv("LRS_ENDPOINT", ""),
+		LRSAuthToken: src.getEnv("LRS_AUTH_TOKEN", ""),
+
+		YjsProviderURL:        src.getEnv("YLOG_PROVIDER_URL", ""),
+		CollabPersistInterval: src.getEnvDuration("COLLAB_PERSIST_INTERVAL", collab.DefaultPersistInterval),
+		CollabIdleTimeout:     src.getEnvDuration("COLLAB_ROOM_IDLE_TIMEOUT", collab.DefaultIdleTimeout),
+		CollabSendBuffer:      src.getEnvInt("COLLAB_SEND_BUFFER", collab.DefaultSendBuffer),
+		CollabReadTimeout:     src.getEnvDuration("COLLAB_READ_TIMEOUT", collab.DefaultReadTimeout),
+		CollabWriteTimeout:    src.getEnvDuration("COLLAB_WRITE_TIMEOUT", 10*time.Second),
+		CollabMaxMessageBytes: src.getEnvInt("COLLAB_MAX_MESSAGE_BYTES", 10485760), // 10MB default
+
+		JWTSecret:   src.getEnv("JWT_SECRET", ""),
+		CORSOrigins: strings.Split(src.getEnv("CORS_ORIGINS", "http://localhost:3000,http://localhost:3001"), ","),
+
+		SMTPHost:     src.getEnv("SMTP_HOST", ""),
+		SMTPPort:     src.getEnvInt("SMTP_PORT", 587),
+		SMTPUser:     src.getEnv("SMTP_USER", ""),
+		SMTPPassword: src.getEnv("SMTP_PASSWORD", ""),
+		SMTPTimeout:  src.getEnvDuration("SMTP_TIMEOUT", email.DefaultSMTPTimeout),
+		FromEmail:    src.getEnv("FROM_EMAIL", "noreply@provemyself.com"),
+
+		EmailOutboxDir:    src.getEnv("EMAIL_OUTBOX_DIR", "./outbox"),
+		EmailQueueSize:    src.getEnvInt("EMAIL_QUEUE_SIZE", 100),
+		EmailMaxAttempts:  src.getEnvInt("EMAIL_MAX_ATTEMPTS", 5),
+		EmailRetryBackoff: src.getEnvDuration("EMAIL_RETRY_BACKOFF", 2*time.Second),
+
+		EnableCollaboration:  src.getEnvBool("ENABLE_COLLABORATION", true),
+		EnableAnalytics:      src.getEnvBool("ENABLE_ANALYTICS", true),
+		EnableLTIIntegration: src.getEnvBool("ENABLE_LTI_INTEGRATION", false),
+
+		LTIToolURL:        src.getEnv("LTI_TOOL_URL", "http://localhost:8080"),
+		LTIPlayerURL:      src.getEnv("LTI_PLAYER_URL", "http://localhost:3000/play"),
+		LTIPrivateKeyFile: src.getEnv("LTI_PRIVATE_KEY_FILE", ""),
+		LTISessionTTL:     src.getEnvDuration("LTI_SESSION_TTL", lti.DefaultSessionTTL),
+
+		WebhookWorkers:              src.getEnvInt("WEBHOOK_WORKERS", 4),
+		WebhookQueueSize:            src.getEnvInt("WEBHOOK_QUEUE_SIZE", 1000),
+		WebhookTimeout:              src.getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookMaxRetries:           src.getEnvInt("WEBHOOK_MAX_RETRIES", 3),
+		WebhookRetryBackoff:         src.getEnvDuration("WEBHOOK_RETRY_BACKOFF", time.Second),
+		WebhookDisableAfter:         src.getEnvInt("WEBHOOK_DISABLE_AFTER", 20),
+		WebhookDeliveryRetention:    src.getEnvDuration("WEBHOOK_DELIVERY_RETENTION", 7*24*time.Hour),
+		WebhookAllowPrivateNetworks: src.getEnvBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+
+		RateLimitRequests: src.getEnvInt("RATE_LIMIT_REQUESTS", 100),
+		RateLimitWindow:   src.getEnvInt("RATE_LIMIT_WINDOW", 60),
+
+		SettingsPollInterval: src.getEnvDuration("SETTINGS_POLL_INTERVAL", 5*time.Second),
+
+		MaxFileSize:      int64(src.getEnvInt("MAX_FILE_SIZE", 10485760)), // 10MB default
+		AllowedFileTypes: strings.Split(src.getEnv("ALLOWED_FILE_TYPES", "image/jpeg,image/png,image/gif,image/webp"), ","),
+
+		MaxRequestBodyBytes:     int64(src.getEnvInt("MAX_REQUEST_BODY_BYTES", 1048576)),       // 1MB default
+		MaxBulkRequestBodyBytes: int64(src.getEnvInt("MAX_BULK_REQUEST_BODY_BYTES", 10485760)), // 10MB default
+		MaxImportBodyBytes:      int64(src.getEnvInt("MAX_IMPORT_BODY_BYTES", 52428800)),       // 50MB default
+
+		TimeoutDefault: src.getEnvDuration("TIMEOUT_DEFAULT", 5*time.Second),
+		TimeoutBulk:    src.getEnvDuration("TIMEOUT_BULK", 30*time.Second),
+		TimeoutUpload:  src.getEnvDuration("TIMEOUT_UPLOAD", 2*time.Minute),
+
+		StreamWriteTimeout: src.getEnvDuration("STREAM_WRITE_TIMEOUT", streaming.DefaultWriteTimeout),
+		StreamKeepAlive:    src.getEnvDuration("STREAM_KEEPALIVE_INTERVAL", streaming.DefaultKeepAlive),
+
+		HealthCacheTTL: src.getEnvDuration("HEALTH_CACHE_TTL", 2*time.Second),
+
+		ResponseCacheEnabled:    src.getEnvBool("RESPONSE_CACHE_ENABLED", false),
+		ResponseCacheMaxEntries: src.getEnvInt("RESPONSE_CACHE_MAX_ENTRIES", 1000),
+		ResponseCacheTTL:        src.getEnvDuration("RESPONSE_CACHE_TTL", 30*time.Second),
+
+		MaintenanceMode:         src.getEnvBool("MAINTENANCE_MODE", false),
+		MaintenanceMessage:      src.getEnv("MAINTENANCE_MESSAGE", ""),
+		MaintenanceAllowReads:   src.getEnvBool("MAINTENANCE_ALLOW_READS", false),
+		MaintenancePollInterval: src.getEnvDuration("MAINTENANCE_POLL_INTERVAL", 5*time.Second),
+		MaintenanceRetryAfter:   src.getEnvDuration("MAINTENANCE_RETRY_AFTER", time.Minute),
+
+		JobTimeout:               src.getEnvDuration("JOB_TIMEOUT", 10*time.Minute),
+		IdempotencyPurgeSchedule: src.getEnv("IDEMPOTENCY_PURGE_SCHEDULE", "*/15 * * * *"),
+		DeletedItemRetention:     src.getEnvDuration("DELETED_ITEM_RETENTION", 30*24*time.Hour),
+		PreviewAttemptRetention:  src.getEnvDuration("PREVIEW_ATTEMPT_RETENTION", 7*24*time.Hour),
+		AttemptRetentionDays:     src.getEnvInt("ATTEMPT_RETENTION_DAYS", 0),
+		AttemptRetentionMode:     src.getEnv("ATTEMPT_RETENTION_MODE", "delete"),
+		AttemptRetentionSchedule: src.getEnv("ATTEMPT_RETENTION_SCHEDULE", "0 3 * * *"),
+
+		ItemRevisionLimit: src.getEnvInt("ITEM_REVISION_LIMIT", 50),
+
+		BreakerFailureThreshold: src.getEnvInt("BREAKER_FAILURE_THRESHOLD", 5),
+		BreakerCoolDown:         src.getEnvDuration("BREAKER_COOL_DOWN", 30*time.Second),
+
+		ShutdownDrainDelay: src.getEnvDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+		ShutdownTimeout:    src.getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		WorkerStopTimeout:  src.getEnvDuration("WORKER_STOP_TIMEOUT", 10*time.Second),
+
+		EnableCompression: src.getEnvBool("ENABLE_COMPRESSION", true),
+		CompressionLevel:  src.getEnvInt("COMPRESSION_LEVEL", 5),
+
+		EnableDebugEndpoints: src.getEnvBool("ENABLE_DEBUG_ENDPOINTS", true),
+		DebugPort:            src.getEnv("DEBUG_PORT", "6060"),
+		DebugAllowedIPs:      src.getEnvList("DEBUG_ALLOWED_IPS"),
+
+		OTLPEndpoint:       src.getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TracingServiceName: src.getEnv("OTEL_SERVICE_NAME", "provemyself-api"),
+		TracingSampleRatio: src.getEnvFloat("OTEL_TRACES_SAMPLE_RATIO", 1.0),
 	}
 
+	// The API reference is public by default everywhere but production
+	cfg.EnableAPIDocs = src.getEnvBool("ENABLE_API_DOCS", !cfg.IsProduction())
+
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
@@ -111,6 +392,178 @@ func (c *Config) Validate() error {
 		if c.DatabaseURL == "" {
 			return errors.New("DATABASE_URL is required in production")
 		}
+		if c.EnableLTIIntegration && c.LTIPrivateKeyFile == "" {
+			return errors.New("LTI_PRIVATE_KEY_FILE is required in production when ENABLE_LTI_INTEGRATION is set")
+		}
+		if len(c.AssetURLSecret) < 32 {
+			return errors.New("ASSET_URL_SECRET of at least 32 characters is required in production to sign asset URLs")
+		}
+	}
+
+	if c.ConfigPollInterval < 0 {
+		return errors.New("CONFIG_POLL_INTERVAL cannot be negative")
+	}
+
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		return errors.New("LOG_LEVEL must be one of trace, debug, info, warn, error, fatal, panic or disabled")
+	}
+	if c.LogSampling < 0 {
+		return errors.New("LOG_SAMPLING cannot be negative")
+	}
+
+	if c.RateLimitRequests < 0 {
+		return errors.New("RATE_LIMIT_REQUESTS cannot be negative")
+	}
+	if c.RateLimitWindow < 1 {
+		return errors.New("RATE_LIMIT_WINDOW must be at least 1 second")
+	}
+	if c.SettingsPollInterval <= 0 {
+		return errors.New("SETTINGS_POLL_INTERVAL must be a positive duration")
+	}
+
+	if c.DBSlowQueryThreshold < 0 {
+		return errors.New("DB_SLOW_QUERY_THRESHOLD cannot be negative")
+	}
+	if c.DBMaxOpenConns < 1 || c.DBMaxOpenConns > 1000 {
+		return errors.New("DB_MAX_OPEN_CONNS must be between 1 and 1000")
+	}
+	if c.DBMaxIdleConns < 0 || c.DBMaxIdleConns > c.DBMaxOpenConns {
+		return errors.New("DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS")
+	}
+	if c.DBConnMaxLifetime < 0 || c.DBConnMaxIdleTime < 0 {
+		return errors.New("DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME cannot be negative")
+	}
+	if c.DBConnectTimeout <= 0 {
+		return errors.New("DB_CONNECT_TIMEOUT must be a positive duration")
+	}
+
+	if c.MaxRequestBodyBytes <= 0 {
+		return errors.New("MAX_REQUEST_BODY_BYTES must be positive")
+	}
+	if c.MaxBulkRequestBodyBytes < c.MaxRequestBodyBytes {
+		return errors.New("MAX_BULK_REQUEST_BODY_BYTES must be at least MAX_REQUEST_BODY_BYTES")
+	}
+	if c.MaxImportBodyBytes < c.MaxRequestBodyBytes {
+		return errors.New("MAX_IMPORT_BODY_BYTES must be at least MAX_REQUEST_BODY_BYTES")
+	}
+
+	if c.TimeoutDefault <= 0 || c.TimeoutBulk <= 0 || c.TimeoutUpload <= 0 {
+		return errors.New("TIMEOUT_DEFAULT, TIMEOUT_BULK and TIMEOUT_UPLOAD must be positive durations")
+	}
+	if c.StreamWriteTimeout <= 0 || c.StreamKeepAlive <= 0 {
+		return errors.New("STREAM_WRITE_TIMEOUT and STREAM_KEEPALIVE_INTERVAL must be positive durations")
+	}
+
+	if c.HealthCacheTTL < 0 {
+		return errors.New("HEALTH_CACHE_TTL cannot be negative")
+	}
+
+	if c.ResponseCacheEnabled && (c.ResponseCacheMaxEntries < 1 || c.ResponseCacheTTL <= 0) {
+		return errors.New("RESPONSE_CACHE_MAX_ENTRIES must be at least 1 and RESPONSE_CACHE_TTL a positive duration")
+	}
+
+	if c.MaintenancePollInterval <= 0 {
+		return errors.New("MAINTENANCE_POLL_INTERVAL must be a positive duration")
+	}
+	if c.MaintenanceRetryAfter < 0 {
+		return errors.New("MAINTENANCE_RETRY_AFTER cannot be negative")
+	}
+
+	if c.JobTimeout <= 0 {
+		return errors.New("JOB_TIMEOUT must be a positive duration")
+	}
+	if _, err := jobs.ParseSchedule(c.IdempotencyPurgeSchedule); err != nil {
+		return fmt.Errorf("IDEMPOTENCY_PURGE_SCHEDULE is invalid: %w", err)
+	}
+	if c.DeletedItemRetention <= 0 {
+		return errors.New("DELETED_ITEM_RETENTION must be a positive duration")
+	}
+	if c.PreviewAttemptRetention <= 0 {
+		return errors.New("PREVIEW_ATTEMPT_RETENTION must be a positive duration")
+	}
+	if c.AttemptRetentionDays < 0 {
+		return errors.New("ATTEMPT_RETENTION_DAYS cannot be negative")
+	}
+	if c.AttemptRetentionMode != "delete" && c.AttemptRetentionMode != "anonymize" {
+		return errors.New("ATTEMPT_RETENTION_MODE must be delete or anonymize")
+	}
+	if _, err := jobs.ParseSchedule(c.AttemptRetentionSchedule); err != nil {
+		return fmt.Errorf("ATTEMPT_RETENTION_SCHEDULE is invalid: %w", err)
+	}
+	if c.ItemRevisionLimit < 1 {
+		return errors.New("ITEM_REVISION_LIMIT must be at least 1")
+	}
+
+	if c.SMTPPort < 1 || c.SMTPPort > 65535 {
+		return errors.New("SMTP_PORT must be between 1 and 65535")
+	}
+	if c.SMTPTimeout <= 0 {
+		return errors.New("SMTP_TIMEOUT must be a positive duration")
+	}
+	if _, err := mail.ParseAddress(c.FromEmail); err != nil {
+		return fmt.Errorf("FROM_EMAIL is invalid: %w", err)
+	}
+	if c.EmailQueueSize < 1 || c.EmailMaxAttempts < 1 {
+		return errors.New("EMAIL_QUEUE_SIZE and EMAIL_MAX_ATTEMPTS must be at least 1")
+	}
+	if c.EmailRetryBackoff < 0 {
+		return errors.New("EMAIL_RETRY_BACKOFF cannot be negative")
+	}
+
+	if c.CollabPersistInterval <= 0 || c.CollabIdleTimeout <= 0 || c.CollabReadTimeout <= 0 || c.CollabWriteTimeout <= 0 {
+		return errors.New("COLLAB_PERSIST_INTERVAL, COLLAB_ROOM_IDLE_TIMEOUT, COLLAB_READ_TIMEOUT and COLLAB_WRITE_TIMEOUT must be positive durations")
+	}
+	if c.CollabSendBuffer < 1 || c.CollabMaxMessageBytes < 1 {
+		return errors.New("COLLAB_SEND_BUFFER and COLLAB_MAX_MESSAGE_BYTES must be at least 1")
+	}
+
+	for name, value := range map[string]string{"LTI_TOOL_URL": c.LTIToolURL, "LTI_PLAYER_URL": c.LTIPlayerURL} {
+		if u, err := url.Parse(value); err != nil || !u.IsAbs() {
+			return fmt.Errorf("%s must be an absolute URL", name)
+		}
+	}
+	if c.LTISessionTTL <= 0 {
+		return errors.New("LTI_SESSION_TTL must be a positive duration")
+	}
+
+	if c.WebhookWorkers < 1 || c.WebhookQueueSize < 1 {
+		return errors.New("WEBHOOK_WORKERS and WEBHOOK_QUEUE_SIZE must be at least 1")
+	}
+	if c.WebhookTimeout <= 0 || c.WebhookDeliveryRetention <= 0 {
+		return errors.New("WEBHOOK_TIMEOUT and WEBHOOK_DELIVERY_RETENTION must be positive durations")
+	}
+	if c.WebhookMaxRetries < 0 || c.WebhookDisableAfter < 0 || c.WebhookRetryBackoff < 0 {
+		return errors.New("WEBHOOK_MAX_RETRIES, WEBHOOK_DISABLE_AFTER and WEBHOOK_RETRY_BACKOFF cannot be negative")
+	}
+
+	if c.BreakerFailureThreshold < 1 {
+		return errors.New("BREAKER_FAILURE_THRESHOLD must be at least 1")
+	}
+	if c.BreakerCoolDown <= 0 {
+		return errors.New("BREAKER_COOL_DOWN must be a positive duration")
+	}
+
+	if c.ShutdownDrainDelay < 0 {
+		return errors.New("SHUTDOWN_DRAIN_DELAY cannot be negative")
+	}
+	if c.ShutdownTimeout <= 0 || c.WorkerStopTimeout <= 0 {
+		return errors.New("SHUTDOWN_TIMEOUT and WORKER_STOP_TIMEOUT must be positive durations")
+	}
+
+	if c.CompressionLevel < -1 || c.CompressionLevel > 9 {
+		return errors.New("COMPRESSION_LEVEL must be between -1 and 9")
+	}
+
+	if c.EnableDebugEndpoints && c.DebugPort == c.Port {
+		return errors.New("DEBUG_PORT must differ from PORT")
+	}
+
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		return errors.New("OTEL_TRACES_SAMPLE_RATIO must be between 0 and 1")
+	}
+
+	if c.AssetURLMaxTTL <= 0 {
+		return errors.New("ASSET_URL_MAX_TTL must be a positive duration")
 	}
 
 	if c.StorageType == "s3" {
@@ -125,6 +578,87 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// MaxRequestTimeout returns the longest per-route timeout, which the server's
+// write timeout must exceed for handlers to be able to answer 504 in time
+func (c *Config) MaxRequestTimeout() time.Duration {
+	longest := c.TimeoutDefault
+	for _, d := range []time.Duration{c.TimeoutBulk, c.TimeoutUpload} {
+		if d > longest {
+			longest = d
+		}
+	}
+	return longest
+}
+
+// Database returns the database connection settings, shared by the API and
+// cmd/migrate
+func (c *Config) Database() store.DatabaseConfig {
+	return store.DatabaseConfig{
+		URL:             c.DatabaseURL,
+		ReplicaURL:      c.DatabaseReplicaURL,
+		MaxOpenConns:    c.DBMaxOpenConns,
+		MaxIdleConns:    c.DBMaxIdleConns,
+		ConnMaxLifetime: c.DBConnMaxLifetime,
+		ConnMaxIdleTime: c.DBConnMaxIdleTime,
+		ConnectTimeout:  c.DBConnectTimeout,
+		WriteLegacyTags: c.DBWriteLegacyTags,
+	}
+}
+
+// SMTP returns the SMTP server settings
+func (c *Config) SMTP() email.SMTPConfig {
+	return email.SMTPConfig{
+		Host:     c.SMTPHost,
+		Port:     c.SMTPPort,
+		Username: c.SMTPUser,
+		Password: c.SMTPPassword,
+		Timeout:  c.SMTPTimeout,
+	}
+}
+
+// EmailQueue returns the settings of the background email queue
+func (c *Config) EmailQueue() email.QueueConfig {
+	return email.QueueConfig{
+		Size:        c.EmailQueueSize,
+		MaxAttempts: c.EmailMaxAttempts,
+		Backoff:     c.EmailRetryBackoff,
+	}
+}
+
+// LTI returns the settings of the LTI tool
+func (c *Config) LTI() lti.Config {
+	return lti.Config{
+		LaunchURL:  strings.TrimSuffix(c.LTIToolURL, "/") + "/lti/launch",
+		SessionTTL: c.LTISessionTTL,
+	}
+}
+
+// Webhooks returns the settings of the webhook dispatcher. Endpoint
+// breakers use the same threshold and cool-down as the storage breaker.
+func (c *Config) Webhooks() webhook.Config {
+	return webhook.Config{
+		Workers:              c.WebhookWorkers,
+		QueueSize:            c.WebhookQueueSize,
+		Timeout:              c.WebhookTimeout,
+		MaxRetries:           c.WebhookMaxRetries,
+		RetryBackoff:         c.WebhookRetryBackoff,
+		DisableAfter:         c.WebhookDisableAfter,
+		BreakerThreshold:     c.BreakerFailureThreshold,
+		BreakerCoolDown:      c.BreakerCoolDown,
+		AllowPrivateNetworks: c.WebhookAllowPrivateNetworks,
+	}
+}
+
+// Collab returns the settings of the collaboration hub
+func (c *Config) Collab() collab.Config {
+	return collab.Config{
+		PersistInterval: c.CollabPersistInterval,
+		IdleTimeout:     c.CollabIdleTimeout,
+		SendBuffer:      c.CollabSendBuffer,
+		ReadTimeout:     c.CollabReadTimeout,
+	}
+}
+
 // IsDevelopment returns true if running in development mode
 func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
@@ -140,15 +674,56 @@ func (c *Config) IsTest() bool {
 	return c.Environment == "test"
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+// source looks up configuration variables in the config file, then in the
+// environment
+type source struct {
+	file map[string]string
+}
+
+func (s source) lookup(key string) string {
+	if value, ok := s.file[key]; ok {
+		return value
+	}
+	return os.Getenv(key)
+}
+
+// readConfigFile reads a file of KEY=VALUE lines. Blank lines and lines
+// starting with # are skipped, and a value may be wrapped in quotes.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CONFIG_FILE: %w", err)
+	}
+
+	values := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(strings.TrimPrefix(key, "export "))
+		if !ok || key == "" {
+			return nil, fmt.Errorf("CONFIG_FILE line %d: expected KEY=VALUE", i+1)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+func (s source) getEnv(key, defaultValue string) string {
+	if value := s.lookup(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
-func getEnvBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
+func (s source) getEnvBool(key string, defaultValue bool) bool {
+	if value := s.lookup(key); value != "" {
 		if parsed, err := strconv.ParseBool(value); err == nil {
 			return parsed
 		}
@@ -156,11 +731,41 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+func (s source) getEnvInt(key string, defaultValue int) int {
+	if value := s.lookup(key); value != "" {
 		if parsed, err := strconv.Atoi(value); err == nil {
 			return parsed
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+func (s source) getEnvFloat(key string, defaultValue float64) float64 {
+	if value := s.lookup(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDuration parses a Go duration such as "5s" or "2m"
+func (s source) getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := s.lookup(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries
+func (s source) getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(s.lookup(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
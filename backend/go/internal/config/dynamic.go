@@ -0,0 +1,416 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/logging"
+)
+
+// Keys of the settings that can be changed at runtime. Anything not listed
+// here, secrets and connection strings in particular, can only be changed
+// in the configuration, which a Watcher reloads.
+const (
+	SettingLogLevel             = "log_level"
+	SettingRateLimitRequests    = "rate_limit_requests"
+	SettingRateLimitWindow      = "rate_limit_window"
+	SettingMaintenanceMessage   = "maintenance_message"
+	SettingEnableCollaboration  = "enable_collaboration"
+	SettingEnableAnalytics      = "enable_analytics"
+	SettingEnableLTIIntegration = "enable_lti_integration"
+)
+
+var (
+	// ErrSettingNotAdjustable is returned for a key outside the allowlist
+	ErrSettingNotAdjustable = errors.New("setting cannot be changed at runtime")
+	// ErrInvalidSetting is returned for a value the setting cannot take
+	ErrInvalidSetting = errors.New("invalid setting value")
+)
+
+// maxMaintenanceMessageLength matches the maintenance API's message limit
+const maxMaintenanceMessageLength = 500
+
+// Settings are the configuration values that can be overridden at runtime
+type Settings struct {
+	LogLevel string
+	// RateLimitRequests per RateLimitWindow seconds and client; 0
+	// turns rate limiting off
+	RateLimitRequests int
+	RateLimitWindow   int
+	// MaintenanceMessage is shown when maintenance was turned on without a
+	// message of its own
+	MaintenanceMessage string
+
+	EnableCollaboration  bool
+	EnableAnalytics      bool
+	EnableLTIIntegration bool
+}
+
+// settingField reads and writes one Settings field as text
+type settingField struct {
+	format func(s Settings) string
+	parse  func(s *Settings, value string) error
+}
+
+var settingFields = map[string]settingField{
+	SettingLogLevel: {
+		format: func(s Settings) string { return s.LogLevel },
+		parse: func(s *Settings, value string) error {
+			level, err := logging.ParseLevel(value)
+			if err != nil {
+				return err
+			}
+			s.LogLevel = level.String()
+			return nil
+		},
+	},
+	SettingRateLimitRequests: intSetting(func(s *Settings) *int { return &s.RateLimitRequests }, 0, 1000000),
+	SettingRateLimitWindow:   intSetting(func(s *Settings) *int { return &s.RateLimitWindow }, 1, 86400),
+	SettingMaintenanceMessage: {
+		format: func(s Settings) string { return s.MaintenanceMessage },
+		parse: func(s *Settings, value string) error {
+			if len(value) > maxMaintenanceMessageLength {
+				return fmt.Errorf("must be at most %d characters", maxMaintenanceMessageLength)
+			}
+			s.MaintenanceMessage = value
+			return nil
+		},
+	},
+	SettingEnableCollaboration:  boolSetting(func(s *Settings) *bool { return &s.EnableCollaboration }),
+	SettingEnableAnalytics:      boolSetting(func(s *Settings) *bool { return &s.EnableAnalytics }),
+	SettingEnableLTIIntegration: boolSetting(func(s *Settings) *bool { return &s.EnableLTIIntegration }),
+}
+
+func intSetting(field func(s *Settings) *int, min, max int) settingField {
+	return settingField{
+		format: func(s Settings) string { return strconv.Itoa(*field(&s)) },
+		parse: func(s *Settings, value string) error {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < min || parsed > max {
+				return fmt.Errorf("must be an integer between %d and %d", min, max)
+			}
+			*field(s) = parsed
+			return nil
+		},
+	}
+}
+
+func boolSetting(field func(s *Settings) *bool) settingField {
+	return settingField{
+		format: func(s Settings) string { return strconv.FormatBool(*field(&s)) },
+		parse: func(s *Settings, value string) error {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.New("must be true or false")
+			}
+			*field(s) = parsed
+			return nil
+		},
+	}
+}
+
+// ValidateSetting checks that key can be changed at runtime and, unless
+// value is nil (reset to the configured value), that it can take value
+func ValidateSetting(key string, value *string) error {
+	field, ok := settingFields[key]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSettingNotAdjustable, key)
+	}
+	if value == nil {
+		return nil
+	}
+
+	var scratch Settings
+	if err := field.parse(&scratch, *value); err != nil {
+		return fmt.Errorf("%w: %s %v", ErrInvalidSetting, key, err)
+	}
+	return nil
+}
+
+// SettingValue reports one runtime setting
+type SettingValue struct {
+	Key string
+	// Value is in effect; Default is the value from the environment
+	Value      string
+	Default    string
+	Overridden bool
+	// UpdatedAt and UpdatedBy record the last override
+	UpdatedAt time.Time
+	UpdatedBy string
+}
+
+// subscriber is notified after a change to any of keys, or to any setting
+// when keys is empty
+type subscriber struct {
+	keys []string
+	fn   func(Settings)
+}
+
+// Dynamic holds the runtime settings: the configured values with the
+// overrides from a core.SettingsStore on top. Every replica refreshes it from
+// the store on an interval (see Refresh), so a change made through one
+// replica applies everywhere within one poll interval, or as soon as it
+// commits when Dynamic is subscribed to a store.ChangeListener. Components
+// that cache a setting subscribe to its changes. It is safe for concurrent
+// use.
+type Dynamic struct {
+	store core.SettingsStore
+
+	mu sync.RWMutex
+	// base holds the configured values the overrides are applied to
+	base        Settings
+	current     Settings
+	overrides   map[string]core.Setting
+	subscribers []subscriber
+}
+
+// NewDynamic creates runtime settings starting from cfg. Overrides apply
+// from the first Refresh.
+func NewDynamic(cfg *Config, store core.SettingsStore) *Dynamic {
+	base := settingsFrom(cfg)
+	return &Dynamic{
+		store:     store,
+		base:      base,
+		current:   base,
+		overrides: make(map[string]core.Setting),
+	}
+}
+
+// settingsFrom returns the configured runtime settings
+func settingsFrom(cfg *Config) Settings {
+	base := Settings{
+		LogLevel:             cfg.LogLevel,
+		RateLimitRequests:    cfg.RateLimitRequests,
+		RateLimitWindow:      cfg.RateLimitWindow,
+		MaintenanceMessage:   cfg.MaintenanceMessage,
+		EnableCollaboration:  cfg.EnableCollaboration,
+		EnableAnalytics:      cfg.EnableAnalytics,
+		EnableLTIIntegration: cfg.EnableLTIIntegration,
+	}
+	// LOG_LEVEL was validated by Load; store it the way overrides are
+	if level, err := logging.ParseLevel(cfg.LogLevel); err == nil {
+		base.LogLevel = level.String()
+	}
+	return base
+}
+
+// DynamicFields are the Config fields Dynamic starts from, which
+// SetDefaults applies when the configuration is reloaded
+var DynamicFields = []string{
+	"LogLevel",
+	"RateLimitRequests",
+	"RateLimitWindow",
+	"MaintenanceMessage",
+	"EnableCollaboration",
+	"EnableAnalytics",
+	"EnableLTIIntegration",
+}
+
+// SetDefaults replaces the configured values with cfg's after a
+// configuration reload. Overrides stay on top, so subscribers are only
+// notified of settings that are not overridden.
+func (d *Dynamic) SetDefaults(ctx context.Context, cfg *Config) {
+	d.mu.Lock()
+	d.base = settingsFrom(cfg)
+	stored := make([]core.Setting, 0, len(d.overrides))
+	for _, override := range d.overrides {
+		stored = append(stored, override)
+	}
+	d.mu.Unlock()
+
+	d.apply(ctx, stored)
+}
+
+// Settings returns the settings in effect
+func (d *Dynamic) Settings() Settings {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.current
+}
+
+// Values returns every runtime setting, sorted by key
+func (d *Dynamic) Values() []SettingValue {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	values := make([]SettingValue, 0, len(settingFields))
+	for key, field := range settingFields {
+		value := SettingValue{
+			Key:     key,
+			Value:   field.format(d.current),
+			Default: field.format(d.base),
+		}
+		if override, ok := d.overrides[key]; ok {
+			value.Overridden = true
+			value.UpdatedAt = override.UpdatedAt
+			value.UpdatedBy = override.UpdatedBy
+		}
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Key < values[j].Key
+	})
+	return values
+}
+
+// Subscribe registers fn to be called with the new settings after a change
+// to any of keys, or to any setting when no keys are given. Subscribers run
+// in the order they subscribed, on the goroutine that applied the change.
+func (d *Dynamic) Subscribe(fn func(Settings), keys ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subscribers = append(d.subscribers, subscriber{keys: keys, fn: fn})
+}
+
+// Refresh reads the overrides from the store and applies them. Every replica
+// runs it on an interval as a background job; a failed read keeps the last
+// known settings.
+func (d *Dynamic) Refresh(ctx context.Context) error {
+	stored, err := d.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	d.apply(ctx, stored)
+	return nil
+}
+
+// ApplyChange implements core.ChangeSubscriber: overrides changed through
+// any replica are read again
+func (d *Dynamic) ApplyChange(ctx context.Context, change core.Change) error {
+	return d.Refresh(ctx)
+}
+
+// Resync implements core.ChangeSubscriber by reading the overrides again
+func (d *Dynamic) Resync(ctx context.Context) error {
+	return d.Refresh(ctx)
+}
+
+// Update validates and stores changes, nil values resetting a setting to its
+// configured value, and applies them to this replica immediately. Every
+// change is audit-logged with its before and after values.
+func (d *Dynamic) Update(ctx context.Context, changes map[string]*string, updatedBy string) error {
+	for key, value := range changes {
+		if err := ValidateSetting(key, value); err != nil {
+			return err
+		}
+	}
+
+	before := d.Settings()
+	if err := d.store.Update(ctx, changes, updatedBy); err != nil {
+		return err
+	}
+	if err := d.Refresh(ctx); err != nil {
+		// Stored, so the next poll picks it up; apply it meanwhile
+		d.applyChanges(ctx, changes, updatedBy)
+	}
+	after := d.Settings()
+
+	keys := make([]string, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field := settingFields[key]
+		// Audit lines are written whatever the log level
+		log.Ctx(ctx).Log().
+			Str("audit", "setting_changed").
+			Str("setting", key).
+			Str("before", field.format(before)).
+			Str("after", field.format(after)).
+			Bool("reset", changes[key] == nil).
+			Str("user_id", updatedBy).
+			Msg("runtime setting changed")
+	}
+	return nil
+}
+
+// applyChanges applies changes on top of the current overrides without
+// reading the store
+func (d *Dynamic) applyChanges(ctx context.Context, changes map[string]*string, updatedBy string) {
+	d.mu.RLock()
+	merged := make(map[string]core.Setting, len(d.overrides)+len(changes))
+	for key, override := range d.overrides {
+		merged[key] = override
+	}
+	d.mu.RUnlock()
+
+	now := time.Now().UTC()
+	for key, value := range changes {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = core.Setting{Key: key, Value: *value, UpdatedAt: now, UpdatedBy: updatedBy}
+	}
+
+	stored := make([]core.Setting, 0, len(merged))
+	for _, override := range merged {
+		stored = append(stored, override)
+	}
+	d.apply(ctx, stored)
+}
+
+// apply replaces the overrides with stored and notifies the subscribers of
+// the settings that changed. A stored value that no longer parses, e.g.
+// after an allowlist change, is logged and ignored.
+func (d *Dynamic) apply(ctx context.Context, stored []core.Setting) {
+	d.mu.Lock()
+	next := d.base
+	overrides := make(map[string]core.Setting, len(stored))
+	for _, override := range stored {
+		field, ok := settingFields[override.Key]
+		if !ok {
+			log.Ctx(ctx).Warn().Str("setting", override.Key).Msg("ignoring unknown runtime setting")
+			continue
+		}
+		if err := field.parse(&next, override.Value); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("setting", override.Key).Msg("ignoring invalid runtime setting")
+			continue
+		}
+		overrides[override.Key] = override
+	}
+
+	previous := d.current
+	d.current = next
+	d.overrides = overrides
+	subscribers := d.subscribers
+	d.mu.Unlock()
+
+	changed := make(map[string]bool)
+	for key, field := range settingFields {
+		if field.format(previous) != field.format(next) {
+			changed[key] = true
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	for _, sub := range subscribers {
+		if sub.interested(changed) {
+			sub.fn(next)
+		}
+	}
+}
+
+func (s subscriber) interested(changed map[string]bool) bool {
+	if len(s.keys) == 0 {
+		return true
+	}
+	for _, key := range s.keys {
+		if changed[key] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+)
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func newTestDynamic() (*Dynamic, *store.MemorySettingsStore) {
+	settingsStore := store.NewMemorySettingsStore()
+	return NewDynamic(&Config{
+		LogLevel:          "INFO",
+		RateLimitRequests: 100,
+		RateLimitWindow:   60,
+		EnableAnalytics:   true,
+	}, settingsStore), settingsStore
+}
+
+func TestDynamic_RefreshOverlaysStoredSettings(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	dynamic, settingsStore := newTestDynamic()
+	require.NoError(t, settingsStore.Update(ctx, map[string]*string{
+		SettingRateLimitRequests: stringPtr("250"),
+		SettingEnableAnalytics:   stringPtr("false"),
+	}, "admin-1"))
+
+	// Act
+	require.NoError(t, dynamic.Refresh(ctx))
+
+	// Assert
+	settings := dynamic.Settings()
+	assert.Equal(t, 250, settings.RateLimitRequests)
+	assert.False(t, settings.EnableAnalytics)
+	assert.Equal(t, 60, settings.RateLimitWindow)
+	assert.Equal(t, "info", settings.LogLevel)
+
+	values := dynamic.Values()
+	require.Len(t, values, len(settingFields))
+	for _, value := range values {
+		if value.Key == SettingRateLimitRequests {
+			assert.Equal(t, "250", value.Value)
+			assert.Equal(t, "100", value.Default)
+			assert.True(t, value.Overridden)
+			assert.Equal(t, "admin-1", value.UpdatedBy)
+		}
+		if value.Key == SettingLogLevel {
+			assert.False(t, value.Overridden)
+		}
+	}
+}
+
+func TestDynamic_SubscribersSeeOnlyTheirChanges(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	dynamic, _ := newTestDynamic()
+
+	var levels []string
+	dynamic.Subscribe(func(s Settings) {
+		levels = append(levels, s.LogLevel)
+	}, SettingLogLevel)
+	var anyChange int
+	dynamic.Subscribe(func(s Settings) {
+		anyChange++
+	})
+
+	// Act
+	require.NoError(t, dynamic.Update(ctx, map[string]*string{SettingEnableAnalytics: stringPtr("false")}, "admin-1"))
+	require.NoError(t, dynamic.Update(ctx, map[string]*string{SettingLogLevel: stringPtr("debug")}, "admin-1"))
+	require.NoError(t, dynamic.Refresh(ctx))
+	require.NoError(t, dynamic.Update(ctx, map[string]*string{SettingLogLevel: nil}, "admin-1"))
+
+	// Assert
+	assert.Equal(t, []string{"debug", "info"}, levels)
+	assert.Equal(t, 3, anyChange)
+}
+
+func TestDynamic_ApplyChangeRereadsTheStore(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	dynamic, settingsStore := newTestDynamic()
+	// Another replica stores an override
+	require.NoError(t, settingsStore.Update(ctx, map[string]*string{SettingRateLimitRequests: stringPtr("250")}, "admin-1"))
+
+	// Act
+	err := dynamic.ApplyChange(ctx, core.Change{Entity: core.ChangeEntitySettings, Action: core.ChangeActionUpdated})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 250, dynamic.Settings().RateLimitRequests)
+}
+
+func TestDynamic_SetDefaultsKeepsOverrides(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	dynamic, _ := newTestDynamic()
+	require.NoError(t, dynamic.Update(ctx, map[string]*string{SettingRateLimitRequests: stringPtr("250")}, "admin-1"))
+
+	var notified []Settings
+	dynamic.Subscribe(func(s Settings) {
+		notified = append(notified, s)
+	})
+
+	// Act
+	dynamic.SetDefaults(ctx, &Config{LogLevel: "debug", RateLimitRequests: 50, RateLimitWindow: 30, EnableAnalytics: true})
+
+	// Assert
+	settings := dynamic.Settings()
+	assert.Equal(t, 250, settings.RateLimitRequests, "overrides stay on top of the new defaults")
+	assert.Equal(t, 30, settings.RateLimitWindow)
+	assert.Equal(t, "debug", settings.LogLevel)
+	require.Len(t, notified, 1)
+	assert.Equal(t, settings, notified[0])
+
+	for _, value := range dynamic.Values() {
+		if value.Key == SettingRateLimitRequests {
+			assert.Equal(t, "50", value.Default)
+		}
+	}
+}
+
+func TestDynamic_UpdateRejectsStaticAndInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		changes  map[string]*string
+		expected error
+	}{
+		{"secret", map[string]*string{"jwt_secret": stringPtr("hunter2")}, ErrSettingNotAdjustable},
+		{"connection string", map[string]*string{"database_url": nil}, ErrSettingNotAdjustable},
+		{"bad log level", map[string]*string{SettingLogLevel: stringPtr("loud")}, ErrInvalidSetting},
+		{"negative rate limit", map[string]*string{SettingRateLimitRequests: stringPtr("-1")}, ErrInvalidSetting},
+		{"bad flag", map[string]*string{SettingEnableAnalytics: stringPtr("maybe")}, ErrInvalidSetting},
+		{
+			name: "one bad key rejects the whole update",
+			changes: map[string]*string{
+				SettingRateLimitRequests: stringPtr("10"),
+				"jwt_secret":             stringPtr("hunter2"),
+			},
+			expected: ErrSettingNotAdjustable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			dynamic, settingsStore := newTestDynamic()
+
+			// Act
+			err := dynamic.Update(ctx, tt.changes, "admin-1")
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expected)
+			stored, listErr := settingsStore.List(ctx)
+			require.NoError(t, listErr)
+			assert.Empty(t, stored)
+			assert.Equal(t, 100, dynamic.Settings().RateLimitRequests)
+		})
+	}
+}
+
+func TestDynamic_RefreshIgnoresInvalidStoredValues(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	dynamic, settingsStore := newTestDynamic()
+	// Written behind the allowlist's back, e.g. by hand in psql
+	require.NoError(t, settingsStore.Update(ctx, map[string]*string{
+		SettingRateLimitWindow: stringPtr("forever"),
+		"jwt_secret":           stringPtr("hunter2"),
+		SettingLogLevel:        stringPtr("warn"),
+	}, "psql"))
+
+	// Act
+	require.NoError(t, dynamic.Refresh(ctx))
+
+	// Assert
+	assert.Equal(t, 60, dynamic.Settings().RateLimitWindow)
+	assert.Equal(t, "warn", dynamic.Settings().LogLevel)
+}
+
+// failingSettingsStore stores updates but cannot be read back
+type failingSettingsStore struct {
+	core.SettingsStore
+}
+
+func (s failingSettingsStore) List(ctx context.Context) ([]core.Setting, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestDynamic_UpdateAppliesLocallyWhenRefreshFails(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	dynamic := NewDynamic(&Config{LogLevel: "info", RateLimitWindow: 60}, failingSettingsStore{store.NewMemorySettingsStore()})
+
+	// Act
+	err := dynamic.Update(ctx, map[string]*string{SettingMaintenanceMessage: stringPtr("Back at 14:00 UTC")}, "admin-1")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Back at 14:00 UTC", dynamic.Settings().MaintenanceMessage)
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+)
+
+// watcherSubscriber is notified after a reload changes any of fields
+type watcherSubscriber struct {
+	fields []string
+	fn     func(*Config)
+}
+
+// Watcher reloads the configuration on SIGHUP (see Run) or when the
+// CONFIG_FILE changes (see Poll). Components that can take a new value
+// without a restart subscribe to the Config fields they use; a reload
+// applies changes to those fields only, and logs a warning naming the
+// changed fields nobody subscribed to, which need a restart. A
+// configuration that fails to load or validate is not applied at all. It is
+// safe for concurrent use.
+type Watcher struct {
+	load func() (*Config, error)
+
+	// reloadMu serializes reloads
+	reloadMu sync.Mutex
+	// fileSum is the hash of the CONFIG_FILE last read by Poll
+	fileSum []byte
+
+	mu          sync.RWMutex
+	current     *Config
+	subscribers []watcherSubscriber
+	reloadable  map[string]bool
+}
+
+// NewWatcher creates a watcher of the running configuration cfg, which
+// load reads again; the API passes Load
+func NewWatcher(cfg *Config, load func() (*Config, error)) *Watcher {
+	w := &Watcher{
+		load:       load,
+		current:    cfg,
+		reloadable: make(map[string]bool),
+	}
+	if cfg.ConfigFile != "" {
+		if data, err := os.ReadFile(cfg.ConfigFile); err == nil {
+			w.fileSum = checksum(data)
+		}
+	}
+	return w
+}
+
+// Config returns the configuration in effect. It must not be modified.
+func (w *Watcher) Config() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Subscribe registers fn to be called with the new configuration after a
+// reload changes any of fields, which are names of Config fields. Those
+// fields become reloadable. Subscribers run in the order they subscribed,
+// on the goroutine that reloaded. An unknown field name panics.
+func (w *Watcher) Subscribe(fn func(*Config), fields ...string) {
+	configType := reflect.TypeOf(Config{})
+	for _, field := range fields {
+		if _, ok := configType.FieldByName(field); !ok {
+			panic(fmt.Sprintf("config: Subscribe to unknown field %q", field))
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, watcherSubscriber{fields: fields, fn: fn})
+	for _, field := range fields {
+		w.reloadable[field] = true
+	}
+}
+
+// Run reloads the configuration on every SIGHUP until ctx is done
+func (w *Watcher) Run(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-hup:
+			log.Ctx(ctx).Info().Msg("SIGHUP received, reloading configuration")
+			// A failed reload is logged and keeps the running configuration
+			_ = w.Reload(ctx)
+		}
+	}
+}
+
+// Poll reloads the configuration if the CONFIG_FILE changed since it was
+// last read. Every replica runs it on an interval as a background job.
+func (w *Watcher) Poll(ctx context.Context) error {
+	file := w.Config().ConfigFile
+	if file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read CONFIG_FILE: %w", err)
+	}
+
+	sum := checksum(data)
+	w.reloadMu.Lock()
+	unchanged := bytes.Equal(sum, w.fileSum)
+	// A file that fails to load is not retried until it changes again
+	w.fileSum = sum
+	w.reloadMu.Unlock()
+	if unchanged {
+		return nil
+	}
+	return w.Reload(ctx)
+}
+
+// Reload loads the configuration again and applies the changes to
+// reloadable fields, notifying their subscribers. Changed fields are
+// audit-logged by name only, since some hold secrets. If the configuration
+// fails to load or validate, the error is logged and returned and the
+// running configuration is kept.
+func (w *Watcher) Reload(ctx context.Context) error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	loaded, err := w.load()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("configuration reload failed, keeping the running configuration")
+		return fmt.Errorf("failed to reload configuration: %w", err)
+	}
+
+	w.mu.RLock()
+	current := w.current
+	reloadable := w.reloadable
+	subscribers := w.subscribers
+	w.mu.RUnlock()
+
+	// next is the running configuration with the reloadable changes
+	next := *current
+	var applied, restart []string
+	nextValue, loadedValue := reflect.ValueOf(&next).Elem(), reflect.ValueOf(loaded).Elem()
+	for _, field := range changedFields(current, loaded) {
+		if !reloadable[field] {
+			restart = append(restart, field)
+			continue
+		}
+		nextValue.FieldByName(field).Set(loadedValue.FieldByName(field))
+		applied = append(applied, field)
+	}
+
+	if len(restart) > 0 {
+		log.Ctx(ctx).Warn().Strs("fields", restart).Msg("configuration changes require a restart to apply")
+	}
+	if len(applied) == 0 {
+		return nil
+	}
+	// The loaded configuration was validated whole; so is the mix of it
+	// with the fields that need a restart
+	if err := next.Validate(); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("configuration reload failed, keeping the running configuration")
+		return fmt.Errorf("failed to reload configuration: %w", err)
+	}
+
+	w.mu.Lock()
+	w.current = &next
+	w.mu.Unlock()
+
+	// Audit lines are written whatever the log level
+	log.Ctx(ctx).Log().
+		Str("audit", "config_reloaded").
+		Strs("fields", applied).
+		Msg("configuration reloaded")
+
+	changed := make(map[string]bool, len(applied))
+	for _, field := range applied {
+		changed[field] = true
+	}
+	for _, sub := range subscribers {
+		for _, field := range sub.fields {
+			if changed[field] {
+				sub.fn(&next)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// changedFields returns the names of the fields that differ between a and
+// b, in declaration order
+func changedFields(a, b *Config) []string {
+	aValue, bValue := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	var fields []string
+	for i := 0; i < aValue.NumField(); i++ {
+		if !reflect.DeepEqual(aValue.Field(i).Interface(), bValue.Field(i).Interface()) {
+			fields = append(fields, aValue.Type().Field(i).Name)
+		}
+	}
+	return fields
+}
+
+func checksum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadFunc returns each config of configs in turn, then fails
+func loadFunc(configs ...*Config) func() (*Config, error) {
+	return func() (*Config, error) {
+		if len(configs) == 0 {
+			return nil, errors.New("LOG_LEVEL must be one of trace, debug, info, warn, error, fatal, panic or disabled")
+		}
+		next := configs[0]
+		configs = configs[1:]
+		return next, nil
+	}
+}
+
+// loadedConfig returns the configuration Load reads with no environment
+func loadedConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := Load()
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestWatcher_ReloadAppliesSubscribedFields(t *testing.T) {
+	// Arrange
+	running := loadedConfig(t)
+	changed := *running
+	changed.LogLevel = "debug"
+	changed.CORSOrigins = []string{"https://app.example.com"}
+	changed.Port = "9090"
+	changed.JWTSecret = "rotated-secret-that-must-not-be-logged"
+
+	watcher := NewWatcher(running, loadFunc(&changed))
+	var levels []string
+	watcher.Subscribe(func(c *Config) {
+		levels = append(levels, c.LogLevel)
+	}, "LogLevel")
+	var origins [][]string
+	watcher.Subscribe(func(c *Config) {
+		origins = append(origins, c.CORSOrigins)
+	}, "CORSOrigins")
+	var rateLimits int
+	watcher.Subscribe(func(c *Config) {
+		rateLimits++
+	}, "RateLimitRequests", "RateLimitWindow")
+
+	var logs bytes.Buffer
+	ctx := zerolog.New(&logs).WithContext(context.Background())
+
+	// Act
+	err := watcher.Reload(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"debug"}, levels)
+	assert.Equal(t, [][]string{{"https://app.example.com"}}, origins)
+	assert.Zero(t, rateLimits, "subscribers of unchanged fields are not notified")
+
+	current := watcher.Config()
+	assert.Equal(t, "debug", current.LogLevel)
+	assert.Equal(t, []string{"https://app.example.com"}, current.CORSOrigins)
+	assert.Equal(t, "8080", current.Port, "fields nobody subscribed to need a restart")
+	assert.Equal(t, running.JWTSecret, current.JWTSecret)
+
+	assert.Contains(t, logs.String(), `"audit":"config_reloaded","fields":["LogLevel","CORSOrigins"]`)
+	assert.Contains(t, logs.String(), `"fields":["Port","JWTSecret"]`)
+	assert.NotContains(t, logs.String(), "rotated-secret", "values are never logged")
+}
+
+func TestWatcher_FailedReloadKeepsTheRunningConfig(t *testing.T) {
+	// Arrange
+	running := loadedConfig(t)
+	watcher := NewWatcher(running, loadFunc())
+	notified := false
+	watcher.Subscribe(func(c *Config) {
+		notified = true
+	}, "LogLevel")
+
+	// Act
+	err := watcher.Reload(context.Background())
+
+	// Assert
+	assert.ErrorContains(t, err, "LOG_LEVEL must be one of")
+	assert.False(t, notified)
+	assert.Same(t, running, watcher.Config())
+}
+
+func TestWatcher_ReloadValidatesTheAppliedConfig(t *testing.T) {
+	// Arrange
+	running := loadedConfig(t)
+	running.EnableDebugEndpoints = true
+	changed := *running
+	// Valid with the new PORT, which needs a restart, but not without it
+	changed.Port = "7070"
+	changed.DebugPort = "8080"
+
+	watcher := NewWatcher(running, loadFunc(&changed))
+	notified := false
+	watcher.Subscribe(func(c *Config) {
+		notified = true
+	}, "DebugPort")
+
+	// Act
+	err := watcher.Reload(context.Background())
+
+	// Assert
+	assert.ErrorContains(t, err, "DEBUG_PORT must differ from PORT")
+	assert.False(t, notified)
+	assert.Equal(t, "6060", watcher.Config().DebugPort)
+}
+
+func TestWatcher_PollReloadsTheChangedFile(t *testing.T) {
+	// Arrange
+	file := filepath.Join(t.TempDir(), "provemyself.env")
+	require.NoError(t, os.WriteFile(file, []byte("# Overrides\nRATE_LIMIT_REQUESTS=100\n"), 0o600))
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("RATE_LIMIT_REQUESTS", "10")
+	t.Setenv("MAINTENANCE_MESSAGE", "from the environment")
+
+	watcher := NewWatcher(loadedConfig(t), Load)
+	var seen []*Config
+	watcher.Subscribe(func(c *Config) {
+		seen = append(seen, c)
+	}, "RateLimitRequests", "MaintenanceMessage")
+	ctx := context.Background()
+
+	// Act
+	unchanged := watcher.Poll(ctx)
+	require.NoError(t, os.WriteFile(file, []byte(`
+# Overrides
+export RATE_LIMIT_REQUESTS = 250
+MAINTENANCE_MESSAGE="Back at 10:00 = soon"
+`), 0o600))
+	changed := watcher.Poll(ctx)
+	again := watcher.Poll(ctx)
+
+	// Assert
+	require.NoError(t, unchanged)
+	require.NoError(t, changed)
+	require.NoError(t, again)
+	require.Len(t, seen, 1, "only a changed file is reloaded")
+	assert.Equal(t, 250, seen[0].RateLimitRequests, "the file takes precedence over the environment")
+	assert.Equal(t, "Back at 10:00 = soon", seen[0].MaintenanceMessage)
+	assert.Equal(t, file, watcher.Config().ConfigFile)
+}
+
+func TestWatcher_PollKeepsTheConfigOnAnInvalidFile(t *testing.T) {
+	// Arrange
+	file := filepath.Join(t.TempDir(), "provemyself.env")
+	require.NoError(t, os.WriteFile(file, []byte("LOG_LEVEL=info\n"), 0o600))
+	t.Setenv("CONFIG_FILE", file)
+	watcher := NewWatcher(loadedConfig(t), Load)
+	watcher.Subscribe(func(c *Config) {}, "LogLevel")
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"invalid value", "LOG_LEVEL=loud\n", "LOG_LEVEL must be one of"},
+		{"malformed line", "LOG_LEVEL=debug\nverbose\n", "CONFIG_FILE line 2: expected KEY=VALUE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(file, []byte(tt.content), 0o600))
+
+			// Act
+			err := watcher.Poll(ctx)
+
+			// Assert
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Equal(t, "info", watcher.Config().LogLevel)
+		})
+	}
+}
+
+func TestWatcher_SubscribeUnknownField(t *testing.T) {
+	watcher := NewWatcher(&Config{}, Load)
+	assert.Panics(t, func() {
+		watcher.Subscribe(func(c *Config) {}, "LogLevl")
+	})
+}
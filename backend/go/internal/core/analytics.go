@@ -0,0 +1,310 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Analytics defaults and bounds
+const (
+	// AnalyticsCacheTTL is how long a project's analytics are served from
+	// the cache when no submission drops them first
+	AnalyticsCacheTTL = time.Minute
+
+	// AnalyticsSeriesDays is how many days the attempts-per-day series
+	// covers, ending on the day of the window's end
+	AnalyticsSeriesDays = 30
+
+	// AnalyticsScoreBuckets is how many score buckets analytics count, each
+	// a 10% range of the maximum score
+	AnalyticsScoreBuckets = 10
+
+	// DefaultPassPercent is the score, as a percentage of the maximum, an
+	// attempt passes with unless the request sets another
+	DefaultPassPercent = 50
+)
+
+// AnalyticsQuery selects the attempts a project's analytics aggregate
+type AnalyticsQuery struct {
+	// Filter keeps the attempts started in a window
+	Filter AttemptFilter
+
+	// PassPercent is the score, as a percentage of the maximum, that
+	// passes an attempt
+	PassPercent int
+
+	// SeriesFrom starts the attempts-per-day series, at midnight UTC
+	SeriesFrom time.Time
+}
+
+// ProjectAnalytics aggregate the attempts at a project, previews left
+// out. Scores are percentages of the attempt's maximum score, so attempts
+// at different publications compare; only submitted attempts with a
+// maximum score count towards them.
+type ProjectAnalytics struct {
+	// Attempts is how many attempts started, Submitted how many of them
+	// were submitted
+	Attempts  int
+	Submitted int
+
+	// CompletionRate is the percentage of the attempts submitted, nil
+	// without attempts
+	CompletionRate *float64
+
+	// AverageScore and MedianScore are the mean and median score, and
+	// PassRate the percentage of scores at or above the pass threshold;
+	// nil without scored attempts
+	AverageScore *float64
+	MedianScore  *float64
+	PassRate     *float64
+
+	// AverageDuration is the mean time from start to submit, nil without
+	// submitted attempts
+	AverageDuration *time.Duration
+
+	// Daily counts the attempts started on each day of the series, oldest
+	// first, days without attempts included
+	Daily []*DailyAttempts
+
+	// ScoreBuckets count the scored attempts in each 10% range, lowest
+	// first. The last range includes 100%.
+	ScoreBuckets []*ScoreBucket
+}
+
+// DailyAttempts is how many attempts started on a day, at midnight UTC
+type DailyAttempts struct {
+	Day      time.Time
+	Attempts int
+}
+
+// ScoreBucket is how many scored attempts fall in a range of scores, from
+// From percent up to, but excluding, To percent
+type ScoreBucket struct {
+	From  int
+	To    int
+	Count int
+}
+
+// StatsStore computes project analytics and answer distributions with SQL
+// aggregates. Its methods keep the submitted attempts in a window, previews
+// left out, and don't check the project or item exists.
+type StatsStore interface {
+	// Analytics aggregates the attempts at a project query selects, with
+	// the days of the series without attempts and the score buckets
+	// without scores left out
+	Analytics(ctx context.Context, projectID string, query AnalyticsQuery) (*ProjectAnalytics, error)
+
+	// CountAnswers counts the attempts answering an item
+	CountAnswers(ctx context.Context, projectID, itemID string, filter AttemptFilter) (int, error)
+
+	// ChoiceCounts counts the attempts choosing each option of a choice
+	// item, or of a multi_choice item when multiple is set, by option ID,
+	// leaving out options never chosen
+	ChoiceCounts(ctx context.Context, projectID, itemID string, multiple bool, filter AttemptFilter) ([]*ChoiceCount, error)
+
+	// TextCounts returns the limit most common answers to a text_entry
+	// item, trimmed and lowercased, most common first, and how many
+	// distinct answers there are
+	TextCounts(ctx context.Context, projectID, itemID string, filter AttemptFilter, limit int) ([]*TextCount, int, error)
+
+	// SequenceCounts returns the limit most common orders given to an
+	// ordering item, most common first
+	SequenceCounts(ctx context.Context, projectID, itemID string, filter AttemptFilter, limit int) ([]*SequenceCount, error)
+
+	// HeatGrid counts the points clicked on a hotspot item in each cell of
+	// a size by size grid over the box bounding them, nil without points
+	HeatGrid(ctx context.Context, projectID, itemID string, filter AttemptFilter, size int) (*HeatGrid, error)
+}
+
+// analyticsEntry is one cached ProjectAnalytics or AnswerDistribution
+type analyticsEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// AnalyticsService serves project analytics and answer distributions, each
+// kept for AnalyticsCacheTTL per project and query. A submission to a
+// project drops its entries at once on every replica that, as a
+// ChangeSubscriber, hears of it. It is safe for concurrent use.
+type AnalyticsService struct {
+	stats    StatsStore
+	projects ProjectStore
+	items    ItemStore
+	now      func() time.Time
+
+	mu sync.Mutex
+	// cache holds the entries of each project by query key
+	cache map[string]map[string]analyticsEntry
+	// generation counts invalidations, so analytics read before one aren't
+	// cached after it
+	generation uint64
+}
+
+// NewAnalyticsService creates a new analytics service
+func NewAnalyticsService(stats StatsStore, projects ProjectStore, items ItemStore) *AnalyticsService {
+	return &AnalyticsService{
+		stats:    stats,
+		projects: projects,
+		items:    items,
+		now:      time.Now,
+		cache:    make(map[string]map[string]analyticsEntry),
+	}
+}
+
+// Analytics aggregates the attempts at a project of the organization in
+// ctx started in filter's window. An attempt passes with passPercent, 0 to
+// 100, of its maximum score. The attempts-per-day series covers the
+// AnalyticsSeriesDays ending on the day filter's window ends, today
+// without an end.
+// Returns ErrProjectNotFound if the project doesn't exist.
+func (s *AnalyticsService) Analytics(ctx context.Context, projectID string, filter AttemptFilter, passPercent int) (*ProjectAnalytics, error) {
+	ctx, span := startSpan(ctx, "AnalyticsService.Analytics", attribute.String("project.id", projectID))
+	defer span.End()
+
+	// The end is exclusive, so the series ends on the day of its last
+	// instant
+	end := s.now()
+	if filter.To != nil {
+		end = filter.To.Add(-time.Nanosecond)
+	}
+	lastDay := end.UTC().Truncate(24 * time.Hour)
+	query := AnalyticsQuery{
+		Filter:      filter,
+		PassPercent: passPercent,
+		SeriesFrom:  lastDay.AddDate(0, 0, 1-AnalyticsSeriesDays),
+	}
+
+	// The organization is part of the key, so the project is found in it
+	// before its analytics are cached for it
+	key := analyticsKey(OrgIDFromContext(ctx), query)
+	cached, generation, ok := s.cached(projectID, key)
+	if ok {
+		return cached.(*ProjectAnalytics), nil
+	}
+
+	if _, err := s.projects.GetByID(ctx, projectID); err != nil {
+		return nil, err
+	}
+	analytics, err := s.stats.Analytics(ctx, projectID, query)
+	if err != nil {
+		return nil, err
+	}
+	analytics.Daily = fillDays(analytics.Daily, query.SeriesFrom)
+	analytics.ScoreBuckets = fillBuckets(analytics.ScoreBuckets)
+	s.store(projectID, key, analytics, generation)
+	return analytics, nil
+}
+
+// InvalidateProject drops the cached analytics of a project
+func (s *AnalyticsService) InvalidateProject(projectID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, projectID)
+	s.generation++
+}
+
+// ApplyChange implements ChangeSubscriber: a submission to a project, or a
+// change to it, made through any replica drops its analytics
+func (s *AnalyticsService) ApplyChange(ctx context.Context, change Change) error {
+	if change.Entity == ChangeEntityAttempts || change.Entity == ChangeEntityProject {
+		s.InvalidateProject(change.ID)
+	}
+	return nil
+}
+
+// Resync implements ChangeSubscriber by dropping every project's analytics
+func (s *AnalyticsService) Resync(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = make(map[string]map[string]analyticsEntry)
+	s.generation++
+	return nil
+}
+
+// cached returns a project's unexpired entry for key, or else the
+// generation to store it with
+func (s *AnalyticsService) cached(projectID, key string) (interface{}, uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[projectID][key]
+	if !ok || !s.now().Before(entry.expires) {
+		return nil, s.generation, false
+	}
+	return entry.value, s.generation, true
+}
+
+// store caches a project's entry for key, unless the project's entries were
+// invalidated since generation. The project's expired entries are dropped,
+// so queries asked once don't pile up.
+func (s *AnalyticsService) store(projectID, key string, value interface{}, generation uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if generation != s.generation {
+		return
+	}
+	now := s.now()
+	entries := s.cache[projectID]
+	if entries == nil {
+		entries = make(map[string]analyticsEntry)
+		s.cache[projectID] = entries
+	}
+	for k, entry := range entries {
+		if !now.Before(entry.expires) {
+			delete(entries, k)
+		}
+	}
+	entries[key] = analyticsEntry{value: value, expires: now.Add(AnalyticsCacheTTL)}
+}
+
+// analyticsKey identifies a query in a project's cached analytics
+func analyticsKey(orgID string, query AnalyticsQuery) string {
+	return fmt.Sprintf("analytics|%s|%s|%d|%s", orgID, filterKey(query.Filter),
+		query.PassPercent, query.SeriesFrom.Format(time.DateOnly))
+}
+
+// filterKey identifies an attempt window in a cache key
+func filterKey(filter AttemptFilter) string {
+	bound := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	return bound(filter.From) + "|" + bound(filter.To)
+}
+
+// fillDays returns the AnalyticsSeriesDays days from from, with the
+// attempts counted on each of days
+func fillDays(days []*DailyAttempts, from time.Time) []*DailyAttempts {
+	counts := make(map[time.Time]int, len(days))
+	for _, day := range days {
+		counts[day.Day.UTC()] = day.Attempts
+	}
+	filled := make([]*DailyAttempts, AnalyticsSeriesDays)
+	for i := range filled {
+		day := from.AddDate(0, 0, i)
+		filled[i] = &DailyAttempts{Day: day, Attempts: counts[day]}
+	}
+	return filled
+}
+
+// fillBuckets returns every score bucket, with the counts of buckets
+func fillBuckets(buckets []*ScoreBucket) []*ScoreBucket {
+	counts := make(map[int]int, len(buckets))
+	for _, bucket := range buckets {
+		counts[bucket.From] = bucket.Count
+	}
+	width := 100 / AnalyticsScoreBuckets
+	filled := make([]*ScoreBucket, AnalyticsScoreBuckets)
+	for i := range filled {
+		from := i * width
+		filled[i] = &ScoreBucket{From: from, To: from + width, Count: counts[from]}
+	}
+	return filled
+}
@@ -0,0 +1,296 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStats counts the aggregations asked of it, and can run a hook while
+// aggregating. Its answer breakdowns are those set, whatever the item.
+type fakeStats struct {
+	calls   int
+	queries []AnalyticsQuery
+	result  ProjectAnalytics
+	err     error
+
+	answered  int
+	choices   []*ChoiceCount
+	texts     []*TextCount
+	distinct  int
+	sequences []*SequenceCount
+	heat      *HeatGrid
+	// multiple and limits are what the last breakdown was asked for
+	multiple bool
+	limits   []int
+
+	// during runs inside the aggregation, before it returns
+	during func()
+}
+
+func (f *fakeStats) Analytics(ctx context.Context, projectID string, query AnalyticsQuery) (*ProjectAnalytics, error) {
+	f.calls++
+	f.queries = append(f.queries, query)
+	if f.during != nil {
+		f.during()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	result := f.result
+	return &result, nil
+}
+
+func (f *fakeStats) CountAnswers(ctx context.Context, projectID, itemID string, filter AttemptFilter) (int, error) {
+	f.calls++
+	return f.answered, f.err
+}
+
+func (f *fakeStats) ChoiceCounts(ctx context.Context, projectID, itemID string, multiple bool, filter AttemptFilter) ([]*ChoiceCount, error) {
+	f.multiple = multiple
+	return f.choices, f.err
+}
+
+func (f *fakeStats) TextCounts(ctx context.Context, projectID, itemID string, filter AttemptFilter, limit int) ([]*TextCount, int, error) {
+	f.limits = append(f.limits, limit)
+	return f.texts, f.distinct, f.err
+}
+
+func (f *fakeStats) SequenceCounts(ctx context.Context, projectID, itemID string, filter AttemptFilter, limit int) ([]*SequenceCount, error) {
+	f.limits = append(f.limits, limit)
+	return f.sequences, f.err
+}
+
+func (f *fakeStats) HeatGrid(ctx context.Context, projectID, itemID string, filter AttemptFilter, size int) (*HeatGrid, error) {
+	f.limits = append(f.limits, size)
+	return f.heat, f.err
+}
+
+func newAnalyticsTestService(stats *fakeStats, now *time.Time) *AnalyticsService {
+	projects := newMockProjectStore()
+	projects.projects["project-1"] = &Project{ID: "project-1", Title: "Capitals"}
+	service := NewAnalyticsService(stats, projects, newMockItemStore())
+	service.now = func() time.Time { return *now }
+	return service
+}
+
+func TestAnalyticsService_Analytics(t *testing.T) {
+	// Arrange
+	now := time.Date(2026, 6, 15, 9, 30, 0, 0, time.UTC)
+	stats := &fakeStats{result: ProjectAnalytics{
+		Attempts:  3,
+		Submitted: 2,
+		Daily:     []*DailyAttempts{{Day: time.Date(2026, 6, 14, 0, 0, 0, 0, time.UTC), Attempts: 2}, {Day: time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC), Attempts: 1}},
+		ScoreBuckets: []*ScoreBucket{
+			{From: 50, To: 60, Count: 1},
+			{From: 90, To: 100, Count: 1},
+		},
+	}}
+	service := newAnalyticsTestService(stats, &now)
+
+	// Act
+	analytics, err := service.Analytics(context.Background(), "project-1", AttemptFilter{}, DefaultPassPercent)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 3, analytics.Attempts)
+	require.Len(t, stats.queries, 1)
+	assert.Equal(t, DefaultPassPercent, stats.queries[0].PassPercent)
+	assert.Equal(t, time.Date(2026, 5, 17, 0, 0, 0, 0, time.UTC), stats.queries[0].SeriesFrom, "the series ends today")
+
+	require.Len(t, analytics.Daily, AnalyticsSeriesDays)
+	assert.Equal(t, time.Date(2026, 5, 17, 0, 0, 0, 0, time.UTC), analytics.Daily[0].Day)
+	assert.Equal(t, 0, analytics.Daily[0].Attempts)
+	assert.Equal(t, 2, analytics.Daily[28].Attempts)
+	assert.Equal(t, time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC), analytics.Daily[29].Day)
+	assert.Equal(t, 1, analytics.Daily[29].Attempts)
+
+	require.Len(t, analytics.ScoreBuckets, AnalyticsScoreBuckets)
+	assert.Equal(t, &ScoreBucket{From: 0, To: 10, Count: 0}, analytics.ScoreBuckets[0])
+	assert.Equal(t, &ScoreBucket{From: 50, To: 60, Count: 1}, analytics.ScoreBuckets[5])
+	assert.Equal(t, &ScoreBucket{From: 90, To: 100, Count: 1}, analytics.ScoreBuckets[9])
+}
+
+func TestAnalyticsService_Analytics_SeriesEndsWithTheWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		to       time.Time
+		expected time.Time
+	}{
+		{"to at midnight ends the day before", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 30, 0, 0, 0, 0, time.UTC)},
+		{"to during a day ends that day", time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC), time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"to in another zone ends its UTC day", time.Date(2026, 3, 1, 1, 0, 0, 0, time.FixedZone("CET", 3600)), time.Date(2026, 1, 30, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			now := time.Date(2026, 6, 15, 9, 30, 0, 0, time.UTC)
+			stats := &fakeStats{}
+			service := newAnalyticsTestService(stats, &now)
+
+			// Act
+			analytics, err := service.Analytics(context.Background(), "project-1", AttemptFilter{To: &tt.to}, DefaultPassPercent)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stats.queries[0].SeriesFrom)
+			assert.Equal(t, tt.expected, analytics.Daily[0].Day)
+		})
+	}
+}
+
+func TestAnalyticsService_Analytics_Cache(t *testing.T) {
+	from := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		second   func(s *AnalyticsService, now *time.Time) (*ProjectAnalytics, error)
+		expected int
+	}{
+		{
+			name: "same query within a minute",
+			second: func(s *AnalyticsService, now *time.Time) (*ProjectAnalytics, error) {
+				*now = now.Add(AnalyticsCacheTTL - time.Second)
+				return s.Analytics(context.Background(), "project-1", AttemptFilter{}, DefaultPassPercent)
+			},
+			expected: 1,
+		},
+		{
+			name: "same query after a minute",
+			second: func(s *AnalyticsService, now *time.Time) (*ProjectAnalytics, error) {
+				*now = now.Add(AnalyticsCacheTTL)
+				return s.Analytics(context.Background(), "project-1", AttemptFilter{}, DefaultPassPercent)
+			},
+			expected: 2,
+		},
+		{
+			name: "other window",
+			second: func(s *AnalyticsService, now *time.Time) (*ProjectAnalytics, error) {
+				return s.Analytics(context.Background(), "project-1", AttemptFilter{From: &from}, DefaultPassPercent)
+			},
+			expected: 2,
+		},
+		{
+			name: "other pass percent",
+			second: func(s *AnalyticsService, now *time.Time) (*ProjectAnalytics, error) {
+				return s.Analytics(context.Background(), "project-1", AttemptFilter{}, 80)
+			},
+			expected: 2,
+		},
+		{
+			name: "other organization",
+			second: func(s *AnalyticsService, now *time.Time) (*ProjectAnalytics, error) {
+				return s.Analytics(WithOrgID(context.Background(), "org-2"), "project-1", AttemptFilter{}, DefaultPassPercent)
+			},
+			expected: 2,
+		},
+		{
+			name: "after a submission",
+			second: func(s *AnalyticsService, now *time.Time) (*ProjectAnalytics, error) {
+				_ = s.ApplyChange(context.Background(), Change{Entity: ChangeEntityAttempts, ID: "project-1", Action: ChangeActionUpdated})
+				return s.Analytics(context.Background(), "project-1", AttemptFilter{}, DefaultPassPercent)
+			},
+			expected: 2,
+		},
+		{
+			name: "after a change to the project",
+			second: func(s *AnalyticsService, now *time.Time) (*ProjectAnalytics, error) {
+				_ = s.ApplyChange(context.Background(), Change{Entity: ChangeEntityProject, ID: "project-1", Action: ChangeActionUpdated})
+				return s.Analytics(context.Background(), "project-1", AttemptFilter{}, DefaultPassPercent)
+			},
+			expected: 2,
+		},
+		{
+			name: "after a submission to another project",
+			second: func(s *AnalyticsService, now *time.Time) (*ProjectAnalytics, error) {
+				_ = s.ApplyChange(context.Background(), Change{Entity: ChangeEntityAttempts, ID: "project-2", Action: ChangeActionUpdated})
+				return s.Analytics(context.Background(), "project-1", AttemptFilter{}, DefaultPassPercent)
+			},
+			expected: 1,
+		},
+		{
+			name: "after a resync",
+			second: func(s *AnalyticsService, now *time.Time) (*ProjectAnalytics, error) {
+				_ = s.Resync(context.Background())
+				return s.Analytics(context.Background(), "project-1", AttemptFilter{}, DefaultPassPercent)
+			},
+			expected: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			now := time.Date(2026, 6, 15, 9, 30, 0, 0, time.UTC)
+			stats := &fakeStats{result: ProjectAnalytics{Attempts: 1}}
+			service := newAnalyticsTestService(stats, &now)
+			_, err := service.Analytics(context.Background(), "project-1", AttemptFilter{}, DefaultPassPercent)
+			require.NoError(t, err)
+
+			// Act
+			analytics, err := tt.second(service, &now)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, 1, analytics.Attempts)
+			assert.Equal(t, tt.expected, stats.calls)
+		})
+	}
+}
+
+func TestAnalyticsService_Analytics_InvalidatedWhileAggregating(t *testing.T) {
+	// Arrange
+	now := time.Date(2026, 6, 15, 9, 30, 0, 0, time.UTC)
+	stats := &fakeStats{}
+	service := newAnalyticsTestService(stats, &now)
+	stats.during = func() {
+		// A submission lands after the aggregation read the attempts
+		stats.during = nil
+		service.InvalidateProject("project-1")
+	}
+
+	// Act
+	_, err := service.Analytics(context.Background(), "project-1", AttemptFilter{}, DefaultPassPercent)
+	require.NoError(t, err)
+	_, err = service.Analytics(context.Background(), "project-1", AttemptFilter{}, DefaultPassPercent)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.calls, "the analytics read before the submission aren't cached")
+}
+
+func TestAnalyticsService_Analytics_Errors(t *testing.T) {
+	storeErr := errors.New("connection refused")
+
+	tests := []struct {
+		name      string
+		projectID string
+		err       error
+		expected  error
+	}{
+		{"project not found", "missing", nil, ErrProjectNotFound},
+		{"store error", "project-1", storeErr, storeErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			now := time.Date(2026, 6, 15, 9, 30, 0, 0, time.UTC)
+			stats := &fakeStats{err: tt.err}
+			service := newAnalyticsTestService(stats, &now)
+
+			// Act
+			_, err := service.Analytics(context.Background(), tt.projectID, AttemptFilter{}, DefaultPassPercent)
+			_, again := service.Analytics(context.Background(), tt.projectID, AttemptFilter{}, DefaultPassPercent)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expected)
+			assert.ErrorIs(t, again, tt.expected, "errors aren't cached")
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// checkAnswer returns response, an answer to item, re-encoded as its item
+// type's answer (see types.ChoiceAnswer and the others), or
+// ErrInvalidAnswer wrapped with the reason if it isn't one: it must decode
+// as the type's answer, without unknown fields, and name only the options,
+// entries or lengths the item has. Title and media items take no answers.
+func checkAnswer(item *Item, response json.RawMessage) (json.RawMessage, error) {
+	var answer interface{}
+	var err error
+	switch item.Type {
+	case types.ItemTypeChoice:
+		answer, err = checkChoiceAnswer(item, response)
+	case types.ItemTypeMultiChoice:
+		answer, err = checkMultiChoiceAnswer(item, response)
+	case types.ItemTypeTextEntry:
+		answer, err = checkTextEntryAnswer(item, response)
+	case types.ItemTypeOrdering:
+		answer, err = checkOrderingAnswer(item, response)
+	case types.ItemTypeHotspot:
+		answer, err = checkHotspotAnswer(response)
+	default:
+		return nil, fmt.Errorf("%w: %s items take no answer", ErrInvalidAnswer, item.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(answer)
+}
+
+// decodeAnswer decodes response into answer, failing with ErrInvalidAnswer
+// for fields an answer of itemType doesn't have
+func decodeAnswer(itemType types.ItemType, response json.RawMessage, answer interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(response))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(answer); err != nil {
+		return fmt.Errorf("%w: not a %s answer: %v", ErrInvalidAnswer, itemType, err)
+	}
+	return nil
+}
+
+// choiceIDs returns the IDs of the options of a choice or multi_choice item
+func choiceIDs(item *Item) ([]string, error) {
+	var content types.ChoiceContent
+	if err := json.Unmarshal(item.Content, &content); err != nil {
+		return nil, fmt.Errorf("failed to decode item %s: %w", item.ID, err)
+	}
+	ids := make([]string, len(content.Choices))
+	for i, choice := range content.Choices {
+		ids[i] = choice.ID
+	}
+	return ids, nil
+}
+
+func checkChoiceAnswer(item *Item, response json.RawMessage) (*types.ChoiceAnswer, error) {
+	var answer types.ChoiceAnswer
+	if err := decodeAnswer(item.Type, response, &answer); err != nil {
+		return nil, err
+	}
+	ids, err := choiceIDs(item)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(ids, answer.ChoiceID) {
+		return nil, fmt.Errorf("%w: the item has no option %q", ErrInvalidAnswer, answer.ChoiceID)
+	}
+	return &answer, nil
+}
+
+func checkMultiChoiceAnswer(item *Item, response json.RawMessage) (*types.MultiChoiceAnswer, error) {
+	var answer types.MultiChoiceAnswer
+	if err := decodeAnswer(item.Type, response, &answer); err != nil {
+		return nil, err
+	}
+	ids, err := choiceIDs(item)
+	if err != nil {
+		return nil, err
+	}
+	if answer.ChoiceIDs == nil {
+		answer.ChoiceIDs = []string{}
+	}
+	for i, id := range answer.ChoiceIDs {
+		if !slices.Contains(ids, id) {
+			return nil, fmt.Errorf("%w: the item has no option %q", ErrInvalidAnswer, id)
+		}
+		if slices.Contains(answer.ChoiceIDs[:i], id) {
+			return nil, fmt.Errorf("%w: option %q is chosen twice", ErrInvalidAnswer, id)
+		}
+	}
+	return &answer, nil
+}
+
+func checkTextEntryAnswer(item *Item, response json.RawMessage) (*types.TextEntryAnswer, error) {
+	var answer types.TextEntryAnswer
+	if err := decodeAnswer(item.Type, response, &answer); err != nil {
+		return nil, err
+	}
+	var content types.TextEntryContent
+	if err := json.Unmarshal(item.Content, &content); err != nil {
+		return nil, fmt.Errorf("failed to decode item %s: %w", item.ID, err)
+	}
+	if content.MaxLength != nil && utf8.RuneCountInString(answer.Text) > *content.MaxLength {
+		return nil, fmt.Errorf("%w: the answer is longer than %d characters", ErrInvalidAnswer, *content.MaxLength)
+	}
+	return &answer, nil
+}
+
+func checkOrderingAnswer(item *Item, response json.RawMessage) (*types.OrderingAnswer, error) {
+	var answer types.OrderingAnswer
+	if err := decodeAnswer(item.Type, response, &answer); err != nil {
+		return nil, err
+	}
+	var content types.OrderingContent
+	if err := json.Unmarshal(item.Content, &content); err != nil {
+		return nil, fmt.Errorf("failed to decode item %s: %w", item.ID, err)
+	}
+
+	ids := make([]string, len(content.Items))
+	for i, entry := range content.Items {
+		ids[i] = entry.ID
+	}
+	ordered := slices.Clone(answer.Order)
+	slices.Sort(ids)
+	slices.Sort(ordered)
+	if !slices.Equal(ids, ordered) {
+		return nil, fmt.Errorf("%w: the order must list each of the item's %d entries once", ErrInvalidAnswer, len(ids))
+	}
+	return &answer, nil
+}
+
+func checkHotspotAnswer(response json.RawMessage) (*types.HotspotAnswer, error) {
+	var answer types.HotspotAnswer
+	if err := decodeAnswer(types.ItemTypeHotspot, response, &answer); err != nil {
+		return nil, err
+	}
+	if answer.X == nil || answer.Y == nil {
+		return nil, fmt.Errorf("%w: the answer needs both x and y", ErrInvalidAnswer)
+	}
+	return &answer, nil
+}
@@ -0,0 +1,190 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// Answer distribution bounds, so an item answered by a million attempts
+// aggregates to as few rows
+const (
+	// TopTextAnswers is how many of the most common text_entry answers a
+	// distribution lists
+	TopTextAnswers = 20
+
+	// TopSequences is how many of the most common orders of an ordering
+	// item a distribution lists
+	TopSequences = 10
+
+	// DefaultHeatGridSize is how many cells a side of a hotspot item's heat
+	// grid has unless the request sets another, and MaxHeatGridSize the
+	// most it may set
+	DefaultHeatGridSize = 20
+	MaxHeatGridSize     = 100
+)
+
+// AnswerDistribution breaks down the submitted attempts' answers to an
+// item, previews left out, in the shape of its type. Options, answers and
+// orders are checked against the item as it is now, which attempts at an
+// older publication may not have answered.
+type AnswerDistribution struct {
+	ItemID   string
+	ItemType types.ItemType
+
+	// Answered is how many attempts answered the item; title and media
+	// items have no answers
+	Answered int
+
+	// Choices count each option of a choice or multi_choice item, in item
+	// order, then the options chosen that the item no longer has
+	Choices []*ChoiceDistribution
+
+	// Texts are the TopTextAnswers most common answers to a text_entry
+	// item, and DistinctTexts how many distinct answers there are
+	Texts         []*TextCount
+	DistinctTexts int
+
+	// Sequences are the TopSequences most common orders given to an
+	// ordering item
+	Sequences []*SequenceCount
+
+	// Heat counts the points clicked on a hotspot item, nil until one is
+	Heat *HeatGrid
+}
+
+// ChoiceDistribution is how many attempts chose an option
+type ChoiceDistribution struct {
+	ChoiceID string
+
+	// Text is the option's, nil for an option the item no longer has
+	Text    *string
+	Correct bool
+	Count   int
+}
+
+// TextCount is how many attempts gave a text_entry answer, trimmed and
+// lowercased
+type TextCount struct {
+	Text    string
+	Count   int
+	Correct bool
+}
+
+// SequenceCount is how many attempts put an ordering item's entries in an
+// order, by entry ID
+type SequenceCount struct {
+	Order   []string
+	Count   int
+	Correct bool
+}
+
+// HeatGrid counts the points clicked on a hotspot item in the cells of a
+// Size by Size grid over the box from MinX, MinY to MaxX, MaxY bounding
+// them, in the coordinates of the item's regions. Counts has a row of Size
+// cells for each band of y, from MinY up, each cell a band of x from MinX
+// up; points on the far edges count in the last row or cell.
+type HeatGrid struct {
+	Size       int
+	MinX, MinY float64
+	MaxX, MaxY float64
+	Counts     [][]int
+}
+
+// AnswerDistribution breaks down the answers to an item of a project of the
+// organization in ctx given by the submitted attempts started in filter's
+// window. A hotspot item's heat grid has gridSize cells a side, 1 to
+// MaxHeatGridSize.
+// Returns ErrProjectNotFound if the project doesn't exist, and
+// ErrItemNotFound if the item doesn't.
+func (s *AnalyticsService) AnswerDistribution(ctx context.Context, projectID, itemID string, filter AttemptFilter, gridSize int) (*AnswerDistribution, error) {
+	ctx, span := startSpan(ctx, "AnalyticsService.AnswerDistribution",
+		attribute.String("project.id", projectID), attribute.String("item.id", itemID))
+	defer span.End()
+
+	key := fmt.Sprintf("distribution|%s|%s|%s|%d", OrgIDFromContext(ctx), itemID, filterKey(filter), gridSize)
+	cached, generation, ok := s.cached(projectID, key)
+	if ok {
+		return cached.(*AnswerDistribution), nil
+	}
+
+	if _, err := s.projects.GetByID(ctx, projectID); err != nil {
+		return nil, err
+	}
+	item, err := s.items.GetByID(ctx, projectID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	distribution := &AnswerDistribution{ItemID: item.ID, ItemType: item.Type}
+	if isQuestion(item.Type) {
+		if distribution.Answered, err = s.stats.CountAnswers(ctx, projectID, itemID, filter); err != nil {
+			return nil, err
+		}
+	}
+	switch item.Type {
+	case types.ItemTypeChoice, types.ItemTypeMultiChoice:
+		counts, err := s.stats.ChoiceCounts(ctx, projectID, itemID, item.Type == types.ItemTypeMultiChoice, filter)
+		if err != nil {
+			return nil, err
+		}
+		distribution.Choices = choiceDistribution(item, counts)
+	case types.ItemTypeTextEntry:
+		if distribution.Texts, distribution.DistinctTexts, err = s.stats.TextCounts(ctx, projectID, itemID, filter, TopTextAnswers); err != nil {
+			return nil, err
+		}
+		var content types.TextEntryContent
+		if json.Unmarshal(item.Content, &content) == nil && content.CorrectAnswer != nil {
+			expected := strings.ToLower(strings.TrimSpace(*content.CorrectAnswer))
+			for _, text := range distribution.Texts {
+				text.Correct = expected != "" && text.Text == expected
+			}
+		}
+	case types.ItemTypeOrdering:
+		if distribution.Sequences, err = s.stats.SequenceCounts(ctx, projectID, itemID, filter, TopSequences); err != nil {
+			return nil, err
+		}
+		if expected, ok := correctOrder(item); ok {
+			for _, sequence := range distribution.Sequences {
+				sequence.Correct = slices.Equal(expected, sequence.Order)
+			}
+		}
+	case types.ItemTypeHotspot:
+		if distribution.Heat, err = s.stats.HeatGrid(ctx, projectID, itemID, filter, gridSize); err != nil {
+			return nil, err
+		}
+	}
+
+	s.store(projectID, key, distribution, generation)
+	return distribution, nil
+}
+
+// choiceDistribution counts every option of a choice or multi_choice item,
+// in item order, then the options of counts the item no longer has
+func choiceDistribution(item *Item, counts []*ChoiceCount) []*ChoiceDistribution {
+	chosen := make(map[string]int, len(counts))
+	for _, count := range counts {
+		chosen[count.ChoiceID] = count.Count
+	}
+
+	var content types.ChoiceContent
+	_ = json.Unmarshal(item.Content, &content)
+	choices := make([]*ChoiceDistribution, 0, len(content.Choices))
+	for _, choice := range content.Choices {
+		text := choice.Text
+		choices = append(choices, &ChoiceDistribution{ChoiceID: choice.ID, Text: &text, Correct: choice.Correct, Count: chosen[choice.ID]})
+		delete(chosen, choice.ID)
+	}
+	for _, count := range counts {
+		if _, ok := chosen[count.ChoiceID]; ok {
+			choices = append(choices, &ChoiceDistribution{ChoiceID: count.ChoiceID, Count: count.Count})
+		}
+	}
+	return choices
+}
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// newDistributionTestService returns an analytics service of "project-1"
+// with item
+func newDistributionTestService(stats *fakeStats, item *Item) *AnalyticsService {
+	now := time.Date(2026, 6, 15, 9, 30, 0, 0, time.UTC)
+	service := newAnalyticsTestService(stats, &now)
+	items := newMockItemStore()
+	items.items[item.ID] = item
+	service.items = items
+	return service
+}
+
+func TestAnalyticsService_AnswerDistribution(t *testing.T) {
+	choiceContent := `{"choices":[{"id":"a","text":"Sirius","correct":true},{"id":"b","text":"Vega"},{"id":"c","text":"Polaris"}]}`
+	sirius, vega, polaris := "Sirius", "Vega", "Polaris"
+
+	tests := []struct {
+		name     string
+		item     *Item
+		stats    *fakeStats
+		expected *AnswerDistribution
+	}{
+		{
+			name:  "choice",
+			item:  &Item{Type: types.ItemTypeChoice, Content: json.RawMessage(choiceContent)},
+			stats: &fakeStats{answered: 5, choices: []*ChoiceCount{{ChoiceID: "a", Count: 2}, {ChoiceID: "b", Count: 2}, {ChoiceID: "z", Count: 1}}},
+			expected: &AnswerDistribution{
+				ItemType: types.ItemTypeChoice,
+				Answered: 5,
+				Choices: []*ChoiceDistribution{
+					{ChoiceID: "a", Text: &sirius, Correct: true, Count: 2},
+					{ChoiceID: "b", Text: &vega, Count: 2},
+					{ChoiceID: "c", Text: &polaris, Count: 0},
+					{ChoiceID: "z", Count: 1},
+				},
+			},
+		},
+		{
+			name:  "multi_choice",
+			item:  &Item{Type: types.ItemTypeMultiChoice, Content: json.RawMessage(choiceContent)},
+			stats: &fakeStats{answered: 2, choices: []*ChoiceCount{{ChoiceID: "a", Count: 2}, {ChoiceID: "c", Count: 1}}},
+			expected: &AnswerDistribution{
+				ItemType: types.ItemTypeMultiChoice,
+				Answered: 2,
+				Choices: []*ChoiceDistribution{
+					{ChoiceID: "a", Text: &sirius, Correct: true, Count: 2},
+					{ChoiceID: "b", Text: &vega, Count: 0},
+					{ChoiceID: "c", Text: &polaris, Count: 1},
+				},
+			},
+		},
+		{
+			name:  "text_entry",
+			item:  &Item{Type: types.ItemTypeTextEntry, Content: json.RawMessage(`{"correct_answer":" Paris "}`)},
+			stats: &fakeStats{answered: 4, texts: []*TextCount{{Text: "paris", Count: 3}, {Text: "lyon", Count: 1}}, distinct: 2},
+			expected: &AnswerDistribution{
+				ItemType:      types.ItemTypeTextEntry,
+				Answered:      4,
+				Texts:         []*TextCount{{Text: "paris", Count: 3, Correct: true}, {Text: "lyon", Count: 1}},
+				DistinctTexts: 2,
+			},
+		},
+		{
+			name: "ordering",
+			item: &Item{Type: types.ItemTypeOrdering, Content: json.RawMessage(`{"items":[{"id":"x","text":"Mercury","correct_order":1},{"id":"y","text":"Venus","correct_order":2}]}`)},
+			stats: &fakeStats{answered: 3, sequences: []*SequenceCount{
+				{Order: []string{"y", "x"}, Count: 2},
+				{Order: []string{"x", "y"}, Count: 1},
+			}},
+			expected: &AnswerDistribution{
+				ItemType: types.ItemTypeOrdering,
+				Answered: 3,
+				Sequences: []*SequenceCount{
+					{Order: []string{"y", "x"}, Count: 2},
+					{Order: []string{"x", "y"}, Count: 1, Correct: true},
+				},
+			},
+		},
+		{
+			name:  "hotspot",
+			item:  &Item{Type: types.ItemTypeHotspot, Content: json.RawMessage(`{"hotspots":[]}`)},
+			stats: &fakeStats{answered: 1, heat: &HeatGrid{Size: 1, MaxX: 1, MaxY: 1, Counts: [][]int{{1}}}},
+			expected: &AnswerDistribution{
+				ItemType: types.ItemTypeHotspot,
+				Answered: 1,
+				Heat:     &HeatGrid{Size: 1, MaxX: 1, MaxY: 1, Counts: [][]int{{1}}},
+			},
+		},
+		{
+			name:     "title",
+			item:     &Item{Type: types.ItemTypeTitle, Content: json.RawMessage(`{}`)},
+			stats:    &fakeStats{answered: 9},
+			expected: &AnswerDistribution{ItemType: types.ItemTypeTitle},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			tt.item.ID, tt.item.ProjectID = "item-1", "project-1"
+			tt.expected.ItemID = "item-1"
+			service := newDistributionTestService(tt.stats, tt.item)
+
+			// Act
+			distribution, err := service.AnswerDistribution(context.Background(), "project-1", "item-1", AttemptFilter{}, DefaultHeatGridSize)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, distribution)
+		})
+	}
+}
+
+func TestAnalyticsService_AnswerDistribution_Bounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		itemType types.ItemType
+		multiple bool
+		expected []int
+	}{
+		{"choice", types.ItemTypeChoice, false, nil},
+		{"multi_choice", types.ItemTypeMultiChoice, true, nil},
+		{"text_entry", types.ItemTypeTextEntry, false, []int{TopTextAnswers}},
+		{"ordering", types.ItemTypeOrdering, false, []int{TopSequences}},
+		{"hotspot", types.ItemTypeHotspot, false, []int{7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			stats := &fakeStats{}
+			service := newDistributionTestService(stats, &Item{ID: "item-1", ProjectID: "project-1", Type: tt.itemType, Content: json.RawMessage(`{}`)})
+
+			// Act
+			_, err := service.AnswerDistribution(context.Background(), "project-1", "item-1", AttemptFilter{}, 7)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.multiple, stats.multiple)
+			assert.Equal(t, tt.expected, stats.limits)
+		})
+	}
+}
+
+func TestAnalyticsService_AnswerDistribution_Cache(t *testing.T) {
+	// Arrange
+	stats := &fakeStats{answered: 1}
+	service := newDistributionTestService(stats, &Item{ID: "item-1", ProjectID: "project-1", Type: types.ItemTypeChoice, Content: json.RawMessage(`{"choices":[]}`)})
+	distribution := func() {
+		_, err := service.AnswerDistribution(context.Background(), "project-1", "item-1", AttemptFilter{}, DefaultHeatGridSize)
+		require.NoError(t, err)
+	}
+
+	// Act
+	distribution()
+	distribution()
+	cachedCalls := stats.calls
+	_ = service.ApplyChange(context.Background(), Change{Entity: ChangeEntityAttempts, ID: "project-1", Action: ChangeActionUpdated})
+	distribution()
+
+	// Assert
+	assert.Equal(t, 1, cachedCalls, "the second read is cached")
+	assert.Equal(t, 2, stats.calls, "a submission drops the cached distribution")
+}
+
+func TestAnalyticsService_AnswerDistribution_NotFound(t *testing.T) {
+	tests := []struct {
+		name      string
+		projectID string
+		itemID    string
+		expected  error
+	}{
+		{"project not found", "missing", "item-1", ErrProjectNotFound},
+		{"item not found", "project-1", "missing", ErrItemNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := newDistributionTestService(&fakeStats{}, &Item{ID: "item-1", ProjectID: "project-1", Type: types.ItemTypeChoice})
+
+			// Act
+			_, err := service.AnswerDistribution(context.Background(), tt.projectID, tt.itemID, AttemptFilter{}, DefaultHeatGridSize)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+func TestCheckAnswer(t *testing.T) {
+	choice := `{"choices":[{"id":"a","text":"Paris","correct":true},{"id":"b","text":"Lyon"}]}`
+	ordering := `{"items":[{"id":"1","text":"Rome","correct_order":1},{"id":"2","text":"Berlin","correct_order":2}]}`
+
+	tests := []struct {
+		name     string
+		itemType types.ItemType
+		content  string
+		response string
+		expected string
+		invalid  bool
+	}{
+		{
+			name:     "choice",
+			itemType: types.ItemTypeChoice,
+			content:  choice,
+			response: `{"choice_id":"b"}`,
+			expected: `{"choice_id":"b"}`,
+		},
+		{
+			name:     "choice of an option the item doesn't have",
+			itemType: types.ItemTypeChoice,
+			content:  choice,
+			response: `{"choice_id":"z"}`,
+			invalid:  true,
+		},
+		{
+			name:     "multi_choice",
+			itemType: types.ItemTypeMultiChoice,
+			content:  choice,
+			response: `{"choice_ids":["b","a"]}`,
+			expected: `{"choice_ids":["b","a"]}`,
+		},
+		{
+			name:     "multi_choice of no options",
+			itemType: types.ItemTypeMultiChoice,
+			content:  choice,
+			response: `{}`,
+			expected: `{"choice_ids":[]}`,
+		},
+		{
+			name:     "multi_choice of an option twice",
+			itemType: types.ItemTypeMultiChoice,
+			content:  choice,
+			response: `{"choice_ids":["a","a"]}`,
+			invalid:  true,
+		},
+		{
+			name:     "text_entry",
+			itemType: types.ItemTypeTextEntry,
+			content:  `{"max_length":6,"multiline":false}`,
+			response: `{"text":"Madrid"}`,
+			expected: `{"text":"Madrid"}`,
+		},
+		{
+			name:     "text_entry longer than its max_length",
+			itemType: types.ItemTypeTextEntry,
+			content:  `{"max_length":5,"multiline":false}`,
+			response: `{"text":"Madrid"}`,
+			invalid:  true,
+		},
+		{
+			name:     "text_entry max_length counts characters, not bytes",
+			itemType: types.ItemTypeTextEntry,
+			content:  `{"max_length":6,"multiline":false}`,
+			response: `{"text":"Zürich"}`,
+			expected: `{"text":"Zürich"}`,
+		},
+		{
+			name:     "ordering",
+			itemType: types.ItemTypeOrdering,
+			content:  ordering,
+			response: `{"order":["2","1"]}`,
+			expected: `{"order":["2","1"]}`,
+		},
+		{
+			name:     "ordering that leaves an entry out",
+			itemType: types.ItemTypeOrdering,
+			content:  ordering,
+			response: `{"order":["2"]}`,
+			invalid:  true,
+		},
+		{
+			name:     "ordering that repeats an entry",
+			itemType: types.ItemTypeOrdering,
+			content:  ordering,
+			response: `{"order":["2","2"]}`,
+			invalid:  true,
+		},
+		{
+			name:     "hotspot",
+			itemType: types.ItemTypeHotspot,
+			content:  `{"image_url":"https://example.com/map.png","hotspots":[{"id":"a","shape":"circle","coords":[10,20,5],"correct":true}]}`,
+			response: `{"x":12.5,"y":0}`,
+			expected: `{"x":12.5,"y":0}`,
+		},
+		{
+			name:     "hotspot without y",
+			itemType: types.ItemTypeHotspot,
+			content:  `{"image_url":"https://example.com/map.png","hotspots":[{"id":"a","shape":"circle","coords":[10,20,5],"correct":true}]}`,
+			response: `{"x":12.5}`,
+			invalid:  true,
+		},
+		{
+			name:     "another type's answer",
+			itemType: types.ItemTypeChoice,
+			content:  choice,
+			response: `{"text":"Paris"}`,
+			invalid:  true,
+		},
+		{
+			name:     "not an object",
+			itemType: types.ItemTypeTextEntry,
+			content:  `{"multiline":false}`,
+			response: `"Madrid"`,
+			invalid:  true,
+		},
+		{
+			name:     "title",
+			itemType: types.ItemTypeTitle,
+			content:  `{"text":"Capitals"}`,
+			response: `{}`,
+			invalid:  true,
+		},
+		{
+			name:     "media",
+			itemType: types.ItemTypeMedia,
+			content:  `{"url":"https://example.com/map.png","media_type":"image"}`,
+			response: `{}`,
+			invalid:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			item := &Item{ID: "item-1", Type: tt.itemType, Content: json.RawMessage(tt.content)}
+
+			// Act
+			answer, err := checkAnswer(item, json.RawMessage(tt.response))
+
+			// Assert
+			if tt.invalid {
+				assert.ErrorIs(t, err, ErrInvalidAnswer)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(answer))
+		})
+	}
+}
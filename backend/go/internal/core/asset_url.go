@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Domain errors for signed asset URLs
+var (
+	// ErrAssetURLInvalid is returned for an asset URL whose signature is
+	// missing or doesn't match its key and expiry
+	ErrAssetURLInvalid = errors.New("asset URL signature is invalid")
+
+	// ErrAssetURLExpired is returned for a correctly signed asset URL past
+	// its expiry
+	ErrAssetURLExpired = errors.New("asset URL has expired")
+)
+
+// Query parameters of a signed asset URL
+const (
+	AssetURLExpiresParam   = "expires"
+	AssetURLSignatureParam = "signature"
+)
+
+// AssetURLSigner signs asset URLs for temporary access with an HMAC-SHA256
+// over the asset's key and the URL's expiry, and verifies them
+type AssetURLSigner struct {
+	secret []byte
+}
+
+// NewAssetURLSigner creates a signer with secret, which must be kept from
+// clients: anyone holding it can sign URLs to any asset
+func NewAssetURLSigner(secret []byte) *AssetURLSigner {
+	return &AssetURLSigner{secret: secret}
+}
+
+// Sign returns the query parameters granting access to the asset at key
+// until expires, to the second
+func (s *AssetURLSigner) Sign(key string, expires time.Time) url.Values {
+	unix := expires.Unix()
+	return url.Values{
+		AssetURLExpiresParam:   {strconv.FormatInt(unix, 10)},
+		AssetURLSignatureParam: {s.signature(key, unix)},
+	}
+}
+
+// Verify checks that query grants access to the asset at key at now.
+// Returns ErrAssetURLInvalid if the key isn't a clean relative path or the
+// signature doesn't match it and the expiry, and ErrAssetURLExpired once the
+// URL has expired.
+func (s *AssetURLSigner) Verify(key string, query url.Values, now time.Time) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("%w: malformed key", ErrAssetURLInvalid)
+	}
+	unix, err := strconv.ParseInt(query.Get(AssetURLExpiresParam), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed expiry", ErrAssetURLInvalid)
+	}
+	signature := query.Get(AssetURLSignatureParam)
+	if !hmac.Equal([]byte(signature), []byte(s.signature(key, unix))) {
+		return ErrAssetURLInvalid
+	}
+	if now.Unix() >= unix {
+		return ErrAssetURLExpired
+	}
+	return nil
+}
+
+// signature is the URL-safe base64 HMAC of key and the expiry. The newline
+// can't appear in the expiry, so no two pairs sign the same message.
+func (s *AssetURLSigner) signature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// AssetService hands out signed URLs to the assets of projects, and serves
+// the assets they point to
+type AssetService struct {
+	projects ProjectStore
+	storage  Storage
+	maxTTL   time.Duration
+}
+
+// NewAssetService creates an asset service whose signed URLs last at most
+// maxTTL
+func NewAssetService(projects ProjectStore, storage Storage, maxTTL time.Duration) *AssetService {
+	return &AssetService{projects: projects, storage: storage, maxTTL: maxTTL}
+}
+
+// SignedURL returns a URL to the asset name of a project of the
+// organization in ctx, and when it expires: after ttl, or the maximum if
+// ttl is longer. Returns ErrProjectNotFound if the project doesn't exist and
+// ErrFileNotFound if it has no such asset.
+func (s *AssetService) SignedURL(ctx context.Context, projectID, name string, ttl time.Duration) (string, time.Time, error) {
+	ctx, span := startSpan(ctx, "AssetService.SignedURL", attribute.String("project.id", projectID))
+	defer span.End()
+
+	if _, err := s.projects.GetByID(ctx, projectID); err != nil {
+		return "", time.Time{}, err
+	}
+	// Names are one path segment, so the key stays in the project's assets
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", time.Time{}, ErrFileNotFound
+	}
+	ttl = min(ttl, s.maxTTL)
+
+	// Storage signs to the second, from a moment later than this
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+	signedURL, err := s.storage.GetSignedURL(ctx, fmt.Sprintf("projects/%s/assets/%s", projectID, name), ttl)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signedURL, expiresAt, nil
+}
+
+// Download retrieves the asset at key, for a request whose signed URL was
+// verified. Returns ErrFileNotFound if there is none.
+func (s *AssetService) Download(ctx context.Context, key string) (io.ReadCloser, *StorageMetadata, error) {
+	return s.storage.Download(ctx, key)
+}
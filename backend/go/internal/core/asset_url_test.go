@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetURLSigner_Verify(t *testing.T) {
+	key := "projects/project-1/assets/map_1712.png"
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	signed := NewAssetURLSigner([]byte("secret")).Sign(key, now.Add(time.Minute))
+
+	with := func(param, value string) url.Values {
+		query := url.Values{}
+		for k, v := range signed {
+			query[k] = v
+		}
+		query.Set(param, value)
+		return query
+	}
+
+	tests := []struct {
+		name        string
+		signer      *AssetURLSigner
+		key         string
+		query       url.Values
+		now         time.Time
+		expectedErr error
+	}{
+		{"signed", NewAssetURLSigner([]byte("secret")), key, signed, now, nil},
+		{"a second before it expires", NewAssetURLSigner([]byte("secret")), key, signed, now.Add(59 * time.Second), nil},
+		{"when it expires", NewAssetURLSigner([]byte("secret")), key, signed, now.Add(time.Minute), ErrAssetURLExpired},
+		{"another key", NewAssetURLSigner([]byte("secret")), "projects/project-1/assets/other.png", signed, now, ErrAssetURLInvalid},
+		{"a later expiry", NewAssetURLSigner([]byte("secret")), key, with(AssetURLExpiresParam, "9999999999"), now, ErrAssetURLInvalid},
+		{"a malformed expiry", NewAssetURLSigner([]byte("secret")), key, with(AssetURLExpiresParam, "soon"), now, ErrAssetURLInvalid},
+		{"another signature", NewAssetURLSigner([]byte("secret")), key, with(AssetURLSignatureParam, "AAAA"), now, ErrAssetURLInvalid},
+		{"no signature", NewAssetURLSigner([]byte("secret")), key, url.Values{AssetURLExpiresParam: signed[AssetURLExpiresParam]}, now, ErrAssetURLInvalid},
+		{"another secret", NewAssetURLSigner([]byte("other")), key, signed, now, ErrAssetURLInvalid},
+		{"a key out of storage", NewAssetURLSigner([]byte("secret")), "../" + key, signed, now, ErrAssetURLInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := tt.signer.Verify(tt.key, tt.query, tt.now)
+
+			// Assert
+			if tt.expectedErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+// signingStorage is a Storage of the files in keys, recording the last
+// signed URL asked for
+type signingStorage struct {
+	Storage
+	keys []string
+
+	key        string
+	expiration time.Duration
+}
+
+func (s *signingStorage) GetSignedURL(ctx context.Context, key string, expiration time.Duration) (string, error) {
+	for _, k := range s.keys {
+		if k == key {
+			s.key, s.expiration = key, expiration
+			return "/assets/" + key + "?signature=abc", nil
+		}
+	}
+	return "", ErrFileNotFound
+}
+
+func TestAssetService_SignedURL(t *testing.T) {
+	// Arrange
+	storage := &signingStorage{keys: []string{"projects/project-1/assets/map.png"}}
+	service := NewAssetService(&publicProjects{}, storage, time.Hour)
+	before := time.Now()
+
+	// Act
+	signedURL, expiresAt, err := service.SignedURL(context.Background(), "project-1", "map.png", 2*time.Hour)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "/assets/projects/project-1/assets/map.png?signature=abc", signedURL)
+	assert.Equal(t, time.Hour, storage.expiration, "the duration is capped at the maximum")
+	assert.WithinDuration(t, before.Add(time.Hour), expiresAt, time.Second)
+}
+
+func TestAssetService_SignedURL_NotFound(t *testing.T) {
+	tests := []struct {
+		name        string
+		projects    ProjectStore
+		asset       string
+		expectedErr error
+	}{
+		{"unknown project", projectsWithout{}, "map.png", ErrProjectNotFound},
+		{"unknown asset", &publicProjects{}, "chart.png", ErrFileNotFound},
+		{"name out of the project's assets", &publicProjects{}, "../../project-2/assets/map.png", ErrFileNotFound},
+		{"parent directory", &publicProjects{}, "..", ErrFileNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			storage := &signingStorage{keys: []string{"projects/project-1/assets/map.png", "projects/project-2/assets/map.png"}}
+			service := NewAssetService(tt.projects, storage, time.Hour)
+
+			// Act
+			_, _, err := service.SignedURL(context.Background(), "project-1", tt.asset, time.Minute)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Empty(t, storage.key, "nothing is signed")
+		})
+	}
+}
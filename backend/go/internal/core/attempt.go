@@ -0,0 +1,415 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Domain errors for attempts
+var (
+	// ErrAttemptNotFound is returned when an attempt doesn't exist
+	ErrAttemptNotFound = errors.New("attempt not found")
+
+	// ErrAttemptAlreadySubmitted is returned when answering or submitting
+	// an attempt that was submitted
+	ErrAttemptAlreadySubmitted = errors.New("attempt already submitted")
+
+	// ErrInvalidAnswer is returned, wrapped with the reason, when an answer
+	// doesn't fit its item (see checkAnswer)
+	ErrInvalidAnswer = errors.New("invalid answer")
+
+	// ErrStaleAnswer is returned when saving an answer numbered below the
+	// save the item's answer was last stored by
+	ErrStaleAnswer = errors.New("stale answer")
+)
+
+// Attempt is a learner taking a published project. It takes the project's
+// latest publication when it starts, so answers answer the items as
+// published then, whatever is published later. A preview is an author
+// taking the project's live items instead, published or not.
+type Attempt struct {
+	ID        string
+	ProjectID string
+
+	// PublicationVersion is the publication taken, 0 for a project
+	// published before publications were kept, whose live items are taken
+	PublicationVersion int
+
+	// IsPreview marks an author's preview, which takes the live items
+	// (PublicationVersion is 0). Previews are left out of the project's
+	// attempts and stats, and purged once they are old (see PurgePreviews).
+	IsPreview bool
+
+	// ParticipantID is the signed-in user taking the attempt, "" for an
+	// anonymous learner. Only they can read, answer and submit it.
+	ParticipantID string
+
+	StartedAt time.Time
+
+	// SubmittedAt is when the attempt was submitted, nil until then. A
+	// submitted attempt takes no more answers.
+	SubmittedAt *time.Time
+
+	// Answers are the attempt's answers, one per item answered. Only Get
+	// and Submit read them.
+	Answers []*Answer
+
+	// Score is the points the answers earned out of MaxScore, both nil until
+	// the attempt is submitted
+	Score    *int
+	MaxScore *int
+
+	// Results grade each question of the publication, answered or not, in
+	// item order. They are empty until the attempt is submitted.
+	Results []*ItemResult
+}
+
+// Answer is an attempt's answer to an item
+type Answer struct {
+	AttemptID string
+	ItemID    string
+
+	// Response is the answer, in the shape of the item type's answer, e.g.
+	// types.ChoiceAnswer
+	Response json.RawMessage
+
+	// Sequence numbers the learner's saves of the answer, so one that
+	// arrives after a later save is refused rather than overwriting it. It
+	// is 0 for saves that aren't numbered, which are always kept.
+	Sequence int64
+
+	// AnsweredAt is when the item was last answered
+	AnsweredAt time.Time
+
+	// Feedback grades the answer as it is saved under the practice results
+	// policy, with the item's explanation; nil otherwise
+	Feedback *ItemResult
+}
+
+// AttemptStore persists attempts and their answers. Attempts are not
+// scoped to the organization in the context: their IDs are only given to
+// the learners taking them. Implementations must be safe for concurrent
+// use.
+type AttemptStore interface {
+	// Create stores a new attempt, started now, and returns it.
+	// Returns ErrProjectNotFound if the project doesn't exist.
+	Create(ctx context.Context, attempt *Attempt) (*Attempt, error)
+
+	// Get retrieves an attempt with its answers and results.
+	// Returns ErrAttemptNotFound if the attempt doesn't exist.
+	Get(ctx context.Context, id string) (*Attempt, error)
+
+	// SaveAnswer stores an answer, answered now, in place of the attempt's
+	// earlier answer to the item, and returns it.
+	// Returns ErrAttemptNotFound if the attempt doesn't exist,
+	// ErrAttemptAlreadySubmitted if it was submitted, and ErrStaleAnswer if
+	// the answer is numbered and the earlier one has a higher Sequence.
+	SaveAnswer(ctx context.Context, answer *Answer) (*Answer, error)
+
+	// Submit marks an attempt submitted now with the results of grading
+	// it, scored as the sum of their points, and returns it with its
+	// answers and results. Of concurrent calls, only one submits the
+	// attempt.
+	// Returns ErrAttemptNotFound if the attempt doesn't exist, and
+	// ErrAttemptAlreadySubmitted if it was submitted.
+	Submit(ctx context.Context, id string, results []*ItemResult) (*Attempt, error)
+
+	// ListByProject returns a page of the attempts at a project started in
+	// filter's window, latest first, without their answers or results, and
+	// how many there are in all. Previews are left out. It doesn't check
+	// the project exists.
+	ListByProject(ctx context.Context, projectID string, filter AttemptFilter, limit, offset int) ([]*Attempt, int, error)
+
+	// Stats aggregates the attempts at a project started in filter's
+	// window, previews left out. It doesn't check the project exists.
+	Stats(ctx context.Context, projectID string, filter AttemptFilter) (*ProjectStats, error)
+
+	// PurgePreviews deletes the previews started before cutoff, with their
+	// answers and results, in every project, returning how many.
+	PurgePreviews(ctx context.Context, cutoff time.Time) (int64, error)
+
+	// ListSubmitted returns up to limit submitted attempts with their
+	// results, in ID order after afterID, "" to start from the first.
+	// Previews are left out. It keeps the attempts at projectID, or at
+	// every project when it is "", started in filter's window.
+	ListSubmitted(ctx context.Context, projectID string, filter AttemptFilter, afterID string, limit int) ([]*Attempt, error)
+
+	// EraseAttempts deletes, or with RetentionModeAnonymize strips the
+	// participant of, up to limit of the attempts selection picks, oldest
+	// first, with the rows they take along, and counts them
+	EraseAttempts(ctx context.Context, selection AttemptSelection, mode RetentionMode, limit int) (*ErasureReport, error)
+}
+
+// AttemptService runs learners' attempts at published projects: it starts
+// them, checks and keeps their answers, and submits and grades them
+type AttemptService struct {
+	store    AttemptStore
+	projects ProjectStore
+	items    ItemStore
+	grading  *GradingService
+	now      func() time.Time
+}
+
+// NewAttemptService creates a new attempt service. The items answer the
+// attempts at projects published before publications were kept.
+func NewAttemptService(store AttemptStore, projects ProjectStore, items ItemStore) *AttemptService {
+	return &AttemptService{
+		store:    store,
+		projects: projects,
+		items:    items,
+		grading:  NewGradingService(),
+		now:      time.Now,
+	}
+}
+
+// Start starts an attempt at the latest publication of a project, by the
+// signed-in user participantID, or by an anonymous learner if it is "".
+// Returns ErrProjectNotFound unless the project is published.
+func (s *AttemptService) Start(ctx context.Context, projectID, participantID string) (*Attempt, error) {
+	ctx, span := startSpan(ctx, "AttemptService.Start", attribute.String("project.id", projectID))
+	defer span.End()
+
+	_, project, err := openPublished(ctx, s.projects, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.Create(ctx, &Attempt{
+		ProjectID:          project.ID,
+		PublicationVersion: latestVersion(project),
+		ParticipantID:      participantID,
+	})
+}
+
+// StartPreview starts a preview of a project of the organization in ctx by
+// its author participantID, published or not. The preview takes the
+// project's live items, as they are when each answer is saved and when it
+// is submitted, and is answered and submitted like any attempt.
+// Returns ErrProjectNotFound if the project doesn't exist.
+func (s *AttemptService) StartPreview(ctx context.Context, projectID, participantID string) (*Attempt, error) {
+	ctx, span := startSpan(ctx, "AttemptService.StartPreview", attribute.String("project.id", projectID))
+	defer span.End()
+
+	project, err := s.projects.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.Create(ctx, &Attempt{
+		ProjectID:     project.ID,
+		ParticipantID: participantID,
+		IsPreview:     true,
+	})
+}
+
+// Get returns an attempt with its answers, and its score and results once
+// it is submitted, as far as the project's results policy shows them (see
+// DiscloseResults). The results have no explanations.
+// Returns ErrAttemptNotFound for another user's attempt (see openAttempt).
+func (s *AttemptService) Get(ctx context.Context, id string) (*Attempt, error) {
+	ctx, span := startSpan(ctx, "AttemptService.Get", attribute.String("attempt.id", id))
+	defer span.End()
+
+	attempt, err := s.openAttempt(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	_, project, err := s.attemptProject(ctx, attempt)
+	if err != nil {
+		return nil, err
+	}
+	s.withhold(attempt, project)
+	return attempt, nil
+}
+
+// SaveAnswer answers an item of the publication an attempt takes, in place
+// of any earlier answer to it. sequence numbers the learner's saves of the
+// answer, or is 0 if they aren't numbered. Under the practice results
+// policy the saved answer carries its grade as Feedback. Returns
+// ErrItemNotFound unless the publication has the item, ErrInvalidAnswer if
+// response doesn't answer it, ErrStaleAnswer if a later save was stored
+// already and ErrAttemptAlreadySubmitted once the attempt is submitted.
+// Returns ErrAttemptNotFound for another user's attempt (see openAttempt).
+func (s *AttemptService) SaveAnswer(ctx context.Context, attemptID, itemID string, response json.RawMessage, sequence int64) (*Answer, error) {
+	ctx, span := startSpan(ctx, "AttemptService.SaveAnswer",
+		attribute.String("attempt.id", attemptID),
+		attribute.String("item.id", itemID))
+	defer span.End()
+
+	attempt, err := s.openAttempt(ctx, attemptID)
+	if err != nil {
+		return nil, err
+	}
+	if attempt.SubmittedAt != nil {
+		return nil, ErrAttemptAlreadySubmitted
+	}
+
+	itemsCtx, project, err := s.attemptProject(ctx, attempt)
+	if err != nil {
+		return nil, err
+	}
+	item, err := s.attemptItem(itemsCtx, attempt, project, itemID)
+	if err != nil {
+		return nil, err
+	}
+	response, err = checkAnswer(item, response)
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := s.store.SaveAnswer(ctx, &Answer{
+		AttemptID: attempt.ID,
+		ItemID:    item.ID,
+		Response:  response,
+		Sequence:  sequence,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if isQuestion(item.Type) && DiscloseResults(projectResults(project), false, s.now()).Feedback {
+		saved.Feedback = s.grade(item, saved.Response)
+		saved.Feedback.Explanation = item.Explanation
+	}
+	return saved, nil
+}
+
+// Submit submits an attempt, after which it takes no more answers, and
+// grades it: every question of its publication is graded, unanswered ones
+// earning nothing, and the attempt scores the points earned. The attempt
+// returned shows its score and results, which carry the items'
+// explanations, as far as the project's results policy does.
+// Returns ErrAttemptAlreadySubmitted if it was submitted before, and
+// ErrAttemptNotFound for another user's attempt (see openAttempt).
+func (s *AttemptService) Submit(ctx context.Context, id string) (*Attempt, error) {
+	ctx, span := startSpan(ctx, "AttemptService.Submit", attribute.String("attempt.id", id))
+	defer span.End()
+
+	attempt, err := s.openAttempt(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if attempt.SubmittedAt != nil {
+		return nil, ErrAttemptAlreadySubmitted
+	}
+	itemsCtx, project, err := s.attemptProject(ctx, attempt)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.attemptItems(itemsCtx, attempt, project)
+	if err != nil {
+		return nil, err
+	}
+
+	answers := make(map[string]json.RawMessage, len(attempt.Answers))
+	for _, answer := range attempt.Answers {
+		answers[answer.ItemID] = answer.Response
+	}
+	results := []*ItemResult{}
+	explanations := make(map[string]*string, len(items))
+	for _, item := range items {
+		if !isQuestion(item.Type) {
+			continue
+		}
+		results = append(results, s.grade(item, answers[item.ID]))
+		explanations[item.ID] = item.Explanation
+	}
+
+	submitted, err := s.store.Submit(ctx, id, results)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range submitted.Results {
+		result.Explanation = explanations[result.ItemID]
+	}
+	s.withhold(submitted, project)
+	return submitted, nil
+}
+
+// grade grades an answer to a question, or its lack, without the item's
+// explanation
+func (s *AttemptService) grade(item *Item, answer json.RawMessage) *ItemResult {
+	earned, possible, correct := s.grading.Grade(item, answer)
+	return &ItemResult{
+		ItemID:   item.ID,
+		Earned:   earned,
+		Possible: possible,
+		Correct:  correct,
+	}
+}
+
+// withhold clears what the project's results policy doesn't show of an
+// attempt now
+func (s *AttemptService) withhold(attempt *Attempt, project *Project) {
+	disclosure := DiscloseResults(projectResults(project), attempt.SubmittedAt != nil, s.now())
+	if !disclosure.Score {
+		attempt.Score, attempt.MaxScore = nil, nil
+	}
+	if !disclosure.Results {
+		attempt.Results = nil
+	}
+}
+
+// openAttempt reads an attempt for the caller in ctx. An anonymous
+// learner's attempt is open to whoever has its ID, but a signed-in user's
+// only to them and the system: to anyone else it doesn't exist, so its ID
+// gives nothing away.
+func (s *AttemptService) openAttempt(ctx context.Context, id string) (*Attempt, error) {
+	attempt, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	scope := AccessScopeFromContext(ctx)
+	if attempt.ParticipantID != "" && !scope.System && scope.UserID != attempt.ParticipantID {
+		return nil, ErrAttemptNotFound
+	}
+	return attempt, nil
+}
+
+// attemptProject reads the project an attempt is at, with its results
+// policy, and returns the context to read its items in. The project must
+// still be published, unless the attempt is a preview. A preview's project
+// is read within its organization but as the caller, who must still be a
+// member of it, since the public endpoints answering attempts aren't
+// scoped to an organization.
+func (s *AttemptService) attemptProject(ctx context.Context, attempt *Attempt) (context.Context, *Project, error) {
+	if !attempt.IsPreview {
+		return openPublished(ctx, s.projects, attempt.ProjectID)
+	}
+
+	orgID, err := s.projects.OrgID(ctx, attempt.ProjectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx = WithOrgID(ctx, orgID)
+
+	project, err := s.projects.GetByID(ctx, attempt.ProjectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ctx, project, nil
+}
+
+// attemptItems returns the items of the publication an attempt takes, in
+// position order, read in the context attemptProject returned with the
+// project. A preview takes the live items.
+func (s *AttemptService) attemptItems(ctx context.Context, attempt *Attempt, project *Project) ([]*Item, error) {
+	_, items, err := publishedItems(ctx, s.projects, s.items, project, attempt.PublicationVersion)
+	return items, err
+}
+
+// attemptItem returns an item of the publication an attempt takes, or
+// ErrItemNotFound
+func (s *AttemptService) attemptItem(ctx context.Context, attempt *Attempt, project *Project, itemID string) (*Item, error) {
+	items, err := s.attemptItems(ctx, attempt, project)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.ID == itemID {
+			return item, nil
+		}
+	}
+	return nil, ErrItemNotFound
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// AttemptFilter narrows the attempts at a project to those started in a
+// window. A nil bound leaves the window open on its side.
+type AttemptFilter struct {
+	// From keeps attempts started at or after it
+	From *time.Time
+	// To keeps attempts started before it
+	To *time.Time
+}
+
+// ProjectStats aggregates the attempts at a project. Only submitted
+// attempts count towards scores and items.
+type ProjectStats struct {
+	// Attempts is how many attempts started, Submitted how many of them
+	// were submitted
+	Attempts  int
+	Submitted int
+
+	// AverageScore is the mean score of the submitted attempts, nil if
+	// there are none
+	AverageScore *float64
+
+	// Items aggregate the graded questions, in item order
+	Items []*ItemStats
+}
+
+// ItemStats aggregates the submitted attempts' grades and answers of a
+// question
+type ItemStats struct {
+	ItemID string
+
+	// Answered is how many attempts answered the item, and Graded how many
+	// were graded on it, answered or not
+	Answered int
+	Graded   int
+
+	// PercentCorrect is the share of the graded attempts whose answer was
+	// correct, from 0 to 100, and AveragePoints the mean points they earned
+	PercentCorrect float64
+	AveragePoints  float64
+
+	// Choices count the attempts choosing each option of a choice or
+	// multi_choice item, among options chosen at least once, by option ID.
+	// They are empty for other item types.
+	Choices []*ChoiceCount
+}
+
+// ChoiceCount is how many attempts chose an option
+type ChoiceCount struct {
+	ChoiceID string
+	Count    int
+}
+
+// ListByProject returns a page of the attempts at a project of the
+// organization in ctx, latest first, and how many there are in all.
+// Returns ErrProjectNotFound if the project doesn't exist.
+func (s *AttemptService) ListByProject(ctx context.Context, projectID string, filter AttemptFilter, limit, offset int) ([]*Attempt, int, error) {
+	ctx, span := startSpan(ctx, "AttemptService.ListByProject", attribute.String("project.id", projectID))
+	defer span.End()
+
+	if _, err := s.projects.GetByID(ctx, projectID); err != nil {
+		return nil, 0, err
+	}
+	return s.store.ListByProject(ctx, projectID, filter, limit, offset)
+}
+
+// Stats aggregates the attempts at a project of the organization in ctx.
+// Returns ErrProjectNotFound if the project doesn't exist.
+func (s *AttemptService) Stats(ctx context.Context, projectID string, filter AttemptFilter) (*ProjectStats, error) {
+	ctx, span := startSpan(ctx, "AttemptService.Stats", attribute.String("project.id", projectID))
+	defer span.End()
+
+	if _, err := s.projects.GetByID(ctx, projectID); err != nil {
+		return nil, err
+	}
+	return s.store.Stats(ctx, projectID, filter)
+}
@@ -0,0 +1,421 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// memoryAttempts is an AttemptStore in memory, without lists or stats
+type memoryAttempts struct {
+	AttemptStore
+	attempts map[string]*Attempt
+}
+
+func newMemoryAttempts() *memoryAttempts {
+	return &memoryAttempts{attempts: map[string]*Attempt{}}
+}
+
+func (s *memoryAttempts) Create(ctx context.Context, attempt *Attempt) (*Attempt, error) {
+	created := *attempt
+	created.ID = "attempt-1"
+	created.StartedAt = time.Now()
+	created.Answers = []*Answer{}
+	s.attempts[created.ID] = &created
+	return &created, nil
+}
+
+func (s *memoryAttempts) Get(ctx context.Context, id string) (*Attempt, error) {
+	attempt, ok := s.attempts[id]
+	if !ok {
+		return nil, ErrAttemptNotFound
+	}
+	return attempt, nil
+}
+
+func (s *memoryAttempts) SaveAnswer(ctx context.Context, answer *Answer) (*Answer, error) {
+	attempt, err := s.Get(ctx, answer.AttemptID)
+	if err != nil {
+		return nil, err
+	}
+	saved := *answer
+	saved.AnsweredAt = time.Now()
+	attempt.Answers = append(attempt.Answers, &saved)
+	return &saved, nil
+}
+
+func (s *memoryAttempts) Submit(ctx context.Context, id string, results []*ItemResult) (*Attempt, error) {
+	attempt, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if attempt.SubmittedAt != nil {
+		return nil, ErrAttemptAlreadySubmitted
+	}
+	now := time.Now()
+	score, maxScore := 0, 0
+	for _, result := range results {
+		score += result.Earned
+		maxScore += result.Possible
+	}
+	attempt.SubmittedAt = &now
+	attempt.Score, attempt.MaxScore = &score, &maxScore
+	attempt.Results = results
+	return attempt, nil
+}
+
+// attemptPublication is version 2 of "project-1", of one choice item
+func attemptPublication() *Publication {
+	return &Publication{
+		ProjectID: "project-1",
+		Version:   2,
+		Project:   &Project{ID: "project-1", Title: "Capitals"},
+		Items: []*Item{{
+			ID:      "item-1",
+			Type:    types.ItemTypeChoice,
+			Content: json.RawMessage(`{"choices":[{"id":"a","text":"Paris","correct":true},{"id":"b","text":"Lyon"}]}`),
+		}},
+	}
+}
+
+func TestAttemptService_Start(t *testing.T) {
+	// Arrange
+	service := NewAttemptService(newMemoryAttempts(), &publicProjects{published: true, publication: attemptPublication()}, nil)
+
+	// Act
+	attempt, err := service.Start(context.Background(), "project-1", "user-1")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "project-1", attempt.ProjectID)
+	assert.Equal(t, 2, attempt.PublicationVersion, "the attempt takes the latest publication")
+	assert.Equal(t, "user-1", attempt.ParticipantID)
+	assert.Nil(t, attempt.SubmittedAt)
+}
+
+func TestAttemptService_Start_NotPublished(t *testing.T) {
+	// Arrange
+	service := NewAttemptService(newMemoryAttempts(), &publicProjects{}, nil)
+
+	// Act
+	_, err := service.Start(context.Background(), "project-1", "")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+}
+
+func TestAttemptService_SaveAnswer(t *testing.T) {
+	tests := []struct {
+		name        string
+		itemID      string
+		response    string
+		submitted   bool
+		expectedErr error
+	}{
+		{
+			name:     "answer",
+			itemID:   "item-1",
+			response: `{"choice_id":"b"}`,
+		},
+		{
+			name:        "item the publication doesn't have",
+			itemID:      "item-2",
+			response:    `{"choice_id":"b"}`,
+			expectedErr: ErrItemNotFound,
+		},
+		{
+			name:        "answer that doesn't fit the item",
+			itemID:      "item-1",
+			response:    `{"choice_id":"z"}`,
+			expectedErr: ErrInvalidAnswer,
+		},
+		{
+			name:        "submitted attempt",
+			itemID:      "item-1",
+			response:    `{"choice_id":"b"}`,
+			submitted:   true,
+			expectedErr: ErrAttemptAlreadySubmitted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			attempts := newMemoryAttempts()
+			service := NewAttemptService(attempts, &publicProjects{published: true, publication: attemptPublication()}, nil)
+			attempt, err := service.Start(ctx, "project-1", "")
+			require.NoError(t, err)
+			if tt.submitted {
+				_, err := service.Submit(ctx, attempt.ID)
+				require.NoError(t, err)
+			}
+
+			// Act
+			answer, err := service.SaveAnswer(ctx, attempt.ID, tt.itemID, json.RawMessage(tt.response), 0)
+
+			// Assert
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Empty(t, attempts.attempts[attempt.ID].Answers, "nothing is saved")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "item-1", answer.ItemID)
+			assert.JSONEq(t, tt.response, string(answer.Response))
+		})
+	}
+}
+
+func TestAttemptService_SaveAnswer_AttemptNotFound(t *testing.T) {
+	// Arrange
+	service := NewAttemptService(newMemoryAttempts(), &publicProjects{published: true, publication: attemptPublication()}, nil)
+
+	// Act
+	_, err := service.SaveAnswer(context.Background(), "missing", "item-1", json.RawMessage(`{"choice_id":"a"}`), 0)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrAttemptNotFound)
+}
+
+func TestAttemptService_ParticipantsOwnTheirAttempts(t *testing.T) {
+	tests := []struct {
+		name   string
+		scope  *AccessScope
+		opened bool
+	}{
+		{name: "participant", scope: &AccessScope{UserID: "user-1"}, opened: true},
+		{name: "system", scope: &AccessScope{System: true}, opened: true},
+		{name: "another user", scope: &AccessScope{UserID: "user-2"}},
+		{name: "operator", scope: &AccessScope{UserID: "admin-1", Role: UserRoleAdmin}},
+		{name: "anonymous learner"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			attempts := newMemoryAttempts()
+			service := NewAttemptService(attempts, &publicProjects{published: true, publication: attemptPublication()}, nil)
+			attempt, err := service.Start(context.Background(), "project-1", "user-1")
+			require.NoError(t, err)
+			ctx := context.Background()
+			if tt.scope != nil {
+				ctx = WithAccessScope(ctx, *tt.scope)
+			}
+
+			// Act
+			_, getErr := service.Get(ctx, attempt.ID)
+			_, saveErr := service.SaveAnswer(ctx, attempt.ID, "item-1", json.RawMessage(`{"choice_id":"a"}`), 0)
+			_, submitErr := service.Submit(ctx, attempt.ID)
+
+			// Assert
+			if tt.opened {
+				assert.NoError(t, getErr)
+				assert.NoError(t, saveErr)
+				assert.NoError(t, submitErr)
+				return
+			}
+			assert.ErrorIs(t, getErr, ErrAttemptNotFound)
+			assert.ErrorIs(t, saveErr, ErrAttemptNotFound)
+			assert.ErrorIs(t, submitErr, ErrAttemptNotFound)
+			assert.Empty(t, attempts.attempts[attempt.ID].Answers, "nothing is saved")
+			assert.Nil(t, attempts.attempts[attempt.ID].SubmittedAt, "the attempt isn't submitted")
+		})
+	}
+}
+
+func TestAttemptService_SaveAnswer_LiveItems(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	live := &Item{ID: "live", Type: types.ItemTypeTextEntry, Content: json.RawMessage(`{"multiline":false}`)}
+	service := NewAttemptService(newMemoryAttempts(), &publicProjects{published: true}, &publishItems{items: []*Item{live}})
+	attempt, err := service.Start(ctx, "project-1", "")
+	require.NoError(t, err)
+
+	// Act
+	answer, err := service.SaveAnswer(ctx, attempt.ID, "live", json.RawMessage(`{"text":"Madrid"}`), 0)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 0, attempt.PublicationVersion)
+	assert.JSONEq(t, `{"text":"Madrid"}`, string(answer.Response))
+}
+
+func TestAttemptService_Submit_GradesTheAttempt(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	two, one := 2, 1
+	explanation := "Paris has been the capital since 508"
+	publication := attemptPublication()
+	publication.Items[0].Points = &two
+	publication.Items[0].Explanation = &explanation
+	publication.Items = append([]*Item{
+		{ID: "intro", Type: types.ItemTypeTitle, Content: json.RawMessage(`{"text":"Capitals"}`)},
+	}, append(publication.Items, &Item{
+		ID:       "item-2",
+		Type:     types.ItemTypeTextEntry,
+		Content:  json.RawMessage(`{"multiline":false,"correct_answer":"Madrid"}`),
+		Required: true,
+		Points:   &one,
+	})...)
+	service := NewAttemptService(newMemoryAttempts(), &publicProjects{published: true, publication: publication}, nil)
+	attempt, err := service.Start(ctx, "project-1", "")
+	require.NoError(t, err)
+	_, err = service.SaveAnswer(ctx, attempt.ID, "item-1", json.RawMessage(`{"choice_id":"a"}`), 0)
+	require.NoError(t, err)
+
+	// Act
+	submitted, err := service.Submit(ctx, attempt.ID)
+	_, resubmitErr := service.Submit(ctx, attempt.ID)
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, submitted.SubmittedAt)
+	assert.Equal(t, 2, *submitted.Score)
+	assert.Equal(t, 3, *submitted.MaxScore)
+	assert.Equal(t, []*ItemResult{
+		{ItemID: "item-1", Earned: 2, Possible: 2, Correct: true, Explanation: &explanation},
+		{ItemID: "item-2", Earned: 0, Possible: 1, Correct: false},
+	}, submitted.Results, "the title is not graded and the unanswered question earns nothing")
+	assert.ErrorIs(t, resubmitErr, ErrAttemptAlreadySubmitted)
+}
+
+func TestAttemptService_ResultsPolicy(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name            string
+		settings        *ResultsSettings
+		expectedScore   bool
+		expectedResults bool
+	}{
+		{name: "default", expectedScore: true, expectedResults: true},
+		{name: "none", settings: &ResultsSettings{Policy: ResultsPolicyNone}},
+		{name: "score_only", settings: &ResultsSettings{Policy: ResultsPolicyScoreOnly}, expectedScore: true},
+		{name: "after_close_date before the date", settings: &ResultsSettings{Policy: ResultsPolicyAfterCloseDate, AvailableAt: &future}},
+		{name: "after_close_date after the date", settings: &ResultsSettings{Policy: ResultsPolicyAfterCloseDate, AvailableAt: &past}, expectedScore: true, expectedResults: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			service := NewAttemptService(newMemoryAttempts(), &publicProjects{published: true, publication: attemptPublication(), results: tt.settings}, nil)
+			service.now = func() time.Time { return now }
+			attempt, err := service.Start(ctx, "project-1", "")
+			require.NoError(t, err)
+			saved, err := service.SaveAnswer(ctx, attempt.ID, "item-1", json.RawMessage(`{"choice_id":"a"}`), 0)
+			require.NoError(t, err)
+
+			// Act
+			submitted, err := service.Submit(ctx, attempt.ID)
+			require.NoError(t, err)
+			read, readErr := service.Get(ctx, attempt.ID)
+
+			// Assert
+			require.NoError(t, readErr)
+			assert.Nil(t, saved.Feedback, "only practice grades answers as they are saved")
+			for _, got := range []*Attempt{submitted, read} {
+				assert.Equal(t, tt.expectedScore, got.Score != nil)
+				assert.Equal(t, tt.expectedScore, got.MaxScore != nil)
+				assert.Equal(t, tt.expectedResults, got.Results != nil)
+			}
+		})
+	}
+}
+
+func TestAttemptService_SaveAnswer_PracticeFeedback(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	one := 1
+	explanation := "Paris has been the capital since 508"
+	publication := attemptPublication()
+	publication.Items[0].Points = &one
+	publication.Items[0].Explanation = &explanation
+	service := NewAttemptService(newMemoryAttempts(), &publicProjects{published: true, publication: publication, results: &ResultsSettings{Policy: ResultsPolicyPractice}}, nil)
+	attempt, err := service.Start(ctx, "project-1", "")
+	require.NoError(t, err)
+
+	// Act
+	wrong, err := service.SaveAnswer(ctx, attempt.ID, "item-1", json.RawMessage(`{"choice_id":"b"}`), 0)
+	require.NoError(t, err)
+	right, err := service.SaveAnswer(ctx, attempt.ID, "item-1", json.RawMessage(`{"choice_id":"a"}`), 0)
+	require.NoError(t, err)
+	inProgress, err := service.Get(ctx, attempt.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, &ItemResult{ItemID: "item-1", Earned: 0, Possible: 1, Correct: false, Explanation: &explanation}, wrong.Feedback)
+	assert.Equal(t, &ItemResult{ItemID: "item-1", Earned: 1, Possible: 1, Correct: true, Explanation: &explanation}, right.Feedback)
+	assert.Nil(t, inProgress.Score, "the score waits for the attempt to be submitted")
+}
+
+func TestAttemptService_StartPreview(t *testing.T) {
+	// Arrange
+	ctx := WithAccessScope(context.Background(), AccessScope{UserID: "author-1"})
+	one := 1
+	live := &Item{ID: "live", Type: types.ItemTypeTextEntry, Content: json.RawMessage(`{"multiline":false,"correct_answer":"Madrid"}`), Points: &one}
+	projects := &publicProjects{publication: attemptPublication()}
+	service := NewAttemptService(newMemoryAttempts(), projects, &publishItems{items: []*Item{live}})
+
+	// Act
+	attempt, err := service.StartPreview(ctx, "project-1", "author-1")
+	require.NoError(t, err)
+	_, saveErr := service.SaveAnswer(ctx, attempt.ID, "live", json.RawMessage(`{"text":"Madrid"}`), 0)
+	_, publishedErr := service.SaveAnswer(ctx, attempt.ID, "item-1", json.RawMessage(`{"choice_id":"a"}`), 0)
+	submitted, submitErr := service.Submit(ctx, attempt.ID)
+
+	// Assert
+	assert.True(t, attempt.IsPreview)
+	assert.Equal(t, "author-1", attempt.ParticipantID)
+	assert.Equal(t, 0, attempt.PublicationVersion, "a preview takes the live items")
+	require.NoError(t, saveErr, "the project isn't published, and needn't be")
+	assert.ErrorIs(t, publishedErr, ErrItemNotFound, "the publication's items aren't taken")
+	require.NoError(t, submitErr)
+	assert.Equal(t, 1, *submitted.Score)
+	assert.Equal(t, "org-1", projects.orgIDs[len(projects.orgIDs)-1], "the items are read in the project's organization")
+	assert.Equal(t, AccessScope{UserID: "author-1"}, projects.scopes[len(projects.scopes)-1], "the items are read as the author")
+}
+
+func TestAttemptService_StartPreview_UnknownProject(t *testing.T) {
+	// Arrange
+	attempts := newMemoryAttempts()
+	service := NewAttemptService(attempts, projectsWithout{}, nil)
+
+	// Act
+	_, err := service.StartPreview(context.Background(), "project-1", "author-1")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+	assert.Empty(t, attempts.attempts, "no preview is started")
+}
+
+// projectsWithout is a ProjectStore of no projects
+type projectsWithout struct {
+	ProjectStore
+}
+
+func (projectsWithout) GetByID(ctx context.Context, id string) (*Project, error) {
+	return nil, ErrProjectNotFound
+}
+
+func TestAttemptService_Analytics_UnknownProject(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	service := NewAttemptService(newMemoryAttempts(), projectsWithout{}, nil)
+
+	// Act
+	_, _, listErr := service.ListByProject(ctx, "project-1", AttemptFilter{}, 20, 0)
+	_, statsErr := service.Stats(ctx, "project-1", AttemptFilter{})
+
+	// Assert
+	assert.ErrorIs(t, listErr, ErrProjectNotFound)
+	assert.ErrorIs(t, statsErr, ErrProjectNotFound)
+}
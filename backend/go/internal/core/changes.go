@@ -0,0 +1,42 @@
+package core
+
+import "context"
+
+// Entities whose changes are announced to every replica
+const (
+	// ChangeEntityProject covers a project and its items: an item change is
+	// announced as an update of its project
+	ChangeEntityProject = "project"
+	// ChangeEntitySettings covers the runtime setting overrides as a whole
+	ChangeEntitySettings = "settings"
+	// ChangeEntityItemLocks covers the edit locks on a project's items; the
+	// change ID is the project's
+	ChangeEntityItemLocks = "item_locks"
+	// ChangeEntityAttempts covers the submitted attempts at a project; the
+	// change ID is the project's
+	ChangeEntityAttempts = "attempts"
+)
+
+// Change actions
+const (
+	ChangeActionUpdated = "updated"
+	ChangeActionDeleted = "deleted"
+)
+
+// Change is a committed write that replicas may hold a cached copy of
+type Change struct {
+	Entity string `json:"entity"`
+	ID     string `json:"id,omitempty"`
+	Action string `json:"action"`
+}
+
+// ChangeSubscriber keeps a per-replica cache current with writes made
+// through any replica
+type ChangeSubscriber interface {
+	// ApplyChange drops or reloads what is cached about change
+	ApplyChange(ctx context.Context, change Change) error
+
+	// Resync drops or reloads everything cached, after changes may have
+	// been missed
+	Resync(ctx context.Context) error
+}
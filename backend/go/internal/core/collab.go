@@ -0,0 +1,23 @@
+package core
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrCollaborationDisabled is returned when real-time collaboration is
+// turned off with the enable_collaboration setting
+var ErrCollaborationDisabled = errors.New("collaboration is disabled")
+
+// CollabStore persists the shared editing document of each project, so
+// collaborative sessions survive restarts. The state is opaque to the
+// store. Rows are keyed by project ID alone: callers check access to the
+// project before reaching the document. Implementations must be safe for
+// concurrent use.
+type CollabStore interface {
+	// Load returns the project's saved state, or nil if it has none
+	Load(ctx context.Context, projectID string) ([]byte, error)
+
+	// Save replaces the project's saved state
+	Save(ctx context.Context, projectID string, state []byte) error
+}
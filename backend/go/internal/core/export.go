@@ -0,0 +1,9 @@
+package core
+
+import "errors"
+
+var (
+	// ErrExportUnsupportedItem is returned when a project has an item the
+	// export format can't represent.
+	ErrExportUnsupportedItem = errors.New("item not supported by the export format")
+)
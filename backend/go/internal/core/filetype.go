@@ -0,0 +1,196 @@
+package core
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// sniffLen is how much of a file its type is detected from, all that
+// http.DetectContentType considers
+const sniffLen = 512
+
+// contentTypeAliases maps other names of a type to the one types are
+// compared by
+var contentTypeAliases = map[string]string{
+	"audio/wave":  "audio/wav",
+	"audio/x-wav": "audio/wav",
+	"image/jpg":   "image/jpeg",
+}
+
+// DetectContentType returns the type of a file from its first bytes, as
+// http.DetectContentType does, without parameters. It also recognizes SVG
+// images and MP3 files without an ID3 tag. Unknown content is
+// "application/octet-stream".
+func DetectContentType(head []byte) string {
+	detected := canonicalContentType(http.DetectContentType(head))
+	switch {
+	case len(head) >= 12 && bytes.Equal(head[:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WEBP")):
+		return "image/webp"
+	case detected == "application/octet-stream" && len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0:
+		// The sync bits of an MPEG audio frame; JPEG's 0xFF 0xD8 lacks them
+		return "audio/mpeg"
+	case strings.HasPrefix(detected, "text/") && isSVG(head):
+		return "image/svg+xml"
+	}
+	return detected
+}
+
+// isSVG reports whether the first element of the XML document starting
+// with head is an svg element. Only as much as head holds is parsed, so an
+// SVG image whose prolog fills it isn't recognized.
+func isSVG(head []byte) bool {
+	decoder := xml.NewDecoder(bytes.NewReader(bytes.TrimPrefix(head, []byte("\xEF\xBB\xBF"))))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local == "svg"
+		}
+	}
+}
+
+// canonicalContentType returns contentType without parameters, by the name
+// types are compared by
+func canonicalContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	if alias, ok := contentTypeAliases[mediaType]; ok {
+		return alias
+	}
+	return mediaType
+}
+
+// isAllowedContentType reports whether contentType is one of allowedTypes,
+// or allowedTypes is empty
+func isAllowedContentType(contentType string, allowedTypes []string) bool {
+	if len(allowedTypes) == 0 {
+		return true
+	}
+	contentType = canonicalContentType(contentType)
+	for _, allowed := range allowedTypes {
+		if contentType == canonicalContentType(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkContent detects the type of file from its content and fails with
+// ErrInvalidFileType unless that is its declared type and is allowed. With
+// no allowed types, any content is. Files detected or declared as SVG
+// images must also be free of scripts, whatever else they hold. It reads
+// file.Reader, and replaces it with a reader of the whole file.
+func checkContent(file *FileUpload, maxSize int64, allowedTypes []string) error {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file.Reader, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	head = head[:n]
+	file.Reader = io.MultiReader(bytes.NewReader(head), file.Reader)
+
+	detected := DetectContentType(head)
+	declared := canonicalContentType(file.ContentType)
+	if detected != "image/svg+xml" && declared != "image/svg+xml" {
+		return checkContentType(detected, declared, allowedTypes)
+	}
+
+	// The whole image is checked, so it is read into memory, up to maxSize
+	// if set
+	reader := file.Reader
+	if maxSize > 0 {
+		reader = io.LimitReader(reader, maxSize+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if maxSize > 0 && int64(len(data)) > maxSize {
+		return fmt.Errorf("%w: file exceeds maximum %d", ErrFileTooBig, maxSize)
+	}
+	// A prolog can be longer than the part types are detected from
+	if strings.HasPrefix(detected, "text/") && isSVG(data) {
+		detected = "image/svg+xml"
+	}
+	if err := checkContentType(detected, declared, allowedTypes); err != nil {
+		return err
+	}
+	if err := checkSVG(data); err != nil {
+		return err
+	}
+	file.Reader = bytes.NewReader(data)
+	return nil
+}
+
+// checkContentType fails with ErrInvalidFileType unless the detected type
+// of a file is its declared type and is one of allowedTypes. With no
+// allowed types, any type is.
+func checkContentType(detected, declared string, allowedTypes []string) error {
+	if len(allowedTypes) == 0 {
+		return nil
+	}
+	if !isAllowedContentType(detected, allowedTypes) {
+		return fmt.Errorf("%w: content is %s, not in allowed types %v", ErrInvalidFileType, detected, allowedTypes)
+	}
+	if detected != declared {
+		return fmt.Errorf("%w: content is %s, not %s", ErrInvalidFileType, detected, declared)
+	}
+	return nil
+}
+
+// checkSVG fails with ErrInvalidFileType if the SVG image in data can run
+// script when it is opened: if it has a script element, an event handler
+// attribute, or an attribute with a javascript: URL, such as a link's href
+// or an animation setting one. foreignObject elements, which embed HTML,
+// fail too. So does an image that isn't well-formed XML: what a browser
+// recovers from it is unknown.
+func checkSVG(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: malformed SVG: %v", ErrInvalidFileType, err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(start.Name.Local, "script") || strings.EqualFold(start.Name.Local, "foreignObject") {
+			return fmt.Errorf("%w: SVG contains a %s element", ErrInvalidFileType, start.Name.Local)
+		}
+		for _, attr := range start.Attr {
+			if strings.HasPrefix(strings.ToLower(attr.Name.Local), "on") {
+				return fmt.Errorf("%w: SVG contains a %s event handler", ErrInvalidFileType, attr.Name.Local)
+			}
+			if hasJavaScriptURL(attr.Value) {
+				return fmt.Errorf("%w: SVG contains a javascript: URL in %s", ErrInvalidFileType, attr.Name.Local)
+			}
+		}
+	}
+}
+
+// hasJavaScriptURL reports whether an attribute value holds a javascript:
+// URL, as browsers parse one: whatever the case, and with the whitespace
+// and control characters they skip removed
+func hasJavaScriptURL(value string) bool {
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7F {
+			return -1
+		}
+		return r
+	}, value)
+	return strings.Contains(strings.ToLower(value), "javascript:")
+}
@@ -0,0 +1,220 @@
+package core
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// ItemResult is the grade of an attempt's answer to a question
+type ItemResult struct {
+	ItemID string
+
+	// Earned is the points the answer earned out of Possible, the item's
+	// points: all of them for a correct answer, none otherwise
+	Earned   int
+	Possible int
+	Correct  bool
+
+	// Explanation is the item's, shown to the learner with the results of
+	// a submitted attempt or with practice feedback. Stores don't keep it.
+	Explanation *string
+}
+
+// GradingService grades answers against the answer keys of their items
+type GradingService struct{}
+
+// NewGradingService creates a new grading service
+func NewGradingService() *GradingService {
+	return &GradingService{}
+}
+
+// isQuestion reports whether learners answer items of the type
+func isQuestion(itemType types.ItemType) bool {
+	switch itemType {
+	case types.ItemTypeChoice, types.ItemTypeMultiChoice, types.ItemTypeTextEntry,
+		types.ItemTypeOrdering, types.ItemTypeHotspot:
+		return true
+	}
+	return false
+}
+
+// Grade grades answer, in the shape of the item type's answer, or nil for
+// an unanswered item. A correct answer earns the item's points; anything
+// else, including an answer or content that doesn't decode, earns none.
+// Items without points, and title and media items, are worth none.
+func (s *GradingService) Grade(item *Item, answer json.RawMessage) (earned, possible int, correct bool) {
+	if item.Points != nil && isQuestion(item.Type) {
+		possible = *item.Points
+	}
+	if len(answer) == 0 {
+		return 0, possible, false
+	}
+
+	switch item.Type {
+	case types.ItemTypeChoice:
+		correct = gradeChoice(item, answer)
+	case types.ItemTypeMultiChoice:
+		correct = gradeMultiChoice(item, answer)
+	case types.ItemTypeTextEntry:
+		correct = gradeTextEntry(item, answer)
+	case types.ItemTypeOrdering:
+		correct = gradeOrdering(item, answer)
+	case types.ItemTypeHotspot:
+		correct = gradeHotspot(item, answer)
+	}
+	if correct {
+		earned = possible
+	}
+	return earned, possible, correct
+}
+
+// correctChoices returns the IDs of the correct options of a choice or
+// multi_choice item, sorted
+func correctChoices(item *Item) ([]string, bool) {
+	var content types.ChoiceContent
+	if err := json.Unmarshal(item.Content, &content); err != nil {
+		return nil, false
+	}
+	var ids []string
+	for _, choice := range content.Choices {
+		if choice.Correct {
+			ids = append(ids, choice.ID)
+		}
+	}
+	slices.Sort(ids)
+	return ids, true
+}
+
+// gradeChoice reports whether the chosen option is a correct one
+func gradeChoice(item *Item, answer json.RawMessage) bool {
+	var response types.ChoiceAnswer
+	if err := json.Unmarshal(answer, &response); err != nil {
+		return false
+	}
+	correct, ok := correctChoices(item)
+	return ok && slices.Contains(correct, response.ChoiceID)
+}
+
+// gradeMultiChoice reports whether the chosen options are exactly the
+// correct ones
+func gradeMultiChoice(item *Item, answer json.RawMessage) bool {
+	var response types.MultiChoiceAnswer
+	if err := json.Unmarshal(answer, &response); err != nil {
+		return false
+	}
+	correct, ok := correctChoices(item)
+	if !ok || len(correct) == 0 {
+		return false
+	}
+	chosen := slices.Clone(response.ChoiceIDs)
+	slices.Sort(chosen)
+	return slices.Equal(correct, slices.Compact(chosen))
+}
+
+// gradeTextEntry reports whether the text is the correct answer, ignoring
+// case and surrounding space. Items without a correct answer have none.
+func gradeTextEntry(item *Item, answer json.RawMessage) bool {
+	var response types.TextEntryAnswer
+	if err := json.Unmarshal(answer, &response); err != nil {
+		return false
+	}
+	var content types.TextEntryContent
+	if err := json.Unmarshal(item.Content, &content); err != nil || content.CorrectAnswer == nil {
+		return false
+	}
+	expected := strings.TrimSpace(*content.CorrectAnswer)
+	return expected != "" && strings.EqualFold(strings.TrimSpace(response.Text), expected)
+}
+
+// gradeOrdering reports whether the entries are in their correct order
+func gradeOrdering(item *Item, answer json.RawMessage) bool {
+	var response types.OrderingAnswer
+	if err := json.Unmarshal(answer, &response); err != nil {
+		return false
+	}
+	expected, ok := correctOrder(item)
+	return ok && slices.Equal(expected, response.Order)
+}
+
+// correctOrder returns the IDs of the entries of an ordering item in their
+// correct order
+func correctOrder(item *Item) ([]string, bool) {
+	var content types.OrderingContent
+	if err := json.Unmarshal(item.Content, &content); err != nil || len(content.Items) == 0 {
+		return nil, false
+	}
+	entries := slices.Clone(content.Items)
+	slices.SortStableFunc(entries, func(a, b types.OrderingItem) int {
+		return a.CorrectOrder - b.CorrectOrder
+	})
+	expected := make([]string, len(entries))
+	for i, entry := range entries {
+		expected[i] = entry.ID
+	}
+	return expected, true
+}
+
+// gradeHotspot reports whether the point falls in a correct region
+func gradeHotspot(item *Item, answer json.RawMessage) bool {
+	var response types.HotspotAnswer
+	if err := json.Unmarshal(answer, &response); err != nil || response.X == nil || response.Y == nil {
+		return false
+	}
+	var content types.HotspotContent
+	if err := json.Unmarshal(item.Content, &content); err != nil {
+		return false
+	}
+	return slices.ContainsFunc(content.Hotspots, func(hotspot types.Hotspot) bool {
+		return hotspot.Correct && hotspotContains(hotspot, *response.X, *response.Y)
+	})
+}
+
+// hotspotContains reports whether the point x, y falls in a region, edges
+// included. Rectangles are x, y, width, height, circles center x, center y,
+// radius and polygons their corners' x, y pairs; regions with coordinates
+// that don't fit their shape contain nothing.
+func hotspotContains(hotspot types.Hotspot, x, y float64) bool {
+	c := hotspot.Coords
+	switch {
+	case hotspot.Shape == "rectangle" && len(c) == 4:
+		return x >= c[0] && x <= c[0]+c[2] && y >= c[1] && y <= c[1]+c[3]
+	case hotspot.Shape == "circle" && len(c) == 3:
+		dx, dy := x-c[0], y-c[1]
+		return dx*dx+dy*dy <= c[2]*c[2]
+	case hotspot.Shape == "polygon" && len(c) >= 6 && len(c)%2 == 0:
+		return polygonContains(c, x, y)
+	}
+	return false
+}
+
+// polygonContains reports whether the point x, y falls in the polygon of
+// corners coords, by counting the edges a ray from the point to the right
+// crosses. Points on an edge are inside.
+func polygonContains(coords []float64, x, y float64) bool {
+	inside := false
+	n := len(coords) / 2
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		xi, yi := coords[2*i], coords[2*i+1]
+		xj, yj := coords[2*j], coords[2*j+1]
+		if onSegment(xi, yi, xj, yj, x, y) {
+			return true
+		}
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// onSegment reports whether the point x, y lies on the segment from x1, y1
+// to x2, y2
+func onSegment(x1, y1, x2, y2, x, y float64) bool {
+	cross := (x2-x1)*(y-y1) - (y2-y1)*(x-x1)
+	if cross != 0 {
+		return false
+	}
+	return x >= min(x1, x2) && x <= max(x1, x2) && y >= min(y1, y2) && y <= max(y1, y2)
+}
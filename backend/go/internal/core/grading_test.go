@@ -0,0 +1,113 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+func TestGradingService_Grade(t *testing.T) {
+	one, two, three := 1, 2, 3
+	choice := `{"choices":[{"id":"a","text":"Paris","correct":true},{"id":"b","text":"Lyon"}]}`
+	multiChoice := `{"choices":[{"id":"a","text":"Oslo","correct":true},{"id":"b","text":"Bergen"},{"id":"c","text":"Stockholm","correct":true}]}`
+	textEntry := `{"multiline":false,"correct_answer":"Madrid"}`
+	ordering := `{"items":[{"id":"1","text":"Rome","correct_order":2},{"id":"2","text":"Berlin","correct_order":1},{"id":"3","text":"Canberra","correct_order":3}]}`
+	hotspot := `{"image_url":"https://example.com/map.png","hotspots":[
+		{"id":"circle","shape":"circle","coords":[100,100,10],"correct":true},
+		{"id":"rectangle","shape":"rectangle","coords":[200,50,40,20],"correct":true},
+		{"id":"triangle","shape":"polygon","coords":[0,0,40,0,20,30],"correct":true},
+		{"id":"wrong","shape":"rectangle","coords":[300,300,50,50]}]}`
+
+	tests := []struct {
+		name            string
+		itemType        types.ItemType
+		content         string
+		points          *int
+		answer          string
+		expectedEarned  int
+		expectedCorrect bool
+	}{
+		{"choice correct", types.ItemTypeChoice, choice, &two, `{"choice_id":"a"}`, 2, true},
+		{"choice wrong", types.ItemTypeChoice, choice, &two, `{"choice_id":"b"}`, 0, false},
+		{"multi_choice every correct option", types.ItemTypeMultiChoice, multiChoice, &three, `{"choice_ids":["c","a"]}`, 3, true},
+		{"multi_choice too few options", types.ItemTypeMultiChoice, multiChoice, &three, `{"choice_ids":["a"]}`, 0, false},
+		{"multi_choice too many options", types.ItemTypeMultiChoice, multiChoice, &three, `{"choice_ids":["a","b","c"]}`, 0, false},
+		{"multi_choice no options", types.ItemTypeMultiChoice, multiChoice, &three, `{"choice_ids":[]}`, 0, false},
+		{"text_entry ignores case and space", types.ItemTypeTextEntry, textEntry, &one, `{"text":"  mADRID \n"}`, 1, true},
+		{"text_entry wrong", types.ItemTypeTextEntry, textEntry, &one, `{"text":"Madri"}`, 0, false},
+		{"text_entry without a correct answer", types.ItemTypeTextEntry, `{"multiline":true}`, &one, `{"text":""}`, 0, false},
+		{"ordering correct", types.ItemTypeOrdering, ordering, &two, `{"order":["2","1","3"]}`, 2, true},
+		{"ordering wrong", types.ItemTypeOrdering, ordering, &two, `{"order":["1","2","3"]}`, 0, false},
+		{"hotspot in a circle", types.ItemTypeHotspot, hotspot, &one, `{"x":105,"y":95}`, 1, true},
+		{"hotspot on a circle's edge", types.ItemTypeHotspot, hotspot, &one, `{"x":110,"y":100}`, 1, true},
+		{"hotspot outside a circle", types.ItemTypeHotspot, hotspot, &one, `{"x":108,"y":108}`, 0, false},
+		{"hotspot in a rectangle", types.ItemTypeHotspot, hotspot, &one, `{"x":239,"y":70}`, 1, true},
+		{"hotspot past a rectangle's width", types.ItemTypeHotspot, hotspot, &one, `{"x":241,"y":60}`, 0, false},
+		{"hotspot in a polygon", types.ItemTypeHotspot, hotspot, &one, `{"x":20,"y":10}`, 1, true},
+		{"hotspot on a polygon's edge", types.ItemTypeHotspot, hotspot, &one, `{"x":10,"y":15}`, 1, true},
+		{"hotspot beside a polygon", types.ItemTypeHotspot, hotspot, &one, `{"x":5,"y":25}`, 0, false},
+		{"hotspot in an incorrect region", types.ItemTypeHotspot, hotspot, &one, `{"x":320,"y":320}`, 0, false},
+		{"item without points", types.ItemTypeChoice, choice, nil, `{"choice_id":"a"}`, 0, true},
+		{"malformed answer", types.ItemTypeChoice, choice, &two, `{"choice_id":`, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			item := &Item{ID: "item-1", Type: tt.itemType, Content: json.RawMessage(tt.content), Points: tt.points}
+			expectedPossible := 0
+			if tt.points != nil {
+				expectedPossible = *tt.points
+			}
+
+			// Act
+			earned, possible, correct := NewGradingService().Grade(item, json.RawMessage(tt.answer))
+
+			// Assert
+			assert.Equal(t, tt.expectedEarned, earned)
+			assert.Equal(t, expectedPossible, possible)
+			assert.Equal(t, tt.expectedCorrect, correct)
+		})
+	}
+}
+
+func TestGradingService_Grade_Unanswered(t *testing.T) {
+	// Arrange
+	two := 2
+	item := &Item{
+		ID:       "item-1",
+		Type:     types.ItemTypeChoice,
+		Content:  json.RawMessage(`{"choices":[{"id":"a","text":"Paris","correct":true}]}`),
+		Required: true,
+		Points:   &two,
+	}
+
+	// Act
+	earned, possible, correct := NewGradingService().Grade(item, nil)
+
+	// Assert
+	assert.Equal(t, 0, earned)
+	assert.Equal(t, 2, possible, "an unanswered question still counts towards the score")
+	assert.False(t, correct)
+}
+
+func TestGradingService_Grade_ItemsWithoutAnswers(t *testing.T) {
+	five := 5
+	for _, item := range []*Item{
+		{ID: "title", Type: types.ItemTypeTitle, Content: json.RawMessage(`{"text":"Capitals"}`), Points: &five},
+		{ID: "media", Type: types.ItemTypeMedia, Content: json.RawMessage(`{"url":"https://example.com/map.png","media_type":"image"}`), Points: &five},
+	} {
+		t.Run(item.ID, func(t *testing.T) {
+			// Act
+			earned, possible, correct := NewGradingService().Grade(item, json.RawMessage(`{}`))
+
+			// Assert
+			assert.Equal(t, 0, earned)
+			assert.Equal(t, 0, possible)
+			assert.False(t, correct)
+		})
+	}
+}
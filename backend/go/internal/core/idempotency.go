@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Domain errors for idempotency key handling.
+var (
+	// ErrIdempotencyKeyNotFound is returned when no unexpired record exists for a key.
+	ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+	// ErrIdempotencyKeyExists is returned when the key is already reserved or has a record.
+	ErrIdempotencyKeyExists = errors.New("idempotency key already exists")
+)
+
+// IdempotencyRecord is the stored outcome of a request made with an Idempotency-Key header.
+// Exact replays of the request are answered from the snapshot instead of re-executing it.
+// The record is reserved, with no response yet, before the request is handled, so a
+// retry that arrives while the original is still in flight is not handled twice.
+type IdempotencyRecord struct {
+	// Key is the client-supplied Idempotency-Key header value.
+	Key string
+
+	// Endpoint scopes the key to a method, path and caller so keys can't collide across routes.
+	Endpoint string
+
+	// RequestHash is a hex-encoded SHA-256 of the request body.
+	// A replay with the same key but a different hash is a conflict.
+	RequestHash string
+
+	// StatusCode, Header and Body are the snapshot of the original response.
+	// StatusCode is 0 while the original request is still being handled.
+	// Header holds the headers the handler set, such as Content-Type,
+	// Location and ETag.
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	// CreatedAt is when the key was reserved.
+	CreatedAt time.Time
+
+	// ExpiresAt is when the record stops being honored. A reservation
+	// expires soon, so a request that never completes doesn't hold its key.
+	ExpiresAt time.Time
+}
+
+// Pending reports whether the original request is still being handled.
+func (r *IdempotencyRecord) Pending() bool {
+	return r.StatusCode == 0
+}
+
+// IdempotencyStore defines the contract for persisting idempotency records.
+// Implementations must be safe for concurrent use.
+type IdempotencyStore interface {
+	// Get returns the unexpired record for the key and endpoint.
+	// Returns ErrIdempotencyKeyNotFound if there is none.
+	Get(ctx context.Context, key, endpoint string) (*IdempotencyRecord, error)
+
+	// Reserve stores a pending record, without a response, replacing an expired one.
+	// Returns ErrIdempotencyKeyExists if an unexpired record for the key and endpoint already exists.
+	Reserve(ctx context.Context, record *IdempotencyRecord) error
+
+	// Complete stores the response of a reserved record and its new expiry.
+	// Returns ErrIdempotencyKeyNotFound if the reservation is gone.
+	Complete(ctx context.Context, record *IdempotencyRecord) error
+
+	// Release deletes a pending record, so the request can be retried.
+	// Completed records are kept.
+	Release(ctx context.Context, key, endpoint string) error
+
+	// DeleteExpired removes records that expired before the given time and returns how many were removed.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
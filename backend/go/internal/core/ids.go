@@ -0,0 +1,27 @@
+package core
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// newIDKey carries the ID of the next row a store creates
+type newIDKey struct{}
+
+// WithNewID makes the stores give the project, item or organization created
+// with ctx the ID id rather than a random UUID, e.g. for fixtures that
+// documentation refers to by ID. A context carrying an ID must create a
+// single row.
+func WithNewID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, newIDKey{}, id)
+}
+
+// NewID returns the ID of a row created with ctx: the one set with
+// WithNewID, or else a new random UUID
+func NewID(ctx context.Context) string {
+	if id, ok := ctx.Value(newIDKey{}).(string); ok {
+		return id
+	}
+	return uuid.NewString()
+}
@@ -0,0 +1,30 @@
+package core
+
+import "errors"
+
+var (
+	// ErrImportInvalidPackage is returned when an uploaded package can't be
+	// read at all, as opposed to items in it that can't be imported.
+	ErrImportInvalidPackage = errors.New("invalid import package")
+)
+
+// ImportedItem is an item read from an imported package: either Input is
+// set, or Reason says why the item is skipped.
+type ImportedItem struct {
+	// Source identifies the item in the package
+	Source string
+	// Title is the item's title in the package, for reporting skipped items
+	Title string
+
+	Input  *ItemInput
+	Reason string
+
+	// Assets are the storage keys of the files uploaded for the item
+	Assets []string
+}
+
+// Skip marks the item as skipped, for reason
+func (i *ImportedItem) Skip(reason string) {
+	i.Input = nil
+	i.Reason = reason
+}
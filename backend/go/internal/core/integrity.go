@@ -0,0 +1,21 @@
+package core
+
+import "context"
+
+// IntegrityResult is the outcome of one referential integrity check
+type IntegrityResult struct {
+	// Check names the check, e.g. "items.project_id"
+	Check string
+	// Description says which rows the check looks for
+	Description string
+	// Orphans is the number of rows, or stored files, whose parent is gone
+	Orphans int
+	// SampleIDs are the IDs, or storage keys, of a few of the orphans
+	SampleIDs []string
+}
+
+// IntegrityChecker looks for rows and stored files left behind by a deleted
+// parent, e.g. items of a project that no longer exists
+type IntegrityChecker interface {
+	CheckIntegrity(ctx context.Context) ([]IntegrityResult, error)
+}
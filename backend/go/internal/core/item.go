@@ -9,8 +9,10 @@ import (
 	"errors"
 	"fmt"
 	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
 
-	"github.com/google/uuid"
 	"github.com/provemyself/backend/internal/types"
 )
 
@@ -31,10 +33,42 @@ var (
 	// ErrItemInvalidPosition is returned when an item position is invalid.
 	ErrItemInvalidPosition = errors.New("invalid item position")
 	
+	// ErrItemDuplicatePositions is returned when a request would put two of
+	// its items at the same position.
+	ErrItemDuplicatePositions = errors.New("duplicate item positions")
+	
 	// ErrItemInvalidContent is returned when item content doesn't match the item type.
 	ErrItemInvalidContent = errors.New("invalid content for item type")
 )
 
+// ItemBatchError is the error of the item that failed a batch of items
+// created together, and with it the whole batch.
+type ItemBatchError struct {
+	// Index is the item's index in the batch, from 0.
+	Index int
+	
+	// Err is why the item failed.
+	Err error
+}
+
+func (e *ItemBatchError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index+1, e.Err)
+}
+
+func (e *ItemBatchError) Unwrap() error {
+	return e.Err
+}
+
+// MinItemSearchLength is the length, in characters, from which item searches
+// use the full-text index. Shorter terms match too many words to be useful.
+const MinItemSearchLength = 3
+
+// maxItemTitleLength is the longest item title, in bytes.
+const maxItemTitleLength = 500
+
+// copyTitleSuffix marks the title of a duplicated item or project.
+const copyTitleSuffix = " (copy)"
+
 // Item represents a quiz item/question entity in the ProveMySelf platform.
 // Each item belongs to a project and represents a single quiz element such as
 // a question, media block, or instructional content.
@@ -82,28 +116,141 @@ type Item struct {
 	
 	// UpdatedAt is the timestamp when the item was last modified.
 	UpdatedAt time.Time
+	
+	// Version starts at 1 and is incremented by every write to the item,
+	// moves and restores included.
+	Version int
 }
 
 // ItemStore defines the contract for item data persistence.
+// Items are scoped by their project's organization like projects are.
 type ItemStore interface {
 	// Create persists a new item with the given parameters.
 	Create(ctx context.Context, projectID string, itemType types.ItemType, title string, content json.RawMessage, position int, required bool, points *int, explanation *string) (*Item, error)
 	
-	// GetByID retrieves an item by its unique identifier.
-	GetByID(ctx context.Context, id string) (*Item, error)
+	// CreateMany persists several items of a project, all or none, in the
+	// order given.
+	CreateMany(ctx context.Context, projectID string, items []NewItem) ([]*Item, error)
+	
+	// CreateBulkCopy is CreateMany for large imports, loading the items in
+	// one round trip where the engine can.
+	CreateBulkCopy(ctx context.Context, projectID string, items []NewItem) ([]*Item, error)
+	
+	// GetByID retrieves an item of a project by its unique identifier.
+	// Returns ErrItemNotFound unless the item is in the project.
+	GetByID(ctx context.Context, projectID, id string) (*Item, error)
 	
 	// ListByProject retrieves all items for a specific project, ordered by position.
 	ListByProject(ctx context.Context, projectID string) ([]*Item, error)
 	
-	// Update modifies an existing item with new values.
-	Update(ctx context.Context, id string, itemType types.ItemType, title string, content json.RawMessage, position int, required bool, points *int, explanation *string) (*Item, error)
+	// List retrieves the page of a project's items selected by opts, with
+	// the number of items matching its filters.
+	List(ctx context.Context, projectID string, opts ItemListOptions) (*ItemPage, error)
+	
+	// Search returns the items of a project matching a full-text query,
+	// best match first. Title matches rank above content matches.
+	Search(ctx context.Context, projectID, query string) ([]*Item, error)
+	
+	// Duplicate copies an item of a project, content included, into a new
+	// item named title after the project's last item. Concurrent duplicates
+	// get distinct positions. Returns ErrItemNotFound unless the item is in
+	// the project.
+	Duplicate(ctx context.Context, projectID, id, title string) (*Item, error)
+	
+	// CountByProject returns the number of items in a project.
+	CountByProject(ctx context.Context, projectID string) (int, error)
+	
+	// MaxPosition returns the highest item position in a project, and false
+	// when the project has no items.
+	MaxPosition(ctx context.Context, projectID string) (int, bool, error)
+	
+	// SumPoints returns the total points of a project's items, counting
+	// unscored items as 0.
+	SumPoints(ctx context.Context, projectID string) (int, error)
+	
+	// Update modifies an existing item of a project with new values.
+	// Returns ErrItemNotFound unless the item is in the project, and
+	// ErrVersionConflict if version is set and the item has another.
+	Update(ctx context.Context, projectID, id string, itemType types.ItemType, title string, content json.RawMessage, position int, required bool, points *int, explanation *string, version *int) (*Item, error)
+	
+	// Delete moves an item of a project to the trash, freeing its position.
+	// Returns ErrItemNotFound unless the item is in the project.
+	Delete(ctx context.Context, projectID, id string) error
+	
+	// Restore brings back a deleted item of a project, at its position or,
+	// if a live item took it, after the project's last item. Returns
+	// ErrItemNotFound unless the project has such a deleted item.
+	Restore(ctx context.Context, projectID, id string) (*Item, error)
+	
+	// PurgeDeleted permanently removes the items deleted before cutoff, in
+	// every project, returning how many.
+	PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error)
+	
+	// UpdatePositions updates the position field for multiple items of a
+	// project. Used for reordering items within a project. It must run in a
+	// transaction (ErrNoTransaction otherwise), which may check that
+	// positions are unique only when it commits. Returns ErrItemNotFound if
+	// any item is not in the project.
+	UpdatePositions(ctx context.Context, projectID string, updates []PositionUpdate) error
+	
+	// ListRevisions returns a page of the revisions an item of a project
+	// keeps, newest first, with how many it keeps. Update and Delete keep
+	// the item as it was before them as a revision. Returns ErrItemNotFound
+	// unless the item is in the project.
+	ListRevisions(ctx context.Context, projectID, id string, limit, offset int) ([]*ItemRevision, int, error)
+	
+	// GetRevision returns a revision of an item of a project by its number.
+	// Returns ErrItemNotFound unless the item is in the project and
+	// ErrItemRevisionNotFound unless it keeps the revision.
+	GetRevision(ctx context.Context, projectID, id string, number int) (*ItemRevision, error)
+}
+
+// ItemListOptions filters and paginates ItemStore.List. Zero values apply no
+// filter.
+type ItemListOptions struct {
+	// Type restricts the list to items of the type.
+	Type types.ItemType
+	
+	// Required restricts the list to required, or to optional, items.
+	Required *bool
 	
-	// Delete permanently removes an item from storage.
-	Delete(ctx context.Context, id string) error
+	// Search restricts the list to items matching the term, ignoring case.
+	// Terms of MinItemSearchLength characters or more are full-text queries
+	// ranking the items by relevance, as in ItemStore.Search; shorter ones
+	// match the title or content as a substring. Lists are otherwise in
+	// position order.
+	Search string
+	
+	// Sort is the list order, overriding the ranking of searches. Empty
+	// keeps position order, or the ranking.
+	Sort ItemSort
+	
+	// Limit and Offset select the page.
+	Limit  int
+	Offset int
+}
+
+// ItemSort orders item lists. Ties are broken by position.
+type ItemSort string
+
+// Item sort orders.
+const (
+	ItemSortPosition      ItemSort = "position"
+	ItemSortPositionDesc  ItemSort = "position_desc"
+	ItemSortCreatedAt     ItemSort = "created_at"
+	ItemSortCreatedAtDesc ItemSort = "created_at_desc"
+	ItemSortUpdatedAt     ItemSort = "updated_at"
+	ItemSortUpdatedAtDesc ItemSort = "updated_at_desc"
+	ItemSortTitle         ItemSort = "title"
+	ItemSortTitleDesc     ItemSort = "title_desc"
+)
+
+// ItemPage is a page of a project's items.
+type ItemPage struct {
+	Items []*Item
 	
-	// UpdatePositions updates the position field for multiple items atomically.
-	// Used for reordering items within a project.
-	UpdatePositions(ctx context.Context, updates []PositionUpdate) error
+	// Total counts the items matching the filters.
+	Total int
 }
 
 // PositionUpdate represents a position change for an item.
@@ -112,62 +259,169 @@ type PositionUpdate struct {
 	Position int
 }
 
+// NewItem is an item to store, its content validated and encoded.
+type NewItem struct {
+	Type        types.ItemType
+	Title       string
+	Content     json.RawMessage
+	Position    int
+	Required    bool
+	Points      *int
+	Explanation *string
+}
+
+// ItemInput holds the fields of an item to create.
+type ItemInput struct {
+	Type        types.ItemType
+	Title       string
+	Content     interface{}
+	Position    int
+	Required    bool
+	Points      *int
+	Explanation *string
+}
+
+// ItemPatch holds the fields of an item to change. Nil fields are left as
+// they are, so a patch cannot clear Points or Explanation.
+type ItemPatch struct {
+	Type  *types.ItemType
+	Title *string
+	
+	// Content is the new content as JSON, or nil to keep the item's.
+	Content json.RawMessage
+	
+	Position    *int
+	Required    *bool
+	Points      *int
+	Explanation *string
+	
+	// Version is the version of the item the patch expects, or nil for
+	// any.
+	Version *int
+}
+
 // ItemService provides business logic for quiz item operations.
 type ItemService struct {
 	itemStore   ItemStore
 	projectStore ProjectStore
+	
+	// tx makes multi-step operations atomic.
+	tx Transactor
+
+	// locks holds the item edit locks; nil until SetLocks is called
+	locks      ItemLockStore
+	lockEvents ItemLockPublisher
+	now        func() time.Time
 }
 
-// NewItemService creates a new item service.
+// NewItemService creates a new item service. Operations are not
+// transactional until SetTransactor is called.
 func NewItemService(itemStore ItemStore, projectStore ProjectStore) *ItemService {
 	return &ItemService{
 		itemStore:   itemStore,
 		projectStore: projectStore,
+		tx:          noTransactions{},
+		lockEvents:  noLockEvents{},
+		now:         time.Now,
 	}
 }
 
-// Create validates and creates a new quiz item.
+// SetTransactor runs the service's multi-step operations in transactions of
+// tx, which the item and project stores must take part in.
+func (s *ItemService) SetTransactor(tx Transactor) {
+	s.tx = tx
+}
+
+// Create validates and creates a new quiz item. The project check and the
+// insert run in one transaction.
 func (s *ItemService) Create(ctx context.Context, projectID string, itemType types.ItemType, title string, content interface{}, position int, required bool, points *int, explanation *string) (*Item, error) {
-	// Validate business rules
-	if err := s.validateTitle(title); err != nil {
-		return nil, err
-	}
-	
-	if err := s.validateType(itemType); err != nil {
+	ctx, span := startSpan(ctx, "ItemService.Create", attribute.String("project.id", projectID))
+	defer span.End()
+
+	contentBytes, err := s.validateInput(ItemInput{Type: itemType, Title: title, Content: content, Position: position})
+	if err != nil {
 		return nil, err
 	}
 	
-	if err := s.validatePosition(position); err != nil {
+	var item *Item
+	err = s.tx.InTx(ctx, "items.create", func(ctx context.Context) error {
+		if err := s.ensureProject(ctx, projectID); err != nil {
+			return err
+		}
+		
+		item, err = s.itemStore.Create(ctx, projectID, itemType, title, contentBytes, position, required, points, explanation)
+		if err != nil {
+			return fmt.Errorf("failed to create item: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 	
-	// Ensure project exists
-	_, err := s.projectStore.GetByID(ctx, projectID)
-	if err != nil {
-		if errors.Is(err, ErrProjectNotFound) {
-			return nil, ErrProjectNotFound
+	return item, nil
+}
+
+// CreateMany validates and creates several items in one transaction: either
+// every item is created or, on the first failure, none is. An item failing
+// validation, or taking a position already taken, fails with an
+// *ItemBatchError saying which; ErrItemDuplicatePositions if another item
+// of the batch has its position.
+func (s *ItemService) CreateMany(ctx context.Context, projectID string, inputs []ItemInput) ([]*Item, error) {
+	ctx, span := startSpan(ctx, "ItemService.CreateMany",
+		attribute.String("project.id", projectID),
+		attribute.Int("items.count", len(inputs)))
+	defer span.End()
+
+	// Validate every item before touching the database
+	newItems := make([]NewItem, len(inputs))
+	positions := make(map[int]int, len(inputs))
+	for i, input := range inputs {
+		contentBytes, err := s.validateInput(input)
+		if err != nil {
+			return nil, &ItemBatchError{Index: i, Err: err}
+		}
+		if other, taken := positions[input.Position]; taken {
+			return nil, &ItemBatchError{Index: i, Err: fmt.Errorf("%w: item %d is at position %d too", ErrItemDuplicatePositions, other+1, input.Position)}
+		}
+		positions[input.Position] = i
+		newItems[i] = NewItem{
+			Type:        input.Type,
+			Title:       input.Title,
+			Content:     contentBytes,
+			Position:    input.Position,
+			Required:    input.Required,
+			Points:      input.Points,
+			Explanation: input.Explanation,
 		}
-		return nil, fmt.Errorf("failed to verify project exists: %w", err)
 	}
 	
-	// Serialize content
-	contentBytes, err := s.serializeContent(itemType, content)
+	var items []*Item
+	err := s.tx.InTx(ctx, "items.create_many", func(ctx context.Context) error {
+		if err := s.ensureProject(ctx, projectID); err != nil {
+			return err
+		}
+		
+		var err error
+		items, err = s.itemStore.CreateBulkCopy(ctx, projectID, newItems)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	
-	// Create the item
-	item, err := s.itemStore.Create(ctx, projectID, itemType, title, contentBytes, position, required, points, explanation)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create item: %w", err)
-	}
-	
-	return item, nil
+	return items, nil
 }
 
-// GetByID retrieves an item by ID.
-func (s *ItemService) GetByID(ctx context.Context, id string) (*Item, error) {
-	item, err := s.itemStore.GetByID(ctx, id)
+// GetByID retrieves an item of a project by ID. Returns ErrItemNotFound
+// unless the item is in the project.
+func (s *ItemService) GetByID(ctx context.Context, projectID, id string) (*Item, error) {
+	ctx, span := startSpan(ctx, "ItemService.GetByID",
+		attribute.String("project.id", projectID),
+		attribute.String("item.id", id))
+	defer span.End()
+
+	item, err := s.itemStore.GetByID(ctx, projectID, id)
 	if err != nil {
 		return nil, err
 	}
@@ -176,13 +430,11 @@ func (s *ItemService) GetByID(ctx context.Context, id string) (*Item, error) {
 
 // ListByProject retrieves all items for a project, ordered by position.
 func (s *ItemService) ListByProject(ctx context.Context, projectID string) ([]*Item, error) {
-	// Ensure project exists
-	_, err := s.projectStore.GetByID(ctx, projectID)
-	if err != nil {
-		if errors.Is(err, ErrProjectNotFound) {
-			return nil, ErrProjectNotFound
-		}
-		return nil, fmt.Errorf("failed to verify project exists: %w", err)
+	ctx, span := startSpan(ctx, "ItemService.ListByProject", attribute.String("project.id", projectID))
+	defer span.End()
+
+	if err := s.ensureProject(ctx, projectID); err != nil {
+		return nil, err
 	}
 	
 	items, err := s.itemStore.ListByProject(ctx, projectID)
@@ -193,29 +445,263 @@ func (s *ItemService) ListByProject(ctx context.Context, projectID string) ([]*I
 	return items, nil
 }
 
-// Update validates and updates an existing item.
-func (s *ItemService) Update(ctx context.Context, id string, itemType types.ItemType, title string, content interface{}, position int, required bool, points *int, explanation *string) (*Item, error) {
-	// Validate business rules
-	if err := s.validateTitle(title); err != nil {
+// List returns the page of a project's items selected by opts, filtered and
+// counted by the store.
+func (s *ItemService) List(ctx context.Context, projectID string, opts ItemListOptions) (*ItemPage, error) {
+	ctx, span := startSpan(ctx, "ItemService.List", attribute.String("project.id", projectID))
+	defer span.End()
+
+	if err := s.ensureProject(ctx, projectID); err != nil {
 		return nil, err
 	}
 	
-	if err := s.validateType(itemType); err != nil {
+	page, err := s.itemStore.List(ctx, projectID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list items: %w", err)
+	}
+	
+	return page, nil
+}
+
+// Search returns the items of a project matching a full-text query, best
+// match first. Queries shorter than MinItemSearchLength are too short to
+// search by, and the caller should match them some other way.
+func (s *ItemService) Search(ctx context.Context, projectID, query string) ([]*Item, error) {
+	ctx, span := startSpan(ctx, "ItemService.Search", attribute.String("project.id", projectID))
+	defer span.End()
+
+	if err := s.ensureProject(ctx, projectID); err != nil {
 		return nil, err
 	}
 	
-	if err := s.validatePosition(position); err != nil {
+	items, err := s.itemStore.Search(ctx, projectID, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search items: %w", err)
+	}
+	
+	return items, nil
+}
+
+// Duplicate copies an item of a project, content included, to the end of the
+// project. The copy's title is the item's with " (copy)" appended, the
+// item's shortened if the result would be too long.
+func (s *ItemService) Duplicate(ctx context.Context, projectID, id string) (*Item, error) {
+	ctx, span := startSpan(ctx, "ItemService.Duplicate",
+		attribute.String("project.id", projectID),
+		attribute.String("item.id", id))
+	defer span.End()
+
+	var item *Item
+	err := s.tx.InTx(ctx, "items.duplicate", func(ctx context.Context) error {
+		if err := s.ensureProject(ctx, projectID); err != nil {
+			return err
+		}
+		
+		source, err := s.itemStore.GetByID(ctx, projectID, id)
+		if err != nil {
+			return err
+		}
+		
+		item, err = s.itemStore.Duplicate(ctx, projectID, id, copyTitle(source.Title, maxItemTitleLength))
+		if err != nil {
+			if errors.Is(err, ErrItemNotFound) || errors.Is(err, ErrProjectNotFound) {
+				return err
+			}
+			return fmt.Errorf("failed to duplicate item: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 	
-	// Serialize content
-	contentBytes, err := s.serializeContent(itemType, content)
+	return item, nil
+}
+
+// copyTitle returns the title of a copy of something titled title, cut on a
+// rune boundary so that with the suffix it is at most maxLength bytes.
+func copyTitle(title string, maxLength int) string {
+	for len(title)+len(copyTitleSuffix) > maxLength {
+		_, size := utf8.DecodeLastRuneInString(title)
+		title = title[:len(title)-size]
+	}
+	return title + copyTitleSuffix
+}
+
+// CountByProject returns the number of items in a project without loading
+// them.
+func (s *ItemService) CountByProject(ctx context.Context, projectID string) (int, error) {
+	ctx, span := startSpan(ctx, "ItemService.CountByProject", attribute.String("project.id", projectID))
+	defer span.End()
+
+	if err := s.ensureProject(ctx, projectID); err != nil {
+		return 0, err
+	}
+	
+	count, err := s.itemStore.CountByProject(ctx, projectID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count items: %w", err)
+	}
+	
+	return count, nil
+}
+
+// MaxPosition returns the highest item position in a project, and false when
+// the project has no items.
+func (s *ItemService) MaxPosition(ctx context.Context, projectID string) (int, bool, error) {
+	ctx, span := startSpan(ctx, "ItemService.MaxPosition", attribute.String("project.id", projectID))
+	defer span.End()
+
+	if err := s.ensureProject(ctx, projectID); err != nil {
+		return 0, false, err
+	}
+	
+	position, ok, err := s.itemStore.MaxPosition(ctx, projectID)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get max item position: %w", err)
+	}
+	
+	return position, ok, nil
+}
+
+// SumPoints returns the total points of a project's items, counting unscored
+// items as 0.
+func (s *ItemService) SumPoints(ctx context.Context, projectID string) (int, error) {
+	ctx, span := startSpan(ctx, "ItemService.SumPoints", attribute.String("project.id", projectID))
+	defer span.End()
+
+	if err := s.ensureProject(ctx, projectID); err != nil {
+		return 0, err
+	}
+	
+	points, err := s.itemStore.SumPoints(ctx, projectID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum item points: %w", err)
+	}
+	
+	return points, nil
+}
+
+// ensureProject returns ErrProjectNotFound unless the project exists. The
+// item store answers for a missing project as for an empty one.
+func (s *ItemService) ensureProject(ctx context.Context, projectID string) error {
+	_, err := s.projectStore.GetByID(ctx, projectID)
+	if err != nil {
+		if errors.Is(err, ErrProjectNotFound) {
+			return ErrProjectNotFound
+		}
+		return fmt.Errorf("failed to verify project exists: %w", err)
+	}
+	return nil
+}
+
+// Update validates and updates an existing item of a project. Returns
+// ErrItemNotFound unless the item is in the project, an *ItemLockedError
+// if another user holds the item's lock, and ErrVersionConflict if version
+// is set and the item has another.
+func (s *ItemService) Update(ctx context.Context, projectID, id string, itemType types.ItemType, title string, content interface{}, position int, required bool, points *int, explanation *string, version *int) (*Item, error) {
+	ctx, span := startSpan(ctx, "ItemService.Update",
+		attribute.String("project.id", projectID),
+		attribute.String("item.id", id))
+	defer span.End()
+
+	contentBytes, err := s.validateInput(ItemInput{Type: itemType, Title: title, Content: content, Position: position})
+	if err != nil {
+		return nil, err
+	}
+	
+	// Update the item, unless someone else holds its lock
+	var item *Item
+	err = s.tx.InTx(ctx, "items.update", func(ctx context.Context) error {
+		if err := s.checkLock(ctx, id, AccessScopeFromContext(ctx).UserID, s.now()); err != nil {
+			return err
+		}
+		item, err = s.itemStore.Update(ctx, projectID, id, itemType, title, contentBytes, position, required, points, explanation, version)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	
-	// Update the item
-	item, err := s.itemStore.Update(ctx, id, itemType, title, contentBytes, position, required, points, explanation)
+	return item, nil
+}
+
+// Patch changes the fields of an item of a project that patch sets, and
+// validates the result as Update does. The content is checked only if the
+// patch sets it or changes the item's type. Returns ErrItemNotFound unless
+// the item is in the project, an *ItemLockedError if another user holds
+// the item's lock, and ErrVersionConflict if the item has another version
+// than the patch's. The item is written only if it is still the version
+// the patch was applied to, and ErrConcurrentModification is returned
+// otherwise for a patch without a version.
+func (s *ItemService) Patch(ctx context.Context, projectID, id string, patch ItemPatch) (*Item, error) {
+	ctx, span := startSpan(ctx, "ItemService.Patch",
+		attribute.String("project.id", projectID),
+		attribute.String("item.id", id))
+	defer span.End()
+
+	var item *Item
+	err := s.tx.InTx(ctx, "items.patch", func(ctx context.Context) error {
+		current, err := s.itemStore.GetByID(ctx, projectID, id)
+		if err != nil {
+			return err
+		}
+		if patch.Version != nil && *patch.Version != current.Version {
+			return ErrVersionConflict
+		}
+		if err := s.checkLock(ctx, id, AccessScopeFromContext(ctx).UserID, s.now()); err != nil {
+			return err
+		}
+		
+		patched := *current
+		if patch.Type != nil {
+			patched.Type = *patch.Type
+		}
+		if patch.Title != nil {
+			patched.Title = *patch.Title
+		}
+		if patch.Position != nil {
+			patched.Position = *patch.Position
+		}
+		if patch.Required != nil {
+			patched.Required = *patch.Required
+		}
+		if patch.Points != nil {
+			patched.Points = patch.Points
+		}
+		if patch.Explanation != nil {
+			patched.Explanation = patch.Explanation
+		}
+		
+		if err := s.validateTitle(patched.Title); err != nil {
+			return err
+		}
+		if err := s.validateType(patched.Type); err != nil {
+			return err
+		}
+		if err := s.validatePosition(patched.Position); err != nil {
+			return err
+		}
+		if patch.Content != nil || patched.Type != current.Type {
+			var content interface{}
+			if patch.Content != nil {
+				content = patch.Content
+			} else if current.Content != nil {
+				content = current.Content
+			}
+			if patched.Content, err = s.serializeContent(patched.Type, content); err != nil {
+				return err
+			}
+		}
+		
+		item, err = s.itemStore.Update(ctx, projectID, id, patched.Type, patched.Title, patched.Content, patched.Position, patched.Required, patched.Points, patched.Explanation, &current.Version)
+		if errors.Is(err, ErrVersionConflict) && patch.Version == nil {
+			// Another write came between the read and this one; the
+			// client named no version it could have been in conflict with
+			return ErrConcurrentModification
+		}
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -223,9 +709,108 @@ func (s *ItemService) Update(ctx context.Context, id string, itemType types.Item
 	return item, nil
 }
 
-// Delete removes an item.
-func (s *ItemService) Delete(ctx context.Context, id string) error {
-	return s.itemStore.Delete(ctx, id)
+// Delete moves an item of a project to the trash, from which Restore
+// brings it back. Returns ErrItemNotFound unless the item is in the
+// project.
+func (s *ItemService) Delete(ctx context.Context, projectID, id string) error {
+	ctx, span := startSpan(ctx, "ItemService.Delete",
+		attribute.String("project.id", projectID),
+		attribute.String("item.id", id))
+	defer span.End()
+
+	return s.itemStore.Delete(ctx, projectID, id)
+}
+
+// Restore brings back a deleted item of a project. It keeps its position
+// unless another item took it since, and goes to the end of the project
+// then.
+func (s *ItemService) Restore(ctx context.Context, projectID, id string) (*Item, error) {
+	ctx, span := startSpan(ctx, "ItemService.Restore",
+		attribute.String("project.id", projectID),
+		attribute.String("item.id", id))
+	defer span.End()
+
+	item, err := s.itemStore.Restore(ctx, projectID, id)
+	if err != nil {
+		if errors.Is(err, ErrItemNotFound) || errors.Is(err, ErrProjectNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to restore item: %w", err)
+	}
+	return item, nil
+}
+
+// UpdatePositions applies a batch of position changes to a project's items
+// atomically. Each item may appear once and must be in the project
+// (ErrItemNotFound otherwise). Two updates to the same position return
+// ErrItemDuplicatePositions before anything is written; an update to the
+// position of an item that is not moved returns ErrItemInvalidPosition
+// before the transaction commits.
+func (s *ItemService) UpdatePositions(ctx context.Context, projectID string, updates []PositionUpdate) error {
+	ctx, span := startSpan(ctx, "ItemService.UpdatePositions",
+		attribute.String("project.id", projectID),
+		attribute.Int("updates.count", len(updates)))
+	defer span.End()
+
+	items := make(map[string]bool, len(updates))
+	positions := make(map[int]bool, len(updates))
+	for _, update := range updates {
+		if err := s.validatePosition(update.Position); err != nil {
+			return err
+		}
+		if items[update.ItemID] {
+			return fmt.Errorf("%w: item %s is moved twice", ErrItemInvalidPosition, update.ItemID)
+		}
+		if positions[update.Position] {
+			return fmt.Errorf("%w: two items are moved to position %d", ErrItemDuplicatePositions, update.Position)
+		}
+		items[update.ItemID] = true
+		positions[update.Position] = true
+	}
+
+	return s.tx.InTx(ctx, "items.update_positions", func(ctx context.Context) error {
+		if err := s.itemStore.UpdatePositions(ctx, projectID, updates); err != nil {
+			return err
+		}
+		return s.checkPositions(ctx, projectID)
+	})
+}
+
+// checkPositions returns ErrItemInvalidPosition if two of a project's items
+// share a position. Stores may only check this when the transaction commits,
+// where it could not be told from other failures.
+func (s *ItemService) checkPositions(ctx context.Context, projectID string) error {
+	items, err := s.itemStore.ListByProject(ctx, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to check item positions: %w", err)
+	}
+
+	taken := make(map[int]string, len(items))
+	for _, item := range items {
+		if other, ok := taken[item.Position]; ok {
+			return fmt.Errorf("%w: items %s and %s would share position %d", ErrItemInvalidPosition, other, item.ID, item.Position)
+		}
+		taken[item.Position] = item.ID
+	}
+	return nil
+}
+
+// validateInput checks an item's business rules and returns its serialized
+// content.
+func (s *ItemService) validateInput(input ItemInput) (json.RawMessage, error) {
+	if err := s.validateTitle(input.Title); err != nil {
+		return nil, err
+	}
+	
+	if err := s.validateType(input.Type); err != nil {
+		return nil, err
+	}
+	
+	if err := s.validatePosition(input.Position); err != nil {
+		return nil, err
+	}
+	
+	return s.serializeContent(input.Type, input.Content)
 }
 
 // validateTitle ensures the title meets business rules.
@@ -233,7 +818,7 @@ func (s *ItemService) validateTitle(title string) error {
 	if len(title) < 1 {
 		return ErrItemTitleTooShort
 	}
-	if len(title) > 500 {
+	if len(title) > maxItemTitleLength {
 		return ErrItemTitleTooLong
 	}
 	return nil
@@ -259,77 +844,63 @@ func (s *ItemService) validatePosition(position int) error {
 	return nil
 }
 
-// serializeContent converts content to JSON based on item type.
+// serializeContent converts content to JSON based on item type. Content is
+// the type's content struct or anything else encoding as JSON that decodes
+// into it, such as the map a request body decodes to, or JSON itself.
 func (s *ItemService) serializeContent(itemType types.ItemType, content interface{}) (json.RawMessage, error) {
 	if content == nil {
 		return json.RawMessage("{}"), nil
 	}
 	
+	contentBytes, err := contentJSON(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrItemInvalidContent, err)
+	}
+	
 	// Validate content structure based on type
 	switch itemType {
 	case types.ItemTypeChoice, types.ItemTypeMultiChoice:
-		if _, ok := content.(types.ChoiceContent); !ok {
-			// Try to unmarshal if it's already JSON
-			if contentBytes, ok := content.([]byte); ok {
-				var choiceContent types.ChoiceContent
-				if err := json.Unmarshal(contentBytes, &choiceContent); err != nil {
-					return nil, fmt.Errorf("%w: invalid choice content structure", ErrItemInvalidContent)
-				}
-			} else {
-				return nil, fmt.Errorf("%w: expected ChoiceContent for choice items", ErrItemInvalidContent)
-			}
-		}
+		err = decodesAs[types.ChoiceContent](contentBytes)
 	case types.ItemTypeMedia:
-		if _, ok := content.(types.MediaContent); !ok {
-			if contentBytes, ok := content.([]byte); ok {
-				var mediaContent types.MediaContent
-				if err := json.Unmarshal(contentBytes, &mediaContent); err != nil {
-					return nil, fmt.Errorf("%w: invalid media content structure", ErrItemInvalidContent)
-				}
-			} else {
-				return nil, fmt.Errorf("%w: expected MediaContent for media items", ErrItemInvalidContent)
-			}
-		}
+		err = decodesAs[types.MediaContent](contentBytes)
 	case types.ItemTypeTextEntry:
-		if _, ok := content.(types.TextEntryContent); !ok {
-			if contentBytes, ok := content.([]byte); ok {
-				var textContent types.TextEntryContent
-				if err := json.Unmarshal(contentBytes, &textContent); err != nil {
-					return nil, fmt.Errorf("%w: invalid text entry content structure", ErrItemInvalidContent)
-				}
-			} else {
-				return nil, fmt.Errorf("%w: expected TextEntryContent for text entry items", ErrItemInvalidContent)
-			}
-		}
+		err = decodesAs[types.TextEntryContent](contentBytes)
 	case types.ItemTypeOrdering:
-		if _, ok := content.(types.OrderingContent); !ok {
-			if contentBytes, ok := content.([]byte); ok {
-				var orderingContent types.OrderingContent
-				if err := json.Unmarshal(contentBytes, &orderingContent); err != nil {
-					return nil, fmt.Errorf("%w: invalid ordering content structure", ErrItemInvalidContent)
-				}
-			} else {
-				return nil, fmt.Errorf("%w: expected OrderingContent for ordering items", ErrItemInvalidContent)
-			}
-		}
+		err = decodesAs[types.OrderingContent](contentBytes)
 	case types.ItemTypeHotspot:
-		if _, ok := content.(types.HotspotContent); !ok {
-			if contentBytes, ok := content.([]byte); ok {
-				var hotspotContent types.HotspotContent
-				if err := json.Unmarshal(contentBytes, &hotspotContent); err != nil {
-					return nil, fmt.Errorf("%w: invalid hotspot content structure", ErrItemInvalidContent)
-				}
-			} else {
-				return nil, fmt.Errorf("%w: expected HotspotContent for hotspot items", ErrItemInvalidContent)
-			}
-		}
+		err = decodesAs[types.HotspotContent](contentBytes)
 	}
-	
-	// Serialize to JSON
-	contentBytes, err := json.Marshal(content)
 	if err != nil {
-		return nil, fmt.Errorf("failed to serialize content: %w", err)
+		return nil, fmt.Errorf("%w: invalid %s content structure", ErrItemInvalidContent, itemType)
 	}
 	
-	return json.RawMessage(contentBytes), nil
+	return contentBytes, nil
+}
+
+// contentJSON returns content as JSON: as it is if it is JSON already,
+// encoded otherwise.
+func contentJSON(content interface{}) (json.RawMessage, error) {
+	var data []byte
+	switch content := content.(type) {
+	case json.RawMessage:
+		data = content
+	case []byte:
+		data = content
+	default:
+		encoded, err := json.Marshal(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize content: %w", err)
+		}
+		return encoded, nil
+	}
+	if !json.Valid(data) {
+		return nil, errors.New("content is not valid JSON")
+	}
+	return data, nil
+}
+
+// decodesAs returns the error decoding data into a T, if any.
+func decodesAs[T any](data []byte) error {
+	var typed T
+	return json.Unmarshal(data, &typed)
 }
\ No newline at end of file
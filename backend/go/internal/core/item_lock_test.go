@@ -0,0 +1,268 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// mockItemLockStore implements ItemLockStore for testing, deciding expiry
+// against the now it is given like the real store
+type mockItemLockStore struct {
+	locks map[string]ItemLock
+}
+
+func newMockItemLockStore() *mockItemLockStore {
+	return &mockItemLockStore{locks: make(map[string]ItemLock)}
+}
+
+func (m *mockItemLockStore) Acquire(ctx context.Context, lock *ItemLock, now time.Time) (*ItemLock, error) {
+	acquired := *lock
+	if held, ok := m.locks[lock.ItemID]; ok && held.ExpiresAt.After(now) {
+		if held.HolderID != lock.HolderID {
+			return nil, &ItemLockedError{Lock: &held}
+		}
+		acquired.AcquiredAt = held.AcquiredAt
+	}
+	m.locks[lock.ItemID] = acquired
+	return &acquired, nil
+}
+
+func (m *mockItemLockStore) Refresh(ctx context.Context, itemID, holderID string, expiresAt, now time.Time) (*ItemLock, error) {
+	held, ok := m.locks[itemID]
+	if !ok || held.HolderID != holderID || !held.ExpiresAt.After(now) {
+		return nil, ErrItemLockNotHeld
+	}
+	held.ExpiresAt = expiresAt
+	m.locks[itemID] = held
+	return &held, nil
+}
+
+func (m *mockItemLockStore) Release(ctx context.Context, itemID, holderID string, now time.Time) (bool, error) {
+	held, ok := m.locks[itemID]
+	if !ok || (held.HolderID != holderID && held.ExpiresAt.After(now)) {
+		return false, nil
+	}
+	delete(m.locks, itemID)
+	return true, nil
+}
+
+func (m *mockItemLockStore) Get(ctx context.Context, itemID string, now time.Time) (*ItemLock, error) {
+	held, ok := m.locks[itemID]
+	if !ok || !held.ExpiresAt.After(now) {
+		return nil, nil
+	}
+	return &held, nil
+}
+
+func (m *mockItemLockStore) ListByProject(ctx context.Context, projectID string, now time.Time) ([]*ItemLock, error) {
+	var locks []*ItemLock
+	for _, held := range m.locks {
+		if held.ProjectID == projectID && held.ExpiresAt.After(now) {
+			held := held
+			locks = append(locks, &held)
+		}
+	}
+	return locks, nil
+}
+
+// lockEventRecorder records the projects whose locks were announced
+type lockEventRecorder struct {
+	projects []string
+}
+
+func (r *lockEventRecorder) PublishItemLocks(projectID string) {
+	r.projects = append(r.projects, projectID)
+}
+
+// lockItems is an ItemStore holding one item
+type lockItems struct {
+	ItemStore
+	item *Item
+}
+
+func (s *lockItems) GetByID(ctx context.Context, projectID, id string) (*Item, error) {
+	if projectID != s.item.ProjectID || id != s.item.ID {
+		return nil, ErrItemNotFound
+	}
+	return s.item, nil
+}
+
+func (s *lockItems) Update(ctx context.Context, projectID, id string, itemType types.ItemType, title string, content json.RawMessage, position int, required bool, points *int, explanation *string, version *int) (*Item, error) {
+	item, err := s.GetByID(ctx, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+	item.Title = title
+	return item, nil
+}
+
+// lockProjects is a ProjectStore holding one project
+type lockProjects struct {
+	ProjectStore
+	id string
+}
+
+func (s *lockProjects) GetByID(ctx context.Context, id string) (*Project, error) {
+	if id != s.id {
+		return nil, ErrProjectNotFound
+	}
+	return &Project{ID: id}, nil
+}
+
+// lockTestService returns a service with locking enabled on a clock the
+// test moves, and an item "item1" in project "project1"
+func lockTestService(t *testing.T) (*ItemService, *lockEventRecorder, *time.Time) {
+	t.Helper()
+	items := &lockItems{item: &Item{ID: "item1", ProjectID: "project1", Type: types.ItemTypeTitle, Title: "Welcome"}}
+	service := NewItemService(items, &lockProjects{id: "project1"})
+	events := &lockEventRecorder{}
+	service.SetLocks(newMockItemLockStore(), events)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	return service, events, &now
+}
+
+func asUser(userID string) context.Context {
+	return WithAccessScope(context.Background(), AccessScope{UserID: userID, Role: "user"})
+}
+
+func TestItemService_Lock(t *testing.T) {
+	service, events, now := lockTestService(t)
+	start := *now
+
+	lock, err := service.Lock(asUser("alice"), "project1", "item1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", lock.HolderID)
+	assert.Equal(t, start.Add(ItemLockTTL), lock.ExpiresAt)
+	assert.Equal(t, []string{"project1"}, events.projects)
+
+	t.Run("another user is told the holder", func(t *testing.T) {
+		_, err := service.Lock(asUser("bob"), "project1", "item1")
+
+		var lockedErr *ItemLockedError
+		require.ErrorAs(t, err, &lockedErr)
+		assert.ErrorIs(t, err, ErrItemLocked)
+		assert.Equal(t, "alice", lockedErr.Lock.HolderID)
+	})
+
+	t.Run("locking again extends the lock", func(t *testing.T) {
+		*now = start.Add(time.Minute)
+
+		lock, err := service.Lock(asUser("alice"), "project1", "item1")
+
+		require.NoError(t, err)
+		assert.Equal(t, start, lock.AcquiredAt)
+		assert.Equal(t, now.Add(ItemLockTTL), lock.ExpiresAt)
+	})
+
+	t.Run("an expired lock is claimable", func(t *testing.T) {
+		*now = now.Add(ItemLockTTL)
+
+		lock, err := service.Lock(asUser("bob"), "project1", "item1")
+
+		require.NoError(t, err)
+		assert.Equal(t, "bob", lock.HolderID)
+		assert.Equal(t, *now, lock.AcquiredAt)
+	})
+
+	t.Run("item of another project", func(t *testing.T) {
+		_, err := service.Lock(asUser("alice"), "other-project", "item1")
+		assert.ErrorIs(t, err, ErrItemNotFound)
+	})
+
+	t.Run("anonymous caller", func(t *testing.T) {
+		_, err := service.Lock(context.Background(), "project1", "item1")
+		assert.ErrorIs(t, err, errItemLockNoUser)
+	})
+}
+
+func TestItemService_RefreshLock(t *testing.T) {
+	service, _, now := lockTestService(t)
+	_, err := service.Lock(asUser("alice"), "project1", "item1")
+	require.NoError(t, err)
+
+	*now = now.Add(time.Minute)
+	lock, err := service.RefreshLock(asUser("alice"), "project1", "item1")
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(ItemLockTTL), lock.ExpiresAt)
+
+	_, err = service.RefreshLock(asUser("bob"), "project1", "item1")
+	assert.ErrorIs(t, err, ErrItemLocked, "bob cannot refresh alice's lock")
+
+	*now = now.Add(ItemLockTTL)
+	_, err = service.RefreshLock(asUser("alice"), "project1", "item1")
+	assert.ErrorIs(t, err, ErrItemLockNotHeld, "an expired lock is not refreshed")
+
+	_, err = service.Lock(asUser("bob"), "project1", "item1")
+	require.NoError(t, err)
+	_, err = service.RefreshLock(asUser("alice"), "project1", "item1")
+	assert.ErrorIs(t, err, ErrItemLocked, "the lock was taken after it expired")
+}
+
+func TestItemService_Unlock(t *testing.T) {
+	service, events, now := lockTestService(t)
+	_, err := service.Lock(asUser("alice"), "project1", "item1")
+	require.NoError(t, err)
+
+	err = service.Unlock(asUser("bob"), "project1", "item1")
+	assert.ErrorIs(t, err, ErrItemLocked, "only the holder releases a lock")
+	locks, err := service.ListLocks(asUser("bob"), "project1")
+	require.NoError(t, err)
+	assert.Len(t, locks, 1)
+
+	require.NoError(t, service.Unlock(asUser("alice"), "project1", "item1"))
+	locks, err = service.ListLocks(asUser("bob"), "project1")
+	require.NoError(t, err)
+	assert.Empty(t, locks)
+	assert.Equal(t, []string{"project1", "project1"}, events.projects)
+
+	assert.NoError(t, service.Unlock(asUser("alice"), "project1", "item1"), "releasing a free item succeeds")
+
+	_, err = service.Lock(asUser("alice"), "project1", "item1")
+	require.NoError(t, err)
+	*now = now.Add(ItemLockTTL)
+	assert.NoError(t, service.Unlock(asUser("bob"), "project1", "item1"), "an expired lock is anyone's")
+}
+
+func TestItemService_Update_RejectsNonHolders(t *testing.T) {
+	service, _, now := lockTestService(t)
+	_, err := service.Lock(asUser("alice"), "project1", "item1")
+	require.NoError(t, err)
+
+	_, err = service.Update(asUser("bob"), "project1", "item1", types.ItemTypeTitle, "Bob's title", nil, 0, false, nil, nil, nil)
+	var lockedErr *ItemLockedError
+	require.ErrorAs(t, err, &lockedErr)
+	assert.Equal(t, "alice", lockedErr.Lock.HolderID)
+
+	_, err = service.Update(context.Background(), "project1", "item1", types.ItemTypeTitle, "Anonymous title", nil, 0, false, nil, nil, nil)
+	assert.ErrorIs(t, err, ErrItemLocked)
+
+	item, err := service.Update(asUser("alice"), "project1", "item1", types.ItemTypeTitle, "Alice's title", nil, 0, false, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice's title", item.Title)
+
+	*now = now.Add(ItemLockTTL)
+	item, err = service.Update(asUser("bob"), "project1", "item1", types.ItemTypeTitle, "Bob's title", nil, 0, false, nil, nil, nil)
+	require.NoError(t, err, "an expired lock does not hold writes back")
+	assert.Equal(t, "Bob's title", item.Title)
+}
+
+func TestItemLockedError(t *testing.T) {
+	err := error(&ItemLockedError{Lock: &ItemLock{
+		ItemID:    "item1",
+		HolderID:  "alice",
+		ExpiresAt: time.Date(2024, 1, 1, 12, 1, 30, 0, time.UTC),
+	}})
+
+	assert.True(t, errors.Is(err, ErrItemLocked))
+	assert.False(t, errors.Is(err, ErrItemLockNotHeld))
+	assert.Equal(t, "item item1 is locked by alice until 2024-01-01T12:01:30Z", err.Error())
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrItemRevisionNotFound is returned for a revision number an item does
+// not have, or no longer keeps
+var ErrItemRevisionNotFound = errors.New("item revision not found")
+
+// ItemRevision is an earlier state of an item, kept when an update or a
+// delete replaced it
+type ItemRevision struct {
+	ItemID string
+	// Number counts the item's revisions from 1, oldest first
+	Number int
+	// Item is the item as it was
+	Item *Item
+	// ChangedAt and ChangedBy are when, and by which user, the item was
+	// changed from this state. ChangedBy is empty for changes no user
+	// made, e.g. by background jobs.
+	ChangedAt time.Time
+	ChangedBy string
+}
+
+// ListRevisions returns a page of the revisions an item of a project keeps,
+// newest first, with their total. Returns ErrItemNotFound unless the item
+// is in the project.
+func (s *ItemService) ListRevisions(ctx context.Context, projectID, id string, limit, offset int) ([]*ItemRevision, int, error) {
+	ctx, span := startSpan(ctx, "ItemService.ListRevisions",
+		attribute.String("project.id", projectID),
+		attribute.String("item.id", id))
+	defer span.End()
+
+	return s.itemStore.ListRevisions(ctx, projectID, id, limit, offset)
+}
+
+// RestoreRevision writes a revision of an item back as a new update, which
+// keeps a revision of its own, so a restore can be undone like any update.
+// The item keeps its current position: revisions roll back what the item
+// says, not where it is. Returns ErrItemRevisionNotFound unless the item
+// keeps the revision, and fails like Update otherwise, with
+// ErrConcurrentModification if another write came first.
+func (s *ItemService) RestoreRevision(ctx context.Context, projectID, id string, number int) (*Item, error) {
+	ctx, span := startSpan(ctx, "ItemService.RestoreRevision",
+		attribute.String("project.id", projectID),
+		attribute.String("item.id", id),
+		attribute.Int("item.revision", number))
+	defer span.End()
+
+	var item *Item
+	err := s.tx.InTx(ctx, "items.restore_revision", func(ctx context.Context) error {
+		revision, err := s.itemStore.GetRevision(ctx, projectID, id, number)
+		if err != nil {
+			return err
+		}
+		current, err := s.itemStore.GetByID(ctx, projectID, id)
+		if err != nil {
+			return err
+		}
+
+		previous := revision.Item
+		var content interface{}
+		if previous.Content != nil {
+			content = previous.Content
+		}
+		item, err = s.Update(ctx, projectID, id, previous.Type, previous.Title, content, current.Position, previous.Required, previous.Points, previous.Explanation, &current.Version)
+		if errors.Is(err, ErrVersionConflict) {
+			return ErrConcurrentModification
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
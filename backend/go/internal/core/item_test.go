@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -50,13 +51,29 @@ func (m *mockItemStore) Create(ctx context.Context, projectID string, itemType t
 	return item, nil
 }
 
-func (m *mockItemStore) GetByID(ctx context.Context, id string) (*Item, error) {
+func (m *mockItemStore) CreateMany(ctx context.Context, projectID string, items []NewItem) ([]*Item, error) {
+	created := make([]*Item, 0, len(items))
+	for _, item := range items {
+		c, err := m.Create(ctx, projectID, item.Type, item.Title, item.Content, item.Position, item.Required, item.Points, item.Explanation)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, c)
+	}
+	return created, nil
+}
+
+func (m *mockItemStore) CreateBulkCopy(ctx context.Context, projectID string, items []NewItem) ([]*Item, error) {
+	return m.CreateMany(ctx, projectID, items)
+}
+
+func (m *mockItemStore) GetByID(ctx context.Context, projectID, id string) (*Item, error) {
 	if m.lastError != nil {
 		return nil, m.lastError
 	}
 
 	item, exists := m.items[id]
-	if !exists {
+	if !exists || item.ProjectID != projectID {
 		return nil, ErrItemNotFound
 	}
 	return item, nil
@@ -74,15 +91,123 @@ func (m *mockItemStore) ListByProject(ctx context.Context, projectID string) ([]
 	return items, nil
 }
 
-func (m *mockItemStore) Update(ctx context.Context, id string, itemType types.ItemType, title string, content json.RawMessage, position int, required bool, points *int, explanation *string) (*Item, error) {
+// List filters by type and required, and matches titles containing the
+// search term, standing in for the SQL filters
+func (m *mockItemStore) List(ctx context.Context, projectID string, opts ItemListOptions) (*ItemPage, error) {
+	if m.lastError != nil {
+		return nil, m.lastError
+	}
+
+	page := &ItemPage{Items: []*Item{}}
+	for _, item := range m.projectItems[projectID] {
+		if opts.Type != "" && item.Type != opts.Type {
+			continue
+		}
+		if opts.Required != nil && item.Required != *opts.Required {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(item.Title), strings.ToLower(opts.Search)) {
+			continue
+		}
+		if page.Total >= opts.Offset && len(page.Items) < opts.Limit {
+			page.Items = append(page.Items, item)
+		}
+		page.Total++
+	}
+	return page, nil
+}
+
+// Search matches titles containing query, standing in for full-text search
+func (m *mockItemStore) Search(ctx context.Context, projectID, query string) ([]*Item, error) {
+	if m.lastError != nil {
+		return nil, m.lastError
+	}
+
+	var matches []*Item
+	for _, item := range m.projectItems[projectID] {
+		if strings.Contains(strings.ToLower(item.Title), strings.ToLower(query)) {
+			matches = append(matches, item)
+		}
+	}
+	return matches, nil
+}
+
+// Duplicate appends a copy of the item, its ID suffixed with "-copy", to
+// the project
+func (m *mockItemStore) Duplicate(ctx context.Context, projectID, id, title string) (*Item, error) {
+	if m.lastError != nil {
+		return nil, m.lastError
+	}
+
+	source, exists := m.items[id]
+	if !exists || source.ProjectID != projectID {
+		return nil, ErrItemNotFound
+	}
+	position := -1
+	for _, item := range m.projectItems[projectID] {
+		position = max(position, item.Position)
+	}
+	item := *source
+	item.ID = id + "-copy"
+	item.Title = title
+	item.Position = position + 1
+	m.items[item.ID] = &item
+	m.projectItems[projectID] = append(m.projectItems[projectID], &item)
+	return &item, nil
+}
+
+func (m *mockItemStore) CountByProject(ctx context.Context, projectID string) (int, error) {
+	if m.lastError != nil {
+		return 0, m.lastError
+	}
+	return len(m.projectItems[projectID]), nil
+}
+
+func (m *mockItemStore) MaxPosition(ctx context.Context, projectID string) (int, bool, error) {
+	if m.lastError != nil {
+		return 0, false, m.lastError
+	}
+
+	items := m.projectItems[projectID]
+	if len(items) == 0 {
+		return 0, false, nil
+	}
+	max := items[0].Position
+	for _, item := range items[1:] {
+		if item.Position > max {
+			max = item.Position
+		}
+	}
+	return max, true, nil
+}
+
+func (m *mockItemStore) SumPoints(ctx context.Context, projectID string) (int, error) {
+	if m.lastError != nil {
+		return 0, m.lastError
+	}
+
+	sum := 0
+	for _, item := range m.projectItems[projectID] {
+		if item.Points != nil {
+			sum += *item.Points
+		}
+	}
+	return sum, nil
+}
+
+func (m *mockItemStore) Update(ctx context.Context, projectID, id string, itemType types.ItemType, title string, content json.RawMessage, position int, required bool, points *int, explanation *string, version *int) (*Item, error) {
 	if m.lastError != nil {
 		return nil, m.lastError
 	}
 
 	item, exists := m.items[id]
-	if !exists {
+	if !exists || item.ProjectID != projectID {
 		return nil, ErrItemNotFound
 	}
+	if version != nil && *version != item.Version {
+		return nil, ErrVersionConflict
+	}
+	item.Version++
 
 	item.Type = itemType
 	item.Title = title
@@ -96,13 +221,13 @@ func (m *mockItemStore) Update(ctx context.Context, id string, itemType types.It
 	return item, nil
 }
 
-func (m *mockItemStore) Delete(ctx context.Context, id string) error {
+func (m *mockItemStore) Delete(ctx context.Context, projectID, id string) error {
 	if m.lastError != nil {
 		return m.lastError
 	}
 
-	_, exists := m.items[id]
-	if !exists {
+	item, exists := m.items[id]
+	if !exists || item.ProjectID != projectID {
 		return ErrItemNotFound
 	}
 
@@ -110,21 +235,51 @@ func (m *mockItemStore) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (m *mockItemStore) UpdatePositions(ctx context.Context, updates []PositionUpdate) error {
+func (m *mockItemStore) Restore(ctx context.Context, projectID, id string) (*Item, error) {
+	return nil, ErrItemNotFound
+}
+
+func (m *mockItemStore) PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockItemStore) ListRevisions(ctx context.Context, projectID, id string, limit, offset int) ([]*ItemRevision, int, error) {
+	return nil, 0, nil
+}
+
+func (m *mockItemStore) GetRevision(ctx context.Context, projectID, id string, number int) (*ItemRevision, error) {
+	return nil, ErrItemRevisionNotFound
+}
+
+func (m *mockItemStore) UpdatePositions(ctx context.Context, projectID string, updates []PositionUpdate) error {
 	if m.lastError != nil {
 		return m.lastError
 	}
 
 	for _, update := range updates {
 		item, exists := m.items[update.ItemID]
-		if exists {
-			item.Position = update.Position
-			item.UpdatedAt = time.Now()
+		if !exists || item.ProjectID != projectID {
+			return ErrItemNotFound
 		}
 	}
+	for _, update := range updates {
+		item := m.items[update.ItemID]
+		item.Position = update.Position
+		item.UpdatedAt = time.Now()
+	}
 	return nil
 }
 
+// recordingTransactor runs fn directly and records the transactions opened
+type recordingTransactor struct {
+	names []string
+}
+
+func (r *recordingTransactor) InTx(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	r.names = append(r.names, name)
+	return fn(ctx)
+}
+
 // mockProjectStore implements ProjectStore for testing
 type mockProjectStore struct {
 	projects  map[string]*Project
@@ -162,11 +317,11 @@ func (m *mockProjectStore) GetByID(ctx context.Context, id string) (*Project, er
 	return project, nil
 }
 
-func (m *mockProjectStore) List(ctx context.Context, limit, offset int) ([]*Project, int, error) {
-	return nil, 0, nil
+func (m *mockProjectStore) List(ctx context.Context, opts ListOptions) (*ProjectPage, error) {
+	return &ProjectPage{}, nil
 }
 
-func (m *mockProjectStore) Update(ctx context.Context, id string, title string, description *string, tags []string) (*Project, error) {
+func (m *mockProjectStore) Update(ctx context.Context, id string, title string, description *string, tags []string, version *int) (*Project, error) {
 	return nil, nil
 }
 
@@ -174,6 +329,90 @@ func (m *mockProjectStore) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+func (m *mockProjectStore) Publish(ctx context.Context, id string) (*Project, error) {
+	project, err := m.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if project.PublishedAt == nil {
+		now := time.Now()
+		project.PublishedAt = &now
+	}
+	return project, nil
+}
+
+func (m *mockProjectStore) SearchByTitle(ctx context.Context, searchTerm string, limit, offset int) ([]*Project, int, error) {
+	return nil, 0, nil
+}
+
+func (m *mockProjectStore) Restore(ctx context.Context, id string) (*Project, error) {
+	return nil, ErrProjectNotFound
+}
+
+func (m *mockProjectStore) Purge(ctx context.Context, id string) error {
+	return ErrProjectNotFound
+}
+
+func (m *mockProjectStore) ListPublications(ctx context.Context, projectID string, limit, offset int) ([]*Publication, int, error) {
+	if _, err := m.GetByID(ctx, projectID); err != nil {
+		return nil, 0, err
+	}
+	return []*Publication{}, 0, nil
+}
+
+func (m *mockProjectStore) GetPublication(ctx context.Context, projectID string, version int) (*Publication, error) {
+	if _, err := m.GetByID(ctx, projectID); err != nil {
+		return nil, err
+	}
+	return nil, ErrPublicationNotFound
+}
+
+func (m *mockProjectStore) PublishedOrgID(ctx context.Context, id string) (string, error) {
+	project, exists := m.projects[id]
+	if !exists || project.PublishedAt == nil {
+		return "", ErrProjectNotFound
+	}
+	return "", nil
+}
+
+func (m *mockProjectStore) OrgID(ctx context.Context, id string) (string, error) {
+	if _, exists := m.projects[id]; !exists {
+		return "", ErrProjectNotFound
+	}
+	return "", nil
+}
+
+// Duplicate copies the project, under the ID "copy-of-" followed by its
+// ID, without its items
+func (m *mockProjectStore) UpdateResultsSettings(ctx context.Context, id string, settings ResultsSettings) (*Project, error) {
+	if m.lastError != nil {
+		return nil, m.lastError
+	}
+	project, exists := m.projects[id]
+	if !exists {
+		return nil, ErrProjectNotFound
+	}
+	project.ResultsSettings = &settings
+	return project, nil
+}
+
+func (m *mockProjectStore) Duplicate(ctx context.Context, id, title string) (*Project, error) {
+	if m.lastError != nil {
+		return nil, m.lastError
+	}
+
+	source, exists := m.projects[id]
+	if !exists {
+		return nil, ErrProjectNotFound
+	}
+	project := *source
+	project.ID = "copy-of-" + id
+	project.Title = title
+	project.PublishedAt = nil
+	m.projects[project.ID] = &project
+	return &project, nil
+}
+
 func TestItemService_Create(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -322,18 +561,24 @@ func TestItemService_GetByID(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("successful get", func(t *testing.T) {
-		item, err := service.GetByID(ctx, "test-item-id")
+		item, err := service.GetByID(ctx, "test-project-id", "test-item-id")
 		require.NoError(t, err)
 		assert.Equal(t, testItem.ID, item.ID)
 		assert.Equal(t, testItem.Title, item.Title)
 	})
 
 	t.Run("item not found", func(t *testing.T) {
-		item, err := service.GetByID(ctx, "non-existent-id")
+		item, err := service.GetByID(ctx, "test-project-id", "non-existent-id")
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrItemNotFound)
 		assert.Nil(t, item)
 	})
+
+	t.Run("item of another project", func(t *testing.T) {
+		item, err := service.GetByID(ctx, "other-project-id", "test-item-id")
+		assert.ErrorIs(t, err, ErrItemNotFound)
+		assert.Nil(t, item)
+	})
 }
 
 func TestItemService_ListByProject(t *testing.T) {
@@ -369,6 +614,376 @@ func TestItemService_ListByProject(t *testing.T) {
 	})
 }
 
+func TestItemService_List(t *testing.T) {
+	// Arrange
+	itemStore := newMockItemStore()
+	projectStore := newMockProjectStore()
+	service := NewItemService(itemStore, projectStore)
+	projectStore.projects["test-project-id"] = &Project{ID: "test-project-id"}
+	itemStore.projectItems["test-project-id"] = []*Item{
+		{ID: "item1", ProjectID: "test-project-id", Type: types.ItemTypeTitle, Title: "Intro", Position: 0},
+		{ID: "item2", ProjectID: "test-project-id", Type: types.ItemTypeChoice, Title: "Pick one", Position: 1, Required: true},
+		{ID: "item3", ProjectID: "test-project-id", Type: types.ItemTypeChoice, Title: "Pick two", Position: 2},
+	}
+	ctx := context.Background()
+
+	// Act
+	page, err := service.List(ctx, "test-project-id", ItemListOptions{Type: types.ItemTypeChoice, Limit: 1, Offset: 1})
+	_, notFoundErr := service.List(ctx, "non-existent-project", ItemListOptions{Limit: 10})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 2, page.Total)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "item3", page.Items[0].ID)
+	assert.ErrorIs(t, notFoundErr, ErrProjectNotFound)
+}
+
+func TestItemService_CreateMany(t *testing.T) {
+	tests := []struct {
+		name          string
+		projectID     string
+		inputs        []ItemInput
+		storeErr      error
+		expectedErr   error
+		expectedItems int
+		expectedTx    []string
+	}{
+		{
+			name:      "creates every item in one transaction",
+			projectID: "test-project-id",
+			inputs: []ItemInput{
+				{Type: types.ItemTypeTitle, Title: "Intro", Position: 0},
+				{Type: types.ItemTypeTitle, Title: "Outro", Position: 1},
+			},
+			expectedItems: 2,
+			expectedTx:    []string{"items.create_many"},
+		},
+		{
+			name:      "invalid item fails before the transaction",
+			projectID: "test-project-id",
+			inputs: []ItemInput{
+				{Type: types.ItemTypeTitle, Title: "Intro", Position: 0},
+				{Type: types.ItemTypeTitle, Title: "", Position: 1},
+			},
+			expectedErr: ErrItemTitleTooShort,
+		},
+		{
+			name:        "project not found",
+			projectID:   "non-existent-project",
+			inputs:      []ItemInput{{Type: types.ItemTypeTitle, Title: "Intro"}},
+			expectedErr: ErrProjectNotFound,
+			expectedTx:  []string{"items.create_many"},
+		},
+		{
+			name:        "store failure fails the whole batch",
+			projectID:   "test-project-id",
+			inputs:      []ItemInput{{Type: types.ItemTypeTitle, Title: "Intro"}},
+			storeErr:    assert.AnError,
+			expectedErr: assert.AnError,
+			expectedTx:  []string{"items.create_many"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			itemStore := newMockItemStore()
+			projectStore := newMockProjectStore()
+			transactor := &recordingTransactor{}
+			service := NewItemService(itemStore, projectStore)
+			service.SetTransactor(transactor)
+			projectStore.projects["test-project-id"] = &Project{ID: "test-project-id"}
+			itemStore.lastError = tt.storeErr
+
+			// Act
+			items, err := service.CreateMany(context.Background(), tt.projectID, tt.inputs)
+
+			// Assert
+			assert.Equal(t, tt.expectedTx, transactor.names)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, items)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, items, tt.expectedItems)
+		})
+	}
+}
+
+func TestItemService_UpdatePositions(t *testing.T) {
+	tests := []struct {
+		name             string
+		updates          []PositionUpdate
+		expectedErr      error
+		expectedPosition map[string]int
+	}{
+		{
+			name:             "swaps positions",
+			updates:          []PositionUpdate{{ItemID: "item1", Position: 1}, {ItemID: "item2", Position: 0}},
+			expectedPosition: map[string]int{"item1": 1, "item2": 0},
+		},
+		{
+			name:        "negative position",
+			updates:     []PositionUpdate{{ItemID: "item1", Position: -1}},
+			expectedErr: ErrItemInvalidPosition,
+		},
+		{
+			name:        "item moved twice",
+			updates:     []PositionUpdate{{ItemID: "item1", Position: 1}, {ItemID: "item1", Position: 2}},
+			expectedErr: ErrItemInvalidPosition,
+		},
+		{
+			name:        "two items moved to one position",
+			updates:     []PositionUpdate{{ItemID: "item1", Position: 2}, {ItemID: "item2", Position: 2}},
+			expectedErr: ErrItemDuplicatePositions,
+		},
+		{
+			name:        "item of another project changes nothing",
+			updates:     []PositionUpdate{{ItemID: "item1", Position: 1}, {ItemID: "other", Position: 0}},
+			expectedErr: ErrItemNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			itemStore := newMockItemStore()
+			service := NewItemService(itemStore, newMockProjectStore())
+			itemStore.items["item1"] = &Item{ID: "item1", ProjectID: "test-project-id", Position: 0}
+			itemStore.items["item2"] = &Item{ID: "item2", ProjectID: "test-project-id", Position: 1}
+			itemStore.items["other"] = &Item{ID: "other", ProjectID: "other-project-id", Position: 0}
+
+			// Act
+			err := service.UpdatePositions(context.Background(), "test-project-id", tt.updates)
+
+			// Assert
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Equal(t, 0, itemStore.items["item1"].Position, "nothing moved")
+				return
+			}
+			require.NoError(t, err)
+			for id, position := range tt.expectedPosition {
+				assert.Equal(t, position, itemStore.items[id].Position, id)
+			}
+		})
+	}
+}
+
+func TestItemService_Search(t *testing.T) {
+	tests := []struct {
+		name        string
+		projectID   string
+		query       string
+		expectedIDs []string
+		expectedErr error
+	}{
+		{
+			name:        "matching items",
+			projectID:   "test-project-id",
+			query:       "lighthouse",
+			expectedIDs: []string{"item1"},
+		},
+		{
+			name:      "no match",
+			projectID: "test-project-id",
+			query:     "volcano",
+		},
+		{
+			name:        "project not found",
+			projectID:   "non-existent-project",
+			query:       "lighthouse",
+			expectedErr: ErrProjectNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			itemStore := newMockItemStore()
+			projectStore := newMockProjectStore()
+			service := NewItemService(itemStore, projectStore)
+			projectStore.projects["test-project-id"] = &Project{ID: "test-project-id"}
+			itemStore.projectItems["test-project-id"] = []*Item{
+				{ID: "item1", Title: "Lighthouse keepers"},
+				{ID: "item2", Title: "Coastal signals"},
+			}
+
+			// Act
+			items, err := service.Search(context.Background(), tt.projectID, tt.query)
+
+			// Assert
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			ids := make([]string, 0, len(items))
+			for _, item := range items {
+				ids = append(ids, item.ID)
+			}
+			assert.ElementsMatch(t, tt.expectedIDs, ids)
+		})
+	}
+}
+
+func TestItemService_Duplicate(t *testing.T) {
+	longTitle := strings.Repeat("a", 497) + "é"
+	tests := []struct {
+		name             string
+		projectID        string
+		itemID           string
+		title            string
+		expectedTitle    string
+		expectedPosition int
+		expectedErr      error
+	}{
+		{
+			name:             "copy at the end",
+			projectID:        "test-project-id",
+			itemID:           "item1",
+			title:            "Lighthouse keepers",
+			expectedTitle:    "Lighthouse keepers (copy)",
+			expectedPosition: 3,
+		},
+		{
+			name:             "long title shortened on a rune boundary",
+			projectID:        "test-project-id",
+			itemID:           "item1",
+			title:            longTitle,
+			expectedTitle:    strings.Repeat("a", 493) + " (copy)",
+			expectedPosition: 3,
+		},
+		{
+			name:        "item not found",
+			projectID:   "test-project-id",
+			itemID:      "missing",
+			title:       "Lighthouse keepers",
+			expectedErr: ErrItemNotFound,
+		},
+		{
+			name:        "item of another project",
+			projectID:   "test-project-id",
+			itemID:      "other",
+			title:       "Lighthouse keepers",
+			expectedErr: ErrItemNotFound,
+		},
+		{
+			name:        "project not found",
+			projectID:   "non-existent-project",
+			itemID:      "item1",
+			title:       "Lighthouse keepers",
+			expectedErr: ErrProjectNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			itemStore := newMockItemStore()
+			projectStore := newMockProjectStore()
+			service := NewItemService(itemStore, projectStore)
+			projectStore.projects["test-project-id"] = &Project{ID: "test-project-id"}
+			content := json.RawMessage(`{"choices":[{"id":"a","text":"Yes"}]}`)
+			items := []*Item{
+				{ID: "item1", ProjectID: "test-project-id", Type: types.ItemTypeChoice, Title: tt.title, Content: content, Position: 0},
+				{ID: "item2", ProjectID: "test-project-id", Type: types.ItemTypeTitle, Title: "Coastal signals", Position: 2},
+			}
+			for _, item := range items {
+				itemStore.items[item.ID] = item
+			}
+			itemStore.projectItems["test-project-id"] = items
+			itemStore.items["other"] = &Item{ID: "other", ProjectID: "other-project-id", Title: "Elsewhere"}
+
+			// Act
+			item, err := service.Duplicate(context.Background(), tt.projectID, tt.itemID)
+
+			// Assert
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "item1-copy", item.ID)
+			assert.Equal(t, tt.expectedTitle, item.Title)
+			assert.LessOrEqual(t, len(item.Title), 500)
+			assert.Equal(t, tt.expectedPosition, item.Position)
+			assert.Equal(t, types.ItemTypeChoice, item.Type)
+			assert.JSONEq(t, string(content), string(item.Content))
+		})
+	}
+}
+
+func TestItemService_Aggregates(t *testing.T) {
+	tests := []struct {
+		name             string
+		projectID        string
+		items            []*Item
+		expectedCount    int
+		expectedMax      int
+		expectedHasItems bool
+		expectedPoints   int
+		expectedErr      error
+	}{
+		{
+			name:      "project with items",
+			projectID: "test-project-id",
+			items: []*Item{
+				{ID: "item1", Position: 0, Points: intPtr(10)},
+				{ID: "item2", Position: 4},
+				{ID: "item3", Position: 2, Points: intPtr(5)},
+			},
+			expectedCount:    3,
+			expectedMax:      4,
+			expectedHasItems: true,
+			expectedPoints:   15,
+		},
+		{
+			name:      "empty project",
+			projectID: "test-project-id",
+		},
+		{
+			name:        "project not found",
+			projectID:   "non-existent-project",
+			expectedErr: ErrProjectNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			itemStore := newMockItemStore()
+			projectStore := newMockProjectStore()
+			service := NewItemService(itemStore, projectStore)
+			projectStore.projects["test-project-id"] = &Project{ID: "test-project-id"}
+			itemStore.projectItems["test-project-id"] = tt.items
+			ctx := context.Background()
+
+			// Act
+			count, countErr := service.CountByProject(ctx, tt.projectID)
+			max, hasItems, maxErr := service.MaxPosition(ctx, tt.projectID)
+			points, pointsErr := service.SumPoints(ctx, tt.projectID)
+
+			// Assert
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, countErr, tt.expectedErr)
+				assert.ErrorIs(t, maxErr, tt.expectedErr)
+				assert.ErrorIs(t, pointsErr, tt.expectedErr)
+				return
+			}
+			require.NoError(t, countErr)
+			require.NoError(t, maxErr)
+			require.NoError(t, pointsErr)
+			assert.Equal(t, tt.expectedCount, count)
+			assert.Equal(t, tt.expectedMax, max)
+			assert.Equal(t, tt.expectedHasItems, hasItems)
+			assert.Equal(t, tt.expectedPoints, points)
+		})
+	}
+}
+
 func TestItemService_Update(t *testing.T) {
 	itemStore := newMockItemStore()
 	projectStore := newMockProjectStore()
@@ -395,7 +1010,7 @@ func TestItemService_Update(t *testing.T) {
 			},
 		}
 
-		item, err := service.Update(ctx, "test-item-id", types.ItemTypeChoice, "Updated Title", newContent, 1, true, intPtr(20), stringPtr("Updated explanation"))
+		item, err := service.Update(ctx, "test-project-id", "test-item-id", types.ItemTypeChoice, "Updated Title", newContent, 1, true, intPtr(20), stringPtr("Updated explanation"), nil)
 		require.NoError(t, err)
 		assert.Equal(t, "Updated Title", item.Title)
 		assert.Equal(t, 1, item.Position)
@@ -405,11 +1020,103 @@ func TestItemService_Update(t *testing.T) {
 	})
 
 	t.Run("item not found", func(t *testing.T) {
-		item, err := service.Update(ctx, "non-existent-id", types.ItemTypeChoice, "Title", nil, 0, false, nil, nil)
+		item, err := service.Update(ctx, "test-project-id", "non-existent-id", types.ItemTypeChoice, "Title", nil, 0, false, nil, nil, nil)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrItemNotFound)
 		assert.Nil(t, item)
 	})
+
+	t.Run("item of another project", func(t *testing.T) {
+		item, err := service.Update(ctx, "other-project-id", "test-item-id", types.ItemTypeChoice, "Title", nil, 0, false, nil, nil, nil)
+		assert.ErrorIs(t, err, ErrItemNotFound)
+		assert.Nil(t, item)
+		assert.Equal(t, "Updated Title", testItem.Title, "the item is unchanged")
+	})
+}
+
+func TestItemService_Patch(t *testing.T) {
+	title := "Renamed"
+	required := true
+	empty := ""
+	choice := types.ItemTypeChoice
+
+	tests := []struct {
+		name        string
+		projectID   string
+		patch       ItemPatch
+		expectedErr error
+		check       func(t *testing.T, item *Item)
+	}{
+		{
+			name:      "changes only the fields set",
+			projectID: "test-project-id",
+			patch:     ItemPatch{Required: &required},
+			check: func(t *testing.T, item *Item) {
+				assert.True(t, item.Required)
+				assert.Equal(t, "Intro", item.Title)
+				assert.Equal(t, 3, item.Position)
+				assert.Equal(t, 5, *item.Points)
+				assert.JSONEq(t, `["intro"]`, string(item.Content), "content left out is not checked")
+			},
+		},
+		{
+			name:      "sets content",
+			projectID: "test-project-id",
+			patch:     ItemPatch{Title: &title, Content: json.RawMessage(`{"size":"large"}`)},
+			check: func(t *testing.T, item *Item) {
+				assert.Equal(t, "Renamed", item.Title)
+				assert.JSONEq(t, `{"size":"large"}`, string(item.Content))
+			},
+		},
+		{
+			name:        "empty title",
+			projectID:   "test-project-id",
+			patch:       ItemPatch{Title: &empty},
+			expectedErr: ErrItemTitleTooShort,
+		},
+		{
+			name:        "type the content does not fit",
+			projectID:   "test-project-id",
+			patch:       ItemPatch{Type: &choice},
+			expectedErr: ErrItemInvalidContent,
+		},
+		{
+			name:        "item of another project",
+			projectID:   "other-project-id",
+			patch:       ItemPatch{Title: &title},
+			expectedErr: ErrItemNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			itemStore := newMockItemStore()
+			service := NewItemService(itemStore, newMockProjectStore())
+			itemStore.items["test-item-id"] = &Item{
+				ID:        "test-item-id",
+				ProjectID: "test-project-id",
+				Type:      types.ItemTypeTitle,
+				Title:     "Intro",
+				Content:   json.RawMessage(`["intro"]`),
+				Position:  3,
+				Points:    intPtr(5),
+			}
+
+			// Act
+			item, err := service.Patch(context.Background(), tt.projectID, "test-item-id", tt.patch)
+
+			// Assert
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, item)
+				assert.Equal(t, "Intro", itemStore.items["test-item-id"].Title, "nothing changed")
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, item)
+		})
+	}
 }
 
 func TestItemService_Delete(t *testing.T) {
@@ -418,17 +1125,22 @@ func TestItemService_Delete(t *testing.T) {
 	service := NewItemService(itemStore, projectStore)
 
 	// Setup test item
-	itemStore.items["test-item-id"] = &Item{ID: "test-item-id"}
+	itemStore.items["test-item-id"] = &Item{ID: "test-item-id", ProjectID: "test-project-id"}
 
 	ctx := context.Background()
 
+	t.Run("item of another project", func(t *testing.T) {
+		err := service.Delete(ctx, "other-project-id", "test-item-id")
+		assert.ErrorIs(t, err, ErrItemNotFound)
+	})
+
 	t.Run("successful delete", func(t *testing.T) {
-		err := service.Delete(ctx, "test-item-id")
+		err := service.Delete(ctx, "test-project-id", "test-item-id")
 		require.NoError(t, err)
 	})
 
 	t.Run("item not found", func(t *testing.T) {
-		err := service.Delete(ctx, "non-existent-id")
+		err := service.Delete(ctx, "test-project-id", "non-existent-id")
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrItemNotFound)
 	})
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Domain errors for LTI launches
+var (
+	// ErrLTIDisabled is returned when LTI launches are turned off with the
+	// enable_lti_integration setting
+	ErrLTIDisabled = errors.New("LTI integration is disabled")
+
+	// ErrLTIPlatformNotFound is returned when no platform is registered with
+	// the given ID, or for the issuer and client ID of a launch
+	ErrLTIPlatformNotFound = errors.New("LTI platform not found")
+
+	// ErrLTIPlatformExists is returned when registering an issuer and
+	// client ID pair that is already registered
+	ErrLTIPlatformExists = errors.New("LTI platform already registered")
+
+	// ErrLTIInvalidLaunch is returned, wrapped with the reason, when a
+	// launch's state or id_token fails validation
+	ErrLTIInvalidLaunch = errors.New("invalid LTI launch")
+
+	// ErrLTIResourceLinkNotMapped is returned when a resource link is
+	// launched before it is bound to a project
+	ErrLTIResourceLinkNotMapped = errors.New("LTI resource link not mapped to a project")
+
+	// ErrLTISessionNotFound is returned when a participant session doesn't
+	// exist or has expired
+	ErrLTISessionNotFound = errors.New("LTI session not found")
+)
+
+// LTIPlatform is a learning platform registered to launch projects as an
+// LTI 1.3 tool. The issuer and client ID identify it in launches.
+type LTIPlatform struct {
+	ID string
+
+	// OrgID is the organization whose projects the platform launches; nil
+	// reaches the projects that belong to no organization
+	OrgID *string
+
+	Issuer   string
+	ClientID string
+
+	// AuthLoginURL is the platform's OIDC authorization endpoint, where
+	// login initiations are redirected
+	AuthLoginURL string
+	// AuthTokenURL is the platform's OAuth 2 token endpoint, which grants
+	// the access tokens for posting scores
+	AuthTokenURL string
+	// KeySetURL serves the JWKS that signs the platform's id_tokens
+	KeySetURL string
+
+	CreatedAt time.Time
+}
+
+// LTIState is an OIDC login in progress. The platform returns the state
+// with the id_token, which must carry the nonce; each state is used once.
+type LTIState struct {
+	State      string
+	Nonce      string
+	PlatformID string
+	ExpiresAt  time.Time
+}
+
+// LTIResourceLink binds a platform's resource link, the placement of the
+// tool in a course, to the published project it launches
+type LTIResourceLink struct {
+	PlatformID     string
+	DeploymentID   string
+	ResourceLinkID string
+	ProjectID      string
+	CreatedAt      time.Time
+}
+
+// LTISession is a participant launched into a project by a platform. The
+// participant holds a bearer token; only its SHA-256 is stored, as the ID.
+type LTISession struct {
+	ID         string
+	PlatformID string
+	ProjectID  string
+
+	// Subject is the platform's ID for the user
+	Subject string
+	Name    string
+	Email   string
+	Roles   []string
+
+	// LineItemURL is the gradebook column scores are posted to, or "" when
+	// the launch granted no score service
+	LineItemURL string
+
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// LTIStore persists platform registrations and the launches they make.
+// Platforms are not scoped to the organization in the context; only
+// operators manage them. Implementations must be safe for concurrent use.
+type LTIStore interface {
+	// CreatePlatform returns ErrLTIPlatformExists if the issuer and client
+	// ID are already registered, or ErrOrganizationNotFound
+	CreatePlatform(ctx context.Context, platform *LTIPlatform) (*LTIPlatform, error)
+
+	// GetPlatform returns ErrLTIPlatformNotFound if the platform doesn't
+	// exist
+	GetPlatform(ctx context.Context, id string) (*LTIPlatform, error)
+
+	// FindPlatform returns the platform registered for the issuer and
+	// client ID. An empty client ID finds the issuer's only registration.
+	// Returns ErrLTIPlatformNotFound if none or several match.
+	FindPlatform(ctx context.Context, issuer, clientID string) (*LTIPlatform, error)
+
+	// ListPlatforms returns every platform ordered by issuer and client ID
+	ListPlatforms(ctx context.Context) ([]*LTIPlatform, error)
+
+	// DeletePlatform removes the platform with its resource links and
+	// sessions. Returns ErrLTIPlatformNotFound if it doesn't exist.
+	DeletePlatform(ctx context.Context, id string) error
+
+	// SaveState stores a login in progress
+	SaveState(ctx context.Context, state *LTIState) error
+
+	// TakeState deletes and returns the state if it has not expired.
+	// Returns ErrLTIInvalidLaunch if it doesn't exist, was already taken or
+	// expired.
+	TakeState(ctx context.Context, state string, now time.Time) (*LTIState, error)
+
+	// GetResourceLink returns ErrLTIResourceLinkNotMapped if the resource
+	// link isn't bound
+	GetResourceLink(ctx context.Context, platformID, deploymentID, resourceLinkID string) (*LTIResourceLink, error)
+
+	// BindResourceLink binds the resource link unless it is already bound,
+	// and returns the binding that holds
+	BindResourceLink(ctx context.Context, link *LTIResourceLink) (*LTIResourceLink, error)
+
+	// CreateSession stores a participant session
+	CreateSession(ctx context.Context, session *LTISession) error
+
+	// GetSession returns ErrLTISessionNotFound if the session doesn't exist
+	// or has expired
+	GetSession(ctx context.Context, id string, now time.Time) (*LTISession, error)
+
+	// DeleteExpired removes the states and sessions that expired before
+	// now and returns how many were deleted
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
@@ -0,0 +1,33 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// MaintenanceMode is the cluster-wide maintenance switch. While it is
+// enabled the API answers requests with 503 maintenance, except reads when
+// AllowReads is set.
+type MaintenanceMode struct {
+	Enabled bool
+
+	// Message is shown to clients whose requests are rejected.
+	Message string
+
+	// AllowReads lets GET, HEAD and OPTIONS requests through.
+	AllowReads bool
+
+	// UpdatedAt and UpdatedBy record the last change.
+	UpdatedAt time.Time
+	UpdatedBy string
+}
+
+// MaintenanceStore persists the maintenance switch so every replica sees the
+// same state. Implementations must be safe for concurrent use.
+type MaintenanceStore interface {
+	// Get returns the current switch, or a disabled one if it was never set.
+	Get(ctx context.Context) (MaintenanceMode, error)
+
+	// Set replaces the switch.
+	Set(ctx context.Context, mode MaintenanceMode) error
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Domain errors for organizations
+var (
+	// ErrOrganizationNotFound is returned when an organization with the given ID doesn't exist
+	ErrOrganizationNotFound = errors.New("organization not found")
+
+	// ErrMembershipNotFound is returned when the user is not a member of the organization
+	ErrMembershipNotFound = errors.New("membership not found")
+
+	// ErrProjectQuotaExceeded is returned when an organization already has as
+	// many projects as its quota allows
+	ErrProjectQuotaExceeded = errors.New("project quota exceeded")
+)
+
+// Membership roles
+const (
+	MembershipRoleAdmin  = "admin"
+	MembershipRoleMember = "member"
+)
+
+// Organization is a tenant: a company or department whose projects and items
+// are invisible to every other organization.
+type Organization struct {
+	ID   string
+	Name string
+
+	// MaxProjects caps the organization's projects; nil means no limit
+	MaxProjects *int
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Membership links a user to an organization they may work in
+type Membership struct {
+	OrgID     string
+	UserID    string
+	Role      string
+	CreatedAt time.Time
+}
+
+// OrganizationStore persists organizations and their members. Organizations
+// themselves are not scoped; only operators manage them.
+type OrganizationStore interface {
+	Create(ctx context.Context, name string, maxProjects *int) (*Organization, error)
+
+	// GetByID returns ErrOrganizationNotFound if the organization doesn't exist
+	GetByID(ctx context.Context, id string) (*Organization, error)
+
+	// List returns every organization ordered by name
+	List(ctx context.Context) ([]*Organization, error)
+
+	// Update returns ErrOrganizationNotFound if the organization doesn't exist
+	Update(ctx context.Context, id, name string, maxProjects *int) (*Organization, error)
+
+	// Delete removes the organization with its projects and memberships.
+	// Returns ErrOrganizationNotFound if the organization doesn't exist.
+	Delete(ctx context.Context, id string) error
+
+	// ListMembers returns ErrOrganizationNotFound if the organization doesn't exist
+	ListMembers(ctx context.Context, orgID string) ([]*Membership, error)
+
+	// PutMember adds the user to the organization or changes their role.
+	// Returns ErrOrganizationNotFound if the organization doesn't exist.
+	PutMember(ctx context.Context, orgID, userID, role string) (*Membership, error)
+
+	// RemoveMember returns ErrMembershipNotFound if the user isn't a member
+	RemoveMember(ctx context.Context, orgID, userID string) error
+
+	// GetMembership returns ErrMembershipNotFound if the user isn't a member
+	GetMembership(ctx context.Context, orgID, userID string) (*Membership, error)
+}
+
+// orgIDKey carries the active organization in a context
+type orgIDKey struct{}
+
+// WithOrgID scopes ctx to the organization: stores read and write only its
+// projects and items
+func WithOrgID(ctx context.Context, orgID string) context.Context {
+	return context.WithValue(ctx, orgIDKey{}, orgID)
+}
+
+// OrgIDFromContext returns the active organization, or "" when ctx isn't
+// scoped to one. Unscoped contexts only see data that belongs to no
+// organization, as in a single-tenant deployment.
+func OrgIDFromContext(ctx context.Context) string {
+	orgID, _ := ctx.Value(orgIDKey{}).(string)
+	return orgID
+}
+
+// UserRoleAdmin is the token role of operators
+const UserRoleAdmin = "admin"
+
+// AccessScope is who the stores act for: a user, with the role and the
+// organization their token carries, or the system itself. Within an
+// organization (see WithOrgID) the stores only reach the projects and items
+// of an organization the user is a member of, whatever the handler
+// checked, unless the scope needs no membership there.
+type AccessScope struct {
+	UserID string
+	Role   string
+
+	// OrgID is the organization the user's token vouches for, trusted like
+	// a membership
+	OrgID string
+
+	// System is set for background jobs, which act for no user
+	System bool
+}
+
+// NeedsMembership reports whether the scope reaches the organization orgID
+// only through a membership. The system's scope and operators' reach every
+// organization, as with RequireRole, and a token reaches its own.
+func (s AccessScope) NeedsMembership(orgID string) bool {
+	return !s.System && s.Role != UserRoleAdmin && s.OrgID != orgID
+}
+
+// accessScopeKey carries the access scope in a context
+type accessScopeKey struct{}
+
+// WithAccessScope makes the stores act for scope in ctx
+func WithAccessScope(ctx context.Context, scope AccessScope) context.Context {
+	return context.WithValue(ctx, accessScopeKey{}, scope)
+}
+
+// AccessScopeFromContext returns the scope the stores act for in ctx. A
+// context without one acts for an anonymous user, who is a member of no
+// organization.
+func AccessScopeFromContext(ctx context.Context) AccessScope {
+	scope, _ := ctx.Value(accessScopeKey{}).(AccessScope)
+	return scope
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countedProjects is a ProjectStore holding a fixed number of projects
+type countedProjects struct {
+	ProjectStore
+	total   int
+	created int
+	// freshReads records whether the last List asked for fresh reads
+	freshReads bool
+}
+
+func (s *countedProjects) List(ctx context.Context, opts ListOptions) (*ProjectPage, error) {
+	s.freshReads = FreshReads(ctx)
+	return &ProjectPage{Total: s.total, HasMore: s.total > 0}, nil
+}
+
+func (s *countedProjects) Create(ctx context.Context, title string, description *string, tags []string) (*Project, error) {
+	s.created++
+	return &Project{ID: "project-1", Title: title}, nil
+}
+
+// quotaOrganizations is an OrganizationStore with one organization
+type quotaOrganizations struct {
+	OrganizationStore
+	org *Organization
+}
+
+func (s *quotaOrganizations) GetByID(ctx context.Context, id string) (*Organization, error) {
+	if s.org == nil || id != s.org.ID {
+		return nil, ErrOrganizationNotFound
+	}
+	return s.org, nil
+}
+
+func TestProjectService_Create_EnforcesOrganizationQuota(t *testing.T) {
+	two := 2
+
+	tests := []struct {
+		name          string
+		orgID         string
+		maxProjects   *int
+		total         int
+		expectedError error
+	}{
+		{"under quota", "org-1", &two, 1, nil},
+		{"at quota", "org-1", &two, 2, ErrProjectQuotaExceeded},
+		{"no quota", "org-1", nil, 50, nil},
+		{"unscoped request has no quota", "", &two, 2, nil},
+		{"unknown organization", "org-2", &two, 0, ErrOrganizationNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			projects := &countedProjects{total: tt.total}
+			service := NewProjectService(projects, nil)
+			service.SetOrganizations(&quotaOrganizations{org: &Organization{ID: "org-1", MaxProjects: tt.maxProjects}})
+
+			ctx := context.Background()
+			if tt.orgID != "" {
+				ctx = WithOrgID(ctx, tt.orgID)
+			}
+
+			// Act
+			_, err := service.Create(ctx, "Quiz", nil, nil)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Zero(t, projects.created)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, projects.created)
+		})
+	}
+}
+
+func TestProjectService_Create_CountsQuotaWithFreshReads(t *testing.T) {
+	// Arrange
+	two := 2
+	projects := &countedProjects{total: 1}
+	service := NewProjectService(projects, nil)
+	service.SetOrganizations(&quotaOrganizations{org: &Organization{ID: "org-1", MaxProjects: &two}})
+
+	// Act
+	_, err := service.Create(WithOrgID(context.Background(), "org-1"), "Quiz", nil, nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, projects.freshReads, "a lagging replica would miss projects just created")
+}
+
+func TestProjectService_Restore_EnforcesOrganizationQuota(t *testing.T) {
+	// Arrange
+	two := 2
+	service := NewProjectService(&countedProjects{total: 2}, nil)
+	service.SetOrganizations(&quotaOrganizations{org: &Organization{ID: "org-1", MaxProjects: &two}})
+
+	// Act
+	_, err := service.Restore(WithOrgID(context.Background(), "org-1"), "project-1")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrProjectQuotaExceeded, "a restored project counts towards the quota again")
+}
+
+func TestAccessScope_NeedsMembership(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    AccessScope
+		expected bool
+	}{
+		{"user", AccessScope{UserID: "user-1", Role: "user"}, true},
+		{"anonymous", AccessScope{}, true},
+		{"token of another organization", AccessScope{UserID: "user-1", Role: "user", OrgID: "org-2"}, true},
+		{"token of the organization", AccessScope{UserID: "user-1", Role: "user", OrgID: "org-1"}, false},
+		{"operator", AccessScope{UserID: "operator-1", Role: UserRoleAdmin}, false},
+		{"system", AccessScope{System: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			needed := tt.scope.NeedsMembership("org-1")
+
+			// Assert
+			assert.Equal(t, tt.expected, needed)
+		})
+	}
+}
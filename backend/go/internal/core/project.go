@@ -16,7 +16,8 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Domain errors for project operations.
@@ -31,8 +32,14 @@ var (
 	
 	// ErrProjectTitleTooLong is returned when a project title exceeds the maximum length.
 	ErrProjectTitleTooLong = errors.New("project title too long")
+	
+	// ErrProjectNotPublished is returned when a project must be published to be taken, as by learners.
+	ErrProjectNotPublished = errors.New("project not published")
 )
 
+// maxProjectTitleLength is the longest project title, in bytes.
+const maxProjectTitleLength = 200
+
 // Project represents a quiz project entity in the ProveMySelf platform.
 // It contains all the metadata for a quiz project, including title, description,
 // tags for categorization, and timestamps for lifecycle management.
@@ -41,7 +48,8 @@ var (
 // - Title must be between 1 and 200 characters
 // - Description is optional and can be up to 1000 characters
 // - Tags are optional, maximum 10 tags, each tag max 50 characters
-// - Projects can be published at most once (PublishedAt is immutable once set)
+// - Projects can be published again; each publish is kept as a numbered
+//   Publication (PublishedAt is the first publish, immutable once set)
 // - CreatedAt and UpdatedAt are managed automatically
 type Project struct {
 	// ID is the unique identifier for the project (UUID format).
@@ -67,9 +75,130 @@ type Project struct {
 	// Updated automatically on any change to the project.
 	UpdatedAt time.Time
 	
-	// PublishedAt is the timestamp when the project was published.
+	// PublishedAt is the timestamp when the project was first published.
 	// Nil until the project is published, then immutable once set.
 	PublishedAt *time.Time
+	
+	// LatestPublishedVersion is the version of the project's latest
+	// Publication, nil while it has none. Only GetByID, List and Publish
+	// read it; nil otherwise.
+	LatestPublishedVersion *int
+	
+	// DeletedAt is the timestamp when the project was deleted. Deleted
+	// projects are only listed with ListOptions.IncludeDeleted.
+	DeletedAt *time.Time
+	
+	// ItemCount is the number of items in the project, not counting
+	// deleted ones. Only GetByID and List count them; nil otherwise.
+	ItemCount *int
+	
+	// ResultsSettings are what learners are shown of their graded
+	// attempts. Only GetByID and UpdateResultsSettings read them; nil
+	// otherwise.
+	ResultsSettings *ResultsSettings
+	
+	// Version starts at 1 and is incremented by every write to the
+	// project, not by writes to its items.
+	Version int
+}
+
+// ProjectStatus filters projects by whether they are published.
+type ProjectStatus string
+
+// Project statuses.
+const (
+	ProjectStatusDraft     ProjectStatus = "draft"
+	ProjectStatusPublished ProjectStatus = "published"
+)
+
+// ProjectSort orders project lists. Ties are broken by ID so pages are
+// stable.
+type ProjectSort string
+
+// Project sort orders.
+const (
+	// ProjectSortCreatedAt lists the newest projects first. It is the default.
+	ProjectSortCreatedAt ProjectSort = "created_at"
+	
+	// ProjectSortUpdatedAt lists the most recently modified projects first.
+	ProjectSortUpdatedAt ProjectSort = "updated_at"
+	
+	// ProjectSortTitle lists projects alphabetically by title.
+	ProjectSortTitle ProjectSort = "title"
+	
+	// ProjectSortCreatedAtAsc lists the oldest projects first.
+	ProjectSortCreatedAtAsc ProjectSort = "created_at_asc"
+	
+	// ProjectSortUpdatedAtAsc lists the least recently modified projects first.
+	ProjectSortUpdatedAtAsc ProjectSort = "updated_at_asc"
+	
+	// ProjectSortTitleDesc lists projects by title in reverse alphabetical order.
+	ProjectSortTitleDesc ProjectSort = "title_desc"
+)
+
+// ListOptions filters, sorts and paginates ProjectStore.List. Zero values
+// apply no filter and the default order.
+type ListOptions struct {
+	// Tags restricts the list to projects having every one of the tags.
+	Tags []string
+	
+	// Status restricts the list to draft or published projects.
+	Status ProjectStatus
+	
+	// Search restricts the list to projects whose title or description
+	// contains the term, case-insensitively.
+	Search string
+	
+	// Sort is the list order, ProjectSortCreatedAt when empty.
+	Sort ProjectSort
+	
+	// Limit and Offset select the page.
+	Limit  int
+	Offset int
+	
+	// After, when set, selects the page following the cursor in Sort
+	// order instead of Offset. Pages read this way are stable while
+	// projects are added, and the total is not counted.
+	After *ProjectCursor
+	
+	// IncludeDeleted lists deleted projects too, which are left out by
+	// default.
+	IncludeDeleted bool
+	
+	// Deleted lists only deleted projects, the trash.
+	Deleted bool
+}
+
+// ProjectCursor is the position of a project in a list, for reading the
+// next page after it. Only the key of the list's sort is compared, then ID.
+type ProjectCursor struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Title     string
+	ID        string
+}
+
+// CursorAfter returns the cursor of a project, usually the last one of a
+// page.
+func CursorAfter(project *Project) *ProjectCursor {
+	return &ProjectCursor{
+		CreatedAt: project.CreatedAt,
+		UpdatedAt: project.UpdatedAt,
+		Title:     project.Title,
+		ID:        project.ID,
+	}
+}
+
+// ProjectPage is a page of a project list.
+type ProjectPage struct {
+	Projects []*Project
+	
+	// Total counts the projects matching the filters, or is -1 for pages
+	// read After a cursor, which skip the count.
+	Total int
+	
+	// HasMore reports whether projects follow the page.
+	HasMore bool
 }
 
 // ProjectStore defines the contract for project data persistence.
@@ -77,6 +206,9 @@ type Project struct {
 // (PostgreSQL, MongoDB, in-memory, etc.) without changing business logic.
 //
 // All methods should be safe for concurrent use and handle context cancellation.
+// Every method is scoped to the organization in ctx (see WithOrgID): projects
+// of other organizations, or of one the access scope in ctx is not a member
+// of (see AccessScope), are reported as not found.
 type ProjectStore interface {
 	// Create persists a new project with the given parameters.
 	// Returns the created project with generated ID and timestamps.
@@ -87,28 +219,67 @@ type ProjectStore interface {
 	// Returns ErrProjectNotFound if the project doesn't exist.
 	GetByID(ctx context.Context, id string) (*Project, error)
 	
-	// List retrieves a paginated list of projects ordered by creation date (desc).
-	// Returns the projects slice, total count, and any error.
-	// Limit and offset are used for pagination.
-	List(ctx context.Context, limit, offset int) ([]*Project, int, error)
+	// List retrieves a page of the projects matching opts, in opts' order.
+	List(ctx context.Context, opts ListOptions) (*ProjectPage, error)
 	
 	// Update modifies an existing project with new values.
 	// Returns the updated project with new UpdatedAt timestamp.
+	// Returns ErrProjectNotFound if the project doesn't exist, and
+	// ErrVersionConflict if version is set and the project has another.
+	Update(ctx context.Context, id string, title string, description *string, tags []string, version *int) (*Project, error)
+	
+	// UpdateResultsSettings sets a project's results policy, as a write to
+	// the project, and returns the project as GetByID does.
 	// Returns ErrProjectNotFound if the project doesn't exist.
-	Update(ctx context.Context, id string, title string, description *string, tags []string) (*Project, error)
+	UpdateResultsSettings(ctx context.Context, id string, settings ResultsSettings) (*Project, error)
 	
-	// Delete permanently removes a project from storage.
+	// Delete moves a project to the trash: it is left out of every other
+	// method, its items with it, until it is restored.
 	// Returns ErrProjectNotFound if the project doesn't exist.
 	Delete(ctx context.Context, id string) error
 	
-	// Publish marks a project as published by setting PublishedAt timestamp.
-	// Can only be called once per project (PublishedAt is immutable).
+	// Restore takes a project out of the trash, with the items it had.
+	// Returns ErrProjectNotFound if the trash has no such project.
+	Restore(ctx context.Context, id string) (*Project, error)
+	
+	// Purge permanently removes a project in the trash and its items.
+	// Returns ErrProjectNotFound if the trash has no such project.
+	Purge(ctx context.Context, id string) error
+	
+	// Publish publishes a project: it freezes the project and its items as
+	// its next Publication, in one transaction, and sets PublishedAt the
+	// first time.
 	// Returns ErrProjectNotFound if the project doesn't exist.
 	Publish(ctx context.Context, id string) (*Project, error)
 	
-	// SearchByTitle finds projects by searching title and description fields.
-	// Returns paginated results matching the search term (case-insensitive).
-	SearchByTitle(ctx context.Context, searchTerm string, limit, offset int) ([]*Project, int, error)
+	// ListPublications retrieves a page of a project's publications, newest
+	// first and without their items, with how many there are.
+	// Returns ErrProjectNotFound if the project doesn't exist.
+	ListPublications(ctx context.Context, projectID string, limit, offset int) ([]*Publication, int, error)
+	
+	// GetPublication retrieves a publication of a project by its version.
+	// Returns ErrProjectNotFound if the project doesn't exist, and
+	// ErrPublicationNotFound if it has no such publication.
+	GetPublication(ctx context.Context, projectID string, version int) (*Publication, error)
+	
+	// PublishedOrgID returns the organization a published project belongs
+	// to, "" for none. Unlike every other method it is not scoped: published
+	// projects are public, and learners reach them without an organization.
+	// Returns ErrProjectNotFound if the project doesn't exist, is deleted or
+	// is not published.
+	PublishedOrgID(ctx context.Context, id string) (string, error)
+	
+	// OrgID returns the organization a project belongs to, "" for none,
+	// published or not. Like PublishedOrgID it is not scoped: callers read
+	// the project itself within the organization, as whoever they act for.
+	// Returns ErrProjectNotFound if the project doesn't exist or is deleted.
+	OrgID(ctx context.Context, id string) (string, error)
+	
+	// Duplicate copies a project, its description, tags, results policy and
+	// items, into a new unpublished project named title, all in one
+	// transaction.
+	// Returns ErrProjectNotFound if the project doesn't exist.
+	Duplicate(ctx context.Context, id, title string) (*Project, error)
 }
 
 // ProjectService implements the use cases for project management.
@@ -121,21 +292,64 @@ type ProjectStore interface {
 type ProjectService struct {
 	// store provides data persistence capabilities for projects.
 	store ProjectStore
+
+	// orgs, when set, supplies the per-organization project quotas.
+	orgs OrganizationStore
+
+	// items, when set, supplies the items checked before publishing.
+	items ItemStore
+
+	// events, when set, is told about created, updated, deleted and
+	// published projects.
+	events EventPublisher
+
+	// files holds the project assets removed with purged projects, nil
+	// without storage.
+	files *StorageService
 }
 
-// NewProjectService creates a new project service
-func NewProjectService(store ProjectStore) *ProjectService {
+// NewProjectService creates a new project service. Purged projects' stored
+// assets are removed from files, which is nil when there is no storage.
+func NewProjectService(store ProjectStore, files *StorageService) *ProjectService {
 	return &ProjectService{
 		store: store,
+		files: files,
+	}
+}
+
+// SetOrganizations enforces the project quotas of the organizations in orgs
+// on projects created in an organization's scope
+func (s *ProjectService) SetOrganizations(orgs OrganizationStore) {
+	s.orgs = orgs
+}
+
+// SetItems checks that a project's items can be taken by learners before
+// it is published
+func (s *ProjectService) SetItems(items ItemStore) {
+	s.items = items
+}
+
+// SetEvents publishes an event after each change to a project
+func (s *ProjectService) SetEvents(events EventPublisher) {
+	s.events = events
+}
+
+// publish tells the event publisher, if any, about the project
+func (s *ProjectService) publish(ctx context.Context, eventType EventType, project *Project) {
+	if s.events != nil {
+		s.events.Publish(ctx, NewEvent(ctx, eventType, project))
 	}
 }
 
 // Create creates a new project
 func (s *ProjectService) Create(ctx context.Context, title string, description *string, tags []string) (*Project, error) {
+	ctx, span := startSpan(ctx, "ProjectService.Create")
+	defer span.End()
+
 	if len(title) < 1 {
 		return nil, ErrProjectTitleTooShort
 	}
-	if len(title) > 200 {
+	if len(title) > maxProjectTitleLength {
 		return nil, ErrProjectTitleTooLong
 	}
 
@@ -149,25 +363,74 @@ func (s *ProjectService) Create(ctx context.Context, title string, description *
 		}
 	}
 
-	return s.store.Create(ctx, title, description, tags)
+	if err := s.checkQuota(ctx); err != nil {
+		return nil, err
+	}
+
+	project, err := s.store.Create(ctx, title, description, tags)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(ctx, EventProjectCreated, project)
+	return project, nil
+}
+
+// checkQuota returns ErrProjectQuotaExceeded when the organization in ctx
+// already has its maximum number of projects. Concurrent creates may
+// overshoot the quota by the number of requests in flight.
+func (s *ProjectService) checkQuota(ctx context.Context) error {
+	orgID := OrgIDFromContext(ctx)
+	if s.orgs == nil || orgID == "" {
+		return nil
+	}
+
+	org, err := s.orgs.GetByID(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to load organization quota: %w", err)
+	}
+	if org.MaxProjects == nil {
+		return nil
+	}
+
+	// The store counts the projects of the organization in ctx, on the
+	// primary so projects created just before count
+	page, err := s.store.List(WithFreshReads(ctx), ListOptions{Limit: 1})
+	if err != nil {
+		return fmt.Errorf("failed to count organization projects: %w", err)
+	}
+	if page.Total >= *org.MaxProjects {
+		return ErrProjectQuotaExceeded
+	}
+	return nil
 }
 
 // GetByID retrieves a project by ID
 func (s *ProjectService) GetByID(ctx context.Context, id string) (*Project, error) {
+	ctx, span := startSpan(ctx, "ProjectService.GetByID", attribute.String("project.id", id))
+	defer span.End()
+
 	return s.store.GetByID(ctx, id)
 }
 
-// List retrieves projects with pagination
-func (s *ProjectService) List(ctx context.Context, limit, offset int) ([]*Project, int, error) {
-	return s.store.List(ctx, limit, offset)
+// List returns the page of projects selected by opts, filtered and counted
+// by the store
+func (s *ProjectService) List(ctx context.Context, opts ListOptions) (*ProjectPage, error) {
+	ctx, span := startSpan(ctx, "ProjectService.List")
+	defer span.End()
+
+	return s.store.List(ctx, opts)
 }
 
-// Update updates a project
-func (s *ProjectService) Update(ctx context.Context, id string, title string, description *string, tags []string) (*Project, error) {
+// Update updates a project, if version is set only while it has that
+// version
+func (s *ProjectService) Update(ctx context.Context, id string, title string, description *string, tags []string, version *int) (*Project, error) {
+	ctx, span := startSpan(ctx, "ProjectService.Update", attribute.String("project.id", id))
+	defer span.End()
+
 	if len(title) < 1 {
 		return nil, ErrProjectTitleTooShort
 	}
-	if len(title) > 200 {
+	if len(title) > maxProjectTitleLength {
 		return nil, ErrProjectTitleTooLong
 	}
 
@@ -181,20 +444,133 @@ func (s *ProjectService) Update(ctx context.Context, id string, title string, de
 		}
 	}
 
-	return s.store.Update(ctx, id, title, description, tags)
+	project, err := s.store.Update(ctx, id, title, description, tags, version)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(ctx, EventProjectUpdated, project)
+	return project, nil
 }
 
-// Delete deletes a project
+// Delete moves a project to the trash
 func (s *ProjectService) Delete(ctx context.Context, id string) error {
-	return s.store.Delete(ctx, id)
+	ctx, span := startSpan(ctx, "ProjectService.Delete", attribute.String("project.id", id))
+	defer span.End()
+
+	if s.events == nil {
+		return s.store.Delete(ctx, id)
+	}
+
+	// The event carries the project as it was
+	project, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.publish(ctx, EventProjectDeleted, project)
+	return nil
 }
 
-// Publish publishes a project
+// Restore takes a project out of the trash. It counts towards the
+// organization's project quota again, so it fails with
+// ErrProjectQuotaExceeded if the organization has no room for it.
+func (s *ProjectService) Restore(ctx context.Context, id string) (*Project, error) {
+	ctx, span := startSpan(ctx, "ProjectService.Restore", attribute.String("project.id", id))
+	defer span.End()
+
+	if err := s.checkQuota(ctx); err != nil {
+		return nil, err
+	}
+
+	project, err := s.store.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(ctx, EventProjectRestored, project)
+	return project, nil
+}
+
+// Purge permanently removes a project in the trash and its items. With
+// storage, it then removes the project's stored assets; the project is
+// purged even if some of them can't be, and `admin storage reconcile`
+// removes them later.
+func (s *ProjectService) Purge(ctx context.Context, id string) error {
+	ctx, span := startSpan(ctx, "ProjectService.Purge", attribute.String("project.id", id))
+	defer span.End()
+
+	if err := s.store.Purge(ctx, id); err != nil {
+		return err
+	}
+	if s.files == nil {
+		return nil
+	}
+
+	removed, failed, err := s.files.CleanupProjectFiles(ctx, id)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", id).Msg("failed to remove files of purged project")
+		return nil
+	}
+	// Each file that failed is logged on its own
+	log.Ctx(ctx).Info().Str("project_id", id).Int("removed", removed).Int("failed", failed).Msg("removed files of purged project")
+	return nil
+}
+
+// Publish publishes a project, again if it was published before, as its
+// next publication. With the items set, it first checks that learners can
+// take it, failing with a *ProjectNotPublishableError if not.
 func (s *ProjectService) Publish(ctx context.Context, id string) (*Project, error) {
-	return s.store.Publish(ctx, id)
+	ctx, span := startSpan(ctx, "ProjectService.Publish", attribute.String("project.id", id))
+	defer span.End()
+
+	if s.items != nil {
+		if _, err := s.store.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		if err := s.checkPublishable(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	project, err := s.store.Publish(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(ctx, EventProjectPublished, project)
+	return project, nil
 }
 
-// SearchByTitle searches projects by title
+// Duplicate copies a project and its items into a new draft project, titled
+// like the project with " (copy)" appended
+func (s *ProjectService) Duplicate(ctx context.Context, id string) (*Project, error) {
+	ctx, span := startSpan(ctx, "ProjectService.Duplicate", attribute.String("project.id", id))
+	defer span.End()
+
+	source, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkQuota(ctx); err != nil {
+		return nil, err
+	}
+
+	project, err := s.store.Duplicate(ctx, id, copyTitle(source.Title, maxProjectTitleLength))
+	if err != nil {
+		return nil, err
+	}
+	s.publish(ctx, EventProjectCreated, project)
+	return project, nil
+}
+
+// SearchByTitle searches projects by title and description
 func (s *ProjectService) SearchByTitle(ctx context.Context, searchTerm string, limit, offset int) ([]*Project, int, error) {
-	return s.store.SearchByTitle(ctx, searchTerm, limit, offset)
+	ctx, span := startSpan(ctx, "ProjectService.SearchByTitle")
+	defer span.End()
+
+	page, err := s.store.List(ctx, ListOptions{Search: searchTerm, Limit: limit, Offset: offset})
+	if err != nil {
+		return nil, 0, err
+	}
+	return page.Projects, page.Total, nil
 }
\ No newline at end of file
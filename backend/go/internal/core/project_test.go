@@ -5,10 +5,51 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// memoryProjectStore is a ProjectStore keeping projects in memory, in the
+// order they were created
+type memoryProjectStore struct {
+	ProjectStore
+	projects []*Project
+}
+
+func newMemoryProjectStore() *memoryProjectStore {
+	return &memoryProjectStore{}
+}
+
+func (m *memoryProjectStore) Create(ctx context.Context, title string, description *string, tags []string) (*Project, error) {
+	now := time.Now()
+	project := &Project{
+		ID:          uuid.NewString(),
+		Title:       title,
+		Description: description,
+		Tags:        tags,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	m.projects = append(m.projects, project)
+	return project, nil
+}
+
+func (m *memoryProjectStore) GetByID(ctx context.Context, id string) (*Project, error) {
+	for _, project := range m.projects {
+		if project.ID == id {
+			return project, nil
+		}
+	}
+	return nil, ErrProjectNotFound
+}
+
+func (m *memoryProjectStore) List(ctx context.Context, opts ListOptions) (*ProjectPage, error) {
+	start := min(opts.Offset, len(m.projects))
+	end := min(start+opts.Limit, len(m.projects))
+	return &ProjectPage{Projects: m.projects[start:end], Total: len(m.projects)}, nil
+}
+
 func TestProjectService_Create(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -68,7 +109,7 @@ func TestProjectService_Create(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Arrange
-			service := NewProjectService()
+			service := NewProjectService(newMemoryProjectStore(), nil)
 			ctx := context.Background()
 
 			// Act
@@ -123,7 +164,7 @@ func TestProjectService_GetByID(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Arrange
-			service := NewProjectService()
+			service := NewProjectService(newMemoryProjectStore(), nil)
 			ctx := context.Background()
 			projectID := tt.setup(service)
 
@@ -240,23 +281,23 @@ func TestProjectService_List(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Arrange
-			service := NewProjectService()
+			service := NewProjectService(newMemoryProjectStore(), nil)
 			tt.setup(service)
 			ctx := context.Background()
 
 			// Act
-			projects, total, err := service.List(ctx, tt.limit, tt.offset)
+			page, err := service.List(ctx, ListOptions{Limit: tt.limit, Offset: tt.offset})
 
 			// Assert
-			assert.NoError(t, err)
-			tt.validate(t, projects, total)
+			require.NoError(t, err)
+			tt.validate(t, page.Projects, page.Total)
 		})
 	}
 }
 
 func TestProjectService_Create_UniqueIDs(t *testing.T) {
 	// Arrange
-	service := NewProjectService()
+	service := NewProjectService(newMemoryProjectStore(), nil)
 	ctx := context.Background()
 
 	// Act - create multiple projects
@@ -279,8 +320,3 @@ func TestProjectService_Create_UniqueIDs(t *testing.T) {
 	assert.NotEmpty(t, project2.ID)
 	assert.NotEmpty(t, project3.ID)
 }
-
-// Helper function to create string pointers
-func stringPtr(s string) *string {
-	return &s
-}
\ No newline at end of file
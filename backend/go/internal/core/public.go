@@ -0,0 +1,209 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// PublicProject is a published project as the player app loads it for
+// learners: its latest publication, without anything that gives the
+// answers away (see SanitizeItem)
+type PublicProject struct {
+	// Project is the project as it was last published
+	Project *Project
+	// Version is the publication served, 0 for a project published before
+	// publications were kept, whose live items are served instead
+	Version int
+	// Items are the sanitized items, in position order
+	Items []*Item
+}
+
+// GetPublic returns a published project for learners to take, whatever the
+// organization in ctx: published projects are public. Returns
+// ErrProjectNotFound unless the project is published.
+func (s *ProjectService) GetPublic(ctx context.Context, id string) (*PublicProject, error) {
+	ctx, span := startSpan(ctx, "ProjectService.GetPublic", attribute.String("project.id", id))
+	defer span.End()
+
+	ctx, project, err := openPublished(ctx, s.store, id)
+	if err != nil {
+		return nil, err
+	}
+	version := latestVersion(project)
+	project, items, err := publishedItems(ctx, s.store, s.items, project, version)
+	if err != nil {
+		return nil, err
+	}
+
+	public := &PublicProject{Project: project, Version: version, Items: make([]*Item, len(items))}
+	for i, item := range items {
+		if public.Items[i], err = SanitizeItem(item); err != nil {
+			return nil, err
+		}
+	}
+	return public, nil
+}
+
+// PublishedVersion returns the version of a published project's latest
+// publication, 0 for a project published before publications were kept.
+// Returns ErrProjectNotFound unless the project is published.
+func (s *ProjectService) PublishedVersion(ctx context.Context, id string) (int, error) {
+	ctx, span := startSpan(ctx, "ProjectService.PublishedVersion", attribute.String("project.id", id))
+	defer span.End()
+
+	_, project, err := openPublished(ctx, s.store, id)
+	if err != nil {
+		return 0, err
+	}
+	return latestVersion(project), nil
+}
+
+// openPublished reads a published project for learners, whatever the
+// organization in ctx, and returns it with the context to read the rest of
+// it in: acting for the system, within the project's organization. Returns
+// ErrProjectNotFound unless the project is published.
+func openPublished(ctx context.Context, projects ProjectStore, id string) (context.Context, *Project, error) {
+	orgID, err := projects.PublishedOrgID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx = WithOrgID(WithAccessScope(ctx, AccessScope{System: true}), orgID)
+
+	project, err := projects.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ctx, project, nil
+}
+
+// latestVersion is the version of a project's latest publication, 0 for a
+// project published before publications were kept
+func latestVersion(project *Project) int {
+	if project.LatestPublishedVersion == nil {
+		return 0
+	}
+	return *project.LatestPublishedVersion
+}
+
+// publishedItems returns a project, read with openPublished, and its
+// items, unsanitized, as published as version, or as they are now for
+// version 0. Without items, projects served live have none.
+func publishedItems(ctx context.Context, projects ProjectStore, items ItemStore, project *Project, version int) (*Project, []*Item, error) {
+	if version > 0 {
+		publication, err := projects.GetPublication(ctx, project.ID, version)
+		if err != nil {
+			return nil, nil, err
+		}
+		return publication.Project, publication.Items, nil
+	}
+	if items == nil {
+		return project, nil, nil
+	}
+	live, err := items.ListByProject(ctx, project.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return project, live, nil
+}
+
+// Learner-facing content: the fields of the item contents in package types
+// that don't grade answers
+type (
+	publicChoice struct {
+		ID   string `json:"id"`
+		Text string `json:"text"`
+	}
+	publicChoiceContent struct {
+		Choices []publicChoice `json:"choices"`
+	}
+	publicOrderingItem struct {
+		ID   string `json:"id"`
+		Text string `json:"text"`
+	}
+	publicOrderingContent struct {
+		Items []publicOrderingItem `json:"items"`
+	}
+	publicTextEntryContent struct {
+		MaxLength   *int    `json:"max_length,omitempty"`
+		Placeholder *string `json:"placeholder,omitempty"`
+		Multiline   bool    `json:"multiline"`
+	}
+	publicHotspotContent struct {
+		ImageURL string  `json:"image_url"`
+		AltText  *string `json:"alt_text,omitempty"`
+	}
+)
+
+// SanitizeItem returns a copy of item that learners can be shown: without
+// its explanation, and with its content stripped of answer keys (see
+// SanitizeContent)
+func SanitizeItem(item *Item) (*Item, error) {
+	content, err := SanitizeContent(item.Type, item.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sanitize item %s: %w", item.ID, err)
+	}
+	sanitized := *item
+	sanitized.Content = content
+	sanitized.Explanation = nil
+	return &sanitized, nil
+}
+
+// SanitizeContent returns the content of an item of itemType without the
+// fields that grade answers: which choices are correct, the correct order
+// and answer, and the hotspot regions, whose geometry is the answer to a
+// hotspot item. Ordering entries are listed by text, so their stored order
+// gives nothing away either. Content of other types is returned as is.
+func SanitizeContent(itemType types.ItemType, content json.RawMessage) (json.RawMessage, error) {
+	if len(content) == 0 {
+		return content, nil
+	}
+
+	var public interface{}
+	switch itemType {
+	case types.ItemTypeChoice, types.ItemTypeMultiChoice:
+		var full types.ChoiceContent
+		if err := json.Unmarshal(content, &full); err != nil {
+			return nil, err
+		}
+		choices := make([]publicChoice, len(full.Choices))
+		for i, choice := range full.Choices {
+			choices[i] = publicChoice{ID: choice.ID, Text: choice.Text}
+		}
+		public = publicChoiceContent{Choices: choices}
+	case types.ItemTypeOrdering:
+		var full types.OrderingContent
+		if err := json.Unmarshal(content, &full); err != nil {
+			return nil, err
+		}
+		entries := make([]publicOrderingItem, len(full.Items))
+		for i, entry := range full.Items {
+			entries[i] = publicOrderingItem{ID: entry.ID, Text: entry.Text}
+		}
+		slices.SortFunc(entries, func(a, b publicOrderingItem) int {
+			return cmp.Or(cmp.Compare(a.Text, b.Text), cmp.Compare(a.ID, b.ID))
+		})
+		public = publicOrderingContent{Items: entries}
+	case types.ItemTypeTextEntry:
+		var full types.TextEntryContent
+		if err := json.Unmarshal(content, &full); err != nil {
+			return nil, err
+		}
+		public = publicTextEntryContent{MaxLength: full.MaxLength, Placeholder: full.Placeholder, Multiline: full.Multiline}
+	case types.ItemTypeHotspot:
+		var full types.HotspotContent
+		if err := json.Unmarshal(content, &full); err != nil {
+			return nil, err
+		}
+		public = publicHotspotContent{ImageURL: full.ImageURL, AltText: full.AltText}
+	default:
+		return content, nil
+	}
+	return json.Marshal(public)
+}
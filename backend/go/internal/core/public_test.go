@@ -0,0 +1,244 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// answerKeys are the content and item fields that grade answers
+var answerKeys = []string{"correct", "correct_order", "correct_answer", "hotspots", "feedback", "explanation"}
+
+// assertNoAnswerKeys fails if any object in the JSON document data has one
+// of answerKeys
+func assertNoAnswerKeys(t *testing.T, data []byte) {
+	t.Helper()
+	var document interface{}
+	require.NoError(t, json.Unmarshal(data, &document))
+
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			for _, key := range answerKeys {
+				assert.NotContains(t, value, key, "answer key left in %s", data)
+			}
+			for _, field := range value {
+				walk(field)
+			}
+		case []interface{}:
+			for _, element := range value {
+				walk(element)
+			}
+		}
+	}
+	walk(document)
+}
+
+func TestSanitizeContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		itemType types.ItemType
+		content  string
+		expected string
+	}{
+		{
+			name:     "title",
+			itemType: types.ItemTypeTitle,
+			content:  `{"text":"Capitals"}`,
+			expected: `{"text":"Capitals"}`,
+		},
+		{
+			name:     "media",
+			itemType: types.ItemTypeMedia,
+			content:  `{"url":"https://example.com/map.png","media_type":"image","autoplay":false,"show_controls":true}`,
+			expected: `{"url":"https://example.com/map.png","media_type":"image","autoplay":false,"show_controls":true}`,
+		},
+		{
+			name:     "choice",
+			itemType: types.ItemTypeChoice,
+			content:  `{"choices":[{"id":"a","text":"Paris","correct":true},{"id":"b","text":"Lyon","correct":false}]}`,
+			expected: `{"choices":[{"id":"a","text":"Paris"},{"id":"b","text":"Lyon"}]}`,
+		},
+		{
+			name:     "multi_choice",
+			itemType: types.ItemTypeMultiChoice,
+			content:  `{"choices":[{"id":"a","text":"Oslo","correct":true},{"id":"b","text":"Bergen"},{"id":"c","text":"Stockholm","correct":true}]}`,
+			expected: `{"choices":[{"id":"a","text":"Oslo"},{"id":"b","text":"Bergen"},{"id":"c","text":"Stockholm"}]}`,
+		},
+		{
+			name:     "ordering entries are listed by text",
+			itemType: types.ItemTypeOrdering,
+			content:  `{"items":[{"id":"1","text":"Rome","correct_order":1},{"id":"2","text":"Berlin","correct_order":3},{"id":"3","text":"Canberra","correct_order":2}]}`,
+			expected: `{"items":[{"id":"2","text":"Berlin"},{"id":"3","text":"Canberra"},{"id":"1","text":"Rome"}]}`,
+		},
+		{
+			name:     "text_entry",
+			itemType: types.ItemTypeTextEntry,
+			content:  `{"max_length":20,"placeholder":"City","multiline":false,"correct_answer":"Madrid"}`,
+			expected: `{"max_length":20,"placeholder":"City","multiline":false}`,
+		},
+		{
+			name:     "hotspot",
+			itemType: types.ItemTypeHotspot,
+			content:  `{"image_url":"https://example.com/map.png","alt_text":"Europe","hotspots":[{"id":"a","shape":"circle","coords":[10,20,5],"correct":true,"feedback":"Yes"},{"id":"b","shape":"rectangle","coords":[0,0,5,5]}]}`,
+			expected: `{"image_url":"https://example.com/map.png","alt_text":"Europe"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			content, err := SanitizeContent(tt.itemType, json.RawMessage(tt.content))
+
+			// Assert
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(content))
+			assertNoAnswerKeys(t, content)
+		})
+	}
+}
+
+func TestSanitizeContent_MalformedContent(t *testing.T) {
+	// Act
+	_, err := SanitizeContent(types.ItemTypeChoice, json.RawMessage(`{"choices":"a"}`))
+
+	// Assert
+	assert.Error(t, err)
+}
+
+func TestSanitizeItem_LeavesTheItemAlone(t *testing.T) {
+	// Arrange
+	explanation := "Paris is the capital"
+	item := &Item{
+		ID:          "item-1",
+		Type:        types.ItemTypeChoice,
+		Title:       "Capital of France?",
+		Content:     json.RawMessage(`{"choices":[{"id":"a","text":"Paris","correct":true}]}`),
+		Explanation: &explanation,
+	}
+
+	// Act
+	sanitized, err := SanitizeItem(item)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, sanitized.Explanation)
+	assert.JSONEq(t, `{"choices":[{"id":"a","text":"Paris"}]}`, string(sanitized.Content))
+	assert.Equal(t, &explanation, item.Explanation, "the item keeps its explanation")
+	assert.Contains(t, string(item.Content), "correct", "the item keeps its content")
+}
+
+// publicProjects is a ProjectStore of one project, "project-1" of
+// "org-1", published as version 2 if it has a publication
+type publicProjects struct {
+	ProjectStore
+	published   bool
+	publication *Publication
+	results     *ResultsSettings
+	// scopes are the organizations the reads after PublishedOrgID were
+	// scoped to, and whether they acted for the system
+	scopes []AccessScope
+	orgIDs []string
+}
+
+func (s *publicProjects) PublishedOrgID(ctx context.Context, id string) (string, error) {
+	if id != "project-1" || !s.published {
+		return "", ErrProjectNotFound
+	}
+	return "org-1", nil
+}
+
+func (s *publicProjects) OrgID(ctx context.Context, id string) (string, error) {
+	if id != "project-1" {
+		return "", ErrProjectNotFound
+	}
+	return "org-1", nil
+}
+
+func (s *publicProjects) GetByID(ctx context.Context, id string) (*Project, error) {
+	s.scopes = append(s.scopes, AccessScopeFromContext(ctx))
+	s.orgIDs = append(s.orgIDs, OrgIDFromContext(ctx))
+	project := &Project{ID: id, Title: "Capitals (edited)", ResultsSettings: s.results}
+	if s.publication != nil {
+		project.LatestPublishedVersion = &s.publication.Version
+	}
+	return project, nil
+}
+
+func (s *publicProjects) GetPublication(ctx context.Context, projectID string, version int) (*Publication, error) {
+	if s.publication == nil || version != s.publication.Version {
+		return nil, ErrPublicationNotFound
+	}
+	return s.publication, nil
+}
+
+func TestProjectService_GetPublic(t *testing.T) {
+	published := &Item{ID: "published", Type: types.ItemTypeChoice, Content: json.RawMessage(`{"choices":[{"id":"a","text":"Paris","correct":true}]}`)}
+	live := &Item{ID: "live", Type: types.ItemTypeTextEntry, Content: json.RawMessage(`{"multiline":false,"correct_answer":"Madrid"}`)}
+
+	tests := []struct {
+		name            string
+		store           *publicProjects
+		expectedTitle   string
+		expectedVersion int
+		expectedItem    string
+	}{
+		{
+			name: "latest publication",
+			store: &publicProjects{published: true, publication: &Publication{
+				ProjectID: "project-1",
+				Version:   2,
+				Project:   &Project{ID: "project-1", Title: "Capitals"},
+				Items:     []*Item{published},
+			}},
+			expectedTitle:   "Capitals",
+			expectedVersion: 2,
+			expectedItem:    "published",
+		},
+		{
+			name:            "live items of a project published before publications were kept",
+			store:           &publicProjects{published: true},
+			expectedTitle:   "Capitals (edited)",
+			expectedVersion: 0,
+			expectedItem:    "live",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := NewProjectService(tt.store, nil)
+			service.SetItems(&publishItems{items: []*Item{live}})
+
+			// Act
+			public, err := service.GetPublic(context.Background(), "project-1")
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedTitle, public.Project.Title)
+			assert.Equal(t, tt.expectedVersion, public.Version)
+			require.Len(t, public.Items, 1)
+			assert.Equal(t, tt.expectedItem, public.Items[0].ID)
+			assertNoAnswerKeys(t, public.Items[0].Content)
+			assert.Equal(t, []string{"org-1"}, tt.store.orgIDs, "the project is read in its organization")
+			assert.True(t, tt.store.scopes[0].System, "learners need no membership")
+		})
+	}
+}
+
+func TestProjectService_GetPublic_NotPublished(t *testing.T) {
+	// Arrange
+	service := NewProjectService(&publicProjects{}, nil)
+
+	// Act
+	_, err := service.GetPublic(context.Background(), "project-1")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+}
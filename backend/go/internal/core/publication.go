@@ -0,0 +1,50 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrPublicationNotFound is returned for a version a project was never
+// published as
+var ErrPublicationNotFound = errors.New("publication not found")
+
+// Publication is a project frozen as it was published, so later edits to
+// the project don't change what learners see
+type Publication struct {
+	ProjectID string
+	// Version counts the project's publications from 1, oldest first
+	Version int
+	// Project is the project as it was published, without item count
+	Project *Project
+	// Items are the project's items as they were published, in position
+	// order. Lists of publications leave them out.
+	Items []*Item
+	// PublishedAt is when the project was published as this version
+	PublishedAt time.Time
+}
+
+// ListPublications returns a page of a project's publications, newest
+// first and without their items, with their total. Returns
+// ErrProjectNotFound unless the project exists.
+func (s *ProjectService) ListPublications(ctx context.Context, projectID string, limit, offset int) ([]*Publication, int, error) {
+	ctx, span := startSpan(ctx, "ProjectService.ListPublications", attribute.String("project.id", projectID))
+	defer span.End()
+
+	return s.store.ListPublications(ctx, projectID, limit, offset)
+}
+
+// GetPublication returns a publication of a project, with its items.
+// Returns ErrPublicationNotFound unless the project was published as
+// version.
+func (s *ProjectService) GetPublication(ctx context.Context, projectID string, version int) (*Publication, error) {
+	ctx, span := startSpan(ctx, "ProjectService.GetPublication",
+		attribute.String("project.id", projectID),
+		attribute.Int("publication.version", version))
+	defer span.End()
+
+	return s.store.GetPublication(ctx, projectID, version)
+}
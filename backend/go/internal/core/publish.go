@@ -0,0 +1,137 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// ErrProjectNotPublishable is returned when publishing a project learners
+// could not take, e.g. one without items. The error is a
+// *ProjectNotPublishableError listing what is wrong.
+var ErrProjectNotPublishable = errors.New("project is not publishable")
+
+// Reasons a project cannot be published
+const (
+	// PublishProblemNoItems is a project without items
+	PublishProblemNoItems = "no_items"
+	// PublishProblemNoCorrectChoice is a scored choice or multi_choice item
+	// none of whose options is correct
+	PublishProblemNoCorrectChoice = "no_correct_choice"
+	// PublishProblemOrderNotContiguous is an ordering item whose correct
+	// orders are not 1, 2, ... up to its number of entries
+	PublishProblemOrderNotContiguous = "order_not_contiguous"
+	// PublishProblemNoCorrectHotspot is a hotspot item none of whose regions
+	// is correct
+	PublishProblemNoCorrectHotspot = "no_correct_hotspot"
+	// PublishProblemInvalidContent is an item whose content does not decode
+	// as its type's
+	PublishProblemInvalidContent = "invalid_content"
+)
+
+// PublishProblem is one reason a project cannot be published
+type PublishProblem struct {
+	// ItemID is the item at fault, empty for problems of the whole project
+	ItemID string
+	// Reason is one of the PublishProblem constants
+	Reason string
+}
+
+// ProjectNotPublishableError is ErrProjectNotPublishable listing every
+// problem found, in item position order
+type ProjectNotPublishableError struct {
+	Problems []PublishProblem
+}
+
+func (e *ProjectNotPublishableError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		if problem.ItemID == "" {
+			problems[i] = problem.Reason
+		} else {
+			problems[i] = fmt.Sprintf("item %s: %s", problem.ItemID, problem.Reason)
+		}
+	}
+	return fmt.Sprintf("%v: %s", ErrProjectNotPublishable, strings.Join(problems, "; "))
+}
+
+// Is makes errors.Is(err, ErrProjectNotPublishable) match
+func (e *ProjectNotPublishableError) Is(target error) bool {
+	return target == ErrProjectNotPublishable
+}
+
+// checkPublishable returns a *ProjectNotPublishableError unless learners
+// can take the project's items: there is at least one, and every question
+// has an answer that is correct
+func (s *ProjectService) checkPublishable(ctx context.Context, projectID string) error {
+	items, err := s.items.ListByProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return &ProjectNotPublishableError{Problems: []PublishProblem{{Reason: PublishProblemNoItems}}}
+	}
+
+	var problems []PublishProblem
+	for _, item := range items {
+		reason, err := publishProblem(item)
+		if err != nil {
+			reason = PublishProblemInvalidContent
+		}
+		if reason != "" {
+			problems = append(problems, PublishProblem{ItemID: item.ID, Reason: reason})
+		}
+	}
+	if len(problems) > 0 {
+		return &ProjectNotPublishableError{Problems: problems}
+	}
+	return nil
+}
+
+// publishProblem returns why item keeps its project from being published,
+// or "" if nothing does. Choice items without points are surveys, where no
+// option needs to be correct.
+func publishProblem(item *Item) (string, error) {
+	switch item.Type {
+	case types.ItemTypeChoice, types.ItemTypeMultiChoice:
+		if item.Points == nil || *item.Points == 0 {
+			return "", nil
+		}
+		var content types.ChoiceContent
+		if err := json.Unmarshal(item.Content, &content); err != nil {
+			return "", err
+		}
+		if !slices.ContainsFunc(content.Choices, func(c types.Choice) bool { return c.Correct }) {
+			return PublishProblemNoCorrectChoice, nil
+		}
+	case types.ItemTypeOrdering:
+		var content types.OrderingContent
+		if err := json.Unmarshal(item.Content, &content); err != nil {
+			return "", err
+		}
+		orders := make([]int, len(content.Items))
+		for i, entry := range content.Items {
+			orders[i] = entry.CorrectOrder
+		}
+		slices.Sort(orders)
+		for i, order := range orders {
+			if order != i+1 {
+				return PublishProblemOrderNotContiguous, nil
+			}
+		}
+	case types.ItemTypeHotspot:
+		var content types.HotspotContent
+		if err := json.Unmarshal(item.Content, &content); err != nil {
+			return "", err
+		}
+		if !slices.ContainsFunc(content.Hotspots, func(h types.Hotspot) bool { return h.Correct }) {
+			return PublishProblemNoCorrectHotspot, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// publishItems is an ItemStore listing the items of "project-1"
+type publishItems struct {
+	ItemStore
+	items []*Item
+}
+
+func (s *publishItems) ListByProject(ctx context.Context, projectID string) ([]*Item, error) {
+	if projectID != "project-1" {
+		return nil, nil
+	}
+	return s.items, nil
+}
+
+func TestProjectService_Publish_ChecksItems(t *testing.T) {
+	one, none := 1, 0
+	item := func(id string, itemType types.ItemType, points *int, content string) *Item {
+		return &Item{ID: id, ProjectID: "project-1", Type: itemType, Title: id, Points: points, Content: json.RawMessage(content)}
+	}
+
+	tests := []struct {
+		name     string
+		items    []*Item
+		problems []PublishProblem
+	}{
+		{
+			name: "runnable",
+			items: []*Item{
+				item("title", types.ItemTypeTitle, nil, `{}`),
+				item("choice", types.ItemTypeChoice, &one, `{"choices":[{"id":"a","text":"A"},{"id":"b","text":"B","correct":true}]}`),
+				item("ordering", types.ItemTypeOrdering, &one, `{"items":[{"id":"a","text":"A","correct_order":2},{"id":"b","text":"B","correct_order":1}]}`),
+				item("hotspot", types.ItemTypeHotspot, nil, `{"image_url":"https://example.com/a.png","hotspots":[{"id":"a","shape":"circle","coords":[1,2,3],"correct":true}]}`),
+			},
+		},
+		{
+			name:     "no items",
+			problems: []PublishProblem{{Reason: PublishProblemNoItems}},
+		},
+		{
+			name: "unscored choice without a correct option",
+			items: []*Item{
+				item("survey", types.ItemTypeChoice, nil, `{"choices":[{"id":"a","text":"A"}]}`),
+				item("warm-up", types.ItemTypeMultiChoice, &none, `{"choices":[{"id":"a","text":"A"}]}`),
+			},
+		},
+		{
+			name: "every problem is listed",
+			items: []*Item{
+				item("choice", types.ItemTypeMultiChoice, &one, `{"choices":[{"id":"a","text":"A"}]}`),
+				item("gap", types.ItemTypeOrdering, nil, `{"items":[{"id":"a","text":"A","correct_order":1},{"id":"b","text":"B","correct_order":3}]}`),
+				item("tie", types.ItemTypeOrdering, nil, `{"items":[{"id":"a","text":"A","correct_order":1},{"id":"b","text":"B","correct_order":1}]}`),
+				item("hotspot", types.ItemTypeHotspot, &one, `{"image_url":"https://example.com/a.png","hotspots":[{"id":"a","shape":"circle","coords":[1,2,3]}]}`),
+				item("broken", types.ItemTypeChoice, &one, `{"choices":"a"}`),
+			},
+			problems: []PublishProblem{
+				{ItemID: "choice", Reason: PublishProblemNoCorrectChoice},
+				{ItemID: "gap", Reason: PublishProblemOrderNotContiguous},
+				{ItemID: "tie", Reason: PublishProblemOrderNotContiguous},
+				{ItemID: "hotspot", Reason: PublishProblemNoCorrectHotspot},
+				{ItemID: "broken", Reason: PublishProblemInvalidContent},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := NewProjectService(&eventProjects{}, nil)
+			service.SetItems(&publishItems{items: tt.items})
+
+			// Act
+			project, err := service.Publish(context.Background(), "project-1")
+
+			// Assert
+			if tt.problems == nil {
+				require.NoError(t, err)
+				assert.Equal(t, "project-1", project.ID)
+				return
+			}
+			require.ErrorIs(t, err, ErrProjectNotPublishable)
+			var notPublishable *ProjectNotPublishableError
+			require.True(t, errors.As(err, &notPublishable))
+			assert.Equal(t, tt.problems, notPublishable.Problems)
+		})
+	}
+}
+
+func TestProjectService_Publish_UnknownProject(t *testing.T) {
+	// Arrange
+	service := NewProjectService(&eventProjects{}, nil)
+	service.SetItems(&publishItems{})
+
+	// Act
+	_, err := service.Publish(context.Background(), "project-2")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrProjectNotFound, "a missing project is not reported as one without items")
+}
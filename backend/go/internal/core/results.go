@@ -0,0 +1,143 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Domain errors for results policies
+var (
+	// ErrInvalidResultsPolicy is returned when setting a results policy
+	// that isn't one of the ResultsPolicy values
+	ErrInvalidResultsPolicy = errors.New("invalid results policy")
+
+	// ErrResultsAvailableAtRequired is returned when setting the
+	// after_close_date policy without the time results become available
+	ErrResultsAvailableAtRequired = errors.New("results available at required")
+)
+
+// ResultsPolicy is what a project's learners are shown of their graded
+// attempts
+type ResultsPolicy string
+
+// Results policies
+const (
+	// ResultsPolicyNone shows learners nothing of their grades, as for a
+	// survey
+	ResultsPolicyNone ResultsPolicy = "none"
+
+	// ResultsPolicyScoreOnly shows the score once the attempt is
+	// submitted, but not which questions were right, as for an exam
+	ResultsPolicyScoreOnly ResultsPolicy = "score_only"
+
+	// ResultsPolicyAfterSubmit shows the score and each question's result,
+	// with its explanation, once the attempt is submitted. It is the
+	// default.
+	ResultsPolicyAfterSubmit ResultsPolicy = "after_submit"
+
+	// ResultsPolicyAfterCloseDate shows what after_submit does, but only
+	// from the project's ResultsAvailableAt on
+	ResultsPolicyAfterCloseDate ResultsPolicy = "after_close_date"
+
+	// ResultsPolicyPractice shows what after_submit does, and grades each
+	// answer as it is saved, as for a practice quiz
+	ResultsPolicyPractice ResultsPolicy = "practice"
+)
+
+// ResultsSettings are a project's results policy
+type ResultsSettings struct {
+	Policy ResultsPolicy
+
+	// AvailableAt is when the results of the after_close_date policy are
+	// shown, nil for the other policies
+	AvailableAt *time.Time
+}
+
+// DefaultResultsSettings are the results policy of a project that never
+// set one
+func DefaultResultsSettings() ResultsSettings {
+	return ResultsSettings{Policy: ResultsPolicyAfterSubmit}
+}
+
+// Validate returns ErrInvalidResultsPolicy for an unknown policy, and
+// ErrResultsAvailableAtRequired for after_close_date without AvailableAt
+func (s ResultsSettings) Validate() error {
+	switch s.Policy {
+	case ResultsPolicyNone, ResultsPolicyScoreOnly, ResultsPolicyAfterSubmit, ResultsPolicyPractice:
+		return nil
+	case ResultsPolicyAfterCloseDate:
+		if s.AvailableAt == nil {
+			return ErrResultsAvailableAtRequired
+		}
+		return nil
+	default:
+		return ErrInvalidResultsPolicy
+	}
+}
+
+// ResultsDisclosure is what a learner is shown of an attempt's grades
+type ResultsDisclosure struct {
+	// Score shows the attempt's score and maximum score
+	Score bool
+
+	// Results shows each question's result, with its explanation when the
+	// attempt is submitted
+	Results bool
+
+	// Feedback grades each answer as it is saved
+	Feedback bool
+}
+
+// DiscloseResults evaluates a results policy for an attempt, submitted or
+// not, at now. Every endpoint showing grades to learners goes through it.
+func DiscloseResults(settings ResultsSettings, submitted bool, now time.Time) ResultsDisclosure {
+	switch settings.Policy {
+	case ResultsPolicyScoreOnly:
+		return ResultsDisclosure{Score: submitted}
+	case ResultsPolicyAfterSubmit:
+		return ResultsDisclosure{Score: submitted, Results: submitted}
+	case ResultsPolicyAfterCloseDate:
+		available := submitted && settings.AvailableAt != nil && !now.Before(*settings.AvailableAt)
+		return ResultsDisclosure{Score: available, Results: available}
+	case ResultsPolicyPractice:
+		return ResultsDisclosure{Score: submitted, Results: submitted, Feedback: !submitted}
+	default:
+		return ResultsDisclosure{}
+	}
+}
+
+// UpdateResultsSettings sets the results policy of a project of the
+// organization in ctx. AvailableAt is only kept for after_close_date.
+// Returns ErrInvalidResultsPolicy or ErrResultsAvailableAtRequired unless
+// the settings are valid, and ErrProjectNotFound if the project doesn't
+// exist.
+func (s *ProjectService) UpdateResultsSettings(ctx context.Context, id string, settings ResultsSettings) (*Project, error) {
+	ctx, span := startSpan(ctx, "ProjectService.UpdateResultsSettings", attribute.String("project.id", id))
+	defer span.End()
+
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	if settings.Policy != ResultsPolicyAfterCloseDate {
+		settings.AvailableAt = nil
+	}
+
+	project, err := s.store.UpdateResultsSettings(ctx, id, settings)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(ctx, EventProjectUpdated, project)
+	return project, nil
+}
+
+// projectResults returns a project's results policy, the default unless it
+// was read (see Project.ResultsSettings)
+func projectResults(project *Project) ResultsSettings {
+	if project.ResultsSettings == nil {
+		return DefaultResultsSettings()
+	}
+	return *project.ResultsSettings
+}
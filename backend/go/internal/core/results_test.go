@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscloseResults(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		settings  ResultsSettings
+		submitted bool
+		expected  ResultsDisclosure
+	}{
+		{"none, in progress", ResultsSettings{Policy: ResultsPolicyNone}, false, ResultsDisclosure{}},
+		{"none, submitted", ResultsSettings{Policy: ResultsPolicyNone}, true, ResultsDisclosure{}},
+		{"score_only, in progress", ResultsSettings{Policy: ResultsPolicyScoreOnly}, false, ResultsDisclosure{}},
+		{"score_only, submitted", ResultsSettings{Policy: ResultsPolicyScoreOnly}, true, ResultsDisclosure{Score: true}},
+		{"after_submit, in progress", ResultsSettings{Policy: ResultsPolicyAfterSubmit}, false, ResultsDisclosure{}},
+		{"after_submit, submitted", ResultsSettings{Policy: ResultsPolicyAfterSubmit}, true, ResultsDisclosure{Score: true, Results: true}},
+		{"after_close_date, in progress", ResultsSettings{Policy: ResultsPolicyAfterCloseDate, AvailableAt: &past}, false, ResultsDisclosure{}},
+		{"after_close_date, submitted before the date", ResultsSettings{Policy: ResultsPolicyAfterCloseDate, AvailableAt: &future}, true, ResultsDisclosure{}},
+		{"after_close_date, submitted at the date", ResultsSettings{Policy: ResultsPolicyAfterCloseDate, AvailableAt: &now}, true, ResultsDisclosure{Score: true, Results: true}},
+		{"after_close_date, submitted after the date", ResultsSettings{Policy: ResultsPolicyAfterCloseDate, AvailableAt: &past}, true, ResultsDisclosure{Score: true, Results: true}},
+		{"after_close_date without a date", ResultsSettings{Policy: ResultsPolicyAfterCloseDate}, true, ResultsDisclosure{}},
+		{"practice, in progress", ResultsSettings{Policy: ResultsPolicyPractice}, false, ResultsDisclosure{Feedback: true}},
+		{"practice, submitted", ResultsSettings{Policy: ResultsPolicyPractice}, true, ResultsDisclosure{Score: true, Results: true}},
+		{"unknown policy", ResultsSettings{Policy: "leaderboard"}, true, ResultsDisclosure{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DiscloseResults(tt.settings, tt.submitted, now))
+		})
+	}
+}
+
+func TestResultsSettings_Validate(t *testing.T) {
+	at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		settings ResultsSettings
+		expected error
+	}{
+		{"default", DefaultResultsSettings(), nil},
+		{"none", ResultsSettings{Policy: ResultsPolicyNone}, nil},
+		{"practice", ResultsSettings{Policy: ResultsPolicyPractice}, nil},
+		{"after_close_date with a date", ResultsSettings{Policy: ResultsPolicyAfterCloseDate, AvailableAt: &at}, nil},
+		{"after_close_date without a date", ResultsSettings{Policy: ResultsPolicyAfterCloseDate}, ErrResultsAvailableAtRequired},
+		{"unknown policy", ResultsSettings{Policy: "show_all"}, ErrInvalidResultsPolicy},
+		{"empty policy", ResultsSettings{}, ErrInvalidResultsPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.settings.Validate())
+		})
+	}
+}
+
+func TestProjectService_UpdateResultsSettings(t *testing.T) {
+	// Arrange
+	store := newMockProjectStore()
+	store.projects["project-1"] = &Project{ID: "project-1", Title: "Capitals"}
+	service := NewProjectService(store, nil)
+	at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// Act
+	project, err := service.UpdateResultsSettings(context.Background(), "project-1", ResultsSettings{Policy: ResultsPolicyScoreOnly, AvailableAt: &at})
+	_, invalidErr := service.UpdateResultsSettings(context.Background(), "project-1", ResultsSettings{Policy: ResultsPolicyAfterCloseDate})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, &ResultsSettings{Policy: ResultsPolicyScoreOnly}, project.ResultsSettings, "the date is only kept for after_close_date")
+	assert.ErrorIs(t, invalidErr, ErrResultsAvailableAtRequired)
+	assert.Equal(t, ResultsPolicyScoreOnly, store.projects["project-1"].ResultsSettings.Policy, "the invalid settings aren't stored")
+}
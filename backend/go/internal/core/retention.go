@@ -0,0 +1,140 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrParticipantAccessDenied is returned when erasing the data of a
+// participant other than the caller without being an operator
+var ErrParticipantAccessDenied = errors.New("participant access denied")
+
+// ErasureBatchSize is how many attempts one statement erases, so erasing
+// years of attempts holds no lock for long
+const ErasureBatchSize = 500
+
+// RetentionMode is what erasing attempts does to them
+type RetentionMode string
+
+// Retention modes
+const (
+	// RetentionModeDelete deletes the attempts with their answers and
+	// results. It is the default.
+	RetentionModeDelete RetentionMode = "delete"
+
+	// RetentionModeAnonymize strips who took the attempts, keeping them
+	// with their answers and scores for the project's stats
+	RetentionModeAnonymize RetentionMode = "anonymize"
+)
+
+// Valid reports whether m is one of the RetentionMode values
+func (m RetentionMode) Valid() bool {
+	return m == RetentionModeDelete || m == RetentionModeAnonymize
+}
+
+// AttemptSelection picks the attempts to erase, in every project, previews
+// included. Anonymizing picks only those of a signed-in participant.
+type AttemptSelection struct {
+	// StartedBefore keeps the attempts started before it, when set
+	StartedBefore *time.Time
+
+	// ParticipantID keeps the attempts of a participant, when set
+	ParticipantID string
+}
+
+// ErasureReport counts the rows erasing attempts affected
+type ErasureReport struct {
+	Attempts int64
+
+	// Answers and Results are the rows deleted with the attempts;
+	// anonymizing keeps them as they are
+	Answers int64
+	Results int64
+}
+
+// add counts other's rows in r
+func (r *ErasureReport) add(other *ErasureReport) {
+	r.Attempts += other.Attempts
+	r.Answers += other.Answers
+	r.Results += other.Results
+}
+
+// ApplyRetention erases the attempts started before cutoff, in every
+// project, by deleting them or anonymizing them as mode says, in batches of
+// ErasureBatchSize. It writes an audit line when it erased any, and returns
+// the rows erased until an error.
+func (s *AttemptService) ApplyRetention(ctx context.Context, cutoff time.Time, mode RetentionMode) (*ErasureReport, error) {
+	ctx, span := startSpan(ctx, "AttemptService.ApplyRetention", attribute.String("retention.mode", string(mode)))
+	defer span.End()
+
+	report, err := s.erase(ctx, AttemptSelection{StartedBefore: &cutoff}, mode)
+	if report.Attempts > 0 || err != nil {
+		// Audit lines are written whatever the log level
+		log.Ctx(ctx).Log().
+			Str("audit", "attempt_retention_applied").
+			Str("mode", string(mode)).
+			Time("cutoff", cutoff).
+			Int64("attempts", report.Attempts).
+			Int64("answers", report.Answers).
+			Int64("results", report.Results).
+			Err(err).
+			Msg("attempt retention applied")
+	}
+	return report, err
+}
+
+// EraseParticipant erases every attempt of a participant, in every
+// project, by deleting them or anonymizing them as mode says, in batches of
+// ErasureBatchSize. Only the participant, operators and the system may. It
+// writes an audit line, and returns the rows erased until an error.
+// Returns ErrParticipantAccessDenied if the caller in ctx may not.
+func (s *AttemptService) EraseParticipant(ctx context.Context, participantID string, mode RetentionMode) (*ErasureReport, error) {
+	ctx, span := startSpan(ctx, "AttemptService.EraseParticipant", attribute.String("retention.mode", string(mode)))
+	defer span.End()
+
+	scope := AccessScopeFromContext(ctx)
+	if !scope.System && scope.Role != UserRoleAdmin && (scope.UserID == "" || scope.UserID != participantID) {
+		return nil, ErrParticipantAccessDenied
+	}
+
+	report, err := s.erase(ctx, AttemptSelection{ParticipantID: participantID}, mode)
+	// Audit lines are written whatever the log level
+	log.Ctx(ctx).Log().
+		Str("audit", "participant_data_erased").
+		Str("participant_id", participantID).
+		Str("mode", string(mode)).
+		Str("user_id", scope.UserID).
+		Int64("attempts", report.Attempts).
+		Int64("answers", report.Answers).
+		Int64("results", report.Results).
+		Err(err).
+		Msg("participant data erased")
+	return report, err
+}
+
+// erase erases the attempts selection picks batch by batch, until a batch
+// comes up short
+func (s *AttemptService) erase(ctx context.Context, selection AttemptSelection, mode RetentionMode) (*ErasureReport, error) {
+	report := &ErasureReport{}
+	if !mode.Valid() {
+		return report, fmt.Errorf("invalid retention mode %q", mode)
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		batch, err := s.store.EraseAttempts(ctx, selection, mode, ErasureBatchSize)
+		if err != nil {
+			return report, err
+		}
+		report.add(batch)
+		if batch.Attempts < ErasureBatchSize {
+			return report, nil
+		}
+	}
+}
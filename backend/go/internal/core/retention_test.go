@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// erasingAttempts is an AttemptStore that erases batches of the sizes set,
+// then nothing, each with two answers and three results an attempt, or
+// fails with err once the batches run out
+type erasingAttempts struct {
+	AttemptStore
+	batches []int64
+	err     error
+
+	// selections and modes are what each call asked for
+	selections []AttemptSelection
+	modes      []RetentionMode
+}
+
+func (s *erasingAttempts) EraseAttempts(ctx context.Context, selection AttemptSelection, mode RetentionMode, limit int) (*ErasureReport, error) {
+	s.selections = append(s.selections, selection)
+	s.modes = append(s.modes, mode)
+	if len(s.batches) == 0 {
+		return &ErasureReport{}, s.err
+	}
+	erased := s.batches[0]
+	s.batches = s.batches[1:]
+	return &ErasureReport{Attempts: erased, Answers: 2 * erased, Results: 3 * erased}, nil
+}
+
+func TestAttemptService_EraseParticipant(t *testing.T) {
+	// Arrange
+	store := &erasingAttempts{batches: []int64{ErasureBatchSize, ErasureBatchSize, 3}}
+	service := NewAttemptService(store, nil, nil)
+	ctx := WithAccessScope(context.Background(), AccessScope{UserID: "learner-1"})
+
+	// Act
+	report, err := service.EraseParticipant(ctx, "learner-1", RetentionModeDelete)
+
+	// Assert
+	require.NoError(t, err)
+	erased := int64(2*ErasureBatchSize + 3)
+	assert.Equal(t, &ErasureReport{Attempts: erased, Answers: 2 * erased, Results: 3 * erased}, report)
+	assert.Len(t, store.selections, 3, "a short batch is the last")
+	assert.Equal(t, AttemptSelection{ParticipantID: "learner-1"}, store.selections[0])
+	assert.Equal(t, RetentionModeDelete, store.modes[0])
+}
+
+func TestAttemptService_EraseParticipant_Access(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    AccessScope
+		expected error
+	}{
+		{"the participant", AccessScope{UserID: "learner-1"}, nil},
+		{"an operator", AccessScope{UserID: "operator-1", Role: UserRoleAdmin}, nil},
+		{"the system", AccessScope{System: true}, nil},
+		{"another user", AccessScope{UserID: "learner-2"}, ErrParticipantAccessDenied},
+		{"an anonymous caller", AccessScope{}, ErrParticipantAccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			store := &erasingAttempts{}
+			service := NewAttemptService(store, nil, nil)
+
+			// Act
+			_, err := service.EraseParticipant(WithAccessScope(context.Background(), tt.scope), "learner-1", RetentionModeAnonymize)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expected)
+			if tt.expected != nil {
+				assert.Empty(t, store.selections, "nothing is erased")
+			}
+		})
+	}
+}
+
+func TestAttemptService_ApplyRetention(t *testing.T) {
+	// Arrange
+	store := &erasingAttempts{batches: []int64{4}}
+	service := NewAttemptService(store, nil, nil)
+	cutoff := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Act
+	report, err := service.ApplyRetention(context.Background(), cutoff, RetentionModeAnonymize)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), report.Attempts)
+	require.Len(t, store.selections, 1)
+	assert.Equal(t, AttemptSelection{StartedBefore: &cutoff}, store.selections[0])
+	assert.Equal(t, RetentionModeAnonymize, store.modes[0])
+}
+
+func TestAttemptService_ApplyRetention_Errors(t *testing.T) {
+	storeErr := errors.New("connection refused")
+
+	tests := []struct {
+		name     string
+		mode     RetentionMode
+		batches  []int64
+		expected int64
+	}{
+		{"unknown mode", RetentionMode("shred"), nil, 0},
+		{"store error after a batch", RetentionModeDelete, []int64{ErasureBatchSize}, ErasureBatchSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			store := &erasingAttempts{batches: tt.batches, err: storeErr}
+			service := NewAttemptService(store, nil, nil)
+
+			// Act
+			report, err := service.ApplyRetention(context.Background(), time.Now(), tt.mode)
+
+			// Assert
+			assert.Error(t, err)
+			assert.Equal(t, tt.expected, report.Attempts, "the report counts the batches erased before the error")
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// ErrSeedInProduction is returned when seeding is asked of a production
+// deployment
+var ErrSeedInProduction = errors.New("seeding is disabled in production")
+
+// seedAssets are the sample files the fixtures' media and hotspot items
+// show
+//
+//go:embed seed_assets/*.svg
+var seedAssets embed.FS
+
+// Seeder creates the development fixtures
+type Seeder interface {
+	Seed(ctx context.Context) (*SeedResult, error)
+}
+
+// SeedResult describes the fixtures after seeding
+type SeedResult struct {
+	OrganizationID string
+	Users          []SeedUser
+	Projects       []SeededProject
+	// Assets are the storage keys of the uploaded sample files
+	Assets []string
+	// Created counts the organizations, memberships, projects and items
+	// this run created; 0 when every fixture already existed
+	Created int
+}
+
+// SeededProject is a fixture project as seeded
+type SeededProject struct {
+	ID        string
+	Title     string
+	Items     int
+	Published bool
+}
+
+// SeedService creates the development fixtures (see seed_fixtures.go)
+// through the services, so they pass the same validation as API requests.
+// Fixtures have fixed IDs, so seeding again creates only the missing ones.
+type SeedService struct {
+	environment string
+	projects    *ProjectService
+	items       *ItemService
+	orgs        OrganizationStore
+	storage     Storage
+}
+
+// NewSeedService creates a seed service for the deployment environment,
+// refusing to seed in "production". Without storage the sample files are
+// not uploaded and the items showing them are skipped.
+func NewSeedService(environment string, projects *ProjectService, items *ItemService, orgs OrganizationStore, storage Storage) *SeedService {
+	return &SeedService{
+		environment: environment,
+		projects:    projects,
+		items:       items,
+		orgs:        orgs,
+		storage:     storage,
+	}
+}
+
+// Seed creates every missing fixture. Returns ErrSeedInProduction in
+// production.
+func (s *SeedService) Seed(ctx context.Context) (*SeedResult, error) {
+	if s.environment == "production" {
+		return nil, ErrSeedInProduction
+	}
+
+	ctx, span := startSpan(ctx, "SeedService.Seed")
+	defer span.End()
+
+	// Fixtures belong to no user
+	ctx = WithAccessScope(ctx, AccessScope{System: true})
+	result := &SeedResult{OrganizationID: SeedOrganizationID, Users: SeedUsers}
+
+	if err := s.seedOrganization(ctx, result); err != nil {
+		return nil, err
+	}
+	for _, fixture := range seedProjects {
+		if err := s.seedProject(ctx, fixture, result); err != nil {
+			return nil, fmt.Errorf("failed to seed project %q: %w", fixture.title, err)
+		}
+	}
+
+	log.Ctx(ctx).Info().
+		Int("created", result.Created).
+		Int("projects", len(result.Projects)).
+		Msg("fixtures seeded")
+
+	return result, nil
+}
+
+// seedOrganization creates the fixture organization and its members
+func (s *SeedService) seedOrganization(ctx context.Context, result *SeedResult) error {
+	if _, err := s.orgs.GetByID(ctx, SeedOrganizationID); errors.Is(err, ErrOrganizationNotFound) {
+		if _, err := s.orgs.Create(WithNewID(ctx, SeedOrganizationID), seedOrganizationName, nil); err != nil {
+			return fmt.Errorf("failed to seed organization: %w", err)
+		}
+		result.Created++
+	} else if err != nil {
+		return fmt.Errorf("failed to get seed organization: %w", err)
+	}
+
+	for _, user := range SeedUsers {
+		if _, err := s.orgs.GetMembership(ctx, SeedOrganizationID, user.ID); errors.Is(err, ErrMembershipNotFound) {
+			if _, err := s.orgs.PutMember(ctx, SeedOrganizationID, user.ID, user.Role); err != nil {
+				return fmt.Errorf("failed to seed member %s: %w", user.ID, err)
+			}
+			result.Created++
+		} else if err != nil {
+			return fmt.Errorf("failed to get seed membership: %w", err)
+		}
+	}
+	return nil
+}
+
+// seedProject creates a fixture project, its items and its sample files,
+// and publishes it if the fixture is published
+func (s *SeedService) seedProject(ctx context.Context, fixture seedProject, result *SeedResult) error {
+	if fixture.inOrganization {
+		ctx = WithOrgID(ctx, SeedOrganizationID)
+	}
+
+	project, err := s.projects.GetByID(ctx, fixture.id)
+	if errors.Is(err, ErrProjectNotFound) {
+		project, err = s.projects.Create(WithNewID(ctx, fixture.id), fixture.title, &fixture.description, fixture.tags)
+		if err != nil {
+			return err
+		}
+		result.Created++
+	}
+	if err != nil {
+		return err
+	}
+
+	seeded := SeededProject{ID: project.ID, Title: project.Title}
+	for position, item := range fixture.items {
+		content := item.content
+		if item.asset != "" {
+			if s.storage == nil {
+				continue
+			}
+			metadata, err := s.uploadAsset(ctx, project.ID, item.asset)
+			if err != nil {
+				return err
+			}
+			result.Assets = append(result.Assets, metadata.Key)
+			content = withAssetURL(content, metadata.URL)
+		}
+
+		id := seedItemID(project.ID, position)
+		if _, err := s.items.GetByID(ctx, project.ID, id); errors.Is(err, ErrItemNotFound) {
+			if _, err := s.items.Create(WithNewID(ctx, id), project.ID, item.itemType, item.title, content, position, item.required, item.points, item.explanation); err != nil {
+				return fmt.Errorf("failed to seed item %q: %w", item.title, err)
+			}
+			result.Created++
+		} else if err != nil {
+			return err
+		}
+		seeded.Items++
+	}
+
+	if fixture.published && project.PublishedAt == nil {
+		if _, err := s.projects.Publish(ctx, project.ID); err != nil {
+			return fmt.Errorf("failed to publish: %w", err)
+		}
+	}
+	seeded.Published = fixture.published
+
+	result.Projects = append(result.Projects, seeded)
+	return nil
+}
+
+// uploadAsset stores a sample file among the project's assets, under a key
+// of its own name, so seeding again overwrites it
+func (s *SeedService) uploadAsset(ctx context.Context, projectID, name string) (*StorageMetadata, error) {
+	data, err := seedAssets.ReadFile(path.Join("seed_assets", name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sample file: %w", err)
+	}
+
+	key := fmt.Sprintf("projects/%s/assets/%s", projectID, name)
+	metadata, err := s.storage.Upload(ctx, key, bytes.NewReader(data), UploadOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload sample file %s: %w", name, err)
+	}
+	return metadata, nil
+}
+
+// seedItemID is the fixed ID of the item at position in a fixture project
+func seedItemID(projectID string, position int) string {
+	return uuid.NewSHA1(uuid.MustParse(projectID), []byte(strconv.Itoa(position))).String()
+}
+
+// withAssetURL points the image of media or hotspot content at url
+func withAssetURL(content interface{}, url string) interface{} {
+	switch c := content.(type) {
+	case types.MediaContent:
+		c.URL = url
+		return c
+	case types.HotspotContent:
+		c.ImageURL = url
+		return c
+	default:
+		return content
+	}
+}
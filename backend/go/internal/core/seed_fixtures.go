@@ -0,0 +1,501 @@
+package core
+
+import "github.com/provemyself/backend/internal/types"
+
+// The development fixtures. Their IDs are fixed so documentation and demos
+// can refer to them; item IDs derive from their project's (see seedItemID).
+// docs/api/README.md lists them.
+
+// SeedOrganizationID is the ID of the fixture organization
+const SeedOrganizationID = "5eed0000-0000-4000-8000-000000000001"
+
+const seedOrganizationName = "Harbor Point School"
+
+// SeedUser is a fixture user: a member of the fixture organization. Users
+// have no accounts of their own yet; in development every bearer token
+// authenticates as dev-user-123.
+type SeedUser struct {
+	ID    string
+	Email string
+	Role  string
+}
+
+// SeedUsers are the members of the fixture organization
+var SeedUsers = []SeedUser{
+	{ID: "dev-user-123", Email: "dev@example.com", Role: MembershipRoleAdmin},
+	{ID: "5eed0000-0000-4000-8000-000000000101", Email: "teacher@example.com", Role: MembershipRoleMember},
+	{ID: "5eed0000-0000-4000-8000-000000000102", Email: "student@example.com", Role: MembershipRoleMember},
+}
+
+// seedProject is a fixture project
+type seedProject struct {
+	id          string
+	title       string
+	description string
+	tags        []string
+	// inOrganization puts the project in the fixture organization rather
+	// than in no organization
+	inOrganization bool
+	published      bool
+	items          []seedItem
+}
+
+// seedItem is a fixture item, at its index in the project
+type seedItem struct {
+	itemType types.ItemType
+	title    string
+	content  interface{}
+	// asset names the sample file, in seed_assets, that media or hotspot
+	// content shows
+	asset       string
+	required    bool
+	points      *int
+	explanation *string
+}
+
+func seedPoints(points int) *int { return &points }
+
+func seedText(text string) *string { return &text }
+
+var seedProjects = []seedProject{
+	{
+		id:          "5eed0000-0000-4000-8000-000000001001",
+		title:       "Solar System Basics",
+		description: "A first tour of the planets for middle school science.",
+		tags:        []string{"science", "astronomy"},
+		items: []seedItem{
+			{itemType: types.ItemTypeTitle, title: "Our Solar System"},
+			{
+				itemType: types.ItemTypeChoice,
+				title:    "Which planet is the largest?",
+				content: types.ChoiceContent{Choices: []types.Choice{
+					{ID: "a", Text: "Saturn"},
+					{ID: "b", Text: "Jupiter", Correct: true},
+					{ID: "c", Text: "Neptune"},
+					{ID: "d", Text: "Earth"},
+				}},
+				required:    true,
+				points:      seedPoints(1),
+				explanation: seedText("Jupiter is more than twice as massive as all the other planets combined."),
+			},
+			{
+				itemType: types.ItemTypeMultiChoice,
+				title:    "Which of these planets are gas giants?",
+				content: types.ChoiceContent{Choices: []types.Choice{
+					{ID: "a", Text: "Jupiter", Correct: true},
+					{ID: "b", Text: "Mars"},
+					{ID: "c", Text: "Saturn", Correct: true},
+					{ID: "d", Text: "Venus"},
+				}},
+				points: seedPoints(2),
+			},
+			{
+				itemType: types.ItemTypeOrdering,
+				title:    "Order the inner planets by distance from the Sun",
+				content: types.OrderingContent{Items: []types.OrderingItem{
+					{ID: "earth", Text: "Earth", CorrectOrder: 3},
+					{ID: "mercury", Text: "Mercury", CorrectOrder: 1},
+					{ID: "mars", Text: "Mars", CorrectOrder: 4},
+					{ID: "venus", Text: "Venus", CorrectOrder: 2},
+				}},
+				points: seedPoints(2),
+			},
+			{
+				itemType: types.ItemTypeTextEntry,
+				title:    "What is the name of the star closest to the Sun?",
+				content: types.TextEntryContent{
+					MaxLength:     seedPoints(50),
+					Placeholder:   seedText("Star name"),
+					CorrectAnswer: seedText("Proxima Centauri"),
+				},
+				points: seedPoints(1),
+			},
+		},
+	},
+	{
+		id:          "5eed0000-0000-4000-8000-000000001002",
+		title:       "Parts of a Plant Cell",
+		description: "Find the organelles of a plant cell on an unlabelled diagram.",
+		tags:        []string{"science", "biology"},
+		items: []seedItem{
+			{
+				itemType: types.ItemTypeMedia,
+				title:    "Study the diagram",
+				content: types.MediaContent{
+					MediaType:    "image",
+					AltText:      seedText("Diagram of a plant cell with its nucleus, vacuole and chloroplasts"),
+					Caption:      seedText("A plant cell, not to scale"),
+					ShowControls: false,
+				},
+				asset: "plant-cell.svg",
+			},
+			{
+				itemType: types.ItemTypeHotspot,
+				title:    "Click the nucleus",
+				content: types.HotspotContent{
+					AltText: seedText("Diagram of a plant cell"),
+					Hotspots: []types.Hotspot{
+						{ID: "nucleus", Shape: "circle", Coords: []float64{220, 200, 70}, Correct: true, Feedback: seedText("The nucleus holds the cell's DNA.")},
+						{ID: "vacuole", Shape: "rectangle", Coords: []float64{280, 200, 560, 400}, Feedback: seedText("That is the central vacuole, which stores water.")},
+						{ID: "chloroplast", Shape: "circle", Coords: []float64{620, 150, 50}, Feedback: seedText("That is a chloroplast.")},
+					},
+				},
+				asset:    "plant-cell.svg",
+				required: true,
+				points:   seedPoints(2),
+			},
+			{
+				itemType: types.ItemTypeHotspot,
+				title:    "Click a chloroplast",
+				content: types.HotspotContent{
+					AltText: seedText("Diagram of a plant cell"),
+					Hotspots: []types.Hotspot{
+						{ID: "chloroplast-top", Shape: "circle", Coords: []float64{620, 150, 50}, Correct: true},
+						{ID: "chloroplast-right", Shape: "circle", Coords: []float64{640, 440, 50}, Correct: true},
+						{ID: "chloroplast-left", Shape: "circle", Coords: []float64{180, 440, 50}, Correct: true},
+						{ID: "mitochondrion", Shape: "polygon", Coords: []float64{444, 480, 480, 462, 516, 480, 480, 498}},
+					},
+				},
+				asset:  "plant-cell.svg",
+				points: seedPoints(2),
+			},
+			{
+				itemType: types.ItemTypeChoice,
+				title:    "Which organelle carries out photosynthesis?",
+				content: types.ChoiceContent{Choices: []types.Choice{
+					{ID: "a", Text: "Mitochondrion"},
+					{ID: "b", Text: "Chloroplast", Correct: true},
+					{ID: "c", Text: "Ribosome"},
+				}},
+				points: seedPoints(1),
+			},
+		},
+	},
+	{
+		id:          "5eed0000-0000-4000-8000-000000001003",
+		title:       "World Capitals",
+		description: "Ten capitals from every continent. Published, for trying out the learner view.",
+		tags:        []string{"geography"},
+		published:   true,
+		items: []seedItem{
+			{itemType: types.ItemTypeTitle, title: "Name the capital"},
+			seedCapital("What is the capital of Australia?", "Canberra", "Sydney", "Melbourne", "Perth"),
+			seedCapital("What is the capital of Canada?", "Ottawa", "Toronto", "Vancouver", "Montreal"),
+			seedCapital("What is the capital of Brazil?", "Brasília", "Rio de Janeiro", "São Paulo", "Salvador"),
+			seedCapital("What is the capital of Nigeria?", "Abuja", "Lagos", "Kano", "Ibadan"),
+			seedCapital("What is the capital of Japan?", "Tokyo", "Kyoto", "Osaka", "Yokohama"),
+			seedCapital("What is the capital of Turkey?", "Ankara", "Istanbul", "Izmir", "Antalya"),
+			seedCapital("What is the capital of New Zealand?", "Wellington", "Auckland", "Christchurch", "Queenstown"),
+			seedCapital("What is the capital of Morocco?", "Rabat", "Casablanca", "Marrakesh", "Fez"),
+			seedCapital("What is the capital of Switzerland?", "Bern", "Zurich", "Geneva", "Basel"),
+			seedCapital("What is the capital of Vietnam?", "Hanoi", "Ho Chi Minh City", "Da Nang", "Hue"),
+		},
+	},
+	{
+		id:          "5eed0000-0000-4000-8000-000000001004",
+		title:       "Fractions and Decimals",
+		description: "Converting and comparing fractions and decimals.",
+		tags:        []string{"math", "fractions"},
+		items: []seedItem{
+			{
+				itemType: types.ItemTypeTextEntry,
+				title:    "Write 0.75 as a fraction in lowest terms",
+				content: types.TextEntryContent{
+					MaxLength:     seedPoints(10),
+					Placeholder:   seedText("e.g. 1/2"),
+					CorrectAnswer: seedText("3/4"),
+				},
+				required: true,
+				points:   seedPoints(1),
+			},
+			{
+				itemType: types.ItemTypeOrdering,
+				title:    "Order from smallest to largest",
+				content: types.OrderingContent{Items: []types.OrderingItem{
+					{ID: "two-thirds", Text: "2/3", CorrectOrder: 3},
+					{ID: "point-six", Text: "0.6", CorrectOrder: 2},
+					{ID: "three-quarters", Text: "3/4", CorrectOrder: 4},
+					{ID: "half", Text: "1/2", CorrectOrder: 1},
+				}},
+				points: seedPoints(2),
+			},
+			{
+				itemType: types.ItemTypeMultiChoice,
+				title:    "Which of these are equal to one half?",
+				content: types.ChoiceContent{Choices: []types.Choice{
+					{ID: "a", Text: "0.5", Correct: true},
+					{ID: "b", Text: "2/4", Correct: true},
+					{ID: "c", Text: "0.05"},
+					{ID: "d", Text: "50%", Correct: true},
+				}},
+				points: seedPoints(2),
+			},
+			{
+				itemType: types.ItemTypeChoice,
+				title:    "What is 1/8 as a decimal?",
+				content: types.ChoiceContent{Choices: []types.Choice{
+					{ID: "a", Text: "0.8"},
+					{ID: "b", Text: "0.18"},
+					{ID: "c", Text: "0.125", Correct: true},
+				}},
+				points:      seedPoints(1),
+				explanation: seedText("1 ÷ 8 = 0.125"),
+			},
+		},
+	},
+	{
+		id:          "5eed0000-0000-4000-8000-000000001005",
+		title:       "The Water Cycle",
+		description: "How water moves between the sea, the sky and the land.",
+		tags:        []string{"science", "earth-science"},
+		items: []seedItem{
+			{
+				itemType: types.ItemTypeMedia,
+				title:    "The water cycle",
+				content: types.MediaContent{
+					MediaType: "image",
+					AltText:   seedText("Sun, clouds, rain over a mountain, and water evaporating from the sea"),
+				},
+				asset: "water-cycle.svg",
+			},
+			{
+				itemType: types.ItemTypeOrdering,
+				title:    "Put the stages of the water cycle in order, starting at the sea",
+				content: types.OrderingContent{Items: []types.OrderingItem{
+					{ID: "precipitation", Text: "Precipitation", CorrectOrder: 3},
+					{ID: "evaporation", Text: "Evaporation", CorrectOrder: 1},
+					{ID: "collection", Text: "Collection", CorrectOrder: 4},
+					{ID: "condensation", Text: "Condensation", CorrectOrder: 2},
+				}},
+				required: true,
+				points:   seedPoints(2),
+			},
+			{
+				itemType: types.ItemTypeHotspot,
+				title:    "Click where condensation happens",
+				content: types.HotspotContent{
+					AltText: seedText("The water cycle"),
+					Hotspots: []types.Hotspot{
+						{ID: "cloud", Shape: "circle", Coords: []float64{310, 95, 90}, Correct: true, Feedback: seedText("Water vapour cools and condenses into cloud droplets.")},
+						{ID: "sea", Shape: "rectangle", Coords: []float64{0, 380, 800, 500}, Feedback: seedText("Water evaporates from the sea.")},
+						{ID: "sun", Shape: "circle", Coords: []float64{700, 80, 50}, Feedback: seedText("The Sun drives evaporation.")},
+						{ID: "mountain", Shape: "polygon", Coords: []float64{0, 380, 120, 220, 240, 380}, Feedback: seedText("Rain collects on the mountain.")},
+					},
+				},
+				asset:  "water-cycle.svg",
+				points: seedPoints(1),
+			},
+		},
+	},
+	{
+		id:          "5eed0000-0000-4000-8000-000000001006",
+		title:       "Spanish Greetings",
+		description: "Everyday greetings and introductions for beginners.",
+		tags:        []string{"languages", "spanish"},
+		items: []seedItem{
+			{
+				itemType: types.ItemTypeChoice,
+				title:    "How do you say \"good morning\" in Spanish?",
+				content: types.ChoiceContent{Choices: []types.Choice{
+					{ID: "a", Text: "Buenas noches"},
+					{ID: "b", Text: "Buenos días", Correct: true},
+					{ID: "c", Text: "Buenas tardes"},
+				}},
+				points: seedPoints(1),
+			},
+			{
+				itemType: types.ItemTypeTextEntry,
+				title:    "Translate: \"My name is Ana\"",
+				content: types.TextEntryContent{
+					MaxLength:     seedPoints(100),
+					CorrectAnswer: seedText("Me llamo Ana"),
+				},
+				points: seedPoints(2),
+			},
+			{
+				itemType: types.ItemTypeMultiChoice,
+				title:    "Which of these are ways to say goodbye?",
+				content: types.ChoiceContent{Choices: []types.Choice{
+					{ID: "a", Text: "Adiós", Correct: true},
+					{ID: "b", Text: "Hasta luego", Correct: true},
+					{ID: "c", Text: "Hola"},
+					{ID: "d", Text: "Chao", Correct: true},
+				}},
+				points: seedPoints(2),
+			},
+		},
+	},
+	{
+		id:          "5eed0000-0000-4000-8000-000000001007",
+		title:       "Lighthouse Safety Briefing",
+		description: "Safety induction for volunteers at the harbor lighthouse museum.",
+		tags:        []string{"safety", "training"},
+		items: []seedItem{
+			{
+				itemType: types.ItemTypeMedia,
+				title:    "Welcome to the lighthouse",
+				content: types.MediaContent{
+					MediaType: "image",
+					AltText:   seedText("A red and white lighthouse on a rocky point at night"),
+					Caption:   seedText("Harbor Point lighthouse, built 1874"),
+				},
+				asset: "lighthouse.svg",
+			},
+			{
+				itemType: types.ItemTypeChoice,
+				title:    "How many visitors may be on the gallery at once?",
+				content: types.ChoiceContent{Choices: []types.Choice{
+					{ID: "a", Text: "4"},
+					{ID: "b", Text: "8", Correct: true},
+					{ID: "c", Text: "No limit"},
+				}},
+				required: true,
+				points:   seedPoints(1),
+			},
+			{
+				itemType: types.ItemTypeTextEntry,
+				title:    "Describe what you do if the fire alarm sounds during a tour",
+				content: types.TextEntryContent{
+					MaxLength:   seedPoints(1000),
+					Placeholder: seedText("Your answer"),
+					Multiline:   true,
+				},
+				required: true,
+			},
+		},
+	},
+	{
+		id:          "5eed0000-0000-4000-8000-000000001008",
+		title:       "Europe 1900–1950",
+		description: "Key events of the first half of the twentieth century.",
+		tags:        []string{"history"},
+		items: []seedItem{
+			{
+				itemType: types.ItemTypeOrdering,
+				title:    "Put these events in chronological order",
+				content: types.OrderingContent{Items: []types.OrderingItem{
+					{ID: "treaty", Text: "Treaty of Versailles signed", CorrectOrder: 2},
+					{ID: "ww1", Text: "First World War begins", CorrectOrder: 1},
+					{ID: "nato", Text: "NATO founded", CorrectOrder: 5},
+					{ID: "crash", Text: "Wall Street Crash", CorrectOrder: 3},
+					{ID: "ww2", Text: "Second World War begins", CorrectOrder: 4},
+				}},
+				required: true,
+				points:   seedPoints(3),
+			},
+			{
+				itemType: types.ItemTypeChoice,
+				title:    "In which year did the First World War end?",
+				content: types.ChoiceContent{Choices: []types.Choice{
+					{ID: "a", Text: "1916"},
+					{ID: "b", Text: "1918", Correct: true},
+					{ID: "c", Text: "1919"},
+					{ID: "d", Text: "1920"},
+				}},
+				points:      seedPoints(1),
+				explanation: seedText("The armistice was signed on 11 November 1918; the Treaty of Versailles followed in 1919."),
+			},
+			{
+				itemType: types.ItemTypeTextEntry,
+				title:    "Which city was divided into four sectors after 1945?",
+				content: types.TextEntryContent{
+					MaxLength:     seedPoints(50),
+					CorrectAnswer: seedText("Berlin"),
+				},
+				points: seedPoints(1),
+			},
+		},
+	},
+	{
+		id:          "5eed0000-0000-4000-8000-000000001009",
+		title:       "Intro to Programming Concepts",
+		description: "Variables, loops and how a program runs.",
+		tags:        []string{"computing", "programming"},
+		items: []seedItem{
+			{itemType: types.ItemTypeTitle, title: "Part 1: Building blocks"},
+			{
+				itemType: types.ItemTypeChoice,
+				title:    "Which of these repeats a block of code?",
+				content: types.ChoiceContent{Choices: []types.Choice{
+					{ID: "a", Text: "A variable"},
+					{ID: "b", Text: "A loop", Correct: true},
+					{ID: "c", Text: "A comment"},
+				}},
+				points: seedPoints(1),
+			},
+			{
+				itemType: types.ItemTypeMultiChoice,
+				title:    "Which of these are data types in most languages?",
+				content: types.ChoiceContent{Choices: []types.Choice{
+					{ID: "a", Text: "Integer", Correct: true},
+					{ID: "b", Text: "String", Correct: true},
+					{ID: "c", Text: "Boolean", Correct: true},
+					{ID: "d", Text: "Semicolon"},
+				}},
+				points: seedPoints(2),
+			},
+			{
+				itemType: types.ItemTypeOrdering,
+				title:    "Order the steps to run a compiled program",
+				content: types.OrderingContent{Items: []types.OrderingItem{
+					{ID: "run", Text: "Run the executable", CorrectOrder: 3},
+					{ID: "write", Text: "Write the source code", CorrectOrder: 1},
+					{ID: "compile", Text: "Compile the source code", CorrectOrder: 2},
+				}},
+				points: seedPoints(2),
+			},
+			{
+				itemType: types.ItemTypeTextEntry,
+				title:    "What does this print? for i in 1..3: print(i * 2)",
+				content: types.TextEntryContent{
+					MaxLength:     seedPoints(20),
+					Placeholder:   seedText("Numbers separated by spaces"),
+					CorrectAnswer: seedText("2 4 6"),
+				},
+				points: seedPoints(2),
+			},
+		},
+	},
+	{
+		id:             "5eed0000-0000-4000-8000-000000001010",
+		title:          "Staff Onboarding",
+		description:    "First-week checklist for new staff at Harbor Point School.",
+		tags:           []string{"onboarding"},
+		inOrganization: true,
+		items: []seedItem{
+			{itemType: types.ItemTypeTitle, title: "Welcome to Harbor Point School"},
+			{
+				itemType: types.ItemTypeChoice,
+				title:    "Where do you sign in when you arrive?",
+				content: types.ChoiceContent{Choices: []types.Choice{
+					{ID: "a", Text: "The front office", Correct: true},
+					{ID: "b", Text: "The staff room"},
+					{ID: "c", Text: "Your classroom"},
+				}},
+				required: true,
+			},
+			{
+				itemType: types.ItemTypeTextEntry,
+				title:    "Who is your first-week mentor?",
+				content: types.TextEntryContent{
+					MaxLength:   seedPoints(100),
+					Placeholder: seedText("Mentor's name"),
+				},
+			},
+		},
+	},
+}
+
+// seedCapital is a one-point choice item whose first option is correct
+func seedCapital(title, capital string, others ...string) seedItem {
+	choices := []types.Choice{{ID: "a", Text: capital, Correct: true}}
+	for i, other := range others {
+		choices = append(choices, types.Choice{ID: string(rune('b' + i)), Text: other})
+	}
+	return seedItem{
+		itemType: types.ItemTypeChoice,
+		title:    title,
+		content:  types.ChoiceContent{Choices: choices},
+		points:   seedPoints(1),
+	}
+}
@@ -0,0 +1,29 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// Setting is a runtime override of a configuration value, shared by every
+// replica. Which keys exist and what values they take is decided by
+// config.Dynamic; the store keeps both as text.
+type Setting struct {
+	Key   string
+	Value string
+
+	// UpdatedAt and UpdatedBy record the last change.
+	UpdatedAt time.Time
+	UpdatedBy string
+}
+
+// SettingsStore persists runtime setting overrides so every replica sees the
+// same values. Implementations must be safe for concurrent use.
+type SettingsStore interface {
+	// List returns every override, sorted by key.
+	List(ctx context.Context) ([]Setting, error)
+
+	// Update stores the given values and removes the overrides of keys
+	// mapped to nil, all or nothing.
+	Update(ctx context.Context, changes map[string]*string, updatedBy string) error
+}
@@ -6,9 +6,13 @@ import (
 	"fmt"
 	"io"
 	"mime"
+	"net/url"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
 var (
@@ -105,6 +109,11 @@ func (s *StorageService) UploadFile(ctx context.Context, projectID string, file
 		return nil, fmt.Errorf("%w: %s not in allowed types %v", ErrInvalidFileType, file.ContentType, s.config.AllowedFileTypes)
 	}
 
+	// The declared type is the client's word; the content must agree
+	if err := checkContent(&file, s.config.MaxFileSize, s.config.AllowedFileTypes); err != nil {
+		return nil, err
+	}
+
 	// Generate storage key
 	key := s.generateFileKey(projectID, file.OriginalName)
 
@@ -151,22 +160,25 @@ func (s *StorageService) ListProjectFiles(ctx context.Context, projectID string,
 	return s.storage.List(ctx, prefix, limit)
 }
 
-// CleanupProjectFiles removes all files for a project
-func (s *StorageService) CleanupProjectFiles(ctx context.Context, projectID string) error {
-	files, err := s.ListProjectFiles(ctx, projectID, 1000) // Get up to 1000 files
+// CleanupProjectFiles removes all files for a project, and returns how
+// many were removed and how many failed to be. A file that fails is logged
+// and skipped; an error is returned only if the files can't be listed.
+func (s *StorageService) CleanupProjectFiles(ctx context.Context, projectID string) (removed, failed int, err error) {
+	files, err := s.ListProjectFiles(ctx, projectID, 0)
 	if err != nil {
-		return fmt.Errorf("failed to list project files: %w", err)
+		return 0, 0, fmt.Errorf("failed to list project files: %w", err)
 	}
 
 	for _, file := range files {
 		if err := s.DeleteFile(ctx, file.Key); err != nil {
-			// Log error but continue with other files
-			// In a real implementation, you'd use structured logging here
+			log.Ctx(ctx).Warn().Err(err).Str("project_id", projectID).Str("key", file.Key).Msg("failed to delete project file")
+			failed++
 			continue
 		}
+		removed++
 	}
 
-	return nil
+	return removed, failed, nil
 }
 
 // HealthCheck checks storage service availability
@@ -186,21 +198,14 @@ func (s *StorageService) generateFileKey(projectID, originalName string) string
 
 // isAllowedFileType checks if the content type is allowed
 func (s *StorageService) isAllowedFileType(contentType string) bool {
-	if len(s.config.AllowedFileTypes) == 0 {
-		return true // Allow all types if none specified
-	}
-
-	for _, allowed := range s.config.AllowedFileTypes {
-		if contentType == allowed {
-			return true
-		}
-	}
-
-	return false
+	return isAllowedContentType(contentType, s.config.AllowedFileTypes)
 }
 
-// ValidateFileUpload performs basic validation on file upload data
-func ValidateFileUpload(file FileUpload, maxSize int64, allowedTypes []string) error {
+// ValidateFileUpload performs basic validation on file upload data. The
+// content must be of the declared type, or the type of the file name if
+// none is declared, so it reads the start of file.Reader, which it replaces
+// with a reader of the whole file.
+func ValidateFileUpload(file *FileUpload, maxSize int64, allowedTypes []string) error {
 	if file.Size <= 0 {
 		return errors.New("file size must be greater than 0")
 	}
@@ -222,20 +227,31 @@ func ValidateFileUpload(file FileUpload, maxSize int64, allowedTypes []string) e
 	}
 
 	// Validate file type
-	if len(allowedTypes) > 0 {
-		allowed := false
-		for _, allowedType := range allowedTypes {
-			if file.ContentType == allowedType {
-				allowed = true
-				break
-			}
-		}
-		if !allowed {
-			return fmt.Errorf("%w: %s not in allowed types %v", ErrInvalidFileType, file.ContentType, allowedTypes)
-		}
+	if !isAllowedContentType(file.ContentType, allowedTypes) {
+		return fmt.Errorf("%w: %s not in allowed types %v", ErrInvalidFileType, file.ContentType, allowedTypes)
 	}
 
-	return nil
+	return checkContent(file, maxSize, allowedTypes)
+}
+
+// ProjectAssetKey returns the storage key of a URL pointing into the
+// project's assets, whatever host or base path storage serves them from.
+// Other URLs, such as links to other sites, are not assets.
+func ProjectAssetKey(raw, projectID string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	prefix := fmt.Sprintf("projects/%s/assets/", projectID)
+	i := strings.Index(u.Path, prefix)
+	if i < 0 {
+		return "", false
+	}
+	key := path.Clean(u.Path[i:])
+	if !strings.HasPrefix(key, prefix) {
+		return "", false
+	}
+	return key, true
 }
 
 // GetContentTypeFromFilename determines content type from filename
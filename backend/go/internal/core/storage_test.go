@@ -0,0 +1,309 @@
+package core
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStorage is a Storage of the files in keys, failing to delete those
+// in undeletable
+type memoryStorage struct {
+	Storage
+	keys        []string
+	undeletable map[string]bool
+}
+
+func (s *memoryStorage) List(ctx context.Context, prefix string, limit int) ([]*StorageMetadata, error) {
+	files := []*StorageMetadata{}
+	for _, key := range s.keys {
+		if strings.HasPrefix(key, prefix) {
+			files = append(files, &StorageMetadata{Key: key})
+		}
+	}
+	return files, nil
+}
+
+func (s *memoryStorage) Delete(ctx context.Context, key string) error {
+	if s.undeletable[key] {
+		return ErrStorageUnavailable
+	}
+	for i, k := range s.keys {
+		if k == key {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			return nil
+		}
+	}
+	return ErrFileNotFound
+}
+
+// uploadingStorage is a Storage recording the content of the last upload
+type uploadingStorage struct {
+	Storage
+	content []byte
+}
+
+func (s *uploadingStorage) Upload(ctx context.Context, key string, reader io.Reader, opts UploadOptions) (*StorageMetadata, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	s.content = content
+	return &StorageMetadata{Key: key, Size: int64(len(content))}, nil
+}
+
+// unlistableStorage is a Storage whose files can't be listed
+type unlistableStorage struct {
+	Storage
+}
+
+func (unlistableStorage) List(ctx context.Context, prefix string, limit int) ([]*StorageMetadata, error) {
+	return nil, ErrStorageUnavailable
+}
+
+// trashedProjects is a ProjectStore with "project-1" in the trash
+type trashedProjects struct {
+	ProjectStore
+	purged bool
+}
+
+func (s *trashedProjects) Purge(ctx context.Context, id string) error {
+	if id != "project-1" || s.purged {
+		return ErrProjectNotFound
+	}
+	s.purged = true
+	return nil
+}
+
+func TestStorageService_CleanupProjectFiles(t *testing.T) {
+	// Arrange
+	storage := &memoryStorage{
+		keys: []string{
+			"projects/project-1/assets/map_1712.png",
+			"projects/project-1/assets/flag_1712.png",
+			"projects/project-1/assets/anthem_1712.mp3",
+			"projects/project-2/assets/map_1712.png",
+		},
+		undeletable: map[string]bool{"projects/project-1/assets/flag_1712.png": true},
+	}
+	service := NewStorageService(storage, StorageConfig{})
+
+	// Act
+	removed, failed, err := service.CleanupProjectFiles(context.Background(), "project-1")
+
+	// Assert
+	require.NoError(t, err, "a file that can't be deleted doesn't stop the others")
+	assert.Equal(t, 2, removed)
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, []string{"projects/project-1/assets/flag_1712.png", "projects/project-2/assets/map_1712.png"}, storage.keys)
+}
+
+func TestStorageService_CleanupProjectFiles_Unlistable(t *testing.T) {
+	// Arrange
+	service := NewStorageService(unlistableStorage{}, StorageConfig{})
+
+	// Act
+	_, _, err := service.CleanupProjectFiles(context.Background(), "project-1")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrStorageUnavailable)
+}
+
+func TestProjectService_Purge_RemovesFiles(t *testing.T) {
+	// Arrange
+	storage := &memoryStorage{keys: []string{"projects/project-1/assets/map_1712.png", "projects/project-2/assets/map_1712.png"}}
+	service := NewProjectService(&trashedProjects{}, NewStorageService(storage, StorageConfig{}))
+
+	// Act
+	err := service.Purge(context.Background(), "project-1")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"projects/project-2/assets/map_1712.png"}, storage.keys)
+}
+
+func TestProjectService_Purge_KeepsFiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		projectID   string
+		storage     Storage
+		expectedErr error
+	}{
+		{"project not in the trash", "project-2", &memoryStorage{keys: []string{"projects/project-2/assets/map_1712.png"}}, ErrProjectNotFound},
+		{"file that can't be deleted", "project-1", &memoryStorage{
+			keys:        []string{"projects/project-1/assets/map_1712.png"},
+			undeletable: map[string]bool{"projects/project-1/assets/map_1712.png": true},
+		}, nil},
+		{"storage unavailable", "project-1", unlistableStorage{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			projects := &trashedProjects{}
+			service := NewProjectService(projects, NewStorageService(tt.storage, StorageConfig{}))
+
+			// Act
+			err := service.Purge(context.Background(), tt.projectID)
+
+			// Assert
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Len(t, tt.storage.(*memoryStorage).keys, 1, "files of a project that isn't purged are kept")
+				return
+			}
+			assert.NoError(t, err, "the project is purged whatever becomes of its files")
+			assert.True(t, projects.purged)
+		})
+	}
+}
+
+const (
+	pngHeader  = "\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"
+	jpegHeader = "\xff\xd8\xff\xe0\x00\x10JFIF\x00"
+	exeHeader  = "MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff"
+	svgImage   = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"><circle cx="5" cy="5" r="4"/></svg>`
+)
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		head     string
+		expected string
+	}{
+		{"png", pngHeader, "image/png"},
+		{"jpeg", jpegHeader, "image/jpeg"},
+		{"gif", "GIF89a\x01\x00\x01\x00", "image/gif"},
+		{"webp", "RIFF\x24\x00\x00\x00WEBPVP8 ", "image/webp"},
+		{"svg", svgImage, "image/svg+xml"},
+		{"svg with a prolog", "\xef\xbb\xbf<?xml version=\"1.0\"?>\n<!-- Drawn by hand -->\n" + svgImage, "image/svg+xml"},
+		{"mp3 with an ID3 tag", "ID3\x03\x00\x00\x00\x00\x00\x00", "audio/mpeg"},
+		{"mp3 without an ID3 tag", "\xff\xfb\x90\x64\x00\x00", "audio/mpeg"},
+		{"wav", "RIFF\x24\x00\x00\x00WAVEfmt ", "audio/wav"},
+		{"executable", exeHeader, "application/octet-stream"},
+		{"other XML", `<?xml version="1.0"?><note>Not a drawing</note>`, "text/xml"},
+		{"html", "<html><body>Hello</body></html>", "text/html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			detected := DetectContentType([]byte(tt.head))
+
+			// Assert
+			assert.Equal(t, tt.expected, detected)
+		})
+	}
+}
+
+func TestStorageService_UploadFile_ChecksContent(t *testing.T) {
+	// Longer than the part types are detected from, to be stored whole
+	longSVG := `<svg xmlns="http://www.w3.org/2000/svg"><desc>` + strings.Repeat("A chart of the stars. ", 40) + `</desc></svg>`
+	// Detected as text, since its svg element starts past that part
+	longProlog := `<?xml version="1.0"?>` + "\n<!-- " + strings.Repeat("Drawn by hand. ", 40) + "-->\n"
+
+	tests := []struct {
+		name        string
+		contentType string
+		content     string
+		expectedErr error
+	}{
+		{"png", "image/png", pngHeader, nil},
+		{"svg", "image/svg+xml", longSVG, nil},
+		{"wav declared by another name", "audio/x-wav", "RIFF\x24\x00\x00\x00WAVEfmt ", nil},
+		{"executable declared as png", "image/png", exeHeader, ErrInvalidFileType},
+		{"jpeg declared as png", "image/png", jpegHeader, ErrInvalidFileType},
+		{"png declared as svg", "image/svg+xml", pngHeader, ErrInvalidFileType},
+		{"html declared as svg", "image/svg+xml", "<html><body><script>alert(1)</script></body></html>", ErrInvalidFileType},
+		{"svg with a script", "image/svg+xml", `<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`, ErrInvalidFileType},
+		{"svg with a script past the detected part", "image/svg+xml", strings.TrimSuffix(longSVG, "</svg>") + `<SCRIPT>alert(1)</SCRIPT></svg>`, ErrInvalidFileType},
+		{"svg with an HTML script", "image/svg+xml", `<svg xmlns="http://www.w3.org/2000/svg"><foreignObject><script xmlns="http://www.w3.org/1999/xhtml">alert(1)</script></foreignObject></svg>`, ErrInvalidFileType},
+		{"svg with an event handler", "image/svg+xml", `<svg xmlns="http://www.w3.org/2000/svg" onload="alert(1)"/>`, ErrInvalidFileType},
+		{"svg with a javascript link", "image/svg+xml", `<svg xmlns="http://www.w3.org/2000/svg"><a href="javascript:alert(1)"><circle r="4"/></a></svg>`, ErrInvalidFileType},
+		{"svg with an obfuscated xlink", "image/svg+xml", `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><a xlink:href=" JaVa&#x09;Script&#58;alert(1)"><circle r="4"/></a></svg>`, ErrInvalidFileType},
+		{"svg animating a javascript link", "image/svg+xml", `<svg xmlns="http://www.w3.org/2000/svg"><a><animate attributeName="href" values="#;javascript:alert(1)"/><circle r="4"/></a></svg>`, ErrInvalidFileType},
+		{"svg with a foreign object", "image/svg+xml", `<svg xmlns="http://www.w3.org/2000/svg"><foreignObject><div xmlns="http://www.w3.org/1999/xhtml">Hi</div></foreignObject></svg>`, ErrInvalidFileType},
+		{"svg with a long prolog", "image/svg+xml", longProlog + `<svg xmlns="http://www.w3.org/2000/svg"><a href="#stars"><circle r="4"/></a></svg>`, nil},
+		{"svg with a long prolog and a script", "image/svg+xml", longProlog + `<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`, ErrInvalidFileType},
+		{"malformed svg", "image/svg+xml", `<svg xmlns="http://www.w3.org/2000/svg"><circle r="4">`, ErrInvalidFileType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			storage := &uploadingStorage{}
+			service := NewStorageService(storage, StorageConfig{
+				MaxFileSize:      4096,
+				AllowedFileTypes: []string{"image/png", "image/svg+xml", "audio/wav"},
+			})
+			file := FileUpload{OriginalName: "chart", ContentType: tt.contentType, Size: int64(len(tt.content)), Reader: strings.NewReader(tt.content)}
+
+			// Act
+			_, err := service.UploadFile(context.Background(), "project-1", file)
+
+			// Assert
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, storage.content, "nothing is stored")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.content, string(storage.content))
+		})
+	}
+}
+
+func TestStorageService_UploadFile_NoAllowedTypes(t *testing.T) {
+	// Arrange
+	storage := &uploadingStorage{}
+	service := NewStorageService(storage, StorageConfig{MaxFileSize: 4096})
+	upload := func(contentType, content string) error {
+		_, err := service.UploadFile(context.Background(), "project-1", FileUpload{OriginalName: "chart", ContentType: contentType, Size: int64(len(content)), Reader: strings.NewReader(content)})
+		return err
+	}
+
+	// Act
+	exeErr := upload("image/png", exeHeader)
+	svgErr := upload("image/png", `<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`)
+	undetectedErr := upload("image/svg+xml", "<!-- "+strings.Repeat("Drawn by hand. ", 40)+`--><svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`)
+
+	// Assert
+	assert.NoError(t, exeErr, "any type is allowed")
+	assert.ErrorIs(t, svgErr, ErrInvalidFileType, "SVG images still can't run scripts")
+	assert.ErrorIs(t, undetectedErr, ErrInvalidFileType, "files declared as SVG images are checked however they are detected")
+}
+
+func TestValidateFileUpload(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        FileUpload
+		expectedErr error
+	}{
+		{"png", FileUpload{OriginalName: "map.png", Size: int64(len(pngHeader)), Reader: strings.NewReader(pngHeader)}, nil},
+		{"executable named as png", FileUpload{OriginalName: "map.png", Size: int64(len(exeHeader)), Reader: strings.NewReader(exeHeader)}, ErrInvalidFileType},
+		{"executable declared as png", FileUpload{OriginalName: "map.exe", ContentType: "image/png", Size: int64(len(exeHeader)), Reader: strings.NewReader(exeHeader)}, ErrInvalidFileType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			file := tt.file
+			err := ValidateFileUpload(&file, 1024, []string{"image/png"})
+
+			// Assert
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "image/png", file.ContentType)
+			content, err := io.ReadAll(file.Reader)
+			require.NoError(t, err)
+			assert.Equal(t, pngHeader, string(content), "the file reads whole after validation")
+		})
+	}
+}
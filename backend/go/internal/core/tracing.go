@@ -0,0 +1,18 @@
+package core
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies spans created by the core services
+const tracerName = "github.com/provemyself/backend/internal/core"
+
+// startSpan starts a child span for a service operation.
+// Tracing is a no-op unless a tracer provider has been installed.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrConcurrentModification is returned when concurrent transactions kept
+// aborting one, e.g. by deadlocking with it, until it ran out of retries
+var ErrConcurrentModification = errors.New("concurrent modification")
+
+// ErrVersionConflict is returned by updates given the version of the
+// project or item they expect when it has another one: someone else wrote
+// it since it was read
+var ErrVersionConflict = errors.New("version conflict")
+
+// ErrNoTransaction is returned by store methods that leave checks to the
+// end of a transaction (see Transactor) when called outside one
+var ErrNoTransaction = errors.New("not in a transaction")
+
+// freshReadsKey marks a context whose reads must see every committed write
+type freshReadsKey struct{}
+
+// WithFreshReads makes the stores read ctx's data from the primary database
+// rather than a read replica, which may lag behind it, e.g. to check a
+// quota against rows just written. Reads in a transaction always do.
+func WithFreshReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshReadsKey{}, true)
+}
+
+// FreshReads reports whether ctx asks for fresh reads (see WithFreshReads)
+func FreshReads(ctx context.Context) bool {
+	fresh, _ := ctx.Value(freshReadsKey{}).(bool)
+	return fresh
+}
+
+// Transactor runs service operations that span several store calls
+// atomically. Store methods called with the context fn receives take part in
+// the transaction; fn returning an error rolls it back. A transaction aborted
+// by a concurrent one may be run again from the start, so fn must not have
+// side effects outside the stores. Calls nested inside fn join the
+// enclosing transaction.
+type Transactor interface {
+	InTx(ctx context.Context, name string, fn func(ctx context.Context) error) error
+}
+
+// noTransactions is the Transactor of services without a database, e.g. in
+// tests: fn runs directly and nothing is rolled back
+type noTransactions struct{}
+
+func (noTransactions) InTx(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
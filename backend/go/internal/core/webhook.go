@@ -0,0 +1,215 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain errors for webhooks
+var (
+	// ErrWebhookNotFound is returned when the user has no webhook with the
+	// given ID
+	ErrWebhookNotFound = errors.New("webhook not found")
+
+	// ErrWebhookDeliveryNotFound is returned when the webhook has no
+	// delivery with the given ID
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+	// ErrWebhookQueueFull is returned when a delivery can't be queued
+	// because the dispatcher is at capacity
+	ErrWebhookQueueFull = errors.New("webhook delivery queue is full")
+)
+
+// EventType names something that happened, which webhooks subscribe to
+type EventType string
+
+// Event types webhooks can subscribe to
+const (
+	EventProjectCreated   EventType = "project.created"
+	EventProjectUpdated   EventType = "project.updated"
+	EventProjectDeleted   EventType = "project.deleted"
+	EventProjectPublished EventType = "project.published"
+	EventProjectRestored  EventType = "project.restored"
+)
+
+// EventTypes lists every event type, in the order they are documented
+var EventTypes = []EventType{
+	EventProjectCreated,
+	EventProjectUpdated,
+	EventProjectDeleted,
+	EventProjectPublished,
+	EventProjectRestored,
+}
+
+// Event is something that happened to a project of an organization, or of
+// no organization when OrgID is ""
+type Event struct {
+	ID         string
+	Type       EventType
+	OrgID      string
+	OccurredAt time.Time
+
+	// Project is the project the event is about, as it is after the event
+	Project *Project
+}
+
+// EventPublisher hands events to whoever subscribed to them. Publishing
+// never fails the operation the event is about; delivery errors are the
+// publisher's to log.
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+// NewEvent returns an event of type about project in the organization in
+// ctx
+func NewEvent(ctx context.Context, eventType EventType, project *Project) Event {
+	return Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		OrgID:      OrgIDFromContext(ctx),
+		OccurredAt: time.Now().UTC(),
+		Project:    project,
+	}
+}
+
+// Webhook is a user's subscription to the events of the projects of an
+// organization. Deliveries are signed with Secret.
+type Webhook struct {
+	ID     string
+	UserID string
+
+	// OrgID is the organization whose projects' events are delivered; nil
+	// for the projects that belong to no organization
+	OrgID *string
+
+	// OwnerEmail is told when the webhook is disabled after failing
+	OwnerEmail string
+
+	URL    string
+	Secret string
+	Events []EventType
+
+	// Active webhooks get deliveries. A webhook is deactivated by its
+	// owner, or by the dispatcher after FailureCount deliveries in a row
+	// failed, which sets DisabledAt.
+	Active       bool
+	FailureCount int
+	DisabledAt   *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Subscribes reports whether the webhook subscribes to eventType
+func (w *Webhook) Subscribes(eventType EventType) bool {
+	for _, subscribed := range w.Events {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus is the outcome of a delivery
+type WebhookDeliveryStatus string
+
+// Webhook delivery statuses
+const (
+	// WebhookDeliveryPending deliveries are queued or being attempted
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliverySucceeded deliveries got a 2xx response
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryFailed deliveries failed every attempt
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is an event sent, or to be sent, to a webhook.
+// Redelivering an event makes a new delivery with the same EventID.
+type WebhookDelivery struct {
+	ID        string
+	WebhookID string
+	EventID   string
+	EventType EventType
+	// Payload is the JSON body sent
+	Payload []byte
+
+	Status   WebhookDeliveryStatus
+	Attempts int
+	// ResponseStatus and ResponseBody are from the last attempt that got a
+	// response; ResponseBody is cut short
+	ResponseStatus *int
+	ResponseBody   string
+	// Latency is how long the last attempt took
+	Latency time.Duration
+	// Error describes why the last attempt failed
+	Error string
+
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+// WebhookDeadLetter is a failed delivery whose event never reached its
+// webhook, with the webhook
+type WebhookDeadLetter struct {
+	Webhook  *Webhook
+	Delivery *WebhookDelivery
+}
+
+// WebhookStore persists webhooks and their deliveries. Webhooks are scoped
+// to the user who owns them rather than to the organization in ctx.
+// Implementations must be safe for concurrent use.
+type WebhookStore interface {
+	// Create returns ErrOrganizationNotFound if the webhook's organization
+	// doesn't exist
+	Create(ctx context.Context, webhook *Webhook) (*Webhook, error)
+
+	// Get returns ErrWebhookNotFound if the user has no such webhook
+	Get(ctx context.Context, userID, id string) (*Webhook, error)
+
+	// List returns the user's webhooks, oldest first
+	List(ctx context.Context, userID string) ([]*Webhook, error)
+
+	// Update stores the webhook's URL, secret, events and active flag.
+	// Activating a webhook clears its failures. Returns ErrWebhookNotFound
+	// if the user has no such webhook.
+	Update(ctx context.Context, webhook *Webhook) (*Webhook, error)
+
+	// Delete removes the webhook with its deliveries. Returns
+	// ErrWebhookNotFound if the user has no such webhook.
+	Delete(ctx context.Context, userID, id string) error
+
+	// ListSubscribed returns the active webhooks of the organization, or
+	// of no organization for "", subscribed to eventType
+	ListSubscribed(ctx context.Context, orgID string, eventType EventType) ([]*Webhook, error)
+
+	// RecordOutcome counts a delivery that succeeded or failed. Once
+	// disableAfter deliveries in a row failed, the webhook is deactivated
+	// and disabled is true, for the call that deactivated it only.
+	RecordOutcome(ctx context.Context, id string, succeeded bool, disableAfter int) (disabled bool, err error)
+
+	// CreateDelivery stores a pending delivery
+	CreateDelivery(ctx context.Context, delivery *WebhookDelivery) (*WebhookDelivery, error)
+
+	// CompleteDelivery stores the delivery's outcome
+	CompleteDelivery(ctx context.Context, delivery *WebhookDelivery) error
+
+	// GetDelivery returns ErrWebhookDeliveryNotFound if the webhook has no
+	// such delivery
+	GetDelivery(ctx context.Context, webhookID, id string) (*WebhookDelivery, error)
+
+	// ListDeliveries returns a page of the webhook's deliveries, newest
+	// first, and their total
+	ListDeliveries(ctx context.Context, webhookID string, limit, offset int) ([]*WebhookDelivery, int, error)
+
+	// ListDeadLetters returns up to limit of the latest failed deliveries
+	// of each event to each active webhook, oldest first, leaving out the
+	// events a later delivery is sending or has sent
+	ListDeadLetters(ctx context.Context, limit int) ([]*WebhookDeadLetter, error)
+
+	// PurgeDeliveries deletes the deliveries created before cutoff and
+	// returns how many it deleted
+	PurgeDeliveries(ctx context.Context, cutoff time.Time) (int64, error)
+}
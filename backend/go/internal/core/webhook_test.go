@@ -0,0 +1,182 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventProjects is a ProjectStore holding the project "project-1"
+type eventProjects struct {
+	ProjectStore
+	deleted bool
+}
+
+func (s *eventProjects) get(id string) (*Project, error) {
+	if id != "project-1" || s.deleted {
+		return nil, ErrProjectNotFound
+	}
+	return &Project{ID: id, Title: "World Capitals"}, nil
+}
+
+func (s *eventProjects) Create(ctx context.Context, title string, description *string, tags []string) (*Project, error) {
+	return &Project{ID: "project-1", Title: title}, nil
+}
+
+func (s *eventProjects) GetByID(ctx context.Context, id string) (*Project, error) {
+	return s.get(id)
+}
+
+func (s *eventProjects) Update(ctx context.Context, id string, title string, description *string, tags []string, version *int) (*Project, error) {
+	if _, err := s.get(id); err != nil {
+		return nil, err
+	}
+	return &Project{ID: id, Title: title}, nil
+}
+
+func (s *eventProjects) Delete(ctx context.Context, id string) error {
+	if _, err := s.get(id); err != nil {
+		return err
+	}
+	s.deleted = true
+	return nil
+}
+
+func (s *eventProjects) Restore(ctx context.Context, id string) (*Project, error) {
+	if id != "project-1" || !s.deleted {
+		return nil, ErrProjectNotFound
+	}
+	s.deleted = false
+	return s.get(id)
+}
+
+func (s *eventProjects) Publish(ctx context.Context, id string) (*Project, error) {
+	return s.get(id)
+}
+
+func (s *eventProjects) Duplicate(ctx context.Context, id, title string) (*Project, error) {
+	if _, err := s.get(id); err != nil {
+		return nil, err
+	}
+	return &Project{ID: "project-2", Title: title}, nil
+}
+
+// recordingPublisher records the events it is given
+type recordingPublisher struct {
+	events []Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event Event) {
+	p.events = append(p.events, event)
+}
+
+func TestProjectService_PublishesEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       func(ctx context.Context, s *ProjectService) error
+		expected EventType
+	}{
+		{"create", func(ctx context.Context, s *ProjectService) error {
+			_, err := s.Create(ctx, "World Capitals", nil, nil)
+			return err
+		}, EventProjectCreated},
+		{"update", func(ctx context.Context, s *ProjectService) error {
+			_, err := s.Update(ctx, "project-1", "World Capitals", nil, nil, nil)
+			return err
+		}, EventProjectUpdated},
+		{"delete", func(ctx context.Context, s *ProjectService) error {
+			return s.Delete(ctx, "project-1")
+		}, EventProjectDeleted},
+		{"publish", func(ctx context.Context, s *ProjectService) error {
+			_, err := s.Publish(ctx, "project-1")
+			return err
+		}, EventProjectPublished},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			publisher := &recordingPublisher{}
+			service := NewProjectService(&eventProjects{}, nil)
+			service.SetEvents(publisher)
+			ctx := WithOrgID(context.Background(), "org-1")
+
+			// Act
+			err := tt.op(ctx, service)
+
+			// Assert
+			require.NoError(t, err)
+			require.Len(t, publisher.events, 1)
+			event := publisher.events[0]
+			assert.Equal(t, tt.expected, event.Type)
+			assert.Equal(t, "org-1", event.OrgID)
+			assert.NotEmpty(t, event.ID)
+			assert.False(t, event.OccurredAt.IsZero())
+			require.NotNil(t, event.Project)
+			assert.Equal(t, "project-1", event.Project.ID)
+			assert.Equal(t, "World Capitals", event.Project.Title)
+		})
+	}
+}
+
+func TestProjectService_FailedChangesPublishNothing(t *testing.T) {
+	// Arrange
+	publisher := &recordingPublisher{}
+	service := NewProjectService(&eventProjects{}, nil)
+	service.SetEvents(publisher)
+	ctx := context.Background()
+
+	// Act
+	_, updateErr := service.Update(ctx, "project-2", "World Capitals", nil, nil, nil)
+	deleteErr := service.Delete(ctx, "project-2")
+	_, publishErr := service.Publish(ctx, "project-2")
+	_, createErr := service.Create(ctx, "", nil, nil)
+	_, duplicateErr := service.Duplicate(ctx, "project-2")
+
+	// Assert
+	assert.ErrorIs(t, updateErr, ErrProjectNotFound)
+	assert.ErrorIs(t, deleteErr, ErrProjectNotFound)
+	assert.ErrorIs(t, publishErr, ErrProjectNotFound)
+	assert.ErrorIs(t, createErr, ErrProjectTitleTooShort)
+	assert.ErrorIs(t, duplicateErr, ErrProjectNotFound)
+	assert.Empty(t, publisher.events)
+}
+
+func TestProjectService_Restore(t *testing.T) {
+	// Arrange
+	publisher := &recordingPublisher{}
+	service := NewProjectService(&eventProjects{deleted: true}, nil)
+	service.SetEvents(publisher)
+	ctx := context.Background()
+
+	// Act
+	project, err := service.Restore(ctx, "project-1")
+	_, againErr := service.Restore(ctx, "project-1")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "project-1", project.ID)
+	assert.ErrorIs(t, againErr, ErrProjectNotFound, "only projects in the trash are restored")
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, EventProjectRestored, publisher.events[0].Type)
+}
+
+func TestProjectService_Duplicate(t *testing.T) {
+	// Arrange
+	publisher := &recordingPublisher{}
+	service := NewProjectService(&eventProjects{}, nil)
+	service.SetEvents(publisher)
+
+	// Act
+	project, err := service.Duplicate(context.Background(), "project-1")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "project-2", project.ID)
+	assert.Equal(t, "World Capitals (copy)", project.Title)
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, EventProjectCreated, publisher.events[0].Type)
+	assert.Equal(t, "project-2", publisher.events[0].Project.ID)
+}
@@ -0,0 +1,97 @@
+// Package email sends transactional email. Messages are rendered from
+// embedded templates (see Templates) into multipart HTML and plain-text
+// bodies, queued in memory (see Queue) and delivered in the background by a
+// Sender: SMTP when a host is configured, otherwise a directory of .eml
+// files for local development. Logs carry each message's recipients,
+// template and Message-ID, never its body.
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Message is a rendered email. Mailer.Send fills in the addressing and
+// rendering fields; the Date and Message-ID are set when it is queued.
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+
+	// Template is the name the message was rendered from, for logs
+	Template  string
+	MessageID string
+	Date      time.Time
+}
+
+// Sender delivers one message. Implementations must be safe for
+// concurrent use.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+var (
+	// ErrNoRecipients is returned for a message without a To address
+	ErrNoRecipients = errors.New("email has no recipients")
+	// ErrInvalidAddress is returned for a From or To address that does not
+	// parse as RFC 5322
+	ErrInvalidAddress = errors.New("invalid email address")
+)
+
+// Mailer renders templates and queues the resulting messages
+type Mailer struct {
+	templates *Templates
+	queue     *Queue
+	from      string
+}
+
+// NewMailer creates a mailer sending from the given address
+func NewMailer(templates *Templates, queue *Queue, from string) *Mailer {
+	return &Mailer{templates: templates, queue: queue, from: from}
+}
+
+// Send renders the named template with data and queues the message for
+// delivery. It returns once the message is queued; delivery failures are
+// retried and logged by the queue.
+func (m *Mailer) Send(ctx context.Context, template string, to []string, data any) error {
+	if len(to) == 0 {
+		return ErrNoRecipients
+	}
+
+	msg, err := m.templates.Render(template, data)
+	if err != nil {
+		return err
+	}
+	msg.From = m.from
+	msg.To = to
+	msg.Date = time.Now().UTC()
+	msg.MessageID = newMessageID(m.from)
+
+	if err := m.queue.Enqueue(msg); err != nil {
+		return fmt.Errorf("failed to queue %s email: %w", template, err)
+	}
+
+	log.Ctx(ctx).Debug().
+		Strs("to", msg.To).
+		Str("template", msg.Template).
+		Str("message_id", msg.MessageID).
+		Msg("email queued")
+	return nil
+}
+
+// newMessageID returns a unique Message-ID in the sender's domain
+func newMessageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = strings.Trim(from[at+1:], "> ")
+	}
+	return fmt.Sprintf("<%s@%s>", uuid.NewString(), domain)
+}
@@ -0,0 +1,50 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileSender writes each message to a .eml file instead of sending it, for
+// development without an SMTP server. The files open in any mail client.
+type FileSender struct {
+	dir string
+}
+
+// NewFileSender creates a sender writing to dir, which is created on the
+// first message
+func NewFileSender(dir string) *FileSender {
+	return &FileSender{dir: dir}
+}
+
+// Send writes msg to <dir>/<date>-<message id>.eml
+func (s *FileSender) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create email directory: %w", err)
+	}
+
+	id := strings.NewReplacer("<", "", ">", "", "@", "_", "/", "_").Replace(msg.MessageID)
+	if id == "" {
+		id = uuid.NewString()
+	}
+	date := msg.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	name := fmt.Sprintf("%s-%s.eml", date.UTC().Format("20060102T150405Z"), id)
+
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package email
+
+import (
+	"context"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSender_Send(t *testing.T) {
+	// Arrange
+	dir := filepath.Join(t.TempDir(), "outbox")
+	sender := NewFileSender(dir)
+	msg := testMessage(t, "World Capitals")
+
+	// Act
+	err := sender.Send(context.Background(), msg)
+
+	// Assert
+	require.NoError(t, err)
+	files, err := filepath.Glob(filepath.Join(dir, "20261016T093000Z-*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	f, err := os.Open(files[0])
+	require.NoError(t, err)
+	defer f.Close()
+	parsed, err := mail.ReadMessage(f)
+	require.NoError(t, err)
+	assert.Equal(t, msg.MessageID, parsed.Header.Get("Message-ID"))
+	assert.Equal(t, `"World Capitals" is published`, parsed.Header.Get("Subject"))
+}
@@ -0,0 +1,105 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Bytes encodes the message as an RFC 5322 message with a
+// multipart/alternative body: the plain-text part first, then the HTML
+// part, both quoted-printable
+func (m *Message) Bytes() ([]byte, error) {
+	from, to, err := m.addresses()
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	if err := writePart(parts, "text/plain; charset=utf-8", m.Text); err != nil {
+		return nil, err
+	}
+	if err := writePart(parts, "text/html; charset=utf-8", m.HTML); err != nil {
+		return nil, err
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now().UTC()
+	}
+	recipients := make([]string, len(to))
+	for i, address := range to {
+		recipients[i] = address.String()
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "From: %s\r\n", from.String())
+	fmt.Fprintf(&out, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&out, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&out, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	if m.MessageID != "" {
+		fmt.Fprintf(&out, "Message-ID: %s\r\n", m.MessageID)
+	}
+	out.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&out, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	out.Write(body.Bytes())
+
+	return out.Bytes(), nil
+}
+
+// envelope returns the bare SMTP envelope sender and recipients
+func (m *Message) envelope() (string, []string, error) {
+	from, to, err := m.addresses()
+	if err != nil {
+		return "", nil, err
+	}
+	recipients := make([]string, len(to))
+	for i, address := range to {
+		recipients[i] = address.Address
+	}
+	return from.Address, recipients, nil
+}
+
+// addresses parses the From and To addresses, which may carry display names
+func (m *Message) addresses() (*mail.Address, []*mail.Address, error) {
+	if len(m.To) == 0 {
+		return nil, nil, ErrNoRecipients
+	}
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: from %q: %v", ErrInvalidAddress, m.From, err)
+	}
+	to := make([]*mail.Address, len(m.To))
+	for i, raw := range m.To {
+		if to[i], err = mail.ParseAddress(raw); err != nil {
+			return nil, nil, fmt.Errorf("%w: recipient %q: %v", ErrInvalidAddress, raw, err)
+		}
+	}
+	return from, to, nil
+}
+
+func writePart(parts *multipart.Writer, contentType, content string) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	part, err := parts.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	encoder := quotedprintable.NewWriter(part)
+	if _, err := encoder.Write([]byte(content)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
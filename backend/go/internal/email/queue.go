@@ -0,0 +1,190 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrQueueFull is returned by Enqueue when the queue is at capacity
+	ErrQueueFull = errors.New("email queue is full")
+	// ErrQueueClosed is returned by Enqueue once the queue is draining
+	ErrQueueClosed = errors.New("email queue is closed")
+)
+
+// QueueConfig tunes a Queue
+type QueueConfig struct {
+	// Size is how many messages may wait for delivery
+	Size int
+	// MaxAttempts is how many times a message is sent before it is dropped
+	MaxAttempts int
+	// Backoff is the wait before the first retry; it doubles after each
+	// further failure
+	Backoff time.Duration
+}
+
+// Queue delivers messages in the background through a Sender. Messages are
+// sent one at a time in the order queued. Failed sends are retried with
+// exponential backoff unless the failure is permanent (see IsPermanent);
+// messages that still fail are logged and dropped. The queue is held in
+// memory, so messages queued when the process dies are lost.
+type Queue struct {
+	sender Sender
+	cfg    QueueConfig
+	sleep  func(ctx context.Context, d time.Duration) error
+
+	mu       sync.RWMutex
+	messages chan *Message
+	closed   bool
+}
+
+// NewQueue creates a queue delivering through sender. Run delivers the
+// messages.
+func NewQueue(sender Sender, cfg QueueConfig) *Queue {
+	if cfg.Size < 1 {
+		cfg.Size = 1
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	return &Queue{
+		sender:   sender,
+		cfg:      cfg,
+		sleep:    sleep,
+		messages: make(chan *Message, cfg.Size),
+	}
+}
+
+// Enqueue queues msg without waiting for room
+func (q *Queue) Enqueue(msg *Message) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+	select {
+	case q.messages <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Len returns how many messages are waiting
+func (q *Queue) Len() int {
+	return len(q.messages)
+}
+
+// Run delivers messages until ctx is done, then drains: the queue stops
+// accepting messages and each one still waiting gets a single attempt
+// without backoff. A stop hook bounds how long the drain may take (see
+// lifecycle.Worker); messages left when it gives up are lost.
+func (q *Queue) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			q.drain(context.WithoutCancel(ctx))
+			return ctx.Err()
+		case msg := <-q.messages:
+			q.deliver(ctx, msg)
+		}
+	}
+}
+
+// deliver sends msg, retrying transient failures until ctx is done
+func (q *Queue) deliver(ctx context.Context, msg *Message) {
+	backoff := q.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		err := q.send(ctx, msg, attempt)
+		if err == nil || IsPermanent(err) || attempt >= q.cfg.MaxAttempts {
+			return
+		}
+		if err := q.sleep(ctx, backoff); err != nil {
+			// Shutting down: Run's drain gives the message its last try
+			q.requeue(msg)
+			return
+		}
+		backoff *= 2
+	}
+}
+
+// drain makes one last attempt at every waiting message
+func (q *Queue) drain(ctx context.Context) {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+
+	for {
+		select {
+		case msg := <-q.messages:
+			q.send(ctx, msg, q.cfg.MaxAttempts)
+		default:
+			return
+		}
+	}
+}
+
+// requeue puts back a message interrupted by shutdown, dropping it if the
+// queue has filled up since
+func (q *Queue) requeue(msg *Message) {
+	select {
+	case q.messages <- msg:
+	default:
+		logMessage(log.Error(), msg).Msg("email dropped: queue full at shutdown")
+	}
+}
+
+// send makes one attempt and logs its outcome. Attempt numbers at or past
+// MaxAttempts are final.
+func (q *Queue) send(ctx context.Context, msg *Message, attempt int) error {
+	start := time.Now()
+	err := q.sender.Send(ctx, msg)
+	if err == nil {
+		logMessage(log.Info(), msg).
+			Int("attempt", attempt).
+			Dur("duration", time.Since(start)).
+			Msg("email sent")
+		return nil
+	}
+
+	if IsPermanent(err) || attempt >= q.cfg.MaxAttempts {
+		logMessage(log.Error(), msg).
+			Err(err).
+			Int("attempt", attempt).
+			Msg("email dropped")
+		return err
+	}
+	logMessage(log.Warn(), msg).
+		Err(err).
+		Int("attempt", attempt).
+		Msg("email send failed, retrying")
+	return err
+}
+
+// logMessage adds a message's metadata to a log event. Bodies are never
+// logged.
+func logMessage(event *zerolog.Event, msg *Message) *zerolog.Event {
+	return event.
+		Strs("to", msg.To).
+		Str("template", msg.Template).
+		Str("message_id", msg.MessageID)
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,172 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/lifecycle"
+)
+
+// recordingSender records the messages it is asked to send
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []*Message
+}
+
+func (s *recordingSender) Send(ctx context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func (s *recordingSender) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+// runQueue runs q as the lifecycle manager would and returns its stop
+// function
+func runQueue(t *testing.T, q *Queue) func() error {
+	t.Helper()
+
+	hook := lifecycle.Worker("email queue", q.Run)
+	require.NoError(t, hook.Start(context.Background()))
+	stopped := false
+	stop := func() error {
+		if stopped {
+			return nil
+		}
+		stopped = true
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return hook.Stop(ctx)
+	}
+	t.Cleanup(func() { stop() })
+	return stop
+}
+
+func TestQueue_RetriesTransientFailures(t *testing.T) {
+	// Arrange
+	server := startSMTPServer(t, &smtpServer{dataReplies: []string{"451 try again later", "421 service not available"}})
+	q := NewQueue(NewSMTPSender(server.config()), QueueConfig{Size: 10, MaxAttempts: 3, Backoff: time.Millisecond})
+	runQueue(t, q)
+
+	// Act
+	require.NoError(t, q.Enqueue(testMessage(t, "World Capitals")))
+
+	// Assert
+	require.Eventually(t, func() bool { return len(server.received()) == 1 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 3, server.dataCount())
+}
+
+func TestQueue_DropsAfterMaxAttempts(t *testing.T) {
+	// Arrange
+	server := startSMTPServer(t, &smtpServer{dataReplies: []string{"451 one", "451 two", "451 three"}})
+	q := NewQueue(NewSMTPSender(server.config()), QueueConfig{Size: 10, MaxAttempts: 2, Backoff: time.Millisecond})
+	runQueue(t, q)
+
+	// Act
+	require.NoError(t, q.Enqueue(testMessage(t, "Dropped")))
+	require.NoError(t, q.Enqueue(testMessage(t, "Delivered")))
+
+	// Assert: the first message used two attempts, the second got the
+	// third rejection and then went through
+	require.Eventually(t, func() bool { return len(server.received()) == 1 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 4, server.dataCount())
+	assert.Contains(t, string(server.received()[0].data), "Delivered")
+}
+
+func TestQueue_DoesNotRetryPermanentFailures(t *testing.T) {
+	// Arrange
+	server := startSMTPServer(t, &smtpServer{dataReplies: []string{"550 mailbox unavailable"}})
+	q := NewQueue(NewSMTPSender(server.config()), QueueConfig{Size: 10, MaxAttempts: 5, Backoff: time.Millisecond})
+	runQueue(t, q)
+
+	// Act
+	require.NoError(t, q.Enqueue(testMessage(t, "Rejected")))
+	require.NoError(t, q.Enqueue(testMessage(t, "Delivered")))
+
+	// Assert
+	require.Eventually(t, func() bool { return len(server.received()) == 1 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 2, server.dataCount())
+}
+
+func TestQueue_Enqueue_Full(t *testing.T) {
+	// Arrange: nothing runs the queue, so messages pile up
+	q := NewQueue(&recordingSender{}, QueueConfig{Size: 2, MaxAttempts: 1})
+	require.NoError(t, q.Enqueue(&Message{}))
+	require.NoError(t, q.Enqueue(&Message{}))
+
+	// Act
+	err := q.Enqueue(&Message{})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrQueueFull)
+	assert.Equal(t, 2, q.Len())
+}
+
+func TestQueue_DrainsOnStop(t *testing.T) {
+	// Arrange
+	sender := &recordingSender{}
+	q := NewQueue(sender, QueueConfig{Size: 10, MaxAttempts: 1})
+	for i := 0; i < 5; i++ {
+		require.NoError(t, q.Enqueue(&Message{To: []string{"teacher@example.com"}}))
+	}
+	stop := runQueue(t, q)
+
+	// Act
+	err := stop()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 5, sender.count())
+	assert.ErrorIs(t, q.Enqueue(&Message{}), ErrQueueClosed)
+}
+
+func TestQueue_RetryInterruptedByStopIsDrained(t *testing.T) {
+	// Arrange: the first attempt fails, and the queue is stopped while it
+	// waits to retry
+	failing := &flakySender{failures: 1}
+	q := NewQueue(failing, QueueConfig{Size: 10, MaxAttempts: 3, Backoff: time.Hour})
+	stop := runQueue(t, q)
+	require.NoError(t, q.Enqueue(&Message{To: []string{"teacher@example.com"}}))
+	require.Eventually(t, func() bool { return failing.attempts() == 1 }, 5*time.Second, time.Millisecond)
+
+	// Act
+	err := stop()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 2, failing.attempts())
+}
+
+// flakySender fails its first failures sends
+type flakySender struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+}
+
+func (s *flakySender) Send(ctx context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.calls <= s.failures {
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+func (s *flakySender) attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
@@ -0,0 +1,132 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// DefaultSMTPTimeout bounds a whole delivery when SMTPConfig.Timeout is unset
+const DefaultSMTPTimeout = 10 * time.Second
+
+// SMTPConfig is an SMTP server to deliver through
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password authenticate with AUTH PLAIN when Username is
+	// set. net/smtp refuses to send them unencrypted to anything but
+	// localhost.
+	Username string
+	Password string
+	// Timeout bounds connecting and the whole SMTP conversation of one
+	// message
+	Timeout time.Duration
+	// TLS configures STARTTLS, which is used whenever the server offers it.
+	// Nil verifies the server against Host.
+	TLS *tls.Config
+}
+
+// SMTPSender delivers messages over SMTP, one connection per message
+type SMTPSender struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPSender creates a sender for the given server
+func NewSMTPSender(cfg SMTPConfig) *SMTPSender {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultSMTPTimeout
+	}
+	return &SMTPSender{cfg: cfg}
+}
+
+// Send delivers msg, upgrading to TLS with STARTTLS when the server
+// supports it
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	from, to, err := msg.envelope()
+	if err != nil {
+		return err
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to greet %s: %w", addr, err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		tlsConfig := s.cfg.TLS
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: s.cfg.Host}
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	if s.cfg.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("SMTP server does not support AUTH")
+		}
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("MAIL FROM rejected: %w", err)
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("RCPT TO %s rejected: %w", recipient, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA rejected: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// IsPermanent reports whether retrying the same message cannot succeed: its
+// addresses are invalid or the server answered with a 5xx reply
+func IsPermanent(err error) bool {
+	if errors.Is(err, ErrNoRecipients) || errors.Is(err, ErrInvalidAddress) {
+		return true
+	}
+	var reply *textproto.Error
+	return errors.As(err, &reply) && reply.Code >= 500
+}
@@ -0,0 +1,370 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpServer is an in-process SMTP server recording the messages it
+// accepts. It offers STARTTLS when tls is set and requires AUTH PLAIN when
+// username is set.
+type smtpServer struct {
+	listener net.Listener
+	tls      *tls.Config
+	username string
+	password string
+	// dataReplies answer the first DATA commands instead of accepting them,
+	// e.g. "451 try again later"
+	dataReplies []string
+	// silent accepts connections without ever greeting
+	silent bool
+
+	mu           sync.Mutex
+	messages     []receivedMessage
+	dataCommands int
+}
+
+type receivedMessage struct {
+	from          string
+	to            []string
+	data          []byte
+	tls           bool
+	authenticated bool
+}
+
+func startSMTPServer(t *testing.T, server *smtpServer) *smtpServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server.listener = listener
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+// config returns an SMTPConfig pointing at the server
+func (s *smtpServer) config() SMTPConfig {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return SMTPConfig{
+		Host:     addr.IP.String(),
+		Port:     addr.Port,
+		Username: s.username,
+		Password: s.password,
+		Timeout:  2 * time.Second,
+	}
+}
+
+func (s *smtpServer) received() []receivedMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMessage(nil), s.messages...)
+}
+
+func (s *smtpServer) dataCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dataCommands
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	if s.silent {
+		io.Copy(io.Discard, conn)
+		return
+	}
+
+	text := textproto.NewConn(conn)
+	var (
+		current       receivedMessage
+		secure        bool
+		authenticated bool
+	)
+	reply := func(line string) { text.PrintfLine("%s", line) }
+
+	reply("220 localhost ESMTP test")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			lines := []string{"localhost"}
+			if s.tls != nil && !secure {
+				lines = append(lines, "STARTTLS")
+			}
+			if s.username != "" {
+				lines = append(lines, "AUTH PLAIN")
+			}
+			lines = append(lines, "8BITMIME")
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				reply("250" + sep + l)
+			}
+		case "STARTTLS":
+			reply("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(conn)
+			secure = true
+		case "AUTH":
+			credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+			if string(credentials) == "\x00"+s.username+"\x00"+s.password {
+				authenticated = true
+				reply("235 authenticated")
+			} else {
+				reply("535 authentication failed")
+			}
+		case "MAIL":
+			if s.username != "" && !authenticated {
+				reply("530 authentication required")
+				continue
+			}
+			current = receivedMessage{from: pathAddress(arg), tls: secure, authenticated: authenticated}
+			reply("250 ok")
+		case "RCPT":
+			current.to = append(current.to, pathAddress(arg))
+			reply("250 ok")
+		case "DATA":
+			s.mu.Lock()
+			s.dataCommands++
+			var rejection string
+			if len(s.dataReplies) > 0 {
+				rejection, s.dataReplies = s.dataReplies[0], s.dataReplies[1:]
+			}
+			s.mu.Unlock()
+			if rejection != "" {
+				reply(rejection)
+				continue
+			}
+
+			reply("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			current.data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, current)
+			s.mu.Unlock()
+			reply("250 queued")
+		case "RSET", "NOOP":
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+// pathAddress returns the address between angle brackets in a MAIL or RCPT
+// argument such as "FROM:<a@example.com> BODY=8BITMIME"
+func pathAddress(arg string) string {
+	_, rest, _ := strings.Cut(arg, "<")
+	address, _, _ := strings.Cut(rest, ">")
+	return address
+}
+
+// selfSignedTLS returns a server certificate for 127.0.0.1 and a client
+// config trusting it
+func selfSignedTLS(t *testing.T) (server, client *tls.Config) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}},
+		&tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}
+}
+
+// testMessage renders the project_published template
+func testMessage(t *testing.T, title string) *Message {
+	t.Helper()
+
+	msg, err := DefaultTemplates().Render("project_published", map[string]any{
+		"ProjectTitle": title,
+		"ItemCount":    11,
+		"ProjectURL":   "https://provemyself.example/projects/p1",
+	})
+	require.NoError(t, err)
+	msg.From = "ProveMySelf <noreply@provemyself.example>"
+	msg.To = []string{"teacher@example.com"}
+	msg.Date = time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	msg.MessageID = newMessageID(msg.From)
+	return msg
+}
+
+func TestSMTPSender_Send(t *testing.T) {
+	// Arrange
+	serverTLS, clientTLS := selfSignedTLS(t)
+	server := startSMTPServer(t, &smtpServer{tls: serverTLS, username: "mailer", password: "s3cret"})
+	cfg := server.config()
+	cfg.TLS = clientTLS
+	sender := NewSMTPSender(cfg)
+	msg := testMessage(t, "Capitales du monde – révision")
+
+	// Act
+	err := sender.Send(context.Background(), msg)
+
+	// Assert
+	require.NoError(t, err)
+	received := server.received()
+	require.Len(t, received, 1)
+	assert.True(t, received[0].tls, "STARTTLS was not used")
+	assert.True(t, received[0].authenticated)
+	assert.Equal(t, "noreply@provemyself.example", received[0].from)
+	assert.Equal(t, []string{"teacher@example.com"}, received[0].to)
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(received[0].data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, `"Capitales du monde – révision" is published`, subject)
+	assert.Equal(t, `"ProveMySelf" <noreply@provemyself.example>`, parsed.Header.Get("From"))
+	assert.Equal(t, "<teacher@example.com>", parsed.Header.Get("To"))
+	assert.Equal(t, msg.MessageID, parsed.Header.Get("Message-ID"))
+	assert.Equal(t, "1.0", parsed.Header.Get("MIME-Version"))
+	date, err := parsed.Header.Date()
+	require.NoError(t, err)
+	assert.True(t, date.Equal(msg.Date))
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+
+	textPart, err := parts.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", textPart.Header.Get("Content-Type"))
+	textBody, err := io.ReadAll(textPart)
+	require.NoError(t, err)
+	assert.Contains(t, string(textBody), `Your project "Capitales du monde – révision" is now published.`)
+	assert.Contains(t, string(textBody), "https://provemyself.example/projects/p1")
+
+	htmlPart, err := parts.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "text/html; charset=utf-8", htmlPart.Header.Get("Content-Type"))
+	htmlBody, err := io.ReadAll(htmlPart)
+	require.NoError(t, err)
+	assert.Contains(t, string(htmlBody), "<strong>Capitales du monde – révision</strong>")
+	assert.Contains(t, string(htmlBody), `<a href="https://provemyself.example/projects/p1"`)
+
+	_, err = parts.NextPart()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestSMTPSender_Send_Rejected(t *testing.T) {
+	tests := []struct {
+		name      string
+		reply     string
+		permanent bool
+	}{
+		{name: "transient", reply: "451 try again later", permanent: false},
+		{name: "permanent", reply: "550 mailbox unavailable", permanent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := startSMTPServer(t, &smtpServer{dataReplies: []string{tt.reply}})
+			sender := NewSMTPSender(server.config())
+
+			// Act
+			err := sender.Send(context.Background(), testMessage(t, "World Capitals"))
+
+			// Assert
+			require.Error(t, err)
+			assert.Equal(t, tt.permanent, IsPermanent(err))
+			assert.Empty(t, server.received())
+		})
+	}
+}
+
+func TestSMTPSender_Send_Timeout(t *testing.T) {
+	// Arrange
+	server := startSMTPServer(t, &smtpServer{silent: true})
+	cfg := server.config()
+	cfg.Timeout = 100 * time.Millisecond
+	sender := NewSMTPSender(cfg)
+	start := time.Now()
+
+	// Act
+	err := sender.Send(context.Background(), testMessage(t, "World Capitals"))
+
+	// Assert
+	require.Error(t, err)
+	assert.False(t, IsPermanent(err))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestSMTPSender_Send_InvalidAddress(t *testing.T) {
+	// Arrange
+	server := startSMTPServer(t, &smtpServer{})
+	sender := NewSMTPSender(server.config())
+	msg := testMessage(t, "World Capitals")
+	msg.To = []string{"not an address"}
+
+	// Act
+	err := sender.Send(context.Background(), msg)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidAddress)
+	assert.True(t, IsPermanent(err))
+	assert.Zero(t, server.dataCount())
+}
@@ -0,0 +1,150 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Layout files wrap every message. A message template <name> is a pair of
+// files: <name>.html defines the "content" block of the HTML layout, and
+// <name>.txt defines the "content" block of the text layout and the
+// one-line "subject".
+const (
+	htmlLayout = "layout.html"
+	textLayout = "layout.txt"
+)
+
+//go:embed templates/*.html templates/*.txt
+var templateFiles embed.FS
+
+// ErrTemplateNotFound is returned when rendering an unknown template
+var ErrTemplateNotFound = errors.New("email template not found")
+
+// Templates is a registry of message templates, parsed once
+type Templates struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+// DefaultTemplates returns the templates embedded from templates/. It
+// panics if they do not parse, which the package tests rule out.
+func DefaultTemplates() *Templates {
+	files, err := fs.Sub(templateFiles, "templates")
+	if err != nil {
+		panic(err)
+	}
+	templates, err := NewTemplates(files)
+	if err != nil {
+		panic(err)
+	}
+	return templates
+}
+
+// NewTemplates parses the layouts and message templates at the root of
+// files. Every message needs both its .html and .txt file.
+func NewTemplates(files fs.FS) (*Templates, error) {
+	htmlBase, err := htmltemplate.New(htmlLayout).Option("missingkey=error").ParseFS(files, htmlLayout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", htmlLayout, err)
+	}
+	textBase, err := texttemplate.New(textLayout).Option("missingkey=error").ParseFS(files, textLayout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", textLayout, err)
+	}
+
+	htmlNames, err := messageNames(files, ".html", htmlLayout)
+	if err != nil {
+		return nil, err
+	}
+	textNames, err := messageNames(files, ".txt", textLayout)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Join(htmlNames, ",") != strings.Join(textNames, ",") {
+		return nil, fmt.Errorf("every email template needs an .html and a .txt file, got %v and %v", htmlNames, textNames)
+	}
+
+	t := &Templates{
+		html: make(map[string]*htmltemplate.Template, len(htmlNames)),
+		text: make(map[string]*texttemplate.Template, len(textNames)),
+	}
+	for _, name := range htmlNames {
+		html, err := htmltemplate.Must(htmlBase.Clone()).ParseFS(files, name+".html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template %s: %w", name, err)
+		}
+		text, err := texttemplate.Must(textBase.Clone()).ParseFS(files, name+".txt")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template %s: %w", name, err)
+		}
+		if html.Lookup("content") == nil || text.Lookup("content") == nil || text.Lookup("subject") == nil {
+			return nil, fmt.Errorf("email template %s must define content in both files and subject in the .txt file", name)
+		}
+		t.html[name] = html
+		t.text[name] = text
+	}
+	return t, nil
+}
+
+// Names returns the registered template names, sorted
+func (t *Templates) Names() []string {
+	names := make([]string, 0, len(t.html))
+	for name := range t.html {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render renders the named template with data into a message with its
+// Template, Subject, Text and HTML set
+func (t *Templates) Render(name string, data any) (*Message, error) {
+	html, ok := t.html[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	text := t.text[name]
+
+	var subject, textBody, htmlBody bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := text.ExecuteTemplate(&textBody, textLayout, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+	if err := html.ExecuteTemplate(&htmlBody, htmlLayout, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s HTML: %w", name, err)
+	}
+
+	return &Message{
+		Template: name,
+		Subject:  strings.Join(strings.Fields(subject.String()), " "),
+		Text:     strings.TrimSpace(textBody.String()) + "\n",
+		HTML:     htmlBody.String(),
+	}, nil
+}
+
+// messageNames lists the message templates with the given extension,
+// leaving out the layout
+func messageNames(files fs.FS, ext, layout string) ([]string, error) {
+	matches, err := fs.Glob(files, "*"+ext)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if match != layout {
+			names = append(names, strings.TrimSuffix(path.Base(match), ext))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
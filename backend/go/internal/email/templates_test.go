@@ -0,0 +1,106 @@
+package email
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultTemplates_RenderEveryTemplate(t *testing.T) {
+	templates := DefaultTemplates()
+	data := map[string]map[string]any{
+		"project_published": {"ProjectTitle": "World Capitals", "ItemCount": 11, "ProjectURL": "https://provemyself.example/p"},
+		"webhook_disabled":  {"URL": "https://hooks.example.com/provemyself", "Failures": 20},
+	}
+
+	require.ElementsMatch(t, templates.Names(), keys(data), "every template needs sample data here")
+	for _, name := range templates.Names() {
+		t.Run(name, func(t *testing.T) {
+			msg, err := templates.Render(name, data[name])
+
+			require.NoError(t, err)
+			assert.Equal(t, name, msg.Template)
+			assert.NotEmpty(t, msg.Subject)
+			assert.NotContains(t, msg.Subject, "\n")
+			assert.Contains(t, msg.HTML, "<!DOCTYPE html>")
+			assert.Contains(t, msg.Text, "ProveMySelf account")
+		})
+	}
+}
+
+func TestTemplates_Render(t *testing.T) {
+	templates := DefaultTemplates()
+
+	t.Run("escapes HTML", func(t *testing.T) {
+		msg, err := templates.Render("project_published", map[string]any{
+			"ProjectTitle": "<script>alert(1)</script>",
+			"ItemCount":    1,
+			"ProjectURL":   "javascript:alert(1)",
+		})
+
+		require.NoError(t, err)
+		assert.NotContains(t, msg.HTML, "<script>")
+		assert.Contains(t, msg.HTML, "&lt;script&gt;")
+		assert.Contains(t, msg.HTML, `href="#ZgotmplZ"`)
+		assert.Contains(t, msg.Text, "<script>alert(1)</script>")
+	})
+
+	t.Run("missing data", func(t *testing.T) {
+		_, err := templates.Render("project_published", map[string]any{"ProjectTitle": "World Capitals"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		_, err := templates.Render("password_reset", nil)
+
+		assert.ErrorIs(t, err, ErrTemplateNotFound)
+	})
+}
+
+func TestNewTemplates_Invalid(t *testing.T) {
+	layouts := fstest.MapFS{
+		"layout.html": {Data: []byte(`<html>{{template "content" .}}</html>`)},
+		"layout.txt":  {Data: []byte(`{{template "content" .}}`)},
+	}
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{name: "missing text file", files: map[string]string{"welcome.html": `{{define "content"}}Hi{{end}}`}},
+		{name: "missing subject", files: map[string]string{
+			"welcome.html": `{{define "content"}}Hi{{end}}`,
+			"welcome.txt":  `{{define "content"}}Hi{{end}}`,
+		}},
+		{name: "syntax error", files: map[string]string{
+			"welcome.html": `{{define "content"}}{{.Name{{end}}`,
+			"welcome.txt":  `{{define "subject"}}Hi{{end}}{{define "content"}}Hi{{end}}`,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := fstest.MapFS{}
+			for name, file := range layouts {
+				files[name] = file
+			}
+			for name, content := range tt.files {
+				files[name] = &fstest.MapFile{Data: []byte(content)}
+			}
+
+			_, err := NewTemplates(files)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func keys(m map[string]map[string]any) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,97 @@
+// Package debug exposes runtime diagnostics (pprof profiles, expvar and a GC
+// trigger) for operators. The routes are admin-only and are served by their
+// own http.Server so long-running profiles aren't cut off by the API's write
+// timeout or request size limits.
+package debug
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rtdebug "runtime/debug"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/respond"
+)
+
+// WriteTimeout bounds debug responses. CPU profiles and traces stream for up
+// to 30 seconds by default, so it must comfortably exceed that.
+const WriteTimeout = 2 * time.Minute
+
+// Config contains debug endpoint configuration
+type Config struct {
+	JWTSecret string
+	// AllowedIPs optionally restricts access to these IPs or CIDR ranges
+	AllowedIPs []string
+}
+
+// NewRouter returns the /debug route group guarded by admin authentication
+func NewRouter(cfg Config) http.Handler {
+	r := chi.NewRouter()
+
+	if len(cfg.AllowedIPs) > 0 {
+		r.Use(middleware.IPAllowlist(cfg.AllowedIPs))
+	}
+	r.Use(middleware.AuthenticateJWT(cfg.JWTSecret))
+	r.Use(middleware.RequireRole("admin"))
+
+	r.Route("/debug", func(r chi.Router) {
+		r.Get("/pprof/", pprof.Index)
+		r.Get("/pprof/cmdline", pprof.Cmdline)
+		r.Get("/pprof/profile", pprof.Profile)
+		r.Get("/pprof/symbol", pprof.Symbol)
+		r.Post("/pprof/symbol", pprof.Symbol)
+		r.Get("/pprof/trace", pprof.Trace)
+		// Named profiles: heap, goroutine, allocs, block, mutex, threadcreate
+		r.Get("/pprof/{profile}", func(w http.ResponseWriter, r *http.Request) {
+			pprof.Handler(chi.URLParam(r, "profile")).ServeHTTP(w, r)
+		})
+
+		r.Get("/vars", expvar.Handler().ServeHTTP)
+		r.Post("/gc", CollectGarbage)
+	})
+
+	return r
+}
+
+// NewServer creates the dedicated debug server
+func NewServer(addr string, cfg Config) *http.Server {
+	return &http.Server{
+		Addr:         addr,
+		Handler:      NewRouter(cfg),
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: WriteTimeout,
+		IdleTimeout:  60 * time.Second,
+	}
+}
+
+// CollectGarbage forces a garbage collection and returns memory to the OS
+func CollectGarbage(w http.ResponseWriter, r *http.Request) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+	rtdebug.FreeOSMemory()
+	elapsed := time.Since(start)
+
+	runtime.ReadMemStats(&after)
+
+	log.Info().
+		Str("user_id", middleware.GetUserID(r.Context())).
+		Uint64("heap_alloc_before", before.HeapAlloc).
+		Uint64("heap_alloc_after", after.HeapAlloc).
+		Dur("elapsed", elapsed).
+		Msg("manual garbage collection triggered")
+
+	respond.JSON(w, http.StatusOK, map[string]interface{}{
+		"heap_alloc_before": before.HeapAlloc,
+		"heap_alloc_after":  after.HeapAlloc,
+		"heap_released":     after.HeapReleased,
+		"elapsed_ms":        elapsed.Milliseconds(),
+	})
+}
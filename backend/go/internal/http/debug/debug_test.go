@@ -0,0 +1,102 @@
+package debug
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRouter_Access(t *testing.T) {
+	tests := []struct {
+		name           string
+		allowedIPs     []string
+		remoteAddr     string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			name:           "anonymous request is rejected",
+			remoteAddr:     "127.0.0.1:5000",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "admin outside allowlist is forbidden",
+			allowedIPs:     []string{"10.0.0.0/8"},
+			remoteAddr:     "192.168.1.5:5000",
+			authorization:  "Bearer admin-token",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "admin inside allowlist is allowed",
+			allowedIPs:     []string{"10.0.0.0/8"},
+			remoteAddr:     "10.1.2.3:5000",
+			authorization:  "Bearer admin-token",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "admin without allowlist is allowed",
+			remoteAddr:     "192.168.1.5:5000",
+			authorization:  "Bearer admin-token",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := NewRouter(Config{JWTSecret: "secret", AllowedIPs: tt.allowedIPs})
+			req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rr := httptest.NewRecorder()
+
+			// Act
+			router.ServeHTTP(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
+
+func TestNewRouter_CPUProfile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping CPU profile in short mode")
+	}
+
+	// Arrange
+	server := httptest.NewServer(NewRouter(Config{JWTSecret: "secret"}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/debug/pprof/profile?seconds=1", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+
+	// Act
+	resp, err := http.DefaultClient.Do(req)
+
+	// Assert
+	if assert.NoError(t, err) {
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/octet-stream", resp.Header.Get("Content-Type"))
+	}
+}
+
+func TestCollectGarbage(t *testing.T) {
+	// Arrange
+	router := NewRouter(Config{JWTSecret: "secret"})
+	req := httptest.NewRequest(http.MethodPost, "/debug/gc", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	rr := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(rr, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "heap_alloc_after")
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/logging"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// MaintenanceSwitch is the maintenance mode the admin handler controls,
+// satisfied by *httpmiddleware.Maintenance
+type MaintenanceSwitch interface {
+	Mode() core.MaintenanceMode
+	Forced() bool
+	Set(ctx context.Context, mode core.MaintenanceMode) error
+}
+
+// AdminHandler handles operator endpoints under /api/v1/admin
+type AdminHandler struct {
+	maintenance MaintenanceSwitch
+	validate    *validator.Validate
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(maintenance MaintenanceSwitch, validate *validator.Validate) *AdminHandler {
+	return &AdminHandler{
+		maintenance: maintenance,
+		validate:    validate,
+	}
+}
+
+// GetLogLevel handles GET /api/v1/admin/log-level
+// @Summary Get log level
+// @Description Returns the process-wide log level
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.LogLevelResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/log-level [get]
+func (h *AdminHandler) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, http.StatusOK, types.LogLevelResponse{Level: logging.Level().String()})
+}
+
+// SetLogLevel handles PUT /api/v1/admin/log-level
+// @Summary Set log level
+// @Description Changes the process-wide log level without a restart. The change is not persisted; LOG_LEVEL applies again on the next start.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.LogLevelRequest true "New log level (trace, debug, info, warn, error, fatal, panic, disabled)"
+// @Success 200 {object} types.LogLevelResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, invalid_log_level"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/log-level [put]
+func (h *AdminHandler) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req types.LogLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpmiddleware.SendBodyReadError(w, err)
+		return
+	}
+
+	level, err := logging.ParseLevel(req.Level)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid_log_level", "Invalid log level", err.Error())
+		return
+	}
+
+	previous := logging.Level()
+	logging.SetLevel(level)
+
+	// Logged at warn so the change is recorded at any level but the most
+	// restrictive
+	log.Warn().
+		Str("previous", previous.String()).
+		Str("level", level.String()).
+		Str("user_id", httpmiddleware.GetUserID(r.Context())).
+		Msg("log level changed")
+
+	respond.JSON(w, http.StatusOK, types.LogLevelResponse{Level: level.String()})
+}
+
+// GetMaintenance handles GET /api/v1/admin/maintenance
+// @Summary Get maintenance mode
+// @Description Returns the maintenance switch in effect on this replica
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.MaintenanceResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/maintenance [get]
+func (h *AdminHandler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, http.StatusOK, h.maintenanceResponse())
+}
+
+// SetMaintenance handles PUT /api/v1/admin/maintenance
+// @Summary Set maintenance mode
+// @Description Turns maintenance mode on or off for every replica. While it is on, API requests other than health probes and admin endpoints get 503 maintenance with the operator message, except GET requests when allow_reads is set. Replicas pick up the change within one poll interval.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.MaintenanceRequest true "Maintenance switch"
+// @Success 200 {object} types.MaintenanceResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/maintenance [put]
+func (h *AdminHandler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req types.MaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpmiddleware.SendBodyReadError(w, err)
+		return
+	}
+
+	if err := h.validate.StructCtx(ctx, req); err != nil {
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
+		return
+	}
+
+	userID := httpmiddleware.GetUserID(ctx)
+	err := h.maintenance.Set(ctx, core.MaintenanceMode{
+		Enabled:    req.Enabled,
+		Message:    req.Message,
+		AllowReads: req.AllowReads,
+		UpdatedBy:  userID,
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to set maintenance mode")
+		respondDomainError(w, err)
+		return
+	}
+
+	log.Ctx(ctx).Warn().
+		Bool("enabled", req.Enabled).
+		Bool("allow_reads", req.AllowReads).
+		Str("user_id", userID).
+		Msg("maintenance mode set")
+
+	respond.JSON(w, http.StatusOK, h.maintenanceResponse())
+}
+
+func (h *AdminHandler) maintenanceResponse() types.MaintenanceResponse {
+	mode := h.maintenance.Mode()
+
+	response := types.MaintenanceResponse{
+		Enabled:    mode.Enabled,
+		Message:    mode.Message,
+		AllowReads: mode.AllowReads,
+		Forced:     h.maintenance.Forced(),
+		UpdatedBy:  mode.UpdatedBy,
+	}
+	if !mode.UpdatedAt.IsZero() {
+		response.UpdatedAt = &mode.UpdatedAt
+	}
+	return response
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/logging"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+)
+
+func TestAdminHandler_SetLogLevel(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedLevel  zerolog.Level
+	}{
+		{"enable debug", `{"level":"debug"}`, http.StatusOK, zerolog.DebugLevel},
+		{"case insensitive", `{"level":"WARN"}`, http.StatusOK, zerolog.WarnLevel},
+		{"unknown level", `{"level":"verbose"}`, http.StatusBadRequest, zerolog.InfoLevel},
+		{"missing level", `{}`, http.StatusBadRequest, zerolog.InfoLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			previous := logging.Level()
+			t.Cleanup(func() { logging.SetLevel(previous) })
+			logging.SetLevel(zerolog.InfoLevel)
+
+			handler := NewAdminHandler(nil, httpmiddleware.NewValidator())
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/log-level", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := newRecorder()
+
+			// Act
+			handler.SetLogLevel(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedLevel, logging.Level())
+			if tt.expectedStatus == http.StatusBadRequest {
+				assertErrorResponse(t, rr.Body.Bytes(), "invalid_log_level")
+				return
+			}
+
+			var response types.LogLevelResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedLevel.String(), response.Level)
+		})
+	}
+}
+
+func TestAdminHandler_SetLogLevel_TogglesDebugOutput(t *testing.T) {
+	// Arrange
+	previous := logging.Level()
+	t.Cleanup(func() { logging.SetLevel(previous) })
+	logging.SetLevel(zerolog.InfoLevel)
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	handler := NewAdminHandler(nil, httpmiddleware.NewValidator())
+
+	logger.Debug().Msg("before toggle")
+
+	// Act
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/log-level", strings.NewReader(`{"level":"debug"}`))
+	handler.SetLogLevel(newRecorder(), req)
+	logger.Debug().Msg("after toggle")
+
+	// Assert
+	assert.NotContains(t, buf.String(), "before toggle")
+	assert.Contains(t, buf.String(), "after toggle")
+
+	rr := newRecorder()
+	handler.GetLogLevel(rr, httptest.NewRequest(http.MethodGet, "/api/v1/admin/log-level", nil))
+	assert.JSONEq(t, `{"level":"debug"}`, rr.Body.String())
+}
+
+func TestAdminHandler_SetMaintenance(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectEnabled  bool
+	}{
+		{"turn on", `{"enabled":true,"message":"Back at 14:00 UTC","allow_reads":true}`, http.StatusOK, true},
+		{"turn off", `{"enabled":false}`, http.StatusOK, false},
+		{"message too long", `{"enabled":true,"message":"` + strings.Repeat("x", 501) + `"}`, http.StatusBadRequest, false},
+		{"malformed body", `{"enabled":`, http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			maintenance := httpmiddleware.NewMaintenance(store.NewMemoryMaintenanceStore(), httpmiddleware.MaintenanceConfig{})
+			handler := NewAdminHandler(maintenance, httpmiddleware.NewValidator())
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/maintenance", strings.NewReader(tt.body))
+			rr := newRecorder()
+
+			// Act
+			handler.SetMaintenance(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectEnabled, maintenance.Mode().Enabled)
+
+			if tt.expectedStatus == http.StatusOK {
+				var response types.MaintenanceResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectEnabled, response.Enabled)
+				assert.False(t, response.Forced)
+				assert.NotNil(t, response.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_GetMaintenance_ReportsForcedMode(t *testing.T) {
+	// Arrange
+	maintenance := httpmiddleware.NewMaintenance(store.NewMemoryMaintenanceStore(), httpmiddleware.MaintenanceConfig{
+		Forced:  true,
+		Message: "Schema migration in progress",
+	})
+	handler := NewAdminHandler(maintenance, httpmiddleware.NewValidator())
+	rr := newRecorder()
+
+	// Act
+	handler.GetMaintenance(rr, httptest.NewRequest(http.MethodGet, "/api/v1/admin/maintenance", nil))
+
+	// Assert
+	assert.JSONEq(t, `{"enabled":true,"message":"Schema migration in progress","allow_reads":false,"forced":true}`, rr.Body.String())
+}
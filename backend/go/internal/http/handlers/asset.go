@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// maxExpiresIn is the longest duration a time.Duration holds, in whole
+// seconds: a longer expires_in would overflow into a negative duration. The
+// service caps it at the configured maximum anyway.
+const maxExpiresIn = math.MaxInt64 / int64(time.Second)
+
+// AssetService signs URLs to project assets and serves them, satisfied by
+// *core.AssetService
+type AssetService interface {
+	SignedURL(ctx context.Context, projectID, name string, ttl time.Duration) (string, time.Time, error)
+	Download(ctx context.Context, key string) (io.ReadCloser, *core.StorageMetadata, error)
+}
+
+// AssetHandler hands out signed URLs to project assets and serves the
+// assets to requests that carry one
+type AssetHandler struct {
+	assets   AssetService
+	signer   *core.AssetURLSigner
+	validate *validator.Validate
+}
+
+// NewAssetHandler creates a new asset handler verifying URLs with signer
+func NewAssetHandler(assets AssetService, signer *core.AssetURLSigner, validate *validator.Validate) *AssetHandler {
+	return &AssetHandler{
+		assets:   assets,
+		signer:   signer,
+		validate: validate,
+	}
+}
+
+// CreateSignedURL handles POST /api/v1/projects/{projectId}/assets/{key}/signed-url
+// @Summary Sign an asset URL
+// @Description Returns a URL to a project asset that anyone holding it can download until it expires, after expires_in seconds or the configured maximum if that is sooner. key is the asset's name under the project's assets. The URL points to GET /assets/{key}, which rejects it with 403 once expired or if it was tampered with.
+// @Tags Projects
+// @Accept json
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param key path string true "Asset name"
+// @Param request body types.SignedURLRequest true "How long the URL lasts"
+// @Success 200 {object} types.SignedURLResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 404 {object} types.ErrorResponse "project_not_found, file_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 503 {object} types.ErrorResponse "storage_unavailable"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/assets/{key}/signed-url [post]
+func (h *AssetHandler) CreateSignedURL(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := chi.URLParam(r, "projectId")
+	name := chi.URLParam(r, "key")
+
+	var req types.SignedURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to decode request")
+		httpmiddleware.SendBodyReadError(w, err)
+		return
+	}
+
+	if err := h.validate.StructCtx(ctx, req); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("validation failed")
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
+		return
+	}
+
+	ttl := time.Duration(min(int64(req.ExpiresIn), maxExpiresIn)) * time.Second
+	signedURL, expiresAt, err := h.assets.SignedURL(ctx, projectID, name, ttl)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Str("asset", name).Msg("failed to sign asset URL")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, types.SignedURLResponse{URL: signedURL, ExpiresAt: expiresAt})
+}
+
+// ServeAsset handles GET /assets/{key}
+// @Summary Download an asset
+// @Description Serves a stored asset to a URL signed by POST /api/v1/projects/{projectId}/assets/{key}/signed-url, which it must be requested with unchanged. Browsers may cache the asset until the URL expires.
+// @Tags Assets
+// @Produce octet-stream,json
+// @Param key path string true "Asset storage key"
+// @Param expires query int true "When the URL expires, in Unix seconds"
+// @Param signature query string true "Signature of the key and expiry"
+// @Success 200 {file} file "The asset"
+// @Failure 403 {object} types.ErrorResponse "asset_url_invalid, asset_url_expired"
+// @Failure 404 {object} types.ErrorResponse "file_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 503 {object} types.ErrorResponse "storage_unavailable"
+// @Router /assets/{key} [get]
+func (h *AssetHandler) ServeAsset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	key := chi.URLParam(r, "*")
+
+	now := time.Now()
+	if err := h.signer.Verify(key, r.URL.Query(), now); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("rejected asset URL")
+		respondDomainError(w, err)
+		return
+	}
+
+	file, metadata, err := h.assets.Download(ctx, key)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("key", key).Msg("failed to open asset")
+		respondDomainError(w, err)
+		return
+	}
+	defer file.Close()
+
+	// Verify parsed the expiry
+	expires, _ := strconv.ParseInt(r.URL.Query().Get(core.AssetURLExpiresParam), 10, 64)
+	w.Header().Set("Content-Type", metadata.ContentType)
+	// Uploaded SVG images are checked for scripts; should one get through,
+	// it still doesn't run when the image is opened
+	w.Header().Set("Content-Security-Policy", "script-src 'none'")
+	w.Header().Set("Content-Length", strconv.FormatInt(metadata.Size, 10))
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", expires-now.Unix()))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, file); err != nil {
+		// The status is sent; the client sees a truncated file
+		log.Ctx(ctx).Error().Err(err).Str("key", key).Msg("failed to send asset")
+	}
+}
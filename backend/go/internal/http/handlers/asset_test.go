@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// assetService is an AssetService of "test-project-id" with one asset,
+// map.png, recording the duration of the last URL signed
+type assetService struct {
+	ttl time.Duration
+}
+
+func (s *assetService) SignedURL(ctx context.Context, projectID, name string, ttl time.Duration) (string, time.Time, error) {
+	if projectID != "test-project-id" {
+		return "", time.Time{}, core.ErrProjectNotFound
+	}
+	if name != "map.png" {
+		return "", time.Time{}, core.ErrFileNotFound
+	}
+	s.ttl = ttl
+	return "/assets/projects/test-project-id/assets/map.png?expires=1709294400&signature=abc", time.Unix(1709294400, 0).UTC(), nil
+}
+
+func (s *assetService) Download(ctx context.Context, key string) (io.ReadCloser, *core.StorageMetadata, error) {
+	if key != "projects/test-project-id/assets/map.png" {
+		return nil, nil, core.ErrFileNotFound
+	}
+	return io.NopCloser(strings.NewReader("PNG")), &core.StorageMetadata{Key: key, ContentType: "image/png", Size: 3}, nil
+}
+
+func TestAssetHandler_CreateSignedURL(t *testing.T) {
+	tests := []struct {
+		name           string
+		projectID      string
+		asset          string
+		body           string
+		expectedStatus int
+		expectedCode   string
+		expectedTTL    time.Duration
+	}{
+		{"signed", "test-project-id", "map.png", `{"expires_in":600}`, http.StatusOK, "", 10 * time.Minute},
+		{"duration past what time.Duration holds", "test-project-id", "map.png", `{"expires_in":9223372036854775807}`, http.StatusOK, "", time.Duration(maxExpiresIn) * time.Second},
+		{"no duration", "test-project-id", "map.png", `{}`, http.StatusBadRequest, types.ErrorCodeValidationFailed, 0},
+		{"negative duration", "test-project-id", "map.png", `{"expires_in":-1}`, http.StatusBadRequest, types.ErrorCodeValidationFailed, 0},
+		{"unknown project", "missing-project-id", "map.png", `{"expires_in":600}`, http.StatusNotFound, "project_not_found", 0},
+		{"unknown asset", "test-project-id", "chart.png", `{"expires_in":600}`, http.StatusNotFound, "file_not_found", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := &assetService{}
+			handler := NewAssetHandler(service, core.NewAssetURLSigner([]byte("secret")), httpmiddleware.NewValidator())
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/"+tt.projectID+"/assets/"+tt.asset+"/signed-url", strings.NewReader(tt.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", tt.projectID)
+			rctx.URLParams.Add("key", tt.asset)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := newRecorder()
+
+			// Act
+			handler.CreateSignedURL(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedCode != "" {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+				return
+			}
+			var response types.SignedURLResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, "/assets/projects/test-project-id/assets/map.png?expires=1709294400&signature=abc", response.URL)
+			assert.Equal(t, time.Unix(1709294400, 0).UTC(), response.ExpiresAt)
+			assert.Equal(t, tt.expectedTTL, service.ttl)
+			assert.Positive(t, service.ttl)
+		})
+	}
+}
+
+func TestAssetHandler_ServeAsset(t *testing.T) {
+	signer := core.NewAssetURLSigner([]byte("secret"))
+	key := "projects/test-project-id/assets/map.png"
+	now := time.Now()
+
+	tests := []struct {
+		name           string
+		key            string
+		query          string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"signed", key, signer.Sign(key, now.Add(time.Minute)).Encode(), http.StatusOK, ""},
+		{"expired", key, signer.Sign(key, now.Add(-time.Second)).Encode(), http.StatusForbidden, "asset_url_expired"},
+		{"signed for another asset", key, signer.Sign("projects/test-project-id/assets/chart.png", now.Add(time.Minute)).Encode(), http.StatusForbidden, "asset_url_invalid"},
+		{"signed with another secret", key, core.NewAssetURLSigner([]byte("other")).Sign(key, now.Add(time.Minute)).Encode(), http.StatusForbidden, "asset_url_invalid"},
+		{"unsigned", key, "", http.StatusForbidden, "asset_url_invalid"},
+		{"signed but gone", "projects/test-project-id/assets/gone.png", signer.Sign("projects/test-project-id/assets/gone.png", now.Add(time.Minute)).Encode(), http.StatusNotFound, "file_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := NewAssetHandler(&assetService{}, signer, httpmiddleware.NewValidator())
+			req := httptest.NewRequest(http.MethodGet, "/assets/"+tt.key+"?"+tt.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("*", tt.key)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := newRecorder()
+
+			// Act
+			handler.ServeAsset(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedCode != "" {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+				return
+			}
+			assert.Equal(t, "PNG", rr.Body.String())
+			assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+			assert.Equal(t, "script-src 'none'", rr.Header().Get("Content-Security-Policy"))
+			assert.Equal(t, "3", rr.Header().Get("Content-Length"))
+			assert.Regexp(t, `^private, max-age=(59|60)$`, rr.Header().Get("Cache-Control"), "caches keep the asset no longer than the URL lasts")
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/pagination"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/i18n"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// AttemptReportService reports on the attempts at a project to its
+// authors and starts their previews, satisfied by *core.AttemptService
+type AttemptReportService interface {
+	StartPreview(ctx context.Context, projectID, participantID string) (*core.Attempt, error)
+	ListByProject(ctx context.Context, projectID string, filter core.AttemptFilter, limit, offset int) ([]*core.Attempt, int, error)
+	Stats(ctx context.Context, projectID string, filter core.AttemptFilter) (*core.ProjectStats, error)
+}
+
+// ProjectAnalytics aggregates the attempts at a project and the answers to
+// its items, satisfied by *core.AnalyticsService
+type ProjectAnalytics interface {
+	Analytics(ctx context.Context, projectID string, filter core.AttemptFilter, passPercent int) (*core.ProjectAnalytics, error)
+	AnswerDistribution(ctx context.Context, projectID, itemID string, filter core.AttemptFilter, gridSize int) (*core.AnswerDistribution, error)
+}
+
+// AttemptHandler handles the attempt results and analytics of a project
+type AttemptHandler struct {
+	service   AttemptReportService
+	analytics ProjectAnalytics
+}
+
+// NewAttemptHandler creates a new attempt handler
+func NewAttemptHandler(service AttemptReportService) *AttemptHandler {
+	return &AttemptHandler{service: service}
+}
+
+// SetAnalytics sets the analytics GetAnalytics serves
+func (h *AttemptHandler) SetAnalytics(analytics ProjectAnalytics) {
+	h.analytics = analytics
+}
+
+// StartPreview handles POST /api/v1/projects/{projectId}/preview-attempts
+// @Summary Start preview attempt
+// @Description Starts a preview of a project, published or not, by the signed-in author: an attempt at the project's live items as they are when each answer is saved and when it is submitted. The preview is read, answered and submitted like any attempt, through the public attempt endpoints with the author's bearer token, and is_preview flags it. Previews are left out of the project's attempts and stats, and are purged PREVIEW_ATTEMPT_RETENTION after they start, 7 days by default.
+// @Tags Projects
+// @Security BearerAuth
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Success 201 {object} types.AttemptResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/preview-attempts [post]
+func (h *AttemptHandler) StartPreview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := chi.URLParam(r, "projectId")
+
+	attempt, err := h.service.StartPreview(ctx, projectID, httpmiddleware.GetUserID(ctx))
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to start preview attempt")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusCreated, attemptResponse(attempt))
+}
+
+// ListAttempts handles GET /api/v1/projects/{projectId}/attempts
+// @Summary List project attempts
+// @Description Returns a page of the attempts learners made at a project, previews left out, latest first, with their participant, and their score and duration once submitted. from and to keep the attempts started in a window.
+// @Tags Projects
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param from query string false "Keep attempts started at or after this RFC 3339 time"
+// @Param to query string false "Keep attempts started before this RFC 3339 time"
+// @Param limit query int false "Maximum number of attempts to return" minimum(1) maximum(100) default(20)
+// @Param offset query int false "Number of attempts to skip" minimum(0) default(0)
+// @Success 200 {object} types.AttemptListResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_pagination, validation_failed"
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/attempts [get]
+func (h *AttemptHandler) ListAttempts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := chi.URLParam(r, "projectId")
+
+	page, err := pagination.Parse(r, pagination.Page{Limit: 20}, 100)
+	if err != nil {
+		pagination.WriteError(w, err)
+		return
+	}
+	filter, ok := attemptFilter(w, r)
+	if !ok {
+		return
+	}
+
+	attempts, total, err := h.service.ListByProject(ctx, projectID, filter, page.Limit, page.Offset)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to list attempts")
+		respondDomainError(w, err)
+		return
+	}
+
+	response := types.AttemptListResponse{
+		ProjectID: projectID,
+		Attempts:  make([]types.AttemptSummaryResponse, len(attempts)),
+		Total:     total,
+		Limit:     page.Limit,
+		Offset:    page.Offset,
+	}
+	for i, attempt := range attempts {
+		response.Attempts[i] = types.AttemptSummaryResponse{
+			ID:            attempt.ID,
+			Version:       attempt.PublicationVersion,
+			ParticipantID: attempt.ParticipantID,
+			StartedAt:     attempt.StartedAt,
+			SubmittedAt:   attempt.SubmittedAt,
+			Score:         attempt.Score,
+			MaxScore:      attempt.MaxScore,
+		}
+		if attempt.SubmittedAt != nil {
+			seconds := attempt.SubmittedAt.Sub(attempt.StartedAt).Seconds()
+			response.Attempts[i].DurationSeconds = &seconds
+		}
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// GetStats handles GET /api/v1/projects/{projectId}/stats
+// @Summary Get project stats
+// @Description Returns aggregates of the attempts learners made at a project, previews left out: how many started and were submitted, their average score, and for each question graded, in item order, how many submitted attempts answered it, the percentage correct and the average points earned. Choice and multi_choice items also count how often each option was chosen, among options chosen at least once. from and to keep the attempts started in a window. A project without attempts has zero counts and no items.
+// @Tags Projects
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param from query string false "Keep attempts started at or after this RFC 3339 time"
+// @Param to query string false "Keep attempts started before this RFC 3339 time"
+// @Success 200 {object} types.ProjectStatsResponse
+// @Failure 400 {object} types.ErrorResponse "validation_failed"
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/stats [get]
+func (h *AttemptHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := chi.URLParam(r, "projectId")
+
+	filter, ok := attemptFilter(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := h.service.Stats(ctx, projectID, filter)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to get project stats")
+		respondDomainError(w, err)
+		return
+	}
+
+	response := types.ProjectStatsResponse{
+		ProjectID:    projectID,
+		From:         filter.From,
+		To:           filter.To,
+		Attempts:     stats.Attempts,
+		Submitted:    stats.Submitted,
+		AverageScore: stats.AverageScore,
+		Items:        make([]types.ItemStatsResponse, len(stats.Items)),
+	}
+	for i, item := range stats.Items {
+		response.Items[i] = types.ItemStatsResponse{
+			ItemID:         item.ItemID,
+			Answered:       item.Answered,
+			Graded:         item.Graded,
+			PercentCorrect: item.PercentCorrect,
+			AveragePoints:  item.AveragePoints,
+		}
+		for _, choice := range item.Choices {
+			response.Items[i].Choices = append(response.Items[i].Choices, types.ChoiceCountResponse{
+				ChoiceID: choice.ChoiceID,
+				Count:    choice.Count,
+			})
+		}
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// GetAnalytics handles GET /api/v1/projects/{projectId}/analytics
+// @Summary Get project analytics
+// @Description Returns analytics of the attempts learners made at a project, previews left out: how many started and were submitted, the completion rate, the average and median score, the pass rate, the average time from start to submit, the attempts started on each of the 30 days ending on the day the window ends, and how many scores fall in each 10% range. Scores are percentages of each attempt's maximum score, counting submitted attempts with a maximum score; an attempt passes with pass_percent, 50 by default. from and to keep the attempts started in a window. Analytics are cached for a minute, and dropped as soon as an attempt at the project is submitted.
+// @Tags Projects
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param from query string false "Keep attempts started at or after this RFC 3339 time"
+// @Param to query string false "Keep attempts started before this RFC 3339 time"
+// @Param pass_percent query int false "Score, as a percentage of the maximum, that passes an attempt" minimum(0) maximum(100) default(50)
+// @Success 200 {object} types.ProjectAnalyticsResponse
+// @Failure 400 {object} types.ErrorResponse "validation_failed"
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/analytics [get]
+func (h *AttemptHandler) GetAnalytics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := chi.URLParam(r, "projectId")
+
+	filter, ok := attemptFilter(w, r)
+	if !ok {
+		return
+	}
+	passPercent, ok := intParam(w, r, "pass_percent", core.DefaultPassPercent, 0, 100)
+	if !ok {
+		return
+	}
+
+	analytics, err := h.analytics.Analytics(ctx, projectID, filter, passPercent)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to get project analytics")
+		respondDomainError(w, err)
+		return
+	}
+
+	response := types.ProjectAnalyticsResponse{
+		ProjectID:      projectID,
+		From:           filter.From,
+		To:             filter.To,
+		PassPercent:    passPercent,
+		Attempts:       analytics.Attempts,
+		Submitted:      analytics.Submitted,
+		CompletionRate: analytics.CompletionRate,
+		AverageScore:   analytics.AverageScore,
+		MedianScore:    analytics.MedianScore,
+		PassRate:       analytics.PassRate,
+		AttemptsPerDay: make([]types.DailyAttemptsResponse, len(analytics.Daily)),
+		ScoreBuckets:   make([]types.ScoreBucketResponse, len(analytics.ScoreBuckets)),
+	}
+	if analytics.AverageDuration != nil {
+		seconds := analytics.AverageDuration.Seconds()
+		response.AverageDurationSeconds = &seconds
+	}
+	for i, day := range analytics.Daily {
+		response.AttemptsPerDay[i] = types.DailyAttemptsResponse{Date: day.Day.Format(time.DateOnly), Attempts: day.Attempts}
+	}
+	for i, bucket := range analytics.ScoreBuckets {
+		response.ScoreBuckets[i] = types.ScoreBucketResponse{From: bucket.From, To: bucket.To, Count: bucket.Count}
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// GetAnswerDistribution handles GET /api/v1/projects/{projectId}/items/{itemId}/answers/distribution
+// @Summary Get the answer distribution of an item
+// @Description Breaks down the answers to an item given by the submitted attempts at its project, previews left out, in the shape of its type: for choice and multi_choice items how many attempts chose each option, with its text, in item order, then options chosen that the item no longer has; for text_entry items the 20 most common answers, trimmed and lowercased, and how many distinct answers there are; for ordering items the 10 most common orders; for hotspot items the points clicked, counted in a grid of grid by grid cells over the box bounding them. Answers, options and orders are marked correct against the item as it is now. Title and media items have no answers. from and to keep the attempts started in a window. Distributions are cached for a minute, and dropped as soon as an attempt at the project is submitted; the ETag changes with the counts.
+// @Tags Items
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param itemId path string true "Item ID" format(uuid)
+// @Param from query string false "Keep attempts started at or after this RFC 3339 time"
+// @Param to query string false "Keep attempts started before this RFC 3339 time"
+// @Param grid query int false "Cells a side of a hotspot item's heat grid" minimum(1) maximum(100) default(20)
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} types.AnswerDistributionResponse
+// @Success 304 "Not modified"
+// @Failure 400 {object} types.ErrorResponse "validation_failed"
+// @Failure 404 {object} types.ErrorResponse "project_not_found, item_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/{itemId}/answers/distribution [get]
+func (h *AttemptHandler) GetAnswerDistribution(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := chi.URLParam(r, "projectId")
+	itemID := chi.URLParam(r, "itemId")
+
+	filter, ok := attemptFilter(w, r)
+	if !ok {
+		return
+	}
+	gridSize, ok := intParam(w, r, "grid", core.DefaultHeatGridSize, 1, core.MaxHeatGridSize)
+	if !ok {
+		return
+	}
+
+	distribution, err := h.analytics.AnswerDistribution(ctx, projectID, itemID, filter, gridSize)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Str("item_id", itemID).Msg("failed to get answer distribution")
+		respondDomainError(w, err)
+		return
+	}
+	if checkNotModified(w, r, answerDistributionETag(distribution, filter)) {
+		return
+	}
+
+	response := types.AnswerDistributionResponse{
+		ProjectID: projectID,
+		ItemID:    distribution.ItemID,
+		ItemType:  distribution.ItemType,
+		From:      filter.From,
+		To:        filter.To,
+		Answered:  distribution.Answered,
+	}
+	for _, choice := range distribution.Choices {
+		response.Choices = append(response.Choices, types.ChoiceDistributionResponse{
+			ChoiceID: choice.ChoiceID, Text: choice.Text, Correct: choice.Correct, Count: choice.Count,
+		})
+	}
+	if distribution.ItemType == types.ItemTypeTextEntry {
+		response.DistinctTexts = &distribution.DistinctTexts
+	}
+	for _, text := range distribution.Texts {
+		response.Texts = append(response.Texts, types.TextCountResponse{Text: text.Text, Correct: text.Correct, Count: text.Count})
+	}
+	for _, sequence := range distribution.Sequences {
+		response.Sequences = append(response.Sequences, types.SequenceCountResponse{Order: sequence.Order, Correct: sequence.Correct, Count: sequence.Count})
+	}
+	if heat := distribution.Heat; heat != nil {
+		response.Heat = &types.HeatGridResponse{
+			Size: heat.Size, MinX: heat.MinX, MinY: heat.MinY, MaxX: heat.MaxX, MaxY: heat.MaxY, Counts: heat.Counts,
+		}
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// intParam reads an integer query parameter from min to max, def when it
+// is left out. It writes a 400 and returns false if it isn't one.
+func intParam(w http.ResponseWriter, r *http.Request, name string, def, min, max int) (int, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err == nil && value >= min && value <= max {
+		return value, true
+	}
+
+	fieldErr := types.ValidationError{Field: name, Tag: "integer"}
+	if err == nil {
+		fieldErr.Tag, fieldErr.Param = "lte", strconv.Itoa(max)
+		if value < min {
+			fieldErr.Tag, fieldErr.Param = "gte", strconv.Itoa(min)
+		}
+	}
+	fieldErr.Message = i18n.Translate(i18n.DefaultLocale, "validation."+fieldErr.Tag, respond.ValidationParams(fieldErr), fieldErr.Tag)
+	respond.ValidationError(w, []types.ValidationError{fieldErr})
+	return 0, false
+}
+
+// attemptFilter reads the window of attempts to report on from the from and
+// to query parameters, RFC 3339 times with to after from. It writes a
+// validation error and returns false when they are not.
+func attemptFilter(w http.ResponseWriter, r *http.Request) (core.AttemptFilter, bool) {
+	query := r.URL.Query()
+	var filter core.AttemptFilter
+	var fieldErrs []types.ValidationError
+	for _, bound := range []struct {
+		field string
+		time  **time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		raw := query.Get(bound.field)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			fieldErrs = append(fieldErrs, types.ValidationError{Field: bound.field, Tag: "datetime", Param: "RFC3339"})
+			continue
+		}
+		*bound.time = &parsed
+	}
+	if fieldErrs == nil && filter.From != nil && filter.To != nil && !filter.To.After(*filter.From) {
+		fieldErrs = append(fieldErrs, types.ValidationError{Field: "to", Tag: "gtfield", Param: "from"})
+	}
+	if fieldErrs != nil {
+		for i := range fieldErrs {
+			fieldErrs[i].Message = i18n.Translate(i18n.DefaultLocale, "validation."+fieldErrs[i].Tag, respond.ValidationParams(fieldErrs[i]), fieldErrs[i].Tag)
+		}
+		respond.ValidationError(w, fieldErrs)
+		return filter, false
+	}
+	return filter, true
+}
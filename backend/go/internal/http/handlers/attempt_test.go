@@ -0,0 +1,573 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// attemptReports is an AttemptReportService of "test-project-id", with one
+// submitted and one unfinished attempt, or none when empty is set
+type attemptReports struct {
+	empty bool
+
+	// filter is the window the last call asked for
+	filter core.AttemptFilter
+}
+
+func (s *attemptReports) StartPreview(ctx context.Context, projectID, participantID string) (*core.Attempt, error) {
+	if projectID != "test-project-id" {
+		return nil, core.ErrProjectNotFound
+	}
+	return &core.Attempt{
+		ID:            "preview-1",
+		ProjectID:     projectID,
+		ParticipantID: participantID,
+		IsPreview:     true,
+		StartedAt:     time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Answers:       []*core.Answer{},
+	}, nil
+}
+
+func (s *attemptReports) ListByProject(ctx context.Context, projectID string, filter core.AttemptFilter, limit, offset int) ([]*core.Attempt, int, error) {
+	if projectID != "test-project-id" {
+		return nil, 0, core.ErrProjectNotFound
+	}
+	s.filter = filter
+	if s.empty {
+		return []*core.Attempt{}, 0, nil
+	}
+	startedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	submittedAt := startedAt.Add(90 * time.Second)
+	score, maxScore := 2, 3
+	return []*core.Attempt{
+		{ID: "attempt-2", ProjectID: projectID, PublicationVersion: 1, StartedAt: startedAt.Add(time.Hour)},
+		{ID: "attempt-1", ProjectID: projectID, PublicationVersion: 1, ParticipantID: "learner-1", StartedAt: startedAt, SubmittedAt: &submittedAt, Score: &score, MaxScore: &maxScore},
+	}, 7, nil
+}
+
+func (s *attemptReports) Stats(ctx context.Context, projectID string, filter core.AttemptFilter) (*core.ProjectStats, error) {
+	if projectID != "test-project-id" {
+		return nil, core.ErrProjectNotFound
+	}
+	s.filter = filter
+	if s.empty {
+		return &core.ProjectStats{Items: []*core.ItemStats{}}, nil
+	}
+	average := 2.0
+	return &core.ProjectStats{
+		Attempts:     2,
+		Submitted:    1,
+		AverageScore: &average,
+		Items: []*core.ItemStats{
+			{ItemID: "choice", Answered: 1, Graded: 1, PercentCorrect: 100, AveragePoints: 2, Choices: []*core.ChoiceCount{{ChoiceID: "a", Count: 1}}},
+			{ItemID: "text_entry", Graded: 1, Choices: []*core.ChoiceCount{}},
+		},
+	}, nil
+}
+
+func attemptRequest(path, projectID string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/"+projectID+path, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("projectId", projectID)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestAttemptHandler_StartPreview(t *testing.T) {
+	tests := []struct {
+		name           string
+		projectID      string
+		expectedStatus int
+	}{
+		{name: "project of the organization", projectID: "test-project-id", expectedStatus: http.StatusCreated},
+		{name: "unknown project", projectID: "missing-project-id", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := NewAttemptHandler(&attemptReports{})
+			req := attemptRequest("/preview-attempts", tt.projectID)
+			req.Method = http.MethodPost
+			req = req.WithContext(context.WithValue(req.Context(), httpmiddleware.UserIDKey, "author-1"))
+			rr := newRecorder()
+
+			// Act
+			handler.StartPreview(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+			var response types.AttemptResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, "preview-1", response.ID)
+			assert.Equal(t, "author-1", response.ParticipantID, "the author takes the preview")
+			assert.True(t, response.IsPreview)
+			assert.Zero(t, response.Version, "a preview takes the live items")
+		})
+	}
+}
+
+func TestAttemptHandler_ListAttempts(t *testing.T) {
+	// Arrange
+	handler := NewAttemptHandler(&attemptReports{})
+	rr := newRecorder()
+
+	// Act
+	handler.ListAttempts(rr, attemptRequest("/attempts?limit=2&offset=4", "test-project-id"))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response types.AttemptListResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "test-project-id", response.ProjectID)
+	assert.Equal(t, 7, response.Total)
+	assert.Equal(t, 2, response.Limit)
+	assert.Equal(t, 4, response.Offset)
+	require.Len(t, response.Attempts, 2)
+
+	unfinished := response.Attempts[0]
+	assert.Equal(t, "attempt-2", unfinished.ID)
+	assert.Nil(t, unfinished.SubmittedAt)
+	assert.Nil(t, unfinished.DurationSeconds, "an unfinished attempt has no duration yet")
+	assert.Nil(t, unfinished.Score)
+
+	submitted := response.Attempts[1]
+	assert.Equal(t, "learner-1", submitted.ParticipantID)
+	require.NotNil(t, submitted.DurationSeconds)
+	assert.Equal(t, 90.0, *submitted.DurationSeconds)
+	assert.Equal(t, 2, *submitted.Score)
+	assert.Equal(t, 3, *submitted.MaxScore)
+}
+
+func TestAttemptHandler_ListAttempts_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		projectID      string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"unknown project", "/attempts", "missing-project-id", http.StatusNotFound, "project_not_found"},
+		{"limit over the maximum", "/attempts?limit=101", "test-project-id", http.StatusBadRequest, "invalid_pagination"},
+		{"malformed from", "/attempts?from=yesterday", "test-project-id", http.StatusBadRequest, types.ErrorCodeValidationFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := NewAttemptHandler(&attemptReports{})
+			rr := newRecorder()
+
+			// Act
+			handler.ListAttempts(rr, attemptRequest(tt.path, tt.projectID))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+		})
+	}
+}
+
+func TestAttemptHandler_GetStats(t *testing.T) {
+	// Arrange
+	service := &attemptReports{}
+	handler := NewAttemptHandler(service)
+	rr := newRecorder()
+
+	// Act
+	handler.GetStats(rr, attemptRequest("/stats?from=2024-03-01T00:00:00Z&to=2024-03-02T00:00:00%2B02:00", "test-project-id"))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	require.NotNil(t, service.filter.From)
+	require.NotNil(t, service.filter.To)
+	assert.True(t, service.filter.From.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, service.filter.To.Equal(time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)))
+
+	var response types.ProjectStatsResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "test-project-id", response.ProjectID)
+	assert.Equal(t, 2, response.Attempts)
+	assert.Equal(t, 1, response.Submitted)
+	assert.Equal(t, 2.0, *response.AverageScore)
+	assert.Equal(t, []types.ItemStatsResponse{
+		{ItemID: "choice", Answered: 1, Graded: 1, PercentCorrect: 100, AveragePoints: 2, Choices: []types.ChoiceCountResponse{{ChoiceID: "a", Count: 1}}},
+		{ItemID: "text_entry", Graded: 1},
+	}, response.Items)
+}
+
+func TestAttemptHandler_GetStats_NoAttempts(t *testing.T) {
+	// Arrange
+	handler := NewAttemptHandler(&attemptReports{empty: true})
+	rr := newRecorder()
+
+	// Act
+	handler.GetStats(rr, attemptRequest("/stats", "test-project-id"))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"project_id":"test-project-id","attempts":0,"submitted":0,"items":[]}`, rr.Body.String())
+}
+
+func TestAttemptHandler_GetStats_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		projectID      string
+		expectedStatus int
+		expectedFields map[string]string
+	}{
+		{
+			name:           "unknown project",
+			path:           "/stats",
+			projectID:      "missing-project-id",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "malformed bounds",
+			path:           "/stats?from=2024-03-01&to=soon",
+			projectID:      "test-project-id",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: map[string]string{"from": "datetime", "to": "datetime"},
+		},
+		{
+			name:           "to not after from",
+			path:           "/stats?from=2024-03-01T00:00:00Z&to=2024-03-01T00:00:00Z",
+			projectID:      "test-project-id",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: map[string]string{"to": "gtfield"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := NewAttemptHandler(&attemptReports{})
+			rr := newRecorder()
+
+			// Act
+			handler.GetStats(rr, attemptRequest(tt.path, tt.projectID))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedFields == nil {
+				assertErrorResponse(t, rr.Body.Bytes(), "project_not_found")
+				return
+			}
+			assert.Equal(t, tt.expectedFields, assertValidationErrors(t, rr.Body.Bytes()))
+		})
+	}
+}
+
+// projectAnalytics is a ProjectAnalytics of "test-project-id", with the
+// choice item "test-item-id", recording the last query
+type projectAnalytics struct {
+	filter      core.AttemptFilter
+	passPercent int
+	gridSize    int
+}
+
+func (a *projectAnalytics) Analytics(ctx context.Context, projectID string, filter core.AttemptFilter, passPercent int) (*core.ProjectAnalytics, error) {
+	if projectID != "test-project-id" {
+		return nil, core.ErrProjectNotFound
+	}
+	a.filter, a.passPercent = filter, passPercent
+	rate, average, median, duration := 75.0, 62.5, 60.0, 90*time.Second
+	return &core.ProjectAnalytics{
+		Attempts:        4,
+		Submitted:       3,
+		CompletionRate:  &rate,
+		AverageScore:    &average,
+		MedianScore:     &median,
+		PassRate:        &rate,
+		AverageDuration: &duration,
+		Daily: []*core.DailyAttempts{
+			{Day: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Attempts: 1},
+			{Day: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), Attempts: 3},
+		},
+		ScoreBuckets: []*core.ScoreBucket{{From: 0, To: 10, Count: 0}, {From: 10, To: 20, Count: 3}},
+	}, nil
+}
+
+func TestAttemptHandler_GetAnalytics(t *testing.T) {
+	// Arrange
+	analytics := &projectAnalytics{}
+	handler := NewAttemptHandler(&attemptReports{})
+	handler.SetAnalytics(analytics)
+	rr := newRecorder()
+
+	// Act
+	handler.GetAnalytics(rr, attemptRequest("/analytics?from=2024-03-01T00:00:00Z&pass_percent=80", "test-project-id"))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	require.NotNil(t, analytics.filter.From)
+	assert.Nil(t, analytics.filter.To)
+	assert.Equal(t, 80, analytics.passPercent)
+	assert.JSONEq(t, `{
+		"project_id": "test-project-id",
+		"from": "2024-03-01T00:00:00Z",
+		"pass_percent": 80,
+		"attempts": 4,
+		"submitted": 3,
+		"completion_rate": 75,
+		"average_score": 62.5,
+		"median_score": 60,
+		"pass_rate": 75,
+		"average_duration_seconds": 90,
+		"attempts_per_day": [{"date": "2024-03-01", "attempts": 1}, {"date": "2024-03-02", "attempts": 3}],
+		"score_buckets": [{"from": 0, "to": 10, "count": 0}, {"from": 10, "to": 20, "count": 3}]
+	}`, rr.Body.String())
+}
+
+func TestAttemptHandler_GetAnalytics_DefaultPassPercent(t *testing.T) {
+	// Arrange
+	analytics := &projectAnalytics{}
+	handler := NewAttemptHandler(&attemptReports{})
+	handler.SetAnalytics(analytics)
+	rr := newRecorder()
+
+	// Act
+	handler.GetAnalytics(rr, attemptRequest("/analytics", "test-project-id"))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, core.DefaultPassPercent, analytics.passPercent)
+}
+
+func TestAttemptHandler_GetAnalytics_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		projectID      string
+		expectedStatus int
+		expectedFields map[string]string
+	}{
+		{
+			name:           "unknown project",
+			path:           "/analytics",
+			projectID:      "missing-project-id",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "to not after from",
+			path:           "/analytics?from=2024-03-01T00:00:00Z&to=2024-02-01T00:00:00Z",
+			projectID:      "test-project-id",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: map[string]string{"to": "gtfield"},
+		},
+		{
+			name:           "pass percent not a number",
+			path:           "/analytics?pass_percent=half",
+			projectID:      "test-project-id",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: map[string]string{"pass_percent": "integer"},
+		},
+		{
+			name:           "pass percent below 0",
+			path:           "/analytics?pass_percent=-1",
+			projectID:      "test-project-id",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: map[string]string{"pass_percent": "gte"},
+		},
+		{
+			name:           "pass percent above 100",
+			path:           "/analytics?pass_percent=101",
+			projectID:      "test-project-id",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: map[string]string{"pass_percent": "lte"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := NewAttemptHandler(&attemptReports{})
+			handler.SetAnalytics(&projectAnalytics{})
+			rr := newRecorder()
+
+			// Act
+			handler.GetAnalytics(rr, attemptRequest(tt.path, tt.projectID))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedFields == nil {
+				assertErrorResponse(t, rr.Body.Bytes(), "project_not_found")
+				return
+			}
+			assert.Equal(t, tt.expectedFields, assertValidationErrors(t, rr.Body.Bytes()))
+		})
+	}
+}
+
+func (a *projectAnalytics) AnswerDistribution(ctx context.Context, projectID, itemID string, filter core.AttemptFilter, gridSize int) (*core.AnswerDistribution, error) {
+	if projectID != "test-project-id" {
+		return nil, core.ErrProjectNotFound
+	}
+	if itemID != "test-item-id" {
+		return nil, core.ErrItemNotFound
+	}
+	a.filter, a.gridSize = filter, gridSize
+	sirius := "Sirius"
+	return &core.AnswerDistribution{
+		ItemID:   itemID,
+		ItemType: types.ItemTypeChoice,
+		Answered: 3,
+		Choices: []*core.ChoiceDistribution{
+			{ChoiceID: "a", Text: &sirius, Correct: true, Count: 2},
+			{ChoiceID: "z", Count: 1},
+		},
+	}, nil
+}
+
+func distributionRequest(projectID, itemID, query string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/"+projectID+"/items/"+itemID+"/answers/distribution"+query, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("projectId", projectID)
+	rctx.URLParams.Add("itemId", itemID)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestAttemptHandler_GetAnswerDistribution(t *testing.T) {
+	// Arrange
+	analytics := &projectAnalytics{}
+	handler := NewAttemptHandler(&attemptReports{})
+	handler.SetAnalytics(analytics)
+	rr := newRecorder()
+
+	// Act
+	handler.GetAnswerDistribution(rr, distributionRequest("test-project-id", "test-item-id", "?to=2024-03-01T00:00:00Z"))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	require.NotNil(t, analytics.filter.To)
+	assert.Equal(t, core.DefaultHeatGridSize, analytics.gridSize)
+	assert.NotEmpty(t, rr.Header().Get("ETag"))
+	assert.Equal(t, "private, no-cache", rr.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{
+		"project_id": "test-project-id",
+		"item_id": "test-item-id",
+		"item_type": "choice",
+		"to": "2024-03-01T00:00:00Z",
+		"answered": 3,
+		"choices": [
+			{"choice_id": "a", "text": "Sirius", "correct": true, "count": 2},
+			{"choice_id": "z", "correct": false, "count": 1}
+		]
+	}`, rr.Body.String())
+}
+
+func TestAttemptHandler_GetAnswerDistribution_NotModified(t *testing.T) {
+	// Arrange
+	handler := NewAttemptHandler(&attemptReports{})
+	handler.SetAnalytics(&projectAnalytics{})
+	first := newRecorder()
+	handler.GetAnswerDistribution(first, distributionRequest("test-project-id", "test-item-id", ""))
+	req := distributionRequest("test-project-id", "test-item-id", "")
+	req.Header.Set("If-None-Match", first.Header().Get("ETag"))
+	windowed := distributionRequest("test-project-id", "test-item-id", "?from=2024-03-01T00:00:00Z")
+	windowed.Header.Set("If-None-Match", first.Header().Get("ETag"))
+	rr, windowedRR := newRecorder(), newRecorder()
+
+	// Act
+	handler.GetAnswerDistribution(rr, req)
+	handler.GetAnswerDistribution(windowedRR, windowed)
+
+	// Assert
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+	assert.Equal(t, http.StatusOK, windowedRR.Code, "another window is another representation")
+}
+
+func TestAttemptHandler_GetAnswerDistribution_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		projectID      string
+		itemID         string
+		query          string
+		expectedStatus int
+		expectedCode   string
+		expectedFields map[string]string
+	}{
+		{
+			name:           "unknown project",
+			projectID:      "missing-project-id",
+			itemID:         "test-item-id",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "project_not_found",
+		},
+		{
+			name:           "unknown item",
+			projectID:      "test-project-id",
+			itemID:         "missing-item-id",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "item_not_found",
+		},
+		{
+			name:           "malformed bound",
+			projectID:      "test-project-id",
+			itemID:         "test-item-id",
+			query:          "?from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: map[string]string{"from": "datetime"},
+		},
+		{
+			name:           "grid not a number",
+			projectID:      "test-project-id",
+			itemID:         "test-item-id",
+			query:          "?grid=fine",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: map[string]string{"grid": "integer"},
+		},
+		{
+			name:           "grid of no cells",
+			projectID:      "test-project-id",
+			itemID:         "test-item-id",
+			query:          "?grid=0",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: map[string]string{"grid": "gte"},
+		},
+		{
+			name:           "grid too fine",
+			projectID:      "test-project-id",
+			itemID:         "test-item-id",
+			query:          "?grid=101",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: map[string]string{"grid": "lte"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := NewAttemptHandler(&attemptReports{})
+			handler.SetAnalytics(&projectAnalytics{})
+			rr := newRecorder()
+
+			// Act
+			handler.GetAnswerDistribution(rr, distributionRequest(tt.projectID, tt.itemID, tt.query))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedFields == nil {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+				return
+			}
+			assert.Equal(t, tt.expectedFields, assertValidationErrors(t, rr.Body.Bytes()))
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/websocket"
+
+	"github.com/provemyself/backend/internal/collab"
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/respond"
+)
+
+// CollabHub relays the editing sessions, satisfied by *collab.Hub
+type CollabHub interface {
+	Serve(ctx context.Context, projectID string, conn collab.Transport) error
+}
+
+// CollabOptions tune the collaboration WebSocket
+type CollabOptions struct {
+	// AllowOrigin reports whether a browser origin may connect; requests
+	// without an Origin header come from other clients and are let through
+	AllowOrigin func(origin string) bool
+	// MaxMessageBytes caps a single message; larger ones close the
+	// connection
+	MaxMessageBytes int
+	// WriteTimeout disconnects a peer that takes longer to accept a message
+	WriteTimeout time.Duration
+}
+
+// CollabHandler handles GET /api/v1/projects/{projectId}/collab
+type CollabHandler struct {
+	projects ProjectService
+	hub      CollabHub
+	// enabled reports the enable_collaboration setting
+	enabled func() bool
+	opts    CollabOptions
+}
+
+// NewCollabHandler creates a new collaboration handler
+func NewCollabHandler(projects ProjectService, hub CollabHub, enabled func() bool, opts CollabOptions) *CollabHandler {
+	return &CollabHandler{
+		projects: projects,
+		hub:      hub,
+		enabled:  enabled,
+		opts:     opts,
+	}
+}
+
+// Collab handles GET /api/v1/projects/{projectId}/collab
+// @Summary Join a project's collaborative editing session
+// @Description Upgrades to a WebSocket speaking the y-websocket protocol (binary sync step 1, sync step 2, update and awareness messages), so Yjs clients editing the same project see each other's changes and presence. The caller must be able to read the project. The document is saved periodically and when the last editor leaves; presence is never saved. Every editor of a project must reach the same replica.
+// @Tags Projects
+// @Security BearerAuth
+// @Param projectId path string true "Project ID"
+// @Success 101 "Switching protocols"
+// @Failure 400 {object} types.ErrorResponse "missing_project_id"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "org_access_denied"
+// @Failure 404 {object} types.ErrorResponse "project_not_found, collaboration_disabled"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Router /api/v1/projects/{projectId}/collab [get]
+func (h *CollabHandler) Collab(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !h.enabled() {
+		respondDomainError(w, core.ErrCollaborationDisabled)
+		return
+	}
+
+	projectID := chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
+	}
+
+	if _, err := h.projects.GetByID(ctx, projectID); err != nil {
+		if !errors.Is(err, core.ErrProjectNotFound) {
+			log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to get project")
+		}
+		respondDomainError(w, err)
+		return
+	}
+
+	server := websocket.Server{
+		Handshake: h.checkOrigin,
+		Handler: func(conn *websocket.Conn) {
+			conn.PayloadType = websocket.BinaryFrame
+			conn.MaxPayloadBytes = h.opts.MaxMessageBytes
+
+			transport := &websocketTransport{conn: conn, writeTimeout: h.opts.WriteTimeout}
+			if err := h.hub.Serve(ctx, projectID, transport); err != nil {
+				log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to join collaboration session")
+			}
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// checkOrigin rejects browsers on origins outside the allowed list, which
+// could otherwise ride on a user's credentials
+func (h *CollabHandler) checkOrigin(config *websocket.Config, r *http.Request) error {
+	parsed, err := websocket.Origin(config, r)
+	if err != nil || parsed == nil {
+		return err
+	}
+	if h.opts.AllowOrigin(parsed.Scheme + "://" + parsed.Host) {
+		return nil
+	}
+	return errors.New("origin not allowed")
+}
+
+// websocketTransport carries y-websocket messages as binary frames
+type websocketTransport struct {
+	conn         *websocket.Conn
+	writeTimeout time.Duration
+	closeOnce    sync.Once
+}
+
+func (t *websocketTransport) Read(timeout time.Duration) ([]byte, error) {
+	if err := t.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	var message []byte
+	err := websocket.Message.Receive(t.conn, &message)
+	return message, err
+}
+
+func (t *websocketTransport) Write(message []byte) error {
+	if err := t.conn.SetWriteDeadline(time.Now().Add(t.writeTimeout)); err != nil {
+		return err
+	}
+	return websocket.Message.Send(t.conn, message)
+}
+
+// Close wakes a blocked Read at once and gives a blocked Write a moment
+// before sending the close frame
+func (t *websocketTransport) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		now := time.Now()
+		t.conn.SetReadDeadline(now)
+		t.conn.SetWriteDeadline(now.Add(time.Second))
+		err = t.conn.Close()
+	})
+	return err
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+
+	"github.com/provemyself/backend/internal/collab"
+	"github.com/provemyself/backend/internal/core"
+)
+
+// echoHub answers every message with the project ID followed by the message
+type echoHub struct {
+	joined chan string
+}
+
+func (h *echoHub) Serve(ctx context.Context, projectID string, conn collab.Transport) error {
+	defer conn.Close()
+	h.joined <- projectID
+	for {
+		message, err := conn.Read(time.Second)
+		if err != nil {
+			return nil
+		}
+		if err := conn.Write(append([]byte(projectID+":"), message...)); err != nil {
+			return nil
+		}
+	}
+}
+
+// newCollabTestServer serves the handler on a test server
+func newCollabTestServer(t *testing.T, projects ProjectService, hub CollabHub, enabled bool) *httptest.Server {
+	t.Helper()
+	handler := NewCollabHandler(projects, hub, func() bool { return enabled }, CollabOptions{
+		AllowOrigin:     func(origin string) bool { return origin == "http://localhost:3000" },
+		MaxMessageBytes: 64,
+		WriteTimeout:    time.Second,
+	})
+	r := chi.NewRouter()
+	r.Get("/api/v1/projects/{projectId}/collab", handler.Collab)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// dialCollab opens the project's collaboration socket from origin
+func dialCollab(server *httptest.Server, projectID, origin string) (*websocket.Conn, error) {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/projects/" + projectID + "/collab"
+	config, err := websocket.NewConfig(url, origin)
+	if err != nil {
+		return nil, err
+	}
+	return websocket.DialConfig(config)
+}
+
+func TestCollabHandler_Collab(t *testing.T) {
+	// Arrange
+	projects := new(MockProjectService)
+	projects.On("GetByID", mock.Anything, "p1").Return(&core.Project{ID: "p1"}, nil)
+	hub := &echoHub{joined: make(chan string, 1)}
+	server := newCollabTestServer(t, projects, hub, true)
+
+	// Act
+	conn, err := dialCollab(server, "p1", "http://localhost:3000")
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, websocket.Message.Send(conn, []byte{0, 2, 1, 7}))
+	var reply []byte
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	err = websocket.Message.Receive(conn, &reply)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "p1", <-hub.joined)
+	assert.Equal(t, append([]byte("p1:"), 0, 2, 1, 7), reply)
+	projects.AssertExpectations(t)
+}
+
+func TestCollabHandler_Collab_ClosesOnOversizedMessages(t *testing.T) {
+	// Arrange
+	projects := new(MockProjectService)
+	projects.On("GetByID", mock.Anything, "p1").Return(&core.Project{ID: "p1"}, nil)
+	hub := &echoHub{joined: make(chan string, 1)}
+	server := newCollabTestServer(t, projects, hub, true)
+	conn, err := dialCollab(server, "p1", "http://localhost:3000")
+	require.NoError(t, err)
+	defer conn.Close()
+	<-hub.joined
+
+	// Act
+	require.NoError(t, websocket.Message.Send(conn, make([]byte, 65)))
+	var reply []byte
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	err = websocket.Message.Receive(conn, &reply)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Empty(t, reply)
+}
+
+func TestCollabHandler_Collab_RejectsOtherOrigins(t *testing.T) {
+	// Arrange
+	projects := new(MockProjectService)
+	projects.On("GetByID", mock.Anything, "p1").Return(&core.Project{ID: "p1"}, nil)
+	hub := &echoHub{joined: make(chan string, 1)}
+	server := newCollabTestServer(t, projects, hub, true)
+
+	// Act
+	_, err := dialCollab(server, "p1", "https://attacker.example")
+
+	// Assert
+	require.Error(t, err)
+	assert.Empty(t, hub.joined)
+}
+
+func TestCollabHandler_Collab_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		enabled      bool
+		err          error
+		expectedCode string
+	}{
+		{name: "collaboration disabled", enabled: false, expectedCode: "collaboration_disabled"},
+		{name: "project not visible", enabled: true, err: core.ErrProjectNotFound, expectedCode: "project_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			projects := new(MockProjectService)
+			projects.On("GetByID", mock.Anything, "p1").Return(nil, tt.err).Maybe()
+			handler := NewCollabHandler(projects, &echoHub{}, func() bool { return tt.enabled }, CollabOptions{})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/p1/collab", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "p1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := newRecorder()
+
+			// Act
+			handler.Collab(rr, req)
+
+			// Assert
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+		})
+	}
+}
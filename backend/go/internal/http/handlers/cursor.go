@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// projectCursor is what a project list's next_cursor encodes: the last
+// project of a page, by the keys of every sort, and the sort it was listed
+// in. Clients pass it back as is.
+type projectCursor struct {
+	Sort      core.ProjectSort `json:"sort"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	Title     string           `json:"title"`
+	ID        string           `json:"id"`
+}
+
+// encodeProjectCursor returns the next_cursor of a page listed in sort
+// whose last project is last
+func encodeProjectCursor(sort core.ProjectSort, last *core.Project) string {
+	after := core.CursorAfter(last)
+	data, _ := json.Marshal(projectCursor{
+		Sort:      sort,
+		CreatedAt: after.CreatedAt,
+		UpdatedAt: after.UpdatedAt,
+		Title:     after.Title,
+		ID:        after.ID,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeProjectCursor returns the position a cursor encodes. It fails for
+// anything encodeProjectCursor would not have returned, and for cursors of
+// another sort than the list's, whose keys would be compared wrongly.
+func decodeProjectCursor(raw string, sort core.ProjectSort) (*core.ProjectCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errors.New("the cursor is not base64url")
+	}
+	var cursor projectCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errors.New("the cursor is not one this API returned")
+	}
+	if cursor.ID == "" {
+		return nil, errors.New("the cursor names no project")
+	}
+	if cursor.Sort != sort {
+		return nil, errors.New("the cursor belongs to a list sorted differently")
+	}
+	return &core.ProjectCursor{
+		CreatedAt: cursor.CreatedAt,
+		UpdatedAt: cursor.UpdatedAt,
+		Title:     cursor.Title,
+		ID:        cursor.ID,
+	}, nil
+}
+
+// parseProjectCursor reads the cursor parameter of a project list sorted
+// by sort, nil when there is none. For a malformed cursor it writes a 400
+// invalid_cursor and returns false.
+func parseProjectCursor(w http.ResponseWriter, r *http.Request, sort core.ProjectSort) (*core.ProjectCursor, bool) {
+	raw := r.URL.Query().Get("cursor")
+	if raw == "" {
+		return nil, true
+	}
+	cursor, err := decodeProjectCursor(raw, sort)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, types.ErrorCodeInvalidCursor, "Invalid cursor", err.Error())
+		return nil, false
+	}
+	return cursor, true
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// docsPage renders the specification served at /openapi.json with Redoc
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>ProveMySelf API</title>
+</head>
+<body>
+  <redoc spec-url="/openapi.json"></redoc>
+  <script src="https://cdn.jsdelivr.net/npm/redoc@2.1.5/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+// DocsHandler serves the OpenAPI specification and an interactive reference
+type DocsHandler struct {
+	spec []byte
+}
+
+// NewDocsHandler creates a new docs handler for a generated specification
+func NewDocsHandler(spec []byte) *DocsHandler {
+	return &DocsHandler{spec: spec}
+}
+
+// GetSpec handles GET /openapi.json
+func (h *DocsHandler) GetSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(h.spec); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("failed to write OpenAPI spec")
+	}
+}
+
+// GetDocs handles GET /docs
+func (h *DocsHandler) GetDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := w.Write([]byte(docsPage)); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("failed to write API docs page")
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocsHandler_GetSpec(t *testing.T) {
+	// Arrange
+	spec := []byte(`{"swagger":"2.0"}`)
+	handler := NewDocsHandler(spec)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.GetSpec(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(spec), w.Body.String())
+}
+
+func TestDocsHandler_GetDocs(t *testing.T) {
+	// Arrange
+	handler := NewDocsHandler(nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.GetDocs(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), `spec-url="/openapi.json"`)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/provemyself/backend/internal/breaker"
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/jobs"
+	"github.com/provemyself/backend/internal/types"
+	"github.com/provemyself/backend/internal/xapi"
+)
+
+// Every core sentinel error that can reach a handler is registered here
+// once, so handlers never switch over errors.Is themselves
+func init() {
+	types.RegisterDomainError(core.ErrProjectNotFound, types.ErrProjectNotFound)
+	types.RegisterDomainError(core.ErrProjectTitleTooShort, types.ErrProjectTitleTooShort)
+	types.RegisterDomainError(core.ErrProjectTitleTooLong, types.ErrProjectTitleTooLong)
+	types.RegisterDomainError(core.ErrProjectQuotaExceeded, types.ErrProjectQuotaExceeded)
+	types.RegisterDomainError(core.ErrProjectNotPublished, types.ErrProjectNotPublished)
+	types.RegisterDomainError(core.ErrProjectNotPublishable, types.ErrProjectNotPublishable)
+	types.RegisterDomainError(core.ErrPublicationNotFound, types.ErrPublicationNotFound)
+	types.RegisterDomainError(core.ErrInvalidResultsPolicy, types.ErrInvalidResultsPolicy)
+	types.RegisterDomainError(core.ErrResultsAvailableAtRequired, types.ErrResultsAvailableAtRequired)
+
+	types.RegisterDomainError(core.ErrItemNotFound, types.ErrItemNotFound)
+	types.RegisterDomainError(core.ErrItemTitleTooShort, types.ErrItemTitleTooShort)
+	types.RegisterDomainError(core.ErrItemTitleTooLong, types.ErrItemTitleTooLong)
+	types.RegisterDomainError(core.ErrItemInvalidType, types.ErrItemInvalidType)
+	types.RegisterDomainError(core.ErrItemInvalidPosition, types.ErrItemInvalidPosition)
+	types.RegisterDomainError(core.ErrItemDuplicatePositions, types.ErrItemDuplicatePositions)
+	types.RegisterDomainError(core.ErrItemInvalidContent, types.ErrItemInvalidContent)
+	types.RegisterDomainError(core.ErrItemLocked, types.ErrItemLocked)
+	types.RegisterDomainError(core.ErrItemLockNotHeld, types.ErrItemLockNotHeld)
+	types.RegisterDomainError(core.ErrItemRevisionNotFound, types.ErrItemRevisionNotFound)
+
+	types.RegisterDomainError(core.ErrAttemptNotFound, types.ErrAttemptNotFound)
+	types.RegisterDomainError(core.ErrAttemptAlreadySubmitted, types.ErrAttemptAlreadySubmitted)
+	types.RegisterDomainError(core.ErrInvalidAnswer, types.ErrInvalidAnswer)
+	types.RegisterDomainError(core.ErrStaleAnswer, types.ErrStaleAnswer)
+	types.RegisterDomainError(core.ErrParticipantAccessDenied, types.ErrResourceAccessDenied)
+
+	types.RegisterDomainError(core.ErrFileNotFound, types.ErrFileNotFound)
+	types.RegisterDomainError(core.ErrFileTooBig, types.ErrFileTooBig)
+	types.RegisterDomainError(core.ErrInvalidFileType, types.ErrInvalidFileType)
+	types.RegisterDomainError(core.ErrStorageUnavailable, types.ErrStorageUnavailable)
+	types.RegisterDomainError(core.ErrAssetURLInvalid, types.ErrAssetURLInvalid)
+	types.RegisterDomainError(core.ErrAssetURLExpired, types.ErrAssetURLExpired)
+
+	types.RegisterDomainError(core.ErrOrganizationNotFound, types.ErrOrganizationNotFound)
+	types.RegisterDomainError(core.ErrMembershipNotFound, types.ErrMembershipNotFound)
+
+	types.RegisterDomainError(core.ErrConcurrentModification, types.ErrConcurrentModification)
+	types.RegisterDomainError(core.ErrVersionConflict, types.ErrVersionConflict)
+
+	types.RegisterDomainError(core.ErrCollaborationDisabled, types.ErrCollaborationDisabled)
+
+	types.RegisterDomainError(core.ErrLTIDisabled, types.ErrLTIDisabled)
+	types.RegisterDomainError(core.ErrLTIPlatformNotFound, types.ErrLTIPlatformNotFound)
+	types.RegisterDomainError(core.ErrLTIPlatformExists, types.ErrLTIPlatformExists)
+	types.RegisterDomainError(core.ErrLTIInvalidLaunch, types.ErrLTIInvalidLaunch)
+	types.RegisterDomainError(core.ErrLTIResourceLinkNotMapped, types.ErrLTIResourceLinkNotMapped)
+	types.RegisterDomainError(core.ErrLTISessionNotFound, types.ErrLTISessionNotFound)
+
+	types.RegisterDomainError(core.ErrWebhookNotFound, types.ErrWebhookNotFound)
+	types.RegisterDomainError(core.ErrWebhookDeliveryNotFound, types.ErrWebhookDeliveryNotFound)
+	types.RegisterDomainError(core.ErrWebhookQueueFull, types.ErrWebhookQueueFull)
+
+	types.RegisterDomainError(core.ErrImportInvalidPackage, types.ErrImportInvalidPackage)
+	types.RegisterDomainError(core.ErrExportUnsupportedItem, types.ErrExportUnsupportedItem)
+
+	types.RegisterDomainError(jobs.ErrJobNotFound, types.ErrJobNotFound)
+	types.RegisterDomainError(jobs.ErrJobRunning, types.ErrJobRunning)
+	types.RegisterDomainError(jobs.ErrNotRunning, types.ErrSchedulerNotRunning)
+
+	types.RegisterDomainError(xapi.ErrLRSNotConfigured, types.ErrLRSNotConfigured)
+
+	// The route's Timeout middleware expired while the store was working
+	types.RegisterDomainError(context.DeadlineExceeded, types.ErrGatewayTimeout)
+}
+
+// respondDomainError writes the API error registered for err, falling back
+// to a 500 internal_error for unregistered errors. Errors from an open
+// circuit breaker also tell the client when to retry, locked items name
+// their lock's holder and when it expires, and projects that cannot be
+// published list their problems.
+func respondDomainError(w http.ResponseWriter, err error) {
+	apiErr := types.MapDomainError(err)
+	details := apiErr.Details
+
+	var openErr *breaker.OpenError
+	if errors.As(err, &openErr) {
+		setRetryAfter(w, openErr.RetryAfter)
+	}
+	var lockedErr *core.ItemLockedError
+	if errors.As(err, &lockedErr) {
+		setRetryAfter(w, time.Until(lockedErr.Lock.ExpiresAt))
+		details = fmt.Sprintf("held by %s until %s", lockedErr.Lock.HolderID, lockedErr.Lock.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	// Say what is wrong with the package or the answer, or which item
+	// can't be exported
+	for _, described := range []error{core.ErrImportInvalidPackage, core.ErrExportUnsupportedItem, core.ErrInvalidAnswer} {
+		if errors.Is(err, described) {
+			details = strings.TrimPrefix(err.Error(), described.Error()+": ")
+		}
+	}
+
+	var notPublishableErr *core.ProjectNotPublishableError
+	if errors.As(err, &notPublishableErr) {
+		problems := make([]types.PublishProblem, len(notPublishableErr.Problems))
+		for i, problem := range notPublishableErr.Problems {
+			problems[i] = types.PublishProblem{ItemID: problem.ItemID, Reason: problem.Reason}
+		}
+		respond.ProblemsError(w, apiErr.StatusCode, apiErr.Code, apiErr.Message, problems, details)
+		return
+	}
+
+	respond.Error(w, apiErr.StatusCode, apiErr.Code, apiErr.Message, details)
+}
+
+// respondItemError writes the error of the item that failed a request for
+// several items with the item's index, coded as the item's error alone
+// would be. The status is 422: the request as a whole is well-formed. It
+// writes nothing, and returns false, unless the item was at fault.
+func respondItemError(w http.ResponseWriter, err *core.ItemBatchError) bool {
+	apiErr := types.MapDomainError(err.Err)
+	if apiErr.StatusCode < 400 || apiErr.StatusCode >= 500 {
+		return false
+	}
+	respond.ItemError(w, http.StatusUnprocessableEntity, err.Index, apiErr.Code, apiErr.Message, err.Err.Error())
+	return true
+}
+
+// setRetryAfter sets the Retry-After header to after, in whole seconds of
+// at least one
+func setRetryAfter(w http.ResponseWriter, after time.Duration) {
+	seconds := int(math.Ceil(after.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/breaker"
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+func TestMapDomainError_RegisteredSentinels(t *testing.T) {
+	registered := types.RegisteredDomainErrors()
+	require.NotEmpty(t, registered)
+
+	for sentinel, expected := range registered {
+		t.Run(sentinel.Error(), func(t *testing.T) {
+			tests := []struct {
+				name string
+				err  error
+			}{
+				{"sentinel", sentinel},
+				{"wrapped", fmt.Errorf("failed to load: %w", sentinel)},
+				{"double wrapped", fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", sentinel))},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					// Act
+					apiErr := types.MapDomainError(tt.err)
+
+					// Assert
+					assert.Same(t, expected, apiErr)
+					assert.NotEqual(t, types.ErrorCodeInternalError, apiErr.Code)
+				})
+			}
+		})
+	}
+}
+
+func TestMapDomainError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedCode   string
+		expectedStatus int
+	}{
+		{"item not found", fmt.Errorf("load item: %w", core.ErrItemNotFound), "item_not_found", http.StatusNotFound},
+		{"project title too short", core.ErrProjectTitleTooShort, "title_too_short", http.StatusUnprocessableEntity},
+		{"file too big", core.ErrFileTooBig, "file_too_big", http.StatusRequestEntityTooLarge},
+		{"unregistered error", errors.New("connection reset"), "internal_error", http.StatusInternalServerError},
+		{"api error passes through", types.ErrForbidden, "forbidden", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			apiErr := types.MapDomainError(tt.err)
+
+			// Assert
+			assert.Equal(t, tt.expectedCode, apiErr.Code)
+			assert.Equal(t, tt.expectedStatus, apiErr.StatusCode)
+		})
+	}
+}
+
+func TestRespondDomainError(t *testing.T) {
+	// Arrange
+	rr := newRecorder()
+
+	// Act
+	respondDomainError(rr, fmt.Errorf("update item: %w", core.ErrItemInvalidPosition))
+
+	// Assert
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assertErrorResponse(t, rr.Body.Bytes(), "invalid_position")
+}
+
+func TestRespondDomainError_OpenBreakerSetsRetryAfter(t *testing.T) {
+	// Arrange
+	err := fmt.Errorf("%w: %w", core.ErrStorageUnavailable, &breaker.OpenError{Name: "storage", RetryAfter: 12300 * time.Millisecond})
+	rr := newRecorder()
+
+	// Act
+	respondDomainError(rr, err)
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "13", rr.Header().Get("Retry-After"))
+	assertErrorResponse(t, rr.Body.Bytes(), "storage_unavailable")
+}
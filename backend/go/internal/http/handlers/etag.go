@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/respond"
+)
+
+// etagBuilder hashes the fields that identify a representation's version.
+// ETags are derived from stored metadata rather than the rendered body so a
+// 304 can be returned without encoding the JSON first.
+type etagBuilder struct {
+	h hash.Hash
+}
+
+func newETagBuilder() *etagBuilder {
+	return &etagBuilder{h: sha256.New()}
+}
+
+func (b *etagBuilder) add(values ...string) *etagBuilder {
+	for _, v := range values {
+		b.h.Write([]byte(v))
+		b.h.Write([]byte{0})
+	}
+	return b
+}
+
+func (b *etagBuilder) addTime(t *time.Time) *etagBuilder {
+	if t == nil {
+		return b.add("")
+	}
+	return b.add(strconv.FormatInt(t.UnixNano(), 10))
+}
+
+func (b *etagBuilder) addInt(values ...int) *etagBuilder {
+	for _, v := range values {
+		b.add(strconv.Itoa(v))
+	}
+	return b
+}
+
+// String returns the strong ETag, including quotes
+func (b *etagBuilder) String() string {
+	return `"` + hex.EncodeToString(b.h.Sum(nil)[:16]) + `"`
+}
+
+// versioned returns the strong ETag of a single resource at version. The
+// version leads the hash so If-Match can be checked against the stored
+// version without recomputing the ETag.
+func (b *etagBuilder) versioned(version int) string {
+	return `"v` + strconv.Itoa(version) + "-" + hex.EncodeToString(b.h.Sum(nil)[:16]) + `"`
+}
+
+// projectETag versions a single project
+func projectETag(project *core.Project) string {
+	return addProject(newETagBuilder(), project).versioned(project.Version)
+}
+
+// projectReadETag versions a project with the items embedded in it
+func projectReadETag(project *core.Project, items []*core.Item) string {
+	b := addProject(newETagBuilder(), project)
+	for _, item := range items {
+		b.add(item.ID).addTime(&item.UpdatedAt).addInt(item.Position, item.Version)
+	}
+	return b.versioned(project.Version)
+}
+
+// addProject adds a project's version to b: its ID, its timestamps and,
+// when it was counted, its item count, which changes with no timestamp of
+// the project's
+func addProject(b *etagBuilder, project *core.Project) *etagBuilder {
+	b.add(project.ID).addTime(&project.UpdatedAt).addTime(project.PublishedAt).addInt(project.Version)
+	if project.ItemCount != nil {
+		b.addInt(*project.ItemCount)
+	}
+	return b
+}
+
+// projectListETag versions a page of projects in one negotiated format,
+// and whether more follow it
+func projectListETag(page *core.ProjectPage, limit, offset int, format respond.Format) string {
+	b := newETagBuilder().add(string(format), strconv.FormatBool(page.HasMore)).addInt(page.Total, limit, offset)
+	for _, project := range page.Projects {
+		addProject(b, project)
+	}
+	return b.String()
+}
+
+// itemETag versions a single item
+func itemETag(item *core.Item) string {
+	return newETagBuilder().
+		add(item.ID).
+		addTime(&item.UpdatedAt).
+		addInt(item.Position, item.Version).
+		versioned(item.Version)
+}
+
+// itemListETag versions a filtered page of items, with the locks on them, in
+// one negotiated format
+func itemListETag(items []*core.Item, locks map[string]*core.ItemLock, total, limit, offset int, format respond.Format) string {
+	b := newETagBuilder().add(string(format)).addInt(total, limit, offset)
+	for _, item := range items {
+		b.add(item.ID).addTime(&item.UpdatedAt).addInt(item.Position, item.Version)
+		if lock, ok := locks[item.ID]; ok {
+			b.add(lock.HolderID).addTime(&lock.ExpiresAt)
+		}
+	}
+	return b.String()
+}
+
+// publicProjectETag versions a published project as learners are served
+// it: by its publication or, for a project published before publications
+// were kept, by its live items
+func publicProjectETag(public *core.PublicProject) string {
+	b := newETagBuilder().add(public.Project.ID).addInt(public.Version)
+	if public.Version == 0 {
+		addProject(b, public.Project)
+		for _, item := range public.Items {
+			b.add(item.ID).addTime(&item.UpdatedAt).addInt(item.Position, item.Version)
+		}
+	}
+	return b.String()
+}
+
+// answerDistributionETag versions an answer distribution in a window.
+// Aggregates have no stored version, so it hashes the counts, which the
+// analytics cache keeps from being recomputed for each revalidation.
+func answerDistributionETag(distribution *core.AnswerDistribution, filter core.AttemptFilter) string {
+	b := newETagBuilder().add(distribution.ItemID, string(distribution.ItemType)).
+		addTime(filter.From).addTime(filter.To).
+		addInt(distribution.Answered, distribution.DistinctTexts)
+	for _, choice := range distribution.Choices {
+		b.add(choice.ChoiceID, strconv.FormatBool(choice.Correct)).addInt(choice.Count)
+		if choice.Text != nil {
+			b.add(*choice.Text)
+		}
+	}
+	for _, text := range distribution.Texts {
+		b.add(text.Text, strconv.FormatBool(text.Correct)).addInt(text.Count)
+	}
+	for _, sequence := range distribution.Sequences {
+		b.add(sequence.Order...).add(strconv.FormatBool(sequence.Correct)).addInt(sequence.Count)
+	}
+	if heat := distribution.Heat; heat != nil {
+		b.addInt(heat.Size).add(strconv.FormatFloat(heat.MinX, 'g', -1, 64), strconv.FormatFloat(heat.MinY, 'g', -1, 64),
+			strconv.FormatFloat(heat.MaxX, 'g', -1, 64), strconv.FormatFloat(heat.MaxY, 'g', -1, 64))
+		for _, row := range heat.Counts {
+			b.addInt(row...)
+		}
+	}
+	return b.String()
+}
+
+// checkNotModified sets the ETag header and, if the request's If-None-Match
+// matches it, writes an empty 304 response. Returns true when the caller
+// should stop handling the request. Editor reads must always revalidate,
+// so responses are marked no-cache rather than given a max-age.
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	return checkNotModifiedAs(w, r, etag, "private, no-cache")
+}
+
+// checkPublicNotModified is checkNotModified for reads anyone may make,
+// which shared caches may keep as long as they revalidate them
+func checkPublicNotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	return checkNotModifiedAs(w, r, etag, "public, no-cache")
+}
+
+func checkNotModifiedAs(w http.ResponseWriter, r *http.Request, etag, cacheControl string) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+
+	if !etagMatches(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches implements the weak comparison If-None-Match requires
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expectedVersion returns the version a write must find its resource at,
+// nil when the request names none. A version in the body wins over the
+// If-Match header; of If-Match only the first ETag counts, and * matches
+// any version. An If-Match that is not an ETag of this API names version
+// 0, which no resource is at, so the write fails with version_conflict.
+func expectedVersion(r *http.Request, body *int) *int {
+	if body != nil {
+		return body
+	}
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return nil
+	}
+
+	candidate, _, _ := strings.Cut(ifMatch, ",")
+	candidate = strings.Trim(strings.TrimPrefix(strings.TrimSpace(candidate), "W/"), `"`)
+	version := 0
+	if digits, _, ok := strings.Cut(strings.TrimPrefix(candidate, "v"), "-"); ok && strings.HasPrefix(candidate, "v") {
+		if n, err := strconv.Atoi(digits); err == nil && n > 0 {
+			version = n
+		}
+	}
+	return &version
+}
@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// memoryProjectStore is a minimal in-memory core.ProjectStore for handler tests
+type memoryProjectStore struct {
+	projects map[string]*core.Project
+}
+
+func (s *memoryProjectStore) Create(ctx context.Context, title string, description *string, tags []string) (*core.Project, error) {
+	return nil, nil
+}
+
+func (s *memoryProjectStore) GetByID(ctx context.Context, id string) (*core.Project, error) {
+	project, ok := s.projects[id]
+	if !ok {
+		return nil, core.ErrProjectNotFound
+	}
+	copied := *project
+	return &copied, nil
+}
+
+func (s *memoryProjectStore) List(ctx context.Context, opts core.ListOptions) (*core.ProjectPage, error) {
+	var projects []*core.Project
+	for _, project := range s.projects {
+		copied := *project
+		projects = append(projects, &copied)
+	}
+	return &core.ProjectPage{Projects: projects, Total: len(projects)}, nil
+}
+
+func (s *memoryProjectStore) Update(ctx context.Context, id string, title string, description *string, tags []string, version *int) (*core.Project, error) {
+	project, ok := s.projects[id]
+	if !ok {
+		return nil, core.ErrProjectNotFound
+	}
+	if version != nil && *version != project.Version {
+		return nil, core.ErrVersionConflict
+	}
+	project.Version++
+	project.Title = title
+	project.Description = description
+	project.Tags = tags
+	project.UpdatedAt = project.UpdatedAt.Add(time.Second)
+	copied := *project
+	return &copied, nil
+}
+
+func (s *memoryProjectStore) Delete(ctx context.Context, id string) error {
+	delete(s.projects, id)
+	return nil
+}
+
+func (s *memoryProjectStore) Restore(ctx context.Context, id string) (*core.Project, error) {
+	return nil, core.ErrProjectNotFound
+}
+
+func (s *memoryProjectStore) Purge(ctx context.Context, id string) error {
+	return core.ErrProjectNotFound
+}
+
+func (s *memoryProjectStore) Publish(ctx context.Context, id string) (*core.Project, error) {
+	return s.GetByID(ctx, id)
+}
+
+func (s *memoryProjectStore) Duplicate(ctx context.Context, id, title string) (*core.Project, error) {
+	return nil, nil
+}
+
+func (s *memoryProjectStore) UpdateResultsSettings(ctx context.Context, id string, settings core.ResultsSettings) (*core.Project, error) {
+	project, ok := s.projects[id]
+	if !ok {
+		return nil, core.ErrProjectNotFound
+	}
+	project.Version++
+	project.ResultsSettings = &settings
+	return s.GetByID(ctx, id)
+}
+
+func (s *memoryProjectStore) ListPublications(ctx context.Context, projectID string, limit, offset int) ([]*core.Publication, int, error) {
+	return nil, 0, nil
+}
+
+func (s *memoryProjectStore) GetPublication(ctx context.Context, projectID string, version int) (*core.Publication, error) {
+	return nil, core.ErrPublicationNotFound
+}
+
+func (s *memoryProjectStore) PublishedOrgID(ctx context.Context, id string) (string, error) {
+	return "", core.ErrProjectNotFound
+}
+
+func (s *memoryProjectStore) OrgID(ctx context.Context, id string) (string, error) {
+	if _, ok := s.projects[id]; !ok {
+		return "", core.ErrProjectNotFound
+	}
+	return "", nil
+}
+
+func newETagTestRouter() http.Handler {
+	store := &memoryProjectStore{projects: map[string]*core.Project{
+		"p1": {ID: "p1", Title: "Quiz", CreatedAt: time.Unix(1700000000, 0), UpdatedAt: time.Unix(1700000000, 0), Version: 1},
+	}}
+	handler := NewProjectHandler(core.NewProjectService(store, nil), httpmiddleware.NewValidator())
+
+	r := chi.NewRouter()
+	r.Get("/projects", handler.ListProjects)
+	r.Get("/projects/{projectId}", handler.GetProject)
+	r.Put("/projects/{projectId}", handler.UpdateProject)
+	return r
+}
+
+func TestProjectHandler_ConditionalGet(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"single project", "/projects/p1"},
+		{"project list", "/projects"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := newETagTestRouter()
+
+			first := httptest.NewRecorder()
+			router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			require.Equal(t, http.StatusOK, first.Code)
+			etag := first.Header().Get("ETag")
+			require.NotEmpty(t, etag)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("If-None-Match", etag)
+			rr := httptest.NewRecorder()
+
+			// Act
+			router.ServeHTTP(rr, req)
+
+			// Assert
+			assert.Equal(t, http.StatusNotModified, rr.Code)
+			assert.Empty(t, rr.Body.String())
+			assert.Equal(t, etag, rr.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestProjectHandler_ETagChangesAfterUpdate(t *testing.T) {
+	// Arrange
+	router := newETagTestRouter()
+
+	first := httptest.NewRecorder()
+	router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/projects/p1", nil))
+	oldETag := first.Header().Get("ETag")
+
+	update := httptest.NewRequest(http.MethodPut, "/projects/p1", strings.NewReader(`{"title":"Renamed"}`))
+	update.Header.Set("Content-Type", "application/json")
+	updated := httptest.NewRecorder()
+	router.ServeHTTP(updated, update)
+	require.Equal(t, http.StatusOK, updated.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/projects/p1", nil)
+	req.Header.Set("If-None-Match", oldETag)
+	rr := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(rr, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEqual(t, oldETag, rr.Header().Get("ETag"))
+	assert.Equal(t, updated.Header().Get("ETag"), rr.Header().Get("ETag"))
+	assert.Contains(t, rr.Body.String(), "Renamed")
+}
+
+func TestProjectHandler_ListETagVariesByFormat(t *testing.T) {
+	// Arrange
+	router := newETagTestRouter()
+
+	first := httptest.NewRecorder()
+	router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/projects", nil))
+	jsonETag := first.Header().Get("ETag")
+
+	req := httptest.NewRequest(http.MethodGet, "/projects", nil)
+	req.Header.Set("Accept", "text/csv")
+	req.Header.Set("If-None-Match", jsonETag)
+	rr := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(rr, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.NotEqual(t, jsonETag, rr.Header().Get("ETag"))
+	assert.Equal(t, "Accept", rr.Header().Get("Vary"))
+}
+
+func TestProjectETag_ChangesWithItemCount(t *testing.T) {
+	// Arrange
+	one, two := 1, 2
+	project := &core.Project{ID: "p1", UpdatedAt: time.Unix(1700000000, 0)}
+	counted := *project
+	counted.ItemCount = &one
+	added := *project
+	added.ItemCount = &two
+
+	// Act
+	etags := []string{projectETag(project), projectETag(&counted), projectETag(&added)}
+
+	// Assert
+	assert.NotEqual(t, etags[1], etags[2], "adding an item changes the project's version")
+	assert.NotEqual(t, etags[0], etags[1])
+	assert.NotEqual(t, projectETag(&counted), projectReadETag(&counted, []*core.Item{{ID: "i1", Position: 1}}), "embedded items are part of the version")
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		expected    bool
+	}{
+		{"empty header", "", false},
+		{"exact match", `"abc"`, true},
+		{"weak match", `W/"abc"`, true},
+		{"match in list", `"xyz", "abc"`, true},
+		{"wildcard", "*", true},
+		{"mismatch", `"xyz"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, etagMatches(tt.ifNoneMatch, `"abc"`))
+		})
+	}
+}
+
+func TestProjectHandler_UpdateIfMatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		ifMatch         func(current, stale string) string
+		body            string
+		expectedStatus  int
+		expectedVersion int
+	}{
+		{"no precondition", func(current, stale string) string { return "" }, `{"title":"Renamed"}`, http.StatusOK, 3},
+		{"current ETag", func(current, stale string) string { return current }, `{"title":"Renamed"}`, http.StatusOK, 3},
+		{"weak current ETag", func(current, stale string) string { return "W/" + current }, `{"title":"Renamed"}`, http.StatusOK, 3},
+		{"wildcard", func(current, stale string) string { return "*" }, `{"title":"Renamed"}`, http.StatusOK, 3},
+		{"stale ETag", func(current, stale string) string { return stale }, `{"title":"Renamed"}`, http.StatusPreconditionFailed, 2},
+		{"ETag of no version", func(current, stale string) string { return `"abc"` }, `{"title":"Renamed"}`, http.StatusPreconditionFailed, 2},
+		{"stale body version", func(current, stale string) string { return "" }, `{"title":"Renamed","version":1}`, http.StatusPreconditionFailed, 2},
+		{"body version wins", func(current, stale string) string { return stale }, `{"title":"Renamed","version":2}`, http.StatusOK, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := newETagTestRouter()
+			first := httptest.NewRecorder()
+			router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/projects/p1", nil))
+			stale := first.Header().Get("ETag")
+
+			edit := httptest.NewRequest(http.MethodPut, "/projects/p1", strings.NewReader(`{"title":"Edited"}`))
+			edited := httptest.NewRecorder()
+			router.ServeHTTP(edited, edit)
+			require.Equal(t, http.StatusOK, edited.Code)
+			current := edited.Header().Get("ETag")
+
+			req := httptest.NewRequest(http.MethodPut, "/projects/p1", strings.NewReader(tt.body))
+			if ifMatch := tt.ifMatch(current, stale); ifMatch != "" {
+				req.Header.Set("If-Match", ifMatch)
+			}
+			rr := httptest.NewRecorder()
+
+			// Act
+			router.ServeHTTP(rr, req)
+
+			// Assert
+			require.Equal(t, tt.expectedStatus, rr.Code, rr.Body.String())
+			if tt.expectedStatus == http.StatusOK {
+				var response types.ProjectResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedVersion, response.Version)
+				assert.Equal(t, "Renamed", response.Title)
+				return
+			}
+			var response struct {
+				Error struct {
+					Code    string                `json:"code"`
+					Current types.ProjectResponse `json:"current"`
+				} `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, types.ErrorCodeVersionConflict, response.Error.Code)
+			assert.Equal(t, tt.expectedVersion, response.Error.Current.Version)
+			assert.Equal(t, "Edited", response.Error.Current.Title, "the conflicting update is not written")
+			assert.Equal(t, current, rr.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestExpectedVersion(t *testing.T) {
+	three := 3
+	tests := []struct {
+		name     string
+		ifMatch  string
+		body     *int
+		expected *int
+	}{
+		{"neither", "", nil, nil},
+		{"wildcard", "*", nil, nil},
+		{"ETag", `"v7-0123abcd"`, nil, intPtr(7)},
+		{"weak ETag", `W/"v7-0123abcd"`, nil, intPtr(7)},
+		{"first of a list", `"v7-0123abcd", "v8-4567ef01"`, nil, intPtr(7)},
+		{"ETag of no version", `"0123abcd"`, nil, intPtr(0)},
+		{"malformed version", `"vx-0123abcd"`, nil, intPtr(0)},
+		{"body wins", `"v7-0123abcd"`, &three, intPtr(3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/projects/p1", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			assert.Equal(t, tt.expected, expectedVersion(req, tt.body))
+		})
+	}
+}
+
+func TestItemETag_CarriesVersion(t *testing.T) {
+	// Arrange
+	item := &core.Item{ID: "i1", UpdatedAt: time.Unix(1700000000, 0), Version: 4}
+	moved := *item
+	moved.Version = 5
+
+	// Act
+	etag := itemETag(item)
+
+	// Assert
+	assert.True(t, strings.HasPrefix(etag, `"v4-`), etag)
+	assert.NotEqual(t, etag, itemETag(&moved), "a reorder changes no timestamp but the version")
+	req := httptest.NewRequest(http.MethodPut, "/", nil)
+	req.Header.Set("If-Match", etag)
+	assert.Equal(t, intPtr(4), expectedVersion(req, nil))
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// ProjectExporter writes a project and its items in one export format,
+// satisfied by *qti.Exporter and *scorm.Exporter. An error returned before the first write
+// leaves the response to the handler.
+type ProjectExporter interface {
+	Export(ctx context.Context, w io.Writer, project *core.Project, items []*core.Item) error
+}
+
+// ExportHandler handles project exports
+type ExportHandler struct {
+	projects  ProjectService
+	items     ItemService
+	exporters map[string]ProjectExporter
+}
+
+// NewExportHandler creates a new export handler serving exporters by
+// format name
+func NewExportHandler(projects ProjectService, items ItemService, exporters map[string]ProjectExporter) *ExportHandler {
+	return &ExportHandler{
+		projects:  projects,
+		items:     items,
+		exporters: exporters,
+	}
+}
+
+// ExportProject handles GET /api/v1/projects/{projectId}/export
+// @Summary Export project
+// @Description Download a project and its items as a zip package. format=qti is a QTI 2.1 content package: imsmanifest.xml, one assessmentItem per item and the assets items show, under assets/. Item explanations are not exported. format=scorm and format=scorm2004 are SCORM 1.2 and 2004 packages of a published quiz: one SCO whose page delivers the quiz, grades it in the browser and reports the score to the LMS. They hold title, media, choice, multi_choice and text_entry items.
+// @Tags Projects
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param format query string true "Export format" Enums(qti, scorm, scorm2004)
+// @Produce application/zip,json
+// @Success 200 {file} file "Zip package"
+// @Failure 400 {object} types.ErrorResponse "unsupported_format"
+// @Failure 404 {object} types.ErrorResponse "project_not_found, file_not_found"
+// @Failure 409 {object} types.ErrorResponse "project_not_published"
+// @Failure 422 {object} types.ErrorResponse "invalid_content, unsupported_item_type"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Router /api/v1/projects/{projectId}/export [get]
+func (h *ExportHandler) ExportProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	exporter, ok := h.exporters[format]
+	if !ok {
+		respond.Error(w, http.StatusBadRequest, types.ErrorCodeUnsupportedFormat, "Unsupported export format",
+			"format must be one of "+strings.Join(h.formats(), ", "))
+		return
+	}
+
+	project, err := h.projects.GetByID(ctx, projectID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to get project")
+		respondDomainError(w, err)
+		return
+	}
+
+	items, err := h.items.ListByProject(ctx, projectID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to list items")
+		respondDomainError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": exportFilename(project.Title, format),
+	}))
+	tw := &trackingWriter{w: w}
+	if err := exporter.Export(ctx, tw, project, items); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Str("format", format).Msg("failed to export project")
+		if !tw.written {
+			w.Header().Del("Content-Disposition")
+			respondDomainError(w, err)
+		}
+		// Past the first write the status is sent; the client sees a
+		// truncated zip
+	}
+}
+
+// formats lists the supported export formats
+func (h *ExportHandler) formats() []string {
+	formats := make([]string, 0, len(h.exporters))
+	for format := range h.exporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// exportFilename names a project's export after its title, as lowercase
+// words joined by dashes
+func exportFilename(title, format string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+	slug := strings.Join(words, "-")
+	if slug == "" {
+		slug = "project"
+	}
+	return slug + "-" + format + ".zip"
+}
+
+// trackingWriter records whether an export has started writing the response
+type trackingWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	t.written = true
+	return t.w.Write(p)
+}
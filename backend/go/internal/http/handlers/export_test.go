@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// fakeExporter writes body, then fails with err
+type fakeExporter struct {
+	body  string
+	err   error
+	items []*core.Item
+}
+
+func (e *fakeExporter) Export(ctx context.Context, w io.Writer, project *core.Project, items []*core.Item) error {
+	e.items = items
+	if e.body != "" {
+		if _, err := io.WriteString(w, e.body); err != nil {
+			return err
+		}
+	}
+	return e.err
+}
+
+func TestExportHandler_ExportProject(t *testing.T) {
+	project := &core.Project{ID: "p1", Title: "Tide Tables: Spring '26"}
+	items := []*core.Item{{ID: "i1", ProjectID: "p1"}}
+
+	tests := []struct {
+		name           string
+		query          string
+		exporter       *fakeExporter
+		mockSetup      func(p *MockProjectService, i *MockItemService)
+		expectedStatus int
+		validate       func(t *testing.T, rr *httptest.ResponseRecorder, exporter *fakeExporter)
+	}{
+		{
+			name:     "zip package",
+			query:    "?format=qti",
+			exporter: &fakeExporter{body: "PK"},
+			mockSetup: func(p *MockProjectService, i *MockItemService) {
+				p.On("GetByID", mock.Anything, "p1").Return(project, nil)
+				i.On("ListByProject", mock.Anything, "p1").Return(items, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validate: func(t *testing.T, rr *httptest.ResponseRecorder, exporter *fakeExporter) {
+				assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+				assert.Equal(t, "attachment; filename=tide-tables-spring-26-qti.zip", rr.Header().Get("Content-Disposition"))
+				assert.Equal(t, "PK", rr.Body.String())
+				assert.Equal(t, items, exporter.items)
+			},
+		},
+		{
+			name:           "missing format",
+			exporter:       &fakeExporter{},
+			mockSetup:      func(p *MockProjectService, i *MockItemService) {},
+			expectedStatus: http.StatusBadRequest,
+			validate: func(t *testing.T, rr *httptest.ResponseRecorder, _ *fakeExporter) {
+				assertErrorResponse(t, rr.Body.Bytes(), types.ErrorCodeUnsupportedFormat)
+			},
+		},
+		{
+			name:           "unknown format",
+			query:          "?format=pdf",
+			exporter:       &fakeExporter{},
+			mockSetup:      func(p *MockProjectService, i *MockItemService) {},
+			expectedStatus: http.StatusBadRequest,
+			validate: func(t *testing.T, rr *httptest.ResponseRecorder, _ *fakeExporter) {
+				response := assertErrorResponse(t, rr.Body.Bytes(), types.ErrorCodeUnsupportedFormat)
+				require.NotNil(t, response.Error.Details)
+				assert.Equal(t, "format must be one of qti", *response.Error.Details)
+			},
+		},
+		{
+			name:     "project not found",
+			query:    "?format=qti",
+			exporter: &fakeExporter{},
+			mockSetup: func(p *MockProjectService, i *MockItemService) {
+				p.On("GetByID", mock.Anything, "p1").Return(nil, core.ErrProjectNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			validate: func(t *testing.T, rr *httptest.ResponseRecorder, _ *fakeExporter) {
+				assertErrorResponse(t, rr.Body.Bytes(), "project_not_found")
+			},
+		},
+		{
+			name:     "unsupported item",
+			query:    "?format=qti",
+			exporter: &fakeExporter{err: fmt.Errorf("%w: item h1 is hotspot", core.ErrExportUnsupportedItem)},
+			mockSetup: func(p *MockProjectService, i *MockItemService) {
+				p.On("GetByID", mock.Anything, "p1").Return(project, nil)
+				i.On("ListByProject", mock.Anything, "p1").Return(items, nil)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			validate: func(t *testing.T, rr *httptest.ResponseRecorder, _ *fakeExporter) {
+				response := assertErrorResponse(t, rr.Body.Bytes(), types.ErrorCodeExportUnsupportedItem)
+				require.NotNil(t, response.Error.Details)
+				assert.Equal(t, "item h1 is hotspot", *response.Error.Details, "details name the item")
+			},
+		},
+		{
+			name:     "missing asset before writing",
+			query:    "?format=qti",
+			exporter: &fakeExporter{err: fmt.Errorf("%w: projects/p1/assets/a.png", core.ErrFileNotFound)},
+			mockSetup: func(p *MockProjectService, i *MockItemService) {
+				p.On("GetByID", mock.Anything, "p1").Return(project, nil)
+				i.On("ListByProject", mock.Anything, "p1").Return(items, nil)
+			},
+			expectedStatus: http.StatusNotFound,
+			validate: func(t *testing.T, rr *httptest.ResponseRecorder, _ *fakeExporter) {
+				assertErrorResponse(t, rr.Body.Bytes(), "file_not_found")
+				assert.Empty(t, rr.Header().Get("Content-Disposition"))
+			},
+		},
+		{
+			name:     "failure after writing",
+			query:    "?format=qti",
+			exporter: &fakeExporter{body: "PK", err: fmt.Errorf("storage unavailable")},
+			mockSetup: func(p *MockProjectService, i *MockItemService) {
+				p.On("GetByID", mock.Anything, "p1").Return(project, nil)
+				i.On("ListByProject", mock.Anything, "p1").Return(items, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validate: func(t *testing.T, rr *httptest.ResponseRecorder, _ *fakeExporter) {
+				assert.Equal(t, "PK", rr.Body.String(), "the truncated zip is not followed by an error body")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			projects := new(MockProjectService)
+			itemService := new(MockItemService)
+			tt.mockSetup(projects, itemService)
+			handler := NewExportHandler(projects, itemService, map[string]ProjectExporter{"qti": tt.exporter})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/p1/export"+tt.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "p1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := newRecorder()
+
+			// Act
+			handler.ExportProject(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			tt.validate(t, rr, tt.exporter)
+			projects.AssertExpectations(t)
+			itemService.AssertExpectations(t)
+		})
+	}
+}
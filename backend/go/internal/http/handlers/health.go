@@ -2,65 +2,174 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"net/http"
+	"runtime/debug"
+	"sync"
 	"time"
 
-	"github.com/provemyself/backend/internal/store"
+	"github.com/rs/zerolog/log"
+
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/respond"
 	"github.com/provemyself/backend/internal/types"
 )
 
+// defaultVersion is reported when the binary carries no module version,
+// e.g. when built with `go build` from a checkout
+const defaultVersion = "0.1.0"
+
+// healthCheckTimeout bounds each dependency check
+const healthCheckTimeout = 3 * time.Second
+
+// HealthDependency is a dependency checked by GetHealth. A failing critical
+// dependency makes the service unhealthy (503); any other failure only
+// degrades it. Details optionally adds dependency-specific state (such as
+// connection pool usage) to the check result.
+type HealthDependency struct {
+	Checker  httpmiddleware.HealthChecker
+	Critical bool
+	Details  func() interface{}
+}
+
 // HealthHandler handles health check endpoints
 type HealthHandler struct {
-	database *store.Database
+	dependencies []HealthDependency
+	cacheTTL     time.Duration
+	version      string
+	commit       string
+
+	mu         sync.Mutex
+	cached     *types.HealthResponse
+	cachedCode int
+	cachedAt   time.Time
 }
 
-// NewHealthHandler creates a new health handler
-func NewHealthHandler(database *store.Database) *HealthHandler {
-	return &HealthHandler{database: database}
+// NewHealthHandler creates a new health handler. The aggregate result is
+// cached for cacheTTL so frequent probes don't turn into dependency load.
+func NewHealthHandler(cacheTTL time.Duration, dependencies ...HealthDependency) *HealthHandler {
+	version, commit := buildVersion()
+	return &HealthHandler{
+		dependencies: dependencies,
+		cacheTTL:     cacheTTL,
+		version:      version,
+		commit:       commit,
+	}
 }
 
 // GetHealth handles GET /api/v1/health
 // @Summary Health check endpoint
-// @Description Returns the health status of the API service
+// @Description Returns the health status of the API service and each dependency, with check latencies. Results are cached briefly.
 // @Tags System
 // @Produce json
-// @Success 200 {object} types.HealthResponse
-// @Failure 503 {object} types.ErrorResponse
+// @Success 200 {object} types.HealthResponse "Healthy or degraded"
+// @Failure 503 {object} types.HealthResponse "A critical dependency is failing"
 // @Router /health [get]
 func (h *HealthHandler) GetHealth(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	response, statusCode := h.check(r.Context())
+	respond.JSON(w, statusCode, response)
+}
+
+// check returns the cached result if fresh, otherwise runs every check.
+// Holding the lock while checking makes concurrent probes share one run.
+func (h *HealthHandler) check(ctx context.Context) (*types.HealthResponse, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
-	// Check database health
-	dbStatus := "healthy"
-	if err := h.database.HealthCheck(ctx); err != nil {
-		dbStatus = "unhealthy"
+	if h.cached != nil && time.Since(h.cachedAt) < h.cacheTTL {
+		return h.cached, h.cachedCode
 	}
 
-	// Determine overall status
-	status := "healthy"
+	// The result is shared with other callers, so one client disconnecting
+	// must not fail the checks
+	ctx = context.WithoutCancel(ctx)
+
+	results := make([]types.HealthCheckResult, len(h.dependencies))
+	var wg sync.WaitGroup
+	for i, dep := range h.dependencies {
+		wg.Add(1)
+		go func(i int, dep HealthDependency) {
+			defer wg.Done()
+			results[i] = runHealthCheck(ctx, dep)
+		}(i, dep)
+	}
+	wg.Wait()
+
+	status := types.HealthStatusHealthy
 	statusCode := http.StatusOK
-	if dbStatus == "unhealthy" {
-		status = "unhealthy"
-		statusCode = http.StatusServiceUnavailable
+	checks := make(map[string]types.HealthCheckResult, len(results))
+	for i, result := range results {
+		checks[h.dependencies[i].Checker.Name()] = result
+		if result.Status == types.HealthStatusHealthy {
+			continue
+		}
+		if result.Critical {
+			status = types.HealthStatusUnhealthy
+			statusCode = http.StatusServiceUnavailable
+		} else if status == types.HealthStatusHealthy {
+			status = types.HealthStatusDegraded
+		}
 	}
 
-	response := types.HealthResponse{
+	h.cached = &types.HealthResponse{
 		Status:    status,
-		Timestamp: time.Now(),
-		Version:   "0.1.0",
-		Services: &types.HealthServices{
-			Database: dbStatus,
-			Storage:  "healthy", // In Phase 0, we assume healthy
-		},
+		Timestamp: time.Now().UTC(),
+		Version:   h.version,
+		Commit:    h.commit,
+		Checks:    checks,
 	}
+	h.cachedCode = statusCode
+	h.cachedAt = time.Now()
+
+	return h.cached, h.cachedCode
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "failed to encode response", http.StatusInternalServerError)
-		return
+func runHealthCheck(ctx context.Context, dep HealthDependency) types.HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dep.Checker.HealthCheck(ctx)
+
+	result := types.HealthCheckResult{
+		Status:    types.HealthStatusHealthy,
+		Critical:  dep.Critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if dep.Details != nil {
+		result.Details = dep.Details()
+	}
+	if err != nil {
+		// The endpoint is public, so the raw error (which may contain
+		// addresses) is only logged
+		log.Warn().Err(err).Str("dependency", dep.Checker.Name()).Msg("dependency health check failed")
+
+		result.Status = types.HealthStatusUnhealthy
+		result.Error = "check failed"
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.Error = "timeout"
+		}
+	}
+	return result
+}
+
+// buildVersion reads the module version and VCS revision embedded by the
+// Go toolchain
+func buildVersion() (version, commit string) {
+	version = defaultVersion
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return version, ""
 	}
-}
\ No newline at end of file
+
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			commit = setting.Value
+		}
+	}
+	return version, commit
+}
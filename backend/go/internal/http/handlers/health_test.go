@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -12,87 +16,143 @@ import (
 	"github.com/provemyself/backend/internal/types"
 )
 
+// fakeChecker is a httpmiddleware.HealthChecker with configurable latency and result
+type fakeChecker struct {
+	name    string
+	latency time.Duration
+	err     error
+	calls   atomic.Int32
+}
+
+func (f *fakeChecker) Name() string {
+	return f.name
+}
+
+func (f *fakeChecker) HealthCheck(ctx context.Context) error {
+	f.calls.Add(1)
+	time.Sleep(f.latency)
+	return f.err
+}
+
+func getHealth(t *testing.T, handler *HealthHandler) (int, types.HealthResponse) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	rr := httptest.NewRecorder()
+	handler.GetHealth(rr, req)
+
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var response types.HealthResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	return rr.Code, response
+}
+
 func TestHealthHandler_GetHealth(t *testing.T) {
 	tests := []struct {
 		name           string
+		databaseErr    error
+		storageErr     error
 		expectedStatus int
-		validateBody   func(t *testing.T, response types.HealthResponse)
+		expectedHealth string
 	}{
-		{
-			name:           "successful health check",
-			expectedStatus: http.StatusOK,
-			validateBody: func(t *testing.T, response types.HealthResponse) {
-				assert.Equal(t, "healthy", response.Status)
-				assert.Equal(t, "0.1.0", response.Version)
-				assert.NotZero(t, response.Timestamp)
-				
-				require.NotNil(t, response.Services)
-				assert.Equal(t, "healthy", response.Services.Database)
-				assert.Equal(t, "healthy", response.Services.Storage)
-			},
-		},
+		{"all dependencies healthy", nil, nil, http.StatusOK, types.HealthStatusHealthy},
+		{"storage down is degraded", nil, errors.New("disk full"), http.StatusOK, types.HealthStatusDegraded},
+		{"database down is unhealthy", errors.New("connection refused"), nil, http.StatusServiceUnavailable, types.HealthStatusUnhealthy},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Arrange
-			handler := NewHealthHandler()
-			req := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
-			rr := httptest.NewRecorder()
+			handler := NewHealthHandler(0,
+				HealthDependency{Checker: &fakeChecker{name: "database", err: tt.databaseErr}, Critical: true},
+				HealthDependency{Checker: &fakeChecker{name: "storage", err: tt.storageErr}},
+			)
 
 			// Act
-			handler.GetHealth(rr, req)
+			code, response := getHealth(t, handler)
 
 			// Assert
-			assert.Equal(t, tt.expectedStatus, rr.Code)
-			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedStatus, code)
+			assert.Equal(t, tt.expectedHealth, response.Status)
+			assert.NotEmpty(t, response.Version)
+			assert.NotZero(t, response.Timestamp)
+			require.Contains(t, response.Checks, "database")
+			require.Contains(t, response.Checks, "storage")
+			assert.True(t, response.Checks["database"].Critical)
+			assert.False(t, response.Checks["storage"].Critical)
+		})
+	}
+}
+
+func TestHealthHandler_GetHealth_ReportsLatencyWithoutErrorDetails(t *testing.T) {
+	// Arrange
+	handler := NewHealthHandler(0,
+		HealthDependency{Checker: &fakeChecker{name: "lrs", latency: 20 * time.Millisecond, err: errors.New("dial tcp 10.0.0.5:443: refused")}},
+	)
 
-			var response types.HealthResponse
-			err := json.NewDecoder(rr.Body).Decode(&response)
-			require.NoError(t, err)
+	// Act
+	_, response := getHealth(t, handler)
 
-			tt.validateBody(t, response)
-		})
+	// Assert
+	check := response.Checks["lrs"]
+	assert.Equal(t, types.HealthStatusUnhealthy, check.Status)
+	assert.GreaterOrEqual(t, check.LatencyMs, 20.0)
+	assert.Equal(t, "check failed", check.Error)
+}
+
+func TestHealthHandler_GetHealth_CacheShortCircuitsSlowChecks(t *testing.T) {
+	// Arrange
+	slow := &fakeChecker{name: "database", latency: 100 * time.Millisecond}
+	handler := NewHealthHandler(time.Minute, HealthDependency{Checker: slow, Critical: true})
+
+	_, first := getHealth(t, handler)
+
+	// Act
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		getHealth(t, handler)
 	}
+	elapsed := time.Since(start)
+
+	// Assert
+	assert.Equal(t, int32(1), slow.calls.Load())
+	assert.Less(t, elapsed, 100*time.Millisecond)
+	_, cached := getHealth(t, handler)
+	assert.Equal(t, first.Timestamp.UnixNano(), cached.Timestamp.UnixNano())
 }
 
-func TestHealthHandler_GetHealth_ContentType(t *testing.T) {
+func TestHealthHandler_GetHealth_CacheExpires(t *testing.T) {
 	// Arrange
-	handler := NewHealthHandler()
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
-	rr := httptest.NewRecorder()
+	checker := &fakeChecker{name: "database"}
+	handler := NewHealthHandler(10*time.Millisecond, HealthDependency{Checker: checker, Critical: true})
+	getHealth(t, handler)
 
 	// Act
-	handler.GetHealth(rr, req)
+	time.Sleep(20 * time.Millisecond)
+	getHealth(t, handler)
 
 	// Assert
-	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Equal(t, int32(2), checker.calls.Load())
 }
 
-func TestHealthHandler_GetHealth_ResponseStructure(t *testing.T) {
+func TestHealthHandler_GetHealth_IncludesDependencyDetails(t *testing.T) {
 	// Arrange
-	handler := NewHealthHandler()
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
-	rr := httptest.NewRecorder()
+	handler := NewHealthHandler(0, HealthDependency{
+		Checker:  &fakeChecker{name: "database"},
+		Critical: true,
+		Details: func() interface{} {
+			return types.DatabasePoolStats{MaxOpen: 25, Open: 3, InUse: 2, Idle: 1, WaitCount: 4}
+		},
+	})
 
 	// Act
-	handler.GetHealth(rr, req)
+	_, response := getHealth(t, handler)
 
 	// Assert
-	assert.Equal(t, http.StatusOK, rr.Code)
-
-	var response map[string]interface{}
-	err := json.NewDecoder(rr.Body).Decode(&response)
-	require.NoError(t, err)
-
-	// Check required fields exist
-	assert.Contains(t, response, "status")
-	assert.Contains(t, response, "timestamp")
-	assert.Contains(t, response, "version")
-	assert.Contains(t, response, "services")
-
-	services, ok := response["services"].(map[string]interface{})
-	require.True(t, ok, "services should be an object")
-	assert.Contains(t, services, "database")
-	assert.Contains(t, services, "storage")
-}
\ No newline at end of file
+	details, ok := response.Checks["database"].Details.(map[string]interface{})
+	require.True(t, ok)
+	assert.EqualValues(t, 25, details["max_open"])
+	assert.EqualValues(t, 2, details["in_use"])
+	assert.EqualValues(t, 4, details["wait_count"])
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// IntegrityHandler handles GET /api/v1/admin/integrity
+type IntegrityHandler struct {
+	checker core.IntegrityChecker
+}
+
+// NewIntegrityHandler creates a new integrity handler
+func NewIntegrityHandler(checker core.IntegrityChecker) *IntegrityHandler {
+	return &IntegrityHandler{checker: checker}
+}
+
+// CheckIntegrity handles GET /api/v1/admin/integrity
+// @Summary Check referential integrity
+// @Description Runs every registered referential check and reports the rows, and stored project files, left behind by a deleted parent, with up to 5 sample IDs per check. Soft-deleted parents still count as present. A healthy database reports 0 orphans.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.IntegrityResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/integrity [get]
+func (h *IntegrityHandler) CheckIntegrity(w http.ResponseWriter, r *http.Request) {
+	results, err := h.checker.CheckIntegrity(r.Context())
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("failed to check integrity")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, integrityResponse(results))
+}
+
+func integrityResponse(results []core.IntegrityResult) types.IntegrityResponse {
+	response := types.IntegrityResponse{Checks: make([]types.IntegrityCheckResponse, len(results))}
+	for i, result := range results {
+		response.Orphans += result.Orphans
+		response.Checks[i] = types.IntegrityCheckResponse{
+			Check:       result.Check,
+			Description: result.Description,
+			Orphans:     result.Orphans,
+			SampleIDs:   result.SampleIDs,
+		}
+	}
+	return response
+}
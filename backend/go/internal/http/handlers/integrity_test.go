@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// fakeIntegrityChecker returns fixed results
+type fakeIntegrityChecker struct {
+	results []core.IntegrityResult
+	err     error
+}
+
+func (f *fakeIntegrityChecker) CheckIntegrity(ctx context.Context) ([]core.IntegrityResult, error) {
+	return f.results, f.err
+}
+
+func TestIntegrityHandler_CheckIntegrity(t *testing.T) {
+	tests := []struct {
+		name            string
+		checker         *fakeIntegrityChecker
+		expectedStatus  int
+		expectedCode    string
+		expectedOrphans int
+	}{
+		{
+			name: "no orphans",
+			checker: &fakeIntegrityChecker{results: []core.IntegrityResult{
+				{Check: "items.project_id", Description: "items whose project row is gone", SampleIDs: []string{}},
+			}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "orphans",
+			checker: &fakeIntegrityChecker{results: []core.IntegrityResult{
+				{Check: "items.project_id", Orphans: 2, SampleIDs: []string{"item-1", "item-2"}},
+				{Check: "storage.projects", Orphans: 1, SampleIDs: []string{"projects/project-1/assets/a.png"}},
+			}},
+			expectedStatus:  http.StatusOK,
+			expectedOrphans: 3,
+		},
+		{
+			name:           "check failed",
+			checker:        &fakeIntegrityChecker{err: errors.New("connection reset")},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   types.ErrorCodeInternalError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := NewIntegrityHandler(tt.checker)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/integrity", nil)
+			rr := newRecorder()
+
+			// Act
+			handler.CheckIntegrity(rr, req)
+
+			// Assert
+			require.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedCode != "" {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+				return
+			}
+			var body types.IntegrityResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, tt.expectedOrphans, body.Orphans)
+			require.Len(t, body.Checks, len(tt.checker.results))
+			for i, result := range tt.checker.results {
+				assert.Equal(t, result.Check, body.Checks[i].Check)
+				assert.Equal(t, result.Orphans, body.Checks[i].Orphans)
+				assert.Equal(t, result.SampleIDs, body.Checks[i].SampleIDs)
+			}
+		})
+	}
+}
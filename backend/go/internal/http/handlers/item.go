@@ -15,17 +15,47 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/pagination"
+	"github.com/provemyself/backend/internal/http/respond"
 	"github.com/provemyself/backend/internal/types"
 )
 
+// ItemService is the item business logic the handler depends on,
+// satisfied by *core.ItemService
+type ItemService interface {
+	Create(ctx context.Context, projectID string, itemType types.ItemType, title string, content interface{}, position int, required bool, points *int, explanation *string) (*core.Item, error)
+	CreateMany(ctx context.Context, projectID string, inputs []core.ItemInput) ([]*core.Item, error)
+	GetByID(ctx context.Context, projectID, id string) (*core.Item, error)
+	ListByProject(ctx context.Context, projectID string) ([]*core.Item, error)
+	List(ctx context.Context, projectID string, opts core.ItemListOptions) (*core.ItemPage, error)
+	Duplicate(ctx context.Context, projectID, id string) (*core.Item, error)
+	Update(ctx context.Context, projectID, id string, itemType types.ItemType, title string, content interface{}, position int, required bool, points *int, explanation *string, version *int) (*core.Item, error)
+	Patch(ctx context.Context, projectID, id string, patch core.ItemPatch) (*core.Item, error)
+	Delete(ctx context.Context, projectID, id string) error
+	Restore(ctx context.Context, projectID, id string) (*core.Item, error)
+	UpdatePositions(ctx context.Context, projectID string, updates []core.PositionUpdate) error
+	ListRevisions(ctx context.Context, projectID, id string, limit, offset int) ([]*core.ItemRevision, int, error)
+	RestoreRevision(ctx context.Context, projectID, id string, number int) (*core.Item, error)
+}
+
 // ItemHandler handles item-related HTTP requests
 type ItemHandler struct {
-	service  *core.ItemService
+	service  ItemService
 	validate *validator.Validate
+
+	// locks is nil until SetLocks is called
+	locks      ItemLockService
+	lockEvents ItemLockEvents
+	// keepAlive is how often an idle lock event stream sends a comment line
+	keepAlive time.Duration
+
+	// importers is nil until SetImporters is called
+	importers map[string]ItemImporter
 }
 
 // NewItemHandler creates a new item handler
-func NewItemHandler(service *core.ItemService, validate *validator.Validate) *ItemHandler {
+func NewItemHandler(service ItemService, validate *validator.Validate) *ItemHandler {
 	return &ItemHandler{
 		service:  service,
 		validate: validate,
@@ -41,38 +71,38 @@ func NewItemHandler(service *core.ItemService, validate *validator.Validate) *It
 // @Param projectId path string true "Project ID" format(uuid)
 // @Param request body types.CreateItemRequest true "Item creation request"
 // @Success 201 {object} types.ItemResponse
-// @Failure 400 {object} types.ErrorResponse
-// @Failure 401 {object} types.ErrorResponse
-// @Failure 404 {object} types.ErrorResponse
-// @Failure 422 {object} types.ErrorResponse
-// @Failure 500 {object} types.ErrorResponse
-// @Router /projects/{projectId}/items [post]
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 422 {object} types.ErrorResponse "invalid_content, title_too_long"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items [post]
 func (h *ItemHandler) CreateItem(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	projectID := chi.URLParam(r, "projectId")
 	if projectID == "" {
-		h.sendJSONError(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
 		return
 	}
 
 	var req types.CreateItemRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to decode request")
-		h.sendJSONError(w, http.StatusBadRequest, "invalid_request_body", "Invalid request body")
+		httpmiddleware.SendBodyReadError(w, err)
 		return
 	}
 
 	if err := h.validate.StructCtx(ctx, req); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("validation failed")
-		h.sendJSONError(w, http.StatusBadRequest, "validation_failed", "Validation failed", err.Error())
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
 		return
 	}
 
 	// Validate content structure based on item type
 	if err := h.validateItemContent(req.Type, req.Content); err != nil {
-		h.sendJSONError(w, http.StatusUnprocessableEntity, "invalid_content", err.Error())
+		respond.Error(w, http.StatusUnprocessableEntity, "invalid_content", err.Error())
 		return
 	}
 
@@ -80,22 +110,7 @@ func (h *ItemHandler) CreateItem(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to create item")
 
-		switch {
-		case errors.Is(err, core.ErrProjectNotFound):
-			h.sendJSONError(w, http.StatusNotFound, "project_not_found", "Project not found")
-		case errors.Is(err, core.ErrItemTitleTooShort):
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "title_too_short", "Item title is too short")
-		case errors.Is(err, core.ErrItemTitleTooLong):
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "title_too_long", "Item title is too long")
-		case errors.Is(err, core.ErrItemInvalidType):
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "invalid_type", "Invalid item type")
-		case errors.Is(err, core.ErrItemInvalidPosition):
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "invalid_position", "Invalid position")
-		case errors.Is(err, core.ErrItemInvalidContent):
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "invalid_content", "Invalid content for item type")
-		default:
-			h.sendJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to create item")
-		}
+		respondDomainError(w, err)
 		return
 	}
 
@@ -111,34 +126,39 @@ func (h *ItemHandler) CreateItem(w http.ResponseWriter, r *http.Request) {
 		Explanation: item.Explanation,
 		CreatedAt:   item.CreatedAt,
 		UpdatedAt:   item.UpdatedAt,
+		Version:     item.Version,
 	}
 
-	h.sendJSONResponse(w, http.StatusCreated, response)
+	respond.JSON(w, http.StatusCreated, response)
 }
 
 // ListItems handles GET /api/v1/projects/{projectId}/items
 // @Summary List items
-// @Description Retrieve all items for a project with optional filtering and search
+// @Description Retrieve all items for a project with optional filtering and search. Items someone holds the edit lock on carry the lock. Send Accept: text/csv or application/x-ndjson, or the format parameter, to get the page as CSV with a header row or as one JSON item per line instead of the JSON envelope.
 // @Tags Items
 // @Param projectId path string true "Project ID" format(uuid)
 // @Param type query string false "Filter by item type"
-// @Param search query string false "Search in item titles and content"
+// @Param search query string false "Search in item titles and content. From 3 characters, a full-text search in web search syntax ranking title matches first; shorter terms match substrings in position order"
 // @Param required query bool false "Filter by required status"
+// @Param sort query string false "Order of the list, a field ascending or after a - descending; position order, or best match first for searches, by default" Enums(position, -position, created_at, -created_at, updated_at, -updated_at, title, -title)
 // @Param limit query int false "Maximum number of items to return" minimum(1) maximum(100) default(50)
 // @Param offset query int false "Number of items to skip" minimum(0) default(0)
-// @Produce json
+// @Param format query string false "Response format, overriding Accept" Enums(json, csv, ndjson)
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Produce json,text/csv,application/x-ndjson
 // @Success 200 {object} types.ItemListResponse
-// @Failure 401 {object} types.ErrorResponse
-// @Failure 404 {object} types.ErrorResponse
-// @Failure 500 {object} types.ErrorResponse
-// @Router /projects/{projectId}/items [get]
+// @Success 304 "Not modified"
+// @Failure 400 {object} types.ErrorResponse "invalid_pagination, invalid_type_filter, invalid_sort, unsupported_format"
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items [get]
 func (h *ItemHandler) ListItems(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	projectID := chi.URLParam(r, "projectId")
 	if projectID == "" {
-		h.sendJSONError(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
 		return
 	}
 
@@ -146,20 +166,13 @@ func (h *ItemHandler) ListItems(w http.ResponseWriter, r *http.Request) {
 	itemType := r.URL.Query().Get("type")
 	search := r.URL.Query().Get("search")
 	requiredStr := r.URL.Query().Get("required")
-	
-	limit := 50
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 && parsed <= 100 {
-			limit = parsed
-		}
-	}
 
-	offset := 0
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if parsed, err := strconv.Atoi(offsetStr); err == nil && parsed >= 0 {
-			offset = parsed
-		}
+	page, err := pagination.Parse(r, pagination.Page{Limit: 50}, 100)
+	if err != nil {
+		pagination.WriteError(w, err)
+		return
 	}
+	limit, offset := page.Limit, page.Offset
 
 	// Parse required filter
 	var required *bool
@@ -172,38 +185,50 @@ func (h *ItemHandler) ListItems(w http.ResponseWriter, r *http.Request) {
 	// Validate item type if provided
 	if itemType != "" {
 		if !h.isValidItemType(itemType) {
-			h.sendJSONError(w, http.StatusBadRequest, "invalid_type_filter", "Invalid item type filter")
+			respond.Error(w, http.StatusBadRequest, "invalid_type_filter", "Invalid item type filter")
 			return
 		}
 	}
 
-	items, err := h.service.ListByProject(ctx, projectID)
+	sort, ok := parseSort(w, r, itemSorts)
+	if !ok {
+		return
+	}
+
+	format, ok := respond.NegotiateFormat(w, r)
+	if !ok {
+		return
+	}
+
+	// The store filters, pages and counts; long enough search terms use the
+	// full-text index, ranked by relevance
+	listed, err := h.service.List(ctx, projectID, core.ItemListOptions{
+		Type:     types.ItemType(itemType),
+		Required: required,
+		Search:   strings.TrimSpace(search),
+		Sort:     sort,
+		Limit:    limit,
+		Offset:   offset,
+	})
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to list items")
 
-		if errors.Is(err, core.ErrProjectNotFound) {
-			h.sendJSONError(w, http.StatusNotFound, "project_not_found", "Project not found")
-		} else {
-			h.sendJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to list items")
-		}
+		respondDomainError(w, err)
 		return
 	}
+	paginatedItems, total := listed.Items, listed.Total
+
+	locks, err := h.itemLocks(ctx, projectID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to list item locks")
 
-	// Apply filters
-	filteredItems := h.filterItems(items, itemType, search, required)
-	
-	// Apply pagination
-	total := len(filteredItems)
-	start := offset
-	end := start + limit
-	if start >= total {
-		start = total
+		respondDomainError(w, err)
+		return
 	}
-	if end > total {
-		end = total
+
+	if checkNotModified(w, r, itemListETag(paginatedItems, locks, total, limit, offset, format)) {
+		return
 	}
-	
-	paginatedItems := filteredItems[start:end]
 
 	// Convert to response format
 	itemResponses := make([]types.ItemResponse, len(paginatedItems))
@@ -220,6 +245,11 @@ func (h *ItemHandler) ListItems(w http.ResponseWriter, r *http.Request) {
 			Explanation: item.Explanation,
 			CreatedAt:   item.CreatedAt,
 			UpdatedAt:   item.UpdatedAt,
+			Version:     item.Version,
+		}
+		if lock, ok := locks[item.ID]; ok {
+			lockResponse := itemLockResponse(lock)
+			itemResponses[i].Lock = &lockResponse
 		}
 	}
 
@@ -231,40 +261,46 @@ func (h *ItemHandler) ListItems(w http.ResponseWriter, r *http.Request) {
 		Offset:    offset,
 	}
 
-	h.sendJSONResponse(w, http.StatusOK, response)
+	respond.Negotiated(w, r, itemRows{response: response})
 }
 
 // GetItem handles GET /api/v1/projects/{projectId}/items/{itemId}
 // @Summary Get item
-// @Description Retrieve a specific item by ID
+// @Description Retrieve a specific item of the project by ID. An item of another project is not found.
 // @Tags Items
 // @Param projectId path string true "Project ID" format(uuid)
 // @Param itemId path string true "Item ID" format(uuid)
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Produce json
 // @Success 200 {object} types.ItemResponse
-// @Failure 401 {object} types.ErrorResponse
-// @Failure 404 {object} types.ErrorResponse
-// @Failure 500 {object} types.ErrorResponse
-// @Router /projects/{projectId}/items/{itemId} [get]
+// @Success 304 "Not modified"
+// @Failure 404 {object} types.ErrorResponse "item_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/{itemId} [get]
 func (h *ItemHandler) GetItem(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
+	projectID := chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
+	}
 	itemID := chi.URLParam(r, "itemId")
 	if itemID == "" {
-		h.sendJSONError(w, http.StatusBadRequest, "missing_item_id", "Item ID is required")
+		respond.Error(w, http.StatusBadRequest, "missing_item_id", "Item ID is required")
 		return
 	}
 
-	item, err := h.service.GetByID(ctx, itemID)
+	item, err := h.service.GetByID(ctx, projectID, itemID)
 	if err != nil {
-		log.Ctx(ctx).Error().Err(err).Str("item_id", itemID).Msg("failed to get item")
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Str("item_id", itemID).Msg("failed to get item")
 
-		if errors.Is(err, core.ErrItemNotFound) {
-			h.sendJSONError(w, http.StatusNotFound, "item_not_found", "Item not found")
-		} else {
-			h.sendJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to get item")
-		}
+		respondDomainError(w, err)
+		return
+	}
+
+	if checkNotModified(w, r, itemETag(item)) {
 		return
 	}
 
@@ -280,80 +316,176 @@ func (h *ItemHandler) GetItem(w http.ResponseWriter, r *http.Request) {
 		Explanation: item.Explanation,
 		CreatedAt:   item.CreatedAt,
 		UpdatedAt:   item.UpdatedAt,
+		Version:     item.Version,
 	}
 
-	h.sendJSONResponse(w, http.StatusOK, response)
+	respond.JSON(w, http.StatusOK, response)
 }
 
 // UpdateItem handles PUT /api/v1/projects/{projectId}/items/{itemId}
 // @Summary Update item
-// @Description Update an existing item
+// @Description Update an existing item of the project; an item of another project is not found. Fails with item_locked while another user holds the item's edit lock. With an If-Match ETag, or a version in the body, which wins, the update fails with version_conflict, and the item as it is now, unless the item is still at that version.
 // @Tags Items
 // @Accept json
 // @Produce json
 // @Param projectId path string true "Project ID" format(uuid)
 // @Param itemId path string true "Item ID" format(uuid)
+// @Param If-Match header string false "ETag of the version the update is based on"
 // @Param request body types.UpdateItemRequest true "Item update request"
 // @Success 200 {object} types.ItemResponse
-// @Failure 400 {object} types.ErrorResponse
-// @Failure 401 {object} types.ErrorResponse
-// @Failure 404 {object} types.ErrorResponse
-// @Failure 422 {object} types.ErrorResponse
-// @Failure 500 {object} types.ErrorResponse
-// @Router /projects/{projectId}/items/{itemId} [put]
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 404 {object} types.ErrorResponse "item_not_found"
+// @Failure 412 {object} types.ErrorResponse "version_conflict"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 422 {object} types.ErrorResponse "invalid_content, title_too_long"
+// @Failure 423 {object} types.ErrorResponse "item_locked"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/{itemId} [put]
 func (h *ItemHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
+	projectID := chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
+	}
 	itemID := chi.URLParam(r, "itemId")
 	if itemID == "" {
-		h.sendJSONError(w, http.StatusBadRequest, "missing_item_id", "Item ID is required")
+		respond.Error(w, http.StatusBadRequest, "missing_item_id", "Item ID is required")
 		return
 	}
 
 	var req types.UpdateItemRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to decode request")
-		h.sendJSONError(w, http.StatusBadRequest, "invalid_request_body", "Invalid request body")
+		httpmiddleware.SendBodyReadError(w, err)
 		return
 	}
 
 	if err := h.validate.StructCtx(ctx, req); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("validation failed")
-		h.sendJSONError(w, http.StatusBadRequest, "validation_failed", "Validation failed", err.Error())
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
 		return
 	}
 
 	// Validate content structure based on item type
 	if err := h.validateItemContent(req.Type, req.Content); err != nil {
-		h.sendJSONError(w, http.StatusUnprocessableEntity, "invalid_content", err.Error())
+		respond.Error(w, http.StatusUnprocessableEntity, "invalid_content", err.Error())
 		return
 	}
 
-	item, err := h.service.Update(ctx, itemID, req.Type, req.Title, req.Content, req.Position, req.Required, req.Points, req.Explanation)
+	item, err := h.service.Update(ctx, projectID, itemID, req.Type, req.Title, req.Content, req.Position, req.Required, req.Points, req.Explanation, expectedVersion(r, req.Version))
+	if errors.Is(err, core.ErrVersionConflict) {
+		h.respondVersionConflict(w, r, projectID, itemID)
+		return
+	}
 	if err != nil {
-		log.Ctx(ctx).Error().Err(err).Str("item_id", itemID).Msg("failed to update item")
-
-		switch {
-		case errors.Is(err, core.ErrItemNotFound):
-			h.sendJSONError(w, http.StatusNotFound, "item_not_found", "Item not found")
-		case errors.Is(err, core.ErrItemTitleTooShort):
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "title_too_short", "Item title is too short")
-		case errors.Is(err, core.ErrItemTitleTooLong):
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "title_too_long", "Item title is too long")
-		case errors.Is(err, core.ErrItemInvalidType):
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "invalid_type", "Invalid item type")
-		case errors.Is(err, core.ErrItemInvalidPosition):
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "invalid_position", "Invalid position")
-		case errors.Is(err, core.ErrItemInvalidContent):
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "invalid_content", "Invalid content for item type")
-		default:
-			h.sendJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to update item")
-		}
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Str("item_id", itemID).Msg("failed to update item")
+
+		respondDomainError(w, err)
 		return
 	}
 
-	response := types.ItemResponse{
+	w.Header().Set("ETag", itemETag(item))
+	respond.JSON(w, http.StatusOK, itemResponse(item))
+}
+
+// PatchItem handles PATCH /api/v1/projects/{projectId}/items/{itemId}
+// @Summary Patch item
+// @Description Change only the fields of an item of the project that the request sets; fields left out, or null, are unchanged. The content is checked if the request sets it or changes the type. Fails with item_locked while another user holds the item's edit lock. Honors If-Match and version like Update item.
+// @Tags Items
+// @Accept json
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param itemId path string true "Item ID" format(uuid)
+// @Param If-Match header string false "ETag of the version the patch is based on"
+// @Param request body types.PatchItemRequest true "Fields to change"
+// @Success 200 {object} types.ItemResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 404 {object} types.ErrorResponse "item_not_found"
+// @Failure 412 {object} types.ErrorResponse "version_conflict"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 422 {object} types.ErrorResponse "invalid_content, title_too_short"
+// @Failure 423 {object} types.ErrorResponse "item_locked"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/{itemId} [patch]
+func (h *ItemHandler) PatchItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
+	}
+	itemID := chi.URLParam(r, "itemId")
+	if itemID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_item_id", "Item ID is required")
+		return
+	}
+
+	var req types.PatchItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to decode request")
+		httpmiddleware.SendBodyReadError(w, err)
+		return
+	}
+
+	if err := h.validate.StructCtx(ctx, req); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("validation failed")
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
+		return
+	}
+
+	patch := core.ItemPatch{
+		Type:        req.Type,
+		Title:       req.Title,
+		Position:    req.Position,
+		Required:    req.Required,
+		Points:      req.Points,
+		Explanation: req.Explanation,
+		Version:     expectedVersion(r, req.Version),
+	}
+	// Null content is left out, like every other null field
+	if string(req.Content) != "null" {
+		patch.Content = req.Content
+	}
+
+	item, err := h.service.Patch(ctx, projectID, itemID, patch)
+	if errors.Is(err, core.ErrVersionConflict) {
+		h.respondVersionConflict(w, r, projectID, itemID)
+		return
+	}
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Str("item_id", itemID).Msg("failed to patch item")
+
+		respondDomainError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", itemETag(item))
+	respond.JSON(w, http.StatusOK, itemResponse(item))
+}
+
+// respondVersionConflict writes the 412 version_conflict of a write that
+// found the item at another version, with the item as it is now and its
+// ETag
+func (h *ItemHandler) respondVersionConflict(w http.ResponseWriter, r *http.Request, projectID, itemID string) {
+	item, err := h.service.GetByID(r.Context(), projectID, itemID)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("project_id", projectID).Str("item_id", itemID).Msg("failed to get conflicting item")
+		respondDomainError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", itemETag(item))
+	respond.ConflictError(w, types.ErrVersionConflict.StatusCode, types.ErrVersionConflict.Code, types.ErrVersionConflict.Message, itemResponse(item))
+}
+
+// itemResponse is the response of a single item
+func itemResponse(item *core.Item) types.ItemResponse {
+	return types.ItemResponse{
 		ID:          item.ID,
 		ProjectID:   item.ProjectID,
 		Type:        item.Type,
@@ -365,91 +497,198 @@ func (h *ItemHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
 		Explanation: item.Explanation,
 		CreatedAt:   item.CreatedAt,
 		UpdatedAt:   item.UpdatedAt,
+		Version:     item.Version,
 	}
-
-	h.sendJSONResponse(w, http.StatusOK, response)
 }
 
 // DeleteItem handles DELETE /api/v1/projects/{projectId}/items/{itemId}
 // @Summary Delete item
-// @Description Delete an item by ID
+// @Description Move an item of the project to the trash, freeing its position. It can be restored until it is purged, DELETED_ITEM_RETENTION after its deletion. An item of another project is not found.
 // @Tags Items
 // @Param projectId path string true "Project ID" format(uuid)
 // @Param itemId path string true "Item ID" format(uuid)
 // @Success 204 "Item deleted successfully"
-// @Failure 401 {object} types.ErrorResponse
-// @Failure 404 {object} types.ErrorResponse
-// @Failure 500 {object} types.ErrorResponse
-// @Router /projects/{projectId}/items/{itemId} [delete]
+// @Failure 404 {object} types.ErrorResponse "item_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/{itemId} [delete]
 func (h *ItemHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
+	projectID := chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
+	}
 	itemID := chi.URLParam(r, "itemId")
 	if itemID == "" {
-		h.sendJSONError(w, http.StatusBadRequest, "missing_item_id", "Item ID is required")
+		respond.Error(w, http.StatusBadRequest, "missing_item_id", "Item ID is required")
 		return
 	}
 
-	err := h.service.Delete(ctx, itemID)
+	err := h.service.Delete(ctx, projectID, itemID)
 	if err != nil {
-		log.Ctx(ctx).Error().Err(err).Str("item_id", itemID).Msg("failed to delete item")
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Str("item_id", itemID).Msg("failed to delete item")
 
-		if errors.Is(err, core.ErrItemNotFound) {
-			h.sendJSONError(w, http.StatusNotFound, "item_not_found", "Item not found")
-		} else {
-			h.sendJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to delete item")
-		}
+		respondDomainError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// DuplicateItem handles POST /api/v1/projects/{projectId}/items/{itemId}/duplicate
+// @Summary Duplicate item
+// @Description Copy an item, content included, to the end of its project. The copy's title is the item's with " (copy)" appended, shortened if it would be too long.
+// @Tags Items
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param itemId path string true "Item ID" format(uuid)
+// @Produce json
+// @Success 201 {object} types.ItemResponse
+// @Failure 404 {object} types.ErrorResponse "item_not_found, project_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/{itemId}/duplicate [post]
+func (h *ItemHandler) DuplicateItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
+	}
+	itemID := chi.URLParam(r, "itemId")
+	if itemID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_item_id", "Item ID is required")
+		return
+	}
+
+	item, err := h.service.Duplicate(ctx, projectID, itemID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Str("item_id", itemID).Msg("failed to duplicate item")
+
+		respondDomainError(w, err)
+		return
+	}
+
+	response := types.ItemResponse{
+		ID:          item.ID,
+		ProjectID:   item.ProjectID,
+		Type:        item.Type,
+		Title:       item.Title,
+		Content:     item.Content,
+		Position:    item.Position,
+		Required:    item.Required,
+		Points:      item.Points,
+		Explanation: item.Explanation,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+		Version:     item.Version,
+	}
+
+	respond.JSON(w, http.StatusCreated, response)
+}
+
+// RestoreItem handles POST /api/v1/projects/{projectId}/items/{itemId}/restore
+// @Summary Restore item
+// @Description Bring back a deleted item of the project, content included. It keeps its position unless another item took it since, and goes to the end of the project then.
+// @Tags Items
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param itemId path string true "Item ID" format(uuid)
+// @Produce json
+// @Success 200 {object} types.ItemResponse
+// @Failure 404 {object} types.ErrorResponse "item_not_found, project_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/{itemId}/restore [post]
+func (h *ItemHandler) RestoreItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
+	}
+	itemID := chi.URLParam(r, "itemId")
+	if itemID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_item_id", "Item ID is required")
+		return
+	}
+
+	item, err := h.service.Restore(ctx, projectID, itemID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Str("item_id", itemID).Msg("failed to restore item")
+
+		respondDomainError(w, err)
+		return
+	}
+
+	response := types.ItemResponse{
+		ID:          item.ID,
+		ProjectID:   item.ProjectID,
+		Type:        item.Type,
+		Title:       item.Title,
+		Content:     item.Content,
+		Position:    item.Position,
+		Required:    item.Required,
+		Points:      item.Points,
+		Explanation: item.Explanation,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+		Version:     item.Version,
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
 // UpdateItemPositions handles PUT /api/v1/projects/{projectId}/items/positions
 // @Summary Update item positions
-// @Description Update the positions of multiple items for reordering
+// @Description Update the positions of multiple items of the project for reordering. Items may swap positions; two updates to the same position fail with duplicate_positions and an update to the position of an item that is not moved with invalid_position. Nothing is moved unless every item is in the project. Responds with the project's items in their new order, paginated like List items.
 // @Tags Items
 // @Accept json
 // @Produce json
 // @Param projectId path string true "Project ID" format(uuid)
 // @Param request body []types.PositionUpdateRequest true "Array of position updates"
-// @Success 200 {object} types.ItemListResponse
-// @Failure 400 {object} types.ErrorResponse
-// @Failure 401 {object} types.ErrorResponse
-// @Failure 404 {object} types.ErrorResponse
-// @Failure 422 {object} types.ErrorResponse
-// @Failure 500 {object} types.ErrorResponse
-// @Router /projects/{projectId}/items/positions [put]
+// @Success 200 {object} types.ItemListResponse "The project's items"
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, empty_updates, validation_failed"
+// @Failure 404 {object} types.ErrorResponse "item_not_found"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 422 {object} types.ErrorResponse "duplicate_positions, invalid_position"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/positions [put]
 func (h *ItemHandler) UpdateItemPositions(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	projectID := chi.URLParam(r, "projectId")
 	if projectID == "" {
-		h.sendJSONError(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
 		return
 	}
 
 	var req []types.PositionUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to decode position update request")
-		h.sendJSONError(w, http.StatusBadRequest, "invalid_request_body", "Invalid request body")
+		httpmiddleware.SendBodyReadError(w, err)
 		return
 	}
 
 	if len(req) == 0 {
-		h.sendJSONError(w, http.StatusBadRequest, "empty_updates", "At least one position update is required")
+		respond.Error(w, http.StatusBadRequest, "empty_updates", "At least one position update is required")
 		return
 	}
 
-	// Validate each position update
-	for _, update := range req {
+	// Validate each position update, reporting every failed field at once
+	var fieldErrors []types.ValidationError
+	for i, update := range req {
 		if err := h.validate.StructCtx(ctx, update); err != nil {
-			h.sendJSONError(w, http.StatusBadRequest, "validation_failed", "Invalid position update", err.Error())
-			return
+			fieldErrors = append(fieldErrors, httpmiddleware.ValidationErrors(err, fmt.Sprintf("positions[%d]", i))...)
 		}
 	}
+	if len(fieldErrors) > 0 {
+		respond.ValidationError(w, fieldErrors)
+		return
+	}
 
 	// Convert to core types
 	updates := make([]core.PositionUpdate, len(req))
@@ -461,9 +700,9 @@ func (h *ItemHandler) UpdateItemPositions(w http.ResponseWriter, r *http.Request
 	}
 
 	// Update positions
-	if err := h.service.UpdatePositions(ctx, updates); err != nil {
+	if err := h.service.UpdatePositions(ctx, projectID, updates); err != nil {
 		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to update item positions")
-		h.sendJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to update item positions")
+		respondDomainError(w, err)
 		return
 	}
 
@@ -473,73 +712,93 @@ func (h *ItemHandler) UpdateItemPositions(w http.ResponseWriter, r *http.Request
 
 // BulkCreateItems handles POST /api/v1/projects/{projectId}/items/bulk
 // @Summary Bulk create items
-// @Description Create multiple items at once
+// @Description Create multiple items at once. Either every item is created or, if any fails, none is. Items must have distinct positions, none of them taken. An item that fails is reported with a 422 error whose index is the item's in the request, from 0.
 // @Tags Items
 // @Accept json
 // @Produce json
 // @Param projectId path string true "Project ID" format(uuid)
 // @Param request body []types.CreateItemRequest true "Array of items to create"
 // @Success 201 {object} types.ItemListResponse
-// @Failure 400 {object} types.ErrorResponse
-// @Failure 401 {object} types.ErrorResponse
-// @Failure 404 {object} types.ErrorResponse
-// @Failure 422 {object} types.ErrorResponse
-// @Failure 500 {object} types.ErrorResponse
-// @Router /projects/{projectId}/items/bulk [post]
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, empty_items, too_many_items, validation_failed"
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 422 {object} types.ErrorResponse "invalid_content, duplicate_positions, invalid_position, invalid_type, title_too_short, title_too_long; index is the failed item's"
+// @Failure 500 {object} types.ErrorResponse "bulk_create_failed, internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/bulk [post]
 func (h *ItemHandler) BulkCreateItems(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	projectID := chi.URLParam(r, "projectId")
 	if projectID == "" {
-		h.sendJSONError(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
 		return
 	}
 
 	var req []types.CreateItemRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to decode bulk create request")
-		h.sendJSONError(w, http.StatusBadRequest, "invalid_request_body", "Invalid request body")
+		httpmiddleware.SendBodyReadError(w, err)
 		return
 	}
 
 	if len(req) == 0 {
-		h.sendJSONError(w, http.StatusBadRequest, "empty_items", "At least one item is required")
+		respond.Error(w, http.StatusBadRequest, "empty_items", "At least one item is required")
 		return
 	}
 
 	if len(req) > 100 {
-		h.sendJSONError(w, http.StatusBadRequest, "too_many_items", "Maximum 100 items can be created at once")
+		respond.Error(w, http.StatusBadRequest, "too_many_items", "Maximum 100 items can be created at once")
 		return
 	}
 
-	// Validate each item
+	// Validate each item, reporting every failed field at once
+	var fieldErrors []types.ValidationError
 	for i, itemReq := range req {
 		if err := h.validate.StructCtx(ctx, itemReq); err != nil {
-			h.sendJSONError(w, http.StatusBadRequest, "validation_failed", 
-				fmt.Sprintf("Item %d validation failed: %s", i+1, err.Error()))
-			return
+			fieldErrors = append(fieldErrors, httpmiddleware.ValidationErrors(err, fmt.Sprintf("items[%d]", i))...)
 		}
+	}
+	if len(fieldErrors) > 0 {
+		respond.ValidationError(w, fieldErrors)
+		return
+	}
 
+	for i, itemReq := range req {
 		if err := h.validateItemContent(itemReq.Type, itemReq.Content); err != nil {
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "invalid_content", 
+			respond.ItemError(w, http.StatusUnprocessableEntity, i, "invalid_content",
 				fmt.Sprintf("Item %d: %s", i+1, err.Error()))
 			return
 		}
 	}
 
-	// Create items
-	createdItems := make([]*core.Item, 0, len(req))
-	for _, itemReq := range req {
-		item, err := h.service.Create(ctx, projectID, itemReq.Type, itemReq.Title, itemReq.Content, 
-			itemReq.Position, itemReq.Required, itemReq.Points, itemReq.Explanation)
-		if err != nil {
-			log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to create item in bulk operation")
-			h.sendJSONError(w, http.StatusInternalServerError, "bulk_create_failed", 
-				"Failed to create some items in bulk operation")
+	// Create items, all or none
+	inputs := make([]core.ItemInput, len(req))
+	for i, itemReq := range req {
+		inputs[i] = core.ItemInput{
+			Type:        itemReq.Type,
+			Title:       itemReq.Title,
+			Content:     itemReq.Content,
+			Position:    itemReq.Position,
+			Required:    itemReq.Required,
+			Points:      itemReq.Points,
+			Explanation: itemReq.Explanation,
+		}
+	}
+	createdItems, err := h.service.CreateMany(ctx, projectID, inputs)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to create items in bulk operation")
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, core.ErrProjectNotFound) {
+			respondDomainError(w, err)
 			return
 		}
-		createdItems = append(createdItems, item)
+		var itemErr *core.ItemBatchError
+		if errors.As(err, &itemErr) && respondItemError(w, itemErr) {
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, "bulk_create_failed", 
+			"Failed to create items in bulk operation; no items were created")
+		return
 	}
 
 	// Convert to response format
@@ -557,6 +816,7 @@ func (h *ItemHandler) BulkCreateItems(w http.ResponseWriter, r *http.Request) {
 			Explanation: item.Explanation,
 			CreatedAt:   item.CreatedAt,
 			UpdatedAt:   item.UpdatedAt,
+			Version:     item.Version,
 		}
 	}
 
@@ -566,7 +826,7 @@ func (h *ItemHandler) BulkCreateItems(w http.ResponseWriter, r *http.Request) {
 		ProjectID: projectID,
 	}
 
-	h.sendJSONResponse(w, http.StatusCreated, response)
+	respond.JSON(w, http.StatusCreated, response)
 }
 
 // validateItemContent validates that the content structure matches the item type
@@ -740,79 +1000,3 @@ func (h *ItemHandler) isValidItemType(itemType string) bool {
 	}
 	return false
 }
-
-// filterItems applies filters to the items list
-func (h *ItemHandler) filterItems(items []*core.Item, itemType, search string, required *bool) []*core.Item {
-	filtered := make([]*core.Item, 0, len(items))
-
-	for _, item := range items {
-		// Filter by type
-		if itemType != "" && string(item.Type) != itemType {
-			continue
-		}
-
-		// Filter by required status
-		if required != nil && item.Required != *required {
-			continue
-		}
-
-		// Filter by search term
-		if search != "" {
-			searchLower := strings.ToLower(search)
-			titleLower := strings.ToLower(item.Title)
-			
-			// Search in title
-			if strings.Contains(titleLower, searchLower) {
-				filtered = append(filtered, item)
-				continue
-			}
-
-			// Search in content (basic string search)
-			contentStr := string(item.Content)
-			if strings.Contains(strings.ToLower(contentStr), searchLower) {
-				filtered = append(filtered, item)
-				continue
-			}
-
-			// If no match found, skip this item
-			continue
-		}
-
-		filtered = append(filtered, item)
-	}
-
-	return filtered
-}
-
-// sendJSONResponse sends a JSON response
-func (h *ItemHandler) sendJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	
-	if data != nil {
-		if err := json.NewEncoder(w).Encode(data); err != nil {
-			log.Error().Err(err).Msg("failed to encode JSON response")
-		}
-	}
-}
-
-// sendJSONError sends a JSON error response
-func (h *ItemHandler) sendJSONError(w http.ResponseWriter, statusCode int, code, message string, details ...string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	errorResponse := types.ErrorResponse{
-		Error: types.Error{
-			Code:    code,
-			Message: message,
-		},
-	}
-
-	if len(details) > 0 && details[0] != "" {
-		errorResponse.Error.Details = &details[0]
-	}
-
-	if err := json.NewEncoder(w).Encode(errorResponse); err != nil {
-		log.Error().Err(err).Msg("failed to encode JSON error response")
-	}
-}
\ No newline at end of file
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// ItemImporter reads items from a package, uploading the files they use,
+// satisfied by *qti.Importer
+type ItemImporter interface {
+	Import(ctx context.Context, projectID string, data []byte) ([]*core.ImportedItem, error)
+	// Discard deletes uploaded files of items that are not created
+	Discard(ctx context.Context, keys []string)
+}
+
+// SetImporters enables item imports, by format name
+func (h *ItemHandler) SetImporters(importers map[string]ItemImporter) {
+	h.importers = importers
+}
+
+// ImportItems handles POST /api/v1/projects/{projectId}/items/import
+// @Summary Import items
+// @Description Import the items of a package, sent as the request body, after the project's items. format=qti reads a QTI 2.x content package, such as an LMS export: choice, text entry, order, hotspot and inline choice interactions are imported, as are items of only text or media, and the files they show are uploaded to the project. Items that can't be imported, or fail the validation of created items, are reported as skipped with the reason; the others are created together, or none is.
+// @Tags Items
+// @Accept application/zip
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param format query string true "Package format" Enums(qti)
+// @Success 200 {object} types.ImportItemsResponse "No item could be imported"
+// @Success 201 {object} types.ImportItemsResponse
+// @Failure 400 {object} types.ErrorResponse "unsupported_format, invalid_package"
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 500 {object} types.ErrorResponse "import_failed, internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/import [post]
+func (h *ItemHandler) ImportItems(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
+	}
+
+	importer, ok := h.importers[r.URL.Query().Get("format")]
+	if !ok {
+		respond.Error(w, http.StatusBadRequest, types.ErrorCodeUnsupportedFormat, "Unsupported import format",
+			"format must be qti")
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		httpmiddleware.SendBodyReadError(w, err)
+		return
+	}
+
+	// Imported items go after the project's, which also checks that the
+	// project exists before any file is uploaded
+	existing, err := h.service.ListByProject(ctx, projectID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to list items")
+		respondDomainError(w, err)
+		return
+	}
+	position := 0
+	for _, item := range existing {
+		if item.Position >= position {
+			position = item.Position + 1
+		}
+	}
+
+	imported, err := importer.Import(ctx, projectID, data)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to read import package")
+		respondDomainError(w, err)
+		return
+	}
+
+	// Accepted items go through the validation of created items
+	var accepted []*core.ImportedItem
+	var discarded []string
+	for _, item := range imported {
+		if item.Input != nil {
+			item.Input.Position = position + len(accepted)
+			if reason := h.validateImported(ctx, item.Input); reason != "" {
+				discarded = append(discarded, item.Assets...)
+				item.Skip(reason)
+			}
+		}
+		if item.Input != nil {
+			accepted = append(accepted, item)
+		}
+	}
+	importer.Discard(ctx, unusedKeys(discarded, accepted))
+
+	response := types.ImportItemsResponse{
+		ProjectID: projectID,
+		Imported:  []types.ImportedItemResult{},
+		Skipped:   []types.SkippedItemResult{},
+	}
+	for _, item := range imported {
+		if item.Input == nil {
+			response.Skipped = append(response.Skipped, types.SkippedItemResult{
+				Source: item.Source,
+				Title:  item.Title,
+				Reason: item.Reason,
+			})
+		}
+	}
+	if len(accepted) == 0 {
+		respond.JSON(w, http.StatusOK, response)
+		return
+	}
+
+	inputs := make([]core.ItemInput, len(accepted))
+	for i, item := range accepted {
+		inputs[i] = *item.Input
+	}
+	created, err := h.service.CreateMany(ctx, projectID, inputs)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to create imported items")
+		var keys []string
+		for _, item := range accepted {
+			keys = append(keys, item.Assets...)
+		}
+		importer.Discard(ctx, keys)
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, core.ErrProjectNotFound) {
+			respondDomainError(w, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, "import_failed",
+			"Failed to create the imported items; no items were created")
+		return
+	}
+
+	for i, item := range created {
+		response.Imported = append(response.Imported, types.ImportedItemResult{
+			Source: accepted[i].Source,
+			Item: types.ItemResponse{
+				ID:          item.ID,
+				ProjectID:   item.ProjectID,
+				Type:        item.Type,
+				Title:       item.Title,
+				Content:     item.Content,
+				Position:    item.Position,
+				Required:    item.Required,
+				Points:      item.Points,
+				Explanation: item.Explanation,
+				CreatedAt:   item.CreatedAt,
+				UpdatedAt:   item.UpdatedAt,
+				Version:     item.Version,
+			},
+		})
+	}
+	respond.JSON(w, http.StatusCreated, response)
+}
+
+// validateImported checks an imported item as CreateItem checks a request,
+// returning why it fails or ""
+func (h *ItemHandler) validateImported(ctx context.Context, input *core.ItemInput) string {
+	req := types.CreateItemRequest{
+		Type:        input.Type,
+		Title:       input.Title,
+		Content:     input.Content,
+		Position:    input.Position,
+		Required:    input.Required,
+		Points:      input.Points,
+		Explanation: input.Explanation,
+	}
+	if err := h.validate.StructCtx(ctx, req); err != nil {
+		return validationReason(err, "")
+	}
+	if err := h.validateItemContent(input.Type, input.Content); err != nil {
+		return validationReason(err, "content")
+	}
+	return ""
+}
+
+// validationReason describes a validation failure in one line
+func validationReason(err error, prefix string) string {
+	fieldErrors := httpmiddleware.ValidationErrors(err, prefix)
+	if len(fieldErrors) == 0 {
+		return err.Error()
+	}
+	messages := make([]string, len(fieldErrors))
+	for i, fieldError := range fieldErrors {
+		messages[i] = fieldError.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// unusedKeys returns the keys no accepted item uses: skipped items may
+// share files with accepted ones
+func unusedKeys(keys []string, accepted []*core.ImportedItem) []string {
+	used := make(map[string]bool)
+	for _, item := range accepted {
+		for _, key := range item.Assets {
+			used[key] = true
+		}
+	}
+	var unused []string
+	for _, key := range keys {
+		if !used[key] {
+			used[key] = true
+			unused = append(unused, key)
+		}
+	}
+	return unused
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// fakeImporter returns items, or fails with err, recording discarded keys
+type fakeImporter struct {
+	items     []*core.ImportedItem
+	err       error
+	data      string
+	discarded []string
+}
+
+func (f *fakeImporter) Import(ctx context.Context, projectID string, data []byte) ([]*core.ImportedItem, error) {
+	f.data = string(data)
+	return f.items, f.err
+}
+
+func (f *fakeImporter) Discard(ctx context.Context, keys []string) {
+	f.discarded = append(f.discarded, keys...)
+}
+
+func importedItems() []*core.ImportedItem {
+	return []*core.ImportedItem{
+		{Source: "q1.xml", Title: "Q1", Input: &core.ItemInput{
+			Type:  types.ItemTypeTitle,
+			Title: "Part 1",
+		}},
+		{Source: "q2.xml", Title: "Q2", Reason: "matchInteraction is not supported"},
+		{Source: "q3.xml", Title: "Q3", Input: &core.ItemInput{
+			Type:    types.ItemTypeMedia,
+			Title:   "Cell",
+			Content: types.MediaContent{URL: "https://quiz.example.com/files/cell.png", MediaType: "image"},
+		}, Assets: []string{"cell.png"}},
+		{Source: "q4.xml", Title: "Q4", Input: &core.ItemInput{
+			Type:    types.ItemTypeChoice,
+			Title:   "No correct answer",
+			Content: types.ChoiceContent{Choices: []types.Choice{{ID: "a", Text: "A"}, {ID: "b", Text: "B"}}},
+		}, Assets: []string{"cell.png", "wrong.png"}},
+	}
+}
+
+func TestItemHandler_ImportItems(t *testing.T) {
+	existing := []*core.Item{{ID: "i1", ProjectID: "p1", Position: 0}, {ID: "i2", ProjectID: "p1", Position: 4}}
+
+	tests := []struct {
+		name           string
+		query          string
+		importer       *fakeImporter
+		setupMock      func(*MockItemService)
+		expectedStatus int
+		validate       func(t *testing.T, body []byte, importer *fakeImporter)
+	}{
+		{
+			name:     "imports after the project's items",
+			query:    "?format=qti",
+			importer: &fakeImporter{items: importedItems()},
+			setupMock: func(m *MockItemService) {
+				m.On("ListByProject", mock.Anything, "p1").Return(existing, nil)
+				m.On("CreateMany", mock.Anything, "p1", mock.MatchedBy(func(inputs []core.ItemInput) bool {
+					return len(inputs) == 2 && inputs[0].Position == 5 && inputs[1].Position == 6
+				})).Return([]*core.Item{
+					{ID: "n1", ProjectID: "p1", Type: types.ItemTypeTitle, Title: "Part 1", Position: 5},
+					{ID: "n2", ProjectID: "p1", Type: types.ItemTypeMedia, Title: "Cell", Position: 6},
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			validate: func(t *testing.T, body []byte, importer *fakeImporter) {
+				var response types.ImportItemsResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, "p1", response.ProjectID)
+				require.Len(t, response.Imported, 2)
+				assert.Equal(t, "q1.xml", response.Imported[0].Source)
+				assert.Equal(t, "n2", response.Imported[1].Item.ID)
+				assert.Equal(t, 6, response.Imported[1].Item.Position)
+				require.Len(t, response.Skipped, 2)
+				assert.Equal(t, types.SkippedItemResult{Source: "q2.xml", Title: "Q2", Reason: "matchInteraction is not supported"}, response.Skipped[0])
+				assert.Equal(t, "q4.xml", response.Skipped[1].Source)
+				assert.Contains(t, response.Skipped[1].Reason, "correct", "items failing validation are skipped")
+				assert.Equal(t, []string{"wrong.png"}, importer.discarded, "files of imported items are kept")
+				assert.Equal(t, "PK", importer.data)
+			},
+		},
+		{
+			name:     "nothing to import",
+			query:    "?format=qti",
+			importer: &fakeImporter{items: importedItems()[1:2]},
+			setupMock: func(m *MockItemService) {
+				m.On("ListByProject", mock.Anything, "p1").Return([]*core.Item{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validate: func(t *testing.T, body []byte, _ *fakeImporter) {
+				var response types.ImportItemsResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.NotNil(t, response.Imported)
+				assert.Empty(t, response.Imported)
+				assert.Len(t, response.Skipped, 1)
+			},
+		},
+		{
+			name:           "unsupported format",
+			query:          "?format=csv",
+			importer:       &fakeImporter{},
+			setupMock:      func(m *MockItemService) {},
+			expectedStatus: http.StatusBadRequest,
+			validate: func(t *testing.T, body []byte, _ *fakeImporter) {
+				response := assertErrorResponse(t, body, types.ErrorCodeUnsupportedFormat)
+				require.NotNil(t, response.Error.Details)
+				assert.Equal(t, "format must be qti", *response.Error.Details)
+			},
+		},
+		{
+			name:     "project not found",
+			query:    "?format=qti",
+			importer: &fakeImporter{},
+			setupMock: func(m *MockItemService) {
+				m.On("ListByProject", mock.Anything, "p1").Return(([]*core.Item)(nil), core.ErrProjectNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			validate: func(t *testing.T, body []byte, importer *fakeImporter) {
+				assertErrorResponse(t, body, types.ErrorCodeProjectNotFound)
+				assert.Empty(t, importer.data, "the package is not read")
+			},
+		},
+		{
+			name:     "invalid package",
+			query:    "?format=qti",
+			importer: &fakeImporter{err: fmt.Errorf("%w: not a zip file", core.ErrImportInvalidPackage)},
+			setupMock: func(m *MockItemService) {
+				m.On("ListByProject", mock.Anything, "p1").Return([]*core.Item{}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			validate: func(t *testing.T, body []byte, _ *fakeImporter) {
+				response := assertErrorResponse(t, body, types.ErrorCodeImportInvalidPackage)
+				require.NotNil(t, response.Error.Details)
+				assert.Equal(t, "not a zip file", *response.Error.Details)
+			},
+		},
+		{
+			name:     "create fails",
+			query:    "?format=qti",
+			importer: &fakeImporter{items: importedItems()[2:3]},
+			setupMock: func(m *MockItemService) {
+				m.On("ListByProject", mock.Anything, "p1").Return([]*core.Item{}, nil)
+				m.On("CreateMany", mock.Anything, "p1", mock.Anything).Return(([]*core.Item)(nil), errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			validate: func(t *testing.T, body []byte, importer *fakeImporter) {
+				assertErrorResponse(t, body, "import_failed")
+				assert.Equal(t, []string{"cell.png"}, importer.discarded, "no item keeps its files")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := new(MockItemService)
+			tt.setupMock(service)
+			handler := NewItemHandler(service, httpmiddleware.NewValidator())
+			handler.SetImporters(map[string]ItemImporter{"qti": tt.importer})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/p1/items/import"+tt.query, strings.NewReader("PK"))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "p1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := newRecorder()
+
+			// Act
+			handler.ImportItems(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			tt.validate(t, rr.Body.Bytes(), tt.importer)
+			service.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUnusedKeys(t *testing.T) {
+	// Arrange
+	accepted := []*core.ImportedItem{{Assets: []string{"a", "b"}}}
+
+	// Act
+	unused := unusedKeys([]string{"b", "c", "c", "d"}, accepted)
+
+	// Assert
+	assert.Equal(t, []string{"c", "d"}, unused)
+}
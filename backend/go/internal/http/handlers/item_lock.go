@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/http/streaming"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// ItemLockService is the item locking the handler depends on, satisfied
+// by *core.ItemService
+type ItemLockService interface {
+	Lock(ctx context.Context, projectID, itemID string) (*core.ItemLock, error)
+	RefreshLock(ctx context.Context, projectID, itemID string) (*core.ItemLock, error)
+	Unlock(ctx context.Context, projectID, itemID string) error
+	ListLocks(ctx context.Context, projectID string) ([]*core.ItemLock, error)
+}
+
+// ItemLockEvents signals when a project's item locks change, satisfied by
+// *collab.Feed
+type ItemLockEvents interface {
+	Subscribe(projectID string) (<-chan struct{}, func())
+}
+
+// SetLocks enables the item lock endpoints, and lists each item's lock
+// with the items. Lock changes are streamed from events, with idle streams
+// sending a comment line every keepAlive.
+func (h *ItemHandler) SetLocks(locks ItemLockService, events ItemLockEvents, keepAlive time.Duration) {
+	h.locks = locks
+	h.lockEvents = events
+	h.keepAlive = keepAlive
+}
+
+// LockItem handles POST /api/v1/projects/{projectId}/items/{itemId}/lock
+// @Summary Lock an item for editing
+// @Description Takes the item's edit lock for the caller, for 90 seconds. While it is held, other users' updates to the item are rejected with item_locked. Keep the lock with PUT on the same path while editing and release it with DELETE when done; an expired lock may be taken by anyone. Taking a lock the caller already holds extends it.
+// @Tags Items
+// @Security BearerAuth
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param itemId path string true "Item ID" format(uuid)
+// @Success 200 {object} types.ItemLockResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 404 {object} types.ErrorResponse "item_not_found"
+// @Failure 423 {object} types.ErrorResponse "item_locked"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/{itemId}/lock [post]
+func (h *ItemHandler) LockItem(w http.ResponseWriter, r *http.Request) {
+	h.respondLock(w, r, "failed to lock item", h.locks.Lock)
+}
+
+// RefreshItemLock handles PUT /api/v1/projects/{projectId}/items/{itemId}/lock
+// @Summary Keep an item lock
+// @Description Extends the caller's edit lock on the item to 90 seconds from now. Send it as a heartbeat while editing, well within the lock's lifetime. Fails with item_lock_not_held if the lock expired, or item_locked if another user has taken it since.
+// @Tags Items
+// @Security BearerAuth
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param itemId path string true "Item ID" format(uuid)
+// @Success 200 {object} types.ItemLockResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 404 {object} types.ErrorResponse "item_not_found"
+// @Failure 409 {object} types.ErrorResponse "item_lock_not_held"
+// @Failure 423 {object} types.ErrorResponse "item_locked"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/{itemId}/lock [put]
+func (h *ItemHandler) RefreshItemLock(w http.ResponseWriter, r *http.Request) {
+	h.respondLock(w, r, "failed to refresh item lock", h.locks.RefreshLock)
+}
+
+// UnlockItem handles DELETE /api/v1/projects/{projectId}/items/{itemId}/lock
+// @Summary Release an item lock
+// @Description Releases the caller's edit lock on the item. Only the holder may release a lock; releasing an item nobody holds succeeds.
+// @Tags Items
+// @Security BearerAuth
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param itemId path string true "Item ID" format(uuid)
+// @Success 204 "Lock released"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 404 {object} types.ErrorResponse "item_not_found"
+// @Failure 423 {object} types.ErrorResponse "item_locked"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/{itemId}/lock [delete]
+func (h *ItemHandler) UnlockItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID, itemID, ok := lockParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.locks.Unlock(ctx, projectID, itemID); err != nil {
+		logLockError(ctx, err, itemID, "failed to unlock item")
+		respondDomainError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StreamItemLocks handles GET /api/v1/projects/{projectId}/items/locks/events
+// @Summary Stream a project's item locks
+// @Description Server-sent event stream of the edit locks on the project's items. A "locks" event carrying every unexpired lock is sent on connect, whenever a lock is taken or released through any replica, and when a lock expires; comment lines keep an idle stream open.
+// @Tags Items
+// @Produce text/event-stream
+// @Param projectId path string true "Project ID" format(uuid)
+// @Success 200 {object} types.ItemLockListResponse "data of each locks event"
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Router /api/v1/projects/{projectId}/items/locks/events [get]
+func (h *ItemHandler) StreamItemLocks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
+	}
+
+	// Subscribe before the first read, so no change falls in between
+	changed, unsubscribe := h.lockEvents.Subscribe(projectID)
+	defer unsubscribe()
+
+	locks, err := h.locks.ListLocks(ctx, projectID)
+	if err != nil {
+		if !errors.Is(err, core.ErrProjectNotFound) {
+			log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to list item locks")
+		}
+		respondDomainError(w, err)
+		return
+	}
+
+	sse, err := streaming.NewSSE(w)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to start item lock event stream")
+		return
+	}
+
+	keepAlive := time.NewTicker(h.keepAlive)
+	defer keepAlive.Stop()
+	expiry := time.NewTimer(time.Hour)
+	expiry.Stop()
+	defer expiry.Stop()
+
+	var last []byte
+	for {
+		payload, err := json.Marshal(itemLockListResponse(projectID, locks))
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to encode item lock event")
+			return
+		}
+		if !bytes.Equal(payload, last) {
+			if err := sse.Send("locks", payload); err != nil {
+				return
+			}
+			last = payload
+			keepAlive.Reset(h.keepAlive)
+		}
+
+		// Wake when the first lock expires, since that changes nothing
+		// in the database
+		if !expiry.Stop() {
+			select {
+			case <-expiry.C:
+			default:
+			}
+		}
+		if next, ok := nextExpiry(locks); ok {
+			expiry.Reset(time.Until(next))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		case <-expiry.C:
+		case <-keepAlive.C:
+			if err := sse.KeepAlive(); err != nil {
+				return
+			}
+			continue
+		}
+
+		locks, err = h.locks.ListLocks(ctx, projectID)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to list item locks")
+			}
+			return
+		}
+	}
+}
+
+// respondLock takes or refreshes the lock with take and writes it
+func (h *ItemHandler) respondLock(w http.ResponseWriter, r *http.Request, failure string, take func(ctx context.Context, projectID, itemID string) (*core.ItemLock, error)) {
+	ctx := r.Context()
+
+	projectID, itemID, ok := lockParams(w, r)
+	if !ok {
+		return
+	}
+
+	lock, err := take(ctx, projectID, itemID)
+	if err != nil {
+		logLockError(ctx, err, itemID, failure)
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, itemLockResponse(lock))
+}
+
+// lockParams reads the project and item IDs of a lock route, writing an
+// error if either is missing
+func lockParams(w http.ResponseWriter, r *http.Request) (projectID, itemID string, ok bool) {
+	projectID = chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return "", "", false
+	}
+	itemID = chi.URLParam(r, "itemId")
+	if itemID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_item_id", "Item ID is required")
+		return "", "", false
+	}
+	return projectID, itemID, true
+}
+
+// logLockError logs failures other than the lock being someone else's,
+// which editors run into routinely
+func logLockError(ctx context.Context, err error, itemID, msg string) {
+	if errors.Is(err, core.ErrItemLocked) || errors.Is(err, core.ErrItemLockNotHeld) {
+		log.Ctx(ctx).Debug().Err(err).Str("item_id", itemID).Msg(msg)
+		return
+	}
+	log.Ctx(ctx).Error().Err(err).Str("item_id", itemID).Msg(msg)
+}
+
+// itemLocks returns the unexpired locks on the project's items by item ID,
+// or nil if locking is not enabled
+func (h *ItemHandler) itemLocks(ctx context.Context, projectID string) (map[string]*core.ItemLock, error) {
+	if h.locks == nil {
+		return nil, nil
+	}
+	locks, err := h.locks.ListLocks(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	byItem := make(map[string]*core.ItemLock, len(locks))
+	for _, lock := range locks {
+		byItem[lock.ItemID] = lock
+	}
+	return byItem, nil
+}
+
+// nextExpiry returns when the first of locks expires
+func nextExpiry(locks []*core.ItemLock) (time.Time, bool) {
+	var next time.Time
+	for _, lock := range locks {
+		if next.IsZero() || lock.ExpiresAt.Before(next) {
+			next = lock.ExpiresAt
+		}
+	}
+	return next, !next.IsZero()
+}
+
+func itemLockResponse(lock *core.ItemLock) types.ItemLockResponse {
+	return types.ItemLockResponse{
+		ItemID:     lock.ItemID,
+		HolderID:   lock.HolderID,
+		AcquiredAt: lock.AcquiredAt,
+		ExpiresAt:  lock.ExpiresAt,
+	}
+}
+
+func itemLockListResponse(projectID string, locks []*core.ItemLock) types.ItemLockListResponse {
+	response := types.ItemLockListResponse{
+		ProjectID: projectID,
+		Locks:     make([]types.ItemLockResponse, len(locks)),
+	}
+	for i, lock := range locks {
+		response.Locks[i] = itemLockResponse(lock)
+	}
+	return response
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/collab"
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// MockItemLockService is a mock implementation of ItemLockService
+type MockItemLockService struct {
+	mock.Mock
+}
+
+func (m *MockItemLockService) Lock(ctx context.Context, projectID, itemID string) (*core.ItemLock, error) {
+	args := m.Called(ctx, projectID, itemID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.ItemLock), args.Error(1)
+}
+
+func (m *MockItemLockService) RefreshLock(ctx context.Context, projectID, itemID string) (*core.ItemLock, error) {
+	args := m.Called(ctx, projectID, itemID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.ItemLock), args.Error(1)
+}
+
+func (m *MockItemLockService) Unlock(ctx context.Context, projectID, itemID string) error {
+	args := m.Called(ctx, projectID, itemID)
+	return args.Error(0)
+}
+
+func (m *MockItemLockService) ListLocks(ctx context.Context, projectID string) ([]*core.ItemLock, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*core.ItemLock), args.Error(1)
+}
+
+// lockRequest builds a request to an item's lock route
+func lockRequest(method string) *http.Request {
+	req := httptest.NewRequest(method, "/api/v1/projects/p1/items/i1/lock", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("projectId", "p1")
+	rctx.URLParams.Add("itemId", "i1")
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func newLockTestHandler(locks ItemLockService, events ItemLockEvents) *ItemHandler {
+	handler := NewItemHandler(new(MockItemService), nil)
+	handler.SetLocks(locks, events, time.Minute)
+	return handler
+}
+
+func TestItemHandler_LockItem(t *testing.T) {
+	// Arrange
+	expiresAt := time.Now().Add(core.ItemLockTTL).UTC().Truncate(time.Second)
+	locks := new(MockItemLockService)
+	locks.On("Lock", mock.Anything, "p1", "i1").Return(&core.ItemLock{
+		ItemID: "i1", ProjectID: "p1", HolderID: "alice", AcquiredAt: expiresAt.Add(-core.ItemLockTTL), ExpiresAt: expiresAt,
+	}, nil)
+	handler := newLockTestHandler(locks, collab.NewFeed())
+	rr := newRecorder()
+
+	// Act
+	handler.LockItem(rr, lockRequest(http.MethodPost))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response types.ItemLockResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "i1", response.ItemID)
+	assert.Equal(t, "alice", response.HolderID)
+	assert.True(t, expiresAt.Equal(response.ExpiresAt))
+	locks.AssertExpectations(t)
+}
+
+func TestItemHandler_LockItem_NamesTheHolder(t *testing.T) {
+	// Arrange
+	expiresAt := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+	locks := new(MockItemLockService)
+	locks.On("Lock", mock.Anything, "p1", "i1").Return(nil, &core.ItemLockedError{Lock: &core.ItemLock{
+		ItemID: "i1", ProjectID: "p1", HolderID: "alice", ExpiresAt: expiresAt,
+	}})
+	handler := newLockTestHandler(locks, collab.NewFeed())
+	rr := newRecorder()
+
+	// Act
+	handler.LockItem(rr, lockRequest(http.MethodPost))
+
+	// Assert
+	assert.Equal(t, http.StatusLocked, rr.Code)
+	response := assertErrorResponse(t, rr.Body.Bytes(), "item_locked")
+	require.NotNil(t, response.Error.Details)
+	assert.Equal(t, "held by alice until "+expiresAt.Format(time.RFC3339), *response.Error.Details)
+	assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+}
+
+func TestItemHandler_RefreshItemLock_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "lock expired", err: core.ErrItemLockNotHeld, expectedStatus: http.StatusConflict, expectedCode: "item_lock_not_held"},
+		{name: "item gone", err: core.ErrItemNotFound, expectedStatus: http.StatusNotFound, expectedCode: "item_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			locks := new(MockItemLockService)
+			locks.On("RefreshLock", mock.Anything, "p1", "i1").Return(nil, tt.err)
+			handler := newLockTestHandler(locks, collab.NewFeed())
+			rr := newRecorder()
+
+			// Act
+			handler.RefreshItemLock(rr, lockRequest(http.MethodPut))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+		})
+	}
+}
+
+func TestItemHandler_UnlockItem(t *testing.T) {
+	// Arrange
+	locks := new(MockItemLockService)
+	locks.On("Unlock", mock.Anything, "p1", "i1").Return(nil)
+	handler := newLockTestHandler(locks, collab.NewFeed())
+	rr := newRecorder()
+
+	// Act
+	handler.UnlockItem(rr, lockRequest(http.MethodDelete))
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	locks.AssertExpectations(t)
+}
+
+func TestItemHandler_StreamItemLocks(t *testing.T) {
+	// Arrange
+	held := &core.ItemLock{ItemID: "i1", ProjectID: "p1", HolderID: "alice", ExpiresAt: time.Now().Add(time.Hour)}
+	locks := new(MockItemLockService)
+	locks.On("ListLocks", mock.Anything, "p1").Return([]*core.ItemLock{held}, nil).Once()
+	locks.On("ListLocks", mock.Anything, "p1").Return([]*core.ItemLock{}, nil)
+	feed := collab.NewFeed()
+	handler := newLockTestHandler(locks, feed)
+	r := chi.NewRouter()
+	r.Get("/api/v1/projects/{projectId}/items/locks/events", handler.StreamItemLocks)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Act
+	resp, err := http.Get(server.URL + "/api/v1/projects/p1/items/locks/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	events := bufio.NewReader(resp.Body)
+	first := readSSEData(t, events)
+	feed.PublishItemLocks("p1")
+	second := readSSEData(t, events)
+
+	// Assert
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	require.Len(t, first.Locks, 1)
+	assert.Equal(t, "alice", first.Locks[0].HolderID)
+	assert.Equal(t, "p1", second.ProjectID)
+	assert.Empty(t, second.Locks, "the release is streamed")
+}
+
+func TestItemHandler_StreamItemLocks_ProjectNotFound(t *testing.T) {
+	// Arrange
+	locks := new(MockItemLockService)
+	locks.On("ListLocks", mock.Anything, "p1").Return(nil, core.ErrProjectNotFound)
+	handler := newLockTestHandler(locks, collab.NewFeed())
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/p1/items/locks/events", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("projectId", "p1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := newRecorder()
+
+	// Act
+	handler.StreamItemLocks(rr, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assertErrorResponse(t, rr.Body.Bytes(), "project_not_found")
+}
+
+func TestItemHandler_ListItems_IncludesLocks(t *testing.T) {
+	// Arrange
+	service := new(MockItemService)
+	service.On("List", mock.Anything, "p1", core.ItemListOptions{Limit: 50}).Return(&core.ItemPage{Items: []*core.Item{
+		{ID: "i1", ProjectID: "p1", Type: types.ItemTypeTitle, Title: "Locked"},
+		{ID: "i2", ProjectID: "p1", Type: types.ItemTypeTitle, Title: "Free", Position: 1},
+	}, Total: 2}, nil)
+	locks := new(MockItemLockService)
+	locks.On("ListLocks", mock.Anything, "p1").Return([]*core.ItemLock{
+		{ItemID: "i1", ProjectID: "p1", HolderID: "alice", ExpiresAt: time.Now().Add(time.Minute)},
+	}, nil)
+	handler := NewItemHandler(service, nil)
+	handler.SetLocks(locks, collab.NewFeed(), time.Minute)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/p1/items", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("projectId", "p1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := newRecorder()
+
+	// Act
+	handler.ListItems(rr, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response types.ItemListResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Len(t, response.Items, 2)
+	require.NotNil(t, response.Items[0].Lock)
+	assert.Equal(t, "alice", response.Items[0].Lock.HolderID)
+	assert.Nil(t, response.Items[1].Lock)
+}
+
+// readSSEData reads events until a locks event and decodes its data
+func readSSEData(t *testing.T, events *bufio.Reader) types.ItemLockListResponse {
+	t.Helper()
+	for {
+		line, err := events.ReadString('\n')
+		require.NoError(t, err)
+		if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: "); ok {
+			var response types.ItemLockListResponse
+			require.NoError(t, json.Unmarshal([]byte(data), &response))
+			return response
+		}
+	}
+}
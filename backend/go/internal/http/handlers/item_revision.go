@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/pagination"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// ListItemRevisions handles GET /api/v1/projects/{projectId}/items/{itemId}/revisions
+// @Summary List item revisions
+// @Description Returns a page of the earlier states of an item of the project, newest first. Every update or delete of the item keeps the item as it was, with when and by whom it was changed; each item keeps its newest ITEM_REVISION_LIMIT revisions, 50 by default.
+// @Tags Items
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param itemId path string true "Item ID" format(uuid)
+// @Param limit query int false "Maximum number of revisions to return" minimum(1) maximum(100) default(20)
+// @Param offset query int false "Number of revisions to skip" minimum(0) default(0)
+// @Success 200 {object} types.ItemRevisionListResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_pagination"
+// @Failure 404 {object} types.ErrorResponse "item_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/{itemId}/revisions [get]
+func (h *ItemHandler) ListItemRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := chi.URLParam(r, "projectId")
+	itemID := chi.URLParam(r, "itemId")
+
+	page, err := pagination.Parse(r, pagination.Page{Limit: 20}, 100)
+	if err != nil {
+		pagination.WriteError(w, err)
+		return
+	}
+
+	revisions, total, err := h.service.ListRevisions(ctx, projectID, itemID, page.Limit, page.Offset)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Str("item_id", itemID).Msg("failed to list item revisions")
+		respondDomainError(w, err)
+		return
+	}
+
+	response := types.ItemRevisionListResponse{
+		ItemID:    itemID,
+		Revisions: make([]types.ItemRevisionResponse, 0, len(revisions)),
+		Total:     total,
+		Limit:     page.Limit,
+		Offset:    page.Offset,
+	}
+	for _, revision := range revisions {
+		response.Revisions = append(response.Revisions, types.ItemRevisionResponse{
+			Revision:  revision.Number,
+			Item:      itemResponse(revision.Item),
+			ChangedAt: revision.ChangedAt,
+			ChangedBy: revision.ChangedBy,
+		})
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// RestoreItemRevision handles POST /api/v1/projects/{projectId}/items/{itemId}/revisions/{revision}/restore
+// @Summary Restore item revision
+// @Description Writes a revision of an item of the project back as a new update, which keeps the item's current state as a revision in turn. The item keeps its current position. Fails like Update item, e.g. with item_locked while another user holds the item's edit lock.
+// @Tags Items
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param itemId path string true "Item ID" format(uuid)
+// @Param revision path int true "Revision number"
+// @Success 200 {object} types.ItemResponse
+// @Failure 404 {object} types.ErrorResponse "item_not_found, item_revision_not_found"
+// @Failure 409 {object} types.ErrorResponse "concurrent_modification"
+// @Failure 422 {object} types.ErrorResponse "invalid_content"
+// @Failure 423 {object} types.ErrorResponse "item_locked"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/items/{itemId}/revisions/{revision}/restore [post]
+func (h *ItemHandler) RestoreItemRevision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := chi.URLParam(r, "projectId")
+	itemID := chi.URLParam(r, "itemId")
+
+	// Revisions are numbered from 1; anything else names none
+	number, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil || number < 1 {
+		respondDomainError(w, core.ErrItemRevisionNotFound)
+		return
+	}
+
+	item, err := h.service.RestoreRevision(ctx, projectID, itemID, number)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Str("item_id", itemID).Int("revision", number).Msg("failed to restore item revision")
+		respondDomainError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", itemETag(item))
+	respond.JSON(w, http.StatusOK, itemResponse(item))
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/types"
+)
+
+func newItemRevisionRequest(method, target string, params map[string]string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	rctx := chi.NewRouteContext()
+	for key, value := range params {
+		rctx.URLParams.Add(key, value)
+	}
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestItemHandler_ListItemRevisions(t *testing.T) {
+	changedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		query            string
+		setupMock        func(*MockItemService)
+		expectedStatus   int
+		validateResponse func(t *testing.T, body []byte)
+	}{
+		{
+			name:  "newest first with the page",
+			query: "?limit=2&offset=1",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("ListRevisions", mock.Anything, "test-project-id", "test-item-id", 2, 1).Return([]*core.ItemRevision{
+					{
+						ItemID:    "test-item-id",
+						Number:    3,
+						Item:      &core.Item{ID: "test-item-id", ProjectID: "test-project-id", Type: types.ItemTypeTitle, Title: "Third", Version: 3},
+						ChangedAt: changedAt,
+						ChangedBy: "user-1",
+					},
+					{
+						ItemID:    "test-item-id",
+						Number:    2,
+						Item:      &core.Item{ID: "test-item-id", ProjectID: "test-project-id", Type: types.ItemTypeTitle, Title: "Second", Version: 2},
+						ChangedAt: changedAt.Add(-time.Hour),
+					},
+				}, 4, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResponse: func(t *testing.T, body []byte) {
+				var response types.ItemRevisionListResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, "test-item-id", response.ItemID)
+				assert.Equal(t, 4, response.Total)
+				assert.Equal(t, 2, response.Limit)
+				assert.Equal(t, 1, response.Offset)
+				require.Len(t, response.Revisions, 2)
+				assert.Equal(t, 3, response.Revisions[0].Revision)
+				assert.Equal(t, "Third", response.Revisions[0].Item.Title)
+				assert.Equal(t, "user-1", response.Revisions[0].ChangedBy)
+				assert.True(t, changedAt.Equal(response.Revisions[0].ChangedAt))
+
+				var raw struct {
+					Revisions []map[string]interface{} `json:"revisions"`
+				}
+				require.NoError(t, json.Unmarshal(body, &raw))
+				assert.NotContains(t, raw.Revisions[1], "changed_by", "changes no user made leave changed_by out")
+			},
+		},
+		{
+			name:  "no revisions",
+			query: "",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("ListRevisions", mock.Anything, "test-project-id", "test-item-id", 20, 0).Return([]*core.ItemRevision{}, 0, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResponse: func(t *testing.T, body []byte) {
+				assert.Contains(t, string(body), `"revisions":[]`)
+			},
+		},
+		{
+			name:           "limit beyond the maximum",
+			query:          "?limit=500",
+			setupMock:      func(mockService *MockItemService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "invalid_pagination")
+			},
+		},
+		{
+			name:  "item not found",
+			query: "",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("ListRevisions", mock.Anything, "test-project-id", "test-item-id", 20, 0).Return(nil, 0, core.ErrItemNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "item_not_found")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockItemService{}
+			tt.setupMock(mockService)
+
+			handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
+
+			req := newItemRevisionRequest(http.MethodGet, "/api/v1/projects/{projectId}/items/{itemId}/revisions"+tt.query,
+				map[string]string{"projectId": "test-project-id", "itemId": "test-item-id"})
+
+			rr := newRecorder()
+			handler.ListItemRevisions(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.validateResponse != nil {
+				tt.validateResponse(t, rr.Body.Bytes())
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestItemHandler_RestoreItemRevision(t *testing.T) {
+	tests := []struct {
+		name             string
+		revision         string
+		setupMock        func(*MockItemService)
+		expectedStatus   int
+		validateResponse func(t *testing.T, body []byte, header http.Header)
+	}{
+		{
+			name:     "successful restore",
+			revision: "2",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("RestoreRevision", mock.Anything, "test-project-id", "test-item-id", 2).Return(&core.Item{
+					ID:        "test-item-id",
+					ProjectID: "test-project-id",
+					Type:      types.ItemTypeTitle,
+					Title:     "Restored",
+					Position:  4,
+					CreatedAt: time.Now(),
+					UpdatedAt: time.Now(),
+					Version:   6,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResponse: func(t *testing.T, body []byte, header http.Header) {
+				var response types.ItemResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, "Restored", response.Title)
+				assert.Equal(t, 6, response.Version)
+				assert.Contains(t, header.Get("ETag"), `"v6-`)
+			},
+		},
+		{
+			name:     "revision not kept",
+			revision: "9",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("RestoreRevision", mock.Anything, "test-project-id", "test-item-id", 9).Return(nil, core.ErrItemRevisionNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte, header http.Header) {
+				assertErrorResponse(t, body, "item_revision_not_found")
+			},
+		},
+		{
+			name:           "revision that is not a number",
+			revision:       "latest",
+			setupMock:      func(mockService *MockItemService) {},
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte, header http.Header) {
+				assertErrorResponse(t, body, "item_revision_not_found")
+			},
+		},
+		{
+			name:     "another write came first",
+			revision: "1",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("RestoreRevision", mock.Anything, "test-project-id", "test-item-id", 1).Return(nil, core.ErrConcurrentModification)
+			},
+			expectedStatus: http.StatusConflict,
+			validateResponse: func(t *testing.T, body []byte, header http.Header) {
+				assertErrorResponse(t, body, "concurrent_modification")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockItemService{}
+			tt.setupMock(mockService)
+
+			handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
+
+			req := newItemRevisionRequest(http.MethodPost, "/api/v1/projects/{projectId}/items/{itemId}/revisions/{revision}/restore",
+				map[string]string{"projectId": "test-project-id", "itemId": "test-item-id", "revision": tt.revision})
+
+			rr := newRecorder()
+			handler.RestoreItemRevision(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.validateResponse != nil {
+				tt.validateResponse(t, rr.Body.Bytes(), rr.Header())
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
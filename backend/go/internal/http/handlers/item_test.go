@@ -4,18 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
 	"github.com/provemyself/backend/internal/types"
 )
 
@@ -32,8 +34,13 @@ func (m *MockItemService) Create(ctx context.Context, projectID string, itemType
 	return args.Get(0).(*core.Item), args.Error(1)
 }
 
-func (m *MockItemService) GetByID(ctx context.Context, id string) (*core.Item, error) {
-	args := m.Called(ctx, id)
+func (m *MockItemService) CreateMany(ctx context.Context, projectID string, inputs []core.ItemInput) ([]*core.Item, error) {
+	args := m.Called(ctx, projectID, inputs)
+	return args.Get(0).([]*core.Item), args.Error(1)
+}
+
+func (m *MockItemService) GetByID(ctx context.Context, projectID, id string) (*core.Item, error) {
+	args := m.Called(ctx, projectID, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -48,19 +55,72 @@ func (m *MockItemService) ListByProject(ctx context.Context, projectID string) (
 	return args.Get(0).([]*core.Item), args.Error(1)
 }
 
-func (m *MockItemService) Update(ctx context.Context, id string, itemType types.ItemType, title string, content interface{}, position int, required bool, points *int, explanation *string) (*core.Item, error) {
-	args := m.Called(ctx, id, itemType, title, content, position, required, points, explanation)
+func (m *MockItemService) List(ctx context.Context, projectID string, opts core.ItemListOptions) (*core.ItemPage, error) {
+	args := m.Called(ctx, projectID, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.ItemPage), args.Error(1)
+}
+
+func (m *MockItemService) Duplicate(ctx context.Context, projectID, id string) (*core.Item, error) {
+	args := m.Called(ctx, projectID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.Item), args.Error(1)
+}
+
+func (m *MockItemService) Update(ctx context.Context, projectID, id string, itemType types.ItemType, title string, content interface{}, position int, required bool, points *int, explanation *string, version *int) (*core.Item, error) {
+	args := m.Called(ctx, projectID, id, itemType, title, content, position, required, points, explanation, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.Item), args.Error(1)
+}
+
+func (m *MockItemService) Patch(ctx context.Context, projectID, id string, patch core.ItemPatch) (*core.Item, error) {
+	args := m.Called(ctx, projectID, id, patch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.Item), args.Error(1)
+}
+
+func (m *MockItemService) Delete(ctx context.Context, projectID, id string) error {
+	args := m.Called(ctx, projectID, id)
+	return args.Error(0)
+}
+
+func (m *MockItemService) Restore(ctx context.Context, projectID, id string) (*core.Item, error) {
+	args := m.Called(ctx, projectID, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*core.Item), args.Error(1)
 }
 
-func (m *MockItemService) Delete(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
+func (m *MockItemService) UpdatePositions(ctx context.Context, projectID string, updates []core.PositionUpdate) error {
+	args := m.Called(ctx, projectID, updates)
 	return args.Error(0)
 }
 
+func (m *MockItemService) ListRevisions(ctx context.Context, projectID, id string, limit, offset int) ([]*core.ItemRevision, int, error) {
+	args := m.Called(ctx, projectID, id, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*core.ItemRevision), args.Int(1), args.Error(2)
+}
+
+func (m *MockItemService) RestoreRevision(ctx context.Context, projectID, id string, number int) (*core.Item, error) {
+	args := m.Called(ctx, projectID, id, number)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.Item), args.Error(1)
+}
+
 func TestItemHandler_CreateItem(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -115,9 +175,7 @@ func TestItemHandler_CreateItem(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 			validateResponse: func(t *testing.T, body []byte) {
-				var errorResponse types.ErrorResponse
-				require.NoError(t, json.Unmarshal(body, &errorResponse))
-				assert.Equal(t, "invalid_request_body", errorResponse.Error.Code)
+				assertErrorResponse(t, body, "invalid_request_body")
 			},
 		},
 		{
@@ -133,9 +191,7 @@ func TestItemHandler_CreateItem(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 			validateResponse: func(t *testing.T, body []byte) {
-				var errorResponse types.ErrorResponse
-				require.NoError(t, json.Unmarshal(body, &errorResponse))
-				assert.Equal(t, "validation_failed", errorResponse.Error.Code)
+				assert.Equal(t, map[string]string{"title": "required"}, assertValidationErrors(t, body))
 			},
 		},
 		{
@@ -151,9 +207,7 @@ func TestItemHandler_CreateItem(t *testing.T) {
 			},
 			expectedStatus: http.StatusNotFound,
 			validateResponse: func(t *testing.T, body []byte) {
-				var errorResponse types.ErrorResponse
-				require.NoError(t, json.Unmarshal(body, &errorResponse))
-				assert.Equal(t, "project_not_found", errorResponse.Error.Code)
+				assertErrorResponse(t, body, "project_not_found")
 			},
 		},
 		{
@@ -169,9 +223,7 @@ func TestItemHandler_CreateItem(t *testing.T) {
 			},
 			expectedStatus: http.StatusUnprocessableEntity,
 			validateResponse: func(t *testing.T, body []byte) {
-				var errorResponse types.ErrorResponse
-				require.NoError(t, json.Unmarshal(body, &errorResponse))
-				assert.Equal(t, "title_too_short", errorResponse.Error.Code)
+				assertErrorResponse(t, body, "title_too_short")
 			},
 		},
 	}
@@ -181,7 +233,7 @@ func TestItemHandler_CreateItem(t *testing.T) {
 			mockService := &MockItemService{}
 			tt.setupMock(mockService)
 
-			handler := NewItemHandler(mockService, validator.New())
+			handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
 
 			var body []byte
 			var err error
@@ -198,7 +250,7 @@ func TestItemHandler_CreateItem(t *testing.T) {
 			rctx.URLParams.Add("projectId", tt.projectID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-			rr := httptest.NewRecorder()
+			rr := newRecorder()
 			handler.CreateItem(rr, req)
 
 			assert.Equal(t, tt.expectedStatus, rr.Code)
@@ -215,6 +267,7 @@ func TestItemHandler_ListItems(t *testing.T) {
 	tests := []struct {
 		name           string
 		projectID      string
+		query          string
 		setupMock      func(*MockItemService)
 		expectedStatus int
 		validateResponse func(t *testing.T, body []byte)
@@ -243,7 +296,7 @@ func TestItemHandler_ListItems(t *testing.T) {
 						UpdatedAt: time.Now(),
 					},
 				}
-				mockService.On("ListByProject", mock.Anything, "test-project-id").Return(items, nil)
+				mockService.On("List", mock.Anything, "test-project-id", core.ItemListOptions{Limit: 50}).Return(&core.ItemPage{Items: items, Total: 2}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			validateResponse: func(t *testing.T, body []byte) {
@@ -256,17 +309,91 @@ func TestItemHandler_ListItems(t *testing.T) {
 				assert.Equal(t, "test-project-id", response.ProjectID)
 			},
 		},
+		{
+			name:      "full-text search keeps the ranking",
+			projectID: "test-project-id",
+			query:     "?search=%20lighthouse%20",
+			setupMock: func(mockService *MockItemService) {
+				items := []*core.Item{
+					{ID: "title-match", ProjectID: "test-project-id", Type: types.ItemTypeChoice, Title: "Lighthouse keepers", Position: 3},
+					{ID: "choice-match", ProjectID: "test-project-id", Type: types.ItemTypeChoice, Title: "Coastal signals", Position: 0},
+				}
+				mockService.On("List", mock.Anything, "test-project-id", core.ItemListOptions{Search: "lighthouse", Limit: 50}).Return(&core.ItemPage{Items: items, Total: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResponse: func(t *testing.T, body []byte) {
+				var response types.ItemListResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, 2, response.Total)
+				require.Len(t, response.Items, 2)
+				assert.Equal(t, "title-match", response.Items[0].ID)
+				assert.Equal(t, "choice-match", response.Items[1].ID)
+			},
+		},
+		{
+			name:      "filters and page are passed to the store",
+			projectID: "test-project-id",
+			query:     "?type=choice&required=true&search=%20bl%20&limit=1&offset=1",
+			setupMock: func(mockService *MockItemService) {
+				required := true
+				items := []*core.Item{
+					{ID: "item2", ProjectID: "test-project-id", Type: types.ItemTypeChoice, Title: "Blue or green?", Position: 4, Required: true},
+				}
+				mockService.On("List", mock.Anything, "test-project-id", core.ItemListOptions{
+					Type: types.ItemTypeChoice, Required: &required, Search: "bl", Limit: 1, Offset: 1,
+				}).Return(&core.ItemPage{Items: items, Total: 7}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResponse: func(t *testing.T, body []byte) {
+				var response types.ItemListResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, 7, response.Total, "the total counts every match, not the page")
+				require.Len(t, response.Items, 1)
+				assert.Equal(t, "item2", response.Items[0].ID)
+				assert.Equal(t, 1, response.Limit)
+				assert.Equal(t, 1, response.Offset)
+			},
+		},
+		{
+			name:      "sort",
+			projectID: "test-project-id",
+			query:     "?sort=-updated_at",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("List", mock.Anything, "test-project-id", core.ItemListOptions{Sort: core.ItemSortUpdatedAtDesc, Limit: 50}).Return(&core.ItemPage{Items: []*core.Item{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown sort field",
+			projectID:      "test-project-id",
+			query:          "?sort=points",
+			setupMock:      func(mockService *MockItemService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateResponse: func(t *testing.T, body []byte) {
+				response := assertErrorResponse(t, body, "invalid_sort")
+				require.NotNil(t, response.Error.Details)
+				assert.Contains(t, *response.Error.Details, "created_at, position, title, updated_at")
+			},
+		},
+		{
+			name:           "invalid type filter",
+			projectID:      "test-project-id",
+			query:          "?type=essay",
+			setupMock:      func(mockService *MockItemService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "invalid_type_filter")
+			},
+		},
 		{
 			name:      "project not found",
 			projectID: "non-existent-project",
 			setupMock: func(mockService *MockItemService) {
-				mockService.On("ListByProject", mock.Anything, "non-existent-project").Return(([]*core.Item)(nil), core.ErrProjectNotFound)
+				mockService.On("List", mock.Anything, "non-existent-project", core.ItemListOptions{Limit: 50}).Return(nil, core.ErrProjectNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 			validateResponse: func(t *testing.T, body []byte) {
-				var errorResponse types.ErrorResponse
-				require.NoError(t, json.Unmarshal(body, &errorResponse))
-				assert.Equal(t, "project_not_found", errorResponse.Error.Code)
+				assertErrorResponse(t, body, "project_not_found")
 			},
 		},
 	}
@@ -276,16 +403,16 @@ func TestItemHandler_ListItems(t *testing.T) {
 			mockService := &MockItemService{}
 			tt.setupMock(mockService)
 
-			handler := NewItemHandler(mockService, validator.New())
+			handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
 
-			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/{projectId}/items", nil)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/{projectId}/items"+tt.query, nil)
 			
 			// Setup chi context with projectId parameter
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("projectId", tt.projectID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-			rr := httptest.NewRecorder()
+			rr := newRecorder()
 			handler.ListItems(rr, req)
 
 			assert.Equal(t, tt.expectedStatus, rr.Code)
@@ -298,17 +425,106 @@ func TestItemHandler_ListItems(t *testing.T) {
 	}
 }
 
+func TestItemHandler_ListItemsCSV(t *testing.T) {
+	// Arrange
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	points := 5
+	mockService := &MockItemService{}
+	mockService.On("List", mock.Anything, "p1", core.ItemListOptions{Limit: 1}).Return(&core.ItemPage{Items: []*core.Item{
+		{
+			ID:        "item1",
+			ProjectID: "p1",
+			Type:      types.ItemTypeChoice,
+			Title:     "Pick one, quickly",
+			Content:   json.RawMessage(`{"choices": ["a", "b"]}`),
+			Points:    &points,
+			CreatedAt: created,
+			UpdatedAt: created,
+		},
+	}, Total: 2}, nil)
+	mockService.On("List", mock.Anything, "p1", core.ItemListOptions{Limit: 1, Offset: 1}).Return(&core.ItemPage{Items: []*core.Item{
+		{ID: "item2", ProjectID: "p1", Type: types.ItemTypeTitle, Title: "Intro", Position: 1, Required: true, CreatedAt: created, UpdatedAt: created},
+	}, Total: 2}, nil)
+	handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/p1/items?limit=1&offset=1", nil)
+	req.Header.Set("Accept", "text/csv")
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("projectId", "p1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := newRecorder()
+
+	// Act
+	handler.ListItems(rr, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "id,project_id,type,title,position,required,points,explanation,content,created_at,updated_at\n"+
+		"item2,p1,title,Intro,1,true,,,,2024-03-01T09:30:00Z,2024-03-01T09:30:00Z\n", rr.Body.String())
+
+	// The other item, with quoting and JSON content
+	req.URL.RawQuery = "limit=1"
+	rr = newRecorder()
+	handler.ListItems(rr, req)
+	assert.Equal(t, "id,project_id,type,title,position,required,points,explanation,content,created_at,updated_at\n"+
+		`item1,p1,choice,"Pick one, quickly",0,false,5,,"{""choices"":[""a"",""b""]}",2024-03-01T09:30:00Z,2024-03-01T09:30:00Z`+"\n", rr.Body.String())
+}
+
+func TestItemHandler_ListItems_RejectsInvalidPagination(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedField string
+		expectedTag   string
+	}{
+		{"zero limit", "limit=0", "limit", "gte"},
+		{"limit above maximum", "limit=5000", "limit", "lte"},
+		{"negative offset", "offset=-3", "offset", "gte"},
+		{"non-integer limit", "limit=abc", "limit", "integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockService := &MockItemService{}
+			handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/p1/items?"+tt.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "p1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := newRecorder()
+
+			// Act
+			handler.ListItems(rr, req)
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			var response types.ValidationErrorResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, types.ErrorCodeInvalidPagination, response.Error.Code)
+			require.Len(t, response.Error.Errors, 1)
+			assert.Equal(t, tt.expectedField, response.Error.Errors[0].Field)
+			assert.Equal(t, tt.expectedTag, response.Error.Errors[0].Tag)
+			mockService.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
 func TestItemHandler_GetItem(t *testing.T) {
 	tests := []struct {
 		name           string
+		projectID      string
 		itemID         string
 		setupMock      func(*MockItemService)
 		expectedStatus int
 		validateResponse func(t *testing.T, body []byte)
 	}{
 		{
-			name:   "successful get",
-			itemID: "test-item-id",
+			name:      "successful get",
+			projectID: "test-project-id",
+			itemID:    "test-item-id",
 			setupMock: func(mockService *MockItemService) {
 				item := &core.Item{
 					ID:        "test-item-id",
@@ -319,7 +535,7 @@ func TestItemHandler_GetItem(t *testing.T) {
 					CreatedAt: time.Now(),
 					UpdatedAt: time.Now(),
 				}
-				mockService.On("GetByID", mock.Anything, "test-item-id").Return(item, nil)
+				mockService.On("GetByID", mock.Anything, "test-project-id", "test-item-id").Return(item, nil)
 			},
 			expectedStatus: http.StatusOK,
 			validateResponse: func(t *testing.T, body []byte) {
@@ -331,16 +547,27 @@ func TestItemHandler_GetItem(t *testing.T) {
 			},
 		},
 		{
-			name:   "item not found",
-			itemID: "non-existent-item",
+			name:      "item not found",
+			projectID: "test-project-id",
+			itemID:    "non-existent-item",
 			setupMock: func(mockService *MockItemService) {
-				mockService.On("GetByID", mock.Anything, "non-existent-item").Return((*core.Item)(nil), core.ErrItemNotFound)
+				mockService.On("GetByID", mock.Anything, "test-project-id", "non-existent-item").Return((*core.Item)(nil), core.ErrItemNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 			validateResponse: func(t *testing.T, body []byte) {
-				var errorResponse types.ErrorResponse
-				require.NoError(t, json.Unmarshal(body, &errorResponse))
-				assert.Equal(t, "item_not_found", errorResponse.Error.Code)
+				assertErrorResponse(t, body, "item_not_found")
+			},
+		},
+		{
+			name:      "item of another project",
+			projectID: "other-project-id",
+			itemID:    "test-item-id",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("GetByID", mock.Anything, "other-project-id", "test-item-id").Return((*core.Item)(nil), core.ErrItemNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "item_not_found")
 			},
 		},
 	}
@@ -350,16 +577,17 @@ func TestItemHandler_GetItem(t *testing.T) {
 			mockService := &MockItemService{}
 			tt.setupMock(mockService)
 
-			handler := NewItemHandler(mockService, validator.New())
+			handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
 
 			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/{projectId}/items/{itemId}", nil)
 			
-			// Setup chi context with itemId parameter
+			// Setup chi context with projectId and itemId parameters
 			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", tt.projectID)
 			rctx.URLParams.Add("itemId", tt.itemID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-			rr := httptest.NewRecorder()
+			rr := newRecorder()
 			handler.GetItem(rr, req)
 
 			assert.Equal(t, tt.expectedStatus, rr.Code)
@@ -375,6 +603,7 @@ func TestItemHandler_GetItem(t *testing.T) {
 func TestItemHandler_UpdateItem(t *testing.T) {
 	tests := []struct {
 		name           string
+		projectID      string
 		itemID         string
 		requestBody    interface{}
 		setupMock      func(*MockItemService)
@@ -382,8 +611,9 @@ func TestItemHandler_UpdateItem(t *testing.T) {
 		validateResponse func(t *testing.T, body []byte)
 	}{
 		{
-			name:   "successful update",
-			itemID: "test-item-id",
+			name:      "successful update",
+			projectID: "test-project-id",
+			itemID:    "test-item-id",
 			requestBody: types.UpdateItemRequest{
 				Type:     types.ItemTypeChoice,
 				Title:    "Updated Question",
@@ -401,7 +631,7 @@ func TestItemHandler_UpdateItem(t *testing.T) {
 					CreatedAt: time.Now(),
 					UpdatedAt: time.Now(),
 				}
-				mockService.On("Update", mock.Anything, "test-item-id", types.ItemTypeChoice, "Updated Question", mock.Anything, 1, false, (*int)(nil), (*string)(nil)).Return(updatedItem, nil)
+				mockService.On("Update", mock.Anything, "test-project-id", "test-item-id", types.ItemTypeChoice, "Updated Question", mock.Anything, 1, false, (*int)(nil), (*string)(nil), (*int)(nil)).Return(updatedItem, nil)
 			},
 			expectedStatus: http.StatusOK,
 			validateResponse: func(t *testing.T, body []byte) {
@@ -414,21 +644,37 @@ func TestItemHandler_UpdateItem(t *testing.T) {
 			},
 		},
 		{
-			name:   "item not found",
-			itemID: "non-existent-item",
+			name:      "item not found",
+			projectID: "test-project-id",
+			itemID:    "non-existent-item",
+			requestBody: types.UpdateItemRequest{
+				Type:     types.ItemTypeChoice,
+				Title:    "Updated Question",
+				Position: 0,
+			},
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("Update", mock.Anything, "test-project-id", "non-existent-item", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return((*core.Item)(nil), core.ErrItemNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "item_not_found")
+			},
+		},
+		{
+			name:      "item of another project",
+			projectID: "other-project-id",
+			itemID:    "test-item-id",
 			requestBody: types.UpdateItemRequest{
 				Type:     types.ItemTypeChoice,
 				Title:    "Updated Question",
 				Position: 0,
 			},
 			setupMock: func(mockService *MockItemService) {
-				mockService.On("Update", mock.Anything, "non-existent-item", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return((*core.Item)(nil), core.ErrItemNotFound)
+				mockService.On("Update", mock.Anything, "other-project-id", "test-item-id", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return((*core.Item)(nil), core.ErrItemNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 			validateResponse: func(t *testing.T, body []byte) {
-				var errorResponse types.ErrorResponse
-				require.NoError(t, json.Unmarshal(body, &errorResponse))
-				assert.Equal(t, "item_not_found", errorResponse.Error.Code)
+				assertErrorResponse(t, body, "item_not_found")
 			},
 		},
 	}
@@ -438,7 +684,7 @@ func TestItemHandler_UpdateItem(t *testing.T) {
 			mockService := &MockItemService{}
 			tt.setupMock(mockService)
 
-			handler := NewItemHandler(mockService, validator.New())
+			handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
 
 			body, err := json.Marshal(tt.requestBody)
 			require.NoError(t, err)
@@ -446,12 +692,13 @@ func TestItemHandler_UpdateItem(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPut, "/api/v1/projects/{projectId}/items/{itemId}", bytes.NewBuffer(body))
 			req.Header.Set("Content-Type", "application/json")
 			
-			// Setup chi context with itemId parameter
+			// Setup chi context with projectId and itemId parameters
 			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", tt.projectID)
 			rctx.URLParams.Add("itemId", tt.itemID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-			rr := httptest.NewRecorder()
+			rr := newRecorder()
 			handler.UpdateItem(rr, req)
 
 			assert.Equal(t, tt.expectedStatus, rr.Code)
@@ -464,33 +711,174 @@ func TestItemHandler_UpdateItem(t *testing.T) {
 	}
 }
 
+func TestItemHandler_PatchItem(t *testing.T) {
+	required := true
+	choice := types.ItemTypeChoice
+	title := "Renamed"
+
+	tests := []struct {
+		name             string
+		body             string
+		setupMock        func(*MockItemService)
+		expectedStatus   int
+		validateResponse func(t *testing.T, body []byte)
+	}{
+		{
+			name: "changes one field",
+			body: `{"required": true}`,
+			setupMock: func(mockService *MockItemService) {
+				patched := &core.Item{ID: "test-item-id", ProjectID: "test-project-id", Type: types.ItemTypeTitle, Title: "Intro", Required: true}
+				mockService.On("Patch", mock.Anything, "test-project-id", "test-item-id", core.ItemPatch{Required: &required}).Return(patched, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResponse: func(t *testing.T, body []byte) {
+				var response types.ItemResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, "Intro", response.Title)
+				assert.True(t, response.Required)
+			},
+		},
+		{
+			name: "null fields are left out",
+			body: `{"title": "Renamed", "content": null, "points": null}`,
+			setupMock: func(mockService *MockItemService) {
+				patched := &core.Item{ID: "test-item-id", ProjectID: "test-project-id", Type: types.ItemTypeTitle, Title: "Renamed"}
+				mockService.On("Patch", mock.Anything, "test-project-id", "test-item-id", core.ItemPatch{Title: &title}).Return(patched, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "stale version",
+			body: `{"title": "Renamed", "version": 2}`,
+			setupMock: func(mockService *MockItemService) {
+				current := &core.Item{ID: "test-item-id", ProjectID: "test-project-id", Type: types.ItemTypeTitle, Title: "Edited elsewhere", Version: 3}
+				mockService.On("Patch", mock.Anything, "test-project-id", "test-item-id", core.ItemPatch{Title: &title, Version: intPtr(2)}).Return(nil, core.ErrVersionConflict)
+				mockService.On("GetByID", mock.Anything, "test-project-id", "test-item-id").Return(current, nil)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			validateResponse: func(t *testing.T, body []byte) {
+				var response struct {
+					Error struct {
+						Code    string             `json:"code"`
+						Current types.ItemResponse `json:"current"`
+					} `json:"error"`
+				}
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, types.ErrorCodeVersionConflict, response.Error.Code)
+				assert.Equal(t, "Edited elsewhere", response.Error.Current.Title)
+				assert.Equal(t, 3, response.Error.Current.Version)
+			},
+		},
+		{
+			name:           "unknown type",
+			body:           `{"type": "essay"}`,
+			setupMock:      func(mockService *MockItemService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateResponse: func(t *testing.T, body []byte) {
+				assert.Equal(t, map[string]string{"type": "oneof"}, assertValidationErrors(t, body))
+			},
+		},
+		{
+			name:           "empty title",
+			body:           `{"title": ""}`,
+			setupMock:      func(mockService *MockItemService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateResponse: func(t *testing.T, body []byte) {
+				assert.Equal(t, map[string]string{"title": "min"}, assertValidationErrors(t, body))
+			},
+		},
+		{
+			name: "type the item's content does not fit",
+			body: `{"type": "choice"}`,
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("Patch", mock.Anything, "test-project-id", "test-item-id", core.ItemPatch{Type: &choice}).
+					Return(nil, fmt.Errorf("%w: invalid choice content structure", core.ErrItemInvalidContent))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "invalid_content")
+			},
+		},
+		{
+			name: "item of another project",
+			body: `{"required": true}`,
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("Patch", mock.Anything, "test-project-id", "test-item-id", core.ItemPatch{Required: &required}).Return(nil, core.ErrItemNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "item_not_found")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockService := &MockItemService{}
+			tt.setupMock(mockService)
+			handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/projects/test-project-id/items/test-item-id", bytes.NewBufferString(tt.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "test-project-id")
+			rctx.URLParams.Add("itemId", "test-item-id")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := newRecorder()
+
+			// Act
+			handler.PatchItem(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.validateResponse != nil {
+				tt.validateResponse(t, rr.Body.Bytes())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestItemHandler_DeleteItem(t *testing.T) {
 	tests := []struct {
 		name           string
+		projectID      string
 		itemID         string
 		setupMock      func(*MockItemService)
 		expectedStatus int
 		validateResponse func(t *testing.T, body []byte)
 	}{
 		{
-			name:   "successful delete",
-			itemID: "test-item-id",
+			name:      "successful delete",
+			projectID: "test-project-id",
+			itemID:    "test-item-id",
 			setupMock: func(mockService *MockItemService) {
-				mockService.On("Delete", mock.Anything, "test-item-id").Return(nil)
+				mockService.On("Delete", mock.Anything, "test-project-id", "test-item-id").Return(nil)
 			},
 			expectedStatus: http.StatusNoContent,
 		},
 		{
-			name:   "item not found",
-			itemID: "non-existent-item",
+			name:      "item not found",
+			projectID: "test-project-id",
+			itemID:    "non-existent-item",
 			setupMock: func(mockService *MockItemService) {
-				mockService.On("Delete", mock.Anything, "non-existent-item").Return(core.ErrItemNotFound)
+				mockService.On("Delete", mock.Anything, "test-project-id", "non-existent-item").Return(core.ErrItemNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 			validateResponse: func(t *testing.T, body []byte) {
-				var errorResponse types.ErrorResponse
-				require.NoError(t, json.Unmarshal(body, &errorResponse))
-				assert.Equal(t, "item_not_found", errorResponse.Error.Code)
+				assertErrorResponse(t, body, "item_not_found")
+			},
+		},
+		{
+			name:      "item of another project",
+			projectID: "other-project-id",
+			itemID:    "test-item-id",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("Delete", mock.Anything, "other-project-id", "test-item-id").Return(core.ErrItemNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "item_not_found")
 			},
 		},
 	}
@@ -500,16 +888,17 @@ func TestItemHandler_DeleteItem(t *testing.T) {
 			mockService := &MockItemService{}
 			tt.setupMock(mockService)
 
-			handler := NewItemHandler(mockService, validator.New())
+			handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
 
 			req := httptest.NewRequest(http.MethodDelete, "/api/v1/projects/{projectId}/items/{itemId}", nil)
 			
-			// Setup chi context with itemId parameter
+			// Setup chi context with projectId and itemId parameters
 			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", tt.projectID)
 			rctx.URLParams.Add("itemId", tt.itemID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-			rr := httptest.NewRecorder()
+			rr := newRecorder()
 			handler.DeleteItem(rr, req)
 
 			assert.Equal(t, tt.expectedStatus, rr.Code)
@@ -522,6 +911,393 @@ func TestItemHandler_DeleteItem(t *testing.T) {
 	}
 }
 
+func TestItemHandler_DuplicateItem(t *testing.T) {
+	tests := []struct {
+		name             string
+		projectID        string
+		itemID           string
+		setupMock        func(*MockItemService)
+		expectedStatus   int
+		validateResponse func(t *testing.T, body []byte)
+	}{
+		{
+			name:      "successful duplicate",
+			projectID: "test-project-id",
+			itemID:    "test-item-id",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("Duplicate", mock.Anything, "test-project-id", "test-item-id").Return(&core.Item{
+					ID:        "copy-item-id",
+					ProjectID: "test-project-id",
+					Type:      types.ItemTypeChoice,
+					Title:     "Lighthouse keepers (copy)",
+					Content:   json.RawMessage(`{"choices":[{"id":"a","text":"Yes"}]}`),
+					Position:  3,
+					CreatedAt: time.Now(),
+					UpdatedAt: time.Now(),
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			validateResponse: func(t *testing.T, body []byte) {
+				var response types.ItemResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, "copy-item-id", response.ID)
+				assert.Equal(t, "Lighthouse keepers (copy)", response.Title)
+				assert.Equal(t, 3, response.Position)
+				assert.NotNil(t, response.Content)
+			},
+		},
+		{
+			name:      "item not found",
+			projectID: "test-project-id",
+			itemID:    "non-existent-item",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("Duplicate", mock.Anything, "test-project-id", "non-existent-item").Return(nil, core.ErrItemNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "item_not_found")
+			},
+		},
+		{
+			name:      "project not found",
+			projectID: "deleted-project-id",
+			itemID:    "test-item-id",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("Duplicate", mock.Anything, "deleted-project-id", "test-item-id").Return(nil, core.ErrProjectNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "project_not_found")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockItemService{}
+			tt.setupMock(mockService)
+
+			handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/{projectId}/items/{itemId}/duplicate", nil)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", tt.projectID)
+			rctx.URLParams.Add("itemId", tt.itemID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := newRecorder()
+			handler.DuplicateItem(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.validateResponse != nil {
+				tt.validateResponse(t, rr.Body.Bytes())
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestItemHandler_RestoreItem(t *testing.T) {
+	tests := []struct {
+		name             string
+		itemID           string
+		setupMock        func(*MockItemService)
+		expectedStatus   int
+		validateResponse func(t *testing.T, body []byte)
+	}{
+		{
+			name:   "successful restore",
+			itemID: "deleted-item-id",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("Restore", mock.Anything, "test-project-id", "deleted-item-id").Return(&core.Item{
+					ID:        "deleted-item-id",
+					ProjectID: "test-project-id",
+					Type:      types.ItemTypeHotspot,
+					Title:     "Find the lighthouse",
+					Content:   json.RawMessage(`{"image_url":"https://example.com/coast.png","hotspots":[]}`),
+					Position:  7,
+					CreatedAt: time.Now(),
+					UpdatedAt: time.Now(),
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResponse: func(t *testing.T, body []byte) {
+				var response types.ItemResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, "deleted-item-id", response.ID)
+				assert.Equal(t, 7, response.Position)
+				assert.NotNil(t, response.Content)
+			},
+		},
+		{
+			name:   "item not in the trash",
+			itemID: "live-item-id",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("Restore", mock.Anything, "test-project-id", "live-item-id").Return(nil, core.ErrItemNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "item_not_found")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockItemService{}
+			tt.setupMock(mockService)
+
+			handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/{projectId}/items/{itemId}/restore", nil)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "test-project-id")
+			rctx.URLParams.Add("itemId", tt.itemID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := newRecorder()
+			handler.RestoreItem(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.validateResponse != nil {
+				tt.validateResponse(t, rr.Body.Bytes())
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestItemHandler_BulkCreateItems(t *testing.T) {
+	body := `[
+		{"type": "title", "title": "Intro", "position": 0},
+		{"type": "title", "title": "Outro", "position": 1, "required": true}
+	]`
+	expectedInputs := []core.ItemInput{
+		{Type: types.ItemTypeTitle, Title: "Intro", Position: 0},
+		{Type: types.ItemTypeTitle, Title: "Outro", Position: 1, Required: true},
+	}
+
+	tests := []struct {
+		name             string
+		setupMock        func(*MockItemService)
+		expectedStatus   int
+		validateResponse func(t *testing.T, body []byte)
+	}{
+		{
+			name: "creates every item in one call",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("CreateMany", mock.Anything, "p1", expectedInputs).Return([]*core.Item{
+					{ID: "item1", ProjectID: "p1", Type: types.ItemTypeTitle, Title: "Intro", Position: 0},
+					{ID: "item2", ProjectID: "p1", Type: types.ItemTypeTitle, Title: "Outro", Position: 1, Required: true},
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			validateResponse: func(t *testing.T, body []byte) {
+				var response types.ItemListResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, 2, response.Total)
+				assert.Equal(t, "item1", response.Items[0].ID)
+				assert.Equal(t, "item2", response.Items[1].ID)
+			},
+		},
+		{
+			name: "project not found",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("CreateMany", mock.Anything, "p1", expectedInputs).Return(([]*core.Item)(nil), core.ErrProjectNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "project_not_found")
+			},
+		},
+		{
+			name: "store failure creates nothing",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("CreateMany", mock.Anything, "p1", expectedInputs).Return(([]*core.Item)(nil), errors.New("failed to create item 2: unique violation"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "bulk_create_failed")
+			},
+		},
+		{
+			name: "a failing item is reported with its index",
+			setupMock: func(mockService *MockItemService) {
+				err := &core.ItemBatchError{Index: 1, Err: fmt.Errorf("%w: position 1 is taken", core.ErrItemInvalidPosition)}
+				mockService.On("CreateMany", mock.Anything, "p1", expectedInputs).Return(([]*core.Item)(nil), err)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			validateResponse: func(t *testing.T, body []byte) {
+				var response types.ErrorResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, "invalid_position", response.Error.Code)
+				require.NotNil(t, response.Error.Index)
+				assert.Equal(t, 1, *response.Error.Index)
+				require.NotNil(t, response.Error.Details)
+				assert.Equal(t, "invalid item position: position 1 is taken", *response.Error.Details)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockService := &MockItemService{}
+			tt.setupMock(mockService)
+			handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/p1/items/bulk", bytes.NewBufferString(body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "p1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := newRecorder()
+
+			// Act
+			handler.BulkCreateItems(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			tt.validateResponse(t, rr.Body.Bytes())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestItemHandler_UpdateItemPositions(t *testing.T) {
+	itemID := "11111111-1111-1111-1111-111111111111"
+	expectedUpdates := []core.PositionUpdate{{ItemID: itemID, Position: 2}}
+
+	tests := []struct {
+		name           string
+		setupMock      func(*MockItemService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "moves the project's items and lists them",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("UpdatePositions", mock.Anything, "p1", expectedUpdates).Return(nil)
+				mockService.On("List", mock.Anything, "p1", core.ItemListOptions{Limit: 50}).Return(&core.ItemPage{Items: []*core.Item{
+					{ID: itemID, ProjectID: "p1", Type: types.ItemTypeTitle, Title: "Intro", Position: 2},
+				}, Total: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "item outside the project",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("UpdatePositions", mock.Anything, "p1", expectedUpdates).Return(core.ErrItemNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "item_not_found",
+		},
+		{
+			name: "colliding positions",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("UpdatePositions", mock.Anything, "p1", expectedUpdates).
+					Return(fmt.Errorf("%w: two items would share a position", core.ErrItemInvalidPosition))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "invalid_position",
+		},
+		{
+			name: "two items moved to one position",
+			setupMock: func(mockService *MockItemService) {
+				mockService.On("UpdatePositions", mock.Anything, "p1", expectedUpdates).
+					Return(fmt.Errorf("%w: two items are moved to position 2", core.ErrItemDuplicatePositions))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "duplicate_positions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockService := &MockItemService{}
+			tt.setupMock(mockService)
+			handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
+
+			body := fmt.Sprintf(`[{"item_id": %q, "position": 2}]`, itemID)
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/projects/p1/items/positions", bytes.NewBufferString(body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "p1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := newRecorder()
+
+			// Act
+			handler.UpdateItemPositions(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedCode != "" {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestItemHandler_BulkValidationErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		serve      func(h *ItemHandler) http.HandlerFunc
+		wantFields map[string]string
+	}{
+		{
+			name: "bulk create names the failing items",
+			path: "/api/v1/projects/p1/items/bulk",
+			body: `[
+				{"type": "title", "title": "Intro"},
+				{"type": "title", "title": ""},
+				{"type": "essay", "title": "Q3", "points": 5000}
+			]`,
+			serve: func(h *ItemHandler) http.HandlerFunc { return h.BulkCreateItems },
+			wantFields: map[string]string{
+				"items[1].title":  "required",
+				"items[2].type":   "oneof",
+				"items[2].points": "max",
+			},
+		},
+		{
+			name:       "position updates name the failing entries",
+			path:       "/api/v1/projects/p1/items/positions",
+			body:       `[{"item_id": "not-a-uuid", "position": 1}]`,
+			serve:      func(h *ItemHandler) http.HandlerFunc { return h.UpdateItemPositions },
+			wantFields: map[string]string{"positions[0].item_id": "uuid"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockService := &MockItemService{}
+			handler := NewItemHandler(mockService, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "p1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := newRecorder()
+
+			// Act
+			tt.serve(handler)(rr, req)
+
+			// Assert
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Equal(t, tt.wantFields, assertValidationErrors(t, rr.Body.Bytes()))
+			// Nothing is created when any item fails
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 // Helper functions
 func intPtr(i int) *int {
 	return &i
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/http/streaming"
+	"github.com/provemyself/backend/internal/jobs"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// JobScheduler is the background job scheduler the jobs handler reports on,
+// satisfied by *jobs.Scheduler
+type JobScheduler interface {
+	Statuses() []jobs.Status
+	Trigger(name string) error
+}
+
+// jobEventsInterval is how often the job event stream checks for changes
+const jobEventsInterval = time.Second
+
+// JobsHandler handles the background job endpoints under /api/v1/admin/jobs
+type JobsHandler struct {
+	scheduler JobScheduler
+	// keepAlive is how often an idle event stream sends a comment line
+	keepAlive time.Duration
+}
+
+// NewJobsHandler creates a new jobs handler
+func NewJobsHandler(scheduler JobScheduler, keepAlive time.Duration) *JobsHandler {
+	return &JobsHandler{
+		scheduler: scheduler,
+		keepAlive: keepAlive,
+	}
+}
+
+// ListJobs handles GET /api/v1/admin/jobs
+// @Summary List background jobs
+// @Description Returns every registered background job with its schedule and the outcome of its last run on the replica that served the request. Runs of cluster-wide jobs that another replica performed are counted as skipped.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.JobListResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/jobs [get]
+func (h *JobsHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, http.StatusOK, h.listResponse())
+}
+
+// StreamJobs handles GET /api/v1/admin/jobs/events
+// @Summary Stream background job status
+// @Description Server-sent event stream of the job list as seen by the replica that served the request. A "jobs" event carrying the full list is sent on connect and whenever a job's status changes; comment lines keep an idle stream open.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce text/event-stream
+// @Success 200 {object} types.JobListResponse "data of each jobs event"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Router /api/v1/admin/jobs/events [get]
+func (h *JobsHandler) StreamJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sse, err := streaming.NewSSE(w)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to start job event stream")
+		return
+	}
+
+	poll := time.NewTicker(jobEventsInterval)
+	defer poll.Stop()
+	keepAlive := time.NewTicker(h.keepAlive)
+	defer keepAlive.Stop()
+
+	var last []byte
+	for {
+		payload, err := json.Marshal(h.listResponse())
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to encode job event")
+			return
+		}
+		if !bytes.Equal(payload, last) {
+			if err := sse.Send("jobs", payload); err != nil {
+				return
+			}
+			last = payload
+			keepAlive.Reset(h.keepAlive)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		case <-keepAlive.C:
+			if err := sse.KeepAlive(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// RunJob handles POST /api/v1/admin/jobs/{name}/run
+// @Summary Run a background job
+// @Description Starts a run of the job now, outside its schedule. The run happens in the background; poll the job list for its outcome. A cluster-wide job still takes its lock, so the run is skipped if another replica is running it.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 202 {object} types.JobStatusResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 404 {object} types.ErrorResponse "job_not_found"
+// @Failure 409 {object} types.ErrorResponse "job_running"
+// @Failure 503 {object} types.ErrorResponse "scheduler_not_running"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/jobs/{name}/run [post]
+func (h *JobsHandler) RunJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := chi.URLParam(r, "name")
+
+	if err := h.scheduler.Trigger(name); err != nil {
+		respondDomainError(w, err)
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("job", name).
+		Str("user_id", httpmiddleware.GetUserID(ctx)).
+		Msg("job triggered")
+
+	for _, status := range h.scheduler.Statuses() {
+		if status.Name == name {
+			respond.JSON(w, http.StatusAccepted, jobStatusResponse(status))
+			return
+		}
+	}
+	respond.JSON(w, http.StatusAccepted, types.JobStatusResponse{Name: name})
+}
+
+func (h *JobsHandler) listResponse() types.JobListResponse {
+	statuses := h.scheduler.Statuses()
+
+	response := types.JobListResponse{Jobs: make([]types.JobStatusResponse, 0, len(statuses))}
+	for _, status := range statuses {
+		response.Jobs = append(response.Jobs, jobStatusResponse(status))
+	}
+	return response
+}
+
+func jobStatusResponse(status jobs.Status) types.JobStatusResponse {
+	return types.JobStatusResponse{
+		Name:           status.Name,
+		Schedule:       status.Schedule,
+		PerReplica:     status.PerReplica,
+		Running:        status.Running,
+		NextRunAt:      optionalTime(status.NextRunAt),
+		LastStartedAt:  optionalTime(status.LastStartedAt),
+		LastFinishedAt: optionalTime(status.LastFinishedAt),
+		LastDurationMs: status.LastDuration.Milliseconds(),
+		LastError:      status.LastError,
+		Runs:           status.Runs,
+		Failures:       status.Failures,
+		Skipped:        status.Skipped,
+	}
+}
+
+// optionalTime returns t in UTC, or nil for the zero time
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
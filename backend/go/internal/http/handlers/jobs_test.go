@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/jobs"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// fakeScheduler reports fixed statuses and fails triggers with triggerErr
+type fakeScheduler struct {
+	statuses   []jobs.Status
+	triggerErr error
+	triggered  []string
+}
+
+func (s *fakeScheduler) Statuses() []jobs.Status {
+	return s.statuses
+}
+
+func (s *fakeScheduler) Trigger(name string) error {
+	if s.triggerErr != nil {
+		return s.triggerErr
+	}
+	s.triggered = append(s.triggered, name)
+	return nil
+}
+
+func TestJobsHandler_ListJobs(t *testing.T) {
+	// Arrange
+	finished := time.Date(2026, 3, 11, 10, 0, 0, 0, time.UTC)
+	scheduler := &fakeScheduler{statuses: []jobs.Status{
+		{
+			Name:           "items.purge_deleted",
+			Schedule:       "*/15 * * * *",
+			LastStartedAt:  finished.Add(-2 * time.Second),
+			LastFinishedAt: finished,
+			LastDuration:   2 * time.Second,
+			LastError:      "connection refused",
+			Runs:           4,
+			Failures:       1,
+			Skipped:        3,
+		},
+		{Name: "maintenance.refresh", Schedule: "@every 5s", PerReplica: true},
+	}}
+	handler := NewJobsHandler(scheduler, time.Second)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/jobs", nil)
+	rr := newRecorder()
+
+	// Act
+	handler.ListJobs(rr, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response types.JobListResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Len(t, response.Jobs, 2)
+
+	purge := response.Jobs[0]
+	assert.Equal(t, "items.purge_deleted", purge.Name)
+	assert.Equal(t, int64(2000), purge.LastDurationMs)
+	assert.Equal(t, "connection refused", purge.LastError)
+	require.NotNil(t, purge.LastFinishedAt)
+	assert.True(t, finished.Equal(*purge.LastFinishedAt))
+	assert.Equal(t, int64(3), purge.Skipped)
+
+	refresh := response.Jobs[1]
+	assert.True(t, refresh.PerReplica)
+	assert.Nil(t, refresh.LastStartedAt)
+	assert.Nil(t, refresh.NextRunAt)
+}
+
+func TestJobsHandler_RunJob(t *testing.T) {
+	tests := []struct {
+		name           string
+		triggerErr     error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"triggers the job", nil, http.StatusAccepted, ""},
+		{"unknown job", jobs.ErrJobNotFound, http.StatusNotFound, types.ErrorCodeJobNotFound},
+		{"already running", jobs.ErrJobRunning, http.StatusConflict, types.ErrorCodeJobRunning},
+		{"scheduler stopped", jobs.ErrNotRunning, http.StatusServiceUnavailable, types.ErrorCodeSchedulerNotRunning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			scheduler := &fakeScheduler{
+				statuses:   []jobs.Status{{Name: "items.purge_deleted", Schedule: "@hourly"}},
+				triggerErr: tt.triggerErr,
+			}
+			handler := NewJobsHandler(scheduler, time.Second)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/jobs/items.purge_deleted/run", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("name", "items.purge_deleted")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := newRecorder()
+
+			// Act
+			handler.RunJob(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedCode != "" {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+				assert.Empty(t, scheduler.triggered)
+				return
+			}
+
+			assert.Equal(t, []string{"items.purge_deleted"}, scheduler.triggered)
+			var response types.JobStatusResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, "@hourly", response.Schedule)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+)
+
+// logLines decodes newline-delimited JSON log output
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func findLogLine(lines []map[string]interface{}, message string) map[string]interface{} {
+	for _, line := range lines {
+		if line["message"] == message {
+			return line
+		}
+	}
+	return nil
+}
+
+func TestRequestLogger_PropagatesToHandlerLogs(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	loggingMiddleware := httpmiddleware.NewLoggingMiddleware(zerolog.New(&buf), 0)
+
+	mockService := new(MockProjectService)
+	mockService.On("GetByID", mock.Anything, "project-1").Return(nil, errors.New("connection reset by peer"))
+	handler := NewProjectHandler(mockService, httpmiddleware.NewValidator())
+
+	r := chi.NewRouter()
+	r.Use(loggingMiddleware.RequestID)
+	r.Use(loggingMiddleware.UserContext)
+	r.Use(loggingMiddleware.RequestLogger)
+	r.Get("/projects/{projectId}", handler.GetProject)
+
+	req := httptest.NewRequest(http.MethodGet, "/projects/project-1", nil)
+	req.Header.Set("X-Request-ID", "req-abc")
+	req.Header.Set("X-User-ID", "user-42")
+	rr := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(rr, req)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	line := findLogLine(logLines(t, &buf), "failed to get project")
+	require.NotNil(t, line, "handler error was not logged")
+	assert.Equal(t, "error", line["level"])
+	assert.Equal(t, "req-abc", line["request_id"])
+	assert.Equal(t, "user-42", line["user_id"])
+	assert.Equal(t, http.MethodGet, line["method"])
+	assert.Equal(t, "/projects/project-1", line["path"])
+	assert.Equal(t, "connection reset by peer", line["error"])
+}
@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/lti"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// LTISessionHeader carries a participant's session token
+const LTISessionHeader = "X-LTI-Session"
+
+// LTIService runs LTI logins and launches, satisfied by *lti.Service
+type LTIService interface {
+	Login(ctx context.Context, req lti.LoginRequest) (string, error)
+	Launch(ctx context.Context, idToken, state string) (*lti.Launch, error)
+	Session(ctx context.Context, token string) (*core.LTISession, error)
+	KeySet() lti.KeySet
+}
+
+// LTIHandler handles the LTI 1.3 tool endpoints under /lti and
+// /.well-known/jwks.json, and the platform registrations under
+// /api/v1/admin/lti/platforms
+type LTIHandler struct {
+	service   LTIService
+	platforms core.LTIStore
+	validate  *validator.Validate
+	// enabled reports the enable_lti_integration setting
+	enabled func() bool
+	// playerURL is where launched participants are sent, followed by the
+	// project ID
+	playerURL string
+}
+
+// NewLTIHandler creates a new LTI handler
+func NewLTIHandler(service LTIService, platforms core.LTIStore, enabled func() bool, playerURL string, validate *validator.Validate) *LTIHandler {
+	return &LTIHandler{
+		service:   service,
+		platforms: platforms,
+		validate:  validate,
+		enabled:   enabled,
+		playerURL: strings.TrimSuffix(playerURL, "/"),
+	}
+}
+
+// Login handles GET and POST /lti/login
+// @Summary Start an LTI launch
+// @Description OpenID Connect third-party initiated login, called by a registered platform with iss, login_hint and optionally client_id and lti_message_hint, as query or form parameters. Redirects the browser to the platform's authorization endpoint with a single-use state and nonce, valid for 10 minutes.
+// @Tags LTI
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param iss formData string true "Platform issuer"
+// @Param login_hint formData string true "Opaque user hint"
+// @Param client_id formData string false "Client ID, if the platform has several registrations"
+// @Param lti_message_hint formData string false "Opaque message hint"
+// @Success 302 "Redirect to the platform's authorization endpoint"
+// @Failure 401 {object} types.ErrorResponse "lti_invalid_launch"
+// @Failure 404 {object} types.ErrorResponse "lti_disabled, lti_platform_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /lti/login [get]
+// @Router /lti/login [post]
+func (h *LTIHandler) Login(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !h.enabled() {
+		respondDomainError(w, core.ErrLTIDisabled)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		httpmiddleware.SendBodyReadError(w, err)
+		return
+	}
+
+	redirect, err := h.service.Login(ctx, lti.LoginRequest{
+		Issuer:      r.Form.Get("iss"),
+		LoginHint:   r.Form.Get("login_hint"),
+		MessageHint: r.Form.Get("lti_message_hint"),
+		ClientID:    r.Form.Get("client_id"),
+	})
+	if err != nil {
+		logLTIError(ctx, err, "failed to start LTI login")
+		respondDomainError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+// Launch handles POST /lti/launch
+// @Summary Complete an LTI launch
+// @Description Receives the platform's id_token and the login's state as a form post. The id_token must be signed with a key of the platform's key set and be a current LTI 1.3 resource link launch for our client ID carrying the login's nonce. A resource link launched for the first time is bound to the published project named by its project_id custom parameter. Opens a participant session and redirects the browser to the player, with the session token in the URL fragment as lti_session.
+// @Tags LTI
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param id_token formData string true "Platform-signed launch"
+// @Param state formData string true "State issued by the login"
+// @Success 303 "Redirect to the player"
+// @Failure 401 {object} types.ErrorResponse "lti_invalid_launch"
+// @Failure 404 {object} types.ErrorResponse "lti_disabled, lti_platform_not_found, lti_resource_link_not_mapped, project_not_found"
+// @Failure 409 {object} types.ErrorResponse "project_not_published"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /lti/launch [post]
+func (h *LTIHandler) Launch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !h.enabled() {
+		respondDomainError(w, core.ErrLTIDisabled)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		httpmiddleware.SendBodyReadError(w, err)
+		return
+	}
+
+	// The platform reports a failed authentication instead of a token
+	if platformErr := r.PostForm.Get("error"); platformErr != "" {
+		log.Ctx(ctx).Warn().
+			Str("error", platformErr).
+			Str("error_description", r.PostForm.Get("error_description")).
+			Msg("LTI platform refused the launch")
+		apiErr := types.MapDomainError(core.ErrLTIInvalidLaunch)
+		respond.Error(w, apiErr.StatusCode, apiErr.Code, apiErr.Message, platformErr)
+		return
+	}
+
+	launch, err := h.service.Launch(ctx, r.PostForm.Get("id_token"), r.PostForm.Get("state"))
+	if err != nil {
+		logLTIError(ctx, err, "failed to launch LTI session")
+		respondDomainError(w, err)
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("platform_id", launch.Session.PlatformID).
+		Str("project_id", launch.Session.ProjectID).
+		Str("subject", launch.Session.Subject).
+		Bool("grade_passback", launch.Session.LineItemURL != "").
+		Msg("LTI session launched")
+
+	// The fragment keeps the token out of server logs and Referer headers
+	player := h.playerURL + "/" + url.PathEscape(launch.Session.ProjectID) +
+		"#" + url.Values{"lti_session": {launch.Token}}.Encode()
+	http.Redirect(w, r, player, http.StatusSeeOther)
+}
+
+// GetSession handles GET /lti/session
+// @Summary Get the LTI session
+// @Description Returns the participant session whose token is sent in the X-LTI-Session header: the launched project and the platform's user.
+// @Tags LTI
+// @Produce json
+// @Param X-LTI-Session header string true "Session token from the launch"
+// @Success 200 {object} types.LTISessionResponse
+// @Failure 401 {object} types.ErrorResponse "lti_session_not_found"
+// @Failure 404 {object} types.ErrorResponse "lti_disabled"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /lti/session [get]
+func (h *LTIHandler) GetSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !h.enabled() {
+		respondDomainError(w, core.ErrLTIDisabled)
+		return
+	}
+
+	session, err := h.service.Session(ctx, r.Header.Get(LTISessionHeader))
+	if err != nil {
+		logLTIError(ctx, err, "failed to get LTI session")
+		respondDomainError(w, err)
+		return
+	}
+
+	roles := session.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	respond.JSON(w, http.StatusOK, types.LTISessionResponse{
+		ProjectID:     session.ProjectID,
+		Subject:       session.Subject,
+		Name:          session.Name,
+		Email:         session.Email,
+		Roles:         roles,
+		GradePassback: session.LineItemURL != "",
+		ExpiresAt:     session.ExpiresAt,
+	})
+}
+
+// GetKeySet handles GET /.well-known/jwks.json
+// @Summary Get the tool's key set
+// @Description Returns the JWKS of the key the tool signs its service requests with, such as the token requests for posting scores. Platforms are registered with this URL.
+// @Tags LTI
+// @Produce json
+// @Success 200 {object} lti.KeySet
+// @Failure 404 {object} types.ErrorResponse "lti_disabled"
+// @Router /.well-known/jwks.json [get]
+func (h *LTIHandler) GetKeySet(w http.ResponseWriter, r *http.Request) {
+	if !h.enabled() {
+		respondDomainError(w, core.ErrLTIDisabled)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respond.JSON(w, http.StatusOK, h.service.KeySet())
+}
+
+// ListPlatforms handles GET /api/v1/admin/lti/platforms
+// @Summary List LTI platforms
+// @Description Returns every registered LTI platform ordered by issuer and client ID
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.LTIPlatformListResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/lti/platforms [get]
+func (h *LTIHandler) ListPlatforms(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	platforms, err := h.platforms.ListPlatforms(ctx)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to list LTI platforms")
+		respondDomainError(w, err)
+		return
+	}
+
+	response := types.LTIPlatformListResponse{Platforms: make([]types.LTIPlatformResponse, 0, len(platforms))}
+	for _, platform := range platforms {
+		response.Platforms = append(response.Platforms, ltiPlatformResponse(platform))
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// CreatePlatform handles POST /api/v1/admin/lti/platforms
+// @Summary Register LTI platform
+// @Description Registers a learning platform by the issuer and client ID it launches with, its OIDC authorization and OAuth 2 token endpoints and the key set its id_tokens are signed with. The platform launches the projects of org_id, or those of no organization. Register the tool on the platform with /lti/login as its login URL, /lti/launch as its redirect URI and /.well-known/jwks.json as its key set.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.LTIPlatformRequest true "Platform"
+// @Success 201 {object} types.LTIPlatformResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 404 {object} types.ErrorResponse "organization_not_found"
+// @Failure 409 {object} types.ErrorResponse "lti_platform_exists"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/lti/platforms [post]
+func (h *LTIHandler) CreatePlatform(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req types.LTIPlatformRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpmiddleware.SendBodyReadError(w, err)
+		return
+	}
+
+	if err := h.validate.StructCtx(ctx, req); err != nil {
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
+		return
+	}
+
+	platform, err := h.platforms.CreatePlatform(ctx, &core.LTIPlatform{
+		OrgID:        req.OrgID,
+		Issuer:       req.Issuer,
+		ClientID:     req.ClientID,
+		AuthLoginURL: req.AuthLoginURL,
+		AuthTokenURL: req.AuthTokenURL,
+		KeySetURL:    req.KeySetURL,
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to register LTI platform")
+		respondDomainError(w, err)
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("platform_id", platform.ID).
+		Str("issuer", platform.Issuer).
+		Str("client_id", platform.ClientID).
+		Msg("LTI platform registered")
+
+	respond.JSON(w, http.StatusCreated, ltiPlatformResponse(platform))
+}
+
+// DeletePlatform handles DELETE /api/v1/admin/lti/platforms/{platformId}
+// @Summary Delete LTI platform
+// @Description Deletes a platform registration together with its resource link bindings and participant sessions
+// @Tags Admin
+// @Security BearerAuth
+// @Param platformId path string true "Platform ID" format(uuid)
+// @Success 204 "No content"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 404 {object} types.ErrorResponse "lti_platform_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/lti/platforms/{platformId} [delete]
+func (h *LTIHandler) DeletePlatform(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	platformID := chi.URLParam(r, "platformId")
+
+	if err := h.platforms.DeletePlatform(ctx, platformID); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("platform_id", platformID).Msg("failed to delete LTI platform")
+		respondDomainError(w, err)
+		return
+	}
+
+	log.Ctx(ctx).Warn().
+		Str("platform_id", platformID).
+		Str("user_id", httpmiddleware.GetUserID(ctx)).
+		Msg("LTI platform deleted")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// logLTIError logs a failed launch step. Launches rejected for what the
+// platform or participant sent are expected and only warned about.
+func logLTIError(ctx context.Context, err error, msg string) {
+	event := log.Ctx(ctx).Error()
+	for _, expected := range []error{
+		core.ErrLTIInvalidLaunch,
+		core.ErrLTIPlatformNotFound,
+		core.ErrLTIResourceLinkNotMapped,
+		core.ErrLTISessionNotFound,
+		core.ErrProjectNotFound,
+		core.ErrProjectNotPublished,
+	} {
+		if errors.Is(err, expected) {
+			event = log.Ctx(ctx).Warn()
+			break
+		}
+	}
+	event.Err(err).Msg(msg)
+}
+
+func ltiPlatformResponse(platform *core.LTIPlatform) types.LTIPlatformResponse {
+	return types.LTIPlatformResponse{
+		ID:           platform.ID,
+		OrgID:        platform.OrgID,
+		Issuer:       platform.Issuer,
+		ClientID:     platform.ClientID,
+		AuthLoginURL: platform.AuthLoginURL,
+		AuthTokenURL: platform.AuthTokenURL,
+		KeySetURL:    platform.KeySetURL,
+		CreatedAt:    platform.CreatedAt,
+	}
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/lti"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// MockLTIService is a mock implementation of LTIService
+type MockLTIService struct {
+	mock.Mock
+}
+
+func (m *MockLTIService) Login(ctx context.Context, req lti.LoginRequest) (string, error) {
+	args := m.Called(ctx, req)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockLTIService) Launch(ctx context.Context, idToken, state string) (*lti.Launch, error) {
+	args := m.Called(ctx, idToken, state)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*lti.Launch), args.Error(1)
+}
+
+func (m *MockLTIService) Session(ctx context.Context, token string) (*core.LTISession, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.LTISession), args.Error(1)
+}
+
+func (m *MockLTIService) KeySet() lti.KeySet {
+	return m.Called().Get(0).(lti.KeySet)
+}
+
+// MockLTIPlatformStore mocks the platform registrations of core.LTIStore
+type MockLTIPlatformStore struct {
+	core.LTIStore
+	mock.Mock
+}
+
+func (m *MockLTIPlatformStore) CreatePlatform(ctx context.Context, platform *core.LTIPlatform) (*core.LTIPlatform, error) {
+	args := m.Called(ctx, platform)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.LTIPlatform), args.Error(1)
+}
+
+func newLTITestHandler(service LTIService, platforms core.LTIStore, enabled bool) *LTIHandler {
+	return NewLTIHandler(service, platforms, func() bool { return enabled }, "https://app.example.com/play/", httpmiddleware.NewValidator())
+}
+
+// ltiFormRequest builds a form post to path
+func ltiFormRequest(path string, form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestLTIHandler_Disabled(t *testing.T) {
+	handler := newLTITestHandler(new(MockLTIService), nil, false)
+	tests := []struct {
+		name  string
+		serve func(w http.ResponseWriter)
+	}{
+		{"login", func(w http.ResponseWriter) {
+			handler.Login(w, httptest.NewRequest(http.MethodGet, "/lti/login?iss=x&login_hint=y", nil))
+		}},
+		{"launch", func(w http.ResponseWriter) {
+			handler.Launch(w, ltiFormRequest("/lti/launch", url.Values{"id_token": {"t"}, "state": {"s"}}))
+		}},
+		{"session", func(w http.ResponseWriter) {
+			handler.GetSession(w, httptest.NewRequest(http.MethodGet, "/lti/session", nil))
+		}},
+		{"key set", func(w http.ResponseWriter) {
+			handler.GetKeySet(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			rr := newRecorder()
+
+			// Act
+			tt.serve(rr)
+
+			// Assert
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assertErrorResponse(t, rr.Body.Bytes(), types.ErrorCodeLTIDisabled)
+		})
+	}
+}
+
+func TestLTIHandler_Login(t *testing.T) {
+	tests := []struct {
+		name    string
+		request *http.Request
+	}{
+		{"query", httptest.NewRequest(http.MethodGet, "/lti/login?iss=https%3A%2F%2Flms.example.com&login_hint=u1&lti_message_hint=m1&client_id=c1", nil)},
+		{"form post", ltiFormRequest("/lti/login", url.Values{
+			"iss": {"https://lms.example.com"}, "login_hint": {"u1"}, "lti_message_hint": {"m1"}, "client_id": {"c1"},
+		})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := new(MockLTIService)
+			service.On("Login", mock.Anything, lti.LoginRequest{
+				Issuer: "https://lms.example.com", LoginHint: "u1", MessageHint: "m1", ClientID: "c1",
+			}).Return("https://lms.example.com/auth?state=s", nil)
+			handler := newLTITestHandler(service, nil, true)
+			rr := newRecorder()
+
+			// Act
+			handler.Login(rr, tt.request)
+
+			// Assert
+			assert.Equal(t, http.StatusFound, rr.Code)
+			assert.Equal(t, "https://lms.example.com/auth?state=s", rr.Header().Get("Location"))
+			service.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLTIHandler_Login_UnknownPlatform(t *testing.T) {
+	// Arrange
+	service := new(MockLTIService)
+	service.On("Login", mock.Anything, mock.Anything).Return("", core.ErrLTIPlatformNotFound)
+	handler := newLTITestHandler(service, nil, true)
+	rr := newRecorder()
+
+	// Act
+	handler.Login(rr, httptest.NewRequest(http.MethodGet, "/lti/login?iss=x&login_hint=y", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assertErrorResponse(t, rr.Body.Bytes(), types.ErrorCodeLTIPlatformNotFound)
+}
+
+func TestLTIHandler_Launch_RedirectsToThePlayer(t *testing.T) {
+	// Arrange
+	service := new(MockLTIService)
+	service.On("Launch", mock.Anything, "id-token", "state-1").Return(&lti.Launch{
+		Session: &core.LTISession{ProjectID: "p1", PlatformID: "platform-1", Subject: "u1"},
+		Token:   "tok/en+",
+	}, nil)
+	handler := newLTITestHandler(service, nil, true)
+	rr := newRecorder()
+
+	// Act
+	handler.Launch(rr, ltiFormRequest("/lti/launch", url.Values{"id_token": {"id-token"}, "state": {"state-1"}}))
+
+	// Assert
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Equal(t, "https://app.example.com/play/p1#lti_session=tok%2Fen%2B", rr.Header().Get("Location"))
+}
+
+func TestLTIHandler_Launch_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		form           url.Values
+		serviceErr     error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "platform refused the login",
+			form:           url.Values{"error": {"login_required"}, "state": {"s"}},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   types.ErrorCodeLTIInvalidLaunch,
+		},
+		{
+			name:           "invalid launch",
+			form:           url.Values{"id_token": {"t"}, "state": {"s"}},
+			serviceErr:     fmt.Errorf("%w: nonce does not match the login", core.ErrLTIInvalidLaunch),
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   types.ErrorCodeLTIInvalidLaunch,
+		},
+		{
+			name:           "resource link not mapped",
+			form:           url.Values{"id_token": {"t"}, "state": {"s"}},
+			serviceErr:     core.ErrLTIResourceLinkNotMapped,
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   types.ErrorCodeLTIResourceLinkNotMapped,
+		},
+		{
+			name:           "project not published",
+			form:           url.Values{"id_token": {"t"}, "state": {"s"}},
+			serviceErr:     core.ErrProjectNotPublished,
+			expectedStatus: http.StatusConflict,
+			expectedCode:   types.ErrorCodeProjectNotPublished,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := new(MockLTIService)
+			if tt.serviceErr != nil {
+				service.On("Launch", mock.Anything, "t", "s").Return(nil, tt.serviceErr)
+			}
+			handler := newLTITestHandler(service, nil, true)
+			rr := newRecorder()
+
+			// Act
+			handler.Launch(rr, ltiFormRequest("/lti/launch", tt.form))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+			service.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLTIHandler_GetSession(t *testing.T) {
+	// Arrange
+	expiresAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	service := new(MockLTIService)
+	service.On("Session", mock.Anything, "token-1").Return(&core.LTISession{
+		ProjectID:   "p1",
+		Subject:     "u1",
+		Name:        "Jane Doe",
+		LineItemURL: "https://lms.example.com/lineitems/1",
+		ExpiresAt:   expiresAt,
+	}, nil)
+	handler := newLTITestHandler(service, nil, true)
+	req := httptest.NewRequest(http.MethodGet, "/lti/session", nil)
+	req.Header.Set(LTISessionHeader, "token-1")
+	rr := newRecorder()
+
+	// Act
+	handler.GetSession(rr, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response types.LTISessionResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, types.LTISessionResponse{
+		ProjectID:     "p1",
+		Subject:       "u1",
+		Name:          "Jane Doe",
+		Roles:         []string{},
+		GradePassback: true,
+		ExpiresAt:     expiresAt,
+	}, response)
+}
+
+func TestLTIHandler_GetSession_NotFound(t *testing.T) {
+	// Arrange
+	service := new(MockLTIService)
+	service.On("Session", mock.Anything, "").Return(nil, core.ErrLTISessionNotFound)
+	handler := newLTITestHandler(service, nil, true)
+	rr := newRecorder()
+
+	// Act
+	handler.GetSession(rr, httptest.NewRequest(http.MethodGet, "/lti/session", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assertErrorResponse(t, rr.Body.Bytes(), types.ErrorCodeLTISessionNotFound)
+}
+
+func TestLTIHandler_GetKeySet(t *testing.T) {
+	// Arrange
+	service := new(MockLTIService)
+	service.On("KeySet").Return(lti.KeySet{Keys: []lti.JWK{{Kty: "RSA", Kid: "k1", N: "n", E: "AQAB"}}})
+	handler := newLTITestHandler(service, nil, true)
+	rr := newRecorder()
+
+	// Act
+	handler.GetKeySet(rr, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "public, max-age=300", rr.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"keys":[{"kty":"RSA","kid":"k1","n":"n","e":"AQAB"}]}`, rr.Body.String())
+}
+
+func TestLTIHandler_CreatePlatform(t *testing.T) {
+	// Arrange
+	platforms := new(MockLTIPlatformStore)
+	platforms.On("CreatePlatform", mock.Anything, mock.MatchedBy(func(p *core.LTIPlatform) bool {
+		return p.Issuer == "https://lms.example.com" && p.ClientID == "c1"
+	})).Return(&core.LTIPlatform{
+		ID: "platform-1", Issuer: "https://lms.example.com", ClientID: "c1",
+		AuthLoginURL: "https://lms.example.com/auth", AuthTokenURL: "https://lms.example.com/token", KeySetURL: "https://lms.example.com/jwks",
+	}, nil)
+	handler := newLTITestHandler(nil, platforms, false)
+	body := `{"issuer":"https://lms.example.com","client_id":"c1","auth_login_url":"https://lms.example.com/auth",` +
+		`"auth_token_url":"https://lms.example.com/token","key_set_url":"https://lms.example.com/jwks"}`
+	rr := newRecorder()
+
+	// Act
+	handler.CreatePlatform(rr, httptest.NewRequest(http.MethodPost, "/api/v1/admin/lti/platforms", strings.NewReader(body)))
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, rr.Code, "platforms are registered before the integration is enabled")
+	var response types.LTIPlatformResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "platform-1", response.ID)
+	platforms.AssertExpectations(t)
+}
+
+func TestLTIHandler_CreatePlatform_Validation(t *testing.T) {
+	// Arrange
+	handler := newLTITestHandler(nil, new(MockLTIPlatformStore), true)
+	body := `{"org_id":"not-a-uuid","issuer":"lms","auth_login_url":"https://lms.example.com/auth",` +
+		`"auth_token_url":"https://lms.example.com/token","key_set_url":"https://lms.example.com/jwks"}`
+	rr := newRecorder()
+
+	// Act
+	handler.CreatePlatform(rr, httptest.NewRequest(http.MethodPost, "/api/v1/admin/lti/platforms", strings.NewReader(body)))
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	fields := assertValidationErrors(t, rr.Body.Bytes())
+	assert.Equal(t, "uuid", fields["org_id"])
+	assert.Equal(t, "url", fields["issuer"])
+	assert.Equal(t, "required", fields["client_id"])
+}
+
+func TestLTIHandler_CreatePlatform_Exists(t *testing.T) {
+	// Arrange
+	platforms := new(MockLTIPlatformStore)
+	platforms.On("CreatePlatform", mock.Anything, mock.Anything).Return(nil, core.ErrLTIPlatformExists)
+	handler := newLTITestHandler(nil, platforms, true)
+	body := `{"issuer":"https://lms.example.com","client_id":"c1","auth_login_url":"https://lms.example.com/auth",` +
+		`"auth_token_url":"https://lms.example.com/token","key_set_url":"https://lms.example.com/jwks"}`
+	rr := newRecorder()
+
+	// Act
+	handler.CreatePlatform(rr, httptest.NewRequest(http.MethodPost, "/api/v1/admin/lti/platforms", strings.NewReader(body)))
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assertErrorResponse(t, rr.Body.Bytes(), types.ErrorCodeLTIPlatformExists)
+}
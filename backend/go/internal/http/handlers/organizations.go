@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// OrganizationHandler handles the organization endpoints under
+// /api/v1/admin/organizations
+type OrganizationHandler struct {
+	orgs     core.OrganizationStore
+	validate *validator.Validate
+}
+
+// NewOrganizationHandler creates a new organization handler
+func NewOrganizationHandler(orgs core.OrganizationStore, validate *validator.Validate) *OrganizationHandler {
+	return &OrganizationHandler{
+		orgs:     orgs,
+		validate: validate,
+	}
+}
+
+// ListOrganizations handles GET /api/v1/admin/organizations
+// @Summary List organizations
+// @Description Returns every organization ordered by name
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.OrganizationListResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/organizations [get]
+func (h *OrganizationHandler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	orgs, err := h.orgs.List(ctx)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to list organizations")
+		respondDomainError(w, err)
+		return
+	}
+
+	response := types.OrganizationListResponse{Organizations: make([]types.OrganizationResponse, 0, len(orgs))}
+	for _, org := range orgs {
+		response.Organizations = append(response.Organizations, organizationResponse(org))
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// CreateOrganization handles POST /api/v1/admin/organizations
+// @Summary Create organization
+// @Description Creates an organization. Its members only see its projects and items; max_projects caps how many projects it may have.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.OrganizationRequest true "Organization"
+// @Success 201 {object} types.OrganizationResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/organizations [post]
+func (h *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, ok := h.decodeOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	org, err := h.orgs.Create(ctx, req.Name, req.MaxProjects)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to create organization")
+		respondDomainError(w, err)
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("org_id", org.ID).Msg("organization created")
+
+	respond.JSON(w, http.StatusCreated, organizationResponse(org))
+}
+
+// GetOrganization handles GET /api/v1/admin/organizations/{orgId}
+// @Summary Get organization
+// @Description Returns one organization
+// @Tags Admin
+// @Security BearerAuth
+// @Param orgId path string true "Organization ID" format(uuid)
+// @Produce json
+// @Success 200 {object} types.OrganizationResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 404 {object} types.ErrorResponse "organization_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/organizations/{orgId} [get]
+func (h *OrganizationHandler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	orgID := chi.URLParam(r, "orgId")
+
+	org, err := h.orgs.GetByID(ctx, orgID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("org_id", orgID).Msg("failed to get organization")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, organizationResponse(org))
+}
+
+// UpdateOrganization handles PUT /api/v1/admin/organizations/{orgId}
+// @Summary Update organization
+// @Description Renames an organization and replaces its project quota. Lowering the quota below the current number of projects keeps them but blocks new ones.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID" format(uuid)
+// @Param request body types.OrganizationRequest true "Organization"
+// @Success 200 {object} types.OrganizationResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 404 {object} types.ErrorResponse "organization_not_found"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/organizations/{orgId} [put]
+func (h *OrganizationHandler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	orgID := chi.URLParam(r, "orgId")
+
+	req, ok := h.decodeOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	org, err := h.orgs.Update(ctx, orgID, req.Name, req.MaxProjects)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("org_id", orgID).Msg("failed to update organization")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, organizationResponse(org))
+}
+
+// DeleteOrganization handles DELETE /api/v1/admin/organizations/{orgId}
+// @Summary Delete organization
+// @Description Deletes an organization together with its memberships, projects and items
+// @Tags Admin
+// @Security BearerAuth
+// @Param orgId path string true "Organization ID" format(uuid)
+// @Success 204 "No content"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 404 {object} types.ErrorResponse "organization_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/organizations/{orgId} [delete]
+func (h *OrganizationHandler) DeleteOrganization(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	orgID := chi.URLParam(r, "orgId")
+
+	if err := h.orgs.Delete(ctx, orgID); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("org_id", orgID).Msg("failed to delete organization")
+		respondDomainError(w, err)
+		return
+	}
+
+	log.Ctx(ctx).Warn().
+		Str("org_id", orgID).
+		Str("user_id", httpmiddleware.GetUserID(ctx)).
+		Msg("organization deleted")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListMembers handles GET /api/v1/admin/organizations/{orgId}/members
+// @Summary List organization members
+// @Description Returns the organization's members ordered by user ID
+// @Tags Admin
+// @Security BearerAuth
+// @Param orgId path string true "Organization ID" format(uuid)
+// @Produce json
+// @Success 200 {object} types.MembershipListResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 404 {object} types.ErrorResponse "organization_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/organizations/{orgId}/members [get]
+func (h *OrganizationHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	orgID := chi.URLParam(r, "orgId")
+
+	members, err := h.orgs.ListMembers(ctx, orgID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("org_id", orgID).Msg("failed to list organization members")
+		respondDomainError(w, err)
+		return
+	}
+
+	response := types.MembershipListResponse{Members: make([]types.MembershipResponse, 0, len(members))}
+	for _, member := range members {
+		response.Members = append(response.Members, membershipResponse(member))
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// PutMember handles PUT /api/v1/admin/organizations/{orgId}/members/{userId}
+// @Summary Add or update organization member
+// @Description Adds the user to the organization, or changes their role if they already are a member. Members may switch to the organization with the X-Org-ID header.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID" format(uuid)
+// @Param userId path string true "User ID"
+// @Param request body types.MembershipRequest true "Membership"
+// @Success 200 {object} types.MembershipResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 404 {object} types.ErrorResponse "organization_not_found"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/organizations/{orgId}/members/{userId} [put]
+func (h *OrganizationHandler) PutMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	orgID := chi.URLParam(r, "orgId")
+	userID := chi.URLParam(r, "userId")
+
+	var req types.MembershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpmiddleware.SendBodyReadError(w, err)
+		return
+	}
+
+	if err := h.validate.StructCtx(ctx, req); err != nil {
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
+		return
+	}
+
+	member, err := h.orgs.PutMember(ctx, orgID, userID, req.Role)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("org_id", orgID).Msg("failed to put organization member")
+		respondDomainError(w, err)
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("org_id", orgID).
+		Str("member_id", userID).
+		Str("role", member.Role).
+		Msg("organization member updated")
+
+	respond.JSON(w, http.StatusOK, membershipResponse(member))
+}
+
+// RemoveMember handles DELETE /api/v1/admin/organizations/{orgId}/members/{userId}
+// @Summary Remove organization member
+// @Description Removes the user from the organization
+// @Tags Admin
+// @Security BearerAuth
+// @Param orgId path string true "Organization ID" format(uuid)
+// @Param userId path string true "User ID"
+// @Success 204 "No content"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 404 {object} types.ErrorResponse "membership_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/organizations/{orgId}/members/{userId} [delete]
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	orgID := chi.URLParam(r, "orgId")
+	userID := chi.URLParam(r, "userId")
+
+	if err := h.orgs.RemoveMember(ctx, orgID, userID); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("org_id", orgID).Msg("failed to remove organization member")
+		respondDomainError(w, err)
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("org_id", orgID).
+		Str("member_id", userID).
+		Msg("organization member removed")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeOrganization reads and validates an organization request, writing
+// the error response if it is invalid
+func (h *OrganizationHandler) decodeOrganization(w http.ResponseWriter, r *http.Request) (types.OrganizationRequest, bool) {
+	var req types.OrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpmiddleware.SendBodyReadError(w, err)
+		return req, false
+	}
+
+	if err := h.validate.StructCtx(r.Context(), req); err != nil {
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
+		return req, false
+	}
+	return req, true
+}
+
+func organizationResponse(org *core.Organization) types.OrganizationResponse {
+	return types.OrganizationResponse{
+		ID:          org.ID,
+		Name:        org.Name,
+		MaxProjects: org.MaxProjects,
+		CreatedAt:   org.CreatedAt,
+		UpdatedAt:   org.UpdatedAt,
+	}
+}
+
+func membershipResponse(member *core.Membership) types.MembershipResponse {
+	return types.MembershipResponse{
+		OrgID:     member.OrgID,
+		UserID:    member.UserID,
+		Role:      member.Role,
+		CreatedAt: member.CreatedAt,
+	}
+}
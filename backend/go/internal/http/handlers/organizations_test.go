@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// newOrganizationRequest builds a request carrying the given URL parameters,
+// alternating names and values
+func newOrganizationRequest(method, body string, params ...string) *http.Request {
+	req := httptest.NewRequest(method, "/api/v1/admin/organizations", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rctx := chi.NewRouteContext()
+	for i := 0; i+1 < len(params); i += 2 {
+		rctx.URLParams.Add(params[i], params[i+1])
+	}
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestOrganizationHandler_CreateAndGet(t *testing.T) {
+	// Arrange
+	handler := NewOrganizationHandler(store.NewMemoryOrganizationStore(), httpmiddleware.NewValidator())
+	created := newRecorder()
+
+	// Act
+	handler.CreateOrganization(created, newOrganizationRequest(http.MethodPost, `{"name":"Engineering","max_projects":5}`))
+
+	// Assert
+	require.Equal(t, http.StatusCreated, created.Code)
+	var org types.OrganizationResponse
+	require.NoError(t, json.Unmarshal(created.Body.Bytes(), &org))
+	assert.Equal(t, "Engineering", org.Name)
+	require.NotNil(t, org.MaxProjects)
+	assert.Equal(t, 5, *org.MaxProjects)
+
+	got := newRecorder()
+	handler.GetOrganization(got, newOrganizationRequest(http.MethodGet, "", "orgId", org.ID))
+	assert.Equal(t, http.StatusOK, got.Code)
+}
+
+func TestOrganizationHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		serve          func(h *OrganizationHandler, w http.ResponseWriter)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "missing name fails validation",
+			serve: func(h *OrganizationHandler, w http.ResponseWriter) {
+				h.CreateOrganization(w, newOrganizationRequest(http.MethodPost, `{"max_projects":1}`))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   types.ErrorCodeValidationFailed,
+		},
+		{
+			name: "unknown organization",
+			serve: func(h *OrganizationHandler, w http.ResponseWriter) {
+				h.GetOrganization(w, newOrganizationRequest(http.MethodGet, "", "orgId", "missing"))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   types.ErrorCodeOrganizationNotFound,
+		},
+		{
+			name: "member of unknown organization",
+			serve: func(h *OrganizationHandler, w http.ResponseWriter) {
+				h.PutMember(w, newOrganizationRequest(http.MethodPut, `{"role":"member"}`, "orgId", "missing", "userId", "user-1"))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   types.ErrorCodeOrganizationNotFound,
+		},
+		{
+			name: "removing a non-member",
+			serve: func(h *OrganizationHandler, w http.ResponseWriter) {
+				h.RemoveMember(w, newOrganizationRequest(http.MethodDelete, "", "orgId", "missing", "userId", "user-1"))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   types.ErrorCodeMembershipNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := NewOrganizationHandler(store.NewMemoryOrganizationStore(), httpmiddleware.NewValidator())
+			rr := newRecorder()
+
+			// Act
+			tt.serve(handler, rr)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+		})
+	}
+}
+
+func TestOrganizationHandler_Members(t *testing.T) {
+	// Arrange
+	orgs := store.NewMemoryOrganizationStore()
+	org, err := orgs.Create(context.Background(), "Engineering", nil)
+	require.NoError(t, err)
+	handler := NewOrganizationHandler(orgs, httpmiddleware.NewValidator())
+
+	// Act
+	put := newRecorder()
+	handler.PutMember(put, newOrganizationRequest(http.MethodPut, `{"role":"admin"}`, "orgId", org.ID, "userId", "user-1"))
+	invalid := newRecorder()
+	handler.PutMember(invalid, newOrganizationRequest(http.MethodPut, `{"role":"owner"}`, "orgId", org.ID, "userId", "user-2"))
+	list := newRecorder()
+	handler.ListMembers(list, newOrganizationRequest(http.MethodGet, "", "orgId", org.ID))
+
+	// Assert
+	require.Equal(t, http.StatusOK, put.Code)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+	assert.Equal(t, map[string]string{"role": "oneof"}, assertValidationErrors(t, invalid.Body.Bytes()))
+
+	var members types.MembershipListResponse
+	require.NoError(t, json.Unmarshal(list.Body.Bytes(), &members))
+	require.Len(t, members.Members, 1)
+	assert.Equal(t, "user-1", members.Members[0].UserID)
+	assert.Equal(t, "admin", members.Members[0].Role)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/i18n"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// ParticipantEraser erases what is kept of a participant, satisfied by
+// *core.AttemptService
+type ParticipantEraser interface {
+	EraseParticipant(ctx context.Context, participantID string, mode core.RetentionMode) (*core.ErasureReport, error)
+}
+
+// ParticipantHandler handles the data kept of the learners taking projects
+type ParticipantHandler struct {
+	eraser ParticipantEraser
+}
+
+// NewParticipantHandler creates a new participant handler
+func NewParticipantHandler(eraser ParticipantEraser) *ParticipantHandler {
+	return &ParticipantHandler{eraser: eraser}
+}
+
+// EraseData handles DELETE /api/v1/participants/{participantId}/data
+// @Summary Erase a participant's data
+// @Description Erases every attempt a participant took, in every project and organization, previews included: with mode delete, the default, the attempts are deleted with their answers and results; with mode anonymize they are kept with their answers and scores, for the projects' stats, but no longer name the participant. Attempts are erased in batches, each in its own transaction, so a failure leaves the batches before it erased; the request can be repeated. Only the participant themselves and operators may erase a participant's data. Every erasure is audit-logged.
+// @Tags Participants
+// @Security BearerAuth
+// @Produce json
+// @Param participantId path string true "Participant ID, the user ID of the signed-in learner"
+// @Param mode query string false "What to do to the attempts" Enums(delete, anonymize) default(delete)
+// @Success 200 {object} types.ParticipantDataErasureResponse
+// @Failure 400 {object} types.ErrorResponse "validation_failed"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "resource_access_denied"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/participants/{participantId}/data [delete]
+func (h *ParticipantHandler) EraseData(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	participantID := chi.URLParam(r, "participantId")
+
+	mode := core.RetentionModeDelete
+	if raw := r.URL.Query().Get("mode"); raw != "" {
+		mode = core.RetentionMode(raw)
+	}
+	if !mode.Valid() {
+		fieldErr := types.ValidationError{Field: "mode", Tag: "oneof", Param: "delete anonymize"}
+		fieldErr.Message = i18n.Translate(i18n.DefaultLocale, "validation.oneof", respond.ValidationParams(fieldErr), fieldErr.Tag)
+		respond.ValidationError(w, []types.ValidationError{fieldErr})
+		return
+	}
+
+	report, err := h.eraser.EraseParticipant(ctx, participantID, mode)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("participant_id", participantID).Msg("failed to erase participant data")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, types.ParticipantDataErasureResponse{
+		ParticipantID: participantID,
+		Mode:          string(mode),
+		Attempts:      report.Attempts,
+		Answers:       report.Answers,
+		Results:       report.Results,
+	})
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// participantEraser is a ParticipantEraser that erases "learner-1"'s two
+// attempts and denies every other participant
+type participantEraser struct {
+	// mode is what the last call asked for
+	mode core.RetentionMode
+}
+
+func (e *participantEraser) EraseParticipant(ctx context.Context, participantID string, mode core.RetentionMode) (*core.ErasureReport, error) {
+	if participantID != "learner-1" {
+		return nil, core.ErrParticipantAccessDenied
+	}
+	e.mode = mode
+	if mode == core.RetentionModeAnonymize {
+		return &core.ErasureReport{Attempts: 2}, nil
+	}
+	return &core.ErasureReport{Attempts: 2, Answers: 5, Results: 6}, nil
+}
+
+func participantRequest(participantID, query string) *http.Request {
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/participants/"+participantID+"/data"+query, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("participantId", participantID)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestParticipantHandler_EraseData(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected types.ParticipantDataErasureResponse
+	}{
+		{
+			name:     "deletes by default",
+			expected: types.ParticipantDataErasureResponse{ParticipantID: "learner-1", Mode: "delete", Attempts: 2, Answers: 5, Results: 6},
+		},
+		{
+			name:     "anonymize",
+			query:    "?mode=anonymize",
+			expected: types.ParticipantDataErasureResponse{ParticipantID: "learner-1", Mode: "anonymize", Attempts: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			eraser := &participantEraser{}
+			handler := NewParticipantHandler(eraser)
+			rr := newRecorder()
+
+			// Act
+			handler.EraseData(rr, participantRequest("learner-1", tt.query))
+
+			// Assert
+			require.Equal(t, http.StatusOK, rr.Code)
+			var response types.ParticipantDataErasureResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tt.expected, response)
+			assert.Equal(t, core.RetentionMode(tt.expected.Mode), eraser.mode)
+		})
+	}
+}
+
+func TestParticipantHandler_EraseData_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		participantID  string
+		query          string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "another participant",
+			participantID:  "learner-2",
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "resource_access_denied",
+		},
+		{
+			name:           "unknown mode",
+			participantID:  "learner-1",
+			query:          "?mode=shred",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			eraser := &participantEraser{}
+			handler := NewParticipantHandler(eraser)
+			rr := newRecorder()
+
+			// Act
+			handler.EraseData(rr, participantRequest(tt.participantID, tt.query))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedCode != "" {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+				return
+			}
+			assert.Equal(t, map[string]string{"mode": "oneof"}, assertValidationErrors(t, rr.Body.Bytes()))
+			assert.Empty(t, eraser.mode, "nothing is erased")
+		})
+	}
+}
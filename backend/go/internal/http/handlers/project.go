@@ -6,65 +6,155 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog/log"
 
 	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/pagination"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/i18n"
 	"github.com/provemyself/backend/internal/types"
 )
 
+// ProjectService is the project business logic the handler depends on,
+// satisfied by *core.ProjectService
+type ProjectService interface {
+	Create(ctx context.Context, title string, description *string, tags []string) (*core.Project, error)
+	GetByID(ctx context.Context, id string) (*core.Project, error)
+	List(ctx context.Context, opts core.ListOptions) (*core.ProjectPage, error)
+	Update(ctx context.Context, id string, title string, description *string, tags []string, version *int) (*core.Project, error)
+	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) (*core.Project, error)
+	Purge(ctx context.Context, id string) error
+	Publish(ctx context.Context, id string) (*core.Project, error)
+	Duplicate(ctx context.Context, id string) (*core.Project, error)
+	UpdateResultsSettings(ctx context.Context, id string, settings core.ResultsSettings) (*core.Project, error)
+	ListPublications(ctx context.Context, projectID string, limit, offset int) ([]*core.Publication, int, error)
+	GetPublication(ctx context.Context, projectID string, version int) (*core.Publication, error)
+}
+
+// ProjectItems lists the items embedded in a project, satisfied by
+// *core.ItemService
+type ProjectItems interface {
+	ListByProject(ctx context.Context, projectID string) ([]*core.Item, error)
+}
+
 // ProjectHandler handles project-related HTTP requests
 type ProjectHandler struct {
-	service  *core.ProjectService
+	service  ProjectService
 	validate *validator.Validate
+	items    ProjectItems
 }
 
 // NewProjectHandler creates a new project handler
-func NewProjectHandler(service *core.ProjectService, validate *validator.Validate) *ProjectHandler {
+func NewProjectHandler(service ProjectService, validate *validator.Validate) *ProjectHandler {
 	return &ProjectHandler{
 		service:  service,
 		validate: validate,
 	}
 }
 
+// SetItems enables include=items, embedding a project's items in it
+func (h *ProjectHandler) SetItems(items ProjectItems) {
+	h.items = items
+}
+
 // ListProjects handles GET /api/v1/projects
 // @Summary List projects
-// @Description Retrieve a list of quiz projects for the authenticated user
+// @Description Retrieve a page of quiz projects, optionally only those matching a search term, having tags or in a publication state. Pages are read by offset, or after the next_cursor of the previous page, which stays stable while projects are created; cursor pages report a total of -1. Send Accept: text/csv or application/x-ndjson, or the format parameter, to get the page as CSV with a header row or as one JSON project per line instead of the JSON envelope.
 // @Tags Projects
 // @Param limit query int false "Maximum number of projects to return" minimum(1) maximum(100) default(20)
 // @Param offset query int false "Number of projects to skip" minimum(0) default(0)
-// @Produce json
+// @Param cursor query string false "next_cursor of the previous page, with the same filters and sort; replaces offset"
+// @Param search query string false "Only projects whose title or description contains the term, case-insensitively; % and _ match literally"
+// @Param tag query []string false "Only projects having the tag; repeat the parameter to require several" collectionFormat(multi)
+// @Param published query bool false "Only published projects if true, only drafts if false"
+// @Param sort query string false "Order of the list, a field ascending or after a - descending; newest first by default" Enums(created_at, -created_at, updated_at, -updated_at, title, -title)
+// @Param format query string false "Response format, overriding Accept" Enums(json, csv, ndjson)
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Produce json,text/csv,application/x-ndjson
 // @Success 200 {object} types.ProjectListResponse
-// @Failure 401 {object} types.ErrorResponse
-// @Failure 500 {object} types.ErrorResponse
-// @Router /projects [get]
+// @Success 304 "Not modified"
+// @Failure 400 {object} types.ErrorResponse "invalid_pagination, validation_failed, invalid_sort, invalid_cursor, unsupported_format"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects [get]
 func (h *ProjectHandler) ListProjects(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-
-	// Parse query parameters
-	limit := 20
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 && parsed <= 100 {
-			limit = parsed
-		}
+	h.listProjects(w, r, false)
+}
+
+// ListTrash handles GET /api/v1/projects/trash
+// @Summary List deleted projects
+// @Description Retrieve a page of the projects in the trash, which can be restored or purged. Takes the parameters of the project list.
+// @Tags Projects
+// @Param limit query int false "Maximum number of projects to return" minimum(1) maximum(100) default(20)
+// @Param offset query int false "Number of projects to skip" minimum(0) default(0)
+// @Param cursor query string false "next_cursor of the previous page, with the same filters and sort; replaces offset"
+// @Param search query string false "Only projects whose title or description contains the term, case-insensitively; % and _ match literally"
+// @Param tag query []string false "Only projects having the tag; repeat the parameter to require several" collectionFormat(multi)
+// @Param published query bool false "Only published projects if true, only drafts if false"
+// @Param sort query string false "Order of the list, a field ascending or after a - descending; newest first by default" Enums(created_at, -created_at, updated_at, -updated_at, title, -title)
+// @Param format query string false "Response format, overriding Accept" Enums(json, csv, ndjson)
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Produce json,text/csv,application/x-ndjson
+// @Success 200 {object} types.ProjectListResponse
+// @Success 304 "Not modified"
+// @Failure 400 {object} types.ErrorResponse "invalid_pagination, validation_failed, invalid_sort, invalid_cursor, unsupported_format"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/trash [get]
+func (h *ProjectHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	h.listProjects(w, r, true)
+}
+
+// listProjects writes a page of the projects selected by r's query, of the
+// trash if deleted
+func (h *ProjectHandler) listProjects(w http.ResponseWriter, r *http.Request, deleted bool) {
+	ctx := r.Context()
+
+	page, err := pagination.Parse(r, pagination.Page{Limit: 20}, 100)
+	if err != nil {
+		pagination.WriteError(w, err)
+		return
 	}
+	limit, offset := page.Limit, page.Offset
 
-	offset := 0
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if parsed, err := strconv.Atoi(offsetStr); err == nil && parsed >= 0 {
-			offset = parsed
-		}
+	opts, ok := projectListOptions(w, r)
+	if !ok {
+		return
+	}
+	opts.Limit, opts.Offset = limit, offset
+	opts.Deleted = deleted
+	if opts.Sort, ok = parseSort(w, r, projectSorts); !ok {
+		return
+	}
+	if opts.After, ok = parseProjectCursor(w, r, opts.Sort); !ok {
+		return
+	}
+	if opts.After != nil {
+		// A cursor replaces the offset
+		offset, opts.Offset = 0, 0
 	}
 
-	// Get projects from service
-	projects, total, err := h.service.List(ctx, limit, offset)
+	format, ok := respond.NegotiateFormat(w, r)
+	if !ok {
+		return
+	}
+
+	// The store filters, pages and counts
+	listed, err := h.service.List(ctx, opts)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to list projects")
-		h.sendJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to list projects")
+		respondDomainError(w, err)
+		return
+	}
+	projects, total := listed.Projects, listed.Total
+
+	if checkNotModified(w, r, projectListETag(listed, limit, offset, format)) {
 		return
 	}
 
@@ -78,7 +168,12 @@ func (h *ProjectHandler) ListProjects(w http.ResponseWriter, r *http.Request) {
 			Tags:        project.Tags,
 			CreatedAt:   project.CreatedAt,
 			UpdatedAt:   project.UpdatedAt,
+			Version:     project.Version,
 			PublishedAt: project.PublishedAt,
+			DeletedAt:   project.DeletedAt,
+			ItemCount:   project.ItemCount,
+
+			LatestPublishedVersion: project.LatestPublishedVersion,
 		}
 	}
 
@@ -88,8 +183,40 @@ func (h *ProjectHandler) ListProjects(w http.ResponseWriter, r *http.Request) {
 		Limit:    limit,
 		Offset:   offset,
 	}
+	if listed.HasMore && len(projects) > 0 {
+		response.NextCursor = encodeProjectCursor(opts.Sort, projects[len(projects)-1])
+	}
 
-	h.sendJSONResponse(w, http.StatusOK, response)
+	respond.Negotiated(w, r, projectRows{response: response})
+}
+
+// projectListOptions reads the filters of a project list from r's query.
+// A blank search term and blank tags are ignored. Unless published is a
+// boolean, it writes a 400 validation_failed and returns false.
+func projectListOptions(w http.ResponseWriter, r *http.Request) (core.ListOptions, bool) {
+	query := r.URL.Query()
+	opts := core.ListOptions{Search: strings.TrimSpace(query.Get("search"))}
+
+	for _, tag := range query["tag"] {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			opts.Tags = append(opts.Tags, tag)
+		}
+	}
+
+	if raw := query.Get("published"); raw != "" {
+		published, err := strconv.ParseBool(raw)
+		if err != nil {
+			fieldErr := types.ValidationError{Field: "published", Tag: "boolean"}
+			fieldErr.Message = i18n.Translate(i18n.DefaultLocale, "validation.boolean", respond.ValidationParams(fieldErr), "boolean")
+			respond.ValidationError(w, []types.ValidationError{fieldErr})
+			return opts, false
+		}
+		opts.Status = core.ProjectStatusDraft
+		if published {
+			opts.Status = core.ProjectStatusPublished
+		}
+	}
+	return opts, true
 }
 
 // CreateProject handles POST /api/v1/projects
@@ -100,25 +227,26 @@ func (h *ProjectHandler) ListProjects(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param request body types.CreateProjectRequest true "Project creation request"
 // @Success 201 {object} types.ProjectResponse
-// @Failure 400 {object} types.ErrorResponse
-// @Failure 401 {object} types.ErrorResponse
-// @Failure 422 {object} types.ErrorResponse
-// @Failure 500 {object} types.ErrorResponse
-// @Router /projects [post]
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 409 {object} types.ErrorResponse "project_quota_exceeded"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 422 {object} types.ErrorResponse "title_too_long"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects [post]
 func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	var req types.CreateProjectRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to decode request")
-		h.sendJSONError(w, http.StatusBadRequest, "invalid_request_body", "Invalid request body")
+		httpmiddleware.SendBodyReadError(w, err)
 		return
 	}
 
 	if err := h.validate.StructCtx(ctx, req); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("validation failed")
-		h.sendJSONError(w, http.StatusBadRequest, "validation_failed", "Validation failed", err.Error())
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
 		return
 	}
 
@@ -126,14 +254,7 @@ func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to create project")
 		
-		switch {
-		case errors.Is(err, core.ErrProjectTitleTooShort):
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "title_too_short", "Project title is too short")
-		case errors.Is(err, core.ErrProjectTitleTooLong):
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "title_too_long", "Project title is too long")
-		default:
-			h.sendJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to create project")
-		}
+		respondDomainError(w, err)
 		return
 	}
 
@@ -144,30 +265,39 @@ func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 		Tags:        project.Tags,
 		CreatedAt:   project.CreatedAt,
 		UpdatedAt:   project.UpdatedAt,
+		Version:     project.Version,
 		PublishedAt: project.PublishedAt,
 	}
 
-	h.sendJSONResponse(w, http.StatusCreated, response)
+	respond.JSON(w, http.StatusCreated, response)
 }
 
 // GetProject handles GET /api/v1/projects/{projectId}
 // @Summary Get project
-// @Description Retrieve a specific project by ID
+// @Description Retrieve a specific project by ID, with its item count. With include=items the project's items are embedded too, in position order.
 // @Tags Projects
 // @Param projectId path string true "Project ID" format(uuid)
+// @Param include query string false "Related resources to embed" Enums(items)
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Produce json
 // @Success 200 {object} types.ProjectResponse
-// @Failure 401 {object} types.ErrorResponse
-// @Failure 404 {object} types.ErrorResponse
-// @Failure 500 {object} types.ErrorResponse
-// @Router /projects/{projectId} [get]
+// @Success 304 "Not modified"
+// @Failure 400 {object} types.ErrorResponse "validation_failed"
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId} [get]
 func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	projectID := chi.URLParam(r, "projectId")
 	if projectID == "" {
-		h.sendJSONError(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
+	}
+
+	includeItems, ok := h.parseInclude(w, r)
+	if !ok {
 		return
 	}
 
@@ -175,11 +305,21 @@ func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to get project")
 		
-		if errors.Is(err, core.ErrProjectNotFound) {
-			h.sendJSONError(w, http.StatusNotFound, "project_not_found", "Project not found")
-		} else {
-			h.sendJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to get project")
+		respondDomainError(w, err)
+		return
+	}
+
+	var items []*core.Item
+	if includeItems {
+		items, err = h.items.ListByProject(ctx, projectID)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to list project items")
+			respondDomainError(w, err)
+			return
 		}
+	}
+
+	if checkNotModified(w, r, projectReadETag(project, items)) {
 		return
 	}
 
@@ -190,67 +330,187 @@ func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
 		Tags:        project.Tags,
 		CreatedAt:   project.CreatedAt,
 		UpdatedAt:   project.UpdatedAt,
+		Version:     project.Version,
 		PublishedAt: project.PublishedAt,
+		ItemCount:   project.ItemCount,
+
+		LatestPublishedVersion: project.LatestPublishedVersion,
+	}
+	setResultsPolicy(&response, project)
+	if includeItems {
+		response.Items = make([]types.ItemResponse, len(items))
+		for i, item := range items {
+			response.Items[i] = types.ItemResponse{
+				ID:          item.ID,
+				ProjectID:   item.ProjectID,
+				Type:        item.Type,
+				Title:       item.Title,
+				Content:     item.Content,
+				Position:    item.Position,
+				Required:    item.Required,
+				Points:      item.Points,
+				Explanation: item.Explanation,
+				CreatedAt:   item.CreatedAt,
+				UpdatedAt:   item.UpdatedAt,
+				Version:     item.Version,
+			}
+		}
 	}
 
-	h.sendJSONResponse(w, http.StatusOK, response)
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// parseInclude reads the include parameter of a project read, a comma
+// separated list of related resources to embed, and reports whether the
+// items are among them. Unless each is one the handler can embed, it
+// writes a 400 validation_failed and returns false.
+func (h *ProjectHandler) parseInclude(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	includeItems := false
+	for _, raw := range r.URL.Query()["include"] {
+		for _, include := range strings.Split(raw, ",") {
+			switch include = strings.TrimSpace(include); {
+			case include == "":
+			case include == "items" && h.items != nil:
+				includeItems = true
+			default:
+				fieldErr := types.ValidationError{Field: "include", Tag: "oneof"}
+				if h.items != nil {
+					fieldErr.Param = "items"
+				}
+				fieldErr.Message = i18n.Translate(i18n.DefaultLocale, "validation.oneof", respond.ValidationParams(fieldErr), "oneof")
+				respond.ValidationError(w, []types.ValidationError{fieldErr})
+				return false, false
+			}
+		}
+	}
+	return includeItems, true
 }
 
 // UpdateProject handles PUT /api/v1/projects/{projectId}
 // @Summary Update project
-// @Description Update an existing project
+// @Description Update an existing project. With an If-Match ETag, or a version in the body, which wins, the update fails with version_conflict, and the project as it is now, unless the project is still at that version.
 // @Tags Projects
 // @Accept json
 // @Produce json
 // @Param projectId path string true "Project ID" format(uuid)
+// @Param If-Match header string false "ETag of the version the update is based on"
 // @Param request body types.UpdateProjectRequest true "Project update request"
 // @Success 200 {object} types.ProjectResponse
-// @Failure 400 {object} types.ErrorResponse
-// @Failure 401 {object} types.ErrorResponse
-// @Failure 404 {object} types.ErrorResponse
-// @Failure 422 {object} types.ErrorResponse
-// @Failure 500 {object} types.ErrorResponse
-// @Router /projects/{projectId} [put]
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 412 {object} types.ErrorResponse "version_conflict"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 422 {object} types.ErrorResponse "title_too_long"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId} [put]
 func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	projectID := chi.URLParam(r, "projectId")
 	if projectID == "" {
-		h.sendJSONError(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
 		return
 	}
 
 	var req types.UpdateProjectRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to decode request")
-		h.sendJSONError(w, http.StatusBadRequest, "invalid_request_body", "Invalid request body")
+		httpmiddleware.SendBodyReadError(w, err)
 		return
 	}
 
 	if err := h.validate.StructCtx(ctx, req); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("validation failed")
-		h.sendJSONError(w, http.StatusBadRequest, "validation_failed", "Validation failed", err.Error())
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
 		return
 	}
 
-	project, err := h.service.Update(ctx, projectID, req.Title, req.Description, req.Tags)
+	project, err := h.service.Update(ctx, projectID, req.Title, req.Description, req.Tags, expectedVersion(r, req.Version))
+	if errors.Is(err, core.ErrVersionConflict) {
+		h.respondVersionConflict(w, r, projectID)
+		return
+	}
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to update project")
 		
-		switch {
-		case errors.Is(err, core.ErrProjectNotFound):
-			h.sendJSONError(w, http.StatusNotFound, "project_not_found", "Project not found")
-		case errors.Is(err, core.ErrProjectTitleTooShort):
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "title_too_short", "Project title is too short")
-		case errors.Is(err, core.ErrProjectTitleTooLong):
-			h.sendJSONError(w, http.StatusUnprocessableEntity, "title_too_long", "Project title is too long")
-		default:
-			h.sendJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to update project")
-		}
+		respondDomainError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", projectETag(project))
+	respond.JSON(w, http.StatusOK, projectResponse(project))
+}
+
+// respondVersionConflict writes the 412 version_conflict of an update that
+// found the project at another version, with the project as it is now and
+// its ETag
+func (h *ProjectHandler) respondVersionConflict(w http.ResponseWriter, r *http.Request, projectID string) {
+	project, err := h.service.GetByID(r.Context(), projectID)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("project_id", projectID).Msg("failed to get conflicting project")
+		respondDomainError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", projectETag(project))
+	respond.ConflictError(w, types.ErrVersionConflict.StatusCode, types.ErrVersionConflict.Code, types.ErrVersionConflict.Message, projectResponse(project))
+}
+
+// UpdateResultsPolicy handles PUT /api/v1/projects/{projectId}/results-policy
+// @Summary Set results policy
+// @Description Set what a project's learners are shown of their grades. none shows nothing, as for a survey; score_only shows the score once the attempt is submitted, as for an exam; after_submit, the default, shows the score and each question's result with its explanation once submitted; after_close_date does the same from results_available_at on, by the server's clock; practice also grades each answer as it is saved. The policy applies to taking attempts from then on, and to reading attempts already taken.
+// @Tags Projects
+// @Accept json
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param request body types.UpdateResultsPolicyRequest true "Results policy"
+// @Success 200 {object} types.ProjectResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 422 {object} types.ErrorResponse "invalid_results_policy, results_available_at_required"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/results-policy [put]
+func (h *ProjectHandler) UpdateResultsPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
+	}
+
+	var req types.UpdateResultsPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to decode request")
+		httpmiddleware.SendBodyReadError(w, err)
+		return
+	}
+
+	if err := h.validate.StructCtx(ctx, req); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("validation failed")
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
+		return
+	}
+
+	project, err := h.service.UpdateResultsSettings(ctx, projectID, core.ResultsSettings{
+		Policy:      core.ResultsPolicy(req.ResultsPolicy),
+		AvailableAt: req.ResultsAvailableAt,
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to update results policy")
+		respondDomainError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", projectETag(project))
+	respond.JSON(w, http.StatusOK, projectResponse(project))
+}
+
+// projectResponse is the response of a single project, without its items
+func projectResponse(project *core.Project) types.ProjectResponse {
 	response := types.ProjectResponse{
 		ID:          project.ID,
 		Title:       project.Title,
@@ -258,29 +518,41 @@ func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
 		Tags:        project.Tags,
 		CreatedAt:   project.CreatedAt,
 		UpdatedAt:   project.UpdatedAt,
+		Version:     project.Version,
 		PublishedAt: project.PublishedAt,
+
+		LatestPublishedVersion: project.LatestPublishedVersion,
 	}
+	setResultsPolicy(&response, project)
+	return response
+}
 
-	h.sendJSONResponse(w, http.StatusOK, response)
+// setResultsPolicy sets a project response's results policy, if the
+// project was read with it
+func setResultsPolicy(response *types.ProjectResponse, project *core.Project) {
+	if project.ResultsSettings == nil {
+		return
+	}
+	response.ResultsPolicy = string(project.ResultsSettings.Policy)
+	response.ResultsAvailableAt = project.ResultsSettings.AvailableAt
 }
 
 // DeleteProject handles DELETE /api/v1/projects/{projectId}
 // @Summary Delete project
-// @Description Delete a project by ID
+// @Description Move a project to the trash. It and its items are hidden until it is restored, and removed for good once it is purged.
 // @Tags Projects
 // @Param projectId path string true "Project ID" format(uuid)
 // @Success 204 "Project deleted successfully"
-// @Failure 401 {object} types.ErrorResponse
-// @Failure 404 {object} types.ErrorResponse
-// @Failure 500 {object} types.ErrorResponse
-// @Router /projects/{projectId} [delete]
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId} [delete]
 func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	projectID := chi.URLParam(r, "projectId")
 	if projectID == "" {
-		h.sendJSONError(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
 		return
 	}
 
@@ -288,48 +560,39 @@ func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to delete project")
 		
-		if errors.Is(err, core.ErrProjectNotFound) {
-			h.sendJSONError(w, http.StatusNotFound, "project_not_found", "Project not found")
-		} else {
-			h.sendJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to delete project")
-		}
+		respondDomainError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// PublishProject handles POST /api/v1/projects/{projectId}/publish
-// @Summary Publish project
-// @Description Mark a project as published
+// RestoreProject handles POST /api/v1/projects/{projectId}/restore
+// @Summary Restore project
+// @Description Take a project out of the trash, with the items it had when it was deleted. The project counts towards the organization's quota again.
 // @Tags Projects
 // @Param projectId path string true "Project ID" format(uuid)
 // @Produce json
 // @Success 200 {object} types.ProjectResponse
-// @Failure 401 {object} types.ErrorResponse
-// @Failure 404 {object} types.ErrorResponse
-// @Failure 409 {object} types.ErrorResponse
-// @Failure 500 {object} types.ErrorResponse
-// @Router /projects/{projectId}/publish [post]
-func (h *ProjectHandler) PublishProject(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 409 {object} types.ErrorResponse "project_quota_exceeded"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/restore [post]
+func (h *ProjectHandler) RestoreProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	projectID := chi.URLParam(r, "projectId")
 	if projectID == "" {
-		h.sendJSONError(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
 		return
 	}
 
-	project, err := h.service.Publish(ctx, projectID)
+	project, err := h.service.Restore(ctx, projectID)
 	if err != nil {
-		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to publish project")
-		
-		if errors.Is(err, core.ErrProjectNotFound) {
-			h.sendJSONError(w, http.StatusNotFound, "project_not_found", "Project not found")
-		} else {
-			h.sendJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to publish project")
-		}
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to restore project")
+
+		respondDomainError(w, err)
 		return
 	}
 
@@ -340,36 +603,114 @@ func (h *ProjectHandler) PublishProject(w http.ResponseWriter, r *http.Request)
 		Tags:        project.Tags,
 		CreatedAt:   project.CreatedAt,
 		UpdatedAt:   project.UpdatedAt,
+		Version:     project.Version,
 		PublishedAt: project.PublishedAt,
 	}
 
-	h.sendJSONResponse(w, http.StatusOK, response)
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// PurgeProject handles DELETE /api/v1/projects/{projectId}/purge
+// @Summary Purge project
+// @Description Permanently delete a project in the trash, its items and its stored assets. This cannot be undone; delete the project first.
+// @Tags Projects
+// @Param projectId path string true "Project ID" format(uuid)
+// @Success 204 "Project purged successfully"
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/purge [delete]
+func (h *ProjectHandler) PurgeProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
+	}
+
+	if err := h.service.Purge(ctx, projectID); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to purge project")
+
+		respondDomainError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
-// Helper methods for consistent JSON responses
+// PublishProject handles POST /api/v1/projects/{projectId}/publish
+// @Summary Publish project
+// @Description Publish a project: freeze it and its items as its next publication, numbered from 1, which later edits don't change. Publishing again publishes the next version; published_at stays the first publication's. A project without items, or with a question that has no correct answer, is not published: error.problems lists the offending items and why.
+// @Tags Projects
+// @Param projectId path string true "Project ID" format(uuid)
+// @Produce json
+// @Success 200 {object} types.ProjectResponse
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 422 {object} types.ErrorResponse "project_not_publishable"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/publish [post]
+func (h *ProjectHandler) PublishProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-func (h *ProjectHandler) sendJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Error().Err(err).Msg("failed to encode JSON response")
+	projectID := chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
 	}
+
+	project, err := h.service.Publish(ctx, projectID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to publish project")
+		
+		respondDomainError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", projectETag(project))
+	respond.JSON(w, http.StatusOK, projectResponse(project))
 }
 
-func (h *ProjectHandler) sendJSONError(w http.ResponseWriter, statusCode int, code, message string, details ...string) {
-	var detailsPtr *string
-	if len(details) > 0 {
-		detailsPtr = &details[0]
+// DuplicateProject handles POST /api/v1/projects/{projectId}/duplicate
+// @Summary Duplicate project
+// @Description Copy a project and all its items into a new draft project titled like it with " (copy)" appended. Items keep their positions, content, points and explanations. The copy is unpublished.
+// @Tags Projects
+// @Param projectId path string true "Project ID" format(uuid)
+// @Produce json
+// @Success 201 {object} types.ProjectResponse
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 409 {object} types.ErrorResponse "project_quota_exceeded"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/duplicate [post]
+func (h *ProjectHandler) DuplicateProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := chi.URLParam(r, "projectId")
+	if projectID == "" {
+		respond.Error(w, http.StatusBadRequest, "missing_project_id", "Project ID is required")
+		return
+	}
+
+	project, err := h.service.Duplicate(ctx, projectID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to duplicate project")
+
+		respondDomainError(w, err)
+		return
 	}
 
-	errorResponse := types.ErrorResponse{
-		Error: types.ErrorDetail{
-			Code:    code,
-			Message: message,
-			Details: detailsPtr,
-		},
+	response := types.ProjectResponse{
+		ID:          project.ID,
+		Title:       project.Title,
+		Description: project.Description,
+		Tags:        project.Tags,
+		CreatedAt:   project.CreatedAt,
+		UpdatedAt:   project.UpdatedAt,
+		Version:     project.Version,
+		PublishedAt: project.PublishedAt,
 	}
 
-	h.sendJSONResponse(w, statusCode, errorResponse)
-}
\ No newline at end of file
+	respond.JSON(w, http.StatusCreated, response)
+}
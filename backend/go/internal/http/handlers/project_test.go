@@ -6,15 +6,17 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
 	"github.com/provemyself/backend/internal/types"
 )
 
@@ -39,12 +41,70 @@ func (m *MockProjectService) GetByID(ctx context.Context, id string) (*core.Proj
 	return args.Get(0).(*core.Project), args.Error(1)
 }
 
-func (m *MockProjectService) List(ctx context.Context, limit, offset int) ([]*core.Project, int, error) {
-	args := m.Called(ctx, limit, offset)
+func (m *MockProjectService) List(ctx context.Context, opts core.ListOptions) (*core.ProjectPage, error) {
+	args := m.Called(ctx, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.ProjectPage), args.Error(1)
+}
+
+func (m *MockProjectService) Update(ctx context.Context, id string, title string, description *string, tags []string, version *int) (*core.Project, error) {
+	args := m.Called(ctx, id, title, description, tags, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.Project), args.Error(1)
+}
+
+func (m *MockProjectService) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockProjectService) Restore(ctx context.Context, id string) (*core.Project, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.Project), args.Error(1)
+}
+
+func (m *MockProjectService) Purge(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockProjectService) Publish(ctx context.Context, id string) (*core.Project, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.Project), args.Error(1)
+}
+
+func (m *MockProjectService) ListPublications(ctx context.Context, projectID string, limit, offset int) ([]*core.Publication, int, error) {
+	args := m.Called(ctx, projectID, limit, offset)
 	if args.Get(0) == nil {
 		return nil, args.Int(1), args.Error(2)
 	}
-	return args.Get(0).([]*core.Project), args.Int(1), args.Error(2)
+	return args.Get(0).([]*core.Publication), args.Int(1), args.Error(2)
+}
+
+func (m *MockProjectService) GetPublication(ctx context.Context, projectID string, version int) (*core.Publication, error) {
+	args := m.Called(ctx, projectID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.Publication), args.Error(1)
+}
+
+func (m *MockProjectService) Duplicate(ctx context.Context, id string) (*core.Project, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.Project), args.Error(1)
 }
 
 func TestProjectHandler_CreateProject(t *testing.T) {
@@ -93,12 +153,7 @@ func TestProjectHandler_CreateProject(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 			validateBody: func(t *testing.T, body []byte) {
-				var response types.ErrorResponse
-				err := json.Unmarshal(body, &response)
-				require.NoError(t, err)
-
-				assert.Equal(t, "validation_failed", response.Error.Code)
-				assert.Contains(t, response.Error.Message, "Validation failed")
+				assert.Equal(t, map[string]string{"title": "required"}, assertValidationErrors(t, body))
 			},
 		},
 		{
@@ -112,11 +167,7 @@ func TestProjectHandler_CreateProject(t *testing.T) {
 			},
 			expectedStatus: http.StatusUnprocessableEntity,
 			validateBody: func(t *testing.T, body []byte) {
-				var response types.ErrorResponse
-				err := json.Unmarshal(body, &response)
-				require.NoError(t, err)
-
-				assert.Equal(t, "title_too_short", response.Error.Code)
+				assertErrorResponse(t, body, "title_too_short")
 			},
 		},
 	}
@@ -127,14 +178,14 @@ func TestProjectHandler_CreateProject(t *testing.T) {
 			mockService := new(MockProjectService)
 			tt.mockSetup(mockService)
 
-			handler := NewProjectHandler(mockService, validator.New())
+			handler := NewProjectHandler(mockService, httpmiddleware.NewValidator())
 
 			body, err := json.Marshal(tt.requestBody)
 			require.NoError(t, err)
 
 			req := httptest.NewRequest(http.MethodPost, "/api/v1/projects", bytes.NewReader(body))
 			req.Header.Set("Content-Type", "application/json")
-			rr := httptest.NewRecorder()
+			rr := newRecorder()
 
 			// Act
 			handler.CreateProject(rr, req)
@@ -152,7 +203,9 @@ func TestProjectHandler_GetProject(t *testing.T) {
 	tests := []struct {
 		name           string
 		projectID      string
+		query          string
 		mockSetup      func(m *MockProjectService)
+		itemsSetup     func(m *MockItemService)
 		expectedStatus int
 		validateBody   func(t *testing.T, body []byte)
 	}{
@@ -162,8 +215,9 @@ func TestProjectHandler_GetProject(t *testing.T) {
 			mockSetup: func(m *MockProjectService) {
 				m.On("GetByID", mock.Anything, "test-id-123").
 					Return(&core.Project{
-						ID:    "test-id-123",
-						Title: "Test Quiz",
+						ID:        "test-id-123",
+						Title:     "Test Quiz",
+						ItemCount: intPtr(12),
 					}, nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -174,6 +228,45 @@ func TestProjectHandler_GetProject(t *testing.T) {
 
 				assert.Equal(t, "test-id-123", response.ID)
 				assert.Equal(t, "Test Quiz", response.Title)
+				require.NotNil(t, response.ItemCount)
+				assert.Equal(t, 12, *response.ItemCount)
+				assert.NotContains(t, string(body), `"items"`, "items are only embedded when included")
+			},
+		},
+		{
+			name:      "items included",
+			projectID: "test-id-123",
+			query:     "?include=items",
+			mockSetup: func(m *MockProjectService) {
+				m.On("GetByID", mock.Anything, "test-id-123").
+					Return(&core.Project{ID: "test-id-123", Title: "Test Quiz", ItemCount: intPtr(2)}, nil)
+			},
+			itemsSetup: func(m *MockItemService) {
+				m.On("ListByProject", mock.Anything, "test-id-123").
+					Return([]*core.Item{
+						{ID: "item-1", ProjectID: "test-id-123", Type: types.ItemTypeTitle, Title: "Welcome", Position: 1},
+						{ID: "item-2", ProjectID: "test-id-123", Type: types.ItemTypeChoice, Title: "Pick one", Position: 2},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var response types.ProjectResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+
+				require.Len(t, response.Items, 2)
+				assert.Equal(t, "item-1", response.Items[0].ID)
+				assert.Equal(t, "item-2", response.Items[1].ID)
+				assert.Equal(t, 2, response.Items[1].Position)
+			},
+		},
+		{
+			name:           "unknown include",
+			projectID:      "test-id-123",
+			query:          "?include=items,owner",
+			mockSetup:      func(m *MockProjectService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateBody: func(t *testing.T, body []byte) {
+				assert.Equal(t, map[string]string{"include": "oneof"}, assertValidationErrors(t, body))
 			},
 		},
 		{
@@ -185,11 +278,7 @@ func TestProjectHandler_GetProject(t *testing.T) {
 			},
 			expectedStatus: http.StatusNotFound,
 			validateBody: func(t *testing.T, body []byte) {
-				var response types.ErrorResponse
-				err := json.Unmarshal(body, &response)
-				require.NoError(t, err)
-
-				assert.Equal(t, "project_not_found", response.Error.Code)
+				assertErrorResponse(t, body, "project_not_found")
 			},
 		},
 	}
@@ -199,11 +288,16 @@ func TestProjectHandler_GetProject(t *testing.T) {
 			// Arrange
 			mockService := new(MockProjectService)
 			tt.mockSetup(mockService)
+			mockItems := new(MockItemService)
+			if tt.itemsSetup != nil {
+				tt.itemsSetup(mockItems)
+			}
 
-			handler := NewProjectHandler(mockService, validator.New())
+			handler := NewProjectHandler(mockService, httpmiddleware.NewValidator())
+			handler.SetItems(mockItems)
 
-			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/"+tt.projectID, nil)
-			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/"+tt.projectID+tt.query, nil)
+			rr := newRecorder()
 
 			// Set up Chi router context
 			rctx := chi.NewRouteContext()
@@ -217,6 +311,264 @@ func TestProjectHandler_GetProject(t *testing.T) {
 			assert.Equal(t, tt.expectedStatus, rr.Code)
 			tt.validateBody(t, rr.Body.Bytes())
 
+			mockService.AssertExpectations(t)
+			mockItems.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProjectHandler_PublishProject(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"published", nil, http.StatusOK, ""},
+		{"project not found", core.ErrProjectNotFound, http.StatusNotFound, "project_not_found"},
+		{"not publishable", &core.ProjectNotPublishableError{Problems: []core.PublishProblem{{Reason: core.PublishProblemNoItems}}}, http.StatusUnprocessableEntity, "project_not_publishable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockService := new(MockProjectService)
+			if tt.err != nil {
+				mockService.On("Publish", mock.Anything, "test-id-123").Return(nil, tt.err)
+			} else {
+				publishedAt := time.Now().UTC()
+				version := 2
+				mockService.On("Publish", mock.Anything, "test-id-123").
+					Return(&core.Project{ID: "test-id-123", Title: "Test Quiz", PublishedAt: &publishedAt, LatestPublishedVersion: &version}, nil)
+			}
+
+			handler := NewProjectHandler(mockService, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/test-id-123/publish", nil)
+			rr := newRecorder()
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "test-id-123")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			// Act
+			handler.PublishProject(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedCode != "" {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+			} else {
+				var response types.ProjectResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.NotNil(t, response.PublishedAt)
+				require.NotNil(t, response.LatestPublishedVersion)
+				assert.Equal(t, 2, *response.LatestPublishedVersion)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProjectHandler_RestoreProject(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"restored", nil, http.StatusOK, ""},
+		{"not in the trash", core.ErrProjectNotFound, http.StatusNotFound, "project_not_found"},
+		{"over quota", core.ErrProjectQuotaExceeded, http.StatusConflict, "project_quota_exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockService := new(MockProjectService)
+			if tt.err != nil {
+				mockService.On("Restore", mock.Anything, "test-id-123").Return(nil, tt.err)
+			} else {
+				mockService.On("Restore", mock.Anything, "test-id-123").
+					Return(&core.Project{ID: "test-id-123", Title: "Test Quiz"}, nil)
+			}
+
+			handler := NewProjectHandler(mockService, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/test-id-123/restore", nil)
+			rr := newRecorder()
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "test-id-123")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			// Act
+			handler.RestoreProject(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedCode != "" {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+			} else {
+				var response types.ProjectResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, "test-id-123", response.ID)
+				assert.Nil(t, response.DeletedAt)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProjectHandler_PurgeProject(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{"purged", nil, http.StatusNoContent},
+		{"not in the trash", core.ErrProjectNotFound, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockService := new(MockProjectService)
+			mockService.On("Purge", mock.Anything, "test-id-123").Return(tt.err)
+
+			handler := NewProjectHandler(mockService, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/projects/test-id-123/purge", nil)
+			rr := newRecorder()
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "test-id-123")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			// Act
+			handler.PurgeProject(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.err != nil {
+				assertErrorResponse(t, rr.Body.Bytes(), "project_not_found")
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProjectHandler_ListTrash(t *testing.T) {
+	// Arrange
+	deletedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	mockService := new(MockProjectService)
+	mockService.On("List", mock.Anything, core.ListOptions{Search: "quiz", Limit: 20, Deleted: true}).
+		Return(&core.ProjectPage{Projects: []*core.Project{{ID: "1", Title: "Old quiz", DeletedAt: &deletedAt}}, Total: 1}, nil)
+	handler := NewProjectHandler(mockService, httpmiddleware.NewValidator())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/trash?search=quiz", nil)
+	rr := newRecorder()
+
+	// Act
+	handler.ListTrash(rr, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response types.ProjectListResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Len(t, response.Projects, 1)
+	require.NotNil(t, response.Projects[0].DeletedAt)
+	assert.Equal(t, deletedAt, *response.Projects[0].DeletedAt)
+	mockService.AssertExpectations(t)
+}
+
+func TestProjectHandler_PublishProject_ListsProblems(t *testing.T) {
+	// Arrange
+	mockService := new(MockProjectService)
+	mockService.On("Publish", mock.Anything, "test-id-123").Return(nil, &core.ProjectNotPublishableError{Problems: []core.PublishProblem{
+		{ItemID: "item-1", Reason: core.PublishProblemNoCorrectChoice},
+		{ItemID: "item-2", Reason: core.PublishProblemOrderNotContiguous},
+	}})
+	handler := NewProjectHandler(mockService, httpmiddleware.NewValidator())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/test-id-123/publish", nil)
+	rr := newRecorder()
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("projectId", "test-id-123")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	// Act
+	handler.PublishProject(rr, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	var response types.ErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "project_not_publishable", response.Error.Code)
+	assert.Equal(t, []types.PublishProblem{
+		{ItemID: "item-1", Reason: "no_correct_choice"},
+		{ItemID: "item-2", Reason: "order_not_contiguous"},
+	}, response.Error.Problems)
+}
+
+func (m *MockProjectService) UpdateResultsSettings(ctx context.Context, id string, settings core.ResultsSettings) (*core.Project, error) {
+	args := m.Called(ctx, id, settings)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.Project), args.Error(1)
+}
+
+func TestProjectHandler_DuplicateProject(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"duplicated", nil, http.StatusCreated, ""},
+		{"project not found", core.ErrProjectNotFound, http.StatusNotFound, "project_not_found"},
+		{"quota exceeded", core.ErrProjectQuotaExceeded, http.StatusConflict, "project_quota_exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockService := new(MockProjectService)
+			if tt.err != nil {
+				mockService.On("Duplicate", mock.Anything, "test-id-123").Return(nil, tt.err)
+			} else {
+				mockService.On("Duplicate", mock.Anything, "test-id-123").
+					Return(&core.Project{ID: "copy-id-456", Title: "Test Quiz (copy)", Tags: []string{"quiz"}}, nil)
+			}
+
+			handler := NewProjectHandler(mockService, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/test-id-123/duplicate", nil)
+			rr := newRecorder()
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "test-id-123")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			// Act
+			handler.DuplicateProject(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedCode != "" {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+			} else {
+				var response types.ProjectResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, "copy-id-456", response.ID)
+				assert.Equal(t, "Test Quiz (copy)", response.Title)
+				assert.Nil(t, response.PublishedAt)
+			}
+
 			mockService.AssertExpectations(t)
 		})
 	}
@@ -235,11 +587,11 @@ func TestProjectHandler_ListProjects(t *testing.T) {
 			queryParams: "",
 			mockSetup: func(m *MockProjectService) {
 				projects := []*core.Project{
-					{ID: "1", Title: "Quiz 1"},
-					{ID: "2", Title: "Quiz 2"},
+					{ID: "1", Title: "Quiz 1", ItemCount: intPtr(12)},
+					{ID: "2", Title: "Quiz 2", ItemCount: intPtr(0)},
 				}
-				m.On("List", mock.Anything, 20, 0).
-					Return(projects, 2, nil)
+				m.On("List", mock.Anything, core.ListOptions{Limit: 20}).
+					Return(&core.ProjectPage{Projects: projects, Total: 2}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			validateBody: func(t *testing.T, body []byte) {
@@ -247,10 +599,12 @@ func TestProjectHandler_ListProjects(t *testing.T) {
 				err := json.Unmarshal(body, &response)
 				require.NoError(t, err)
 
-				assert.Len(t, response.Projects, 2)
+				require.Len(t, response.Projects, 2)
 				assert.Equal(t, 2, response.Total)
 				assert.Equal(t, 20, response.Limit)
 				assert.Equal(t, 0, response.Offset)
+				assert.Equal(t, 12, *response.Projects[0].ItemCount)
+				assert.Equal(t, 0, *response.Projects[1].ItemCount, "an empty project has a count of 0")
 			},
 		},
 		{
@@ -260,8 +614,8 @@ func TestProjectHandler_ListProjects(t *testing.T) {
 				projects := []*core.Project{
 					{ID: "6", Title: "Quiz 6"},
 				}
-				m.On("List", mock.Anything, 10, 5).
-					Return(projects, 50, nil)
+				m.On("List", mock.Anything, core.ListOptions{Limit: 10, Offset: 5}).
+					Return(&core.ProjectPage{Projects: projects, Total: 50}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			validateBody: func(t *testing.T, body []byte) {
@@ -275,6 +629,157 @@ func TestProjectHandler_ListProjects(t *testing.T) {
 				assert.Equal(t, 5, response.Offset)
 			},
 		},
+		{
+			name:        "search term",
+			queryParams: "?search=%20100%25%20&limit=10",
+			mockSetup: func(m *MockProjectService) {
+				m.On("List", mock.Anything, core.ListOptions{Search: "100%", Limit: 10}).
+					Return(&core.ProjectPage{Projects: []*core.Project{{ID: "3", Title: "100% Quiz"}}, Total: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var response types.ProjectListResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+
+				require.Len(t, response.Projects, 1)
+				assert.Equal(t, "100% Quiz", response.Projects[0].Title)
+				assert.Equal(t, 1, response.Total)
+			},
+		},
+		{
+			name:        "blank search term lists every project",
+			queryParams: "?search=%20%20",
+			mockSetup: func(m *MockProjectService) {
+				m.On("List", mock.Anything, core.ListOptions{Limit: 20}).
+					Return(&core.ProjectPage{Projects: []*core.Project{{ID: "1", Title: "Quiz 1"}}, Total: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var response types.ProjectListResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+
+				assert.Len(t, response.Projects, 1)
+			},
+		},
+		{
+			name:        "tags and published",
+			queryParams: "?tag=math&tag=%20&tag=algebra&published=true",
+			mockSetup: func(m *MockProjectService) {
+				m.On("List", mock.Anything, core.ListOptions{Tags: []string{"math", "algebra"}, Status: core.ProjectStatusPublished, Limit: 20}).
+					Return(&core.ProjectPage{Projects: []*core.Project{}, Total: 0}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var response types.ProjectListResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+
+				assert.Empty(t, response.Projects)
+			},
+		},
+		{
+			name:        "drafts",
+			queryParams: "?published=false",
+			mockSetup: func(m *MockProjectService) {
+				m.On("List", mock.Anything, core.ListOptions{Status: core.ProjectStatusDraft, Limit: 20}).
+					Return(&core.ProjectPage{Projects: []*core.Project{{ID: "1", Title: "Quiz 1"}}, Total: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody: func(t *testing.T, body []byte) {
+				var response types.ProjectListResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+
+				assert.Equal(t, 1, response.Total)
+			},
+		},
+		{
+			name:        "sort",
+			queryParams: "?sort=title",
+			mockSetup: func(m *MockProjectService) {
+				m.On("List", mock.Anything, core.ListOptions{Sort: core.ProjectSortTitle, Limit: 20}).
+					Return(&core.ProjectPage{Projects: []*core.Project{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody:   func(t *testing.T, body []byte) {},
+		},
+		{
+			name:        "descending sort",
+			queryParams: "?sort=-created_at",
+			mockSetup: func(m *MockProjectService) {
+				m.On("List", mock.Anything, core.ListOptions{Sort: core.ProjectSortCreatedAt, Limit: 20}).
+					Return(&core.ProjectPage{Projects: []*core.Project{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateBody:   func(t *testing.T, body []byte) {},
+		},
+		{
+			name:           "items' sort fields are not project sort fields",
+			queryParams:    "?sort=position",
+			mockSetup:      func(m *MockProjectService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateBody: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "invalid_sort")
+			},
+		},
+		{
+			name:           "malformed cursor",
+			queryParams:    "?cursor=not-a-cursor!",
+			mockSetup:      func(m *MockProjectService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateBody: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "invalid_cursor")
+			},
+		},
+		{
+			name:           "cursor of another sort",
+			queryParams:    "?sort=title&cursor=" + encodeProjectCursor(core.ProjectSortCreatedAt, &core.Project{ID: "1"}),
+			mockSetup:      func(m *MockProjectService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateBody: func(t *testing.T, body []byte) {
+				response := assertErrorResponse(t, body, "invalid_cursor")
+				require.NotNil(t, response.Error.Details)
+				assert.Equal(t, "the cursor belongs to a list sorted differently", *response.Error.Details)
+			},
+		},
+		{
+			name:           "published must be a boolean",
+			queryParams:    "?published=yes",
+			mockSetup:      func(m *MockProjectService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateBody: func(t *testing.T, body []byte) {
+				assert.Equal(t, map[string]string{"published": "boolean"}, assertValidationErrors(t, body))
+			},
+		},
+		{
+			name:           "invalid pagination is rejected",
+			queryParams:    "?limit=abc&offset=-3",
+			mockSetup:      func(m *MockProjectService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateBody: func(t *testing.T, body []byte) {
+				var response types.ValidationErrorResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+
+				assert.Equal(t, types.ErrorCodeInvalidPagination, response.Error.Code)
+				assert.Equal(t, []types.ValidationError{
+					{Field: "limit", Tag: "integer", Message: "Field 'limit' must be an integer"},
+					{Field: "offset", Tag: "gte", Param: "0", Message: "Field 'offset' must be greater than or equal to 0"},
+				}, response.Error.Errors)
+			},
+		},
+		{
+			name:           "limit above the endpoint maximum is rejected",
+			queryParams:    "?limit=5000",
+			mockSetup:      func(m *MockProjectService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateBody: func(t *testing.T, body []byte) {
+				var response types.ValidationErrorResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+
+				assert.Equal(t, types.ErrorCodeInvalidPagination, response.Error.Code)
+				require.Len(t, response.Error.Errors, 1)
+				assert.Equal(t, "lte", response.Error.Errors[0].Tag)
+				assert.Equal(t, "100", response.Error.Errors[0].Param)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -283,10 +788,10 @@ func TestProjectHandler_ListProjects(t *testing.T) {
 			mockService := new(MockProjectService)
 			tt.mockSetup(mockService)
 
-			handler := NewProjectHandler(mockService, validator.New())
+			handler := NewProjectHandler(mockService, httpmiddleware.NewValidator())
 
 			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects"+tt.queryParams, nil)
-			rr := httptest.NewRecorder()
+			rr := newRecorder()
 
 			// Act
 			handler.ListProjects(rr, req)
@@ -300,7 +805,179 @@ func TestProjectHandler_ListProjects(t *testing.T) {
 	}
 }
 
+func TestProjectHandler_ListProjects_Cursor(t *testing.T) {
+	// Arrange
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	first := []*core.Project{
+		{ID: "3", Title: "Quiz 3", CreatedAt: created.Add(2 * time.Hour)},
+		{ID: "2", Title: "Quiz 2", CreatedAt: created.Add(time.Hour)},
+	}
+	second := []*core.Project{{ID: "1", Title: "Quiz 1", CreatedAt: created}}
+
+	mockService := new(MockProjectService)
+	mockService.On("List", mock.Anything, core.ListOptions{Limit: 2, Offset: 4}).
+		Return(&core.ProjectPage{Projects: first, Total: 7, HasMore: true}, nil)
+	mockService.On("List", mock.Anything, core.ListOptions{Limit: 2, After: core.CursorAfter(first[1])}).
+		Return(&core.ProjectPage{Projects: second, Total: -1}, nil)
+	handler := NewProjectHandler(mockService, httpmiddleware.NewValidator())
+
+	list := func(query string) types.ProjectListResponse {
+		rr := newRecorder()
+		handler.ListProjects(rr, httptest.NewRequest(http.MethodGet, "/api/v1/projects"+query, nil))
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var response types.ProjectListResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return response
+	}
+
+	// Act
+	page := list("?limit=2&offset=4")
+	next := list("?limit=2&offset=4&cursor=" + page.NextCursor)
+
+	// Assert
+	assert.Equal(t, 7, page.Total)
+	assert.NotEmpty(t, page.NextCursor, "more projects follow the first page")
+	require.Len(t, next.Projects, 1)
+	assert.Equal(t, "1", next.Projects[0].ID)
+	assert.Equal(t, -1, next.Total, "cursor pages are not counted")
+	assert.Equal(t, 0, next.Offset, "the cursor replaces the offset")
+	assert.Empty(t, next.NextCursor, "nothing follows the last page")
+	mockService.AssertExpectations(t)
+}
+
+// testRequestID is the request ID the RequestID middleware would have set
+const testRequestID = "test-request-id"
+
+// newRecorder returns a recorder carrying the X-Request-ID response header
+func newRecorder() *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	rr.Header().Set("X-Request-ID", testRequestID)
+	return rr
+}
+
+// assertErrorResponse checks that body is the canonical error shape with the
+// given code and the request ID embedded
+func assertErrorResponse(t *testing.T, body []byte, code string) types.ErrorResponse {
+	t.Helper()
+
+	var response types.ErrorResponse
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, code, response.Error.Code)
+	assert.NotEmpty(t, response.Error.Message)
+	assert.Equal(t, testRequestID, response.Error.RequestID)
+	return response
+}
+
+// assertValidationErrors checks that body is the structured validation error
+// shape and returns the tag that failed for each field
+func assertValidationErrors(t *testing.T, body []byte) map[string]string {
+	t.Helper()
+
+	var response types.ValidationErrorResponse
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, types.ErrorCodeValidationFailed, response.Error.Code)
+	assert.Equal(t, testRequestID, response.Error.RequestID)
+
+	fields := make(map[string]string, len(response.Error.Errors))
+	for _, fieldErr := range response.Error.Errors {
+		assert.NotEmpty(t, fieldErr.Message)
+		fields[fieldErr.Field] = fieldErr.Tag
+	}
+	return fields
+}
+
 // Helper function to create string pointers
 func stringPtr(s string) *string {
 	return &s
-}
\ No newline at end of file
+}
+
+func TestProjectHandler_UpdateResultsPolicy(t *testing.T) {
+	availableAt := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		body           string
+		settings       *core.ResultsSettings
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "score only",
+			body:           `{"results_policy":"score_only"}`,
+			settings:       &core.ResultsSettings{Policy: core.ResultsPolicyScoreOnly},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "after the close date",
+			body:           `{"results_policy":"after_close_date","results_available_at":"2026-06-01T12:00:00Z"}`,
+			settings:       &core.ResultsSettings{Policy: core.ResultsPolicyAfterCloseDate, AvailableAt: &availableAt},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "close date missing",
+			body:           `{"results_policy":"after_close_date"}`,
+			settings:       &core.ResultsSettings{Policy: core.ResultsPolicyAfterCloseDate},
+			err:            core.ErrResultsAvailableAtRequired,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "results_available_at_required",
+		},
+		{
+			name:           "unknown policy",
+			body:           `{"results_policy":"leaderboard"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "validation_failed",
+		},
+		{
+			name:           "project not found",
+			body:           `{"results_policy":"none"}`,
+			settings:       &core.ResultsSettings{Policy: core.ResultsPolicyNone},
+			err:            core.ErrProjectNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "project_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockService := new(MockProjectService)
+			if tt.settings != nil {
+				if tt.err != nil {
+					mockService.On("UpdateResultsSettings", mock.Anything, "test-id-123", *tt.settings).Return(nil, tt.err)
+				} else {
+					mockService.On("UpdateResultsSettings", mock.Anything, "test-id-123", *tt.settings).
+						Return(&core.Project{ID: "test-id-123", Title: "Test Quiz", Version: 2, ResultsSettings: tt.settings}, nil)
+				}
+			}
+
+			handler := NewProjectHandler(mockService, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/projects/test-id-123/results-policy", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := newRecorder()
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "test-id-123")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			// Act
+			handler.UpdateResultsPolicy(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedCode != "" {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+			} else {
+				var response types.ProjectResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, string(tt.settings.Policy), response.ResultsPolicy)
+				assert.Equal(t, tt.settings.AvailableAt, response.ResultsAvailableAt)
+				assert.NotEmpty(t, rr.Header().Get("ETag"))
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// PublicProjectService serves published projects to learners, satisfied by
+// *core.ProjectService
+type PublicProjectService interface {
+	GetPublic(ctx context.Context, id string) (*core.PublicProject, error)
+}
+
+// AttemptService runs learners' attempts, satisfied by *core.AttemptService
+type AttemptService interface {
+	Start(ctx context.Context, projectID, participantID string) (*core.Attempt, error)
+	Get(ctx context.Context, id string) (*core.Attempt, error)
+	SaveAnswer(ctx context.Context, attemptID, itemID string, response json.RawMessage, sequence int64) (*core.Answer, error)
+	Submit(ctx context.Context, id string) (*core.Attempt, error)
+}
+
+// PublicHandler handles the endpoints under /api/v1/public, which the
+// player app calls for learners without authentication
+type PublicHandler struct {
+	projects PublicProjectService
+	attempts AttemptService
+	validate *validator.Validate
+}
+
+// NewPublicHandler creates a new public handler
+func NewPublicHandler(projects PublicProjectService, attempts AttemptService, validate *validator.Validate) *PublicHandler {
+	return &PublicHandler{
+		projects: projects,
+		attempts: attempts,
+		validate: validate,
+	}
+}
+
+// GetProject handles GET /api/v1/public/projects/{projectId}
+// @Summary Get published project
+// @Description Returns a published project for learners to take, as it was last published, with its items in position order. Nothing gives the answers away: items have no explanation, choices and ordering entries no correctness or correct order, text entries no correct answer and hotspot items no regions. Ordering entries are listed by text. Projects published before publications were kept are served as they are now, without a version. Needs no authentication, and serves projects of any organization.
+// @Tags Public
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} types.PublicProjectResponse
+// @Success 304 "Not modified"
+// @Failure 404 {object} types.ErrorResponse "project_not_found, also for projects that are not published"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/public/projects/{projectId} [get]
+func (h *PublicHandler) GetProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := chi.URLParam(r, "projectId")
+
+	public, err := h.projects.GetPublic(ctx, projectID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to get published project")
+		respondDomainError(w, err)
+		return
+	}
+	if checkPublicNotModified(w, r, publicProjectETag(public)) {
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, publicProjectResponse(public))
+}
+
+// publicProjectResponse converts a published project for learners to its
+// API response
+func publicProjectResponse(public *core.PublicProject) types.PublicProjectResponse {
+	response := types.PublicProjectResponse{
+		ID:          public.Project.ID,
+		Title:       public.Project.Title,
+		Description: public.Project.Description,
+		Version:     public.Version,
+		PublishedAt: public.Project.PublishedAt,
+		Items:       make([]types.PublicItemResponse, len(public.Items)),
+	}
+	for i, item := range public.Items {
+		response.Items[i] = types.PublicItemResponse{
+			ID:       item.ID,
+			Type:     item.Type,
+			Title:    item.Title,
+			Content:  item.Content,
+			Position: item.Position,
+			Required: item.Required,
+			Points:   item.Points,
+		}
+	}
+	return response
+}
+
+// StartAttempt handles POST /api/v1/public/projects/{projectId}/attempts
+// @Summary Start attempt
+// @Description Starts an attempt at a published project, taking the publication that is latest now: its answers answer the items as published then, whatever is published later. A bearer token is optional; with one, the attempt records its user as the participant. An anonymous attempt's ID is all it takes to answer and submit it, so the player keeps it to itself. A participant's attempt is only theirs: reading, answering and submitting it takes their bearer token, and to anyone else it is not found. A retry sent with the same Idempotency-Key gets the first response again instead of starting another attempt.
+// @Tags Public
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param Idempotency-Key header string false "Key the player picks per attempt, to retry safely"
+// @Success 201 {object} types.AttemptResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_idempotency_key"
+// @Failure 404 {object} types.ErrorResponse "project_not_found, also for projects that are not published"
+// @Failure 409 {object} types.ErrorResponse "idempotency_conflict, idempotency_in_progress"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/public/projects/{projectId}/attempts [post]
+func (h *PublicHandler) StartAttempt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := chi.URLParam(r, "projectId")
+
+	attempt, err := h.attempts.Start(ctx, projectID, httpmiddleware.GetUserID(ctx))
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to start attempt")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusCreated, attemptResponse(attempt))
+}
+
+// GetAttempt handles GET /api/v1/public/attempts/{attemptId}
+// @Summary Get attempt
+// @Description Returns an attempt with the answers it gave so far, so the player can resume it. Once submitted, the attempt has its score and the results of each question, without their explanations, as far as the project's results policy shows them: none shows neither, score_only only the score, and after_close_date both only from the project's results_available_at on.
+// @Tags Public
+// @Produce json
+// @Param attemptId path string true "Attempt ID" format(uuid)
+// @Success 200 {object} types.AttemptResponse
+// @Failure 404 {object} types.ErrorResponse "attempt_not_found, also for another user's attempt"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/public/attempts/{attemptId} [get]
+func (h *PublicHandler) GetAttempt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	attemptID := chi.URLParam(r, "attemptId")
+
+	attempt, err := h.attempts.Get(ctx, attemptID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("attempt_id", attemptID).Msg("failed to get attempt")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, attemptResponse(attempt))
+}
+
+// SaveAnswer handles PUT /api/v1/public/attempts/{attemptId}/answers/{itemId}
+// @Summary Answer item
+// @Description Answers an item of the publication an attempt takes, replacing any earlier answer to it, until the attempt is submitted. The answer has the shape of the item type's: {"choice_id"} for choice, {"choice_ids"} for multi_choice, {"text"} for text_entry, {"order"}, every entry ID first to last, for ordering and {"x","y"} for hotspot items. It must name only the item's options or entries and fit a text entry's max_length. Title and media items take no answer. Saves numbered by sequence are kept in order: one numbered below the save the answer was last stored by arrived late and is refused with stale_answer. Under the project's practice results policy, an answer to a question comes back with feedback grading it, with the item's explanation. A retry sent with the same Idempotency-Key gets the first response again without saving the answer twice.
+// @Tags Public
+// @Accept json
+// @Produce json
+// @Param attemptId path string true "Attempt ID" format(uuid)
+// @Param itemId path string true "Item ID" format(uuid)
+// @Param Idempotency-Key header string false "Key the player picks per save, to retry safely"
+// @Param request body types.SaveAnswerRequest true "Answer"
+// @Success 200 {object} types.AnswerResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed, invalid_idempotency_key"
+// @Failure 404 {object} types.ErrorResponse "attempt_not_found, also for another user's attempt, item_not_found"
+// @Failure 409 {object} types.ErrorResponse "attempt_already_submitted, stale_answer, idempotency_conflict, idempotency_in_progress"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 422 {object} types.ErrorResponse "invalid_answer"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/public/attempts/{attemptId}/answers/{itemId} [put]
+func (h *PublicHandler) SaveAnswer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	attemptID := chi.URLParam(r, "attemptId")
+	itemID := chi.URLParam(r, "itemId")
+
+	var req types.SaveAnswerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to decode request")
+		httpmiddleware.SendBodyReadError(w, err)
+		return
+	}
+
+	if err := h.validate.StructCtx(ctx, req); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("validation failed")
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
+		return
+	}
+
+	answer, err := h.attempts.SaveAnswer(ctx, attemptID, itemID, req.Answer, req.Sequence)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("attempt_id", attemptID).Str("item_id", itemID).Msg("failed to save answer")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, answerResponse(answer))
+}
+
+// SubmitAttempt handles POST /api/v1/public/attempts/{attemptId}/submit
+// @Summary Submit attempt
+// @Description Submits an attempt with the answers it gave, after which it takes no more, and grades it. Every question of the attempt's publication is graded, answered or not: an answer earns all of the item's points if it is correct and none otherwise. A choice is correct if the option chosen is a correct one, multiple choices if they are exactly the correct options, a text entry if it is the correct answer ignoring case and surrounding space, an ordering if the entries are in their correct order and a hotspot if the point is in a correct region. The attempt scores the points earned out of the points of its questions, and each result carries its item's explanation. The response shows the score and results as far as the project's results policy does, as reading the attempt does.
+// @Tags Public
+// @Produce json
+// @Param attemptId path string true "Attempt ID" format(uuid)
+// @Success 200 {object} types.AttemptResponse
+// @Failure 404 {object} types.ErrorResponse "attempt_not_found, also for another user's attempt"
+// @Failure 409 {object} types.ErrorResponse "attempt_already_submitted"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/public/attempts/{attemptId}/submit [post]
+func (h *PublicHandler) SubmitAttempt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	attemptID := chi.URLParam(r, "attemptId")
+
+	attempt, err := h.attempts.Submit(ctx, attemptID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("attempt_id", attemptID).Msg("failed to submit attempt")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, attemptResponse(attempt))
+}
+
+// attemptResponse converts an attempt to its API response
+func attemptResponse(attempt *core.Attempt) types.AttemptResponse {
+	response := types.AttemptResponse{
+		ID:            attempt.ID,
+		ProjectID:     attempt.ProjectID,
+		Version:       attempt.PublicationVersion,
+		ParticipantID: attempt.ParticipantID,
+		IsPreview:     attempt.IsPreview,
+		StartedAt:     attempt.StartedAt,
+		SubmittedAt:   attempt.SubmittedAt,
+		Answers:       make([]types.AnswerResponse, len(attempt.Answers)),
+		Score:         attempt.Score,
+		MaxScore:      attempt.MaxScore,
+	}
+	for i, answer := range attempt.Answers {
+		response.Answers[i] = answerResponse(answer)
+	}
+	for _, result := range attempt.Results {
+		response.Results = append(response.Results, itemResultResponse(result))
+	}
+	return response
+}
+
+// answerResponse converts an answer to its API response
+func answerResponse(answer *core.Answer) types.AnswerResponse {
+	response := types.AnswerResponse{
+		ItemID:     answer.ItemID,
+		Answer:     answer.Response,
+		AnsweredAt: answer.AnsweredAt,
+		Sequence:   answer.Sequence,
+	}
+	if answer.Feedback != nil {
+		feedback := itemResultResponse(answer.Feedback)
+		response.Feedback = &feedback
+	}
+	return response
+}
+
+// itemResultResponse converts a core.ItemResult to its API response
+func itemResultResponse(result *core.ItemResult) types.ItemResultResponse {
+	return types.ItemResultResponse{
+		ItemID:      result.ItemID,
+		Correct:     result.Correct,
+		Earned:      result.Earned,
+		Possible:    result.Possible,
+		Explanation: result.Explanation,
+	}
+}
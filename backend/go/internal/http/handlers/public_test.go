@@ -0,0 +1,529 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// publishedProjectStore is a core.ProjectStore of one published project,
+// "test-project-id", whose latest publication has an item of every type
+type publishedProjectStore struct {
+	core.ProjectStore
+}
+
+func (s publishedProjectStore) PublishedOrgID(ctx context.Context, id string) (string, error) {
+	if id != "test-project-id" {
+		return "", core.ErrProjectNotFound
+	}
+	return "", nil
+}
+
+func (s publishedProjectStore) GetByID(ctx context.Context, id string) (*core.Project, error) {
+	version := 3
+	return &core.Project{ID: id, Title: "Capitals (draft)", LatestPublishedVersion: &version}, nil
+}
+
+func (s publishedProjectStore) GetPublication(ctx context.Context, projectID string, version int) (*core.Publication, error) {
+	publishedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	explanation := "Paris has been the capital since 508"
+	points := 2
+	item := func(id string, itemType types.ItemType, content string) *core.Item {
+		return &core.Item{
+			ID:          id,
+			ProjectID:   projectID,
+			Type:        itemType,
+			Title:       id,
+			Content:     json.RawMessage(content),
+			Points:      &points,
+			Explanation: &explanation,
+		}
+	}
+	return &core.Publication{
+		ProjectID:   projectID,
+		Version:     version,
+		PublishedAt: publishedAt,
+		Project:     &core.Project{ID: projectID, Title: "Capitals", PublishedAt: &publishedAt},
+		Items: []*core.Item{
+			item("title", types.ItemTypeTitle, `{"text":"Capitals"}`),
+			item("media", types.ItemTypeMedia, `{"url":"https://example.com/map.png","media_type":"image","autoplay":false,"show_controls":true}`),
+			item("choice", types.ItemTypeChoice, `{"choices":[{"id":"a","text":"Paris","correct":true},{"id":"b","text":"Lyon","correct":false}]}`),
+			item("multi_choice", types.ItemTypeMultiChoice, `{"choices":[{"id":"a","text":"Oslo","correct":true},{"id":"b","text":"Bergen"}]}`),
+			item("text_entry", types.ItemTypeTextEntry, `{"multiline":false,"correct_answer":"Madrid"}`),
+			item("ordering", types.ItemTypeOrdering, `{"items":[{"id":"a","text":"Rome","correct_order":2},{"id":"b","text":"Berlin","correct_order":1}]}`),
+			item("hotspot", types.ItemTypeHotspot, `{"image_url":"https://example.com/map.png","hotspots":[{"id":"a","shape":"circle","coords":[10,20,5],"correct":true,"feedback":"Yes"}]}`),
+		},
+	}, nil
+}
+
+func TestPublicHandler_GetProject(t *testing.T) {
+	tests := []struct {
+		name             string
+		projectID        string
+		expectedStatus   int
+		validateResponse func(t *testing.T, body []byte)
+	}{
+		{
+			name:           "latest publication without answer keys",
+			projectID:      "test-project-id",
+			expectedStatus: http.StatusOK,
+			validateResponse: func(t *testing.T, body []byte) {
+				var response types.PublicProjectResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, "Capitals", response.Title)
+				assert.Equal(t, 3, response.Version)
+				require.Len(t, response.Items, 7)
+				for _, item := range response.Items {
+					assert.Equal(t, 2, *item.Points, "learners see what an item is worth")
+				}
+
+				for _, key := range []string{`"correct"`, `"correct_order"`, `"correct_answer"`, `"hotspots"`, `"feedback"`, `"explanation"`} {
+					assert.NotContains(t, string(body), key)
+				}
+				assert.NotContains(t, string(body), "Madrid", "the correct answer is not served")
+			},
+		},
+		{
+			name:           "unpublished or unknown project",
+			projectID:      "draft-project-id",
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "project_not_found")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewPublicHandler(core.NewProjectService(publishedProjectStore{}, nil), nil, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/public/projects/"+tt.projectID, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", tt.projectID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := newRecorder()
+			handler.GetProject(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			tt.validateResponse(t, rr.Body.Bytes())
+		})
+	}
+}
+
+func TestPublicHandler_GetProject_Revalidates(t *testing.T) {
+	// Arrange
+	handler := NewPublicHandler(core.NewProjectService(publishedProjectStore{}, nil), nil, httpmiddleware.NewValidator())
+	router := chi.NewRouter()
+	router.Get("/public/projects/{projectId}", handler.GetProject)
+	first := newRecorder()
+	router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/public/projects/test-project-id", nil))
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/public/projects/test-project-id", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr := newRecorder()
+	router.ServeHTTP(rr, req)
+
+	// Assert
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, "public, no-cache", first.Header().Get("Cache-Control"))
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+}
+
+// largePublicationStore is publishedProjectStore with 50 choice items in
+// its latest publication
+type largePublicationStore struct {
+	publishedProjectStore
+}
+
+func (s largePublicationStore) GetPublication(ctx context.Context, projectID string, version int) (*core.Publication, error) {
+	items := make([]*core.Item, 50)
+	for i := range items {
+		items[i] = &core.Item{
+			ID:        fmt.Sprintf("item-%d", i),
+			ProjectID: projectID,
+			Type:      types.ItemTypeChoice,
+			Title:     fmt.Sprintf("Question %d", i),
+			Content:   json.RawMessage(`{"choices":[{"id":"a","text":"Paris","correct":true},{"id":"b","text":"Lyon"},{"id":"c","text":"Nice"}]}`),
+			Position:  i,
+		}
+	}
+	return &core.Publication{ProjectID: projectID, Version: version, Project: &core.Project{ID: projectID, Title: "Capitals"}, Items: items}, nil
+}
+
+// BenchmarkGetPublicProject_ResponseCache compares rendering a published
+// project with serving it from the response cache; run with -benchmem to
+// see the allocations a hit saves
+func BenchmarkGetPublicProject_ResponseCache(b *testing.B) {
+	projects := core.NewProjectService(largePublicationStore{}, nil)
+	handler := NewPublicHandler(projects, nil, httpmiddleware.NewValidator())
+
+	uncached := chi.NewRouter()
+	uncached.Get("/public/projects/{projectId}", handler.GetProject)
+
+	cache := httpmiddleware.NewResponseCache(100, time.Hour, nil)
+	cached := chi.NewRouter()
+	cached.With(cache.Cache(projects)).Get("/public/projects/{projectId}", handler.GetProject)
+
+	for _, bench := range []struct {
+		name   string
+		router http.Handler
+	}{
+		{"render", uncached},
+		{"cache_hit", cached},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				bench.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/public/projects/test-project-id", nil))
+				if w.Code != http.StatusOK {
+					b.Fatalf("unexpected status %d", w.Code)
+				}
+			}
+		})
+	}
+}
+
+// attemptService is an AttemptService of one attempt, "attempt-1" at
+// "test-project-id", answered with a choice and submitted, and graded, once
+// submitted is set
+type attemptService struct {
+	submitted     bool
+	participantID string
+	// savedSequence numbers the save the item's answer was last stored by
+	savedSequence int64
+}
+
+func (s *attemptService) attempt(id string) (*core.Attempt, error) {
+	if id != "attempt-1" {
+		return nil, core.ErrAttemptNotFound
+	}
+	attempt := &core.Attempt{
+		ID:                 id,
+		ProjectID:          "test-project-id",
+		PublicationVersion: 3,
+		ParticipantID:      s.participantID,
+		StartedAt:          time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Answers: []*core.Answer{{
+			AttemptID:  id,
+			ItemID:     "choice",
+			Response:   json.RawMessage(`{"choice_id":"a"}`),
+			AnsweredAt: time.Date(2024, 3, 1, 12, 1, 0, 0, time.UTC),
+		}},
+	}
+	if s.submitted {
+		submittedAt := time.Date(2024, 3, 1, 12, 5, 0, 0, time.UTC)
+		explanation := "Paris has been the capital since 508"
+		score, maxScore := 2, 3
+		attempt.SubmittedAt = &submittedAt
+		attempt.Score, attempt.MaxScore = &score, &maxScore
+		attempt.Results = []*core.ItemResult{
+			{ItemID: "choice", Earned: 2, Possible: 2, Correct: true, Explanation: &explanation},
+			{ItemID: "text_entry", Earned: 0, Possible: 1},
+		}
+	}
+	return attempt, nil
+}
+
+func (s *attemptService) Start(ctx context.Context, projectID, participantID string) (*core.Attempt, error) {
+	if projectID != "test-project-id" {
+		return nil, core.ErrProjectNotFound
+	}
+	s.participantID = participantID
+	return s.attempt("attempt-1")
+}
+
+func (s *attemptService) Get(ctx context.Context, id string) (*core.Attempt, error) {
+	return s.attempt(id)
+}
+
+func (s *attemptService) SaveAnswer(ctx context.Context, attemptID, itemID string, response json.RawMessage, sequence int64) (*core.Answer, error) {
+	if _, err := s.attempt(attemptID); err != nil {
+		return nil, err
+	}
+	if s.submitted {
+		return nil, core.ErrAttemptAlreadySubmitted
+	}
+	if itemID != "choice" {
+		return nil, core.ErrItemNotFound
+	}
+	if string(response) != `{"choice_id":"b"}` {
+		return nil, fmt.Errorf("%w: the item has no option", core.ErrInvalidAnswer)
+	}
+	if sequence != 0 && sequence < s.savedSequence {
+		return nil, core.ErrStaleAnswer
+	}
+	return &core.Answer{AttemptID: attemptID, ItemID: itemID, Response: response, Sequence: sequence, AnsweredAt: time.Now()}, nil
+}
+
+func (s *attemptService) Submit(ctx context.Context, id string) (*core.Attempt, error) {
+	if _, err := s.attempt(id); err != nil {
+		return nil, err
+	}
+	if s.submitted {
+		return nil, core.ErrAttemptAlreadySubmitted
+	}
+	s.submitted = true
+	return s.attempt(id)
+}
+
+func TestPublicHandler_StartAttempt(t *testing.T) {
+	tests := []struct {
+		name                  string
+		projectID             string
+		userID                string
+		expectedStatus        int
+		expectedParticipantID string
+	}{
+		{
+			name:           "anonymous learner",
+			projectID:      "test-project-id",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:                  "signed-in learner",
+			projectID:             "test-project-id",
+			userID:                "learner-1",
+			expectedStatus:        http.StatusCreated,
+			expectedParticipantID: "learner-1",
+		},
+		{
+			name:           "unpublished or unknown project",
+			projectID:      "draft-project-id",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewPublicHandler(nil, &attemptService{}, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/public/projects/"+tt.projectID+"/attempts", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", tt.projectID)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			if tt.userID != "" {
+				ctx = context.WithValue(ctx, httpmiddleware.UserIDKey, tt.userID)
+			}
+			req = req.WithContext(ctx)
+
+			rr := newRecorder()
+			handler.StartAttempt(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus != http.StatusCreated {
+				assertErrorResponse(t, rr.Body.Bytes(), "project_not_found")
+				return
+			}
+			var response types.AttemptResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, "attempt-1", response.ID)
+			assert.Equal(t, 3, response.Version)
+			assert.Equal(t, tt.expectedParticipantID, response.ParticipantID)
+			assert.Nil(t, response.SubmittedAt)
+			assert.Nil(t, response.Score, "attempts are scored once submitted")
+			assert.Empty(t, response.Results)
+		})
+	}
+}
+
+func TestPublicHandler_SaveAnswer(t *testing.T) {
+	tests := []struct {
+		name           string
+		attemptID      string
+		itemID         string
+		body           string
+		submitted      bool
+		savedSequence  int64
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "answer",
+			attemptID:      "attempt-1",
+			itemID:         "choice",
+			body:           `{"answer":{"choice_id":"b"}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "numbered answer",
+			attemptID:      "attempt-1",
+			itemID:         "choice",
+			body:           `{"answer":{"choice_id":"b"},"sequence":4}`,
+			savedSequence:  3,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "stale sequence",
+			attemptID:      "attempt-1",
+			itemID:         "choice",
+			body:           `{"answer":{"choice_id":"b"},"sequence":2}`,
+			savedSequence:  3,
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "stale_answer",
+		},
+		{
+			name:           "negative sequence",
+			attemptID:      "attempt-1",
+			itemID:         "choice",
+			body:           `{"answer":{"choice_id":"b"},"sequence":-1}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "validation_failed",
+		},
+		{
+			name:           "without an answer",
+			attemptID:      "attempt-1",
+			itemID:         "choice",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "validation_failed",
+		},
+		{
+			name:           "malformed body",
+			attemptID:      "attempt-1",
+			itemID:         "choice",
+			body:           `{"answer":`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request_body",
+		},
+		{
+			name:           "answer that doesn't fit the item",
+			attemptID:      "attempt-1",
+			itemID:         "choice",
+			body:           `{"answer":{"choice_id":"z"}}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "invalid_answer",
+		},
+		{
+			name:           "unknown item",
+			attemptID:      "attempt-1",
+			itemID:         "missing",
+			body:           `{"answer":{"choice_id":"b"}}`,
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "item_not_found",
+		},
+		{
+			name:           "unknown attempt",
+			attemptID:      "missing",
+			itemID:         "choice",
+			body:           `{"answer":{"choice_id":"b"}}`,
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "attempt_not_found",
+		},
+		{
+			name:           "submitted attempt",
+			attemptID:      "attempt-1",
+			itemID:         "choice",
+			body:           `{"answer":{"choice_id":"b"}}`,
+			submitted:      true,
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "attempt_already_submitted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewPublicHandler(nil, &attemptService{submitted: tt.submitted, savedSequence: tt.savedSequence}, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/public/attempts/"+tt.attemptID+"/answers/"+tt.itemID, strings.NewReader(tt.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("attemptId", tt.attemptID)
+			rctx.URLParams.Add("itemId", tt.itemID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := newRecorder()
+			handler.SaveAnswer(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedCode != "" {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+				return
+			}
+			var response types.AnswerResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, "choice", response.ItemID)
+			assert.Equal(t, map[string]interface{}{"choice_id": "b"}, response.Answer)
+			var sent types.SaveAnswerRequest
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &sent))
+			assert.Equal(t, sent.Sequence, response.Sequence)
+		})
+	}
+}
+
+func TestPublicHandler_SubmitAttempt(t *testing.T) {
+	tests := []struct {
+		name           string
+		attemptID      string
+		submitted      bool
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "submit",
+			attemptID:      "attempt-1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "submitted attempt",
+			attemptID:      "attempt-1",
+			submitted:      true,
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "attempt_already_submitted",
+		},
+		{
+			name:           "unknown attempt",
+			attemptID:      "missing",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "attempt_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewPublicHandler(nil, &attemptService{submitted: tt.submitted}, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/public/attempts/"+tt.attemptID+"/submit", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("attemptId", tt.attemptID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := newRecorder()
+			handler.SubmitAttempt(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedCode != "" {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+				return
+			}
+			var response types.AttemptResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			require.NotNil(t, response.SubmittedAt)
+			require.Len(t, response.Answers, 1)
+			assert.Equal(t, "choice", response.Answers[0].ItemID)
+			assert.Equal(t, 2, *response.Score)
+			assert.Equal(t, 3, *response.MaxScore)
+			require.Len(t, response.Results, 2)
+			assert.True(t, response.Results[0].Correct)
+			assert.Equal(t, "Paris has been the capital since 508", *response.Results[0].Explanation)
+			assert.Equal(t, types.ItemResultResponse{ItemID: "text_entry", Possible: 1}, response.Results[1])
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/pagination"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// ListPublications handles GET /api/v1/projects/{projectId}/publications
+// @Summary List project publications
+// @Description Returns a page of the versions a project was published as, newest first, without the items they froze. Projects published before publications were kept have none until they are published again.
+// @Tags Projects
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param limit query int false "Maximum number of publications to return" minimum(1) maximum(100) default(20)
+// @Param offset query int false "Number of publications to skip" minimum(0) default(0)
+// @Success 200 {object} types.PublicationListResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_pagination"
+// @Failure 404 {object} types.ErrorResponse "project_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/publications [get]
+func (h *ProjectHandler) ListPublications(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := chi.URLParam(r, "projectId")
+
+	page, err := pagination.Parse(r, pagination.Page{Limit: 20}, 100)
+	if err != nil {
+		pagination.WriteError(w, err)
+		return
+	}
+
+	publications, total, err := h.service.ListPublications(ctx, projectID, page.Limit, page.Offset)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to list publications")
+		respondDomainError(w, err)
+		return
+	}
+
+	response := types.PublicationListResponse{
+		ProjectID:    projectID,
+		Publications: make([]types.PublicationSummaryResponse, len(publications)),
+		Total:        total,
+		Limit:        page.Limit,
+		Offset:       page.Offset,
+	}
+	for i, publication := range publications {
+		response.Publications[i] = types.PublicationSummaryResponse{
+			Version:     publication.Version,
+			PublishedAt: publication.PublishedAt,
+		}
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// GetPublication handles GET /api/v1/projects/{projectId}/publications/{version}
+// @Summary Get project publication
+// @Description Returns a project and its items as they were published as a version, whatever was edited since.
+// @Tags Projects
+// @Produce json
+// @Param projectId path string true "Project ID" format(uuid)
+// @Param version path int true "Publication version"
+// @Success 200 {object} types.PublicationResponse
+// @Failure 404 {object} types.ErrorResponse "project_not_found, publication_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/projects/{projectId}/publications/{version} [get]
+func (h *ProjectHandler) GetPublication(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := chi.URLParam(r, "projectId")
+
+	// Publications are numbered from 1; anything else names none
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version < 1 {
+		respondDomainError(w, core.ErrPublicationNotFound)
+		return
+	}
+
+	publication, err := h.service.GetPublication(ctx, projectID, version)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Int("version", version).Msg("failed to get publication")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, publicationResponse(publication))
+}
+
+// publicationResponse is the response of a publication with its items
+func publicationResponse(publication *core.Publication) types.PublicationResponse {
+	response := types.PublicationResponse{
+		ProjectID:   publication.ProjectID,
+		Version:     publication.Version,
+		PublishedAt: publication.PublishedAt,
+		Project:     projectResponse(publication.Project),
+		Items:       make([]types.ItemResponse, len(publication.Items)),
+	}
+	for i, item := range publication.Items {
+		response.Items[i] = itemResponse(item)
+	}
+	return response
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/types"
+)
+
+func TestProjectHandler_ListPublications(t *testing.T) {
+	publishedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		query            string
+		setupMock        func(*MockProjectService)
+		expectedStatus   int
+		validateResponse func(t *testing.T, body []byte)
+	}{
+		{
+			name:  "newest first with the page",
+			query: "?limit=2&offset=1",
+			setupMock: func(mockService *MockProjectService) {
+				mockService.On("ListPublications", mock.Anything, "test-project-id", 2, 1).Return([]*core.Publication{
+					{ProjectID: "test-project-id", Version: 2, PublishedAt: publishedAt},
+					{ProjectID: "test-project-id", Version: 1, PublishedAt: publishedAt.Add(-time.Hour)},
+				}, 3, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResponse: func(t *testing.T, body []byte) {
+				var response types.PublicationListResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, "test-project-id", response.ProjectID)
+				assert.Equal(t, 3, response.Total)
+				assert.Equal(t, 2, response.Limit)
+				assert.Equal(t, 1, response.Offset)
+				require.Len(t, response.Publications, 2)
+				assert.Equal(t, 2, response.Publications[0].Version)
+				assert.True(t, publishedAt.Equal(response.Publications[0].PublishedAt))
+			},
+		},
+		{
+			name:  "never published",
+			query: "",
+			setupMock: func(mockService *MockProjectService) {
+				mockService.On("ListPublications", mock.Anything, "test-project-id", 20, 0).Return([]*core.Publication{}, 0, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResponse: func(t *testing.T, body []byte) {
+				assert.Contains(t, string(body), `"publications":[]`)
+			},
+		},
+		{
+			name:           "negative offset",
+			query:          "?offset=-1",
+			setupMock:      func(mockService *MockProjectService) {},
+			expectedStatus: http.StatusBadRequest,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "invalid_pagination")
+			},
+		},
+		{
+			name:  "project not found",
+			query: "",
+			setupMock: func(mockService *MockProjectService) {
+				mockService.On("ListPublications", mock.Anything, "test-project-id", 20, 0).Return(nil, 0, core.ErrProjectNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "project_not_found")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockProjectService)
+			tt.setupMock(mockService)
+
+			handler := NewProjectHandler(mockService, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/test-project-id/publications"+tt.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "test-project-id")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := newRecorder()
+			handler.ListPublications(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			tt.validateResponse(t, rr.Body.Bytes())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProjectHandler_GetPublication(t *testing.T) {
+	publishedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		version          string
+		setupMock        func(*MockProjectService)
+		expectedStatus   int
+		validateResponse func(t *testing.T, body []byte)
+	}{
+		{
+			name:    "frozen project and items",
+			version: "2",
+			setupMock: func(mockService *MockProjectService) {
+				mockService.On("GetPublication", mock.Anything, "test-project-id", 2).Return(&core.Publication{
+					ProjectID:   "test-project-id",
+					Version:     2,
+					PublishedAt: publishedAt,
+					Project:     &core.Project{ID: "test-project-id", Title: "Tide Tables", PublishedAt: &publishedAt, Version: 4},
+					Items: []*core.Item{
+						{ID: "item-1", ProjectID: "test-project-id", Type: types.ItemTypeTitle, Title: "Welcome", Position: 0, Version: 1},
+					},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResponse: func(t *testing.T, body []byte) {
+				var response types.PublicationResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, 2, response.Version)
+				assert.Equal(t, "Tide Tables", response.Project.Title)
+				assert.Equal(t, 4, response.Project.Version)
+				require.Len(t, response.Items, 1)
+				assert.Equal(t, "Welcome", response.Items[0].Title)
+			},
+		},
+		{
+			name:    "version never published",
+			version: "7",
+			setupMock: func(mockService *MockProjectService) {
+				mockService.On("GetPublication", mock.Anything, "test-project-id", 7).Return(nil, core.ErrPublicationNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "publication_not_found")
+			},
+		},
+		{
+			name:           "version that is not a number",
+			version:        "latest",
+			setupMock:      func(mockService *MockProjectService) {},
+			expectedStatus: http.StatusNotFound,
+			validateResponse: func(t *testing.T, body []byte) {
+				assertErrorResponse(t, body, "publication_not_found")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockProjectService)
+			tt.setupMock(mockService)
+
+			handler := NewProjectHandler(mockService, httpmiddleware.NewValidator())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/test-project-id/publications/"+tt.version, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("projectId", "test-project-id")
+			rctx.URLParams.Add("version", tt.version)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := newRecorder()
+			handler.GetPublication(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			tt.validateResponse(t, rr.Body.Bytes())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+const (
+	defaultTopKeysWindow = 5 * time.Minute
+	defaultTopKeysLimit  = 10
+	maxTopKeysLimit      = 100
+)
+
+// RateLimitReporter reports the heaviest rate limit keys, satisfied by
+// *httpmiddleware.RateLimiter
+type RateLimitReporter interface {
+	TopKeys(window time.Duration, n int) []httpmiddleware.KeyUsage
+}
+
+// RateLimitHandler handles the rate limit endpoints under
+// /api/v1/admin/rate-limits
+type RateLimitHandler struct {
+	limiter RateLimitReporter
+}
+
+// NewRateLimitHandler creates a new rate limit handler
+func NewRateLimitHandler(limiter RateLimitReporter) *RateLimitHandler {
+	return &RateLimitHandler{limiter: limiter}
+}
+
+// TopKeys handles GET /api/v1/admin/rate-limits/top
+// @Summary List the heaviest rate limit keys
+// @Description Returns the clients with the most requests over the window on the replica that served the request, heaviest first. Clients are keyed by user when authenticated and by IP otherwise. Counts near the bottom of a busy list are estimates.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param window query string false "Window to report on, at most 15m (default 5m)"
+// @Param limit query int false "Number of keys to return" minimum(1) maximum(100) default(10)
+// @Success 200 {object} types.RateLimitTopResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_window, invalid_limit"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/rate-limits/top [get]
+func (h *RateLimitHandler) TopKeys(w http.ResponseWriter, r *http.Request) {
+	window := defaultTopKeysWindow
+	if raw := r.URL.Query().Get("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < time.Minute || parsed > httpmiddleware.TopKeysRetention {
+			respond.Error(w, http.StatusBadRequest, "invalid_window",
+				"Window must be a duration between 1m and "+httpmiddleware.TopKeysRetention.String())
+			return
+		}
+		window = parsed
+	}
+
+	limit := defaultTopKeysLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxTopKeysLimit {
+			respond.Error(w, http.StatusBadRequest, "invalid_limit",
+				"Limit must be an integer between 1 and "+strconv.Itoa(maxTopKeysLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	usage := h.limiter.TopKeys(window, limit)
+	response := types.RateLimitTopResponse{
+		WindowSeconds: int(window / time.Second),
+		Keys:          make([]types.RateLimitKeyResponse, len(usage)),
+	}
+	for i, key := range usage {
+		response.Keys[i] = types.RateLimitKeyResponse{
+			Key:      key.Key,
+			KeyType:  key.KeyType,
+			Requests: key.Requests,
+			Limited:  key.Limited,
+		}
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
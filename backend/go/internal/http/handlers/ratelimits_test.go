@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// fakeRateLimitReporter records the arguments it was asked for
+type fakeRateLimitReporter struct {
+	usage  []httpmiddleware.KeyUsage
+	window time.Duration
+	n      int
+}
+
+func (f *fakeRateLimitReporter) TopKeys(window time.Duration, n int) []httpmiddleware.KeyUsage {
+	f.window, f.n = window, n
+	return f.usage
+}
+
+func TestRateLimitHandler_TopKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCode   string
+		wantWindow time.Duration
+		wantN      int
+	}{
+		{"defaults", "", http.StatusOK, "", 5 * time.Minute, 10},
+		{"window and limit", "?window=15m&limit=3", http.StatusOK, "", 15 * time.Minute, 3},
+		{"window too long", "?window=1h", http.StatusBadRequest, "invalid_window", 0, 0},
+		{"window not a duration", "?window=five", http.StatusBadRequest, "invalid_window", 0, 0},
+		{"limit too large", "?limit=1000", http.StatusBadRequest, "invalid_limit", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			reporter := &fakeRateLimitReporter{usage: []httpmiddleware.KeyUsage{
+				{Key: "ip:203.0.113.7", KeyType: "ip", Requests: 120, Limited: 20},
+			}}
+			handler := NewRateLimitHandler(reporter)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/rate-limits/top"+tt.query, nil)
+			rr := newRecorder()
+
+			// Act
+			handler.TopKeys(rr, req)
+
+			// Assert
+			require.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantCode != "" {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.wantCode)
+				return
+			}
+			assert.Equal(t, tt.wantWindow, reporter.window)
+			assert.Equal(t, tt.wantN, reporter.n)
+
+			var body types.RateLimitTopResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, int(tt.wantWindow/time.Second), body.WindowSeconds)
+			assert.Equal(t, []types.RateLimitKeyResponse{
+				{Key: "ip:203.0.113.7", KeyType: "ip", Requests: 120, Limited: 20},
+			}, body.Keys)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// projectRows renders a project list page for respond.Negotiated
+type projectRows struct {
+	response types.ProjectListResponse
+}
+
+func (p projectRows) Envelope() interface{} { return p.response }
+
+func (p projectRows) Columns() []string {
+	return []string{"id", "title", "description", "tags", "item_count", "created_at", "updated_at", "published_at"}
+}
+
+func (p projectRows) Len() int { return len(p.response.Projects) }
+
+func (p projectRows) Record(i int) []string {
+	project := p.response.Projects[i]
+	return []string{
+		project.ID,
+		project.Title,
+		optionalString(project.Description),
+		// All tags share one cell, separated by semicolons
+		strings.Join(project.Tags, ";"),
+		optionalInt(project.ItemCount),
+		csvTime(project.CreatedAt),
+		csvTime(project.UpdatedAt),
+		optionalCSVTime(project.PublishedAt),
+	}
+}
+
+func (p projectRows) Object(i int) interface{} { return p.response.Projects[i] }
+
+// itemRows renders an item list page for respond.Negotiated
+type itemRows struct {
+	response types.ItemListResponse
+}
+
+func (it itemRows) Envelope() interface{} { return it.response }
+
+func (it itemRows) Columns() []string {
+	return []string{"id", "project_id", "type", "title", "position", "required", "points", "explanation", "content", "created_at", "updated_at"}
+}
+
+func (it itemRows) Len() int { return len(it.response.Items) }
+
+func (it itemRows) Record(i int) []string {
+	item := it.response.Items[i]
+
+	// Content is free-form per item type, so it stays JSON inside its cell
+	content := ""
+	if encoded, err := json.Marshal(item.Content); err == nil && string(encoded) != "null" {
+		content = string(encoded)
+	}
+
+	return []string{
+		item.ID,
+		item.ProjectID,
+		string(item.Type),
+		item.Title,
+		strconv.Itoa(item.Position),
+		strconv.FormatBool(item.Required),
+		optionalInt(item.Points),
+		optionalString(item.Explanation),
+		content,
+		csvTime(item.CreatedAt),
+		csvTime(item.UpdatedAt),
+	}
+}
+
+func (it itemRows) Object(i int) interface{} { return it.response.Items[i] }
+
+func optionalString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func optionalInt(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
+
+func csvTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func optionalCSVTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return csvTime(*t)
+}
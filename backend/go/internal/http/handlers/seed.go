@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// SeedHandler handles POST /api/v1/admin/seed
+type SeedHandler struct {
+	seeder core.Seeder
+}
+
+// NewSeedHandler creates a new seed handler
+func NewSeedHandler(seeder core.Seeder) *SeedHandler {
+	return &SeedHandler{seeder: seeder}
+}
+
+// Seed handles POST /api/v1/admin/seed
+// @Summary Seed development fixtures
+// @Description Creates the documented development fixtures: an organization with 3 members and 10 projects covering every item type, one of them published, with sample files uploaded to local storage. Fixtures have fixed IDs, so seeding again creates only the missing ones and reports created 0 once all exist. Only mounted when ENVIRONMENT is development.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.SeedResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/seed [post]
+func (h *SeedHandler) Seed(w http.ResponseWriter, r *http.Request) {
+	result, err := h.seeder.Seed(r.Context())
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("failed to seed fixtures")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, seedResponse(result))
+}
+
+func seedResponse(result *core.SeedResult) types.SeedResponse {
+	response := types.SeedResponse{
+		OrganizationID: result.OrganizationID,
+		Users:          make([]types.SeedUserResponse, len(result.Users)),
+		Projects:       make([]types.SeedProjectResponse, len(result.Projects)),
+		Assets:         result.Assets,
+		Created:        result.Created,
+	}
+	if response.Assets == nil {
+		response.Assets = []string{}
+	}
+	for i, user := range result.Users {
+		response.Users[i] = types.SeedUserResponse{ID: user.ID, Email: user.Email, Role: user.Role}
+	}
+	for i, project := range result.Projects {
+		response.Projects[i] = types.SeedProjectResponse{
+			ID:        project.ID,
+			Title:     project.Title,
+			Items:     project.Items,
+			Published: project.Published,
+		}
+	}
+	return response
+}
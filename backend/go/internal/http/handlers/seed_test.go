@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// fakeSeeder returns a fixed result
+type fakeSeeder struct {
+	result *core.SeedResult
+	err    error
+}
+
+func (f *fakeSeeder) Seed(ctx context.Context) (*core.SeedResult, error) {
+	return f.result, f.err
+}
+
+func TestSeedHandler_Seed(t *testing.T) {
+	tests := []struct {
+		name           string
+		seeder         *fakeSeeder
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "seeded",
+			seeder: &fakeSeeder{result: &core.SeedResult{
+				OrganizationID: core.SeedOrganizationID,
+				Users:          core.SeedUsers,
+				Projects: []core.SeededProject{
+					{ID: "5eed0000-0000-4000-8000-000000001003", Title: "World Capitals", Items: 11, Published: true},
+				},
+				Assets:  []string{"projects/5eed0000-0000-4000-8000-000000001002/assets/plant-cell.svg"},
+				Created: 42,
+			}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "seeding failed",
+			seeder:         &fakeSeeder{err: errors.New("connection reset")},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   types.ErrorCodeInternalError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := NewSeedHandler(tt.seeder)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/seed", nil)
+			rr := newRecorder()
+
+			// Act
+			handler.Seed(rr, req)
+
+			// Assert
+			require.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedCode != "" {
+				assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+				return
+			}
+			var body types.SeedResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, core.SeedOrganizationID, body.OrganizationID)
+			assert.Equal(t, tt.seeder.result.Created, body.Created)
+			assert.Equal(t, tt.seeder.result.Assets, body.Assets)
+			require.Len(t, body.Users, len(core.SeedUsers))
+			assert.Equal(t, "dev@example.com", body.Users[0].Email)
+			require.Len(t, body.Projects, 1)
+			assert.Equal(t, types.SeedProjectResponse{
+				ID:        "5eed0000-0000-4000-8000-000000001003",
+				Title:     "World Capitals",
+				Items:     11,
+				Published: true,
+			}, body.Projects[0])
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/config"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// RuntimeSettings are the settings the settings handler reads and changes,
+// satisfied by *config.Dynamic
+type RuntimeSettings interface {
+	Values() []config.SettingValue
+	Update(ctx context.Context, changes map[string]*string, updatedBy string) error
+}
+
+// SettingsHandler handles the runtime settings endpoints under
+// /api/v1/admin/settings
+type SettingsHandler struct {
+	settings RuntimeSettings
+	validate *validator.Validate
+}
+
+// NewSettingsHandler creates a new settings handler
+func NewSettingsHandler(settings RuntimeSettings, validate *validator.Validate) *SettingsHandler {
+	return &SettingsHandler{
+		settings: settings,
+		validate: validate,
+	}
+}
+
+// GetSettings handles GET /api/v1/admin/settings
+// @Summary Get runtime settings
+// @Description Returns every setting that can be changed without a restart, with the value in effect on this replica and the value from the environment
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.SettingsResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/settings [get]
+func (h *SettingsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, http.StatusOK, h.settingsResponse())
+}
+
+// UpdateSettings handles PUT /api/v1/admin/settings
+// @Summary Change runtime settings
+// @Description Overrides settings for every replica; null resets a setting to the value from the environment. Only log_level, rate_limit_requests, rate_limit_window, maintenance_message and the enable_* feature flags can be changed; other keys, such as secrets and connection strings, are rejected. Replicas pick up the change within one poll interval. Every change is audit-logged.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.UpdateSettingsRequest true "Settings to change"
+// @Success 200 {object} types.SettingsResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/settings [put]
+func (h *SettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req types.UpdateSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpmiddleware.SendBodyReadError(w, err)
+		return
+	}
+
+	if err := h.validate.StructCtx(ctx, req); err != nil {
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
+		return
+	}
+
+	changes, fieldErrors := settingChanges(req.Settings)
+	if len(fieldErrors) > 0 {
+		respond.ValidationError(w, fieldErrors)
+		return
+	}
+
+	if err := h.settings.Update(ctx, changes, httpmiddleware.GetUserID(ctx)); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to update settings")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, h.settingsResponse())
+}
+
+// settingChanges converts the request's values to the text settings are
+// stored as, validating each one
+func settingChanges(values map[string]interface{}) (map[string]*string, []types.ValidationError) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	changes := make(map[string]*string, len(values))
+	var fieldErrors []types.ValidationError
+	for _, key := range keys {
+		field := "settings." + key
+
+		value, ok := settingText(values[key])
+		if !ok {
+			fieldErrors = append(fieldErrors, types.ValidationError{
+				Field:   field,
+				Tag:     "invalid",
+				Message: key + " must be a string, number, boolean or null",
+			})
+			continue
+		}
+
+		if err := config.ValidateSetting(key, value); err != nil {
+			tag := "invalid"
+			if errors.Is(err, config.ErrSettingNotAdjustable) {
+				tag = "not_adjustable"
+			}
+			fieldErrors = append(fieldErrors, types.ValidationError{
+				Field:   field,
+				Tag:     tag,
+				Message: err.Error(),
+			})
+			continue
+		}
+
+		changes[key] = value
+	}
+	return changes, fieldErrors
+}
+
+// settingText renders a decoded JSON value as setting text; nil stays nil
+func settingText(value interface{}) (*string, bool) {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		return nil, true
+	case string:
+		text = v
+	case bool:
+		text = strconv.FormatBool(v)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return nil, false
+	}
+	return &text, true
+}
+
+func (h *SettingsHandler) settingsResponse() types.SettingsResponse {
+	values := h.settings.Values()
+
+	response := types.SettingsResponse{Settings: make([]types.SettingResponse, 0, len(values))}
+	for _, value := range values {
+		setting := types.SettingResponse{
+			Key:        value.Key,
+			Value:      value.Value,
+			Default:    value.Default,
+			Overridden: value.Overridden,
+			UpdatedAt:  optionalTime(value.UpdatedAt),
+			UpdatedBy:  value.UpdatedBy,
+		}
+		response.Settings = append(response.Settings, setting)
+	}
+	return response
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/config"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+)
+
+func newSettingsRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/settings", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req.WithContext(context.WithValue(req.Context(), httpmiddleware.UserIDKey, "admin-1"))
+}
+
+func settingByKey(t *testing.T, response types.SettingsResponse, key string) types.SettingResponse {
+	t.Helper()
+	for _, setting := range response.Settings {
+		if setting.Key == key {
+			return setting
+		}
+	}
+	t.Fatalf("setting %q missing from response", key)
+	return types.SettingResponse{}
+}
+
+func TestSettingsHandler_UpdateSettings(t *testing.T) {
+	// Arrange
+	settings := config.NewDynamic(&config.Config{LogLevel: "info", RateLimitRequests: 100, RateLimitWindow: 60}, store.NewMemorySettingsStore())
+	handler := NewSettingsHandler(settings, httpmiddleware.NewValidator())
+	rr := newRecorder()
+
+	// Act
+	handler.UpdateSettings(rr, newSettingsRequest(`{"settings":{"rate_limit_requests":250,"enable_analytics":true,"maintenance_message":"Back soon"}}`))
+
+	// Assert
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response types.SettingsResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	rateLimit := settingByKey(t, response, config.SettingRateLimitRequests)
+	assert.Equal(t, "250", rateLimit.Value)
+	assert.Equal(t, "100", rateLimit.Default)
+	assert.True(t, rateLimit.Overridden)
+	assert.Equal(t, "admin-1", rateLimit.UpdatedBy)
+	assert.NotNil(t, rateLimit.UpdatedAt)
+	assert.Equal(t, "true", settingByKey(t, response, config.SettingEnableAnalytics).Value)
+	assert.False(t, settingByKey(t, response, config.SettingLogLevel).Overridden)
+	assert.Equal(t, 250, settings.Settings().RateLimitRequests)
+}
+
+func TestSettingsHandler_UpdateSettingsResetsWithNull(t *testing.T) {
+	// Arrange
+	settings := config.NewDynamic(&config.Config{LogLevel: "info", RateLimitWindow: 60}, store.NewMemorySettingsStore())
+	handler := NewSettingsHandler(settings, httpmiddleware.NewValidator())
+	handler.UpdateSettings(newRecorder(), newSettingsRequest(`{"settings":{"log_level":"debug"}}`))
+	require.Equal(t, "debug", settings.Settings().LogLevel)
+	rr := newRecorder()
+
+	// Act
+	handler.UpdateSettings(rr, newSettingsRequest(`{"settings":{"log_level":null}}`))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "info", settings.Settings().LogLevel)
+}
+
+func TestSettingsHandler_UpdateSettingsRejections(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedErrors []types.ValidationError
+	}{
+		{
+			name: "static security settings",
+			body: `{"settings":{"jwt_secret":"hunter2","database_url":"postgres://evil"}}`,
+			expectedErrors: []types.ValidationError{
+				{Field: "settings.database_url", Tag: "not_adjustable", Message: "setting cannot be changed at runtime: database_url"},
+				{Field: "settings.jwt_secret", Tag: "not_adjustable", Message: "setting cannot be changed at runtime: jwt_secret"},
+			},
+		},
+		{
+			name: "invalid values",
+			body: `{"settings":{"rate_limit_window":0,"enable_analytics":{"on":true}}}`,
+			expectedErrors: []types.ValidationError{
+				{Field: "settings.enable_analytics", Tag: "invalid", Message: "enable_analytics must be a string, number, boolean or null"},
+				{Field: "settings.rate_limit_window", Tag: "invalid", Message: "invalid setting value: rate_limit_window must be an integer between 1 and 86400"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			settingsStore := store.NewMemorySettingsStore()
+			settings := config.NewDynamic(&config.Config{LogLevel: "info", RateLimitWindow: 60}, settingsStore)
+			handler := NewSettingsHandler(settings, httpmiddleware.NewValidator())
+			rr := newRecorder()
+
+			// Act
+			handler.UpdateSettings(rr, newSettingsRequest(tt.body))
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+			var response types.ValidationErrorResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, types.ErrorCodeValidationFailed, response.Error.Code)
+			assert.Equal(t, tt.expectedErrors, response.Error.Errors)
+
+			stored, err := settingsStore.List(context.Background())
+			require.NoError(t, err)
+			assert.Empty(t, stored)
+		})
+	}
+}
+
+func TestSettingsHandler_UpdateSettingsRequiresSettings(t *testing.T) {
+	// Arrange
+	handler := NewSettingsHandler(nil, httpmiddleware.NewValidator())
+	rr := newRecorder()
+
+	// Act
+	handler.UpdateSettings(rr, newSettingsRequest(`{"settings":{}}`))
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assertErrorResponse(t, rr.Body.Bytes(), "validation_failed")
+}
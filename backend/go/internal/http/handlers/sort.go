@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// projectSorts are the values of the sort parameter of project lists: a
+// field, ascending, or the field after a - for descending order
+var projectSorts = map[string]core.ProjectSort{
+	"created_at":  core.ProjectSortCreatedAtAsc,
+	"-created_at": core.ProjectSortCreatedAt,
+	"updated_at":  core.ProjectSortUpdatedAtAsc,
+	"-updated_at": core.ProjectSortUpdatedAt,
+	"title":       core.ProjectSortTitle,
+	"-title":      core.ProjectSortTitleDesc,
+}
+
+// itemSorts are the values of the sort parameter of item lists, as
+// projectSorts are for projects
+var itemSorts = map[string]core.ItemSort{
+	"position":    core.ItemSortPosition,
+	"-position":   core.ItemSortPositionDesc,
+	"created_at":  core.ItemSortCreatedAt,
+	"-created_at": core.ItemSortCreatedAtDesc,
+	"updated_at":  core.ItemSortUpdatedAt,
+	"-updated_at": core.ItemSortUpdatedAtDesc,
+	"title":       core.ItemSortTitle,
+	"-title":      core.ItemSortTitleDesc,
+}
+
+// parseSort returns the sort that sorts maps r's sort parameter to, or the
+// zero value, the list's default order, when there is none. For any other
+// value it writes a 400 invalid_sort naming the fields and returns false.
+func parseSort[S ~string](w http.ResponseWriter, r *http.Request, sorts map[string]S) (S, bool) {
+	raw := r.URL.Query().Get("sort")
+	if raw == "" {
+		return "", true
+	}
+	if sort, ok := sorts[raw]; ok {
+		return sort, true
+	}
+
+	var fields []string
+	for name := range sorts {
+		if !strings.HasPrefix(name, "-") {
+			fields = append(fields, name)
+		}
+	}
+	slices.Sort(fields)
+	respond.Error(w, http.StatusBadRequest, types.ErrorCodeInvalidSort, "Invalid sort parameter",
+		fmt.Sprintf("cannot sort by %q; sort by %s, after a - for descending order", raw, strings.Join(fields, ", ")))
+	return "", false
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// Timeouts are scaled down 100x from the defaults (5s default, 30s bulk) so
+// a simulated 10s store call takes 100ms
+const (
+	testTimeoutDefault = 50 * time.Millisecond
+	testTimeoutBulk    = 300 * time.Millisecond
+	testStoreLatency   = 100 * time.Millisecond
+)
+
+// slowItemService behaves like a store that takes testStoreLatency per call
+// and gives up when the request context expires
+type slowItemService struct{}
+
+func (slowItemService) wait(ctx context.Context) error {
+	select {
+	case <-time.After(testStoreLatency):
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to query items: %w", ctx.Err())
+	}
+}
+
+func (s slowItemService) Create(ctx context.Context, projectID string, itemType types.ItemType, title string, content interface{}, position int, required bool, points *int, explanation *string) (*core.Item, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &core.Item{ID: "item-1", ProjectID: projectID, Type: itemType, Title: title, Position: position}, nil
+}
+
+func (s slowItemService) CreateMany(ctx context.Context, projectID string, inputs []core.ItemInput) ([]*core.Item, error) {
+	items := make([]*core.Item, len(inputs))
+	for i, input := range inputs {
+		if err := s.wait(ctx); err != nil {
+			return nil, err
+		}
+		items[i] = &core.Item{ID: fmt.Sprintf("item-%d", i+1), ProjectID: projectID, Type: input.Type, Title: input.Title, Position: input.Position}
+	}
+	return items, nil
+}
+
+func (s slowItemService) GetByID(ctx context.Context, projectID, id string) (*core.Item, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &core.Item{ID: id, ProjectID: projectID, Type: types.ItemTypeTitle, Title: "Intro"}, nil
+}
+
+func (s slowItemService) ListByProject(ctx context.Context, projectID string) ([]*core.Item, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s slowItemService) List(ctx context.Context, projectID string, opts core.ItemListOptions) (*core.ItemPage, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &core.ItemPage{Items: []*core.Item{}}, nil
+}
+
+func (s slowItemService) Duplicate(ctx context.Context, projectID, id string) (*core.Item, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s slowItemService) Update(ctx context.Context, projectID, id string, itemType types.ItemType, title string, content interface{}, position int, required bool, points *int, explanation *string, version *int) (*core.Item, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s slowItemService) Patch(ctx context.Context, projectID, id string, patch core.ItemPatch) (*core.Item, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s slowItemService) Delete(ctx context.Context, projectID, id string) error {
+	return s.wait(ctx)
+}
+
+func (s slowItemService) Restore(ctx context.Context, projectID, id string) (*core.Item, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s slowItemService) UpdatePositions(ctx context.Context, projectID string, updates []core.PositionUpdate) error {
+	return s.wait(ctx)
+}
+
+func (s slowItemService) ListRevisions(ctx context.Context, projectID, id string, limit, offset int) ([]*core.ItemRevision, int, error) {
+	return nil, 0, s.wait(ctx)
+}
+
+func (s slowItemService) RestoreRevision(ctx context.Context, projectID, id string, number int) (*core.Item, error) {
+	return nil, s.wait(ctx)
+}
+
+// newTimeoutTestRouter mirrors the item route groups in main.go
+func newTimeoutTestRouter() http.Handler {
+	handler := NewItemHandler(slowItemService{}, httpmiddleware.NewValidator())
+
+	r := chi.NewRouter()
+	r.Route("/projects/{projectId}/items", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(httpmiddleware.Timeout(testTimeoutDefault))
+			r.Get("/{itemId}", handler.GetItem)
+		})
+		r.With(httpmiddleware.Timeout(testTimeoutBulk)).Post("/bulk", handler.BulkCreateItems)
+	})
+	return r
+}
+
+func TestRouteTimeouts(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "plain GET exceeds the default timeout",
+			method:         http.MethodGet,
+			path:           "/projects/project-1/items/item-1",
+			expectedStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:           "bulk create tolerates a slow store",
+			method:         http.MethodPost,
+			path:           "/projects/project-1/items/bulk",
+			body:           `[{"type":"title","title":"Intro","position":0}]`,
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := newTimeoutTestRouter()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := newRecorder()
+
+			// Act
+			router.ServeHTTP(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusGatewayTimeout {
+				assertErrorResponse(t, rr.Body.Bytes(), "gateway_timeout")
+			}
+		})
+	}
+}
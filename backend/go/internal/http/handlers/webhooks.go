@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/pagination"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// WebhookRedeliverer queues deliveries again, satisfied by
+// *webhook.Dispatcher
+type WebhookRedeliverer interface {
+	Redeliver(ctx context.Context, userID, webhookID, deliveryID string) (*core.WebhookDelivery, error)
+}
+
+// WebhookHandler handles the signed-in user's webhooks under
+// /api/v1/me/webhooks
+type WebhookHandler struct {
+	store      core.WebhookStore
+	dispatcher WebhookRedeliverer
+	validate   *validator.Validate
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(store core.WebhookStore, dispatcher WebhookRedeliverer, validate *validator.Validate) *WebhookHandler {
+	return &WebhookHandler{store: store, dispatcher: dispatcher, validate: validate}
+}
+
+// ListWebhooks handles GET /api/v1/me/webhooks
+// @Summary List my webhooks
+// @Description Returns the signed-in user's webhooks, oldest first, whatever organization they deliver the events of. Secrets are not returned.
+// @Tags Webhooks
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.WebhookListResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/me/webhooks [get]
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	webhooks, err := h.store.List(ctx, httpmiddleware.GetUserID(ctx))
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to list webhooks")
+		respondDomainError(w, err)
+		return
+	}
+
+	response := types.WebhookListResponse{Webhooks: make([]types.WebhookResponse, 0, len(webhooks))}
+	for _, webhook := range webhooks {
+		response.Webhooks = append(response.Webhooks, webhookResponse(webhook))
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// CreateWebhook handles POST /api/v1/me/webhooks
+// @Summary Create webhook
+// @Description Subscribes a URL to events of the projects of the organization in X-Org-ID, or of the projects that belong to no organization. Deliveries are POSTs of the versioned event payload, signed in the X-ProveMySelf-Signature header as t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>"> keyed with the secret. A secret is generated when none is given; the response is the only one that returns it.
+// @Tags Webhooks
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string false "Organization whose project events are delivered"
+// @Param request body types.WebhookRequest true "Webhook"
+// @Success 201 {object} types.WebhookResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "org_access_denied"
+// @Failure 404 {object} types.ErrorResponse "organization_not_found"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/me/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req types.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpmiddleware.SendBodyReadError(w, err)
+		return
+	}
+
+	if err := h.validate.StructCtx(ctx, req); err != nil {
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
+		return
+	}
+
+	secret := ""
+	if req.Secret != nil {
+		secret = *req.Secret
+	} else {
+		generated, err := newWebhookSecret()
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to generate webhook secret")
+			respondDomainError(w, err)
+			return
+		}
+		secret = generated
+	}
+
+	var orgID *string
+	if id := core.OrgIDFromContext(ctx); id != "" {
+		orgID = &id
+	}
+	webhook, err := h.store.Create(ctx, &core.Webhook{
+		UserID:     httpmiddleware.GetUserID(ctx),
+		OrgID:      orgID,
+		OwnerEmail: httpmiddleware.GetUserEmail(ctx),
+		URL:        req.URL,
+		Secret:     secret,
+		Events:     eventTypes(req.Events),
+		Active:     req.Active == nil || *req.Active,
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to create webhook")
+		respondDomainError(w, err)
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("webhook_id", webhook.ID).
+		Str("user_id", webhook.UserID).
+		Msg("webhook created")
+
+	response := webhookResponse(webhook)
+	response.Secret = webhook.Secret
+	respond.JSON(w, http.StatusCreated, response)
+}
+
+// GetWebhook handles GET /api/v1/me/webhooks/{webhookId}
+// @Summary Get webhook
+// @Description Returns one of the signed-in user's webhooks, without its secret
+// @Tags Webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Success 200 {object} types.WebhookResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 404 {object} types.ErrorResponse "webhook_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/me/webhooks/{webhookId} [get]
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	webhookID := chi.URLParam(r, "webhookId")
+
+	webhook, err := h.store.Get(ctx, httpmiddleware.GetUserID(ctx), webhookID)
+	if err != nil {
+		logWebhookError(ctx, err, webhookID, "failed to get webhook")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, webhookResponse(webhook))
+}
+
+// UpdateWebhook handles PUT /api/v1/me/webhooks/{webhookId}
+// @Summary Update webhook
+// @Description Replaces a webhook's URL, events and active flag, and its secret when one is given. Activating a webhook, including one disabled after failing, clears its failures.
+// @Tags Webhooks
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Param request body types.WebhookRequest true "Webhook"
+// @Success 200 {object} types.WebhookResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_request_body, validation_failed"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 404 {object} types.ErrorResponse "webhook_not_found"
+// @Failure 413 {object} types.ErrorResponse "request_too_large"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/me/webhooks/{webhookId} [put]
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	webhookID := chi.URLParam(r, "webhookId")
+
+	var req types.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpmiddleware.SendBodyReadError(w, err)
+		return
+	}
+
+	if err := h.validate.StructCtx(ctx, req); err != nil {
+		respond.ValidationError(w, httpmiddleware.ValidationErrors(err, ""))
+		return
+	}
+
+	webhook, err := h.store.Get(ctx, httpmiddleware.GetUserID(ctx), webhookID)
+	if err != nil {
+		logWebhookError(ctx, err, webhookID, "failed to get webhook")
+		respondDomainError(w, err)
+		return
+	}
+
+	webhook.URL = req.URL
+	webhook.Events = eventTypes(req.Events)
+	webhook.Active = req.Active == nil || *req.Active
+	if req.Secret != nil {
+		webhook.Secret = *req.Secret
+	}
+	updated, err := h.store.Update(ctx, webhook)
+	if err != nil {
+		logWebhookError(ctx, err, webhookID, "failed to update webhook")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, webhookResponse(updated))
+}
+
+// DeleteWebhook handles DELETE /api/v1/me/webhooks/{webhookId}
+// @Summary Delete webhook
+// @Description Deletes one of the signed-in user's webhooks with its deliveries
+// @Tags Webhooks
+// @Security BearerAuth
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Success 204 "No content"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 404 {object} types.ErrorResponse "webhook_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/me/webhooks/{webhookId} [delete]
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	webhookID := chi.URLParam(r, "webhookId")
+
+	if err := h.store.Delete(ctx, httpmiddleware.GetUserID(ctx), webhookID); err != nil {
+		logWebhookError(ctx, err, webhookID, "failed to delete webhook")
+		respondDomainError(w, err)
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("webhook_id", webhookID).
+		Str("user_id", httpmiddleware.GetUserID(ctx)).
+		Msg("webhook deleted")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /api/v1/me/webhooks/{webhookId}/deliveries
+// @Summary List webhook deliveries
+// @Description Returns a page of a webhook's deliveries, newest first, with their status, attempts, latency and the start of the last response. Deliveries are kept for WEBHOOK_DELIVERY_RETENTION, 7 days by default.
+// @Tags Webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Param limit query int false "Maximum number of deliveries to return" minimum(1) maximum(100) default(20)
+// @Param offset query int false "Number of deliveries to skip" minimum(0) default(0)
+// @Success 200 {object} types.WebhookDeliveryListResponse
+// @Failure 400 {object} types.ErrorResponse "invalid_pagination"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 404 {object} types.ErrorResponse "webhook_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/me/webhooks/{webhookId}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	webhookID := chi.URLParam(r, "webhookId")
+
+	page, err := pagination.Parse(r, pagination.Page{Limit: 20}, 100)
+	if err != nil {
+		pagination.WriteError(w, err)
+		return
+	}
+
+	// Deliveries are reached through a webhook of the user's
+	if _, err := h.store.Get(ctx, httpmiddleware.GetUserID(ctx), webhookID); err != nil {
+		logWebhookError(ctx, err, webhookID, "failed to get webhook")
+		respondDomainError(w, err)
+		return
+	}
+	deliveries, total, err := h.store.ListDeliveries(ctx, webhookID, page.Limit, page.Offset)
+	if err != nil {
+		logWebhookError(ctx, err, webhookID, "failed to list webhook deliveries")
+		respondDomainError(w, err)
+		return
+	}
+
+	response := types.WebhookDeliveryListResponse{
+		Deliveries: make([]types.WebhookDeliveryResponse, 0, len(deliveries)),
+		Total:      total,
+		Limit:      page.Limit,
+		Offset:     page.Offset,
+	}
+	for _, delivery := range deliveries {
+		response.Deliveries = append(response.Deliveries, webhookDeliveryResponse(delivery))
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// RedeliverWebhook handles POST /api/v1/me/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver
+// @Summary Redeliver webhook event
+// @Description Queues a new delivery of a past delivery's event, with the same payload and event ID, signed again with the webhook's current secret. Works for inactive webhooks too, e.g. to check a fixed endpoint before turning the webhook back on.
+// @Tags Webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Param deliveryId path string true "Delivery ID" format(uuid)
+// @Success 202 {object} types.WebhookDeliveryResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 404 {object} types.ErrorResponse "webhook_not_found, webhook_delivery_not_found"
+// @Failure 500 {object} types.ErrorResponse "internal_error"
+// @Failure 503 {object} types.ErrorResponse "webhook_queue_full"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/me/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver [post]
+func (h *WebhookHandler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	webhookID := chi.URLParam(r, "webhookId")
+
+	delivery, err := h.dispatcher.Redeliver(ctx, httpmiddleware.GetUserID(ctx), webhookID, chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		logWebhookError(ctx, err, webhookID, "failed to redeliver webhook event")
+		respondDomainError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusAccepted, webhookDeliveryResponse(delivery))
+}
+
+// newWebhookSecret returns a random secret for signing deliveries
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// logWebhookError logs a failed webhook request. Webhooks and deliveries
+// that aren't there are the caller's mistake and are only warned about.
+func logWebhookError(ctx context.Context, err error, webhookID, msg string) {
+	event := log.Ctx(ctx).Error()
+	if errors.Is(err, core.ErrWebhookNotFound) || errors.Is(err, core.ErrWebhookDeliveryNotFound) {
+		event = log.Ctx(ctx).Warn()
+	}
+	event.Err(err).Str("webhook_id", webhookID).Msg(msg)
+}
+
+func eventTypes(names []string) []core.EventType {
+	events := make([]core.EventType, len(names))
+	for i, name := range names {
+		events[i] = core.EventType(name)
+	}
+	return events
+}
+
+func webhookResponse(webhook *core.Webhook) types.WebhookResponse {
+	events := make([]string, len(webhook.Events))
+	for i, event := range webhook.Events {
+		events[i] = string(event)
+	}
+	return types.WebhookResponse{
+		ID:           webhook.ID,
+		OrgID:        webhook.OrgID,
+		URL:          webhook.URL,
+		Events:       events,
+		Active:       webhook.Active,
+		FailureCount: webhook.FailureCount,
+		DisabledAt:   webhook.DisabledAt,
+		CreatedAt:    webhook.CreatedAt,
+		UpdatedAt:    webhook.UpdatedAt,
+	}
+}
+
+func webhookDeliveryResponse(delivery *core.WebhookDelivery) types.WebhookDeliveryResponse {
+	return types.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID,
+		EventType:      string(delivery.EventType),
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		ResponseBody:   delivery.ResponseBody,
+		LatencyMs:      delivery.Latency.Milliseconds(),
+		Error:          delivery.Error,
+		CreatedAt:      delivery.CreatedAt,
+		CompletedAt:    delivery.CompletedAt,
+	}
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// MockWebhookStore is a mock implementation of core.WebhookStore
+type MockWebhookStore struct {
+	core.WebhookStore
+	mock.Mock
+}
+
+func (m *MockWebhookStore) Create(ctx context.Context, webhook *core.Webhook) (*core.Webhook, error) {
+	args := m.Called(ctx, webhook)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookStore) Get(ctx context.Context, userID, id string) (*core.Webhook, error) {
+	args := m.Called(ctx, userID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookStore) Update(ctx context.Context, webhook *core.Webhook) (*core.Webhook, error) {
+	args := m.Called(ctx, webhook)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookStore) ListDeliveries(ctx context.Context, webhookID string, limit, offset int) ([]*core.WebhookDelivery, int, error) {
+	args := m.Called(ctx, webhookID, limit, offset)
+	return args.Get(0).([]*core.WebhookDelivery), args.Int(1), args.Error(2)
+}
+
+// MockWebhookRedeliverer is a mock implementation of WebhookRedeliverer
+type MockWebhookRedeliverer struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRedeliverer) Redeliver(ctx context.Context, userID, webhookID, deliveryID string) (*core.WebhookDelivery, error) {
+	args := m.Called(ctx, userID, webhookID, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*core.WebhookDelivery), args.Error(1)
+}
+
+// webhookRequest builds a request by alice carrying the given URL
+// parameters, alternating names and values
+func webhookRequest(method, target, body string, params ...string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rctx := chi.NewRouteContext()
+	for i := 0; i+1 < len(params); i += 2 {
+		rctx.URLParams.Add(params[i], params[i+1])
+	}
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, httpmiddleware.UserIDKey, "alice")
+	ctx = context.WithValue(ctx, httpmiddleware.UserEmailKey, "alice@example.com")
+	return req.WithContext(ctx)
+}
+
+func TestWebhookHandler_CreateWebhook(t *testing.T) {
+	// Arrange
+	webhooks := new(MockWebhookStore)
+	webhooks.On("Create", mock.Anything, mock.MatchedBy(func(w *core.Webhook) bool {
+		return w.UserID == "alice" && w.OwnerEmail == "alice@example.com" && w.OrgID == nil &&
+			strings.HasPrefix(w.Secret, "whsec_") && len(w.Secret) == 70 && w.Active &&
+			assert.ObjectsAreEqual([]core.EventType{core.EventProjectPublished}, w.Events)
+	})).Return(&core.Webhook{
+		ID: "w1", URL: "https://hooks.example.com/provemyself", Secret: "whsec_stored",
+		Events: []core.EventType{core.EventProjectPublished}, Active: true,
+	}, nil)
+	handler := NewWebhookHandler(webhooks, nil, httpmiddleware.NewValidator())
+	rr := newRecorder()
+
+	// Act
+	handler.CreateWebhook(rr, webhookRequest(http.MethodPost, "/api/v1/me/webhooks",
+		`{"url":"https://hooks.example.com/provemyself","events":["project.published"]}`))
+
+	// Assert
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var response types.WebhookResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "w1", response.ID)
+	assert.Equal(t, "whsec_stored", response.Secret, "the secret is returned on creation")
+	assert.Equal(t, []string{"project.published"}, response.Events)
+	webhooks.AssertExpectations(t)
+}
+
+func TestWebhookHandler_UpdateWebhook_KeepsTheSecret(t *testing.T) {
+	// Arrange
+	webhooks := new(MockWebhookStore)
+	webhooks.On("Get", mock.Anything, "alice", "w1").Return(&core.Webhook{
+		ID: "w1", UserID: "alice", URL: "https://hooks.example.com/old", Secret: "kept-secret-0123456789",
+		Events: []core.EventType{core.EventProjectCreated},
+	}, nil)
+	webhooks.On("Update", mock.Anything, mock.MatchedBy(func(w *core.Webhook) bool {
+		return w.Secret == "kept-secret-0123456789" && w.URL == "https://hooks.example.com/new" && !w.Active
+	})).Return(&core.Webhook{ID: "w1", URL: "https://hooks.example.com/new", Secret: "kept-secret-0123456789"}, nil)
+	handler := NewWebhookHandler(webhooks, nil, httpmiddleware.NewValidator())
+	rr := newRecorder()
+
+	// Act
+	handler.UpdateWebhook(rr, webhookRequest(http.MethodPut, "/api/v1/me/webhooks/w1",
+		`{"url":"https://hooks.example.com/new","events":["project.deleted"],"active":false}`, "webhookId", "w1"))
+
+	// Assert
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.NotContains(t, rr.Body.String(), "kept-secret", "secrets are only returned on creation")
+	webhooks.AssertExpectations(t)
+}
+
+func TestWebhookHandler_ListDeliveries(t *testing.T) {
+	// Arrange
+	status := 500
+	created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	webhooks := new(MockWebhookStore)
+	webhooks.On("Get", mock.Anything, "alice", "w1").Return(&core.Webhook{ID: "w1", UserID: "alice"}, nil)
+	webhooks.On("ListDeliveries", mock.Anything, "w1", 10, 5).Return([]*core.WebhookDelivery{{
+		ID: "d1", WebhookID: "w1", EventID: "e1", EventType: core.EventProjectCreated,
+		Status: core.WebhookDeliveryFailed, Attempts: 4, ResponseStatus: &status, ResponseBody: "oops",
+		Latency: 1500 * time.Millisecond, Error: "webhook endpoint responded 500", CreatedAt: created,
+	}}, 6, nil)
+	handler := NewWebhookHandler(webhooks, nil, httpmiddleware.NewValidator())
+	rr := newRecorder()
+
+	// Act
+	handler.ListDeliveries(rr, webhookRequest(http.MethodGet, "/api/v1/me/webhooks/w1/deliveries?limit=10&offset=5", "", "webhookId", "w1"))
+
+	// Assert
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var response types.WebhookDeliveryListResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 6, response.Total)
+	require.Len(t, response.Deliveries, 1)
+	assert.Equal(t, "failed", response.Deliveries[0].Status)
+	assert.Equal(t, int64(1500), response.Deliveries[0].LatencyMs)
+	assert.Equal(t, 500, *response.Deliveries[0].ResponseStatus)
+}
+
+func TestWebhookHandler_RedeliverWebhook(t *testing.T) {
+	// Arrange
+	dispatcher := new(MockWebhookRedeliverer)
+	dispatcher.On("Redeliver", mock.Anything, "alice", "w1", "d1").Return(&core.WebhookDelivery{
+		ID: "d2", WebhookID: "w1", EventID: "e1", EventType: core.EventProjectCreated, Status: core.WebhookDeliveryPending,
+	}, nil)
+	handler := NewWebhookHandler(new(MockWebhookStore), dispatcher, httpmiddleware.NewValidator())
+	rr := newRecorder()
+
+	// Act
+	handler.RedeliverWebhook(rr, webhookRequest(http.MethodPost, "/api/v1/me/webhooks/w1/deliveries/d1/redeliver", "",
+		"webhookId", "w1", "deliveryId", "d1"))
+
+	// Assert
+	require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+	var response types.WebhookDeliveryResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "d2", response.ID)
+	assert.Equal(t, "e1", response.EventID, "a redelivery carries the same event")
+	assert.Equal(t, "pending", response.Status)
+}
+
+func TestWebhookHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		setup          func(store *MockWebhookStore, dispatcher *MockWebhookRedeliverer)
+		serve          func(h *WebhookHandler, w http.ResponseWriter)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "unknown event type",
+			serve: func(h *WebhookHandler, w http.ResponseWriter) {
+				h.CreateWebhook(w, webhookRequest(http.MethodPost, "/api/v1/me/webhooks", `{"url":"https://hooks.example.com","events":["project.exploded"]}`))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   types.ErrorCodeValidationFailed,
+		},
+		{
+			name: "no events",
+			serve: func(h *WebhookHandler, w http.ResponseWriter) {
+				h.CreateWebhook(w, webhookRequest(http.MethodPost, "/api/v1/me/webhooks", `{"url":"https://hooks.example.com","events":[]}`))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   types.ErrorCodeValidationFailed,
+		},
+		{
+			name: "not an HTTP URL",
+			serve: func(h *WebhookHandler, w http.ResponseWriter) {
+				h.CreateWebhook(w, webhookRequest(http.MethodPost, "/api/v1/me/webhooks", `{"url":"ftp://hooks.example.com","events":["project.created"]}`))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   types.ErrorCodeValidationFailed,
+		},
+		{
+			name: "short secret",
+			serve: func(h *WebhookHandler, w http.ResponseWriter) {
+				h.CreateWebhook(w, webhookRequest(http.MethodPost, "/api/v1/me/webhooks", `{"url":"https://hooks.example.com","secret":"short","events":["project.created"]}`))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   types.ErrorCodeValidationFailed,
+		},
+		{
+			name: "another user's webhook",
+			setup: func(store *MockWebhookStore, dispatcher *MockWebhookRedeliverer) {
+				store.On("Get", mock.Anything, "alice", "w2").Return(nil, core.ErrWebhookNotFound)
+			},
+			serve: func(h *WebhookHandler, w http.ResponseWriter) {
+				h.ListDeliveries(w, webhookRequest(http.MethodGet, "/api/v1/me/webhooks/w2/deliveries", "", "webhookId", "w2"))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   types.ErrorCodeWebhookNotFound,
+		},
+		{
+			name: "unknown delivery",
+			setup: func(store *MockWebhookStore, dispatcher *MockWebhookRedeliverer) {
+				dispatcher.On("Redeliver", mock.Anything, "alice", "w1", "d9").Return(nil, core.ErrWebhookDeliveryNotFound)
+			},
+			serve: func(h *WebhookHandler, w http.ResponseWriter) {
+				h.RedeliverWebhook(w, webhookRequest(http.MethodPost, "/", "", "webhookId", "w1", "deliveryId", "d9"))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   types.ErrorCodeWebhookDeliveryNotFound,
+		},
+		{
+			name: "queue full",
+			setup: func(store *MockWebhookStore, dispatcher *MockWebhookRedeliverer) {
+				dispatcher.On("Redeliver", mock.Anything, "alice", "w1", "d1").Return(nil, core.ErrWebhookQueueFull)
+			},
+			serve: func(h *WebhookHandler, w http.ResponseWriter) {
+				h.RedeliverWebhook(w, webhookRequest(http.MethodPost, "/", "", "webhookId", "w1", "deliveryId", "d1"))
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   types.ErrorCodeWebhookQueueFull,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			webhooks := new(MockWebhookStore)
+			dispatcher := new(MockWebhookRedeliverer)
+			if tt.setup != nil {
+				tt.setup(webhooks, dispatcher)
+			}
+			handler := NewWebhookHandler(webhooks, dispatcher, httpmiddleware.NewValidator())
+			rr := newRecorder()
+
+			// Act
+			tt.serve(handler, rr)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/i18n"
+	"github.com/provemyself/backend/internal/types"
+	"github.com/provemyself/backend/internal/xapi"
+)
+
+// XAPIExporter backfills xAPI statements, satisfied by *xapi.Exporter
+type XAPIExporter interface {
+	Start(req xapi.ExportRequest) (xapi.ExportProgress, error)
+	Progress() xapi.ExportProgress
+	Write(ctx context.Context, w io.Writer, req xapi.ExportRequest) error
+}
+
+// XAPIHandler handles the xAPI endpoints under /api/v1/admin/xapi
+type XAPIHandler struct {
+	exporter XAPIExporter
+}
+
+// NewXAPIHandler creates a new xAPI handler
+func NewXAPIHandler(exporter XAPIExporter) *XAPIHandler {
+	return &XAPIHandler{exporter: exporter}
+}
+
+// Export handles POST /api/v1/admin/xapi/export
+// @Summary Export xAPI statements
+// @Description Backfills the xAPI statements of submitted attempts, previews left out: a completed statement with the score for each attempt, and an answered statement for each of its questions. Statement IDs are derived from the attempt and item IDs, so exporting an attempt again sends the same statements and the LRS keeps one copy. By default the export is sent to the configured LRS in the background, one export at a time, and the response reports its progress; poll GET /api/v1/admin/xapi/export for the rest. Progress is kept by the replica that took the request. With format=file the statements are downloaded instead, as newline-delimited JSON.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json,application/x-ndjson
+// @Param project_id query string false "Keep this project's attempts, all projects when unset" format(uuid)
+// @Param from query string false "Keep attempts started at or after this RFC 3339 time"
+// @Param to query string false "Keep attempts started before this RFC 3339 time"
+// @Param format query string false "Download the statements instead of sending them to the LRS" Enums(file)
+// @Success 200 {file} file "With format=file, one statement a line"
+// @Success 202 {object} types.XAPIExportResponse
+// @Failure 400 {object} types.ErrorResponse "validation_failed"
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 409 {object} types.ErrorResponse "job_running"
+// @Failure 503 {object} types.ErrorResponse "lrs_not_configured, scheduler_not_running"
+// @Router /api/v1/admin/xapi/export [post]
+func (h *XAPIHandler) Export(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	var fieldErrs []types.ValidationError
+	projectID := query.Get("project_id")
+	if projectID != "" {
+		if _, err := uuid.Parse(projectID); err != nil {
+			fieldErrs = append(fieldErrs, types.ValidationError{Field: "project_id", Tag: "uuid"})
+		}
+	}
+	format := query.Get("format")
+	if format != "" && format != "file" {
+		fieldErrs = append(fieldErrs, types.ValidationError{Field: "format", Tag: "oneof", Param: "file"})
+	}
+	if fieldErrs != nil {
+		for i := range fieldErrs {
+			fieldErrs[i].Message = i18n.Translate(i18n.DefaultLocale, "validation."+fieldErrs[i].Tag, respond.ValidationParams(fieldErrs[i]), fieldErrs[i].Tag)
+		}
+		respond.ValidationError(w, fieldErrs)
+		return
+	}
+	filter, ok := attemptFilter(w, r)
+	if !ok {
+		return
+	}
+	req := xapi.ExportRequest{ProjectID: projectID, Filter: filter}
+
+	if format == "file" {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": "xapi-statements.ndjson",
+		}))
+		tw := &trackingWriter{w: w}
+		if err := h.exporter.Write(ctx, tw, req); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("project_id", projectID).Msg("failed to export xAPI statements")
+			if !tw.written {
+				w.Header().Del("Content-Disposition")
+				respondDomainError(w, err)
+			}
+			// Past the first write the status is sent; the client sees a
+			// truncated file
+		}
+		return
+	}
+
+	progress, err := h.exporter.Start(req)
+	if err != nil {
+		respondDomainError(w, err)
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("project_id", projectID).
+		Str("user_id", httpmiddleware.GetUserID(ctx)).
+		Msg("xAPI export started")
+
+	respond.JSON(w, http.StatusAccepted, xapiExportResponse(progress))
+}
+
+// ExportStatus handles GET /api/v1/admin/xapi/export
+// @Summary Get xAPI export progress
+// @Description Returns the progress of the last xAPI export to the LRS started on the replica that served the request, idle if there was none since it started.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.XAPIExportResponse
+// @Failure 401 {object} types.ErrorResponse "missing_token, invalid_token_format, empty_token"
+// @Failure 403 {object} types.ErrorResponse "insufficient_permissions"
+// @Failure 504 {object} types.ErrorResponse "gateway_timeout"
+// @Router /api/v1/admin/xapi/export [get]
+func (h *XAPIHandler) ExportStatus(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, http.StatusOK, xapiExportResponse(h.exporter.Progress()))
+}
+
+func xapiExportResponse(progress xapi.ExportProgress) types.XAPIExportResponse {
+	return types.XAPIExportResponse{
+		State:      string(progress.State),
+		ProjectID:  progress.Request.ProjectID,
+		From:       progress.Request.Filter.From,
+		To:         progress.Request.Filter.To,
+		Attempts:   progress.Attempts,
+		Statements: progress.Statements,
+		StartedAt:  progress.StartedAt,
+		FinishedAt: progress.FinishedAt,
+		Error:      progress.Error,
+	}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/jobs"
+	"github.com/provemyself/backend/internal/types"
+	"github.com/provemyself/backend/internal/xapi"
+)
+
+// fakeXAPIExporter records the export asked for, writing body for a file
+// export and failing a started one with startErr
+type fakeXAPIExporter struct {
+	body     string
+	writeErr error
+	startErr error
+	progress xapi.ExportProgress
+
+	req *xapi.ExportRequest
+}
+
+func (e *fakeXAPIExporter) Start(req xapi.ExportRequest) (xapi.ExportProgress, error) {
+	if e.startErr != nil {
+		return xapi.ExportProgress{}, e.startErr
+	}
+	e.req = &req
+	return xapi.ExportProgress{State: xapi.ExportStatePending, Request: req}, nil
+}
+
+func (e *fakeXAPIExporter) Progress() xapi.ExportProgress {
+	return e.progress
+}
+
+func (e *fakeXAPIExporter) Write(ctx context.Context, w io.Writer, req xapi.ExportRequest) error {
+	e.req = &req
+	if e.writeErr != nil {
+		return e.writeErr
+	}
+	_, err := io.WriteString(w, e.body)
+	return err
+}
+
+func TestXAPIHandler_Export(t *testing.T) {
+	// Arrange
+	exporter := &fakeXAPIExporter{}
+	handler := NewXAPIHandler(exporter)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/xapi/export?project_id=8f6c2b0e-4a1d-4e57-9c3b-2f1a7d9e5b40&from=2026-01-01T00:00:00Z", nil)
+	rr := newRecorder()
+
+	// Act
+	handler.Export(rr, req)
+
+	// Assert
+	require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+	var response types.XAPIExportResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "pending", response.State)
+	assert.Equal(t, "8f6c2b0e-4a1d-4e57-9c3b-2f1a7d9e5b40", response.ProjectID)
+	require.NotNil(t, exporter.req)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), *exporter.req.Filter.From)
+	assert.Nil(t, exporter.req.Filter.To)
+}
+
+func TestXAPIHandler_Export_File(t *testing.T) {
+	// Arrange
+	exporter := &fakeXAPIExporter{body: "{\"id\":\"s1\"}\n"}
+	handler := NewXAPIHandler(exporter)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/xapi/export?format=file", nil)
+	rr := newRecorder()
+
+	// Act
+	handler.Export(rr, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=xapi-statements.ndjson", rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, exporter.body, rr.Body.String())
+	require.NotNil(t, exporter.req)
+	assert.Empty(t, exporter.req.ProjectID, "every project is exported")
+}
+
+func TestXAPIHandler_Export_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		exporter       *fakeXAPIExporter
+		expectedStatus int
+		expectedCode   string
+		expectedFields map[string]string
+	}{
+		{
+			name:           "invalid project ID and format",
+			query:          "?project_id=project-1&format=csv",
+			exporter:       &fakeXAPIExporter{},
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: map[string]string{"project_id": "uuid", "format": "oneof"},
+		},
+		{
+			name:           "empty window",
+			query:          "?from=2026-02-01T00:00:00Z&to=2026-01-01T00:00:00Z",
+			exporter:       &fakeXAPIExporter{},
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: map[string]string{"to": "gtfield"},
+		},
+		{
+			name:           "no LRS",
+			exporter:       &fakeXAPIExporter{startErr: xapi.ErrLRSNotConfigured},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   types.ErrorCodeLRSNotConfigured,
+		},
+		{
+			name:           "export running",
+			exporter:       &fakeXAPIExporter{startErr: jobs.ErrJobRunning},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   types.ErrorCodeJobRunning,
+		},
+		{
+			name:           "file export fails before writing",
+			query:          "?format=file",
+			exporter:       &fakeXAPIExporter{writeErr: errors.New("connection refused")},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   types.ErrorCodeInternalError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := NewXAPIHandler(tt.exporter)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/xapi/export"+tt.query, nil)
+			rr := newRecorder()
+
+			// Act
+			handler.Export(rr, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedFields != nil {
+				assert.Equal(t, tt.expectedFields, assertValidationErrors(t, rr.Body.Bytes()))
+				assert.Nil(t, tt.exporter.req, "nothing is exported")
+				return
+			}
+			assertErrorResponse(t, rr.Body.Bytes(), tt.expectedCode)
+			assert.Empty(t, rr.Header().Get("Content-Disposition"))
+		})
+	}
+}
+
+func TestXAPIHandler_ExportStatus(t *testing.T) {
+	// Arrange
+	startedAt := time.Date(2026, 3, 11, 10, 0, 0, 0, time.UTC)
+	exporter := &fakeXAPIExporter{progress: xapi.ExportProgress{
+		State:      xapi.ExportStateRunning,
+		Attempts:   120,
+		Statements: 600,
+		StartedAt:  &startedAt,
+	}}
+	handler := NewXAPIHandler(exporter)
+	rr := newRecorder()
+
+	// Act
+	handler.ExportStatus(rr, httptest.NewRequest(http.MethodGet, "/api/v1/admin/xapi/export", nil))
+
+	// Assert
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response types.XAPIExportResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, types.XAPIExportResponse{State: "running", Attempts: 120, Statements: 600, StartedAt: &startedAt}, response)
+}
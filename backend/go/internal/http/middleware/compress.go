@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultCompressionMinSize is the smallest response body worth compressing
+const DefaultCompressionMinSize = 1024
+
+// compressibleTypes lists the content types that benefit from compression.
+// Images, audio and video served by the asset proxy are already compressed.
+var compressibleTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/javascript",
+	"application/x-ndjson",
+	"application/xml",
+	"image/svg+xml",
+	"text/",
+}
+
+// Compress middleware gzip- or deflate-encodes responses according to the
+// request's Accept-Encoding. Bodies smaller than minSize, non-compressible
+// content types, responses that already carry a Content-Encoding, and
+// streaming (SSE/WebSocket) requests are passed through untouched.
+// Strong ETags are weakened since the encoded bytes differ from the
+// representation they were computed on.
+func Compress(level, minSize int) func(http.Handler) http.Handler {
+	gzipPool := &sync.Pool{New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, level)
+		return w
+	}}
+	flatePool := &sync.Pool{New: func() interface{} {
+		w, _ := flate.NewWriter(io.Discard, level)
+		return w
+	}}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || isStreamingRequest(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressResponseWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				minSize:        minSize,
+				gzipPool:       gzipPool,
+				flatePool:      flatePool,
+			}
+			defer cw.Close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header
+func negotiateEncoding(acceptEncoding string) string {
+	var deflateAccepted bool
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.ReplaceAll(params, " ", "") == "q=0" {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "gzip":
+			return "gzip"
+		case "deflate":
+			deflateAccepted = true
+		}
+	}
+	if deflateAccepted {
+		return "deflate"
+	}
+	return ""
+}
+
+// isStreamingRequest reports whether the request is for SSE or a WebSocket upgrade
+func isStreamingRequest(r *http.Request) bool {
+	return r.Header.Get("Upgrade") != "" ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+func isCompressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressResponseWriter buffers the start of a response until it knows
+// whether the body is large enough to compress
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding  string
+	minSize   int
+	gzipPool  *sync.Pool
+	flatePool *sync.Pool
+
+	status     int
+	buf        []byte
+	decided    bool
+	compressor io.WriteCloser
+}
+
+func (cw *compressResponseWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+func (cw *compressResponseWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	if !cw.decided {
+		// Skip buffering when the response can't be compressed anyway
+		if !cw.eligible() {
+			cw.start(false)
+		} else {
+			cw.buf = append(cw.buf, p...)
+			if len(cw.buf) < cw.minSize {
+				return len(p), nil
+			}
+			cw.start(true)
+			if err := cw.flushBuffer(); err != nil {
+				return 0, err
+			}
+			return len(p), nil
+		}
+	}
+
+	if cw.compressor != nil {
+		return cw.compressor.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// eligible reports whether headers allow compressing this response
+func (cw *compressResponseWriter) eligible() bool {
+	header := cw.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if cw.status < http.StatusOK || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	return contentType == "" || isCompressible(contentType)
+}
+
+// start commits the response headers, enabling compression if requested
+func (cw *compressResponseWriter) start(compress bool) {
+	cw.decided = true
+
+	if compress {
+		header := cw.Header()
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", http.DetectContentType(cw.buf))
+		}
+		if isCompressible(header.Get("Content-Type")) {
+			header.Set("Content-Encoding", cw.encoding)
+			header.Del("Content-Length")
+			if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+				header.Set("ETag", "W/"+etag)
+			}
+			cw.compressor = cw.newCompressor()
+		}
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+func (cw *compressResponseWriter) newCompressor() io.WriteCloser {
+	if cw.encoding == "gzip" {
+		gz := cw.gzipPool.Get().(*gzip.Writer)
+		gz.Reset(cw.ResponseWriter)
+		return gz
+	}
+	fl := cw.flatePool.Get().(*flate.Writer)
+	fl.Reset(cw.ResponseWriter)
+	return fl
+}
+
+func (cw *compressResponseWriter) flushBuffer() error {
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.compressor != nil {
+		_, err := cw.compressor.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// Flush sends any buffered data to the client
+func (cw *compressResponseWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		cw.start(false)
+		_ = cw.flushBuffer()
+	}
+	if flusher, ok := cw.compressor.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets WebSocket upgrades take over the connection
+func (cw *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("response writer does not support hijacking")
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close finishes the response, writing small bodies uncompressed
+func (cw *compressResponseWriter) Close() error {
+	if !cw.decided {
+		if cw.status == 0 {
+			// Handler wrote nothing
+			return nil
+		}
+		cw.start(false)
+		if err := cw.flushBuffer(); err != nil {
+			return err
+		}
+	}
+
+	if cw.compressor == nil {
+		return nil
+	}
+
+	err := cw.compressor.Close()
+	switch c := cw.compressor.(type) {
+	case *gzip.Writer:
+		cw.gzipPool.Put(c)
+	case *flate.Writer:
+		cw.flatePool.Put(c)
+	}
+	cw.compressor = nil
+	return err
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/http/respond"
+)
+
+type testItem struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+func largeItemList() []testItem {
+	items := make([]testItem, 200)
+	for i := range items {
+		items[i] = testItem{
+			ID:      fmt.Sprintf("item-%d", i),
+			Title:   "What is the capital of France?",
+			Content: `{"options":["Paris","London","Berlin","Madrid"],"correct":0}`,
+		}
+	}
+	return items
+}
+
+func TestCompress_LargeItemList(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		decode         func(io.Reader) (io.Reader, error)
+	}{
+		{
+			name:           "gzip",
+			acceptEncoding: "gzip, deflate, br",
+			decode:         func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		},
+		{
+			name:           "deflate",
+			acceptEncoding: "deflate",
+			decode:         func(r io.Reader) (io.Reader, error) { return flate.NewReader(r), nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			items := largeItemList()
+			uncompressed, err := json.Marshal(items)
+			require.NoError(t, err)
+
+			handler := Compress(gzip.DefaultCompression, DefaultCompressionMinSize)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("ETag", `"v1"`)
+					respond.JSON(w, http.StatusOK, items)
+				}),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/p/items", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rr := httptest.NewRecorder()
+
+			// Act
+			handler.ServeHTTP(rr, req)
+
+			// Assert
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tt.name, rr.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+			assert.Equal(t, `W/"v1"`, rr.Header().Get("ETag"))
+			assert.Less(t, rr.Body.Len(), len(uncompressed)/4)
+
+			reader, err := tt.decode(rr.Body)
+			require.NoError(t, err)
+
+			var decoded []testItem
+			require.NoError(t, json.NewDecoder(reader).Decode(&decoded))
+			assert.Equal(t, items, decoded)
+		})
+	}
+}
+
+func TestCompress_PassThrough(t *testing.T) {
+	largeText := strings.Repeat("a", 4096)
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		accept         string
+		contentType    string
+		body           string
+	}{
+		{"client does not accept encoding", "", "", "application/json", largeText},
+		{"body below threshold", "gzip", "", "application/json", `{"ok":true}`},
+		{"already compressed content type", "gzip", "", "image/png", largeText},
+		{"server-sent events", "gzip", "text/event-stream", "text/event-stream", largeText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := Compress(gzip.DefaultCompression, DefaultCompressionMinSize)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", tt.contentType)
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(tt.body))
+				}),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+
+			// Act
+			handler.ServeHTTP(rr, req)
+
+			// Assert
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Empty(t, rr.Header().Get("Content-Encoding"))
+			assert.Equal(t, tt.body, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// Deprecation flags an API version or route prefix slated for removal
+type Deprecation struct {
+	// Prefix is matched against the request path, e.g. "/api/v1" or
+	// "/api/v1/projects"; the longest matching entry wins
+	Prefix string
+	// Since is when the deprecation took effect, sent as the Deprecation
+	// header (RFC 9745)
+	Since time.Time
+	// Sunset is when the prefix stops being served, sent as the Sunset
+	// header (RFC 8594). Zero means no date has been set yet.
+	Sunset time.Time
+	// Successor replaces Prefix to build the successor-version link, e.g.
+	// "/api/v2". Empty means there is no replacement.
+	Successor string
+}
+
+// Deprecate adds Deprecation, Sunset and successor-version Link headers to
+// requests matching an entry of table. Once an entry's sunset has passed its
+// requests fail with 410 and a hint naming the successor route.
+func Deprecate(table []Deprecation) func(http.Handler) http.Handler {
+	return deprecate(table, time.Now)
+}
+
+func deprecate(table []Deprecation, now func() time.Time) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			entry, ok := matchDeprecation(table, r.URL.Path)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(entry.Since.Unix(), 10))
+			if !entry.Sunset.IsZero() {
+				w.Header().Set("Sunset", entry.Sunset.UTC().Format(http.TimeFormat))
+			}
+
+			var successor string
+			if entry.Successor != "" {
+				successor = entry.Successor + strings.TrimPrefix(r.URL.Path, entry.Prefix)
+				w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+			}
+
+			if !entry.Sunset.IsZero() && !now().Before(entry.Sunset) {
+				var hint string
+				if successor != "" {
+					hint = fmt.Sprintf("Use %s %s instead", r.Method, successor)
+				}
+				respond.Error(w, http.StatusGone, types.ErrorCodeVersionSunset,
+					fmt.Sprintf("%s was removed on %s", entry.Prefix, entry.Sunset.UTC().Format("2006-01-02")), hint)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// matchDeprecation returns the longest entry whose prefix covers path
+func matchDeprecation(table []Deprecation, path string) (Deprecation, bool) {
+	var match Deprecation
+	found := false
+	for _, entry := range table {
+		if path != entry.Prefix && !strings.HasPrefix(path, strings.TrimSuffix(entry.Prefix, "/")+"/") {
+			continue
+		}
+		if !found || len(entry.Prefix) > len(match.Prefix) {
+			match, found = entry, true
+		}
+	}
+	return match, found
+}
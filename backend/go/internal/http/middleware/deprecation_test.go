@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+func TestDeprecate(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	table := []Deprecation{
+		{Prefix: "/api/v1", Since: since, Successor: "/api/v2"},
+		{Prefix: "/api/v1/legacy", Since: since, Sunset: sunset},
+	}
+
+	tests := []struct {
+		name              string
+		path              string
+		now               time.Time
+		expectedStatus    int
+		expectDeprecation bool
+		expectedSunset    string
+		expectedLink      string
+	}{
+		{"other version untouched", "/api/v2/projects", since, http.StatusOK, false, "", ""},
+		{"prefix must end at a segment", "/api/v10/projects", since, http.StatusOK, false, "", ""},
+		{"deprecated version links its successor", "/api/v1/projects", since, http.StatusOK, true, "", `</api/v2/projects>; rel="successor-version"`},
+		{"route before sunset", "/api/v1/legacy/export", sunset.Add(-time.Hour), http.StatusOK, true, "Wed, 01 Jul 2026 00:00:00 GMT", ""},
+		{"route after sunset is gone", "/api/v1/legacy/export", sunset, http.StatusGone, true, "Wed, 01 Jul 2026 00:00:00 GMT", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := deprecate(table, func() time.Time { return tt.now })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectDeprecation {
+				assert.Equal(t, "@1767225600", w.Header().Get("Deprecation"))
+			} else {
+				assert.Empty(t, w.Header().Get("Deprecation"))
+			}
+			assert.Equal(t, tt.expectedSunset, w.Header().Get("Sunset"))
+			assert.Equal(t, tt.expectedLink, w.Header().Get("Link"))
+		})
+	}
+}
+
+func TestDeprecate_SunsetResponseHintsSuccessor(t *testing.T) {
+	// Arrange
+	sunset := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	table := []Deprecation{{Prefix: "/api/v1", Since: sunset.AddDate(0, -6, 0), Sunset: sunset, Successor: "/api/v2"}}
+	handler := deprecate(table, func() time.Time { return sunset.Add(time.Hour) })(http.NotFoundHandler())
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/projects", nil))
+
+	// Assert
+	require.Equal(t, http.StatusGone, w.Code)
+	var body types.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, types.ErrorCodeVersionSunset, body.Error.Code)
+	require.NotNil(t, body.Error.Details)
+	assert.Equal(t, "Use GET /api/v2/projects instead", *body.Error.Details)
+}
@@ -1,65 +1,72 @@
+// Package middleware provides HTTP middleware components for the ProveMySelf API.
+// This package includes error handling, validation, logging, and health monitoring middleware.
 package middleware
 
 import (
-	"encoding/json"
 	"net/http"
+	"runtime/debug"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/zerolog/log"
 
+	"github.com/provemyself/backend/internal/http/respond"
 	"github.com/provemyself/backend/internal/types"
 )
 
-// ErrorHandler middleware handles panics and errors
-func ErrorHandler(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				log.Error().
-					Interface("error", err).
-					Str("method", r.Method).
-					Str("path", r.URL.Path).
-					Msg("panic recovered")
+// PanicObserver is notified of every recovered panic, e.g. to feed a metric
+type PanicObserver interface {
+	ObservePanic(r *http.Request)
+}
 
-				SendJSONError(w, http.StatusInternalServerError, "internal_error", "An unexpected error occurred")
-			}
-		}()
+// ErrorHandler provides standardized error handling middleware
+type ErrorHandler struct {
+	panics PanicObserver
+}
 
-		next.ServeHTTP(w, r)
-	})
+// NewErrorHandler creates a new error handler middleware. panics may be nil.
+func NewErrorHandler(panics PanicObserver) *ErrorHandler {
+	return &ErrorHandler{panics: panics}
 }
 
-// SendJSONError sends a standardized JSON error response
-func SendJSONError(w http.ResponseWriter, statusCode int, code, message string, details ...string) {
-	var detailsPtr *string
-	if len(details) > 0 {
-		detailsPtr = &details[0]
-	}
+// Recovery turns a handler panic into a 500 internal_error response carrying
+// the request ID, and logs the panic with its stack through the request's
+// logger. If the handler had already started its response, an error body
+// would corrupt it, so the connection is aborted instead and the client sees
+// a failed request rather than a truncated success. Panics with
+// http.ErrAbortHandler are passed on untouched, as net/http expects.
+func (e *ErrorHandler) Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
-	errorResponse := types.ErrorResponse{
-		Error: types.ErrorDetail{
-			Code:    code,
-			Message: message,
-			Details: detailsPtr,
-		},
-	}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
+			if e.panics != nil {
+				e.panics.ObservePanic(r)
+			}
 
-	if err := json.NewEncoder(w).Encode(errorResponse); err != nil {
-		log.Error().Err(err).Msg("failed to encode error response")
-		// Fallback to plain text error
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-	}
-}
+			// Status is only set once the handler has sent headers
+			headersSent := ww.Status() != 0
+			log.Ctx(r.Context()).Error().
+				Interface("panic", p).
+				Str("url", r.URL.String()).
+				Str("remote_addr", r.RemoteAddr).
+				Bool("headers_sent", headersSent).
+				Bytes("stack", debug.Stack()).
+				Msg("panic recovered")
 
-// SendJSONResponse sends a standardized JSON success response
-func SendJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
+			if headersSent {
+				panic(http.ErrAbortHandler)
+			}
+			respond.Error(w, http.StatusInternalServerError, types.ErrorCodeInternalError, "An unexpected error occurred")
+		}()
 
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Error().Err(err).Msg("failed to encode JSON response")
-		SendJSONError(w, http.StatusInternalServerError, "encoding_error", "Failed to encode response")
-	}
-}
\ No newline at end of file
+		next.ServeHTTP(ww, r)
+	})
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+type countingObserver struct {
+	panics int
+}
+
+func (o *countingObserver) ObservePanic(r *http.Request) { o.panics++ }
+
+// serveRecovered runs handler behind Recovery with a request ID already set
+// on the response, as the RequestID middleware does in production
+func serveRecovered(handler http.HandlerFunc, observer PanicObserver) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	w.Header().Set(respond.RequestIDHeader, "req-123")
+	NewErrorHandler(observer).Recovery(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/projects", nil))
+	return w
+}
+
+func TestRecovery_PanicBeforeWrite(t *testing.T) {
+	// Arrange
+	observer := &countingObserver{}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}
+
+	// Act
+	w := serveRecovered(handler, observer)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var body types.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, types.ErrorCodeInternalError, body.Error.Code)
+	assert.Equal(t, "req-123", body.Error.RequestID)
+	assert.NotContains(t, w.Body.String(), "boom")
+	assert.Equal(t, 1, observer.panics)
+}
+
+func TestRecovery_PanicAfterPartialWrite(t *testing.T) {
+	// Arrange
+	observer := &countingObserver{}
+	w := httptest.NewRecorder()
+	handler := NewErrorHandler(observer).Recovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"items":[`))
+		panic("boom")
+	}))
+
+	// Act & Assert
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/projects", nil))
+	})
+	// The partial body is left as it was rather than followed by an error
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"items":[`, w.Body.String())
+	assert.Equal(t, 1, observer.panics)
+}
+
+func TestRecovery_ErrAbortHandlerIsRepanicked(t *testing.T) {
+	// Arrange
+	observer := &countingObserver{}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}
+
+	// Act & Assert
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		serveRecovered(handler, observer)
+	})
+	assert.Equal(t, 0, observer.panics)
+}
+
+func TestRecovery_NoPanic(t *testing.T) {
+	// Arrange
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		respond.JSON(w, http.StatusCreated, map[string]string{"id": "1"})
+	}
+
+	// Act
+	w := serveRecovered(handler, nil)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"id":"1"}`, w.Body.String())
+}
@@ -2,26 +2,56 @@ package middleware
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/http/respond"
 )
 
 // HealthMiddleware provides health and metrics endpoints
 type HealthMiddleware struct {
-	startTime time.Time
+	startTime     time.Time
+	readiness     *Readiness
+	schemaVersion SchemaVersionFunc
+	shuttingDown  atomic.Bool
 }
 
-// NewHealthMiddleware creates a new health middleware
-func NewHealthMiddleware() *HealthMiddleware {
+// SchemaVersionFunc reports the applied database migration version and
+// whether the last migration failed halfway, e.g. store.Database.SchemaVersion
+type SchemaVersionFunc func(ctx context.Context) (version uint, dirty bool, err error)
+
+// NewHealthMiddleware creates a new health middleware. The readiness probe
+// reports not_ready until every startup phase tracked by readiness has
+// completed; readiness may be nil.
+func NewHealthMiddleware(readiness *Readiness) *HealthMiddleware {
+	if readiness == nil {
+		readiness = NewReadiness()
+	}
 	return &HealthMiddleware{
 		startTime: time.Now(),
+		readiness: readiness,
 	}
 }
 
+// ReportSchemaVersion makes the readiness probe include the database schema
+// version. A dirty schema, left by a failed migration, is not ready.
+func (h *HealthMiddleware) ReportSchemaVersion(fn SchemaVersionFunc) {
+	h.schemaVersion = fn
+}
+
+// MarkShuttingDown makes the readiness probe report not_ready so load
+// balancers stop routing new traffic while in-flight requests drain
+func (h *HealthMiddleware) MarkShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
 // SystemMetrics represents system health metrics
 type SystemMetrics struct {
 	Uptime          string         `json:"uptime"`
@@ -72,7 +102,7 @@ func (h *HealthMiddleware) Metrics(w http.ResponseWriter, r *http.Request) {
 	metrics := SystemMetrics{
 		Uptime:        uptime.String(),
 		UptimeSeconds: uptime.Seconds(),
-		Timestamp:     time.Now(),
+		Timestamp:     time.Now().UTC(),
 		Version:       "0.1.0", // This should come from build info
 		GoVersion:     runtime.Version(),
 		NumGoroutines: runtime.NumGoroutine(),
@@ -100,34 +130,42 @@ func (h *HealthMiddleware) Metrics(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-
-	if err := json.NewEncoder(w).Encode(metrics); err != nil {
-		log.Error().Err(err).Msg("failed to encode metrics response")
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-	}
+	respond.JSON(w, http.StatusOK, metrics)
 }
 
 // LivenessProbe provides a simple liveness probe endpoint
 func (h *HealthMiddleware) LivenessProbe(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"status":    "alive",
-		"timestamp": time.Now(),
+		"timestamp": time.Now().UTC(),
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Error().Err(err).Msg("failed to encode liveness response")
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-	}
+	respond.JSON(w, http.StatusOK, response)
 }
 
 // ReadinessProbe provides a readiness probe that can include dependency checks
 func (h *HealthMiddleware) ReadinessProbe(dependencies []HealthChecker) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if h.shuttingDown.Load() {
+			respond.JSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+				"status":    "not_ready",
+				"reason":    "shutting_down",
+				"timestamp": time.Now().UTC(),
+			})
+			return
+		}
+
+		phases := h.readiness.Phases()
+		if !h.readiness.Ready() {
+			respond.JSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+				"status":    "not_ready",
+				"reason":    "starting",
+				"phases":    phases,
+				"timestamp": time.Now().UTC(),
+			})
+			return
+		}
+
 		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 		defer cancel()
 
@@ -136,15 +174,37 @@ func (h *HealthMiddleware) ReadinessProbe(dependencies []HealthChecker) http.Han
 
 		// Check all dependencies
 		for _, dep := range dependencies {
-			if err := dep.HealthCheck(ctx); err != nil {
+			err := dep.HealthCheck(ctx)
+			switch {
+			case err == nil:
+				checks[dep.Name()] = "healthy"
+			case isDegraded(err):
+				// Still able to serve traffic, e.g. with an open circuit
+				// breaker failing one dependency's calls fast
+				checks[dep.Name()] = "degraded"
+			default:
 				checks[dep.Name()] = "unhealthy"
 				allHealthy = false
 				log.Warn().
 					Err(err).
 					Str("dependency", dep.Name()).
 					Msg("dependency health check failed")
-			} else {
-				checks[dep.Name()] = "healthy"
+			}
+		}
+
+		var schema map[string]interface{}
+		if h.schemaVersion != nil {
+			version, dirty, err := h.schemaVersion(ctx)
+			switch {
+			case err != nil:
+				allHealthy = false
+				log.Warn().Err(err).Msg("schema version check failed")
+			case dirty:
+				allHealthy = false
+				log.Warn().Uint("schema_version", version).Msg("database schema is dirty")
+				fallthrough
+			default:
+				schema = map[string]interface{}{"version": version, "dirty": dirty}
 			}
 		}
 
@@ -158,17 +218,15 @@ func (h *HealthMiddleware) ReadinessProbe(dependencies []HealthChecker) http.Han
 
 		response := map[string]interface{}{
 			"status":    status,
-			"timestamp": time.Now(),
+			"timestamp": time.Now().UTC(),
 			"checks":    checks,
+			"phases":    phases,
 		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(statusCode)
-
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.Error().Err(err).Msg("failed to encode readiness response")
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		if schema != nil {
+			response["schema"] = schema
 		}
+
+		respond.JSON(w, statusCode, response)
 	}
 }
 
@@ -178,6 +236,14 @@ type HealthChecker interface {
 	HealthCheck(ctx context.Context) error
 }
 
+// isDegraded reports whether a health check error marks itself (with a
+// Degraded() bool method) as degrading the service rather than making it
+// unready
+func isDegraded(err error) bool {
+	var degraded interface{ Degraded() bool }
+	return errors.As(err, &degraded) && degraded.Degraded()
+}
+
 // DatabaseHealthChecker implements health checking for databases
 type DatabaseHealthChecker struct {
 	name string
@@ -224,4 +290,58 @@ func (s *StorageHealthChecker) Name() string {
 // HealthCheck performs the health check
 func (s *StorageHealthChecker) HealthCheck(ctx context.Context) error {
 	return s.check(ctx)
-}
\ No newline at end of file
+}
+// HTTPHealthChecker checks that an HTTP dependency (such as the LRS) is
+// reachable. Any response below 500 counts as reachable, since the probe
+// is usually unauthenticated.
+type HTTPHealthChecker struct {
+	name   string
+	client *http.Client
+
+	mu  sync.RWMutex
+	url string
+}
+
+// NewHTTPHealthChecker creates a new HTTP reachability checker
+func NewHTTPHealthChecker(name, url string) *HTTPHealthChecker {
+	return &HTTPHealthChecker{
+		name:   name,
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Name returns the name of the health checker
+func (h *HTTPHealthChecker) Name() string {
+	return h.name
+}
+
+// SetURL changes the URL checked, after a configuration reload
+func (h *HTTPHealthChecker) SetURL(url string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.url = url
+}
+
+// HealthCheck performs the health check
+func (h *HTTPHealthChecker) HealthCheck(ctx context.Context) error {
+	h.mu.RLock()
+	url := h.url
+	h.mu.RUnlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return fmt.Errorf("invalid health check URL: %w", err)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s unreachable: %w", h.name, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%s returned status %d", h.name, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/respond"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the client's idempotency key
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader marks responses served from a stored snapshot
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// DefaultIdempotencyTTL is how long stored responses are honored
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	// idempotencyReservationTTL is how long a key stays reserved by a
+	// request that neither completes nor is released, e.g. when its replica
+	// dies mid-request
+	idempotencyReservationTTL = time.Minute
+
+	// maxIdempotencyKeyLength bounds the header value to the storage column size
+	maxIdempotencyKeyLength = 255
+)
+
+// Idempotency middleware replays stored responses for requests carrying an Idempotency-Key header.
+// Routes opt in by mounting it with r.With(...). Requests without the header pass straight through.
+//
+// The key is scoped to the method, path and authenticated user, and reserved before the request
+// is handled. An exact replay (same key, same body) returns the original status, headers and body;
+// while the original is still in flight it returns 409 idempotency_in_progress with Retry-After.
+// The same key with a different body returns 409 idempotency_conflict. 5xx responses are not
+// stored so the client can retry.
+func Idempotency(store core.IdempotencyStore, ttl time.Duration) func(http.Handler) http.Handler {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				respond.Error(w, http.StatusBadRequest, "invalid_idempotency_key",
+					"Idempotency-Key must be at most 255 characters")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				SendBodyReadError(w, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			endpoint := idempotencyEndpoint(r)
+			requestHash := hashRequestBody(body)
+
+			now := time.Now().UTC()
+			err = store.Reserve(ctx, &core.IdempotencyRecord{
+				Key:         key,
+				Endpoint:    endpoint,
+				RequestHash: requestHash,
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(idempotencyReservationTTL),
+			})
+			switch {
+			case err == nil:
+				// First time we've seen this key, execute the request below
+			case errors.Is(err, core.ErrIdempotencyKeyExists):
+				answerReservedKey(w, r, store, key, endpoint, requestHash)
+				return
+			default:
+				log.Ctx(ctx).Error().Err(err).Str("endpoint", endpoint).Msg("failed to reserve idempotency key")
+				respond.Error(w, http.StatusInternalServerError, "internal_error", "Failed to process idempotency key")
+				return
+			}
+
+			// The reservation outlives a cancelled request, and is released
+			// if the handler panics
+			storeCtx := context.WithoutCancel(ctx)
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				if err := store.Release(storeCtx, key, endpoint); err != nil {
+					log.Ctx(ctx).Error().Err(err).Str("endpoint", endpoint).Msg("failed to release idempotency key")
+				}
+			}()
+
+			// Capture the response while streaming it to the client
+			before := w.Header().Clone()
+			var captured bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&captured)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status >= http.StatusInternalServerError {
+				return
+			}
+
+			completedAt := time.Now().UTC()
+			err = store.Complete(storeCtx, &core.IdempotencyRecord{
+				Key:         key,
+				Endpoint:    endpoint,
+				RequestHash: requestHash,
+				StatusCode:  status,
+				Header:      handlerHeaders(before, ww.Header()),
+				Body:        captured.Bytes(),
+				CreatedAt:   now,
+				ExpiresAt:   completedAt.Add(ttl),
+			})
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Str("endpoint", endpoint).Msg("failed to store idempotency record")
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// answerReservedKey answers a request whose key another request already
+// reserved: with that request's response, or a conflict if it's still in
+// flight or had another body
+func answerReservedKey(w http.ResponseWriter, r *http.Request, store core.IdempotencyStore, key, endpoint, requestHash string) {
+	ctx := r.Context()
+
+	record, err := store.Get(ctx, key, endpoint)
+	if errors.Is(err, core.ErrIdempotencyKeyNotFound) {
+		// The reservation was released or expired since; the client can
+		// send the request again
+		w.Header().Set("Retry-After", "1")
+		respond.Error(w, http.StatusConflict, "idempotency_in_progress",
+			"A request with this Idempotency-Key is still being processed")
+		return
+	}
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("endpoint", endpoint).Msg("failed to look up idempotency key")
+		respond.Error(w, http.StatusInternalServerError, "internal_error", "Failed to process idempotency key")
+		return
+	}
+
+	if record.RequestHash != requestHash {
+		log.Ctx(ctx).Warn().
+			Str("endpoint", endpoint).
+			Msg("idempotency key reused with a different request body")
+
+		respond.Error(w, http.StatusConflict, "idempotency_conflict",
+			"Idempotency-Key was already used with a different request body")
+		return
+	}
+
+	if record.Pending() {
+		w.Header().Set("Retry-After", "1")
+		respond.Error(w, http.StatusConflict, "idempotency_in_progress",
+			"A request with this Idempotency-Key is still being processed")
+		return
+	}
+
+	replayIdempotentResponse(w, record)
+}
+
+// handlerHeaders returns the headers of after that the handler set or
+// changed, leaving out those set before it ran, such as the request ID
+func handlerHeaders(before, after http.Header) http.Header {
+	header := http.Header{}
+	for name, values := range after {
+		if previous, ok := before[name]; ok && slices.Equal(previous, values) {
+			continue
+		}
+		if name == "Date" || name == "Content-Length" {
+			continue
+		}
+		header[name] = slices.Clone(values)
+	}
+	return header
+}
+
+// replayIdempotentResponse writes a stored response snapshot
+func replayIdempotentResponse(w http.ResponseWriter, record *core.IdempotencyRecord) {
+	for name, values := range record.Header {
+		w.Header()[name] = slices.Clone(values)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+
+	if _, err := w.Write(record.Body); err != nil {
+		log.Error().Err(err).Msg("failed to write replayed response")
+	}
+}
+
+// idempotencyEndpoint scopes a key to the method, path and caller
+func idempotencyEndpoint(r *http.Request) string {
+	endpoint := r.Method + " " + r.URL.Path
+	if userID := GetUserID(r.Context()); userID != "" {
+		endpoint += " " + userID
+	}
+	return endpoint
+}
+
+// hashRequestBody returns the hex-encoded SHA-256 of a request body
+func hashRequestBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
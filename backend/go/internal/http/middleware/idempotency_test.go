@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+)
+
+func TestIdempotency(t *testing.T) {
+	tests := []struct {
+		name            string
+		firstBody       string
+		secondBody      string
+		handlerStatus   int
+		expectedCalls   int
+		expectedStatus  int
+		expectReplayed  bool
+		expectErrorCode string
+	}{
+		{
+			name:           "exact replay returns stored response",
+			firstBody:      `{"title":"Quiz"}`,
+			secondBody:     `{"title":"Quiz"}`,
+			handlerStatus:  http.StatusCreated,
+			expectedCalls:  1,
+			expectedStatus: http.StatusCreated,
+			expectReplayed: true,
+		},
+		{
+			name:            "same key with different body conflicts",
+			firstBody:       `{"title":"Quiz"}`,
+			secondBody:      `{"title":"Other"}`,
+			handlerStatus:   http.StatusCreated,
+			expectedCalls:   1,
+			expectedStatus:  http.StatusConflict,
+			expectErrorCode: "idempotency_conflict",
+		},
+		{
+			name:           "server errors are not stored",
+			firstBody:      `{"title":"Quiz"}`,
+			secondBody:     `{"title":"Quiz"}`,
+			handlerStatus:  http.StatusInternalServerError,
+			expectedCalls:  2,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			calls := 0
+			handler := Idempotency(store.NewMemoryIdempotencyStore(), time.Hour)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls++
+					w.Header().Set("Content-Type", "application/json")
+					w.Header().Set("Location", "/api/v1/projects/p-1")
+					w.Header().Set("ETag", `"1"`)
+					w.WriteHeader(tt.handlerStatus)
+					w.Write([]byte(`{"call":` + string(rune('0'+calls)) + `}`))
+				}),
+			)
+
+			send := func(body string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/projects", strings.NewReader(body))
+				req.Header.Set(IdempotencyKeyHeader, "key-123")
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, req)
+				return rr
+			}
+
+			// Act
+			first := send(tt.firstBody)
+			second := send(tt.secondBody)
+
+			// Assert
+			assert.Equal(t, tt.handlerStatus, first.Code)
+			assert.Equal(t, tt.expectedCalls, calls)
+			assert.Equal(t, tt.expectedStatus, second.Code)
+
+			if tt.expectReplayed {
+				assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+				assert.Equal(t, first.Body.String(), second.Body.String())
+				assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+				assert.Equal(t, "/api/v1/projects/p-1", second.Header().Get("Location"))
+				assert.Equal(t, `"1"`, second.Header().Get("ETag"))
+			}
+
+			if tt.expectErrorCode != "" {
+				var errorResponse types.ErrorResponse
+				require.NoError(t, json.Unmarshal(second.Body.Bytes(), &errorResponse))
+				assert.Equal(t, tt.expectErrorCode, errorResponse.Error.Code)
+			}
+		})
+	}
+}
+
+func TestIdempotency_WithoutHeader(t *testing.T) {
+	// Arrange
+	calls := 0
+	handler := Idempotency(store.NewMemoryIdempotencyStore(), time.Hour)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+
+	// Act
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/projects", strings.NewReader(`{}`))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Assert
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_KeyScopedToPath(t *testing.T) {
+	// Arrange
+	calls := 0
+	handler := Idempotency(store.NewMemoryIdempotencyStore(), time.Hour)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+
+	// Act
+	for _, path := range []string{"/api/v1/projects/a/items", "/api/v1/projects/b/items"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "shared-key")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Assert
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_RetryWhileInFlight(t *testing.T) {
+	// Arrange
+	var calls atomic.Int32
+	entered := make(chan struct{})
+	finish := make(chan struct{})
+	handler := Idempotency(store.NewMemoryIdempotencyStore(), time.Hour)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			close(entered)
+			<-finish
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/projects", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-123")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send() }()
+	<-entered
+
+	// Act
+	retry := send()
+	close(finish)
+	first := <-done
+	replay := send()
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, retry.Code)
+	assert.Equal(t, "1", retry.Header().Get("Retry-After"))
+	var errorResponse types.ErrorResponse
+	require.NoError(t, json.Unmarshal(retry.Body.Bytes(), &errorResponse))
+	assert.Equal(t, "idempotency_in_progress", errorResponse.Error.Code)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotency_PanicReleasesTheKey(t *testing.T) {
+	// Arrange
+	calls := 0
+	handler := Idempotency(store.NewMemoryIdempotencyStore(), time.Hour)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				panic("boom")
+			}
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/projects", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-123")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	require.Panics(t, func() { send() })
+
+	// Act
+	retry := send()
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Empty(t, retry.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_ReplayKeepsHeadersSetBeforeTheHandler(t *testing.T) {
+	// Arrange
+	calls := 0
+	inner := Idempotency(store.NewMemoryIdempotencyStore(), time.Hour)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Location", "/api/v1/projects/p-1")
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+	send := func(requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/projects", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-123")
+		rr := httptest.NewRecorder()
+		rr.Header().Set("X-Request-ID", requestID)
+		inner.ServeHTTP(rr, req)
+		return rr
+	}
+	send("request-1")
+
+	// Act
+	replay := send("request-2")
+
+	// Assert
+	assert.Equal(t, "true", replay.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, "/api/v1/projects/p-1", replay.Header().Get("Location"))
+	assert.Equal(t, "request-2", replay.Header().Get("X-Request-ID"))
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/i18n"
+)
+
+// Localize middleware negotiates the response locale from the request's
+// Accept-Language header and announces it in X-Content-Language. Error
+// responses written through respond read the header back to translate their
+// messages; error codes are the same in every locale.
+func Localize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(respond.ContentLanguageHeader, i18n.Negotiate(r.Header.Get("Accept-Language")))
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+func TestLocalize_TranslatesErrorMessages(t *testing.T) {
+	tests := []struct {
+		name             string
+		acceptLanguage   string
+		expectedLanguage string
+		expectedMessage  string
+	}{
+		{"Spanish request", "es-ES,es;q=0.9,en;q=0.5", "es", "Proyecto no encontrado"},
+		{"Hebrew request", "he", "he", "הפרויקט לא נמצא"},
+		{"English request keeps the handler's message", "en-US", "en", "Project 42 not found"},
+		{"no preference", "", "en", "Project 42 not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := Localize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				respond.Error(w, http.StatusNotFound, types.ErrorCodeProjectNotFound, "Project 42 not found")
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/42", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedLanguage, w.Header().Get(respond.ContentLanguageHeader))
+			var response types.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, types.ErrorCodeProjectNotFound, response.Error.Code)
+			assert.Equal(t, tt.expectedMessage, response.Error.Message)
+		})
+	}
+}
+
+func TestLocalize_TranslatesValidationMessages(t *testing.T) {
+	// Arrange
+	type request struct {
+		Title string `json:"title" validate:"required,min=3"`
+		Type  string `json:"type" validate:"oneof=single multi"`
+	}
+	validate := NewValidator()
+	handler := Localize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respond.ValidationError(w, ValidationErrors(validate.Struct(request{Title: "ab", Type: "other"}), "items[0]"))
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/42/items/bulk", nil)
+	req.Header.Set("Accept-Language", "es")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(w, req)
+
+	// Assert
+	var response types.ValidationErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, types.ErrorCodeValidationFailed, response.Error.Code)
+	assert.Equal(t, "La validación de la solicitud falló", response.Error.Message)
+	assert.Equal(t, []types.ValidationError{
+		{Field: "items[0].title", Tag: "min", Param: "3", Message: "El campo 'title' debe tener al menos 3 caracteres"},
+		{Field: "items[0].type", Tag: "oneof", Param: "single multi", Message: "El campo 'type' debe ser uno de: single multi"},
+	}, response.Error.Errors)
+}
+
+func TestValidationErrors_EnglishMessages(t *testing.T) {
+	// Arrange
+	type request struct {
+		Title string   `json:"title" validate:"required"`
+		Tags  []string `json:"tags" validate:"dive,project_tag"`
+	}
+
+	// Act
+	fieldErrors := ValidationErrors(NewValidator().Struct(request{Tags: []string{"bad tag"}}), "")
+
+	// Assert
+	require.Len(t, fieldErrors, 2)
+	assert.Equal(t, "Field 'title' is required", fieldErrors[0].Message)
+	assert.Equal(t, "Field 'tags[0]' failed validation rule 'project_tag'", fieldErrors[1].Message)
+}
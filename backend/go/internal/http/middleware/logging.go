@@ -3,233 +3,239 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/logging"
 )
 
-// ContextKey represents keys used in request context
-type ContextKey string
+// contextKey is a custom type for context keys to avoid collisions
+type contextKey string
 
-const (
-	// RequestIDKey is the context key for request ID
-	RequestIDKey ContextKey = "request_id"
-	// LoggerKey is the context key for the request logger
-	LoggerKey ContextKey = "logger"
-)
+// headerUserIDKey is the context key for the user ID a request claims in its
+// X-User-ID header. It is only logged: the authenticated user is under
+// UserIDKey.
+const headerUserIDKey contextKey = "header_user_id"
+
+// LoggingMiddleware provides enhanced request logging
+type LoggingMiddleware struct {
+	logger        zerolog.Logger
+	requestLogger zerolog.Logger
+}
 
-// RequestID middleware adds a unique request ID to each request
-func RequestID(next http.Handler) http.Handler {
+// NewLoggingMiddleware creates a new logging middleware. Handlers get logger
+// through log.Ctx; the per-request "request started/completed" lines keep
+// only every requestLogSampling-th info line (0 or 1 keeps all of them).
+func NewLoggingMiddleware(logger zerolog.Logger, requestLogSampling uint32) *LoggingMiddleware {
+	return &LoggingMiddleware{
+		logger:        logger,
+		requestLogger: logging.Sampled(logger, requestLogSampling),
+	}
+}
+
+// RequestLogger logs HTTP requests with detailed context
+func (l *LoggingMiddleware) RequestLogger(next http.Handler) http.Handler {
+	return middleware.RequestLogger(&StructuredLogger{logger: l.requestLogger})(next)
+}
+
+// RequestID adds a unique request ID to each request and attaches a logger
+// carrying it (plus method and path) to the request context, so log.Ctx(ctx)
+// in handlers and stores produces correlated lines
+func (l *LoggingMiddleware) RequestID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Try to get request ID from header first (for distributed systems)
 		requestID := r.Header.Get("X-Request-ID")
 		if requestID == "" {
+			// Generate a new UUID if not provided
 			requestID = uuid.New().String()
 		}
 
-		// Set response header
+		// Add to response header
 		w.Header().Set("X-Request-ID", requestID)
 
-		// Add to context
-		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
+		// Add to request context
+		ctx := logging.WithRequestID(r.Context(), requestID)
+		ctx = l.logger.With().
+			Str("request_id", requestID).
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Logger().
+			WithContext(ctx)
+
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// StructuredLogger middleware provides structured logging for HTTP requests
-func StructuredLogger(logger zerolog.Logger) func(next http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			start := time.Now()
-
-			// Get request ID from context
-			requestID := GetRequestID(r.Context())
-
-			// Create request-specific logger
-			reqLogger := logger.With().
-				Str("request_id", requestID).
-				Str("method", r.Method).
-				Str("path", r.URL.Path).
-				Str("remote_addr", r.RemoteAddr).
-				Str("user_agent", r.UserAgent()).
-				Logger()
-
-			// Add logger to context
-			ctx := context.WithValue(r.Context(), LoggerKey, &reqLogger)
-
-			// Wrap response writer to capture status code and size
-			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
-
-			// Log request start
-			reqLogger.Info().
-				Str("query", r.URL.RawQuery).
-				Int64("content_length", r.ContentLength).
-				Msg("request started")
-
-			// Process request
-			next.ServeHTTP(ww, r.WithContext(ctx))
-
-			// Calculate duration
-			duration := time.Since(start)
-
-			// Log request completion
-			reqLogger.Info().
-				Int("status", ww.Status()).
-				Int("bytes_written", ww.BytesWritten()).
-				Dur("duration", duration).
-				Msg("request completed")
-
-			// Log slow requests as warnings
-			if duration > 5*time.Second {
-				reqLogger.Warn().
-					Dur("duration", duration).
-					Msg("slow request detected")
-			}
-
-			// Log client errors as warnings
-			if ww.Status() >= 400 && ww.Status() < 500 {
-				reqLogger.Warn().
-					Int("status", ww.Status()).
-					Msg("client error")
-			}
+// UserContext adds user information to the request context (for authenticated requests)
+func (l *LoggingMiddleware) UserContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// This would be populated by authentication middleware
+		// For now, we'll skip if no auth headers present
+		userID := r.Header.Get("X-User-ID")
+		if userID != "" {
+			ctx := context.WithValue(r.Context(), headerUserIDKey, userID)
+			ctx = zerolog.Ctx(ctx).With().Str("user_id", userID).Logger().WithContext(ctx)
+			r = r.WithContext(ctx)
+		}
 
-			// Log server errors as errors
-			if ww.Status() >= 500 {
-				reqLogger.Error().
-					Int("status", ww.Status()).
-					Msg("server error")
-			}
-		})
-	}
+		next.ServeHTTP(w, r)
+	})
 }
 
-// GetRequestID returns the request ID from context
-func GetRequestID(ctx context.Context) string {
-	if requestID, ok := ctx.Value(RequestIDKey).(string); ok {
-		return requestID
-	}
-	return ""
+// StructuredLogger implements chi's LogFormatter interface with structured logging
+type StructuredLogger struct {
+	logger zerolog.Logger
 }
 
-// GetLogger returns the request logger from context
-func GetLogger(ctx context.Context) *zerolog.Logger {
-	if logger, ok := ctx.Value(LoggerKey).(*zerolog.Logger); ok {
-		return logger
+// NewLogEntry creates a new log entry for a request
+func (l *StructuredLogger) NewLogEntry(r *http.Request) middleware.LogEntry {
+	entry := &StructuredLoggerEntry{logger: l.logger}
+	
+	// Extract request context values
+	requestID := logging.GetRequestID(r.Context())
+	userID, _ := r.Context().Value(headerUserIDKey).(string)
+
+	// Create log event with request context
+	logEvent := l.logger.Info().
+		Str("method", r.Method).
+		Str("url", r.URL.String()).
+		Str("remote_addr", getRemoteAddr(r)).
+		Str("user_agent", r.UserAgent()).
+		Str("request_id", requestID).
+		Str("proto", r.Proto)
+
+	// Add user ID if available
+	if userID != "" {
+		logEvent = logEvent.Str("user_id", userID)
 	}
-	return &log.Logger
-}
 
-// LogError logs an error with context information
-func LogError(ctx context.Context, err error, message string) {
-	logger := GetLogger(ctx)
-	logger.Error().
-		Err(err).
-		Str("request_id", GetRequestID(ctx)).
-		Msg(message)
-}
+	// Add trace ID if available (for distributed tracing)
+	if traceID := logging.GetTraceID(r.Context()); traceID != "" {
+		logEvent = logEvent.Str("trace_id", traceID)
+	}
 
-// LogWarn logs a warning with context information
-func LogWarn(ctx context.Context, message string) {
-	logger := GetLogger(ctx)
-	logger.Warn().
-		Str("request_id", GetRequestID(ctx)).
-		Msg(message)
-}
+	// Log request headers for debugging (excluding sensitive ones)
+	if debugEnabled(l.logger) {
+		headers := make(map[string]string)
+		for name, values := range r.Header {
+			if !isSensitiveHeader(name) {
+				headers[name] = strings.Join(values, ", ")
+			}
+		}
+		if len(headers) > 0 {
+			logEvent = logEvent.Interface("headers", headers)
+		}
+	}
 
-// LogInfo logs an info message with context information
-func LogInfo(ctx context.Context, message string) {
-	logger := GetLogger(ctx)
-	logger.Info().
-		Str("request_id", GetRequestID(ctx)).
-		Msg(message)
+	logEvent.Msg("request started")
+	entry.logger = l.logger
+	
+	return entry
 }
 
-// LogAudit logs an audit event
-func LogAudit(ctx context.Context, action, resource string, details map[string]interface{}) {
-	logger := GetLogger(ctx)
-	event := logger.Info().
-		Str("request_id", GetRequestID(ctx)).
-		Str("audit_action", action).
-		Str("audit_resource", resource)
-
-	// Add details
-	for key, value := range details {
-		event = event.Interface(key, value)
-	}
-
-	event.Msg("audit event")
+// StructuredLoggerEntry represents a log entry for a single request
+type StructuredLoggerEntry struct {
+	logger    zerolog.Logger
+	startTime time.Time
 }
 
-// Security middleware logs security-related events
-func SecurityLogger(logger zerolog.Logger) func(next http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Log suspicious patterns
-			if len(r.URL.Path) > 1000 {
-				logger.Warn().
-					Str("request_id", GetRequestID(r.Context())).
-					Str("remote_addr", r.RemoteAddr).
-					Str("path", r.URL.Path).
-					Msg("unusually long path detected")
+// Write logs the response for a request
+func (l *StructuredLoggerEntry) Write(status, bytes int, header http.Header, elapsed time.Duration, extra interface{}) {
+	logEvent := l.logger.Info().
+		Int("status", status).
+		Int("bytes", bytes).
+		Dur("elapsed", elapsed)
+
+	// Add response headers for debugging
+	if debugEnabled(l.logger) {
+		responseHeaders := make(map[string]string)
+		for name, values := range header {
+			if !isSensitiveHeader(name) {
+				responseHeaders[name] = strings.Join(values, ", ")
 			}
+		}
+		if len(responseHeaders) > 0 {
+			logEvent = logEvent.Interface("response_headers", responseHeaders)
+		}
+	}
 
-			// Log potential injection attempts
-			query := r.URL.RawQuery
-			suspiciousPatterns := []string{"<script", "javascript:", "SELECT ", "UNION ", "DROP ", "INSERT "}
-			for _, pattern := range suspiciousPatterns {
-				if contains(query, pattern) || contains(r.URL.Path, pattern) {
-					logger.Warn().
-						Str("request_id", GetRequestID(r.Context())).
-						Str("remote_addr", r.RemoteAddr).
-						Str("path", r.URL.Path).
-						Str("query", query).
-						Str("pattern", pattern).
-						Msg("potential injection attempt detected")
-					break
-				}
-			}
+	// Add extra context if provided
+	if extra != nil {
+		logEvent = logEvent.Interface("extra", extra)
+	}
 
-			next.ServeHTTP(w, r)
-		})
+	// Determine log level based on status code
+	switch {
+	case status >= 500:
+		logEvent = l.logger.Error().
+			Int("status", status).
+			Int("bytes", bytes).
+			Dur("elapsed", elapsed)
+	case status >= 400:
+		logEvent = l.logger.Warn().
+			Int("status", status).
+			Int("bytes", bytes).
+			Dur("elapsed", elapsed)
 	}
+
+	logEvent.Msg("request completed")
 }
 
-// contains checks if a string contains a substring (case-insensitive)
-func contains(s, substr string) bool {
-	// Simple case-insensitive contains check
-	s = toLower(s)
-	substr = toLower(substr)
-	return len(s) >= len(substr) && indexOf(s, substr) >= 0
+// Panic logs panic information
+func (l *StructuredLoggerEntry) Panic(v interface{}, stack []byte) {
+	l.logger.Error().
+		Interface("panic", v).
+		Bytes("stack", stack).
+		Msg("request panic")
 }
 
-// toLower converts string to lowercase
-func toLower(s string) string {
-	result := make([]rune, len(s))
-	for i, r := range s {
-		if r >= 'A' && r <= 'Z' {
-			result[i] = r + 32
-		} else {
-			result[i] = r
+// Helper functions
+
+// getRemoteAddr returns the real client IP address
+func getRemoteAddr(r *http.Request) string {
+	// Check for X-Forwarded-For header (load balancer/proxy)
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		// Take the first IP if multiple are present
+		if ips := strings.Split(xff, ","); len(ips) > 0 {
+			return strings.TrimSpace(ips[0])
 		}
 	}
-	return string(result)
+	
+	// Check for X-Real-IP header
+	if xri := r.Header.Get("X-Real-IP"); xri != "" {
+		return xri
+	}
+	
+	// Fall back to RemoteAddr
+	return r.RemoteAddr
 }
 
-// indexOf finds the index of a substring
-func indexOf(s, substr string) int {
-	if len(substr) == 0 {
-		return 0
+// isSensitiveHeader checks if a header contains sensitive information
+func isSensitiveHeader(name string) bool {
+	sensitiveHeaders := []string{
+		"authorization",
+		"cookie",
+		"x-api-key",
+		"x-auth-token",
+		"x-access-token",
 	}
-	if len(substr) > len(s) {
-		return -1
-	}
-
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return i
+	
+	lowerName := strings.ToLower(name)
+	for _, sensitive := range sensitiveHeaders {
+		if lowerName == sensitive {
+			return true
 		}
 	}
-	return -1
-}
\ No newline at end of file
+	
+	return false
+}
+
+// debugEnabled reports whether debug output is enabled for logger, taking
+// the runtime-adjustable global level into account
+func debugEnabled(logger zerolog.Logger) bool {
+	return zerolog.GlobalLevel() <= zerolog.DebugLevel && logger.GetLevel() <= zerolog.DebugLevel
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserContext_HeaderIsOnlyLogged(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logging := NewLoggingMiddleware(zerolog.New(&buf), 0)
+	var authenticatedUser string
+	handler := logging.RequestID(logging.UserContext(logging.RequestLogger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticatedUser = GetUserID(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projects", nil)
+	req.Header.Set("X-User-ID", "mallory")
+
+	// Act
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert
+	assert.Empty(t, authenticatedUser, "the header doesn't authenticate anyone")
+	assert.Contains(t, buf.String(), `"user_id":"mallory"`)
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// defaultMaintenanceMessage is sent when neither the switch nor the
+// maintenance_message setting has a message
+const defaultMaintenanceMessage = "The service is undergoing maintenance, please try again later"
+
+// MaintenanceConfig contains maintenance settings from configuration
+type MaintenanceConfig struct {
+	// Forced turns maintenance on regardless of the stored switch, with
+	// Message and AllowReads
+	Forced     bool
+	AllowReads bool
+	// Message is also shown when the stored switch has no message; the
+	// maintenance_message runtime setting replaces it (see SetMessage)
+	Message string
+	// RetryAfter is sent to rejected clients
+	RetryAfter time.Duration
+}
+
+// Maintenance rejects requests with 503 maintenance while the maintenance
+// switch is on. The switch lives in a core.MaintenanceStore shared by every
+// replica; each replica polls it (see Run) and serves from the last state
+// read, so a change takes effect everywhere within one poll interval.
+type Maintenance struct {
+	store core.MaintenanceStore
+	cfg   MaintenanceConfig
+
+	mu   sync.RWMutex
+	mode core.MaintenanceMode
+	// message replaces an empty switch message, starting as cfg.Message
+	message string
+}
+
+// NewMaintenance creates a maintenance switch backed by store. It starts
+// disabled (unless forced) until the first Refresh.
+func NewMaintenance(store core.MaintenanceStore, cfg MaintenanceConfig) *Maintenance {
+	return &Maintenance{store: store, cfg: cfg, message: cfg.Message}
+}
+
+// Mode returns the switch in effect
+func (m *Maintenance) Mode() core.MaintenanceMode {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.cfg.Forced {
+		return core.MaintenanceMode{
+			Enabled:    true,
+			Message:    m.message,
+			AllowReads: m.cfg.AllowReads,
+		}
+	}
+	return m.mode
+}
+
+// SetMessage changes the message shown when maintenance was turned on
+// without one, including when it is forced by configuration
+func (m *Maintenance) SetMessage(message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.message = message
+}
+
+// fallbackMessage returns the message for a switch that has none
+func (m *Maintenance) fallbackMessage() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.message != "" {
+		return m.message
+	}
+	return defaultMaintenanceMessage
+}
+
+// Forced reports whether configuration keeps maintenance on regardless of
+// the stored switch
+func (m *Maintenance) Forced() bool {
+	return m.cfg.Forced
+}
+
+// Set stores a new switch and applies it to this replica immediately
+func (m *Maintenance) Set(ctx context.Context, mode core.MaintenanceMode) error {
+	if err := m.store.Set(ctx, mode); err != nil {
+		return err
+	}
+
+	if err := m.Refresh(ctx); err != nil {
+		// Stored, so the next poll picks it up; apply it meanwhile
+		m.apply(mode)
+	}
+	return nil
+}
+
+// Refresh reads the switch from the store. Every replica runs it on an
+// interval as a background job; a failed read keeps the last known state.
+func (m *Maintenance) Refresh(ctx context.Context) error {
+	mode, err := m.store.Get(ctx)
+	if err != nil {
+		return err
+	}
+	m.apply(mode)
+	return nil
+}
+
+func (m *Maintenance) apply(mode core.MaintenanceMode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if mode.Enabled != m.mode.Enabled {
+		log.Warn().
+			Bool("enabled", mode.Enabled).
+			Bool("allow_reads", mode.AllowReads).
+			Str("updated_by", mode.UpdatedBy).
+			Msg("maintenance mode changed")
+	}
+	m.mode = mode
+}
+
+// Middleware rejects requests while maintenance is on. Mount it on the
+// routes it guards only: health probes and admin endpoints, including the
+// maintenance toggle itself, must stay reachable.
+func (m *Maintenance) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mode := m.Mode()
+		if !mode.Enabled || (mode.AllowReads && isReadMethod(r.Method)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		message := mode.Message
+		if message == "" {
+			message = m.fallbackMessage()
+		}
+
+		if m.cfg.RetryAfter > 0 {
+			seconds := int(math.Ceil(m.cfg.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		}
+		respond.Error(w, http.StatusServiceUnavailable, types.ErrorCodeMaintenance, message)
+	})
+}
+
+// isReadMethod reports whether method is safe (RFC 9110)
+func isReadMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
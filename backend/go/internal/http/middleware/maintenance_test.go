@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+)
+
+func serveThrough(m *Maintenance, method string) *httptest.ResponseRecorder {
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(method, "/api/v1/projects", nil))
+	return w
+}
+
+func TestMaintenance_Middleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           core.MaintenanceMode
+		method         string
+		expectedStatus int
+	}{
+		{"off lets writes through", core.MaintenanceMode{}, http.MethodPost, http.StatusOK},
+		{"on rejects writes", core.MaintenanceMode{Enabled: true}, http.MethodPost, http.StatusServiceUnavailable},
+		{"on rejects reads", core.MaintenanceMode{Enabled: true}, http.MethodGet, http.StatusServiceUnavailable},
+		{"read-allowed mode lets reads through", core.MaintenanceMode{Enabled: true, AllowReads: true}, http.MethodGet, http.StatusOK},
+		{"read-allowed mode rejects writes", core.MaintenanceMode{Enabled: true, AllowReads: true}, http.MethodPut, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			m := NewMaintenance(store.NewMemoryMaintenanceStore(), MaintenanceConfig{RetryAfter: 90 * time.Second})
+			require.NoError(t, m.Set(context.Background(), tt.mode))
+
+			// Act
+			w := serveThrough(m, tt.method)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusServiceUnavailable {
+				assert.Equal(t, "90", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestMaintenance_TogglesOnAndOff(t *testing.T) {
+	// Arrange
+	m := NewMaintenance(store.NewMemoryMaintenanceStore(), MaintenanceConfig{})
+	ctx := context.Background()
+
+	// Act & Assert
+	require.NoError(t, m.Set(ctx, core.MaintenanceMode{Enabled: true, Message: "Upgrading the database until 14:00 UTC"}))
+	w := serveThrough(m, http.MethodPost)
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var body types.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, types.ErrorCodeMaintenance, body.Error.Code)
+	assert.Equal(t, "Upgrading the database until 14:00 UTC", body.Error.Message)
+
+	require.NoError(t, m.Set(ctx, core.MaintenanceMode{Enabled: false}))
+	assert.Equal(t, http.StatusOK, serveThrough(m, http.MethodPost).Code)
+}
+
+func TestMaintenance_ForcedByConfig(t *testing.T) {
+	// Arrange
+	m := NewMaintenance(store.NewMemoryMaintenanceStore(), MaintenanceConfig{Forced: true, AllowReads: true})
+
+	// Act
+	require.NoError(t, m.Set(context.Background(), core.MaintenanceMode{Enabled: false}))
+
+	// Assert
+	assert.True(t, m.Mode().Enabled)
+	assert.Equal(t, http.StatusServiceUnavailable, serveThrough(m, http.MethodDelete).Code)
+	assert.Equal(t, http.StatusOK, serveThrough(m, http.MethodGet).Code)
+}
+
+func TestMaintenance_RefreshPicksUpOtherReplicasChanges(t *testing.T) {
+	// Arrange
+	shared := store.NewMemoryMaintenanceStore()
+	replicaA := NewMaintenance(shared, MaintenanceConfig{})
+	replicaB := NewMaintenance(shared, MaintenanceConfig{})
+	require.NoError(t, replicaA.Set(context.Background(), core.MaintenanceMode{Enabled: true}))
+	require.False(t, replicaB.Mode().Enabled)
+
+	// Act
+	err := replicaB.Refresh(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, replicaB.Mode().Enabled)
+	assert.Equal(t, http.StatusServiceUnavailable, serveThrough(replicaB, http.MethodPost).Code)
+}
+
+func TestMaintenance_SetMessageFillsEmptySwitchMessage(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	m := NewMaintenance(store.NewMemoryMaintenanceStore(), MaintenanceConfig{Message: "Configured message"})
+	require.NoError(t, m.Set(ctx, core.MaintenanceMode{Enabled: true}))
+	m.SetMessage("Back at 14:00 UTC")
+
+	// Act
+	w := serveThrough(m, http.MethodGet)
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var body types.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Back at 14:00 UTC", body.Error.Message)
+}
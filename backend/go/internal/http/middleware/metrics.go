@@ -2,26 +2,43 @@ package middleware
 
 import (
 	"net/http"
-	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/metrics"
 )
 
-// MetricsCollector collects HTTP metrics
+// RouteStats holds aggregated request statistics for one method and route pattern
+type RouteStats struct {
+	Count       int64         `json:"count"`
+	ErrorCount  int64         `json:"error_count"`
+	TotalTime   time.Duration `json:"total_duration"`
+	MinDuration time.Duration `json:"min_duration"`
+	MaxDuration time.Duration `json:"max_duration"`
+}
+
+// AvgDuration returns the mean request duration
+func (s RouteStats) AvgDuration() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.TotalTime / time.Duration(s.Count)
+}
+
+// MetricsCollector collects per-route HTTP metrics.
+// It is safe for concurrent use.
 type MetricsCollector struct {
-	requestDurations map[string]time.Duration
-	requestCounts    map[string]int
-	errorCounts      map[string]int
+	mu     sync.Mutex
+	routes map[string]*RouteStats
 }
 
 // NewMetricsCollector creates a new metrics collector
 func NewMetricsCollector() *MetricsCollector {
 	return &MetricsCollector{
-		requestDurations: make(map[string]time.Duration),
-		requestCounts:    make(map[string]int),
-		errorCounts:      make(map[string]int),
+		routes: make(map[string]*RouteStats),
 	}
 }
 
@@ -36,44 +53,68 @@ func (mc *MetricsCollector) Metrics(next http.Handler) http.Handler {
 		// Process request
 		next.ServeHTTP(ww, r)
 
-		// Calculate metrics
-		duration := time.Since(start)
-		status := ww.Status()
-		method := r.Method
-		path := r.URL.Path
-
-		// Create metric key
-		key := method + " " + path
-
-		// Update metrics
-		mc.requestCounts[key]++
-		mc.requestDurations[key] = duration
-
-		// Count errors
-		if status >= 400 {
-			errorKey := key + " " + strconv.Itoa(status)
-			mc.errorCounts[errorKey]++
-		}
+		// Key by route pattern so /projects/{projectId} stays a single entry
+		key := r.Method + " " + metrics.RoutePattern(r)
+		stats := mc.record(key, time.Since(start), ww.Status() >= 400)
 
 		// Log performance metrics periodically
-		if mc.requestCounts[key]%100 == 0 {
+		if stats.Count%100 == 0 {
 			log.Info().
-				Str("method", method).
-				Str("path", path).
-				Int("count", mc.requestCounts[key]).
-				Dur("avg_duration", mc.requestDurations[key]).
+				Str("route", key).
+				Int64("count", stats.Count).
+				Int64("errors", stats.ErrorCount).
+				Dur("avg_duration", stats.AvgDuration()).
+				Dur("min_duration", stats.MinDuration).
+				Dur("max_duration", stats.MaxDuration).
 				Msg("performance metrics")
 		}
 	})
 }
 
-// GetMetrics returns current metrics
-func (mc *MetricsCollector) GetMetrics() map[string]interface{} {
-	return map[string]interface{}{
-		"request_counts":    mc.requestCounts,
-		"request_durations": mc.requestDurations,
-		"error_counts":      mc.errorCounts,
+// record adds one observation and returns a copy of the updated stats
+func (mc *MetricsCollector) record(key string, duration time.Duration, failed bool) RouteStats {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	stats, ok := mc.routes[key]
+	if !ok {
+		stats = &RouteStats{MinDuration: duration, MaxDuration: duration}
+		mc.routes[key] = stats
+	}
+
+	stats.Count++
+	stats.TotalTime += duration
+	if duration < stats.MinDuration {
+		stats.MinDuration = duration
+	}
+	if duration > stats.MaxDuration {
+		stats.MaxDuration = duration
+	}
+	if failed {
+		stats.ErrorCount++
+	}
+
+	return *stats
+}
+
+// GetMetrics returns a consistent snapshot of the per-route stats
+func (mc *MetricsCollector) GetMetrics() map[string]RouteStats {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	snapshot := make(map[string]RouteStats, len(mc.routes))
+	for key, stats := range mc.routes {
+		snapshot[key] = *stats
 	}
+	return snapshot
+}
+
+// Reset clears all collected metrics
+func (mc *MetricsCollector) Reset() {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.routes = make(map[string]*RouteStats)
 }
 
 // HealthMetrics tracks health check metrics
@@ -86,14 +127,14 @@ type HealthMetrics struct {
 
 var (
 	healthMetrics = &HealthMetrics{
-		LastCheck: time.Now(),
+		LastCheck: time.Now().UTC(),
 	}
 	startTime = time.Now()
 )
 
 // UpdateHealthMetrics updates health check metrics
 func UpdateHealthMetrics(success bool) {
-	healthMetrics.LastCheck = time.Now()
+	healthMetrics.LastCheck = time.Now().UTC()
 	healthMetrics.CheckCount++
 	healthMetrics.Uptime = time.Since(startTime).String()
 	
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetricsRouter(mc *MetricsCollector) http.Handler {
+	r := chi.NewRouter()
+	r.Use(mc.Metrics)
+	r.Get("/projects/{projectId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	r.Post("/projects", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	return r
+}
+
+func TestMetricsCollector_ConcurrentRequests(t *testing.T) {
+	// Arrange
+	const goroutines = 50
+	const requestsPerGoroutine = 20
+
+	mc := NewMetricsCollector()
+	router := newMetricsRouter(mc)
+
+	// Act
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < requestsPerGoroutine; i++ {
+				path := fmt.Sprintf("/projects/%d-%d", g, i)
+				router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+				router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/projects", nil))
+				_ = mc.GetMetrics()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// Assert
+	snapshot := mc.GetMetrics()
+	require.Len(t, snapshot, 2)
+
+	get := snapshot["GET /projects/{projectId}"]
+	assert.Equal(t, int64(goroutines*requestsPerGoroutine), get.Count)
+	assert.Zero(t, get.ErrorCount)
+	assert.LessOrEqual(t, get.MinDuration, get.AvgDuration())
+	assert.GreaterOrEqual(t, get.MaxDuration, get.AvgDuration())
+
+	post := snapshot["POST /projects"]
+	assert.Equal(t, int64(goroutines*requestsPerGoroutine), post.Count)
+	assert.Equal(t, post.Count, post.ErrorCount)
+}
+
+func TestMetricsCollector_SnapshotIsCopy(t *testing.T) {
+	// Arrange
+	mc := NewMetricsCollector()
+	router := newMetricsRouter(mc)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/projects/a", nil))
+
+	// Act
+	snapshot := mc.GetMetrics()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/projects/b", nil))
+
+	// Assert
+	assert.Equal(t, int64(1), snapshot["GET /projects/{projectId}"].Count)
+	assert.Equal(t, int64(2), mc.GetMetrics()["GET /projects/{projectId}"].Count)
+}
+
+func TestMetricsCollector_Reset(t *testing.T) {
+	// Arrange
+	mc := NewMetricsCollector()
+	router := newMetricsRouter(mc)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/projects/a", nil))
+
+	// Act
+	mc.Reset()
+
+	// Assert
+	assert.Empty(t, mc.GetMetrics())
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/respond"
+)
+
+// OrgIDHeader switches a member of several organizations to another one
+// than their token's
+const OrgIDHeader = "X-Org-ID"
+
+// MembershipChecker looks up a user's membership of an organization.
+// Implemented by core.OrganizationStore.
+type MembershipChecker interface {
+	GetMembership(ctx context.Context, orgID, userID string) (*core.Membership, error)
+}
+
+// OrgScope scopes the request to an organization, so stores only read and
+// write its data. The organization is the X-Org-ID header, checked against
+// the user's memberships, or else the one carried by the user's token.
+// Requests with neither stay unscoped. Must run after authentication.
+func OrgScope(memberships MembershipChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			orgID := GetUserOrgID(ctx)
+
+			if requested := r.Header.Get(OrgIDHeader); requested != "" && requested != orgID {
+				userID := GetUserID(ctx)
+				if userID == "" {
+					respond.Error(w, http.StatusUnauthorized, "authentication_required", "Authentication required")
+					return
+				}
+
+				if _, err := memberships.GetMembership(ctx, requested, userID); err != nil {
+					if errors.Is(err, core.ErrMembershipNotFound) {
+						respond.Error(w, http.StatusForbidden, "org_access_denied",
+							"You are not a member of this organization")
+						return
+					}
+					log.Ctx(ctx).Error().Err(err).Str("org_id", requested).Msg("failed to check organization membership")
+					respond.Error(w, http.StatusInternalServerError, "internal_error", "Failed to check organization membership")
+					return
+				}
+				orgID = requested
+			}
+
+			if orgID != "" {
+				ctx = core.WithOrgID(ctx, orgID)
+				ctx = zerolog.Ctx(ctx).With().Str("org_id", orgID).Logger().WithContext(ctx)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
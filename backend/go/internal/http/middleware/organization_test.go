@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+)
+
+func TestOrgScope(t *testing.T) {
+	// Arrange
+	orgs := store.NewMemoryOrganizationStore()
+	member, err := orgs.Create(context.Background(), "Engineering", nil)
+	require.NoError(t, err)
+	other, err := orgs.Create(context.Background(), "Sales", nil)
+	require.NoError(t, err)
+	_, err = orgs.PutMember(context.Background(), member.ID, "user-1", core.MembershipRoleMember)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		user           *User
+		header         string
+		expectedStatus int
+		expectedOrgID  string
+	}{
+		{"anonymous request stays unscoped", nil, "", http.StatusOK, ""},
+		{"token organization scopes the request", &User{ID: "user-1", OrgID: other.ID}, "", http.StatusOK, other.ID},
+		{"header switches to a member organization", &User{ID: "user-1", OrgID: other.ID}, member.ID, http.StatusOK, member.ID},
+		{"header naming the token organization needs no membership", &User{ID: "user-2", OrgID: other.ID}, other.ID, http.StatusOK, other.ID},
+		{"header of a foreign organization is forbidden", &User{ID: "user-1"}, other.ID, http.StatusForbidden, ""},
+		{"header without authentication is rejected", nil, member.ID, http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var orgID string
+			var scope core.AccessScope
+			handler := OrgScope(orgs)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				orgID = core.OrgIDFromContext(r.Context())
+				scope = core.AccessScopeFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects", nil)
+			if tt.user != nil {
+				req = req.WithContext(withUser(req.Context(), tt.user))
+			}
+			if tt.header != "" {
+				req.Header.Set(OrgIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedOrgID, orgID)
+			if tt.user != nil && tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.user.ID, scope.UserID, "the stores act for the user")
+			}
+			if tt.expectedStatus == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), "org_access_denied")
+			}
+		})
+	}
+}
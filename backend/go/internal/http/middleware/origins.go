@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Origins is the list of browser origins allowed to call the API, which a
+// configuration reload replaces (see Set). It is safe for concurrent use.
+type Origins struct {
+	mu      sync.RWMutex
+	allowed map[string]bool
+}
+
+// NewOrigins creates an origin list from origins such as
+// "https://app.example.com"
+func NewOrigins(origins []string) *Origins {
+	o := &Origins{}
+	o.Set(origins)
+	return o
+}
+
+// Set replaces the allowed origins. Blank entries are ignored.
+func (o *Origins) Set(origins []string) {
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			allowed[origin] = true
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.allowed = allowed
+}
+
+// Allowed reports whether origin is allowed
+func (o *Origins) Allowed(origin string) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.allowed[origin]
+}
+
+// AllowOriginFunc is Allowed in the form cors.Options takes
+func (o *Origins) AllowOriginFunc(r *http.Request, origin string) bool {
+	return o.Allowed(origin)
+}
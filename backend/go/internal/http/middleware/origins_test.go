@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrigins_Set(t *testing.T) {
+	// Arrange
+	origins := NewOrigins([]string{"http://localhost:3000", " http://localhost:3001 ", ""})
+
+	// Act
+	before := origins.Allowed("http://localhost:3001")
+	origins.Set([]string{"https://app.example.com"})
+
+	// Assert
+	assert.True(t, before, "entries are trimmed")
+	assert.False(t, origins.Allowed(""))
+	assert.False(t, origins.Allowed("http://localhost:3000"), "the old list is replaced")
+	assert.True(t, origins.Allowed("https://app.example.com"))
+}
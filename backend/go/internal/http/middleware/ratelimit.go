@@ -0,0 +1,238 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/metrics"
+)
+
+// Key types a rate limit is counted against
+const (
+	RateLimitKeyIP   = "ip"
+	RateLimitKeyUser = "user"
+)
+
+// unmatchedRouteGroup labels requests that match no route
+const unmatchedRouteGroup = "unmatched"
+
+// RateLimitObserver receives rate limiting decisions, e.g. to export them as
+// metrics. Implemented by metrics.RateLimitMetrics.
+type RateLimitObserver interface {
+	ObserveRateLimit(routeGroup string, allowed bool)
+	SetRateLimitKeys(n int)
+}
+
+// Decision is the outcome of checking one request against the rate limit
+type Decision struct {
+	Allowed bool
+	// Key is what the request was counted against, e.g. "ip:203.0.113.7"
+	Key     string
+	KeyType string
+	// Limit is 0 when rate limiting is off
+	Limit int
+	// Remaining is the budget left in the window after this request
+	Remaining int
+}
+
+// RateLimiter represents a simple rate limiter. It is safe for concurrent
+// use, and its limit can be changed while it serves requests.
+type RateLimiter struct {
+	mu       sync.Mutex
+	requests map[string][]time.Time
+	limit    int
+	window   time.Duration
+	top      *topKeys
+
+	observer RateLimitObserver
+	// logSampler is shared by every request so limited requests are logged
+	// at a bounded rate however many there are
+	logSampler zerolog.Sampler
+}
+
+// NewRateLimiter creates a new rate limiter. A limit of 0 lets every request
+// through. observer may be nil.
+func NewRateLimiter(limit int, window time.Duration, observer RateLimitObserver) *RateLimiter {
+	return &RateLimiter{
+		requests:   make(map[string][]time.Time),
+		limit:      limit,
+		window:     window,
+		top:        newTopKeys(topKeysCapacity, TopKeysRetention),
+		observer:   observer,
+		logSampler: &zerolog.BurstSampler{Burst: 10, Period: time.Second},
+	}
+}
+
+// SetLimit changes the limit. Requests already recorded count against the
+// new one.
+func (rl *RateLimiter) SetLimit(limit int, window time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.limit = limit
+	rl.window = window
+}
+
+// RateLimit middleware limits requests per user when the request is
+// authenticated and per client IP otherwise
+func (rl *RateLimiter) RateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decision := rl.Allow(r)
+
+		if !decision.Allowed {
+			// Routing hasn't happened yet; match the route to label the request
+			pattern := matchRoutePattern(r)
+			rl.observe(routeGroup(pattern), false)
+
+			logger := log.Ctx(r.Context()).Sample(rl.logSampler)
+			logger.Warn().
+				Str("key", decision.Key).
+				Str("key_type", decision.KeyType).
+				Int("limit", decision.Limit).
+				Int("remaining", decision.Remaining).
+				Str("route", pattern).
+				Msg("rate limit exceeded")
+
+			respond.Error(w, http.StatusTooManyRequests, "rate_limited",
+				"Rate limit exceeded. Please try again later.")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+		rl.observe(routeGroup(metrics.RoutePattern(r)), true)
+	})
+}
+
+// Allow records r against its key unless the key is over the limit
+func (rl *RateLimiter) Allow(r *http.Request) Decision {
+	decision := Decision{Key: RateLimitKeyIP + ":" + getClientIP(r), KeyType: RateLimitKeyIP}
+	if userID := GetUserID(r.Context()); userID != "" {
+		decision.Key = RateLimitKeyUser + ":" + userID
+		decision.KeyType = RateLimitKeyUser
+	}
+
+	now := time.Now()
+	rl.mu.Lock()
+	rl.decide(&decision, now)
+	rl.top.record(decision.Key, decision.Allowed, now)
+	keys := len(rl.requests)
+	rl.mu.Unlock()
+
+	if rl.observer != nil {
+		rl.observer.SetRateLimitKeys(keys)
+	}
+	return decision
+}
+
+// decide fills in the decision for one request. Callers hold rl.mu.
+func (rl *RateLimiter) decide(decision *Decision, now time.Time) {
+	decision.Limit = rl.limit
+	if rl.limit <= 0 {
+		decision.Allowed = true
+		return
+	}
+
+	// Clean old requests
+	rl.cleanOldRequests(decision.Key, now)
+
+	// Check rate limit
+	if len(rl.requests[decision.Key]) >= rl.limit {
+		return
+	}
+
+	// Record request
+	rl.requests[decision.Key] = append(rl.requests[decision.Key], now)
+	decision.Allowed = true
+	decision.Remaining = rl.limit - len(rl.requests[decision.Key])
+}
+
+// Prune forgets keys with no requests in the window. Keys are otherwise only
+// cleaned up when they make another request.
+func (rl *RateLimiter) Prune() {
+	rl.mu.Lock()
+	now := time.Now()
+	for key := range rl.requests {
+		rl.cleanOldRequests(key, now)
+	}
+	keys := len(rl.requests)
+	rl.mu.Unlock()
+
+	if rl.observer != nil {
+		rl.observer.SetRateLimitKeys(keys)
+	}
+}
+
+// TopKeys returns up to n of the keys with the most requests over the last
+// window, heaviest first. Counts cover this replica only and are estimates
+// once more keys are seen than are tracked.
+func (rl *RateLimiter) TopKeys(window time.Duration, n int) []KeyUsage {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	return rl.top.top(window, n, time.Now())
+}
+
+func (rl *RateLimiter) observe(group string, allowed bool) {
+	if rl.observer != nil {
+		rl.observer.ObserveRateLimit(group, allowed)
+	}
+}
+
+// cleanOldRequests removes requests outside the time window
+func (rl *RateLimiter) cleanOldRequests(key string, now time.Time) {
+	requests := rl.requests[key]
+	cutoff := now.Add(-rl.window)
+
+	var validRequests []time.Time
+	for _, reqTime := range requests {
+		if reqTime.After(cutoff) {
+			validRequests = append(validRequests, reqTime)
+		}
+	}
+
+	// Drop idle clients so the map doesn't grow with every IP ever seen
+	if len(validRequests) == 0 {
+		delete(rl.requests, key)
+		return
+	}
+	rl.requests[key] = validRequests
+}
+
+// matchRoutePattern finds the route pattern r would be served by, for
+// middleware that runs before routing has finished
+func matchRoutePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return unmatchedRouteGroup
+	}
+
+	match := chi.NewRouteContext()
+	if !rctx.Routes.Match(match, r.Method, r.URL.Path) {
+		return unmatchedRouteGroup
+	}
+	if pattern := match.RoutePattern(); pattern != "" {
+		return pattern
+	}
+	return unmatchedRouteGroup
+}
+
+// routeGroup reduces a route pattern to its first three segments, e.g.
+// /api/v1/projects/{projectId}/items to /api/v1/projects, to keep metric
+// labels few
+func routeGroup(pattern string) string {
+	segments := strings.Split(strings.Trim(pattern, "/"), "/")
+	if !strings.HasPrefix(pattern, "/") || segments[0] == "" {
+		return unmatchedRouteGroup
+	}
+	if len(segments) > 3 {
+		segments = segments[:3]
+	}
+	return "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/metrics"
+)
+
+func TestRateLimiter_SetLimit(t *testing.T) {
+	// Arrange
+	limiter := NewRateLimiter(2, time.Minute, nil)
+	handler := limiter.RateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func() int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/projects", nil))
+		return w.Code
+	}
+
+	// Act & Assert
+	assert.Equal(t, http.StatusOK, serve())
+	assert.Equal(t, http.StatusOK, serve())
+	assert.Equal(t, http.StatusTooManyRequests, serve())
+
+	// Requests already counted apply to the new limit
+	limiter.SetLimit(3, time.Minute)
+	assert.Equal(t, http.StatusOK, serve())
+	assert.Equal(t, http.StatusTooManyRequests, serve())
+
+	// 0 turns limiting off
+	limiter.SetLimit(0, time.Minute)
+	assert.Equal(t, http.StatusOK, serve())
+}
+
+func TestRateLimiter_Metrics(t *testing.T) {
+	// Arrange
+	registry := metrics.NewRegistry()
+	limiter := NewRateLimiter(2, time.Minute, metrics.NewRateLimitMetrics(registry))
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(limiter.RateLimit)
+		r.Route("/api/v1", func(r chi.Router) {
+			r.Get("/projects/{projectId}/items", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+		})
+	})
+	serve := func(remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/p1/items", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Act
+	for i := 0; i < 3; i++ {
+		serve("203.0.113.7:1234")
+	}
+	serve("203.0.113.8:1234")
+
+	w := httptest.NewRecorder()
+	metrics.Handler(registry).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+
+	// Assert
+	assert.Contains(t, body, `provemyself_rate_limit_decisions_total{decision="allowed",route_group="/api/v1/projects"} 3`)
+	assert.Contains(t, body, `provemyself_rate_limit_decisions_total{decision="limited",route_group="/api/v1/projects"} 1`)
+	assert.Contains(t, body, `provemyself_rate_limit_tracked_keys 2`)
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	// Arrange
+	limiter := NewRateLimiter(2, time.Minute, nil)
+	anonymous := httptest.NewRequest(http.MethodGet, "/api/v1/projects", nil)
+	anonymous.RemoteAddr = "203.0.113.7:1234"
+	authenticated := anonymous.WithContext(withUser(anonymous.Context(), &User{ID: "user-1"}))
+
+	// Act
+	first := limiter.Allow(anonymous)
+	second := limiter.Allow(anonymous)
+	third := limiter.Allow(anonymous)
+	user := limiter.Allow(authenticated)
+
+	// Assert
+	assert.Equal(t, Decision{Allowed: true, Key: "ip:203.0.113.7", KeyType: RateLimitKeyIP, Limit: 2, Remaining: 1}, first)
+	assert.Equal(t, 0, second.Remaining)
+	assert.True(t, second.Allowed)
+	assert.False(t, third.Allowed)
+	// Authenticated requests have a budget of their own
+	assert.Equal(t, Decision{Allowed: true, Key: "user:user-1", KeyType: RateLimitKeyUser, Limit: 2, Remaining: 1}, user)
+}
+
+func TestRouteGroup(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"/api/v1/projects/{projectId}/items/", "/api/v1/projects"},
+		{"/api/v1/admin/jobs", "/api/v1/admin"},
+		{"/health", "/health"},
+		{"/", "unmatched"},
+		{"unmatched", "unmatched"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert.Equal(t, tt.want, routeGroup(tt.pattern))
+		})
+	}
+}
+
+func TestTopKeys_Ordering(t *testing.T) {
+	// Arrange
+	now := time.Date(2026, 3, 11, 10, 30, 0, 0, time.UTC)
+	top := newTopKeys(10, TopKeysRetention)
+	record := func(key string, n int, at time.Time) {
+		for i := 0; i < n; i++ {
+			top.record(key, true, at)
+		}
+	}
+	record("ip:b", 3, now)
+	record("ip:a", 3, now.Add(-2*time.Minute))
+	record("user:heavy", 5, now.Add(-time.Minute))
+	record("ip:c", 1, now)
+	top.record("user:heavy", false, now)
+	// Outside a 5 minute window
+	record("ip:old", 10, now.Add(-6*time.Minute))
+
+	// Act
+	usage := top.top(5*time.Minute, 3, now)
+
+	// Assert
+	require.Len(t, usage, 3)
+	assert.Equal(t, KeyUsage{Key: "user:heavy", KeyType: "user", Requests: 6, Limited: 1}, usage[0])
+	// Ties are broken by key
+	assert.Equal(t, "ip:a", usage[1].Key)
+	assert.Equal(t, "ip:b", usage[2].Key)
+
+	assert.Equal(t, "ip:old", top.top(TopKeysRetention, 1, now)[0].Key)
+}
+
+func TestTopKeys_EvictsLightestKey(t *testing.T) {
+	// Arrange
+	now := time.Date(2026, 3, 11, 10, 30, 0, 0, time.UTC)
+	top := newTopKeys(2, TopKeysRetention)
+	for i := 0; i < 5; i++ {
+		top.record("ip:heavy", true, now)
+	}
+	top.record("ip:light", true, now)
+
+	// Act
+	top.record("ip:new", true, now)
+	usage := top.top(time.Minute, 10, now)
+
+	// Assert
+	require.Len(t, usage, 2)
+	assert.Equal(t, KeyUsage{Key: "ip:heavy", KeyType: "ip", Requests: 5}, usage[0])
+	// The new key takes over the evicted key's count, so it is never
+	// underestimated
+	assert.Equal(t, KeyUsage{Key: "ip:new", KeyType: "ip", Requests: 2}, usage[1])
+}
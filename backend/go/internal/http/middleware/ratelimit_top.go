@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// TopKeysRetention is the longest window TopKeys can report on
+const TopKeysRetention = 15 * time.Minute
+
+// topKeysCapacity is how many keys each minute tracks. Keys beyond it evict
+// the lightest one, so light keys may be missed but heavy ones are not.
+const topKeysCapacity = 100
+
+// KeyUsage reports the requests made by one rate limit key
+type KeyUsage struct {
+	Key      string
+	KeyType  string
+	Requests int64
+	// Limited counts the requests that were rejected
+	Limited int64
+}
+
+// keyCount is one key's counts within a minute
+type keyCount struct {
+	requests int64
+	limited  int64
+}
+
+// keyBucket holds the counts for one minute
+type keyBucket struct {
+	minute int64
+	counts map[string]*keyCount
+}
+
+// topKeys tracks the heaviest keys per minute with the Space-Saving
+// algorithm: a full bucket replaces its lightest key and carries that key's
+// count over, so a count may be overestimated but never underestimated. It
+// is not safe for concurrent use.
+type topKeys struct {
+	capacity int
+	buckets  []keyBucket
+}
+
+func newTopKeys(capacity int, retention time.Duration) *topKeys {
+	return &topKeys{
+		capacity: capacity,
+		buckets:  make([]keyBucket, int(retention/time.Minute)),
+	}
+}
+
+// record counts one request from key at now
+func (t *topKeys) record(key string, allowed bool, now time.Time) {
+	minute := now.Unix() / 60
+	bucket := &t.buckets[minute%int64(len(t.buckets))]
+	if bucket.minute != minute || bucket.counts == nil {
+		*bucket = keyBucket{minute: minute, counts: make(map[string]*keyCount, t.capacity)}
+	}
+
+	count, ok := bucket.counts[key]
+	if !ok {
+		count = &keyCount{}
+		if len(bucket.counts) >= t.capacity {
+			lightest := bucket.lightest()
+			*count = *bucket.counts[lightest]
+			delete(bucket.counts, lightest)
+		}
+		bucket.counts[key] = count
+	}
+
+	count.requests++
+	if !allowed {
+		count.limited++
+	}
+}
+
+// lightest returns the key with the fewest requests
+func (b *keyBucket) lightest() string {
+	var key string
+	var min int64 = -1
+	for k, count := range b.counts {
+		if min < 0 || count.requests < min || (count.requests == min && k < key) {
+			key, min = k, count.requests
+		}
+	}
+	return key
+}
+
+// top sums the minutes within window of now and returns up to n keys by
+// requests, heaviest first and ties by key
+func (t *topKeys) top(window time.Duration, n int, now time.Time) []KeyUsage {
+	current := now.Unix() / 60
+	// A window always includes the current, partial minute
+	oldest := current - int64((window-1)/time.Minute)
+
+	totals := make(map[string]*keyCount)
+	for i := range t.buckets {
+		bucket := &t.buckets[i]
+		if bucket.counts == nil || bucket.minute < oldest || bucket.minute > current {
+			continue
+		}
+		for key, count := range bucket.counts {
+			total, ok := totals[key]
+			if !ok {
+				total = &keyCount{}
+				totals[key] = total
+			}
+			total.requests += count.requests
+			total.limited += count.limited
+		}
+	}
+
+	usage := make([]KeyUsage, 0, len(totals))
+	for key, total := range totals {
+		keyType, _, _ := strings.Cut(key, ":")
+		usage = append(usage, KeyUsage{
+			Key:      key,
+			KeyType:  keyType,
+			Requests: total.requests,
+			Limited:  total.limited,
+		})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Requests != usage[j].Requests {
+			return usage[i].Requests > usage[j].Requests
+		}
+		return usage[i].Key < usage[j].Key
+	})
+
+	if len(usage) > n {
+		usage = usage[:n]
+	}
+	return usage
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import "sync"
+
+// Startup phases reported by the readiness probe
+const (
+	PhaseMigrationsDone = "migrations_done"
+	PhaseStorageChecked = "storage_checked"
+	PhaseWorkersStarted = "workers_started"
+)
+
+// Readiness tracks startup phases. The server starts listening before
+// startup finishes, so liveness answers during long migrations while
+// readiness stays not_ready until every phase has completed.
+type Readiness struct {
+	mu     sync.RWMutex
+	phases map[string]bool
+}
+
+// NewReadiness creates a tracker that is ready once every given phase has
+// completed
+func NewReadiness(phases ...string) *Readiness {
+	r := &Readiness{phases: make(map[string]bool, len(phases))}
+	for _, phase := range phases {
+		r.phases[phase] = false
+	}
+	return r
+}
+
+// Complete marks a startup phase as done
+func (r *Readiness) Complete(phase string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phases[phase] = true
+}
+
+// Phases returns a copy of each phase's completion state
+func (r *Readiness) Phases() map[string]bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	phases := make(map[string]bool, len(r.phases))
+	for phase, done := range r.phases {
+		phases[phase] = done
+	}
+	return phases
+}
+
+// Ready reports whether every phase has completed
+func (r *Readiness) Ready() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, done := range r.phases {
+		if !done {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubChecker struct {
+	name string
+	err  error
+}
+
+func (c stubChecker) Name() string { return c.name }
+
+func (c stubChecker) HealthCheck(ctx context.Context) error { return c.err }
+
+type readinessResponse struct {
+	Status string            `json:"status"`
+	Reason string            `json:"reason"`
+	Checks map[string]string `json:"checks"`
+	Phases map[string]bool   `json:"phases"`
+	Schema *struct {
+		Version uint `json:"version"`
+		Dirty   bool `json:"dirty"`
+	} `json:"schema"`
+}
+
+func probe(t *testing.T, handler http.HandlerFunc) (int, readinessResponse) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	var body readinessResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w.Code, body
+}
+
+func TestReadiness_Ready(t *testing.T) {
+	// Arrange
+	readiness := NewReadiness(PhaseMigrationsDone, PhaseStorageChecked)
+
+	// Act & Assert
+	assert.False(t, readiness.Ready())
+	readiness.Complete(PhaseMigrationsDone)
+	assert.False(t, readiness.Ready())
+	readiness.Complete(PhaseStorageChecked)
+	assert.True(t, readiness.Ready())
+	assert.Equal(t, map[string]bool{
+		PhaseMigrationsDone: true,
+		PhaseStorageChecked: true,
+	}, readiness.Phases())
+}
+
+func TestReadinessProbe_FlipsAfterDelayedMigration(t *testing.T) {
+	// Arrange
+	readiness := NewReadiness(PhaseMigrationsDone, PhaseStorageChecked, PhaseWorkersStarted)
+	handler := NewHealthMiddleware(readiness).ReadinessProbe([]HealthChecker{
+		stubChecker{name: "database"},
+	})
+
+	migrate := func() { time.Sleep(50 * time.Millisecond) }
+	go func() {
+		migrate()
+		readiness.Complete(PhaseMigrationsDone)
+		readiness.Complete(PhaseStorageChecked)
+		readiness.Complete(PhaseWorkersStarted)
+	}()
+
+	// Act
+	code, body := probe(t, handler)
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", body.Status)
+	assert.Equal(t, "starting", body.Reason)
+	assert.False(t, body.Phases[PhaseMigrationsDone])
+
+	require.Eventually(t, func() bool {
+		code, _ := probe(t, handler)
+		return code == http.StatusOK
+	}, 2*time.Second, 10*time.Millisecond)
+
+	_, body = probe(t, handler)
+	assert.Equal(t, "ready", body.Status)
+	assert.Equal(t, "healthy", body.Checks["database"])
+	assert.Equal(t, map[string]bool{
+		PhaseMigrationsDone: true,
+		PhaseStorageChecked: true,
+		PhaseWorkersStarted: true,
+	}, body.Phases)
+}
+
+func TestReadinessProbe_ChecksDependenciesOnceStarted(t *testing.T) {
+	// Arrange
+	readiness := NewReadiness(PhaseMigrationsDone)
+	readiness.Complete(PhaseMigrationsDone)
+	handler := NewHealthMiddleware(readiness).ReadinessProbe([]HealthChecker{
+		stubChecker{name: "database", err: errors.New("connection refused")},
+	})
+
+	// Act
+	code, body := probe(t, handler)
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", body.Status)
+	assert.Equal(t, "unhealthy", body.Checks["database"])
+	assert.True(t, body.Phases[PhaseMigrationsDone])
+}
+
+func TestReadinessProbe_ReportsSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		dirty          bool
+		err            error
+		expectedStatus int
+		expectSchema   bool
+	}{
+		{"clean schema", false, nil, http.StatusOK, true},
+		{"dirty schema is not ready", true, nil, http.StatusServiceUnavailable, true},
+		{"unreadable version is not ready", false, errors.New("relation does not exist"), http.StatusServiceUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			health := NewHealthMiddleware(nil)
+			health.ReportSchemaVersion(func(ctx context.Context) (uint, bool, error) {
+				return 3, tt.dirty, tt.err
+			})
+			handler := health.ReadinessProbe(nil)
+
+			// Act
+			code, body := probe(t, handler)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, code)
+			if !tt.expectSchema {
+				assert.Nil(t, body.Schema)
+				return
+			}
+			require.NotNil(t, body.Schema)
+			assert.Equal(t, uint(3), body.Schema.Version)
+			assert.Equal(t, tt.dirty, body.Schema.Dirty)
+		})
+	}
+}
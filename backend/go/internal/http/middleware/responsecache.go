@@ -0,0 +1,290 @@
+package middleware
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// Response cache lookup results, as reported to the observer and in the
+// X-Cache response header
+const (
+	CacheHit    = "hit"
+	CacheMiss   = "miss"
+	CacheBypass = "bypass"
+)
+
+// cachedHeaders are the response headers replayed on a hit
+var cachedHeaders = []string{"Content-Type", "ETag", "Cache-Control", "Vary"}
+
+// ResponseCacheObserver receives response cache lookups, e.g. to export them
+// as metrics. Implemented by metrics.ResponseCacheMetrics.
+type ResponseCacheObserver interface {
+	ObserveResponseCache(result string)
+	SetResponseCacheEntries(n int)
+}
+
+// PublishedVersions looks up the version of a project's latest
+// publication, satisfied by *core.ProjectService
+type PublishedVersions interface {
+	PublishedVersion(ctx context.Context, projectID string) (int, error)
+}
+
+// cachedResponse is one rendered 200 response
+type cachedResponse struct {
+	key       string
+	projectID string
+	header    http.Header
+	body      []byte
+	expires   time.Time
+}
+
+// ResponseCache keeps rendered responses to anonymous reads of published
+// projects in a least-recently-used cache, so repeated reads of a popular
+// quiz skip loading its publication and JSON encoding. Entries are keyed by
+// the version of the project's latest publication, so a publish on any
+// replica is served at once. They expire after a TTL, and are dropped as
+// soon as a write to their project succeeds on this replica or, subscribed
+// to a store.ChangeListener, commits on another. It is safe for concurrent
+// use.
+type ResponseCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	// order holds *cachedResponse, most recently used first
+	order     *list.List
+	entries   map[string]*list.Element
+	byProject map[string]map[string]struct{}
+
+	observer ResponseCacheObserver
+	now      func() time.Time
+}
+
+// NewResponseCache creates a cache of at most maxEntries responses, each
+// kept for ttl. observer may be nil.
+func NewResponseCache(maxEntries int, ttl time.Duration, observer ResponseCacheObserver) *ResponseCache {
+	return &ResponseCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		byProject:  make(map[string]map[string]struct{}),
+		observer:   observer,
+		now:        time.Now,
+	}
+}
+
+// Cache middleware serves GET requests for a published project, named by
+// the {projectId} route parameter, from the cache and stores their 200
+// responses. Authenticated and organization-scoped requests bypass it, and
+// so do projects that are not published, or were published before
+// publications were kept and are served with their live items.
+func (c *ResponseCache) Cache(versions PublishedVersions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			projectID := chi.URLParam(r, "projectId")
+			if r.Method != http.MethodGet || projectID == "" || IsAuthenticated(r.Context()) ||
+				core.OrgIDFromContext(r.Context()) != "" || r.Header.Get("Authorization") != "" {
+				c.observe(CacheBypass)
+				next.ServeHTTP(w, r)
+				return
+			}
+			version, err := versions.PublishedVersion(r.Context(), projectID)
+			if err != nil || version == 0 {
+				c.observe(CacheBypass)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// The representation varies with the publication, the path, the
+			// query and the negotiated format
+			key := r.URL.Path + "?" + r.URL.RawQuery + "@v" + strconv.Itoa(version) + "|" + r.Header.Get("Accept")
+			if entry, ok := c.get(key); ok {
+				c.observe(CacheHit)
+				serveCached(w, r, entry)
+				return
+			}
+			c.observe(CacheMiss)
+
+			var body bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&body)
+			ww.Header().Set("X-Cache", CacheMiss)
+
+			next.ServeHTTP(ww, r)
+
+			if ww.Status() == http.StatusOK {
+				c.put(key, projectID, ww.Header(), body.Bytes())
+			}
+		})
+	}
+}
+
+// Invalidate middleware drops the cached responses of the request's
+// project once a write to it succeeds
+func (c *ResponseCache) Invalidate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		if projectID := chi.URLParam(r, "projectId"); projectID != "" && ww.Status() < 400 {
+			c.InvalidateProject(projectID)
+		}
+	})
+}
+
+// InvalidateProject drops every cached response of the project
+func (c *ResponseCache) InvalidateProject(projectID string) {
+	c.mu.Lock()
+	for key := range c.byProject[projectID] {
+		c.remove(c.entries[key])
+	}
+	entries := c.order.Len()
+	c.mu.Unlock()
+
+	if c.observer != nil {
+		c.observer.SetResponseCacheEntries(entries)
+	}
+}
+
+// ApplyChange implements core.ChangeSubscriber: a change to a project made
+// through any replica drops its cached responses
+func (c *ResponseCache) ApplyChange(ctx context.Context, change core.Change) error {
+	if change.Entity == core.ChangeEntityProject {
+		c.InvalidateProject(change.ID)
+	}
+	return nil
+}
+
+// Resync implements core.ChangeSubscriber by dropping every cached response
+func (c *ResponseCache) Resync(ctx context.Context) error {
+	c.mu.Lock()
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	c.byProject = make(map[string]map[string]struct{})
+	c.mu.Unlock()
+
+	if c.observer != nil {
+		c.observer.SetResponseCacheEntries(0)
+	}
+	return nil
+}
+
+// Len returns the number of cached responses
+func (c *ResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *ResponseCache) get(key string) (*cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cachedResponse)
+	if !c.now().Before(entry.expires) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry, true
+}
+
+func (c *ResponseCache) put(key, projectID string, header http.Header, body []byte) {
+	entry := &cachedResponse{
+		key:       key,
+		projectID: projectID,
+		header:    make(http.Header, len(cachedHeaders)),
+		body:      append([]byte(nil), body...),
+	}
+	for _, name := range cachedHeaders {
+		if values := header.Values(name); len(values) > 0 {
+			entry.header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+	}
+
+	c.mu.Lock()
+	entry.expires = c.now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	if c.byProject[projectID] == nil {
+		c.byProject[projectID] = make(map[string]struct{})
+	}
+	c.byProject[projectID][key] = struct{}{}
+
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+	entries := c.order.Len()
+	c.mu.Unlock()
+
+	if c.observer != nil {
+		c.observer.SetResponseCacheEntries(entries)
+	}
+}
+
+// remove drops an entry. Callers hold c.mu.
+func (c *ResponseCache) remove(element *list.Element) {
+	if element == nil {
+		return
+	}
+	entry := c.order.Remove(element).(*cachedResponse)
+	delete(c.entries, entry.key)
+	delete(c.byProject[entry.projectID], entry.key)
+	if len(c.byProject[entry.projectID]) == 0 {
+		delete(c.byProject, entry.projectID)
+	}
+}
+
+func (c *ResponseCache) observe(result string) {
+	if c.observer != nil {
+		c.observer.ObserveResponseCache(result)
+	}
+}
+
+// serveCached writes a cached response, or 304 when the client already has
+// its ETag
+func serveCached(w http.ResponseWriter, r *http.Request, entry *cachedResponse) {
+	for name, values := range entry.header {
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	w.Header().Set("X-Cache", CacheHit)
+
+	if etag := entry.header.Get("ETag"); etag != "" && ifNoneMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(entry.body)
+}
+
+// ifNoneMatch implements the weak comparison If-None-Match requires
+func ifNoneMatch(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// recordingCacheObserver collects response cache lookups
+type recordingCacheObserver struct {
+	results []string
+	entries int
+}
+
+func (o *recordingCacheObserver) ObserveResponseCache(result string) {
+	o.results = append(o.results, result)
+}
+
+func (o *recordingCacheObserver) SetResponseCacheEntries(n int) {
+	o.entries = n
+}
+
+// publishedVersions maps published projects to their latest publication
+type publishedVersions map[string]int
+
+func (v publishedVersions) PublishedVersion(ctx context.Context, projectID string) (int, error) {
+	version, ok := v[projectID]
+	if !ok {
+		return 0, core.ErrProjectNotFound
+	}
+	return version, nil
+}
+
+// newCachedRouter serves GET and PUT /projects/{projectId} through cache,
+// counting how often the read handler runs. Projects p1 to p3 are
+// published at version 1.
+func newCachedRouter(cache *ResponseCache, reads *int) http.Handler {
+	return newPublishedRouter(cache, publishedVersions{"p1": 1, "p2": 1, "p3": 1}, reads)
+}
+
+func newPublishedRouter(cache *ResponseCache, versions publishedVersions, reads *int) http.Handler {
+	r := chi.NewRouter()
+	r.With(cache.Cache(versions)).Get("/projects/{projectId}", func(w http.ResponseWriter, r *http.Request) {
+		*reads++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":"` + chi.URLParam(r, "projectId") + `"}`))
+	})
+	r.With(cache.Invalidate).Put("/projects/{projectId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return r
+}
+
+func serveCache(h http.Handler, method, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestResponseCache_ServesRepeatedReads(t *testing.T) {
+	// Arrange
+	observer := &recordingCacheObserver{}
+	cache := NewResponseCache(10, time.Minute, observer)
+	var reads int
+	router := newCachedRouter(cache, &reads)
+
+	// Act
+	first := serveCache(router, http.MethodGet, "/projects/p1", nil)
+	second := serveCache(router, http.MethodGet, "/projects/p1", nil)
+	revalidated := serveCache(router, http.MethodGet, "/projects/p1", http.Header{"If-None-Match": {`"v1"`}})
+
+	// Assert
+	assert.Equal(t, 1, reads)
+	assert.Equal(t, CacheMiss, first.Header().Get("X-Cache"))
+	assert.Equal(t, CacheHit, second.Header().Get("X-Cache"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, `"v1"`, second.Header().Get("ETag"))
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	assert.Equal(t, http.StatusNotModified, revalidated.Code)
+	assert.Empty(t, revalidated.Body.String())
+	assert.Equal(t, []string{CacheMiss, CacheHit, CacheHit}, observer.results)
+	assert.Equal(t, 1, observer.entries)
+}
+
+func TestResponseCache_BypassesAuthenticatedReads(t *testing.T) {
+	// Arrange
+	observer := &recordingCacheObserver{}
+	cache := NewResponseCache(10, time.Minute, observer)
+	var reads int
+	router := newCachedRouter(cache, &reads)
+	auth := http.Header{"Authorization": {"Bearer editor-token"}}
+
+	// Act
+	serveCache(router, http.MethodGet, "/projects/p1", auth)
+	serveCache(router, http.MethodGet, "/projects/p1", auth)
+
+	// Assert
+	assert.Equal(t, 2, reads)
+	assert.Equal(t, []string{CacheBypass, CacheBypass}, observer.results)
+	assert.Zero(t, cache.Len())
+}
+
+func TestResponseCache_KeyedByPublishedVersion(t *testing.T) {
+	// Arrange
+	observer := &recordingCacheObserver{}
+	cache := NewResponseCache(10, time.Minute, observer)
+	versions := publishedVersions{"p1": 1, "legacy": 0}
+	var reads int
+	router := newPublishedRouter(cache, versions, &reads)
+	serveCache(router, http.MethodGet, "/projects/p1", nil)
+
+	// Act
+	versions["p1"] = 2 // published again, e.g. through another replica
+	republished := serveCache(router, http.MethodGet, "/projects/p1", nil)
+	again := serveCache(router, http.MethodGet, "/projects/p1", nil)
+	serveCache(router, http.MethodGet, "/projects/legacy", nil)
+	serveCache(router, http.MethodGet, "/projects/unpublished", nil)
+
+	// Assert
+	assert.Equal(t, CacheMiss, republished.Header().Get("X-Cache"))
+	assert.Equal(t, CacheHit, again.Header().Get("X-Cache"))
+	assert.Equal(t, 4, reads)
+	assert.Equal(t, []string{CacheMiss, CacheMiss, CacheHit, CacheBypass, CacheBypass}, observer.results)
+}
+
+func TestResponseCache_WriteInvalidatesProject(t *testing.T) {
+	// Arrange
+	cache := NewResponseCache(10, time.Minute, nil)
+	var reads int
+	router := newCachedRouter(cache, &reads)
+	serveCache(router, http.MethodGet, "/projects/p1", nil)
+	serveCache(router, http.MethodGet, "/projects/p2", nil)
+	require.Equal(t, 2, cache.Len())
+
+	// Act
+	serveCache(router, http.MethodPut, "/projects/p1", nil)
+	after := serveCache(router, http.MethodGet, "/projects/p1", nil)
+	other := serveCache(router, http.MethodGet, "/projects/p2", nil)
+
+	// Assert
+	assert.Equal(t, CacheMiss, after.Header().Get("X-Cache"))
+	assert.Equal(t, CacheHit, other.Header().Get("X-Cache"))
+	assert.Equal(t, 3, reads)
+}
+
+func TestResponseCache_AppliesChangesFromOtherReplicas(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	observer := &recordingCacheObserver{}
+	cache := NewResponseCache(10, time.Minute, observer)
+	var reads int
+	router := newCachedRouter(cache, &reads)
+	serveCache(router, http.MethodGet, "/projects/p1", nil)
+	serveCache(router, http.MethodGet, "/projects/p2", nil)
+	serveCache(router, http.MethodGet, "/projects/p3", nil)
+
+	// Act
+	require.NoError(t, cache.ApplyChange(ctx, core.Change{Entity: core.ChangeEntitySettings, Action: core.ChangeActionUpdated}))
+	unrelated := cache.Len()
+	require.NoError(t, cache.ApplyChange(ctx, core.Change{Entity: core.ChangeEntityProject, ID: "p1", Action: core.ChangeActionUpdated}))
+	changed := serveCache(router, http.MethodGet, "/projects/p1", nil)
+	other := serveCache(router, http.MethodGet, "/projects/p2", nil)
+	require.NoError(t, cache.Resync(ctx))
+
+	// Assert
+	assert.Equal(t, 3, unrelated)
+	assert.Equal(t, CacheMiss, changed.Header().Get("X-Cache"))
+	assert.Equal(t, CacheHit, other.Header().Get("X-Cache"))
+	assert.Zero(t, cache.Len())
+	assert.Zero(t, observer.entries)
+}
+
+func TestResponseCache_EvictsLeastRecentlyUsedAndExpired(t *testing.T) {
+	// Arrange
+	cache := NewResponseCache(2, time.Minute, nil)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	var reads int
+	router := newCachedRouter(cache, &reads)
+
+	serveCache(router, http.MethodGet, "/projects/p1", nil)
+	serveCache(router, http.MethodGet, "/projects/p2", nil)
+	serveCache(router, http.MethodGet, "/projects/p1", nil) // p1 is now the most recent
+
+	// Act
+	serveCache(router, http.MethodGet, "/projects/p3", nil)
+	evicted := serveCache(router, http.MethodGet, "/projects/p2", nil)
+	now = now.Add(2 * time.Minute)
+	expired := serveCache(router, http.MethodGet, "/projects/p2", nil)
+
+	// Assert
+	assert.Equal(t, CacheMiss, evicted.Header().Get("X-Cache"))
+	assert.Equal(t, CacheMiss, expired.Header().Get("X-Cache"))
+	assert.Equal(t, 2, cache.Len())
+}
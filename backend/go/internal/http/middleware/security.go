@@ -2,11 +2,15 @@ package middleware
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"strings"
-	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/respond"
 )
 
 // SecurityHeaders middleware adds security headers to responses
@@ -39,66 +43,6 @@ func SecurityHeaders(next http.Handler) http.Handler {
 	})
 }
 
-// RateLimiter represents a simple rate limiter
-type RateLimiter struct {
-	requests map[string][]time.Time
-	limit    int
-	window   time.Duration
-}
-
-// NewRateLimiter creates a new rate limiter
-func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
-	return &RateLimiter{
-		requests: make(map[string][]time.Time),
-		limit:    limit,
-		window:   window,
-	}
-}
-
-// RateLimit middleware implements rate limiting per IP
-func (rl *RateLimiter) RateLimit(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip := getClientIP(r)
-		now := time.Now()
-
-		// Clean old requests
-		rl.cleanOldRequests(ip, now)
-
-		// Check rate limit
-		if len(rl.requests[ip]) >= rl.limit {
-			log.Warn().
-				Str("ip", ip).
-				Int("requests", len(rl.requests[ip])).
-				Int("limit", rl.limit).
-				Msg("rate limit exceeded")
-
-			SendJSONError(w, http.StatusTooManyRequests, "rate_limited", 
-				"Rate limit exceeded. Please try again later.")
-			return
-		}
-
-		// Record request
-		rl.requests[ip] = append(rl.requests[ip], now)
-
-		next.ServeHTTP(w, r)
-	})
-}
-
-// cleanOldRequests removes requests outside the time window
-func (rl *RateLimiter) cleanOldRequests(ip string, now time.Time) {
-	requests := rl.requests[ip]
-	cutoff := now.Add(-rl.window)
-	
-	var validRequests []time.Time
-	for _, reqTime := range requests {
-		if reqTime.After(cutoff) {
-			validRequests = append(validRequests, reqTime)
-		}
-	}
-	
-	rl.requests[ip] = validRequests
-}
-
 // getClientIP extracts the real client IP from request headers
 func getClientIP(r *http.Request) string {
 	// Check X-Forwarded-For header
@@ -123,6 +67,57 @@ func getClientIP(r *http.Request) string {
 	return ip
 }
 
+// IPAllowlist middleware rejects requests whose peer address isn't in the list.
+// Entries may be single IPs or CIDR ranges. Only RemoteAddr is checked since
+// forwarding headers can be set by the client.
+func IPAllowlist(allowed []string) func(http.Handler) http.Handler {
+	var networks []*net.IPNet
+	for _, entry := range allowed {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Warn().Err(err).Str("entry", entry).Msg("ignoring invalid IP allowlist entry")
+			continue
+		}
+		networks = append(networks, network)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+
+			if ip := net.ParseIP(host); ip != nil {
+				for _, network := range networks {
+					if network.Contains(ip) {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+
+			log.Warn().
+				Str("remote_addr", r.RemoteAddr).
+				Str("path", r.URL.Path).
+				Msg("request from IP outside allowlist")
+
+			respond.Error(w, http.StatusForbidden, "ip_not_allowed", "Access from this address is not allowed")
+		})
+	}
+}
+
 // AuthContextKey represents keys used in authentication context
 type AuthContextKey string
 
@@ -133,6 +128,9 @@ const (
 	UserRoleKey AuthContextKey = "user_role"
 	// UserEmailKey is the context key for user email
 	UserEmailKey AuthContextKey = "user_email"
+	// UserOrgIDKey is the context key for the organization the user's token
+	// was issued for
+	UserOrgIDKey AuthContextKey = "user_org_id"
 )
 
 // User represents an authenticated user
@@ -140,6 +138,8 @@ type User struct {
 	ID    string `json:"id"`
 	Email string `json:"email"`
 	Role  string `json:"role"`
+	// OrgID is the organization carried by the token, if any
+	OrgID string `json:"org_id,omitempty"`
 }
 
 // AuthenticateJWT middleware validates JWT tokens (skeleton implementation)
@@ -149,14 +149,14 @@ func AuthenticateJWT(jwtSecret string) func(http.Handler) http.Handler {
 			// Extract token from Authorization header
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				SendJSONError(w, http.StatusUnauthorized, "missing_token", "Authorization header required")
+				respond.Error(w, http.StatusUnauthorized, "missing_token", "Authorization header required")
 				return
 			}
 
 			// Check Bearer prefix
 			const bearerPrefix = "Bearer "
 			if !strings.HasPrefix(authHeader, bearerPrefix) {
-				SendJSONError(w, http.StatusUnauthorized, "invalid_token_format", "Token must be prefixed with 'Bearer '")
+				respond.Error(w, http.StatusUnauthorized, "invalid_token_format", "Token must be prefixed with 'Bearer '")
 				return
 			}
 
@@ -165,7 +165,7 @@ func AuthenticateJWT(jwtSecret string) func(http.Handler) http.Handler {
 			// TODO: Implement actual JWT validation
 			// For now, this is a skeleton that accepts any non-empty token in development
 			if token == "" {
-				SendJSONError(w, http.StatusUnauthorized, "empty_token", "Token cannot be empty")
+				respond.Error(w, http.StatusUnauthorized, "empty_token", "Token cannot be empty")
 				return
 			}
 
@@ -176,12 +176,7 @@ func AuthenticateJWT(jwtSecret string) func(http.Handler) http.Handler {
 				Role:  "admin",
 			}
 
-			// Add user to context
-			ctx := context.WithValue(r.Context(), UserIDKey, user.ID)
-			ctx = context.WithValue(ctx, UserEmailKey, user.Email)
-			ctx = context.WithValue(ctx, UserRoleKey, user.Role)
-
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(withUser(r.Context(), user)))
 		})
 	}
 }
@@ -219,12 +214,7 @@ func OptionalAuth(jwtSecret string) func(http.Handler) http.Handler {
 				Role:  "admin",
 			}
 
-			// Add user to context
-			ctx := context.WithValue(r.Context(), UserIDKey, user.ID)
-			ctx = context.WithValue(ctx, UserEmailKey, user.Email)
-			ctx = context.WithValue(ctx, UserRoleKey, user.Role)
-
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(withUser(r.Context(), user)))
 		})
 	}
 }
@@ -235,12 +225,12 @@ func RequireRole(role string) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			userRole := GetUserRole(r.Context())
 			if userRole == "" {
-				SendJSONError(w, http.StatusUnauthorized, "authentication_required", "Authentication required")
+				respond.Error(w, http.StatusUnauthorized, "authentication_required", "Authentication required")
 				return
 			}
 
 			if userRole != role && userRole != "admin" { // Admin can access everything
-				SendJSONError(w, http.StatusForbidden, "insufficient_permissions", 
+				respond.Error(w, http.StatusForbidden, "insufficient_permissions", 
 					"Insufficient permissions for this resource")
 				return
 			}
@@ -250,6 +240,17 @@ func RequireRole(role string) func(http.Handler) http.Handler {
 	}
 }
 
+// withUser adds the authenticated user to the context and to its request
+// logger, and makes the stores act for them
+func withUser(ctx context.Context, user *User) context.Context {
+	ctx = context.WithValue(ctx, UserIDKey, user.ID)
+	ctx = context.WithValue(ctx, UserEmailKey, user.Email)
+	ctx = context.WithValue(ctx, UserRoleKey, user.Role)
+	ctx = context.WithValue(ctx, UserOrgIDKey, user.OrgID)
+	ctx = core.WithAccessScope(ctx, core.AccessScope{UserID: user.ID, Role: user.Role, OrgID: user.OrgID})
+	return zerolog.Ctx(ctx).With().Str("user_id", user.ID).Logger().WithContext(ctx)
+}
+
 // Helper functions to extract user information from context
 
 // GetUserID returns the user ID from context
@@ -268,6 +269,15 @@ func GetUserEmail(ctx context.Context) string {
 	return ""
 }
 
+// GetUserOrgID returns the organization carried by the user's token from
+// context
+func GetUserOrgID(ctx context.Context) string {
+	if orgID, ok := ctx.Value(UserOrgIDKey).(string); ok {
+		return orgID
+	}
+	return ""
+}
+
 // GetUserRole returns the user role from context
 func GetUserRole(ctx context.Context) string {
 	if role, ok := ctx.Value(UserRoleKey).(string); ok {
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Timeout bounds the request context to d. Unlike chi's Timeout it never
+// writes a response itself: the deadline surfaces as context.DeadlineExceeded
+// from the store, and the handler answers 504 gateway_timeout. Apply it per
+// route group so slow routes (bulk, uploads) get a longer budget and
+// streaming routes can go without one.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
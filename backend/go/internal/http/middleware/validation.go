@@ -1,39 +1,77 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"reflect"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/i18n"
+	"github.com/provemyself/backend/internal/types"
 )
 
-// ValidationError represents a validation error with field details
-type ValidationError struct {
-	Field   string `json:"field"`
-	Tag     string `json:"tag"`
-	Value   string `json:"value"`
-	Message string `json:"message"`
+// NewValidator creates the validator shared by the handlers. Field errors are
+// named by their JSON field names, and the custom rules are registered.
+func NewValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(JSONTagName)
+	ValidatorExtensions(v)
+	return v
 }
 
-// FormatValidationError formats validator errors into a user-friendly format
-func FormatValidationError(err error) (string, string) {
-	var validationErrors []ValidationError
-
-	if validatorErrors, ok := err.(validator.ValidationErrors); ok {
-		for _, validationErr := range validatorErrors {
-			fieldError := ValidationError{
-				Field:   validationErr.Field(),
-				Tag:     validationErr.Tag(),
-				Value:   fmt.Sprintf("%v", validationErr.Value()),
-				Message: getValidationErrorMessage(validationErr),
-			}
-			validationErrors = append(validationErrors, fieldError)
+// JSONTagName names a struct field after its JSON key in validation errors.
+// Register it with validator.RegisterTagNameFunc.
+func JSONTagName(fld reflect.StructField) string {
+	name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// ValidationErrors converts validator errors into one entry per failed field.
+// Fields are named by their path in the request body, e.g. tags[2], under
+// prefix when it is set, e.g. items[3].title for prefix items[3].
+func ValidationErrors(err error, prefix string) []types.ValidationError {
+	var validationErrors []types.ValidationError
+
+	var validatorErrors validator.ValidationErrors
+	if !errors.As(err, &validatorErrors) {
+		return validationErrors
+	}
+
+	for _, validationErr := range validatorErrors {
+		// The namespace starts with the struct's Go name
+		field := validationErr.Namespace()
+		if i := strings.Index(field, "."); i >= 0 {
+			field = field[i+1:]
+		}
+		if prefix != "" {
+			field = prefix + "." + field
 		}
+
+		fieldErr := types.ValidationError{
+			Field: field,
+			Tag:   validationErr.Tag(),
+			Param: validationErr.Param(),
+		}
+		fieldErr.Message = getValidationErrorMessage(fieldErr)
+		validationErrors = append(validationErrors, fieldErr)
 	}
+	return validationErrors
+}
 
+// FormatValidationError formats validator errors into a user-friendly format
+func FormatValidationError(err error) (string, string) {
+	validationErrors := ValidationErrors(err, "")
 	if len(validationErrors) == 0 {
 		return "validation_failed", "Validation failed"
 	}
@@ -48,38 +86,15 @@ func FormatValidationError(err error) (string, string) {
 	return "validation_failed", details
 }
 
-// getValidationErrorMessage returns a user-friendly message for validation errors
-func getValidationErrorMessage(fe validator.FieldError) string {
-	field := strings.ToLower(fe.Field())
-
-	switch fe.Tag() {
-	case "required":
-		return fmt.Sprintf("Field '%s' is required", field)
-	case "min":
-		return fmt.Sprintf("Field '%s' must be at least %s characters", field, fe.Param())
-	case "max":
-		return fmt.Sprintf("Field '%s' must be at most %s characters", field, fe.Param())
-	case "email":
-		return fmt.Sprintf("Field '%s' must be a valid email address", field)
-	case "url":
-		return fmt.Sprintf("Field '%s' must be a valid URL", field)
-	case "uuid":
-		return fmt.Sprintf("Field '%s' must be a valid UUID", field)
-	case "oneof":
-		return fmt.Sprintf("Field '%s' must be one of: %s", field, fe.Param())
-	case "dive":
-		return fmt.Sprintf("Array field '%s' contains invalid items", field)
-	case "gte":
-		return fmt.Sprintf("Field '%s' must be greater than or equal to %s", field, fe.Param())
-	case "lte":
-		return fmt.Sprintf("Field '%s' must be less than or equal to %s", field, fe.Param())
-	case "gt":
-		return fmt.Sprintf("Field '%s' must be greater than %s", field, fe.Param())
-	case "lt":
-		return fmt.Sprintf("Field '%s' must be less than %s", field, fe.Param())
-	default:
-		return fmt.Sprintf("Field '%s' failed validation rule '%s'", field, fe.Tag())
+// getValidationErrorMessage renders the English catalog message of a
+// validation error. Rules without their own message use validation.default.
+// respond.ValidationError renders the same templates in other locales.
+func getValidationErrorMessage(fieldErr types.ValidationError) string {
+	key := "validation." + fieldErr.Tag
+	if !i18n.Has(key) {
+		key = "validation.default"
 	}
+	return i18n.Translate(i18n.DefaultLocale, key, respond.ValidationParams(fieldErr), fieldErr.Tag)
 }
 
 // ValidateJSON validates JSON request body
@@ -100,28 +115,95 @@ func DecodeAndValidateJSON(r *http.Request, v *validator.Validate, dst interface
 	return nil
 }
 
-// RequestSizeLimit middleware limits the size of request bodies
+// DefaultMaxRequestBodyBytes is the request body limit used when none is configured
+const DefaultMaxRequestBodyBytes = 1 << 20 // 1MB
+
+// bodyLimitKey stores the request's limitedBody so a route-level
+// RequestSizeLimit can replace, rather than nest inside, the global one
+type bodyLimitKey struct{}
+
+// limitedBody applies the size limit on first read, by which time any
+// route-level override has been applied
+type limitedBody struct {
+	w             http.ResponseWriter
+	body          io.ReadCloser
+	contentLength int64
+	limit         int64
+	reader        io.ReadCloser
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		// Reject declared lengths up front instead of reading up to the limit
+		if b.contentLength > b.limit {
+			log.Warn().
+				Int64("content_length", b.contentLength).
+				Int64("max_bytes", b.limit).
+				Msg("request body too large")
+
+			return 0, &http.MaxBytesError{Limit: b.limit}
+		}
+		b.reader = http.MaxBytesReader(b.w, b.body, b.limit)
+	}
+	return b.reader.Read(p)
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+// RequestSizeLimit middleware limits the size of request bodies.
+// Both declared lengths and chunked bodies (ContentLength -1) surface as an
+// *http.MaxBytesError from the body reader, which handlers turn into a 413
+// with SendBodyReadError. When applied again on a route, the inner limit
+// overrides the outer one, so bulk endpoints can allow more than the global
+// default.
 func RequestSizeLimit(maxBytes int64) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.ContentLength > maxBytes {
-				log.Warn().
-					Int64("content_length", r.ContentLength).
-					Int64("max_bytes", maxBytes).
-					Msg("request body too large")
-
-				SendJSONError(w, http.StatusRequestEntityTooLarge, "request_too_large", 
-					fmt.Sprintf("Request body too large. Maximum size is %d bytes", maxBytes))
+			if body, ok := r.Context().Value(bodyLimitKey{}).(*limitedBody); ok && body.reader == nil {
+				body.limit = maxBytes
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			// Limit the request body reader
-			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			body := &limitedBody{
+				w:             w,
+				body:          r.Body,
+				contentLength: r.ContentLength,
+				limit:         maxBytes,
+			}
+			r = r.WithContext(context.WithValue(r.Context(), bodyLimitKey{}, body))
+			r.Body = body
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// IsRequestTooLarge reports whether err came from reading past a RequestSizeLimit
+func IsRequestTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// SendRequestTooLarge sends the standard 413 request_too_large error
+func SendRequestTooLarge(w http.ResponseWriter, maxBytes int64) {
+	respond.Error(w, http.StatusRequestEntityTooLarge, "request_too_large",
+		fmt.Sprintf("Request body too large. Maximum size is %d bytes", maxBytes))
+}
+
+// SendBodyReadError maps an error from reading or decoding a request body to
+// 413 request_too_large when the size limit was hit, or 400 invalid_request_body
+func SendBodyReadError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		SendRequestTooLarge(w, maxBytesErr.Limit)
+		return
+	}
+
+	respond.Error(w, http.StatusBadRequest, "invalid_request_body", "Invalid request body")
+}
+
 // ContentTypeJSON middleware ensures request has JSON content type
 func ContentTypeJSON(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -135,7 +217,7 @@ func ContentTypeJSON(next http.Handler) http.Handler {
 					Str("path", r.URL.Path).
 					Msg("invalid content type")
 
-				SendJSONError(w, http.StatusUnsupportedMediaType, "invalid_content_type", 
+				respond.Error(w, http.StatusUnsupportedMediaType, "invalid_content_type", 
 					"Content-Type must be application/json")
 				return
 			}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// unsizedReader hides the length of its body so the client sends it chunked
+type unsizedReader struct {
+	io.Reader
+}
+
+func TestRequestSizeLimit(t *testing.T) {
+	decodeHandler := func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			SendBodyReadError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	r := chi.NewRouter()
+	r.Use(RequestSizeLimit(64))
+	r.Post("/projects", decodeHandler)
+	r.With(RequestSizeLimit(1024)).Post("/items/bulk", decodeHandler)
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	largeBody := `{"title":"` + strings.Repeat("a", 500) + `"}`
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		chunked        bool
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"small body", "/projects", `{"title":"Quiz"}`, false, http.StatusOK, ""},
+		{"oversized body with content length", "/projects", largeBody, false, http.StatusRequestEntityTooLarge, "request_too_large"},
+		{"oversized chunked body", "/projects", largeBody, true, http.StatusRequestEntityTooLarge, "request_too_large"},
+		{"malformed body", "/projects", `{"title":`, false, http.StatusBadRequest, "invalid_request_body"},
+		{"route override allows larger body", "/items/bulk", largeBody, false, http.StatusOK, ""},
+		{"route override allows larger chunked body", "/items/bulk", largeBody, true, http.StatusOK, ""},
+		{"route override still enforces its own limit", "/items/bulk", `{"title":"` + strings.Repeat("a", 2000) + `"}`, true, http.StatusRequestEntityTooLarge, "request_too_large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var body io.Reader = strings.NewReader(tt.body)
+			if tt.chunked {
+				body = unsizedReader{body}
+			}
+			req, err := http.NewRequest(http.MethodPost, server.URL+tt.path, body)
+			require.NoError(t, err)
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			// Act
+			resp, err := http.DefaultClient.Do(req)
+
+			// Assert
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			if tt.expectedCode != "" {
+				var errorResponse types.ErrorResponse
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&errorResponse))
+				assert.Equal(t, tt.expectedCode, errorResponse.Error.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Package pagination parses the limit and offset query parameters of list
+// endpoints. Values that are not integers, or fall outside the endpoint's
+// range, are rejected with a 400 invalid_pagination naming each bad
+// parameter, rather than silently replaced by defaults.
+package pagination
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/provemyself/backend/internal/http/respond"
+	"github.com/provemyself/backend/internal/i18n"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// MaxLimit caps the page size of every list endpoint, whatever maximum the
+// endpoint itself asks for
+const MaxLimit = 1000
+
+// Page is the window of a list to return
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// Error lists the pagination parameters a request got wrong
+type Error struct {
+	Fields []types.ValidationError
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Message
+	}
+	return "invalid pagination: " + strings.Join(messages, "; ")
+}
+
+// Parse reads limit and offset from r's query. Missing parameters take their
+// value from defaults. limit must be between 1 and max, itself capped at
+// MaxLimit, and offset must not be negative; otherwise an *Error is returned.
+func Parse(r *http.Request, defaults Page, max int) (Page, error) {
+	max = min(max, MaxLimit)
+	query := r.URL.Query()
+	page := defaults
+
+	var fields []types.ValidationError
+	if raw := query.Get("limit"); raw != "" {
+		limit, fieldErr := parseParam("limit", raw, 1, max)
+		if fieldErr != nil {
+			fields = append(fields, *fieldErr)
+		}
+		page.Limit = limit
+	}
+	if raw := query.Get("offset"); raw != "" {
+		offset, fieldErr := parseParam("offset", raw, 0, -1)
+		if fieldErr != nil {
+			fields = append(fields, *fieldErr)
+		}
+		page.Offset = offset
+	}
+
+	if fields != nil {
+		return defaults, &Error{Fields: fields}
+	}
+	return page, nil
+}
+
+// WriteError writes the 400 invalid_pagination response for an error
+// returned by Parse
+func WriteError(w http.ResponseWriter, err error) {
+	var fields []types.ValidationError
+	if paginationErr, ok := err.(*Error); ok {
+		fields = paginationErr.Fields
+	}
+	respond.FieldErrors(w, types.ErrorCodeInvalidPagination, "Invalid pagination parameters", fields)
+}
+
+// parseParam parses one parameter, which must be an integer of at least
+// lowest and, unless highest is negative, at most highest
+func parseParam(name, raw string, lowest, highest int) (int, *types.ValidationError) {
+	value, err := strconv.Atoi(raw)
+	switch {
+	case err != nil:
+		return 0, fieldError(name, "integer", "")
+	case value < lowest:
+		return 0, fieldError(name, "gte", strconv.Itoa(lowest))
+	case highest >= 0 && value > highest:
+		return 0, fieldError(name, "lte", strconv.Itoa(highest))
+	}
+	return value, nil
+}
+
+func fieldError(name, tag, param string) *types.ValidationError {
+	fieldErr := types.ValidationError{Field: name, Tag: tag, Param: param}
+	fieldErr.Message = i18n.Translate(i18n.DefaultLocale, "validation."+tag, respond.ValidationParams(fieldErr), tag)
+	return &fieldErr
+}
@@ -0,0 +1,72 @@
+package pagination
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	defaults := Page{Limit: 20}
+
+	tests := []struct {
+		name           string
+		query          string
+		max            int
+		expected       Page
+		expectedFields map[string]string
+	}{
+		{"defaults", "", 100, Page{Limit: 20}, nil},
+		{"explicit values", "limit=10&offset=5", 100, Page{Limit: 10, Offset: 5}, nil},
+		{"limit at maximum", "limit=100", 100, Page{Limit: 100}, nil},
+		{"zero limit", "limit=0", 100, defaults, map[string]string{"limit": "gte"}},
+		{"limit over maximum", "limit=101", 100, defaults, map[string]string{"limit": "lte"}},
+		{"negative offset", "offset=-3", 100, defaults, map[string]string{"offset": "gte"}},
+		{"non-integer values", "limit=abc&offset=1.5", 100, defaults, map[string]string{"limit": "integer", "offset": "integer"}},
+		{"endpoint maximum is capped globally", "limit=5000", 1_000_000, defaults, map[string]string{"limit": "lte"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects?"+tt.query, nil)
+
+			// Act
+			page, err := Parse(req, defaults, tt.max)
+
+			// Assert
+			assert.Equal(t, tt.expected, page)
+			if tt.expectedFields == nil {
+				require.NoError(t, err)
+				return
+			}
+			var paginationErr *Error
+			require.ErrorAs(t, err, &paginationErr)
+			fields := make(map[string]string, len(paginationErr.Fields))
+			for _, field := range paginationErr.Fields {
+				assert.NotEmpty(t, field.Message)
+				fields[field.Field] = field.Tag
+			}
+			assert.Equal(t, tt.expectedFields, fields)
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	// Arrange
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projects?limit=5000", nil)
+	_, err := Parse(req, Page{Limit: 20}, MaxLimit)
+	w := httptest.NewRecorder()
+
+	// Act
+	WriteError(w, err)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":{"code":"invalid_pagination","message":"Invalid pagination parameters","errors":[`+
+		`{"field":"limit","tag":"lte","param":"1000","message":"Field 'limit' must be less than or equal to 1000"}]}}`,
+		w.Body.String())
+}
@@ -0,0 +1,185 @@
+package respond
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// Format is a representation a list endpoint can be negotiated into
+type Format string
+
+const (
+	// FormatJSON is the default JSON envelope
+	FormatJSON Format = "json"
+	// FormatCSV is RFC 4180 CSV with a header row
+	FormatCSV Format = "csv"
+	// FormatNDJSON is one JSON object per line
+	FormatNDJSON Format = "ndjson"
+)
+
+// FormatParam is the query parameter that selects a format, overriding Accept
+const FormatParam = "format"
+
+var formatContentTypes = map[Format]string{
+	FormatJSON:   "application/json",
+	FormatCSV:    "text/csv; charset=utf-8",
+	FormatNDJSON: "application/x-ndjson",
+}
+
+var mediaTypeFormats = map[string]Format{
+	"application/json":     FormatJSON,
+	"text/csv":             FormatCSV,
+	"application/x-ndjson": FormatNDJSON,
+}
+
+// RowProvider is one page of list data, renderable in every Format
+type RowProvider interface {
+	// Envelope returns the body of the default JSON response
+	Envelope() interface{}
+	// Columns returns the CSV header row
+	Columns() []string
+	// Len returns the number of rows on the page
+	Len() int
+	// Record returns row i as CSV fields, one per column
+	Record(i int) []string
+	// Object returns row i as the value encoded on its NDJSON line
+	Object(i int) interface{}
+}
+
+// NegotiateFormat picks the list format for r and marks the response as
+// varying by Accept. The format query parameter wins over the Accept header;
+// among acceptable media types the highest q value wins, and anything else,
+// including a missing header, gets JSON. An unknown format parameter writes
+// a 400 unsupported_format error and returns false.
+func NegotiateFormat(w http.ResponseWriter, r *http.Request) (Format, bool) {
+	addVary(w.Header(), "Accept")
+
+	if param := r.URL.Query().Get(FormatParam); param != "" {
+		format := Format(strings.ToLower(param))
+		if _, ok := formatContentTypes[format]; !ok {
+			Error(w, http.StatusBadRequest, types.ErrorCodeUnsupportedFormat, "Unsupported response format",
+				"format must be one of json, csv, ndjson")
+			return "", false
+		}
+		return format, true
+	}
+
+	return acceptedFormat(r.Header.Get("Accept")), true
+}
+
+// Negotiated writes rows with a 200 status in the format the request asked
+// for. CSV and NDJSON are streamed row by row; once the first row is out a
+// failure can only be logged, so errors are always JSON and must be written
+// before calling Negotiated.
+func Negotiated(w http.ResponseWriter, r *http.Request, rows RowProvider) {
+	format, ok := NegotiateFormat(w, r)
+	if !ok {
+		return
+	}
+	if format == FormatJSON {
+		JSON(w, http.StatusOK, rows.Envelope())
+		return
+	}
+
+	w.Header().Set("Content-Type", formatContentTypes[format])
+	w.WriteHeader(http.StatusOK)
+
+	var err error
+	if format == FormatCSV {
+		err = writeCSV(w, rows)
+	} else {
+		err = writeNDJSON(w, rows)
+	}
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("format", string(format)).Msg("failed to write list response")
+	}
+}
+
+func writeCSV(w http.ResponseWriter, rows RowProvider) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(rows.Columns()); err != nil {
+		return err
+	}
+	for i := 0; i < rows.Len(); i++ {
+		record := rows.Record(i)
+		for j, field := range record {
+			record[j] = escapeFormula(field)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeNDJSON(w http.ResponseWriter, rows RowProvider) error {
+	bw := bufio.NewWriter(w)
+	// Encode appends the newline that terminates each line
+	enc := json.NewEncoder(bw)
+	for i := 0; i < rows.Len(); i++ {
+		if err := enc.Encode(rows.Object(i)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// escapeFormula stops spreadsheets from evaluating a field as a formula
+// (CSV injection) by prefixing it with a single quote
+func escapeFormula(field string) string {
+	if field == "" {
+		return field
+	}
+	switch field[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + field
+	}
+	return field
+}
+
+// acceptedFormat returns the supported format the Accept header prefers
+func acceptedFormat(accept string) Format {
+	best, bestQ := FormatJSON, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		format, ok := mediaTypeFormats[mediaType]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+// addVary adds value to the Vary header unless it is already listed
+func addVary(header http.Header, value string) {
+	for _, existing := range header.Values("Vary") {
+		for _, v := range strings.Split(existing, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), value) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", value)
+}
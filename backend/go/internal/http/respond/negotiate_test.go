@@ -0,0 +1,157 @@
+package respond
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+type row struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type testRows []row
+
+func (t testRows) Envelope() interface{}    { return map[string]interface{}{"rows": []row(t)} }
+func (t testRows) Columns() []string        { return []string{"id", "title"} }
+func (t testRows) Len() int                 { return len(t) }
+func (t testRows) Record(i int) []string    { return []string{t[i].ID, t[i].Title} }
+func (t testRows) Object(i int) interface{} { return t[i] }
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		accept   string
+		expected Format
+	}{
+		{name: "no preference", expected: FormatJSON},
+		{name: "wildcard", accept: "*/*", expected: FormatJSON},
+		{name: "csv", accept: "text/csv", expected: FormatCSV},
+		{name: "ndjson", accept: "application/x-ndjson", expected: FormatNDJSON},
+		{name: "unsupported media type", accept: "application/xml", expected: FormatJSON},
+		{name: "highest q wins", accept: "application/json;q=0.5, text/csv;q=0.9", expected: FormatCSV},
+		{name: "first wins on equal q", accept: "application/x-ndjson, text/csv", expected: FormatNDJSON},
+		{name: "refused with q=0", accept: "text/csv;q=0", expected: FormatJSON},
+		{name: "format parameter overrides accept", query: "?format=ndjson", accept: "text/csv", expected: FormatNDJSON},
+		{name: "format parameter is case-insensitive", query: "?format=CSV", expected: FormatCSV},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/projects"+tt.query, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			// Act
+			format, ok := NegotiateFormat(w, r)
+
+			// Assert
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, format)
+			assert.Equal(t, "Accept", w.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestNegotiateFormat_UnsupportedParameter(t *testing.T) {
+	// Arrange
+	w := httptest.NewRecorder()
+	w.Header().Set(RequestIDHeader, "req-123")
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/projects?format=xml", nil)
+	r.Header.Set("Accept", "text/csv")
+
+	// Act
+	_, ok := NegotiateFormat(w, r)
+
+	// Assert
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var response types.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, types.ErrorCodeUnsupportedFormat, response.Error.Code)
+	assert.Equal(t, "req-123", response.Error.RequestID)
+}
+
+func TestNegotiated(t *testing.T) {
+	rows := testRows{
+		{ID: "1", Title: `Say "hi", then leave`},
+		{ID: "2", Title: "two\nlines"},
+		{ID: "3", Title: "=SUM(A1:A2)"},
+	}
+
+	tests := []struct {
+		name         string
+		accept       string
+		expectedType string
+		expectedBody string
+	}{
+		{
+			name:         "json envelope by default",
+			expectedType: "application/json",
+			expectedBody: `{"rows":[{"id":"1","title":"Say \"hi\", then leave"},{"id":"2","title":"two\nlines"},{"id":"3","title":"=SUM(A1:A2)"}]}` + "\n",
+		},
+		{
+			name:         "csv with header and RFC 4180 quoting",
+			accept:       "text/csv",
+			expectedType: "text/csv; charset=utf-8",
+			expectedBody: "id,title\n" +
+				"1,\"Say \"\"hi\"\", then leave\"\n" +
+				"2,\"two\nlines\"\n" +
+				"3,'=SUM(A1:A2)\n",
+		},
+		{
+			name:         "ndjson with one object per line",
+			accept:       "application/x-ndjson",
+			expectedType: "application/x-ndjson",
+			expectedBody: `{"id":"1","title":"Say \"hi\", then leave"}` + "\n" +
+				`{"id":"2","title":"two\nlines"}` + "\n" +
+				`{"id":"3","title":"=SUM(A1:A2)"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/projects", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			// Act
+			Negotiated(w, r, rows)
+
+			// Assert
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+			assert.Equal(t, []string{"Accept"}, w.Header().Values("Vary"))
+		})
+	}
+}
+
+func TestNegotiated_EmptyCSVHasHeaderRow(t *testing.T) {
+	// Arrange
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/projects?format=csv", nil)
+
+	// Act
+	Negotiated(w, r, testRows{})
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "id,title\n", w.Body.String())
+}
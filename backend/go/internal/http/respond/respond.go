@@ -0,0 +1,140 @@
+// Package respond writes the API's JSON success and error responses, and
+// the CSV and NDJSON forms of list responses. Every handler and middleware
+// goes through these helpers so clients only ever see the canonical
+// types.ErrorResponse and types.ValidationErrorResponse shapes.
+package respond
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/i18n"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// RequestIDHeader is the header the request ID middleware sets on responses
+const RequestIDHeader = "X-Request-ID"
+
+// ContentLanguageHeader is the header the Localize middleware sets on
+// responses to the locale error messages are written in
+const ContentLanguageHeader = "X-Content-Language"
+
+// JSON writes data as a JSON response with the given status code.
+// A nil data writes the status with an empty body.
+func JSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if data == nil {
+		return
+	}
+
+	// Headers are already sent, so an encoding failure can only be logged
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Error().Err(err).Msg("failed to encode JSON response")
+	}
+}
+
+// Error writes a types.ErrorResponse. The first non-empty details value, if
+// any, is included as error.details. The request ID already set on the
+// response is embedded so clients can quote it when reporting problems.
+// For non-English responses the message is replaced by the catalog's
+// translation of code; details are passed through untranslated.
+func Error(w http.ResponseWriter, statusCode int, code, message string, details ...string) {
+	writeError(w, statusCode, types.ErrorDetail{Code: code, Message: message}, details)
+}
+
+// ItemError writes a types.ErrorResponse like Error, for the item at index
+// of a request's array of items
+func ItemError(w http.ResponseWriter, statusCode, index int, code, message string, details ...string) {
+	writeError(w, statusCode, types.ErrorDetail{Code: code, Message: message, Index: &index}, details)
+}
+
+// ProblemsError writes a types.ErrorResponse like Error, listing what keeps
+// a project from being published
+func ProblemsError(w http.ResponseWriter, statusCode int, code, message string, problems []types.PublishProblem, details ...string) {
+	writeError(w, statusCode, types.ErrorDetail{Code: code, Message: message, Problems: problems}, details)
+}
+
+// ConflictError writes a types.ErrorResponse like Error, with the current
+// state of the resource a write found at another version than it expected
+func ConflictError(w http.ResponseWriter, statusCode int, code, message string, current interface{}, details ...string) {
+	writeError(w, statusCode, types.ErrorDetail{Code: code, Message: message, Current: current}, details)
+}
+
+// writeError completes detail, which has the code, the message in English
+// and any fields of its own, and writes it
+func writeError(w http.ResponseWriter, statusCode int, detail types.ErrorDetail, details []string) {
+	if len(details) > 0 && details[0] != "" {
+		detail.Details = &details[0]
+	}
+	detail.Message = localize(w, "errors."+detail.Code, detail.Message)
+	detail.RequestID = w.Header().Get(RequestIDHeader)
+
+	JSON(w, statusCode, types.ErrorResponse{Error: detail})
+}
+
+// ValidationError writes a 400 types.ValidationErrorResponse listing the
+// individual field errors
+func ValidationError(w http.ResponseWriter, errors []types.ValidationError) {
+	FieldErrors(w, types.ErrorCodeValidationFailed, "Request validation failed", errors)
+}
+
+// FieldErrors writes a 400 types.ValidationErrorResponse with the given code,
+// for request problems other than body validation that are still reported
+// per field. Errors of validator rules the catalogs know are re-rendered in
+// the response's locale; other messages are kept as given.
+func FieldErrors(w http.ResponseWriter, code, message string, errors []types.ValidationError) {
+	if errors == nil {
+		errors = []types.ValidationError{}
+	}
+
+	if locale := w.Header().Get(ContentLanguageHeader); locale != "" && locale != i18n.DefaultLocale {
+		localized := make([]types.ValidationError, len(errors))
+		for i, fieldErr := range errors {
+			localized[i] = fieldErr
+			if key := "validation." + fieldErr.Tag; i18n.Has(key) {
+				localized[i].Message = i18n.Translate(locale, key, ValidationParams(fieldErr), fieldErr.Message)
+			}
+		}
+		errors = localized
+	}
+
+	JSON(w, http.StatusBadRequest, types.ValidationErrorResponse{
+		Error: types.ValidationErrorDetail{
+			Code:      code,
+			Message:   localize(w, "errors."+code, message),
+			Errors:    errors,
+			RequestID: w.Header().Get(RequestIDHeader),
+		},
+	})
+}
+
+// ValidationParams returns the template parameters of a field error's
+// message: the field's own name, e.g. title for items[3].title, the rule's
+// parameter and the rule itself
+func ValidationParams(fieldErr types.ValidationError) map[string]string {
+	field := fieldErr.Field
+	if i := strings.LastIndex(field, "."); i >= 0 {
+		field = field[i+1:]
+	}
+	return map[string]string{
+		"field": strings.ToLower(field),
+		"param": fieldErr.Param,
+		"tag":   fieldErr.Tag,
+	}
+}
+
+// localize resolves key in the locale negotiated for the response. English
+// responses keep message, the source text the catalogs translate, since
+// callers often make it more specific than the catalog entry.
+func localize(w http.ResponseWriter, key, message string) string {
+	locale := w.Header().Get(ContentLanguageHeader)
+	if locale == "" || locale == i18n.DefaultLocale {
+		return message
+	}
+	return i18n.Translate(locale, key, nil, message)
+}
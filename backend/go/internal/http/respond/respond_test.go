@@ -0,0 +1,135 @@
+package respond
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		name              string
+		requestID         string
+		details           []string
+		expectedDetails   *string
+		expectedRequestID string
+	}{
+		{"with request ID", "req-123", nil, nil, "req-123"},
+		{"without request ID", "", nil, nil, ""},
+		{"with details", "req-123", []string{"title is required"}, stringPtr("title is required"), "req-123"},
+		{"empty details omitted", "req-123", []string{""}, nil, "req-123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			rr := httptest.NewRecorder()
+			if tt.requestID != "" {
+				rr.Header().Set(RequestIDHeader, tt.requestID)
+			}
+
+			// Act
+			Error(rr, http.StatusNotFound, "project_not_found", "Project not found", tt.details...)
+
+			// Assert
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+			var response types.ErrorResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, "project_not_found", response.Error.Code)
+			assert.Equal(t, "Project not found", response.Error.Message)
+			assert.Equal(t, tt.expectedDetails, response.Error.Details)
+			assert.Equal(t, tt.expectedRequestID, response.Error.RequestID)
+		})
+	}
+}
+
+func TestItemError(t *testing.T) {
+	// Arrange
+	rr := httptest.NewRecorder()
+
+	// Act
+	ItemError(rr, http.StatusUnprocessableEntity, 0, "invalid_position", "Invalid position", "position 2 is taken")
+
+	// Assert
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	var response types.ErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "invalid_position", response.Error.Code)
+	require.NotNil(t, response.Error.Index, "index 0 is not omitted")
+	assert.Equal(t, 0, *response.Error.Index)
+	assert.Equal(t, stringPtr("position 2 is taken"), response.Error.Details)
+}
+
+func TestProblemsError(t *testing.T) {
+	// Arrange
+	rr := httptest.NewRecorder()
+	problems := []types.PublishProblem{{Reason: "no_items"}}
+
+	// Act
+	ProblemsError(rr, http.StatusUnprocessableEntity, "project_not_publishable", "Project cannot be published", problems)
+
+	// Assert
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	var response types.ErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "project_not_publishable", response.Error.Code)
+	assert.Equal(t, problems, response.Error.Problems)
+	assert.Nil(t, response.Error.Index)
+	assert.Nil(t, response.Error.Details)
+}
+
+func TestError_OmitsIndex(t *testing.T) {
+	// Arrange
+	rr := httptest.NewRecorder()
+
+	// Act
+	Error(rr, http.StatusNotFound, "project_not_found", "Project not found")
+
+	// Assert
+	assert.NotContains(t, rr.Body.String(), `"index"`)
+}
+
+func TestValidationError(t *testing.T) {
+	// Arrange
+	rr := httptest.NewRecorder()
+	rr.Header().Set(RequestIDHeader, "req-123")
+	errors := []types.ValidationError{
+		{Field: "title", Tag: "required", Message: "title is required"},
+	}
+
+	// Act
+	ValidationError(rr, errors)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var response types.ValidationErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, types.ErrorCodeValidationFailed, response.Error.Code)
+	assert.Equal(t, errors, response.Error.Errors)
+	assert.Equal(t, "req-123", response.Error.RequestID)
+}
+
+func TestJSON_NilData(t *testing.T) {
+	// Arrange
+	rr := httptest.NewRecorder()
+
+	// Act
+	JSON(rr, http.StatusAccepted, nil)
+
+	// Assert
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Empty(t, rr.Body.String())
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
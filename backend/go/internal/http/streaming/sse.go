@@ -0,0 +1,64 @@
+package streaming
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultKeepAlive is how often an idle event stream sends a comment line,
+// keeping proxies and load balancers from closing the connection
+const DefaultKeepAlive = 15 * time.Second
+
+// SSE writes a server-sent event stream. It is not safe for concurrent use.
+type SSE struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+}
+
+// NewSSE starts an event stream on w, writing the status and headers
+func NewSSE(w http.ResponseWriter) (*SSE, error) {
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// Stop nginx from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	s := &SSE{w: w, controller: http.NewResponseController(w)}
+	if err := s.controller.Flush(); err != nil {
+		return nil, fmt.Errorf("response does not support streaming: %w", err)
+	}
+	return s, nil
+}
+
+// Send writes one event and flushes it to the client. Multi-line data is
+// split across data fields as the format requires.
+func (s *SSE) Send(event string, data []byte) error {
+	var buf bytes.Buffer
+	if event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", event)
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	return s.write(buf.Bytes())
+}
+
+// KeepAlive writes a comment line, which clients ignore
+func (s *SSE) KeepAlive() error {
+	return s.write([]byte(": keep-alive\n\n"))
+}
+
+func (s *SSE) write(p []byte) error {
+	if _, err := s.w.Write(p); err != nil {
+		return err
+	}
+	return s.controller.Flush()
+}
@@ -0,0 +1,27 @@
+package streaming
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSE_WritesEventStreamFormat(t *testing.T) {
+	// Arrange
+	rr := httptest.NewRecorder()
+	sse, err := NewSSE(rr)
+	require.NoError(t, err)
+
+	// Act
+	require.NoError(t, sse.Send("jobs", []byte("{\"a\":1}\n{\"b\":2}")))
+	require.NoError(t, sse.KeepAlive())
+	require.NoError(t, sse.Send("", []byte("ping")))
+
+	// Assert
+	assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+	assert.True(t, rr.Flushed)
+	assert.Equal(t, "event: jobs\ndata: {\"a\":1}\ndata: {\"b\":2}\n\n: keep-alive\n\ndata: ping\n\n", rr.Body.String())
+}
@@ -0,0 +1,76 @@
+// Package streaming serves long-lived responses (server-sent events, large
+// exports and downloads) from the main API server. The server's
+// WriteTimeout bounds a whole response, so it would cut these off midway;
+// instead, a streaming route pushes the connection's write deadline forward
+// before every write, so only a stalled client is disconnected.
+//
+// Streaming routes are registered only in the "Streaming" groups of
+// cmd/api/routes.go, behind Middleware and outside every Timeout group;
+// anywhere else the server's WriteTimeout applies. Keep this list in sync:
+//
+//	GET /api/{v1,v2}/admin/jobs/events                       job status (server-sent events)
+//	GET /api/{v1,v2}/projects/{projectId}/export             project export (zip)
+//	GET /api/{v1,v2}/projects/{projectId}/items/locks/events item locks (server-sent events)
+package streaming
+
+import (
+	"net/http"
+	"time"
+)
+
+// DefaultWriteTimeout bounds a single write on a streaming response
+const DefaultWriteTimeout = 30 * time.Second
+
+// Middleware marks a route as streaming: every write on the response gets
+// writeTimeout from the moment it starts instead of sharing the server's
+// WriteTimeout with the rest of the response.
+func Middleware(writeTimeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			dw := &deadlineWriter{
+				ResponseWriter: w,
+				controller:     http.NewResponseController(w),
+				timeout:        writeTimeout,
+			}
+			// Lift the server's deadline now: it started when the request
+			// was read and may be nearly used up before the first write
+			dw.extend()
+
+			next.ServeHTTP(dw, r)
+		})
+	}
+}
+
+// deadlineWriter extends the connection's write deadline before each write
+type deadlineWriter struct {
+	http.ResponseWriter
+	controller *http.ResponseController
+	timeout    time.Duration
+}
+
+// extend pushes the write deadline forward. Writers that cannot set one
+// (e.g. httptest.ResponseRecorder) are left alone.
+func (w *deadlineWriter) extend() {
+	w.controller.SetWriteDeadline(time.Now().Add(w.timeout))
+}
+
+func (w *deadlineWriter) WriteHeader(statusCode int) {
+	w.extend()
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *deadlineWriter) Write(p []byte) (int, error) {
+	w.extend()
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush sends buffered data to the client
+func (w *deadlineWriter) Flush() {
+	w.extend()
+	w.controller.Flush()
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (w *deadlineWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,195 @@
+// Package i18n resolves the human-readable text of API errors in the
+// client's language. Error codes never change; only their messages are
+// looked up in per-locale message catalogs, embedded from catalogs/*.json.
+// Messages may contain {name} placeholders filled from template parameters.
+// A locale missing a key falls back to its base language and then to
+// English, and the gap is logged at error level so catalogs stay complete.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultLocale is the source language of the catalogs and the last entry
+// of every fallback chain
+const DefaultLocale = "en"
+
+//go:embed catalogs/*.json
+var catalogFiles embed.FS
+
+// catalogs maps locale to message key to message template
+var catalogs = mustLoadCatalogs(catalogFiles)
+
+// reportedMissing holds the locale/key pairs already logged as missing, so a
+// gap is reported once per process rather than on every request
+var reportedMissing sync.Map
+
+func mustLoadCatalogs(files fs.FS) map[string]map[string]string {
+	names, err := fs.Glob(files, "catalogs/*.json")
+	if err != nil {
+		panic(err)
+	}
+
+	loaded := make(map[string]map[string]string, len(names))
+	for _, name := range names {
+		data, err := fs.ReadFile(files, name)
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", name, err))
+		}
+		loaded[strings.TrimSuffix(path.Base(name), ".json")] = messages
+	}
+	return loaded
+}
+
+// Locales returns the locales with a catalog, sorted
+func Locales() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Keys returns the message keys of a locale's catalog, sorted
+func Keys(locale string) []string {
+	keys := make([]string, 0, len(catalogs[locale]))
+	for key := range catalogs[locale] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Has reports whether the English catalog defines key
+func Has(key string) bool {
+	_, ok := catalogs[DefaultLocale][key]
+	return ok
+}
+
+// Negotiate picks the catalog locale that best matches an Accept-Language
+// header. Language ranges are tried in order of their quality values; a
+// regional range such as es-MX falls back to its base language. Ranges with
+// q=0 are excluded, and the wildcard or no match selects DefaultLocale.
+func Negotiate(acceptLanguage string) string {
+	type languageRange struct {
+		tag     string
+		quality float64
+	}
+
+	var ranges []languageRange
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, ok := strings.Cut(param, "=")
+			if !ok || strings.TrimSpace(name) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				parsed = 0
+			}
+			quality = parsed
+		}
+		if quality > 0 {
+			ranges = append(ranges, languageRange{tag: tag, quality: quality})
+		}
+	}
+
+	// Equal qualities keep the client's order
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	for _, r := range ranges {
+		if r.tag == "*" {
+			return DefaultLocale
+		}
+		for _, candidate := range languageChain(r.tag) {
+			if _, ok := catalogs[candidate]; ok {
+				return candidate
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+// Translate renders key in locale, filling {name} placeholders from params.
+// A key the locale lacks falls back to its base language and then English,
+// and fallback is returned when no catalog has it. Either gap is logged.
+func Translate(locale, key string, params map[string]string, fallback string) string {
+	for _, candidate := range fallbackChain(locale) {
+		message, ok := catalogs[candidate][key]
+		if !ok {
+			continue
+		}
+		if candidate != strings.ToLower(locale) {
+			reportMissing(locale, key)
+		}
+		return render(message, params)
+	}
+
+	reportMissing(DefaultLocale, key)
+	return fallback
+}
+
+// languageChain lists a language tag and, for regional tags, its base
+// language, e.g. es-mx then es
+func languageChain(tag string) []string {
+	chain := []string{tag}
+	if base, _, ok := strings.Cut(tag, "-"); ok {
+		chain = append(chain, base)
+	}
+	return chain
+}
+
+// fallbackChain is the language chain of locale followed by DefaultLocale
+func fallbackChain(locale string) []string {
+	chain := languageChain(strings.ToLower(locale))
+	if chain[len(chain)-1] != DefaultLocale {
+		chain = append(chain, DefaultLocale)
+	}
+	return chain
+}
+
+func render(message string, params map[string]string) string {
+	if len(params) == 0 {
+		return message
+	}
+
+	replacements := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		replacements = append(replacements, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(replacements...).Replace(message)
+}
+
+func reportMissing(locale, key string) {
+	if _, reported := reportedMissing.LoadOrStore(locale+"|"+key, struct{}{}); reported {
+		return
+	}
+	log.Error().
+		Str("locale", locale).
+		Str("key", key).
+		Msg("message catalog is missing a key")
+}
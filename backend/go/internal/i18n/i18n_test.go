@@ -0,0 +1,90 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{[a-z]+\}`)
+
+func TestCatalogs_AreComplete(t *testing.T) {
+	require.ElementsMatch(t, []string{"de", "en", "es", "he"}, Locales())
+
+	for _, locale := range Locales() {
+		t.Run(locale, func(t *testing.T) {
+			// Every locale translates exactly the English keys
+			assert.Equal(t, Keys(DefaultLocale), Keys(locale))
+
+			// and keeps every placeholder of the English message
+			for _, key := range Keys(DefaultLocale) {
+				message, ok := catalogs[locale][key]
+				if !ok {
+					continue
+				}
+				assert.ElementsMatch(t,
+					placeholderPattern.FindAllString(catalogs[DefaultLocale][key], -1),
+					placeholderPattern.FindAllString(message, -1),
+					"placeholders of %s", key)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		expected       string
+	}{
+		{"no header", "", "en"},
+		{"exact match", "de", "de"},
+		{"region falls back to its language", "es-MX", "es"},
+		{"case insensitive", "HE-il", "he"},
+		{"highest quality wins", "de;q=0.5, es;q=0.9, en;q=0.1", "es"},
+		{"unsupported language is skipped", "fr-CA, fr;q=0.9, he;q=0.8", "he"},
+		{"equal qualities keep client order", "es;q=0.7, de;q=0.7", "es"},
+		{"q=0 excludes a language", "es;q=0, de;q=0.2", "de"},
+		{"wildcard selects the default", "fr, *;q=0.5, es;q=0.1", "en"},
+		{"malformed quality is excluded", "es;q=high, de;q=0.3", "de"},
+		{"nothing supported", "fr, ja", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			locale := Negotiate(tt.acceptLanguage)
+
+			// Assert
+			assert.Equal(t, tt.expected, locale)
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name     string
+		locale   string
+		key      string
+		params   map[string]string
+		expected string
+	}{
+		{"translated message", "es", "errors.project_not_found", nil, "Proyecto no encontrado"},
+		{"template parameters", "de", "validation.min", map[string]string{"field": "title", "param": "3"}, "Das Feld 'title' muss mindestens 3 Zeichen lang sein"},
+		{"regional locale uses its language", "es-mx", "errors.item_not_found", nil, "Elemento no encontrado"},
+		{"unknown locale falls back to English", "fr", "errors.item_not_found", nil, "Item not found"},
+		{"unknown key returns the fallback", "es", "errors.no_such_code", nil, "fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			message := Translate(tt.locale, tt.key, tt.params, "fallback")
+
+			// Assert
+			assert.Equal(t, tt.expected, message)
+		})
+	}
+}
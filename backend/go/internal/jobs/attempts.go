@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// PurgePreviewAttempts deletes the authors' previews started longer than
+// retention ago, with their answers and results
+func PurgePreviewAttempts(store core.AttemptStore, retention time.Duration) Job {
+	return Func("attempts.purge_previews", func(ctx context.Context) error {
+		deleted, err := store.PurgePreviews(ctx, time.Now().Add(-retention))
+		if err != nil {
+			return err
+		}
+
+		if deleted > 0 {
+			log.Ctx(ctx).Info().Int64("deleted", deleted).Msg("purged preview attempts")
+		}
+		return nil
+	})
+}
+
+// ApplyAttemptRetention erases the attempts started longer than retention
+// ago, in every project, deleting or anonymizing them as mode says. The
+// service writes the audit line.
+func ApplyAttemptRetention(service *core.AttemptService, retention time.Duration, mode core.RetentionMode) Job {
+	return Func("attempts.apply_retention", func(ctx context.Context) error {
+		_, err := service.ApplyRetention(ctx, time.Now().Add(-retention), mode)
+		return err
+	})
+}
@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// PurgeExpiredIdempotencyKeys deletes idempotency records once they stop
+// being honored, keeping the table from growing without bound
+func PurgeExpiredIdempotencyKeys(store core.IdempotencyStore) Job {
+	return Func("idempotency.purge_expired", func(ctx context.Context) error {
+		deleted, err := store.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if deleted > 0 {
+			log.Ctx(ctx).Info().Int64("deleted", deleted).Msg("purged expired idempotency keys")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// PurgeDeletedItems removes the items deleted longer than retention ago,
+// which can no longer be restored
+func PurgeDeletedItems(store core.ItemStore, retention time.Duration) Job {
+	return Func("items.purge_deleted", func(ctx context.Context) error {
+		deleted, err := store.PurgeDeleted(ctx, time.Now().Add(-retention))
+		if err != nil {
+			return err
+		}
+
+		if deleted > 0 {
+			log.Ctx(ctx).Info().Int64("deleted", deleted).Msg("purged deleted items")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,95 @@
+// Package jobs runs background work on a schedule. Jobs run on fixed
+// intervals or cron expressions, at most once at a time across every
+// replica (see Locker), with panics recovered and the outcome of each run
+// recorded for the admin API and metrics.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Job is a unit of background work. Run should return promptly once ctx is
+// done.
+type Job interface {
+	Name() string
+	Run(ctx context.Context) error
+}
+
+// Func adapts a function into a Job
+func Func(name string, fn func(ctx context.Context) error) Job {
+	return funcJob{name: name, fn: fn}
+}
+
+type funcJob struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (j funcJob) Name() string                  { return j.name }
+func (j funcJob) Run(ctx context.Context) error { return j.fn(ctx) }
+
+// Locker makes a job single-flight across replicas. TryLock does not wait:
+// it reports ok=false when another replica holds the key. unlock releases
+// the lock and must be called once the run is over.
+type Locker interface {
+	TryLock(ctx context.Context, key string) (unlock func(), ok bool, err error)
+}
+
+// LockerFunc adapts a function into a Locker
+type LockerFunc func(ctx context.Context, key string) (unlock func(), ok bool, err error)
+
+// TryLock calls f
+func (f LockerFunc) TryLock(ctx context.Context, key string) (unlock func(), ok bool, err error) {
+	return f(ctx, key)
+}
+
+// Observer is notified after every run, e.g. to feed metrics
+type Observer interface {
+	ObserveJob(name string, duration time.Duration, err error)
+}
+
+var (
+	// ErrJobNotFound is returned for an unregistered job name
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobRunning is returned when triggering a job that is already running
+	ErrJobRunning = errors.New("job is already running")
+	// ErrNotRunning is returned when triggering a job before the scheduler
+	// started or after it stopped
+	ErrNotRunning = errors.New("scheduler is not running")
+	// ErrPanic wraps the value a job panicked with
+	ErrPanic = errors.New("job panicked")
+)
+
+// Options tune how a registered job runs
+type Options struct {
+	// Timeout bounds a single run. Zero means no limit beyond shutdown.
+	Timeout time.Duration
+	// PerReplica runs the job on every replica instead of once across the
+	// cluster, e.g. to refresh state each replica caches
+	PerReplica bool
+	// RunAtStart runs the job as soon as the scheduler starts, before its
+	// first scheduled time
+	RunAtStart bool
+}
+
+// Status is a job's schedule and the outcome of its last run on this
+// replica. Runs skipped because another replica held the lock are counted
+// but leave the last run untouched.
+type Status struct {
+	Name       string
+	Schedule   string
+	PerReplica bool
+	Running    bool
+	// NextRunAt is zero when the schedule has no upcoming time
+	NextRunAt      time.Time
+	LastStartedAt  time.Time
+	LastFinishedAt time.Time
+	LastDuration   time.Duration
+	// LastError is empty when the last run succeeded
+	LastError string
+	Runs      int64
+	Failures  int64
+	Skipped   int64
+}
@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// PurgeExpiredLTISessions deletes the LTI logins that were never completed
+// and the participant sessions that have expired
+func PurgeExpiredLTISessions(store core.LTIStore) Job {
+	return Func("lti.purge_expired", func(ctx context.Context) error {
+		deleted, err := store.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if deleted > 0 {
+			log.Ctx(ctx).Info().Int64("deleted", deleted).Msg("purged expired LTI states and sessions")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,255 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a job runs
+type Schedule interface {
+	// Next returns the first run time after t, or the zero time if there
+	// is none
+	Next(t time.Time) time.Time
+	String() string
+}
+
+// Every runs a job at a fixed interval, measured from the end of the
+// previous run
+func Every(interval time.Duration) Schedule {
+	return everySchedule(interval)
+}
+
+type everySchedule time.Duration
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+func (s everySchedule) String() string {
+	return "@every " + time.Duration(s).String()
+}
+
+// OnDemand never runs a job by itself: it runs only when triggered
+func OnDemand() Schedule {
+	return onDemandSchedule{}
+}
+
+type onDemandSchedule struct{}
+
+func (onDemandSchedule) Next(time.Time) time.Time {
+	return time.Time{}
+}
+
+func (onDemandSchedule) String() string {
+	return "@on_demand"
+}
+
+// cronDescriptors are the shorthand schedules ParseSchedule accepts
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule parses "@every <duration>", a descriptor such as "@hourly",
+// or a five-field cron expression (minute hour day-of-month month
+// day-of-week). Fields accept *, numbers, ranges (1-5), lists (1,15) and
+// steps (*/10, 0-30/5); day-of-week 0 and 7 are Sunday. Cron times are
+// evaluated in the location of the time passed to Next, UTC for the
+// scheduler.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("invalid schedule %q: interval must be positive", spec)
+		}
+		return Every(interval), nil
+	}
+
+	expr := spec
+	if descriptor, ok := cronDescriptors[spec]; ok {
+		expr = descriptor
+	}
+
+	schedule, err := parseCron(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	schedule.spec = spec
+	return schedule, nil
+}
+
+// cronField is a set of allowed values, one bit per value
+type cronField uint64
+
+func (f cronField) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+type cronSchedule struct {
+	spec                         string
+	minute, hour, dom, month     cronField
+	dow                          cronField
+	domRestricted, dowRestricted bool
+}
+
+type fieldBounds struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []fieldBounds{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+func parseCron(expr string) (*cronSchedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("expected %d fields, got %d", len(cronFields), len(parts))
+	}
+
+	var fields [5]cronField
+	for i, part := range parts {
+		field, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cronFields[i].name, err)
+		}
+		fields[i] = field
+	}
+
+	// 7 is an alias for Sunday
+	dow := fields[4]
+	if dow.has(7) {
+		dow = dow&^(1<<7) | 1
+	}
+
+	return &cronSchedule{
+		minute:        fields[0],
+		hour:          fields[1],
+		dom:           fields[2],
+		month:         fields[3],
+		dow:           dow,
+		domRestricted: !strings.HasPrefix(parts[2], "*"),
+		dowRestricted: !strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+func parseCronField(part string, bounds fieldBounds) (cronField, error) {
+	var field cronField
+
+	for _, item := range strings.Split(part, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		low, high := bounds.min, bounds.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			lowPart, highPart, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseCronValue(lowPart, bounds); err != nil {
+				return 0, err
+			}
+			if high, err = parseCronValue(highPart, bounds); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			value, err := parseCronValue(rangePart, bounds)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			// "5/15" means from 5 to the end in steps of 15
+			if !hasStep {
+				high = value
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			field |= 1 << uint(v)
+		}
+	}
+
+	return field, nil
+}
+
+func parseCronValue(s string, bounds fieldBounds) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < bounds.min || v > bounds.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, bounds.min, bounds.max)
+	}
+	return v, nil
+}
+
+// cronSearchLimit bounds Next for expressions that never match, such as
+// February 30th
+const cronSearchLimit = 5 // years
+
+// Next skips ahead a whole month, day or hour at a time when that unit
+// cannot match, so it takes at most a few hundred steps
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	limit := t.AddDate(cronSearchLimit, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !s.month.has(int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !s.hour.has(t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !s.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// dayMatches follows cron: when both day fields are restricted, a day
+// matching either one matches
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom.has(t.Day())
+	dow := s.dow.has(int(t.Weekday()))
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+func (s *cronSchedule) String() string {
+	return s.spec
+}
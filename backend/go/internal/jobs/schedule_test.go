@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule_Next(t *testing.T) {
+	// Wednesday
+	from := time.Date(2026, 3, 11, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		spec     string
+		expected time.Time
+	}{
+		{"every interval", "@every 30s", from.Add(30 * time.Second)},
+		{"every minute", "* * * * *", time.Date(2026, 3, 11, 10, 8, 0, 0, time.UTC)},
+		{"step", "*/15 * * * *", time.Date(2026, 3, 11, 10, 15, 0, 0, time.UTC)},
+		{"hourly descriptor", "@hourly", time.Date(2026, 3, 11, 11, 0, 0, 0, time.UTC)},
+		{"daily at a fixed time, later today", "30 14 * * *", time.Date(2026, 3, 11, 14, 30, 0, 0, time.UTC)},
+		{"daily at a fixed time, already passed", "0 3 * * *", time.Date(2026, 3, 12, 3, 0, 0, 0, time.UTC)},
+		{"range and list", "0 9-17 * * 1,5", time.Date(2026, 3, 13, 9, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 0 * * 7", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"month rollover", "0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"day of month or day of week", "0 0 20 * 5", time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"never matches", "0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			schedule, err := ParseSchedule(tt.spec)
+			require.NoError(t, err)
+
+			// Act
+			next := schedule.Next(from)
+
+			// Assert
+			assert.Equal(t, tt.expected, next)
+			assert.Equal(t, tt.spec, schedule.String())
+		})
+	}
+}
+
+func TestOnDemand_NeverRuns(t *testing.T) {
+	// Act
+	next := OnDemand().Next(time.Date(2026, 3, 11, 10, 7, 30, 0, time.UTC))
+
+	// Assert
+	assert.True(t, next.IsZero())
+}
+
+func TestParseSchedule_RejectsInvalidSpecs(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{"empty", ""},
+		{"too few fields", "* * * *"},
+		{"out of range", "60 * * * *"},
+		{"reversed range", "0 17-9 * * *"},
+		{"zero step", "*/0 * * * *"},
+		{"not a number", "a * * * *"},
+		{"unknown descriptor", "@fortnightly"},
+		{"bad interval", "@every soon"},
+		{"negative interval", "@every -5m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := ParseSchedule(tt.spec)
+
+			// Assert
+			assert.Error(t, err)
+		})
+	}
+}
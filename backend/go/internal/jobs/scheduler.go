@@ -0,0 +1,281 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Scheduler runs registered jobs on their schedules. Each job runs in its
+// own goroutine and never overlaps itself; unless registered PerReplica, a
+// run is skipped when another replica holds the job's lock. It is safe for
+// concurrent use.
+type Scheduler struct {
+	locker   Locker
+	observer Observer
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	running bool
+}
+
+type entry struct {
+	job      Job
+	schedule Schedule
+	opts     Options
+	// trigger asks the job's loop for an immediate run
+	trigger chan struct{}
+	// status is guarded by the scheduler's mutex
+	status Status
+}
+
+// NewScheduler creates a scheduler. A nil locker disables cross-replica
+// locking, e.g. for single-replica deployments and tests; observer may be
+// nil.
+func NewScheduler(locker Locker, observer Observer) *Scheduler {
+	return &Scheduler{
+		locker:   locker,
+		observer: observer,
+		now:      time.Now,
+		entries:  make(map[string]*entry),
+	}
+}
+
+// Register adds a job. Jobs must be registered before Run and names must
+// be unique.
+func (s *Scheduler) Register(job Job, schedule Schedule, opts Options) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := job.Name()
+	if s.running {
+		return fmt.Errorf("cannot register job %q: scheduler already running", name)
+	}
+	if _, exists := s.entries[name]; exists {
+		return fmt.Errorf("job %q is already registered", name)
+	}
+
+	s.entries[name] = &entry{
+		job:      job,
+		schedule: schedule,
+		opts:     opts,
+		trigger:  make(chan struct{}, 1),
+		status: Status{
+			Name:       name,
+			Schedule:   schedule.String(),
+			PerReplica: opts.PerReplica,
+		},
+	}
+	return nil
+}
+
+// Run runs every job until ctx is done, then waits for in-flight runs to
+// return. Running jobs see ctx cancelled, so a stop hook bounds how long
+// they get to finish.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return errors.New("scheduler already running")
+	}
+	s.running = true
+	entries := make([]*entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, e := range entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, e)
+		}()
+	}
+
+	<-ctx.Done()
+	wg.Wait()
+
+	s.mu.Lock()
+	s.running = false
+	s.mu.Unlock()
+
+	return ctx.Err()
+}
+
+// Trigger asks for an immediate run of the named job outside its schedule.
+// The run happens in the background, subject to the job's lock like any
+// other run.
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if !s.running {
+		return ErrNotRunning
+	}
+	if e.status.Running {
+		return ErrJobRunning
+	}
+
+	select {
+	case e.trigger <- struct{}{}:
+		return nil
+	default:
+		// A trigger is already pending
+		return ErrJobRunning
+	}
+}
+
+// Statuses returns every job's status, sorted by name
+func (s *Scheduler) Statuses() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, 0, len(s.entries))
+	for _, e := range s.entries {
+		statuses = append(statuses, e.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// loop runs one job until ctx is done. The next run is scheduled from the
+// end of the previous one, so a slow run never piles up behind itself.
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	if e.opts.RunAtStart {
+		s.execute(ctx, e)
+	}
+
+	for {
+		next := e.schedule.Next(s.now().UTC())
+		s.update(e, func(status *Status) {
+			status.NextRunAt = next
+		})
+
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if !next.IsZero() {
+			timer = time.NewTimer(next.Sub(s.now()))
+			fire = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-fire:
+		case <-e.trigger:
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+
+		s.execute(ctx, e)
+	}
+}
+
+// execute runs the job once if this replica gets its lock
+func (s *Scheduler) execute(ctx context.Context, e *entry) {
+	name := e.job.Name()
+	logger := log.With().Str("job", name).Logger()
+	ctx = logger.WithContext(ctx)
+
+	if s.locker != nil && !e.opts.PerReplica {
+		unlock, ok, err := s.locker.TryLock(ctx, "job:"+name)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warn().Err(err).Msg("failed to acquire job lock")
+			}
+			s.update(e, func(status *Status) {
+				status.Skipped++
+			})
+			return
+		}
+		if !ok {
+			logger.Debug().Msg("job is running on another replica, skipping")
+			s.update(e, func(status *Status) {
+				status.Skipped++
+			})
+			return
+		}
+		defer unlock()
+	}
+
+	start := s.now()
+	s.update(e, func(status *Status) {
+		status.Running = true
+		status.LastStartedAt = start
+	})
+
+	err := s.run(ctx, e)
+	duration := s.now().Sub(start)
+
+	s.update(e, func(status *Status) {
+		status.Running = false
+		status.LastFinishedAt = start.Add(duration)
+		status.LastDuration = duration
+		status.LastError = ""
+		status.Runs++
+		if err != nil {
+			status.LastError = err.Error()
+			status.Failures++
+		}
+	})
+
+	if s.observer != nil {
+		s.observer.ObserveJob(name, duration, err)
+	}
+
+	switch {
+	case err == nil:
+		logger.Debug().Dur("duration", duration).Msg("job finished")
+	case ctx.Err() != nil:
+		logger.Warn().Err(err).Dur("duration", duration).Msg("job interrupted by shutdown")
+	default:
+		logger.Error().Err(err).Dur("duration", duration).Msg("job failed")
+	}
+}
+
+// run calls the job under its timeout, turning a panic into an error so one
+// broken job cannot take the process down
+func (s *Scheduler) run(ctx context.Context, e *entry) (err error) {
+	if e.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.opts.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			log.Ctx(ctx).Error().
+				Interface("panic", p).
+				Bytes("stack", debug.Stack()).
+				Msg("job panicked")
+			err = fmt.Errorf("%w: %v", ErrPanic, p)
+		}
+	}()
+
+	return e.job.Run(ctx)
+}
+
+func (s *Scheduler) update(e *entry, fn func(status *Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&e.status)
+}
@@ -0,0 +1,267 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// never is a schedule without upcoming runs, for jobs that only run when
+// triggered
+var never = func() Schedule {
+	schedule, err := ParseSchedule("0 0 30 2 *")
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}()
+
+// sharedLocker stands in for Postgres advisory locks shared by replicas
+type sharedLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *sharedLocker) TryLock(ctx context.Context, key string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held[key] {
+		return nil, false, nil
+	}
+	l.held[key] = true
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, key)
+	}, true, nil
+}
+
+// recordingObserver collects ObserveJob calls
+type recordingObserver struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (o *recordingObserver) ObserveJob(name string, duration time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.errs = append(o.errs, err)
+}
+
+func (o *recordingObserver) observed() []error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]error(nil), o.errs...)
+}
+
+// start runs s until the test ends
+func start(t *testing.T, s *Scheduler) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.running
+	}, time.Second, time.Millisecond)
+}
+
+func statusOf(s *Scheduler, name string) Status {
+	for _, status := range s.Statuses() {
+		if status.Name == name {
+			return status
+		}
+	}
+	return Status{}
+}
+
+func TestScheduler_RunsIntervalJobs(t *testing.T) {
+	// Arrange
+	var runs atomic.Int32
+	s := NewScheduler(nil, nil)
+	require.NoError(t, s.Register(Func("tick", func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}), Every(5*time.Millisecond), Options{}))
+
+	// Act
+	start(t, s)
+
+	// Assert
+	require.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+	status := statusOf(s, "tick")
+	assert.Equal(t, "@every 5ms", status.Schedule)
+	assert.GreaterOrEqual(t, status.Runs, int64(3))
+	assert.Empty(t, status.LastError)
+	assert.False(t, status.LastFinishedAt.IsZero())
+	assert.False(t, status.NextRunAt.IsZero())
+}
+
+func TestScheduler_RecoversPanics(t *testing.T) {
+	// Arrange
+	observer := &recordingObserver{}
+	s := NewScheduler(nil, observer)
+	require.NoError(t, s.Register(Func("broken", func(ctx context.Context) error {
+		panic("nil map")
+	}), never, Options{}))
+	start(t, s)
+
+	// Act
+	require.NoError(t, s.Trigger("broken"))
+
+	// Assert
+	require.Eventually(t, func() bool { return statusOf(s, "broken").Failures == 1 }, time.Second, time.Millisecond)
+	assert.Contains(t, statusOf(s, "broken").LastError, "nil map")
+	require.Len(t, observer.observed(), 1)
+	assert.ErrorIs(t, observer.observed()[0], ErrPanic)
+}
+
+func TestScheduler_RecordsFailuresAndRecovery(t *testing.T) {
+	// Arrange
+	var fail atomic.Bool
+	fail.Store(true)
+	s := NewScheduler(nil, nil)
+	require.NoError(t, s.Register(Func("flaky", func(ctx context.Context) error {
+		if fail.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	}), never, Options{}))
+	start(t, s)
+
+	// Act
+	require.NoError(t, s.Trigger("flaky"))
+	require.Eventually(t, func() bool { return statusOf(s, "flaky").Runs == 1 }, time.Second, time.Millisecond)
+	failed := statusOf(s, "flaky")
+
+	fail.Store(false)
+	require.NoError(t, s.Trigger("flaky"))
+	require.Eventually(t, func() bool { return statusOf(s, "flaky").Runs == 2 }, time.Second, time.Millisecond)
+	recovered := statusOf(s, "flaky")
+
+	// Assert
+	assert.Equal(t, "connection refused", failed.LastError)
+	assert.Empty(t, recovered.LastError)
+	assert.Equal(t, int64(1), recovered.Failures)
+}
+
+func TestScheduler_SingleFlightAcrossReplicas(t *testing.T) {
+	// Arrange
+	locker := &sharedLocker{held: make(map[string]bool)}
+	release := make(chan struct{})
+	var running atomic.Int32
+
+	job := Func("report", func(ctx context.Context) error {
+		running.Add(1)
+		<-release
+		return nil
+	})
+	replicaA := NewScheduler(locker, nil)
+	replicaB := NewScheduler(locker, nil)
+	require.NoError(t, replicaA.Register(job, never, Options{}))
+	require.NoError(t, replicaB.Register(job, never, Options{}))
+	start(t, replicaA)
+	start(t, replicaB)
+
+	// Act
+	require.NoError(t, replicaA.Trigger("report"))
+	require.Eventually(t, func() bool { return statusOf(replicaA, "report").Running }, time.Second, time.Millisecond)
+	require.NoError(t, replicaB.Trigger("report"))
+	require.Eventually(t, func() bool { return statusOf(replicaB, "report").Skipped == 1 }, time.Second, time.Millisecond)
+	close(release)
+
+	// Assert
+	require.Eventually(t, func() bool { return statusOf(replicaA, "report").Runs == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), running.Load())
+	assert.Equal(t, int64(0), statusOf(replicaB, "report").Runs)
+}
+
+func TestScheduler_PerReplicaJobsSkipTheLock(t *testing.T) {
+	// Arrange
+	locker := &sharedLocker{held: map[string]bool{"job:refresh": true}}
+	var runs atomic.Int32
+	s := NewScheduler(locker, nil)
+	require.NoError(t, s.Register(Func("refresh", func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}), never, Options{PerReplica: true, RunAtStart: true}))
+
+	// Act
+	start(t, s)
+
+	// Assert
+	require.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, time.Millisecond)
+	assert.True(t, statusOf(s, "refresh").PerReplica)
+}
+
+func TestScheduler_Trigger(t *testing.T) {
+	// Arrange
+	s := NewScheduler(nil, nil)
+	require.NoError(t, s.Register(Func("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}), never, Options{}))
+
+	// Act & Assert
+	assert.ErrorIs(t, s.Trigger("slow"), ErrNotRunning)
+
+	start(t, s)
+	assert.ErrorIs(t, s.Trigger("missing"), ErrJobNotFound)
+	require.NoError(t, s.Trigger("slow"))
+	require.Eventually(t, func() bool { return statusOf(s, "slow").Running }, time.Second, time.Millisecond)
+	assert.ErrorIs(t, s.Trigger("slow"), ErrJobRunning)
+}
+
+func TestScheduler_StopCancelsAndWaitsForRunningJobs(t *testing.T) {
+	// Arrange
+	started := make(chan struct{})
+	var finished atomic.Bool
+	s := NewScheduler(nil, nil)
+	require.NoError(t, s.Register(Func("long", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		finished.Store(true)
+		return ctx.Err()
+	}), never, Options{RunAtStart: true}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	<-started
+
+	// Act
+	cancel()
+	err := <-done
+
+	// Assert
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.True(t, finished.Load())
+}
+
+func TestScheduler_RejectsDuplicateNames(t *testing.T) {
+	// Arrange
+	s := NewScheduler(nil, nil)
+	job := Func("purge", func(ctx context.Context) error { return nil })
+	require.NoError(t, s.Register(job, never, Options{}))
+
+	// Act
+	err := s.Register(job, never, Options{})
+
+	// Assert
+	assert.Error(t, err)
+}
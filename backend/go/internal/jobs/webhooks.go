@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// PurgeWebhookDeliveries deletes the webhook deliveries older than
+// retention
+func PurgeWebhookDeliveries(store core.WebhookStore, retention time.Duration) Job {
+	return Func("webhooks.purge_deliveries", func(ctx context.Context) error {
+		deleted, err := store.PurgeDeliveries(ctx, time.Now().Add(-retention))
+		if err != nil {
+			return err
+		}
+
+		if deleted > 0 {
+			log.Ctx(ctx).Info().Int64("deleted", deleted).Msg("purged old webhook deliveries")
+		}
+		return nil
+	})
+}
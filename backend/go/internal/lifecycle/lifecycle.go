@@ -0,0 +1,164 @@
+// Package lifecycle starts and stops the application's long-running
+// components (debug server, background workers, exporters) in a defined
+// order so shutdown can drain them instead of killing them mid-work.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Hook is a component's start and stop functions. Start must not block:
+// long-running work belongs in a goroutine (see Worker). Either function
+// may be nil.
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Manager runs registered hooks. Components start in registration order
+// and stop in reverse, so a component can depend on anything registered
+// before it.
+type Manager struct {
+	mu          sync.Mutex
+	hooks       []Hook
+	started     int
+	stopTimeout time.Duration
+}
+
+// New creates a manager that gives each component up to stopTimeout to stop
+func New(stopTimeout time.Duration) *Manager {
+	return &Manager{stopTimeout: stopTimeout}
+}
+
+// Append registers a component. Components must be registered before Start.
+func (m *Manager) Append(hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// Start starts every component in order. If one fails, the components
+// already started are stopped again and the error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, hook := range m.hooks[m.started:] {
+		if hook.Start != nil {
+			if err := hook.Start(ctx); err != nil {
+				startErr := fmt.Errorf("failed to start %s: %w", hook.Name, err)
+				if stopErr := m.stopLocked(context.Background()); stopErr != nil {
+					return errors.Join(startErr, stopErr)
+				}
+				return startErr
+			}
+		}
+		log.Info().Str("component", hook.Name).Msg("component started")
+		m.started++
+	}
+
+	return nil
+}
+
+// Stop stops the started components in reverse order, each bounded by the
+// manager's stop timeout and by ctx. Components that fail or time out are
+// logged and reported in the returned error; the rest are still stopped.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stopLocked(ctx)
+}
+
+func (m *Manager) stopLocked(ctx context.Context) error {
+	var errs []error
+	for ; m.started > 0; m.started-- {
+		hook := m.hooks[m.started-1]
+		if hook.Stop == nil {
+			continue
+		}
+
+		start := time.Now()
+		if err := m.stopOne(ctx, hook); err != nil {
+			log.Error().
+				Err(err).
+				Str("component", hook.Name).
+				Dur("duration", time.Since(start)).
+				Msg("component failed to stop cleanly")
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", hook.Name, err))
+			continue
+		}
+
+		log.Info().
+			Str("component", hook.Name).
+			Dur("duration", time.Since(start)).
+			Msg("component stopped")
+	}
+
+	return errors.Join(errs...)
+}
+
+// stopOne runs a stop hook under the per-component timeout. A hook that
+// ignores its context is abandoned once the timeout passes.
+func (m *Manager) stopOne(ctx context.Context, hook Hook) error {
+	if m.stopTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.stopTimeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- hook.Stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Worker adapts a long-running function into a Hook. Start runs fn in a
+// goroutine with a context derived from the start context; Stop cancels
+// that context and waits for fn to return. fn should finish (or leave
+// re-claimable) any in-flight unit of work before returning.
+func Worker(name string, fn func(ctx context.Context) error) Hook {
+	var (
+		cancel context.CancelFunc
+		done   = make(chan struct{})
+	)
+
+	return Hook{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			var workerCtx context.Context
+			workerCtx, cancel = context.WithCancel(ctx)
+
+			go func() {
+				defer close(done)
+				if err := fn(workerCtx); err != nil && !errors.Is(err, context.Canceled) {
+					log.Error().Err(err).Str("component", name).Msg("worker exited with error")
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
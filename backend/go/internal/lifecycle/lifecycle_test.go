@@ -0,0 +1,149 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder collects the order hooks run in
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) hook(name string) Hook {
+	return Hook{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			r.events = append(r.events, "start "+name)
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			r.events = append(r.events, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func TestManager_StartsInOrderAndStopsInReverse(t *testing.T) {
+	// Arrange
+	rec := &recorder{}
+	m := New(time.Second)
+	m.Append(rec.hook("tracing"))
+	m.Append(rec.hook("debug server"))
+	m.Append(rec.hook("outbox"))
+
+	// Act
+	require.NoError(t, m.Start(context.Background()))
+	require.NoError(t, m.Stop(context.Background()))
+
+	// Assert
+	assert.Equal(t, []string{
+		"start tracing", "start debug server", "start outbox",
+		"stop outbox", "stop debug server", "stop tracing",
+	}, rec.events)
+}
+
+func TestManager_StartFailureStopsStartedComponents(t *testing.T) {
+	// Arrange
+	rec := &recorder{}
+	m := New(time.Second)
+	m.Append(rec.hook("first"))
+	m.Append(Hook{
+		Name:  "broken",
+		Start: func(ctx context.Context) error { return errors.New("port in use") },
+	})
+	m.Append(rec.hook("never started"))
+
+	// Act
+	err := m.Start(context.Background())
+
+	// Assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broken")
+	assert.Equal(t, []string{"start first", "stop first"}, rec.events)
+}
+
+func TestManager_StopTimeout(t *testing.T) {
+	// Arrange
+	rec := &recorder{}
+	m := New(20 * time.Millisecond)
+	m.Append(rec.hook("healthy"))
+	m.Append(Hook{
+		Name: "stuck",
+		Stop: func(ctx context.Context) error {
+			time.Sleep(time.Second) // ignores its context
+			return nil
+		},
+	})
+	require.NoError(t, m.Start(context.Background()))
+
+	// Act
+	start := time.Now()
+	err := m.Stop(context.Background())
+
+	// Assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stuck")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, []string{"start healthy", "stop healthy"}, rec.events, "later components still stop")
+}
+
+func TestWorker_FinishesInFlightWorkOnStop(t *testing.T) {
+	// Arrange
+	var processed int
+	worker := Worker("outbox", func(ctx context.Context) error {
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				// Simulate a delivery that must not be cut off halfway
+				time.Sleep(5 * time.Millisecond)
+				processed++
+			}
+		}
+	})
+
+	m := New(time.Second)
+	m.Append(worker)
+	require.NoError(t, m.Start(context.Background()))
+	time.Sleep(20 * time.Millisecond)
+
+	// Act
+	err := m.Stop(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Positive(t, processed)
+}
+
+func TestWorker_CancelledWithStartContext(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	exited := make(chan struct{})
+	worker := Worker("janitor", func(ctx context.Context) error {
+		<-ctx.Done()
+		close(exited)
+		return ctx.Err()
+	})
+	require.NoError(t, worker.Start(ctx))
+
+	// Act
+	cancel()
+
+	// Assert
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not observe root context cancellation")
+	}
+	assert.NoError(t, worker.Stop(context.Background()))
+}
@@ -0,0 +1,39 @@
+package logging
+
+import "context"
+
+// contextKey is a custom type for context keys to avoid collisions
+type contextKey string
+
+const (
+	// requestIDKey is the context key for request ID
+	requestIDKey contextKey = "request_id"
+	// traceIDKey is the context key for distributed tracing
+	traceIDKey contextKey = "trace_id"
+)
+
+// GetRequestID retrieves the request ID from context
+func GetRequestID(ctx context.Context) string {
+	if requestID, ok := ctx.Value(requestIDKey).(string); ok {
+		return requestID
+	}
+	return ""
+}
+
+// WithRequestID adds request ID to context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// GetTraceID retrieves the trace ID from context
+func GetTraceID(ctx context.Context) string {
+	if traceID, ok := ctx.Value(traceIDKey).(string); ok {
+		return traceID
+	}
+	return ""
+}
+
+// WithTraceID adds trace ID to context
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
@@ -0,0 +1,84 @@
+// Package logging builds the process logger from configuration, lets
+// operators change the log level at runtime, and carries request and trace
+// IDs through contexts.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Config contains logger configuration
+type Config struct {
+	Level zerolog.Level
+	// Pretty writes human-readable console output instead of JSON
+	Pretty bool
+	// Output defaults to os.Stdout
+	Output io.Writer
+}
+
+// ParseLevel parses a LOG_LEVEL value such as "debug" or "warn". Unlike
+// zerolog.ParseLevel it rejects the empty string.
+func ParseLevel(s string) (zerolog.Level, error) {
+	level, err := zerolog.ParseLevel(strings.ToLower(strings.TrimSpace(s)))
+	if err != nil || level == zerolog.NoLevel {
+		return zerolog.NoLevel, fmt.Errorf("invalid log level %q", s)
+	}
+	return level, nil
+}
+
+// New builds the process logger, applies its level globally and installs it
+// as the zerolog/log package logger and the log.Ctx fallback. Caller information is only added to
+// pretty (development) output since it's expensive to collect on every line.
+func New(cfg Config) zerolog.Logger {
+	out := cfg.Output
+	if out == nil {
+		out = os.Stdout
+	}
+
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+
+	var ctx zerolog.Context
+	if cfg.Pretty {
+		ctx = zerolog.New(zerolog.ConsoleWriter{Out: out, TimeFormat: "15:04:05"}).With().Timestamp().Caller()
+	} else {
+		ctx = zerolog.New(out).With().Timestamp()
+	}
+	logger := ctx.Logger()
+
+	SetLevel(cfg.Level)
+	log.Logger = logger
+	// log.Ctx falls back to this logger outside requests (startup, workers)
+	zerolog.DefaultContextLogger = &log.Logger
+
+	return logger
+}
+
+// Sampled returns a logger that writes only every nth debug and info line.
+// Warnings and errors are never sampled. n <= 1 disables sampling.
+func Sampled(logger zerolog.Logger, n uint32) zerolog.Logger {
+	if n <= 1 {
+		return logger
+	}
+
+	sampler := &zerolog.BasicSampler{N: n}
+	return logger.Sample(zerolog.LevelSampler{
+		DebugSampler: sampler,
+		InfoSampler:  sampler,
+	})
+}
+
+// Level returns the current global log level
+func Level() zerolog.Level {
+	return zerolog.GlobalLevel()
+}
+
+// SetLevel changes the global log level for every logger in the process
+func SetLevel(level zerolog.Level) {
+	zerolog.SetGlobalLevel(level)
+}
@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// restoreGlobals undoes New's changes to the process-wide logger and level
+func restoreGlobals(t *testing.T) {
+	t.Helper()
+
+	level, logger, contextLogger := zerolog.GlobalLevel(), log.Logger, zerolog.DefaultContextLogger
+	t.Cleanup(func() {
+		zerolog.SetGlobalLevel(level)
+		log.Logger = logger
+		zerolog.DefaultContextLogger = contextLogger
+	})
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected zerolog.Level
+		wantErr  bool
+	}{
+		{"debug", zerolog.DebugLevel, false},
+		{"INFO", zerolog.InfoLevel, false},
+		{" warn ", zerolog.WarnLevel, false},
+		{"disabled", zerolog.Disabled, false},
+		{"", zerolog.NoLevel, true},
+		{"verbose", zerolog.NoLevel, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			// Act
+			level, err := ParseLevel(tt.input)
+
+			// Assert
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, level)
+		})
+	}
+}
+
+func TestNew_InfoLevelDropsDebugLines(t *testing.T) {
+	// Arrange
+	restoreGlobals(t)
+	var buf bytes.Buffer
+	logger := New(Config{Level: zerolog.InfoLevel, Output: &buf})
+
+	// Act
+	logger.Debug().Msg("cache miss")
+	log.Debug().Msg("package logger debug")
+	logger.Info().Msg("request completed")
+
+	// Assert
+	assert.NotContains(t, buf.String(), "cache miss")
+	assert.NotContains(t, buf.String(), "package logger debug")
+	assert.Contains(t, buf.String(), "request completed")
+}
+
+func TestNew_JSONOutputWithoutCaller(t *testing.T) {
+	// Arrange
+	restoreGlobals(t)
+	var buf bytes.Buffer
+	logger := New(Config{Level: zerolog.InfoLevel, Output: &buf})
+
+	// Act
+	logger.Info().Msg("started")
+
+	// Assert
+	assert.True(t, strings.HasPrefix(buf.String(), "{"), "production output is JSON")
+	assert.NotContains(t, buf.String(), `"caller"`)
+}
+
+func TestSetLevel_AppliesToExistingLoggers(t *testing.T) {
+	// Arrange
+	restoreGlobals(t)
+	var buf bytes.Buffer
+	logger := New(Config{Level: zerolog.InfoLevel, Output: &buf})
+
+	// Act
+	SetLevel(zerolog.DebugLevel)
+	logger.Debug().Msg("now visible")
+
+	// Assert
+	assert.Equal(t, zerolog.DebugLevel, Level())
+	assert.Contains(t, buf.String(), "now visible")
+}
+
+func TestSampled_NeverDropsWarningsOrErrors(t *testing.T) {
+	// Arrange
+	restoreGlobals(t)
+	var buf bytes.Buffer
+	logger := Sampled(New(Config{Level: zerolog.InfoLevel, Output: &buf}), 10)
+
+	// Act
+	for i := 0; i < 100; i++ {
+		logger.Info().Msg("request completed")
+		logger.Warn().Msg("client error")
+		logger.Error().Msg("server error")
+	}
+
+	// Assert
+	out := buf.String()
+	assert.Equal(t, 10, strings.Count(out, "request completed"))
+	assert.Equal(t, 100, strings.Count(out, "client error"))
+	assert.Equal(t, 100, strings.Count(out, "server error"))
+}
+
+func TestSampled_DisabledForSmallN(t *testing.T) {
+	// Arrange
+	restoreGlobals(t)
+	var buf bytes.Buffer
+	logger := Sampled(New(Config{Level: zerolog.InfoLevel, Output: &buf}), 1)
+
+	// Act
+	for i := 0; i < 5; i++ {
+		logger.Info().Msg("request completed")
+	}
+
+	// Assert
+	assert.Equal(t, 5, strings.Count(buf.String(), "request completed"))
+}
@@ -0,0 +1,214 @@
+package lti
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+const (
+	// scoreMediaType is the content type of Assignment and Grade Services
+	// scores
+	scoreMediaType = "application/vnd.ims.lis.v1.score+json"
+
+	// clientAssertionTTL is how long the JWT authenticating a token request
+	// is valid
+	clientAssertionTTL = 5 * time.Minute
+	// tokenExpiryMargin renews access tokens before the platform expires
+	// them
+	tokenExpiryMargin = 30 * time.Second
+	// maxErrorBodyBytes caps how much of a platform's error is reported
+	maxErrorBodyBytes = 512
+)
+
+// Score is a participant's result, posted to their line item
+type Score struct {
+	Given   float64
+	Maximum float64
+	Comment string
+}
+
+// scoreMessage is the Assignment and Grade Services score
+type scoreMessage struct {
+	UserID           string  `json:"userId"`
+	ScoreGiven       float64 `json:"scoreGiven"`
+	ScoreMaximum     float64 `json:"scoreMaximum"`
+	Comment          string  `json:"comment,omitempty"`
+	Timestamp        string  `json:"timestamp"`
+	ActivityProgress string  `json:"activityProgress"`
+	GradingProgress  string  `json:"gradingProgress"`
+}
+
+// gradeService posts scores to the platforms, authenticating with access
+// tokens granted for a JWT signed with the tool's key (RFC 7523). Tokens
+// are cached per platform until shortly before they expire.
+type gradeService struct {
+	client *http.Client
+	key    *Key
+	now    func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]accessToken
+}
+
+type accessToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+func newGradeService(client *http.Client, key *Key) *gradeService {
+	return &gradeService{client: client, key: key, now: time.Now, tokens: make(map[string]accessToken)}
+}
+
+// postScore posts the session's score to its line item
+func (g *gradeService) postScore(ctx context.Context, platform *core.LTIPlatform, session *core.LTISession, score Score) error {
+	endpoint, err := scoresURL(session.LineItemURL)
+	if err != nil {
+		return err
+	}
+	token, err := g.accessToken(ctx, platform)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(scoreMessage{
+		UserID:           session.Subject,
+		ScoreGiven:       score.Given,
+		ScoreMaximum:     score.Maximum,
+		Comment:          score.Comment,
+		Timestamp:        g.now().UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		ActivityProgress: "Completed",
+		GradingProgress:  "FullyGraded",
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid line item URL: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", scoreMediaType)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post score: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The platform may revoke a token before it expires
+		g.forgetToken(platform.ID)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return platformError("failed to post score", resp)
+	}
+	return nil
+}
+
+// accessToken returns a cached access token for the platform, or requests
+// one with the client credentials grant
+func (g *gradeService) accessToken(ctx context.Context, platform *core.LTIPlatform) (string, error) {
+	g.mu.Lock()
+	cached, ok := g.tokens[platform.ID]
+	g.mu.Unlock()
+	if ok && g.now().Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+
+	now := g.now()
+	assertion, err := g.key.sign(map[string]interface{}{
+		"iss": platform.ClientID,
+		"sub": platform.ClientID,
+		"aud": platform.AuthTokenURL,
+		"iat": now.Unix(),
+		"exp": now.Add(clientAssertionTTL).Unix(),
+		"jti": uuid.NewString(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {assertion},
+		"scope":                 {ScopeScore},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, platform.AuthTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("invalid token URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", platformError("failed to request access token", resp)
+	}
+
+	var granted struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&granted); err != nil {
+		return "", fmt.Errorf("failed to decode access token: %w", err)
+	}
+	if granted.AccessToken == "" {
+		return "", fmt.Errorf("platform granted no access token")
+	}
+
+	if granted.ExpiresIn > 0 {
+		g.mu.Lock()
+		g.tokens[platform.ID] = accessToken{
+			value:     granted.AccessToken,
+			expiresAt: now.Add(time.Duration(granted.ExpiresIn)*time.Second - tokenExpiryMargin),
+		}
+		g.mu.Unlock()
+	}
+	return granted.AccessToken, nil
+}
+
+func (g *gradeService) forgetToken(platformID string) {
+	g.mu.Lock()
+	delete(g.tokens, platformID)
+	g.mu.Unlock()
+}
+
+// scoresURL is the scores endpoint of a line item: its path with /scores
+// appended, keeping its query
+func scoresURL(lineItem string) (string, error) {
+	u, err := url.Parse(lineItem)
+	if err != nil || !u.IsAbs() {
+		return "", fmt.Errorf("invalid line item URL %q", lineItem)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/scores"
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+// platformError describes an unsuccessful response from a platform
+func platformError(action string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	if len(body) == 0 {
+		return fmt.Errorf("%s: platform answered %s", action, resp.Status)
+	}
+	return fmt.Errorf("%s: platform answered %s: %s", action, resp.Status, bytes.TrimSpace(body))
+}
@@ -0,0 +1,149 @@
+package lti
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// Claim values of LTI 1.3 Core and Assignment and Grade Services
+const (
+	MessageTypeResourceLink = "LtiResourceLinkRequest"
+	Version                 = "1.3.0"
+
+	// ScopeScore lets the tool post scores to a line item
+	ScopeScore = "https://purl.imsglobal.org/spec/lti-ags/scope/score"
+)
+
+// clockSkew is the leeway given to the platform's clock
+const clockSkew = time.Minute
+
+// LaunchClaims are the claims of a resource link launch's id_token the tool
+// uses
+type LaunchClaims struct {
+	Issuer          string   `json:"iss"`
+	Subject         string   `json:"sub"`
+	Audience        audience `json:"aud"`
+	AuthorizedParty string   `json:"azp,omitempty"`
+	ExpiresAt       int64    `json:"exp"`
+	IssuedAt        int64    `json:"iat"`
+	Nonce           string   `json:"nonce"`
+
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+
+	MessageType   string        `json:"https://purl.imsglobal.org/spec/lti/claim/message_type"`
+	Version       string        `json:"https://purl.imsglobal.org/spec/lti/claim/version"`
+	DeploymentID  string        `json:"https://purl.imsglobal.org/spec/lti/claim/deployment_id"`
+	TargetLinkURI string        `json:"https://purl.imsglobal.org/spec/lti/claim/target_link_uri,omitempty"`
+	ResourceLink  *ResourceLink `json:"https://purl.imsglobal.org/spec/lti/claim/resource_link"`
+	Roles         []string      `json:"https://purl.imsglobal.org/spec/lti/claim/roles"`
+
+	// Custom holds the parameters the platform was configured to send;
+	// project_id binds a new resource link to a project
+	Custom map[string]interface{} `json:"https://purl.imsglobal.org/spec/lti/claim/custom,omitempty"`
+
+	// Endpoint is present when the platform grants grade services
+	Endpoint *GradeEndpoint `json:"https://purl.imsglobal.org/spec/lti-ags/claim/endpoint,omitempty"`
+}
+
+// ResourceLink is the placement of the tool in a course
+type ResourceLink struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+}
+
+// GradeEndpoint is the Assignment and Grade Services claim
+type GradeEndpoint struct {
+	Scope     []string `json:"scope"`
+	LineItems string   `json:"lineitems,omitempty"`
+	LineItem  string   `json:"lineitem,omitempty"`
+}
+
+// audience is the aud claim, which is a string or an array of strings
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return errors.New("aud must be a string or an array of strings")
+	}
+	*a = many
+	return nil
+}
+
+func (a audience) contains(s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the claims of a resource link launch from platform,
+// following the LTI 1.3 Security Framework: the token must be issued by the
+// platform for our client ID, be current, carry the login's nonce, and be a
+// 1.3 resource link launch. The signature is checked separately.
+func (c *LaunchClaims) Validate(platform *core.LTIPlatform, nonce string, now time.Time) error {
+	switch {
+	case c.Issuer != platform.Issuer:
+		return invalidLaunch("iss does not match the platform")
+	case !c.Audience.contains(platform.ClientID):
+		return invalidLaunch("aud does not include the client ID")
+	case len(c.Audience) > 1 && c.AuthorizedParty == "":
+		return invalidLaunch("azp is required with several audiences")
+	case c.AuthorizedParty != "" && c.AuthorizedParty != platform.ClientID:
+		return invalidLaunch("azp does not match the client ID")
+	case c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(clockSkew)):
+		return invalidLaunch("id_token has expired")
+	case c.IssuedAt == 0 || time.Unix(c.IssuedAt, 0).After(now.Add(clockSkew)):
+		return invalidLaunch("id_token is issued in the future")
+	case nonce == "" || c.Nonce != nonce:
+		return invalidLaunch("nonce does not match the login")
+	case c.MessageType != MessageTypeResourceLink:
+		return invalidLaunch(fmt.Sprintf("unsupported message type %q", c.MessageType))
+	case c.Version != Version:
+		return invalidLaunch(fmt.Sprintf("unsupported LTI version %q", c.Version))
+	case c.DeploymentID == "":
+		return invalidLaunch("deployment_id is missing")
+	case c.ResourceLink == nil || c.ResourceLink.ID == "":
+		return invalidLaunch("resource_link id is missing")
+	case c.Subject == "":
+		return invalidLaunch("anonymous launches are not supported")
+	}
+	return nil
+}
+
+// CustomString returns the custom parameter name if it is a string
+func (c *LaunchClaims) CustomString(name string) string {
+	value, _ := c.Custom[name].(string)
+	return value
+}
+
+// ScoreLineItem returns the line item scores are posted to, or "" when the
+// launch grants no score service for one
+func (c *LaunchClaims) ScoreLineItem() string {
+	if c.Endpoint == nil || c.Endpoint.LineItem == "" {
+		return ""
+	}
+	for _, scope := range c.Endpoint.Scope {
+		if scope == ScopeScore {
+			return c.Endpoint.LineItem
+		}
+	}
+	return ""
+}
+
+// invalidLaunch wraps core.ErrLTIInvalidLaunch with the reason
+func invalidLaunch(reason string) error {
+	return fmt.Errorf("%w: %s", core.ErrLTIInvalidLaunch, reason)
+}
@@ -0,0 +1,205 @@
+package lti
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// The reference launch is the resource link launch example of LTI 1.3 Core,
+// with the grade services claim of the Assignment and Grade Services
+// example. It was issued by refPlatform for refNonce and is current at
+// refNow.
+const (
+	refIssuer   = "https://platform.example.edu"
+	refClientID = "962fa4d8-bcbf-49a0-94b2-2de05ad274af"
+	refNonce    = "fc5fdc6d-5dd6-47f4-b2c9-5d1216e9b771"
+	refLineItem = "https://www.myuniv.example.com/2344/lineitems/1234/lineitem"
+)
+
+var refNow = time.Unix(1510185528, 0)
+
+func refPlatform() *core.LTIPlatform {
+	return &core.LTIPlatform{ID: "platform-1", Issuer: refIssuer, ClientID: refClientID}
+}
+
+// referenceLaunch returns the claims of the reference launch as a map the
+// test can change
+func referenceLaunch(t *testing.T) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile("testdata/resource_link_launch.json")
+	require.NoError(t, err)
+	var claims map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &claims))
+	return claims
+}
+
+func decodeClaims(t *testing.T, raw map[string]interface{}) *LaunchClaims {
+	t.Helper()
+	data, err := json.Marshal(raw)
+	require.NoError(t, err)
+	var claims LaunchClaims
+	require.NoError(t, json.Unmarshal(data, &claims))
+	return &claims
+}
+
+func TestLaunchClaims_Validate_ReferenceLaunch(t *testing.T) {
+	// Arrange
+	claims := decodeClaims(t, referenceLaunch(t))
+
+	// Act
+	err := claims.Validate(refPlatform(), refNonce, refNow)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "a6d5c443-1f51-4783-ba1a-7686ffe3b54a", claims.Subject)
+	assert.Equal(t, "Ms Jane Marie Doe", claims.Name)
+	assert.Equal(t, "07940580-b309-415e-a37c-914d387c1150", claims.DeploymentID)
+	assert.Equal(t, "200d101f-2c14-434a-a0f3-57c2a42369fd", claims.ResourceLink.ID)
+	assert.Len(t, claims.Roles, 3)
+	assert.Equal(t, refLineItem, claims.ScoreLineItem())
+}
+
+// The cases follow the known bad payloads of the LTI 1.3 certification
+// suite, plus the Security Framework's audience and nonce rules
+func TestLaunchClaims_Validate_RejectsBadPayloads(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(claims map[string]interface{})
+		nonce  string
+		now    time.Time
+	}{
+		{
+			name:   "wrong issuer",
+			change: func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" },
+		},
+		{
+			name:   "audience without the client ID",
+			change: func(c map[string]interface{}) { c["aud"] = "someone-else" },
+		},
+		{
+			name: "several audiences without azp",
+			change: func(c map[string]interface{}) {
+				c["aud"] = []string{refClientID, "someone-else"}
+				delete(c, "azp")
+			},
+		},
+		{
+			name:   "azp for another client",
+			change: func(c map[string]interface{}) { c["azp"] = "someone-else" },
+		},
+		{
+			name: "timestamps incorrect: expired",
+			now:  time.Unix(1510185728, 0).Add(2 * time.Minute),
+		},
+		{
+			name: "timestamps incorrect: issued in the future",
+			now:  time.Unix(1510185228, 0).Add(-2 * time.Minute),
+		},
+		{
+			name:   "timestamps missing",
+			change: func(c map[string]interface{}) { delete(c, "exp"); delete(c, "iat") },
+		},
+		{
+			name:  "nonce of another login",
+			nonce: "another-nonce",
+		},
+		{
+			name: "message_type claim missing",
+			change: func(c map[string]interface{}) {
+				delete(c, "https://purl.imsglobal.org/spec/lti/claim/message_type")
+			},
+		},
+		{
+			name: "invalid LTI message",
+			change: func(c map[string]interface{}) {
+				c["https://purl.imsglobal.org/spec/lti/claim/message_type"] = "LtiDeepLinkingRequest"
+			},
+		},
+		{
+			name: "wrong LTI version",
+			change: func(c map[string]interface{}) {
+				c["https://purl.imsglobal.org/spec/lti/claim/version"] = "1.2.0"
+			},
+		},
+		{
+			name: "no LTI version",
+			change: func(c map[string]interface{}) {
+				delete(c, "https://purl.imsglobal.org/spec/lti/claim/version")
+			},
+		},
+		{
+			name: "deployment_id claim missing",
+			change: func(c map[string]interface{}) {
+				delete(c, "https://purl.imsglobal.org/spec/lti/claim/deployment_id")
+			},
+		},
+		{
+			name: "resource_link id claim missing",
+			change: func(c map[string]interface{}) {
+				c["https://purl.imsglobal.org/spec/lti/claim/resource_link"] = map[string]interface{}{"title": "Introduction Assignment"}
+			},
+		},
+		{
+			name:   "user claim missing",
+			change: func(c map[string]interface{}) { delete(c, "sub") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			raw := referenceLaunch(t)
+			if tt.change != nil {
+				tt.change(raw)
+			}
+			claims := decodeClaims(t, raw)
+			nonce, now := refNonce, refNow
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+			if !tt.now.IsZero() {
+				now = tt.now
+			}
+
+			// Act
+			err := claims.Validate(refPlatform(), nonce, now)
+
+			// Assert
+			assert.ErrorIs(t, err, core.ErrLTIInvalidLaunch)
+		})
+	}
+}
+
+func TestLaunchClaims_Validate_AllowsClockSkew(t *testing.T) {
+	// Arrange
+	claims := decodeClaims(t, referenceLaunch(t))
+
+	// Act
+	err := claims.Validate(refPlatform(), refNonce, time.Unix(1510185728, 0).Add(30*time.Second))
+
+	// Assert
+	assert.NoError(t, err)
+}
+
+func TestLaunchClaims_ScoreLineItem_RequiresTheScoreScope(t *testing.T) {
+	// Arrange
+	raw := referenceLaunch(t)
+	raw["https://purl.imsglobal.org/spec/lti-ags/claim/endpoint"] = map[string]interface{}{
+		"scope":    []string{"https://purl.imsglobal.org/spec/lti-ags/scope/result.readonly"},
+		"lineitem": refLineItem,
+	}
+	claims := decodeClaims(t, raw)
+
+	// Act
+	lineItem := claims.ScoreLineItem()
+
+	// Assert
+	assert.Empty(t, lineItem)
+}
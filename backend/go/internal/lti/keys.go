@@ -0,0 +1,143 @@
+package lti
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// toolKeyBits is the size of keys generated for development
+const toolKeyBits = 2048
+
+// Key is the tool's signing key. Platforms verify the tokens it signs with
+// the public half, published at /.well-known/jwks.json.
+type Key struct {
+	private *rsa.PrivateKey
+	// ID is the key's RFC 7638 thumbprint, so it changes with the key
+	ID string
+}
+
+// NewKey wraps an RSA private key
+func NewKey(private *rsa.PrivateKey) *Key {
+	return &Key{private: private, ID: thumbprint(&private.PublicKey)}
+}
+
+// GenerateKey creates a key that lasts as long as the process. Platforms
+// cache a tool's key set, so restarting with a new key breaks grade
+// passback until they fetch it again; production loads its key from a file.
+func GenerateKey() (*Key, error) {
+	private, err := rsa.GenerateKey(rand.Reader, toolKeyBits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate LTI key: %w", err)
+	}
+	return NewKey(private), nil
+}
+
+// LoadKey reads a PEM-encoded RSA private key, in PKCS #1 or PKCS #8 form
+func LoadKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read LTI key: %w", err)
+	}
+	return ParseKey(data)
+}
+
+// ParseKey decodes a PEM-encoded RSA private key, in PKCS #1 or PKCS #8 form
+func ParseKey(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("LTI key is not PEM-encoded")
+	}
+
+	if private, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return NewKey(private), nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse LTI key: %w", err)
+	}
+	private, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("LTI key is not an RSA key")
+	}
+	return NewKey(private), nil
+}
+
+// KeySet returns the JWKS publishing the key's public half
+func (k *Key) KeySet() KeySet {
+	return KeySet{Keys: []JWK{publicJWK(&k.private.PublicKey, k.ID)}}
+}
+
+// sign signs claims with the key
+func (k *Key) sign(claims interface{}) (string, error) {
+	return signJWT(claims, k.private, k.ID)
+}
+
+// KeySet is a JSON Web Key Set (RFC 7517)
+type KeySet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is a JSON Web Key. Only RSA signing keys are used by LTI.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// PublicKey decodes an RSA key, rejecting keys not meant for signatures
+func (j JWK) PublicKey() (*rsa.PublicKey, error) {
+	if j.Kty != "RSA" {
+		return nil, fmt.Errorf("key %q is not an RSA key", j.Kid)
+	}
+	if j.Use != "" && j.Use != "sig" {
+		return nil, fmt.Errorf("key %q is not a signing key", j.Kid)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(j.N)
+	if err != nil {
+		return nil, fmt.Errorf("key %q has an invalid modulus", j.Kid)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(j.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, fmt.Errorf("key %q has an invalid exponent", j.Kid)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+func publicJWK(key *rsa.PublicKey, kid string) JWK {
+	return JWK{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: algRS256,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// thumbprint is the RFC 7638 SHA-256 thumbprint of an RSA key
+func thumbprint(key *rsa.PublicKey) string {
+	jwk := publicJWK(key, "")
+	// The required members in lexicographic order, without whitespace
+	canonical, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{E: jwk.E, Kty: jwk.Kty, N: jwk.N})
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
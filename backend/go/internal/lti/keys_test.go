@@ -0,0 +1,167 @@
+package lti
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testKeysOnce sync.Once
+	testKeys     [2]*Key
+)
+
+// testKey returns one of two keys generated once for the package's tests:
+// 0 is the tool's, 1 the platform's
+func testKey(t *testing.T, i int) *Key {
+	t.Helper()
+	testKeysOnce.Do(func() {
+		for j := range testKeys {
+			key, err := GenerateKey()
+			if err != nil {
+				panic(err)
+			}
+			testKeys[j] = key
+		}
+	})
+	return testKeys[i]
+}
+
+func TestThumbprint_RFC7638Example(t *testing.T) {
+	// Arrange: the key of RFC 7638 section 3.1
+	jwk := JWK{
+		Kty: "RSA",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:   "AQAB",
+	}
+	key, err := jwk.PublicKey()
+	require.NoError(t, err)
+
+	// Act
+	id := thumbprint(key)
+
+	// Assert
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", id)
+}
+
+func TestKey_KeySet_PublishesThePublicKey(t *testing.T) {
+	// Arrange
+	key := testKey(t, 0)
+
+	// Act
+	set := key.KeySet()
+
+	// Assert
+	require.Len(t, set.Keys, 1)
+	jwk := set.Keys[0]
+	assert.Equal(t, key.ID, jwk.Kid)
+	assert.Equal(t, "sig", jwk.Use)
+	assert.Equal(t, "RS256", jwk.Alg)
+	public, err := jwk.PublicKey()
+	require.NoError(t, err)
+	assert.True(t, public.Equal(&key.private.PublicKey))
+}
+
+func TestParseKey(t *testing.T) {
+	private := testKey(t, 0).private
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		pem  []byte
+	}{
+		{"PKCS #1", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})},
+		{"PKCS #8", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			key, err := ParseKey(tt.pem)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, testKey(t, 0).ID, key.ID)
+		})
+	}
+}
+
+func TestParseKey_RejectsNonPEM(t *testing.T) {
+	// Act
+	_, err := ParseKey([]byte("not a key"))
+
+	// Assert
+	assert.Error(t, err)
+}
+
+func TestVerifyJWT(t *testing.T) {
+	key := testKey(t, 0)
+	keyFor := func(kid string) (*rsa.PublicKey, error) {
+		if kid != key.ID {
+			return nil, errors.New("unknown key")
+		}
+		return &key.private.PublicKey, nil
+	}
+	token, err := key.sign(map[string]string{"sub": "jane"})
+	require.NoError(t, err)
+	segments := strings.Split(token, ".")
+
+	t.Run("round trip", func(t *testing.T) {
+		// Act
+		payload, err := verifyJWT(token, keyFor)
+
+		// Assert
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"sub":"jane"}`, string(payload))
+	})
+
+	t.Run("tampered payload", func(t *testing.T) {
+		// Arrange
+		forged := segments[0] + "." + encodeSegment([]byte(`{"sub":"john"}`)) + "." + segments[2]
+
+		// Act
+		_, err := verifyJWT(forged, keyFor)
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("unsigned token", func(t *testing.T) {
+		// Arrange
+		unsigned := encodeSegment([]byte(`{"alg":"none"}`)) + "." + segments[1] + "."
+
+		// Act
+		_, err := verifyJWT(unsigned, keyFor)
+
+		// Assert
+		assert.ErrorContains(t, err, "unsupported signing algorithm")
+	})
+
+	t.Run("signed with another key", func(t *testing.T) {
+		// Arrange
+		other, err := signJWT(map[string]string{"sub": "jane"}, testKey(t, 1).private, key.ID)
+		require.NoError(t, err)
+
+		// Act
+		_, err = verifyJWT(other, keyFor)
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		// Act
+		_, err := verifyJWT("abc.def", keyFor)
+
+		// Assert
+		assert.ErrorIs(t, err, errMalformedToken)
+	})
+}
@@ -0,0 +1,118 @@
+package lti
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// keySetMaxAge is how long a fetched key set is trusted
+	keySetMaxAge = time.Hour
+	// keySetRefetchInterval spaces out fetches triggered by unknown key
+	// IDs, so forged tokens cannot make us hammer a platform
+	keySetRefetchInterval = time.Minute
+	// maxKeySetBytes caps the size of a fetched key set
+	maxKeySetBytes = 1 << 20
+)
+
+// keySets fetches and caches the platforms' key sets by URL. A token signed
+// with an unknown key refetches its set, since platforms rotate keys by
+// publishing the new one before using it.
+type keySets struct {
+	client *http.Client
+	now    func() time.Time
+
+	mu   sync.Mutex
+	sets map[string]*cachedKeySet
+}
+
+type cachedKeySet struct {
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+func newKeySets(client *http.Client) *keySets {
+	return &keySets{client: client, now: time.Now, sets: make(map[string]*cachedKeySet)}
+}
+
+// key returns the key kid of the set at url. A token without a kid is
+// verified with the set's only key.
+func (c *keySets) key(ctx context.Context, url, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	set, ok := c.sets[url]
+	if ok && now.Sub(set.fetched) < keySetMaxAge {
+		if key, found := set.find(kid); found {
+			return key, nil
+		}
+		if now.Sub(set.fetched) < keySetRefetchInterval {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+	}
+
+	// Fetching under the lock makes concurrent launches share one fetch
+	keys, err := c.fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	set = &cachedKeySet{keys: keys, fetched: now}
+	c.sets[url] = set
+
+	if key, found := set.find(kid); found {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (s *cachedKeySet) find(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// fetch reads the key set at url, skipping keys that are not RSA signing
+// keys
+func (c *keySets) fetch(ctx context.Context, url string) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key set URL: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch key set: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch key set: platform answered %s", resp.Status)
+	}
+
+	var set KeySet
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxKeySetBytes)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode key set: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
@@ -0,0 +1,307 @@
+// Package lti makes projects launchable from learning platforms as an LTI
+// 1.3 tool. A launch is an OpenID Connect third-party initiated login: the
+// platform calls the login endpoint, the tool redirects the browser back to
+// the platform's authorization endpoint with a state and a nonce, and the
+// platform posts an id_token signed with its key to the launch endpoint.
+// The tool verifies the token against the key set the platform was
+// registered with, maps the launch's resource link to a published project
+// and opens a participant session bound to the platform's user.
+//
+// Scores go back to the platform's gradebook through Assignment and Grade
+// Services, authenticated with access tokens the platform grants for JWTs
+// signed with the tool's key, whose public half is served as a JWKS.
+package lti
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+const (
+	// DefaultSessionTTL is how long a participant session lasts
+	DefaultSessionTTL = 4 * time.Hour
+	// stateTTL is how long a login may take to come back as a launch
+	stateTTL = 10 * time.Minute
+	// defaultHTTPTimeout bounds each call to a platform
+	defaultHTTPTimeout = 10 * time.Second
+
+	// customProjectID is the custom parameter that binds a resource link
+	// to a project on its first launch
+	customProjectID = "project_id"
+)
+
+// ErrNoLineItem is returned when posting a score for a session whose
+// launch granted no score service
+var ErrNoLineItem = errors.New("LTI launch has no line item for scores")
+
+// Config tunes the tool
+type Config struct {
+	// LaunchURL is the tool's launch endpoint, registered with every
+	// platform as its redirect URI
+	LaunchURL string
+	// SessionTTL is how long a participant session lasts
+	SessionTTL time.Duration
+	// HTTPClient fetches the platforms' key sets and posts scores
+	HTTPClient *http.Client
+}
+
+// Service runs logins and launches and posts scores
+type Service struct {
+	store    core.LTIStore
+	projects core.ProjectStore
+	key      *Key
+	cfg      Config
+
+	keySets *keySets
+	grades  *gradeService
+	now     func() time.Time
+}
+
+// NewService creates a service signing with key
+func NewService(store core.LTIStore, projects core.ProjectStore, key *Key, cfg Config) *Service {
+	if cfg.SessionTTL <= 0 {
+		cfg.SessionTTL = DefaultSessionTTL
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	return &Service{
+		store:    store,
+		projects: projects,
+		key:      key,
+		cfg:      cfg,
+		keySets:  newKeySets(cfg.HTTPClient),
+		grades:   newGradeService(cfg.HTTPClient, key),
+		now:      time.Now,
+	}
+}
+
+// KeySet returns the JWKS platforms verify the tool's tokens with
+func (s *Service) KeySet() KeySet {
+	return s.key.KeySet()
+}
+
+// LoginRequest is a third-party initiated login from a platform
+type LoginRequest struct {
+	Issuer    string
+	LoginHint string
+	// MessageHint is opaque to the tool and passed back to the platform
+	MessageHint string
+	// ClientID is only sent by platforms with several registrations
+	ClientID string
+}
+
+// Login starts a launch and returns the platform authorization URL the
+// browser is redirected to
+func (s *Service) Login(ctx context.Context, req LoginRequest) (string, error) {
+	if req.Issuer == "" || req.LoginHint == "" {
+		return "", invalidLaunch("iss and login_hint are required")
+	}
+
+	platform, err := s.store.FindPlatform(ctx, req.Issuer, req.ClientID)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.store.SaveState(ctx, &core.LTIState{
+		State:      state,
+		Nonce:      nonce,
+		PlatformID: platform.ID,
+		ExpiresAt:  s.now().Add(stateTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	redirect, err := url.Parse(platform.AuthLoginURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid platform login URL: %w", err)
+	}
+	query := redirect.Query()
+	query.Set("scope", "openid")
+	query.Set("response_type", "id_token")
+	query.Set("response_mode", "form_post")
+	query.Set("prompt", "none")
+	query.Set("client_id", platform.ClientID)
+	query.Set("redirect_uri", s.cfg.LaunchURL)
+	query.Set("login_hint", req.LoginHint)
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	if req.MessageHint != "" {
+		query.Set("lti_message_hint", req.MessageHint)
+	}
+	redirect.RawQuery = query.Encode()
+	return redirect.String(), nil
+}
+
+// Launch is a completed launch
+type Launch struct {
+	Session *core.LTISession
+	// Token is the participant's bearer token for the session
+	Token string
+}
+
+// Launch completes the login that issued state with the platform's
+// id_token, and opens a session in the launched project. A resource link
+// launched for the first time is bound to the project named by its
+// project_id custom parameter; later launches keep that binding.
+func (s *Service) Launch(ctx context.Context, idToken, state string) (*Launch, error) {
+	now := s.now()
+
+	login, err := s.store.TakeState(ctx, state, now)
+	if err != nil {
+		return nil, err
+	}
+	platform, err := s.store.GetPlatform(ctx, login.PlatformID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := verifyJWT(idToken, func(kid string) (*rsa.PublicKey, error) {
+		return s.keySets.key(ctx, platform.KeySetURL, kid)
+	})
+	if err != nil {
+		return nil, invalidLaunch(err.Error())
+	}
+	var claims LaunchClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, invalidLaunch("id_token claims are malformed")
+	}
+	if err := claims.Validate(platform, login.Nonce, now); err != nil {
+		return nil, err
+	}
+
+	// The launch acts for the platform, within its organization
+	ctx = core.WithAccessScope(ctx, core.AccessScope{System: true})
+	if platform.OrgID != nil {
+		ctx = core.WithOrgID(ctx, *platform.OrgID)
+	}
+
+	link, err := s.resourceLink(ctx, platform, &claims)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPublished(ctx, link.ProjectID); err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	session := &core.LTISession{
+		ID:          sessionID(token),
+		PlatformID:  platform.ID,
+		ProjectID:   link.ProjectID,
+		Subject:     claims.Subject,
+		Name:        claims.Name,
+		Email:       claims.Email,
+		Roles:       claims.Roles,
+		LineItemURL: claims.ScoreLineItem(),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.cfg.SessionTTL),
+	}
+	if err := s.store.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return &Launch{Session: session, Token: token}, nil
+}
+
+// resourceLink returns the binding of the launch's resource link, binding
+// it to its project_id custom parameter if it has none
+func (s *Service) resourceLink(ctx context.Context, platform *core.LTIPlatform, claims *LaunchClaims) (*core.LTIResourceLink, error) {
+	link, err := s.store.GetResourceLink(ctx, platform.ID, claims.DeploymentID, claims.ResourceLink.ID)
+	if !errors.Is(err, core.ErrLTIResourceLinkNotMapped) {
+		return link, err
+	}
+
+	projectID := claims.CustomString(customProjectID)
+	if projectID == "" {
+		return nil, err
+	}
+	// Only a project the platform may launch is bound
+	if err := s.checkPublished(ctx, projectID); err != nil {
+		return nil, err
+	}
+	return s.store.BindResourceLink(ctx, &core.LTIResourceLink{
+		PlatformID:     platform.ID,
+		DeploymentID:   claims.DeploymentID,
+		ResourceLinkID: claims.ResourceLink.ID,
+		ProjectID:      projectID,
+	})
+}
+
+// checkPublished returns ErrProjectNotPublished unless the project in reach
+// of ctx is published
+func (s *Service) checkPublished(ctx context.Context, projectID string) error {
+	project, err := s.projects.GetByID(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if project.PublishedAt == nil {
+		return core.ErrProjectNotPublished
+	}
+	return nil
+}
+
+// Session returns the unexpired session of a participant's token
+func (s *Service) Session(ctx context.Context, token string) (*core.LTISession, error) {
+	if token == "" {
+		return nil, core.ErrLTISessionNotFound
+	}
+	return s.store.GetSession(ctx, sessionID(token), s.now())
+}
+
+// PublishScore posts a participant's score to the line item of the launch
+// that opened the session. Returns ErrNoLineItem if the platform granted no
+// score service.
+func (s *Service) PublishScore(ctx context.Context, sessionID string, score Score) error {
+	session, err := s.store.GetSession(ctx, sessionID, s.now())
+	if err != nil {
+		return err
+	}
+	if session.LineItemURL == "" {
+		return ErrNoLineItem
+	}
+	platform, err := s.store.GetPlatform(ctx, session.PlatformID)
+	if err != nil {
+		return err
+	}
+	return s.grades.postScore(ctx, platform, session, score)
+}
+
+// randomToken returns 256 random bits, base64url encoded
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// sessionID is the stored ID of a participant's token
+func sessionID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,594 @@
+package lti
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+const testLaunchURL = "https://tool.example.com/lti/launch"
+
+// memStore is a core.LTIStore in memory
+type memStore struct {
+	mu        sync.Mutex
+	platforms map[string]*core.LTIPlatform
+	states    map[string]*core.LTIState
+	links     map[string]*core.LTIResourceLink
+	sessions  map[string]*core.LTISession
+}
+
+func newMemStore(platforms ...*core.LTIPlatform) *memStore {
+	s := &memStore{
+		platforms: make(map[string]*core.LTIPlatform),
+		states:    make(map[string]*core.LTIState),
+		links:     make(map[string]*core.LTIResourceLink),
+		sessions:  make(map[string]*core.LTISession),
+	}
+	for _, p := range platforms {
+		s.platforms[p.ID] = p
+	}
+	return s
+}
+
+func linkKey(platformID, deploymentID, resourceLinkID string) string {
+	return platformID + "|" + deploymentID + "|" + resourceLinkID
+}
+
+func (s *memStore) CreatePlatform(ctx context.Context, platform *core.LTIPlatform) (*core.LTIPlatform, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.platforms[platform.ID] = platform
+	return platform, nil
+}
+
+func (s *memStore) GetPlatform(ctx context.Context, id string) (*core.LTIPlatform, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.platforms[id]; ok {
+		return p, nil
+	}
+	return nil, core.ErrLTIPlatformNotFound
+}
+
+func (s *memStore) FindPlatform(ctx context.Context, issuer, clientID string) (*core.LTIPlatform, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found []*core.LTIPlatform
+	for _, p := range s.platforms {
+		if p.Issuer == issuer && (clientID == "" || p.ClientID == clientID) {
+			found = append(found, p)
+		}
+	}
+	if len(found) != 1 {
+		return nil, core.ErrLTIPlatformNotFound
+	}
+	return found[0], nil
+}
+
+func (s *memStore) ListPlatforms(ctx context.Context) ([]*core.LTIPlatform, error) {
+	return nil, nil
+}
+
+func (s *memStore) DeletePlatform(ctx context.Context, id string) error {
+	return nil
+}
+
+func (s *memStore) SaveState(ctx context.Context, state *core.LTIState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[state.State] = state
+	return nil
+}
+
+func (s *memStore) TakeState(ctx context.Context, state string, now time.Time) (*core.LTIState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	taken, ok := s.states[state]
+	delete(s.states, state)
+	if !ok || !taken.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("%w: unknown, used or expired state", core.ErrLTIInvalidLaunch)
+	}
+	return taken, nil
+}
+
+func (s *memStore) GetResourceLink(ctx context.Context, platformID, deploymentID, resourceLinkID string) (*core.LTIResourceLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if link, ok := s.links[linkKey(platformID, deploymentID, resourceLinkID)]; ok {
+		return link, nil
+	}
+	return nil, core.ErrLTIResourceLinkNotMapped
+}
+
+func (s *memStore) BindResourceLink(ctx context.Context, link *core.LTIResourceLink) (*core.LTIResourceLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := linkKey(link.PlatformID, link.DeploymentID, link.ResourceLinkID)
+	if existing, ok := s.links[key]; ok {
+		return existing, nil
+	}
+	s.links[key] = link
+	return link, nil
+}
+
+func (s *memStore) CreateSession(ctx context.Context, session *core.LTISession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = session
+	return nil
+}
+
+func (s *memStore) GetSession(ctx context.Context, id string, now time.Time) (*core.LTISession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session, ok := s.sessions[id]; ok && session.ExpiresAt.After(now) {
+		return session, nil
+	}
+	return nil, core.ErrLTISessionNotFound
+}
+
+func (s *memStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+// fakeProjects serves projects by ID, and records the access scope of the
+// lookups
+type fakeProjects struct {
+	core.ProjectStore
+	projects map[string]*core.Project
+	scopes   []core.AccessScope
+}
+
+func (p *fakeProjects) GetByID(ctx context.Context, id string) (*core.Project, error) {
+	p.scopes = append(p.scopes, core.AccessScopeFromContext(ctx))
+	if project, ok := p.projects[id]; ok {
+		return project, nil
+	}
+	return nil, core.ErrProjectNotFound
+}
+
+// fakePlatform is a learning platform serving its key set, granting access
+// tokens and taking scores
+type fakePlatform struct {
+	*httptest.Server
+	key     *Key
+	toolKey *Key
+
+	mu         sync.Mutex
+	keyFetches int
+	grants     int
+	scores     []*http.Request
+	bodies     []scoreMessage
+}
+
+func newFakePlatform(t *testing.T) *fakePlatform {
+	p := &fakePlatform{key: testKey(t, 1), toolKey: testKey(t, 0)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		p.keyFetches++
+		p.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(p.key.KeySet())
+	})
+	mux.HandleFunc("/token", p.grant)
+	mux.HandleFunc("/2344/lineitems/1234/lineitem/scores", func(w http.ResponseWriter, r *http.Request) {
+		var body scoreMessage
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		p.mu.Lock()
+		p.scores = append(p.scores, r)
+		p.bodies = append(p.bodies, body)
+		p.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// grant issues an access token for a client assertion signed by the tool
+func (p *fakePlatform) grant(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil ||
+		r.PostForm.Get("grant_type") != "client_credentials" ||
+		r.PostForm.Get("client_assertion_type") != "urn:ietf:params:oauth:client-assertion-type:jwt-bearer" ||
+		r.PostForm.Get("scope") != ScopeScore {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	payload, err := verifyJWT(r.PostForm.Get("client_assertion"), func(kid string) (*rsa.PublicKey, error) {
+		return &p.toolKey.private.PublicKey, nil
+	})
+	var assertion struct {
+		Iss string `json:"iss"`
+		Sub string `json:"sub"`
+		Aud string `json:"aud"`
+	}
+	if err != nil || json.Unmarshal(payload, &assertion) != nil ||
+		assertion.Iss != refClientID || assertion.Sub != refClientID || assertion.Aud != p.URL+"/token" {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+
+	p.mu.Lock()
+	p.grants++
+	token := fmt.Sprintf("token-%d", p.grants)
+	p.mu.Unlock()
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"scope":        ScopeScore,
+	})
+}
+
+func (p *fakePlatform) platform() *core.LTIPlatform {
+	return &core.LTIPlatform{
+		ID:           "platform-1",
+		Issuer:       refIssuer,
+		ClientID:     refClientID,
+		AuthLoginURL: p.URL + "/auth",
+		AuthTokenURL: p.URL + "/token",
+		KeySetURL:    p.URL + "/jwks",
+	}
+}
+
+// idToken signs the reference launch for nonce, pointing its line item at
+// the platform and its custom project_id at projectID
+func (p *fakePlatform) idToken(t *testing.T, nonce, projectID string) string {
+	t.Helper()
+	claims := referenceLaunch(t)
+	claims["nonce"] = nonce
+	claims["https://purl.imsglobal.org/spec/lti/claim/custom"] = map[string]interface{}{customProjectID: projectID}
+	endpoint := claims["https://purl.imsglobal.org/spec/lti-ags/claim/endpoint"].(map[string]interface{})
+	endpoint["lineitem"] = p.URL + "/2344/lineitems/1234/lineitem"
+	token, err := p.key.sign(claims)
+	require.NoError(t, err)
+	return token
+}
+
+type serviceFixture struct {
+	service  *Service
+	store    *memStore
+	projects *fakeProjects
+	platform *fakePlatform
+}
+
+func newServiceFixture(t *testing.T) *serviceFixture {
+	platform := newFakePlatform(t)
+	publishedAt := refNow.Add(-time.Hour)
+	projects := &fakeProjects{projects: map[string]*core.Project{
+		"project-1": {ID: "project-1", PublishedAt: &publishedAt},
+		"draft":     {ID: "draft"},
+	}}
+	store := newMemStore(platform.platform())
+
+	service := NewService(store, projects, testKey(t, 0), Config{LaunchURL: testLaunchURL, HTTPClient: platform.Client()})
+	service.now = func() time.Time { return refNow }
+	service.keySets.now = service.now
+	service.grades.now = service.now
+	return &serviceFixture{service: service, store: store, projects: projects, platform: platform}
+}
+
+// login runs the login and returns the state and nonce the platform gets
+func (f *serviceFixture) login(t *testing.T) (state, nonce string) {
+	t.Helper()
+	redirect, err := f.service.Login(context.Background(), LoginRequest{Issuer: refIssuer, LoginHint: "hint"})
+	require.NoError(t, err)
+	u, err := url.Parse(redirect)
+	require.NoError(t, err)
+	return u.Query().Get("state"), u.Query().Get("nonce")
+}
+
+func TestService_Login_RedirectsToThePlatform(t *testing.T) {
+	// Arrange
+	f := newServiceFixture(t)
+
+	// Act
+	redirect, err := f.service.Login(context.Background(), LoginRequest{
+		Issuer:      refIssuer,
+		LoginHint:   "user-42",
+		MessageHint: "link-7",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	u, err := url.Parse(redirect)
+	require.NoError(t, err)
+	assert.Equal(t, f.platform.URL+"/auth", u.Scheme+"://"+u.Host+u.Path)
+	query := u.Query()
+	assert.Equal(t, "openid", query.Get("scope"))
+	assert.Equal(t, "id_token", query.Get("response_type"))
+	assert.Equal(t, "form_post", query.Get("response_mode"))
+	assert.Equal(t, "none", query.Get("prompt"))
+	assert.Equal(t, refClientID, query.Get("client_id"))
+	assert.Equal(t, testLaunchURL, query.Get("redirect_uri"))
+	assert.Equal(t, "user-42", query.Get("login_hint"))
+	assert.Equal(t, "link-7", query.Get("lti_message_hint"))
+	assert.NotEmpty(t, query.Get("nonce"))
+	require.Contains(t, f.store.states, query.Get("state"))
+	assert.Equal(t, refNow.Add(stateTTL), f.store.states[query.Get("state")].ExpiresAt)
+}
+
+func TestService_Login_UnknownPlatform(t *testing.T) {
+	// Arrange
+	f := newServiceFixture(t)
+
+	// Act
+	_, err := f.service.Login(context.Background(), LoginRequest{Issuer: "https://unknown.example.com", LoginHint: "hint"})
+
+	// Assert
+	assert.ErrorIs(t, err, core.ErrLTIPlatformNotFound)
+}
+
+func TestService_Launch_BindsTheResourceLinkAndOpensASession(t *testing.T) {
+	// Arrange
+	f := newServiceFixture(t)
+	state, nonce := f.login(t)
+
+	// Act
+	launch, err := f.service.Launch(context.Background(), f.platform.idToken(t, nonce, "project-1"), state)
+
+	// Assert
+	require.NoError(t, err)
+	session := launch.Session
+	assert.Equal(t, "project-1", session.ProjectID)
+	assert.Equal(t, "a6d5c443-1f51-4783-ba1a-7686ffe3b54a", session.Subject)
+	assert.Equal(t, "jane@platform.example.edu", session.Email)
+	assert.Equal(t, f.platform.URL+"/2344/lineitems/1234/lineitem", session.LineItemURL)
+	assert.Equal(t, refNow.Add(DefaultSessionTTL), session.ExpiresAt)
+	assert.Equal(t, sessionID(launch.Token), session.ID, "only the token's hash is stored")
+	assert.Contains(t, f.store.links, linkKey("platform-1", "07940580-b309-415e-a37c-914d387c1150", "200d101f-2c14-434a-a0f3-57c2a42369fd"))
+	for _, scope := range f.projects.scopes {
+		assert.True(t, scope.System, "the launch reads projects for the platform")
+	}
+
+	got, err := f.service.Session(context.Background(), launch.Token)
+	require.NoError(t, err)
+	assert.Equal(t, session, got)
+}
+
+func TestService_Launch_KeepsTheExistingBinding(t *testing.T) {
+	// Arrange
+	f := newServiceFixture(t)
+	state, nonce := f.login(t)
+	_, err := f.service.Launch(context.Background(), f.platform.idToken(t, nonce, "project-1"), state)
+	require.NoError(t, err)
+	state, nonce = f.login(t)
+
+	// Act: the course now points the link at a draft
+	launch, err := f.service.Launch(context.Background(), f.platform.idToken(t, nonce, "draft"), state)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "project-1", launch.Session.ProjectID)
+}
+
+func TestService_Launch_Rejections(t *testing.T) {
+	tests := []struct {
+		name    string
+		launch  func(t *testing.T, f *serviceFixture) (idToken, state string)
+		wantErr error
+	}{
+		{
+			name: "state used twice",
+			launch: func(t *testing.T, f *serviceFixture) (string, string) {
+				state, nonce := f.login(t)
+				token := f.platform.idToken(t, nonce, "project-1")
+				_, err := f.service.Launch(context.Background(), token, state)
+				require.NoError(t, err)
+				return token, state
+			},
+			wantErr: core.ErrLTIInvalidLaunch,
+		},
+		{
+			name: "nonce of another login",
+			launch: func(t *testing.T, f *serviceFixture) (string, string) {
+				state, _ := f.login(t)
+				return f.platform.idToken(t, "another-nonce", "project-1"), state
+			},
+			wantErr: core.ErrLTIInvalidLaunch,
+		},
+		{
+			name: "signed with a key the platform does not publish",
+			launch: func(t *testing.T, f *serviceFixture) (string, string) {
+				state, nonce := f.login(t)
+				claims := referenceLaunch(t)
+				claims["nonce"] = nonce
+				token, err := testKey(t, 0).sign(claims)
+				require.NoError(t, err)
+				return token, state
+			},
+			wantErr: core.ErrLTIInvalidLaunch,
+		},
+		{
+			name: "no kid sent in the JWT header",
+			launch: func(t *testing.T, f *serviceFixture) (string, string) {
+				state, nonce := f.login(t)
+				claims := referenceLaunch(t)
+				claims["nonce"] = nonce
+				token, err := signJWT(claims, testKey(t, 0).private, "")
+				require.NoError(t, err)
+				return token, state
+			},
+			wantErr: core.ErrLTIInvalidLaunch,
+		},
+		{
+			name: "unmapped link without project_id",
+			launch: func(t *testing.T, f *serviceFixture) (string, string) {
+				state, nonce := f.login(t)
+				claims := referenceLaunch(t)
+				claims["nonce"] = nonce
+				token, err := f.platform.key.sign(claims)
+				require.NoError(t, err)
+				return token, state
+			},
+			wantErr: core.ErrLTIResourceLinkNotMapped,
+		},
+		{
+			name: "draft project",
+			launch: func(t *testing.T, f *serviceFixture) (string, string) {
+				state, nonce := f.login(t)
+				return f.platform.idToken(t, nonce, "draft"), state
+			},
+			wantErr: core.ErrProjectNotPublished,
+		},
+		{
+			name: "unknown project",
+			launch: func(t *testing.T, f *serviceFixture) (string, string) {
+				state, nonce := f.login(t)
+				return f.platform.idToken(t, nonce, "missing"), state
+			},
+			wantErr: core.ErrProjectNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			f := newServiceFixture(t)
+			idToken, state := tt.launch(t, f)
+
+			// Act
+			_, err := f.service.Launch(context.Background(), idToken, state)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestService_Launch_RefetchesTheKeySetForANewKey(t *testing.T) {
+	// Arrange
+	f := newServiceFixture(t)
+	state, nonce := f.login(t)
+	_, err := f.service.Launch(context.Background(), f.platform.idToken(t, nonce, "project-1"), state)
+	require.NoError(t, err)
+	f.platform.key = testKey(t, 0) // the platform rotates its key
+	later := refNow.Add(2 * keySetRefetchInterval)
+	f.service.keySets.now = func() time.Time { return later }
+	state, nonce = f.login(t)
+
+	// Act
+	_, err = f.service.Launch(context.Background(), f.platform.idToken(t, nonce, "project-1"), state)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.platform.keyFetches)
+}
+
+func TestService_Session_Expired(t *testing.T) {
+	// Arrange
+	f := newServiceFixture(t)
+	state, nonce := f.login(t)
+	launch, err := f.service.Launch(context.Background(), f.platform.idToken(t, nonce, "project-1"), state)
+	require.NoError(t, err)
+	f.service.now = func() time.Time { return refNow.Add(DefaultSessionTTL) }
+
+	// Act
+	_, err = f.service.Session(context.Background(), launch.Token)
+
+	// Assert
+	assert.ErrorIs(t, err, core.ErrLTISessionNotFound)
+}
+
+func TestService_PublishScore_PostsToTheLineItem(t *testing.T) {
+	// Arrange
+	f := newServiceFixture(t)
+	state, nonce := f.login(t)
+	launch, err := f.service.Launch(context.Background(), f.platform.idToken(t, nonce, "project-1"), state)
+	require.NoError(t, err)
+
+	// Act
+	err = f.service.PublishScore(context.Background(), launch.Session.ID, Score{Given: 7, Maximum: 10, Comment: "Well done"})
+	require.NoError(t, err)
+	err = f.service.PublishScore(context.Background(), launch.Session.ID, Score{Given: 9, Maximum: 10})
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, f.platform.scores, 2)
+	assert.Equal(t, 1, f.platform.grants, "the access token is reused")
+	req := f.platform.scores[0]
+	assert.Equal(t, "application/vnd.ims.lis.v1.score+json", req.Header.Get("Content-Type"))
+	assert.Equal(t, "Bearer token-1", req.Header.Get("Authorization"))
+	assert.Equal(t, scoreMessage{
+		UserID:           "a6d5c443-1f51-4783-ba1a-7686ffe3b54a",
+		ScoreGiven:       7,
+		ScoreMaximum:     10,
+		Comment:          "Well done",
+		Timestamp:        "2017-11-08T23:58:48.000Z",
+		ActivityProgress: "Completed",
+		GradingProgress:  "FullyGraded",
+	}, f.platform.bodies[0])
+}
+
+func TestService_PublishScore_WithoutScoreService(t *testing.T) {
+	// Arrange
+	f := newServiceFixture(t)
+	session := &core.LTISession{ID: "session-1", PlatformID: "platform-1", ExpiresAt: refNow.Add(time.Hour)}
+	require.NoError(t, f.store.CreateSession(context.Background(), session))
+
+	// Act
+	err := f.service.PublishScore(context.Background(), session.ID, Score{Given: 1, Maximum: 1})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrNoLineItem)
+}
+
+func TestGradeService_ForgetsRevokedTokens(t *testing.T) {
+	// Arrange
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			calls++
+			_, _ = io.WriteString(w, `{"access_token":"t","expires_in":3600}`)
+			return
+		}
+		http.Error(w, "revoked", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	grades := newGradeService(server.Client(), testKey(t, 0))
+	platform := &core.LTIPlatform{ID: "platform-1", ClientID: refClientID, AuthTokenURL: server.URL + "/token"}
+	session := &core.LTISession{Subject: "jane", LineItemURL: server.URL + "/lineitem"}
+
+	// Act
+	err1 := grades.postScore(context.Background(), platform, session, Score{Given: 1, Maximum: 1})
+	err2 := grades.postScore(context.Background(), platform, session, Score{Given: 1, Maximum: 1})
+
+	// Assert
+	assert.ErrorContains(t, err1, "401")
+	assert.Error(t, err2)
+	assert.Equal(t, 2, calls)
+}
+
+func TestScoresURL(t *testing.T) {
+	tests := []struct {
+		lineItem string
+		want     string
+	}{
+		{"https://lms.example.com/2344/lineitems/1234/lineitem", "https://lms.example.com/2344/lineitems/1234/lineitem/scores"},
+		{"https://lms.example.com/lineitems/1234/?type_id=7", "https://lms.example.com/lineitems/1234/scores?type_id=7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lineItem, func(t *testing.T) {
+			// Act
+			got, err := scoresURL(tt.lineItem)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
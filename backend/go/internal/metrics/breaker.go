@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/provemyself/backend/internal/breaker"
+)
+
+// BreakerMetrics exports circuit breaker state
+type BreakerMetrics struct {
+	state       *prometheus.GaugeVec
+	transitions *prometheus.CounterVec
+}
+
+// NewBreakerMetrics creates circuit breaker collectors and registers them
+func NewBreakerMetrics(registerer prometheus.Registerer) *BreakerMetrics {
+	m := &BreakerMetrics{
+		state: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "circuit_breaker_state",
+			Help:      "Circuit breaker state: 0 closed, 1 half-open, 2 open.",
+		}, []string{"breaker"}),
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "circuit_breaker_transitions_total",
+			Help:      "Total number of circuit breaker state transitions.",
+		}, []string{"breaker", "to"}),
+	}
+
+	registerer.MustRegister(m.state, m.transitions)
+
+	return m
+}
+
+// ObserveStateChange records a transition. It matches breaker.Config's
+// OnStateChange.
+func (m *BreakerMetrics) ObserveStateChange(name string, from, to breaker.State) {
+	m.state.WithLabelValues(name).Set(float64(to))
+	m.transitions.WithLabelValues(name, to.String()).Inc()
+}
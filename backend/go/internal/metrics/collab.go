@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CollabMetrics exports the collaboration hub's rooms and connections. It
+// implements collab.Observer.
+type CollabMetrics struct {
+	rooms       prometheus.Gauge
+	connections prometheus.Gauge
+	messages    *prometheus.CounterVec
+	slowPeers   prometheus.Counter
+}
+
+// NewCollabMetrics creates collaboration collectors and registers them
+func NewCollabMetrics(registerer prometheus.Registerer) *CollabMetrics {
+	m := &CollabMetrics{
+		rooms: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "collab_rooms",
+			Help:      "Number of collaborative editing rooms held in memory.",
+		}),
+		connections: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "collab_connections",
+			Help:      "Number of open collaborative editing connections.",
+		}),
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "collab_messages_total",
+			Help:      "Total number of collaboration messages received, by kind.",
+		}, []string{"kind"}),
+		slowPeers: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "collab_slow_peer_disconnects_total",
+			Help:      "Total number of collaboration connections closed because their send buffer was full.",
+		}),
+	}
+
+	registerer.MustRegister(m.rooms, m.connections, m.messages, m.slowPeers)
+
+	return m
+}
+
+// ObserveRooms records the room count
+func (m *CollabMetrics) ObserveRooms(n int) {
+	m.rooms.Set(float64(n))
+}
+
+// ObserveConnections records the connection count
+func (m *CollabMetrics) ObserveConnections(n int) {
+	m.connections.Set(float64(n))
+}
+
+// ObserveMessage counts a received message
+func (m *CollabMetrics) ObserveMessage(kind string) {
+	m.messages.WithLabelValues(kind).Inc()
+}
+
+// ObserveSlowPeer counts a connection closed for falling behind
+func (m *CollabMetrics) ObserveSlowPeer() {
+	m.slowPeers.Inc()
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unmatchedRoute labels requests that didn't match any registered route
+const unmatchedRoute = "unmatched"
+
+// HTTPMetrics instruments HTTP requests
+type HTTPMetrics struct {
+	duration *prometheus.HistogramVec
+	requests *prometheus.CounterVec
+	inFlight prometheus.Gauge
+	panics   *prometheus.CounterVec
+}
+
+// NewHTTPMetrics creates HTTP request collectors and registers them
+func NewHTTPMetrics(registerer prometheus.Registerer) *HTTPMetrics {
+	m := &HTTPMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of HTTP requests in seconds.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests.",
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests currently being served.",
+		}),
+		panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_panics_total",
+			Help: "Total number of HTTP handler panics recovered.",
+		}, []string{"route"}),
+	}
+
+	registerer.MustRegister(m.duration, m.requests, m.inFlight, m.panics)
+
+	return m
+}
+
+// Middleware records duration, count and in-flight requests.
+// Requests are labeled by the chi route pattern rather than the raw path so
+// IDs in the URL don't create a new series per resource.
+func (m *HTTPMetrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		labels := prometheus.Labels{
+			"method": r.Method,
+			"route":  RoutePattern(r),
+			"status": statusClass(ww.Status()),
+		}
+
+		m.duration.With(labels).Observe(time.Since(start).Seconds())
+		m.requests.With(labels).Inc()
+	})
+}
+
+// ObservePanic counts a panic recovered while serving r
+func (m *HTTPMetrics) ObservePanic(r *http.Request) {
+	m.panics.WithLabelValues(RoutePattern(r)).Inc()
+}
+
+// RoutePattern returns the matched chi route pattern for a request.
+// It is only complete once routing has finished, i.e. after the handler has run.
+func RoutePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return unmatchedRoute
+	}
+
+	if pattern := rctx.RoutePattern(); pattern != "" {
+		return pattern
+	}
+
+	return unmatchedRoute
+}
+
+// statusClass collapses a status code into its class (2xx, 4xx, ...)
+func statusClass(status int) string {
+	if status == 0 {
+		// Handlers that never call WriteHeader implicitly respond 200
+		status = http.StatusOK
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
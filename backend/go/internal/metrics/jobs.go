@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// JobMetrics times background job runs by job name. It implements
+// jobs.Observer.
+type JobMetrics struct {
+	duration *prometheus.HistogramVec
+	failures *prometheus.CounterVec
+}
+
+// NewJobMetrics creates background job collectors and registers them
+func NewJobMetrics(registerer prometheus.Registerer) *JobMetrics {
+	m := &JobMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "job_duration_seconds",
+			Help:      "Duration of background job runs in seconds.",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 900},
+		}, []string{"job"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "job_failures_total",
+			Help:      "Total number of background job runs that returned an error or panicked.",
+		}, []string{"job"}),
+	}
+
+	registerer.MustRegister(m.duration, m.failures)
+
+	return m
+}
+
+// ObserveJob records one run
+func (m *JobMetrics) ObserveJob(name string, duration time.Duration, err error) {
+	m.duration.WithLabelValues(name).Observe(duration.Seconds())
+	if err != nil {
+		m.failures.WithLabelValues(name).Inc()
+	}
+}
@@ -0,0 +1,30 @@
+// Package metrics provides Prometheus instrumentation for the ProveMySelf API.
+// It owns the metrics registry and the collectors for HTTP traffic, the database
+// connection pool, and storage operations.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes application-specific metric names
+const Namespace = "provemyself"
+
+// NewRegistry creates a registry with the Go runtime and process collectors registered
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+// Handler serves the registry in the Prometheus text exposition format
+func Handler(gatherer prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,233 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+func scrape(t *testing.T, handler http.Handler) string {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	return rr.Body.String()
+}
+
+func TestHTTPMetrics_Scrape(t *testing.T) {
+	// Arrange
+	registry := NewRegistry()
+	httpMetrics := NewHTTPMetrics(registry)
+
+	r := chi.NewRouter()
+	r.Use(httpMetrics.Middleware)
+	r.Get("/api/v1/projects/{projectId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	r.Post("/api/v1/projects", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	// Act
+	for _, id := range []string{"a", "b", "c"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/projects/"+id, nil))
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/projects", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	body := scrape(t, Handler(registry))
+
+	// Assert
+	tests := []struct {
+		name   string
+		series string
+	}{
+		{"duration histogram by route pattern", `http_request_duration_seconds_count{method="GET",route="/api/v1/projects/{projectId}",status="2xx"} 3`},
+		{"request counter by status class", `http_requests_total{method="POST",route="/api/v1/projects",status="4xx"} 1`},
+		{"unmatched routes collapse to one label", `http_requests_total{method="GET",route="unmatched",status="4xx"} 1`},
+		{"in-flight gauge", `http_requests_in_flight 0`},
+		{"go runtime collector", `go_goroutines`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Contains(t, body, tt.series)
+		})
+	}
+
+	assert.NotContains(t, body, `route="/api/v1/projects/a"`)
+}
+
+type fakeStorage struct {
+	core.Storage
+	err error
+}
+
+func (s *fakeStorage) Delete(ctx context.Context, key string) error {
+	return s.err
+}
+
+func TestRegisterPoolStats_Scrape(t *testing.T) {
+	// Arrange
+	registry := NewRegistry()
+	stats := types.DatabasePoolStats{MaxOpen: 25, Open: 4, InUse: 3, Idle: 1, WaitCount: 7, WaitDurationMs: 1500}
+	RegisterPoolStats(registry, "postgres", func() types.DatabasePoolStats { return stats })
+
+	// Act
+	before := scrape(t, Handler(registry))
+	stats.WaitCount = 9
+	after := scrape(t, Handler(registry))
+
+	// Assert
+	assert.Contains(t, before, `go_sql_max_open_connections{db_name="postgres"} 25`)
+	assert.Contains(t, before, `go_sql_open_connections{db_name="postgres"} 4`)
+	assert.Contains(t, before, `go_sql_in_use_connections{db_name="postgres"} 3`)
+	assert.Contains(t, before, `go_sql_idle_connections{db_name="postgres"} 1`)
+	assert.Contains(t, before, `go_sql_wait_count_total{db_name="postgres"} 7`)
+	assert.Contains(t, before, `go_sql_wait_duration_seconds_total{db_name="postgres"} 1.5`)
+	assert.Contains(t, after, `go_sql_wait_count_total{db_name="postgres"} 9`, "stats are read on every scrape")
+}
+
+func TestStorageMetrics_Scrape(t *testing.T) {
+	// Arrange
+	registry := NewRegistry()
+	storageMetrics := NewStorageMetrics(registry)
+	ok := storageMetrics.Wrap(&fakeStorage{})
+	failing := storageMetrics.Wrap(&fakeStorage{err: errors.New("boom")})
+
+	// Act
+	_ = ok.Delete(context.Background(), "a")
+	_ = ok.Delete(context.Background(), "b")
+	_ = failing.Delete(context.Background(), "c")
+
+	body := scrape(t, Handler(registry))
+
+	// Assert
+	assert.Contains(t, body, `provemyself_storage_operations_total{operation="delete",result="success"} 2`)
+	assert.Contains(t, body, `provemyself_storage_operations_total{operation="delete",result="error"} 1`)
+	assert.True(t, strings.Contains(body, "provemyself_storage_operation_duration_seconds_bucket"))
+}
+
+type fakeItemStore struct {
+	core.ItemStore
+	err error
+}
+
+func (s *fakeItemStore) GetByID(ctx context.Context, projectID, id string) (*core.Item, error) {
+	return nil, s.err
+}
+
+func (s *fakeItemStore) ListByProject(ctx context.Context, projectID string) ([]*core.Item, error) {
+	return nil, s.err
+}
+
+type fakeProjectStore struct {
+	core.ProjectStore
+	err error
+}
+
+func (s *fakeProjectStore) Delete(ctx context.Context, id string) error {
+	return s.err
+}
+
+func TestStoreMetrics_Scrape(t *testing.T) {
+	// Arrange
+	types.RegisterDomainError(core.ErrItemNotFound, types.ErrItemNotFound)
+	types.RegisterDomainError(core.ErrProjectNotFound, types.ErrProjectNotFound)
+	registry := NewRegistry()
+	storeMetrics := NewStoreMetrics(registry)
+	items := storeMetrics.WrapItemStore(&fakeItemStore{})
+	missing := storeMetrics.WrapItemStore(&fakeItemStore{err: core.ErrItemNotFound})
+	projects := storeMetrics.WrapProjectStore(&fakeProjectStore{err: errors.New("connection reset")})
+
+	// Act
+	_, _ = items.ListByProject(context.Background(), "project-1")
+	_, _ = items.ListByProject(context.Background(), "project-2")
+	_, _ = missing.GetByID(context.Background(), "project-1", "item-1")
+	_ = projects.Delete(context.Background(), "project-1")
+
+	body := scrape(t, Handler(registry))
+
+	// Assert
+	assert.Contains(t, body, `provemyself_store_operation_duration_seconds_count{method="list_by_project",store="item_store"} 2`)
+	assert.Contains(t, body, `provemyself_store_operation_duration_seconds_count{method="get_by_id",store="item_store"} 1`)
+	assert.Contains(t, body, `provemyself_store_operation_errors_total{kind="not_found",method="get_by_id",store="item_store"} 1`)
+	assert.Contains(t, body, `provemyself_store_operation_errors_total{kind="other",method="delete",store="project_store"} 1`)
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "provemyself_store_operation_errors_total{") {
+			assert.NotContains(t, line, `method="list_by_project"`, "successes are not errors")
+		}
+	}
+}
+
+func TestStoreErrorKind(t *testing.T) {
+	types.RegisterDomainError(core.ErrItemNotFound, types.ErrItemNotFound)
+	types.RegisterDomainError(core.ErrItemInvalidPosition, types.ErrItemInvalidPosition)
+	types.RegisterDomainError(core.ErrProjectQuotaExceeded, types.ErrProjectQuotaExceeded)
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"missing row", core.ErrItemNotFound, "not_found"},
+		{"wrapped missing row", fmt.Errorf("failed to get item: %w", core.ErrItemNotFound), "not_found"},
+		{"taken position", core.ErrItemInvalidPosition, "constraint"},
+		{"quota", core.ErrProjectQuotaExceeded, "constraint"},
+		{"unregistered error", errors.New("connection reset"), "other"},
+		{"canceled", context.Canceled, "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			kind := storeErrorKind(tt.err)
+
+			// Assert
+			assert.Equal(t, tt.expected, kind)
+		})
+	}
+}
+
+func TestStoreMetrics_PreservesErrors(t *testing.T) {
+	types.RegisterDomainError(core.ErrItemNotFound, types.ErrItemNotFound)
+	storeMetrics := NewStoreMetrics(NewRegistry())
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"success", nil},
+		{"sentinel", core.ErrItemNotFound},
+		{"wrapped sentinel", fmt.Errorf("failed to get item: %w", core.ErrItemNotFound)},
+		{"other error", errors.New("connection reset")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			items := storeMetrics.WrapItemStore(&fakeItemStore{err: tt.err})
+
+			// Act
+			_, err := items.GetByID(context.Background(), "project-1", "item-1")
+
+			// Assert
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, errors.Is(tt.err, core.ErrItemNotFound), errors.Is(err, core.ErrItemNotFound))
+		})
+	}
+}
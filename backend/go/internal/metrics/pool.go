@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/provemyself/backend/internal/types"
+)
+
+// poolCollector reads a connection pool's statistics on every scrape. It
+// keeps the go_sql_* names collectors.NewDBStatsCollector used, so
+// dashboards built on them still work now that the pool is pgxpool's rather
+// than database/sql's.
+type poolCollector struct {
+	stats func() types.DatabasePoolStats
+
+	maxOpen      *prometheus.Desc
+	open         *prometheus.Desc
+	inUse        *prometheus.Desc
+	idle         *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+}
+
+// RegisterPoolStats exposes a database's connection pool usage (open,
+// in-use and idle connections and wait counts) as reported by stats
+func RegisterPoolStats(registerer prometheus.Registerer, dbName string, stats func() types.DatabasePoolStats) {
+	labels := prometheus.Labels{"db_name": dbName}
+	registerer.MustRegister(&poolCollector{
+		stats:        stats,
+		maxOpen:      prometheus.NewDesc("go_sql_max_open_connections", "Maximum number of open connections to the database.", nil, labels),
+		open:         prometheus.NewDesc("go_sql_open_connections", "The number of established connections both in use and idle.", nil, labels),
+		inUse:        prometheus.NewDesc("go_sql_in_use_connections", "The number of connections currently in use.", nil, labels),
+		idle:         prometheus.NewDesc("go_sql_idle_connections", "The number of idle connections.", nil, labels),
+		waitCount:    prometheus.NewDesc("go_sql_wait_count_total", "The total number of connections waited for.", nil, labels),
+		waitDuration: prometheus.NewDesc("go_sql_wait_duration_seconds_total", "The total time blocked waiting for a new connection.", nil, labels),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	wait := time.Duration(stats.WaitDurationMs) * time.Millisecond
+
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpen))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.Open))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, wait.Seconds())
+}
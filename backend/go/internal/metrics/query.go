@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// QueryMetrics times database statements by the short query name the store
+// gives them (e.g. "items.list_by_project"). It implements store.QueryObserver.
+type QueryMetrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+	retries  *prometheus.CounterVec
+}
+
+// NewQueryMetrics creates database query collectors and registers them
+func NewQueryMetrics(registerer prometheus.Registerer) *QueryMetrics {
+	m := &QueryMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Duration of database statements in seconds.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .2, .5, 1, 2.5, 5},
+		}, []string{"query"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "db_query_errors_total",
+			Help:      "Total number of database statements that returned an error.",
+		}, []string{"query"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "db_query_retries_total",
+			Help:      "Total number of database statements or transactions retried after a transient error.",
+		}, []string{"query"}),
+	}
+
+	registerer.MustRegister(m.duration, m.errors, m.retries)
+
+	return m
+}
+
+// ObserveQuery records one statement
+func (m *QueryMetrics) ObserveQuery(name string, duration time.Duration, err error) {
+	m.duration.WithLabelValues(name).Observe(duration.Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(name).Inc()
+	}
+}
+
+// ObserveRetry records one retry
+func (m *QueryMetrics) ObserveRetry(name string) {
+	m.retries.WithLabelValues(name).Inc()
+}
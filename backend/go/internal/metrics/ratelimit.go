@@ -0,0 +1,44 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// RateLimitMetrics counts rate limiting decisions. It implements
+// middleware.RateLimitObserver.
+type RateLimitMetrics struct {
+	decisions *prometheus.CounterVec
+	keys      prometheus.Gauge
+}
+
+// NewRateLimitMetrics creates rate limiting collectors and registers them
+func NewRateLimitMetrics(registerer prometheus.Registerer) *RateLimitMetrics {
+	m := &RateLimitMetrics{
+		decisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "rate_limit_decisions_total",
+			Help:      "Total number of requests checked against the rate limit, by decision and route group.",
+		}, []string{"decision", "route_group"}),
+		keys: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "rate_limit_tracked_keys",
+			Help:      "Number of clients with requests in the current rate limit window.",
+		}),
+	}
+
+	registerer.MustRegister(m.decisions, m.keys)
+
+	return m
+}
+
+// ObserveRateLimit counts one decision
+func (m *RateLimitMetrics) ObserveRateLimit(routeGroup string, allowed bool) {
+	decision := "allowed"
+	if !allowed {
+		decision = "limited"
+	}
+	m.decisions.WithLabelValues(decision, routeGroup).Inc()
+}
+
+// SetRateLimitKeys records the number of tracked keys
+func (m *RateLimitMetrics) SetRateLimitKeys(n int) {
+	m.keys.Set(float64(n))
+}
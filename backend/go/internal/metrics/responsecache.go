@@ -0,0 +1,40 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// ResponseCacheMetrics counts response cache lookups. It implements
+// middleware.ResponseCacheObserver.
+type ResponseCacheMetrics struct {
+	lookups *prometheus.CounterVec
+	entries prometheus.Gauge
+}
+
+// NewResponseCacheMetrics creates response cache collectors and registers them
+func NewResponseCacheMetrics(registerer prometheus.Registerer) *ResponseCacheMetrics {
+	m := &ResponseCacheMetrics{
+		lookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "response_cache_requests_total",
+			Help:      "Total number of reads checked against the response cache, by result (hit, miss, bypass).",
+		}, []string{"result"}),
+		entries: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "response_cache_entries",
+			Help:      "Number of responses in the response cache.",
+		}),
+	}
+
+	registerer.MustRegister(m.lookups, m.entries)
+
+	return m
+}
+
+// ObserveResponseCache counts one lookup
+func (m *ResponseCacheMetrics) ObserveResponseCache(result string) {
+	m.lookups.WithLabelValues(result).Inc()
+}
+
+// SetResponseCacheEntries records the number of cached responses
+func (m *ResponseCacheMetrics) SetResponseCacheEntries(n int) {
+	m.entries.Set(float64(n))
+}
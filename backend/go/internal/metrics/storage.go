@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// StorageMetrics counts and times file storage operations
+type StorageMetrics struct {
+	operations *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+}
+
+// NewStorageMetrics creates storage operation collectors and registers them
+func NewStorageMetrics(registerer prometheus.Registerer) *StorageMetrics {
+	m := &StorageMetrics{
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "storage_operations_total",
+			Help:      "Total number of file storage operations.",
+		}, []string{"operation", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "storage_operation_duration_seconds",
+			Help:      "Duration of file storage operations in seconds.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+	}
+
+	registerer.MustRegister(m.operations, m.duration)
+
+	return m
+}
+
+// Wrap decorates a storage backend so every operation is recorded
+func (m *StorageMetrics) Wrap(storage core.Storage) core.Storage {
+	return &instrumentedStorage{next: storage, metrics: m}
+}
+
+func (m *StorageMetrics) observe(operation string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	m.operations.WithLabelValues(operation, result).Inc()
+	m.duration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// instrumentedStorage implements core.Storage by delegating to another backend
+type instrumentedStorage struct {
+	next    core.Storage
+	metrics *StorageMetrics
+}
+
+func (s *instrumentedStorage) Upload(ctx context.Context, key string, reader io.Reader, opts core.UploadOptions) (*core.StorageMetadata, error) {
+	start := time.Now()
+	metadata, err := s.next.Upload(ctx, key, reader, opts)
+	s.metrics.observe("upload", start, err)
+	return metadata, err
+}
+
+func (s *instrumentedStorage) Download(ctx context.Context, key string) (io.ReadCloser, *core.StorageMetadata, error) {
+	start := time.Now()
+	reader, metadata, err := s.next.Download(ctx, key)
+	s.metrics.observe("download", start, err)
+	return reader, metadata, err
+}
+
+func (s *instrumentedStorage) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := s.next.Delete(ctx, key)
+	s.metrics.observe("delete", start, err)
+	return err
+}
+
+func (s *instrumentedStorage) Exists(ctx context.Context, key string) (bool, error) {
+	start := time.Now()
+	exists, err := s.next.Exists(ctx, key)
+	s.metrics.observe("exists", start, err)
+	return exists, err
+}
+
+func (s *instrumentedStorage) GetURL(ctx context.Context, key string) (string, error) {
+	start := time.Now()
+	url, err := s.next.GetURL(ctx, key)
+	s.metrics.observe("get_url", start, err)
+	return url, err
+}
+
+func (s *instrumentedStorage) GetSignedURL(ctx context.Context, key string, expiration time.Duration) (string, error) {
+	start := time.Now()
+	url, err := s.next.GetSignedURL(ctx, key, expiration)
+	s.metrics.observe("get_signed_url", start, err)
+	return url, err
+}
+
+func (s *instrumentedStorage) List(ctx context.Context, prefix string, limit int) ([]*core.StorageMetadata, error) {
+	start := time.Now()
+	files, err := s.next.List(ctx, prefix, limit)
+	s.metrics.observe("list", start, err)
+	return files, err
+}
+
+func (s *instrumentedStorage) HealthCheck(ctx context.Context) error {
+	start := time.Now()
+	err := s.next.HealthCheck(ctx)
+	s.metrics.observe("health_check", start, err)
+	return err
+}
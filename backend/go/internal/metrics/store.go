@@ -0,0 +1,319 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// Error kinds of store operations, so expected misses can be told apart
+// from real failures
+const (
+	storeErrorNotFound   = "not_found"
+	storeErrorConstraint = "constraint"
+	storeErrorOther      = "other"
+)
+
+// StoreMetrics times the operations of the domain stores by store and method
+// (e.g. store="item_store", method="list_by_project"), one level above the
+// statements QueryMetrics times
+type StoreMetrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// NewStoreMetrics creates store operation collectors and registers them
+func NewStoreMetrics(registerer prometheus.Registerer) *StoreMetrics {
+	m := &StoreMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "store_operation_duration_seconds",
+			Help:      "Duration of store operations in seconds.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .2, .5, 1, 2.5, 5},
+		}, []string{"store", "method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "store_operation_errors_total",
+			Help:      "Total number of store operations that returned an error, by kind: not_found, constraint or other.",
+		}, []string{"store", "method", "kind"}),
+	}
+
+	registerer.MustRegister(m.duration, m.errors)
+
+	return m
+}
+
+// WrapProjectStore decorates a project store so every operation is recorded
+func (m *StoreMetrics) WrapProjectStore(store core.ProjectStore) core.ProjectStore {
+	return &instrumentedProjectStore{next: store, metrics: m}
+}
+
+// WrapItemStore decorates an item store so every operation is recorded
+func (m *StoreMetrics) WrapItemStore(store core.ItemStore) core.ItemStore {
+	return &instrumentedItemStore{next: store, metrics: m}
+}
+
+func (m *StoreMetrics) observe(store, method string, start time.Time, err error) {
+	m.duration.WithLabelValues(store, method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(store, method, storeErrorKind(err)).Inc()
+	}
+}
+
+// storeErrorKind classifies err by the API error its sentinel maps to:
+// a 404 is a miss, any other client error a rule the data enforces
+func storeErrorKind(err error) string {
+	status := types.MapDomainError(err).StatusCode
+	switch {
+	case status == http.StatusNotFound:
+		return storeErrorNotFound
+	case status >= 400 && status < 500:
+		return storeErrorConstraint
+	default:
+		return storeErrorOther
+	}
+}
+
+// instrumentedProjectStore implements core.ProjectStore by delegating to
+// another store
+type instrumentedProjectStore struct {
+	next    core.ProjectStore
+	metrics *StoreMetrics
+}
+
+func (s *instrumentedProjectStore) Create(ctx context.Context, title string, description *string, tags []string) (*core.Project, error) {
+	start := time.Now()
+	project, err := s.next.Create(ctx, title, description, tags)
+	s.metrics.observe("project_store", "create", start, err)
+	return project, err
+}
+
+func (s *instrumentedProjectStore) GetByID(ctx context.Context, id string) (*core.Project, error) {
+	start := time.Now()
+	project, err := s.next.GetByID(ctx, id)
+	s.metrics.observe("project_store", "get_by_id", start, err)
+	return project, err
+}
+
+func (s *instrumentedProjectStore) List(ctx context.Context, opts core.ListOptions) (*core.ProjectPage, error) {
+	start := time.Now()
+	page, err := s.next.List(ctx, opts)
+	s.metrics.observe("project_store", "list", start, err)
+	return page, err
+}
+
+func (s *instrumentedProjectStore) Update(ctx context.Context, id string, title string, description *string, tags []string, version *int) (*core.Project, error) {
+	start := time.Now()
+	project, err := s.next.Update(ctx, id, title, description, tags, version)
+	s.metrics.observe("project_store", "update", start, err)
+	return project, err
+}
+
+func (s *instrumentedProjectStore) Delete(ctx context.Context, id string) error {
+	start := time.Now()
+	err := s.next.Delete(ctx, id)
+	s.metrics.observe("project_store", "delete", start, err)
+	return err
+}
+
+func (s *instrumentedProjectStore) Restore(ctx context.Context, id string) (*core.Project, error) {
+	start := time.Now()
+	project, err := s.next.Restore(ctx, id)
+	s.metrics.observe("project_store", "restore", start, err)
+	return project, err
+}
+
+func (s *instrumentedProjectStore) Purge(ctx context.Context, id string) error {
+	start := time.Now()
+	err := s.next.Purge(ctx, id)
+	s.metrics.observe("project_store", "purge", start, err)
+	return err
+}
+
+func (s *instrumentedProjectStore) Publish(ctx context.Context, id string) (*core.Project, error) {
+	start := time.Now()
+	project, err := s.next.Publish(ctx, id)
+	s.metrics.observe("project_store", "publish", start, err)
+	return project, err
+}
+
+func (s *instrumentedProjectStore) ListPublications(ctx context.Context, projectID string, limit, offset int) ([]*core.Publication, int, error) {
+	start := time.Now()
+	publications, total, err := s.next.ListPublications(ctx, projectID, limit, offset)
+	s.metrics.observe("project_store", "list_publications", start, err)
+	return publications, total, err
+}
+
+func (s *instrumentedProjectStore) GetPublication(ctx context.Context, projectID string, version int) (*core.Publication, error) {
+	start := time.Now()
+	publication, err := s.next.GetPublication(ctx, projectID, version)
+	s.metrics.observe("project_store", "get_publication", start, err)
+	return publication, err
+}
+
+func (s *instrumentedProjectStore) PublishedOrgID(ctx context.Context, id string) (string, error) {
+	start := time.Now()
+	orgID, err := s.next.PublishedOrgID(ctx, id)
+	s.metrics.observe("project_store", "published_org_id", start, err)
+	return orgID, err
+}
+
+func (s *instrumentedProjectStore) OrgID(ctx context.Context, id string) (string, error) {
+	start := time.Now()
+	orgID, err := s.next.OrgID(ctx, id)
+	s.metrics.observe("project_store", "org_id", start, err)
+	return orgID, err
+}
+
+func (s *instrumentedProjectStore) Duplicate(ctx context.Context, id, title string) (*core.Project, error) {
+	start := time.Now()
+	project, err := s.next.Duplicate(ctx, id, title)
+	s.metrics.observe("project_store", "duplicate", start, err)
+	return project, err
+}
+
+func (s *instrumentedProjectStore) UpdateResultsSettings(ctx context.Context, id string, settings core.ResultsSettings) (*core.Project, error) {
+	start := time.Now()
+	project, err := s.next.UpdateResultsSettings(ctx, id, settings)
+	s.metrics.observe("project_store", "update_results_settings", start, err)
+	return project, err
+}
+
+// instrumentedItemStore implements core.ItemStore by delegating to another
+// store
+type instrumentedItemStore struct {
+	next    core.ItemStore
+	metrics *StoreMetrics
+}
+
+func (s *instrumentedItemStore) Create(ctx context.Context, projectID string, itemType types.ItemType, title string, content json.RawMessage, position int, required bool, points *int, explanation *string) (*core.Item, error) {
+	start := time.Now()
+	item, err := s.next.Create(ctx, projectID, itemType, title, content, position, required, points, explanation)
+	s.metrics.observe("item_store", "create", start, err)
+	return item, err
+}
+
+func (s *instrumentedItemStore) CreateMany(ctx context.Context, projectID string, items []core.NewItem) ([]*core.Item, error) {
+	start := time.Now()
+	created, err := s.next.CreateMany(ctx, projectID, items)
+	s.metrics.observe("item_store", "create_many", start, err)
+	return created, err
+}
+
+func (s *instrumentedItemStore) CreateBulkCopy(ctx context.Context, projectID string, items []core.NewItem) ([]*core.Item, error) {
+	start := time.Now()
+	created, err := s.next.CreateBulkCopy(ctx, projectID, items)
+	s.metrics.observe("item_store", "create_bulk_copy", start, err)
+	return created, err
+}
+
+func (s *instrumentedItemStore) GetByID(ctx context.Context, projectID, id string) (*core.Item, error) {
+	start := time.Now()
+	item, err := s.next.GetByID(ctx, projectID, id)
+	s.metrics.observe("item_store", "get_by_id", start, err)
+	return item, err
+}
+
+func (s *instrumentedItemStore) ListByProject(ctx context.Context, projectID string) ([]*core.Item, error) {
+	start := time.Now()
+	items, err := s.next.ListByProject(ctx, projectID)
+	s.metrics.observe("item_store", "list_by_project", start, err)
+	return items, err
+}
+
+func (s *instrumentedItemStore) List(ctx context.Context, projectID string, opts core.ItemListOptions) (*core.ItemPage, error) {
+	start := time.Now()
+	page, err := s.next.List(ctx, projectID, opts)
+	s.metrics.observe("item_store", "list", start, err)
+	return page, err
+}
+
+func (s *instrumentedItemStore) Search(ctx context.Context, projectID, query string) ([]*core.Item, error) {
+	start := time.Now()
+	items, err := s.next.Search(ctx, projectID, query)
+	s.metrics.observe("item_store", "search", start, err)
+	return items, err
+}
+
+func (s *instrumentedItemStore) Duplicate(ctx context.Context, projectID, id, title string) (*core.Item, error) {
+	start := time.Now()
+	item, err := s.next.Duplicate(ctx, projectID, id, title)
+	s.metrics.observe("item_store", "duplicate", start, err)
+	return item, err
+}
+
+func (s *instrumentedItemStore) CountByProject(ctx context.Context, projectID string) (int, error) {
+	start := time.Now()
+	count, err := s.next.CountByProject(ctx, projectID)
+	s.metrics.observe("item_store", "count_by_project", start, err)
+	return count, err
+}
+
+func (s *instrumentedItemStore) MaxPosition(ctx context.Context, projectID string) (int, bool, error) {
+	start := time.Now()
+	position, ok, err := s.next.MaxPosition(ctx, projectID)
+	s.metrics.observe("item_store", "max_position", start, err)
+	return position, ok, err
+}
+
+func (s *instrumentedItemStore) SumPoints(ctx context.Context, projectID string) (int, error) {
+	start := time.Now()
+	points, err := s.next.SumPoints(ctx, projectID)
+	s.metrics.observe("item_store", "sum_points", start, err)
+	return points, err
+}
+
+func (s *instrumentedItemStore) Update(ctx context.Context, projectID, id string, itemType types.ItemType, title string, content json.RawMessage, position int, required bool, points *int, explanation *string, version *int) (*core.Item, error) {
+	start := time.Now()
+	item, err := s.next.Update(ctx, projectID, id, itemType, title, content, position, required, points, explanation, version)
+	s.metrics.observe("item_store", "update", start, err)
+	return item, err
+}
+
+func (s *instrumentedItemStore) Delete(ctx context.Context, projectID, id string) error {
+	start := time.Now()
+	err := s.next.Delete(ctx, projectID, id)
+	s.metrics.observe("item_store", "delete", start, err)
+	return err
+}
+
+func (s *instrumentedItemStore) Restore(ctx context.Context, projectID, id string) (*core.Item, error) {
+	start := time.Now()
+	item, err := s.next.Restore(ctx, projectID, id)
+	s.metrics.observe("item_store", "restore", start, err)
+	return item, err
+}
+
+func (s *instrumentedItemStore) PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error) {
+	start := time.Now()
+	deleted, err := s.next.PurgeDeleted(ctx, cutoff)
+	s.metrics.observe("item_store", "purge_deleted", start, err)
+	return deleted, err
+}
+
+func (s *instrumentedItemStore) UpdatePositions(ctx context.Context, projectID string, updates []core.PositionUpdate) error {
+	start := time.Now()
+	err := s.next.UpdatePositions(ctx, projectID, updates)
+	s.metrics.observe("item_store", "update_positions", start, err)
+	return err
+}
+
+func (s *instrumentedItemStore) ListRevisions(ctx context.Context, projectID, id string, limit, offset int) ([]*core.ItemRevision, int, error) {
+	start := time.Now()
+	revisions, total, err := s.next.ListRevisions(ctx, projectID, id, limit, offset)
+	s.metrics.observe("item_store", "list_revisions", start, err)
+	return revisions, total, err
+}
+
+func (s *instrumentedItemStore) GetRevision(ctx context.Context, projectID, id string, number int) (*core.ItemRevision, error) {
+	start := time.Now()
+	revision, err := s.next.GetRevision(ctx, projectID, id, number)
+	s.metrics.observe("item_store", "get_revision", start, err)
+	return revision, err
+}
@@ -0,0 +1,351 @@
+package qti
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// defaultWeight is the score of an item without points
+const defaultWeight = 1
+
+// itemIdentifier is the QTI identifier of an item. Identifiers must start
+// with a letter, which UUIDs need not.
+func itemIdentifier(item *core.Item) string {
+	return "item-" + item.ID
+}
+
+// choiceIdentifier is the QTI identifier of the i-th choice, hotspot or
+// ordering entry. Our IDs are free-form, so they are not reused.
+func choiceIdentifier(i int) string {
+	return "choice_" + strconv.Itoa(i+1)
+}
+
+// convertItem maps an item onto a QTI assessmentItem. hrefs rewrites the
+// URLs of media the item shows.
+func convertItem(item *core.Item, hrefs func(url string) string) (*AssessmentItem, error) {
+	doc := &AssessmentItem{
+		Xmlns:          NamespaceItem,
+		XmlnsXSI:       namespaceXSI,
+		SchemaLocation: schemaLocationItem,
+		Identifier:     itemIdentifier(item),
+		Title:          item.Title,
+	}
+
+	weight := float64(defaultWeight)
+	if item.Points != nil {
+		weight = float64(*item.Points)
+	}
+
+	var err error
+	switch item.Type {
+	case types.ItemTypeTitle:
+		doc.ItemBody.Blocks = []interface{}{
+			RubricBlock{View: "candidate", Blocks: []interface{}{Heading{Text: item.Title}}},
+		}
+	case types.ItemTypeMedia:
+		err = convertMedia(doc, item, hrefs)
+	case types.ItemTypeChoice, types.ItemTypeMultiChoice:
+		err = convertChoice(doc, item, weight)
+	case types.ItemTypeTextEntry:
+		err = convertTextEntry(doc, item, weight)
+	case types.ItemTypeOrdering:
+		err = convertOrdering(doc, item, weight)
+	case types.ItemTypeHotspot:
+		err = convertHotspot(doc, item, weight, hrefs)
+	default:
+		err = fmt.Errorf("%w: %s", core.ErrItemInvalidType, item.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("item %s: %w", item.ID, err)
+	}
+	return doc, nil
+}
+
+// decodeContent decodes an item's content into v
+func decodeContent(item *core.Item, v interface{}) error {
+	if err := json.Unmarshal(item.Content, v); err != nil {
+		return fmt.Errorf("%w: %v", core.ErrItemInvalidContent, err)
+	}
+	return nil
+}
+
+func convertMedia(doc *AssessmentItem, item *core.Item, hrefs func(string) string) error {
+	var content types.MediaContent
+	if err := decodeContent(item, &content); err != nil {
+		return err
+	}
+
+	var media interface{}
+	href := hrefs(content.URL)
+	if content.MediaType == "image" {
+		media = Img{Src: href, Alt: optional(content.AltText)}
+	} else {
+		media = Object{Data: href, Type: core.GetContentTypeFromFilename(content.URL), Text: optional(content.AltText)}
+	}
+
+	blocks := []interface{}{Heading{Text: item.Title}, Div{Blocks: []interface{}{media}}}
+	if caption := optional(content.Caption); caption != "" {
+		blocks = append(blocks, Paragraph{Text: caption})
+	}
+	doc.ItemBody.Blocks = blocks
+	return nil
+}
+
+// convertChoice maps choice and multi_choice items. The weight is spread
+// over the correct choices, and wrong ones take as much off a multiple
+// response, never going below 0.
+func convertChoice(doc *AssessmentItem, item *core.Item, weight float64) error {
+	var content types.ChoiceContent
+	if err := decodeContent(item, &content); err != nil {
+		return err
+	}
+
+	multiple := item.Type == types.ItemTypeMultiChoice
+	var correct []string
+	choices := make([]SimpleChoice, len(content.Choices))
+	for i, choice := range content.Choices {
+		choices[i] = SimpleChoice{Identifier: choiceIdentifier(i), Text: choice.Text}
+		if choice.Correct {
+			correct = append(correct, choiceIdentifier(i))
+		}
+	}
+
+	declaration := ResponseDeclaration{Identifier: responseID, Cardinality: cardinalitySingle, BaseType: baseTypeIdentifier}
+	mapping := &Mapping{}
+	maxChoices := 1
+	if multiple {
+		declaration.Cardinality = cardinalityMultiple
+		maxChoices = 0
+		lower, upper := 0.0, weight
+		mapping.LowerBound, mapping.UpperBound = &lower, &upper
+	}
+	for i, choice := range content.Choices {
+		value := 0.0
+		switch {
+		case !multiple && choice.Correct:
+			value = weight
+		case multiple && len(correct) > 0 && choice.Correct:
+			value = weight / float64(len(correct))
+		case multiple && len(correct) > 0:
+			value = -weight / float64(len(correct))
+		}
+		mapping.Entries = append(mapping.Entries, MapEntry{MapKey: choiceIdentifier(i), MappedValue: value, CaseSensitive: true})
+	}
+	declaration.Mapping = mapping
+	if len(correct) > 0 {
+		if !multiple {
+			correct = correct[:1]
+		}
+		declaration.CorrectResponse = &CorrectResponse{Values: correct}
+	}
+
+	scored(doc, declaration, weight, true)
+	doc.ItemBody.Blocks = []interface{}{ChoiceInteraction{
+		ResponseIdentifier: responseID,
+		MaxChoices:         maxChoices,
+		Prompt:             &Prompt{Text: item.Title},
+		Choices:            choices,
+	}}
+	return nil
+}
+
+// convertTextEntry maps text_entry items; a correct answer must be matched
+// exactly, and answers to items without one are left to a marker
+func convertTextEntry(doc *AssessmentItem, item *core.Item, weight float64) error {
+	var content types.TextEntryContent
+	if err := decodeContent(item, &content); err != nil {
+		return err
+	}
+
+	declaration := ResponseDeclaration{Identifier: responseID, Cardinality: cardinalitySingle, BaseType: baseTypeString}
+	answer := optional(content.CorrectAnswer)
+	if answer != "" {
+		declaration.CorrectResponse = &CorrectResponse{Values: []string{answer}}
+		declaration.Mapping = &Mapping{Entries: []MapEntry{{MapKey: answer, MappedValue: weight, CaseSensitive: true}}}
+	}
+	scored(doc, declaration, weight, answer != "")
+
+	expectedLength := 0
+	if content.MaxLength != nil {
+		expectedLength = *content.MaxLength
+	}
+	if content.Multiline {
+		doc.ItemBody.Blocks = []interface{}{ExtendedTextInteraction{
+			ResponseIdentifier: responseID,
+			ExpectedLength:     expectedLength,
+			PlaceholderText:    optional(content.Placeholder),
+			Prompt:             &Prompt{Text: item.Title},
+		}}
+		return nil
+	}
+	// A text entry is inline, so it sits in a paragraph under the question
+	doc.ItemBody.Blocks = []interface{}{
+		Paragraph{Text: item.Title},
+		Paragraph{Inline: []interface{}{TextEntryInteraction{
+			ResponseIdentifier: responseID,
+			ExpectedLength:     expectedLength,
+			PlaceholderText:    optional(content.Placeholder),
+		}}},
+	}
+	return nil
+}
+
+// convertOrdering maps ordering items, scored all or nothing
+func convertOrdering(doc *AssessmentItem, item *core.Item, weight float64) error {
+	var content types.OrderingContent
+	if err := decodeContent(item, &content); err != nil {
+		return err
+	}
+
+	choices := make([]SimpleChoice, len(content.Items))
+	order := make([]int, len(content.Items))
+	for i, entry := range content.Items {
+		choices[i] = SimpleChoice{Identifier: choiceIdentifier(i), Text: entry.Text}
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return content.Items[order[a]].CorrectOrder < content.Items[order[b]].CorrectOrder
+	})
+	correct := make([]string, len(order))
+	for i, index := range order {
+		correct[i] = choiceIdentifier(index)
+	}
+
+	scored(doc, ResponseDeclaration{
+		Identifier:      responseID,
+		Cardinality:     cardinalityOrdered,
+		BaseType:        baseTypeIdentifier,
+		CorrectResponse: &CorrectResponse{Values: correct},
+	}, weight, false)
+	doc.ResponseProcessing = matchCorrect(weight)
+	doc.ItemBody.Blocks = []interface{}{OrderInteraction{
+		ResponseIdentifier: responseID,
+		// The entries are stored in the order they are shown, which may
+		// well be the correct one
+		Shuffle: true,
+		Prompt:  &Prompt{Text: item.Title},
+		Choices: choices,
+	}}
+	return nil
+}
+
+// convertHotspot maps hotspot items. Any correct area scores the weight.
+func convertHotspot(doc *AssessmentItem, item *core.Item, weight float64, hrefs func(string) string) error {
+	var content types.HotspotContent
+	if err := decodeContent(item, &content); err != nil {
+		return err
+	}
+
+	declaration := ResponseDeclaration{Identifier: responseID, Cardinality: cardinalitySingle, BaseType: baseTypeIdentifier}
+	mapping := &Mapping{}
+	choices := make([]HotspotChoice, len(content.Hotspots))
+	for i, hotspot := range content.Hotspots {
+		shape, coords, err := convertCoords(hotspot)
+		if err != nil {
+			return err
+		}
+		choices[i] = HotspotChoice{Identifier: choiceIdentifier(i), Shape: shape, Coords: coords}
+
+		value := 0.0
+		if hotspot.Correct {
+			value = weight
+			if declaration.CorrectResponse == nil {
+				declaration.CorrectResponse = &CorrectResponse{Values: []string{choiceIdentifier(i)}}
+			}
+		}
+		mapping.Entries = append(mapping.Entries, MapEntry{MapKey: choiceIdentifier(i), MappedValue: value, CaseSensitive: true})
+	}
+	declaration.Mapping = mapping
+
+	scored(doc, declaration, weight, true)
+	doc.ItemBody.Blocks = []interface{}{HotspotInteraction{
+		ResponseIdentifier: responseID,
+		MaxChoices:         1,
+		Prompt:             &Prompt{Text: item.Title},
+		Object: Object{
+			Data: hrefs(content.ImageURL),
+			Type: core.GetContentTypeFromFilename(content.ImageURL),
+			Text: optional(content.AltText),
+		},
+		Choices: choices,
+	}}
+	return nil
+}
+
+// convertCoords maps a hotspot's shape and coordinates onto QTI's. Our
+// rectangles are x, y, width, height where QTI's are left, top, right,
+// bottom, and QTI polygons repeat their first point at the end. Coordinates
+// are rounded to whole pixels.
+func convertCoords(hotspot types.Hotspot) (string, string, error) {
+	c := hotspot.Coords
+	var shape string
+	var coords []float64
+	switch {
+	case hotspot.Shape == "rectangle" && len(c) == 4:
+		shape, coords = "rect", []float64{c[0], c[1], c[0] + c[2], c[1] + c[3]}
+	case hotspot.Shape == "circle" && len(c) == 3:
+		shape, coords = "circle", c
+	case hotspot.Shape == "polygon" && len(c) >= 6 && len(c)%2 == 0:
+		shape, coords = "poly", c
+		if c[0] != c[len(c)-2] || c[1] != c[len(c)-1] {
+			coords = append(append([]float64(nil), c...), c[0], c[1])
+		}
+	default:
+		return "", "", fmt.Errorf("%w: hotspot %s has %d coordinates for a %s", core.ErrItemInvalidContent, hotspot.ID, len(c), hotspot.Shape)
+	}
+
+	values := make([]string, len(coords))
+	for i, v := range coords {
+		values[i] = strconv.Itoa(int(math.Round(v)))
+	}
+	return shape, strings.Join(values, ","), nil
+}
+
+// scored declares the response, SCORE and MAXSCORE. Mapped responses are
+// scored with the map_response template; the caller sets the processing of
+// other responses.
+func scored(doc *AssessmentItem, declaration ResponseDeclaration, weight float64, mapped bool) {
+	doc.ResponseDeclarations = []ResponseDeclaration{declaration}
+	doc.OutcomeDeclarations = []OutcomeDeclaration{
+		{Identifier: scoreID, Cardinality: cardinalitySingle, BaseType: baseTypeFloat, DefaultValue: &DefaultValue{Value: "0"}},
+		{Identifier: maxScoreID, Cardinality: cardinalitySingle, BaseType: baseTypeFloat, DefaultValue: &DefaultValue{Value: formatFloat(weight)}},
+	}
+	if mapped {
+		doc.ResponseProcessing = &ResponseProcessing{Template: templateMapResponse}
+	}
+}
+
+// matchCorrect scores weight for the correct response and 0 otherwise
+func matchCorrect(weight float64) *ResponseProcessing {
+	return &ResponseProcessing{Conditions: []ResponseCondition{{
+		If: ResponseIf{
+			Match: Match{Variable: VariableRef{Identifier: responseID}, Correct: VariableRef{Identifier: responseID}},
+			SetOutcomeValue: SetOutcomeValue{
+				Identifier: scoreID,
+				BaseValue:  BaseValue{BaseType: baseTypeFloat, Value: formatFloat(weight)},
+			},
+		},
+		Else: ResponseElse{SetOutcomeValue: SetOutcomeValue{
+			Identifier: scoreID,
+			BaseValue:  BaseValue{BaseType: baseTypeFloat, Value: "0"},
+		}},
+	}}}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func optional(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
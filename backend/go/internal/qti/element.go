@@ -0,0 +1,159 @@
+package qti
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// element is a parsed XML element. Imported packages come from many tools
+// that each use their own subset of QTI and of XHTML in item bodies, so
+// they are read as a tree rather than into typed structs. Names are local:
+// namespaces and their versions are ignored.
+type element struct {
+	name  string
+	attrs map[string]string
+	// content is the element's text and child elements in document order,
+	// as strings and *element
+	content []interface{}
+}
+
+// parseElement reads the root element of an XML document. HTML entities
+// such as &nbsp;, which item bodies often use, are accepted.
+func parseElement(r io.Reader) (*element, error) {
+	dec := xml.NewDecoder(r)
+	dec.Entity = xml.HTMLEntity
+
+	var stack []*element
+	var root *element
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			e := &element{name: tok.Name.Local, attrs: make(map[string]string, len(tok.Attr))}
+			for _, attr := range tok.Attr {
+				e.attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.content = append(parent.content, e)
+			} else if root == nil {
+				root = e
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.content = append(parent.content, string(tok))
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
+
+// children returns the element's child elements named name, or all of
+// them for ""
+func (e *element) children(name string) []*element {
+	var children []*element
+	for _, c := range e.content {
+		if child, ok := c.(*element); ok && (name == "" || child.name == name) {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// child returns the first child element named name, or nil
+func (e *element) child(name string) *element {
+	if children := e.children(name); len(children) > 0 {
+		return children[0]
+	}
+	return nil
+}
+
+// findAll returns the descendants for which match is true, depth first,
+// without looking inside matching elements
+func (e *element) findAll(match func(*element) bool) []*element {
+	var found []*element
+	for _, child := range e.children("") {
+		if match(child) {
+			found = append(found, child)
+			continue
+		}
+		found = append(found, child.findAll(match)...)
+	}
+	return found
+}
+
+// parent returns the element holding the descendant d, or nil
+func (e *element) parent(d *element) *element {
+	for _, child := range e.children("") {
+		if child == d {
+			return e
+		}
+		if p := child.parent(d); p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// find returns the first descendant named name, or nil
+func (e *element) find(name string) *element {
+	found := e.findAll(func(c *element) bool { return c.name == name })
+	if len(found) > 0 {
+		return found[0]
+	}
+	return nil
+}
+
+// blockElements are the XHTML elements that separate words in text
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "ul": true, "ol": true, "li": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"table": true, "tr": true, "td": true, "th": true, "blockquote": true, "pre": true,
+}
+
+// text returns the element's text with whitespace collapsed. replace, if
+// set, stands in for descendants: it returns their text and true, or false
+// to descend into them.
+func (e *element) text(replace func(*element) (string, bool)) string {
+	var b strings.Builder
+	var walk func(*element)
+	walk = func(e *element) {
+		for _, c := range e.content {
+			switch c := c.(type) {
+			case string:
+				b.WriteString(c)
+			case *element:
+				if replace != nil {
+					if s, ok := replace(c); ok {
+						b.WriteString(s)
+						continue
+					}
+				}
+				if blockElements[c.name] {
+					b.WriteString(" ")
+				}
+				walk(c)
+				if blockElements[c.name] {
+					b.WriteString(" ")
+				}
+			}
+		}
+	}
+	walk(e)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
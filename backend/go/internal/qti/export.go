@@ -0,0 +1,164 @@
+// Package qti exports projects as QTI 2.1 content packages, which LMSs
+// import as item banks.
+package qti
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// manifestName is the manifest's path in the package
+const manifestName = "imsmanifest.xml"
+
+// assetsDir is where the package keeps the project's assets
+const assetsDir = "assets/"
+
+// Exporter writes projects as QTI 2.1 zip packages
+type Exporter struct {
+	storage core.Storage
+}
+
+// NewExporter creates an exporter packaging the assets items reference from
+// storage. Without storage, asset URLs are kept as they are.
+func NewExporter(storage core.Storage) *Exporter {
+	return &Exporter{storage: storage}
+}
+
+// packaged is an item document and the assets it references
+type packaged struct {
+	name   string
+	doc    *AssessmentItem
+	assets []string
+}
+
+// Export writes the project's items to w as a zip. Items are converted and
+// assets checked before anything is written, so an error returned before
+// the zip starts leaves w untouched: core.ErrItemInvalidContent for an item
+// that can't be mapped, core.ErrFileNotFound for a missing asset.
+func (e *Exporter) Export(ctx context.Context, w io.Writer, project *core.Project, items []*core.Item) error {
+	// keys maps package paths to storage keys
+	keys := make(map[string]string)
+	prefix := fmt.Sprintf("projects/%s/assets/", project.ID)
+
+	docs := make([]packaged, 0, len(items))
+	for _, item := range items {
+		var assets []string
+		hrefs := func(raw string) string {
+			key, ok := core.ProjectAssetKey(raw, project.ID)
+			if !ok || e.storage == nil {
+				return raw
+			}
+			name := assetsDir + strings.TrimPrefix(key, prefix)
+			keys[name] = key
+			assets = append(assets, name)
+			return name
+		}
+		doc, err := convertItem(item, hrefs)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, packaged{name: itemIdentifier(item) + ".xml", doc: doc, assets: assets})
+	}
+
+	names := make([]string, 0, len(keys))
+	for name, key := range keys {
+		exists, err := e.storage.Exists(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to check asset %s: %w", key, err)
+		}
+		if !exists {
+			return fmt.Errorf("%w: %s", core.ErrFileNotFound, key)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zw := zip.NewWriter(w)
+	header := func(name string) *zip.FileHeader {
+		return &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: project.UpdatedAt}
+	}
+
+	if err := writeXML(zw, header(manifestName), buildManifest(project, docs)); err != nil {
+		return err
+	}
+	for _, d := range docs {
+		if err := writeXML(zw, header(d.name), d.doc); err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		if err := e.copyAsset(ctx, zw, header(name), keys[name]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// buildManifest lists one resource per item, with the assets it uses
+func buildManifest(project *core.Project, docs []packaged) *Manifest {
+	manifest := &Manifest{
+		Xmlns:          NamespaceManifest,
+		XmlnsXSI:       namespaceXSI,
+		SchemaLocation: schemaLocationManifest,
+		Identifier:     "project-" + project.ID,
+		Metadata:       ManifestMetadata{Schema: "QTIv2.1 Package", SchemaVersion: "1.0.0"},
+	}
+	for _, d := range docs {
+		resource := Resource{
+			Identifier: d.doc.Identifier,
+			Type:       ResourceTypeItem,
+			Href:       d.name,
+			Files:      []ResourceFile{{Href: d.name}},
+		}
+		seen := make(map[string]bool)
+		for _, asset := range d.assets {
+			if !seen[asset] {
+				seen[asset] = true
+				resource.Files = append(resource.Files, ResourceFile{Href: asset})
+			}
+		}
+		manifest.Resources = append(manifest.Resources, resource)
+	}
+	return manifest
+}
+
+func writeXML(zw *zip.Writer, header *zip.FileHeader, v interface{}) error {
+	f, err := zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", header.Name, err)
+	}
+	if _, err := io.WriteString(f, xml.Header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", header.Name, err)
+	}
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write %s: %w", header.Name, err)
+	}
+	_, err = io.WriteString(f, "\n")
+	return err
+}
+
+func (e *Exporter) copyAsset(ctx context.Context, zw *zip.Writer, header *zip.FileHeader, key string) error {
+	body, _, err := e.storage.Download(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to read asset %s: %w", key, err)
+	}
+	defer body.Close()
+
+	f, err := zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", header.Name, err)
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		return fmt.Errorf("failed to write %s: %w", header.Name, err)
+	}
+	return nil
+}
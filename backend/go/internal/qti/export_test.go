@@ -0,0 +1,408 @@
+package qti
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+const projectID = "5f0c6a4e-2d1b-4c8e-9a7f-3b6d8e1f2a90"
+
+func strPtr(s string) *string { return &s }
+func intPtr(i int) *int       { return &i }
+
+func newItem(t *testing.T, id string, itemType types.ItemType, title string, content interface{}) *core.Item {
+	t.Helper()
+	data, err := json.Marshal(content)
+	require.NoError(t, err)
+	return &core.Item{ID: id, ProjectID: projectID, Type: itemType, Title: title, Content: data}
+}
+
+// sampleItems has one item of every type, as golden file name and item
+func sampleItems(t *testing.T) map[string]*core.Item {
+	assetURL := "/files/projects/" + projectID + "/assets/"
+
+	choice := newItem(t, "c1", types.ItemTypeChoice, "Which planet is largest?", types.ChoiceContent{Choices: []types.Choice{
+		{ID: "a", Text: "Mars"}, {ID: "b", Text: "Jupiter", Correct: true}, {ID: "c", Text: "Venus"},
+	}})
+	choice.Points = intPtr(2)
+
+	multi := newItem(t, "m1", types.ItemTypeMultiChoice, "Which are gas giants?", types.ChoiceContent{Choices: []types.Choice{
+		{ID: "a", Text: "Jupiter", Correct: true}, {ID: "b", Text: "Earth"}, {ID: "c", Text: "Saturn", Correct: true}, {ID: "d", Text: "Mars"},
+	}})
+	multi.Points = intPtr(4)
+
+	return map[string]*core.Item{
+		"title": newItem(t, "t1", types.ItemTypeTitle, "The Solar System", map[string]interface{}{}),
+		"media_image": newItem(t, "i1", types.ItemTypeMedia, "Saturn's rings", types.MediaContent{
+			URL: assetURL + "saturn.png", MediaType: "image", AltText: strPtr("Saturn & its rings"), Caption: strPtr("Taken by Cassini"),
+		}),
+		"media_video": newItem(t, "v1", types.ItemTypeMedia, "Launch", types.MediaContent{
+			URL: assetURL + "launch.mp4", MediaType: "video",
+		}),
+		"choice":       choice,
+		"multi_choice": multi,
+		"text_entry": newItem(t, "e1", types.ItemTypeTextEntry, "Name the red planet", types.TextEntryContent{
+			MaxLength: intPtr(20), Placeholder: strPtr("Planet"), CorrectAnswer: strPtr("Mars"),
+		}),
+		"text_entry_multiline": newItem(t, "e2", types.ItemTypeTextEntry, "Describe an eclipse", types.TextEntryContent{
+			Multiline: true,
+		}),
+		"ordering": newItem(t, "o1", types.ItemTypeOrdering, "Order by distance from the Sun", types.OrderingContent{Items: []types.OrderingItem{
+			{ID: "a", Text: "Mars", CorrectOrder: 3}, {ID: "b", Text: "Mercury", CorrectOrder: 1}, {ID: "c", Text: "Earth", CorrectOrder: 2},
+		}}),
+		"hotspot": newItem(t, "h1", types.ItemTypeHotspot, "Click the Great Red Spot", types.HotspotContent{
+			ImageURL: assetURL + "jupiter.png",
+			AltText:  strPtr("Jupiter"),
+			Hotspots: []types.Hotspot{
+				{ID: "spot", Shape: "circle", Coords: []float64{120, 80.4, 15}, Correct: true},
+				{ID: "pole", Shape: "rectangle", Coords: []float64{10, 10, 50.5, 20}},
+				{ID: "band", Shape: "polygon", Coords: []float64{0, 0, 40, 0, 20, 30}},
+			},
+		}),
+	}
+}
+
+func marshalItem(t *testing.T, item *core.Item) []byte {
+	t.Helper()
+	doc, err := convertItem(item, func(raw string) string {
+		key, ok := core.ProjectAssetKey(raw, projectID)
+		require.True(t, ok)
+		return assetsDir + filepath.Base(key)
+	})
+	require.NoError(t, err)
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	require.NoError(t, err)
+	return append([]byte(xml.Header), append(out, '\n')...)
+}
+
+func TestConvertItem_Golden(t *testing.T) {
+	for name, item := range sampleItems(t) {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			got := marshalItem(t, item)
+
+			// Assert
+			golden := filepath.Join("testdata", name+".xml")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, got, 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+// itemChildren is the sequence of assessmentItem's children in the QTI 2.1
+// schema; each may repeat, in this order
+var itemChildren = []string{
+	"responseDeclaration", "outcomeDeclaration", "templateDeclaration", "templateProcessing",
+	"stylesheet", "itemBody", "responseProcessing", "modalFeedback",
+}
+
+// requiredAttrs are the attributes the schema requires of the elements we
+// write
+var requiredAttrs = map[string][]string{
+	"assessmentItem":          {"identifier", "title", "adaptive", "timeDependent"},
+	"responseDeclaration":     {"identifier", "cardinality"},
+	"outcomeDeclaration":      {"identifier", "cardinality"},
+	"mapEntry":                {"mapKey", "mappedValue"},
+	"choiceInteraction":       {"responseIdentifier", "shuffle", "maxChoices"},
+	"orderInteraction":        {"responseIdentifier", "shuffle"},
+	"hotspotInteraction":      {"responseIdentifier", "maxChoices"},
+	"textEntryInteraction":    {"responseIdentifier"},
+	"extendedTextInteraction": {"responseIdentifier"},
+	"simpleChoice":            {"identifier"},
+	"hotspotChoice":           {"identifier", "shape", "coords"},
+	"rubricBlock":             {"view"},
+	"img":                     {"src", "alt"},
+	"object":                  {"data", "type"},
+	"baseValue":               {"baseType"},
+	"variable":                {"identifier"},
+	"correct":                 {"identifier"},
+	"setOutcomeValue":         {"identifier"},
+}
+
+// inlineOnly are interactions the schema only allows inside text
+var inlineOnly = map[string]bool{"textEntryInteraction": true}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// node is a parsed element, for checking a document's structure
+type node struct {
+	name     xml.Name
+	attrs    map[string]string
+	children []*node
+}
+
+func parseNode(t *testing.T, data []byte) *node {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*node
+	var root *node
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: tok.Name, attrs: make(map[string]string)}
+			for _, attr := range tok.Attr {
+				n.attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) == 0 {
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+	require.NotNil(t, root)
+	return root
+}
+
+func (n *node) walk(parent *node, fn func(n, parent *node)) {
+	fn(n, parent)
+	for _, child := range n.children {
+		child.walk(n, fn)
+	}
+}
+
+// TestGolden_FollowsSchema checks the golden files against the parts of the
+// QTI 2.1 schema the exporter relies on: the namespace, the order of the
+// item's children, required attributes, identifiers and where interactions
+// may appear. The schema itself can't be fetched in tests.
+func TestGolden_FollowsSchema(t *testing.T) {
+	for name := range sampleItems(t) {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			data, err := os.ReadFile(filepath.Join("testdata", name+".xml"))
+			require.NoError(t, err)
+
+			// Act
+			root := parseNode(t, data)
+
+			// Assert
+			assert.Equal(t, NamespaceItem, root.name.Space)
+			assert.Equal(t, "assessmentItem", root.name.Local)
+			assert.Equal(t, schemaLocationItem, root.attrs["schemaLocation"])
+
+			last := -1
+			for _, child := range root.children {
+				index := indexOf(itemChildren, child.name.Local)
+				require.GreaterOrEqual(t, index, 0, "unexpected child %s", child.name.Local)
+				assert.GreaterOrEqual(t, index, last, "%s is out of order", child.name.Local)
+				last = index
+			}
+
+			declared := make(map[string]bool)
+			root.walk(nil, func(n, parent *node) {
+				assert.Equal(t, NamespaceItem, n.name.Space, "%s is outside the QTI namespace", n.name.Local)
+				for _, attr := range requiredAttrs[n.name.Local] {
+					assert.Contains(t, n.attrs, attr, "%s needs %s", n.name.Local, attr)
+				}
+				for _, attr := range []string{"identifier", "responseIdentifier"} {
+					if id, ok := n.attrs[attr]; ok {
+						assert.Regexp(t, identifierPattern, id)
+					}
+				}
+				if n.name.Local == "responseDeclaration" || n.name.Local == "outcomeDeclaration" {
+					declared[n.attrs["identifier"]] = true
+				}
+				if inlineOnly[n.name.Local] {
+					assert.Equal(t, "p", parent.name.Local, "%s must be inline", n.name.Local)
+				}
+			})
+			root.walk(nil, func(n, _ *node) {
+				if id, ok := n.attrs["responseIdentifier"]; ok {
+					assert.True(t, declared[id], "%s is not declared", id)
+				}
+				if n.name.Local == "setOutcomeValue" || n.name.Local == "variable" {
+					assert.True(t, declared[n.attrs["identifier"]], "%s is not declared", n.attrs["identifier"])
+				}
+			})
+		})
+	}
+}
+
+func TestConvertCoords(t *testing.T) {
+	tests := []struct {
+		name    string
+		hotspot types.Hotspot
+		shape   string
+		coords  string
+		wantErr bool
+	}{
+		{"rectangle to corners", types.Hotspot{Shape: "rectangle", Coords: []float64{10, 20, 30, 40}}, "rect", "10,20,40,60", false},
+		{"circle rounded", types.Hotspot{Shape: "circle", Coords: []float64{10.4, 20.6, 5}}, "circle", "10,21,5", false},
+		{"open polygon closed", types.Hotspot{Shape: "polygon", Coords: []float64{0, 0, 10, 0, 5, 5}}, "poly", "0,0,10,0,5,5,0,0", false},
+		{"closed polygon kept", types.Hotspot{Shape: "polygon", Coords: []float64{0, 0, 10, 0, 5, 5, 0, 0}}, "poly", "0,0,10,0,5,5,0,0", false},
+		{"short rectangle", types.Hotspot{Shape: "rectangle", Coords: []float64{10, 20, 30}}, "", "", true},
+		{"odd polygon", types.Hotspot{Shape: "polygon", Coords: []float64{0, 0, 10, 0, 5}}, "", "", true},
+		{"unknown shape", types.Hotspot{Shape: "star", Coords: []float64{0, 0, 1}}, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			shape, coords, err := convertCoords(tt.hotspot)
+
+			// Assert
+			if tt.wantErr {
+				assert.ErrorIs(t, err, core.ErrItemInvalidContent)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.shape, shape)
+			assert.Equal(t, tt.coords, coords)
+		})
+	}
+}
+
+// memStorage keeps files in memory
+type memStorage struct {
+	core.Storage
+	files map[string]string
+}
+
+func (s *memStorage) Exists(ctx context.Context, key string) (bool, error) {
+	_, ok := s.files[key]
+	return ok, nil
+}
+
+func (s *memStorage) Download(ctx context.Context, key string) (io.ReadCloser, *core.StorageMetadata, error) {
+	data, ok := s.files[key]
+	if !ok {
+		return nil, nil, core.ErrFileNotFound
+	}
+	return io.NopCloser(strings.NewReader(data)), &core.StorageMetadata{Key: key}, nil
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestExporter_Export(t *testing.T) {
+	// Arrange
+	samples := sampleItems(t)
+	items := []*core.Item{samples["title"], samples["media_image"], samples["hotspot"], samples["choice"]}
+	items = append(items, newItem(t, "x1", types.ItemTypeMedia, "Elsewhere", types.MediaContent{
+		URL: "https://example.com/moon.png", MediaType: "image",
+	}))
+	storage := &memStorage{files: map[string]string{
+		"projects/" + projectID + "/assets/saturn.png":  "saturn",
+		"projects/" + projectID + "/assets/jupiter.png": "jupiter",
+	}}
+	project := &core.Project{ID: projectID, Title: "Planets", UpdatedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	var buf bytes.Buffer
+
+	// Act
+	err := NewExporter(storage).Export(context.Background(), &buf, project, items)
+
+	// Assert
+	require.NoError(t, err)
+	files := readZip(t, buf.Bytes())
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{
+		"assets/jupiter.png", "assets/saturn.png", "imsmanifest.xml",
+		"item-c1.xml", "item-h1.xml", "item-i1.xml", "item-t1.xml", "item-x1.xml",
+	}, names)
+	assert.Equal(t, "saturn", files["assets/saturn.png"])
+	assert.Equal(t, string(marshalItem(t, samples["hotspot"])), files["item-h1.xml"])
+	assert.Contains(t, files["item-x1.xml"], `src="https://example.com/moon.png"`, "external URLs are kept")
+
+	golden := filepath.Join("testdata", manifestName)
+	if *update {
+		require.NoError(t, os.WriteFile(golden, []byte(files[manifestName]), 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), files[manifestName])
+
+	manifest := parseNode(t, []byte(files[manifestName]))
+	assert.Equal(t, NamespaceManifest, manifest.name.Space)
+	assert.Equal(t, schemaLocationManifest, manifest.attrs["schemaLocation"])
+	var children []string
+	for _, child := range manifest.children {
+		children = append(children, child.name.Local)
+	}
+	assert.Equal(t, []string{"metadata", "organizations", "resources"}, children)
+	manifest.walk(nil, func(n, _ *node) {
+		if n.name.Local == "file" {
+			assert.Contains(t, files, n.attrs["href"], "the manifest lists a file the package lacks")
+		}
+	})
+}
+
+func TestExporter_Export_Errors(t *testing.T) {
+	project := &core.Project{ID: projectID, Title: "Planets"}
+	samples := sampleItems(t)
+
+	tests := []struct {
+		name    string
+		item    *core.Item
+		wantErr error
+	}{
+		{"missing asset", samples["media_image"], core.ErrFileNotFound},
+		{"invalid content", &core.Item{ID: "bad", Type: types.ItemTypeChoice, Content: json.RawMessage(`"nope"`)}, core.ErrItemInvalidContent},
+		{"invalid coordinates", newItem(t, "h2", types.ItemTypeHotspot, "Where?", types.HotspotContent{
+			ImageURL: "https://example.com/map.png",
+			Hotspots: []types.Hotspot{{ID: "a", Shape: "rectangle", Coords: []float64{1, 2}}},
+		}), core.ErrItemInvalidContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var buf bytes.Buffer
+
+			// Act
+			err := NewExporter(&memStorage{}).Export(context.Background(), &buf, project, []*core.Item{tt.item})
+
+			// Assert
+			assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+			assert.Zero(t, buf.Len(), "nothing is written before the export is known to succeed")
+		})
+	}
+}
@@ -0,0 +1,556 @@
+package qti
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+const (
+	// MaxImportItems is the most items a package may hold
+	MaxImportItems = 500
+
+	// maxDocumentBytes bounds the manifest and each item document, which
+	// are read into memory
+	maxDocumentBytes = 4 << 20
+
+	// maxTitleLength is the longest item title
+	maxTitleLength = 500
+	// maxAltTextLength is the longest text alternative of media
+	maxAltTextLength = 200
+
+	// blank stands in for an inline interaction in an imported title
+	blank = "____"
+
+	// fileBase is how Canvas refers to the package's web_resources folder
+	fileBase = "$IMS-CC-FILEBASE$"
+)
+
+// Package is a QTI content package read for import
+type Package struct {
+	files map[string]*zip.File
+	// Items are the package's items in manifest order, with the package
+	// paths of the files each uses as Assets
+	Items []*core.ImportedItem
+}
+
+// ReadPackage reads a QTI 2.x content package. Items that can't be
+// imported are kept with the reason; only a package that can't be read at
+// all, such as one without imsmanifest.xml, returns
+// core.ErrImportInvalidPackage.
+func ReadPackage(r io.ReaderAt, size int64) (*Package, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a zip file", core.ErrImportInvalidPackage)
+	}
+
+	pkg := &Package{files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		pkg.files[path.Clean(strings.TrimPrefix(f.Name, "/"))] = f
+	}
+
+	manifest, err := pkg.parse(manifestName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrImportInvalidPackage, err)
+	}
+
+	for _, resource := range resources(manifest) {
+		resourceType := resource.attrs["type"]
+		source := resource.attrs["href"]
+		if source == "" {
+			source = resource.attrs["identifier"]
+		}
+		switch {
+		case strings.HasPrefix(resourceType, "imsqti_item_xmlv2"):
+			pkg.Items = append(pkg.Items, pkg.readItem(source))
+		case strings.HasPrefix(resourceType, "imsqti_xmlv1") || strings.HasPrefix(resourceType, "imsqti_assessment_xmlv1"):
+			// QTI 1.2 quizzes hold all their items in one document
+			pkg.Items = append(pkg.Items, &core.ImportedItem{
+				Source: source,
+				Reason: "QTI 1.2 is not supported; export the quiz as QTI 2.1",
+			})
+		}
+	}
+
+	if len(pkg.Items) == 0 {
+		return nil, fmt.Errorf("%w: the manifest lists no assessment items", core.ErrImportInvalidPackage)
+	}
+	if len(pkg.Items) > MaxImportItems {
+		return nil, fmt.Errorf("%w: %d items, at most %d can be imported at once", core.ErrImportInvalidPackage, len(pkg.Items), MaxImportItems)
+	}
+	return pkg, nil
+}
+
+// Open opens a file of the package
+func (p *Package) Open(name string) (io.ReadCloser, int64, error) {
+	f, ok := p.files[name]
+	if !ok {
+		return nil, 0, fmt.Errorf("%s is missing from the package", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	return rc, int64(f.UncompressedSize64), nil
+}
+
+// parse reads an XML document of the package
+func (p *Package) parse(name string) (*element, error) {
+	rc, _, err := p.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	root, err := parseElement(io.LimitReader(rc, maxDocumentBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", name, err)
+	}
+	return root, nil
+}
+
+// resources lists the manifest's resources, in order
+func resources(manifest *element) []*element {
+	var list []*element
+	for _, group := range manifest.children("resources") {
+		list = append(list, group.children("resource")...)
+	}
+	return list
+}
+
+// readItem reads the item document at href
+func (p *Package) readItem(href string) *core.ImportedItem {
+	item := &core.ImportedItem{Source: href}
+	name := path.Clean(href)
+
+	root, err := p.parse(name)
+	if err != nil {
+		item.Reason = err.Error()
+		return item
+	}
+	if root.name != "assessmentItem" {
+		item.Reason = fmt.Sprintf("%s is not an assessmentItem", href)
+		return item
+	}
+	item.Title = root.attrs["title"]
+
+	conv := &itemReader{root: root, dir: path.Dir(name), pkg: p}
+	input, err := conv.read()
+	if err != nil {
+		item.Reason = err.Error()
+		return item
+	}
+	item.Input = input
+	item.Assets = conv.assets
+	return item
+}
+
+// itemReader converts one assessmentItem into an item
+type itemReader struct {
+	root *element
+	// dir is the item document's folder, which its references are
+	// relative to
+	dir string
+	pkg *Package
+	// assets are the package paths of the files the item uses
+	assets []string
+}
+
+// interactions are the interactions items can be made of
+var interactions = map[string]bool{
+	"choiceInteraction":       true,
+	"textEntryInteraction":    true,
+	"extendedTextInteraction": true,
+	"orderInteraction":        true,
+	"hotspotInteraction":      true,
+	"inlineChoiceInteraction": true,
+}
+
+// inlineInteractions sit within text, which a blank stands in for in the
+// title
+var inlineInteractions = map[string]bool{
+	"textEntryInteraction":    true,
+	"inlineChoiceInteraction": true,
+}
+
+func (r *itemReader) read() (*core.ItemInput, error) {
+	body := r.root.child("itemBody")
+	if body == nil {
+		return nil, errors.New("the item has no itemBody")
+	}
+
+	found := body.findAll(func(e *element) bool {
+		return strings.HasSuffix(e.name, "Interaction")
+	})
+	if len(found) > 1 {
+		return nil, fmt.Errorf("the item has %d interactions; only items with one can be imported", len(found))
+	}
+	if len(found) == 0 {
+		return r.readContent(body)
+	}
+
+	interaction := found[0]
+	if !interactions[interaction.name] {
+		return nil, fmt.Errorf("%s is not supported", interaction.name)
+	}
+
+	input := &core.ItemInput{Title: r.title(body, interaction), Points: r.points()}
+	if input.Title == "" {
+		return nil, errors.New("the item has no question text")
+	}
+	correct := r.correct(interaction.attrs["responseIdentifier"])
+
+	var err error
+	switch interaction.name {
+	case "choiceInteraction", "inlineChoiceInteraction":
+		input.Type, input.Content, err = readChoice(interaction, correct)
+	case "textEntryInteraction", "extendedTextInteraction":
+		input.Type, input.Content = types.ItemTypeTextEntry, readTextEntry(interaction, correct)
+	case "orderInteraction":
+		input.Type, input.Content, err = readOrdering(interaction, correct)
+	case "hotspotInteraction":
+		input.Type, input.Content, err = r.readHotspot(interaction, correct)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+// readContent converts an item without an interaction: into a media item
+// if it shows an image or other media, and a title item otherwise
+func (r *itemReader) readContent(body *element) (*core.ItemInput, error) {
+	title := truncate(r.root.attrs["title"])
+	media := body.findAll(func(e *element) bool { return e.name == "img" || e.name == "object" })
+	if len(media) == 0 {
+		text := truncate(body.text(nil))
+		if text == "" {
+			text = title
+		}
+		if text == "" {
+			return nil, errors.New("the item is empty")
+		}
+		return &core.ItemInput{Type: types.ItemTypeTitle, Title: text}, nil
+	}
+
+	ref, alt, contentType := mediaSource(media[0])
+	src, err := r.asset(ref)
+	if err != nil {
+		return nil, err
+	}
+	if contentType == "" {
+		contentType = core.GetContentTypeFromFilename(ref)
+	}
+	content := types.MediaContent{URL: src, MediaType: "image"}
+	switch {
+	case strings.HasPrefix(contentType, "video/"):
+		content.MediaType = "video"
+	case strings.HasPrefix(contentType, "audio/"):
+		content.MediaType = "audio"
+	}
+	content.ShowControls = content.MediaType != "image"
+	if alt = truncateTo(alt, maxAltTextLength); alt != "" {
+		content.AltText = &alt
+	}
+	// Headings repeat the title
+	caption := truncate(body.text(func(e *element) (string, bool) {
+		return " ", len(e.name) == 2 && e.name[0] == 'h' && e.name[1] >= '1' && e.name[1] <= '6'
+	}))
+	if caption != "" {
+		content.Caption = &caption
+	}
+	if title == "" {
+		title = "Media"
+	}
+	return &core.ItemInput{Type: types.ItemTypeMedia, Title: title, Content: content}, nil
+}
+
+// mediaSource returns the reference, text alternative and declared type of
+// an img or object
+func mediaSource(e *element) (ref, alt, contentType string) {
+	if e.name == "img" {
+		return e.attrs["src"], e.attrs["alt"], ""
+	}
+	return e.attrs["data"], e.text(nil), e.attrs["type"]
+}
+
+// title is the question: the interaction's prompt, or the body's text with
+// a blank for an inline interaction, or the item's title
+func (r *itemReader) title(body, interaction *element) string {
+	if prompt := interaction.child("prompt"); prompt != nil {
+		if text := prompt.text(nil); text != "" {
+			return truncate(text)
+		}
+	}
+	// The blank goes where the interaction sits in a sentence, not in a
+	// paragraph of its own
+	inline := false
+	if inlineInteractions[interaction.name] {
+		inline = body.parent(interaction).text(func(e *element) (string, bool) {
+			return "", e == interaction
+		}) != ""
+	}
+	text := body.text(func(e *element) (string, bool) {
+		if e == interaction && inline {
+			return blank, true
+		}
+		if e == interaction {
+			return " ", true
+		}
+		return "", false
+	})
+	if text != "" {
+		return truncate(text)
+	}
+	return truncate(r.root.attrs["title"])
+}
+
+// points is the item's MAXSCORE, or its mapping's largest score
+func (r *itemReader) points() *int {
+	for _, outcome := range r.root.children("outcomeDeclaration") {
+		if outcome.attrs["identifier"] != maxScoreID {
+			continue
+		}
+		if value := outcome.find("value"); value != nil {
+			if f, err := strconv.ParseFloat(value.text(nil), 64); err == nil && f >= 0 {
+				points := int(math.Round(f))
+				return &points
+			}
+		}
+	}
+
+	for _, declaration := range r.root.children("responseDeclaration") {
+		mapping := declaration.child("mapping")
+		if mapping == nil {
+			continue
+		}
+		if f, err := strconv.ParseFloat(mapping.attrs["upperBound"], 64); err == nil && f >= 0 {
+			points := int(math.Round(f))
+			return &points
+		}
+		best, sum := 0.0, 0.0
+		for _, entry := range mapping.children("mapEntry") {
+			if f, err := strconv.ParseFloat(entry.attrs["mappedValue"], 64); err == nil && f > 0 {
+				best = math.Max(best, f)
+				sum += f
+			}
+		}
+		if declaration.attrs["cardinality"] != cardinalitySingle {
+			best = sum
+		}
+		if best > 0 {
+			points := int(math.Round(best))
+			return &points
+		}
+	}
+	return nil
+}
+
+// correct lists the correct values of a response, in order: its
+// correctResponse, or else the keys its mapping scores
+func (r *itemReader) correct(responseIdentifier string) []string {
+	for _, declaration := range r.root.children("responseDeclaration") {
+		if declaration.attrs["identifier"] != responseIdentifier {
+			continue
+		}
+		var values []string
+		if correctResponse := declaration.child("correctResponse"); correctResponse != nil {
+			for _, value := range correctResponse.children("value") {
+				values = append(values, strings.TrimSpace(value.text(nil)))
+			}
+			return values
+		}
+		if mapping := declaration.child("mapping"); mapping != nil {
+			for _, entry := range mapping.children("mapEntry") {
+				if f, err := strconv.ParseFloat(entry.attrs["mappedValue"], 64); err == nil && f > 0 {
+					values = append(values, entry.attrs["mapKey"])
+				}
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func readChoice(interaction *element, correct []string) (types.ItemType, interface{}, error) {
+	name := "simpleChoice"
+	itemType := types.ItemTypeMultiChoice
+	if interaction.name == "inlineChoiceInteraction" {
+		// A fill-in-the-blank with options; only one fits the blank
+		name = "inlineChoice"
+		itemType = types.ItemTypeChoice
+	} else if interaction.attrs["maxChoices"] == "1" {
+		itemType = types.ItemTypeChoice
+	}
+
+	var content types.ChoiceContent
+	for _, choice := range interaction.children(name) {
+		text := choice.text(nil)
+		if text == "" {
+			return "", nil, fmt.Errorf("choice %s has no text", choice.attrs["identifier"])
+		}
+		content.Choices = append(content.Choices, types.Choice{
+			ID:      choice.attrs["identifier"],
+			Text:    text,
+			Correct: contains(correct, choice.attrs["identifier"]),
+		})
+	}
+	return itemType, content, nil
+}
+
+func readTextEntry(interaction *element, correct []string) types.TextEntryContent {
+	content := types.TextEntryContent{Multiline: interaction.name == "extendedTextInteraction"}
+	if n, err := strconv.Atoi(interaction.attrs["expectedLength"]); err == nil && n > 0 {
+		content.MaxLength = &n
+	}
+	if placeholder := interaction.attrs["placeholderText"]; placeholder != "" {
+		content.Placeholder = &placeholder
+	}
+	if len(correct) > 0 && correct[0] != "" {
+		content.CorrectAnswer = &correct[0]
+	}
+	return content
+}
+
+func readOrdering(interaction *element, correct []string) (types.ItemType, interface{}, error) {
+	var content types.OrderingContent
+	for _, choice := range interaction.children("simpleChoice") {
+		id := choice.attrs["identifier"]
+		order := indexOf(correct, id) + 1
+		if order == 0 {
+			return "", nil, fmt.Errorf("choice %s is missing from the correct order", id)
+		}
+		content.Items = append(content.Items, types.OrderingItem{ID: id, Text: choice.text(nil), CorrectOrder: order})
+	}
+	return types.ItemTypeOrdering, content, nil
+}
+
+func (r *itemReader) readHotspot(interaction *element, correct []string) (types.ItemType, interface{}, error) {
+	image := interaction.child("object")
+	if image == nil {
+		image = interaction.find("img")
+	}
+	if image == nil {
+		return "", nil, errors.New("the hotspot interaction has no image")
+	}
+	ref, alt, _ := mediaSource(image)
+	src, err := r.asset(ref)
+	if err != nil {
+		return "", nil, err
+	}
+
+	content := types.HotspotContent{ImageURL: src}
+	if alt = truncateTo(alt, maxAltTextLength); alt != "" {
+		content.AltText = &alt
+	}
+	for _, choice := range interaction.children("hotspotChoice") {
+		id := choice.attrs["identifier"]
+		shape, coords, err := readCoords(choice.attrs["shape"], choice.attrs["coords"])
+		if err != nil {
+			return "", nil, fmt.Errorf("hotspot %s: %w", id, err)
+		}
+		content.Hotspots = append(content.Hotspots, types.Hotspot{
+			ID: id, Shape: shape, Coords: coords, Correct: contains(correct, id),
+		})
+	}
+	return types.ItemTypeHotspot, content, nil
+}
+
+// readCoords reverses convertCoords: rectangles become x, y, width, height
+// and polygons lose their closing point
+func readCoords(shape, raw string) (string, []float64, error) {
+	var c []float64
+	for _, field := range strings.Split(raw, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid coordinates %q", raw)
+		}
+		c = append(c, v)
+	}
+
+	switch {
+	case shape == "rect" && len(c) == 4:
+		return "rectangle", []float64{c[0], c[1], c[2] - c[0], c[3] - c[1]}, nil
+	case shape == "circle" && len(c) == 3:
+		return "circle", c, nil
+	case shape == "poly" && len(c) >= 6 && len(c)%2 == 0:
+		if n := len(c); n >= 8 && c[0] == c[n-2] && c[1] == c[n-1] {
+			c = c[:n-2]
+		}
+		return "polygon", c, nil
+	}
+	return "", nil, fmt.Errorf("%s with %d coordinates is not supported", shape, len(c))
+}
+
+// asset resolves a reference in the item to a file of the package and
+// records it. Absolute URLs are kept as they are.
+func (r *itemReader) asset(ref string) (string, error) {
+	if ref == "" {
+		return "", errors.New("the item references media without a source")
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid media reference %q", ref)
+	}
+	if u.IsAbs() {
+		return ref, nil
+	}
+
+	name := u.Path
+	dir := r.dir
+	if strings.HasPrefix(name, fileBase+"/") {
+		name, dir = strings.TrimPrefix(name, fileBase+"/"), "web_resources"
+	}
+	name = path.Join(dir, name)
+	if strings.HasPrefix(name, "../") || path.IsAbs(name) {
+		return "", fmt.Errorf("media reference %q is outside the package", ref)
+	}
+	if _, ok := r.pkg.files[name]; !ok {
+		return "", fmt.Errorf("%s is missing from the package", name)
+	}
+	if !contains(r.assets, name) {
+		r.assets = append(r.assets, name)
+	}
+	return name, nil
+}
+
+// truncate shortens s to the longest title, counted in bytes as the item
+// service does
+func truncate(s string) string {
+	return truncateTo(s, maxTitleLength)
+}
+
+// truncateTo shortens s to at most n bytes, ending it with an ellipsis
+func truncateTo(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := n - len("…")
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return strings.TrimSpace(s[:cut]) + "…"
+}
+
+func contains(list []string, s string) bool {
+	return indexOf(list, s) >= 0
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
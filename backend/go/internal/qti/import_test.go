@@ -0,0 +1,493 @@
+package qti
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// zipDir packages a folder of testdata/import as an LMS would
+func zipDir(t *testing.T, name string) []byte {
+	t.Helper()
+	root := filepath.Join("testdata", "import", name)
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func readPackage(t *testing.T, data []byte) *Package {
+	t.Helper()
+	pkg, err := ReadPackage(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	return pkg
+}
+
+func TestReadPackage_Canvas(t *testing.T) {
+	// Act
+	pkg := readPackage(t, zipDir(t, "canvas"))
+
+	// Assert
+	require.Len(t, pkg.Items, 4)
+
+	quiz := pkg.Items[0]
+	assert.Equal(t, "g5e0c0a1", quiz.Source)
+	assert.Nil(t, quiz.Input)
+	assert.Contains(t, quiz.Reason, "QTI 1.2 is not supported")
+
+	choice := pkg.Items[1]
+	require.NotNil(t, choice.Input, choice.Reason)
+	assert.Equal(t, "g5e0c0a1/i6b1.xml", choice.Source)
+	assert.Equal(t, "Question 1", choice.Title)
+	assert.Equal(t, types.ItemTypeChoice, choice.Input.Type)
+	assert.Equal(t, "Which river is the longest in Europe?", choice.Input.Title, "entities and markup are reduced to text")
+	assert.Equal(t, intPtr(2), choice.Input.Points)
+	assert.Equal(t, types.ChoiceContent{Choices: []types.Choice{
+		{ID: "c1", Text: "Danube"}, {ID: "c2", Text: "Volga", Correct: true}, {ID: "c3", Text: "Rhine"},
+	}}, choice.Input.Content)
+	assert.Empty(t, choice.Assets)
+
+	hotspot := pkg.Items[2]
+	require.NotNil(t, hotspot.Input, hotspot.Reason)
+	assert.Equal(t, types.ItemTypeHotspot, hotspot.Input.Type)
+	assert.Equal(t, "Click on Australia.", hotspot.Input.Title)
+	assert.Nil(t, hotspot.Input.Points)
+	mapFile := "web_resources/Uploaded Media/world map.png"
+	assert.Equal(t, types.HotspotContent{
+		ImageURL: mapFile,
+		AltText:  strPtr("World map"),
+		Hotspots: []types.Hotspot{
+			{ID: "h1", Shape: "rectangle", Coords: []float64{300, 120, 60, 50}, Correct: true},
+			{ID: "h2", Shape: "polygon", Coords: []float64{10, 10, 90, 10, 50, 60}},
+		},
+	}, hotspot.Input.Content)
+	assert.Equal(t, []string{mapFile}, hotspot.Assets, "the file base refers to web_resources")
+
+	match := pkg.Items[3]
+	assert.Nil(t, match.Input)
+	assert.Equal(t, "Question 3", match.Title)
+	assert.Equal(t, "matchInteraction is not supported", match.Reason)
+}
+
+func TestReadPackage_Moodle(t *testing.T) {
+	// Act
+	pkg := readPackage(t, zipDir(t, "moodle"))
+
+	// Assert
+	require.Len(t, pkg.Items, 7)
+	for _, item := range pkg.Items {
+		require.NotNil(t, item.Input, "%s: %s", item.Source, item.Reason)
+	}
+
+	inline := pkg.Items[0].Input
+	assert.Equal(t, types.ItemTypeChoice, inline.Type)
+	assert.Equal(t, "The ____ is the powerhouse of the cell.", inline.Title)
+	assert.Equal(t, types.ChoiceContent{Choices: []types.Choice{
+		{ID: "ribo", Text: "ribosome"}, {ID: "mito", Text: "mitochondrion", Correct: true},
+	}}, inline.Content)
+
+	essay := pkg.Items[1].Input
+	assert.Equal(t, types.ItemTypeTextEntry, essay.Type)
+	assert.Equal(t, "Explain osmosis in your own words.", essay.Title)
+	assert.Equal(t, types.TextEntryContent{
+		Multiline: true, MaxLength: intPtr(2000), Placeholder: strPtr("Write your answer"),
+	}, essay.Content)
+
+	entry := pkg.Items[2].Input
+	assert.Equal(t, types.ItemTypeTextEntry, entry.Type)
+	assert.Equal(t, "DNA is stored in the ____.", entry.Title)
+	assert.Equal(t, intPtr(3), entry.Points, "the best mapped value")
+	assert.Equal(t, types.TextEntryContent{MaxLength: intPtr(20), CorrectAnswer: strPtr("nucleus")}, entry.Content)
+
+	ordering := pkg.Items[3].Input
+	assert.Equal(t, types.ItemTypeOrdering, ordering.Type)
+	assert.Equal(t, intPtr(4), ordering.Points)
+	assert.Equal(t, types.OrderingContent{Items: []types.OrderingItem{
+		{ID: "ana", Text: "Anaphase", CorrectOrder: 3},
+		{ID: "meta", Text: "Metaphase", CorrectOrder: 2},
+		{ID: "pro", Text: "Prophase", CorrectOrder: 1},
+		{ID: "telo", Text: "Telophase", CorrectOrder: 4},
+	}}, ordering.Content)
+
+	multi := pkg.Items[4].Input
+	assert.Equal(t, types.ItemTypeMultiChoice, multi.Type)
+	assert.Equal(t, intPtr(2), multi.Points, "the sum of the positive mapped values")
+	assert.Equal(t, types.ChoiceContent{Choices: []types.Choice{
+		{ID: "a", Text: "Chloroplast", Correct: true}, {ID: "b", Text: "Centriole"}, {ID: "c", Text: "Cell wall", Correct: true},
+	}}, multi.Content)
+
+	title := pkg.Items[5].Input
+	assert.Equal(t, types.ItemTypeTitle, title.Type)
+	assert.Equal(t, "Part 2 The next questions are about cell division.", title.Title)
+
+	media := pkg.Items[6]
+	assert.Equal(t, types.ItemTypeMedia, media.Input.Type)
+	assert.Equal(t, "Animal cell", media.Input.Title)
+	assert.Equal(t, types.MediaContent{
+		URL:       "images/cell.png",
+		MediaType: "image",
+		AltText:   strPtr("Diagram of an animal cell"),
+		Caption:   strPtr("Study the diagram before going on."),
+	}, media.Input.Content)
+	assert.Equal(t, []string{"images/cell.png"}, media.Assets)
+}
+
+func TestReadPackage_RoundTrip(t *testing.T) {
+	// Arrange
+	samples := sampleItems(t)
+	names := []string{"title", "media_image", "media_video", "choice", "multi_choice", "text_entry", "text_entry_multiline", "ordering", "hotspot"}
+	items := make([]*core.Item, len(names))
+	for i, name := range names {
+		items[i] = samples[name]
+	}
+	storage := &memStorage{files: map[string]string{
+		"projects/" + projectID + "/assets/saturn.png":  "saturn",
+		"projects/" + projectID + "/assets/launch.mp4":  "launch",
+		"projects/" + projectID + "/assets/jupiter.png": "jupiter",
+	}}
+	var buf bytes.Buffer
+	require.NoError(t, NewExporter(storage).Export(context.Background(), &buf, &core.Project{ID: projectID, Title: "Planets"}, items))
+
+	// Act
+	pkg := readPackage(t, buf.Bytes())
+
+	// Assert
+	require.Len(t, pkg.Items, len(items))
+	for i, item := range items {
+		imported := pkg.Items[i]
+		require.NotNil(t, imported.Input, "%s: %s", names[i], imported.Reason)
+		assert.Equal(t, item.Type, imported.Input.Type, names[i])
+		assert.Equal(t, item.Title, imported.Input.Title, names[i])
+		if item.Type != types.ItemTypeTitle && item.Type != types.ItemTypeMedia {
+			points := item.Points
+			if points == nil {
+				points = intPtr(1)
+			}
+			assert.Equal(t, points, imported.Input.Points, names[i])
+		}
+
+		// Exports number the choices, round coordinates to whole pixels
+		// and score unweighted items as 1, and files are referenced in the
+		// package
+		want := itemContent(t, item)
+		switch c := want.(type) {
+		case types.MediaContent:
+			c.URL = assetsDir + filepath.Base(c.URL)
+			c.ShowControls = c.MediaType != "image"
+			want = c
+		case types.ChoiceContent:
+			for j := range c.Choices {
+				c.Choices[j].ID = choiceIdentifier(j)
+			}
+		case types.OrderingContent:
+			for j := range c.Items {
+				c.Items[j].ID = choiceIdentifier(j)
+			}
+		case types.HotspotContent:
+			c.ImageURL = assetsDir + filepath.Base(c.ImageURL)
+			for j := range c.Hotspots {
+				c.Hotspots[j].ID = choiceIdentifier(j)
+				for k, v := range c.Hotspots[j].Coords {
+					c.Hotspots[j].Coords[k] = math.Round(v)
+				}
+			}
+			want = c
+		}
+		assert.Equal(t, want, imported.Input.Content, names[i])
+	}
+}
+
+// itemContent decodes an item's content as the type the importer builds
+func itemContent(t *testing.T, item *core.Item) interface{} {
+	t.Helper()
+	var content interface{}
+	switch item.Type {
+	case types.ItemTypeTitle:
+		return nil
+	case types.ItemTypeMedia:
+		var c types.MediaContent
+		require.NoError(t, decodeContent(item, &c))
+		content = c
+	case types.ItemTypeChoice, types.ItemTypeMultiChoice:
+		var c types.ChoiceContent
+		require.NoError(t, decodeContent(item, &c))
+		content = c
+	case types.ItemTypeTextEntry:
+		var c types.TextEntryContent
+		require.NoError(t, decodeContent(item, &c))
+		content = c
+	case types.ItemTypeOrdering:
+		var c types.OrderingContent
+		require.NoError(t, decodeContent(item, &c))
+		content = c
+	case types.ItemTypeHotspot:
+		var c types.HotspotContent
+		require.NoError(t, decodeContent(item, &c))
+		content = c
+	}
+	return content
+}
+
+func TestReadPackage_Invalid(t *testing.T) {
+	manyItems := map[string]string{}
+	var resources strings.Builder
+	for i := 0; i <= MaxImportItems; i++ {
+		fmt.Fprintf(&resources, `<resource identifier="i%d" type="imsqti_item_xmlv2p1" href="i%d.xml"/>`, i, i)
+	}
+	manyItems[manifestName] = `<manifest><resources>` + resources.String() + `</resources></manifest>`
+
+	tests := []struct {
+		name       string
+		data       []byte
+		wantReason string
+	}{
+		{"not a zip", []byte("hello"), "not a zip file"},
+		{"no manifest", zipFiles(t, map[string]string{"item.xml": "<assessmentItem/>"}), "imsmanifest.xml is missing"},
+		{"malformed manifest", zipFiles(t, map[string]string{manifestName: "<manifest>"}), "failed to parse imsmanifest.xml"},
+		{"no items", zipFiles(t, map[string]string{manifestName: `<manifest><resources><resource type="webcontent" href="a.png"/></resources></manifest>`}), "no assessment items"},
+		{"too many items", zipFiles(t, manyItems), "at most 500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := ReadPackage(bytes.NewReader(tt.data), int64(len(tt.data)))
+
+			// Assert
+			assert.True(t, errors.Is(err, core.ErrImportInvalidPackage), "got %v", err)
+			assert.Contains(t, err.Error(), tt.wantReason)
+		})
+	}
+}
+
+func TestReadPackage_SkippedItems(t *testing.T) {
+	item := func(body string) string {
+		return `<assessmentItem identifier="x" title="X"><itemBody>` + body + `</itemBody></assessmentItem>`
+	}
+
+	tests := []struct {
+		name       string
+		document   string
+		wantReason string
+	}{
+		{"two interactions", item(`<textEntryInteraction responseIdentifier="A"/><textEntryInteraction responseIdentifier="B"/>`), "the item has 2 interactions"},
+		{"missing file", item(`<img src="gone.png"/>`), "gone.png is missing from the package"},
+		{"outside the package", item(`<img src="../../etc/passwd"/>`), "outside the package"},
+		{"incomplete order", `<assessmentItem identifier="x"><responseDeclaration identifier="R"><correctResponse><value>a</value></correctResponse></responseDeclaration>` +
+			`<itemBody><orderInteraction responseIdentifier="R"><prompt>Order</prompt><simpleChoice identifier="a">A</simpleChoice><simpleChoice identifier="b">B</simpleChoice></orderInteraction></itemBody></assessmentItem>`,
+			"choice b is missing from the correct order"},
+		{"not an item", `<assessmentTest identifier="x"/>`, "is not an assessmentItem"},
+		{"malformed", `<assessmentItem>`, "failed to parse item.xml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			data := zipFiles(t, map[string]string{
+				manifestName: `<manifest><resources><resource type="imsqti_item_xmlv2p1" href="item.xml"/></resources></manifest>`,
+				"item.xml":   tt.document,
+			})
+
+			// Act
+			pkg := readPackage(t, data)
+
+			// Assert
+			require.Len(t, pkg.Items, 1)
+			assert.Nil(t, pkg.Items[0].Input)
+			assert.Contains(t, pkg.Items[0].Reason, tt.wantReason)
+		})
+	}
+}
+
+func TestReadCoords(t *testing.T) {
+	tests := []struct {
+		shape, coords string
+		wantShape     string
+		want          []float64
+	}{
+		{"rect", "10,20,60,40", "rectangle", []float64{10, 20, 50, 20}},
+		{"circle", "5, 6, 7", "circle", []float64{5, 6, 7}},
+		{"poly", "0,0,40,0,20,30,0,0", "polygon", []float64{0, 0, 40, 0, 20, 30}},
+		{"poly", "0,0,40,0,20,30", "polygon", []float64{0, 0, 40, 0, 20, 30}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shape+" "+tt.coords, func(t *testing.T) {
+			// Act
+			shape, coords, err := readCoords(tt.shape, tt.coords)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantShape, shape)
+			assert.Equal(t, tt.want, coords)
+		})
+	}
+
+	for _, bad := range [][2]string{{"rect", "1,2"}, {"ellipse", "1,2,3,4"}, {"circle", "a,b,c"}} {
+		_, _, err := readCoords(bad[0], bad[1])
+		assert.Error(t, err, "%s %s", bad[0], bad[1])
+	}
+}
+
+type fakeAssetStore struct {
+	uploads   []core.FileUpload
+	deleted   []string
+	uploadErr map[string]error
+}
+
+func (s *fakeAssetStore) UploadFile(ctx context.Context, projectID string, file core.FileUpload) (*core.StorageMetadata, error) {
+	if err := s.uploadErr[file.OriginalName]; err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadAll(file.Reader); err != nil {
+		return nil, err
+	}
+	s.uploads = append(s.uploads, file)
+	key := "projects/" + projectID + "/assets/" + file.OriginalName
+	return &core.StorageMetadata{Key: key, URL: "/files/" + key, UploadedAt: time.Now()}, nil
+}
+
+func (s *fakeAssetStore) DeleteFile(ctx context.Context, key string) error {
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func TestImporter_Import(t *testing.T) {
+	// Arrange
+	files := &fakeAssetStore{}
+	importer := NewImporter(files, "https://quiz.example.com/")
+
+	// Act
+	items, err := importer.Import(context.Background(), projectID, zipDir(t, "canvas"))
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+	require.Len(t, files.uploads, 1)
+	assert.Equal(t, "world map.png", files.uploads[0].OriginalName)
+	assert.Equal(t, "image/png", files.uploads[0].ContentType)
+
+	key := "projects/" + projectID + "/assets/world map.png"
+	hotspot := items[2]
+	assert.Equal(t, "https://quiz.example.com/files/"+key, hotspot.Input.Content.(types.HotspotContent).ImageURL)
+	assert.Equal(t, []string{key}, hotspot.Assets, "assets become storage keys")
+	assert.Empty(t, files.deleted)
+}
+
+func TestImporter_Import_SharedFileUploadedOnce(t *testing.T) {
+	// Arrange
+	media := `<assessmentItem identifier="x" title="Cell"><itemBody><img src="cell.png"/></itemBody></assessmentItem>`
+	data := zipFiles(t, map[string]string{
+		manifestName: `<manifest><resources><resource type="imsqti_item_xmlv2p1" href="a.xml"/><resource type="imsqti_item_xmlv2p1" href="b.xml"/></resources></manifest>`,
+		"a.xml":      media,
+		"b.xml":      media,
+		"cell.png":   "png",
+	})
+	files := &fakeAssetStore{}
+
+	// Act
+	items, err := NewImporter(files, "").Import(context.Background(), projectID, data)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, files.uploads, 1)
+	require.Len(t, items, 2)
+	assert.Equal(t, items[0].Input.Content, items[1].Input.Content)
+	assert.Equal(t, "/files/projects/"+projectID+"/assets/cell.png", items[0].Input.Content.(types.MediaContent).URL,
+		"without a base URL the storage URL is kept")
+}
+
+func TestImporter_Import_RefusedFile(t *testing.T) {
+	// Arrange
+	files := &fakeAssetStore{uploadErr: map[string]error{"cell.png": core.ErrInvalidFileType}}
+
+	// Act
+	items, err := NewImporter(files, "").Import(context.Background(), projectID, zipDir(t, "moodle"))
+
+	// Assert
+	require.NoError(t, err)
+	media := items[6]
+	assert.Nil(t, media.Input)
+	assert.Nil(t, media.Assets)
+	assert.Contains(t, media.Reason, "images/cell.png")
+	assert.NotNil(t, items[0].Input, "other items are imported")
+}
+
+func TestImporter_Import_UploadFailure(t *testing.T) {
+	// Arrange
+	data := zipFiles(t, map[string]string{
+		manifestName: `<manifest><resources><resource type="imsqti_item_xmlv2p1" href="a.xml"/><resource type="imsqti_item_xmlv2p1" href="b.xml"/></resources></manifest>`,
+		"a.xml":      `<assessmentItem title="A"><itemBody><img src="a.png"/></itemBody></assessmentItem>`,
+		"b.xml":      `<assessmentItem title="B"><itemBody><img src="b.png"/></itemBody></assessmentItem>`,
+		"a.png":      "a",
+		"b.png":      "b",
+	})
+	failure := errors.New("disk full")
+	files := &fakeAssetStore{uploadErr: map[string]error{"b.png": failure}}
+
+	// Act
+	items, err := NewImporter(files, "").Import(context.Background(), projectID, data)
+
+	// Assert
+	assert.True(t, errors.Is(err, failure), "got %v", err)
+	assert.Nil(t, items)
+	assert.Equal(t, []string{"projects/" + projectID + "/assets/a.png"}, files.deleted, "files uploaded so far are deleted")
+}
+
+func TestImporter_Import_WithoutStorage(t *testing.T) {
+	// Act
+	items, err := NewImporter(nil, "").Import(context.Background(), projectID, zipDir(t, "moodle"))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, items[6].Input)
+	assert.Contains(t, items[6].Reason, "file storage is not configured")
+	assert.NotNil(t, items[5].Input)
+}
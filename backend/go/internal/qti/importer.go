@@ -0,0 +1,151 @@
+package qti
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// AssetStore keeps the files of imported items, satisfied by
+// *core.StorageService
+type AssetStore interface {
+	UploadFile(ctx context.Context, projectID string, file core.FileUpload) (*core.StorageMetadata, error)
+	DeleteFile(ctx context.Context, key string) error
+}
+
+// Importer reads QTI packages into items, uploading the files they use
+type Importer struct {
+	files AssetStore
+	// baseURL makes the URLs of uploaded files absolute, for storage that
+	// serves them from a path of the API
+	baseURL string
+}
+
+// NewImporter creates an importer uploading files to files. Without it,
+// items using files of the package are skipped.
+func NewImporter(files AssetStore, baseURL string) *Importer {
+	return &Importer{files: files, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Import reads a package and uploads the files of the items it can import,
+// pointing their content at the uploaded copies. Items whose files are
+// refused, for their type or size, are skipped. core.ErrImportInvalidPackage
+// is returned for a package that can't be read; on any other error the
+// files uploaded so far are deleted.
+func (i *Importer) Import(ctx context.Context, projectID string, data []byte) ([]*core.ImportedItem, error) {
+	pkg, err := ReadPackage(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	// uploaded maps package paths to their uploaded copies
+	uploaded := make(map[string]*core.StorageMetadata)
+	var keys []string
+	for _, item := range pkg.Items {
+		if item.Input == nil || len(item.Assets) == 0 {
+			continue
+		}
+		if i.files == nil {
+			item.Skip("the item uses files, and file storage is not configured")
+			continue
+		}
+
+		urls := make(map[string]string, len(item.Assets))
+		var itemKeys []string
+		var refused error
+		for _, name := range item.Assets {
+			metadata, ok := uploaded[name]
+			if !ok {
+				metadata, err = i.upload(ctx, projectID, pkg, name)
+				if errors.Is(err, core.ErrInvalidFileType) || errors.Is(err, core.ErrFileTooBig) {
+					refused = fmt.Errorf("%s: %w", name, err)
+					break
+				}
+				if err != nil {
+					i.Discard(ctx, keys)
+					return nil, err
+				}
+				uploaded[name] = metadata
+				keys = append(keys, metadata.Key)
+			}
+			urls[name] = i.url(metadata)
+			itemKeys = append(itemKeys, metadata.Key)
+		}
+		if refused != nil {
+			item.Skip(refused.Error())
+			continue
+		}
+
+		item.Input.Content = withURLs(item.Input.Content, urls)
+		item.Assets = itemKeys
+	}
+
+	for _, item := range pkg.Items {
+		if item.Input == nil {
+			item.Assets = nil
+		}
+	}
+	return pkg.Items, nil
+}
+
+// Discard deletes uploaded files that ended up unused, logging failures
+func (i *Importer) Discard(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := i.files.DeleteFile(ctx, key); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("failed to delete imported file")
+		}
+	}
+}
+
+func (i *Importer) upload(ctx context.Context, projectID string, pkg *Package, name string) (*core.StorageMetadata, error) {
+	rc, size, err := pkg.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	metadata, err := i.files.UploadFile(ctx, projectID, core.FileUpload{
+		OriginalName: path.Base(name),
+		ContentType:  core.GetContentTypeFromFilename(name),
+		Size:         size,
+		Reader:       rc,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload %s: %w", name, err)
+	}
+	return metadata, nil
+}
+
+// url is where an uploaded file is served
+func (i *Importer) url(metadata *core.StorageMetadata) string {
+	if u, err := url.Parse(metadata.URL); err == nil && !u.IsAbs() && i.baseURL != "" {
+		return i.baseURL + "/" + strings.TrimPrefix(metadata.URL, "/")
+	}
+	return metadata.URL
+}
+
+// withURLs points content's media at the uploaded copies of package files
+func withURLs(content interface{}, urls map[string]string) interface{} {
+	switch c := content.(type) {
+	case types.MediaContent:
+		if u, ok := urls[c.URL]; ok {
+			c.URL = u
+		}
+		return c
+	case types.HotspotContent:
+		if u, ok := urls[c.ImageURL]; ok {
+			c.ImageURL = u
+		}
+		return c
+	}
+	return content
+}
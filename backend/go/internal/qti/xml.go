@@ -0,0 +1,293 @@
+package qti
+
+import "encoding/xml"
+
+// Namespaces and schema locations of QTI 2.1 items and their content
+// package
+const (
+	NamespaceItem     = "http://www.imsglobal.org/xsd/imsqti_v2p1"
+	NamespaceManifest = "http://www.imsglobal.org/xsd/imscp_v1p1"
+	namespaceXSI      = "http://www.w3.org/2001/XMLSchema-instance"
+
+	schemaLocationItem     = NamespaceItem + " http://www.imsglobal.org/xsd/qti/qtiv2p1/imsqti_v2p1.xsd"
+	schemaLocationManifest = NamespaceManifest + " http://www.imsglobal.org/xsd/qti/qtiv2p1/qtiv2p1_imscpv1p2_v1p0.xsd"
+
+	// ResourceTypeItem is the manifest resource type of an assessmentItem
+	ResourceTypeItem = "imsqti_item_xmlv2p1"
+
+	// templateMapResponse scores the response with its declaration's mapping
+	templateMapResponse = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/map_response"
+)
+
+// Identifiers of the variables every scored item declares
+const (
+	responseID = "RESPONSE"
+	scoreID    = "SCORE"
+	maxScoreID = "MAXSCORE"
+)
+
+// Cardinalities and base types of QTI variables
+const (
+	cardinalitySingle   = "single"
+	cardinalityMultiple = "multiple"
+	cardinalityOrdered  = "ordered"
+
+	baseTypeIdentifier = "identifier"
+	baseTypeString     = "string"
+	baseTypeFloat      = "float"
+)
+
+// AssessmentItem is a QTI 2.1 item document. Its children follow the order
+// the schema requires.
+type AssessmentItem struct {
+	XMLName        xml.Name `xml:"assessmentItem"`
+	Xmlns          string   `xml:"xmlns,attr"`
+	XmlnsXSI       string   `xml:"xmlns:xsi,attr"`
+	SchemaLocation string   `xml:"xsi:schemaLocation,attr"`
+	Identifier     string   `xml:"identifier,attr"`
+	Title          string   `xml:"title,attr"`
+	Adaptive       bool     `xml:"adaptive,attr"`
+	TimeDependent  bool     `xml:"timeDependent,attr"`
+
+	ResponseDeclarations []ResponseDeclaration `xml:"responseDeclaration"`
+	OutcomeDeclarations  []OutcomeDeclaration  `xml:"outcomeDeclaration"`
+	ItemBody             ItemBody              `xml:"itemBody"`
+	ResponseProcessing   *ResponseProcessing   `xml:"responseProcessing,omitempty"`
+}
+
+// ResponseDeclaration declares the candidate's response to an interaction
+type ResponseDeclaration struct {
+	Identifier      string           `xml:"identifier,attr"`
+	Cardinality     string           `xml:"cardinality,attr"`
+	BaseType        string           `xml:"baseType,attr"`
+	CorrectResponse *CorrectResponse `xml:"correctResponse,omitempty"`
+	Mapping         *Mapping         `xml:"mapping,omitempty"`
+}
+
+// CorrectResponse lists the correct values, in order for ordered responses
+type CorrectResponse struct {
+	Values []string `xml:"value"`
+}
+
+// Mapping scores a response by summing the values its keys map to, within
+// the bounds
+type Mapping struct {
+	LowerBound   *float64   `xml:"lowerBound,attr,omitempty"`
+	UpperBound   *float64   `xml:"upperBound,attr,omitempty"`
+	DefaultValue float64    `xml:"defaultValue,attr"`
+	Entries      []MapEntry `xml:"mapEntry"`
+}
+
+// MapEntry is the score of one response value
+type MapEntry struct {
+	MapKey        string  `xml:"mapKey,attr"`
+	MappedValue   float64 `xml:"mappedValue,attr"`
+	CaseSensitive bool    `xml:"caseSensitive,attr"`
+}
+
+// OutcomeDeclaration declares an outcome of the item, such as its score
+type OutcomeDeclaration struct {
+	Identifier   string        `xml:"identifier,attr"`
+	Cardinality  string        `xml:"cardinality,attr"`
+	BaseType     string        `xml:"baseType,attr"`
+	DefaultValue *DefaultValue `xml:"defaultValue,omitempty"`
+}
+
+// DefaultValue is an outcome's value before response processing
+type DefaultValue struct {
+	Value string `xml:"value"`
+}
+
+// ResponseProcessing sets the outcomes from the responses, with a standard
+// template or with the item's own rules
+type ResponseProcessing struct {
+	Template   string              `xml:"template,attr,omitempty"`
+	Conditions []ResponseCondition `xml:"responseCondition"`
+}
+
+// ResponseCondition sets the score to If's when the response matches the
+// correct response, and to Else's otherwise
+type ResponseCondition struct {
+	If   ResponseIf   `xml:"responseIf"`
+	Else ResponseElse `xml:"responseElse"`
+}
+
+// ResponseIf is the branch taken on a correct response
+type ResponseIf struct {
+	Match           Match           `xml:"match"`
+	SetOutcomeValue SetOutcomeValue `xml:"setOutcomeValue"`
+}
+
+// ResponseElse is the branch taken otherwise
+type ResponseElse struct {
+	SetOutcomeValue SetOutcomeValue `xml:"setOutcomeValue"`
+}
+
+// Match compares the response variable with its correct value
+type Match struct {
+	Variable VariableRef `xml:"variable"`
+	Correct  VariableRef `xml:"correct"`
+}
+
+// VariableRef names a declared variable
+type VariableRef struct {
+	Identifier string `xml:"identifier,attr"`
+}
+
+// SetOutcomeValue assigns a constant to an outcome
+type SetOutcomeValue struct {
+	Identifier string    `xml:"identifier,attr"`
+	BaseValue  BaseValue `xml:"baseValue"`
+}
+
+// BaseValue is a typed constant
+type BaseValue struct {
+	BaseType string `xml:"baseType,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// ItemBody holds the item's content and interactions, as blocks in display
+// order
+type ItemBody struct {
+	Blocks []interface{} `xml:",any"`
+}
+
+// RubricBlock is content shown to a view, such as instructions for the
+// candidate
+type RubricBlock struct {
+	XMLName xml.Name      `xml:"rubricBlock"`
+	View    string        `xml:"view,attr"`
+	Blocks  []interface{} `xml:",any"`
+}
+
+// Div groups blocks
+type Div struct {
+	XMLName xml.Name      `xml:"div"`
+	Blocks  []interface{} `xml:",any"`
+}
+
+// Heading is a level 2 heading
+type Heading struct {
+	XMLName xml.Name `xml:"h2"`
+	Text    string   `xml:",chardata"`
+}
+
+// Paragraph is text followed by inline elements, such as a text entry
+type Paragraph struct {
+	XMLName xml.Name      `xml:"p"`
+	Text    string        `xml:",chardata"`
+	Inline  []interface{} `xml:",any"`
+}
+
+// Img is an image
+type Img struct {
+	XMLName xml.Name `xml:"img"`
+	Src     string   `xml:"src,attr"`
+	Alt     string   `xml:"alt,attr"`
+}
+
+// Object embeds media; its text is shown where the media can't be
+type Object struct {
+	XMLName xml.Name `xml:"object"`
+	Data    string   `xml:"data,attr"`
+	Type    string   `xml:"type,attr"`
+	Text    string   `xml:",chardata"`
+}
+
+// Prompt is the question an interaction asks
+type Prompt struct {
+	Text string `xml:",chardata"`
+}
+
+// ChoiceInteraction picks up to MaxChoices of its choices; 0 means any
+// number
+type ChoiceInteraction struct {
+	XMLName            xml.Name       `xml:"choiceInteraction"`
+	ResponseIdentifier string         `xml:"responseIdentifier,attr"`
+	Shuffle            bool           `xml:"shuffle,attr"`
+	MaxChoices         int            `xml:"maxChoices,attr"`
+	Prompt             *Prompt        `xml:"prompt,omitempty"`
+	Choices            []SimpleChoice `xml:"simpleChoice"`
+}
+
+// SimpleChoice is a choice of a choice or order interaction
+type SimpleChoice struct {
+	Identifier string `xml:"identifier,attr"`
+	Text       string `xml:",chardata"`
+}
+
+// TextEntryInteraction is an inline, single-line text response
+type TextEntryInteraction struct {
+	XMLName            xml.Name `xml:"textEntryInteraction"`
+	ResponseIdentifier string   `xml:"responseIdentifier,attr"`
+	ExpectedLength     int      `xml:"expectedLength,attr,omitempty"`
+	PlaceholderText    string   `xml:"placeholderText,attr,omitempty"`
+}
+
+// ExtendedTextInteraction is a multi-line text response
+type ExtendedTextInteraction struct {
+	XMLName            xml.Name `xml:"extendedTextInteraction"`
+	ResponseIdentifier string   `xml:"responseIdentifier,attr"`
+	ExpectedLength     int      `xml:"expectedLength,attr,omitempty"`
+	PlaceholderText    string   `xml:"placeholderText,attr,omitempty"`
+	Prompt             *Prompt  `xml:"prompt,omitempty"`
+}
+
+// OrderInteraction puts its choices in order
+type OrderInteraction struct {
+	XMLName            xml.Name       `xml:"orderInteraction"`
+	ResponseIdentifier string         `xml:"responseIdentifier,attr"`
+	Shuffle            bool           `xml:"shuffle,attr"`
+	Prompt             *Prompt        `xml:"prompt,omitempty"`
+	Choices            []SimpleChoice `xml:"simpleChoice"`
+}
+
+// HotspotInteraction selects areas of an image
+type HotspotInteraction struct {
+	XMLName            xml.Name        `xml:"hotspotInteraction"`
+	ResponseIdentifier string          `xml:"responseIdentifier,attr"`
+	MaxChoices         int             `xml:"maxChoices,attr"`
+	Prompt             *Prompt         `xml:"prompt,omitempty"`
+	Object             Object          `xml:"object"`
+	Choices            []HotspotChoice `xml:"hotspotChoice"`
+}
+
+// HotspotChoice is an area of the image, in pixels: rect is left, top,
+// right, bottom; circle is center x, center y, radius; poly is the points
+// of a closed path
+type HotspotChoice struct {
+	Identifier string `xml:"identifier,attr"`
+	Shape      string `xml:"shape,attr"`
+	Coords     string `xml:"coords,attr"`
+}
+
+// Manifest is the IMS content package manifest, imsmanifest.xml
+type Manifest struct {
+	XMLName        xml.Name         `xml:"manifest"`
+	Xmlns          string           `xml:"xmlns,attr"`
+	XmlnsXSI       string           `xml:"xmlns:xsi,attr"`
+	SchemaLocation string           `xml:"xsi:schemaLocation,attr"`
+	Identifier     string           `xml:"identifier,attr"`
+	Metadata       ManifestMetadata `xml:"metadata"`
+	Organizations  struct{}         `xml:"organizations"`
+	Resources      []Resource       `xml:"resources>resource"`
+}
+
+// ManifestMetadata names the package's schema
+type ManifestMetadata struct {
+	Schema        string `xml:"schema"`
+	SchemaVersion string `xml:"schemaversion"`
+}
+
+// Resource is a file of the package with the files it depends on
+type Resource struct {
+	Identifier string         `xml:"identifier,attr"`
+	Type       string         `xml:"type,attr"`
+	Href       string         `xml:"href,attr"`
+	Files      []ResourceFile `xml:"file"`
+}
+
+// ResourceFile is a path in the package
+type ResourceFile struct {
+	Href string `xml:"href,attr"`
+}
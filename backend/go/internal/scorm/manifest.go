@@ -0,0 +1,67 @@
+package scorm
+
+import "encoding/xml"
+
+// Namespaces of the manifest, by version
+const (
+	NamespaceManifest12   = "http://www.imsproject.org/xsd/imscp_rootv1p1p2"
+	NamespaceADLCP12      = "http://www.adlnet.org/xsd/adlcp_rootv1p2"
+	NamespaceManifest2004 = "http://www.imsglobal.org/xsd/imscp_v1p1"
+	NamespaceADLCP2004    = "http://www.adlnet.org/xsd/adlcp_v1p3"
+)
+
+// Manifest is a package's imsmanifest.xml. Its children follow the order
+// the schema requires.
+type Manifest struct {
+	XMLName    xml.Name `xml:"manifest"`
+	Xmlns      string   `xml:"xmlns,attr"`
+	XmlnsADLCP string   `xml:"xmlns:adlcp,attr"`
+	Identifier string   `xml:"identifier,attr"`
+
+	Metadata      Metadata      `xml:"metadata"`
+	Organizations Organizations `xml:"organizations"`
+	Resources     []Resource    `xml:"resources>resource"`
+}
+
+// Metadata names the SCORM version the package conforms to
+type Metadata struct {
+	Schema        string `xml:"schema"`
+	SchemaVersion string `xml:"schemaversion"`
+}
+
+// Organizations lists the package's course structures
+type Organizations struct {
+	Default       string         `xml:"default,attr"`
+	Organizations []Organization `xml:"organization"`
+}
+
+// Organization is a course structure, whose items launch resources
+type Organization struct {
+	Identifier string `xml:"identifier,attr"`
+	Title      string `xml:"title"`
+	Items      []Item `xml:"item"`
+}
+
+// Item is an activity of an organization
+type Item struct {
+	Identifier    string `xml:"identifier,attr"`
+	IdentifierRef string `xml:"identifierref,attr"`
+	IsVisible     bool   `xml:"isvisible,attr"`
+	Title         string `xml:"title"`
+}
+
+// Resource is a launchable SCO and the files it is made of. SCORM 1.2 and
+// 2004 spell the attribute naming its kind differently.
+type Resource struct {
+	Identifier    string         `xml:"identifier,attr"`
+	Type          string         `xml:"type,attr"`
+	ScormType12   string         `xml:"adlcp:scormtype,attr,omitempty"`
+	ScormType2004 string         `xml:"adlcp:scormType,attr,omitempty"`
+	Href          string         `xml:"href,attr"`
+	Files         []ResourceFile `xml:"file"`
+}
+
+// ResourceFile is a file of the package a resource uses
+type ResourceFile struct {
+	Href string `xml:"href,attr"`
+}
@@ -0,0 +1,406 @@
+/*
+ * Quiz runtime of SCORM packages exported by ProveMySelf. It renders the
+ * quiz embedded in index.html, grades it in the browser and reports the
+ * score to the LMS through the SCORM 1.2 or 2004 API. Written in ES5 for
+ * the browsers older LMSs still run in.
+ */
+(function () {
+  'use strict';
+
+  // The SCORM API's object and calls, by version
+  var apis = {
+    '1.2': {
+      name: 'API',
+      initialize: 'LMSInitialize',
+      getValue: 'LMSGetValue',
+      setValue: 'LMSSetValue',
+      commit: 'LMSCommit',
+      finish: 'LMSFinish'
+    },
+    '2004': {
+      name: 'API_1484_11',
+      initialize: 'Initialize',
+      getValue: 'GetValue',
+      setValue: 'SetValue',
+      commit: 'Commit',
+      finish: 'Terminate'
+    }
+  };
+
+  // findAPI looks for the LMS's API object in the frames around the page
+  // and in the window that opened it, as the SCORM specs describe
+  function findAPI(win, name) {
+    for (var depth = 0; win && depth < 10; depth++) {
+      try {
+        if (win[name]) {
+          return win[name];
+        }
+      } catch (e) {
+        // A frame of another origin
+      }
+      if (win.parent === win) {
+        break;
+      }
+      win = win.parent;
+    }
+    return null;
+  }
+
+  // LMS talks to the LMS; without one, the quiz still runs and calls do
+  // nothing
+  function LMS(version) {
+    var calls = apis[version] || apis['1.2'];
+    var api = findAPI(window, calls.name);
+    if (!api && window.opener) {
+      api = findAPI(window.opener, calls.name);
+    }
+    this.version = version;
+    this.calls = calls;
+    this.api = api;
+    this.connected = false;
+    this.finished = false;
+  }
+
+  LMS.prototype.call = function (name, a, b) {
+    if (!this.api) {
+      return '';
+    }
+    try {
+      return String(this.api[this.calls[name]](a, b));
+    } catch (e) {
+      return '';
+    }
+  };
+
+  LMS.prototype.start = function () {
+    this.connected = this.call('initialize', '') === 'true';
+    if (!this.connected) {
+      return;
+    }
+    if (this.version === '2004') {
+      var completion = this.call('getValue', 'cmi.completion_status');
+      if (completion === 'not attempted' || completion === 'unknown') {
+        this.call('setValue', 'cmi.completion_status', 'incomplete');
+      }
+    } else if (this.call('getValue', 'cmi.core.lesson_status') === 'not attempted') {
+      this.call('setValue', 'cmi.core.lesson_status', 'incomplete');
+    }
+    this.call('commit', '');
+  };
+
+  // report records the result. Without scored items the quiz is only
+  // completed; otherwise it is passed or failed when the LMS sets a mastery
+  // score, and completed when it doesn't.
+  LMS.prototype.report = function (earned, max) {
+    if (!this.connected) {
+      return;
+    }
+    if (this.version === '2004') {
+      var success = 'unknown';
+      if (max > 0) {
+        var scaled = earned / max;
+        this.call('setValue', 'cmi.score.raw', String(earned));
+        this.call('setValue', 'cmi.score.min', '0');
+        this.call('setValue', 'cmi.score.max', String(max));
+        this.call('setValue', 'cmi.score.scaled', String(Math.round(scaled * 10000) / 10000));
+        var passing = parseFloat(this.call('getValue', 'cmi.scaled_passing_score'));
+        if (!isNaN(passing)) {
+          success = scaled >= passing ? 'passed' : 'failed';
+        }
+      }
+      this.call('setValue', 'cmi.completion_status', 'completed');
+      this.call('setValue', 'cmi.success_status', success);
+      this.call('setValue', 'cmi.exit', 'normal');
+    } else {
+      var status = 'completed';
+      if (max > 0) {
+        this.call('setValue', 'cmi.core.score.raw', String(earned));
+        this.call('setValue', 'cmi.core.score.min', '0');
+        this.call('setValue', 'cmi.core.score.max', String(max));
+        var mastery = parseFloat(this.call('getValue', 'cmi.student_data.mastery_score'));
+        if (!isNaN(mastery)) {
+          status = earned / max * 100 >= mastery ? 'passed' : 'failed';
+        }
+      }
+      this.call('setValue', 'cmi.core.lesson_status', status);
+    }
+    this.call('commit', '');
+    this.finish();
+  };
+
+  LMS.prototype.finish = function () {
+    if (this.connected && !this.finished) {
+      this.finished = true;
+      this.call('finish', '');
+    }
+  };
+
+  // sha256 returns the hex SHA-256 of s, UTF-8 encoded. Browsers only
+  // offer it asynchronously and on HTTPS pages, which not every LMS
+  // serves.
+  var K = [
+    0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+    0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+    0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+    0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+    0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+    0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+    0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+    0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2
+  ];
+
+  function rotr(x, n) {
+    return (x >>> n) | (x << (32 - n));
+  }
+
+  function sha256(s) {
+    var bytes = unescape(encodeURIComponent(s));
+    var length = bytes.length;
+    var words = [];
+    var i;
+    for (i = 0; i < length; i++) {
+      words[i >> 2] |= bytes.charCodeAt(i) << (24 - (i % 4) * 8);
+    }
+    words[length >> 2] |= 0x80 << (24 - (length % 4) * 8);
+    words[(((length + 8) >> 6) << 4) + 15] = length * 8;
+
+    var h = [0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a, 0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19];
+    var w = [];
+    for (var block = 0; block < words.length; block += 16) {
+      var a = h[0], b = h[1], c = h[2], d = h[3], e = h[4], f = h[5], g = h[6], k = h[7];
+      for (i = 0; i < 64; i++) {
+        if (i < 16) {
+          w[i] = words[block + i] | 0;
+        } else {
+          var s0 = rotr(w[i - 15], 7) ^ rotr(w[i - 15], 18) ^ (w[i - 15] >>> 3);
+          var s1 = rotr(w[i - 2], 17) ^ rotr(w[i - 2], 19) ^ (w[i - 2] >>> 10);
+          w[i] = (w[i - 16] + s0 + w[i - 7] + s1) | 0;
+        }
+        var t1 = (k + (rotr(e, 6) ^ rotr(e, 11) ^ rotr(e, 25)) + ((e & f) ^ (~e & g)) + K[i] + w[i]) | 0;
+        var t2 = ((rotr(a, 2) ^ rotr(a, 13) ^ rotr(a, 22)) + ((a & b) ^ (a & c) ^ (b & c))) | 0;
+        k = g;
+        g = f;
+        f = e;
+        e = (d + t1) | 0;
+        d = c;
+        c = b;
+        b = a;
+        a = (t1 + t2) | 0;
+      }
+      h[0] = (h[0] + a) | 0;
+      h[1] = (h[1] + b) | 0;
+      h[2] = (h[2] + c) | 0;
+      h[3] = (h[3] + d) | 0;
+      h[4] = (h[4] + e) | 0;
+      h[5] = (h[5] + f) | 0;
+      h[6] = (h[6] + g) | 0;
+      h[7] = (h[7] + k) | 0;
+    }
+
+    var hex = '';
+    for (i = 0; i < h.length; i++) {
+      hex += ('00000000' + (h[i] >>> 0).toString(16)).slice(-8);
+    }
+    return hex;
+  }
+
+  // responses read the learner's response to a question in the form its
+  // answer was hashed in, or '' for none: the chosen IDs, sorted, one per
+  // line, or the text, trimmed. Choices are graded as a set and text
+  // exactly.
+  var responses = {
+    choice: function (field) {
+      var ids = [];
+      var inputs = field.getElementsByTagName('input');
+      for (var i = 0; i < inputs.length; i++) {
+        if (inputs[i].checked) {
+          ids.push(inputs[i].value);
+        }
+      }
+      ids.sort();
+      return ids.join('\n');
+    },
+    text_entry: function (field) {
+      var input = field.getElementsByTagName('textarea')[0] || field.getElementsByTagName('input')[0];
+      return input.value.replace(/^\s+|\s+$/g, '');
+    }
+  };
+  responses.multi_choice = responses.choice;
+
+  function element(tag, attrs, text) {
+    var el = document.createElement(tag);
+    for (var name in attrs) {
+      if (attrs.hasOwnProperty(name)) {
+        el.setAttribute(name, attrs[name]);
+      }
+    }
+    if (text) {
+      el.appendChild(document.createTextNode(text));
+    }
+    return el;
+  }
+
+  function heading(tag, item) {
+    return element(tag, item.required ? { 'class': 'required' } : {}, item.title);
+  }
+
+  function renderMedia(item) {
+    var content = item.content;
+    var section = element('section', { 'class': 'item' });
+    section.appendChild(heading('h2', item));
+    var figure = element('figure');
+    var media;
+    if (content.media_type === 'image') {
+      media = element('img', { src: content.url, alt: content.alt_text || '' });
+    } else {
+      media = element(content.media_type, { src: content.url });
+      if (content.show_controls) {
+        media.setAttribute('controls', '');
+      }
+      if (content.autoplay) {
+        media.setAttribute('autoplay', '');
+      }
+      if (content.alt_text) {
+        media.setAttribute('aria-label', content.alt_text);
+      }
+    }
+    figure.appendChild(media);
+    if (content.caption) {
+      figure.appendChild(element('figcaption', {}, content.caption));
+    }
+    section.appendChild(figure);
+    return section;
+  }
+
+  function renderQuestion(item, index) {
+    var field = element('fieldset', { 'class': 'item' });
+    field.appendChild(heading('legend', item));
+    var name = 'item-' + index;
+    if (item.type === 'text_entry') {
+      var content = item.content;
+      var input = element(content.multiline ? 'textarea' : 'input', { name: name, 'aria-label': item.title });
+      if (!content.multiline) {
+        input.setAttribute('type', 'text');
+      }
+      if (content.max_length) {
+        input.setAttribute('maxlength', String(content.max_length));
+      }
+      if (content.placeholder) {
+        input.setAttribute('placeholder', content.placeholder);
+      }
+      field.appendChild(input);
+      return field;
+    }
+
+    var type = item.type === 'choice' ? 'radio' : 'checkbox';
+    var choices = item.content.choices || [];
+    for (var i = 0; i < choices.length; i++) {
+      var label = element('label', { 'class': 'choice' });
+      label.appendChild(element('input', { type: type, name: name, value: choices[i].id }));
+      label.appendChild(document.createTextNode(' ' + choices[i].text));
+      field.appendChild(label);
+    }
+    return field;
+  }
+
+  function render(quiz, root) {
+    root.innerHTML = '';
+    root.appendChild(element('h1', {}, quiz.title));
+    if (quiz.description) {
+      root.appendChild(element('p', {}, quiz.description));
+    }
+
+    var form = element('form', { novalidate: '' });
+    var questions = [];
+    for (var i = 0; i < quiz.items.length; i++) {
+      var item = quiz.items[i];
+      if (item.type === 'title') {
+        form.appendChild(element('h2', {}, item.title));
+      } else if (item.type === 'media') {
+        form.appendChild(renderMedia(item));
+      } else if (responses[item.type]) {
+        var field = renderQuestion(item, i);
+        form.appendChild(field);
+        questions.push({ item: item, field: field });
+      }
+    }
+    form.appendChild(element('button', { type: 'submit' }, 'Submit'));
+    var message = element('p', { 'class': 'message', role: 'status' });
+    form.appendChild(message);
+    root.appendChild(form);
+    return { form: form, questions: questions, message: message };
+  }
+
+  function grade(questions) {
+    var earned = 0;
+    var max = 0;
+    for (var i = 0; i < questions.length; i++) {
+      var q = questions[i];
+      if (!q.item.answer) {
+        continue;
+      }
+      max += q.item.points;
+      var correct = sha256(q.item.id + '\n' + q.response) === q.item.answer;
+      if (correct) {
+        earned += q.item.points;
+      }
+      q.field.className += correct ? ' correct' : ' incorrect';
+      q.field.appendChild(element('p', { 'class': 'verdict' }, correct ? 'Correct' : 'Incorrect'));
+    }
+    return { earned: earned, max: max };
+  }
+
+  function start() {
+    var quiz = JSON.parse(document.getElementById('snapshot').textContent);
+    var lms = new LMS(quiz.version);
+    lms.start();
+
+    var view = render(quiz, document.getElementById('quiz'));
+    if (!lms.connected) {
+      view.message.appendChild(document.createTextNode('Not connected to an LMS: your result will not be recorded.'));
+    }
+
+    view.form.onsubmit = function (event) {
+      event.preventDefault();
+      var missing = null;
+      for (var i = 0; i < view.questions.length; i++) {
+        var q = view.questions[i];
+        q.response = responses[q.item.type](q.field);
+        if (q.item.required && q.response === '' && !missing) {
+          missing = q;
+        }
+      }
+      if (missing) {
+        view.message.className = 'message error';
+        view.message.innerHTML = '';
+        view.message.appendChild(document.createTextNode('Answer every question marked with * first.'));
+        var input = missing.field.getElementsByTagName('input')[0] || missing.field.getElementsByTagName('textarea')[0];
+        input.focus();
+        return;
+      }
+
+      var fields = view.form.elements;
+      for (i = 0; i < fields.length; i++) {
+        fields[i].disabled = true;
+      }
+      var score = grade(view.questions);
+      lms.report(score.earned, score.max);
+
+      view.message.className = 'message';
+      view.message.innerHTML = '';
+      var result = score.max > 0 ? 'Your score: ' + score.earned + ' of ' + score.max + '.' : 'Your answers are submitted.';
+      if (!lms.connected) {
+        result += ' Not connected to an LMS: your result is not recorded.';
+      }
+      view.message.appendChild(document.createTextNode(result));
+    };
+
+    // Closing the page before submitting leaves the attempt incomplete
+    window.onbeforeunload = function () {
+      lms.finish();
+    };
+    window.onunload = window.onbeforeunload;
+  }
+
+  start();
+}());
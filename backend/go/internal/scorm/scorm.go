@@ -0,0 +1,232 @@
+// Package scorm exports published quizzes as SCORM 1.2 and 2004 packages
+// for LMSs without LTI: a single SCO whose page delivers the quiz, grades
+// it in the browser and reports the score through the SCORM API.
+package scorm
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// Version is a SCORM version packages are written for
+type Version string
+
+const (
+	Version12   Version = "1.2"
+	Version2004 Version = "2004"
+)
+
+const (
+	// manifestName is the manifest's path in the package
+	manifestName = "imsmanifest.xml"
+	// launchPage is the SCO's page, which embeds the quiz
+	launchPage = "index.html"
+	// assetsDir is where the package keeps the project's assets
+	assetsDir = "assets/"
+)
+
+//go:embed runtime
+var runtimeFiles embed.FS
+
+// runtimeAssets are the runtime's files copied into every package as they
+// are
+var runtimeAssets = []string{"runtime.js", "style.css"}
+
+var pageTemplate = template.Must(template.ParseFS(runtimeFiles, "runtime/"+launchPage))
+
+// Exporter writes published projects as SCORM zip packages
+type Exporter struct {
+	storage core.Storage
+	version Version
+}
+
+// NewExporter creates an exporter of version packages, bundling the assets
+// items reference from storage. Without storage, asset URLs are kept as
+// they are.
+func NewExporter(storage core.Storage, version Version) *Exporter {
+	return &Exporter{storage: storage, version: version}
+}
+
+// Export writes the project as a zip to w. The quiz is converted and assets
+// checked before anything is written, so an error returned before the zip
+// starts leaves w untouched: core.ErrProjectNotPublished for a draft,
+// core.ErrExportUnsupportedItem for an item type the runtime can't deliver,
+// core.ErrItemInvalidContent for content that can't be read and
+// core.ErrFileNotFound for a missing asset.
+func (e *Exporter) Export(ctx context.Context, w io.Writer, project *core.Project, items []*core.Item) error {
+	if project.PublishedAt == nil {
+		return core.ErrProjectNotPublished
+	}
+
+	// keys maps package paths to storage keys
+	keys := make(map[string]string)
+	prefix := fmt.Sprintf("projects/%s/assets/", project.ID)
+	hrefs := func(raw string) string {
+		key, ok := core.ProjectAssetKey(raw, project.ID)
+		if !ok || e.storage == nil {
+			return raw
+		}
+		name := assetsDir + strings.TrimPrefix(key, prefix)
+		keys[name] = key
+		return name
+	}
+
+	snapshot := Snapshot{Version: string(e.version), Title: project.Title, Items: []SnapshotItem{}}
+	if project.Description != nil {
+		snapshot.Description = *project.Description
+	}
+	for _, item := range items {
+		s, err := snapshotItem(item, hrefs)
+		if err != nil {
+			return err
+		}
+		snapshot.Items = append(snapshot.Items, s)
+	}
+
+	assets := make([]string, 0, len(keys))
+	for name, key := range keys {
+		exists, err := e.storage.Exists(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to check asset %s: %w", key, err)
+		}
+		if !exists {
+			return fmt.Errorf("%w: %s", core.ErrFileNotFound, key)
+		}
+		assets = append(assets, name)
+	}
+	sort.Strings(assets)
+
+	page, err := renderPage(snapshot)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	header := func(name string) *zip.FileHeader {
+		return &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: project.UpdatedAt}
+	}
+
+	if err := writeXML(zw, header(manifestName), e.buildManifest(project, assets)); err != nil {
+		return err
+	}
+	if err := writeFile(zw, header(launchPage), bytes.NewReader(page)); err != nil {
+		return err
+	}
+	for _, name := range runtimeAssets {
+		f, err := runtimeFiles.Open("runtime/" + name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		err = writeFile(zw, header(name), f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range assets {
+		if err := e.copyAsset(ctx, zw, header(name), keys[name]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// renderPage embeds the snapshot in the launch page. The JSON escapes <, >
+// and &, so quiz text can't end the script element holding it.
+func renderPage(snapshot Snapshot) ([]byte, error) {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the quiz: %w", err)
+	}
+	var buf bytes.Buffer
+	err = pageTemplate.Execute(&buf, struct {
+		Title    string
+		Snapshot template.JS
+	}{snapshot.Title, template.JS(data)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", launchPage, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// buildManifest describes the package as one organization launching one
+// SCO, which is made of the page, the runtime and the assets
+func (e *Exporter) buildManifest(project *core.Project, assets []string) *Manifest {
+	manifest := &Manifest{
+		Identifier: "project-" + project.ID,
+		Metadata:   Metadata{Schema: "ADL SCORM"},
+		Organizations: Organizations{
+			Default: "quiz",
+			Organizations: []Organization{{
+				Identifier: "quiz",
+				Title:      project.Title,
+				Items:      []Item{{Identifier: "quiz-item", IdentifierRef: "quiz-sco", IsVisible: true, Title: project.Title}},
+			}},
+		},
+	}
+	resource := Resource{Identifier: "quiz-sco", Type: "webcontent", Href: launchPage}
+	switch e.version {
+	case Version2004:
+		manifest.Xmlns, manifest.XmlnsADLCP = NamespaceManifest2004, NamespaceADLCP2004
+		manifest.Metadata.SchemaVersion = "2004 4th Edition"
+		resource.ScormType2004 = "sco"
+	default:
+		manifest.Xmlns, manifest.XmlnsADLCP = NamespaceManifest12, NamespaceADLCP12
+		manifest.Metadata.SchemaVersion = "1.2"
+		resource.ScormType12 = "sco"
+	}
+
+	for _, name := range append([]string{launchPage}, append(runtimeAssets, assets...)...) {
+		resource.Files = append(resource.Files, ResourceFile{Href: name})
+	}
+	manifest.Resources = []Resource{resource}
+	return manifest
+}
+
+func writeXML(zw *zip.Writer, header *zip.FileHeader, v interface{}) error {
+	f, err := zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", header.Name, err)
+	}
+	if _, err := io.WriteString(f, xml.Header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", header.Name, err)
+	}
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write %s: %w", header.Name, err)
+	}
+	_, err = io.WriteString(f, "\n")
+	return err
+}
+
+func writeFile(zw *zip.Writer, header *zip.FileHeader, r io.Reader) error {
+	f, err := zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", header.Name, err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("failed to write %s: %w", header.Name, err)
+	}
+	return nil
+}
+
+func (e *Exporter) copyAsset(ctx context.Context, zw *zip.Writer, header *zip.FileHeader, key string) error {
+	body, _, err := e.storage.Download(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to read asset %s: %w", key, err)
+	}
+	defer body.Close()
+	return writeFile(zw, header, body)
+}
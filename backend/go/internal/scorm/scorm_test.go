@@ -0,0 +1,273 @@
+package scorm
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+const projectID = "5f0c6a4e-2d1b-4c8e-9a7f-3b6d8e1f2a90"
+
+func strPtr(s string) *string { return &s }
+func intPtr(i int) *int       { return &i }
+
+func newItem(t *testing.T, id string, itemType types.ItemType, title string, content interface{}) *core.Item {
+	t.Helper()
+	data, err := json.Marshal(content)
+	require.NoError(t, err)
+	return &core.Item{ID: id, ProjectID: projectID, Type: itemType, Title: title, Content: data}
+}
+
+func publishedProject() *core.Project {
+	published := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &core.Project{
+		ID:          projectID,
+		Title:       "Planets <Quiz>",
+		Description: strPtr("Ten minutes & no notes"),
+		UpdatedAt:   published,
+		PublishedAt: &published,
+	}
+}
+
+// sampleItems has an item of every type the runtime delivers, graded and
+// not
+func sampleItems(t *testing.T) []*core.Item {
+	assetURL := "https://cdn.example.com/files/projects/" + projectID + "/assets/"
+
+	choice := newItem(t, "c1", types.ItemTypeChoice, "Which planet is largest?", types.ChoiceContent{Choices: []types.Choice{
+		{ID: "a", Text: "Mars"}, {ID: "b", Text: "Jupiter", Correct: true}, {ID: "c", Text: "Venus"},
+	}})
+	choice.Points = intPtr(2)
+	choice.Required = true
+
+	return []*core.Item{
+		newItem(t, "t1", types.ItemTypeTitle, "The Solar System", map[string]interface{}{}),
+		newItem(t, "i1", types.ItemTypeMedia, "Saturn's rings", types.MediaContent{
+			URL: assetURL + "saturn.png", MediaType: "image", AltText: strPtr("Saturn"), Caption: strPtr("Taken by Cassini"),
+		}),
+		newItem(t, "x1", types.ItemTypeMedia, "Launch", types.MediaContent{
+			URL: "https://example.com/launch.mp4", MediaType: "video", ShowControls: true,
+		}),
+		choice,
+		newItem(t, "m1", types.ItemTypeMultiChoice, "Which are gas giants?", types.ChoiceContent{Choices: []types.Choice{
+			{ID: "a", Text: "Jupiter", Correct: true}, {ID: "b", Text: "Earth"}, {ID: "c", Text: "Saturn", Correct: true},
+		}}),
+		newItem(t, "e1", types.ItemTypeTextEntry, "Name the red planet", types.TextEntryContent{
+			MaxLength: intPtr(20), Placeholder: strPtr("Planet"), CorrectAnswer: strPtr(" Mars "),
+		}),
+		newItem(t, "e2", types.ItemTypeTextEntry, "Describe an eclipse", types.TextEntryContent{Multiline: true}),
+	}
+}
+
+// memStorage keeps files in memory
+type memStorage struct {
+	core.Storage
+	files map[string]string
+}
+
+func (s *memStorage) Exists(ctx context.Context, key string) (bool, error) {
+	_, ok := s.files[key]
+	return ok, nil
+}
+
+func (s *memStorage) Download(ctx context.Context, key string) (io.ReadCloser, *core.StorageMetadata, error) {
+	data, ok := s.files[key]
+	if !ok {
+		return nil, nil, core.ErrFileNotFound
+	}
+	return io.NopCloser(strings.NewReader(data)), &core.StorageMetadata{Key: key}, nil
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func export(t *testing.T, version Version) map[string]string {
+	t.Helper()
+	storage := &memStorage{files: map[string]string{
+		"projects/" + projectID + "/assets/saturn.png": "saturn",
+	}}
+	var buf bytes.Buffer
+	require.NoError(t, NewExporter(storage, version).Export(context.Background(), &buf, publishedProject(), sampleItems(t)))
+	return readZip(t, buf.Bytes())
+}
+
+// embeddedSnapshot returns the quiz embedded in the launch page
+func embeddedSnapshot(t *testing.T, page string) Snapshot {
+	t.Helper()
+	match := regexp.MustCompile(`(?s)<script type="application/json" id="snapshot">(.*?)</script>`).FindStringSubmatch(page)
+	require.NotNil(t, match, "the page embeds the quiz")
+	var snapshot Snapshot
+	require.NoError(t, json.Unmarshal([]byte(match[1]), &snapshot))
+	return snapshot
+}
+
+func TestExporter_Export_Golden(t *testing.T) {
+	for _, version := range []Version{Version12, Version2004} {
+		t.Run(string(version), func(t *testing.T) {
+			// Act
+			files := export(t, version)
+
+			// Assert
+			names := make([]string, 0, len(files))
+			for name := range files {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			assert.Equal(t, []string{"assets/saturn.png", "imsmanifest.xml", "index.html", "runtime.js", "style.css"}, names)
+			assert.Equal(t, "saturn", files["assets/saturn.png"])
+			for _, name := range runtimeAssets {
+				want, err := runtimeFiles.ReadFile("runtime/" + name)
+				require.NoError(t, err)
+				assert.Equal(t, string(want), files[name], "%s is copied as is", name)
+			}
+
+			for _, name := range []string{manifestName, launchPage} {
+				golden := filepath.Join("testdata", "scorm"+strings.ReplaceAll(string(version), ".", ""), name)
+				if *update {
+					require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0o755))
+					require.NoError(t, os.WriteFile(golden, []byte(files[name]), 0o644))
+				}
+				want, err := os.ReadFile(golden)
+				require.NoError(t, err)
+				assert.Equal(t, string(want), files[name])
+			}
+		})
+	}
+}
+
+// TestManifest_FollowsSchema validates the golden manifests with xmllint
+// against the subsets of the IMS Content Packaging and ADL schemas in
+// testdata/xsd
+func TestManifest_FollowsSchema(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint is not installed")
+	}
+
+	tests := []struct {
+		dir    string
+		schema string
+	}{
+		{"scorm12", "imscp_rootv1p1p2.xsd"},
+		{"scorm2004", "imscp_v1p1.xsd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			// Act
+			out, err := exec.Command(xmllint, "--noout", "--nonet",
+				"--schema", filepath.Join("testdata", "xsd", tt.schema),
+				filepath.Join("testdata", tt.dir, manifestName)).CombinedOutput()
+
+			// Assert
+			assert.NoError(t, err, string(out))
+		})
+	}
+}
+
+func TestExporter_Export_Snapshot(t *testing.T) {
+	// Act
+	files := export(t, Version2004)
+
+	// Assert
+	page := files[launchPage]
+	assert.NotContains(t, page, "correct", "answers are stripped")
+	assert.Contains(t, page, "<title>Planets &lt;Quiz&gt;</title>")
+
+	snapshot := embeddedSnapshot(t, page)
+	assert.Equal(t, "2004", snapshot.Version)
+	assert.Equal(t, "Planets <Quiz>", snapshot.Title)
+	assert.Equal(t, "Ten minutes & no notes", snapshot.Description)
+	require.Len(t, snapshot.Items, 7)
+
+	byID := make(map[string]SnapshotItem)
+	for _, item := range snapshot.Items {
+		byID[item.ID] = item
+	}
+	assert.Equal(t, answerHash("c1", "b"), byID["c1"].Answer)
+	assert.Equal(t, 2, byID["c1"].Points)
+	assert.True(t, byID["c1"].Required)
+	assert.Equal(t, answerHash("m1", "a\nc"), byID["m1"].Answer, "choices are hashed as a sorted set")
+	assert.Equal(t, 1, byID["m1"].Points, "items without points score 1")
+	assert.Equal(t, answerHash("e1", "Mars"), byID["e1"].Answer, "text answers are trimmed")
+	assert.Empty(t, byID["e2"].Answer, "text entries without an answer are not graded")
+	assert.Zero(t, byID["e2"].Points)
+	assert.Empty(t, byID["t1"].Answer)
+
+	media := byID["i1"].Content.(map[string]interface{})
+	assert.Equal(t, "assets/saturn.png", media["url"], "assets are bundled")
+	external := byID["x1"].Content.(map[string]interface{})
+	assert.Equal(t, "https://example.com/launch.mp4", external["url"], "external URLs are kept")
+}
+
+// TestAnswerHash pins the hash the runtime's SHA-256 must reproduce
+func TestAnswerHash(t *testing.T) {
+	assert.Equal(t, "c447323d46816b9b03e9e4e7b35f6039190d50de27a5d2a4a89529037f385d5f", answerHash("id-1", "b\nc"))
+}
+
+func TestExporter_Export_Errors(t *testing.T) {
+	draft := publishedProject()
+	draft.PublishedAt = nil
+
+	tests := []struct {
+		name    string
+		project *core.Project
+		item    *core.Item
+		wantErr error
+	}{
+		{"draft", draft, newItem(t, "t1", types.ItemTypeTitle, "Title", map[string]interface{}{}), core.ErrProjectNotPublished},
+		{"hotspot", publishedProject(), newItem(t, "h1", types.ItemTypeHotspot, "Where?", types.HotspotContent{
+			ImageURL: "https://example.com/map.png",
+		}), core.ErrExportUnsupportedItem},
+		{"ordering", publishedProject(), newItem(t, "o1", types.ItemTypeOrdering, "Order", types.OrderingContent{}), core.ErrExportUnsupportedItem},
+		{"invalid content", publishedProject(), &core.Item{ID: "bad", Type: types.ItemTypeChoice, Content: json.RawMessage(`"nope"`)}, core.ErrItemInvalidContent},
+		{"missing asset", publishedProject(), newItem(t, "i1", types.ItemTypeMedia, "Gone", types.MediaContent{
+			URL: "/files/projects/" + projectID + "/assets/gone.png", MediaType: "image",
+		}), core.ErrFileNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var buf bytes.Buffer
+
+			// Act
+			err := NewExporter(&memStorage{}, Version12).Export(context.Background(), &buf, tt.project, []*core.Item{tt.item})
+
+			// Assert
+			assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+			assert.Zero(t, buf.Len(), "nothing is written before the export is known to succeed")
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package scorm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// Snapshot is the quiz a package delivers, embedded in its page as JSON.
+// It holds no answers in plain text: graded items carry a hash of their
+// correct response instead.
+type Snapshot struct {
+	// Version is the SCORM API the runtime talks to: "1.2" or "2004"
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Items       []SnapshotItem `json:"items"`
+}
+
+// SnapshotItem is an item of the quiz as the runtime renders it
+type SnapshotItem struct {
+	ID       string         `json:"id"`
+	Type     types.ItemType `json:"type"`
+	Title    string         `json:"title"`
+	Required bool           `json:"required,omitempty"`
+	Content  interface{}    `json:"content,omitempty"`
+	// Points and Answer are set for items the runtime grades
+	Points int    `json:"points,omitempty"`
+	Answer string `json:"answer,omitempty"`
+}
+
+// snapshotChoice is a choice without whether it is correct
+type snapshotChoice struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+type snapshotChoiceContent struct {
+	Choices []snapshotChoice `json:"choices"`
+}
+
+// snapshotTextEntryContent is a text entry without its correct answer
+type snapshotTextEntryContent struct {
+	Multiline   bool    `json:"multiline"`
+	MaxLength   *int    `json:"max_length,omitempty"`
+	Placeholder *string `json:"placeholder,omitempty"`
+}
+
+// supportedTypes are the item types the runtime delivers
+const supportedTypes = "title, media, choice, multi_choice and text_entry"
+
+// snapshotItem converts an item for the runtime, pointing media at the
+// package's copy of an asset through hrefs. Choices are graded when they
+// have a correct choice and text entries when they have a correct answer;
+// a graded item scores its points, or 1 without.
+func snapshotItem(item *core.Item, hrefs func(string) string) (SnapshotItem, error) {
+	s := SnapshotItem{ID: item.ID, Type: item.Type, Title: item.Title, Required: item.Required}
+
+	var answer string
+	switch item.Type {
+	case types.ItemTypeTitle:
+	case types.ItemTypeMedia:
+		var content types.MediaContent
+		if err := decodeContent(item, &content); err != nil {
+			return s, err
+		}
+		content.URL = hrefs(content.URL)
+		s.Content = content
+	case types.ItemTypeChoice, types.ItemTypeMultiChoice:
+		var content types.ChoiceContent
+		if err := decodeContent(item, &content); err != nil {
+			return s, err
+		}
+		var stripped snapshotChoiceContent
+		var correct []string
+		for _, choice := range content.Choices {
+			stripped.Choices = append(stripped.Choices, snapshotChoice{ID: choice.ID, Text: choice.Text})
+			if choice.Correct {
+				correct = append(correct, choice.ID)
+			}
+		}
+		s.Content = stripped
+		answer = choiceResponse(correct)
+	case types.ItemTypeTextEntry:
+		var content types.TextEntryContent
+		if err := decodeContent(item, &content); err != nil {
+			return s, err
+		}
+		s.Content = snapshotTextEntryContent{
+			Multiline:   content.Multiline,
+			MaxLength:   content.MaxLength,
+			Placeholder: content.Placeholder,
+		}
+		if content.CorrectAnswer != nil {
+			answer = strings.TrimSpace(*content.CorrectAnswer)
+		}
+	default:
+		return s, fmt.Errorf("%w: item %s is %s; SCORM packages hold %s items",
+			core.ErrExportUnsupportedItem, item.ID, item.Type, supportedTypes)
+	}
+
+	if answer != "" {
+		s.Points = 1
+		if item.Points != nil {
+			s.Points = *item.Points
+		}
+		s.Answer = answerHash(item.ID, answer)
+	}
+	return s, nil
+}
+
+// decodeContent decodes an item's content into v
+func decodeContent(item *core.Item, v interface{}) error {
+	if err := json.Unmarshal(item.Content, v); err != nil {
+		return fmt.Errorf("%w: %v", core.ErrItemInvalidContent, err)
+	}
+	return nil
+}
+
+// choiceResponse is the canonical form of a set of choices: their IDs,
+// sorted, one per line. The runtime builds the learner's response the same
+// way.
+func choiceResponse(ids []string) string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, "\n")
+}
+
+// answerHash is the hex SHA-256 of an item's ID and its correct response,
+// on separate lines, which the runtime compares the learner's response to.
+// The hash keeps the answers out of the page's source; like any offline
+// quiz, the package can't keep a determined learner from trying responses.
+func answerHash(itemID, response string) string {
+	sum := sha256.Sum256([]byte(itemID + "\n" + response))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// advisoryUnlockTimeout bounds releasing a lock, which happens after the
+// caller's context may already be done
+const advisoryUnlockTimeout = 5 * time.Second
+
+// TryAdvisoryLock takes a session-level Postgres advisory lock on key
+// without waiting. The lock lives on a connection held until unlock is
+// called, so it is also released if the process dies. Wrapped in
+// jobs.LockerFunc it keeps background jobs single-flight across replicas.
+// Engines without advisory locks (see Capabilities) lock within the process.
+func (d *Database) TryAdvisoryLock(ctx context.Context, key string) (unlock func(), ok bool, err error) {
+	if !d.dialect.Capabilities().AdvisoryLocks {
+		unlock, ok = d.localLocks.try(key)
+		return unlock, ok, nil
+	}
+
+	c, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get connection for advisory lock: %w", err)
+	}
+	runner := d.runner(c)
+
+	if err := runner.QueryRow(ctx, "advisory_lock.try", `SELECT pg_try_advisory_lock(hashtext($1))`, key).Scan(&ok); err != nil {
+		c.Close()
+		return nil, false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !ok {
+		c.Close()
+		return nil, false, nil
+	}
+
+	unlock = func() {
+		ctx, cancel := context.WithTimeout(context.Background(), advisoryUnlockTimeout)
+		defer cancel()
+
+		if _, err := runner.Exec(ctx, "advisory_lock.release", `SELECT pg_advisory_unlock(hashtext($1))`, key); err != nil {
+			// Discard the connection instead of returning it to the pool:
+			// closing the session releases the lock
+			log.Warn().Err(err).Str("key", key).Msg("failed to release advisory lock, discarding connection")
+			c.Raw(func(driverConn interface{}) error {
+				return driver.ErrBadConn
+			})
+		}
+		c.Close()
+	}
+	return unlock, true, nil
+}
+
+// localLocks are locks held by this process, keyed like advisory locks
+type localLocks struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+// try takes the lock on key unless it is already held
+func (l *localLocks) try(key string) (unlock func(), ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held[key] {
+		return nil, false
+	}
+	if l.held == nil {
+		l.held = make(map[string]bool)
+	}
+	l.held[key] = true
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			delete(l.held, key)
+		})
+	}, true
+}
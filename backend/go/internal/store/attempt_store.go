@@ -0,0 +1,663 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store/dbtypes"
+)
+
+// AttemptStore implements core.AttemptStore. Attempts are read from the
+// primary, since learners answer right after starting.
+type AttemptStore struct {
+	db *Database
+}
+
+// NewAttemptStore creates a new attempt store
+func NewAttemptStore(db *Database) *AttemptStore {
+	return &AttemptStore{db: db}
+}
+
+const (
+	attemptColumns = `id, project_id, publication_version, participant_id, is_preview, started_at, submitted_at, score, max_score`
+	answerColumns  = `attempt_id, item_id, response, sequence, answered_at`
+	resultColumns  = `item_id, earned, possible, correct`
+)
+
+// Create stores a new attempt, started now
+func (s *AttemptStore) Create(ctx context.Context, attempt *core.Attempt) (*core.Attempt, error) {
+	var participantID *string
+	if attempt.ParticipantID != "" {
+		participantID = &attempt.ParticipantID
+	}
+
+	query := `
+		INSERT INTO attempts (id, project_id, publication_version, participant_id, is_preview, started_at)
+		VALUES ($1, $2, $3, $4, $5, ` + s.db.dialect.Now() + `)
+		RETURNING ` + attemptColumns + `
+	`
+	created, err := scanAttempt(s.db.QueryRow(ctx, "attempts.create", query,
+		core.NewID(ctx), attempt.ProjectID, attempt.PublicationVersion, participantID, attempt.IsPreview))
+	if violation, ok := s.db.dialect.Violation(err); ok && violation.Kind == ForeignKeyViolation {
+		return nil, core.ErrProjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attempt: %w", err)
+	}
+	created.Answers = []*core.Answer{}
+	created.Results = []*core.ItemResult{}
+	return created, nil
+}
+
+// Get retrieves an attempt with its answers and results
+func (s *AttemptStore) Get(ctx context.Context, id string) (*core.Attempt, error) {
+	attempt, err := scanAttempt(s.db.QueryRow(ctx, "attempts.get",
+		`SELECT `+attemptColumns+` FROM attempts WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrAttemptNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attempt: %w", err)
+	}
+
+	if attempt.Answers, err = s.answers(ctx, attempt.ID); err != nil {
+		return nil, err
+	}
+	if attempt.Results, err = s.results(ctx, attempt.ID); err != nil {
+		return nil, err
+	}
+	return attempt, nil
+}
+
+// SaveAnswer stores an answer in place of the attempt's earlier answer to
+// the item, unless that was stored by a later numbered save. The attempt's
+// row is locked meanwhile, so no answer is saved once a concurrent Submit
+// has returned.
+func (s *AttemptStore) SaveAnswer(ctx context.Context, answer *core.Answer) (*core.Answer, error) {
+	var saved *core.Answer
+	err := s.db.InTx(ctx, "attempts.save_answer", func(ctx context.Context) error {
+		var submittedAt *time.Time
+		err := s.db.QueryRow(ctx, "attempts.lock",
+			`SELECT submitted_at FROM attempts WHERE id = $1`+s.db.dialect.ForUpdate(),
+			answer.AttemptID).Scan(scanNullUTC(&submittedAt))
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.ErrAttemptNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock attempt: %w", err)
+		}
+		if submittedAt != nil {
+			return core.ErrAttemptAlreadySubmitted
+		}
+
+		query := `
+			INSERT INTO answers (attempt_id, item_id, response, sequence, answered_at)
+			VALUES ($1, $2, $3, $4, ` + s.db.dialect.Now() + `)
+			ON CONFLICT (attempt_id, item_id) DO UPDATE
+			SET response = EXCLUDED.response, sequence = EXCLUDED.sequence, answered_at = EXCLUDED.answered_at
+			WHERE EXCLUDED.sequence = 0 OR answers.sequence <= EXCLUDED.sequence
+			RETURNING ` + answerColumns + `
+		`
+		saved, err = scanAnswer(s.db.QueryRow(ctx, "answers.save", query,
+			answer.AttemptID, answer.ItemID, dbtypes.JSON(answer.Response), answer.Sequence))
+		if errors.Is(err, sql.ErrNoRows) {
+			// The stored answer was left alone: a later save stored it
+			return core.ErrStaleAnswer
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save answer: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// Submit marks an attempt submitted now with its results, unless it was
+// submitted before, in one transaction, and announces the submission of
+// any attempt but a preview to every replica (see ChangeListener)
+func (s *AttemptStore) Submit(ctx context.Context, id string, results []*core.ItemResult) (*core.Attempt, error) {
+	score, maxScore := 0, 0
+	for _, result := range results {
+		score += result.Earned
+		maxScore += result.Possible
+	}
+
+	var attempt *core.Attempt
+	err := s.db.InTx(ctx, "attempts.submit", func(ctx context.Context) error {
+		query := `
+			UPDATE attempts
+			SET submitted_at = ` + s.db.dialect.Now() + `, score = $2, max_score = $3
+			WHERE id = $1 AND submitted_at IS NULL
+			RETURNING ` + attemptColumns
+		var err error
+		attempt, err = scanAttempt(s.db.QueryRow(ctx, "attempts.submit", query, id, score, maxScore))
+		if errors.Is(err, sql.ErrNoRows) {
+			if _, err := s.Get(ctx, id); err != nil {
+				return err
+			}
+			return core.ErrAttemptAlreadySubmitted
+		}
+		if err != nil {
+			return fmt.Errorf("failed to submit attempt: %w", err)
+		}
+
+		for position, result := range results {
+			_, err := s.db.Exec(ctx, "attempt_results.insert", `
+				INSERT INTO attempt_results (attempt_id, item_id, position, earned, possible, correct)
+				VALUES ($1, $2, $3, $4, $5, $6)
+			`, attempt.ID, result.ItemID, position, result.Earned, result.Possible, result.Correct)
+			if err != nil {
+				return fmt.Errorf("failed to save attempt result: %w", err)
+			}
+		}
+
+		if attempt.Answers, err = s.answers(ctx, attempt.ID); err != nil {
+			return err
+		}
+		if attempt.Results, err = s.results(ctx, attempt.ID); err != nil {
+			return err
+		}
+
+		if attempt.IsPreview {
+			return nil
+		}
+		return s.db.notify(ctx, core.Change{Entity: core.ChangeEntityAttempts, ID: attempt.ProjectID, Action: core.ChangeActionUpdated})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attempt, nil
+}
+
+// ListByProject returns a page of the attempts at a project started in
+// filter's window, previews left out, latest first, and how many there are
+// in all. It reads the replica.
+func (s *AttemptStore) ListByProject(ctx context.Context, projectID string, filter core.AttemptFilter, limit, offset int) ([]*core.Attempt, int, error) {
+	window, args := attemptWindow(projectID, filter)
+
+	var total int
+	err := s.db.ReadQueryRow(ctx, "attempts.count",
+		`SELECT COUNT(*) FROM attempts WHERE `+window, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count attempts: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM attempts
+		WHERE %s
+		ORDER BY started_at DESC, id
+		LIMIT $%d OFFSET $%d
+	`, attemptColumns, window, len(args)+1, len(args)+2)
+	rows, err := s.db.ReadQuery(ctx, "attempts.list", query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := make([]*core.Attempt, 0, limit)
+	for rows.Next() {
+		attempt, err := scanAttempt(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan attempt: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate attempts: %w", err)
+	}
+	return attempts, total, nil
+}
+
+// Stats aggregates the attempts at a project started in filter's window,
+// previews left out, each figure in one grouped query. It reads the
+// replica.
+func (s *AttemptStore) Stats(ctx context.Context, projectID string, filter core.AttemptFilter) (*core.ProjectStats, error) {
+	window, args := attemptWindow(projectID, filter)
+	stats := &core.ProjectStats{Items: []*core.ItemStats{}}
+
+	var averageScore sql.NullFloat64
+	err := s.db.ReadQueryRow(ctx, "attempts.stats", `
+		SELECT COUNT(*), COUNT(submitted_at), CAST(AVG(score) AS DOUBLE PRECISION)
+		FROM attempts
+		WHERE `+window, args...).Scan(&stats.Attempts, &stats.Submitted, &averageScore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate attempts: %w", err)
+	}
+	if averageScore.Valid {
+		stats.AverageScore = &averageScore.Float64
+	}
+
+	// Only submitted attempts have results
+	rows, err := s.db.ReadQuery(ctx, "attempt_results.stats", `
+		SELECT
+			attempt_results.item_id,
+			COUNT(*),
+			CAST(100.0 * SUM(CASE WHEN attempt_results.correct THEN 1 ELSE 0 END) / COUNT(*) AS DOUBLE PRECISION),
+			CAST(AVG(attempt_results.earned) AS DOUBLE PRECISION)
+		FROM attempt_results
+		JOIN attempts ON attempts.id = attempt_results.attempt_id
+		WHERE `+window+`
+		GROUP BY attempt_results.item_id
+		ORDER BY MIN(attempt_results.position), attempt_results.item_id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate attempt results: %w", err)
+	}
+	defer rows.Close()
+
+	items := map[string]*core.ItemStats{}
+	for rows.Next() {
+		item := &core.ItemStats{Choices: []*core.ChoiceCount{}}
+		if err := rows.Scan(&item.ItemID, &item.Graded, &item.PercentCorrect, &item.AveragePoints); err != nil {
+			return nil, fmt.Errorf("failed to scan item stats: %w", err)
+		}
+		stats.Items = append(stats.Items, item)
+		items[item.ItemID] = item
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate item stats: %w", err)
+	}
+	rows.Close()
+
+	if err := s.countAnswers(ctx, window, args, items); err != nil {
+		return nil, err
+	}
+	if err := s.countChoices(ctx, window, args, items); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// countAnswers sets how many submitted attempts in the window answered each
+// of items
+func (s *AttemptStore) countAnswers(ctx context.Context, window string, args []interface{}, items map[string]*core.ItemStats) error {
+	rows, err := s.db.ReadQuery(ctx, "answers.stats", `
+		SELECT answers.item_id, COUNT(*)
+		FROM answers
+		JOIN attempts ON attempts.id = answers.attempt_id
+		WHERE `+window+` AND attempts.submitted_at IS NOT NULL
+		GROUP BY answers.item_id
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to count answers: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemID string
+		var answered int
+		if err := rows.Scan(&itemID, &answered); err != nil {
+			return fmt.Errorf("failed to scan answer count: %w", err)
+		}
+		if item, ok := items[itemID]; ok {
+			item.Answered = answered
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate answer counts: %w", err)
+	}
+	return nil
+}
+
+// countChoices counts, for each of items, the submitted attempts in the
+// window choosing each option: the choice_id of choice answers and every
+// one of the choice_ids of multi_choice answers
+func (s *AttemptStore) countChoices(ctx context.Context, window string, args []interface{}, items map[string]*core.ItemStats) error {
+	choiceID := s.db.dialect.JSONText("answers.response", "choice_id")
+	query := `
+		SELECT chosen.item_id, chosen.choice_id, COUNT(*)
+		FROM (
+			SELECT answers.item_id AS item_id, ` + choiceID + ` AS choice_id
+			FROM answers
+			JOIN attempts ON attempts.id = answers.attempt_id
+			WHERE ` + window + ` AND attempts.submitted_at IS NOT NULL AND ` + choiceID + ` IS NOT NULL
+			UNION ALL
+			SELECT answers.item_id AS item_id, choice_ids.value AS choice_id
+			FROM answers
+			JOIN attempts ON attempts.id = answers.attempt_id
+			CROSS JOIN ` + s.db.dialect.JSONElements("answers.response", "choice_ids", "choice_ids") + `
+			WHERE ` + window + ` AND attempts.submitted_at IS NOT NULL
+		) AS chosen
+		GROUP BY chosen.item_id, chosen.choice_id
+		ORDER BY chosen.item_id, chosen.choice_id
+	`
+	rows, err := s.db.ReadQuery(ctx, "answers.choice_stats", query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to count choices: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemID string
+		var choice core.ChoiceCount
+		if err := rows.Scan(&itemID, &choice.ChoiceID, &choice.Count); err != nil {
+			return fmt.Errorf("failed to scan choice count: %w", err)
+		}
+		if item, ok := items[itemID]; ok {
+			item.Choices = append(item.Choices, &choice)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate choice counts: %w", err)
+	}
+	return nil
+}
+
+// PurgePreviews deletes the previews started before cutoff, in every
+// project. Their answers and results go with them.
+func (s *AttemptStore) PurgePreviews(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := s.db.Exec(ctx, "attempts.purge_previews",
+		`DELETE FROM attempts WHERE is_preview AND started_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge preview attempts: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return deleted, nil
+}
+
+// EraseAttempts deletes, or anonymizes, up to limit of the attempts
+// selection picks, oldest first, in one transaction. The answers and
+// results of deleted attempts are counted before they go with them, and
+// deleting attempts announces the change to the stats of their projects
+// to every replica (see ChangeListener).
+func (s *AttemptStore) EraseAttempts(ctx context.Context, selection core.AttemptSelection, mode core.RetentionMode, limit int) (*core.ErasureReport, error) {
+	var conditions []string
+	var args []interface{}
+	if selection.StartedBefore != nil {
+		args = append(args, *selection.StartedBefore)
+		conditions = append(conditions, fmt.Sprintf("started_at < $%d", len(args)))
+	}
+	if selection.ParticipantID != "" {
+		args = append(args, selection.ParticipantID)
+		conditions = append(conditions, fmt.Sprintf("participant_id = $%d", len(args)))
+	}
+	if mode == core.RetentionModeAnonymize {
+		conditions = append(conditions, "participant_id IS NOT NULL")
+	}
+	if len(conditions) == 0 {
+		return nil, errors.New("failed to erase attempts: no attempts selected")
+	}
+
+	report := &core.ErasureReport{}
+	err := s.db.InTx(ctx, "attempts.erase", func(ctx context.Context) error {
+		query := fmt.Sprintf(`
+			SELECT id, project_id, is_preview
+			FROM attempts
+			WHERE %s
+			ORDER BY started_at, id
+			LIMIT $%d`, strings.Join(conditions, " AND "), len(args)+1) + s.db.dialect.ForUpdate()
+		rows, err := s.db.Query(ctx, "attempts.select_erased", query, append(args, limit)...)
+		if err != nil {
+			return fmt.Errorf("failed to select attempts to erase: %w", err)
+		}
+		defer rows.Close()
+
+		var ids, projectIDs []string
+		seen := map[string]bool{}
+		for rows.Next() {
+			var id, projectID string
+			var isPreview bool
+			if err := rows.Scan(&id, &projectID, &isPreview); err != nil {
+				return fmt.Errorf("failed to scan attempt to erase: %w", err)
+			}
+			ids = append(ids, id)
+			if !isPreview && !seen[projectID] {
+				seen[projectID] = true
+				projectIDs = append(projectIDs, projectID)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to iterate attempts to erase: %w", err)
+		}
+		rows.Close()
+		if len(ids) == 0 {
+			return nil
+		}
+		in, inArgs := inList(ids)
+
+		if mode == core.RetentionModeAnonymize {
+			result, err := s.db.Exec(ctx, "attempts.anonymize",
+				`UPDATE attempts SET participant_id = NULL WHERE id IN `+in, inArgs...)
+			if err != nil {
+				return fmt.Errorf("failed to anonymize attempts: %w", err)
+			}
+			report.Attempts, err = result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to get rows affected: %w", err)
+			}
+			return nil
+		}
+
+		for _, count := range []struct {
+			name  string
+			table string
+			into  *int64
+		}{
+			{"answers.count_erased", "answers", &report.Answers},
+			{"attempt_results.count_erased", "attempt_results", &report.Results},
+		} {
+			err := s.db.QueryRow(ctx, count.name,
+				`SELECT COUNT(*) FROM `+count.table+` WHERE attempt_id IN `+in, inArgs...).Scan(count.into)
+			if err != nil {
+				return fmt.Errorf("failed to count %s of erased attempts: %w", count.table, err)
+			}
+		}
+		result, err := s.db.Exec(ctx, "attempts.erase", `DELETE FROM attempts WHERE id IN `+in, inArgs...)
+		if err != nil {
+			return fmt.Errorf("failed to delete attempts: %w", err)
+		}
+		report.Attempts, err = result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		for _, projectID := range projectIDs {
+			err := s.db.notify(ctx, core.Change{Entity: core.ChangeEntityAttempts, ID: projectID, Action: core.ChangeActionUpdated})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ListSubmitted returns a batch of submitted attempts with their results,
+// in ID order after afterID, previews left out. It reads the replica.
+func (s *AttemptStore) ListSubmitted(ctx context.Context, projectID string, filter core.AttemptFilter, afterID string, limit int) ([]*core.Attempt, error) {
+	conditions := []string{"submitted_at IS NOT NULL", "NOT is_preview"}
+	var args []interface{}
+	if projectID != "" {
+		args = append(args, projectID)
+		conditions = append(conditions, fmt.Sprintf("project_id = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("started_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("started_at < $%d", len(args)))
+	}
+	if afterID != "" {
+		args = append(args, afterID)
+		conditions = append(conditions, fmt.Sprintf("id > $%d", len(args)))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM attempts
+		WHERE %s
+		ORDER BY id
+		LIMIT $%d
+	`, attemptColumns, strings.Join(conditions, " AND "), len(args)+1)
+	rows, err := s.db.ReadQuery(ctx, "attempts.list_submitted", query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list submitted attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []*core.Attempt{}
+	byID := map[string]*core.Attempt{}
+	var ids []string
+	for rows.Next() {
+		attempt, err := scanAttempt(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attempt: %w", err)
+		}
+		attempt.Results = []*core.ItemResult{}
+		attempts = append(attempts, attempt)
+		byID[attempt.ID] = attempt
+		ids = append(ids, attempt.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate attempts: %w", err)
+	}
+	rows.Close()
+	if len(ids) == 0 {
+		return attempts, nil
+	}
+
+	// The results of the whole batch are read at once
+	in, inArgs := inList(ids)
+	rows, err = s.db.ReadQuery(ctx, "attempt_results.list_submitted",
+		`SELECT attempt_id, `+resultColumns+` FROM attempt_results WHERE attempt_id IN `+in+` ORDER BY attempt_id, position`, inArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attempt results: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var attemptID string
+		var result core.ItemResult
+		if err := rows.Scan(&attemptID, &result.ItemID, &result.Earned, &result.Possible, &result.Correct); err != nil {
+			return nil, fmt.Errorf("failed to scan attempt result: %w", err)
+		}
+		if attempt, ok := byID[attemptID]; ok {
+			attempt.Results = append(attempt.Results, &result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate attempt results: %w", err)
+	}
+	return attempts, nil
+}
+
+// inList returns an IN list of placeholders binding values, from $1, and
+// its arguments
+func inList(values []string) (string, []interface{}) {
+	placeholders := make([]string, len(values))
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = value
+	}
+	return "(" + strings.Join(placeholders, ", ") + ")", args
+}
+
+// attemptWindow returns the condition keeping the attempts at a project
+// started in filter's window, previews left out, and its arguments, bound
+// from $1
+func attemptWindow(projectID string, filter core.AttemptFilter) (string, []interface{}) {
+	window := "attempts.project_id = $1 AND NOT attempts.is_preview"
+	args := []interface{}{projectID}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		window += fmt.Sprintf(" AND attempts.started_at >= $%d", len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		window += fmt.Sprintf(" AND attempts.started_at < $%d", len(args))
+	}
+	return window, args
+}
+
+// answers returns the answers of an attempt, in the order they were first
+// given
+func (s *AttemptStore) answers(ctx context.Context, attemptID string) ([]*core.Answer, error) {
+	rows, err := s.db.Query(ctx, "answers.list",
+		`SELECT `+answerColumns+` FROM answers WHERE attempt_id = $1 ORDER BY answered_at, item_id`, attemptID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list answers: %w", err)
+	}
+	defer rows.Close()
+
+	answers := []*core.Answer{}
+	for rows.Next() {
+		answer, err := scanAnswer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan answer: %w", err)
+		}
+		answers = append(answers, answer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate answers: %w", err)
+	}
+	return answers, nil
+}
+
+// results returns the results of an attempt, in item order
+func (s *AttemptStore) results(ctx context.Context, attemptID string) ([]*core.ItemResult, error) {
+	rows, err := s.db.Query(ctx, "attempt_results.list",
+		`SELECT `+resultColumns+` FROM attempt_results WHERE attempt_id = $1 ORDER BY position`, attemptID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attempt results: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*core.ItemResult{}
+	for rows.Next() {
+		var result core.ItemResult
+		if err := rows.Scan(&result.ItemID, &result.Earned, &result.Possible, &result.Correct); err != nil {
+			return nil, fmt.Errorf("failed to scan attempt result: %w", err)
+		}
+		results = append(results, &result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate attempt results: %w", err)
+	}
+	return results, nil
+}
+
+func scanAttempt(row rowScanner) (*core.Attempt, error) {
+	var attempt core.Attempt
+	var participantID sql.NullString
+	var score, maxScore sql.NullInt64
+	err := row.Scan(&attempt.ID, &attempt.ProjectID, &attempt.PublicationVersion, &participantID,
+		&attempt.IsPreview, scanUTC(&attempt.StartedAt), scanNullUTC(&attempt.SubmittedAt), &score, &maxScore)
+	if err != nil {
+		return nil, err
+	}
+	attempt.ParticipantID = participantID.String
+	if score.Valid && maxScore.Valid {
+		earned, possible := int(score.Int64), int(maxScore.Int64)
+		attempt.Score, attempt.MaxScore = &earned, &possible
+	}
+	return &attempt, nil
+}
+
+func scanAnswer(row rowScanner) (*core.Answer, error) {
+	var answer core.Answer
+	var response dbtypes.JSON
+	if err := row.Scan(&answer.AttemptID, &answer.ItemID, &response, &answer.Sequence, scanUTC(&answer.AnsweredAt)); err != nil {
+		return nil, err
+	}
+	answer.Response = json.RawMessage(response)
+	return &answer, nil
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// sendBatch sends every statement of batch to Postgres in one round trip,
+// on the connection of the transaction r runs in, and reads their results
+// with the functions queued with them. database/sql has no batches, so it
+// goes through pgx's native interface, untraced; it is timed and logged
+// like one statement named name.
+func (r *Runner) sendBatch(ctx context.Context, name string, batch *pgx.Batch) error {
+	if r.session == nil {
+		return errors.New("store: batches must run in a transaction")
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	err := r.session.Raw(func(driverConn interface{}) error {
+		conn, err := pgxConn(driverConn)
+		if err != nil {
+			return err
+		}
+		return conn.SendBatch(ctx, batch).Close()
+	})
+	r.observe(ctx, name, batch.QueuedQueries[0].SQL, start, err)
+	return err
+}
+
+// copyFrom loads rows into the columns of table with COPY FROM in one round
+// trip, on the connection of the transaction r runs in. Like sendBatch it
+// goes through pgx's native interface, timed and logged like one statement
+// named name. A row the table rejects aborts the whole COPY, and with it
+// the transaction.
+func (r *Runner) copyFrom(ctx context.Context, name, table string, columns []string, rows [][]interface{}) error {
+	if r.session == nil {
+		return errors.New("store: COPY must run in a transaction")
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	err := r.session.Raw(func(driverConn interface{}) error {
+		conn, err := pgxConn(driverConn)
+		if err != nil {
+			return err
+		}
+		copied, err := conn.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+		if err == nil && copied != int64(len(rows)) {
+			err = fmt.Errorf("store: copied %d of %d rows", copied, len(rows))
+		}
+		return err
+	})
+	r.observe(ctx, name, "COPY "+table+" ("+strings.Join(columns, ", ")+") FROM STDIN", start, err)
+	return err
+}
+
+// pgxConn returns the native connection behind a database/sql driver
+// connection of a Postgres database
+func pgxConn(driverConn interface{}) (*pgx.Conn, error) {
+	// otelsql wraps the driver's connections
+	if traced, ok := driverConn.(interface{ Raw() driver.Conn }); ok {
+		driverConn = traced.Raw()
+	}
+	conn, ok := driverConn.(*stdlib.Conn)
+	if !ok {
+		return nil, fmt.Errorf("store: %T is not a pgx connection", driverConn)
+	}
+	return conn.Conn(), nil
+}
@@ -0,0 +1,203 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// ChangesChannel is the Postgres notification channel stores announce
+// committed changes on, with a JSON-encoded core.Change as the payload
+const ChangesChannel = "provemyself_changes"
+
+// Change listener reconnect backoff, and how long closing its connection
+// may take
+const (
+	listenInitialBackoff = 250 * time.Millisecond
+	listenMaxBackoff     = 30 * time.Second
+	listenCloseTimeout   = 5 * time.Second
+)
+
+// notify announces change to every replica's ChangeListener. Inside a
+// transaction Postgres delivers it once the transaction commits, and drops
+// it on rollback. Outside one the write it follows has already committed,
+// so a failure is only logged: other replicas then serve their copy until
+// it expires or they resync.
+func (d *Database) notify(ctx context.Context, change core.Change) error {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx.notify(ctx, change)
+	}
+	if err := d.runner(d.db).notify(ctx, change); err != nil {
+		log.Ctx(ctx).Warn().Err(err).
+			Str("entity", change.Entity).
+			Str("id", change.ID).
+			Msg("failed to announce change")
+	}
+	return nil
+}
+
+// notify announces change on r, a no-op on engines without notifications.
+// Identical notifications of one transaction are delivered once.
+func (r *Runner) notify(ctx context.Context, change core.Change) error {
+	if !r.dialect.Capabilities().Notifications {
+		return nil
+	}
+
+	payload, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("failed to encode change: %w", err)
+	}
+	if _, err := r.Exec(ctx, "changes.notify", `SELECT pg_notify($1, $2)`, ChangesChannel, string(payload)); err != nil {
+		return fmt.Errorf("failed to announce change: %w", err)
+	}
+	return nil
+}
+
+// changeSubscription is a subscriber and the entities it is told about,
+// every entity when empty
+type changeSubscription struct {
+	entities   []string
+	subscriber core.ChangeSubscriber
+}
+
+// ChangeListener keeps the caches of one replica current with writes made
+// through any replica: it LISTENs on ChangesChannel over a connection of its
+// own and hands every change to the subscribers of its entity. Whenever it
+// (re)connects it resyncs every subscriber, since changes committed while
+// nothing listened were missed; a payload it cannot read does the same.
+type ChangeListener struct {
+	db *Database
+
+	mu          sync.RWMutex
+	subscribers []changeSubscription
+}
+
+// NewChangeListener creates a listener for the database's changes
+func NewChangeListener(db *Database) *ChangeListener {
+	return &ChangeListener{db: db}
+}
+
+// Subscribe registers subscriber for changes to any of entities, or to any
+// entity when none are given. Subscribers run in the order they subscribed,
+// on the listener's goroutine.
+func (l *ChangeListener) Subscribe(subscriber core.ChangeSubscriber, entities ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.subscribers = append(l.subscribers, changeSubscription{entities: entities, subscriber: subscriber})
+}
+
+// Run listens until ctx is done, reconnecting with backoff when the
+// connection fails. On engines without notifications it returns at once.
+func (l *ChangeListener) Run(ctx context.Context) error {
+	if !l.db.dialect.Capabilities().Notifications {
+		log.Ctx(ctx).Debug().Str("dialect", l.db.dialect.Name()).Msg("change notifications unsupported, not listening")
+		return nil
+	}
+
+	backoff := listenInitialBackoff
+	for {
+		connected, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if connected {
+			backoff = listenInitialBackoff
+		}
+		log.Ctx(ctx).Warn().Err(err).Dur("retry_in", backoff).Msg("change listener disconnected")
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, listenMaxBackoff)
+	}
+}
+
+// listen connects, LISTENs and dispatches notifications until the
+// connection fails or ctx is done
+func (l *ChangeListener) listen(ctx context.Context) (connected bool, err error) {
+	// LISTEN holds its connection for good, so it is not taken from the pool
+	conn, err := pgx.ConnectConfig(ctx, l.db.pool.Config().ConnConfig.Copy())
+	if err != nil {
+		return false, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), listenCloseTimeout)
+		defer cancel()
+		conn.Close(closeCtx)
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{ChangesChannel}.Sanitize()); err != nil {
+		return false, fmt.Errorf("failed to listen: %w", err)
+	}
+	log.Ctx(ctx).Info().Str("channel", ChangesChannel).Msg("listening for changes")
+
+	// Only now that it listens can nothing slip between the resync and the
+	// first notification
+	l.resync(ctx)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+		l.dispatch(ctx, notification.Payload)
+	}
+}
+
+// dispatch hands the change in payload to its subscribers
+func (l *ChangeListener) dispatch(ctx context.Context, payload string) {
+	var change core.Change
+	if err := json.Unmarshal([]byte(payload), &change); err != nil || change.Entity == "" {
+		log.Ctx(ctx).Warn().Err(err).Str("payload", payload).Msg("unreadable change notification, resyncing")
+		l.resync(ctx)
+		return
+	}
+
+	for _, sub := range l.subscriptions() {
+		if !sub.interested(change.Entity) {
+			continue
+		}
+		if err := sub.subscriber.ApplyChange(ctx, change); err != nil {
+			log.Ctx(ctx).Warn().Err(err).
+				Str("entity", change.Entity).
+				Str("id", change.ID).
+				Msg("failed to apply change")
+		}
+	}
+}
+
+// resync resyncs every subscriber
+func (l *ChangeListener) resync(ctx context.Context) {
+	for _, sub := range l.subscriptions() {
+		if err := sub.subscriber.Resync(ctx); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("failed to resync after missed changes")
+		}
+	}
+}
+
+func (l *ChangeListener) subscriptions() []changeSubscription {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.subscribers
+}
+
+func (s changeSubscription) interested(entity string) bool {
+	if len(s.entities) == 0 {
+		return true
+	}
+	for _, e := range s.entities {
+		if e == entity {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// recordingSubscriber collects the changes and resyncs it is told about
+type recordingSubscriber struct {
+	changes []core.Change
+	resyncs int
+}
+
+func (s *recordingSubscriber) ApplyChange(ctx context.Context, change core.Change) error {
+	s.changes = append(s.changes, change)
+	return nil
+}
+
+func (s *recordingSubscriber) Resync(ctx context.Context) error {
+	s.resyncs++
+	return nil
+}
+
+func TestDatabase_Notify(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	change := core.Change{Entity: core.ChangeEntityProject, ID: "project-1", Action: core.ChangeActionDeleted}
+
+	// Act
+	err := database.notify(context.Background(), change)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"entity":"project","id":"project-1","action":"deleted"}`}, stub.notifications)
+}
+
+func TestDatabase_Notify_OutsideATransactionOnlyLogsFailures(t *testing.T) {
+	// Arrange
+	failure := errors.New("boom")
+	database := newStubDatabase(t, 0, failure)
+	change := core.Change{Entity: core.ChangeEntitySettings, Action: core.ChangeActionUpdated}
+
+	// Act
+	outside := database.notify(context.Background(), change)
+	inside := database.InTx(context.Background(), "test", func(ctx context.Context) error {
+		return database.notify(ctx, change)
+	})
+
+	// Assert
+	assert.NoError(t, outside, "the write it follows has committed")
+	assert.ErrorIs(t, inside, failure)
+}
+
+func TestChangeListener_Dispatch(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	listener := NewChangeListener(newStubDatabase(t, 0, nil))
+	projects := &recordingSubscriber{}
+	everything := &recordingSubscriber{}
+	listener.Subscribe(projects, core.ChangeEntityProject)
+	listener.Subscribe(everything)
+
+	// Act
+	listener.dispatch(ctx, `{"entity":"project","id":"project-1","action":"updated"}`)
+	listener.dispatch(ctx, `{"entity":"settings","action":"updated"}`)
+	listener.dispatch(ctx, `not json`)
+
+	// Assert
+	assert.Equal(t, []core.Change{{Entity: core.ChangeEntityProject, ID: "project-1", Action: core.ChangeActionUpdated}}, projects.changes)
+	assert.Len(t, everything.changes, 2)
+	assert.Equal(t, 1, projects.resyncs, "an unreadable notification resyncs every subscriber")
+	assert.Equal(t, 1, everything.resyncs)
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CollabStore implements core.CollabStore with one row per project
+type CollabStore struct {
+	db *Database
+}
+
+// NewCollabStore creates a new collaboration document store
+func NewCollabStore(db *Database) *CollabStore {
+	return &CollabStore{db: db}
+}
+
+// Load returns the project's saved state, or nil if it has none. It reads
+// the primary, since a lagging replica would roll back the last edits.
+func (s *CollabStore) Load(ctx context.Context, projectID string) ([]byte, error) {
+	query := `
+		SELECT state
+		FROM collab_docs
+		WHERE project_id = $1
+	`
+
+	var state []byte
+	err := s.db.QueryRow(ctx, "collab_docs.load", query, projectID).Scan(&state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load collaboration document: %w", err)
+	}
+
+	return state, nil
+}
+
+// Save replaces the project's saved state
+func (s *CollabStore) Save(ctx context.Context, projectID string, state []byte) error {
+	query := `
+		INSERT INTO collab_docs (project_id, state, updated_at)
+		VALUES ($1, $2, ` + s.db.dialect.Now() + `)
+		ON CONFLICT (project_id) DO UPDATE
+		SET state = EXCLUDED.state,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := s.db.Exec(ctx, "collab_docs.save", query, projectID, state); err != nil {
+		return fmt.Errorf("failed to save collaboration document: %w", err)
+	}
+
+	return nil
+}
@@ -6,183 +6,363 @@ import (
 	"fmt"
 	"time"
 
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/XSAM/otelsql"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
 	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/tracing"
+	"github.com/provemyself/backend/internal/types"
 )
 
 // Database wraps a SQL database connection
 type Database struct {
 	db *sql.DB
+	// pool holds the connections of a Postgres database, which db borrows
+	// for each statement; nil on other engines
+	pool    *pgxpool.Pool
+	dialect Dialect
+	// dsn is the driver's data source name, for connections of its own
+	dsn   string
+	instr *queryInstrumentation
+	// localLocks stand in for advisory locks on engines without them
+	localLocks localLocks
+	// replica, if configured, serves the reads that tolerate lag (see
+	// StaleQuery)
+	replica *replica
+	// writeLegacyTags is DatabaseConfig.WriteLegacyTags
+	writeLegacyTags bool
+}
+
+// Startup connection retry backoff
+const (
+	connectInitialBackoff = 250 * time.Millisecond
+	connectMaxBackoff     = 5 * time.Second
+)
+
+// DatabaseConfig contains connection and pool settings
+type DatabaseConfig struct {
+	// URL is a Postgres connection URL, or sqlite://path for a SQLite
+	// database file
+	URL string
+	// ReplicaURL, if set, is a read replica of URL on the same engine. Its
+	// pool has the same settings.
+	ReplicaURL   string
+	MaxOpenConns int
+	// MaxIdleConns is how many idle connections are kept open. On Postgres
+	// the pool keeps at least this many open, closing the others once idle
+	// for ConnMaxIdleTime.
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// ConnectTimeout bounds how long startup waits for the database to
+	// accept connections, retrying with backoff until then
+	ConnectTimeout time.Duration
+	// WriteLegacyTags keeps writing project tags to the JSONB tags column
+	// as well as to the tags_arr array the stores read, so the release
+	// before migration 10 can be rolled back to. A later migration drops
+	// the JSONB column, and this setting with it.
+	WriteLegacyTags bool
 }
 
-// NewDatabase creates a new database connection
-func NewDatabase(databaseURL string) (*Database, error) {
-	db, err := sql.Open("postgres", databaseURL)
+// NewDatabase creates a new database connection. The URL's scheme selects
+// the dialect.
+func NewDatabase(ctx context.Context, cfg DatabaseConfig) (*Database, error) {
+	dialect, dsn, err := dialectForURL(cfg.URL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
-	// Configure connection pool
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	// Wrap the driver so every query is recorded as a span under the request's trace
+	traced := []otelsql.Option{
+		otelsql.WithAttributes(dialect.systemAttribute()),
+		otelsql.WithSpanNameFormatter(tracing.SQLSpanName),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	}
+
+	db, pool, err := openDB(dialect, dsn, cfg, traced)
+	if err != nil {
+		return nil, err
+	}
 
 	// Test the connection
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := waitForDatabase(ctx, db, cfg.ConnectTimeout); err != nil {
+		db.Close()
+		if pool != nil {
+			pool.Close()
+		}
+		return nil, err
+	}
+
+	log.Info().
+		Str("dialect", dialect.Name()).
+		Int("max_open_conns", cfg.MaxOpenConns).
+		Int("max_idle_conns", cfg.MaxIdleConns).
+		Msg("database connection established")
+
+	database := newDatabase(db, dialect)
+	database.pool = pool
+	database.dsn = dsn
+	database.writeLegacyTags = cfg.WriteLegacyTags
+
+	if cfg.ReplicaURL != "" {
+		if database.replica, err = openReplica(ctx, dialect, cfg, traced); err != nil {
+			database.Close()
+			return nil, err
+		}
+	}
+	return database, nil
+}
+
+// openDB opens the connection pool of a database
+func openDB(dialect Dialect, dsn string, cfg DatabaseConfig, traced []otelsql.Option) (*sql.DB, *pgxpool.Pool, error) {
+	if _, ok := dialect.(postgresDialect); ok {
+		pool, err := newPool(dsn, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		// database/sql borrows a pool connection for each statement or
+		// transaction and returns it at once, leaving the idle ones to pgx
+		db := otelsql.OpenDB(stdlib.GetPoolConnector(pool), traced...)
+		db.SetMaxIdleConns(0)
+		return db, pool, nil
+	}
+
+	db, err := otelsql.Open(dialect.driverName(), dsn, traced...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return db, nil, nil
+}
+
+// newPool configures the pgx pool of a Postgres database. Connections are
+// opened on first use, so it does not wait for the database.
+func newPool(dsn string, cfg DatabaseConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database URL: %w", err)
+	}
+
+	// Times are read and written in UTC whatever the server's time zone
+	poolConfig.ConnConfig.RuntimeParams["timezone"] = "UTC"
+
+	if cfg.MaxOpenConns > 0 {
+		poolConfig.MaxConns = int32(cfg.MaxOpenConns)
+	}
+	poolConfig.MinConns = int32(cfg.MaxIdleConns)
+	if cfg.ConnMaxLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.ConnMaxLifetime
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.ConnMaxIdleTime
+	}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+	}
+	return pool, nil
+}
+
+// waitForDatabase pings until the database answers or timeout passes, so the
+// API can start before Postgres is ready (e.g. in docker-compose)
+func waitForDatabase(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	if err := db.PingContext(ctx); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	backoff := connectInitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		log.Warn().
+			Err(err).
+			Int("attempt", attempt).
+			Dur("retry_in", backoff).
+			Msg("database not reachable yet")
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to ping database after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, connectMaxBackoff)
+	}
+}
+
+func newDatabase(db *sql.DB, dialect Dialect) *Database {
+	return &Database{
+		db:      db,
+		dialect: dialect,
+		instr:   &queryInstrumentation{slowThreshold: DefaultSlowQueryThreshold},
 	}
+}
 
-	log.Info().Msg("database connection established")
+// Dialect returns the dialect of the database's engine
+func (d *Database) Dialect() Dialect {
+	return d.dialect
+}
 
-	return &Database{db: db}, nil
+// Capabilities returns the optional features of the database's engine
+func (d *Database) Capabilities() Capabilities {
+	return d.dialect.Capabilities()
+}
+
+// Instrument configures query timing: statements at or above slowThreshold
+// are logged (0 disables the log) and every statement is reported to
+// observer, which may be nil. It must be called before the database is used.
+func (d *Database) Instrument(slowThreshold time.Duration, observer QueryObserver) {
+	d.instr.slowThreshold = slowThreshold
+	d.instr.observer = observer
 }
 
 // Close closes the database connection
 func (d *Database) Close() error {
+	var err error
 	if d.db != nil {
-		return d.db.Close()
+		err = d.db.Close()
 	}
-	return nil
+	if d.pool != nil {
+		d.pool.Close()
+	}
+	if d.replica != nil {
+		d.replica.close()
+	}
+	return err
 }
 
-// DB returns the underlying sql.DB instance
+// DB returns the underlying sql.DB instance of the primary. Stores should
+// run statements through Query, QueryRow and Exec so they are timed.
 func (d *Database) DB() *sql.DB {
 	return d.db
 }
 
+// ReadDB returns the sql.DB instance of the read replica, or of the primary
+// when no replica is configured or the replica is failing. Its data may lag
+// behind the primary's. Stores should run statements through StaleQuery and
+// StaleQueryRow.
+func (d *Database) ReadDB() *sql.DB {
+	if d.replica != nil && d.replica.usable() {
+		return d.replica.db
+	}
+	return d.db
+}
+
+// Query runs a named statement that returns rows (see Runner)
+func (d *Database) Query(ctx context.Context, name, query string, args ...interface{}) (*sql.Rows, error) {
+	return d.runnerFor(ctx).Query(ctx, name, query, args...)
+}
+
+// QueryRow runs a named statement that returns at most one row (see Runner)
+func (d *Database) QueryRow(ctx context.Context, name, query string, args ...interface{}) *sql.Row {
+	return d.runnerFor(ctx).QueryRow(ctx, name, query, args...)
+}
+
+// Exec runs a named statement that returns no rows (see Runner)
+func (d *Database) Exec(ctx context.Context, name, query string, args ...interface{}) (sql.Result, error) {
+	return d.runnerFor(ctx).Exec(ctx, name, query, args...)
+}
+
+// Tx returns a Runner for statements inside tx
+func (d *Database) Tx(tx *sql.Tx) *Runner {
+	return d.runner(tx)
+}
+
+func (d *Database) runner(c conn) *Runner {
+	return &Runner{conn: c, dialect: d.dialect, instr: d.instr}
+}
+
+// PoolStats reports the connection pool's current state. On Postgres it
+// reads the pgx pool, where WaitCount counts acquisitions that found no idle
+// connection and WaitDurationMs is the time spent acquiring connections.
+func (d *Database) PoolStats() types.DatabasePoolStats {
+	if d.pool != nil {
+		stats := d.pool.Stat()
+		return types.DatabasePoolStats{
+			MaxOpen:        int(stats.MaxConns()),
+			Open:           int(stats.TotalConns()),
+			InUse:          int(stats.AcquiredConns()),
+			Idle:           int(stats.IdleConns()),
+			WaitCount:      stats.EmptyAcquireCount(),
+			WaitDurationMs: stats.AcquireDuration().Milliseconds(),
+		}
+	}
+
+	stats := d.db.Stats()
+	return types.DatabasePoolStats{
+		MaxOpen:        stats.MaxOpenConnections,
+		Open:           stats.OpenConnections,
+		InUse:          stats.InUse,
+		Idle:           stats.Idle,
+		WaitCount:      stats.WaitCount,
+		WaitDurationMs: stats.WaitDuration.Milliseconds(),
+	}
+}
+
 // HealthCheck checks if the database is accessible
 func (d *Database) HealthCheck(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	if err := d.db.PingContext(ctx); err != nil {
+	// On Postgres the pool pings one of its connections directly, and
+	// drops it if the ping finds it broken
+	ping := d.db.PingContext
+	if d.pool != nil {
+		ping = d.pool.Ping
+	}
+	if err := ping(ctx); err != nil {
 		return fmt.Errorf("database health check failed: %w", err)
 	}
 
 	return nil
 }
 
-// Migrate runs database migrations
-func (d *Database) Migrate(ctx context.Context) error {
-	log.Info().Msg("running database migrations")
-
-	// Create projects table
-	createProjectsTable := `
-		CREATE TABLE IF NOT EXISTS projects (
-			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-			title VARCHAR(200) NOT NULL CHECK (char_length(title) > 0),
-			description TEXT,
-			tags JSONB DEFAULT '[]'::jsonb,
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-			published_at TIMESTAMP WITH TIME ZONE
-		);
-	`
-
-	if _, err := d.db.ExecContext(ctx, createProjectsTable); err != nil {
-		return fmt.Errorf("failed to create projects table: %w", err)
-	}
-
-	// Create index on created_at for sorting
-	createProjectsIndex := `
-		CREATE INDEX IF NOT EXISTS idx_projects_created_at 
-		ON projects (created_at DESC);
-	`
-
-	if _, err := d.db.ExecContext(ctx, createProjectsIndex); err != nil {
-		return fmt.Errorf("failed to create projects index: %w", err)
-	}
-
-	// Create updated_at trigger function
-	createUpdatedAtFunction := `
-		CREATE OR REPLACE FUNCTION update_updated_at_column()
-		RETURNS TRIGGER AS $$
-		BEGIN
-			NEW.updated_at = NOW();
-			RETURN NEW;
-		END;
-		$$ language 'plpgsql';
-	`
-
-	if _, err := d.db.ExecContext(ctx, createUpdatedAtFunction); err != nil {
-		return fmt.Errorf("failed to create updated_at function: %w", err)
-	}
-
-	// Create trigger for projects
-	createProjectsUpdatedAtTrigger := `
-		DROP TRIGGER IF EXISTS update_projects_updated_at ON projects;
-		CREATE TRIGGER update_projects_updated_at
-			BEFORE UPDATE ON projects
-			FOR EACH ROW
-			EXECUTE FUNCTION update_updated_at_column();
-	`
-
-	if _, err := d.db.ExecContext(ctx, createProjectsUpdatedAtTrigger); err != nil {
-		return fmt.Errorf("failed to create projects updated_at trigger: %w", err)
-	}
-
-	// Create items table
-	createItemsTable := `
-		CREATE TABLE IF NOT EXISTS items (
-			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
-			type VARCHAR(50) NOT NULL CHECK (type IN ('title', 'media', 'choice', 'multi_choice', 'text_entry', 'ordering', 'hotspot')),
-			title VARCHAR(500) NOT NULL CHECK (char_length(title) > 0),
-			content JSONB DEFAULT '{}'::jsonb,
-			position INTEGER NOT NULL CHECK (position >= 0),
-			required BOOLEAN DEFAULT false,
-			points INTEGER CHECK (points IS NULL OR (points >= 0 AND points <= 1000)),
-			explanation TEXT,
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-			UNIQUE(project_id, position)
-		);
-	`
-
-	if _, err := d.db.ExecContext(ctx, createItemsTable); err != nil {
-		return fmt.Errorf("failed to create items table: %w", err)
-	}
-
-	// Create indexes for items
-	createItemsIndexes := `
-		CREATE INDEX IF NOT EXISTS idx_items_project_position 
-		ON items (project_id, position ASC);
-		
-		CREATE INDEX IF NOT EXISTS idx_items_created_at 
-		ON items (created_at DESC);
-	`
-
-	if _, err := d.db.ExecContext(ctx, createItemsIndexes); err != nil {
-		return fmt.Errorf("failed to create items indexes: %w", err)
-	}
-
-	// Create trigger for items
-	createItemsUpdatedAtTrigger := `
-		DROP TRIGGER IF EXISTS update_items_updated_at ON items;
-		CREATE TRIGGER update_items_updated_at
-			BEFORE UPDATE ON items
-			FOR EACH ROW
-			EXECUTE FUNCTION update_updated_at_column();
-	`
-
-	if _, err := d.db.ExecContext(ctx, createItemsUpdatedAtTrigger); err != nil {
-		return fmt.Errorf("failed to create items updated_at trigger: %w", err)
-	}
-
-	log.Info().Msg("database migrations completed successfully")
-	return nil
+// Transaction runs fn in a database transaction. If the database aborts it
+// because of a concurrent transaction (e.g. serialization failure or deadlock),
+// the whole transaction, fn included, is run again, so fn must not have side
+// effects outside tx; once it has been aborted on every attempt, the error
+// wraps core.ErrConcurrentModification. When ctx is already in a
+// transaction (see InTx), fn joins it.
+func (d *Database) Transaction(ctx context.Context, name string, fn func(tx *Runner) error) error {
+	if tx, ok := txFromContext(ctx); ok {
+		return fn(tx)
+	}
+	err := d.retry(ctx, name, maxTxAttempts, d.dialect.TxConflict, func() error {
+		return d.transactionOnce(ctx, fn)
+	})
+	if d.dialect.TxConflict(err) {
+		return fmt.Errorf("%w: %w", core.ErrConcurrentModification, err)
+	}
+	return err
 }
 
-// Transaction executes a function within a database transaction
-func (d *Database) Transaction(ctx context.Context, fn func(*sql.Tx) error) error {
-	tx, err := d.db.BeginTx(ctx, nil)
+func (d *Database) transactionOnce(ctx context.Context, fn func(tx *Runner) error) error {
+	// The transaction keeps its connection, so batches can join it
+	session, err := d.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection for transaction: %w", err)
+	}
+	defer session.Close()
+
+	tx, err := session.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	runner := d.Tx(tx)
+	runner.session = session
 
 	defer func() {
 		if p := recover(); p != nil {
@@ -191,9 +371,9 @@ func (d *Database) Transaction(ctx context.Context, fn func(*sql.Tx) error) erro
 		}
 	}()
 
-	if err := fn(tx); err != nil {
+	if err := fn(runner); err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
-			log.Error().Err(rbErr).Msg("failed to rollback transaction")
+			log.Ctx(ctx).Error().Err(rbErr).Msg("failed to rollback transaction")
 		}
 		return err
 	}
@@ -203,4 +383,4 @@ func (d *Database) Transaction(ctx context.Context, fn func(*sql.Tx) error) erro
 	}
 
 	return nil
-}
\ No newline at end of file
+}
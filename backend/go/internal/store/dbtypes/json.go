@@ -0,0 +1,39 @@
+// Package dbtypes holds the column types sqlc maps onto in the generated
+// store queries (see sqlc.yaml), where the driver's own types fall short.
+package dbtypes
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// JSON is a JSON column. Unlike json.RawMessage it scans NULL as nil and
+// the text SQLite returns for JSON, and it is written as text, which every
+// engine parses, where SQLite would take bytes for its binary JSON format.
+type JSON json.RawMessage
+
+// Scan implements sql.Scanner
+func (j *JSON) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		// The driver reuses its buffer once the next row is read
+		*j = bytes.Clone(v)
+	case string:
+		*j = JSON(v)
+	default:
+		return fmt.Errorf("cannot scan %T into JSON", src)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer
+func (j JSON) Value() (driver.Value, error) {
+	if j == nil {
+		return nil, nil
+	}
+	return string(j), nil
+}
@@ -0,0 +1,74 @@
+package dbtypes
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSON_Scan(t *testing.T) {
+	tests := []struct {
+		name        string
+		src         interface{}
+		expected    JSON
+		expectError bool
+	}{
+		{"bytes", []byte(`{"a":1}`), JSON(`{"a":1}`), false},
+		{"text", `{"a":1}`, JSON(`{"a":1}`), false},
+		{"null", nil, nil, false},
+		{"number", int64(1), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			var j JSON
+			err := j.Scan(tt.src)
+
+			// Assert
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, j)
+		})
+	}
+}
+
+func TestJSON_Scan_CopiesTheDriversBuffer(t *testing.T) {
+	// Arrange
+	buf := []byte(`{"a":1}`)
+	var j JSON
+
+	// Act
+	require.NoError(t, j.Scan(buf))
+	copy(buf, `{"b":2}`)
+
+	// Assert
+	assert.Equal(t, JSON(`{"a":1}`), j)
+}
+
+func TestJSON_Value(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     JSON
+		expected driver.Value
+	}{
+		{"text", JSON(`{"a":1}`), `{"a":1}`},
+		{"null", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			value, err := tt.json.Value()
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
@@ -0,0 +1,451 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// Dialect covers what differs between the database engines the stores run
+// on. Stores write their statements for Postgres, with $n placeholders, and
+// take the few engine-specific fragments from the dialect; the Runner
+// rewrites placeholders and arguments for the driver. Array columns are
+// bound as []string and scanned with scanStrings.
+type Dialect interface {
+	// Name identifies the engine in logs and metrics
+	Name() string
+	// Capabilities lists the optional features the engine supports
+	Capabilities() Capabilities
+
+	// Now is the expression for the current time
+	Now() string
+	// Timestamp is the column type of a point in time
+	Timestamp() string
+	// Cast converts expr to sqlType where the engine needs it to type a
+	// placeholder
+	Cast(expr, sqlType string) string
+	// ILike matches expr against a LIKE pattern escaped with backslashes,
+	// ignoring case
+	ILike(expr, pattern string) string
+	// ContainsAll matches rows whose array column holds every value,
+	// binding its argument with bind
+	ContainsAll(column string, values []string, bind func(interface{}) string) string
+	// TableExists is a boolean query for whether the table named by its
+	// first argument exists
+	TableExists() string
+	// ForUpdate is the clause ending a SELECT that locks the rows it reads
+	// until the transaction ends
+	ForUpdate() string
+	// JSONText is the expression for the text of a field of a JSON column,
+	// NULL if the document has no such field
+	JSONText(column, field string) string
+	// JSONElements is a FROM clause item named alias: a table of the
+	// elements of an array field of a JSON column, as text in a column named
+	// value, with no rows if the document has no such field
+	JSONElements(column, field, alias string) string
+	// Day is the expression for the UTC calendar day of a timestamp, as
+	// YYYY-MM-DD text
+	Day(expr string) string
+	// Seconds is the expression for the seconds from one timestamp to
+	// another, NULL if either is
+	Seconds(from, to string) string
+	// Floor is the expression for the largest integer not above a number
+	Floor(expr string) string
+
+	// Violation classifies err if a constraint rejected the statement
+	Violation(err error) (Violation, bool)
+	// Transient reports whether the engine failed a statement for a reason
+	// a retry may get past
+	Transient(err error) bool
+	// TxConflict reports whether the engine aborted a transaction because
+	// of a concurrent one, so it can be run again as a whole
+	TxConflict(err error) bool
+
+	driverName() string
+	systemAttribute() attribute.KeyValue
+	rebind(query string, args []interface{}) (string, []interface{})
+	// scanStrings returns a Scan destination storing an array column in
+	// values, NULL as an empty slice
+	scanStrings(values *[]string) sql.Scanner
+	migrationsDir() string
+}
+
+// Capabilities are the optional features of an engine. Stores fall back to
+// simpler behavior without them; see each field.
+type Capabilities struct {
+	// FullTextSearch ranks item searches with a text search index. Without
+	// it searches match substrings, title matches first.
+	FullTextSearch bool
+	// AdvisoryLocks take locks shared by every replica. Without them locks
+	// only exclude other holders in the same process, which is enough for
+	// the single replica such an engine supports.
+	AdvisoryLocks bool
+	// DeferredConstraints check a unique constraint at the end of a
+	// statement. Without them items are moved to free positions before
+	// their new ones, so reordering takes two statements.
+	DeferredConstraints bool
+	// Batches send many statements in one round trip. Without them bulk
+	// inserts run one statement at a time.
+	Batches bool
+	// Notifications announce committed changes to every replica (see
+	// ChangeListener). Without them nothing is announced, and the single
+	// replica such an engine supports has nothing to hear.
+	Notifications bool
+	// Copy loads many rows into a table with COPY FROM in one round trip.
+	// Without it large imports take the bulk insert path.
+	Copy bool
+	// WritableCTEs run an UPDATE in a WITH clause, so one statement can
+	// change a row and report why it left another alone. Without them such
+	// a change takes two statements in a transaction.
+	WritableCTEs bool
+	// Percentiles aggregate a median with percentile_cont. Without them it
+	// is the mean of the middle rows numbered by a window function.
+	Percentiles bool
+}
+
+// ViolationKind is the kind of constraint a statement violated
+type ViolationKind int
+
+const (
+	UniqueViolation ViolationKind = iota + 1
+	CheckViolation
+	ForeignKeyViolation
+)
+
+// Violation is a constraint violation. Constraint is the constraint's name,
+// when the engine reports it.
+type Violation struct {
+	Kind       ViolationKind
+	Constraint string
+}
+
+// Is reports whether v is a violation of the named constraint
+func (v Violation) Is(kind ViolationKind, constraint string) bool {
+	return v.Kind == kind && v.Constraint == constraint
+}
+
+// Postgres error codes (SQLSTATE)
+const (
+	pgUniqueViolation      = "23505"
+	pgCheckViolation       = "23514"
+	pgForeignKeyViolation  = "23503"
+	pgExclusionViolation   = "23P01"
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+	pgAdminShutdown        = "57P01"
+	pgCannotConnectNow     = "57P03"
+)
+
+// dialectForURL picks the dialect from the scheme of a database URL and
+// returns the driver's data source name. sqlite:// URLs name a database
+// file; anything else is passed to Postgres.
+func dialectForURL(url string) (Dialect, string, error) {
+	path, ok := strings.CutPrefix(url, "sqlite://")
+	if !ok {
+		return postgresDialect{}, url, nil
+	}
+	if path == "" || strings.HasPrefix(path, "?") {
+		return nil, "", errors.New("sqlite database URL has no file path")
+	}
+	return sqliteDialect{}, sqliteDSN(path), nil
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) Capabilities() Capabilities {
+	return Capabilities{FullTextSearch: true, AdvisoryLocks: true, DeferredConstraints: true, Batches: true, Notifications: true, Copy: true, WritableCTEs: true, Percentiles: true}
+}
+
+func (postgresDialect) Now() string { return "NOW()" }
+
+func (postgresDialect) Timestamp() string { return "TIMESTAMP WITH TIME ZONE" }
+
+func (postgresDialect) Cast(expr, sqlType string) string { return expr + "::" + sqlType }
+
+func (postgresDialect) ILike(expr, pattern string) string { return expr + " ILIKE " + pattern }
+
+// ContainsAll binds values as a slice, which pgx encodes as a text array
+func (postgresDialect) ContainsAll(column string, values []string, bind func(interface{}) string) string {
+	return column + " @> " + bind(values) + "::text[]"
+}
+
+func (postgresDialect) TableExists() string { return `SELECT to_regclass($1) IS NOT NULL` }
+
+func (postgresDialect) ForUpdate() string { return " FOR UPDATE" }
+
+func (postgresDialect) JSONText(column, field string) string {
+	return column + "->>'" + field + "'"
+}
+
+func (postgresDialect) JSONElements(column, field, alias string) string {
+	return "jsonb_array_elements_text(" + column + "->'" + field + "') AS " + alias + "(value)"
+}
+
+func (postgresDialect) Day(expr string) string {
+	return "to_char(date_trunc('day', " + expr + " AT TIME ZONE 'UTC'), 'YYYY-MM-DD')"
+}
+
+func (postgresDialect) Seconds(from, to string) string {
+	return "EXTRACT(EPOCH FROM " + to + " - " + from + ")"
+}
+
+func (postgresDialect) Floor(expr string) string { return "CAST(FLOOR(" + expr + ") AS INTEGER)" }
+
+func (postgresDialect) Violation(err error) (Violation, bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return Violation{}, false
+	}
+	// Unique keys that ignore deleted rows yet stay deferrable are
+	// exclusion constraints on equality (see migration 0008)
+	kinds := map[string]ViolationKind{
+		pgUniqueViolation:     UniqueViolation,
+		pgExclusionViolation:  UniqueViolation,
+		pgCheckViolation:      CheckViolation,
+		pgForeignKeyViolation: ForeignKeyViolation,
+	}
+	kind, ok := kinds[pgErr.Code]
+	return Violation{Kind: kind, Constraint: pgErr.ConstraintName}, ok
+}
+
+// Transient also covers failures pgx knows happened before the statement
+// reached the server, such as a connection lost while acquiring it
+func (postgresDialect) Transient(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return pgconn.SafeToRetry(err)
+	}
+	switch pgErr.Code {
+	case pgSerializationFailure, pgDeadlockDetected, pgAdminShutdown, pgCannotConnectNow:
+		return true
+	}
+	return false
+}
+
+func (postgresDialect) TxConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected
+}
+
+func (postgresDialect) driverName() string { return "pgx" }
+
+func (postgresDialect) systemAttribute() attribute.KeyValue { return semconv.DBSystemPostgreSQL }
+
+func (postgresDialect) rebind(query string, args []interface{}) (string, []interface{}) {
+	return query, args
+}
+
+// pgTypeMaps hold the type maps that decode arrays in the text format the
+// driver returns them in. A map caches its scan plans unguarded, so each
+// is used by one scan at a time.
+var pgTypeMaps = sync.Pool{New: func() interface{} { return pgtype.NewMap() }}
+
+func (postgresDialect) scanStrings(values *[]string) sql.Scanner {
+	return postgresStrings{values: values}
+}
+
+// postgresStrings scans a text array into values
+type postgresStrings struct {
+	values *[]string
+}
+
+func (s postgresStrings) Scan(src interface{}) error {
+	if src == nil {
+		*s.values = []string{}
+		return nil
+	}
+	typeMap := pgTypeMaps.Get().(*pgtype.Map)
+	defer pgTypeMaps.Put(typeMap)
+	return typeMap.SQLScanner(s.values).Scan(src)
+}
+
+func (postgresDialect) migrationsDir() string { return "migrations" }
+
+// sqliteDialect runs the stores on a single SQLite file, for tests and
+// small single-replica deployments. It needs a binary built with cgo.
+type sqliteDialect struct{}
+
+// sqliteTimeFormat is how times are stored: UTC with milliseconds, the
+// precision of Now, in fixed width, so stored times compare as text
+const sqliteTimeFormat = "2006-01-02 15:04:05.000-07:00"
+
+// sqliteUniqueConstraints names the unique constraints, which SQLite
+// reports by their columns
+var sqliteUniqueConstraints = map[string]string{
+	"items.project_id, items.position": itemPositionConstraint,
+}
+
+// sqlitePlaceholder matches the $n placeholders of a statement
+var sqlitePlaceholder = regexp.MustCompile(`\$(\d+)`)
+
+// sqliteDSN enables foreign keys, which SQLite leaves off, waits for the
+// write lock rather than failing at once, takes it when a transaction
+// begins, so two transactions never deadlock upgrading their read locks,
+// and reads times in UTC
+func sqliteDSN(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return "file:" + path + separator + "_foreign_keys=1&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate&_loc=UTC"
+}
+
+func (sqliteDialect) Name() string { return "sqlite" }
+
+func (sqliteDialect) Capabilities() Capabilities { return Capabilities{} }
+
+func (sqliteDialect) Now() string { return `strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')` }
+
+// Timestamp is the declared type the driver scans into time.Time
+func (sqliteDialect) Timestamp() string { return "TIMESTAMP" }
+
+func (sqliteDialect) Cast(expr, sqlType string) string { return expr }
+
+// ILike relies on LIKE ignoring case, which SQLite does for ASCII letters
+func (sqliteDialect) ILike(expr, pattern string) string {
+	return expr + " LIKE " + pattern + ` ESCAPE '\'`
+}
+
+func (sqliteDialect) ContainsAll(column string, values []string, bind func(interface{}) string) string {
+	wanted := bind(values)
+	// json_each yields a null column as one NULL value, which NOT IN cannot
+	// rule out, so only the array's strings are compared
+	return "NOT EXISTS (SELECT 1 FROM json_each(" + wanted + ") AS wanted WHERE wanted.value NOT IN (SELECT value FROM json_each(" + column + ") WHERE type = 'text'))"
+}
+
+func (sqliteDialect) TableExists() string {
+	return `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = $1)`
+}
+
+// ForUpdate is empty: transactions take the database's write lock when they
+// begin (see _txlock), so no other writer can change what they read
+func (sqliteDialect) ForUpdate() string { return "" }
+
+func (sqliteDialect) JSONText(column, field string) string {
+	return "json_extract(" + column + ", '$." + field + "')"
+}
+
+func (sqliteDialect) JSONElements(column, field, alias string) string {
+	return "json_each(" + column + ", '$." + field + "') AS " + alias
+}
+
+// Day relies on date() reading the offset timestamps are stored with
+func (sqliteDialect) Day(expr string) string { return "date(" + expr + ")" }
+
+func (sqliteDialect) Seconds(from, to string) string {
+	return "(julianday(" + to + ") - julianday(" + from + ")) * 86400.0"
+}
+
+// Floor rounds towards zero and steps below negative fractions, since
+// SQLite is built without its math functions
+func (sqliteDialect) Floor(expr string) string {
+	return "(CAST(" + expr + " AS INTEGER) - (" + expr + " < CAST(" + expr + " AS INTEGER)))"
+}
+
+func (sqliteDialect) Violation(err error) (Violation, bool) {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.Code != sqlite3.ErrConstraint {
+		return Violation{}, false
+	}
+	// Messages read e.g. "CHECK constraint failed: projects_title_check"
+	_, subject, _ := strings.Cut(sqliteErr.Error(), "failed: ")
+	switch sqliteErr.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		return Violation{Kind: UniqueViolation, Constraint: sqliteUniqueConstraints[subject]}, true
+	case sqlite3.ErrConstraintCheck:
+		return Violation{Kind: CheckViolation, Constraint: subject}, true
+	case sqlite3.ErrConstraintForeignKey:
+		return Violation{Kind: ForeignKeyViolation}, true
+	}
+	return Violation{}, false
+}
+
+// Transient treats a database still locked after the busy timeout as
+// transient; SQLite has no other failures worth retrying
+func (sqliteDialect) Transient(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
+
+func (d sqliteDialect) TxConflict(err error) bool { return d.Transient(err) }
+
+func (sqliteDialect) driverName() string { return "sqlite3" }
+
+func (sqliteDialect) systemAttribute() attribute.KeyValue { return semconv.DBSystemSqlite }
+
+// rebind numbers placeholders ?n, which SQLite binds by position like $n,
+// stores times in UTC and arrays as JSON text. CURRENT_TIMESTAMP, which the generated queries
+// use, becomes Now, as SQLite's has neither milliseconds nor a time zone.
+func (d sqliteDialect) rebind(query string, args []interface{}) (string, []interface{}) {
+	bound := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch t := arg.(type) {
+		case time.Time:
+			bound[i] = t.UTC().Format(sqliteTimeFormat)
+		case *time.Time:
+			if t != nil {
+				bound[i] = t.UTC().Format(sqliteTimeFormat)
+			}
+		case []string:
+			if t == nil {
+				t = []string{}
+			}
+			// Marshalling strings cannot fail
+			valuesJSON, _ := json.Marshal(t)
+			bound[i] = string(valuesJSON)
+		default:
+			bound[i] = arg
+		}
+	}
+	query = strings.ReplaceAll(query, "CURRENT_TIMESTAMP", d.Now())
+	return sqlitePlaceholder.ReplaceAllString(query, "?$1"), bound
+}
+
+func (sqliteDialect) scanStrings(values *[]string) sql.Scanner {
+	return sqliteStrings{values: values}
+}
+
+// sqliteStrings scans an array stored as JSON text into values
+type sqliteStrings struct {
+	values *[]string
+}
+
+func (s sqliteStrings) Scan(src interface{}) error {
+	var scanned []string
+	switch v := src.(type) {
+	case nil:
+	case string:
+		if err := json.Unmarshal([]byte(v), &scanned); err != nil {
+			return fmt.Errorf("cannot scan %q into a string array: %w", v, err)
+		}
+	case []byte:
+		if err := json.Unmarshal(v, &scanned); err != nil {
+			return fmt.Errorf("cannot scan %q into a string array: %w", v, err)
+		}
+	default:
+		return fmt.Errorf("cannot scan %T into a string array", src)
+	}
+	if scanned == nil {
+		scanned = []string{}
+	}
+	*s.values = scanned
+	return nil
+}
+
+func (sqliteDialect) migrationsDir() string { return "migrations/sqlite" }
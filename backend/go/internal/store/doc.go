@@ -0,0 +1,77 @@
+// Package store implements the core store interfaces on PostgreSQL.
+//
+// Stores run every statement through their Database (Query, QueryRow, Exec,
+// and the retrying ReadQuery and ReadQueryRow), naming it for logs and
+// metrics, and restrict it to the organization in the context with scoped.
+//
+// # Generated queries
+//
+// Item queries are written in queries/*.sql and compiled by sqlc into the
+// typed Go of package dbgen (see sqlc.yaml at the module root). Stores call
+// them through Database.read and Database.write, which run them like any
+// other named statement, and convert the generated rows to core types. After
+// editing a query or adding a migration, run `make sqlc` in backend/go and
+// check in the result; TestGeneratedQueries_AreCurrent fails while the
+// generated code is stale.
+//
+// # Transactions
+//
+// Service operations that make several store calls atomically, e.g. a check
+// followed by an insert or a batch of inserts, run them inside a
+// core.Transactor, which *Database implements:
+//
+//	err := s.tx.InTx(ctx, "items.create_many", func(ctx context.Context) error {
+//		// every store call made with this ctx joins the transaction
+//	})
+//
+// The transaction travels in the context, like the organization scope, so
+// store methods need no transaction-bound variants: Database's statement
+// methods run on the context's transaction when there is one. Inside a
+// transaction reads are not retried, since a failed statement aborts it, and
+// a nested InTx or Transaction joins the enclosing transaction instead of
+// opening another. The whole transaction is retried on serialization
+// failures and deadlocks, so fn must not have side effects outside the
+// database, and its statements must run one at a time, closing rows before
+// the next statement.
+//
+// New features should use InTx rather than passing *sql.Tx or *Runner
+// between stores. Transaction remains for a single store method that must
+// undo its own statements, such as SettingsStore.Update. Store methods whose
+// checks are deferred to the commit, such as ItemStore.UpdatePositions,
+// instead require the caller's transaction and return core.ErrNoTransaction
+// outside one, so the caller can check the final state before committing.
+//
+// # Read replica
+//
+// With DatabaseConfig.ReplicaURL set, reads that tolerate lag, like project
+// listings, item search and aggregations, run through StaleQuery,
+// StaleQueryRow or Database.readStale, which use the replica. Everything
+// else reads the primary, notably lookups by ID that follow a write in the
+// same request. A transaction reads the primary, and so does a context from
+// core.WithFreshReads, for a check that must see rows just written. A
+// replica failing a statement or a health check sends reads to the primary
+// until it answers again.
+//
+// # Change notifications
+//
+// A write that other replicas may have cached, such as a project update or
+// a settings change, is announced with notify in the same transaction, so
+// Postgres delivers it on ChangesChannel only once it commits. Each replica
+// runs a ChangeListener that hands the changes to its caches.
+//
+// # Soft delete
+//
+// Deleting a project or an item stamps its deleted_at and keeps the row.
+// Every query of those tables leaves deleted rows out through notDeleted,
+// which the stores' scoped conditions include; only a project list asking
+// for core.ListOptions.IncludeDeleted sees them. Unique indexes cover live
+// rows only, so a deleted item's position can be taken again.
+//
+// # Access scope
+//
+// Project and item queries are scoped to the organization in ctx and, for a
+// user's core.AccessScope, to organizations the user is a member of, so a
+// route that forgot its check still reads and writes nothing foreign. The
+// authentication middleware sets the scope of requests; background jobs
+// act for the system through SystemScope.
+package store
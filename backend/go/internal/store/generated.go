@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/provemyself/backend/internal/store/dbgen"
+)
+
+// namedStatements runs the queries sqlc generates (package dbgen) through a
+// Database's statement methods, under the name a store gives the call, so
+// they are timed, rebound for the dialect and run on the context's
+// transaction like hand-written statements. Reads are retried as with
+// ReadQuery; stale reads may go to the replica, as with StaleQuery.
+type namedStatements struct {
+	db    *Database
+	name  string
+	read  bool
+	stale bool
+}
+
+// read returns the generated queries for a read-only statement named name
+func (d *Database) read(name string) *dbgen.Queries {
+	return dbgen.New(namedStatements{db: d, name: name, read: true})
+}
+
+// write returns the generated queries for a statement named name that
+// changes data
+func (d *Database) write(name string) *dbgen.Queries {
+	return dbgen.New(namedStatements{db: d, name: name})
+}
+
+func (s namedStatements) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.db.Exec(ctx, s.name, query, args...)
+}
+
+// PrepareContext is unsupported: the dialect rebinds every statement as it
+// runs, and sqlc only prepares statements when emit_prepared_queries is set
+func (s namedStatements) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("store: generated queries are not prepared")
+}
+
+func (s namedStatements) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if s.stale {
+		return s.db.StaleQuery(ctx, s.name, query, args...)
+	}
+	if s.read {
+		return s.db.ReadQuery(ctx, s.name, query, args...)
+	}
+	return s.db.Query(ctx, s.name, query, args...)
+}
+
+func (s namedStatements) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if s.stale {
+		return s.db.StaleQueryRow(ctx, s.name, query, args...)
+	}
+	if s.read {
+		return s.db.ReadQueryRow(ctx, s.name, query, args...)
+	}
+	return s.db.QueryRow(ctx, s.name, query, args...)
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/store/dbgen"
+)
+
+// TestGeneratedQueries_AreCurrent fails if sqlc would generate other code
+// from the queries and migrations than the committed dbgen package. It
+// needs the sqlc CLI, found through the SQLC environment variable or PATH;
+// `make sqlc-check` installs it.
+func TestGeneratedQueries_AreCurrent(t *testing.T) {
+	sqlc := os.Getenv("SQLC")
+	if sqlc == "" {
+		var err error
+		if sqlc, err = exec.LookPath("sqlc"); err != nil {
+			t.Skip("sqlc not installed; run `make sqlc-check`")
+		}
+	}
+
+	// Act
+	cmd := exec.Command(sqlc, "diff")
+	cmd.Dir = filepath.Join("..", "..")
+	out, err := cmd.CombinedOutput()
+
+	// Assert
+	assert.NoError(t, err, "the generated queries are stale; run `make sqlc`:\n%s", out)
+}
+
+func TestNamedStatements(t *testing.T) {
+	tests := []struct {
+		name               string
+		read               bool
+		expectedStatements int
+	}{
+		{"reads are retried", true, 2},
+		{"writes are not", false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			database := newStubDatabase(t, 0, nil)
+			observer := &recordingObserver{}
+			database.Instrument(0, observer)
+			stub.inject(&pgconn.PgError{Code: "40001"})
+			queries := database.write("items.count_by_project")
+			if tt.read {
+				queries = database.read("items.count_by_project")
+			}
+			stub.value = int64(3)
+
+			// Act
+			count, err := queries.CountItemsByProject(context.Background(), dbgen.CountItemsByProjectParams{ProjectID: "project-1"})
+
+			// Assert
+			assert.Equal(t, tt.expectedStatements, stub.statements)
+			for _, name := range observer.names {
+				assert.Equal(t, "items.count_by_project", name)
+			}
+			if !tt.read {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), count)
+			_, args := stub.last()
+			assert.Equal(t, []interface{}{"project-1", nil, nil}, args)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store/dbtypes"
+)
+
+// IdempotencyStore implements idempotency record persistence on the
+// database's dialect
+type IdempotencyStore struct {
+	db *Database
+}
+
+// NewIdempotencyStore creates a new idempotency store
+func NewIdempotencyStore(db *Database) *IdempotencyStore {
+	return &IdempotencyStore{db: db}
+}
+
+// Get retrieves the unexpired record for a key and endpoint
+func (s *IdempotencyStore) Get(ctx context.Context, key, endpoint string) (*core.IdempotencyRecord, error) {
+	query := `
+		SELECT key, endpoint, request_hash, status_code, response_headers, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE key = $1 AND endpoint = $2 AND expires_at > ` + s.db.dialect.Now() + `
+	`
+
+	var record core.IdempotencyRecord
+	var header dbtypes.JSON
+	err := s.db.ReadQueryRow(ctx, "idempotency.get", query, key, endpoint).Scan(
+		&record.Key,
+		&record.Endpoint,
+		&record.RequestHash,
+		&record.StatusCode,
+		&header,
+		&record.Body,
+		scanUTC(&record.CreatedAt),
+		scanUTC(&record.ExpiresAt),
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, core.ErrIdempotencyKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+
+	if header != nil {
+		if err := json.Unmarshal(header, &record.Header); err != nil {
+			return nil, fmt.Errorf("failed to decode idempotency response headers: %w", err)
+		}
+	}
+
+	return &record, nil
+}
+
+// Reserve stores a pending record, replacing an expired one for the same key and endpoint
+func (s *IdempotencyStore) Reserve(ctx context.Context, record *core.IdempotencyRecord) error {
+	query := `
+		INSERT INTO idempotency_keys (key, endpoint, request_hash, status_code, expires_at)
+		VALUES ($1, $2, $3, 0, $4)
+		ON CONFLICT (key, endpoint) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			status_code = 0,
+			response_headers = NULL,
+			response_body = NULL,
+			created_at = ` + s.db.dialect.Now() + `,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= ` + s.db.dialect.Now() + `
+	`
+
+	result, err := s.db.Exec(ctx, "idempotency.reserve", query,
+		record.Key, record.Endpoint, record.RequestHash, record.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return core.ErrIdempotencyKeyExists
+	}
+
+	return nil
+}
+
+// Complete stores the response of a reserved record
+func (s *IdempotencyStore) Complete(ctx context.Context, record *core.IdempotencyRecord) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotency response headers: %w", err)
+	}
+
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $3, response_headers = $4, response_body = $5, expires_at = $6
+		WHERE key = $1 AND endpoint = $2 AND status_code = 0
+	`
+
+	result, err := s.db.Exec(ctx, "idempotency.complete", query,
+		record.Key, record.Endpoint, record.StatusCode, dbtypes.JSON(header), record.Body, record.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotency record: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return core.ErrIdempotencyKeyNotFound
+	}
+
+	return nil
+}
+
+// Release deletes a pending record
+func (s *IdempotencyStore) Release(ctx context.Context, key, endpoint string) error {
+	query := `DELETE FROM idempotency_keys WHERE key = $1 AND endpoint = $2 AND status_code = 0`
+
+	if _, err := s.db.Exec(ctx, "idempotency.release", query, key, endpoint); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes records that expired before the given time
+func (s *IdempotencyStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM idempotency_keys WHERE expires_at <= $1`
+
+	result, err := s.db.Exec(ctx, "idempotency.delete_expired", query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency records: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,304 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+const (
+	// integritySampleSize is the number of orphan IDs a check reports
+	integritySampleSize = 5
+
+	// projectAssetsPrefix is where the storage service keeps project files,
+	// as projects/{projectID}/assets/...
+	projectAssetsPrefix = "projects/"
+
+	// projectsLookupChunk is the number of project IDs looked up at once
+	projectsLookupChunk = 500
+)
+
+// IntegrityCheck is a referential check of one table: Query selects the id
+// of every row of Table whose parent row is gone.
+type IntegrityCheck struct {
+	Name        string
+	Table       string
+	Description string
+	Query       string
+}
+
+// integrityChecks covers every table referencing projects or attempts. A
+// migration adding such a table must add its check here, which
+// TestIntegrityChecks_CoverEveryChildTable enforces. Queries include
+// soft-deleted rows: a deleted row still needs its parent.
+var integrityChecks = []IntegrityCheck{
+	{
+		Name:        "items.project_id",
+		Table:       "items",
+		Description: "items whose project row is gone",
+		Query: `
+			SELECT items.id AS id
+			FROM items
+			LEFT JOIN projects ON projects.id = items.project_id
+			WHERE projects.id IS NULL
+		`,
+	},
+	{
+		Name:        "collab_docs.project_id",
+		Table:       "collab_docs",
+		Description: "collaboration documents whose project row is gone",
+		Query: `
+			SELECT collab_docs.project_id AS id
+			FROM collab_docs
+			LEFT JOIN projects ON projects.id = collab_docs.project_id
+			WHERE projects.id IS NULL
+		`,
+	},
+	{
+		Name:        "item_locks.project_id",
+		Table:       "item_locks",
+		Description: "item locks whose project row is gone",
+		Query: `
+			SELECT item_locks.item_id AS id
+			FROM item_locks
+			LEFT JOIN projects ON projects.id = item_locks.project_id
+			WHERE projects.id IS NULL
+		`,
+	},
+	{
+		Name:        "lti_resource_links.project_id",
+		Table:       "lti_resource_links",
+		Description: "LTI resource links whose project row is gone",
+		Query: `
+			SELECT lti_resource_links.resource_link_id AS id
+			FROM lti_resource_links
+			LEFT JOIN projects ON projects.id = lti_resource_links.project_id
+			WHERE projects.id IS NULL
+		`,
+	},
+	{
+		Name:        "lti_sessions.project_id",
+		Table:       "lti_sessions",
+		Description: "LTI sessions whose project row is gone",
+		Query: `
+			SELECT lti_sessions.id AS id
+			FROM lti_sessions
+			LEFT JOIN projects ON projects.id = lti_sessions.project_id
+			WHERE projects.id IS NULL
+		`,
+	},
+	{
+		Name:        "publications.project_id",
+		Table:       "publications",
+		Description: "publications whose project row is gone",
+		Query: `
+			SELECT publications.project_id AS id
+			FROM publications
+			LEFT JOIN projects ON projects.id = publications.project_id
+			WHERE projects.id IS NULL
+		`,
+	},
+	{
+		Name:        "attempts.project_id",
+		Table:       "attempts",
+		Description: "attempts whose project row is gone",
+		Query: `
+			SELECT attempts.id AS id
+			FROM attempts
+			LEFT JOIN projects ON projects.id = attempts.project_id
+			WHERE projects.id IS NULL
+		`,
+	},
+	{
+		Name:        "answers.attempt_id",
+		Table:       "answers",
+		Description: "answers whose attempt row is gone",
+		Query: `
+			SELECT answers.attempt_id AS id
+			FROM answers
+			LEFT JOIN attempts ON attempts.id = answers.attempt_id
+			WHERE attempts.id IS NULL
+		`,
+	},
+	{
+		Name:        "attempt_results.attempt_id",
+		Table:       "attempt_results",
+		Description: "attempt results whose attempt row is gone",
+		Query: `
+			SELECT attempt_results.attempt_id AS id
+			FROM attempt_results
+			LEFT JOIN attempts ON attempts.id = attempt_results.attempt_id
+			WHERE attempts.id IS NULL
+		`,
+	},
+}
+
+// projectsWithIDs selects the projects among a list of IDs, deleted ones
+// included: a soft-deleted project keeps its files
+const projectsWithIDs = `SELECT id FROM projects WHERE `
+
+// IntegrityStore runs the referential checks against the database and, when
+// it has one, the project files in storage. It implements
+// core.IntegrityChecker.
+type IntegrityStore struct {
+	db      *Database
+	storage core.Storage
+	checks  []IntegrityCheck
+}
+
+// NewIntegrityStore creates an integrity store. storage may be nil, in
+// which case stored files are not checked.
+func NewIntegrityStore(db *Database, storage core.Storage) *IntegrityStore {
+	return &IntegrityStore{db: db, storage: storage, checks: integrityChecks}
+}
+
+// CheckIntegrity runs every check, the database ones first
+func (s *IntegrityStore) CheckIntegrity(ctx context.Context) ([]core.IntegrityResult, error) {
+	results := make([]core.IntegrityResult, 0, len(s.checks)+1)
+	for _, check := range s.checks {
+		result, err := s.run(ctx, check)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	if s.storage != nil {
+		result, err := s.checkProjectFiles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// run counts the orphans of a check and samples their IDs
+func (s *IntegrityStore) run(ctx context.Context, check IntegrityCheck) (core.IntegrityResult, error) {
+	result := core.IntegrityResult{
+		Check:       check.Name,
+		Description: check.Description,
+		SampleIDs:   []string{},
+	}
+
+	name := "integrity." + check.Name
+	if err := s.db.ReadQueryRow(ctx, name, `SELECT COUNT(*) FROM (`+check.Query+`) orphans`).Scan(&result.Orphans); err != nil {
+		return core.IntegrityResult{}, fmt.Errorf("failed to run integrity check %s: %w", check.Name, err)
+	}
+	if result.Orphans == 0 {
+		return result, nil
+	}
+
+	rows, err := s.db.ReadQuery(ctx, name, `SELECT id FROM (`+check.Query+`) orphans ORDER BY id LIMIT $1`, integritySampleSize)
+	if err != nil {
+		return core.IntegrityResult{}, fmt.Errorf("failed to sample integrity check %s: %w", check.Name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return core.IntegrityResult{}, fmt.Errorf("failed to scan orphan of %s: %w", check.Name, err)
+		}
+		result.SampleIDs = append(result.SampleIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return core.IntegrityResult{}, fmt.Errorf("failed to sample integrity check %s: %w", check.Name, err)
+	}
+
+	return result, nil
+}
+
+// checkProjectFiles finds stored project files whose project row is gone
+func (s *IntegrityStore) checkProjectFiles(ctx context.Context) (core.IntegrityResult, error) {
+	result := core.IntegrityResult{
+		Check:       "storage.projects",
+		Description: "files under " + projectAssetsPrefix + " whose project row is gone",
+		SampleIDs:   []string{},
+	}
+
+	keys, err := s.OrphanedFiles(ctx)
+	if err != nil {
+		return core.IntegrityResult{}, err
+	}
+	result.Orphans = len(keys)
+	result.SampleIDs = append(result.SampleIDs, keys[:min(len(keys), integritySampleSize)]...)
+	return result, nil
+}
+
+// OrphanedFiles returns the storage keys of the project files whose project
+// row is gone, grouped by project. It returns none without storage.
+func (s *IntegrityStore) OrphanedFiles(ctx context.Context) ([]string, error) {
+	if s.storage == nil {
+		return nil, nil
+	}
+
+	files, err := s.storage.List(ctx, projectAssetsPrefix, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project files: %w", err)
+	}
+
+	keysByProject := make(map[string][]string)
+	for _, file := range files {
+		projectID, _, _ := strings.Cut(strings.TrimPrefix(file.Key, projectAssetsPrefix), "/")
+		keysByProject[projectID] = append(keysByProject[projectID], file.Key)
+	}
+	projectIDs := make([]string, 0, len(keysByProject))
+	for projectID := range keysByProject {
+		projectIDs = append(projectIDs, projectID)
+	}
+	sort.Strings(projectIDs)
+
+	existing, err := s.existingProjects(ctx, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []string
+	for _, projectID := range projectIDs {
+		if !existing[projectID] {
+			orphans = append(orphans, keysByProject[projectID]...)
+		}
+	}
+	return orphans, nil
+}
+
+// existingProjects returns which of ids name a project row. IDs are
+// compared as text, so a directory that is no UUID is simply missing.
+func (s *IntegrityStore) existingProjects(ctx context.Context, ids []string) (map[string]bool, error) {
+	existing := make(map[string]bool, len(ids))
+	for start := 0; start < len(ids); start += projectsLookupChunk {
+		chunk := ids[start:min(start+projectsLookupChunk, len(ids))]
+
+		placeholders := make([]string, len(chunk))
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+			args[i] = id
+		}
+
+		query := projectsWithIDs + s.db.dialect.Cast("id", "text") + ` IN (` + strings.Join(placeholders, ", ") + `)`
+		rows, err := s.db.ReadQuery(ctx, "integrity.existing_projects", query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up projects: %w", err)
+		}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan project: %w", err)
+			}
+			existing[id] = true
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up projects: %w", err)
+		}
+	}
+	return existing, nil
+}
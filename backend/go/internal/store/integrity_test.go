@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// childTableStatement matches the table a migration statement creates or
+// alters
+var childTableStatement = regexp.MustCompile(`(?is)^\s*(?:CREATE TABLE(?: IF NOT EXISTS)?|ALTER TABLE)\s+(\w+)`)
+
+// parentReference matches a column or foreign key tying a row to a project
+// or an attempt
+var parentReference = regexp.MustCompile(`(?i)\b(?:project_id|attempt_id)\b|REFERENCES\s+(?:projects|attempts)\b`)
+
+func TestIntegrityChecks_CoverEveryChildTable(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("migrations", "*.up.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	checked := make(map[string]bool)
+	for _, check := range integrityChecks {
+		checked[check.Table] = true
+	}
+
+	children := make(map[string]bool)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		for _, statement := range strings.Split(string(content), ";") {
+			var lines []string
+			for _, line := range strings.Split(statement, "\n") {
+				if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+					lines = append(lines, line)
+				}
+			}
+			statement = strings.Join(lines, "\n")
+
+			match := childTableStatement.FindStringSubmatch(statement)
+			if match != nil && parentReference.MatchString(statement) {
+				children[strings.ToLower(match[1])] = true
+			}
+		}
+	}
+
+	require.Contains(t, children, "items")
+	for table := range children {
+		assert.True(t, checked[table], "%s refers to projects or attempts but has no integrity check", table)
+	}
+}
+
+// listingStorage lists a fixed set of keys
+type listingStorage struct {
+	core.Storage
+	keys []string
+}
+
+func (s *listingStorage) List(ctx context.Context, prefix string, limit int) ([]*core.StorageMetadata, error) {
+	var files []*core.StorageMetadata
+	for _, key := range s.keys {
+		if strings.HasPrefix(key, prefix) {
+			files = append(files, &core.StorageMetadata{Key: key})
+		}
+	}
+	return files, nil
+}
+
+func TestIntegrityStore_CheckIntegrity_FilesOfMissingProjects(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	stub.value = "project-1"
+	storage := &listingStorage{keys: []string{
+		"projects/project-1/assets/kept.png",
+		"projects/project-2/assets/left-behind.png",
+		"projects/project-2/assets/also-left-behind.pdf",
+	}}
+	integrity := NewIntegrityStore(database, storage)
+	integrity.checks = nil
+
+	// Act
+	results, err := integrity.CheckIntegrity(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "storage.projects", results[0].Check)
+	assert.Equal(t, 2, results[0].Orphans)
+	assert.Equal(t, []string{"projects/project-2/assets/left-behind.png", "projects/project-2/assets/also-left-behind.pdf"}, results[0].SampleIDs)
+	query, args := stub.last()
+	assert.Equal(t, "SELECT id FROM projects WHERE id::text IN ($1, $2)", query)
+	assert.Equal(t, []interface{}{"project-1", "project-2"}, args)
+}
+
+func TestIntegrityStore_CheckIntegrity_SkipsSamplingWithoutOrphans(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	stub.value = int64(0)
+
+	// Act
+	results, err := NewIntegrityStore(database, nil).CheckIntegrity(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, results, len(integrityChecks), "no storage, no storage check")
+	for _, result := range results {
+		assert.Zero(t, result.Orphans)
+		assert.Empty(t, result.SampleIDs)
+	}
+	assert.Equal(t, len(integrityChecks), stub.statements, "one count per check")
+}
+
+func TestIntegrityStore_CheckIntegrity_SamplesOrphans(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	stub.value = int64(3)
+
+	// Act
+	results, err := NewIntegrityStore(database, nil).CheckIntegrity(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "items.project_id", results[0].Check)
+	assert.Equal(t, 3, results[0].Orphans)
+	assert.Equal(t, []string{"3"}, results[0].SampleIDs)
+	query, args := stub.last()
+	assert.True(t, strings.HasSuffix(query, "orphans ORDER BY id LIMIT $1"), query)
+	assert.Equal(t, []interface{}{int64(integritySampleSize)}, args)
+}
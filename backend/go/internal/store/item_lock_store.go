@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// ItemLockStore implements core.ItemLockStore with one row per locked item.
+// Expired rows stay until someone takes or releases the lock. It reads the
+// primary throughout: locks last seconds, less than a replica may lag.
+type ItemLockStore struct {
+	db *Database
+}
+
+// NewItemLockStore creates a new item lock store
+func NewItemLockStore(db *Database) *ItemLockStore {
+	return &ItemLockStore{db: db}
+}
+
+const itemLockColumns = `item_id, project_id, holder_id, acquired_at, expires_at`
+
+// Acquire inserts the lock, or takes over the item's row if it is expired
+// or already the holder's. The conditional upsert settles races between
+// claimants of an expired lock: exactly one of them writes the row.
+func (s *ItemLockStore) Acquire(ctx context.Context, lock *core.ItemLock, now time.Time) (*core.ItemLock, error) {
+	query := `
+		INSERT INTO item_locks (` + itemLockColumns + `)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (item_id) DO UPDATE
+		SET holder_id = EXCLUDED.holder_id,
+			acquired_at = CASE
+				WHEN item_locks.holder_id = EXCLUDED.holder_id AND item_locks.expires_at > $6 THEN item_locks.acquired_at
+				ELSE EXCLUDED.acquired_at
+			END,
+			expires_at = EXCLUDED.expires_at
+		WHERE item_locks.holder_id = EXCLUDED.holder_id OR item_locks.expires_at <= $6
+		RETURNING ` + itemLockColumns + `
+	`
+
+	// A lock that expires or is released between the upsert and the read
+	// of its holder is tried once more
+	for attempt := 0; attempt < 2; attempt++ {
+		acquired, err := scanItemLock(s.db.QueryRow(ctx, "item_locks.acquire", query,
+			lock.ItemID, lock.ProjectID, lock.HolderID, lock.AcquiredAt, lock.ExpiresAt, now))
+		if violation, ok := s.db.dialect.Violation(err); ok && violation.Kind == ForeignKeyViolation {
+			return nil, core.ErrItemNotFound
+		}
+		if err == nil {
+			if err := s.db.notify(ctx, itemLocksChanged(lock.ProjectID)); err != nil {
+				return nil, err
+			}
+			return acquired, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to acquire item lock: %w", err)
+		}
+
+		held, err := s.Get(ctx, lock.ItemID, now)
+		if err != nil {
+			return nil, err
+		}
+		if held != nil {
+			return nil, &core.ItemLockedError{Lock: held}
+		}
+	}
+
+	return nil, core.ErrConcurrentModification
+}
+
+// Refresh moves the expiry of the holder's unexpired lock
+func (s *ItemLockStore) Refresh(ctx context.Context, itemID, holderID string, expiresAt, now time.Time) (*core.ItemLock, error) {
+	query := `
+		UPDATE item_locks
+		SET expires_at = $3
+		WHERE item_id = $1 AND holder_id = $2 AND expires_at > $4
+		RETURNING ` + itemLockColumns + `
+	`
+
+	lock, err := scanItemLock(s.db.QueryRow(ctx, "item_locks.refresh", query, itemID, holderID, expiresAt, now))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrItemLockNotHeld
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh item lock: %w", err)
+	}
+
+	return lock, nil
+}
+
+// Release deletes the item's lock if the holder holds it or it expired
+func (s *ItemLockStore) Release(ctx context.Context, itemID, holderID string, now time.Time) (bool, error) {
+	query := `
+		DELETE FROM item_locks
+		WHERE item_id = $1 AND (holder_id = $2 OR expires_at <= $3)
+		RETURNING project_id
+	`
+
+	var projectID string
+	err := s.db.QueryRow(ctx, "item_locks.release", query, itemID, holderID, now).Scan(&projectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to release item lock: %w", err)
+	}
+
+	if err := s.db.notify(ctx, itemLocksChanged(projectID)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Get returns the item's unexpired lock, or nil
+func (s *ItemLockStore) Get(ctx context.Context, itemID string, now time.Time) (*core.ItemLock, error) {
+	query := `
+		SELECT ` + itemLockColumns + `
+		FROM item_locks
+		WHERE item_id = $1 AND expires_at > $2
+	`
+
+	lock, err := scanItemLock(s.db.QueryRow(ctx, "item_locks.get", query, itemID, now))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item lock: %w", err)
+	}
+
+	return lock, nil
+}
+
+// ListByProject returns the unexpired locks on the project's items that
+// are not deleted, oldest first
+func (s *ItemLockStore) ListByProject(ctx context.Context, projectID string, now time.Time) ([]*core.ItemLock, error) {
+	query := `
+		SELECT ` + itemLockColumns + `
+		FROM item_locks
+		WHERE project_id = $1 AND expires_at > $2
+			AND item_id IN (SELECT id FROM items WHERE project_id = $1 AND deleted_at IS NULL)
+		ORDER BY acquired_at, item_id
+	`
+
+	rows, err := s.db.Query(ctx, "item_locks.list_by_project", query, projectID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list item locks: %w", err)
+	}
+	defer rows.Close()
+
+	var locks []*core.ItemLock
+	for rows.Next() {
+		lock, err := scanItemLock(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan item lock: %w", err)
+		}
+		locks = append(locks, lock)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list item locks: %w", err)
+	}
+
+	return locks, nil
+}
+
+// scanItemLock scans a row of itemLockColumns
+func scanItemLock(row rowScanner) (*core.ItemLock, error) {
+	var lock core.ItemLock
+	err := row.Scan(&lock.ItemID, &lock.ProjectID, &lock.HolderID, scanUTC(&lock.AcquiredAt), scanUTC(&lock.ExpiresAt))
+	if err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// itemLocksChanged is the change announced when a project's item locks are
+// taken or released
+func itemLocksChanged(projectID string) core.Change {
+	return core.Change{Entity: core.ChangeEntityItemLocks, ID: projectID, Action: core.ChangeActionUpdated}
+}
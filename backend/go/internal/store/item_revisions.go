@@ -0,0 +1,182 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store/dbtypes"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// itemRevisionColumns are the columns every revision query returns, in the
+// order scanItemRevision reads them
+const itemRevisionColumns = "item_id, revision_number, snapshot, changed_at, changed_by"
+
+// itemSnapshot is the snapshot column of item_revisions: the fields of the
+// item a write replaced. The item's ID, project and creation time are the
+// revision row's item's own.
+type itemSnapshot struct {
+	Type        types.ItemType  `json:"type"`
+	Title       string          `json:"title"`
+	Content     json.RawMessage `json:"content,omitempty"`
+	Position    int             `json:"position"`
+	Required    bool            `json:"required"`
+	Points      *int            `json:"points,omitempty"`
+	Explanation *string         `json:"explanation,omitempty"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Version     int             `json:"version"`
+}
+
+// keepRevision keeps previous, the item a write in the transaction in ctx
+// replaced, as the item's next revision, by the user in ctx, and prunes the
+// item's revisions beyond the newest revisionLimit. The item's row must be
+// locked (see lockItem), which keeps revision numbers of concurrent writes
+// apart.
+func (s *ItemStore) keepRevision(ctx context.Context, previous *core.Item) error {
+	snapshot, err := json.Marshal(itemSnapshot{
+		Type:        previous.Type,
+		Title:       previous.Title,
+		Content:     previous.Content,
+		Position:    previous.Position,
+		Required:    previous.Required,
+		Points:      previous.Points,
+		Explanation: previous.Explanation,
+		UpdatedAt:   previous.UpdatedAt,
+		Version:     previous.Version,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode item revision: %w", err)
+	}
+
+	var changedBy *string
+	if userID := core.AccessScopeFromContext(ctx).UserID; userID != "" {
+		changedBy = &userID
+	}
+
+	_, err = s.db.Exec(ctx, "item_revisions.create", `
+		INSERT INTO item_revisions (item_id, revision_number, snapshot, changed_at, changed_by)
+		VALUES ($1, (SELECT COALESCE(MAX(revision_number), 0) + 1 FROM item_revisions WHERE item_id = $1), $2, `+s.db.dialect.Now()+`, $3)
+	`, previous.ID, dbtypes.JSON(snapshot), changedBy)
+	if err != nil {
+		return fmt.Errorf("failed to keep item revision: %w", err)
+	}
+
+	_, err = s.db.Exec(ctx, "item_revisions.prune", `
+		DELETE FROM item_revisions
+		WHERE item_id = $1
+		AND revision_number <= (SELECT MAX(revision_number) FROM item_revisions WHERE item_id = $1) - $2
+	`, previous.ID, s.revisionLimit)
+	if err != nil {
+		return fmt.Errorf("failed to prune item revisions: %w", err)
+	}
+	return nil
+}
+
+// ListRevisions returns a page of the revisions an item of a project keeps,
+// newest first, with how many it keeps. Returns core.ErrItemNotFound unless
+// the item is in the project, in the organization in ctx.
+func (s *ItemStore) ListRevisions(ctx context.Context, projectID, id string, limit, offset int) ([]*core.ItemRevision, int, error) {
+	item, err := s.GetByID(ctx, projectID, id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = s.db.ReadQueryRow(ctx, "item_revisions.count",
+		`SELECT COUNT(*) FROM item_revisions WHERE item_id = $1`, item.ID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count item revisions: %w", err)
+	}
+
+	query := `
+		SELECT ` + itemRevisionColumns + `
+		FROM item_revisions
+		WHERE item_id = $1
+		ORDER BY revision_number DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := s.db.ReadQuery(ctx, "item_revisions.list", query, item.ID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list item revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := make([]*core.ItemRevision, 0, limit)
+	for rows.Next() {
+		revision, err := scanItemRevision(rows, item)
+		if err != nil {
+			return nil, 0, err
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate item revisions: %w", err)
+	}
+	return revisions, total, nil
+}
+
+// GetRevision returns a revision of an item of a project by its number.
+// Returns core.ErrItemNotFound unless the item is in the project, in the
+// organization in ctx, and core.ErrItemRevisionNotFound unless the item
+// keeps the revision.
+func (s *ItemStore) GetRevision(ctx context.Context, projectID, id string, number int) (*core.ItemRevision, error) {
+	item, err := s.GetByID(ctx, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	row := s.db.ReadQueryRow(ctx, "item_revisions.get", `
+		SELECT `+itemRevisionColumns+`
+		FROM item_revisions
+		WHERE item_id = $1 AND revision_number = $2
+	`, item.ID, number)
+	revision, err := scanItemRevision(row, item)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrItemRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// scanItemRevision scans a row of itemRevisionColumns of a revision of
+// item, which supplies the fields snapshots leave out
+func scanItemRevision(row rowScanner, item *core.Item) (*core.ItemRevision, error) {
+	var revision core.ItemRevision
+	var snapshot dbtypes.JSON
+	var changedBy sql.NullString
+	err := row.Scan(&revision.ItemID, &revision.Number, &snapshot, scanUTC(&revision.ChangedAt), &changedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan item revision: %w", err)
+	}
+
+	var fields itemSnapshot
+	if err := json.Unmarshal(snapshot, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode item revision %d: %w", revision.Number, err)
+	}
+	revision.ChangedBy = changedBy.String
+	revision.Item = &core.Item{
+		ID:          item.ID,
+		ProjectID:   item.ProjectID,
+		Type:        fields.Type,
+		Title:       fields.Title,
+		Content:     fields.Content,
+		Position:    fields.Position,
+		Required:    fields.Required,
+		Points:      fields.Points,
+		Explanation: fields.Explanation,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   fields.UpdatedAt.UTC(),
+		Version:     fields.Version,
+	}
+	return &revision, nil
+}
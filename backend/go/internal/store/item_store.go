@@ -4,93 +4,437 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 
-	"github.com/rs/zerolog/log"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 
 	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store/dbgen"
+	"github.com/provemyself/backend/internal/store/dbtypes"
 	"github.com/provemyself/backend/internal/types"
 )
 
-// ItemStore implements item data access using PostgreSQL
+// itemPositionConstraint keeps item positions unique within a project
+const itemPositionConstraint = "items_project_id_position_key"
+
+// DefaultItemRevisionLimit is how many revisions an item keeps unless
+// SetRevisionLimit says otherwise
+const DefaultItemRevisionLimit = 50
+
+// ItemStore implements item data access on the database's dialect
 type ItemStore struct {
 	db *Database
+	// revisionLimit is how many revisions each item keeps
+	revisionLimit int
 }
 
 // NewItemStore creates a new item store
 func NewItemStore(db *Database) *ItemStore {
-	return &ItemStore{db: db}
+	return &ItemStore{db: db, revisionLimit: DefaultItemRevisionLimit}
 }
 
-// Create creates a new item in the database
-func (s *ItemStore) Create(ctx context.Context, projectID string, itemType types.ItemType, title string, content json.RawMessage, position int, required bool, points *int, explanation *string) (*core.Item, error) {
-	var item core.Item
+// SetRevisionLimit sets how many revisions each item keeps. Older ones are
+// pruned as the next revision of the item is kept.
+func (s *ItemStore) SetRevisionLimit(limit int) {
+	s.revisionLimit = limit
+}
 
-	query := `
-		INSERT INTO items (project_id, type, title, content, position, required, points, explanation)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at
-	`
+// scoped restricts where, whose placeholders are bound to args, to the items
+// that are not deleted of projects in the organization in ctx that are not
+// deleted. Every hand-written item query is built with it; the generated
+// ones (queries/items.sql) spell the condition out with orgIDParam and
+// memberIDParam.
+func (s *ItemStore) scoped(ctx context.Context, where string, args ...interface{}) (string, []interface{}) {
+	return s.scopedIncluding(ctx, false, where, args...)
+}
 
-	row := s.db.DB().QueryRowContext(ctx, query, projectID, string(itemType), title, content, position, required, points, explanation)
-
-	var contentRaw []byte
-	var typeStr string
-	err := row.Scan(
-		&item.ID,
-		&item.ProjectID,
-		&typeStr,
-		&item.Title,
-		&contentRaw,
-		&item.Position,
-		&item.Required,
-		&item.Points,
-		&item.Explanation,
-		&item.CreatedAt,
-		&item.UpdatedAt,
-	)
+// scopedIncluding is scoped, keeping deleted items if includeDeleted. Items
+// of deleted projects are left out either way.
+func (s *ItemStore) scopedIncluding(ctx context.Context, includeDeleted bool, where string, args ...interface{}) (string, []interface{}) {
+	return andScope(where, args, func(n int) (string, []interface{}) {
+		projects, projectArgs := orgScope(ctx, "org_id", n)
+		condition := "project_id IN (SELECT id FROM projects WHERE " + notDeleted("projects") + " AND " + projects + ")"
+		if !includeDeleted {
+			condition = notDeleted("items") + " AND " + condition
+		}
+		return condition, projectArgs
+	})
+}
+
+// itemRow is the columns every item query returns. Each generated query
+// has a row type of its own with the same fields, converted to itemRow, so
+// a column added to one query and not the others fails to compile.
+type itemRow dbgen.GetItemRow
+
+// item converts a row of an item query
+func (row itemRow) item() *core.Item {
+	return &core.Item{
+		ID:          row.ID,
+		ProjectID:   row.ProjectID,
+		Type:        row.Type,
+		Title:       row.Title,
+		Content:     json.RawMessage(row.Content),
+		Position:    row.Position,
+		Required:    row.Required,
+		Points:      row.Points,
+		Explanation: row.Explanation,
+		CreatedAt:   row.CreatedAt.UTC(),
+		UpdatedAt:   row.UpdatedAt.UTC(),
+		Version:     row.Version,
+	}
+}
 
+// Create creates a new item in the database. Returns core.ErrProjectNotFound
+// unless the project is in the organization in ctx.
+func (s *ItemStore) Create(ctx context.Context, projectID string, itemType types.ItemType, title string, content json.RawMessage, position int, required bool, points *int, explanation *string) (*core.Item, error) {
+	inScope, err := s.db.read("items.project_in_scope").ProjectInScope(ctx, dbgen.ProjectInScopeParams{
+		ID:       projectID,
+		OrgID:    orgIDParam(ctx),
+		MemberID: memberIDParam(ctx),
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("failed to create item: no rows returned")
+		return nil, fmt.Errorf("failed to check item project: %w", err)
+	}
+	if !inScope {
+		return nil, core.ErrProjectNotFound
+	}
+
+	row, err := s.db.write("items.create").CreateItem(ctx, dbgen.CreateItemParams{
+		ID:          core.NewID(ctx),
+		ProjectID:   projectID,
+		Type:        itemType,
+		Title:       title,
+		Content:     dbtypes.JSON(content),
+		Position:    position,
+		Required:    required,
+		Points:      points,
+		Explanation: explanation,
+	})
+	if err != nil {
+		return nil, s.createError(err, position)
+	}
+
+	if err := s.db.notify(ctx, projectChanged(projectID)); err != nil {
+		return nil, err
+	}
+
+	return itemRow(row).item(), nil
+}
+
+// createError is the error of an insert of an item at position failing with
+// err
+func (s *ItemStore) createError(err error, position int) error {
+	if violation, ok := s.db.dialect.Violation(err); ok {
+		switch {
+		case violation.Kind == ForeignKeyViolation:
+			// The project was deleted since it was checked
+			return core.ErrProjectNotFound
+		case violation.Is(UniqueViolation, itemPositionConstraint):
+			return fmt.Errorf("%w: position %d is taken", core.ErrItemInvalidPosition, position)
 		}
-		return nil, fmt.Errorf("failed to create item: %w", err)
 	}
+	return fmt.Errorf("failed to create item: %w", err)
+}
 
-	item.Type = types.ItemType(typeStr)
-	item.Content = json.RawMessage(contentRaw)
+// projectChanged is the change announced for a write to an item of the
+// project: cached deliveries are per project
+func projectChanged(projectID string) core.Change {
+	return core.Change{Entity: core.ChangeEntityProject, ID: projectID, Action: core.ChangeActionUpdated}
+}
 
-	return &item, nil
+// createItemBatched is the CreateItem query of queries/items.sql, for
+// batches: sqlc generates those only for pgx's native interface
+const createItemBatched = `
+	INSERT INTO items (id, project_id, type, title, content, position, required, points, explanation)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at, version
+`
+
+// CreateMany creates items in a project in one transaction, all or none,
+// sending every insert in one batch where the engine has batches. Returns
+// core.ErrProjectNotFound unless the project is in the organization in ctx,
+// and a *core.ItemBatchError for the first item that fails to insert.
+func (s *ItemStore) CreateMany(ctx context.Context, projectID string, items []core.NewItem) ([]*core.Item, error) {
+	var created []*core.Item
+	err := s.db.InTx(ctx, "items.create_many", func(ctx context.Context) error {
+		// A retried transaction starts the list over
+		created = make([]*core.Item, len(items))
+		if !s.db.dialect.Capabilities().Batches {
+			for i, item := range items {
+				c, err := s.Create(ctx, projectID, item.Type, item.Title, item.Content, item.Position, item.Required, item.Points, item.Explanation)
+				if errors.Is(err, core.ErrProjectNotFound) {
+					return err
+				}
+				if err != nil {
+					return &core.ItemBatchError{Index: i, Err: err}
+				}
+				created[i] = c
+			}
+			return nil
+		}
+
+		inScope, err := s.db.read("items.project_in_scope").ProjectInScope(ctx, dbgen.ProjectInScopeParams{
+			ID:       projectID,
+			OrgID:    orgIDParam(ctx),
+			MemberID: memberIDParam(ctx),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to check item project: %w", err)
+		}
+		if !inScope {
+			return core.ErrProjectNotFound
+		}
+
+		batch := &pgx.Batch{}
+		for i, item := range items {
+			batch.Queue(createItemBatched, uuid.NewString(), projectID, item.Type, item.Title, dbtypes.JSON(item.Content),
+				item.Position, item.Required, item.Points, item.Explanation).QueryRow(func(row pgx.Row) error {
+				var r dbgen.CreateItemRow
+				err := row.Scan(&r.ID, &r.ProjectID, &r.Type, &r.Title, &r.Content, &r.Position, &r.Required,
+					&r.Points, &r.Explanation, &r.CreatedAt, &r.UpdatedAt, &r.Version)
+				if err != nil {
+					return &core.ItemBatchError{Index: i, Err: s.createError(err, item.Position)}
+				}
+				created[i] = itemRow(r).item()
+				return nil
+			})
+		}
+
+		tx, _ := txFromContext(ctx)
+		err = tx.sendBatch(ctx, "items.create_many", batch)
+		if errors.Is(err, core.ErrProjectNotFound) {
+			return core.ErrProjectNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return tx.notify(ctx, projectChanged(projectID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
 }
 
-// GetByID retrieves an item by its ID
-func (s *ItemStore) GetByID(ctx context.Context, id string) (*core.Item, error) {
-	var item core.Item
+// bulkCopyMinItems is the fewest items CreateBulkCopy loads with COPY:
+// smaller imports gain nothing over CreateMany's batch
+const bulkCopyMinItems = 20
+
+// itemCopyColumns are the columns CreateBulkCopy loads. COPY has no
+// RETURNING, so the IDs and timestamps the column defaults would give are
+// made in Go.
+var itemCopyColumns = []string{"id", "project_id", "type", "title", "content", "position", "required", "points", "explanation", "created_at", "updated_at"}
+
+// CreateBulkCopy creates items in a project like CreateMany, loading them
+// with COPY in one round trip and reading them back, which is much faster
+// for imports of hundreds of items. Smaller imports, and engines without
+// COPY, take CreateMany.
+func (s *ItemStore) CreateBulkCopy(ctx context.Context, projectID string, items []core.NewItem) ([]*core.Item, error) {
+	if len(items) < bulkCopyMinItems || !s.db.dialect.Capabilities().Copy {
+		return s.CreateMany(ctx, projectID, items)
+	}
+
+	var created []*core.Item
+	err := s.db.InTx(ctx, "items.create_bulk_copy", func(ctx context.Context) error {
+		inScope, err := s.db.read("items.project_in_scope").ProjectInScope(ctx, dbgen.ProjectInScopeParams{
+			ID:       projectID,
+			OrgID:    orgIDParam(ctx),
+			MemberID: memberIDParam(ctx),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to check item project: %w", err)
+		}
+		if !inScope {
+			return core.ErrProjectNotFound
+		}
+
+		// COPY aborts on a taken position without saying whose
+		if err := s.checkPositionsFree(ctx, projectID, items); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		ids := make([]string, len(items))
+		rows := make([][]interface{}, len(items))
+		for i, item := range items {
+			ids[i] = uuid.NewString()
+			rows[i] = []interface{}{ids[i], projectID, string(item.Type), item.Title, dbtypes.JSON(item.Content),
+				item.Position, item.Required, item.Points, item.Explanation, now, now}
+		}
+
+		tx, _ := txFromContext(ctx)
+		err = tx.copyFrom(ctx, "items.create_bulk_copy", "items", itemCopyColumns, rows)
+		// The project was deleted since it was checked
+		if violation, ok := s.db.dialect.Violation(err); ok && violation.Kind == ForeignKeyViolation {
+			return core.ErrProjectNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to copy items: %w", err)
+		}
+
+		created, err = s.readBack(ctx, ids)
+		if err != nil {
+			return err
+		}
+		return tx.notify(ctx, projectChanged(projectID))
+	})
+	if err != nil {
+		return nil, err
+	}
 
+	return created, nil
+}
+
+// checkPositionsFree returns a *core.ItemBatchError for the first of items
+// at a position a live item of the project already has
+func (s *ItemStore) checkPositionsFree(ctx context.Context, projectID string, items []core.NewItem) error {
+	positions := make([]int, len(items))
+	for i, item := range items {
+		positions[i] = item.Position
+	}
+
+	query := `SELECT position FROM items WHERE project_id = $1 AND position = ANY($2) AND deleted_at IS NULL`
+	rows, err := s.db.Query(ctx, "items.taken_positions", query, projectID, positions)
+	if err != nil {
+		return fmt.Errorf("failed to check item positions: %w", err)
+	}
+	defer rows.Close()
+
+	taken := make(map[int]bool)
+	for rows.Next() {
+		var position int
+		if err := rows.Scan(&position); err != nil {
+			return fmt.Errorf("failed to scan item position: %w", err)
+		}
+		taken[position] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to check item positions: %w", err)
+	}
+
+	for i, item := range items {
+		if taken[item.Position] {
+			return &core.ItemBatchError{Index: i, Err: fmt.Errorf("%w: position %d is taken", core.ErrItemInvalidPosition, item.Position)}
+		}
+	}
+	return nil
+}
+
+// readBack reads the items with ids, just created, in the order of ids
+func (s *ItemStore) readBack(ctx context.Context, ids []string) ([]*core.Item, error) {
+	where, args := s.scoped(ctx, "id = ANY($1)", ids)
 	query := `
-		SELECT id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at
+		SELECT id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at, version
 		FROM items
-		WHERE id = $1
-	`
+		WHERE ` + where
+
+	rows, err := s.db.Query(ctx, "items.read_back", query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read back items: %w", err)
+	}
+	defer rows.Close()
+
+	read, err := scanItems(rows)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*core.Item, len(read))
+	for _, item := range read {
+		byID[item.ID] = item
+	}
+
+	items := make([]*core.Item, len(ids))
+	for i, id := range ids {
+		item, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("failed to read back item %d", i+1)
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// Duplicate copies an item of a project into a new item named title, at
+// the position after the project's last item. The project's row is locked
+// while the position is taken, so concurrent duplicates each get their own.
+// Returns core.ErrProjectNotFound unless the project is in the organization
+// in ctx and core.ErrItemNotFound unless the item is in the project.
+func (s *ItemStore) Duplicate(ctx context.Context, projectID, id, title string) (*core.Item, error) {
+	var item *core.Item
+	err := s.db.InTx(ctx, "items.duplicate", func(ctx context.Context) error {
+		if err := s.lockProject(ctx, projectID); err != nil {
+			return err
+		}
 
-	row := s.db.DB().QueryRowContext(ctx, query, id)
-
-	var contentRaw []byte
-	var typeStr string
-	err := row.Scan(
-		&item.ID,
-		&item.ProjectID,
-		&typeStr,
-		&item.Title,
-		&contentRaw,
-		&item.Position,
-		&item.Required,
-		&item.Points,
-		&item.Explanation,
-		&item.CreatedAt,
-		&item.UpdatedAt,
-	)
+		where, args := s.scoped(ctx, "id = $1 AND project_id = $2", id, projectID)
+		query := fmt.Sprintf(`
+			INSERT INTO items (id, project_id, type, title, content, position, required, points, explanation)
+			SELECT $%d, project_id, type, $%d, content, (
+				SELECT COALESCE(MAX(siblings.position), -1) + 1
+				FROM items AS siblings
+				WHERE siblings.project_id = $2 AND siblings.deleted_at IS NULL
+			), required, points, explanation
+			FROM items
+			WHERE %s
+			RETURNING id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at, version
+		`, len(args)+1, len(args)+2, where)
+
+		rows, err := s.db.Query(ctx, "items.duplicate", query, append(args, core.NewID(ctx), title)...)
+		if err != nil {
+			return fmt.Errorf("failed to duplicate item: %w", err)
+		}
+		defer rows.Close()
 
+		items, err := scanItems(rows)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return core.ErrItemNotFound
+		}
+		item = items[0]
+		return s.db.notify(ctx, projectChanged(projectID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// lockProject locks the row of a project in the organization in ctx until
+// the transaction in ctx ends. Returns core.ErrProjectNotFound if there is
+// no such project.
+func (s *ItemStore) lockProject(ctx context.Context, projectID string) error {
+	where, args := andScope("id = $1", []interface{}{projectID}, func(n int) (string, []interface{}) {
+		projects, projectArgs := orgScope(ctx, "org_id", n)
+		return notDeleted("projects") + " AND " + projects, projectArgs
+	})
+
+	var id string
+	err := s.db.QueryRow(ctx, "items.lock_project", `SELECT id FROM projects WHERE `+where+s.db.dialect.ForUpdate(), args...).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return core.ErrProjectNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock project: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an item of a project by its ID
+func (s *ItemStore) GetByID(ctx context.Context, projectID, id string) (*core.Item, error) {
+	row, err := s.db.read("items.get_by_id").GetItem(ctx, dbgen.GetItemParams{
+		ID:        id,
+		ProjectID: projectID,
+		OrgID:     orgIDParam(ctx),
+		MemberID:  memberIDParam(ctx),
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, core.ErrItemNotFound
@@ -98,31 +442,192 @@ func (s *ItemStore) GetByID(ctx context.Context, id string) (*core.Item, error)
 		return nil, fmt.Errorf("failed to get item by ID: %w", err)
 	}
 
-	item.Type = types.ItemType(typeStr)
-	item.Content = json.RawMessage(contentRaw)
-
-	return &item, nil
+	return itemRow(row).item(), nil
 }
 
 // ListByProject retrieves all items for a project, ordered by position
 func (s *ItemStore) ListByProject(ctx context.Context, projectID string) ([]*core.Item, error) {
+	rows, err := s.db.read("items.list_by_project").ListItemsByProject(ctx, dbgen.ListItemsByProjectParams{
+		ProjectID: projectID,
+		OrgID:     orgIDParam(ctx),
+		MemberID:  memberIDParam(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query items: %w", err)
+	}
+
+	items := make([]*core.Item, len(rows))
+	for i, row := range rows {
+		items[i] = itemRow(row).item()
+	}
+	return items, nil
+}
+
+// List retrieves the page of a project's items selected by opts. The total
+// is counted with the same filter, so it counts exactly the items the pages
+// are drawn from.
+func (s *ItemStore) List(ctx context.Context, projectID string, opts core.ItemListOptions) (*core.ItemPage, error) {
+	if _, ok := itemSorts[opts.Sort]; !ok && opts.Sort != "" {
+		return nil, fmt.Errorf("unknown item sort %q", opts.Sort)
+	}
+	filter, filterArgs, orderBy := s.buildItemFilter(projectID, opts)
+	where, args := s.scoped(ctx, filter, filterArgs...)
+
+	page := &core.ItemPage{}
+	countQuery := `SELECT COUNT(*) FROM items WHERE ` + where
+	if err := s.db.ReadQueryRow(ctx, "items.count_filtered", countQuery, args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("failed to count items: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at, version
+		FROM items
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, where, orderBy, len(args)+1, len(args)+2)
+
+	rows, err := s.db.ReadQuery(ctx, "items.list", query, append(args, opts.Limit, opts.Offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query items: %w", err)
+	}
+	defer rows.Close()
+
+	if page.Items, err = scanItems(rows); err != nil {
+		return nil, err
+	}
+	if page.Items == nil {
+		page.Items = []*core.Item{}
+	}
+	return page, nil
+}
+
+// buildItemFilter returns the condition selecting the items of a project
+// that match opts' filters, with placeholders numbered from $1 and bound to
+// args, and the order of the list. Short search terms match the title or
+// the content's JSON text; longer ones search as Search does, ranked the
+// same way.
+func (s *ItemStore) buildItemFilter(projectID string, opts core.ItemListOptions) (string, []interface{}, string) {
+	var args []interface{}
+	bind := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	ilike := s.db.dialect.ILike
+
+	conditions := []string{"project_id = " + bind(projectID)}
+	orderBy := "position ASC"
+
+	if opts.Type != "" {
+		conditions = append(conditions, "type = "+bind(string(opts.Type)))
+	}
+	if opts.Required != nil {
+		conditions = append(conditions, "required = "+bind(*opts.Required))
+	}
+
+	switch {
+	case opts.Search == "":
+	case utf8.RuneCountInString(opts.Search) < core.MinItemSearchLength:
+		pattern := bind("%" + escapeLike(opts.Search) + "%")
+		conditions = append(conditions, "("+ilike("title", pattern)+" OR "+ilike(s.db.dialect.Cast("content", "text"), pattern)+")")
+	case s.db.dialect.Capabilities().FullTextSearch:
+		query := "websearch_to_tsquery('english', " + bind(opts.Search) + ")"
+		conditions = append(conditions, "search_vector @@ "+query)
+		orderBy = "ts_rank(search_vector, " + query + ") DESC, position ASC"
+	default:
+		pattern := bind("%" + escapeLike(opts.Search) + "%")
+		conditions = append(conditions, s.textMatch(pattern))
+		orderBy = "CASE WHEN " + ilike("title", pattern) + " THEN 0 ELSE 1 END, position ASC"
+	}
+	if sort, ok := itemSorts[opts.Sort]; ok {
+		orderBy = sort
+	}
+
+	return strings.Join(conditions, " AND "), args, orderBy
+}
+
+// itemSorts are the ORDER BY clauses of the sorts of item lists, by name
+var itemSorts = map[core.ItemSort]string{
+	core.ItemSortPosition:      "position ASC",
+	core.ItemSortPositionDesc:  "position DESC",
+	core.ItemSortCreatedAt:     "created_at ASC, position ASC",
+	core.ItemSortCreatedAtDesc: "created_at DESC, position ASC",
+	core.ItemSortUpdatedAt:     "updated_at ASC, position ASC",
+	core.ItemSortUpdatedAtDesc: "updated_at DESC, position ASC",
+	core.ItemSortTitle:         "title ASC, position ASC",
+	core.ItemSortTitleDesc:     "title DESC, position ASC",
+}
+
+// Search returns the items of a project whose search vector matches terms,
+// in web search syntax ("quoted phrases", or, -excluded), ranked by
+// relevance. The title is weighted above the content text, so title matches
+// come first; equal ranks keep position order. Engines without full-text
+// search match terms as a substring instead (see searchText). It reads the
+// replica.
+func (s *ItemStore) Search(ctx context.Context, projectID, terms string) ([]*core.Item, error) {
+	if !s.db.dialect.Capabilities().FullTextSearch {
+		return s.searchText(ctx, projectID, terms)
+	}
+
+	rows, err := s.db.readStale("items.search").SearchItems(ctx, dbgen.SearchItemsParams{
+		ProjectID: projectID,
+		Terms:     terms,
+		OrgID:     orgIDParam(ctx),
+		MemberID:  memberIDParam(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search items: %w", err)
+	}
+
+	items := make([]*core.Item, len(rows))
+	for i, row := range rows {
+		items[i] = itemRow(row).item()
+	}
+	return items, nil
+}
+
+// searchText finds the items of a project whose title, or a text of their
+// content the search vector would index, contains terms, ignoring case.
+// Title matches come first, then position order. It reads the content with
+// SQLite's json_tree, the only engine without full-text search.
+func (s *ItemStore) searchText(ctx context.Context, projectID, terms string) ([]*core.Item, error) {
+	ilike := s.db.dialect.ILike
+	where, args := s.scoped(ctx, "project_id = $1 AND "+s.textMatch("$2"), projectID, "%"+escapeLike(terms)+"%")
 	query := `
-		SELECT id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at
+		SELECT id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at, version
 		FROM items
-		WHERE project_id = $1
-		ORDER BY position ASC
+		WHERE ` + where + `
+		ORDER BY CASE WHEN ` + ilike("title", "$2") + ` THEN 0 ELSE 1 END, position ASC
 	`
 
-	rows, err := s.db.DB().QueryContext(ctx, query, projectID)
+	rows, err := s.db.StaleQuery(ctx, "items.search_text", query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query items: %w", err)
+		return nil, fmt.Errorf("failed to search items: %w", err)
 	}
 	defer rows.Close()
 
+	return scanItems(rows)
+}
+
+// textMatch is the condition of searchText: the title, or a text of the
+// content the search vector would index, matches pattern
+func (s *ItemStore) textMatch(pattern string) string {
+	ilike := s.db.dialect.ILike
+	return "(" + ilike("title", pattern) + ` OR EXISTS (
+		SELECT 1 FROM json_tree(content)
+		WHERE key IN ('text', 'correct_answer') AND type = 'text' AND ` + ilike("value", pattern) + `))`
+}
+
+// scanItems reads every row of a hand-written item query, for the
+// statements sqlc cannot type on every dialect. It scans like the generated
+// queries: content into dbtypes.JSON, which copies the driver's buffer, and
+// the nullable points and explanation into pointers, so NULL stays apart
+// from 0 and "".
+func scanItems(rows *sql.Rows) ([]*core.Item, error) {
 	var items []*core.Item
 	for rows.Next() {
 		var item core.Item
-		var contentRaw []byte
+		var content dbtypes.JSON
 		var typeStr string
 
 		err := rows.Scan(
@@ -130,13 +635,14 @@ func (s *ItemStore) ListByProject(ctx context.Context, projectID string) ([]*cor
 			&item.ProjectID,
 			&typeStr,
 			&item.Title,
-			&contentRaw,
+			&content,
 			&item.Position,
 			&item.Required,
 			&item.Points,
 			&item.Explanation,
-			&item.CreatedAt,
-			&item.UpdatedAt,
+			scanUTC(&item.CreatedAt),
+			scanUTC(&item.UpdatedAt),
+			&item.Version,
 		)
 
 		if err != nil {
@@ -144,112 +650,308 @@ func (s *ItemStore) ListByProject(ctx context.Context, projectID string) ([]*cor
 		}
 
 		item.Type = types.ItemType(typeStr)
-		item.Content = json.RawMessage(contentRaw)
+		item.Content = json.RawMessage(content)
 		items = append(items, &item)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error during rows iteration: %w", err)
 	}
 
 	return items, nil
 }
 
-// Update updates an existing item
-func (s *ItemStore) Update(ctx context.Context, id string, itemType types.ItemType, title string, content json.RawMessage, position int, required bool, points *int, explanation *string) (*core.Item, error) {
-	var item core.Item
-
-	query := `
-		UPDATE items
-		SET type = $2, title = $3, content = $4, position = $5, required = $6, points = $7, explanation = $8, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
-		RETURNING id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at
-	`
+// CountByProject returns the number of items in a project, read from the
+// replica
+func (s *ItemStore) CountByProject(ctx context.Context, projectID string) (int, error) {
+	count, err := s.db.readStale("items.count_by_project").CountItemsByProject(ctx, dbgen.CountItemsByProjectParams{
+		ProjectID: projectID,
+		OrgID:     orgIDParam(ctx),
+		MemberID:  memberIDParam(ctx),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count items: %w", err)
+	}
+	return int(count), nil
+}
 
-	row := s.db.DB().QueryRowContext(ctx, query, id, string(itemType), title, content, position, required, points, explanation)
-
-	var contentRaw []byte
-	var typeStr string
-	err := row.Scan(
-		&item.ID,
-		&item.ProjectID,
-		&typeStr,
-		&item.Title,
-		&contentRaw,
-		&item.Position,
-		&item.Required,
-		&item.Points,
-		&item.Explanation,
-		&item.CreatedAt,
-		&item.UpdatedAt,
-	)
+// MaxPosition returns the highest item position in a project, and false
+// when the project has no items
+func (s *ItemStore) MaxPosition(ctx context.Context, projectID string) (int, bool, error) {
+	position, err := s.db.read("items.max_position").MaxItemPosition(ctx, dbgen.MaxItemPositionParams{
+		ProjectID: projectID,
+		OrgID:     orgIDParam(ctx),
+		MemberID:  memberIDParam(ctx),
+	})
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get max item position: %w", err)
+	}
+	if position < 0 {
+		return 0, false, nil
+	}
+	return position, true, nil
+}
 
+// SumPoints returns the total points of a project's items, counting
+// unscored items as 0. It reads the replica.
+func (s *ItemStore) SumPoints(ctx context.Context, projectID string) (int, error) {
+	points, err := s.db.readStale("items.sum_points").SumItemPoints(ctx, dbgen.SumItemPointsParams{
+		ProjectID: projectID,
+		OrgID:     orgIDParam(ctx),
+		MemberID:  memberIDParam(ctx),
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, core.ErrItemNotFound
+		return 0, fmt.Errorf("failed to sum item points: %w", err)
+	}
+	return points, nil
+}
+
+// Update updates an existing item of a project and increments its version,
+// keeping the item as it was as a revision. With a version, the item is
+// only updated while it has that version.
+func (s *ItemStore) Update(ctx context.Context, projectID, id string, itemType types.ItemType, title string, content json.RawMessage, position int, required bool, points *int, explanation *string, version *int) (*core.Item, error) {
+	var item *core.Item
+	err := s.db.InTx(ctx, "items.update", func(ctx context.Context) error {
+		previous, err := s.lockItem(ctx, projectID, id)
+		if err != nil {
+			return err
+		}
+		if version != nil && *version != previous.Version {
+			return core.ErrVersionConflict
 		}
-		return nil, fmt.Errorf("failed to update item: %w", err)
+
+		row, err := s.db.write("items.update").UpdateItem(ctx, dbgen.UpdateItemParams{
+			Type:        itemType,
+			Title:       title,
+			Content:     dbtypes.JSON(content),
+			Position:    position,
+			Required:    required,
+			Points:      points,
+			Explanation: explanation,
+			ID:          id,
+			ProjectID:   projectID,
+			Version:     version,
+			OrgID:       orgIDParam(ctx),
+			MemberID:    memberIDParam(ctx),
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return core.ErrItemNotFound
+			}
+			return fmt.Errorf("failed to update item: %w", err)
+		}
+		item = itemRow(row).item()
+
+		if err := s.keepRevision(ctx, previous); err != nil {
+			return err
+		}
+		return s.db.notify(ctx, projectChanged(row.ProjectID))
+	})
+	if err != nil {
+		return nil, err
 	}
+	return item, nil
+}
 
-	item.Type = types.ItemType(typeStr)
-	item.Content = json.RawMessage(contentRaw)
+// Delete moves an item of a project to the trash, from which Restore
+// brings it back until PurgeDeleted removes it. The item as it was is kept
+// as a revision.
+func (s *ItemStore) Delete(ctx context.Context, projectID, id string) error {
+	return s.db.InTx(ctx, "items.delete", func(ctx context.Context) error {
+		previous, err := s.lockItem(ctx, projectID, id)
+		if err != nil {
+			return err
+		}
 
-	return &item, nil
+		_, err = s.db.write("items.delete").DeleteItem(ctx, dbgen.DeleteItemParams{
+			ID:        id,
+			ProjectID: projectID,
+			OrgID:     orgIDParam(ctx),
+			MemberID:  memberIDParam(ctx),
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return core.ErrItemNotFound
+			}
+			return fmt.Errorf("failed to delete item: %w", err)
+		}
+
+		if err := s.keepRevision(ctx, previous); err != nil {
+			return err
+		}
+		return s.db.notify(ctx, projectChanged(projectID))
+	})
 }
 
-// Delete removes an item from the database
-func (s *ItemStore) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM items WHERE id = $1`
+// lockItem reads an item of a project in the organization in ctx, locking
+// its row until the transaction in ctx ends, so the item a write replaces
+// is the one kept as its revision. Returns core.ErrItemNotFound if there is
+// no such item.
+func (s *ItemStore) lockItem(ctx context.Context, projectID, id string) (*core.Item, error) {
+	where, args := s.scoped(ctx, "id = $1 AND project_id = $2", id, projectID)
+	query := `
+		SELECT id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at, version
+		FROM items
+		WHERE ` + where + s.db.dialect.ForUpdate()
 
-	result, err := s.db.DB().ExecContext(ctx, query, id)
+	rows, err := s.db.Query(ctx, "items.lock", query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to delete item: %w", err)
+		return nil, fmt.Errorf("failed to lock item: %w", err)
 	}
+	defer rows.Close()
 
-	rowsAffected, err := result.RowsAffected()
+	items, err := scanItems(rows)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, core.ErrItemNotFound
 	}
+	return items[0], nil
+}
 
-	if rowsAffected == 0 {
-		return core.ErrItemNotFound
+// Restore brings back a deleted item of a project. It keeps its position
+// unless a live item took it since, in which case it goes after the
+// project's last item; the project's row is locked meanwhile, as in
+// Duplicate. Returns core.ErrProjectNotFound unless the project is in the
+// organization in ctx and core.ErrItemNotFound unless the project has such
+// a deleted item.
+func (s *ItemStore) Restore(ctx context.Context, projectID, id string) (*core.Item, error) {
+	var item *core.Item
+	err := s.db.InTx(ctx, "items.restore", func(ctx context.Context) error {
+		if err := s.lockProject(ctx, projectID); err != nil {
+			return err
+		}
+
+		where, args := s.scopedIncluding(ctx, true, "id = $1 AND project_id = $2 AND items.deleted_at IS NOT NULL", id, projectID)
+		query := `
+			UPDATE items
+			SET deleted_at = NULL, updated_at = ` + s.db.dialect.Now() + `, version = items.version + 1, position = CASE
+				WHEN EXISTS (
+					SELECT 1 FROM items AS siblings
+					WHERE siblings.project_id = $2 AND siblings.position = items.position AND siblings.deleted_at IS NULL
+				) THEN (
+					SELECT COALESCE(MAX(siblings.position), -1) + 1
+					FROM items AS siblings
+					WHERE siblings.project_id = $2 AND siblings.deleted_at IS NULL
+				)
+				ELSE items.position
+			END
+			WHERE ` + where + `
+			RETURNING id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at, version
+		`
+
+		rows, err := s.db.Query(ctx, "items.restore", query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to restore item: %w", err)
+		}
+		defer rows.Close()
+
+		items, err := scanItems(rows)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return core.ErrItemNotFound
+		}
+		item = items[0]
+		return s.db.notify(ctx, projectChanged(projectID))
+	})
+	if err != nil {
+		return nil, err
 	}
+	return item, nil
+}
 
-	return nil
+// PurgeDeleted removes the items deleted before cutoff for good, in every
+// organization
+func (s *ItemStore) PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := s.db.Exec(ctx, "items.purge_deleted", `DELETE FROM items WHERE deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted items: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return deleted, nil
 }
 
-// UpdatePositions updates the position field for multiple items atomically
-func (s *ItemStore) UpdatePositions(ctx context.Context, updates []core.PositionUpdate) error {
+// UpdatePositions moves items of a project to new positions in a single
+// statement. It must run in a transaction (see InTx), and returns
+// core.ErrNoTransaction outside one: on engines with deferred constraints
+// it defers the unique position constraint to the commit, so items may
+// share a position until then, across any number of calls; engines that
+// check it row by row first move the items above every position in use,
+// and reject collisions as they happen. Unless every item is in the
+// project, within the organization in ctx, core.ErrItemNotFound is
+// returned. Final positions colliding with each other or with unmoved items
+// return core.ErrItemInvalidPosition where they are checked; with the
+// constraint deferred, the caller checks them before committing.
+func (s *ItemStore) UpdatePositions(ctx context.Context, projectID string, updates []core.PositionUpdate) error {
+	tx, ok := txFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: item positions are updated in one", core.ErrNoTransaction)
+	}
 	if len(updates) == 0 {
 		return nil
 	}
 
-	// Begin transaction
-	tx, err := s.db.DB().BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+	cast := s.db.dialect.Cast
+	values := make([]string, len(updates))
+	args := make([]interface{}, 1, 1+2*len(updates))
+	args[0] = projectID
+	for i, update := range updates {
+		values[i] = "(" + cast(fmt.Sprintf("$%d", len(args)+1), "uuid") + ", " + cast(fmt.Sprintf("$%d", len(args)+2), "integer") + ")"
+		args = append(args, update.ItemID, update.Position)
 	}
-	defer func() {
-		if err != nil {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				log.Ctx(ctx).Error().Err(rollbackErr).Msg("failed to rollback transaction")
-			}
-		}
-	}()
 
-	// Update each position
-	query := `UPDATE items SET position = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
-	for _, update := range updates {
-		_, err = tx.ExecContext(ctx, query, update.ItemID, update.Position)
-		if err != nil {
-			return fmt.Errorf("failed to update position for item %s: %w", update.ItemID, err)
+	// A common table expression names the columns of the values on every
+	// engine. Parking items out of the way is not a move of its own, so only
+	// the final statement increments their versions.
+	where, args := s.scoped(ctx, "items.id = v.id AND items.project_id = $1", args...)
+	move := func(position, version string) string {
+		return `
+			WITH v (id, position) AS (VALUES ` + strings.Join(values, ", ") + `)
+			UPDATE items SET position = ` + position + `, version = ` + version + `, updated_at = ` + s.db.dialect.Now() + `
+			FROM v
+			WHERE ` + where
+	}
+
+	if s.db.dialect.Capabilities().DeferredConstraints {
+		// Until the transaction ends
+		if _, err := tx.Exec(ctx, "items.defer_positions", `SET CONSTRAINTS `+itemPositionConstraint+` DEFERRED`); err != nil {
+			return fmt.Errorf("failed to defer the item position constraint: %w", err)
 		}
+	} else {
+		above := "v.position + (SELECT COALESCE(MAX(position), 0) + 1 FROM items WHERE project_id = $1)"
+		if err := s.movePositions(ctx, tx, "items.park_positions", move(above, "items.version"), args, len(updates)); err != nil {
+			return err
+		}
+	}
+	if err := s.movePositions(ctx, tx, "items.update_positions", move("v.position", "items.version + 1"), args, len(updates)); err != nil {
+		return err
 	}
+	return tx.notify(ctx, projectChanged(projectID))
+}
 
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+// movePositions runs a statement of UpdatePositions, which must move every
+// one of n items
+func (s *ItemStore) movePositions(ctx context.Context, tx *Runner, name, query string, args []interface{}, n int) error {
+	result, err := tx.Exec(ctx, name, query, args...)
+	if err != nil {
+		if violation, ok := s.db.dialect.Violation(err); ok && violation.Is(UniqueViolation, itemPositionConstraint) {
+			return fmt.Errorf("%w: two items would share a position", core.ErrItemInvalidPosition)
+		}
+		return fmt.Errorf("failed to update item positions: %w", err)
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected != int64(n) {
+		return core.ErrItemNotFound
+	}
 	return nil
-}
\ No newline at end of file
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -18,6 +20,11 @@ import (
 type LocalStorage struct {
 	basePath string
 	baseURL  string
+
+	// signer, if set, signs the URLs of GetSignedURL, which point under
+	// signedURL
+	signer    *core.AssetURLSigner
+	signedURL string
 }
 
 // NewLocalStorage creates a new local storage instance
@@ -72,7 +79,7 @@ func (ls *LocalStorage) Upload(ctx context.Context, key string, reader io.Reader
 		OriginalName: filepath.Base(key),
 		ContentType:  core.GetContentTypeFromFilename(key),
 		Size:         size,
-		UploadedAt:   time.Now(),
+		UploadedAt:   time.Now().UTC(),
 		URL:          ls.getPublicURL(key),
 		ETag:         etag,
 	}
@@ -159,10 +166,33 @@ func (ls *LocalStorage) GetURL(ctx context.Context, key string) (string, error)
 	return ls.getPublicURL(key), nil
 }
 
-// GetSignedURL returns a signed URL (not implemented for local storage)
+// SetSigner makes GetSignedURL sign URLs with signer, pointing under
+// baseURL where they are verified before the file is served
+func (ls *LocalStorage) SetSigner(signer *core.AssetURLSigner, baseURL string) {
+	ls.signer = signer
+	ls.signedURL = baseURL
+}
+
+// GetSignedURL returns a URL to the file that is valid for expiration.
+// Storage without a signer has no signed URLs.
 func (ls *LocalStorage) GetSignedURL(ctx context.Context, key string, expiration time.Duration) (string, error) {
-	// Local storage doesn't support signed URLs, return regular URL
-	return ls.GetURL(ctx, key)
+	if ls.signer == nil {
+		return "", errors.New("local storage has no URL signer")
+	}
+	exists, err := ls.Exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", core.ErrFileNotFound
+	}
+
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	query := ls.signer.Sign(key, time.Now().Add(expiration))
+	return strings.TrimSuffix(ls.signedURL, "/") + "/" + strings.Join(segments, "/") + "?" + query.Encode(), nil
 }
 
 // List lists files with optional prefix
@@ -0,0 +1,292 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// LTIStore implements core.LTIStore. Logins and sessions are read from the
+// primary, since a launch follows its login within seconds.
+type LTIStore struct {
+	db *Database
+}
+
+// NewLTIStore creates a new LTI store
+func NewLTIStore(db *Database) *LTIStore {
+	return &LTIStore{db: db}
+}
+
+const ltiPlatformColumns = `id, org_id, issuer, client_id, auth_login_url, auth_token_url, key_set_url, created_at`
+
+// CreatePlatform registers a platform
+func (s *LTIStore) CreatePlatform(ctx context.Context, platform *core.LTIPlatform) (*core.LTIPlatform, error) {
+	query := `
+		INSERT INTO lti_platforms (id, org_id, issuer, client_id, auth_login_url, auth_token_url, key_set_url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + ltiPlatformColumns + `
+	`
+
+	created, err := scanLTIPlatform(s.db.QueryRow(ctx, "lti.create_platform", query,
+		core.NewID(ctx), platform.OrgID, platform.Issuer, platform.ClientID,
+		platform.AuthLoginURL, platform.AuthTokenURL, platform.KeySetURL))
+	if violation, ok := s.db.dialect.Violation(err); ok {
+		switch violation.Kind {
+		case UniqueViolation:
+			return nil, core.ErrLTIPlatformExists
+		case ForeignKeyViolation:
+			return nil, core.ErrOrganizationNotFound
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LTI platform: %w", err)
+	}
+	return created, nil
+}
+
+// GetPlatform retrieves a platform by ID
+func (s *LTIStore) GetPlatform(ctx context.Context, id string) (*core.LTIPlatform, error) {
+	query := `SELECT ` + ltiPlatformColumns + ` FROM lti_platforms WHERE id = $1`
+
+	platform, err := scanLTIPlatform(s.db.QueryRow(ctx, "lti.get_platform", query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrLTIPlatformNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get LTI platform: %w", err)
+	}
+	return platform, nil
+}
+
+// FindPlatform retrieves the platform registered for the issuer and client
+// ID, or the issuer's only platform for an empty client ID
+func (s *LTIStore) FindPlatform(ctx context.Context, issuer, clientID string) (*core.LTIPlatform, error) {
+	query := `
+		SELECT ` + ltiPlatformColumns + `
+		FROM lti_platforms
+		WHERE issuer = $1 AND ($2 = '' OR client_id = $2)
+		LIMIT 2
+	`
+
+	platforms, err := s.queryPlatforms(ctx, "lti.find_platform", query, issuer, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if len(platforms) != 1 {
+		return nil, core.ErrLTIPlatformNotFound
+	}
+	return platforms[0], nil
+}
+
+// ListPlatforms returns every platform ordered by issuer and client ID
+func (s *LTIStore) ListPlatforms(ctx context.Context) ([]*core.LTIPlatform, error) {
+	query := `
+		SELECT ` + ltiPlatformColumns + `
+		FROM lti_platforms
+		ORDER BY issuer, client_id
+	`
+	return s.queryPlatforms(ctx, "lti.list_platforms", query)
+}
+
+// DeletePlatform removes a platform; its states, resource links and
+// sessions cascade
+func (s *LTIStore) DeletePlatform(ctx context.Context, id string) error {
+	result, err := s.db.Exec(ctx, "lti.delete_platform", `DELETE FROM lti_platforms WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete LTI platform: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return core.ErrLTIPlatformNotFound
+	}
+	return nil
+}
+
+func (s *LTIStore) queryPlatforms(ctx context.Context, name, query string, args ...interface{}) ([]*core.LTIPlatform, error) {
+	rows, err := s.db.Query(ctx, name, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list LTI platforms: %w", err)
+	}
+	defer rows.Close()
+
+	var platforms []*core.LTIPlatform
+	for rows.Next() {
+		platform, err := scanLTIPlatform(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan LTI platform: %w", err)
+		}
+		platforms = append(platforms, platform)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate LTI platforms: %w", err)
+	}
+	return platforms, nil
+}
+
+// SaveState stores a login in progress
+func (s *LTIStore) SaveState(ctx context.Context, state *core.LTIState) error {
+	query := `
+		INSERT INTO lti_states (state, nonce, platform_id, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := s.db.Exec(ctx, "lti.save_state", query, state.State, state.Nonce, state.PlatformID, state.ExpiresAt)
+	if violation, ok := s.db.dialect.Violation(err); ok && violation.Kind == ForeignKeyViolation {
+		return core.ErrLTIPlatformNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save LTI state: %w", err)
+	}
+	return nil
+}
+
+// TakeState deletes the state and returns it if it has not expired. The
+// delete settles concurrent launches with the same state: one of them
+// gets the row.
+func (s *LTIStore) TakeState(ctx context.Context, state string, now time.Time) (*core.LTIState, error) {
+	query := `
+		DELETE FROM lti_states
+		WHERE state = $1
+		RETURNING state, nonce, platform_id, expires_at
+	`
+
+	var taken core.LTIState
+	err := s.db.QueryRow(ctx, "lti.take_state", query, state).
+		Scan(&taken.State, &taken.Nonce, &taken.PlatformID, scanUTC(&taken.ExpiresAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: unknown or used state", core.ErrLTIInvalidLaunch)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take LTI state: %w", err)
+	}
+	if !taken.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("%w: the login has expired", core.ErrLTIInvalidLaunch)
+	}
+	return &taken, nil
+}
+
+const ltiResourceLinkColumns = `platform_id, deployment_id, resource_link_id, project_id, created_at`
+
+// GetResourceLink retrieves the binding of a resource link
+func (s *LTIStore) GetResourceLink(ctx context.Context, platformID, deploymentID, resourceLinkID string) (*core.LTIResourceLink, error) {
+	query := `
+		SELECT ` + ltiResourceLinkColumns + `
+		FROM lti_resource_links
+		WHERE platform_id = $1 AND deployment_id = $2 AND resource_link_id = $3
+	`
+
+	var link core.LTIResourceLink
+	err := s.db.QueryRow(ctx, "lti.get_resource_link", query, platformID, deploymentID, resourceLinkID).
+		Scan(&link.PlatformID, &link.DeploymentID, &link.ResourceLinkID, &link.ProjectID, scanUTC(&link.CreatedAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrLTIResourceLinkNotMapped
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get LTI resource link: %w", err)
+	}
+	return &link, nil
+}
+
+// BindResourceLink binds the resource link unless a concurrent launch
+// bound it first, and returns the binding that holds
+func (s *LTIStore) BindResourceLink(ctx context.Context, link *core.LTIResourceLink) (*core.LTIResourceLink, error) {
+	query := `
+		INSERT INTO lti_resource_links (platform_id, deployment_id, resource_link_id, project_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (platform_id, deployment_id, resource_link_id) DO NOTHING
+	`
+
+	_, err := s.db.Exec(ctx, "lti.bind_resource_link", query, link.PlatformID, link.DeploymentID, link.ResourceLinkID, link.ProjectID)
+	if violation, ok := s.db.dialect.Violation(err); ok && violation.Kind == ForeignKeyViolation {
+		return nil, core.ErrProjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind LTI resource link: %w", err)
+	}
+	return s.GetResourceLink(ctx, link.PlatformID, link.DeploymentID, link.ResourceLinkID)
+}
+
+const ltiSessionColumns = `id, platform_id, project_id, subject, name, email, roles, lineitem_url, created_at, expires_at`
+
+// CreateSession stores a participant session
+func (s *LTIStore) CreateSession(ctx context.Context, session *core.LTISession) error {
+	query := `
+		INSERT INTO lti_sessions (` + ltiSessionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := s.db.Exec(ctx, "lti.create_session", query,
+		session.ID, session.PlatformID, session.ProjectID, session.Subject, session.Name, session.Email,
+		strings.Join(session.Roles, " "), session.LineItemURL, session.CreatedAt, session.ExpiresAt)
+	if violation, ok := s.db.dialect.Violation(err); ok && violation.Kind == ForeignKeyViolation {
+		return core.ErrProjectNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create LTI session: %w", err)
+	}
+	return nil
+}
+
+// GetSession retrieves an unexpired session
+func (s *LTIStore) GetSession(ctx context.Context, id string, now time.Time) (*core.LTISession, error) {
+	query := `
+		SELECT ` + ltiSessionColumns + `
+		FROM lti_sessions
+		WHERE id = $1 AND expires_at > $2
+	`
+
+	var session core.LTISession
+	var roles string
+	err := s.db.QueryRow(ctx, "lti.get_session", query, id, now).Scan(
+		&session.ID, &session.PlatformID, &session.ProjectID, &session.Subject, &session.Name, &session.Email,
+		&roles, &session.LineItemURL, scanUTC(&session.CreatedAt), scanUTC(&session.ExpiresAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrLTISessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get LTI session: %w", err)
+	}
+	session.Roles = strings.Fields(roles)
+	return &session, nil
+}
+
+// DeleteExpired removes the states and sessions that expired before now
+func (s *LTIStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	for _, table := range []string{"lti_states", "lti_sessions"} {
+		result, err := s.db.Exec(ctx, "lti.delete_expired", `DELETE FROM `+table+` WHERE expires_at <= $1`, now)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete expired %s: %w", table, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return deleted, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		deleted += n
+	}
+	return deleted, nil
+}
+
+// scanLTIPlatform scans a row of ltiPlatformColumns
+func scanLTIPlatform(row rowScanner) (*core.LTIPlatform, error) {
+	var platform core.LTIPlatform
+	var orgID sql.NullString
+	err := row.Scan(&platform.ID, &orgID, &platform.Issuer, &platform.ClientID,
+		&platform.AuthLoginURL, &platform.AuthTokenURL, &platform.KeySetURL, scanUTC(&platform.CreatedAt))
+	if err != nil {
+		return nil, err
+	}
+	if orgID.Valid {
+		platform.OrgID = &orgID.String
+	}
+	return &platform, nil
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// MaintenanceStore implements maintenance switch persistence using a
+// single-row table
+type MaintenanceStore struct {
+	db *Database
+}
+
+// NewMaintenanceStore creates a new maintenance store
+func NewMaintenanceStore(db *Database) *MaintenanceStore {
+	return &MaintenanceStore{db: db}
+}
+
+// Get returns the current switch, or a disabled one if it was never set
+func (s *MaintenanceStore) Get(ctx context.Context) (core.MaintenanceMode, error) {
+	query := `
+		SELECT enabled, message, allow_reads, updated_at, updated_by
+		FROM maintenance
+		WHERE id
+	`
+
+	var mode core.MaintenanceMode
+	err := s.db.ReadQueryRow(ctx, "maintenance.get", query).Scan(
+		&mode.Enabled,
+		&mode.Message,
+		&mode.AllowReads,
+		scanUTC(&mode.UpdatedAt),
+		&mode.UpdatedBy,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return core.MaintenanceMode{}, nil
+		}
+		return core.MaintenanceMode{}, fmt.Errorf("failed to get maintenance mode: %w", err)
+	}
+
+	return mode, nil
+}
+
+// Set replaces the switch
+func (s *MaintenanceStore) Set(ctx context.Context, mode core.MaintenanceMode) error {
+	query := `
+		INSERT INTO maintenance (id, enabled, message, allow_reads, updated_at, updated_by)
+		VALUES (TRUE, $1, $2, $3, ` + s.db.dialect.Now() + `, $4)
+		ON CONFLICT (id) DO UPDATE
+		SET enabled = EXCLUDED.enabled,
+			message = EXCLUDED.message,
+			allow_reads = EXCLUDED.allow_reads,
+			updated_at = EXCLUDED.updated_at,
+			updated_by = EXCLUDED.updated_by
+	`
+
+	if _, err := s.db.Exec(ctx, "maintenance.set", query,
+		mode.Enabled, mode.Message, mode.AllowReads, mode.UpdatedBy); err != nil {
+		return fmt.Errorf("failed to set maintenance mode: %w", err)
+	}
+
+	return nil
+}
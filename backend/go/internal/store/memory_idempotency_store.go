@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// MemoryIdempotencyStore implements idempotency record persistence in process memory.
+// Suitable for tests and single-instance deployments; records are lost on restart.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*core.IdempotencyRecord
+	now     func() time.Time
+}
+
+// NewMemoryIdempotencyStore creates a new in-memory idempotency store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records: make(map[string]*core.IdempotencyRecord),
+		now:     utcNow,
+	}
+}
+
+// Get retrieves the unexpired record for a key and endpoint
+func (s *MemoryIdempotencyStore) Get(ctx context.Context, key, endpoint string) (*core.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.records[memoryIdempotencyKey(key, endpoint)]
+	if !exists || !record.ExpiresAt.After(s.now()) {
+		return nil, core.ErrIdempotencyKeyNotFound
+	}
+
+	return copyIdempotencyRecord(record), nil
+}
+
+// Reserve stores a pending record, replacing an expired one for the same key and endpoint
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, record *core.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mapKey := memoryIdempotencyKey(record.Key, record.Endpoint)
+	if existing, exists := s.records[mapKey]; exists && existing.ExpiresAt.After(s.now()) {
+		return core.ErrIdempotencyKeyExists
+	}
+
+	stored := copyIdempotencyRecord(record)
+	stored.StatusCode = 0
+	stored.Header = nil
+	stored.Body = nil
+	if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = s.now()
+	}
+	s.records[mapKey] = stored
+
+	return nil
+}
+
+// Complete stores the response of a reserved record
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, record *core.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, exists := s.records[memoryIdempotencyKey(record.Key, record.Endpoint)]
+	if !exists || !existing.Pending() {
+		return core.ErrIdempotencyKeyNotFound
+	}
+
+	completed := copyIdempotencyRecord(record)
+	existing.StatusCode = completed.StatusCode
+	existing.Header = completed.Header
+	existing.Body = completed.Body
+	existing.ExpiresAt = completed.ExpiresAt
+
+	return nil
+}
+
+// Release deletes a pending record
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key, endpoint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mapKey := memoryIdempotencyKey(key, endpoint)
+	if existing, exists := s.records[mapKey]; exists && existing.Pending() {
+		delete(s.records, mapKey)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes records that expired before the given time
+func (s *MemoryIdempotencyStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var removed int64
+	for mapKey, record := range s.records {
+		if !record.ExpiresAt.After(before) {
+			delete(s.records, mapKey)
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// copyIdempotencyRecord copies a record so callers can't change the stored one
+func copyIdempotencyRecord(record *core.IdempotencyRecord) *core.IdempotencyRecord {
+	copied := *record
+	copied.Header = record.Header.Clone()
+	copied.Body = append([]byte(nil), record.Body...)
+	return &copied
+}
+
+// memoryIdempotencyKey builds the map key for a key/endpoint pair
+func memoryIdempotencyKey(key, endpoint string) string {
+	return endpoint + "\x00" + key
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// MemoryMaintenanceStore implements maintenance switch persistence in process memory.
+// Suitable for tests and single-instance deployments; the switch is lost on restart.
+type MemoryMaintenanceStore struct {
+	mu   sync.Mutex
+	mode core.MaintenanceMode
+	now  func() time.Time
+}
+
+// NewMemoryMaintenanceStore creates a new in-memory maintenance store
+func NewMemoryMaintenanceStore() *MemoryMaintenanceStore {
+	return &MemoryMaintenanceStore{now: utcNow}
+}
+
+// Get returns the current switch, or a disabled one if it was never set
+func (s *MemoryMaintenanceStore) Get(ctx context.Context) (core.MaintenanceMode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mode, nil
+}
+
+// Set replaces the switch
+func (s *MemoryMaintenanceStore) Set(ctx context.Context, mode core.MaintenanceMode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mode.UpdatedAt = s.now()
+	s.mode = mode
+	return nil
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// MemoryOrganizationStore implements organization persistence in process memory.
+// Suitable for tests and single-instance deployments; organizations are lost on restart.
+type MemoryOrganizationStore struct {
+	mu      sync.Mutex
+	orgs    map[string]*core.Organization
+	members map[string]map[string]*core.Membership
+	now     func() time.Time
+}
+
+// NewMemoryOrganizationStore creates a new in-memory organization store
+func NewMemoryOrganizationStore() *MemoryOrganizationStore {
+	return &MemoryOrganizationStore{
+		orgs:    make(map[string]*core.Organization),
+		members: make(map[string]map[string]*core.Membership),
+		now:     utcNow,
+	}
+}
+
+// Create creates a new organization
+func (s *MemoryOrganizationStore) Create(ctx context.Context, name string, maxProjects *int) (*core.Organization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	org := &core.Organization{
+		ID:          core.NewID(ctx),
+		Name:        name,
+		MaxProjects: maxProjects,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	s.orgs[org.ID] = org
+	s.members[org.ID] = make(map[string]*core.Membership)
+
+	copied := *org
+	return &copied, nil
+}
+
+// GetByID retrieves an organization by ID
+func (s *MemoryOrganizationStore) GetByID(ctx context.Context, id string) (*core.Organization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org, ok := s.orgs[id]
+	if !ok {
+		return nil, core.ErrOrganizationNotFound
+	}
+	copied := *org
+	return &copied, nil
+}
+
+// List returns every organization ordered by name
+func (s *MemoryOrganizationStore) List(ctx context.Context) ([]*core.Organization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgs := make([]*core.Organization, 0, len(s.orgs))
+	for _, org := range s.orgs {
+		copied := *org
+		orgs = append(orgs, &copied)
+	}
+	sort.Slice(orgs, func(i, j int) bool {
+		if orgs[i].Name != orgs[j].Name {
+			return orgs[i].Name < orgs[j].Name
+		}
+		return orgs[i].ID < orgs[j].ID
+	})
+	return orgs, nil
+}
+
+// Update changes an organization's name and quota
+func (s *MemoryOrganizationStore) Update(ctx context.Context, id, name string, maxProjects *int) (*core.Organization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org, ok := s.orgs[id]
+	if !ok {
+		return nil, core.ErrOrganizationNotFound
+	}
+	org.Name = name
+	org.MaxProjects = maxProjects
+	org.UpdatedAt = s.now()
+
+	copied := *org
+	return &copied, nil
+}
+
+// Delete removes an organization and its memberships
+func (s *MemoryOrganizationStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.orgs[id]; !ok {
+		return core.ErrOrganizationNotFound
+	}
+	delete(s.orgs, id)
+	delete(s.members, id)
+	return nil
+}
+
+// ListMembers returns the organization's members ordered by user ID
+func (s *MemoryOrganizationStore) ListMembers(ctx context.Context, orgID string) ([]*core.Membership, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members, ok := s.members[orgID]
+	if !ok {
+		return nil, core.ErrOrganizationNotFound
+	}
+
+	list := make([]*core.Membership, 0, len(members))
+	for _, member := range members {
+		copied := *member
+		list = append(list, &copied)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].UserID < list[j].UserID
+	})
+	return list, nil
+}
+
+// PutMember adds the user to the organization or changes their role
+func (s *MemoryOrganizationStore) PutMember(ctx context.Context, orgID, userID, role string) (*core.Membership, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members, ok := s.members[orgID]
+	if !ok {
+		return nil, core.ErrOrganizationNotFound
+	}
+
+	member, ok := members[userID]
+	if !ok {
+		member = &core.Membership{OrgID: orgID, UserID: userID, CreatedAt: s.now()}
+		members[userID] = member
+	}
+	member.Role = role
+
+	copied := *member
+	return &copied, nil
+}
+
+// RemoveMember removes the user from the organization
+func (s *MemoryOrganizationStore) RemoveMember(ctx context.Context, orgID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.members[orgID][userID]; !ok {
+		return core.ErrMembershipNotFound
+	}
+	delete(s.members[orgID], userID)
+	return nil
+}
+
+// GetMembership returns the user's membership of the organization
+func (s *MemoryOrganizationStore) GetMembership(ctx context.Context, orgID, userID string) (*core.Membership, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	member, ok := s.members[orgID][userID]
+	if !ok {
+		return nil, core.ErrMembershipNotFound
+	}
+	copied := *member
+	return &copied, nil
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// MemorySettingsStore implements runtime setting persistence in process memory.
+// Suitable for tests and single-instance deployments; overrides are lost on restart.
+type MemorySettingsStore struct {
+	mu       sync.Mutex
+	settings map[string]core.Setting
+	now      func() time.Time
+}
+
+// NewMemorySettingsStore creates a new in-memory settings store
+func NewMemorySettingsStore() *MemorySettingsStore {
+	return &MemorySettingsStore{
+		settings: make(map[string]core.Setting),
+		now:      utcNow,
+	}
+}
+
+// List returns every override, sorted by key
+func (s *MemorySettingsStore) List(ctx context.Context) ([]core.Setting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings := make([]core.Setting, 0, len(s.settings))
+	for _, setting := range s.settings {
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].Key < settings[j].Key
+	})
+	return settings, nil
+}
+
+// Update stores the given values and removes the overrides of keys mapped
+// to nil
+func (s *MemorySettingsStore) Update(ctx context.Context, changes map[string]*string, updatedBy string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, value := range changes {
+		if value == nil {
+			delete(s.settings, key)
+			continue
+		}
+		s.settings[key] = core.Setting{Key: key, Value: *value, UpdatedAt: now, UpdatedBy: updatedBy}
+	}
+	return nil
+}
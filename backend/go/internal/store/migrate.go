@@ -0,0 +1,380 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/rs/zerolog/log"
+)
+
+// MigrationsTable records the applied schema version and whether the last
+// migration failed halfway (dirty)
+const MigrationsTable = "schema_migrations"
+
+// migrationsHistoryTable records when each applied version was first seen
+// applied. golang-migrate itself keeps only the current version.
+const migrationsHistoryTable = "schema_migrations_history"
+
+// migrationFiles holds the numbered up/down SQL migrations, named
+// NNNN_description.up.sql and NNNN_description.down.sql: Postgres ones in
+// migrations and SQLite ones in migrations/sqlite. The SQLite schema starts
+// from the Postgres schema of version 6, without its full-text search; later
+// migrations are added to both, each directory numbered in its own sequence,
+// unless only one engine needs them, like Postgres's conversion of timestamp
+// columns to time zones in version 9.
+//
+//go:embed migrations/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+// MigrationInfo is one embedded migration and whether it is applied
+type MigrationInfo struct {
+	Version uint
+	Name    string
+	Applied bool
+	// AppliedAt is when the version was recorded as applied, if known
+	AppliedAt *time.Time
+}
+
+// MigrationStatus is the schema's migration state
+type MigrationStatus struct {
+	// Version is the current version, 0 before the first migration
+	Version uint
+	// Dirty means migration Version failed halfway and the schema needs
+	// repairing by hand before Force marks it clean
+	Dirty      bool
+	Latest     uint
+	Migrations []MigrationInfo
+}
+
+// Migrate applies every pending migration and logs the resulting schema
+// version. Replicas starting together are serialized by the advisory lock
+// golang-migrate takes on the migrations table, so only one applies each
+// migration. Cancelling ctx stops after the migration in progress.
+func (d *Database) Migrate(ctx context.Context) error {
+	m, err := d.Migrator(ctx)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	log.Info().Msg("running database migrations")
+	if err := m.Up(ctx); err != nil {
+		return err
+	}
+
+	version, dirty, err := d.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	log.Info().
+		Uint("schema_version", version).
+		Bool("dirty", dirty).
+		Msg("database migrations completed successfully")
+	return nil
+}
+
+// SchemaVersion returns the applied migration version, 0 before the first
+// migration, and whether the last migration failed halfway. It reads the
+// migrations table directly, without taking the migration lock.
+func (d *Database) SchemaVersion(ctx context.Context) (version uint, dirty bool, err error) {
+	err = d.QueryRow(ctx, "schema.version", `SELECT version, dirty FROM `+MigrationsTable+` LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// LatestMigrationVersion returns the version of the newest embedded
+// migration for the database's engine
+func (d *Database) LatestMigrationVersion() uint {
+	migrations, err := embeddedMigrations(d.dialect)
+	if err != nil || len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// Migrator applies, inspects and rolls back the embedded migrations. Each
+// operation holds golang-migrate's advisory lock while it runs and stops
+// after the migration in progress when its context is cancelled.
+type Migrator struct {
+	db      *Database
+	migrate *migrate.Migrate
+}
+
+// Migrator creates a Migrator on a connection of its own, returned to the
+// pool (or, for SQLite, closed) by Close
+func (d *Database) Migrator(ctx context.Context) (*Migrator, error) {
+	src, err := iofs.New(migrationFiles, d.dialect.migrationsDir())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	driver, err := d.migrationDriver(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, d.dialect.Name(), driver)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("failed to prepare migrations: %w", err)
+	}
+	m.Log = migrateLogger{}
+	return &Migrator{db: d, migrate: m}, nil
+}
+
+// migrationDriver connects golang-migrate to the database. The SQLite driver
+// closes the database it is given, so it gets one of its own.
+func (d *Database) migrationDriver(ctx context.Context) (database.Driver, error) {
+	if _, ok := d.dialect.(sqliteDialect); ok {
+		db, err := sql.Open(d.dialect.driverName(), d.dsn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database for migrations: %w", err)
+		}
+		driver, err := sqlite3.WithInstance(db, &sqlite3.Config{MigrationsTable: MigrationsTable})
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to prepare migrations: %w", err)
+		}
+		return driver, nil
+	}
+
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection for migrations: %w", err)
+	}
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{MigrationsTable: MigrationsTable})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to prepare migrations: %w", err)
+	}
+	return driver, nil
+}
+
+// Close releases the migrator's connection
+func (m *Migrator) Close() {
+	if sourceErr, dbErr := m.migrate.Close(); sourceErr != nil || dbErr != nil {
+		log.Warn().
+			AnErr("source_error", sourceErr).
+			AnErr("database_error", dbErr).
+			Msg("failed to close migrator")
+	}
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.run(ctx, "apply migrations", m.migrate.Up)
+}
+
+// Down rolls back the given number of applied migrations
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+	return m.run(ctx, "roll back migrations", func() error { return m.migrate.Steps(-steps) })
+}
+
+// Goto migrates up or down to the given version; 0 rolls back every
+// migration
+func (m *Migrator) Goto(ctx context.Context, version uint) error {
+	return m.run(ctx, fmt.Sprintf("migrate to version %d", version), func() error {
+		if version == 0 {
+			return m.migrate.Down()
+		}
+		return m.migrate.Migrate(version)
+	})
+}
+
+// Force records version as applied and clean without running anything, 0
+// meaning no migration is applied. It is the way out of a dirty schema once
+// it has been repaired by hand.
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	return m.run(ctx, fmt.Sprintf("force version %d", version), func() error {
+		if version == 0 {
+			return m.migrate.Force(database.NilVersion)
+		}
+		return m.migrate.Force(int(version))
+	})
+}
+
+// Drop drops every table in the schema, migrations tables included
+func (m *Migrator) Drop(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() { m.migrate.GracefulStop <- true })
+	defer stop()
+
+	if err := m.migrate.Drop(); err != nil {
+		return fmt.Errorf("failed to drop schema: %w", err)
+	}
+	return nil
+}
+
+// Status lists every embedded migration with whether, and since when, it
+// is applied
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	migrations, err := embeddedMigrations(m.db.dialect)
+	if err != nil {
+		return nil, err
+	}
+	version, dirty, err := m.db.SchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	appliedAt, err := m.history(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{Version: version, Dirty: dirty, Migrations: migrations}
+	for i := range status.Migrations {
+		migration := &status.Migrations[i]
+		migration.Applied = isApplied(migration.Version, version, dirty)
+		if at, ok := appliedAt[migration.Version]; ok && migration.Applied {
+			migration.AppliedAt = &at
+		}
+		status.Latest = migration.Version
+	}
+	return status, nil
+}
+
+// run performs a golang-migrate operation, treating "nothing to do" as
+// success, and then brings the history in line with the new version
+func (m *Migrator) run(ctx context.Context, action string, operation func() error) error {
+	stop := context.AfterFunc(ctx, func() { m.migrate.GracefulStop <- true })
+	defer stop()
+
+	if err := operation(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+	return m.recordHistory(ctx)
+}
+
+// recordHistory adds the versions that are now applied to the history and
+// removes rolled back ones. Versions applied by one operation share its
+// completion time.
+func (m *Migrator) recordHistory(ctx context.Context) error {
+	version, dirty, err := m.db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	migrations, err := embeddedMigrations(m.db.dialect)
+	if err != nil {
+		return err
+	}
+
+	return m.db.Transaction(ctx, "schema.history", func(tx *Runner) error {
+		if _, err := tx.Exec(ctx, "schema.history.create", `
+			CREATE TABLE IF NOT EXISTS `+migrationsHistoryTable+` (
+				version BIGINT PRIMARY KEY,
+				applied_at `+m.db.dialect.Timestamp()+` NOT NULL DEFAULT (`+m.db.dialect.Now()+`)
+			)`); err != nil {
+			return fmt.Errorf("failed to create migrations history table: %w", err)
+		}
+
+		for _, migration := range migrations {
+			if isApplied(migration.Version, version, dirty) {
+				_, err = tx.Exec(ctx, "schema.history.insert",
+					`INSERT INTO `+migrationsHistoryTable+` (version) VALUES ($1) ON CONFLICT (version) DO NOTHING`,
+					migration.Version)
+			} else {
+				_, err = tx.Exec(ctx, "schema.history.delete",
+					`DELETE FROM `+migrationsHistoryTable+` WHERE version = $1`, migration.Version)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to record migrations history: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// history returns when each version was recorded as applied. Schemas
+// migrated before the history was kept have none.
+func (m *Migrator) history(ctx context.Context) (map[uint]time.Time, error) {
+	var exists bool
+	if err := m.db.QueryRow(ctx, "schema.history.exists", m.db.dialect.TableExists(), migrationsHistoryTable).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to read migrations history: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	rows, err := m.db.Query(ctx, "schema.history.list", `SELECT version, applied_at FROM `+migrationsHistoryTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations history: %w", err)
+	}
+	defer rows.Close()
+
+	appliedAt := make(map[uint]time.Time)
+	for rows.Next() {
+		var version uint
+		var at time.Time
+		if err := rows.Scan(&version, scanUTC(&at)); err != nil {
+			return nil, fmt.Errorf("failed to read migrations history: %w", err)
+		}
+		appliedAt[version] = at
+	}
+	return appliedAt, rows.Err()
+}
+
+// isApplied reports whether migration is fully applied at the current
+// version. A dirty current version failed halfway, so it is not.
+func isApplied(migration, current uint, dirty bool) bool {
+	return migration < current || (migration == current && !dirty)
+}
+
+// embeddedMigrations lists the embedded migrations of dialect in version
+// order
+func embeddedMigrations(dialect Dialect) ([]MigrationInfo, error) {
+	src, err := iofs.New(migrationFiles, dialect.migrationsDir())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	defer src.Close()
+
+	var migrations []MigrationInfo
+	version, err := src.First()
+	for err == nil {
+		migrations = append(migrations, MigrationInfo{Version: version, Name: migrationName(src, version)})
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	return migrations, nil
+}
+
+func migrationName(src source.Driver, version uint) string {
+	up, name, err := src.ReadUp(version)
+	if err != nil {
+		return ""
+	}
+	up.Close()
+	return name
+}
+
+// migrateLogger routes golang-migrate's progress messages to zerolog
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...interface{}) {
+	log.Info().Msg("migrate: " + strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (migrateLogger) Verbose() bool {
+	return false
+}
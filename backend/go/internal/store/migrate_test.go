@@ -0,0 +1,73 @@
+package store
+
+import (
+	"io/fs"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations_AreContiguousWithDownPaths(t *testing.T) {
+	for _, dialect := range []Dialect{postgresDialect{}, sqliteDialect{}} {
+		t.Run(dialect.Name(), func(t *testing.T) {
+			// Arrange
+			source, err := iofs.New(migrationFiles, dialect.migrationsDir())
+			require.NoError(t, err)
+			defer source.Close()
+
+			// Act
+			var versions []uint
+			version, err := source.First()
+			for err == nil {
+				versions = append(versions, version)
+
+				up, _, upErr := source.ReadUp(version)
+				require.NoError(t, upErr, "up migration %d", version)
+				up.Close()
+				down, _, downErr := source.ReadDown(version)
+				require.NoError(t, downErr, "down migration %d", version)
+				down.Close()
+
+				version, err = source.Next(version)
+			}
+
+			// Assert
+			require.ErrorIs(t, err, fs.ErrNotExist)
+			require.NotEmpty(t, versions)
+			for i, version := range versions {
+				assert.Equal(t, uint(i+1), version, "migrations must be numbered 1, 2, 3, ...")
+			}
+		})
+	}
+}
+
+// sqlComment and sqlString match what timestamp types are looked for
+// outside of
+var (
+	sqlComment = regexp.MustCompile(`--[^\n]*`)
+	sqlString  = regexp.MustCompile(`'(?:[^']|'')*'`)
+)
+
+// timestampType matches a timestamp column type and its time zone clause
+var timestampType = regexp.MustCompile(`(?i)\bTIMESTAMP\b(\s+WITH(?:OUT)?\s+TIME\s+ZONE)?`)
+
+func TestMigrations_TimestampsHaveTimeZones(t *testing.T) {
+	files, err := fs.Glob(migrationFiles, postgresDialect{}.migrationsDir()+"/*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		content, err := fs.ReadFile(migrationFiles, file)
+		require.NoError(t, err)
+
+		statements := sqlString.ReplaceAllString(sqlComment.ReplaceAllString(string(content), ""), "''")
+		for _, match := range timestampType.FindAllStringSubmatch(statements, -1) {
+			zone := strings.ToUpper(strings.Join(strings.Fields(match[1]), " "))
+			assert.Equal(t, "WITH TIME ZONE", zone, "%s declares %q: timestamps must be TIMESTAMP WITH TIME ZONE", file, match[0])
+		}
+	}
+}
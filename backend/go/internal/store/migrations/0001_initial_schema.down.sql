@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS org_memberships;
+DROP TABLE IF EXISTS items;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS organizations;
+DROP TABLE IF EXISTS settings;
+DROP TABLE IF EXISTS maintenance;
+DROP FUNCTION IF EXISTS update_updated_at_column();
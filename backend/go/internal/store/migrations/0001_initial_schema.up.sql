@@ -0,0 +1,106 @@
+-- Schema as created by the inline migrations that preceded versioned ones.
+-- Every statement is idempotent so databases created by those converge on
+-- the same schema as fresh ones, and are then recorded at version 1.
+
+CREATE TABLE IF NOT EXISTS projects (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	title VARCHAR(200) NOT NULL CHECK (char_length(title) > 0),
+	description TEXT,
+	tags JSONB DEFAULT '[]'::jsonb,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	published_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_created_at
+ON projects (created_at DESC);
+
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+	NEW.updated_at = NOW();
+	RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS update_projects_updated_at ON projects;
+CREATE TRIGGER update_projects_updated_at
+	BEFORE UPDATE ON projects
+	FOR EACH ROW
+	EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS items (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	type VARCHAR(50) NOT NULL CHECK (type IN ('title', 'media', 'choice', 'multi_choice', 'text_entry', 'ordering', 'hotspot')),
+	title VARCHAR(500) NOT NULL CHECK (char_length(title) > 0),
+	content JSONB DEFAULT '{}'::jsonb,
+	position INTEGER NOT NULL CHECK (position >= 0),
+	required BOOLEAN DEFAULT false,
+	points INTEGER CHECK (points IS NULL OR (points >= 0 AND points <= 1000)),
+	explanation TEXT,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	UNIQUE(project_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_items_project_position
+ON items (project_id, position ASC);
+
+CREATE INDEX IF NOT EXISTS idx_items_created_at
+ON items (created_at DESC);
+
+DROP TRIGGER IF EXISTS update_items_updated_at ON items;
+CREATE TRIGGER update_items_updated_at
+	BEFORE UPDATE ON items
+	FOR EACH ROW
+	EXECUTE FUNCTION update_updated_at_column();
+
+-- The CHECK on the boolean primary key allows a single row, shared by every
+-- replica
+CREATE TABLE IF NOT EXISTS maintenance (
+	id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+	enabled BOOLEAN NOT NULL DEFAULT false,
+	message TEXT NOT NULL DEFAULT '',
+	allow_reads BOOLEAN NOT NULL DEFAULT false,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_by VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+-- Only overridden runtime settings have a row; the rest keep their
+-- configured value
+CREATE TABLE IF NOT EXISTS settings (
+	key VARCHAR(100) PRIMARY KEY,
+	value TEXT NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_by VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+-- Projects without an organization belong to the single-tenant deployment
+CREATE TABLE IF NOT EXISTS organizations (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	name VARCHAR(200) NOT NULL CHECK (char_length(name) > 0),
+	max_projects INTEGER CHECK (max_projects IS NULL OR max_projects >= 0),
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS update_organizations_updated_at ON organizations;
+CREATE TRIGGER update_organizations_updated_at
+	BEFORE UPDATE ON organizations
+	FOR EACH ROW
+	EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS org_memberships (
+	org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+	user_id VARCHAR(255) NOT NULL,
+	role VARCHAR(20) NOT NULL CHECK (role IN ('admin', 'member')),
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	PRIMARY KEY (org_id, user_id)
+);
+
+ALTER TABLE projects
+ADD COLUMN IF NOT EXISTS org_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_projects_org_id_created_at
+ON projects (org_id, created_at DESC);
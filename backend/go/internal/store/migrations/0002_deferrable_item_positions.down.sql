@@ -0,0 +1,3 @@
+ALTER TABLE items DROP CONSTRAINT items_project_id_position_key;
+ALTER TABLE items ADD CONSTRAINT items_project_id_position_key
+	UNIQUE (project_id, position);
@@ -0,0 +1,6 @@
+-- Reordering items moves them in a single UPDATE, during which two items can
+-- briefly share a position (e.g. when swapping). A deferrable constraint is
+-- checked at the end of the statement rather than row by row.
+ALTER TABLE items DROP CONSTRAINT items_project_id_position_key;
+ALTER TABLE items ADD CONSTRAINT items_project_id_position_key
+	UNIQUE (project_id, position) DEFERRABLE INITIALLY IMMEDIATE;
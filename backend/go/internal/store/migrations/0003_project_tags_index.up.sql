@@ -0,0 +1,3 @@
+-- Serves the tag filter of project lists, tags @> '["a","b"]'
+CREATE INDEX IF NOT EXISTS idx_projects_tags
+ON projects USING GIN (tags jsonb_path_ops);
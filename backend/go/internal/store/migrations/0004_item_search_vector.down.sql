@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS update_items_search_vector ON items;
+ALTER TABLE items DROP COLUMN IF EXISTS search_vector;
+DROP FUNCTION IF EXISTS update_item_search_vector();
+DROP FUNCTION IF EXISTS item_search_vector(TEXT, JSONB);
@@ -0,0 +1,30 @@
+-- Full-text search over items: the title, weighted A, and the text of
+-- their content, weighted B: choice texts, ordering texts and the accepted
+-- answer of text entries. A trigger rather than a generated column keeps
+-- the vector in sync, so adding the column doesn't rewrite the table and
+-- existing rows can be backfilled in batches (0005).
+CREATE OR REPLACE FUNCTION item_search_vector(title TEXT, content JSONB)
+RETURNS tsvector AS $$
+	SELECT setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+		setweight(jsonb_to_tsvector('english',
+			jsonb_path_query_array(coalesce(content, '{}'), '$.choices[*].text') ||
+			jsonb_path_query_array(coalesce(content, '{}'), '$.items[*].text') ||
+			jsonb_path_query_array(coalesce(content, '{}'), '$.correct_answer'),
+			'["string"]'), 'B')
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION update_item_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+	NEW.search_vector = item_search_vector(NEW.title, NEW.content);
+	RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+ALTER TABLE items ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+DROP TRIGGER IF EXISTS update_items_search_vector ON items;
+CREATE TRIGGER update_items_search_vector
+	BEFORE INSERT OR UPDATE OF title, content ON items
+	FOR EACH ROW
+	EXECUTE FUNCTION update_item_search_vector();
@@ -0,0 +1 @@
+-- Nothing to undo: rolling back 0004 drops the column
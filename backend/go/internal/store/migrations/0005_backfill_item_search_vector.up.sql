@@ -0,0 +1,16 @@
+-- Fills the search vector of items created before 0004, committing every
+-- 1000 rows so no lock is held for long. It must stay the only statement
+-- in this file: COMMIT is not allowed inside a multi-statement transaction.
+DO $$
+DECLARE
+	updated INTEGER;
+BEGIN
+	LOOP
+		UPDATE items SET search_vector = item_search_vector(title, content)
+		WHERE id IN (SELECT id FROM items WHERE search_vector IS NULL LIMIT 1000);
+		GET DIAGNOSTICS updated = ROW_COUNT;
+		EXIT WHEN updated = 0;
+		COMMIT;
+	END LOOP;
+END
+$$;
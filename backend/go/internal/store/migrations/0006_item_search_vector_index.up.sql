@@ -0,0 +1,4 @@
+-- Built concurrently so item writes continue meanwhile, which requires
+-- running outside a transaction, as the only statement in the file
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_items_search_vector
+ON items USING GIN (search_vector);
@@ -0,0 +1,5 @@
+-- Serves keyset pages of project lists, (created_at, id) < (cursor), in
+-- the order of the default sort. Built concurrently, as the only statement
+-- in the file, so project writes continue meanwhile.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_projects_created_at_id
+ON projects (created_at DESC, id DESC);
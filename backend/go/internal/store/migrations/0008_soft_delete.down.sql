@@ -0,0 +1,10 @@
+-- Deleted rows cannot be kept: their positions may collide with live items
+DELETE FROM items WHERE deleted_at IS NOT NULL;
+DELETE FROM projects WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE items DROP CONSTRAINT items_project_id_position_key;
+ALTER TABLE items ADD CONSTRAINT items_project_id_position_key
+	UNIQUE (project_id, position) DEFERRABLE INITIALLY IMMEDIATE;
+
+ALTER TABLE items DROP COLUMN deleted_at;
+ALTER TABLE projects DROP COLUMN deleted_at;
@@ -0,0 +1,13 @@
+-- Deleting a project or an item stamps deleted_at instead of removing the
+-- row. The stores leave deleted rows out of every query.
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE items ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- A deleted item's position can be taken again. A unique index restricted
+-- to live items cannot be deferred, which reordering relies on to swap
+-- positions; an exclusion constraint on equality is the deferrable
+-- equivalent, and keeps the constraint's name.
+ALTER TABLE items DROP CONSTRAINT items_project_id_position_key;
+ALTER TABLE items ADD CONSTRAINT items_project_id_position_key
+	EXCLUDE USING btree (project_id WITH =, position WITH =) WHERE (deleted_at IS NULL)
+	DEFERRABLE INITIALLY IMMEDIATE;
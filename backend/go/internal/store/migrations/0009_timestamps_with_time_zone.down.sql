@@ -0,0 +1,2 @@
+-- Nothing to undo: the columns already had time zones, and converting one
+-- back would lose them
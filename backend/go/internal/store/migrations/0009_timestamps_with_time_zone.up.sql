@@ -0,0 +1,24 @@
+-- Every timestamp column is TIMESTAMP WITH TIME ZONE: one without a time
+-- zone stores the session's wall-clock time, which changes meaning with the
+-- server's time zone. Converts any that is not, reading its values as UTC.
+-- TestMigrations_TimestampsHaveTimeZones keeps later migrations from adding
+-- one.
+DO $$
+DECLARE
+	col RECORD;
+BEGIN
+	FOR col IN
+		SELECT c.table_name, c.column_name
+		FROM information_schema.columns c
+		JOIN information_schema.tables t
+			ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = 'public'
+			AND t.table_type = 'BASE TABLE'
+			AND c.data_type = 'timestamp without time zone'
+	LOOP
+		RAISE NOTICE 'converting %.% to timestamptz', col.table_name, col.column_name;
+		EXECUTE format('ALTER TABLE %I ALTER COLUMN %I TYPE TIMESTAMP WITH TIME ZONE USING %I AT TIME ZONE ''UTC''',
+			col.table_name, col.column_name, col.column_name);
+	END LOOP;
+END
+$$;
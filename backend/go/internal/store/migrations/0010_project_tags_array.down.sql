@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_projects_tags_arr;
+ALTER TABLE projects DROP COLUMN IF EXISTS tags_arr;
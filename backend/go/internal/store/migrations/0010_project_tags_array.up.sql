@@ -0,0 +1,19 @@
+-- Tags move from the JSONB array to a text array, which a GIN index serves
+-- for the tag filter of project lists, tags_arr @> '{a,b}'. Projects whose
+-- tags are NULL or an empty JSON array get an empty array, never NULL.
+-- The stores keep writing the JSONB column while DB_WRITE_LEGACY_TAGS is
+-- on, so the previous release can be rolled back to; a later migration
+-- drops it.
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS tags_arr TEXT[] NOT NULL DEFAULT '{}';
+
+UPDATE projects
+SET tags_arr = ARRAY(
+	SELECT tag
+	FROM jsonb_array_elements_text(tags) WITH ORDINALITY AS elements (tag, n)
+	WHERE tag IS NOT NULL
+	ORDER BY n
+)
+WHERE jsonb_typeof(tags) = 'array';
+
+CREATE INDEX IF NOT EXISTS idx_projects_tags_arr
+ON projects USING GIN (tags_arr);
@@ -0,0 +1,7 @@
+-- The shared editing document of each project, saved by the collaboration
+-- hub as the Yjs updates that built it. It goes with its project.
+CREATE TABLE IF NOT EXISTS collab_docs (
+	project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+	state BYTEA NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,12 @@
+-- Soft edit locks on items, one per item. A lock past expires_at is free for
+-- anyone to take; rows go with their item.
+CREATE TABLE IF NOT EXISTS item_locks (
+	item_id UUID PRIMARY KEY REFERENCES items(id) ON DELETE CASCADE,
+	project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	holder_id TEXT NOT NULL,
+	acquired_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_locks_project_id
+	ON item_locks(project_id, expires_at);
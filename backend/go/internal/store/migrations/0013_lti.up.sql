@@ -0,0 +1,59 @@
+-- LTI 1.3 platforms registered to launch projects, and the launches they
+-- make. A platform launches the projects of its organization, or those of
+-- no organization when it has none.
+CREATE TABLE IF NOT EXISTS lti_platforms (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	org_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+	issuer TEXT NOT NULL,
+	client_id TEXT NOT NULL,
+	auth_login_url TEXT NOT NULL,
+	auth_token_url TEXT NOT NULL,
+	key_set_url TEXT NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	CONSTRAINT lti_platforms_issuer_client_id_key UNIQUE (issuer, client_id)
+);
+
+-- Logins waiting for their launch; each state is used once
+CREATE TABLE IF NOT EXISTS lti_states (
+	state TEXT PRIMARY KEY,
+	nonce TEXT NOT NULL,
+	platform_id UUID NOT NULL REFERENCES lti_platforms(id) ON DELETE CASCADE,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_lti_states_expires_at
+	ON lti_states(expires_at);
+
+-- The project each placement of the tool in a course launches
+CREATE TABLE IF NOT EXISTS lti_resource_links (
+	platform_id UUID NOT NULL REFERENCES lti_platforms(id) ON DELETE CASCADE,
+	deployment_id TEXT NOT NULL,
+	resource_link_id TEXT NOT NULL,
+	project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	PRIMARY KEY (platform_id, deployment_id, resource_link_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lti_resource_links_project_id
+	ON lti_resource_links(project_id);
+
+-- Participants launched into a project. The id is the SHA-256 of the
+-- participant's token, which is never stored.
+CREATE TABLE IF NOT EXISTS lti_sessions (
+	id CHAR(64) PRIMARY KEY,
+	platform_id UUID NOT NULL REFERENCES lti_platforms(id) ON DELETE CASCADE,
+	project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	subject TEXT NOT NULL,
+	name TEXT NOT NULL DEFAULT '',
+	email TEXT NOT NULL DEFAULT '',
+	roles TEXT NOT NULL DEFAULT '',
+	lineitem_url TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_lti_sessions_project_id
+	ON lti_sessions(project_id);
+
+CREATE INDEX IF NOT EXISTS idx_lti_sessions_expires_at
+	ON lti_sessions(expires_at);
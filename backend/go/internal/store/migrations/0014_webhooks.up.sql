@@ -0,0 +1,47 @@
+-- Webhooks users subscribe to the events of the projects of their
+-- organization, or of no organization when org_id is NULL. Events are the
+-- subscribed event types, space-separated. The secret signs deliveries, so
+-- it is kept as is.
+CREATE TABLE IF NOT EXISTS webhooks (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id VARCHAR(255) NOT NULL,
+	org_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+	owner_email TEXT NOT NULL DEFAULT '',
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	events TEXT NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT true,
+	failure_count INTEGER NOT NULL DEFAULT 0,
+	disabled_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id
+	ON webhooks(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_org_id
+	ON webhooks(org_id);
+
+-- Events sent, or to be sent, to webhooks; purged after a retention period
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+	event_id TEXT NOT NULL,
+	event_type TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	response_status INTEGER,
+	response_body TEXT NOT NULL DEFAULT '',
+	latency_ms BIGINT NOT NULL DEFAULT 0,
+	error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id_created_at
+	ON webhook_deliveries(webhook_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at
+	ON webhook_deliveries(created_at);
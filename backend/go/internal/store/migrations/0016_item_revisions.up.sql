@@ -0,0 +1,12 @@
+-- The earlier states of items: every update or delete of an item keeps
+-- the row it replaced, numbered from 1 per item. Only the newest
+-- ITEM_REVISION_LIMIT revisions of an item are kept; rows go with their
+-- item.
+CREATE TABLE IF NOT EXISTS item_revisions (
+	item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+	revision_number INTEGER NOT NULL,
+	snapshot JSONB NOT NULL,
+	changed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	changed_by TEXT,
+	PRIMARY KEY (item_id, revision_number)
+);
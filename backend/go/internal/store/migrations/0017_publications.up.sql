@@ -0,0 +1,10 @@
+-- The projects as they were published: every publish freezes the project
+-- and its items, numbered from 1 per project, so later edits don't change
+-- what learners see. Rows go with their project.
+CREATE TABLE IF NOT EXISTS publications (
+	project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	version INTEGER NOT NULL,
+	payload JSONB NOT NULL,
+	published_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	PRIMARY KEY (project_id, version)
+);
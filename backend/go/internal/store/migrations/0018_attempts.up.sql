@@ -0,0 +1,24 @@
+-- Learners' attempts at published projects, and their answers. An attempt
+-- takes the publication that was latest when it started (publication
+-- version 0 takes the project's live items), by a signed-in participant or
+-- an anonymous one when participant_id is NULL. It takes answers, one per
+-- item, until it is submitted. Rows go with their project.
+CREATE TABLE IF NOT EXISTS attempts (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	publication_version INTEGER NOT NULL,
+	participant_id TEXT,
+	started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	submitted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_attempts_project_id_started_at
+	ON attempts(project_id, started_at);
+
+CREATE TABLE IF NOT EXISTS answers (
+	attempt_id UUID NOT NULL REFERENCES attempts(id) ON DELETE CASCADE,
+	item_id UUID NOT NULL,
+	response JSONB NOT NULL,
+	answered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	PRIMARY KEY (attempt_id, item_id)
+);
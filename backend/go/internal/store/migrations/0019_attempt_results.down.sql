@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS attempt_results;
+ALTER TABLE attempts DROP COLUMN IF EXISTS max_score;
+ALTER TABLE attempts DROP COLUMN IF EXISTS score;
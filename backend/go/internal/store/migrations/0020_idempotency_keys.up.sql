@@ -0,0 +1,16 @@
+-- Responses to requests sent with an Idempotency-Key, kept until expires_at
+-- so a retry of the same request gets the same response
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	key VARCHAR(255) NOT NULL,
+	endpoint VARCHAR(1000) NOT NULL,
+	request_hash CHAR(64) NOT NULL,
+	status_code INTEGER NOT NULL,
+	content_type VARCHAR(255),
+	response_body BYTEA,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	PRIMARY KEY (key, endpoint)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at
+ON idempotency_keys (expires_at);
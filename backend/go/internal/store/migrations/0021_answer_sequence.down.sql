@@ -0,0 +1 @@
+ALTER TABLE answers DROP COLUMN IF EXISTS sequence;
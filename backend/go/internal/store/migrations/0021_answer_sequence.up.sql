@@ -0,0 +1,5 @@
+-- A learner's client numbers the saves of its answers: sequence is the
+-- number of the save an answer was last stored by, 0 for saves that weren't
+-- numbered. A save numbered below the stored one arrived late and is
+-- refused.
+ALTER TABLE answers ADD COLUMN IF NOT EXISTS sequence BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,10 @@
+-- A key is reserved, with status_code 0 and no response, before its request
+-- is handled. The response keeps every header the handler set, not only its
+-- content type.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS response_headers JSONB;
+
+UPDATE idempotency_keys
+SET response_headers = jsonb_build_object('Content-Type', jsonb_build_array(content_type))
+WHERE content_type IS NOT NULL AND response_headers IS NULL;
+
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS content_type;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_attempts_previews_started_at;
+ALTER TABLE attempts DROP COLUMN IF EXISTS is_preview;
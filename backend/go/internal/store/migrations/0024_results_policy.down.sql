@@ -0,0 +1,2 @@
+ALTER TABLE projects DROP COLUMN IF EXISTS results_available_at;
+ALTER TABLE projects DROP COLUMN IF EXISTS results_policy;
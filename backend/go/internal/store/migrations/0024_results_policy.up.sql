@@ -0,0 +1,6 @@
+-- A project's results policy is what its learners are shown of their
+-- graded attempts: none, score_only, after_submit (the default),
+-- after_close_date, from results_available_at on, or practice, which also
+-- grades each answer as it is saved.
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS results_policy TEXT NOT NULL DEFAULT 'after_submit';
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS results_available_at TIMESTAMP WITH TIME ZONE;
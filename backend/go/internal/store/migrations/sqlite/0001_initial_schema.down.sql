@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS settings;
+DROP TABLE IF EXISTS maintenance;
+DROP TABLE IF EXISTS items;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS org_memberships;
+DROP TABLE IF EXISTS organizations;
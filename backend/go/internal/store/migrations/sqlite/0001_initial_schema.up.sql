@@ -0,0 +1,96 @@
+-- SQLite equivalent of the Postgres schema at version 6, without the item
+-- search vector. IDs are UUIDs generated by the stores, JSON is stored as
+-- text and times as UTC text, which the store's time format compares
+-- correctly. Constraints are named so SQLite reports them by name.
+
+CREATE TABLE IF NOT EXISTS organizations (
+	id TEXT PRIMARY KEY NOT NULL,
+	name VARCHAR(200) NOT NULL CONSTRAINT organizations_name_check CHECK (length(name) > 0),
+	max_projects INTEGER CONSTRAINT organizations_max_projects_check CHECK (max_projects IS NULL OR max_projects >= 0),
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS org_memberships (
+	org_id TEXT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+	user_id VARCHAR(255) NOT NULL,
+	role VARCHAR(20) NOT NULL CONSTRAINT org_memberships_role_check CHECK (role IN ('admin', 'member')),
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	PRIMARY KEY (org_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+	id TEXT PRIMARY KEY NOT NULL,
+	title VARCHAR(200) NOT NULL CONSTRAINT projects_title_check CHECK (length(title) > 0),
+	description TEXT,
+	tags TEXT DEFAULT '[]',
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	published_at TIMESTAMP,
+	org_id TEXT REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_created_at
+ON projects (created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_projects_org_id_created_at
+ON projects (org_id, created_at DESC);
+
+-- SQLite checks the unique position row by row, so the item store moves
+-- items to free positions before reordering them
+CREATE TABLE IF NOT EXISTS items (
+	id TEXT PRIMARY KEY NOT NULL,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	type VARCHAR(50) NOT NULL CONSTRAINT items_type_check CHECK (type IN ('title', 'media', 'choice', 'multi_choice', 'text_entry', 'ordering', 'hotspot')),
+	title VARCHAR(500) NOT NULL CONSTRAINT items_title_check CHECK (length(title) > 0),
+	content TEXT DEFAULT '{}',
+	position INTEGER NOT NULL CONSTRAINT items_position_check CHECK (position >= 0),
+	required BOOLEAN DEFAULT false,
+	points INTEGER CONSTRAINT items_points_check CHECK (points IS NULL OR (points >= 0 AND points <= 1000)),
+	explanation TEXT,
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	UNIQUE (project_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_items_project_position
+ON items (project_id, position ASC);
+
+CREATE INDEX IF NOT EXISTS idx_items_created_at
+ON items (created_at DESC);
+
+CREATE TABLE IF NOT EXISTS maintenance (
+	id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+	enabled BOOLEAN NOT NULL DEFAULT false,
+	message TEXT NOT NULL DEFAULT '',
+	allow_reads BOOLEAN NOT NULL DEFAULT false,
+	updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	updated_by VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS settings (
+	key VARCHAR(100) PRIMARY KEY,
+	value TEXT NOT NULL,
+	updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	updated_by VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+-- Stand-ins for the Postgres update_updated_at_column triggers, for updates
+-- that leave updated_at alone
+CREATE TRIGGER IF NOT EXISTS update_organizations_updated_at
+AFTER UPDATE ON organizations FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+	UPDATE organizations SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS update_projects_updated_at
+AFTER UPDATE ON projects FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+	UPDATE projects SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS update_items_updated_at
+AFTER UPDATE ON items FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+	UPDATE items SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END;
@@ -0,0 +1,4 @@
+-- Serves keyset pages of project lists, (created_at, id) < (cursor), in
+-- the order of the default sort
+CREATE INDEX IF NOT EXISTS idx_projects_created_at_id
+ON projects (created_at DESC, id DESC);
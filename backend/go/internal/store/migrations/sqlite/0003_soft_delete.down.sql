@@ -0,0 +1,39 @@
+-- Deleted rows cannot be kept: their positions may collide with live items
+DELETE FROM items WHERE deleted_at IS NOT NULL;
+DELETE FROM projects WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE items_unique_position (
+	id TEXT PRIMARY KEY NOT NULL,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	type VARCHAR(50) NOT NULL CONSTRAINT items_type_check CHECK (type IN ('title', 'media', 'choice', 'multi_choice', 'text_entry', 'ordering', 'hotspot')),
+	title VARCHAR(500) NOT NULL CONSTRAINT items_title_check CHECK (length(title) > 0),
+	content TEXT DEFAULT '{}',
+	position INTEGER NOT NULL CONSTRAINT items_position_check CHECK (position >= 0),
+	required BOOLEAN DEFAULT false,
+	points INTEGER CONSTRAINT items_points_check CHECK (points IS NULL OR (points >= 0 AND points <= 1000)),
+	explanation TEXT,
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	UNIQUE (project_id, position)
+);
+
+INSERT INTO items_unique_position (id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at)
+SELECT id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at
+FROM items;
+
+DROP TABLE items;
+ALTER TABLE items_unique_position RENAME TO items;
+
+CREATE INDEX idx_items_project_position
+ON items (project_id, position ASC);
+
+CREATE INDEX idx_items_created_at
+ON items (created_at DESC);
+
+CREATE TRIGGER update_items_updated_at
+AFTER UPDATE ON items FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+	UPDATE items SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END;
+
+ALTER TABLE projects DROP COLUMN deleted_at;
@@ -0,0 +1,43 @@
+-- Deleting a project or an item stamps deleted_at instead of removing the
+-- row. The stores leave deleted rows out of every query.
+ALTER TABLE projects ADD COLUMN deleted_at TIMESTAMP;
+
+-- A deleted item's position can be taken again, so the unique position
+-- becomes a partial index over live items. SQLite cannot drop a table's
+-- constraint, so the table is rebuilt without it.
+CREATE TABLE items_soft_delete (
+	id TEXT PRIMARY KEY NOT NULL,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	type VARCHAR(50) NOT NULL CONSTRAINT items_type_check CHECK (type IN ('title', 'media', 'choice', 'multi_choice', 'text_entry', 'ordering', 'hotspot')),
+	title VARCHAR(500) NOT NULL CONSTRAINT items_title_check CHECK (length(title) > 0),
+	content TEXT DEFAULT '{}',
+	position INTEGER NOT NULL CONSTRAINT items_position_check CHECK (position >= 0),
+	required BOOLEAN DEFAULT false,
+	points INTEGER CONSTRAINT items_points_check CHECK (points IS NULL OR (points >= 0 AND points <= 1000)),
+	explanation TEXT,
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	deleted_at TIMESTAMP
+);
+
+INSERT INTO items_soft_delete (id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at)
+SELECT id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at
+FROM items;
+
+DROP TABLE items;
+ALTER TABLE items_soft_delete RENAME TO items;
+
+CREATE UNIQUE INDEX items_project_id_position_key
+ON items (project_id, position) WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_items_project_position
+ON items (project_id, position ASC);
+
+CREATE INDEX idx_items_created_at
+ON items (created_at DESC);
+
+CREATE TRIGGER update_items_updated_at
+AFTER UPDATE ON items FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+	UPDATE items SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END;
@@ -0,0 +1,8 @@
+-- Tags move to tags_arr, as on Postgres. SQLite has no arrays, so the
+-- column holds a JSON array of strings like tags did. Projects whose tags
+-- are NULL or not an array get an empty array, never NULL.
+ALTER TABLE projects ADD COLUMN tags_arr TEXT NOT NULL DEFAULT '[]';
+
+UPDATE projects
+SET tags_arr = (SELECT json_group_array(value) FROM json_each(tags) WHERE type = 'text')
+WHERE json_valid(tags) AND json_type(tags) = 'array';
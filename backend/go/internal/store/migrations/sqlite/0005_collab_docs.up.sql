@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS collab_docs (
+	project_id TEXT PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+	state BLOB NOT NULL,
+	updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS item_locks (
+	item_id TEXT PRIMARY KEY REFERENCES items(id) ON DELETE CASCADE,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	holder_id TEXT NOT NULL,
+	acquired_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_locks_project_id
+	ON item_locks(project_id, expires_at);
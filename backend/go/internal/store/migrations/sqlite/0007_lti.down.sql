@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS lti_sessions;
+DROP TABLE IF EXISTS lti_resource_links;
+DROP TABLE IF EXISTS lti_states;
+DROP TABLE IF EXISTS lti_platforms;
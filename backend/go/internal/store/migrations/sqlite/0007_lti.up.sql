@@ -0,0 +1,52 @@
+CREATE TABLE IF NOT EXISTS lti_platforms (
+	id TEXT PRIMARY KEY,
+	org_id TEXT REFERENCES organizations(id) ON DELETE CASCADE,
+	issuer TEXT NOT NULL,
+	client_id TEXT NOT NULL,
+	auth_login_url TEXT NOT NULL,
+	auth_token_url TEXT NOT NULL,
+	key_set_url TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	CONSTRAINT lti_platforms_issuer_client_id_key UNIQUE (issuer, client_id)
+);
+
+CREATE TABLE IF NOT EXISTS lti_states (
+	state TEXT PRIMARY KEY,
+	nonce TEXT NOT NULL,
+	platform_id TEXT NOT NULL REFERENCES lti_platforms(id) ON DELETE CASCADE,
+	expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_lti_states_expires_at
+	ON lti_states(expires_at);
+
+CREATE TABLE IF NOT EXISTS lti_resource_links (
+	platform_id TEXT NOT NULL REFERENCES lti_platforms(id) ON DELETE CASCADE,
+	deployment_id TEXT NOT NULL,
+	resource_link_id TEXT NOT NULL,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	PRIMARY KEY (platform_id, deployment_id, resource_link_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lti_resource_links_project_id
+	ON lti_resource_links(project_id);
+
+CREATE TABLE IF NOT EXISTS lti_sessions (
+	id TEXT PRIMARY KEY,
+	platform_id TEXT NOT NULL REFERENCES lti_platforms(id) ON DELETE CASCADE,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	subject TEXT NOT NULL,
+	name TEXT NOT NULL DEFAULT '',
+	email TEXT NOT NULL DEFAULT '',
+	roles TEXT NOT NULL DEFAULT '',
+	lineitem_url TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_lti_sessions_project_id
+	ON lti_sessions(project_id);
+
+CREATE INDEX IF NOT EXISTS idx_lti_sessions_expires_at
+	ON lti_sessions(expires_at);
@@ -0,0 +1,4 @@
+-- Every write to a project or an item increments its version, which
+-- clients send back to update only the version they read
+ALTER TABLE projects ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE items ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,10 @@
+-- The projects as they were published: every publish freezes the project
+-- and its items, numbered from 1 per project, so later edits don't change
+-- what learners see. Rows go with their project.
+CREATE TABLE IF NOT EXISTS publications (
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	version INTEGER NOT NULL,
+	payload TEXT NOT NULL,
+	published_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (project_id, version)
+);
@@ -0,0 +1,24 @@
+-- Learners' attempts at published projects, and their answers. An attempt
+-- takes the publication that was latest when it started (publication
+-- version 0 takes the project's live items), by a signed-in participant or
+-- an anonymous one when participant_id is NULL. It takes answers, one per
+-- item, until it is submitted. Rows go with their project.
+CREATE TABLE IF NOT EXISTS attempts (
+	id TEXT PRIMARY KEY,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	publication_version INTEGER NOT NULL,
+	participant_id TEXT,
+	started_at TIMESTAMP NOT NULL,
+	submitted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_attempts_project_id_started_at
+	ON attempts(project_id, started_at);
+
+CREATE TABLE IF NOT EXISTS answers (
+	attempt_id TEXT NOT NULL REFERENCES attempts(id) ON DELETE CASCADE,
+	item_id TEXT NOT NULL,
+	response TEXT NOT NULL,
+	answered_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (attempt_id, item_id)
+);
@@ -0,0 +1,16 @@
+-- Submitting an attempt grades it: score is the points its answers earned
+-- out of max_score, both NULL until then, and attempt_results keeps the
+-- grade of each question the attempt's publication has, answered or not,
+-- in item order. Rows go with their attempt.
+ALTER TABLE attempts ADD COLUMN score INTEGER;
+ALTER TABLE attempts ADD COLUMN max_score INTEGER;
+
+CREATE TABLE IF NOT EXISTS attempt_results (
+	attempt_id TEXT NOT NULL REFERENCES attempts(id) ON DELETE CASCADE,
+	item_id TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	earned INTEGER NOT NULL,
+	possible INTEGER NOT NULL,
+	correct BOOLEAN NOT NULL,
+	PRIMARY KEY (attempt_id, item_id)
+);
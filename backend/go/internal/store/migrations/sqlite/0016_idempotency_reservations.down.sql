@@ -0,0 +1,9 @@
+ALTER TABLE idempotency_keys ADD COLUMN content_type VARCHAR(255);
+
+UPDATE idempotency_keys
+SET content_type = json_extract(response_headers, '$."Content-Type"[0]')
+WHERE response_headers IS NOT NULL;
+
+DELETE FROM idempotency_keys WHERE status_code = 0;
+
+ALTER TABLE idempotency_keys DROP COLUMN response_headers;
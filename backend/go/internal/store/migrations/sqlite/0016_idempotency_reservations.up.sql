@@ -0,0 +1,10 @@
+-- A key is reserved, with status_code 0 and no response, before its request
+-- is handled. The response keeps every header the handler set, not only its
+-- content type.
+ALTER TABLE idempotency_keys ADD COLUMN response_headers TEXT;
+
+UPDATE idempotency_keys
+SET response_headers = json_object('Content-Type', json_array(content_type))
+WHERE content_type IS NOT NULL AND response_headers IS NULL;
+
+ALTER TABLE idempotency_keys DROP COLUMN content_type;
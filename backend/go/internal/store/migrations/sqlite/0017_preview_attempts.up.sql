@@ -0,0 +1,8 @@
+-- Authors preview their projects: a preview is an attempt at the live
+-- items, published or not, by its author. Previews are left out of the
+-- project's attempts and stats, and purged once they are old, which the
+-- partial index finds.
+ALTER TABLE attempts ADD COLUMN is_preview BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_attempts_previews_started_at
+	ON attempts(started_at) WHERE is_preview;
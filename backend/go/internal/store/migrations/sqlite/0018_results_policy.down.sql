@@ -0,0 +1,2 @@
+ALTER TABLE projects DROP COLUMN results_available_at;
+ALTER TABLE projects DROP COLUMN results_policy;
@@ -0,0 +1,241 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// OrganizationStore implements organization persistence on the database's
+// dialect
+type OrganizationStore struct {
+	db *Database
+}
+
+// NewOrganizationStore creates a new organization store
+func NewOrganizationStore(db *Database) *OrganizationStore {
+	return &OrganizationStore{db: db}
+}
+
+// Create creates a new organization
+func (s *OrganizationStore) Create(ctx context.Context, name string, maxProjects *int) (*core.Organization, error) {
+	query := `
+		INSERT INTO organizations (id, name, max_projects)
+		VALUES ($1, $2, $3)
+		RETURNING id, name, max_projects, created_at, updated_at
+	`
+
+	org, err := scanOrganization(s.db.QueryRow(ctx, "organizations.create", query, core.NewID(ctx), name, maxProjects))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+	return org, nil
+}
+
+// GetByID retrieves an organization by ID
+func (s *OrganizationStore) GetByID(ctx context.Context, id string) (*core.Organization, error) {
+	query := `
+		SELECT id, name, max_projects, created_at, updated_at
+		FROM organizations
+		WHERE id = $1
+	`
+
+	org, err := scanOrganization(s.db.ReadQueryRow(ctx, "organizations.get_by_id", query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, core.ErrOrganizationNotFound
+		}
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return org, nil
+}
+
+// List returns every organization ordered by name
+func (s *OrganizationStore) List(ctx context.Context) ([]*core.Organization, error) {
+	query := `
+		SELECT id, name, max_projects, created_at, updated_at
+		FROM organizations
+		ORDER BY name, id
+	`
+
+	rows, err := s.db.ReadQuery(ctx, "organizations.list", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	var orgs []*core.Organization
+	for rows.Next() {
+		org, err := scanOrganization(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		orgs = append(orgs, org)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate organizations: %w", err)
+	}
+
+	return orgs, nil
+}
+
+// Update changes an organization's name and quota
+func (s *OrganizationStore) Update(ctx context.Context, id, name string, maxProjects *int) (*core.Organization, error) {
+	query := `
+		UPDATE organizations
+		SET name = $2, max_projects = $3, updated_at = ` + s.db.dialect.Now() + `
+		WHERE id = $1
+		RETURNING id, name, max_projects, created_at, updated_at
+	`
+
+	org, err := scanOrganization(s.db.QueryRow(ctx, "organizations.update", query, id, name, maxProjects))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, core.ErrOrganizationNotFound
+		}
+		return nil, fmt.Errorf("failed to update organization: %w", err)
+	}
+	return org, nil
+}
+
+// Delete removes an organization; its projects and memberships cascade
+func (s *OrganizationStore) Delete(ctx context.Context, id string) error {
+	result, err := s.db.Exec(ctx, "organizations.delete", `DELETE FROM organizations WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return core.ErrOrganizationNotFound
+	}
+	return nil
+}
+
+// ListMembers returns the organization's members ordered by user ID
+func (s *OrganizationStore) ListMembers(ctx context.Context, orgID string) ([]*core.Membership, error) {
+	if _, err := s.GetByID(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT org_id, user_id, role, created_at
+		FROM org_memberships
+		WHERE org_id = $1
+		ORDER BY user_id
+	`
+
+	rows, err := s.db.ReadQuery(ctx, "organizations.list_members", query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*core.Membership
+	for rows.Next() {
+		var member core.Membership
+		if err := rows.Scan(&member.OrgID, &member.UserID, &member.Role, scanUTC(&member.CreatedAt)); err != nil {
+			return nil, fmt.Errorf("failed to scan member: %w", err)
+		}
+		members = append(members, &member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate members: %w", err)
+	}
+
+	return members, nil
+}
+
+// PutMember adds the user to the organization or changes their role
+func (s *OrganizationStore) PutMember(ctx context.Context, orgID, userID, role string) (*core.Membership, error) {
+	query := `
+		INSERT INTO org_memberships (org_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (org_id, user_id) DO UPDATE
+		SET role = EXCLUDED.role
+		RETURNING org_id, user_id, role, created_at
+	`
+
+	var member core.Membership
+	err := s.db.QueryRow(ctx, "organizations.put_member", query, orgID, userID, role).Scan(
+		&member.OrgID,
+		&member.UserID,
+		&member.Role,
+		scanUTC(&member.CreatedAt),
+	)
+	if err != nil {
+		if violation, ok := s.db.dialect.Violation(err); ok && violation.Kind == ForeignKeyViolation {
+			return nil, core.ErrOrganizationNotFound
+		}
+		return nil, fmt.Errorf("failed to put member: %w", err)
+	}
+	return &member, nil
+}
+
+// RemoveMember removes the user from the organization
+func (s *OrganizationStore) RemoveMember(ctx context.Context, orgID, userID string) error {
+	query := `DELETE FROM org_memberships WHERE org_id = $1 AND user_id = $2`
+
+	result, err := s.db.Exec(ctx, "organizations.remove_member", query, orgID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return core.ErrMembershipNotFound
+	}
+	return nil
+}
+
+// GetMembership returns the user's membership of the organization
+func (s *OrganizationStore) GetMembership(ctx context.Context, orgID, userID string) (*core.Membership, error) {
+	query := `
+		SELECT org_id, user_id, role, created_at
+		FROM org_memberships
+		WHERE org_id = $1 AND user_id = $2
+	`
+
+	var member core.Membership
+	err := s.db.ReadQueryRow(ctx, "organizations.get_membership", query, orgID, userID).Scan(
+		&member.OrgID,
+		&member.UserID,
+		&member.Role,
+		scanUTC(&member.CreatedAt),
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, core.ErrMembershipNotFound
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	return &member, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanOrganization(row rowScanner) (*core.Organization, error) {
+	var org core.Organization
+	var maxProjects sql.NullInt64
+	if err := row.Scan(&org.ID, &org.Name, &maxProjects, scanUTC(&org.CreatedAt), scanUTC(&org.UpdatedAt)); err != nil {
+		return nil, err
+	}
+	if maxProjects.Valid {
+		n := int(maxProjects.Int64)
+		org.MaxProjects = &n
+	}
+	return &org, nil
+}
@@ -4,16 +4,33 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"time"
+	"strings"
 
-	"github.com/lib/pq"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog/log"
 
 	"github.com/provemyself/backend/internal/core"
 )
 
-// ProjectStore implements project data access using PostgreSQL
+// projectTitleConstraint rejects empty project titles
+const projectTitleConstraint = "projects_title_check"
+
+// itemCountsJoin joins each project's number of items, not counting deleted
+// ones, as item_counts.item_count, which is NULL for a project without
+// items. The items are counted in one grouped query whatever the number of
+// projects read.
+const itemCountsJoin = `
+	LEFT JOIN (
+		SELECT project_id, COUNT(*) AS item_count
+		FROM items
+		WHERE deleted_at IS NULL
+		GROUP BY project_id
+	) AS item_counts ON item_counts.project_id = projects.id`
+
+// ProjectStore implements project data access on the database's dialect
 type ProjectStore struct {
 	db *Database
 }
@@ -23,56 +40,83 @@ func NewProjectStore(db *Database) *ProjectStore {
 	return &ProjectStore{db: db}
 }
 
-// Create creates a new project in the database
+// scoped restricts where, whose placeholders are bound to args, to the
+// projects of the organization in ctx that are not deleted. Every project
+// query is built with it.
+func (s *ProjectStore) scoped(ctx context.Context, where string, args ...interface{}) (string, []interface{}) {
+	return s.scopedIncluding(ctx, false, where, args...)
+}
+
+// scopedIncluding is scoped, keeping deleted projects if includeDeleted
+func (s *ProjectStore) scopedIncluding(ctx context.Context, includeDeleted bool, where string, args ...interface{}) (string, []interface{}) {
+	return andScope(where, args, func(n int) (string, []interface{}) {
+		condition, scopeArgs := orgScope(ctx, "org_id", n)
+		if !includeDeleted {
+			condition = notDeleted("projects") + " AND " + condition
+		}
+		return condition, scopeArgs
+	})
+}
+
+// Create creates a new project in the database, in the organization in ctx.
+// Returns core.ErrMembershipNotFound unless the access scope in ctx may
+// work in that organization.
 func (s *ProjectStore) Create(ctx context.Context, title string, description *string, tags []string) (*core.Project, error) {
 	var project core.Project
 
-	// Convert tags to JSON
-	tagsJSON, err := json.Marshal(tags)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal tags: %w", err)
+	if memberID := memberIDParam(ctx); memberID != nil {
+		var member bool
+		memberQuery := `SELECT EXISTS(SELECT 1 FROM org_memberships WHERE org_id = $1 AND user_id = $2)`
+		if err := s.db.QueryRow(ctx, "projects.check_membership", memberQuery, core.OrgIDFromContext(ctx), *memberID).Scan(&member); err != nil {
+			return nil, fmt.Errorf("failed to check organization membership: %w", err)
+		}
+		if !member {
+			return nil, core.ErrMembershipNotFound
+		}
+	}
+
+	columns := "id, title, description, tags_arr, org_id"
+	values := "$1, $2, $3, $4, $5"
+	args := []interface{}{core.NewID(ctx), title, description, tagsArray(tags), orgIDArg(ctx)}
+	if s.db.writeLegacyTags {
+		columns += ", tags"
+		values += ", $6"
+		args = append(args, legacyTags(tags))
 	}
 
 	query := `
-		INSERT INTO projects (title, description, tags)
-		VALUES ($1, $2, $3)
-		RETURNING id, title, description, tags, created_at, updated_at, published_at
+		INSERT INTO projects (` + columns + `)
+		VALUES (` + values + `)
+		RETURNING id, title, description, tags_arr, created_at, updated_at, published_at, deleted_at, version
 	`
 
-	row := s.db.DB().QueryRowContext(ctx, query, title, description, tagsJSON)
+	row := s.db.QueryRow(ctx, "projects.create", query, args...)
 
-	var tagsRaw []byte
-	err = row.Scan(
+	err := row.Scan(
 		&project.ID,
 		&project.Title,
 		&project.Description,
-		&tagsRaw,
-		&project.CreatedAt,
-		&project.UpdatedAt,
-		&project.PublishedAt,
+		s.db.dialect.scanStrings(&project.Tags),
+		scanUTC(&project.CreatedAt),
+		scanUTC(&project.UpdatedAt),
+		scanNullUTC(&project.PublishedAt),
+		scanNullUTC(&project.DeletedAt),
+		&project.Version,
 	)
 
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code {
-			case "23514": // check_violation
-				if pqErr.Constraint == "projects_title_check" {
-					return nil, core.ErrProjectTitleTooShort
-				}
-			case "23505": // unique_violation
+		if violation, ok := s.db.dialect.Violation(err); ok {
+			switch {
+			case violation.Is(CheckViolation, projectTitleConstraint):
+				return nil, core.ErrProjectTitleTooShort
+			case violation.Kind == UniqueViolation:
 				return nil, fmt.Errorf("project already exists: %w", err)
 			}
 		}
 		return nil, fmt.Errorf("failed to create project: %w", err)
 	}
 
-	// Unmarshal tags
-	if err := json.Unmarshal(tagsRaw, &project.Tags); err != nil {
-		log.Warn().Err(err).Msg("failed to unmarshal project tags")
-		project.Tags = []string{} // Fallback to empty slice
-	}
-
-	log.Info().
+	log.Ctx(ctx).Info().
 		Str("project_id", project.ID).
 		Str("title", project.Title).
 		Msg("project created successfully")
@@ -80,27 +124,37 @@ func (s *ProjectStore) Create(ctx context.Context, title string, description *st
 	return &project, nil
 }
 
-// GetByID retrieves a project by ID
+// GetByID retrieves a project by ID, with its item count and latest
+// publication version
 func (s *ProjectStore) GetByID(ctx context.Context, id string) (*core.Project, error) {
 	var project core.Project
+	var itemCount int
 
+	where, args := s.scoped(ctx, "id = $1", id)
 	query := `
-		SELECT id, title, description, tags, created_at, updated_at, published_at
-		FROM projects
-		WHERE id = $1
-	`
+		SELECT id, title, description, tags_arr, created_at, updated_at, published_at, deleted_at, version,
+			COALESCE(item_counts.item_count, 0), publication_versions.latest_version,
+			results_policy, results_available_at
+		FROM projects` + itemCountsJoin + publicationVersionsJoin + `
+		WHERE ` + where
 
-	row := s.db.DB().QueryRowContext(ctx, query, id)
+	row := s.db.ReadQueryRow(ctx, "projects.get_by_id", query, args...)
 
-	var tagsRaw []byte
+	var results core.ResultsSettings
 	err := row.Scan(
 		&project.ID,
 		&project.Title,
 		&project.Description,
-		&tagsRaw,
-		&project.CreatedAt,
-		&project.UpdatedAt,
-		&project.PublishedAt,
+		s.db.dialect.scanStrings(&project.Tags),
+		scanUTC(&project.CreatedAt),
+		scanUTC(&project.UpdatedAt),
+		scanNullUTC(&project.PublishedAt),
+		scanNullUTC(&project.DeletedAt),
+		&project.Version,
+		&itemCount,
+		&project.LatestPublishedVersion,
+		&results.Policy,
+		scanNullUTC(&results.AvailableAt),
 	)
 
 	if err != nil {
@@ -109,122 +163,246 @@ func (s *ProjectStore) GetByID(ctx context.Context, id string) (*core.Project, e
 		}
 		return nil, fmt.Errorf("failed to get project: %w", err)
 	}
-
-	// Unmarshal tags
-	if err := json.Unmarshal(tagsRaw, &project.Tags); err != nil {
-		log.Warn().Err(err).Str("project_id", id).Msg("failed to unmarshal project tags")
-		project.Tags = []string{} // Fallback to empty slice
-	}
+	project.ItemCount = &itemCount
+	project.ResultsSettings = &results
 
 	return &project, nil
 }
 
-// List retrieves projects with pagination
-func (s *ProjectStore) List(ctx context.Context, limit, offset int) ([]*core.Project, int, error) {
-	// First, get the total count
-	var total int
-	countQuery := `SELECT COUNT(*) FROM projects`
-	if err := s.db.DB().QueryRowContext(ctx, countQuery).Scan(&total); err != nil {
-		return nil, 0, fmt.Errorf("failed to count projects: %w", err)
+// List retrieves the page of projects selected by opts. Offset pages also
+// count the total, read with the same filter, so it counts exactly the
+// projects the pages are drawn from; pages after a cursor skip the count.
+// One project more than the limit is read to tell whether another page
+// follows. Each project's items are counted, and its latest publication
+// version read, in the same query. Listings tolerate lag, so they read the
+// replica.
+func (s *ProjectStore) List(ctx context.Context, opts core.ListOptions) (*core.ProjectPage, error) {
+	sort, ok := projectSorts[opts.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown project sort %q", opts.Sort)
+	}
+	if err := validateProjectStatus(opts.Status); err != nil {
+		return nil, err
 	}
 
-	// Get the projects
-	query := `
-		SELECT id, title, description, tags, created_at, updated_at, published_at
-		FROM projects
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`
+	filter, filterArgs := buildProjectFilter(s.db.dialect, opts)
+	where, args := s.scopedIncluding(ctx, opts.IncludeDeleted || opts.Deleted, filter, filterArgs...)
 
-	rows, err := s.db.DB().QueryContext(ctx, query, limit, offset)
+	page := &core.ProjectPage{Total: -1}
+	offset := 0
+	if opts.After == nil {
+		countQuery := `SELECT COUNT(*) FROM projects WHERE ` + where
+		if err := s.db.StaleQueryRow(ctx, "projects.count", countQuery, args...).Scan(&page.Total); err != nil {
+			return nil, fmt.Errorf("failed to count projects: %w", err)
+		}
+		offset = opts.Offset
+	}
+
+	// Get the projects
+	query := fmt.Sprintf(`
+		SELECT id, title, description, tags_arr, created_at, updated_at, published_at, deleted_at, version,
+			COALESCE(item_counts.item_count, 0), publication_versions.latest_version
+		FROM projects%s%s
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, itemCountsJoin, publicationVersionsJoin, where, sort.orderBy(), len(args)+1, len(args)+2)
+
+	rows, err := s.db.StaleQuery(ctx, "projects.list", query, append(args, opts.Limit+1, offset)...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to query projects: %w", err)
+		return nil, fmt.Errorf("failed to query projects: %w", err)
 	}
 	defer rows.Close()
 
-	var projects []*core.Project
 	for rows.Next() {
 		var project core.Project
-		var tagsRaw []byte
+		var itemCount int
 
 		err := rows.Scan(
 			&project.ID,
 			&project.Title,
 			&project.Description,
-			&tagsRaw,
-			&project.CreatedAt,
-			&project.UpdatedAt,
-			&project.PublishedAt,
+			s.db.dialect.scanStrings(&project.Tags),
+			scanUTC(&project.CreatedAt),
+			scanUTC(&project.UpdatedAt),
+			scanNullUTC(&project.PublishedAt),
+			scanNullUTC(&project.DeletedAt),
+			&project.Version,
+			&itemCount,
+			&project.LatestPublishedVersion,
 		)
 
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan project: %w", err)
+			return nil, fmt.Errorf("failed to scan project: %w", err)
 		}
+		project.ItemCount = &itemCount
 
-		// Unmarshal tags
-		if err := json.Unmarshal(tagsRaw, &project.Tags); err != nil {
-			log.Warn().Err(err).Str("project_id", project.ID).Msg("failed to unmarshal project tags")
-			project.Tags = []string{} // Fallback to empty slice
-		}
-
-		projects = append(projects, &project)
+		page.Projects = append(page.Projects, &project)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("failed to iterate projects: %w", err)
+		return nil, fmt.Errorf("failed to iterate projects: %w", err)
 	}
 
-	return projects, total, nil
+	if len(page.Projects) > opts.Limit {
+		page.Projects = page.Projects[:opts.Limit]
+		page.HasMore = true
+	}
+
+	return page, nil
 }
 
-// Update updates a project
-func (s *ProjectStore) Update(ctx context.Context, id string, title string, description *string, tags []string) (*core.Project, error) {
-	// Convert tags to JSON
-	tagsJSON, err := json.Marshal(tags)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal tags: %w", err)
+// buildProjectFilter returns the condition selecting the projects that
+// match opts' filters and follow its cursor, with placeholders numbered from
+// $1 and bound to args, or "" when opts filters nothing. Both the count and
+// the page query of List are built from it. On Postgres the tags condition
+// is served by the GIN index on tags_arr.
+func buildProjectFilter(dialect Dialect, opts core.ListOptions) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	bind := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if len(opts.Tags) > 0 {
+		conditions = append(conditions, dialect.ContainsAll("tags_arr", opts.Tags, bind))
+	}
+
+	if opts.Deleted {
+		conditions = append(conditions, "deleted_at IS NOT NULL")
+	}
+
+	switch opts.Status {
+	case core.ProjectStatusDraft:
+		conditions = append(conditions, "published_at IS NULL")
+	case core.ProjectStatusPublished:
+		conditions = append(conditions, "published_at IS NOT NULL")
+	}
+
+	if opts.Search != "" {
+		pattern := bind("%" + escapeLike(opts.Search) + "%")
+		conditions = append(conditions, "("+dialect.ILike("title", pattern)+" OR "+dialect.ILike("description", pattern)+")")
+	}
+
+	if sort, ok := projectSorts[opts.Sort]; ok && opts.After != nil {
+		conditions = append(conditions, sort.after(opts.After, bind))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// projectSort is the key a sort orders projects by, ID breaking ties
+type projectSort struct {
+	column     string
+	descending bool
+	// value reads the key from a cursor
+	value func(cursor *core.ProjectCursor) interface{}
+}
+
+// projectSorts are the sorts of project lists, by name
+var projectSorts = map[core.ProjectSort]projectSort{
+	"":                        {"created_at", true, func(c *core.ProjectCursor) interface{} { return c.CreatedAt }},
+	core.ProjectSortCreatedAt: {"created_at", true, func(c *core.ProjectCursor) interface{} { return c.CreatedAt }},
+	core.ProjectSortUpdatedAt: {"updated_at", true, func(c *core.ProjectCursor) interface{} { return c.UpdatedAt }},
+	core.ProjectSortTitle:     {"title", false, func(c *core.ProjectCursor) interface{} { return c.Title }},
+
+	core.ProjectSortCreatedAtAsc: {"created_at", false, func(c *core.ProjectCursor) interface{} { return c.CreatedAt }},
+	core.ProjectSortUpdatedAtAsc: {"updated_at", false, func(c *core.ProjectCursor) interface{} { return c.UpdatedAt }},
+	core.ProjectSortTitleDesc:    {"title", true, func(c *core.ProjectCursor) interface{} { return c.Title }},
+}
+
+// orderBy returns the ORDER BY clause of the sort
+func (s projectSort) orderBy() string {
+	direction := "ASC"
+	if s.descending {
+		direction = "DESC"
+	}
+	return s.column + " " + direction + ", id " + direction
+}
+
+// after returns the condition selecting the projects that follow cursor in
+// the sort's order. Comparing the key and ID as one row value lets the
+// (created_at DESC, id DESC) index serve the default sort.
+func (s projectSort) after(cursor *core.ProjectCursor, bind func(interface{}) string) string {
+	comparison := ">"
+	if s.descending {
+		comparison = "<"
+	}
+	return fmt.Sprintf("(%s, id) %s (%s, %s)", s.column, comparison, bind(s.value(cursor)), bind(cursor.ID))
+}
+
+func validateProjectStatus(status core.ProjectStatus) error {
+	switch status {
+	case "", core.ProjectStatusDraft, core.ProjectStatusPublished:
+		return nil
+	default:
+		return fmt.Errorf("unknown project status %q", status)
 	}
+}
+
+// escapeLike escapes the LIKE wildcards in a search term so it matches
+// literally
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+}
 
+// Update updates a project and increments its version. With a version, the
+// project is only updated while it has that version.
+func (s *ProjectStore) Update(ctx context.Context, id string, title string, description *string, tags []string, version *int) (*core.Project, error) {
+	set := "title = $1, description = $2, tags_arr = $3"
+	args := []interface{}{title, description, tagsArray(tags), id}
+	if s.db.writeLegacyTags {
+		set += ", tags = $5"
+		args = append(args, legacyTags(tags))
+	}
+	filter := "id = $4"
+	if version != nil {
+		args = append(args, *version)
+		filter += fmt.Sprintf(" AND version = $%d", len(args))
+	}
+
+	where, args := s.scoped(ctx, filter, args...)
 	query := `
 		UPDATE projects 
-		SET title = $1, description = $2, tags = $3, updated_at = NOW()
-		WHERE id = $4
-		RETURNING id, title, description, tags, created_at, updated_at, published_at
+		SET ` + set + `, updated_at = ` + s.db.dialect.Now() + `, version = version + 1
+		WHERE ` + where + `
+		RETURNING id, title, description, tags_arr, created_at, updated_at, published_at, deleted_at, version
 	`
 
-	row := s.db.DB().QueryRowContext(ctx, query, title, description, tagsJSON, id)
+	row := s.db.QueryRow(ctx, "projects.update", query, args...)
 
 	var project core.Project
-	var tagsRaw []byte
-	err = row.Scan(
+	err := row.Scan(
 		&project.ID,
 		&project.Title,
 		&project.Description,
-		&tagsRaw,
-		&project.CreatedAt,
-		&project.UpdatedAt,
-		&project.PublishedAt,
+		s.db.dialect.scanStrings(&project.Tags),
+		scanUTC(&project.CreatedAt),
+		scanUTC(&project.UpdatedAt),
+		scanNullUTC(&project.PublishedAt),
+		scanNullUTC(&project.DeletedAt),
+		&project.Version,
 	)
 
 	if err != nil {
+		if err == sql.ErrNoRows && version != nil {
+			return nil, s.versionConflict(ctx, id)
+		}
 		if err == sql.ErrNoRows {
 			return nil, core.ErrProjectNotFound
 		}
-		if pqErr, ok := err.(*pq.Error); ok {
-			if pqErr.Code == "23514" && pqErr.Constraint == "projects_title_check" {
-				return nil, core.ErrProjectTitleTooShort
-			}
+		if violation, ok := s.db.dialect.Violation(err); ok && violation.Is(CheckViolation, projectTitleConstraint) {
+			return nil, core.ErrProjectTitleTooShort
 		}
 		return nil, fmt.Errorf("failed to update project: %w", err)
 	}
 
-	// Unmarshal tags
-	if err := json.Unmarshal(tagsRaw, &project.Tags); err != nil {
-		log.Warn().Err(err).Str("project_id", id).Msg("failed to unmarshal project tags")
-		project.Tags = []string{} // Fallback to empty slice
+	if err := s.db.notify(ctx, core.Change{Entity: core.ChangeEntityProject, ID: id, Action: core.ChangeActionUpdated}); err != nil {
+		return nil, err
 	}
 
-	log.Info().
+	log.Ctx(ctx).Info().
 		Str("project_id", project.ID).
 		Str("title", project.Title).
 		Msg("project updated successfully")
@@ -232,11 +410,70 @@ func (s *ProjectStore) Update(ctx context.Context, id string, title string, desc
 	return &project, nil
 }
 
-// Delete deletes a project
+// UpdateResultsSettings sets a project's results policy, bumping its
+// version, and reads it back with GetByID
+func (s *ProjectStore) UpdateResultsSettings(ctx context.Context, id string, settings core.ResultsSettings) (*core.Project, error) {
+	where, args := s.scoped(ctx, "id = $3", string(settings.Policy), settings.AvailableAt, id)
+	result, err := s.db.Exec(ctx, "projects.update_results", `
+		UPDATE projects
+		SET results_policy = $1, results_available_at = $2, updated_at = `+s.db.dialect.Now()+`, version = version + 1
+		WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update results policy: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if updated == 0 {
+		return nil, core.ErrProjectNotFound
+	}
+
+	if err := s.db.notify(ctx, core.Change{Entity: core.ChangeEntityProject, ID: id, Action: core.ChangeActionUpdated}); err != nil {
+		return nil, err
+	}
+	return s.GetByID(ctx, id)
+}
+
+// versionConflict tells why an update expecting a version of a project
+// changed nothing: core.ErrVersionConflict if the project exists, with
+// another version, and core.ErrProjectNotFound if it does not
+func (s *ProjectStore) versionConflict(ctx context.Context, id string) error {
+	where, args := s.scoped(ctx, "id = $1", id)
+	var exists bool
+	if err := s.db.QueryRow(ctx, "projects.exists", `SELECT EXISTS(SELECT 1 FROM projects WHERE `+where+`)`, args...).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check project: %w", err)
+	}
+	if !exists {
+		return core.ErrProjectNotFound
+	}
+	return core.ErrVersionConflict
+}
+
+// tagsArray is the value of the tags_arr column: tags, or an empty array
+// for none
+func tagsArray(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// legacyTags is the value of the JSONB tags column that tags_arr replaces,
+// written while DatabaseConfig.WriteLegacyTags is on
+func legacyTags(tags []string) string {
+	// Marshalling strings cannot fail
+	tagsJSON, _ := json.Marshal(tagsArray(tags))
+	return string(tagsJSON)
+}
+
+// Delete soft-deletes a project: it and its items are left out of every
+// query from then on, but stay in the database
 func (s *ProjectStore) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM projects WHERE id = $1`
+	where, args := s.scoped(ctx, "id = $1", id)
+	query := `UPDATE projects SET deleted_at = ` + s.db.dialect.Now() + `, updated_at = ` + s.db.dialect.Now() + `, version = version + 1 WHERE ` + where
 
-	result, err := s.db.DB().ExecContext(ctx, query, id)
+	result, err := s.db.Exec(ctx, "projects.delete", query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to delete project: %w", err)
 	}
@@ -250,121 +487,265 @@ func (s *ProjectStore) Delete(ctx context.Context, id string) error {
 		return core.ErrProjectNotFound
 	}
 
-	log.Info().
+	if err := s.db.notify(ctx, core.Change{Entity: core.ChangeEntityProject, ID: id, Action: core.ChangeActionDeleted}); err != nil {
+		return err
+	}
+
+	log.Ctx(ctx).Info().
 		Str("project_id", id).
 		Msg("project deleted successfully")
 
 	return nil
 }
 
-// Publish marks a project as published
-func (s *ProjectStore) Publish(ctx context.Context, id string) (*core.Project, error) {
+// Restore takes a project out of the trash. Its items were left as they
+// were when it was deleted, so they come back with it.
+func (s *ProjectStore) Restore(ctx context.Context, id string) (*core.Project, error) {
+	where, args := s.scopedIncluding(ctx, true, "id = $1 AND deleted_at IS NOT NULL", id)
 	query := `
-		UPDATE projects 
-		SET published_at = NOW(), updated_at = NOW()
-		WHERE id = $1 AND published_at IS NULL
-		RETURNING id, title, description, tags, created_at, updated_at, published_at
+		UPDATE projects
+		SET deleted_at = NULL, updated_at = ` + s.db.dialect.Now() + `, version = version + 1
+		WHERE ` + where + `
+		RETURNING id, title, description, tags_arr, created_at, updated_at, published_at, deleted_at, version
 	`
 
-	row := s.db.DB().QueryRowContext(ctx, query, id)
-
 	var project core.Project
-	var tagsRaw []byte
-	err := row.Scan(
+	err := s.db.QueryRow(ctx, "projects.restore", query, args...).Scan(
 		&project.ID,
 		&project.Title,
 		&project.Description,
-		&tagsRaw,
-		&project.CreatedAt,
-		&project.UpdatedAt,
-		&project.PublishedAt,
+		s.db.dialect.scanStrings(&project.Tags),
+		scanUTC(&project.CreatedAt),
+		scanUTC(&project.UpdatedAt),
+		scanNullUTC(&project.PublishedAt),
+		scanNullUTC(&project.DeletedAt),
+		&project.Version,
 	)
-
 	if err != nil {
 		if err == sql.ErrNoRows {
-			// Check if project exists but is already published
-			var exists bool
-			checkQuery := `SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1)`
-			if checkErr := s.db.DB().QueryRowContext(ctx, checkQuery, id).Scan(&exists); checkErr != nil {
-				return nil, fmt.Errorf("failed to check project existence: %w", checkErr)
-			}
-			if !exists {
-				return nil, core.ErrProjectNotFound
-			}
-			return nil, fmt.Errorf("project is already published")
+			return nil, core.ErrProjectNotFound
 		}
-		return nil, fmt.Errorf("failed to publish project: %w", err)
+		return nil, fmt.Errorf("failed to restore project: %w", err)
 	}
 
-	// Unmarshal tags
-	if err := json.Unmarshal(tagsRaw, &project.Tags); err != nil {
-		log.Warn().Err(err).Str("project_id", id).Msg("failed to unmarshal project tags")
-		project.Tags = []string{} // Fallback to empty slice
+	if err := s.db.notify(ctx, core.Change{Entity: core.ChangeEntityProject, ID: id, Action: core.ChangeActionUpdated}); err != nil {
+		return nil, err
 	}
 
-	log.Info().
-		Str("project_id", project.ID).
-		Msg("project published successfully")
+	log.Ctx(ctx).Info().
+		Str("project_id", id).
+		Msg("project restored successfully")
 
 	return &project, nil
 }
 
-// SearchByTitle searches projects by title
-func (s *ProjectStore) SearchByTitle(ctx context.Context, searchTerm string, limit, offset int) ([]*core.Project, int, error) {
-	searchPattern := "%" + searchTerm + "%"
+// Purge deletes a project in the trash for good. Its items, and every other
+// row of the project, go with it by cascade.
+func (s *ProjectStore) Purge(ctx context.Context, id string) error {
+	where, args := s.scopedIncluding(ctx, true, "id = $1 AND deleted_at IS NOT NULL", id)
+	query := `DELETE FROM projects WHERE ` + where
 
-	// Get total count
-	var total int
-	countQuery := `
-		SELECT COUNT(*) FROM projects 
-		WHERE title ILIKE $1 OR description ILIKE $1
-	`
-	if err := s.db.DB().QueryRowContext(ctx, countQuery, searchPattern).Scan(&total); err != nil {
-		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	result, err := s.db.Exec(ctx, "projects.purge", query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to purge project: %w", err)
 	}
 
-	// Get projects
-	query := `
-		SELECT id, title, description, tags, created_at, updated_at, published_at
-		FROM projects
-		WHERE title ILIKE $1 OR description ILIKE $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`
-
-	rows, err := s.db.DB().QueryContext(ctx, query, searchPattern, limit, offset)
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to search projects: %w", err)
+		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
-	defer rows.Close()
 
-	var projects []*core.Project
-	for rows.Next() {
-		var project core.Project
-		var tagsRaw []byte
+	if rowsAffected == 0 {
+		return core.ErrProjectNotFound
+	}
 
-		err := rows.Scan(
+	log.Ctx(ctx).Info().
+		Str("project_id", id).
+		Msg("project purged successfully")
+
+	return nil
+}
+
+// duplicateItemQuery copies a live item into another project under a new ID
+const duplicateItemQuery = `
+	INSERT INTO items (id, project_id, type, title, content, position, required, points, explanation)
+	SELECT $1, $2, type, title, content, position, required, points, explanation
+	FROM items
+	WHERE id = $3 AND items.deleted_at IS NULL
+`
+
+// Duplicate copies a project into a new unpublished project named title,
+// with the project's description, tags and results policy, and copies its
+// items into the new project at the same positions. Project and items are
+// copied in one transaction, all or none, the item copies in one batch
+// where the engine has batches. Returns core.ErrProjectNotFound if there is
+// no such project.
+func (s *ProjectStore) Duplicate(ctx context.Context, id, title string) (*core.Project, error) {
+	var project core.Project
+	err := s.db.InTx(ctx, "projects.duplicate", func(ctx context.Context) error {
+		columns := "id, title, description, tags_arr, org_id, results_policy, results_available_at"
+		if s.db.writeLegacyTags {
+			columns += ", tags"
+		}
+		where, args := s.scoped(ctx, "id = $1", id)
+		query := fmt.Sprintf(`
+			INSERT INTO projects (%s)
+			SELECT $%d, $%d, %s
+			FROM projects
+			WHERE %s
+			RETURNING id, title, description, tags_arr, created_at, updated_at, published_at, deleted_at, version
+		`, columns, len(args)+1, len(args)+2, strings.TrimPrefix(columns, "id, title, "), where)
+
+		err := s.db.QueryRow(ctx, "projects.duplicate", query, append(args, core.NewID(ctx), title)...).Scan(
 			&project.ID,
 			&project.Title,
 			&project.Description,
-			&tagsRaw,
-			&project.CreatedAt,
-			&project.UpdatedAt,
-			&project.PublishedAt,
+			s.db.dialect.scanStrings(&project.Tags),
+			scanUTC(&project.CreatedAt),
+			scanUTC(&project.UpdatedAt),
+			scanNullUTC(&project.PublishedAt),
+			scanNullUTC(&project.DeletedAt),
+			&project.Version,
 		)
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.ErrProjectNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to duplicate project: %w", err)
+		}
+
+		itemIDs, err := s.itemIDs(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.duplicateItems(ctx, project.ID, itemIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Ctx(ctx).Info().
+		Str("project_id", project.ID).
+		Str("source_project_id", id).
+		Msg("project duplicated successfully")
+
+	return &project, nil
+}
+
+// itemIDs returns the IDs of a project's live items, in position order
+func (s *ProjectStore) itemIDs(ctx context.Context, projectID string) ([]string, error) {
+	query := `SELECT id FROM items WHERE project_id = $1 AND deleted_at IS NULL ORDER BY position`
+	rows, err := s.db.Query(ctx, "projects.item_ids", query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project items: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan item ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list project items: %w", err)
+	}
+	return ids, nil
+}
+
+// duplicateItems copies the items with ids into a project, in the
+// transaction in ctx. Each copy gets a random ID: an ID set with
+// core.WithNewID is the new project's.
+func (s *ProjectStore) duplicateItems(ctx context.Context, projectID string, ids []string) error {
+	if !s.db.dialect.Capabilities().Batches {
+		for i, id := range ids {
+			if _, err := s.db.Exec(ctx, "projects.duplicate_item", duplicateItemQuery, uuid.NewString(), projectID, id); err != nil {
+				return fmt.Errorf("failed to copy item %d: %w", i+1, err)
+			}
+		}
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, id := range ids {
+		batch.Queue(duplicateItemQuery, uuid.NewString(), projectID, id)
+	}
+	tx, _ := txFromContext(ctx)
+	if err := tx.sendBatch(ctx, "projects.duplicate_items", batch); err != nil {
+		return fmt.Errorf("failed to copy items: %w", err)
+	}
+	return nil
+}
 
+// Publish publishes a project as its next publication: it sets the
+// project's published_at the first time, increments its version and keeps
+// the project and its items as they are in publications, in one
+// transaction. Returns core.ErrProjectNotFound if there is no such
+// project. The project's row is locked by the update, so concurrent calls
+// publish one version each.
+func (s *ProjectStore) Publish(ctx context.Context, id string) (*core.Project, error) {
+	var project *core.Project
+	err := s.db.InTx(ctx, "projects.publish", func(ctx context.Context) error {
+		where, args := s.scoped(ctx, "id = $1", id)
+		query := `
+			UPDATE projects
+			SET published_at = COALESCE(published_at, ` + s.db.dialect.Now() + `), updated_at = ` + s.db.dialect.Now() + `, version = version + 1
+			WHERE ` + where + `
+			RETURNING ` + publishReturning
+
+		var err error
+		project, err = s.scanPublished(s.db.QueryRow(ctx, "projects.publish", query, args...))
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.ErrProjectNotFound
+		}
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan project: %w", err)
+			return fmt.Errorf("failed to publish project: %w", err)
 		}
 
-		// Unmarshal tags
-		if err := json.Unmarshal(tagsRaw, &project.Tags); err != nil {
-			log.Warn().Err(err).Str("project_id", project.ID).Msg("failed to unmarshal project tags")
-			project.Tags = []string{}
+		version, err := s.keepPublication(ctx, project)
+		if err != nil {
+			return err
 		}
+		project.LatestPublishedVersion = &version
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		projects = append(projects, &project)
+	if err := s.db.notify(ctx, core.Change{Entity: core.ChangeEntityProject, ID: id, Action: core.ChangeActionUpdated}); err != nil {
+		return nil, err
 	}
 
-	return projects, total, nil
-}
\ No newline at end of file
+	log.Ctx(ctx).Info().
+		Str("project_id", project.ID).
+		Int("publication_version", *project.LatestPublishedVersion).
+		Msg("project published successfully")
+
+	return project, nil
+}
+
+// publishReturning are the columns of a published project
+const publishReturning = "id, title, description, tags_arr, created_at, updated_at, published_at, deleted_at, version"
+
+// scanPublished scans the publishReturning columns
+func (s *ProjectStore) scanPublished(row *sql.Row) (*core.Project, error) {
+	var project core.Project
+	err := row.Scan(
+		&project.ID,
+		&project.Title,
+		&project.Description,
+		s.db.dialect.scanStrings(&project.Tags),
+		scanUTC(&project.CreatedAt),
+		scanUTC(&project.UpdatedAt),
+		scanNullUTC(&project.PublishedAt),
+		scanNullUTC(&project.DeletedAt),
+		&project.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
@@ -0,0 +1,318 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+func TestBuildProjectFilter(t *testing.T) {
+	tags := []string{"math", "algebra"}
+	cursor := &core.ProjectCursor{
+		CreatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC),
+		Title:     "Quiz",
+		ID:        "project-1",
+	}
+
+	tests := []struct {
+		name          string
+		opts          core.ListOptions
+		expectedWhere string
+		expectedArgs  []interface{}
+	}{
+		{
+			name:          "no filters",
+			opts:          core.ListOptions{Limit: 20, Sort: core.ProjectSortTitle},
+			expectedWhere: "",
+		},
+		{
+			name:          "tags",
+			opts:          core.ListOptions{Tags: tags},
+			expectedWhere: "tags_arr @> $1::text[]",
+			expectedArgs:  []interface{}{tags},
+		},
+		{
+			name:          "draft",
+			opts:          core.ListOptions{Status: core.ProjectStatusDraft},
+			expectedWhere: "published_at IS NULL",
+		},
+		{
+			name:          "published",
+			opts:          core.ListOptions{Status: core.ProjectStatusPublished},
+			expectedWhere: "published_at IS NOT NULL",
+		},
+		{
+			name:          "search",
+			opts:          core.ListOptions{Search: "quiz"},
+			expectedWhere: "(title ILIKE $1 OR description ILIKE $1)",
+			expectedArgs:  []interface{}{"%quiz%"},
+		},
+		{
+			name:          "search matches wildcards literally",
+			opts:          core.ListOptions{Search: `100%_\`},
+			expectedWhere: "(title ILIKE $1 OR description ILIKE $1)",
+			expectedArgs:  []interface{}{`%100\%\_\\%`},
+		},
+		{
+			name:          "trash",
+			opts:          core.ListOptions{Deleted: true},
+			expectedWhere: "deleted_at IS NOT NULL",
+		},
+		{
+			name:          "tags and status",
+			opts:          core.ListOptions{Tags: tags, Status: core.ProjectStatusPublished},
+			expectedWhere: "tags_arr @> $1::text[] AND published_at IS NOT NULL",
+			expectedArgs:  []interface{}{tags},
+		},
+		{
+			name:          "tags and search",
+			opts:          core.ListOptions{Tags: tags, Search: "quiz"},
+			expectedWhere: "tags_arr @> $1::text[] AND (title ILIKE $2 OR description ILIKE $2)",
+			expectedArgs:  []interface{}{tags, "%quiz%"},
+		},
+		{
+			name:          "status and search",
+			opts:          core.ListOptions{Status: core.ProjectStatusDraft, Search: "quiz"},
+			expectedWhere: "published_at IS NULL AND (title ILIKE $1 OR description ILIKE $1)",
+			expectedArgs:  []interface{}{"%quiz%"},
+		},
+		{
+			name:          "after a cursor in the default sort",
+			opts:          core.ListOptions{After: cursor},
+			expectedWhere: "(created_at, id) < ($1, $2)",
+			expectedArgs:  []interface{}{cursor.CreatedAt, cursor.ID},
+		},
+		{
+			name:          "after a cursor by update",
+			opts:          core.ListOptions{Sort: core.ProjectSortUpdatedAt, After: cursor},
+			expectedWhere: "(updated_at, id) < ($1, $2)",
+			expectedArgs:  []interface{}{cursor.UpdatedAt, cursor.ID},
+		},
+		{
+			name:          "after a cursor by title",
+			opts:          core.ListOptions{Sort: core.ProjectSortTitle, Search: "quiz", After: cursor},
+			expectedWhere: "(title ILIKE $1 OR description ILIKE $1) AND (title, id) > ($2, $3)",
+			expectedArgs:  []interface{}{"%quiz%", cursor.Title, cursor.ID},
+		},
+		{
+			name:          "after a cursor oldest first",
+			opts:          core.ListOptions{Sort: core.ProjectSortCreatedAtAsc, After: cursor},
+			expectedWhere: "(created_at, id) > ($1, $2)",
+			expectedArgs:  []interface{}{cursor.CreatedAt, cursor.ID},
+		},
+		{
+			name:          "after a cursor by title descending",
+			opts:          core.ListOptions{Sort: core.ProjectSortTitleDesc, After: cursor},
+			expectedWhere: "(title, id) < ($1, $2)",
+			expectedArgs:  []interface{}{cursor.Title, cursor.ID},
+		},
+		{
+			name:          "every filter",
+			opts:          core.ListOptions{Tags: tags, Status: core.ProjectStatusDraft, Search: "quiz", Sort: core.ProjectSortUpdatedAt, Limit: 5, Offset: 10},
+			expectedWhere: "tags_arr @> $1::text[] AND published_at IS NULL AND (title ILIKE $2 OR description ILIKE $2)",
+			expectedArgs:  []interface{}{tags, "%quiz%"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			where, args := buildProjectFilter(postgresDialect{}, tt.opts)
+
+			// Assert
+			assert.Equal(t, tt.expectedWhere, where)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+func TestProjectStore_List_ScopesTheFilter(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	projects := NewProjectStore(database)
+	ctx := SystemScope(core.WithOrgID(context.Background(), "org-a"))
+	opts := core.ListOptions{Tags: []string{"math"}, Status: core.ProjectStatusPublished, Search: "quiz", Limit: 10, Offset: 20}
+
+	// Act
+	_, err := projects.List(ctx, opts)
+
+	// Assert
+	require.Error(t, err, "the stub's row is not a count")
+	query, args := stub.last()
+	assert.Equal(t, "SELECT COUNT(*) FROM projects WHERE (tags_arr @> $1::text[] AND published_at IS NOT NULL AND (title ILIKE $2 OR description ILIKE $2)) AND projects.deleted_at IS NULL AND org_id = $3", query)
+	assert.Equal(t, []interface{}{[]string{"math"}, "%quiz%", "org-a"}, args)
+}
+
+func TestProjectStore_WritesLegacyTags(t *testing.T) {
+	tests := []struct {
+		name            string
+		writeLegacyTags bool
+		expectedCreate  string
+		expectedUpdate  string
+		expectedArgs    []interface{}
+	}{
+		{
+			name:           "array only",
+			expectedCreate: "INSERT INTO projects (id, title, description, tags_arr, org_id)",
+			expectedUpdate: "SET title = $1, description = $2, tags_arr = $3, updated_at",
+			expectedArgs:   []interface{}{"Quiz", nil, []string{}, "project-1"},
+		},
+		{
+			name:            "array and JSONB",
+			writeLegacyTags: true,
+			expectedCreate:  "INSERT INTO projects (id, title, description, tags_arr, org_id, tags)",
+			expectedUpdate:  "SET title = $1, description = $2, tags_arr = $3, tags = $5, updated_at",
+			expectedArgs:    []interface{}{"Quiz", nil, []string{}, "project-1", "[]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			database := newStubDatabase(t, 0, nil)
+			database.writeLegacyTags = tt.writeLegacyTags
+			projects := NewProjectStore(database)
+			ctx := context.Background()
+
+			// Act
+			_, createErr := projects.Create(ctx, "Quiz", nil, nil)
+			createQuery, _ := stub.last()
+			_, updateErr := projects.Update(ctx, "project-1", "Quiz", nil, nil, nil)
+			updateQuery, updateArgs := stub.last()
+
+			// Assert
+			require.Error(t, createErr, "the stub's row is not a project")
+			require.Error(t, updateErr, "the stub's row is not a project")
+			assert.Contains(t, createQuery, tt.expectedCreate)
+			assert.Contains(t, updateQuery, tt.expectedUpdate)
+			assert.Equal(t, tt.expectedArgs, updateArgs[:len(tt.expectedArgs)])
+		})
+	}
+}
+
+func TestProjectStore_List_AfterCursorSkipsTheCount(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	stub.match = func(string, []driver.NamedValue) bool { return false }
+	projects := NewProjectStore(database)
+	cursor := &core.ProjectCursor{CreatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), ID: "project-1"}
+
+	// Act
+	page, err := projects.List(context.Background(), core.ListOptions{Limit: 10, Offset: 20, After: cursor})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, -1, page.Total)
+	assert.False(t, page.HasMore)
+	assert.Equal(t, 1, stub.statements, "only the page is read")
+	query, args := stub.last()
+	assert.Contains(t, query, "WHERE ((created_at, id) < ($1, $2)) AND projects.deleted_at IS NULL AND org_id IS NULL")
+	assert.Contains(t, query, "ORDER BY created_at DESC, id DESC")
+	assert.Equal(t, []interface{}{cursor.CreatedAt, "project-1", int64(11), int64(0)}, args, "one extra project, and no offset")
+}
+
+func TestProjectStore_List_CountsItemsInThePageQuery(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	stub.match = func(string, []driver.NamedValue) bool { return false }
+	projects := NewProjectStore(database)
+	cursor := &core.ProjectCursor{CreatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), ID: "project-1"}
+
+	// Act
+	_, err := projects.List(context.Background(), core.ListOptions{Limit: 10, After: cursor})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, stub.statements, "items are not counted per project")
+	query, _ := stub.last()
+	assert.Contains(t, query, "COALESCE(item_counts.item_count, 0)")
+	assert.Contains(t, query, "GROUP BY project_id")
+}
+
+func TestProjectStore_List_IncludeDeleted(t *testing.T) {
+	tests := []struct {
+		name           string
+		includeDeleted bool
+		deleted        bool
+		expectedWhere  string
+	}{
+		{
+			name:          "deleted projects left out",
+			expectedWhere: "WHERE projects.deleted_at IS NULL AND org_id IS NULL",
+		},
+		{
+			name:           "deleted projects included",
+			includeDeleted: true,
+			expectedWhere:  "WHERE org_id IS NULL",
+		},
+		{
+			name:          "trash",
+			deleted:       true,
+			expectedWhere: "WHERE (deleted_at IS NOT NULL) AND org_id IS NULL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			database := newStubDatabase(t, 0, nil)
+			projects := NewProjectStore(database)
+
+			// Act
+			_, err := projects.List(context.Background(), core.ListOptions{Limit: 10, IncludeDeleted: tt.includeDeleted, Deleted: tt.deleted})
+
+			// Assert
+			require.Error(t, err, "the stub's row is not a count")
+			query, _ := stub.last()
+			assert.Equal(t, "SELECT COUNT(*) FROM projects "+tt.expectedWhere, query)
+		})
+	}
+}
+
+func TestProjectStore_Delete_KeepsTheRow(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	projects := NewProjectStore(database)
+
+	// Act
+	err := projects.Delete(context.Background(), "project-1")
+
+	// Assert
+	require.NoError(t, err)
+	query, args := stub.last()
+	assert.Equal(t, "UPDATE projects SET deleted_at = NOW(), updated_at = NOW(), version = version + 1 WHERE (id = $1) AND projects.deleted_at IS NULL AND org_id IS NULL", query)
+	assert.Equal(t, []interface{}{"project-1"}, args)
+}
+
+func TestProjectStore_List_RejectsUnknownOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts core.ListOptions
+	}{
+		{"unknown sort", core.ListOptions{Sort: "popularity"}},
+		{"unknown status", core.ListOptions{Status: "archived"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			database := newStubDatabase(t, 0, nil)
+
+			// Act
+			_, err := NewProjectStore(database).List(context.Background(), tt.opts)
+
+			// Assert
+			assert.Error(t, err)
+			assert.Equal(t, 0, stub.statements)
+			assert.True(t, strings.HasPrefix(err.Error(), "unknown project"))
+		})
+	}
+}
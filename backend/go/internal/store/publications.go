@@ -0,0 +1,260 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store/dbtypes"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// publicationVersionsJoin joins each project's latest publication version
+// as publication_versions.latest_version, which is NULL for a project never
+// published since publications were kept
+const publicationVersionsJoin = `
+	LEFT JOIN (
+		SELECT project_id, MAX(version) AS latest_version
+		FROM publications
+		GROUP BY project_id
+	) AS publication_versions ON publication_versions.project_id = projects.id`
+
+// publicationPayload is the payload column of publications: the project
+// and its items as they were published. The project's ID is the row's.
+type publicationPayload struct {
+	Project publishedProject `json:"project"`
+	Items   []publishedItem  `json:"items"`
+}
+
+type publishedProject struct {
+	Title       string     `json:"title"`
+	Description *string    `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Version     int        `json:"version"`
+}
+
+type publishedItem struct {
+	ID          string          `json:"id"`
+	Type        types.ItemType  `json:"type"`
+	Title       string          `json:"title"`
+	Content     json.RawMessage `json:"content,omitempty"`
+	Position    int             `json:"position"`
+	Required    bool            `json:"required"`
+	Points      *int            `json:"points,omitempty"`
+	Explanation *string         `json:"explanation,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Version     int             `json:"version"`
+}
+
+// keepPublication keeps project, just published in the transaction in ctx,
+// and its items as the project's next publication, and returns its
+// version. The project's row must be locked, which keeps the versions of
+// concurrent publishes apart.
+func (s *ProjectStore) keepPublication(ctx context.Context, project *core.Project) (int, error) {
+	rows, err := s.db.Query(ctx, "publications.items", `
+		SELECT id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at, version
+		FROM items
+		WHERE project_id = $1 AND deleted_at IS NULL
+		ORDER BY position ASC
+	`, project.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read published items: %w", err)
+	}
+	items, err := scanItems(rows)
+	rows.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	payload := publicationPayload{
+		Project: publishedProject{
+			Title:       project.Title,
+			Description: project.Description,
+			Tags:        project.Tags,
+			CreatedAt:   project.CreatedAt,
+			UpdatedAt:   project.UpdatedAt,
+			PublishedAt: project.PublishedAt,
+			Version:     project.Version,
+		},
+		Items: make([]publishedItem, len(items)),
+	}
+	for i, item := range items {
+		payload.Items[i] = publishedItem{
+			ID:          item.ID,
+			Type:        item.Type,
+			Title:       item.Title,
+			Content:     item.Content,
+			Position:    item.Position,
+			Required:    item.Required,
+			Points:      item.Points,
+			Explanation: item.Explanation,
+			CreatedAt:   item.CreatedAt,
+			UpdatedAt:   item.UpdatedAt,
+			Version:     item.Version,
+		}
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode publication: %w", err)
+	}
+
+	var version int
+	err = s.db.QueryRow(ctx, "publications.create", `
+		INSERT INTO publications (project_id, version, payload, published_at)
+		VALUES ($1, (SELECT COALESCE(MAX(version), 0) + 1 FROM publications WHERE project_id = $1), $2, `+s.db.dialect.Now()+`)
+		RETURNING version
+	`, project.ID, dbtypes.JSON(encoded)).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to keep publication: %w", err)
+	}
+	return version, nil
+}
+
+// ListPublications returns a page of a project's publications, newest
+// first and without their payloads, with how many there are. Returns
+// core.ErrProjectNotFound unless the project is in the organization in ctx.
+func (s *ProjectStore) ListPublications(ctx context.Context, projectID string, limit, offset int) ([]*core.Publication, int, error) {
+	project, err := s.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = s.db.ReadQueryRow(ctx, "publications.count",
+		`SELECT COUNT(*) FROM publications WHERE project_id = $1`, project.ID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count publications: %w", err)
+	}
+
+	rows, err := s.db.ReadQuery(ctx, "publications.list", `
+		SELECT project_id, version, published_at
+		FROM publications
+		WHERE project_id = $1
+		ORDER BY version DESC
+		LIMIT $2 OFFSET $3
+	`, project.ID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list publications: %w", err)
+	}
+	defer rows.Close()
+
+	publications := make([]*core.Publication, 0, limit)
+	for rows.Next() {
+		var publication core.Publication
+		if err := rows.Scan(&publication.ProjectID, &publication.Version, scanUTC(&publication.PublishedAt)); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan publication: %w", err)
+		}
+		publications = append(publications, &publication)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate publications: %w", err)
+	}
+	return publications, total, nil
+}
+
+// PublishedOrgID returns the organization a published project belongs to,
+// "" for none, whatever the organization in ctx. Returns
+// core.ErrProjectNotFound unless the project is published and not deleted.
+func (s *ProjectStore) PublishedOrgID(ctx context.Context, id string) (string, error) {
+	var orgID sql.NullString
+	err := s.db.ReadQueryRow(ctx, "projects.published_org_id", `
+		SELECT org_id
+		FROM projects
+		WHERE id = $1 AND published_at IS NOT NULL AND `+notDeleted("projects"),
+		id).Scan(&orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", core.ErrProjectNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get published project: %w", err)
+	}
+	return orgID.String, nil
+}
+
+// OrgID returns the organization a project belongs to, "" for none,
+// whatever the organization in ctx and whether it is published. Returns
+// core.ErrProjectNotFound unless the project exists and is not deleted.
+func (s *ProjectStore) OrgID(ctx context.Context, id string) (string, error) {
+	var orgID sql.NullString
+	err := s.db.ReadQueryRow(ctx, "projects.org_id", `
+		SELECT org_id
+		FROM projects
+		WHERE id = $1 AND `+notDeleted("projects"),
+		id).Scan(&orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", core.ErrProjectNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get project organization: %w", err)
+	}
+	return orgID.String, nil
+}
+
+// GetPublication returns a publication of a project by its version, with
+// its items. Returns core.ErrProjectNotFound unless the project is in the
+// organization in ctx, and core.ErrPublicationNotFound unless it was
+// published as version.
+func (s *ProjectStore) GetPublication(ctx context.Context, projectID string, version int) (*core.Publication, error) {
+	project, err := s.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var publication core.Publication
+	var payload dbtypes.JSON
+	err = s.db.ReadQueryRow(ctx, "publications.get", `
+		SELECT project_id, version, payload, published_at
+		FROM publications
+		WHERE project_id = $1 AND version = $2
+	`, project.ID, version).Scan(&publication.ProjectID, &publication.Version, &payload, scanUTC(&publication.PublishedAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrPublicationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get publication: %w", err)
+	}
+
+	var fields publicationPayload
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode publication %d: %w", publication.Version, err)
+	}
+	publication.Project = &core.Project{
+		ID:          publication.ProjectID,
+		Title:       fields.Project.Title,
+		Description: fields.Project.Description,
+		Tags:        fields.Project.Tags,
+		CreatedAt:   fields.Project.CreatedAt.UTC(),
+		UpdatedAt:   fields.Project.UpdatedAt.UTC(),
+		Version:     fields.Project.Version,
+	}
+	if publishedAt := fields.Project.PublishedAt; publishedAt != nil {
+		utc := publishedAt.UTC()
+		publication.Project.PublishedAt = &utc
+	}
+	publication.Items = make([]*core.Item, len(fields.Items))
+	for i, item := range fields.Items {
+		publication.Items[i] = &core.Item{
+			ID:          item.ID,
+			ProjectID:   publication.ProjectID,
+			Type:        item.Type,
+			Title:       item.Title,
+			Content:     item.Content,
+			Position:    item.Position,
+			Required:    item.Required,
+			Points:      item.Points,
+			Explanation: item.Explanation,
+			CreatedAt:   item.CreatedAt.UTC(),
+			UpdatedAt:   item.UpdatedAt.UTC(),
+			Version:     item.Version,
+		}
+	}
+	return &publication, nil
+}
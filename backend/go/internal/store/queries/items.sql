@@ -0,0 +1,161 @@
+-- name: ProjectInScope :one
+-- ProjectInScope reports whether a project is in the organization org_id.
+-- Like every item query, a NULL org_id matches the projects of no
+-- organization, a member_id must be a member of the project's organization
+-- (see orgScope), and deleted projects are left out.
+SELECT EXISTS (
+	SELECT 1 FROM projects
+	WHERE projects.id = sqlc.arg(id)
+		AND projects.deleted_at IS NULL
+		AND (projects.org_id = sqlc.narg(org_id) OR (projects.org_id IS NULL AND sqlc.narg(org_id) IS NULL))
+		AND (sqlc.narg(member_id) IS NULL OR EXISTS (
+			SELECT 1 FROM org_memberships
+			WHERE org_memberships.org_id = projects.org_id AND org_memberships.user_id = sqlc.narg(member_id)
+		))
+);
+
+-- name: CreateItem :one
+-- CreateItem is not scoped; check the project with ProjectInScope first.
+INSERT INTO items (id, project_id, type, title, content, position, required, points, explanation)
+VALUES (sqlc.arg(id), sqlc.arg(project_id), sqlc.arg(type), sqlc.arg(title), sqlc.arg(content), sqlc.arg(position), sqlc.arg(required), sqlc.narg(points), sqlc.narg(explanation))
+RETURNING id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at, version;
+
+-- name: GetItem :one
+SELECT id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at, version
+FROM items
+WHERE id = sqlc.arg(id)
+	AND project_id = sqlc.arg(project_id)
+	AND items.deleted_at IS NULL
+	AND project_id IN (
+		SELECT projects.id FROM projects
+		WHERE projects.deleted_at IS NULL
+			AND (projects.org_id = sqlc.narg(org_id) OR (projects.org_id IS NULL AND sqlc.narg(org_id) IS NULL))
+			AND (sqlc.narg(member_id) IS NULL OR EXISTS (
+				SELECT 1 FROM org_memberships
+				WHERE org_memberships.org_id = projects.org_id AND org_memberships.user_id = sqlc.narg(member_id)
+			))
+	);
+
+-- name: ListItemsByProject :many
+SELECT id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at, version
+FROM items
+WHERE project_id = sqlc.arg(project_id)
+	AND items.deleted_at IS NULL
+	AND project_id IN (
+		SELECT projects.id FROM projects
+		WHERE projects.deleted_at IS NULL
+			AND (projects.org_id = sqlc.narg(org_id) OR (projects.org_id IS NULL AND sqlc.narg(org_id) IS NULL))
+			AND (sqlc.narg(member_id) IS NULL OR EXISTS (
+				SELECT 1 FROM org_memberships
+				WHERE org_memberships.org_id = projects.org_id AND org_memberships.user_id = sqlc.narg(member_id)
+			))
+	)
+ORDER BY position ASC;
+
+-- name: SearchItems :many
+-- SearchItems ranks by the full-text index, so it only runs on dialects
+-- with the FullTextSearch capability.
+SELECT id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at, version
+FROM items
+WHERE project_id = sqlc.arg(project_id)
+	AND search_vector @@ websearch_to_tsquery('english', sqlc.arg(terms))
+	AND items.deleted_at IS NULL
+	AND project_id IN (
+		SELECT projects.id FROM projects
+		WHERE projects.deleted_at IS NULL
+			AND (projects.org_id = sqlc.narg(org_id) OR (projects.org_id IS NULL AND sqlc.narg(org_id) IS NULL))
+			AND (sqlc.narg(member_id) IS NULL OR EXISTS (
+				SELECT 1 FROM org_memberships
+				WHERE org_memberships.org_id = projects.org_id AND org_memberships.user_id = sqlc.narg(member_id)
+			))
+	)
+ORDER BY ts_rank(search_vector, websearch_to_tsquery('english', sqlc.arg(terms))) DESC, position ASC;
+
+-- name: CountItemsByProject :one
+SELECT COUNT(*)
+FROM items
+WHERE project_id = sqlc.arg(project_id)
+	AND items.deleted_at IS NULL
+	AND project_id IN (
+		SELECT projects.id FROM projects
+		WHERE projects.deleted_at IS NULL
+			AND (projects.org_id = sqlc.narg(org_id) OR (projects.org_id IS NULL AND sqlc.narg(org_id) IS NULL))
+			AND (sqlc.narg(member_id) IS NULL OR EXISTS (
+				SELECT 1 FROM org_memberships
+				WHERE org_memberships.org_id = projects.org_id AND org_memberships.user_id = sqlc.narg(member_id)
+			))
+	);
+
+-- name: MaxItemPosition :one
+-- MaxItemPosition is -1 for a project without items, as positions are
+-- never negative.
+SELECT CAST(COALESCE(MAX(position), -1) AS integer) AS max_position
+FROM items
+WHERE project_id = sqlc.arg(project_id)
+	AND items.deleted_at IS NULL
+	AND project_id IN (
+		SELECT projects.id FROM projects
+		WHERE projects.deleted_at IS NULL
+			AND (projects.org_id = sqlc.narg(org_id) OR (projects.org_id IS NULL AND sqlc.narg(org_id) IS NULL))
+			AND (sqlc.narg(member_id) IS NULL OR EXISTS (
+				SELECT 1 FROM org_memberships
+				WHERE org_memberships.org_id = projects.org_id AND org_memberships.user_id = sqlc.narg(member_id)
+			))
+	);
+
+-- name: SumItemPoints :one
+SELECT CAST(COALESCE(SUM(points), 0) AS integer) AS points
+FROM items
+WHERE project_id = sqlc.arg(project_id)
+	AND items.deleted_at IS NULL
+	AND project_id IN (
+		SELECT projects.id FROM projects
+		WHERE projects.deleted_at IS NULL
+			AND (projects.org_id = sqlc.narg(org_id) OR (projects.org_id IS NULL AND sqlc.narg(org_id) IS NULL))
+			AND (sqlc.narg(member_id) IS NULL OR EXISTS (
+				SELECT 1 FROM org_memberships
+				WHERE org_memberships.org_id = projects.org_id AND org_memberships.user_id = sqlc.narg(member_id)
+			))
+	);
+
+-- name: UpdateItem :one
+-- UpdateItem stamps updated_at with CURRENT_TIMESTAMP, which the SQLite
+-- dialect rewrites to its own Now, and increments the version. With a
+-- version, only an item still at that version is updated.
+UPDATE items
+SET type = sqlc.arg(type), title = sqlc.arg(title), content = sqlc.arg(content), position = sqlc.arg(position),
+	required = sqlc.arg(required), points = sqlc.narg(points), explanation = sqlc.narg(explanation), updated_at = CURRENT_TIMESTAMP,
+	version = items.version + 1
+WHERE id = sqlc.arg(id)
+	AND project_id = sqlc.arg(project_id)
+	AND (items.version = sqlc.narg(version) OR sqlc.narg(version) IS NULL)
+	AND items.deleted_at IS NULL
+	AND project_id IN (
+		SELECT projects.id FROM projects
+		WHERE projects.deleted_at IS NULL
+			AND (projects.org_id = sqlc.narg(org_id) OR (projects.org_id IS NULL AND sqlc.narg(org_id) IS NULL))
+			AND (sqlc.narg(member_id) IS NULL OR EXISTS (
+				SELECT 1 FROM org_memberships
+				WHERE org_memberships.org_id = projects.org_id AND org_memberships.user_id = sqlc.narg(member_id)
+			))
+	)
+RETURNING id, project_id, type, title, content, position, required, points, explanation, created_at, updated_at, version;
+
+-- name: DeleteItem :one
+-- DeleteItem soft-deletes the item, which frees its position for the
+-- project's other items.
+UPDATE items
+SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP, version = items.version + 1
+WHERE id = sqlc.arg(id)
+	AND project_id = sqlc.arg(project_id)
+	AND items.deleted_at IS NULL
+	AND project_id IN (
+		SELECT projects.id FROM projects
+		WHERE projects.deleted_at IS NULL
+			AND (projects.org_id = sqlc.narg(org_id) OR (projects.org_id IS NULL AND sqlc.narg(org_id) IS NULL))
+			AND (sqlc.narg(member_id) IS NULL OR EXISTS (
+				SELECT 1 FROM org_memberships
+				WHERE org_memberships.org_id = projects.org_id AND org_memberships.user_id = sqlc.narg(member_id)
+			))
+	)
+RETURNING project_id;
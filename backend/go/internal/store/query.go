@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultSlowQueryThreshold is used until Instrument configures another one
+const DefaultSlowQueryThreshold = 200 * time.Millisecond
+
+// maxLoggedSQLLength bounds the SQL summary in slow query logs
+const maxLoggedSQLLength = 200
+
+// QueryObserver is notified of every statement run through a Runner, e.g. to
+// feed a latency histogram, and of every retry. name is the short query name
+// given by the store.
+type QueryObserver interface {
+	ObserveQuery(name string, duration time.Duration, err error)
+	ObserveRetry(name string)
+}
+
+// conn is the subset of *sql.DB and *sql.Tx used to run statements
+type conn interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// queryInstrumentation is shared by a Database and the Runners it creates
+type queryInstrumentation struct {
+	slowThreshold time.Duration
+	observer      QueryObserver
+}
+
+// Runner runs named statements against a database or transaction, timing
+// each one. Statements slower than the slow query threshold are logged with
+// the request's logger. Stores name their queries (e.g.
+// "items.list_by_project") so logs and metrics never carry raw SQL or
+// arguments.
+type Runner struct {
+	conn    conn
+	dialect Dialect
+	instr   *queryInstrumentation
+	// session is the connection a transaction runs on, for statements sent
+	// through the driver's native interface (see sendBatch)
+	session *sql.Conn
+}
+
+// Query runs a statement that returns rows
+func (r *Runner) Query(ctx context.Context, name, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	bound, args := r.dialect.rebind(query, args)
+	rows, err := r.conn.QueryContext(ctx, bound, args...)
+	r.observe(ctx, name, query, start, err)
+	return rows, err
+}
+
+// QueryRow runs a statement that returns at most one row. The statement runs
+// before QueryRow returns, so the timing covers it; errors surface on Scan.
+func (r *Runner) QueryRow(ctx context.Context, name, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	bound, args := r.dialect.rebind(query, args)
+	row := r.conn.QueryRowContext(ctx, bound, args...)
+	r.observe(ctx, name, query, start, row.Err())
+	return row
+}
+
+// Exec runs a statement that returns no rows
+func (r *Runner) Exec(ctx context.Context, name, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	bound, args := r.dialect.rebind(query, args)
+	result, err := r.conn.ExecContext(ctx, bound, args...)
+	r.observe(ctx, name, query, start, err)
+	return result, err
+}
+
+func (r *Runner) observe(ctx context.Context, name, query string, start time.Time, err error) {
+	duration := time.Since(start)
+
+	if r.instr.observer != nil {
+		r.instr.observer.ObserveQuery(name, duration, err)
+	}
+
+	if r.instr.slowThreshold > 0 && duration >= r.instr.slowThreshold {
+		log.Ctx(ctx).Warn().
+			Str("query", name).
+			Str("sql", summarizeSQL(query)).
+			Dur("duration", duration).
+			Dur("threshold", r.instr.slowThreshold).
+			Msg("slow query")
+	}
+}
+
+// summarizeSQL collapses whitespace and truncates a statement for logging.
+// Arguments are never included.
+func summarizeSQL(query string) string {
+	summary := strings.Join(strings.Fields(query), " ")
+	if len(summary) > maxLoggedSQLLength {
+		summary = summary[:maxLoggedSQLLength] + "..."
+	}
+	return summary
+}
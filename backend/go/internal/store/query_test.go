@@ -0,0 +1,350 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/metrics"
+)
+
+// stubDriver is a database/sql driver whose statements take a configurable
+// time and return a single row with one column
+type stubDriver struct {
+	latency time.Duration
+	err     error
+	// value, if set, is the row's value in place of "row-1"
+	value driver.Value
+	// pingFailures is the number of pings that fail before one succeeds
+	pingFailures atomic.Int32
+	// match, if set, decides whether a query returns its row
+	match func(query string, args []driver.NamedValue) bool
+
+	mu sync.Mutex
+	// injected errors are returned by the next statements, one each
+	injected   []error
+	statements int
+	// lastQuery and lastArgs record the most recent statement
+	lastQuery string
+	lastArgs  []interface{}
+	// begins, commits and rollbacks count transactions
+	begins, commits, rollbacks int
+	// notifications are the payloads of the changes announced, which are
+	// neither counted nor recorded as statements
+	notifications []string
+}
+
+// last returns the most recent statement and its arguments
+func (d *stubDriver) last() (string, []interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.lastQuery, d.lastArgs
+}
+
+func (d *stubDriver) record(query string, args []driver.NamedValue) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lastQuery = query
+	d.lastArgs = make([]interface{}, len(args))
+	for i, arg := range args {
+		d.lastArgs[i] = arg.Value
+	}
+}
+
+// notify records an announced change, failing like any statement
+func (d *stubDriver) notify(args []driver.NamedValue) (driver.Result, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.err != nil {
+		return nil, d.err
+	}
+	d.notifications = append(d.notifications, args[1].Value.(string))
+	return driver.RowsAffected(1), nil
+}
+
+// inject makes the next len(errs) statements fail with errs in order
+func (d *stubDriver) inject(errs ...error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.injected = errs
+}
+
+func (d *stubDriver) next() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.statements++
+	if len(d.injected) == 0 {
+		return d.err
+	}
+	err := d.injected[0]
+	d.injected = d.injected[1:]
+	return err
+}
+
+func (d *stubDriver) Open(name string) (driver.Conn, error) {
+	return &stubConn{driver: d}, nil
+}
+
+type stubConn struct {
+	driver *stubDriver
+}
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c *stubConn) Close() error { return nil }
+
+func (c *stubConn) Begin() (driver.Tx, error) {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	c.driver.begins++
+	return stubTx{driver: c.driver}, nil
+}
+
+type stubTx struct {
+	driver *stubDriver
+}
+
+func (t stubTx) Commit() error {
+	t.driver.mu.Lock()
+	defer t.driver.mu.Unlock()
+	t.driver.commits++
+	return nil
+}
+
+func (t stubTx) Rollback() error {
+	t.driver.mu.Lock()
+	defer t.driver.mu.Unlock()
+	t.driver.rollbacks++
+	return nil
+}
+
+func (c *stubConn) Ping(ctx context.Context) error {
+	if c.driver.pingFailures.Add(-1) >= 0 {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (c *stubConn) wait(ctx context.Context) error {
+	select {
+	case <-time.After(c.driver.latency):
+		return c.driver.next()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *stubConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.driver.record(query, args)
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	if c.driver.match != nil && !c.driver.match(query, args) {
+		return &stubRows{done: true}, nil
+	}
+	return &stubRows{value: c.driver.value}, nil
+}
+
+// CheckNamedValue passes string slices to the driver, as pgx's does, and
+// leaves other arguments to database/sql
+func (c *stubConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.([]string); ok {
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+func (c *stubConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(query, "pg_notify(") {
+		return c.driver.notify(args)
+	}
+	c.driver.record(query, args)
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type stubRows struct {
+	done  bool
+	value driver.Value
+}
+
+func (r *stubRows) Columns() []string { return []string{"id"} }
+
+func (r *stubRows) Close() error { return nil }
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = "row-1"
+	if r.value != nil {
+		dest[0] = r.value
+	}
+	return nil
+}
+
+var registerStubDriver sync.Once
+
+// stub is shared by every test database; tests set its latency and error
+var stub = &stubDriver{}
+
+func newStubDatabase(t *testing.T, latency time.Duration, err error) *Database {
+	t.Helper()
+
+	registerStubDriver.Do(func() {
+		sql.Register("stub", stub)
+	})
+	stub.latency, stub.err = latency, err
+	stub.match, stub.value = nil, nil
+	stub.pingFailures.Store(0)
+	stub.inject()
+	stub.mu.Lock()
+	stub.statements = 0
+	stub.begins, stub.commits, stub.rollbacks = 0, 0, 0
+	stub.lastQuery, stub.lastArgs = "", nil
+	stub.notifications = nil
+	stub.mu.Unlock()
+
+	db, openErr := sql.Open("stub", "")
+	require.NoError(t, openErr)
+	t.Cleanup(func() { db.Close() })
+
+	return newDatabase(db, postgresDialect{})
+}
+
+// recordingObserver collects ObserveQuery and ObserveRetry calls
+type recordingObserver struct {
+	names   []string
+	errs    []error
+	retries []string
+}
+
+func (o *recordingObserver) ObserveRetry(name string) {
+	o.retries = append(o.retries, name)
+}
+
+func (o *recordingObserver) ObserveQuery(name string, duration time.Duration, err error) {
+	o.names = append(o.names, name)
+	o.errs = append(o.errs, err)
+}
+
+func TestRunner_LogsSlowQueries(t *testing.T) {
+	tests := []struct {
+		name       string
+		latency    time.Duration
+		threshold  time.Duration
+		expectSlow bool
+	}{
+		{"fast query is not logged", 0, 20 * time.Millisecond, false},
+		{"slow query is logged", 30 * time.Millisecond, 20 * time.Millisecond, true},
+		{"zero threshold disables the log", 30 * time.Millisecond, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			database := newStubDatabase(t, tt.latency, nil)
+			observer := &recordingObserver{}
+			database.Instrument(tt.threshold, observer)
+
+			var buf bytes.Buffer
+			ctx := zerolog.New(&buf).With().Str("request_id", "req-123").Logger().WithContext(context.Background())
+
+			// Act
+			var id string
+			err := database.QueryRow(ctx, "items.get_by_id", `
+				SELECT id
+				FROM items
+				WHERE id = $1
+			`, "secret-argument").Scan(&id)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, "row-1", id)
+			assert.Equal(t, []string{"items.get_by_id"}, observer.names)
+
+			if !tt.expectSlow {
+				assert.Empty(t, buf.String())
+				return
+			}
+			out := buf.String()
+			assert.Contains(t, out, `"message":"slow query"`)
+			assert.Contains(t, out, `"query":"items.get_by_id"`)
+			assert.Contains(t, out, `"sql":"SELECT id FROM items WHERE id = $1"`)
+			assert.Contains(t, out, `"request_id":"req-123"`)
+			assert.Contains(t, out, `"duration"`)
+			assert.NotContains(t, out, "secret-argument")
+		})
+	}
+}
+
+func TestRunner_ReportsErrors(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, errors.New("connection reset by peer"))
+	observer := &recordingObserver{}
+	database.Instrument(time.Second, observer)
+
+	// Act
+	_, err := database.Exec(context.Background(), "items.delete", "DELETE FROM items WHERE id = $1", "item-1")
+
+	// Assert
+	require.Error(t, err)
+	require.Len(t, observer.errs, 1)
+	assert.Error(t, observer.errs[0])
+}
+
+func TestSummarizeSQL_Truncates(t *testing.T) {
+	// Arrange
+	query := "SELECT " + strings.Repeat("column_name, ", 50) + "id FROM items"
+
+	// Act
+	summary := summarizeSQL(query)
+
+	// Assert
+	assert.Len(t, summary, maxLoggedSQLLength+len("..."))
+	assert.True(t, strings.HasPrefix(summary, "SELECT column_name,"))
+}
+
+func TestRunner_FeedsQueryHistogram(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 5*time.Millisecond, nil)
+	registry := prometheus.NewRegistry()
+	database.Instrument(time.Second, metrics.NewQueryMetrics(registry))
+
+	// Act
+	rows, err := database.Query(context.Background(), "items.list_by_project", "SELECT id FROM items WHERE project_id = $1", "project-1")
+	require.NoError(t, err)
+	rows.Close()
+
+	// Assert
+	expected := `
+		# HELP provemyself_db_query_errors_total Total number of database statements that returned an error.
+		# TYPE provemyself_db_query_errors_total counter
+	`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "provemyself_db_query_errors_total"))
+	count, err := testutil.GatherAndCount(registry, "provemyself_db_query_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,180 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/XSAM/otelsql"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store/dbgen"
+)
+
+// replicaRecheckInterval is how long reads stay on the primary after the
+// replica failed before they try the replica again
+const replicaRecheckInterval = 30 * time.Second
+
+// replica is a read replica of the database, which applies the primary's
+// writes with some lag
+type replica struct {
+	db *sql.DB
+	// pool is as Database.pool
+	pool *pgxpool.Pool
+	// failedAt is when the replica last failed, in Unix nanoseconds, or 0
+	failedAt atomic.Int64
+}
+
+// openReplica opens the pool of the read replica. A replica that does not
+// answer yet is not an error: reads use the primary until it does.
+func openReplica(ctx context.Context, dialect Dialect, cfg DatabaseConfig, traced []otelsql.Option) (*replica, error) {
+	replicaDialect, dsn, err := dialectForURL(cfg.ReplicaURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid replica URL: %w", err)
+	}
+	if replicaDialect.Name() != dialect.Name() {
+		return nil, fmt.Errorf("replica is a %s database, the primary %s", replicaDialect.Name(), dialect.Name())
+	}
+
+	db, pool, err := openDB(dialect, dsn, cfg, traced)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replica: %w", err)
+	}
+	r := &replica{db: db, pool: pool}
+
+	if err := r.ping(ctx); err != nil {
+		r.markFailed(err)
+	} else {
+		log.Info().Msg("read replica connection established")
+	}
+	return r, nil
+}
+
+func (r *replica) ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if r.pool != nil {
+		return r.pool.Ping(ctx)
+	}
+	return r.db.PingContext(ctx)
+}
+
+// usable reports whether reads may go to the replica: it has not failed
+// lately
+func (r *replica) usable() bool {
+	failedAt := r.failedAt.Load()
+	return failedAt == 0 || time.Since(time.Unix(0, failedAt)) >= replicaRecheckInterval
+}
+
+// markFailed sends reads to the primary for replicaRecheckInterval
+func (r *replica) markFailed(err error) {
+	if r.failedAt.Swap(time.Now().UnixNano()) == 0 {
+		log.Warn().Err(err).Msg("read replica failed, reading from the primary")
+	}
+}
+
+// markHealthy sends reads back to the replica
+func (r *replica) markHealthy() {
+	if r.failedAt.Swap(0) != 0 {
+		log.Info().Msg("read replica recovered")
+	}
+}
+
+func (r *replica) close() {
+	r.db.Close()
+	if r.pool != nil {
+		r.pool.Close()
+	}
+}
+
+// ReplicaError reports a failing read replica. Reads fall back to the
+// primary, so the service is degraded rather than unready.
+type ReplicaError struct {
+	Err error
+}
+
+func (e *ReplicaError) Error() string {
+	return fmt.Sprintf("read replica health check failed: %v", e.Err)
+}
+
+// Unwrap returns the error of the replica's ping
+func (e *ReplicaError) Unwrap() error {
+	return e.Err
+}
+
+// Degraded marks the error as degrading rather than failing readiness
+func (e *ReplicaError) Degraded() bool {
+	return true
+}
+
+// HasReplica reports whether a read replica is configured
+func (d *Database) HasReplica() bool {
+	return d.replica != nil
+}
+
+// ReplicaHealthCheck checks if the read replica is accessible, returning a
+// *ReplicaError if not. A replica that answers again serves reads at once.
+// Without a replica it always succeeds.
+func (d *Database) ReplicaHealthCheck(ctx context.Context) error {
+	if d.replica == nil {
+		return nil
+	}
+	if err := d.replica.ping(ctx); err != nil {
+		d.replica.markFailed(err)
+		return &ReplicaError{Err: err}
+	}
+	d.replica.markHealthy()
+	return nil
+}
+
+// replicaRunner returns a Runner for the read replica, and false when the
+// statement must read the primary: no replica is usable, ctx is in a
+// transaction or ctx asks for fresh reads (see core.WithFreshReads)
+func (d *Database) replicaRunner(ctx context.Context) (*Runner, bool) {
+	if d.replica == nil || !d.replica.usable() || core.FreshReads(ctx) {
+		return nil, false
+	}
+	if _, ok := txFromContext(ctx); ok {
+		return nil, false
+	}
+	return d.runner(d.replica.db), true
+}
+
+// StaleQuery is ReadQuery for statements that tolerate the replica's lag,
+// like listings and aggregations: it reads the replica when one is usable.
+// A transient replica failure sends the statement, and the next ones for a
+// while, to the primary.
+func (d *Database) StaleQuery(ctx context.Context, name, query string, args ...interface{}) (*sql.Rows, error) {
+	if runner, ok := d.replicaRunner(ctx); ok {
+		rows, err := runner.Query(ctx, name, query, args...)
+		if !d.transient(err) {
+			return rows, err
+		}
+		d.replica.markFailed(err)
+	}
+	return d.ReadQuery(ctx, name, query, args...)
+}
+
+// StaleQueryRow is ReadQueryRow for statements that tolerate the replica's
+// lag (see StaleQuery)
+func (d *Database) StaleQueryRow(ctx context.Context, name, query string, args ...interface{}) *sql.Row {
+	if runner, ok := d.replicaRunner(ctx); ok {
+		row := runner.QueryRow(ctx, name, query, args...)
+		if err := row.Err(); !d.transient(err) {
+			return row
+		}
+		d.replica.markFailed(row.Err())
+	}
+	return d.ReadQueryRow(ctx, name, query, args...)
+}
+
+// readStale returns the generated queries for a read-only statement named
+// name that tolerates the replica's lag
+func (d *Database) readStale(name string) *dbgen.Queries {
+	return dbgen.New(namedStatements{db: d, name: name, read: true, stale: true})
+}
@@ -0,0 +1,201 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+var registerReplicaDriver sync.Once
+
+// replicaStub is the driver of the test replica. Its rows hold "stale",
+// the primary's "fresh", standing for data the replica has yet to apply.
+var replicaStub = &stubDriver{}
+
+// withStubReplica gives database a stub replica
+func withStubReplica(t *testing.T, database *Database) {
+	t.Helper()
+
+	registerReplicaDriver.Do(func() {
+		sql.Register("stub-replica", replicaStub)
+	})
+	replicaStub.latency, replicaStub.err = 0, nil
+	replicaStub.match, replicaStub.value = nil, "stale"
+	replicaStub.pingFailures.Store(0)
+	replicaStub.inject()
+	replicaStub.mu.Lock()
+	replicaStub.statements = 0
+	replicaStub.lastQuery, replicaStub.lastArgs = "", nil
+	replicaStub.mu.Unlock()
+	stub.value = "fresh"
+
+	db, err := sql.Open("stub-replica", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	database.replica = &replica{db: db}
+}
+
+// readValue reads the single stub row with StaleQueryRow
+func readValue(t *testing.T, ctx context.Context, database *Database) string {
+	t.Helper()
+
+	var value string
+	require.NoError(t, database.StaleQueryRow(ctx, "test.stale", "SELECT 1").Scan(&value))
+	return value
+}
+
+func TestDatabase_StaleQuery_ReadsReplica(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	withStubReplica(t, database)
+	ctx := context.Background()
+
+	// Act
+	rows, err := database.StaleQuery(ctx, "test.stale", "SELECT 1")
+	require.NoError(t, err)
+	items, err := scanValues(rows)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, []string{"stale"}, items)
+	assert.Equal(t, "stale", readValue(t, ctx, database))
+	assert.Equal(t, 2, replicaStub.statements)
+	assert.Zero(t, stub.statements)
+	assert.Same(t, database.replica.db, database.ReadDB())
+}
+
+func TestDatabase_StaleQuery_FreshReadsAndTransactionsReadPrimary(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	withStubReplica(t, database)
+
+	// Act
+	fresh := readValue(t, core.WithFreshReads(context.Background()), database)
+	var inTx string
+	err := database.InTx(context.Background(), "test.tx", func(ctx context.Context) error {
+		inTx = readValue(t, ctx, database)
+		return nil
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", fresh)
+	assert.Equal(t, "fresh", inTx)
+	assert.Zero(t, replicaStub.statements)
+}
+
+func TestDatabase_StaleQuery_FallsBackWhileReplicaFails(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	withStubReplica(t, database)
+	replicaStub.inject(&net.OpError{Op: "read", Err: syscall.ECONNRESET})
+	ctx := context.Background()
+
+	// Act & Assert: the failed read is answered by the primary, and so is
+	// the next one
+	assert.Equal(t, "fresh", readValue(t, ctx, database))
+	assert.Equal(t, "fresh", readValue(t, ctx, database))
+	assert.Equal(t, 1, replicaStub.statements)
+	assert.Same(t, database.DB(), database.ReadDB())
+
+	// Act & Assert: a passing health check sends reads back to the replica
+	require.NoError(t, database.ReplicaHealthCheck(ctx))
+	assert.Equal(t, "stale", readValue(t, ctx, database))
+}
+
+func TestDatabase_StaleQuery_RetriesReplicaAfterInterval(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	withStubReplica(t, database)
+	database.replica.failedAt.Store(time.Now().Add(-replicaRecheckInterval).UnixNano())
+
+	// Act & Assert
+	assert.Equal(t, "stale", readValue(t, context.Background(), database))
+}
+
+func TestDatabase_StaleQuery_KeepsReplicaOnQueryErrors(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	withStubReplica(t, database)
+	replicaStub.inject(errors.New("syntax error"))
+
+	// Act
+	err := database.StaleQueryRow(context.Background(), "test.stale", "SELECT 1").Scan(new(string))
+
+	// Assert
+	assert.EqualError(t, err, "syntax error")
+	assert.Zero(t, stub.statements)
+	assert.True(t, database.replica.usable())
+}
+
+func TestDatabase_ReplicaHealthCheck(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	ctx := context.Background()
+
+	// Act & Assert: without a replica, reads use the primary
+	assert.NoError(t, database.ReplicaHealthCheck(ctx))
+	assert.Same(t, database.DB(), database.ReadDB())
+
+	// Act
+	withStubReplica(t, database)
+	replicaStub.pingFailures.Store(1)
+	err := database.ReplicaHealthCheck(ctx)
+
+	// Assert
+	var replicaErr *ReplicaError
+	require.ErrorAs(t, err, &replicaErr)
+	assert.True(t, replicaErr.Degraded())
+	assert.Same(t, database.DB(), database.ReadDB())
+	assert.Equal(t, "fresh", readValue(t, ctx, database))
+
+	require.NoError(t, database.ReplicaHealthCheck(ctx))
+	assert.Same(t, database.replica.db, database.ReadDB())
+}
+
+func TestProjectStore_List_ReadsReplica(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	withStubReplica(t, database)
+	replicaStub.value = int64(0)
+	replicaStub.match = func(query string, args []driver.NamedValue) bool {
+		return strings.HasPrefix(query, "SELECT COUNT(*)")
+	}
+	projects := NewProjectStore(database)
+
+	// Act
+	_, err := projects.List(context.Background(), core.ListOptions{Limit: 10})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 2, replicaStub.statements, "count and page")
+	assert.Zero(t, stub.statements)
+}
+
+// scanValues reads the single column of every row
+func scanValues(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
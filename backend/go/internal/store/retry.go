@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Retry limits. Reads are retried on any transient error; transactions are
+// retried as a whole, up to three times, and only when the database aborted
+// them.
+const (
+	maxReadAttempts     = 3
+	maxTxAttempts       = 4
+	retryInitialBackoff = 50 * time.Millisecond
+)
+
+// isTransient reports whether a read failed for a reason a retry may get
+// past, such as a failover dropping connections
+func isTransient(dialect Dialect, err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if dialect.Transient(err) {
+		return true
+	}
+
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+// transient is isTransient for the database's dialect
+func (d *Database) transient(err error) bool {
+	return isTransient(d.dialect, err)
+}
+
+// retry runs fn until it succeeds, fails with an error retryable rejects or
+// runs out of attempts, backing off exponentially in between. Each wait is
+// jittered, so callers that failed together, like two transactions one of
+// which the other deadlocked, do not collide again. It stops waiting as
+// soon as ctx is done.
+func (d *Database) retry(ctx context.Context, name string, attempts int, retryable func(error) bool, fn func() error) error {
+	backoff := retryInitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt == attempts || !retryable(err) {
+			return err
+		}
+
+		if d.instr.observer != nil {
+			d.instr.observer.ObserveRetry(name)
+		}
+		wait := jittered(backoff)
+		log.Ctx(ctx).Warn().
+			Err(err).
+			Str("query", name).
+			Int("attempt", attempt).
+			Dur("retry_in", wait).
+			Msg("retrying transient database error")
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+}
+
+// jittered returns a wait between half of backoff and backoff
+func jittered(backoff time.Duration) time.Duration {
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// ReadQuery is Query for read-only statements: transient failures are
+// retried, except inside a transaction, which a failed statement aborts.
+// Errors while iterating the returned rows are not retried.
+func (d *Database) ReadQuery(ctx context.Context, name, query string, args ...interface{}) (*sql.Rows, error) {
+	if _, ok := txFromContext(ctx); ok {
+		return d.Query(ctx, name, query, args...)
+	}
+
+	var rows *sql.Rows
+	err := d.retry(ctx, name, maxReadAttempts, d.transient, func() error {
+		var err error
+		rows, err = d.Query(ctx, name, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// ReadQueryRow is QueryRow for read-only statements: transient failures are
+// retried outside transactions
+func (d *Database) ReadQueryRow(ctx context.Context, name, query string, args ...interface{}) *sql.Row {
+	if _, ok := txFromContext(ctx); ok {
+		return d.QueryRow(ctx, name, query, args...)
+	}
+
+	var row *sql.Row
+	d.retry(ctx, name, maxReadAttempts, d.transient, func() error {
+		row = d.QueryRow(ctx, name, query, args...)
+		return row.Err()
+	})
+	return row
+}
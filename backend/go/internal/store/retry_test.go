@@ -0,0 +1,257 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// unsentError is a failure pgx reports as safe to retry, as it happened
+// before the statement was sent
+type unsentError struct{}
+
+func (unsentError) Error() string { return "failed to write startup message" }
+
+func (unsentError) SafeToRetry() bool { return true }
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"deadlock detected", &pgconn.PgError{Code: "40P01"}, true},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, true},
+		{"cannot connect now", &pgconn.PgError{Code: "57P03"}, true},
+		{"wrapped pgx error", fmt.Errorf("failed to query: %w", &pgconn.PgError{Code: "40001"}), true},
+		{"statement never sent", fmt.Errorf("failed to query: %w", unsentError{}), true},
+		{"bad connection", driver.ErrBadConn, true},
+		{"connection refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"syntax error", &pgconn.PgError{Code: "42601"}, false},
+		{"context canceled", context.Canceled, false},
+		{"deadline exceeded", context.DeadlineExceeded, false},
+		{"other error", errors.New("boom"), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act & Assert
+			assert.Equal(t, tt.transient, isTransient(postgresDialect{}, tt.err))
+		})
+	}
+}
+
+func TestIsTransient_SQLite(t *testing.T) {
+	sqlite := sqliteDialect{}
+
+	assert.True(t, isTransient(sqlite, sqlite3.Error{Code: sqlite3.ErrBusy}))
+	assert.True(t, isTransient(sqlite, fmt.Errorf("failed to query: %w", sqlite3.Error{Code: sqlite3.ErrLocked})))
+	assert.True(t, isTransient(sqlite, io.ErrUnexpectedEOF))
+	assert.False(t, isTransient(sqlite, sqlite3.Error{Code: sqlite3.ErrConstraint}))
+	assert.False(t, isTransient(sqlite, &pgconn.PgError{Code: "40001"}))
+}
+
+func TestIsTxConflict(t *testing.T) {
+	postgres := postgresDialect{}
+	assert.True(t, postgres.TxConflict(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, postgres.TxConflict(&pgconn.PgError{Code: "40P01"}))
+	assert.False(t, postgres.TxConflict(&pgconn.PgError{Code: "57P01"}))
+	assert.False(t, postgres.TxConflict(driver.ErrBadConn))
+
+	sqlite := sqliteDialect{}
+	assert.True(t, sqlite.TxConflict(sqlite3.Error{Code: sqlite3.ErrBusy}))
+	assert.False(t, sqlite.TxConflict(sqlite3.Error{Code: sqlite3.ErrConstraint}))
+}
+
+// connReset is a transient error. driver.ErrBadConn is not used in these
+// tests because database/sql already retries it internally.
+var connReset = &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+
+func TestReadQueryRow_RetriesTransientErrors(t *testing.T) {
+	tests := []struct {
+		name               string
+		injected           []error
+		expectErr          bool
+		expectedStatements int
+	}{
+		{"succeeds after two failures", []error{connReset, &pgconn.PgError{Code: "57P01"}}, false, 3},
+		{"gives up after three attempts", []error{connReset, connReset, connReset}, true, 3},
+		{"does not retry permanent errors", []error{&pgconn.PgError{Code: "42P01"}}, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			database := newStubDatabase(t, 0, nil)
+			observer := &recordingObserver{}
+			database.Instrument(0, observer)
+			stub.inject(tt.injected...)
+
+			// Act
+			var id string
+			err := database.ReadQueryRow(context.Background(), "items.get_by_id", "SELECT id FROM items WHERE id = $1", "item-1").Scan(&id)
+
+			// Assert
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "row-1", id)
+			}
+			assert.Equal(t, tt.expectedStatements, stub.statements)
+			assert.Len(t, observer.retries, tt.expectedStatements-1, "one retry metric per retry")
+		})
+	}
+}
+
+func TestReadQuery_StopsRetryingWhenContextIsDone(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, connReset)
+	database.Instrument(0, &recordingObserver{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Act
+	_, err := database.ReadQuery(ctx, "items.list_by_project", "SELECT id FROM items WHERE project_id = $1", "project-1")
+
+	// Assert
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, stub.statements, "no attempt after the context expired")
+}
+
+func TestExec_DoesNotRetryWrites(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	database.Instrument(0, &recordingObserver{})
+	stub.inject(&pgconn.PgError{Code: "57P01"})
+
+	// Act
+	_, err := database.Exec(context.Background(), "items.delete", "DELETE FROM items WHERE id = $1", "item-1")
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, 1, stub.statements)
+}
+
+func TestTransaction_RetriesSerializationFailures(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	observer := &recordingObserver{}
+	database.Instrument(0, observer)
+	stub.inject(&pgconn.PgError{Code: "40001"})
+
+	// Act
+	runs := 0
+	err := database.Transaction(context.Background(), "items.update_positions", func(tx *Runner) error {
+		runs++
+		if _, err := tx.Exec(context.Background(), "items.update_position", "UPDATE items SET position = $2 WHERE id = $1", "item-1", 1); err != nil {
+			return err
+		}
+		_, err := tx.Exec(context.Background(), "items.update_position", "UPDATE items SET position = $2 WHERE id = $1", "item-2", 2)
+		return err
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 2, runs, "the whole transaction ran again")
+	assert.Equal(t, []string{"items.update_positions"}, observer.retries)
+}
+
+func TestTransaction_DoesNotRetryOtherErrors(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	database.Instrument(0, &recordingObserver{})
+	stub.inject(connReset)
+
+	// Act
+	runs := 0
+	err := database.Transaction(context.Background(), "items.update_positions", func(tx *Runner) error {
+		runs++
+		_, err := tx.Exec(context.Background(), "items.update_position", "UPDATE items SET position = $2 WHERE id = $1", "item-1", 1)
+		return err
+	})
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, 1, runs)
+}
+
+func TestTransaction_GivesUpOnConcurrentModification(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	observer := &recordingObserver{}
+	database.Instrument(0, observer)
+	deadlock := &pgconn.PgError{Code: "40P01"}
+	stub.inject(deadlock, deadlock, deadlock, deadlock)
+
+	// Act
+	runs := 0
+	err := database.Transaction(context.Background(), "items.update_positions", func(tx *Runner) error {
+		runs++
+		_, err := tx.Exec(context.Background(), "items.update_position", "UPDATE items SET position = $2 WHERE id = $1", "item-1", 1)
+		return err
+	})
+
+	// Assert
+	require.ErrorIs(t, err, core.ErrConcurrentModification)
+	assert.ErrorIs(t, err, deadlock, "the database's error is kept")
+	assert.Equal(t, 4, runs, "three retries")
+	assert.Len(t, observer.retries, 3)
+	assert.Equal(t, 4, stub.rollbacks)
+	assert.Zero(t, stub.commits)
+}
+
+func TestItemStore_UpdatePositions_RetriesDeadlocks(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	database.Instrument(0, &recordingObserver{})
+	stub.inject(&pgconn.PgError{Code: "40P01"})
+	items := NewItemStore(database)
+	ctx := SystemScope(context.Background())
+
+	// Act
+	err := database.InTx(ctx, "items.update_positions", func(ctx context.Context) error {
+		return items.UpdatePositions(ctx, "project-1", []core.PositionUpdate{{ItemID: "item-1", Position: 1}})
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 2, stub.begins, "the whole reorder ran again")
+	assert.Equal(t, 1, stub.rollbacks)
+	assert.Equal(t, 1, stub.commits)
+	assert.Equal(t, 3, stub.statements, "the failed deferral, then the deferral and the move")
+}
+
+func TestJittered(t *testing.T) {
+	for _, backoff := range []time.Duration{0, time.Nanosecond, retryInitialBackoff, 4 * retryInitialBackoff} {
+		t.Run(backoff.String(), func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				// Act
+				wait := jittered(backoff)
+
+				// Assert
+				assert.GreaterOrEqual(t, wait, backoff/2)
+				assert.LessOrEqual(t, wait, backoff)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// orgScope returns the condition restricting column to the organization in
+// ctx, binding its ID to placeholder $n. Unscoped contexts match only rows
+// that belong to no organization, so tenants never see each other's data
+// and a single-tenant deployment sees everything. Unless the access scope
+// in ctx needs no membership, the condition also requires its user, bound
+// to $n+1, to be a member of the organization, so a foreign organization's
+// rows stay out of reach whatever the handler checked.
+func orgScope(ctx context.Context, column string, n int) (string, []interface{}) {
+	orgID := core.OrgIDFromContext(ctx)
+	if orgID == "" {
+		return column + " IS NULL", nil
+	}
+
+	condition := fmt.Sprintf("%s = $%d", column, n)
+	if memberID := memberIDParam(ctx); memberID != nil {
+		condition += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM org_memberships WHERE org_memberships.org_id = $%d AND org_memberships.user_id = $%d)", n, n+1)
+		return condition, []interface{}{orgID, *memberID}
+	}
+	return condition, []interface{}{orgID}
+}
+
+// orgIDArg is the org_id to store for rows created in ctx
+func orgIDArg(ctx context.Context) interface{} {
+	if orgID := core.OrgIDFromContext(ctx); orgID != "" {
+		return orgID
+	}
+	return nil
+}
+
+// orgIDParam is the org_id the generated queries are scoped to, nil for an
+// unscoped context, which they match like orgScope
+func orgIDParam(ctx context.Context) *string {
+	if orgID := core.OrgIDFromContext(ctx); orgID != "" {
+		return &orgID
+	}
+	return nil
+}
+
+// memberIDParam is the user who must be a member of the organization in ctx,
+// nil for an unscoped context or an access scope that needs no membership
+// there. The generated queries take it as member_id.
+func memberIDParam(ctx context.Context) *string {
+	orgID := core.OrgIDFromContext(ctx)
+	scope := core.AccessScopeFromContext(ctx)
+	if orgID == "" || !scope.NeedsMembership(orgID) {
+		return nil
+	}
+	return &scope.UserID
+}
+
+// SystemScope makes the stores act for the system in ctx, reaching the
+// organization in ctx without a membership. Background jobs use it; request
+// contexts get their user's scope from the OrgScope middleware.
+func SystemScope(ctx context.Context) context.Context {
+	return core.WithAccessScope(ctx, core.AccessScope{System: true})
+}
+
+// notDeleted is the condition leaving out the soft-deleted rows of table.
+// Deleting a project or an item only stamps its deleted_at, so every query
+// of those tables must include it, or ask for deleted rows explicitly (see
+// core.ListOptions.IncludeDeleted); TestStoreQueries_LeaveOutDeletedRows
+// checks that they do.
+func notDeleted(table string) string {
+	return table + ".deleted_at IS NULL"
+}
+
+// andScope joins where, whose placeholders are bound to args, with scope,
+// numbering the scope's placeholder after them
+func andScope(where string, args []interface{}, scope func(n int) (string, []interface{})) (string, []interface{}) {
+	condition, scopeArgs := scope(len(args) + 1)
+	if where != "" {
+		condition = "(" + where + ") AND " + condition
+	}
+	return condition, append(args, scopeArgs...)
+}
@@ -0,0 +1,285 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+func TestAndScope(t *testing.T) {
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		where         string
+		args          []interface{}
+		expectedWhere string
+		expectedArgs  []interface{}
+	}{
+		{
+			name:          "scoped context binds the organization after the arguments",
+			ctx:           SystemScope(core.WithOrgID(context.Background(), "org-a")),
+			where:         "id = $1",
+			args:          []interface{}{"project-1"},
+			expectedWhere: "(id = $1) AND org_id = $2",
+			expectedArgs:  []interface{}{"project-1", "org-a"},
+		},
+		{
+			name:          "user must be a member of the organization",
+			ctx:           core.WithAccessScope(core.WithOrgID(context.Background(), "org-a"), core.AccessScope{UserID: "user-1", Role: "user"}),
+			where:         "id = $1",
+			args:          []interface{}{"project-1"},
+			expectedWhere: "(id = $1) AND org_id = $2 AND EXISTS (SELECT 1 FROM org_memberships WHERE org_memberships.org_id = $2 AND org_memberships.user_id = $3)",
+			expectedArgs:  []interface{}{"project-1", "org-a", "user-1"},
+		},
+		{
+			name:          "context without an access scope is a member of nothing",
+			ctx:           core.WithOrgID(context.Background(), "org-a"),
+			where:         "id = $1",
+			args:          []interface{}{"project-1"},
+			expectedWhere: "(id = $1) AND org_id = $2 AND EXISTS (SELECT 1 FROM org_memberships WHERE org_memberships.org_id = $2 AND org_memberships.user_id = $3)",
+			expectedArgs:  []interface{}{"project-1", "org-a", ""},
+		},
+		{
+			name:          "operators need no membership",
+			ctx:           core.WithAccessScope(core.WithOrgID(context.Background(), "org-a"), core.AccessScope{UserID: "operator-1", Role: core.UserRoleAdmin}),
+			where:         "id = $1",
+			args:          []interface{}{"project-1"},
+			expectedWhere: "(id = $1) AND org_id = $2",
+			expectedArgs:  []interface{}{"project-1", "org-a"},
+		},
+		{
+			name:          "token vouches for its own organization",
+			ctx:           core.WithAccessScope(core.WithOrgID(context.Background(), "org-a"), core.AccessScope{UserID: "user-1", Role: "user", OrgID: "org-a"}),
+			where:         "id = $1",
+			args:          []interface{}{"project-1"},
+			expectedWhere: "(id = $1) AND org_id = $2",
+			expectedArgs:  []interface{}{"project-1", "org-a"},
+		},
+		{
+			name:          "user scope leaves rows without an organization alone",
+			ctx:           core.WithAccessScope(context.Background(), core.AccessScope{UserID: "user-1", Role: "user"}),
+			where:         "id = $1",
+			args:          []interface{}{"project-1"},
+			expectedWhere: "(id = $1) AND org_id IS NULL",
+			expectedArgs:  []interface{}{"project-1"},
+		},
+		{
+			name:          "unscoped context matches rows without an organization",
+			ctx:           context.Background(),
+			where:         "id = $1",
+			args:          []interface{}{"project-1"},
+			expectedWhere: "(id = $1) AND org_id IS NULL",
+			expectedArgs:  []interface{}{"project-1"},
+		},
+		{
+			name:          "empty condition is the scope alone",
+			ctx:           SystemScope(core.WithOrgID(context.Background(), "org-a")),
+			where:         "",
+			args:          nil,
+			expectedWhere: "org_id = $1",
+			expectedArgs:  []interface{}{"org-a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			where, args := andScope(tt.where, tt.args, func(n int) (string, []interface{}) {
+				return orgScope(tt.ctx, "org_id", n)
+			})
+
+			// Assert
+			assert.Equal(t, tt.expectedWhere, where)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+// onlyOrg makes the stub database return its row only to queries bound to
+// orgID, as Postgres would for a row of that organization
+func onlyOrg(orgID string) func(query string, args []driver.NamedValue) bool {
+	return func(query string, args []driver.NamedValue) bool {
+		for _, arg := range args {
+			if arg.Value == orgID {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func TestProjectStore_GetByID_OtherOrganizationIsNotFound(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	stub.match = onlyOrg("org-a")
+	projects := NewProjectStore(database)
+	ctx := SystemScope(core.WithOrgID(context.Background(), "org-b"))
+
+	// Act
+	_, err := projects.GetByID(ctx, "project-of-org-a")
+
+	// Assert
+	assert.ErrorIs(t, err, core.ErrProjectNotFound)
+	query, args := stub.last()
+	assert.Contains(t, query, "org_id = $2")
+	assert.Equal(t, []interface{}{"project-of-org-a", "org-b"}, args)
+}
+
+func TestItemStore_GetByID_OtherOrganizationIsNotFound(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	stub.match = onlyOrg("org-a")
+	items := NewItemStore(database)
+	ctx := SystemScope(core.WithOrgID(context.Background(), "org-b"))
+
+	// Act
+	_, err := items.GetByID(ctx, "project-1", "item-of-org-a")
+
+	// Assert
+	assert.ErrorIs(t, err, core.ErrItemNotFound)
+	query, args := stub.last()
+	assert.Contains(t, query, "WHERE projects.deleted_at IS NULL\n\t\t\tAND (projects.org_id = $3")
+	assert.Equal(t, []interface{}{"item-of-org-a", "project-1", "org-b", nil}, args, "the system needs no membership")
+}
+
+func TestItemStore_Create_InOtherOrganizationsProjectIsNotFound(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	// The project is not in org-b, so the scope check finds nothing
+	stub.value = false
+	items := NewItemStore(database)
+	ctx := SystemScope(core.WithOrgID(context.Background(), "org-b"))
+
+	// Act
+	_, err := items.Create(ctx, "project-of-org-a", "choice", "Question", nil, 0, false, nil, nil)
+
+	// Assert
+	assert.ErrorIs(t, err, core.ErrProjectNotFound)
+	query, args := stub.last()
+	assert.Contains(t, query, "-- name: ProjectInScope")
+	assert.Equal(t, []interface{}{"project-of-org-a", "org-b", nil}, args)
+	assert.Equal(t, 1, stub.statements, "nothing is inserted")
+}
+
+func TestProjectStore_Create_StoresOrganization(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	stub.match = func(string, []driver.NamedValue) bool { return false }
+	projects := NewProjectStore(database)
+	ctx := SystemScope(core.WithOrgID(context.Background(), "org-a"))
+
+	// Act
+	_, _ = projects.Create(ctx, "Quiz", nil, nil)
+
+	// Assert
+	query, args := stub.last()
+	assert.Contains(t, query, "org_id")
+	assert.Contains(t, args, "org-a")
+}
+
+func TestItemStore_UpdatePositions(t *testing.T) {
+	tests := []struct {
+		name              string
+		updates           []core.PositionUpdate
+		expectedErr       error
+		expectedCommits   int
+		expectedRollbacks int
+	}{
+		{
+			name:            "every item updated",
+			updates:         []core.PositionUpdate{{ItemID: "item-1", Position: 3}},
+			expectedCommits: 1,
+		},
+		{
+			// The stub database updates one row whatever the statement
+			name:              "missing item rolls back",
+			updates:           []core.PositionUpdate{{ItemID: "item-1", Position: 3}, {ItemID: "item-2", Position: 0}},
+			expectedErr:       core.ErrItemNotFound,
+			expectedRollbacks: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			database := newStubDatabase(t, 0, nil)
+			items := NewItemStore(database)
+			ctx := SystemScope(core.WithOrgID(context.Background(), "org-a"))
+
+			// Act
+			err := database.InTx(ctx, "items.update_positions", func(ctx context.Context) error {
+				return items.UpdatePositions(ctx, "project-1", tt.updates)
+			})
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, 2, stub.statements, "the constraint is deferred, then one statement moves the whole batch")
+			assert.Equal(t, tt.expectedCommits, stub.commits)
+			assert.Equal(t, tt.expectedRollbacks, stub.rollbacks)
+			assert.Len(t, stub.notifications, tt.expectedCommits, "the project change is announced with the commit")
+
+			query, args := stub.last()
+			assert.Contains(t, query, "items.project_id = $1")
+			assert.Contains(t, query, "items.deleted_at IS NULL AND project_id IN (SELECT id FROM projects WHERE projects.deleted_at IS NULL AND org_id = $")
+			assert.Equal(t, "project-1", args[0])
+			assert.Equal(t, "org-a", args[len(args)-1])
+			assert.Len(t, args, 2+2*len(tt.updates))
+		})
+	}
+}
+
+func TestItemStore_UpdatePositions_OutsideTransaction(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	items := NewItemStore(database)
+	ctx := SystemScope(context.Background())
+
+	// Act
+	err := items.UpdatePositions(ctx, "project-1", []core.PositionUpdate{{ItemID: "item-1", Position: 3}})
+
+	// Assert
+	assert.ErrorIs(t, err, core.ErrNoTransaction)
+	assert.Zero(t, stub.statements)
+	assert.Zero(t, stub.begins)
+}
+
+func TestProjectStore_Create_NonMemberIsRejected(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	// The membership check finds nothing
+	stub.value = false
+	projects := NewProjectStore(database)
+	ctx := core.WithAccessScope(core.WithOrgID(context.Background(), "org-a"), core.AccessScope{UserID: "user-1", Role: "user"})
+
+	// Act
+	_, err := projects.Create(ctx, "Quiz", nil, nil)
+
+	// Assert
+	assert.ErrorIs(t, err, core.ErrMembershipNotFound)
+	query, args := stub.last()
+	assert.Contains(t, query, "FROM org_memberships")
+	assert.Equal(t, []interface{}{"org-a", "user-1"}, args)
+	assert.Equal(t, 1, stub.statements, "nothing is inserted")
+}
+
+func TestItemStore_GetByID_NonMemberIsNotFound(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	// Only user-1 is a member of org-a
+	stub.match = onlyOrg("user-1")
+	items := NewItemStore(database)
+	ctx := core.WithAccessScope(core.WithOrgID(context.Background(), "org-a"), core.AccessScope{UserID: "user-2", Role: "user"})
+
+	// Act
+	_, err := items.GetByID(ctx, "project-1", "item-of-org-a")
+
+	// Assert
+	assert.ErrorIs(t, err, core.ErrItemNotFound)
+	query, args := stub.last()
+	assert.Contains(t, query, "org_memberships.user_id = $4")
+	assert.Equal(t, []interface{}{"item-of-org-a", "project-1", "org-a", "user-2"}, args)
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// SettingsStore implements runtime setting persistence on the database's
+// dialect
+type SettingsStore struct {
+	db *Database
+}
+
+// NewSettingsStore creates a new settings store
+func NewSettingsStore(db *Database) *SettingsStore {
+	return &SettingsStore{db: db}
+}
+
+// List returns every override, sorted by key
+func (s *SettingsStore) List(ctx context.Context) ([]core.Setting, error) {
+	query := `
+		SELECT key, value, updated_at, updated_by
+		FROM settings
+		ORDER BY key
+	`
+
+	rows, err := s.db.ReadQuery(ctx, "settings.list", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list settings: %w", err)
+	}
+	defer rows.Close()
+
+	var settings []core.Setting
+	for rows.Next() {
+		var setting core.Setting
+		if err := rows.Scan(&setting.Key, &setting.Value, scanUTC(&setting.UpdatedAt), &setting.UpdatedBy); err != nil {
+			return nil, fmt.Errorf("failed to scan setting: %w", err)
+		}
+		settings = append(settings, setting)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// Update stores the given values and removes the overrides of keys mapped
+// to nil in one transaction
+func (s *SettingsStore) Update(ctx context.Context, changes map[string]*string, updatedBy string) error {
+	upsert := `
+		INSERT INTO settings (key, value, updated_at, updated_by)
+		VALUES ($1, $2, ` + s.db.dialect.Now() + `, $3)
+		ON CONFLICT (key) DO UPDATE
+		SET value = EXCLUDED.value,
+			updated_at = EXCLUDED.updated_at,
+			updated_by = EXCLUDED.updated_by
+	`
+	remove := `DELETE FROM settings WHERE key = $1`
+
+	err := s.db.Transaction(ctx, "settings.update", func(tx *Runner) error {
+		for key, value := range changes {
+			if value == nil {
+				if _, err := tx.Exec(ctx, "settings.delete", remove, key); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := tx.Exec(ctx, "settings.upsert", upsert, key, *value, updatedBy); err != nil {
+				return err
+			}
+		}
+		return tx.notify(ctx, core.Change{Entity: core.ChangeEntitySettings, Action: core.ChangeActionUpdated})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update settings: %w", err)
+	}
+
+	return nil
+}
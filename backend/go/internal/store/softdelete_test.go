@@ -0,0 +1,87 @@
+package store
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// softDeletedTable matches SQL reading or changing a table with soft-deleted
+// rows. Inserts are left alone: a new row is never deleted.
+var softDeletedTable = regexp.MustCompile(`(?i)\b(FROM|UPDATE|JOIN)\s+(projects|items)\b`)
+
+// deletedRowsLeftOut matches what leaves soft-deleted rows out of a query
+var deletedRowsLeftOut = regexp.MustCompile(`deleted_at|\bnotDeleted\b|\bscoped\b|\bscopedIncluding\b`)
+
+func TestStoreQueries_LeaveOutDeletedRows(t *testing.T) {
+	t.Run("store functions", func(t *testing.T) {
+		files, err := filepath.Glob("*.go")
+		require.NoError(t, err)
+
+		fset := token.NewFileSet()
+		for _, file := range files {
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			parsed, err := parser.ParseFile(fset, file, nil, 0)
+			require.NoError(t, err)
+
+			for _, decl := range parsed.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Body == nil {
+					continue
+				}
+				var queries []string
+				leftOut := false
+				ast.Inspect(fn.Body, func(node ast.Node) bool {
+					switch node := node.(type) {
+					case *ast.BasicLit:
+						if node.Kind != token.STRING {
+							break
+						}
+						text, err := strconv.Unquote(node.Value)
+						require.NoError(t, err)
+						if softDeletedTable.MatchString(text) {
+							queries = append(queries, text)
+						}
+						leftOut = leftOut || deletedRowsLeftOut.MatchString(text)
+					case *ast.Ident:
+						leftOut = leftOut || deletedRowsLeftOut.MatchString(node.Name)
+					}
+					return true
+				})
+
+				if len(queries) > 0 {
+					assert.True(t, leftOut, "%s: %s queries %q without leaving out deleted rows",
+						fset.Position(fn.Pos()), fn.Name.Name, queries)
+				}
+			}
+		}
+	})
+
+	t.Run("sqlc queries", func(t *testing.T) {
+		files, err := filepath.Glob(filepath.Join("queries", "*.sql"))
+		require.NoError(t, err)
+
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			require.NoError(t, err)
+
+			for _, query := range strings.Split(string(content), "-- name: ")[1:] {
+				name, _, _ := strings.Cut(query, " ")
+				if softDeletedTable.MatchString(query) {
+					assert.Contains(t, query, "deleted_at", "%s: %s does not leave out deleted rows", file, name)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,395 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// StatsStore implements core.StatsStore. Analytics read the replica, since
+// they are cached for a minute anyway.
+type StatsStore struct {
+	db *Database
+}
+
+// NewStatsStore creates a new stats store
+func NewStatsStore(db *Database) *StatsStore {
+	return &StatsStore{db: db}
+}
+
+// scorePercent is an attempt's score as a percentage of its maximum score,
+// for the attempts scored kept
+const scorePercent = "100.0 * attempts.score / attempts.max_score"
+
+// scored keeps the submitted attempts with a maximum score
+const scored = " AND attempts.max_score > 0"
+
+// Analytics aggregates the attempts at a project query selects, previews
+// left out, each figure in one grouped query
+func (s *StatsStore) Analytics(ctx context.Context, projectID string, query core.AnalyticsQuery) (*core.ProjectAnalytics, error) {
+	window, args := attemptWindow(projectID, query.Filter)
+	analytics := &core.ProjectAnalytics{}
+
+	var scoredAttempts, passed int
+	var averageScore, averageSeconds sql.NullFloat64
+	err := s.db.ReadQueryRow(ctx, "attempts.analytics", fmt.Sprintf(`
+		SELECT
+			COUNT(*),
+			COUNT(attempts.submitted_at),
+			COALESCE(SUM(CASE WHEN attempts.max_score > 0 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN attempts.max_score > 0 AND 100 * attempts.score >= $%d * attempts.max_score THEN 1 ELSE 0 END), 0),
+			CAST(AVG(CASE WHEN attempts.max_score > 0 THEN %s END) AS DOUBLE PRECISION),
+			CAST(AVG(%s) AS DOUBLE PRECISION)
+		FROM attempts
+		WHERE %s
+	`, len(args)+1, scorePercent, s.db.dialect.Seconds("attempts.started_at", "attempts.submitted_at"), window),
+		append(args, query.PassPercent)...,
+	).Scan(&analytics.Attempts, &analytics.Submitted, &scoredAttempts, &passed, &averageScore, &averageSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate attempts: %w", err)
+	}
+
+	if analytics.Attempts > 0 {
+		rate := 100 * float64(analytics.Submitted) / float64(analytics.Attempts)
+		analytics.CompletionRate = &rate
+	}
+	if averageSeconds.Valid {
+		duration := time.Duration(averageSeconds.Float64 * float64(time.Second))
+		analytics.AverageDuration = &duration
+	}
+	if scoredAttempts > 0 {
+		analytics.AverageScore = &averageScore.Float64
+		rate := 100 * float64(passed) / float64(scoredAttempts)
+		analytics.PassRate = &rate
+		if analytics.MedianScore, err = s.medianScore(ctx, window, args); err != nil {
+			return nil, err
+		}
+	}
+
+	if analytics.Daily, err = s.daily(ctx, window, args, query.SeriesFrom); err != nil {
+		return nil, err
+	}
+	if analytics.ScoreBuckets, err = s.scoreBuckets(ctx, window, args); err != nil {
+		return nil, err
+	}
+	return analytics, nil
+}
+
+// medianScore returns the median score of the scored attempts in the
+// window, nil if there are none
+func (s *StatsStore) medianScore(ctx context.Context, window string, args []interface{}) (*float64, error) {
+	query := `
+		SELECT CAST(percentile_cont(0.5) WITHIN GROUP (ORDER BY ` + scorePercent + `) AS DOUBLE PRECISION)
+		FROM attempts
+		WHERE ` + window + scored
+	if !s.db.dialect.Capabilities().Percentiles {
+		// The mean of the middle row, or the two middle rows of an even
+		// count, is what percentile_cont(0.5) interpolates
+		query = `
+			SELECT CAST(AVG(ranked.score) AS DOUBLE PRECISION)
+			FROM (
+				SELECT ` + scorePercent + ` AS score,
+					ROW_NUMBER() OVER (ORDER BY ` + scorePercent + `) AS position,
+					COUNT(*) OVER () AS total
+				FROM attempts
+				WHERE ` + window + scored + `
+			) AS ranked
+			WHERE ranked.position IN ((ranked.total + 1) / 2, (ranked.total + 2) / 2)`
+	}
+
+	var median sql.NullFloat64
+	if err := s.db.ReadQueryRow(ctx, "attempts.median_score", query, args...).Scan(&median); err != nil {
+		return nil, fmt.Errorf("failed to aggregate median score: %w", err)
+	}
+	if !median.Valid {
+		return nil, nil
+	}
+	return &median.Float64, nil
+}
+
+// daily counts the attempts in the window started on each UTC day from
+// from on, leaving out days without attempts
+func (s *StatsStore) daily(ctx context.Context, window string, args []interface{}, from time.Time) ([]*core.DailyAttempts, error) {
+	day := s.db.dialect.Day("attempts.started_at")
+	rows, err := s.db.ReadQuery(ctx, "attempts.daily", fmt.Sprintf(`
+		SELECT %s, COUNT(*)
+		FROM attempts
+		WHERE %s AND attempts.started_at >= $%d
+		GROUP BY %s
+		ORDER BY %s
+	`, day, window, len(args)+1, day, day), append(args, from)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count attempts per day: %w", err)
+	}
+	defer rows.Close()
+
+	var days []*core.DailyAttempts
+	for rows.Next() {
+		var date string
+		var count int
+		if err := rows.Scan(&date, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan attempts per day: %w", err)
+		}
+		parsed, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse day %q: %w", date, err)
+		}
+		days = append(days, &core.DailyAttempts{Day: parsed, Attempts: count})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate attempts per day: %w", err)
+	}
+	return days, nil
+}
+
+// scoreBuckets counts the scored attempts in the window in each 10% range
+// of scores, the full score in the last, leaving out empty buckets
+func (s *StatsStore) scoreBuckets(ctx context.Context, window string, args []interface{}) ([]*core.ScoreBucket, error) {
+	bucket := fmt.Sprintf("CASE WHEN attempts.score >= attempts.max_score THEN %d ELSE %d * attempts.score / attempts.max_score END",
+		core.AnalyticsScoreBuckets-1, core.AnalyticsScoreBuckets)
+	rows, err := s.db.ReadQuery(ctx, "attempts.score_buckets", `
+		SELECT `+bucket+`, COUNT(*)
+		FROM attempts
+		WHERE `+window+scored+`
+		GROUP BY `+bucket+`
+		ORDER BY `+bucket, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count score buckets: %w", err)
+	}
+	defer rows.Close()
+
+	width := 100 / core.AnalyticsScoreBuckets
+	var buckets []*core.ScoreBucket
+	for rows.Next() {
+		var index, count int
+		if err := rows.Scan(&index, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan score bucket: %w", err)
+		}
+		buckets = append(buckets, &core.ScoreBucket{From: index * width, To: (index + 1) * width, Count: count})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate score buckets: %w", err)
+	}
+	return buckets, nil
+}
+
+// maxChoiceCounts bounds the options a choice count returns, should
+// answers name more options than an item has
+const maxChoiceCounts = 100
+
+// answerWindow returns the condition keeping the answers to an item given
+// by the submitted attempts at a project started in filter's window,
+// previews left out, and its arguments, bound from $1. It joins answers to
+// attempts.
+func answerWindow(projectID, itemID string, filter core.AttemptFilter) (string, []interface{}) {
+	window, args := attemptWindow(projectID, filter)
+	args = append(args, itemID)
+	return window + fmt.Sprintf(" AND attempts.submitted_at IS NOT NULL AND answers.item_id = $%d", len(args)), args
+}
+
+// answersJoin is the FROM clause of the answers answerWindow keeps
+const answersJoin = "answers JOIN attempts ON attempts.id = answers.attempt_id"
+
+// CountAnswers counts the submitted attempts in the window answering an
+// item
+func (s *StatsStore) CountAnswers(ctx context.Context, projectID, itemID string, filter core.AttemptFilter) (int, error) {
+	window, args := answerWindow(projectID, itemID, filter)
+	var answered int
+	err := s.db.ReadQueryRow(ctx, "answers.count",
+		`SELECT COUNT(*) FROM `+answersJoin+` WHERE `+window, args...).Scan(&answered)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count answers: %w", err)
+	}
+	return answered, nil
+}
+
+// ChoiceCounts counts the submitted attempts in the window choosing each
+// option of an item: the choice_id of choice answers, or every one of the
+// choice_ids of multi_choice answers when multiple is set
+func (s *StatsStore) ChoiceCounts(ctx context.Context, projectID, itemID string, multiple bool, filter core.AttemptFilter) ([]*core.ChoiceCount, error) {
+	window, args := answerWindow(projectID, itemID, filter)
+	choiceID := s.db.dialect.JSONText("answers.response", "choice_id")
+	from := answersJoin
+	if multiple {
+		choiceID = "choice_ids.value"
+		from += " CROSS JOIN " + s.db.dialect.JSONElements("answers.response", "choice_ids", "choice_ids")
+	}
+	rows, err := s.db.ReadQuery(ctx, "answers.choice_counts", fmt.Sprintf(`
+		SELECT %s, COUNT(*)
+		FROM %s
+		WHERE %s AND %s IS NOT NULL
+		GROUP BY %s
+		ORDER BY %s
+		LIMIT %d
+	`, choiceID, from, window, choiceID, choiceID, choiceID, maxChoiceCounts), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count choices: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []*core.ChoiceCount{}
+	for rows.Next() {
+		var count core.ChoiceCount
+		if err := rows.Scan(&count.ChoiceID, &count.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan choice count: %w", err)
+		}
+		counts = append(counts, &count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate choice counts: %w", err)
+	}
+	return counts, nil
+}
+
+// TextCounts returns the limit most common text answers to an item given by
+// the submitted attempts in the window, trimmed and lowercased, most common
+// first, and how many distinct answers there are. SQLite lowercases ASCII
+// letters only.
+func (s *StatsStore) TextCounts(ctx context.Context, projectID, itemID string, filter core.AttemptFilter, limit int) ([]*core.TextCount, int, error) {
+	window, args := answerWindow(projectID, itemID, filter)
+	text := "LOWER(TRIM(" + s.db.dialect.JSONText("answers.response", "text") + "))"
+	window += " AND " + text + " IS NOT NULL"
+
+	var distinct int
+	err := s.db.ReadQueryRow(ctx, "answers.distinct_texts",
+		`SELECT COUNT(DISTINCT `+text+`) FROM `+answersJoin+` WHERE `+window, args...).Scan(&distinct)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count distinct answers: %w", err)
+	}
+
+	rows, err := s.db.ReadQuery(ctx, "answers.text_counts", fmt.Sprintf(`
+		SELECT %s, COUNT(*)
+		FROM %s
+		WHERE %s
+		GROUP BY %s
+		ORDER BY COUNT(*) DESC, %s
+		LIMIT $%d
+	`, text, answersJoin, window, text, text, len(args)+1), append(args, limit)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count answers by text: %w", err)
+	}
+	defer rows.Close()
+
+	texts := []*core.TextCount{}
+	for rows.Next() {
+		var count core.TextCount
+		if err := rows.Scan(&count.Text, &count.Count); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan text count: %w", err)
+		}
+		texts = append(texts, &count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate text counts: %w", err)
+	}
+	return texts, distinct, nil
+}
+
+// SequenceCounts returns the limit most common orders given to an item by
+// the submitted attempts in the window, most common first. Orders group by
+// the JSON text of the array, which each engine writes one way.
+func (s *StatsStore) SequenceCounts(ctx context.Context, projectID, itemID string, filter core.AttemptFilter, limit int) ([]*core.SequenceCount, error) {
+	window, args := answerWindow(projectID, itemID, filter)
+	order := s.db.dialect.JSONText("answers.response", "order")
+	rows, err := s.db.ReadQuery(ctx, "answers.sequence_counts", fmt.Sprintf(`
+		SELECT %s, COUNT(*)
+		FROM %s
+		WHERE %s AND %s IS NOT NULL
+		GROUP BY %s
+		ORDER BY COUNT(*) DESC, %s
+		LIMIT $%d
+	`, order, answersJoin, window, order, order, order, len(args)+1), append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count orders: %w", err)
+	}
+	defer rows.Close()
+
+	sequences := []*core.SequenceCount{}
+	for rows.Next() {
+		var raw string
+		var sequence core.SequenceCount
+		if err := rows.Scan(&raw, &sequence.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan order count: %w", err)
+		}
+		if err := json.Unmarshal([]byte(raw), &sequence.Order); err != nil {
+			return nil, fmt.Errorf("failed to decode order %q: %w", raw, err)
+		}
+		sequences = append(sequences, &sequence)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate order counts: %w", err)
+	}
+	return sequences, nil
+}
+
+// HeatGrid counts the points clicked on an item by the submitted attempts
+// in the window in each cell of a size by size grid over the box bounding
+// them: one query finds the box, another groups the points by cell, so at
+// most size squared rows come back
+func (s *StatsStore) HeatGrid(ctx context.Context, projectID, itemID string, filter core.AttemptFilter, size int) (*core.HeatGrid, error) {
+	window, args := answerWindow(projectID, itemID, filter)
+	x := "CAST(" + s.db.dialect.JSONText("answers.response", "x") + " AS DOUBLE PRECISION)"
+	y := "CAST(" + s.db.dialect.JSONText("answers.response", "y") + " AS DOUBLE PRECISION)"
+	window += " AND " + x + " IS NOT NULL AND " + y + " IS NOT NULL"
+
+	var points int
+	var minX, maxX, minY, maxY sql.NullFloat64
+	err := s.db.ReadQueryRow(ctx, "answers.heat_bounds", fmt.Sprintf(`
+		SELECT COUNT(*), MIN(%s), MAX(%s), MIN(%s), MAX(%s)
+		FROM %s
+		WHERE %s
+	`, x, x, y, y, answersJoin, window), args...).Scan(&points, &minX, &maxX, &minY, &maxY)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bound clicks: %w", err)
+	}
+	if points == 0 {
+		return nil, nil
+	}
+
+	grid := &core.HeatGrid{Size: size, MinX: minX.Float64, MaxX: maxX.Float64, MinY: minY.Float64, MaxY: maxY.Float64}
+	// A cell is the band a coordinate falls in, the far edge in the last
+	// one; a box without width or height is one band across
+	band := func(coordinate string, min, max float64) string {
+		span := max - min
+		if span == 0 {
+			span = 1
+		}
+		args = append(args, min, span)
+		cell := s.db.dialect.Floor(fmt.Sprintf("(%s - %s) * %d / %s", coordinate,
+			s.db.dialect.Cast(fmt.Sprintf("$%d", len(args)-1), "DOUBLE PRECISION"), size,
+			s.db.dialect.Cast(fmt.Sprintf("$%d", len(args)), "DOUBLE PRECISION")))
+		return fmt.Sprintf("CASE WHEN %s >= %d THEN %d ELSE %s END", cell, size, size-1, cell)
+	}
+	column, row := band(x, grid.MinX, grid.MaxX), band(y, grid.MinY, grid.MaxY)
+	rows, err := s.db.ReadQuery(ctx, "answers.heat_grid", fmt.Sprintf(`
+		SELECT %s, %s, COUNT(*)
+		FROM %s
+		WHERE %s
+		GROUP BY %s, %s
+	`, row, column, answersJoin, window, row, column), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count clicks by cell: %w", err)
+	}
+	defer rows.Close()
+
+	grid.Counts = make([][]int, size)
+	for i := range grid.Counts {
+		grid.Counts[i] = make([]int, size)
+	}
+	for rows.Next() {
+		var r, c, count int
+		if err := rows.Scan(&r, &c, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan cell count: %w", err)
+		}
+		if r >= 0 && r < size && c >= 0 && c < size {
+			grid.Counts[r][c] = count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate cell counts: %w", err)
+	}
+	return grid, nil
+}
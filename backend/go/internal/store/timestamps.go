@@ -0,0 +1,64 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Stores return every time in UTC, whatever the server's or the database
+// session's time zone: scans of timestamp columns go through scanUTC or
+// scanNullUTC, generated rows are converted when they become core types,
+// and the in-memory stores stamp times with utcNow.
+
+// utcTime scans a NOT NULL timestamp column into t, in UTC
+type utcTime struct {
+	t *time.Time
+}
+
+// scanUTC returns a Scan destination storing a timestamp in t, in UTC
+func scanUTC(t *time.Time) sql.Scanner {
+	return utcTime{t: t}
+}
+
+func (s utcTime) Scan(src interface{}) error {
+	var scanned sql.NullTime
+	if err := scanned.Scan(src); err != nil {
+		return err
+	}
+	if !scanned.Valid {
+		return errors.New("cannot scan NULL into a time")
+	}
+	*s.t = scanned.Time.UTC()
+	return nil
+}
+
+// utcNullTime scans a nullable timestamp column into t, in UTC, or nil
+type utcNullTime struct {
+	t **time.Time
+}
+
+// scanNullUTC returns a Scan destination storing a nullable timestamp in
+// t, in UTC
+func scanNullUTC(t **time.Time) sql.Scanner {
+	return utcNullTime{t: t}
+}
+
+func (s utcNullTime) Scan(src interface{}) error {
+	var scanned sql.NullTime
+	if err := scanned.Scan(src); err != nil {
+		return err
+	}
+	if !scanned.Valid {
+		*s.t = nil
+		return nil
+	}
+	utc := scanned.Time.UTC()
+	*s.t = &utc
+	return nil
+}
+
+// utcNow is time.Now in UTC
+func utcNow() time.Time {
+	return time.Now().UTC()
+}
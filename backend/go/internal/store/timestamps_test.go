@@ -0,0 +1,58 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanUTC(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	local := time.Date(2026, 3, 1, 9, 30, 0, 0, tokyo)
+
+	tests := []struct {
+		name        string
+		src         interface{}
+		expected    time.Time
+		expectError bool
+	}{
+		{"offset time", local, time.Date(2026, 3, 1, 0, 30, 0, 0, time.UTC), false},
+		{"UTC time", local.UTC(), local.UTC(), false},
+		{"null", nil, time.Time{}, true},
+		{"not a time", int64(1), time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			var scanned time.Time
+			err := scanUTC(&scanned).Scan(tt.src)
+
+			// Assert
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, scanned)
+			assert.Equal(t, time.UTC, scanned.Location())
+		})
+	}
+}
+
+func TestScanNullUTC(t *testing.T) {
+	// Arrange
+	local := time.Date(2026, 3, 1, 9, 30, 0, 0, time.FixedZone("JST", 9*60*60))
+	previous := time.Now()
+	scanned := &previous
+
+	// Act & Assert
+	require.NoError(t, scanNullUTC(&scanned).Scan(local))
+	require.NotNil(t, scanned)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 30, 0, 0, time.UTC), *scanned)
+
+	require.NoError(t, scanNullUTC(&scanned).Scan(nil))
+	assert.Nil(t, scanned)
+}
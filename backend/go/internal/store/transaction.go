@@ -0,0 +1,35 @@
+package store
+
+import "context"
+
+// txKey carries the Runner of the transaction a context is in
+type txKey struct{}
+
+// withTx returns ctx carrying tx, so statements run with it join tx
+func withTx(ctx context.Context, tx *Runner) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// txFromContext returns the transaction ctx is in, if any
+func txFromContext(ctx context.Context) (*Runner, bool) {
+	tx, ok := ctx.Value(txKey{}).(*Runner)
+	return tx, ok
+}
+
+// InTx implements core.Transactor: fn runs in a transaction that every
+// statement run with the context it receives joins, through any store. See
+// the package documentation.
+func (d *Database) InTx(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	return d.Transaction(ctx, name, func(tx *Runner) error {
+		return fn(withTx(ctx, tx))
+	})
+}
+
+// runnerFor returns the Runner of the transaction ctx is in or, outside
+// one, a Runner for the connection pool
+func (d *Database) runnerFor(ctx context.Context) *Runner {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx
+	}
+	return d.runner(d.db)
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInTx_NestedTransactionsJoin(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+
+	// Act
+	err := database.InTx(context.Background(), "test.tx", func(ctx context.Context) error {
+		var id string
+		if err := database.ReadQueryRow(ctx, "items.get_by_id", "SELECT id FROM items WHERE id = $1", "item-1").Scan(&id); err != nil {
+			return err
+		}
+		if err := database.InTx(ctx, "test.nested", func(ctx context.Context) error { return nil }); err != nil {
+			return err
+		}
+		return database.Transaction(ctx, "test.nested", func(tx *Runner) error {
+			_, err := tx.Exec(ctx, "test.exec", "UPDATE items SET position = 0")
+			return err
+		})
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, stub.begins)
+	assert.Equal(t, 1, stub.commits)
+	assert.Equal(t, 2, stub.statements)
+}
+
+func TestInTx(t *testing.T) {
+	failure := errors.New("boom")
+
+	tests := []struct {
+		name              string
+		fn                func(ctx context.Context, database *Database) error
+		expectedErr       error
+		expectedCommits   int
+		expectedRollbacks int
+	}{
+		{
+			name: "commits when fn succeeds",
+			fn: func(ctx context.Context, database *Database) error {
+				_, err := database.Exec(ctx, "test.exec", "DELETE FROM items")
+				return err
+			},
+			expectedCommits: 1,
+		},
+		{
+			name: "rolls back when fn fails",
+			fn: func(ctx context.Context, database *Database) error {
+				if _, err := database.Exec(ctx, "test.exec", "DELETE FROM items"); err != nil {
+					return err
+				}
+				return failure
+			},
+			expectedErr:       failure,
+			expectedRollbacks: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			database := newStubDatabase(t, 0, nil)
+
+			// Act
+			err := database.InTx(context.Background(), "test.tx", func(ctx context.Context) error {
+				return tt.fn(ctx, database)
+			})
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, 1, stub.begins)
+			assert.Equal(t, tt.expectedCommits, stub.commits)
+			assert.Equal(t, tt.expectedRollbacks, stub.rollbacks)
+		})
+	}
+}
+
+func TestInTx_ReadsAreNotRetried(t *testing.T) {
+	// Arrange
+	database := newStubDatabase(t, 0, nil)
+	stub.inject(connReset)
+
+	// Act
+	err := database.InTx(context.Background(), "test.tx", func(ctx context.Context) error {
+		var id string
+		return database.ReadQueryRow(ctx, "items.get_by_id", "SELECT id FROM items WHERE id = $1", "item-1").Scan(&id)
+	})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, 1, stub.statements, "a failed statement aborts the transaction, so it is not retried")
+	assert.Equal(t, 1, stub.rollbacks)
+}
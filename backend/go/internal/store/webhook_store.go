@@ -0,0 +1,449 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// WebhookStore implements core.WebhookStore. Webhooks are scoped to the
+// user who owns them; the dispatcher reads and writes them from the primary.
+type WebhookStore struct {
+	db *Database
+}
+
+// NewWebhookStore creates a new webhook store
+func NewWebhookStore(db *Database) *WebhookStore {
+	return &WebhookStore{db: db}
+}
+
+const webhookColumns = `id, user_id, org_id, owner_email, url, secret, events, active, failure_count, disabled_at, created_at, updated_at`
+
+// Create stores a webhook for the user and organization it names
+func (s *WebhookStore) Create(ctx context.Context, webhook *core.Webhook) (*core.Webhook, error) {
+	query := `
+		INSERT INTO webhooks (id, user_id, org_id, owner_email, url, secret, events, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + webhookColumns + `
+	`
+
+	created, err := scanWebhook(s.db.QueryRow(ctx, "webhooks.create", query,
+		core.NewID(ctx), webhook.UserID, webhook.OrgID, webhook.OwnerEmail,
+		webhook.URL, webhook.Secret, joinEvents(webhook.Events), webhook.Active))
+	if violation, ok := s.db.dialect.Violation(err); ok && violation.Kind == ForeignKeyViolation {
+		return nil, core.ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return created, nil
+}
+
+// Get retrieves one of the user's webhooks
+func (s *WebhookStore) Get(ctx context.Context, userID, id string) (*core.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND user_id = $2`
+
+	webhook, err := scanWebhook(s.db.QueryRow(ctx, "webhooks.get", query, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// List returns the user's webhooks, oldest first
+func (s *WebhookStore) List(ctx context.Context, userID string) ([]*core.Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`
+	return s.queryWebhooks(ctx, "webhooks.list", query, userID)
+}
+
+// Update stores the webhook's URL, secret, events and active flag.
+// Activating it clears its failures.
+func (s *WebhookStore) Update(ctx context.Context, webhook *core.Webhook) (*core.Webhook, error) {
+	query := `
+		UPDATE webhooks
+		SET url = $3, secret = $4, events = $5, active = $6,
+			failure_count = CASE WHEN $6 THEN 0 ELSE failure_count END,
+			disabled_at = CASE WHEN $6 THEN NULL ELSE disabled_at END,
+			updated_at = $7
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + webhookColumns + `
+	`
+
+	updated, err := scanWebhook(s.db.QueryRow(ctx, "webhooks.update", query,
+		webhook.ID, webhook.UserID, webhook.URL, webhook.Secret, joinEvents(webhook.Events),
+		webhook.Active, utcNow()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return updated, nil
+}
+
+// Delete removes one of the user's webhooks; its deliveries cascade
+func (s *WebhookStore) Delete(ctx context.Context, userID, id string) error {
+	result, err := s.db.Exec(ctx, "webhooks.delete", `DELETE FROM webhooks WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return core.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// ListSubscribed returns the active webhooks of the organization's members,
+// or of no organization for "", subscribed to eventType. Organizations have few
+// webhooks, so the event filter is applied here rather than in SQL.
+func (s *WebhookStore) ListSubscribed(ctx context.Context, orgID string, eventType core.EventType) ([]*core.Webhook, error) {
+	condition, args := "org_id IS NULL", []interface{}{}
+	if orgID != "" {
+		// A user who left the organization stops getting its events
+		condition = `org_id = $1 AND EXISTS (
+			SELECT 1 FROM org_memberships
+			WHERE org_memberships.org_id = webhooks.org_id AND org_memberships.user_id = webhooks.user_id
+		)`
+		args = []interface{}{orgID}
+	}
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE active AND ` + condition + `
+		ORDER BY created_at, id
+	`
+
+	webhooks, err := s.queryWebhooks(ctx, "webhooks.list_subscribed", query, args...)
+	if err != nil {
+		return nil, err
+	}
+	subscribed := webhooks[:0]
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(eventType) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	return subscribed, nil
+}
+
+// RecordOutcome resets the webhook's failures after a success and counts
+// one after a failure, deactivating the webhook once disableAfter are
+// counted. disableAfter < 1 never deactivates it.
+func (s *WebhookStore) RecordOutcome(ctx context.Context, id string, succeeded bool, disableAfter int) (bool, error) {
+	if succeeded {
+		_, err := s.db.Exec(ctx, "webhooks.record_success", `UPDATE webhooks SET failure_count = 0 WHERE id = $1`, id)
+		if err != nil {
+			return false, fmt.Errorf("failed to record webhook success: %w", err)
+		}
+		return false, nil
+	}
+
+	var failures int
+	var active bool
+	err := s.db.QueryRow(ctx, "webhooks.record_failure",
+		`UPDATE webhooks SET failure_count = failure_count + 1 WHERE id = $1 RETURNING failure_count, active`, id).
+		Scan(&failures, &active)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, core.ErrWebhookNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record webhook failure: %w", err)
+	}
+	if disableAfter < 1 || !active || failures < disableAfter {
+		return false, nil
+	}
+
+	// Of concurrent failures past the threshold, only the one that
+	// deactivates the webhook reports it, so its owner is told once
+	result, err := s.db.Exec(ctx, "webhooks.disable",
+		`UPDATE webhooks SET active = false, disabled_at = $2 WHERE id = $1 AND active`, id, utcNow())
+	if err != nil {
+		return false, fmt.Errorf("failed to disable webhook: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected == 1, nil
+}
+
+const webhookDeliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, response_status, response_body, latency_ms, error, created_at, completed_at`
+
+// CreateDelivery stores a pending delivery
+func (s *WebhookStore) CreateDelivery(ctx context.Context, delivery *core.WebhookDelivery) (*core.WebhookDelivery, error) {
+	query := `
+		INSERT INTO webhook_deliveries (id, webhook_id, event_id, event_type, payload, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + webhookDeliveryColumns + `
+	`
+
+	created, err := scanWebhookDelivery(s.db.QueryRow(ctx, "webhooks.create_delivery", query,
+		core.NewID(ctx), delivery.WebhookID, delivery.EventID, string(delivery.EventType),
+		string(delivery.Payload), string(delivery.Status)))
+	if violation, ok := s.db.dialect.Violation(err); ok && violation.Kind == ForeignKeyViolation {
+		return nil, core.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+	return created, nil
+}
+
+// CompleteDelivery stores the delivery's outcome
+func (s *WebhookStore) CompleteDelivery(ctx context.Context, delivery *core.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, response_status = $4, response_body = $5,
+			latency_ms = $6, error = $7, completed_at = $8
+		WHERE id = $1
+	`
+
+	_, err := s.db.Exec(ctx, "webhooks.complete_delivery", query,
+		delivery.ID, string(delivery.Status), delivery.Attempts, delivery.ResponseStatus,
+		delivery.ResponseBody, delivery.Latency.Milliseconds(), delivery.Error, delivery.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// GetDelivery retrieves one of the webhook's deliveries
+func (s *WebhookStore) GetDelivery(ctx context.Context, webhookID, id string) (*core.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2`
+
+	delivery, err := scanWebhookDelivery(s.db.QueryRow(ctx, "webhooks.get_delivery", query, id, webhookID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// ListDeliveries returns a page of the webhook's deliveries, newest first,
+// and their total
+func (s *WebhookStore) ListDeliveries(ctx context.Context, webhookID string, limit, offset int) ([]*core.WebhookDelivery, int, error) {
+	var total int
+	err := s.db.QueryRow(ctx, "webhooks.count_deliveries",
+		`SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1`, webhookID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := s.db.Query(ctx, "webhooks.list_deliveries", query, webhookID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*core.WebhookDelivery, 0, limit)
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate webhook deliveries: %w", err)
+	}
+	return deliveries, total, nil
+}
+
+// ListDeadLetters returns up to limit of the latest failed deliveries of
+// each event to each active webhook, oldest first, leaving out the events a
+// later delivery is sending or has sent
+func (s *WebhookStore) ListDeadLetters(ctx context.Context, limit int) ([]*core.WebhookDeadLetter, error) {
+	query := `
+		SELECT ` + qualifyColumns("webhooks", webhookColumns) + `, ` + qualifyColumns("deliveries", webhookDeliveryColumns) + `
+		FROM webhook_deliveries deliveries
+		JOIN webhooks ON webhooks.id = deliveries.webhook_id
+		WHERE deliveries.status = $1 AND webhooks.active
+		AND NOT EXISTS (
+			SELECT 1 FROM webhook_deliveries later
+			WHERE later.webhook_id = deliveries.webhook_id
+			AND later.event_id = deliveries.event_id
+			AND later.id <> deliveries.id
+			AND (later.status <> $1 OR later.created_at > deliveries.created_at)
+		)
+		ORDER BY deliveries.created_at, deliveries.id
+		LIMIT $2
+	`
+	rows, err := s.db.Query(ctx, "webhooks.list_dead_letters", query, string(core.WebhookDeliveryFailed), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	deadLetters := []*core.WebhookDeadLetter{}
+	for rows.Next() {
+		webhook, delivery, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		deadLetters = append(deadLetters, &core.WebhookDeadLetter{Webhook: webhook, Delivery: delivery})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate dead letters: %w", err)
+	}
+	return deadLetters, nil
+}
+
+// PurgeDeliveries deletes the deliveries created before cutoff
+func (s *WebhookStore) PurgeDeliveries(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := s.db.Exec(ctx, "webhooks.purge_deliveries", `DELETE FROM webhook_deliveries WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge webhook deliveries: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return deleted, nil
+}
+
+func (s *WebhookStore) queryWebhooks(ctx context.Context, name, query string, args ...interface{}) ([]*core.Webhook, error) {
+	rows, err := s.db.Query(ctx, name, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []*core.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func joinEvents(events []core.EventType) string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = string(event)
+	}
+	return strings.Join(names, " ")
+}
+
+// qualifyColumns prefixes each of a comma-separated list of columns with
+// table
+func qualifyColumns(table, columns string) string {
+	names := strings.Split(columns, ", ")
+	for i, name := range names {
+		names[i] = table + "." + name
+	}
+	return strings.Join(names, ", ")
+}
+
+// webhookScan holds the values of a row of webhookColumns while it is
+// scanned
+type webhookScan struct {
+	webhook core.Webhook
+	orgID   sql.NullString
+	events  string
+}
+
+func (w *webhookScan) dest() []interface{} {
+	return []interface{}{&w.webhook.ID, &w.webhook.UserID, &w.orgID, &w.webhook.OwnerEmail, &w.webhook.URL,
+		&w.webhook.Secret, &w.events, &w.webhook.Active, &w.webhook.FailureCount,
+		scanNullUTC(&w.webhook.DisabledAt), scanUTC(&w.webhook.CreatedAt), scanUTC(&w.webhook.UpdatedAt)}
+}
+
+func (w *webhookScan) result() *core.Webhook {
+	if w.orgID.Valid {
+		w.webhook.OrgID = &w.orgID.String
+	}
+	for _, event := range strings.Fields(w.events) {
+		w.webhook.Events = append(w.webhook.Events, core.EventType(event))
+	}
+	return &w.webhook
+}
+
+// deliveryScan holds the values of a row of webhookDeliveryColumns while it
+// is scanned
+type deliveryScan struct {
+	delivery                   core.WebhookDelivery
+	eventType, payload, status string
+	responseStatus             sql.NullInt64
+	latencyMs                  int64
+}
+
+func (d *deliveryScan) dest() []interface{} {
+	return []interface{}{&d.delivery.ID, &d.delivery.WebhookID, &d.delivery.EventID, &d.eventType, &d.payload,
+		&d.status, &d.delivery.Attempts, &d.responseStatus, &d.delivery.ResponseBody, &d.latencyMs,
+		&d.delivery.Error, scanUTC(&d.delivery.CreatedAt), scanNullUTC(&d.delivery.CompletedAt)}
+}
+
+func (d *deliveryScan) result() *core.WebhookDelivery {
+	delivery := &d.delivery
+	delivery.EventType = core.EventType(d.eventType)
+	delivery.Payload = []byte(d.payload)
+	delivery.Status = core.WebhookDeliveryStatus(d.status)
+	delivery.Latency = time.Duration(d.latencyMs) * time.Millisecond
+	if d.responseStatus.Valid {
+		code := int(d.responseStatus.Int64)
+		delivery.ResponseStatus = &code
+	}
+	return delivery
+}
+
+// scanWebhook scans a row of webhookColumns
+func scanWebhook(row rowScanner) (*core.Webhook, error) {
+	var w webhookScan
+	if err := row.Scan(w.dest()...); err != nil {
+		return nil, err
+	}
+	return w.result(), nil
+}
+
+// scanWebhookDelivery scans a row of webhookDeliveryColumns
+func scanWebhookDelivery(row rowScanner) (*core.WebhookDelivery, error) {
+	var d deliveryScan
+	if err := row.Scan(d.dest()...); err != nil {
+		return nil, err
+	}
+	return d.result(), nil
+}
+
+// scanDeadLetter scans a row of webhookColumns followed by
+// webhookDeliveryColumns
+func scanDeadLetter(row rowScanner) (*core.Webhook, *core.WebhookDelivery, error) {
+	var w webhookScan
+	var d deliveryScan
+	if err := row.Scan(append(w.dest(), d.dest()...)...); err != nil {
+		return nil, nil, err
+	}
+	return w.result(), d.result(), nil
+}
@@ -0,0 +1,120 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/logging"
+)
+
+func installRecorder(t testing.TB) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+
+	return recorder
+}
+
+func newTracedRouter(handler http.HandlerFunc) http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.NewLoggingMiddleware(zerolog.Nop(), 0).RequestID)
+	r.Use(Middleware)
+	r.Get("/api/v1/projects/{projectId}", handler)
+	return r
+}
+
+func TestMiddleware(t *testing.T) {
+	// Arrange
+	recorder := installRecorder(t)
+
+	var traceIDInContext string
+	router := newTracedRouter(func(w http.ResponseWriter, r *http.Request) {
+		traceIDInContext = logging.GetTraceID(r.Context())
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/123", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("X-Request-ID", "req-1")
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	assert.Equal(t, "GET /api/v1/projects/{projectId}", span.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceIDInContext)
+	assert.Contains(t, span.Attributes(), attribute.String("http.request_id", "req-1"))
+	assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", http.StatusNotFound))
+}
+
+func TestStatementSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"select", "SELECT id, title FROM projects WHERE id = $1", "SELECT projects"},
+		{"insert", "INSERT INTO items (id, project_id) VALUES ($1, $2)", "INSERT items"},
+		{"update", "UPDATE projects SET title = $1", "UPDATE projects"},
+		{"delete", "delete from items where project_id = $1", "DELETE items"},
+		{"ddl", "CREATE TABLE IF NOT EXISTS projects (id UUID)", "CREATE"},
+		{"empty", "   ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result := StatementSummary(tt.query)
+
+			// Assert
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func benchmarkMiddleware(b *testing.B) {
+	router := newTracedRouter(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/projects/123", nil))
+	}
+}
+
+func BenchmarkMiddleware_Noop(b *testing.B) {
+	otel.SetTracerProvider(noop.NewTracerProvider())
+	benchmarkMiddleware(b)
+}
+
+func BenchmarkMiddleware_Recording(b *testing.B) {
+	installRecorder(b)
+	benchmarkMiddleware(b)
+}
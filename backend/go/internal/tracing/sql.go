@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/XSAM/otelsql"
+)
+
+// SQLSpanName names database spans after a short statement summary such as
+// "SELECT projects" so traces are readable without expanding db.statement.
+func SQLSpanName(_ context.Context, method otelsql.Method, query string) string {
+	if summary := StatementSummary(query); summary != "" {
+		return summary
+	}
+	return string(method)
+}
+
+// StatementSummary returns the SQL operation and its primary table, e.g.
+// "INSERT items" or "DELETE projects". Unknown shapes return the operation only.
+func StatementSummary(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	operation := strings.ToUpper(fields[0])
+
+	var tableKeyword string
+	switch operation {
+	case "SELECT", "DELETE":
+		tableKeyword = "FROM"
+	case "INSERT":
+		tableKeyword = "INTO"
+	case "UPDATE":
+		if len(fields) > 1 {
+			return operation + " " + trimIdentifier(fields[1])
+		}
+		return operation
+	default:
+		return operation
+	}
+
+	for i := 1; i < len(fields)-1; i++ {
+		if strings.EqualFold(fields[i], tableKeyword) {
+			return operation + " " + trimIdentifier(fields[i+1])
+		}
+	}
+
+	return operation
+}
+
+// trimIdentifier strips quoting and trailing punctuation from a table name
+func trimIdentifier(name string) string {
+	return strings.Trim(name, `"(),;`)
+}
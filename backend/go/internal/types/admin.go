@@ -0,0 +1,198 @@
+package types
+
+import "time"
+
+// LogLevelRequest represents a request to change the log level at runtime
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// LogLevelResponse reports the current log level
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// MaintenanceRequest turns maintenance mode on or off
+type MaintenanceRequest struct {
+	Enabled bool `json:"enabled"`
+	// Message is shown to clients whose requests are rejected
+	Message string `json:"message,omitempty" validate:"max=500"`
+	// AllowReads keeps GET requests working during maintenance
+	AllowReads bool `json:"allow_reads"`
+}
+
+// MaintenanceResponse reports the maintenance switch in effect
+type MaintenanceResponse struct {
+	Enabled    bool   `json:"enabled"`
+	Message    string `json:"message,omitempty"`
+	AllowReads bool   `json:"allow_reads"`
+	// Forced is set when MAINTENANCE_MODE keeps maintenance on regardless
+	// of the switch
+	Forced    bool       `json:"forced"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+}
+
+// JobStatusResponse reports a background job as seen by the replica that
+// served the request
+type JobStatusResponse struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	// PerReplica jobs run on every replica rather than once per cluster
+	PerReplica     bool       `json:"per_replica"`
+	Running        bool       `json:"running"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	// LastError is empty when the last run succeeded
+	LastError string `json:"last_error,omitempty"`
+	Runs      int64  `json:"runs"`
+	Failures  int64  `json:"failures"`
+	// Skipped counts runs left to another replica holding the job's lock
+	Skipped int64 `json:"skipped"`
+}
+
+// JobListResponse lists the registered background jobs
+type JobListResponse struct {
+	Jobs []JobStatusResponse `json:"jobs"`
+}
+
+// XAPIExportResponse reports the last xAPI export to the LRS, as seen by
+// the replica that served the request
+type XAPIExportResponse struct {
+	// State is idle, pending, running, succeeded or failed
+	State string `json:"state"`
+	// ProjectID, From and To are the export's filter; all projects and
+	// all time when unset
+	ProjectID  string     `json:"project_id,omitempty"`
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+	Attempts   int        `json:"attempts"`
+	Statements int        `json:"statements"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// Error is why a failed export stopped
+	Error string `json:"error,omitempty"`
+}
+
+// SettingResponse reports one runtime-adjustable setting
+type SettingResponse struct {
+	Key string `json:"key"`
+	// Value is in effect; Default is the value from the environment
+	Value      string `json:"value"`
+	Default    string `json:"default"`
+	Overridden bool   `json:"overridden"`
+	// UpdatedAt and UpdatedBy record the last override
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+}
+
+// SettingsResponse lists the runtime-adjustable settings
+type SettingsResponse struct {
+	Settings []SettingResponse `json:"settings"`
+}
+
+// UpdateSettingsRequest changes runtime settings by key. A value may be a
+// string, number or boolean; null resets the setting to its configured value.
+type UpdateSettingsRequest struct {
+	Settings map[string]interface{} `json:"settings" validate:"required,min=1"`
+}
+
+// RateLimitKeyResponse reports the requests made by one rate limit key
+type RateLimitKeyResponse struct {
+	Key     string `json:"key"`
+	KeyType string `json:"key_type"`
+	// Requests may be overestimated for keys near the bottom of the list
+	Requests int64 `json:"requests"`
+	Limited  int64 `json:"limited"`
+}
+
+// RateLimitTopResponse lists the heaviest rate limit keys on the replica
+// that served the request
+type RateLimitTopResponse struct {
+	WindowSeconds int                    `json:"window_seconds"`
+	Keys          []RateLimitKeyResponse `json:"keys"`
+}
+
+// IntegrityCheckResponse reports the orphans one referential check found
+type IntegrityCheckResponse struct {
+	Check       string `json:"check"`
+	Description string `json:"description"`
+	Orphans     int    `json:"orphans"`
+	// SampleIDs are the IDs, or storage keys, of up to 5 orphans
+	SampleIDs []string `json:"sample_ids"`
+}
+
+// IntegrityResponse lists the outcome of every referential check
+type IntegrityResponse struct {
+	// Orphans is the total over all checks; 0 means nothing was left behind
+	Orphans int                      `json:"orphans"`
+	Checks  []IntegrityCheckResponse `json:"checks"`
+}
+
+// OrganizationRequest creates or updates an organization
+type OrganizationRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=200"`
+	// MaxProjects caps the organization's projects; omit for no limit
+	MaxProjects *int `json:"max_projects,omitempty" validate:"omitempty,min=0"`
+}
+
+// OrganizationResponse represents an organization
+type OrganizationResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	MaxProjects *int      `json:"max_projects,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// OrganizationListResponse lists every organization
+type OrganizationListResponse struct {
+	Organizations []OrganizationResponse `json:"organizations"`
+}
+
+// MembershipRequest adds a user to an organization or changes their role
+type MembershipRequest struct {
+	Role string `json:"role" validate:"required,oneof=admin member"`
+}
+
+// MembershipResponse represents a user's membership of an organization
+type MembershipResponse struct {
+	OrgID     string    `json:"org_id"`
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MembershipListResponse lists an organization's members
+type MembershipListResponse struct {
+	Members []MembershipResponse `json:"members"`
+}
+
+// SeedUserResponse is a fixture user
+type SeedUserResponse struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// SeedProjectResponse is a fixture project
+type SeedProjectResponse struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Items     int    `json:"items"`
+	Published bool   `json:"published"`
+}
+
+// SeedResponse describes the development fixtures after seeding
+type SeedResponse struct {
+	OrganizationID string                `json:"organization_id"`
+	Users          []SeedUserResponse    `json:"users"`
+	Projects       []SeedProjectResponse `json:"projects"`
+	// Assets are the storage keys of the uploaded sample files
+	Assets []string `json:"assets"`
+	// Created counts the records this request created; 0 when the fixtures
+	// were already in place
+	Created int `json:"created"`
+}
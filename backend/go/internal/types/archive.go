@@ -0,0 +1,17 @@
+package types
+
+import "time"
+
+// ProjectArchiveVersion is the version of the project archives written by
+// `admin project export`. Fields may be added within a version.
+const ProjectArchiveVersion = 1
+
+// ProjectArchive is a project with its items, as exported by the admin CLI.
+// Asset files are not included: items keep the URLs of the files they show.
+type ProjectArchive struct {
+	Version    int             `json:"version"`
+	ExportedAt time.Time       `json:"exported_at"`
+	Project    ProjectResponse `json:"project"`
+	// Items are in position order
+	Items []ItemResponse `json:"items"`
+}
@@ -0,0 +1,16 @@
+package types
+
+import "time"
+
+// SignedURLRequest represents a request for a signed URL to a project asset
+type SignedURLRequest struct {
+	// ExpiresIn is how many seconds the URL lasts, capped at the configured
+	// maximum
+	ExpiresIn int `json:"expires_in" validate:"required,min=1"`
+}
+
+// SignedURLResponse represents a signed URL to a project asset
+type SignedURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
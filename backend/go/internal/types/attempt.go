@@ -0,0 +1,265 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ChoiceAnswer is the answer to a choice item: the ID of the chosen option
+type ChoiceAnswer struct {
+	ChoiceID string `json:"choice_id"`
+}
+
+// MultiChoiceAnswer is the answer to a multi_choice item: the IDs of every
+// chosen option, in any order
+type MultiChoiceAnswer struct {
+	ChoiceIDs []string `json:"choice_ids"`
+}
+
+// TextEntryAnswer is the answer to a text_entry item
+type TextEntryAnswer struct {
+	Text string `json:"text"`
+}
+
+// OrderingAnswer is the answer to an ordering item: the IDs of every entry,
+// first to last
+type OrderingAnswer struct {
+	Order []string `json:"order"`
+}
+
+// HotspotAnswer is the answer to a hotspot item: the point of the image
+// clicked, in the coordinates of the item's hotspot regions
+type HotspotAnswer struct {
+	X *float64 `json:"x"`
+	Y *float64 `json:"y"`
+}
+
+// SaveAnswerRequest represents the request body for answering an item of
+// an attempt. Answer has the shape of the item type's answer, e.g.
+// ChoiceAnswer.
+type SaveAnswerRequest struct {
+	Answer json.RawMessage `json:"answer" validate:"required"`
+	// Sequence numbers the client's saves of the answer, counting up; a save
+	// numbered below the one the answer was last saved by is refused. 0 or
+	// left out saves the answer whatever came before.
+	Sequence int64 `json:"sequence" validate:"min=0"`
+}
+
+// AttemptResponse represents a learner's attempt at a published project,
+// or an author's preview of a project
+type AttemptResponse struct {
+	ID        string `json:"id"`
+	ProjectID string `json:"project_id"`
+	// Version is the publication the attempt takes; it is left out for
+	// previews and projects published before publications were kept, whose
+	// live items are taken
+	Version int `json:"version,omitempty"`
+	// ParticipantID is the signed-in user taking the attempt, left out for
+	// anonymous learners
+	ParticipantID string `json:"participant_id,omitempty"`
+	// IsPreview is true for an author's preview, which no stats count
+	IsPreview   bool             `json:"is_preview"`
+	StartedAt   time.Time        `json:"started_at"`
+	SubmittedAt *time.Time       `json:"submitted_at,omitempty"`
+	Answers     []AnswerResponse `json:"answers"`
+	// Score is the points the answers earned out of MaxScore; both are left
+	// out until the attempt is submitted, and when the project's results
+	// policy withholds them
+	Score    *int `json:"score,omitempty"`
+	MaxScore *int `json:"max_score,omitempty"`
+	// Results grade each question, answered or not, in item order, once the
+	// attempt is submitted, unless the project's results policy withholds
+	// them
+	Results []ItemResultResponse `json:"results,omitempty"`
+}
+
+// ItemResultResponse represents the grade of an attempt's answer to a
+// question. Explanation is only returned by submitting the attempt, and by
+// practice feedback on a saved answer.
+type ItemResultResponse struct {
+	ItemID      string  `json:"item_id"`
+	Correct     bool    `json:"correct"`
+	Earned      int     `json:"earned"`
+	Possible    int     `json:"possible"`
+	Explanation *string `json:"explanation,omitempty"`
+}
+
+// AnswerResponse represents the answer an attempt gave to an item
+type AnswerResponse struct {
+	ItemID     string      `json:"item_id"`
+	Answer     interface{} `json:"answer"`
+	AnsweredAt time.Time   `json:"answered_at"`
+	// Sequence is the number of the save that stored the answer, left out
+	// if it wasn't numbered
+	Sequence int64 `json:"sequence,omitempty"`
+	// Feedback, returned by saving an answer to a question of a project
+	// with the practice results policy, grades the answer
+	Feedback *ItemResultResponse `json:"feedback,omitempty"`
+}
+
+// AttemptSummaryResponse represents an attempt in a project's list of
+// attempts, without its answers
+type AttemptSummaryResponse struct {
+	ID            string     `json:"id"`
+	Version       int        `json:"version,omitempty"`
+	ParticipantID string     `json:"participant_id,omitempty"`
+	StartedAt     time.Time  `json:"started_at"`
+	SubmittedAt   *time.Time `json:"submitted_at,omitempty"`
+	// DurationSeconds is the time from starting to submitting the attempt;
+	// it and the scores are left out until the attempt is submitted
+	DurationSeconds *float64 `json:"duration_seconds,omitempty"`
+	Score           *int     `json:"score,omitempty"`
+	MaxScore        *int     `json:"max_score,omitempty"`
+}
+
+// AttemptListResponse represents a page of the attempts at a project
+type AttemptListResponse struct {
+	ProjectID string                   `json:"project_id"`
+	Attempts  []AttemptSummaryResponse `json:"attempts"`
+	Total     int                      `json:"total"`
+	Limit     int                      `json:"limit"`
+	Offset    int                      `json:"offset"`
+}
+
+// ProjectStatsResponse represents the aggregates of the attempts at a
+// project started between From and To, when given
+type ProjectStatsResponse struct {
+	ProjectID string     `json:"project_id"`
+	From      *time.Time `json:"from,omitempty"`
+	To        *time.Time `json:"to,omitempty"`
+	Attempts  int        `json:"attempts"`
+	Submitted int        `json:"submitted"`
+	// AverageScore is left out until an attempt is submitted
+	AverageScore *float64            `json:"average_score,omitempty"`
+	Items        []ItemStatsResponse `json:"items"`
+}
+
+// ItemStatsResponse represents the aggregates of the submitted attempts'
+// answers to a question
+type ItemStatsResponse struct {
+	ItemID         string                `json:"item_id"`
+	Answered       int                   `json:"answered"`
+	Graded         int                   `json:"graded"`
+	PercentCorrect float64               `json:"percent_correct"`
+	AveragePoints  float64               `json:"average_points"`
+	Choices        []ChoiceCountResponse `json:"choices,omitempty"`
+}
+
+// ChoiceCountResponse represents how many attempts chose an option
+type ChoiceCountResponse struct {
+	ChoiceID string `json:"choice_id"`
+	Count    int    `json:"count"`
+}
+
+// ProjectAnalyticsResponse represents the analytics of the attempts at a
+// project started between From and To, when given. Scores and rates are
+// percentages.
+type ProjectAnalyticsResponse struct {
+	ProjectID   string     `json:"project_id"`
+	From        *time.Time `json:"from,omitempty"`
+	To          *time.Time `json:"to,omitempty"`
+	PassPercent int        `json:"pass_percent"`
+	Attempts    int        `json:"attempts"`
+	Submitted   int        `json:"submitted"`
+	// CompletionRate is left out without attempts
+	CompletionRate *float64 `json:"completion_rate,omitempty"`
+	// AverageScore, MedianScore and PassRate are left out until an attempt
+	// with a maximum score is submitted
+	AverageScore *float64 `json:"average_score,omitempty"`
+	MedianScore  *float64 `json:"median_score,omitempty"`
+	PassRate     *float64 `json:"pass_rate,omitempty"`
+	// AverageDurationSeconds is left out until an attempt is submitted
+	AverageDurationSeconds *float64 `json:"average_duration_seconds,omitempty"`
+	// AttemptsPerDay covers the 30 days ending on the day the window ends,
+	// oldest first
+	AttemptsPerDay []DailyAttemptsResponse `json:"attempts_per_day"`
+	// ScoreBuckets count the scores in each 10% range, lowest first
+	ScoreBuckets []ScoreBucketResponse `json:"score_buckets"`
+}
+
+// DailyAttemptsResponse represents how many attempts started on a UTC day
+type DailyAttemptsResponse struct {
+	// Date is the day, as YYYY-MM-DD
+	Date     string `json:"date"`
+	Attempts int    `json:"attempts"`
+}
+
+// ScoreBucketResponse represents how many scores are at least From and
+// below To percent, or up to 100 for the last bucket
+type ScoreBucketResponse struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Count int `json:"count"`
+}
+
+// AnswerDistributionResponse represents the breakdown of the submitted
+// attempts' answers to an item, with the field of its type set
+type AnswerDistributionResponse struct {
+	ProjectID string     `json:"project_id"`
+	ItemID    string     `json:"item_id"`
+	ItemType  ItemType   `json:"item_type"`
+	From      *time.Time `json:"from,omitempty"`
+	To        *time.Time `json:"to,omitempty"`
+	Answered  int        `json:"answered"`
+	// Choices count every option of a choice or multi_choice item, in item
+	// order, then the options chosen that the item no longer has
+	Choices []ChoiceDistributionResponse `json:"choices,omitempty"`
+	// Texts are the 20 most common text_entry answers, trimmed and
+	// lowercased, of DistinctTexts
+	Texts         []TextCountResponse `json:"texts,omitempty"`
+	DistinctTexts *int                `json:"distinct_texts,omitempty"`
+	// Sequences are the 10 most common orders given to an ordering item
+	Sequences []SequenceCountResponse `json:"sequences,omitempty"`
+	// Heat counts the points clicked on a hotspot item, left out until one
+	// is
+	Heat *HeatGridResponse `json:"heat,omitempty"`
+}
+
+// ChoiceDistributionResponse represents how many attempts chose an option
+type ChoiceDistributionResponse struct {
+	ChoiceID string `json:"choice_id"`
+	// Text is left out for an option the item no longer has
+	Text    *string `json:"text,omitempty"`
+	Correct bool    `json:"correct"`
+	Count   int     `json:"count"`
+}
+
+// TextCountResponse represents how many attempts gave a text answer
+type TextCountResponse struct {
+	Text    string `json:"text"`
+	Correct bool   `json:"correct"`
+	Count   int    `json:"count"`
+}
+
+// SequenceCountResponse represents how many attempts gave an order, by
+// entry ID
+type SequenceCountResponse struct {
+	Order   []string `json:"order"`
+	Correct bool     `json:"correct"`
+	Count   int      `json:"count"`
+}
+
+// HeatGridResponse represents the points clicked on a hotspot item,
+// counted in a size by size grid over the box bounding them
+type HeatGridResponse struct {
+	Size int     `json:"size"`
+	MinX float64 `json:"min_x"`
+	MinY float64 `json:"min_y"`
+	MaxX float64 `json:"max_x"`
+	MaxY float64 `json:"max_y"`
+	// Counts has a row for each band of y from min_y up, of a cell for each
+	// band of x from min_x up
+	Counts [][]int `json:"counts"`
+}
+
+// ParticipantDataErasureResponse reports the rows erasing a participant's
+// attempts affected
+type ParticipantDataErasureResponse struct {
+	ParticipantID string `json:"participant_id"`
+	Mode          string `json:"mode"`
+	Attempts      int64  `json:"attempts"`
+	// Answers and Results are the rows deleted with the attempts, 0 when
+	// anonymizing
+	Answers int64 `json:"answers"`
+	Results int64 `json:"results"`
+}
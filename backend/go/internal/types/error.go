@@ -10,6 +10,24 @@ type ErrorDetail struct {
 	Code    string  `json:"code"`
 	Message string  `json:"message"`
 	Details *string `json:"details,omitempty"`
+	// Index is, in the response to a request with an array of items, the
+	// index from 0 of the item that failed it
+	Index *int `json:"index,omitempty"`
+	// Problems are, for a project that cannot be published, what keeps it
+	// from being published
+	Problems []PublishProblem `json:"problems,omitempty"`
+	// Current is, for a write that failed with version_conflict, the
+	// resource as it is stored now
+	Current interface{} `json:"current,omitempty"`
+	// RequestID echoes the X-Request-ID of the failed request
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// PublishProblem is one reason a project cannot be published
+type PublishProblem struct {
+	// ItemID is the item at fault, absent for problems of the whole project
+	ItemID string `json:"item_id,omitempty"`
+	Reason string `json:"reason"`
 }
 
 // ValidationErrorResponse represents a validation error response
@@ -22,11 +40,16 @@ type ValidationErrorDetail struct {
 	Code    string            `json:"code"`
 	Message string            `json:"message"`
 	Errors  []ValidationError `json:"errors"`
+	// RequestID echoes the X-Request-ID of the failed request
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // ValidationError represents a single field validation error
 type ValidationError struct {
-	Field   string `json:"field"`
-	Tag     string `json:"tag"`
+	Field string `json:"field"`
+	Tag   string `json:"tag"`
+	// Param is the rule's parameter, e.g. 3 for min=3, so clients can render
+	// their own message
+	Param   string `json:"param,omitempty"`
 	Message string `json:"message"`
-}
\ No newline at end of file
+}
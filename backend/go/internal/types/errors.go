@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 )
 
 // Common error codes used across the application
@@ -19,18 +20,52 @@ const (
 	ErrorCodeInvalidContentType = "invalid_content_type"
 	ErrorCodeRequestTooLarge    = "request_too_large"
 	ErrorCodeRateLimited        = "rate_limited"
+	ErrorCodeGatewayTimeout     = "gateway_timeout"
+	ErrorCodeVersionSunset      = "version_sunset"
+	ErrorCodeMaintenance        = "maintenance"
+	ErrorCodeUnsupportedFormat  = "unsupported_format"
+	ErrorCodeInvalidPagination  = "invalid_pagination"
+	ErrorCodeInvalidSort        = "invalid_sort"
+	ErrorCodeInvalidCursor      = "invalid_cursor"
+	ErrorCodeVersionConflict        = "version_conflict"
+	ErrorCodeConcurrentModification = "concurrent_modification"
 
 	// Project-specific errors
 	ErrorCodeProjectNotFound     = "project_not_found"
-	ErrorCodeProjectTitleTooShort = "project_title_too_short"
-	ErrorCodeProjectTitleTooLong  = "project_title_too_long"
+	ErrorCodeProjectTitleTooShort = "title_too_short"
+	ErrorCodeProjectTitleTooLong  = "title_too_long"
 	ErrorCodeProjectExists       = "project_exists"
+	ErrorCodeProjectNotPublished = "project_not_published"
+	ErrorCodeProjectNotPublishable = "project_not_publishable"
+	ErrorCodePublicationNotFound = "publication_not_found"
+	ErrorCodeInvalidResultsPolicy = "invalid_results_policy"
+	ErrorCodeResultsAvailableAtRequired = "results_available_at_required"
+
+	// Item-specific errors
+	ErrorCodeItemNotFound        = "item_not_found"
+	ErrorCodeItemTitleTooShort   = "title_too_short"
+	ErrorCodeItemTitleTooLong    = "title_too_long"
+	ErrorCodeItemInvalidType     = "invalid_type"
+	ErrorCodeItemInvalidPosition = "invalid_position"
+	ErrorCodeItemDuplicatePositions = "duplicate_positions"
+	ErrorCodeItemInvalidContent  = "invalid_content"
+	ErrorCodeItemLocked          = "item_locked"
+	ErrorCodeItemLockNotHeld     = "item_lock_not_held"
+	ErrorCodeItemRevisionNotFound = "item_revision_not_found"
+
+	// Attempt errors
+	ErrorCodeAttemptNotFound         = "attempt_not_found"
+	ErrorCodeAttemptAlreadySubmitted = "attempt_already_submitted"
+	ErrorCodeInvalidAnswer           = "invalid_answer"
+	ErrorCodeStaleAnswer             = "stale_answer"
 
 	// File upload errors
 	ErrorCodeFileNotFound     = "file_not_found"
 	ErrorCodeFileTooBig       = "file_too_big"
 	ErrorCodeInvalidFileType  = "invalid_file_type"
 	ErrorCodeStorageUnavailable = "storage_unavailable"
+	ErrorCodeAssetURLInvalid    = "asset_url_invalid"
+	ErrorCodeAssetURLExpired    = "asset_url_expired"
 
 	// Authentication errors
 	ErrorCodeInvalidToken     = "invalid_token"
@@ -40,6 +75,39 @@ const (
 	// Authorization errors
 	ErrorCodeInsufficientPermissions = "insufficient_permissions"
 	ErrorCodeResourceAccessDenied     = "resource_access_denied"
+
+	// Background job errors
+	ErrorCodeJobNotFound         = "job_not_found"
+	ErrorCodeJobRunning          = "job_running"
+	ErrorCodeSchedulerNotRunning = "scheduler_not_running"
+
+	// Organization errors
+	ErrorCodeOrganizationNotFound = "organization_not_found"
+	ErrorCodeMembershipNotFound   = "membership_not_found"
+	ErrorCodeProjectQuotaExceeded = "project_quota_exceeded"
+
+	// Collaboration errors
+	ErrorCodeCollaborationDisabled = "collaboration_disabled"
+
+	// LTI errors
+	ErrorCodeLTIDisabled               = "lti_disabled"
+	ErrorCodeLTIPlatformNotFound       = "lti_platform_not_found"
+	ErrorCodeLTIPlatformExists         = "lti_platform_exists"
+	ErrorCodeLTIInvalidLaunch          = "lti_invalid_launch"
+	ErrorCodeLTIResourceLinkNotMapped  = "lti_resource_link_not_mapped"
+	ErrorCodeLTISessionNotFound        = "lti_session_not_found"
+
+	// Webhook errors
+	ErrorCodeWebhookNotFound         = "webhook_not_found"
+	ErrorCodeWebhookDeliveryNotFound = "webhook_delivery_not_found"
+	ErrorCodeWebhookQueueFull        = "webhook_queue_full"
+
+	// Import and export errors
+	ErrorCodeImportInvalidPackage  = "invalid_package"
+	ErrorCodeExportUnsupportedItem = "unsupported_item_type"
+
+	// xAPI errors
+	ErrorCodeLRSNotConfigured = "lrs_not_configured"
 )
 
 // APIError represents a structured API error
@@ -148,12 +216,60 @@ var (
 		StatusCode: http.StatusBadRequest,
 	}
 
+	ErrGatewayTimeout = &APIError{
+		Code:       ErrorCodeGatewayTimeout,
+		Message:    "The request took too long to complete",
+		StatusCode: http.StatusGatewayTimeout,
+	}
+
+	ErrConcurrentModification = &APIError{
+		Code:       ErrorCodeConcurrentModification,
+		Message:    "The resource was modified concurrently; fetch it again and retry",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrVersionConflict = &APIError{
+		Code:       ErrorCodeVersionConflict,
+		Message:    "The resource has changed since the version the request names",
+		StatusCode: http.StatusPreconditionFailed,
+	}
+
 	ErrProjectNotFound = &APIError{
 		Code:       ErrorCodeProjectNotFound,
 		Message:    "Project not found",
 		StatusCode: http.StatusNotFound,
 	}
 
+	ErrProjectNotPublished = &APIError{
+		Code:       ErrorCodeProjectNotPublished,
+		Message:    "Project is not published",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrPublicationNotFound = &APIError{
+		Code:       ErrorCodePublicationNotFound,
+		Message:    "Publication not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrProjectNotPublishable = &APIError{
+		Code:       ErrorCodeProjectNotPublishable,
+		Message:    "Project cannot be published until its problems are fixed",
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+
+	ErrInvalidResultsPolicy = &APIError{
+		Code:       ErrorCodeInvalidResultsPolicy,
+		Message:    "Invalid results policy",
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+
+	ErrResultsAvailableAtRequired = &APIError{
+		Code:       ErrorCodeResultsAvailableAtRequired,
+		Message:    "The after_close_date results policy requires results_available_at",
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+
 	ErrProjectTitleTooShort = &APIError{
 		Code:       ErrorCodeProjectTitleTooShort,
 		Message:    "Project title is too short",
@@ -166,6 +282,96 @@ var (
 		StatusCode: http.StatusUnprocessableEntity,
 	}
 
+	ErrItemNotFound = &APIError{
+		Code:       ErrorCodeItemNotFound,
+		Message:    "Item not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrItemTitleTooShort = &APIError{
+		Code:       ErrorCodeItemTitleTooShort,
+		Message:    "Item title is too short",
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+
+	ErrItemTitleTooLong = &APIError{
+		Code:       ErrorCodeItemTitleTooLong,
+		Message:    "Item title is too long",
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+
+	ErrItemInvalidType = &APIError{
+		Code:       ErrorCodeItemInvalidType,
+		Message:    "Invalid item type",
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+
+	ErrItemInvalidPosition = &APIError{
+		Code:       ErrorCodeItemInvalidPosition,
+		Message:    "Invalid position",
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+
+	ErrItemDuplicatePositions = &APIError{
+		Code:       ErrorCodeItemDuplicatePositions,
+		Message:    "Two items of the request have the same position",
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+
+	ErrItemInvalidContent = &APIError{
+		Code:       ErrorCodeItemInvalidContent,
+		Message:    "Invalid content for item type",
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+
+	ErrItemLocked = &APIError{
+		Code:       ErrorCodeItemLocked,
+		Message:    "Another user is editing this item",
+		StatusCode: http.StatusLocked,
+	}
+
+	ErrItemLockNotHeld = &APIError{
+		Code:       ErrorCodeItemLockNotHeld,
+		Message:    "You do not hold the lock on this item; take it again",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrItemRevisionNotFound = &APIError{
+		Code:       ErrorCodeItemRevisionNotFound,
+		Message:    "Item revision not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrAttemptNotFound = &APIError{
+		Code:       ErrorCodeAttemptNotFound,
+		Message:    "Attempt not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrAttemptAlreadySubmitted = &APIError{
+		Code:       ErrorCodeAttemptAlreadySubmitted,
+		Message:    "Attempt was already submitted",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrInvalidAnswer = &APIError{
+		Code:       ErrorCodeInvalidAnswer,
+		Message:    "Invalid answer for item",
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+
+	ErrStaleAnswer = &APIError{
+		Code:       ErrorCodeStaleAnswer,
+		Message:    "A later save of the answer was stored already",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrFileNotFound = &APIError{
+		Code:       ErrorCodeFileNotFound,
+		Message:    "File not found",
+		StatusCode: http.StatusNotFound,
+	}
+
 	ErrFileTooBig = &APIError{
 		Code:       ErrorCodeFileTooBig,
 		Message:    "File size exceeds the maximum allowed limit",
@@ -183,9 +389,170 @@ var (
 		Message:    "Storage service is currently unavailable",
 		StatusCode: http.StatusServiceUnavailable,
 	}
+
+	ErrResourceAccessDenied = &APIError{
+		Code:       ErrorCodeResourceAccessDenied,
+		Message:    "Access to this resource is denied",
+		StatusCode: http.StatusForbidden,
+	}
+
+	ErrAssetURLInvalid = &APIError{
+		Code:       ErrorCodeAssetURLInvalid,
+		Message:    "Asset URL signature is invalid",
+		StatusCode: http.StatusForbidden,
+	}
+
+	ErrAssetURLExpired = &APIError{
+		Code:       ErrorCodeAssetURLExpired,
+		Message:    "Asset URL has expired",
+		StatusCode: http.StatusForbidden,
+	}
+
+	ErrJobNotFound = &APIError{
+		Code:       ErrorCodeJobNotFound,
+		Message:    "Job not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrJobRunning = &APIError{
+		Code:       ErrorCodeJobRunning,
+		Message:    "Job is already running",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrSchedulerNotRunning = &APIError{
+		Code:       ErrorCodeSchedulerNotRunning,
+		Message:    "Background jobs are not running on this replica",
+		StatusCode: http.StatusServiceUnavailable,
+	}
+
+	ErrOrganizationNotFound = &APIError{
+		Code:       ErrorCodeOrganizationNotFound,
+		Message:    "Organization not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrMembershipNotFound = &APIError{
+		Code:       ErrorCodeMembershipNotFound,
+		Message:    "User is not a member of the organization",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrProjectQuotaExceeded = &APIError{
+		Code:       ErrorCodeProjectQuotaExceeded,
+		Message:    "The organization has reached its project quota",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrCollaborationDisabled = &APIError{
+		Code:       ErrorCodeCollaborationDisabled,
+		Message:    "Real-time collaboration is turned off",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrLTIDisabled = &APIError{
+		Code:       ErrorCodeLTIDisabled,
+		Message:    "LTI integration is turned off",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrLTIPlatformNotFound = &APIError{
+		Code:       ErrorCodeLTIPlatformNotFound,
+		Message:    "LTI platform not registered",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrLTIPlatformExists = &APIError{
+		Code:       ErrorCodeLTIPlatformExists,
+		Message:    "An LTI platform with this issuer and client ID is already registered",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrLTIInvalidLaunch = &APIError{
+		Code:       ErrorCodeLTIInvalidLaunch,
+		Message:    "The LTI launch could not be verified",
+		StatusCode: http.StatusUnauthorized,
+	}
+
+	ErrLTIResourceLinkNotMapped = &APIError{
+		Code:       ErrorCodeLTIResourceLinkNotMapped,
+		Message:    "This LTI link is not bound to a project; set its project_id custom parameter",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrLTISessionNotFound = &APIError{
+		Code:       ErrorCodeLTISessionNotFound,
+		Message:    "LTI session not found or expired; launch again from the platform",
+		StatusCode: http.StatusUnauthorized,
+	}
+
+	ErrWebhookNotFound = &APIError{
+		Code:       ErrorCodeWebhookNotFound,
+		Message:    "Webhook not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrWebhookDeliveryNotFound = &APIError{
+		Code:       ErrorCodeWebhookDeliveryNotFound,
+		Message:    "Webhook delivery not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrWebhookQueueFull = &APIError{
+		Code:       ErrorCodeWebhookQueueFull,
+		Message:    "Too many webhook deliveries are queued; try again later",
+		StatusCode: http.StatusServiceUnavailable,
+	}
+
+	ErrImportInvalidPackage = &APIError{
+		Code:       ErrorCodeImportInvalidPackage,
+		Message:    "The package could not be read",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrExportUnsupportedItem = &APIError{
+		Code:       ErrorCodeExportUnsupportedItem,
+		Message:    "The project has items the format can't represent",
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+
+	ErrLRSNotConfigured = &APIError{
+		Code:       ErrorCodeLRSNotConfigured,
+		Message:    "No Learning Record Store is configured",
+		StatusCode: http.StatusServiceUnavailable,
+	}
+)
+
+// domainErrors maps sentinel errors from the domain layer to the API error
+// returned for them. The domain layer imports this package, so sentinels are
+// registered by the HTTP layer rather than referenced here directly.
+var (
+	domainErrorsMu sync.RWMutex
+	domainErrors   = make(map[error]*APIError)
 )
 
-// MapDomainError maps domain errors to API errors
+// RegisterDomainError registers the API error returned for a domain sentinel
+// error, or for any error wrapping it
+func RegisterDomainError(sentinel error, apiErr *APIError) {
+	domainErrorsMu.Lock()
+	defer domainErrorsMu.Unlock()
+	domainErrors[sentinel] = apiErr
+}
+
+// RegisteredDomainErrors returns a copy of the sentinel registrations
+func RegisteredDomainErrors() map[error]*APIError {
+	domainErrorsMu.RLock()
+	defer domainErrorsMu.RUnlock()
+
+	registered := make(map[error]*APIError, len(domainErrors))
+	for sentinel, apiErr := range domainErrors {
+		registered[sentinel] = apiErr
+	}
+	return registered
+}
+
+// MapDomainError maps domain errors to API errors. Errors that are, or wrap,
+// a registered sentinel get its API error; anything else is an internal error.
 func MapDomainError(err error) *APIError {
 	if err == nil {
 		return nil
@@ -197,27 +564,18 @@ func MapDomainError(err error) *APIError {
 		return apiErr
 	}
 
-	// Map common domain errors
-	switch {
-	case errors.Is(err, errors.New("project not found")):
-		return ErrProjectNotFound
-	case errors.Is(err, errors.New("project title too short")):
-		return ErrProjectTitleTooShort
-	case errors.Is(err, errors.New("project title too long")):
-		return ErrProjectTitleTooLong
-	case errors.Is(err, errors.New("file not found")):
-		return NewAPIError(ErrorCodeFileNotFound, "File not found", http.StatusNotFound)
-	case errors.Is(err, errors.New("file too big")):
-		return ErrFileTooBig
-	case errors.Is(err, errors.New("invalid file type")):
-		return ErrInvalidFileType
-	case errors.Is(err, errors.New("storage unavailable")):
-		return ErrStorageUnavailable
-	default:
-		// For unknown errors, return internal server error
-		return NewAPIErrorWithCause(ErrorCodeInternalError, "An unexpected error occurred", 
-			http.StatusInternalServerError, err)
+	domainErrorsMu.RLock()
+	defer domainErrorsMu.RUnlock()
+
+	for sentinel, mapped := range domainErrors {
+		if errors.Is(err, sentinel) {
+			return mapped
+		}
 	}
+
+	// For unknown errors, return internal server error
+	return NewAPIErrorWithCause(ErrorCodeInternalError, "An unexpected error occurred",
+		http.StatusInternalServerError, err)
 }
 
 // IsClientError returns true if the error is a client error (4xx)
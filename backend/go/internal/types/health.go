@@ -4,14 +4,37 @@ import "time"
 
 // HealthResponse represents the health check response
 type HealthResponse struct {
-	Status    string              `json:"status"`
-	Timestamp time.Time           `json:"timestamp"`
-	Version   string              `json:"version"`
-	Services  *HealthServices     `json:"services,omitempty"`
+	Status    string                       `json:"status"`
+	Timestamp time.Time                    `json:"timestamp"`
+	Version   string                       `json:"version"`
+	Commit    string                       `json:"commit,omitempty"`
+	Checks    map[string]HealthCheckResult `json:"checks"`
 }
 
-// HealthServices represents the status of dependent services
-type HealthServices struct {
-	Database string `json:"database,omitempty"`
-	Storage  string `json:"storage,omitempty"`
-}
\ No newline at end of file
+// HealthCheckResult reports the outcome of a single dependency check
+type HealthCheckResult struct {
+	Status    string      `json:"status"`
+	Critical  bool        `json:"critical"`
+	LatencyMs float64     `json:"latency_ms"`
+	Error     string      `json:"error,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// DatabasePoolStats reports database connection pool usage. A WaitCount
+// that keeps growing means requests are queueing for connections.
+type DatabasePoolStats struct {
+	MaxOpen        int   `json:"max_open"`
+	Open           int   `json:"open"`
+	InUse          int   `json:"in_use"`
+	Idle           int   `json:"idle"`
+	WaitCount      int64 `json:"wait_count"`
+	WaitDurationMs int64 `json:"wait_duration_ms"`
+}
+
+// Health statuses. A degraded service is still serving requests but a
+// non-critical dependency is failing.
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusDegraded  = "degraded"
+	HealthStatusUnhealthy = "unhealthy"
+)
@@ -0,0 +1,23 @@
+package types
+
+// ImportItemsResponse reports the items an import created and those it
+// skipped
+type ImportItemsResponse struct {
+	ProjectID string               `json:"project_id"`
+	Imported  []ImportedItemResult `json:"imported"`
+	Skipped   []SkippedItemResult  `json:"skipped"`
+}
+
+// ImportedItemResult is an item created from a package
+type ImportedItemResult struct {
+	// Source is the item's file in the package
+	Source string       `json:"source"`
+	Item   ItemResponse `json:"item"`
+}
+
+// SkippedItemResult is an item of a package that was not imported
+type SkippedItemResult struct {
+	Source string `json:"source"`
+	Title  string `json:"title,omitempty"`
+	Reason string `json:"reason"`
+}
@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ItemType represents the type of quiz item/question
 type ItemType string
@@ -42,6 +45,22 @@ type UpdateItemRequest struct {
 	Required    bool        `json:"required"`
 	Points      *int        `json:"points,omitempty" validate:"omitempty,min=0,max=1000"`
 	Explanation *string     `json:"explanation,omitempty" validate:"omitempty,max=1000"`
+	// Version, like If-Match, updates the item only if it has that version
+	Version *int `json:"version,omitempty" validate:"omitnil,min=1"`
+}
+
+// PatchItemRequest represents a request to change some fields of a quiz
+// item. Fields left out, or null, are unchanged.
+type PatchItemRequest struct {
+	Type        *ItemType       `json:"type,omitempty" validate:"omitnil,oneof=title media choice multi_choice text_entry ordering hotspot"`
+	Title       *string         `json:"title,omitempty" validate:"omitnil,min=1,max=500"`
+	Content     json.RawMessage `json:"content,omitempty"`
+	Position    *int            `json:"position,omitempty" validate:"omitnil,min=0"`
+	Required    *bool           `json:"required,omitempty"`
+	Points      *int            `json:"points,omitempty" validate:"omitnil,min=0,max=1000"`
+	Explanation *string         `json:"explanation,omitempty" validate:"omitnil,max=1000"`
+	// Version, like If-Match, patches the item only if it has that version
+	Version *int `json:"version,omitempty" validate:"omitnil,min=1"`
 }
 
 // ItemResponse represents a quiz item in API responses
@@ -57,6 +76,24 @@ type ItemResponse struct {
 	Explanation *string     `json:"explanation,omitempty"`
 	CreatedAt   time.Time   `json:"created_at"`
 	UpdatedAt   time.Time   `json:"updated_at"`
+	// Version is incremented by every write to the item
+	Version int `json:"version"`
+	// Lock is the item's edit lock, listed while someone holds it
+	Lock *ItemLockResponse `json:"lock,omitempty"`
+}
+
+// ItemLockResponse represents an edit lock on an item
+type ItemLockResponse struct {
+	ItemID     string    `json:"item_id"`
+	HolderID   string    `json:"holder_id"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// ItemLockListResponse represents the edit locks on a project's items
+type ItemLockListResponse struct {
+	ProjectID string             `json:"project_id"`
+	Locks     []ItemLockResponse `json:"locks"`
 }
 
 // ItemListResponse represents a list of quiz items
@@ -68,6 +105,30 @@ type ItemListResponse struct {
 	Offset    int            `json:"offset,omitempty"`
 }
 
+// ItemRevisionResponse represents an earlier state of an item, kept when an
+// update or a delete replaced it
+type ItemRevisionResponse struct {
+	// Revision counts the item's revisions from 1, oldest first
+	Revision int `json:"revision"`
+	// Item is the item as it was
+	Item ItemResponse `json:"item"`
+	// ChangedAt and ChangedBy are when, and by which user, the item was
+	// changed from this state; ChangedBy is left out for changes no user
+	// made
+	ChangedAt time.Time `json:"changed_at"`
+	ChangedBy string    `json:"changed_by,omitempty"`
+}
+
+// ItemRevisionListResponse represents a paginated list of an item's
+// revisions, newest first
+type ItemRevisionListResponse struct {
+	ItemID    string                 `json:"item_id"`
+	Revisions []ItemRevisionResponse `json:"revisions"`
+	Total     int                    `json:"total"`
+	Limit     int                    `json:"limit"`
+	Offset    int                    `json:"offset"`
+}
+
 // PositionUpdateRequest represents a request to update item positions
 type PositionUpdateRequest struct {
 	ItemID   string `json:"item_id" validate:"required,uuid"`
@@ -0,0 +1,45 @@
+package types
+
+import "time"
+
+// LTIPlatformRequest registers a learning platform that launches projects
+// as an LTI 1.3 tool
+type LTIPlatformRequest struct {
+	// OrgID is the organization whose projects the platform launches; omit
+	// for the projects that belong to no organization
+	OrgID        *string `json:"org_id,omitempty" validate:"omitempty,uuid"`
+	Issuer       string  `json:"issuer" validate:"required,url,max=1000"`
+	ClientID     string  `json:"client_id" validate:"required,max=255"`
+	AuthLoginURL string  `json:"auth_login_url" validate:"required,url,max=1000"`
+	AuthTokenURL string  `json:"auth_token_url" validate:"required,url,max=1000"`
+	KeySetURL    string  `json:"key_set_url" validate:"required,url,max=1000"`
+}
+
+// LTIPlatformResponse represents a registered platform
+type LTIPlatformResponse struct {
+	ID           string    `json:"id"`
+	OrgID        *string   `json:"org_id,omitempty"`
+	Issuer       string    `json:"issuer"`
+	ClientID     string    `json:"client_id"`
+	AuthLoginURL string    `json:"auth_login_url"`
+	AuthTokenURL string    `json:"auth_token_url"`
+	KeySetURL    string    `json:"key_set_url"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// LTIPlatformListResponse lists every registered platform
+type LTIPlatformListResponse struct {
+	Platforms []LTIPlatformResponse `json:"platforms"`
+}
+
+// LTISessionResponse represents a participant launched into a project
+type LTISessionResponse struct {
+	ProjectID string   `json:"project_id"`
+	Subject   string   `json:"subject"`
+	Name      string   `json:"name,omitempty"`
+	Email     string   `json:"email,omitempty"`
+	Roles     []string `json:"roles"`
+	// GradePassback is set when scores are posted back to the platform
+	GradePassback bool      `json:"grade_passback"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
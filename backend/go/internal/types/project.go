@@ -14,6 +14,9 @@ type UpdateProjectRequest struct {
 	Title       string   `json:"title" validate:"required,min=1,max=200"`
 	Description *string  `json:"description,omitempty" validate:"omitempty,max=1000"`
 	Tags        []string `json:"tags,omitempty" validate:"omitempty,dive,max=50"`
+	// Version, like If-Match, updates the project only if it has that
+	// version
+	Version *int `json:"version,omitempty" validate:"omitnil,min=1"`
 }
 
 // ProjectResponse represents a project in API responses
@@ -25,12 +28,74 @@ type ProjectResponse struct {
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 	PublishedAt *time.Time `json:"published_at,omitempty"`
+	// Version is incremented by every write to the project
+	Version int `json:"version"`
+	// DeletedAt is set for projects in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// ItemCount, present when reading and listing projects, counts the
+	// project's items
+	ItemCount *int `json:"item_count,omitempty"`
+	// LatestPublishedVersion, present when reading, listing and publishing
+	// a published project, is the version of its latest publication
+	LatestPublishedVersion *int `json:"latest_published_version,omitempty"`
+	// Items are the project's items in position order, embedded with
+	// include=items
+	Items []ItemResponse `json:"items,omitempty"`
+	// ResultsPolicy, present when reading a project and setting its
+	// results policy, is what learners are shown of their grades:
+	// none, score_only, after_submit, after_close_date or practice
+	ResultsPolicy string `json:"results_policy,omitempty"`
+	// ResultsAvailableAt is when the after_close_date policy starts showing
+	// results
+	ResultsAvailableAt *time.Time `json:"results_available_at,omitempty"`
+}
+
+// UpdateResultsPolicyRequest sets what a project's learners are shown of
+// their grades
+type UpdateResultsPolicyRequest struct {
+	ResultsPolicy string `json:"results_policy" validate:"required,oneof=none score_only after_submit after_close_date practice"`
+	// ResultsAvailableAt is required by, and only kept for, the
+	// after_close_date policy
+	ResultsAvailableAt *time.Time `json:"results_available_at,omitempty"`
 }
 
 // ProjectListResponse represents a paginated list of projects
 type ProjectListResponse struct {
 	Projects []ProjectResponse `json:"projects"`
+	// Total counts the matching projects, or is -1 for pages read after a
+	// cursor, which skip the count
 	Total    int              `json:"total"`
 	Limit    int              `json:"limit"`
 	Offset   int              `json:"offset"`
-}
\ No newline at end of file
+	// NextCursor, present when more projects follow, reads the next page as
+	// the cursor parameter
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+// PublicationResponse represents a project frozen as it was published
+type PublicationResponse struct {
+	ProjectID string `json:"project_id"`
+	// Version counts the project's publications from 1, oldest first
+	Version     int       `json:"version"`
+	PublishedAt time.Time `json:"published_at"`
+	// Project and Items are the project and its items, in position order,
+	// as they were published
+	Project ProjectResponse `json:"project"`
+	Items   []ItemResponse  `json:"items"`
+}
+
+// PublicationSummaryResponse represents a publication in lists, without
+// the project it froze
+type PublicationSummaryResponse struct {
+	Version     int       `json:"version"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// PublicationListResponse represents a paginated list of a project's
+// publications, newest first
+type PublicationListResponse struct {
+	ProjectID    string                       `json:"project_id"`
+	Publications []PublicationSummaryResponse `json:"publications"`
+	Total        int                          `json:"total"`
+	Limit        int                          `json:"limit"`
+	Offset       int                          `json:"offset"`
+}
@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+// PublicProjectResponse represents a published project as learners take
+// it, without answer keys
+type PublicProjectResponse struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Description *string `json:"description,omitempty"`
+	// Version is the publication served; it is left out for projects
+	// published before publications were kept, served as they are now
+	Version     int                  `json:"version,omitempty"`
+	PublishedAt *time.Time           `json:"published_at,omitempty"`
+	Items       []PublicItemResponse `json:"items"`
+}
+
+// PublicItemResponse represents an item as learners see it: its content
+// carries no correct choices, order, answer or hotspot regions, and it has
+// no explanation
+type PublicItemResponse struct {
+	ID       string      `json:"id"`
+	Type     ItemType    `json:"type"`
+	Title    string      `json:"title"`
+	Content  interface{} `json:"content,omitempty"`
+	Position int         `json:"position"`
+	Required bool        `json:"required"`
+	Points   *int        `json:"points,omitempty"`
+}
@@ -0,0 +1,103 @@
+package types
+
+import "time"
+
+// WebhookRequest subscribes a URL to the events of the projects of the
+// organization in scope, or of the projects that belong to no organization
+type WebhookRequest struct {
+	URL string `json:"url" validate:"required,http_url,max=2000"`
+	// Secret signs the deliveries. One is generated when it is omitted on
+	// creation; omit it on update to keep the current one.
+	Secret *string `json:"secret,omitempty" validate:"omitempty,min=16,max=200"`
+	// Events lists the event types to deliver
+	Events []string `json:"events" validate:"required,min=1,unique,dive,oneof=project.created project.updated project.deleted project.published project.restored"`
+	// Active defaults to true. Activating a disabled webhook clears its
+	// failures.
+	Active *bool `json:"active,omitempty"`
+}
+
+// WebhookResponse represents a webhook
+type WebhookResponse struct {
+	ID     string   `json:"id"`
+	OrgID  *string  `json:"org_id,omitempty"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Secret is returned when the webhook is created only
+	Secret string `json:"secret,omitempty"`
+	Active bool   `json:"active"`
+	// FailureCount counts the deliveries in a row that failed
+	FailureCount int `json:"failure_count"`
+	// DisabledAt is set when the webhook was deactivated after failing
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// WebhookListResponse lists the user's webhooks
+type WebhookListResponse struct {
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+// WebhookDeliveryResponse represents an event sent, or to be sent, to a
+// webhook
+type WebhookDeliveryResponse struct {
+	ID        string `json:"id"`
+	EventID   string `json:"event_id"`
+	EventType string `json:"event_type"`
+	// Status is pending, succeeded or failed
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+	// ResponseStatus and ResponseBody are from the last attempt that got a
+	// response; ResponseBody is cut to its first KB
+	ResponseStatus *int       `json:"response_status,omitempty"`
+	ResponseBody   string     `json:"response_body,omitempty"`
+	LatencyMs      int64      `json:"latency_ms"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+// WebhookDeliveryListResponse represents a paginated list of deliveries,
+// newest first
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+	Total      int                       `json:"total"`
+	Limit      int                       `json:"limit"`
+	Offset     int                       `json:"offset"`
+}
+
+// WebhookPayloadVersion is the version of the event payloads delivered to
+// webhooks. Fields may be added within a version; renaming or removing one
+// makes a new version, with its own types below.
+const WebhookPayloadVersion = "1"
+
+// WebhookEventV1 is the body of a delivery
+type WebhookEventV1 struct {
+	// ID identifies the event; redeliveries carry the same ID
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Version   string    `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// OrgID is omitted for the projects that belong to no organization
+	OrgID string `json:"org_id,omitempty"`
+	// Data is a ProjectEventDataV1 for project events
+	Data interface{} `json:"data"`
+}
+
+// ProjectEventDataV1 is the data of the project.* events
+type ProjectEventDataV1 struct {
+	// Project is the project as it is after the event; for
+	// project.deleted, as it was before it
+	Project WebhookProjectV1 `json:"project"`
+}
+
+// WebhookProjectV1 represents a project in event payloads
+type WebhookProjectV1 struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description *string    `json:"description,omitempty"`
+	Tags        []string   `json:"tags"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+}
@@ -0,0 +1,74 @@
+package types
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestWebhookEventV1_Golden pins the version 1 payloads: a change to a
+// golden file other than an added field needs a new payload version
+func TestWebhookEventV1_Golden(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	published := created.Add(90 * time.Minute)
+	description := "Capitals of the countries of Europe"
+
+	events := map[string]WebhookEventV1{
+		"project_published": {
+			ID:        "0b6f1c9e-5d4a-4f7e-8c2b-1a3d5e7f9b20",
+			Type:      "project.published",
+			Version:   WebhookPayloadVersion,
+			CreatedAt: published,
+			OrgID:     "3e8a2f61-7c9d-4b05-a1e6-5f2d8c4b7a93",
+			Data: ProjectEventDataV1{Project: WebhookProjectV1{
+				ID:          "5f0c6a4e-2d1b-4c8e-9a7f-3b6d8e1f2a90",
+				Title:       "World Capitals",
+				Description: &description,
+				Tags:        []string{"geography", "europe"},
+				CreatedAt:   created,
+				UpdatedAt:   published,
+				PublishedAt: &published,
+			}},
+		},
+		"project_deleted": {
+			ID:        "7d2e9a4b-1f6c-4e38-b5a0-9c8d7e6f5a41",
+			Type:      "project.deleted",
+			Version:   WebhookPayloadVersion,
+			CreatedAt: published,
+			Data: ProjectEventDataV1{Project: WebhookProjectV1{
+				ID:        "5f0c6a4e-2d1b-4c8e-9a7f-3b6d8e1f2a90",
+				Title:     "World Capitals",
+				Tags:      []string{},
+				CreatedAt: created,
+				UpdatedAt: created,
+			}},
+		},
+	}
+
+	for name, event := range events {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			got, err := json.MarshalIndent(event, "", "  ")
+			require.NoError(t, err)
+			got = append(got, '\n')
+
+			// Assert
+			golden := filepath.Join("testdata", "webhooks", name+".json")
+			if *update {
+				require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0o755))
+				require.NoError(t, os.WriteFile(golden, got, 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
@@ -0,0 +1,455 @@
+// Package webhook delivers events to the URLs users subscribe to them.
+// Every subscription feeds the same Dispatcher, so deliveries are signed,
+// retried and recorded the same way whoever subscribed.
+//
+// A delivery is a POST of the event's JSON payload (see Payload) signed with
+// the webhook's secret (see Sign). It succeeds on any 2xx response; other
+// responses, timeouts and connection errors are retried with exponential
+// backoff. Each webhook has a circuit breaker, so an endpoint that keeps
+// failing is skipped for a cool-down instead of tying up the workers, and a
+// webhook whose deliveries keep failing is disabled and its owner emailed.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/breaker"
+	"github.com/provemyself/backend/internal/core"
+)
+
+// Headers set on every delivery
+const (
+	// SignatureHeader carries "t=<unix seconds>,v1=<hex HMAC-SHA256>" of
+	// "<unix seconds>.<body>" keyed with the webhook's secret
+	SignatureHeader = "X-ProveMySelf-Signature"
+	EventHeader     = "X-ProveMySelf-Event"
+	DeliveryHeader  = "X-ProveMySelf-Delivery"
+
+	// responseSnippetSize is how much of a response body is kept
+	responseSnippetSize = 1024
+
+	// DisabledTemplate is the email sent when a webhook is disabled
+	DisabledTemplate = "webhook_disabled"
+)
+
+// ErrPrivateAddress is returned for deliveries to addresses on loopback,
+// private or link-local networks while they are not allowed
+var ErrPrivateAddress = errors.New("webhook URL resolves to a private network address")
+
+// Mailer sends templated email; *email.Mailer implements it
+type Mailer interface {
+	Send(ctx context.Context, template string, to []string, data any) error
+}
+
+// Config tunes a Dispatcher
+type Config struct {
+	// Workers is how many deliveries are attempted at once
+	Workers int
+	// QueueSize is how many deliveries may wait for a worker
+	QueueSize int
+	// Timeout bounds each attempt
+	Timeout time.Duration
+	// MaxRetries is how many times a failed delivery is attempted again
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry; it doubles after
+	// each further failure
+	RetryBackoff time.Duration
+	// DisableAfter is how many deliveries in a row may fail before the
+	// webhook is disabled
+	DisableAfter int
+	// BreakerThreshold is how many failed attempts in a row open a
+	// webhook's breaker, and BreakerCoolDown how long it stays open
+	BreakerThreshold int
+	BreakerCoolDown  time.Duration
+	// AllowPrivateNetworks lets webhooks target loopback, private and
+	// link-local addresses, e.g. in development
+	AllowPrivateNetworks bool
+	// HTTPClient overrides the client deliveries are sent with
+	HTTPClient *http.Client
+}
+
+// DisabledEmail is the data of the email sent when a webhook is disabled
+type DisabledEmail struct {
+	URL      string
+	Failures int
+}
+
+// job is a delivery waiting for a worker
+type job struct {
+	webhook  *core.Webhook
+	delivery *core.WebhookDelivery
+}
+
+// Dispatcher queues deliveries and attempts them on a pool of workers (see
+// Run). The queue is held in memory: deliveries still queued when the
+// process stops are recorded as failed and can be redelivered.
+type Dispatcher struct {
+	store  core.WebhookStore
+	mailer Mailer
+	cfg    Config
+	client *http.Client
+	sleep  func(ctx context.Context, d time.Duration) error
+	now    func() time.Time
+
+	queue chan job
+
+	breakersMu sync.Mutex
+	breakers   map[string]*breaker.Breaker
+}
+
+// NewDispatcher creates a dispatcher recording deliveries in store.
+// mailer may be nil, in which case owners are not told about disabled
+// webhooks.
+func NewDispatcher(store core.WebhookStore, mailer Mailer, cfg Config) *Dispatcher {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize < 1 {
+		cfg.QueueSize = 1
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.BreakerThreshold < 1 {
+		cfg.BreakerThreshold = 5
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = newHTTPClient(cfg)
+	}
+	return &Dispatcher{
+		store:    store,
+		mailer:   mailer,
+		cfg:      cfg,
+		client:   client,
+		sleep:    sleep,
+		now:      time.Now,
+		queue:    make(chan job, cfg.QueueSize),
+		breakers: make(map[string]*breaker.Breaker),
+	}
+}
+
+// newHTTPClient returns a client that doesn't follow redirects, so a
+// redirect counts as a failed delivery, and that refuses to connect to
+// private addresses unless they are allowed. Addresses are checked after
+// DNS resolution, so a public name pointing at a private address is
+// refused too.
+func newHTTPClient(cfg Config) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivate(ip) {
+				return ErrPrivateAddress
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isPrivate(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
+
+// Sign returns the signature header value of body sent at t
+func Sign(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Publish queues a delivery of event to every active webhook subscribed to
+// it. Failures are logged rather than returned: an event never fails the
+// change it reports.
+func (d *Dispatcher) Publish(ctx context.Context, event core.Event) {
+	// The request that caused the event may finish before the deliveries
+	// are recorded
+	ctx = context.WithoutCancel(ctx)
+	logger := log.Ctx(ctx).With().Str("event_id", event.ID).Str("event_type", string(event.Type)).Logger()
+
+	webhooks, err := d.store.ListSubscribed(ctx, event.OrgID, event.Type)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to list webhooks for event")
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+	payload, err := Payload(event)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to encode webhook payload")
+		return
+	}
+
+	for _, webhook := range webhooks {
+		_, err := d.enqueue(ctx, webhook, &core.WebhookDelivery{
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			EventType: event.Type,
+			Payload:   payload,
+		})
+		if err != nil {
+			logger.Error().Err(err).Str("webhook_id", webhook.ID).Msg("failed to queue webhook delivery")
+		}
+	}
+}
+
+// Redeliver queues a new delivery of the payload of one of the user's
+// webhook's deliveries. Returns ErrWebhookNotFound, ErrWebhookDeliveryNotFound
+// or, when the queue is full, ErrWebhookQueueFull.
+func (d *Dispatcher) Redeliver(ctx context.Context, userID, webhookID, deliveryID string) (*core.WebhookDelivery, error) {
+	webhook, err := d.store.Get(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := d.store.GetDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	return d.enqueue(ctx, webhook, &core.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   previous.EventID,
+		EventType: previous.EventType,
+		Payload:   previous.Payload,
+	})
+}
+
+// Resend sends a new delivery of previous's payload to webhook right away,
+// with the usual retries, and returns it once its outcome is recorded. It
+// is for processes that don't Run the dispatcher, such as the admin CLI.
+func (d *Dispatcher) Resend(ctx context.Context, webhook *core.Webhook, previous *core.WebhookDelivery) (*core.WebhookDelivery, error) {
+	delivery, err := d.store.CreateDelivery(ctx, &core.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   previous.EventID,
+		EventType: previous.EventType,
+		Payload:   previous.Payload,
+		Status:    core.WebhookDeliveryPending,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	d.deliver(ctx, job{webhook: webhook, delivery: delivery})
+	return delivery, nil
+}
+
+// enqueue records a pending delivery and queues it. A delivery that doesn't
+// fit in the queue is recorded as failed.
+func (d *Dispatcher) enqueue(ctx context.Context, webhook *core.Webhook, delivery *core.WebhookDelivery) (*core.WebhookDelivery, error) {
+	delivery.Status = core.WebhookDeliveryPending
+	created, err := d.store.CreateDelivery(ctx, delivery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+
+	select {
+	case d.queue <- job{webhook: webhook, delivery: created}:
+		return created, nil
+	default:
+		d.fail(ctx, created, core.ErrWebhookQueueFull)
+		return nil, core.ErrWebhookQueueFull
+	}
+}
+
+// Run attempts queued deliveries on the configured number of workers until
+// ctx is done. Deliveries still queued then are recorded as failed.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < d.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// select picks at random among ready cases, so a stopped
+			// dispatcher would otherwise keep taking deliveries
+			for ctx.Err() == nil {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-d.queue:
+					d.deliver(ctx, j)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	stopped := context.WithoutCancel(ctx)
+	for {
+		select {
+		case j := <-d.queue:
+			d.fail(stopped, j.delivery, errors.New("webhook dispatcher stopped before the delivery was attempted"))
+		default:
+			return ctx.Err()
+		}
+	}
+}
+
+// deliver attempts a delivery until it succeeds or its retries run out,
+// then records the outcome
+func (d *Dispatcher) deliver(ctx context.Context, j job) {
+	delivery := j.delivery
+	logger := log.Ctx(ctx).With().
+		Str("webhook_id", j.webhook.ID).
+		Str("delivery_id", delivery.ID).
+		Str("event_type", string(delivery.EventType)).
+		Logger()
+	b := d.breaker(j.webhook.ID)
+
+	backoff := d.cfg.RetryBackoff
+	var err error
+	for attempt := 0; attempt <= d.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if sleepErr := d.sleep(ctx, backoff); sleepErr != nil {
+				err = sleepErr
+				break
+			}
+			backoff *= 2
+		}
+		err = b.Execute(func() error {
+			return d.attempt(ctx, j.webhook, delivery)
+		})
+		if err == nil || errors.Is(err, breaker.ErrOpen) || errors.Is(err, ErrPrivateAddress) {
+			break
+		}
+		logger.Warn().Err(err).Int("attempt", delivery.Attempts).Msg("webhook delivery attempt failed")
+	}
+
+	// The outcome is recorded even when shutdown interrupted the retries
+	ctx = context.WithoutCancel(ctx)
+	if err != nil {
+		d.fail(ctx, delivery, err)
+		logger.Error().Err(err).Int("attempts", delivery.Attempts).Msg("webhook delivery failed")
+	} else {
+		d.complete(ctx, delivery, core.WebhookDeliverySucceeded)
+		logger.Info().Int("attempts", delivery.Attempts).Dur("latency", delivery.Latency).Msg("webhook delivered")
+	}
+
+	disabled, recordErr := d.store.RecordOutcome(ctx, j.webhook.ID, err == nil, d.cfg.DisableAfter)
+	if recordErr != nil {
+		logger.Error().Err(recordErr).Msg("failed to record webhook outcome")
+		return
+	}
+	if disabled {
+		d.notifyDisabled(ctx, logger, j.webhook)
+	}
+}
+
+// attempt sends the delivery once, recording the response on it
+func (d *Dispatcher) attempt(ctx context.Context, webhook *core.Webhook, delivery *core.WebhookDelivery) error {
+	delivery.Attempts++
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ProveMySelf-Webhooks/1")
+	req.Header.Set(EventHeader, string(delivery.EventType))
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, d.now(), delivery.Payload))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	delivery.Latency = time.Since(start)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, responseSnippetSize))
+	status := resp.StatusCode
+	delivery.ResponseStatus = &status
+	delivery.ResponseBody = string(bytes.ToValidUTF8(snippet, nil))
+	if status < 200 || status > 299 {
+		return fmt.Errorf("webhook endpoint responded %d", status)
+	}
+	return nil
+}
+
+// breaker returns the webhook's breaker
+func (d *Dispatcher) breaker(webhookID string) *breaker.Breaker {
+	d.breakersMu.Lock()
+	defer d.breakersMu.Unlock()
+
+	b, ok := d.breakers[webhookID]
+	if !ok {
+		b = breaker.New(breaker.Config{
+			Name:             "webhook " + webhookID,
+			FailureThreshold: d.cfg.BreakerThreshold,
+			CoolDown:         d.cfg.BreakerCoolDown,
+		})
+		d.breakers[webhookID] = b
+	}
+	return b
+}
+
+// fail records the delivery as failed because of err
+func (d *Dispatcher) fail(ctx context.Context, delivery *core.WebhookDelivery, err error) {
+	delivery.Error = err.Error()
+	d.complete(ctx, delivery, core.WebhookDeliveryFailed)
+}
+
+func (d *Dispatcher) complete(ctx context.Context, delivery *core.WebhookDelivery, status core.WebhookDeliveryStatus) {
+	completed := d.now().UTC()
+	delivery.Status = status
+	delivery.CompletedAt = &completed
+	if err := d.store.CompleteDelivery(ctx, delivery); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("delivery_id", delivery.ID).Msg("failed to record webhook delivery outcome")
+	}
+}
+
+// notifyDisabled logs and emails the owner of a webhook that was disabled
+func (d *Dispatcher) notifyDisabled(ctx context.Context, logger zerolog.Logger, webhook *core.Webhook) {
+	logger.Warn().Int("failures", d.cfg.DisableAfter).Msg("webhook disabled after failed deliveries")
+	if d.mailer == nil || webhook.OwnerEmail == "" {
+		return
+	}
+	data := DisabledEmail{URL: webhook.URL, Failures: d.cfg.DisableAfter}
+	if err := d.mailer.Send(ctx, DisabledTemplate, []string{webhook.OwnerEmail}, data); err != nil {
+		logger.Error().Err(err).Msg("failed to email the owner of a disabled webhook")
+	}
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,469 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// memoryStore keeps webhooks and deliveries in memory
+type memoryStore struct {
+	core.WebhookStore
+
+	mu         sync.Mutex
+	webhooks   []*core.Webhook
+	deliveries map[string]*core.WebhookDelivery
+	failures   map[string]int
+	outcomes   []bool
+}
+
+func newMemoryStore(webhooks ...*core.Webhook) *memoryStore {
+	return &memoryStore{
+		webhooks:   webhooks,
+		deliveries: make(map[string]*core.WebhookDelivery),
+		failures:   make(map[string]int),
+	}
+}
+
+func (s *memoryStore) Get(ctx context.Context, userID, id string) (*core.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, webhook := range s.webhooks {
+		if webhook.ID == id && webhook.UserID == userID {
+			return webhook, nil
+		}
+	}
+	return nil, core.ErrWebhookNotFound
+}
+
+func (s *memoryStore) ListSubscribed(ctx context.Context, orgID string, eventType core.EventType) ([]*core.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var subscribed []*core.Webhook
+	for _, webhook := range s.webhooks {
+		if webhook.Active && webhook.Subscribes(eventType) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	return subscribed, nil
+}
+
+func (s *memoryStore) RecordOutcome(ctx context.Context, id string, succeeded bool, disableAfter int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outcomes = append(s.outcomes, succeeded)
+	if succeeded {
+		s.failures[id] = 0
+		return false, nil
+	}
+	s.failures[id]++
+	return s.failures[id] == disableAfter, nil
+}
+
+func (s *memoryStore) CreateDelivery(ctx context.Context, delivery *core.WebhookDelivery) (*core.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	created := *delivery
+	created.ID = fmt.Sprintf("delivery-%d", len(s.deliveries)+1)
+	created.CreatedAt = time.Now().UTC()
+	s.deliveries[created.ID] = &created
+	stored := created
+	return &stored, nil
+}
+
+func (s *memoryStore) CompleteDelivery(ctx context.Context, delivery *core.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	completed := *delivery
+	s.deliveries[delivery.ID] = &completed
+	return nil
+}
+
+func (s *memoryStore) GetDelivery(ctx context.Context, webhookID, id string) (*core.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delivery, ok := s.deliveries[id]
+	if !ok || delivery.WebhookID != webhookID {
+		return nil, core.ErrWebhookDeliveryNotFound
+	}
+	found := *delivery
+	return &found, nil
+}
+
+func (s *memoryStore) delivery(t *testing.T, id string) core.WebhookDelivery {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delivery, ok := s.deliveries[id]
+	require.True(t, ok, "delivery %s was not recorded", id)
+	return *delivery
+}
+
+// recordingMailer records the emails it is asked to send
+type recordingMailer struct {
+	templates []string
+	to        [][]string
+	data      []any
+}
+
+func (m *recordingMailer) Send(ctx context.Context, template string, to []string, data any) error {
+	m.templates = append(m.templates, template)
+	m.to = append(m.to, to)
+	m.data = append(m.data, data)
+	return nil
+}
+
+// endpoint is a webhook receiver answering with the given statuses in turn,
+// then 200
+type endpoint struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newEndpoint(t *testing.T, statuses ...int) *endpoint {
+	t.Helper()
+	e := &endpoint{statuses: statuses}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		e.mu.Lock()
+		e.requests = append(e.requests, r)
+		e.bodies = append(e.bodies, body)
+		status := http.StatusOK
+		if len(e.statuses) > 0 {
+			status, e.statuses = e.statuses[0], e.statuses[1:]
+		}
+		e.mu.Unlock()
+		if status == http.StatusFound {
+			w.Header().Set("Location", "/elsewhere")
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(http.StatusText(status)))
+	}))
+	t.Cleanup(e.Close)
+	return e
+}
+
+func (e *endpoint) count() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.requests)
+}
+
+func testWebhook(url string) *core.Webhook {
+	return &core.Webhook{
+		ID:         "webhook-1",
+		UserID:     "alice",
+		OwnerEmail: "alice@example.com",
+		URL:        url,
+		Secret:     "whsec_0123456789abcdef",
+		Events:     []core.EventType{core.EventProjectCreated},
+		Active:     true,
+	}
+}
+
+func testEvent() core.Event {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return core.Event{
+		ID:         "event-1",
+		Type:       core.EventProjectCreated,
+		OccurredAt: now,
+		Project:    &core.Project{ID: "project-1", Title: "World Capitals", CreatedAt: now, UpdatedAt: now},
+	}
+}
+
+// testDispatcher returns a dispatcher allowed to reach httptest servers,
+// recording the backoffs it waits for instead of sleeping
+func testDispatcher(store core.WebhookStore, mailer Mailer, cfg Config) (*Dispatcher, *[]time.Duration) {
+	cfg.AllowPrivateNetworks = true
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	d := NewDispatcher(store, mailer, cfg)
+	var sleeps []time.Duration
+	d.sleep = func(ctx context.Context, backoff time.Duration) error {
+		sleeps = append(sleeps, backoff)
+		return nil
+	}
+	d.now = func() time.Time { return time.Unix(1772366400, 0) }
+	return d, &sleeps
+}
+
+// deliverNext attempts the next queued delivery as a worker would
+func deliverNext(t *testing.T, d *Dispatcher) string {
+	t.Helper()
+	select {
+	case j := <-d.queue:
+		d.deliver(context.Background(), j)
+		return j.delivery.ID
+	default:
+		t.Fatal("no delivery was queued")
+		return ""
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Arrange
+	at := time.Unix(1772366400, 0)
+
+	// Act
+	signature := Sign("whsec_0123456789abcdef", at, []byte(`{"id":"event-1"}`))
+
+	// Assert
+	assert.Equal(t, "t=1772366400,v1=53d327b6a6d61ddc19507a0d976793048fdc2603999aa84122b73990432c3d01", signature)
+	assert.NotEqual(t, signature, Sign("whsec_another-secret", at, []byte(`{"id":"event-1"}`)))
+}
+
+func TestDispatcher_DeliversSignedPayload(t *testing.T) {
+	// Arrange
+	server := newEndpoint(t)
+	store := newMemoryStore(testWebhook(server.URL))
+	d, sleeps := testDispatcher(store, nil, Config{MaxRetries: 3, RetryBackoff: time.Second})
+
+	// Act
+	d.Publish(context.Background(), testEvent())
+	id := deliverNext(t, d)
+
+	// Assert
+	require.Equal(t, 1, server.count())
+	req, body := server.requests[0], server.bodies[0]
+	payload, err := Payload(testEvent())
+	require.NoError(t, err)
+	assert.JSONEq(t, string(payload), string(body))
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, "project.created", req.Header.Get(EventHeader))
+	assert.Equal(t, id, req.Header.Get(DeliveryHeader))
+	assert.Equal(t, Sign("whsec_0123456789abcdef", time.Unix(1772366400, 0), body), req.Header.Get(SignatureHeader))
+
+	delivery := store.delivery(t, id)
+	assert.Equal(t, core.WebhookDeliverySucceeded, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	require.NotNil(t, delivery.ResponseStatus)
+	assert.Equal(t, http.StatusOK, *delivery.ResponseStatus)
+	assert.Equal(t, "OK", delivery.ResponseBody)
+	assert.Empty(t, delivery.Error)
+	assert.NotNil(t, delivery.CompletedAt)
+	assert.Empty(t, *sleeps)
+	assert.Equal(t, []bool{true}, store.outcomes)
+}
+
+func TestDispatcher_Publish_SkipsUnsubscribedWebhooks(t *testing.T) {
+	// Arrange
+	webhook := testWebhook("https://hooks.example.com")
+	webhook.Events = []core.EventType{core.EventProjectDeleted}
+	d, _ := testDispatcher(newMemoryStore(webhook), nil, Config{})
+
+	// Act
+	d.Publish(context.Background(), testEvent())
+
+	// Assert
+	assert.Zero(t, len(d.queue))
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	// Arrange
+	server := newEndpoint(t, http.StatusInternalServerError, http.StatusServiceUnavailable)
+	store := newMemoryStore(testWebhook(server.URL))
+	d, sleeps := testDispatcher(store, nil, Config{MaxRetries: 3, RetryBackoff: time.Second})
+
+	// Act
+	d.Publish(context.Background(), testEvent())
+	id := deliverNext(t, d)
+
+	// Assert
+	delivery := store.delivery(t, id)
+	assert.Equal(t, core.WebhookDeliverySucceeded, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *sleeps)
+	assert.Equal(t, []bool{true}, store.outcomes)
+}
+
+func TestDispatcher_FailsAfterRetries(t *testing.T) {
+	// Arrange
+	server := newEndpoint(t, 500, 500, 500, 500)
+	store := newMemoryStore(testWebhook(server.URL))
+	d, sleeps := testDispatcher(store, nil, Config{MaxRetries: 3, RetryBackoff: time.Second, BreakerThreshold: 10})
+
+	// Act
+	d.Publish(context.Background(), testEvent())
+	id := deliverNext(t, d)
+
+	// Assert
+	delivery := store.delivery(t, id)
+	assert.Equal(t, core.WebhookDeliveryFailed, delivery.Status)
+	assert.Equal(t, 4, delivery.Attempts)
+	assert.Equal(t, "webhook endpoint responded 500", delivery.Error)
+	assert.Equal(t, "Internal Server Error", delivery.ResponseBody)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, *sleeps)
+	assert.Equal(t, []bool{false}, store.outcomes)
+}
+
+func TestDispatcher_RedirectIsAFailure(t *testing.T) {
+	// Arrange
+	server := newEndpoint(t, http.StatusFound)
+	store := newMemoryStore(testWebhook(server.URL))
+	d, _ := testDispatcher(store, nil, Config{})
+
+	// Act
+	d.Publish(context.Background(), testEvent())
+	id := deliverNext(t, d)
+
+	// Assert
+	assert.Equal(t, 1, server.count(), "the redirect is not followed")
+	delivery := store.delivery(t, id)
+	assert.Equal(t, core.WebhookDeliveryFailed, delivery.Status)
+	require.NotNil(t, delivery.ResponseStatus)
+	assert.Equal(t, http.StatusFound, *delivery.ResponseStatus)
+}
+
+func TestDispatcher_RefusesPrivateAddresses(t *testing.T) {
+	// Arrange
+	server := newEndpoint(t)
+	store := newMemoryStore(testWebhook(server.URL))
+	d := NewDispatcher(store, nil, Config{Timeout: 5 * time.Second, MaxRetries: 3})
+	var sleeps []time.Duration
+	d.sleep = func(ctx context.Context, backoff time.Duration) error {
+		sleeps = append(sleeps, backoff)
+		return nil
+	}
+
+	// Act
+	d.Publish(context.Background(), testEvent())
+	id := deliverNext(t, d)
+
+	// Assert
+	assert.Zero(t, server.count())
+	delivery := store.delivery(t, id)
+	assert.Equal(t, core.WebhookDeliveryFailed, delivery.Status)
+	assert.Contains(t, delivery.Error, ErrPrivateAddress.Error())
+	assert.Equal(t, 1, delivery.Attempts, "a refused address is not retried")
+	assert.Empty(t, sleeps)
+}
+
+func TestDispatcher_DisablesAndEmailsOwner(t *testing.T) {
+	// Arrange
+	server := newEndpoint(t, 500, 500)
+	store := newMemoryStore(testWebhook(server.URL))
+	mailer := &recordingMailer{}
+	d, _ := testDispatcher(store, mailer, Config{DisableAfter: 2})
+
+	// Act
+	d.Publish(context.Background(), testEvent())
+	deliverNext(t, d)
+	d.Publish(context.Background(), testEvent())
+	deliverNext(t, d)
+
+	// Assert
+	require.Len(t, mailer.templates, 1)
+	assert.Equal(t, DisabledTemplate, mailer.templates[0])
+	assert.Equal(t, []string{"alice@example.com"}, mailer.to[0])
+	assert.Equal(t, DisabledEmail{URL: server.URL, Failures: 2}, mailer.data[0])
+}
+
+func TestDispatcher_QueueFull(t *testing.T) {
+	// Arrange
+	first := testWebhook("https://hooks.example.com/first")
+	second := testWebhook("https://hooks.example.com/second")
+	second.ID = "webhook-2"
+	store := newMemoryStore(first, second)
+	d, _ := testDispatcher(store, nil, Config{QueueSize: 1})
+
+	// Act
+	d.Publish(context.Background(), testEvent())
+	_, redeliverErr := d.Redeliver(context.Background(), "alice", "webhook-1", "delivery-1")
+
+	// Assert
+	assert.Equal(t, 1, len(d.queue))
+	dropped := store.delivery(t, "delivery-2")
+	assert.Equal(t, "webhook-2", dropped.WebhookID)
+	assert.Equal(t, core.WebhookDeliveryFailed, dropped.Status)
+	assert.Equal(t, core.ErrWebhookQueueFull.Error(), dropped.Error)
+	assert.ErrorIs(t, redeliverErr, core.ErrWebhookQueueFull)
+}
+
+func TestDispatcher_Redeliver(t *testing.T) {
+	// Arrange
+	server := newEndpoint(t, 500)
+	store := newMemoryStore(testWebhook(server.URL))
+	d, _ := testDispatcher(store, nil, Config{})
+	d.Publish(context.Background(), testEvent())
+	failed := deliverNext(t, d)
+
+	// Act
+	redelivery, err := d.Redeliver(context.Background(), "alice", "webhook-1", failed)
+	require.NoError(t, err)
+	deliverNext(t, d)
+	_, othersErr := d.Redeliver(context.Background(), "bob", "webhook-1", failed)
+	_, unknownErr := d.Redeliver(context.Background(), "alice", "webhook-1", "delivery-9")
+
+	// Assert
+	assert.NotEqual(t, failed, redelivery.ID)
+	delivery := store.delivery(t, redelivery.ID)
+	assert.Equal(t, core.WebhookDeliverySucceeded, delivery.Status)
+	assert.Equal(t, "event-1", delivery.EventID)
+	assert.Equal(t, server.bodies[0], server.bodies[1], "the same payload is sent again")
+
+	var sent map[string]any
+	require.NoError(t, json.Unmarshal(server.bodies[1], &sent))
+	assert.Equal(t, "event-1", sent["id"])
+	assert.ErrorIs(t, othersErr, core.ErrWebhookNotFound)
+	assert.ErrorIs(t, unknownErr, core.ErrWebhookDeliveryNotFound)
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	// Arrange
+	server := newEndpoint(t)
+	store := newMemoryStore(testWebhook(server.URL))
+	d, _ := testDispatcher(store, nil, Config{Workers: 2})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+
+	// Act
+	d.Publish(context.Background(), testEvent())
+	require.Eventually(t, func() bool {
+		return store.delivery(t, "delivery-1").Status == core.WebhookDeliverySucceeded
+	}, 5*time.Second, 5*time.Millisecond)
+	cancel()
+
+	// Assert
+	assert.True(t, errors.Is(<-done, context.Canceled))
+}
+
+func TestDispatcher_Run_FailsQueuedDeliveriesOnStop(t *testing.T) {
+	// Arrange
+	server := newEndpoint(t)
+	store := newMemoryStore(testWebhook(server.URL))
+	d, _ := testDispatcher(store, nil, Config{QueueSize: 10})
+	d.Publish(context.Background(), testEvent())
+	d.Publish(context.Background(), testEvent())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	err := d.Run(ctx)
+
+	// Assert
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, server.count())
+	for _, id := range []string{"delivery-1", "delivery-2"} {
+		delivery := store.delivery(t, id)
+		assert.Equal(t, core.WebhookDeliveryFailed, delivery.Status)
+		assert.Equal(t, "webhook dispatcher stopped before the delivery was attempted", delivery.Error)
+	}
+}
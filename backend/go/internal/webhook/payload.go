@@ -0,0 +1,41 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// Payload returns the JSON body delivered for event, in the current payload
+// version
+func Payload(event core.Event) ([]byte, error) {
+	if event.Project == nil {
+		return nil, fmt.Errorf("event %s has no project", event.Type)
+	}
+	return json.Marshal(types.WebhookEventV1{
+		ID:        event.ID,
+		Type:      string(event.Type),
+		Version:   types.WebhookPayloadVersion,
+		CreatedAt: event.OccurredAt,
+		OrgID:     event.OrgID,
+		Data:      types.ProjectEventDataV1{Project: projectV1(event.Project)},
+	})
+}
+
+func projectV1(project *core.Project) types.WebhookProjectV1 {
+	tags := project.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return types.WebhookProjectV1{
+		ID:          project.ID,
+		Title:       project.Title,
+		Description: project.Description,
+		Tags:        tags,
+		CreatedAt:   project.CreatedAt,
+		UpdatedAt:   project.UpdatedAt,
+		PublishedAt: project.PublishedAt,
+	}
+}
@@ -0,0 +1,100 @@
+package xapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	// VersionHeader carries the xAPI version every request speaks
+	VersionHeader = "X-Experience-API-Version"
+	Version       = "1.0.3"
+
+	// responseSnippetSize is how much of an error response is kept
+	responseSnippetSize = 512
+)
+
+// ErrLRSNotConfigured is returned when sending statements while no LRS
+// endpoint is configured
+var ErrLRSNotConfigured = errors.New("no LRS is configured")
+
+// Client sends statements to an LRS. Its endpoint can change at runtime,
+// after a configuration reload. It is safe for concurrent use.
+type Client struct {
+	authorization string
+	http          *http.Client
+
+	mu       sync.RWMutex
+	endpoint string
+}
+
+// NewClient creates a client of the LRS at endpoint, its xAPI base URL,
+// sending authorization as the Authorization header when it is set. An
+// empty endpoint leaves the client unconfigured.
+func NewClient(endpoint, authorization string, httpClient *http.Client) *Client {
+	return &Client{
+		endpoint:      strings.TrimSuffix(endpoint, "/"),
+		authorization: authorization,
+		http:          httpClient,
+	}
+}
+
+// SetEndpoint changes the LRS statements are sent to, "" to stop sending
+func (c *Client) SetEndpoint(endpoint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.endpoint = strings.TrimSuffix(endpoint, "/")
+}
+
+// Configured reports whether the client has an LRS to send to
+func (c *Client) Configured() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.endpoint != ""
+}
+
+// Send posts statements to the LRS in one request. Any response but a 2xx
+// is an error; the LRS keeps one copy of a statement sent twice.
+// Returns ErrLRSNotConfigured if there is no LRS.
+func (c *Client) Send(ctx context.Context, statements []Statement) error {
+	c.mu.RLock()
+	endpoint := c.endpoint
+	c.mu.RUnlock()
+	if endpoint == "" {
+		return ErrLRSNotConfigured
+	}
+
+	body, err := json.Marshal(statements)
+	if err != nil {
+		return fmt.Errorf("failed to encode statements: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/statements", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid LRS endpoint: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(VersionHeader, Version)
+	if c.authorization != "" {
+		req.Header.Set("Authorization", c.authorization)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("LRS unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, responseSnippetSize))
+		return fmt.Errorf("LRS responded %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,70 @@
+package xapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Send(t *testing.T) {
+	// Arrange
+	var got *http.Request
+	var sent []Statement
+	lrs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer lrs.Close()
+	client := NewClient(lrs.URL+"/xapi/", "Basic dXNlcjpwYXNz", lrs.Client())
+	statements := NewBuilder("https://api.example.com").Statements(submittedAttempt("attempt-1", "learner-1"))
+
+	// Act
+	err := client.Send(context.Background(), statements)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Equal(t, "/xapi/statements", got.URL.Path)
+	assert.Equal(t, Version, got.Header.Get(VersionHeader))
+	assert.Equal(t, "Basic dXNlcjpwYXNz", got.Header.Get("Authorization"))
+	require.Len(t, sent, len(statements))
+	assert.Equal(t, statements[0].ID, sent[0].ID)
+}
+
+func TestClient_Send_Errors(t *testing.T) {
+	t.Run("error response", func(t *testing.T) {
+		// Arrange
+		lrs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "conflicting statement", http.StatusConflict)
+		}))
+		defer lrs.Close()
+		client := NewClient(lrs.URL, "", lrs.Client())
+
+		// Act
+		err := client.Send(context.Background(), []Statement{})
+
+		// Assert
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "409")
+		assert.Contains(t, err.Error(), "conflicting statement")
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		// Arrange
+		client := NewClient("http://lrs.example.com", "", http.DefaultClient)
+		client.SetEndpoint("")
+
+		// Act
+		err := client.Send(context.Background(), []Statement{})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrLRSNotConfigured)
+		assert.False(t, client.Configured())
+	})
+}
@@ -0,0 +1,245 @@
+package xapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/jobs"
+)
+
+// ExportJob is the name of the job an Exporter runs in
+const ExportJob = "xapi.export"
+
+const (
+	// ExportBatchSize is how many attempts are read at a time
+	ExportBatchSize = 100
+
+	// SendBatchSize is how many statements go in one request to the LRS
+	SendBatchSize = 500
+)
+
+// AttemptSource lists the submitted attempts to export, satisfied by
+// core.AttemptStore
+type AttemptSource interface {
+	ListSubmitted(ctx context.Context, projectID string, filter core.AttemptFilter, afterID string, limit int) ([]*core.Attempt, error)
+}
+
+// Sender sends statements to an LRS, satisfied by *Client
+type Sender interface {
+	Configured() bool
+	Send(ctx context.Context, statements []Statement) error
+}
+
+// ExportRequest picks the attempts to export: those at ProjectID, or at
+// every project when it is "", started in Filter's window
+type ExportRequest struct {
+	ProjectID string
+	Filter    core.AttemptFilter
+}
+
+// ExportState is where an export to the LRS stands
+type ExportState string
+
+// Export states
+const (
+	// ExportStateIdle is before the first export since the API started
+	ExportStateIdle ExportState = "idle"
+	// ExportStatePending is an export waiting for its job to run
+	ExportStatePending   ExportState = "pending"
+	ExportStateRunning   ExportState = "running"
+	ExportStateSucceeded ExportState = "succeeded"
+	ExportStateFailed    ExportState = "failed"
+)
+
+// ExportProgress reports the last export to the LRS
+type ExportProgress struct {
+	State   ExportState
+	Request ExportRequest
+
+	// Attempts and Statements count what was sent so far
+	Attempts   int
+	Statements int
+
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+
+	// Error is why a failed export stopped
+	Error string
+}
+
+// Exporter backfills the statements of submitted attempts. An export to
+// the LRS runs in the ExportJob background job, one at a time, with its
+// progress kept in this replica's memory; a file export is written as it
+// is read. It is safe for concurrent use.
+type Exporter struct {
+	attempts AttemptSource
+	builder  *Builder
+	sender   Sender
+	now      func() time.Time
+
+	mu       sync.Mutex
+	trigger  func() error
+	pending  *ExportRequest
+	progress ExportProgress
+}
+
+// NewExporter creates an exporter of the attempts in source, built into
+// statements by builder and sent to the LRS with sender
+func NewExporter(attempts AttemptSource, builder *Builder, sender Sender) *Exporter {
+	return &Exporter{
+		attempts: attempts,
+		builder:  builder,
+		sender:   sender,
+		now:      time.Now,
+		progress: ExportProgress{State: ExportStateIdle},
+	}
+}
+
+// SetTrigger sets how Start runs the export job, e.g. by triggering it on
+// the scheduler
+func (e *Exporter) SetTrigger(trigger func() error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.trigger = trigger
+}
+
+// Start asks for an export of the attempts req picks to the LRS and
+// returns its progress; the export runs in the background.
+// Returns ErrLRSNotConfigured if there is no LRS, and jobs.ErrJobRunning if
+// an export is pending or running.
+func (e *Exporter) Start(req ExportRequest) (ExportProgress, error) {
+	if !e.sender.Configured() {
+		return ExportProgress{}, ErrLRSNotConfigured
+	}
+
+	e.mu.Lock()
+	if e.pending != nil || e.progress.State == ExportStateRunning {
+		e.mu.Unlock()
+		return ExportProgress{}, jobs.ErrJobRunning
+	}
+	if e.trigger == nil {
+		e.mu.Unlock()
+		return ExportProgress{}, jobs.ErrNotRunning
+	}
+	previous := e.progress
+	e.pending = &req
+	e.progress = ExportProgress{State: ExportStatePending, Request: req}
+	trigger := e.trigger
+	e.mu.Unlock()
+
+	if err := trigger(); err != nil {
+		e.mu.Lock()
+		e.pending = nil
+		e.progress = previous
+		e.mu.Unlock()
+		return ExportProgress{}, err
+	}
+	return e.Progress(), nil
+}
+
+// Progress returns the progress of the last export to the LRS
+func (e *Exporter) Progress() ExportProgress {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.progress
+}
+
+// Run sends the pending export to the LRS batch by batch. It is the body
+// of the ExportJob job, and does nothing when no export is pending.
+func (e *Exporter) Run(ctx context.Context) error {
+	e.mu.Lock()
+	if e.pending == nil {
+		e.mu.Unlock()
+		return nil
+	}
+	req := *e.pending
+	e.pending = nil
+	startedAt := e.now()
+	e.progress = ExportProgress{State: ExportStateRunning, Request: req, StartedAt: &startedAt}
+	e.mu.Unlock()
+
+	err := e.walk(ctx, req, func(attempts []*core.Attempt, statements []Statement) error {
+		for start := 0; start < len(statements); start += SendBatchSize {
+			end := min(start+SendBatchSize, len(statements))
+			if err := e.sender.Send(ctx, statements[start:end]); err != nil {
+				return err
+			}
+		}
+		e.mu.Lock()
+		e.progress.Attempts += len(attempts)
+		e.progress.Statements += len(statements)
+		e.mu.Unlock()
+		return nil
+	})
+
+	finishedAt := e.now()
+	e.mu.Lock()
+	e.progress.FinishedAt = &finishedAt
+	e.progress.State = ExportStateSucceeded
+	if err != nil {
+		e.progress.State = ExportStateFailed
+		e.progress.Error = err.Error()
+	}
+	progress := e.progress
+	e.mu.Unlock()
+
+	log.Ctx(ctx).Info().
+		Str("project_id", req.ProjectID).
+		Int("attempts", progress.Attempts).
+		Int("statements", progress.Statements).
+		Err(err).
+		Msg("exported xAPI statements")
+	return err
+}
+
+// Write writes the statements of the attempts req picks to w as
+// newline-delimited JSON, one statement a line
+func (e *Exporter) Write(ctx context.Context, w io.Writer, req ExportRequest) error {
+	encoder := json.NewEncoder(w)
+	return e.walk(ctx, req, func(_ []*core.Attempt, statements []Statement) error {
+		for _, statement := range statements {
+			if err := encoder.Encode(statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// walk passes fn the attempts req picks, ExportBatchSize at a time in ID
+// order, with their statements, until a batch comes up short
+func (e *Exporter) walk(ctx context.Context, req ExportRequest, fn func(attempts []*core.Attempt, statements []Statement) error) error {
+	afterID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		attempts, err := e.attempts.ListSubmitted(ctx, req.ProjectID, req.Filter, afterID, ExportBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list attempts: %w", err)
+		}
+		if len(attempts) == 0 {
+			return nil
+		}
+
+		var statements []Statement
+		for _, attempt := range attempts {
+			statements = append(statements, e.builder.Statements(attempt)...)
+		}
+		if err := fn(attempts, statements); err != nil {
+			return err
+		}
+
+		if len(attempts) < ExportBatchSize {
+			return nil
+		}
+		afterID = attempts[len(attempts)-1].ID
+	}
+}
@@ -0,0 +1,219 @@
+package xapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/jobs"
+)
+
+// memoryAttempts is an AttemptSource of submitted attempts in ID order
+type memoryAttempts struct {
+	attempts []*core.Attempt
+
+	// projectIDs are what each call asked for
+	projectIDs []string
+}
+
+// newMemoryAttempts returns n submitted attempts of two items each
+func newMemoryAttempts(n int) *memoryAttempts {
+	source := &memoryAttempts{}
+	for i := 0; i < n; i++ {
+		source.attempts = append(source.attempts, submittedAttempt(fmt.Sprintf("attempt-%04d", i), "learner-1"))
+	}
+	return source
+}
+
+func (s *memoryAttempts) ListSubmitted(ctx context.Context, projectID string, filter core.AttemptFilter, afterID string, limit int) ([]*core.Attempt, error) {
+	s.projectIDs = append(s.projectIDs, projectID)
+	var batch []*core.Attempt
+	for _, attempt := range s.attempts {
+		if attempt.ID > afterID && len(batch) < limit {
+			batch = append(batch, attempt)
+		}
+	}
+	return batch, nil
+}
+
+// recordingSender records the statements sent, failing once failAfter
+// requests succeeded when it is set
+type recordingSender struct {
+	unconfigured bool
+	failAfter    int
+
+	requests   int
+	statements []Statement
+}
+
+func (s *recordingSender) Configured() bool { return !s.unconfigured }
+
+func (s *recordingSender) Send(ctx context.Context, statements []Statement) error {
+	if s.failAfter > 0 && s.requests == s.failAfter {
+		return errors.New("LRS responded 503")
+	}
+	s.requests++
+	s.statements = append(s.statements, statements...)
+	return nil
+}
+
+// statementIDs returns the IDs of statements
+func statementIDs(statements []Statement) []string {
+	ids := make([]string, len(statements))
+	for i, statement := range statements {
+		ids[i] = statement.ID
+	}
+	return ids
+}
+
+// newTestExporter returns an exporter whose trigger runs the export at once
+func newTestExporter(source *memoryAttempts, sender *recordingSender) *Exporter {
+	exporter := NewExporter(source, NewBuilder("https://api.example.com"), sender)
+	exporter.SetTrigger(func() error {
+		_ = exporter.Run(context.Background())
+		return nil
+	})
+	return exporter
+}
+
+func TestExporter_Run(t *testing.T) {
+	// Arrange
+	source := newMemoryAttempts(ExportBatchSize + 20)
+	sender := &recordingSender{}
+	exporter := newTestExporter(source, sender)
+
+	// Act
+	_, err := exporter.Start(ExportRequest{ProjectID: "project-1"})
+
+	// Assert
+	require.NoError(t, err)
+	progress := exporter.Progress()
+	assert.Equal(t, ExportStateSucceeded, progress.State)
+	assert.Equal(t, ExportBatchSize+20, progress.Attempts)
+	assert.Equal(t, 3*(ExportBatchSize+20), progress.Statements)
+	assert.Empty(t, progress.Error)
+	assert.NotNil(t, progress.StartedAt)
+	assert.NotNil(t, progress.FinishedAt)
+	assert.Len(t, sender.statements, progress.Statements)
+	assert.Equal(t, []string{"project-1", "project-1"}, source.projectIDs, "attempts are read in batches")
+}
+
+func TestExporter_Run_StableStatementIDs(t *testing.T) {
+	// Arrange
+	source := newMemoryAttempts(ExportBatchSize + 1)
+	sender := &recordingSender{}
+	exporter := newTestExporter(source, sender)
+
+	// Act
+	_, err := exporter.Start(ExportRequest{})
+	require.NoError(t, err)
+	first := statementIDs(sender.statements)
+	sender.statements = nil
+	_, err = exporter.Start(ExportRequest{})
+	require.NoError(t, err)
+	second := statementIDs(sender.statements)
+
+	// Assert
+	require.Len(t, first, 3*(ExportBatchSize+1))
+	assert.Equal(t, first, second, "a second run sends the same statement IDs")
+}
+
+func TestExporter_Run_Failure(t *testing.T) {
+	// Arrange
+	sender := &recordingSender{failAfter: 1}
+	exporter := newTestExporter(newMemoryAttempts(ExportBatchSize+5), sender)
+
+	// Act
+	_, err := exporter.Start(ExportRequest{})
+
+	// Assert
+	require.NoError(t, err, "the export runs in the background")
+	progress := exporter.Progress()
+	assert.Equal(t, ExportStateFailed, progress.State)
+	assert.Equal(t, ExportBatchSize, progress.Attempts, "the batches before the failure count")
+	assert.Equal(t, "LRS responded 503", progress.Error)
+}
+
+func TestExporter_Run_NothingPending(t *testing.T) {
+	// Arrange
+	sender := &recordingSender{}
+	exporter := NewExporter(newMemoryAttempts(3), NewBuilder("https://api.example.com"), sender)
+
+	// Act
+	err := exporter.Run(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, ExportStateIdle, exporter.Progress().State)
+	assert.Empty(t, sender.statements)
+}
+
+func TestExporter_Start_Errors(t *testing.T) {
+	triggerErr := errors.New("scheduler is not running")
+
+	tests := []struct {
+		name     string
+		sender   *recordingSender
+		trigger  func() error
+		pending  bool
+		expected error
+	}{
+		{"no LRS", &recordingSender{unconfigured: true}, func() error { return nil }, false, ErrLRSNotConfigured},
+		{"export pending", &recordingSender{}, func() error { return nil }, true, jobs.ErrJobRunning},
+		{"trigger fails", &recordingSender{}, func() error { return triggerErr }, false, triggerErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			exporter := NewExporter(newMemoryAttempts(1), NewBuilder("https://api.example.com"), tt.sender)
+			exporter.SetTrigger(func() error { return nil })
+			if tt.pending {
+				_, err := exporter.Start(ExportRequest{})
+				require.NoError(t, err)
+			}
+			exporter.SetTrigger(tt.trigger)
+			before := exporter.Progress()
+
+			// Act
+			_, err := exporter.Start(ExportRequest{ProjectID: "project-2"})
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expected)
+			assert.Equal(t, before, exporter.Progress(), "a refused export leaves the progress as it was")
+		})
+	}
+}
+
+func TestExporter_Write(t *testing.T) {
+	// Arrange
+	exporter := NewExporter(newMemoryAttempts(ExportBatchSize+1), NewBuilder("https://api.example.com"), &recordingSender{unconfigured: true})
+	write := func() []byte {
+		var buf bytes.Buffer
+		require.NoError(t, exporter.Write(context.Background(), &buf, ExportRequest{}))
+		return buf.Bytes()
+	}
+
+	// Act
+	first := write()
+	second := write()
+
+	// Assert
+	assert.Equal(t, first, second, "a second run writes the same statements")
+	lines := 0
+	scanner := bufio.NewScanner(bytes.NewReader(first))
+	for scanner.Scan() {
+		var statement Statement
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &statement), "each line is a statement")
+		lines++
+	}
+	assert.Equal(t, 3*(ExportBatchSize+1), lines)
+}
@@ -0,0 +1,197 @@
+// Package xapi reports submitted attempts to a Learning Record Store as
+// xAPI statements. A Builder turns an attempt into statements, a Client
+// sends them to the LRS, and an Exporter backfills past attempts, to the
+// LRS or as a newline-delimited JSON download.
+//
+// Statement IDs are derived from the attempt and item IDs (see
+// StatementID), so sending the same attempt twice sends the same
+// statements and the LRS keeps one copy of each.
+package xapi
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// Verbs the statements use
+var (
+	VerbCompleted = Verb{
+		ID:      "http://adlnet.gov/expapi/verbs/completed",
+		Display: map[string]string{"en-US": "completed"},
+	}
+	VerbAnswered = Verb{
+		ID:      "http://adlnet.gov/expapi/verbs/answered",
+		Display: map[string]string{"en-US": "answered"},
+	}
+)
+
+// Activity types of the statements' objects
+const (
+	ActivityTypeAssessment  = "http://adlnet.gov/expapi/activities/assessment"
+	ActivityTypeInteraction = "http://adlnet.gov/expapi/activities/cmi.interaction"
+)
+
+// statementNamespace is the UUID namespace statement IDs are derived in
+var statementNamespace = uuid.MustParse("5f0b7c1e-2d44-4f4b-9a43-6c1e8e0d2b7a")
+
+// Statement is an xAPI statement, as sent to an LRS
+type Statement struct {
+	ID        string    `json:"id"`
+	Actor     Agent     `json:"actor"`
+	Verb      Verb      `json:"verb"`
+	Object    Activity  `json:"object"`
+	Result    *Result   `json:"result,omitempty"`
+	Context   *Context  `json:"context,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Agent is the learner a statement is about, identified by an account on
+// this API
+type Agent struct {
+	ObjectType string  `json:"objectType"`
+	Account    Account `json:"account"`
+}
+
+// Account identifies an agent by its name on the system at HomePage
+type Account struct {
+	HomePage string `json:"homePage"`
+	Name     string `json:"name"`
+}
+
+// Verb is what the actor did
+type Verb struct {
+	ID      string            `json:"id"`
+	Display map[string]string `json:"display"`
+}
+
+// Activity is what the actor did it to
+type Activity struct {
+	ObjectType string     `json:"objectType"`
+	ID         string     `json:"id"`
+	Definition Definition `json:"definition"`
+}
+
+// Definition describes an activity
+type Definition struct {
+	Type string `json:"type"`
+}
+
+// Result is the outcome of the activity
+type Result struct {
+	Score      *Score `json:"score,omitempty"`
+	Success    *bool  `json:"success,omitempty"`
+	Completion *bool  `json:"completion,omitempty"`
+}
+
+// Score is the points earned out of Max; Scaled is Raw/Max
+type Score struct {
+	Scaled float64 `json:"scaled"`
+	Raw    int     `json:"raw"`
+	Min    int     `json:"min"`
+	Max    int     `json:"max"`
+}
+
+// Context ties a statement to its attempt and project
+type Context struct {
+	// Registration is the attempt's ID, shared by all of its statements
+	Registration      string             `json:"registration"`
+	ContextActivities *ContextActivities `json:"contextActivities,omitempty"`
+}
+
+// ContextActivities lists the activities a statement's object is part of
+type ContextActivities struct {
+	Parent []Activity `json:"parent"`
+}
+
+// StatementID returns the ID of an attempt's statement about itemID, or
+// about its completion when itemID is "". The ID is derived from both, so
+// it is the same every time the attempt is reported.
+func StatementID(attemptID, itemID string) string {
+	return uuid.NewSHA1(statementNamespace, []byte(attemptID+"/"+itemID)).String()
+}
+
+// Builder turns attempts into statements. It is the one way statements are
+// built, so a backfill sends exactly what live reporting would.
+type Builder struct {
+	baseURL string
+}
+
+// NewBuilder creates a builder naming activities and accounts under
+// baseURL, the API's public base URL
+func NewBuilder(baseURL string) *Builder {
+	return &Builder{baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Statements returns a submitted attempt's statements: that the learner
+// completed the project, with their score, then that they answered each
+// question, in item order. It returns none for an attempt not submitted.
+func (b *Builder) Statements(attempt *core.Attempt) []Statement {
+	if attempt.SubmittedAt == nil {
+		return nil
+	}
+
+	actor := Agent{
+		ObjectType: "Agent",
+		Account:    Account{HomePage: b.baseURL, Name: attempt.ParticipantID},
+	}
+	if attempt.ParticipantID == "" {
+		// Anonymous learners are told apart by their attempt
+		actor.Account.Name = "anonymous:" + attempt.ID
+	}
+	project := Activity{
+		ObjectType: "Activity",
+		ID:         b.baseURL + "/xapi/activities/projects/" + attempt.ProjectID,
+		Definition: Definition{Type: ActivityTypeAssessment},
+	}
+	timestamp := attempt.SubmittedAt.UTC()
+
+	completed := true
+	statements := make([]Statement, 0, len(attempt.Results)+1)
+	statements = append(statements, Statement{
+		ID:        StatementID(attempt.ID, ""),
+		Actor:     actor,
+		Verb:      VerbCompleted,
+		Object:    project,
+		Result:    &Result{Score: score(attempt.Score, attempt.MaxScore), Completion: &completed},
+		Context:   &Context{Registration: attempt.ID},
+		Timestamp: timestamp,
+	})
+
+	for _, result := range attempt.Results {
+		success := result.Correct
+		statements = append(statements, Statement{
+			ID:    StatementID(attempt.ID, result.ItemID),
+			Actor: actor,
+			Verb:  VerbAnswered,
+			Object: Activity{
+				ObjectType: "Activity",
+				ID:         project.ID + "/items/" + result.ItemID,
+				Definition: Definition{Type: ActivityTypeInteraction},
+			},
+			Result: &Result{Score: score(&result.Earned, &result.Possible), Success: &success},
+			Context: &Context{
+				Registration:      attempt.ID,
+				ContextActivities: &ContextActivities{Parent: []Activity{project}},
+			},
+			Timestamp: timestamp,
+		})
+	}
+	return statements
+}
+
+// score returns the score of earned points out of possible, nil when
+// either is unknown
+func score(earned, possible *int) *Score {
+	if earned == nil || possible == nil {
+		return nil
+	}
+	s := &Score{Raw: *earned, Max: *possible}
+	if *possible > 0 {
+		s.Scaled = float64(*earned) / float64(*possible)
+	}
+	return s
+}
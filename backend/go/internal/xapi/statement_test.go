@@ -0,0 +1,99 @@
+package xapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+)
+
+// submittedAttempt returns a submitted attempt at "project-1" scoring 3 out
+// of 5 over two items
+func submittedAttempt(id, participantID string) *core.Attempt {
+	submittedAt := time.Date(2026, 5, 4, 10, 30, 0, 0, time.UTC)
+	score, maxScore := 3, 5
+	return &core.Attempt{
+		ID:            id,
+		ProjectID:     "project-1",
+		ParticipantID: participantID,
+		StartedAt:     submittedAt.Add(-10 * time.Minute),
+		SubmittedAt:   &submittedAt,
+		Score:         &score,
+		MaxScore:      &maxScore,
+		Results: []*core.ItemResult{
+			{ItemID: "item-1", Earned: 3, Possible: 3, Correct: true},
+			{ItemID: "item-2", Earned: 0, Possible: 2},
+		},
+	}
+}
+
+func TestBuilder_Statements(t *testing.T) {
+	// Arrange
+	builder := NewBuilder("https://api.example.com/")
+	attempt := submittedAttempt("attempt-1", "learner-1")
+
+	// Act
+	statements := builder.Statements(attempt)
+
+	// Assert
+	require.Len(t, statements, 3)
+	project := "https://api.example.com/xapi/activities/projects/project-1"
+
+	completed := statements[0]
+	assert.Equal(t, StatementID("attempt-1", ""), completed.ID)
+	assert.Equal(t, Account{HomePage: "https://api.example.com", Name: "learner-1"}, completed.Actor.Account)
+	assert.Equal(t, VerbCompleted, completed.Verb)
+	assert.Equal(t, project, completed.Object.ID)
+	assert.Equal(t, &Score{Scaled: 0.6, Raw: 3, Max: 5}, completed.Result.Score)
+	assert.Equal(t, "attempt-1", completed.Context.Registration)
+	assert.Equal(t, *attempt.SubmittedAt, completed.Timestamp)
+
+	for i, result := range attempt.Results {
+		answered := statements[i+1]
+		assert.Equal(t, StatementID("attempt-1", result.ItemID), answered.ID)
+		assert.Equal(t, VerbAnswered, answered.Verb)
+		assert.Equal(t, project+"/items/"+result.ItemID, answered.Object.ID)
+		assert.Equal(t, result.Correct, *answered.Result.Success)
+		assert.Equal(t, result.Earned, answered.Result.Score.Raw)
+		assert.Equal(t, project, answered.Context.ContextActivities.Parent[0].ID)
+	}
+}
+
+func TestBuilder_Statements_AnonymousAndUnsubmitted(t *testing.T) {
+	builder := NewBuilder("https://api.example.com")
+
+	t.Run("anonymous learner", func(t *testing.T) {
+		// Act
+		statements := builder.Statements(submittedAttempt("attempt-1", ""))
+
+		// Assert
+		require.NotEmpty(t, statements)
+		assert.Equal(t, "anonymous:attempt-1", statements[0].Actor.Account.Name)
+	})
+
+	t.Run("not submitted", func(t *testing.T) {
+		// Arrange
+		attempt := submittedAttempt("attempt-1", "learner-1")
+		attempt.SubmittedAt = nil
+
+		// Act
+		statements := builder.Statements(attempt)
+
+		// Assert
+		assert.Empty(t, statements)
+	})
+}
+
+func TestStatementID(t *testing.T) {
+	// Act
+	id := StatementID("attempt-1", "item-1")
+
+	// Assert
+	assert.Equal(t, id, StatementID("attempt-1", "item-1"), "the same attempt and item give the same ID")
+	assert.NotEqual(t, id, StatementID("attempt-1", "item-2"))
+	assert.NotEqual(t, id, StatementID("attempt-2", "item-1"))
+	assert.NotEqual(t, id, StatementID("attempt-1", ""))
+}
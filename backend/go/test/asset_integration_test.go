@@ -0,0 +1,74 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/handlers"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/store"
+)
+
+func TestAssets_SignedURLServesTheAsset(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, err := store.NewProjectStore(database).Create(ctx, "Star Charts", nil, nil)
+	require.NoError(t, err)
+	signer := core.NewAssetURLSigner([]byte("asset-url-secret"))
+	storage := store.NewLocalStorage(t.TempDir(), "/files")
+	storage.SetSigner(signer, "/assets")
+	_, err = storage.Upload(ctx, "projects/"+project.ID+"/assets/star chart.png", strings.NewReader("png"), core.UploadOptions{})
+	require.NoError(t, err)
+	assets := core.NewAssetService(store.NewProjectStore(database), storage, time.Hour)
+	handler := handlers.NewAssetHandler(assets, signer, httpmiddleware.NewValidator())
+	r := chi.NewRouter()
+	r.Get("/assets/*", handler.ServeAsset)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		return rr
+	}
+
+	// Act
+	signedURL, expiresAt, err := assets.SignedURL(ctx, project.ID, "star chart.png", 2*time.Hour)
+	require.NoError(t, err)
+	served := get(signedURL)
+	tampered := get(strings.Replace(signedURL, "star%20chart.png", "other.png", 1))
+	_, _, missingErr := assets.SignedURL(ctx, project.ID, "other.png", time.Minute)
+
+	// Assert
+	assert.True(t, strings.HasPrefix(signedURL, "/assets/projects/"+project.ID+"/assets/star%20chart.png?"), signedURL)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, 2*time.Second, "the duration is capped at the maximum")
+	assert.Equal(t, http.StatusOK, served.Code)
+	assert.Equal(t, "png", served.Body.String())
+	assert.Equal(t, "image/png", served.Header().Get("Content-Type"))
+	assert.Equal(t, http.StatusForbidden, tampered.Code)
+	assert.ErrorIs(t, missingErr, core.ErrFileNotFound)
+}
+
+func TestLocalStorage_GetSignedURL_WithoutSigner(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	storage := store.NewLocalStorage(t.TempDir(), "/files")
+	_, err := storage.Upload(ctx, "projects/p/assets/map.png", strings.NewReader("png"), core.UploadOptions{})
+	require.NoError(t, err)
+
+	// Act
+	_, err = storage.GetSignedURL(ctx, "projects/p/assets/map.png", time.Minute)
+
+	// Assert
+	assert.Error(t, err, "unsigned URLs are not handed out as signed ones")
+}
@@ -0,0 +1,1105 @@
+//go:build integration
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+	"github.com/provemyself/backend/internal/xapi"
+)
+
+// publishedQuiz creates and publishes a project of an organization with
+// one choice item, and returns the project and the item
+func publishedQuiz(t *testing.T, ctx context.Context, database *store.Database) (*core.Project, *core.Item) {
+	t.Helper()
+	projects := store.NewProjectStore(database)
+	items := store.NewItemStore(database)
+	org, err := store.NewOrganizationStore(database).Create(ctx, "Observatory", nil)
+	require.NoError(t, err)
+	orgCtx := store.SystemScope(core.WithOrgID(ctx, org.ID))
+
+	project, err := projects.Create(orgCtx, "Star Charts", nil, nil)
+	require.NoError(t, err)
+	item, err := items.Create(orgCtx, project.ID, types.ItemTypeChoice, "Brightest star?", json.RawMessage(`{"choices":[{"id":"a","text":"Sirius","correct":true},{"id":"b","text":"Vega"}]}`), 0, true, intPtr(1), nil)
+	require.NoError(t, err)
+	_, err = projects.Publish(orgCtx, project.ID)
+	require.NoError(t, err)
+	return project, item
+}
+
+// projectOrgScope returns ctx scoped to the organization of a published
+// project, standing in for its authors' requests
+func projectOrgScope(t *testing.T, ctx context.Context, database *store.Database, projectID string) context.Context {
+	t.Helper()
+	orgID, err := store.NewProjectStore(database).PublishedOrgID(ctx, projectID)
+	require.NoError(t, err)
+	return store.SystemScope(core.WithOrgID(ctx, orgID))
+}
+
+// asLearner returns ctx acting for the signed-in learner userID
+func asLearner(ctx context.Context, userID string) context.Context {
+	return core.WithAccessScope(ctx, core.AccessScope{UserID: userID})
+}
+
+func newAttemptService(database *store.Database) *core.AttemptService {
+	return core.NewAttemptService(store.NewAttemptStore(database), store.NewProjectStore(database), store.NewItemStore(database))
+}
+
+func TestAttemptService_Lifecycle(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, item := publishedQuiz(t, ctx, database)
+	service := newAttemptService(database)
+
+	// Act
+	learnerCtx := asLearner(ctx, "learner-1")
+	attempt, err := service.Start(learnerCtx, project.ID, "learner-1")
+	require.NoError(t, err)
+	_, err = service.SaveAnswer(learnerCtx, attempt.ID, item.ID, json.RawMessage(`{"choice_id":"b"}`), 0)
+	require.NoError(t, err)
+	answer, err := service.SaveAnswer(learnerCtx, attempt.ID, item.ID, json.RawMessage(`{"choice_id":"a"}`), 0)
+	require.NoError(t, err)
+	_, otherSubmitErr := service.Submit(asLearner(ctx, "learner-2"), attempt.ID)
+	_, anonymousGetErr := service.Get(ctx, attempt.ID)
+	submitted, submitErr := service.Submit(learnerCtx, attempt.ID)
+	_, resubmitErr := service.Submit(learnerCtx, attempt.ID)
+	_, lateErr := service.SaveAnswer(learnerCtx, attempt.ID, item.ID, json.RawMessage(`{"choice_id":"b"}`), 0)
+	stored, getErr := service.Get(learnerCtx, attempt.ID)
+
+	// Assert
+	assert.Equal(t, project.ID, attempt.ProjectID)
+	assert.Equal(t, 1, attempt.PublicationVersion)
+	assert.Equal(t, "learner-1", attempt.ParticipantID)
+	assert.False(t, attempt.StartedAt.IsZero())
+	assert.Nil(t, attempt.SubmittedAt)
+	assert.JSONEq(t, `{"choice_id":"a"}`, string(answer.Response))
+
+	assert.ErrorIs(t, otherSubmitErr, core.ErrAttemptNotFound, "another learner can't submit the attempt")
+	assert.ErrorIs(t, anonymousGetErr, core.ErrAttemptNotFound, "nor can anyone without a token read it")
+
+	require.NoError(t, submitErr)
+	require.NotNil(t, submitted.SubmittedAt)
+	require.Len(t, submitted.Answers, 1, "a second answer replaces the first")
+	assert.JSONEq(t, `{"choice_id":"a"}`, string(submitted.Answers[0].Response))
+
+	assert.ErrorIs(t, resubmitErr, core.ErrAttemptAlreadySubmitted)
+	assert.ErrorIs(t, lateErr, core.ErrAttemptAlreadySubmitted)
+
+	require.NoError(t, getErr)
+	assert.Equal(t, submitted.SubmittedAt, stored.SubmittedAt)
+	require.Len(t, stored.Answers, 1)
+	assert.JSONEq(t, `{"choice_id":"a"}`, string(stored.Answers[0].Response))
+}
+
+func TestAttemptService_SaveAnswer_RefusesStaleSaves(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, item := publishedQuiz(t, ctx, database)
+	service := newAttemptService(database)
+	attempt, err := service.Start(ctx, project.ID, "")
+	require.NoError(t, err)
+
+	// Act
+	latest, err := service.SaveAnswer(ctx, attempt.ID, item.ID, json.RawMessage(`{"choice_id":"a"}`), 2)
+	require.NoError(t, err)
+	_, staleErr := service.SaveAnswer(ctx, attempt.ID, item.ID, json.RawMessage(`{"choice_id":"b"}`), 1)
+	retried, retryErr := service.SaveAnswer(ctx, attempt.ID, item.ID, json.RawMessage(`{"choice_id":"a"}`), 2)
+	unnumbered, unnumberedErr := service.SaveAnswer(ctx, attempt.ID, item.ID, json.RawMessage(`{"choice_id":"b"}`), 0)
+	stored, getErr := service.Get(ctx, attempt.ID)
+
+	// Assert
+	assert.Equal(t, int64(2), latest.Sequence)
+	assert.ErrorIs(t, staleErr, core.ErrStaleAnswer)
+	require.NoError(t, retryErr, "a save numbered as the stored one is a retry")
+	assert.Equal(t, int64(2), retried.Sequence)
+	require.NoError(t, unnumberedErr, "saves that aren't numbered are always kept")
+	assert.Equal(t, int64(0), unnumbered.Sequence)
+
+	require.NoError(t, getErr)
+	require.Len(t, stored.Answers, 1)
+	assert.JSONEq(t, `{"choice_id":"b"}`, string(stored.Answers[0].Response))
+}
+
+func TestAttemptService_Submit_KeepsTheGrades(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+	items := store.NewItemStore(database)
+	project, err := projects.Create(ctx, "Star Charts", nil, nil)
+	require.NoError(t, err)
+	_, err = items.Create(ctx, project.ID, types.ItemTypeTitle, "Welcome", json.RawMessage(`{"text":"Stars"}`), 0, false, nil, nil)
+	require.NoError(t, err)
+	choice, err := items.Create(ctx, project.ID, types.ItemTypeChoice, "Brightest star?", json.RawMessage(`{"choices":[{"id":"a","text":"Sirius","correct":true},{"id":"b","text":"Vega"}]}`), 1, true, intPtr(2), stringPtr("Sirius outshines every other star"))
+	require.NoError(t, err)
+	ordering, err := items.Create(ctx, project.ID, types.ItemTypeOrdering, "Nearest first", json.RawMessage(`{"items":[{"id":"1","text":"Sirius","correct_order":2},{"id":"2","text":"Alpha Centauri","correct_order":1}]}`), 2, true, intPtr(3), nil)
+	require.NoError(t, err)
+	entry, err := items.Create(ctx, project.ID, types.ItemTypeTextEntry, "Our star?", json.RawMessage(`{"multiline":false,"correct_answer":"the Sun"}`), 3, true, intPtr(1), nil)
+	require.NoError(t, err)
+	_, err = projects.Publish(ctx, project.ID)
+	require.NoError(t, err)
+	service := newAttemptService(database)
+	attempt, err := service.Start(ctx, project.ID, "")
+	require.NoError(t, err)
+	_, err = service.SaveAnswer(ctx, attempt.ID, choice.ID, json.RawMessage(`{"choice_id":"a"}`), 0)
+	require.NoError(t, err)
+	_, err = service.SaveAnswer(ctx, attempt.ID, ordering.ID, json.RawMessage(`{"order":["1","2"]}`), 0)
+	require.NoError(t, err)
+
+	// Act
+	submitted, err := service.Submit(ctx, attempt.ID)
+	require.NoError(t, err)
+	stored, getErr := service.Get(ctx, attempt.ID)
+
+	// Assert
+	require.NotNil(t, submitted.Score)
+	assert.Equal(t, 2, *submitted.Score)
+	assert.Equal(t, 6, *submitted.MaxScore)
+	require.Len(t, submitted.Results, 3, "the title is not graded")
+	assert.Equal(t, core.ItemResult{ItemID: choice.ID, Earned: 2, Possible: 2, Correct: true, Explanation: stringPtr("Sirius outshines every other star")}, *submitted.Results[0])
+	assert.Equal(t, core.ItemResult{ItemID: ordering.ID, Earned: 0, Possible: 3, Correct: false}, *submitted.Results[1])
+	assert.Equal(t, core.ItemResult{ItemID: entry.ID, Earned: 0, Possible: 1, Correct: false}, *submitted.Results[2], "an unanswered question earns nothing")
+
+	require.NoError(t, getErr)
+	assert.Equal(t, 2, *stored.Score)
+	assert.Equal(t, 6, *stored.MaxScore)
+	require.Len(t, stored.Results, 3)
+	for i, result := range stored.Results {
+		assert.Equal(t, submitted.Results[i].ItemID, result.ItemID)
+		assert.Equal(t, submitted.Results[i].Earned, result.Earned)
+		assert.Equal(t, submitted.Results[i].Correct, result.Correct)
+	}
+}
+
+func TestAttemptService_AnonymousAttempt(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, _ := publishedQuiz(t, ctx, database)
+	service := newAttemptService(database)
+
+	// Act
+	attempt, err := service.Start(ctx, project.ID, "")
+	require.NoError(t, err)
+	stored, err := service.Get(ctx, attempt.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, stored.ParticipantID)
+	assert.Empty(t, stored.Answers)
+}
+
+func TestAttemptService_Start_UnpublishedProject(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	draft, err := store.NewProjectStore(database).Create(ctx, "Comet Orbits", nil, nil)
+	require.NoError(t, err)
+	service := newAttemptService(database)
+
+	// Act
+	_, draftErr := service.Start(ctx, draft.ID, "")
+	_, missingErr := service.Start(ctx, uuid.NewString(), "")
+
+	// Assert
+	assert.ErrorIs(t, draftErr, core.ErrProjectNotFound)
+	assert.ErrorIs(t, missingErr, core.ErrProjectNotFound)
+}
+
+func TestAttemptService_SaveAnswer_ChecksTheAnswer(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, item := publishedQuiz(t, ctx, database)
+	service := newAttemptService(database)
+	attempt, err := service.Start(ctx, project.ID, "")
+	require.NoError(t, err)
+
+	// Act
+	_, invalidErr := service.SaveAnswer(ctx, attempt.ID, item.ID, json.RawMessage(`{"choice_id":"z"}`), 0)
+	_, itemErr := service.SaveAnswer(ctx, attempt.ID, uuid.NewString(), json.RawMessage(`{"choice_id":"a"}`), 0)
+	_, attemptErr := service.SaveAnswer(ctx, uuid.NewString(), item.ID, json.RawMessage(`{"choice_id":"a"}`), 0)
+	_, submitErr := service.Submit(ctx, uuid.NewString())
+	stored, err := service.Get(ctx, attempt.ID)
+
+	// Assert
+	assert.ErrorIs(t, invalidErr, core.ErrInvalidAnswer)
+	assert.ErrorIs(t, itemErr, core.ErrItemNotFound)
+	assert.ErrorIs(t, attemptErr, core.ErrAttemptNotFound)
+	assert.ErrorIs(t, submitErr, core.ErrAttemptNotFound)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Answers)
+}
+
+func TestAttemptStore_ConcurrentSubmitsSubmitOnce(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, item := publishedQuiz(t, ctx, database)
+	attempts := store.NewAttemptStore(database)
+	attempt, err := attempts.Create(ctx, &core.Attempt{ProjectID: project.ID, PublicationVersion: 1})
+	require.NoError(t, err)
+
+	// Act
+	const callers = 5
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = attempts.Submit(ctx, attempt.ID, []*core.ItemResult{{ItemID: item.ID, Earned: i, Possible: callers}})
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert
+	submitted := 0
+	for _, err := range errs {
+		if err == nil {
+			submitted++
+			continue
+		}
+		assert.ErrorIs(t, err, core.ErrAttemptAlreadySubmitted)
+	}
+	assert.Equal(t, 1, submitted)
+	stored, err := attempts.Get(ctx, attempt.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored.Results, 1, "only the submit that won keeps its results")
+}
+
+func TestAttemptService_Stats(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+	items := store.NewItemStore(database)
+	project, err := projects.Create(ctx, "Star Charts", nil, nil)
+	require.NoError(t, err)
+	choice, err := items.Create(ctx, project.ID, types.ItemTypeChoice, "Brightest star?", json.RawMessage(`{"choices":[{"id":"a","text":"Sirius","correct":true},{"id":"b","text":"Vega"}]}`), 0, true, intPtr(2), nil)
+	require.NoError(t, err)
+	multiChoice, err := items.Create(ctx, project.ID, types.ItemTypeMultiChoice, "Giant planets?", json.RawMessage(`{"choices":[{"id":"a","text":"Jupiter","correct":true},{"id":"b","text":"Mars"},{"id":"c","text":"Saturn","correct":true}]}`), 1, true, intPtr(1), nil)
+	require.NoError(t, err)
+	_, err = projects.Publish(ctx, project.ID)
+	require.NoError(t, err)
+	service := newAttemptService(database)
+
+	take := func(participantID string, answers map[string]string, submit bool) {
+		learnerCtx := asLearner(ctx, participantID)
+		attempt, err := service.Start(learnerCtx, project.ID, participantID)
+		require.NoError(t, err)
+		for itemID, response := range answers {
+			_, err := service.SaveAnswer(learnerCtx, attempt.ID, itemID, json.RawMessage(response), 0)
+			require.NoError(t, err)
+		}
+		if submit {
+			_, err := service.Submit(learnerCtx, attempt.ID)
+			require.NoError(t, err)
+		}
+	}
+	take("learner-1", map[string]string{choice.ID: `{"choice_id":"a"}`, multiChoice.ID: `{"choice_ids":["c","a"]}`}, true)
+	take("learner-2", map[string]string{choice.ID: `{"choice_id":"b"}`, multiChoice.ID: `{"choice_ids":["a"]}`}, true)
+	take("", map[string]string{choice.ID: `{"choice_id":"b"}`}, false)
+
+	// Act
+	stats, err := service.Stats(ctx, project.ID, core.AttemptFilter{})
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, 3, stats.Attempts)
+	assert.Equal(t, 2, stats.Submitted)
+	require.NotNil(t, stats.AverageScore)
+	assert.InDelta(t, 1.5, *stats.AverageScore, 0.001)
+	require.Len(t, stats.Items, 2, "the items come in their order")
+
+	assert.Equal(t, choice.ID, stats.Items[0].ItemID)
+	assert.Equal(t, 2, stats.Items[0].Answered, "the unfinished attempt's answer doesn't count")
+	assert.Equal(t, 2, stats.Items[0].Graded)
+	assert.InDelta(t, 50, stats.Items[0].PercentCorrect, 0.001)
+	assert.InDelta(t, 1, stats.Items[0].AveragePoints, 0.001)
+	assert.Equal(t, []*core.ChoiceCount{{ChoiceID: "a", Count: 1}, {ChoiceID: "b", Count: 1}}, stats.Items[0].Choices)
+
+	assert.Equal(t, multiChoice.ID, stats.Items[1].ItemID)
+	assert.Equal(t, 2, stats.Items[1].Answered)
+	assert.InDelta(t, 50, stats.Items[1].PercentCorrect, 0.001)
+	assert.InDelta(t, 0.5, stats.Items[1].AveragePoints, 0.001)
+	assert.Equal(t, []*core.ChoiceCount{{ChoiceID: "a", Count: 2}, {ChoiceID: "c", Count: 1}}, stats.Items[1].Choices)
+}
+
+func TestAttemptService_Stats_Window(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, item := publishedQuiz(t, ctx, database)
+	service := newAttemptService(database)
+	attempt, err := service.Start(ctx, project.ID, "")
+	require.NoError(t, err)
+	_, err = service.SaveAnswer(ctx, attempt.ID, item.ID, json.RawMessage(`{"choice_id":"a"}`), 0)
+	require.NoError(t, err)
+	_, err = service.Submit(ctx, attempt.ID)
+	require.NoError(t, err)
+	authorCtx := projectOrgScope(t, ctx, database, project.ID)
+	lastHour := attempt.StartedAt.Add(-time.Hour)
+	nextHour := attempt.StartedAt.Add(time.Hour)
+
+	// Act
+	within, withinErr := service.Stats(authorCtx, project.ID, core.AttemptFilter{From: &lastHour, To: &nextHour})
+	after, afterErr := service.Stats(authorCtx, project.ID, core.AttemptFilter{From: &nextHour})
+	before, beforeErr := service.Stats(authorCtx, project.ID, core.AttemptFilter{To: &lastHour})
+
+	// Assert
+	require.NoError(t, withinErr)
+	assert.Equal(t, 1, within.Attempts)
+	require.Len(t, within.Items, 1)
+	assert.Equal(t, []*core.ChoiceCount{{ChoiceID: "a", Count: 1}}, within.Items[0].Choices)
+
+	for _, stats := range []*core.ProjectStats{after, before} {
+		assert.Equal(t, 0, stats.Attempts)
+		assert.Equal(t, 0, stats.Submitted)
+		assert.Nil(t, stats.AverageScore)
+		assert.NotNil(t, stats.Items)
+		assert.Empty(t, stats.Items)
+	}
+	require.NoError(t, afterErr)
+	require.NoError(t, beforeErr)
+}
+
+func TestAttemptService_Stats_NoAttempts(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, err := store.NewProjectStore(database).Create(ctx, "Comet Orbits", nil, nil)
+	require.NoError(t, err)
+	service := newAttemptService(database)
+
+	// Act
+	stats, err := service.Stats(ctx, project.ID, core.AttemptFilter{})
+	_, missingErr := service.Stats(ctx, uuid.NewString(), core.AttemptFilter{})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, &core.ProjectStats{Items: []*core.ItemStats{}}, stats)
+	assert.ErrorIs(t, missingErr, core.ErrProjectNotFound)
+}
+
+func TestAttemptService_ListByProject(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, item := publishedQuiz(t, ctx, database)
+	other, _ := publishedQuiz(t, ctx, database)
+	service := newAttemptService(database)
+	var started []string
+	for _, participantID := range []string{"learner-1", "learner-2", "learner-3"} {
+		attempt, err := service.Start(ctx, project.ID, participantID)
+		require.NoError(t, err)
+		started = append(started, attempt.ID)
+	}
+	_, err := service.SaveAnswer(asLearner(ctx, "learner-1"), started[0], item.ID, json.RawMessage(`{"choice_id":"a"}`), 0)
+	require.NoError(t, err)
+	_, err = service.Submit(asLearner(ctx, "learner-1"), started[0])
+	require.NoError(t, err)
+	_, err = service.Start(ctx, other.ID, "learner-1")
+	require.NoError(t, err)
+	authorCtx := projectOrgScope(t, ctx, database, project.ID)
+
+	// Act
+	page, total, err := service.ListByProject(authorCtx, project.ID, core.AttemptFilter{}, 2, 0)
+	require.NoError(t, err)
+	rest, _, restErr := service.ListByProject(authorCtx, project.ID, core.AttemptFilter{}, 2, 2)
+	_, _, missingErr := service.ListByProject(authorCtx, uuid.NewString(), core.AttemptFilter{}, 2, 0)
+	_, _, otherOrgErr := service.ListByProject(projectOrgScope(t, ctx, database, other.ID), project.ID, core.AttemptFilter{}, 2, 0)
+
+	// Assert
+	assert.Equal(t, 3, total, "the other project's attempt doesn't count")
+	require.NoError(t, restErr)
+	listed := append(page, rest...)
+	require.Len(t, listed, 3)
+	ids := make([]string, len(listed))
+	for i, attempt := range listed {
+		ids[i] = attempt.ID
+		if attempt.ID == started[0] {
+			require.NotNil(t, attempt.SubmittedAt)
+			assert.Equal(t, 1, *attempt.Score)
+			assert.Equal(t, 1, *attempt.MaxScore)
+			assert.Equal(t, "learner-1", attempt.ParticipantID)
+		}
+	}
+	assert.ElementsMatch(t, started, ids)
+	assert.ErrorIs(t, missingErr, core.ErrProjectNotFound)
+	assert.ErrorIs(t, otherOrgErr, core.ErrProjectNotFound, "another organization's authors don't see the attempts")
+}
+
+func TestAttemptService_Preview(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	org, err := store.NewOrganizationStore(database).Create(ctx, "Observatory", nil)
+	require.NoError(t, err)
+	orgCtx := store.SystemScope(core.WithOrgID(ctx, org.ID))
+	project, err := store.NewProjectStore(database).Create(orgCtx, "Star Charts (draft)", nil, nil)
+	require.NoError(t, err)
+	item, err := store.NewItemStore(database).Create(orgCtx, project.ID, types.ItemTypeChoice, "Brightest star?", json.RawMessage(`{"choices":[{"id":"a","text":"Sirius","correct":true},{"id":"b","text":"Vega"}]}`), 0, true, intPtr(1), nil)
+	require.NoError(t, err)
+	// The author's token vouches for the organization; answering goes
+	// through the public endpoints, which aren't scoped to it
+	authorCtx := core.WithAccessScope(ctx, core.AccessScope{UserID: "author-1", OrgID: org.ID})
+	service := newAttemptService(database)
+
+	// Act
+	preview, err := service.StartPreview(core.WithOrgID(authorCtx, org.ID), project.ID, "author-1")
+	require.NoError(t, err)
+	_, saveErr := service.SaveAnswer(authorCtx, preview.ID, item.ID, json.RawMessage(`{"choice_id":"a"}`), 0)
+	_, strangerErr := service.SaveAnswer(asLearner(ctx, "learner-1"), preview.ID, item.ID, json.RawMessage(`{"choice_id":"b"}`), 0)
+	submitted, submitErr := service.Submit(authorCtx, preview.ID)
+	_, startErr := service.Start(asLearner(ctx, "learner-1"), project.ID, "learner-1")
+	stats, statsErr := service.Stats(orgCtx, project.ID, core.AttemptFilter{})
+	_, total, listErr := service.ListByProject(orgCtx, project.ID, core.AttemptFilter{}, 20, 0)
+
+	// Assert
+	assert.True(t, preview.IsPreview)
+	assert.Equal(t, "author-1", preview.ParticipantID)
+	assert.Equal(t, 0, preview.PublicationVersion, "the preview takes the live items")
+	require.NoError(t, saveErr, "a draft can be previewed")
+	assert.ErrorIs(t, strangerErr, core.ErrAttemptNotFound)
+	require.NoError(t, submitErr)
+	assert.True(t, submitted.IsPreview)
+	assert.Equal(t, 1, *submitted.Score)
+	assert.ErrorIs(t, startErr, core.ErrProjectNotFound, "learners still can't take the draft")
+	require.NoError(t, statsErr)
+	assert.Zero(t, stats.Attempts, "previews aren't counted")
+	assert.Empty(t, stats.Items)
+	require.NoError(t, listErr)
+	assert.Zero(t, total, "previews aren't listed")
+}
+
+func TestAttemptStore_PurgePreviews(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, item := publishedQuiz(t, ctx, database)
+	attempts := store.NewAttemptStore(database)
+	preview, err := attempts.Create(ctx, &core.Attempt{ProjectID: project.ID, ParticipantID: "author-1", IsPreview: true})
+	require.NoError(t, err)
+	_, err = attempts.SaveAnswer(ctx, &core.Answer{AttemptID: preview.ID, ItemID: item.ID, Response: json.RawMessage(`{"choice_id":"a"}`)})
+	require.NoError(t, err)
+	_, err = attempts.Submit(ctx, preview.ID, []*core.ItemResult{{ItemID: item.ID, Earned: 1, Possible: 1, Correct: true}})
+	require.NoError(t, err)
+	attempt, err := attempts.Create(ctx, &core.Attempt{ProjectID: project.ID, PublicationVersion: 1, ParticipantID: "learner-1"})
+	require.NoError(t, err)
+
+	// Act
+	keptDeleted, keptErr := attempts.PurgePreviews(ctx, preview.StartedAt.Add(-time.Minute))
+	deleted, err := attempts.PurgePreviews(ctx, time.Now().Add(time.Minute))
+
+	// Assert
+	require.NoError(t, keptErr)
+	assert.Zero(t, keptDeleted, "previews started after the cutoff are kept")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, err = attempts.Get(ctx, preview.ID)
+	assert.ErrorIs(t, err, core.ErrAttemptNotFound)
+	_, err = attempts.Get(ctx, attempt.ID)
+	assert.NoError(t, err, "learners' attempts are never purged")
+}
+
+// erasureFixture starts, in a published quiz, a submitted attempt by
+// learner-1 with its answer a year ago, an unfinished one by learner-1
+// now, one by learner-2 a year ago, and an anonymous one a year ago. It
+// returns the attempts by name and when a year ago was.
+func erasureFixture(t *testing.T, ctx context.Context, database *store.Database) (map[string]*core.Attempt, time.Time) {
+	t.Helper()
+	project, item := publishedQuiz(t, ctx, database)
+	attempts := store.NewAttemptStore(database)
+	yearAgo := time.Now().AddDate(-1, 0, 0).UTC().Truncate(time.Second)
+
+	started := map[string]*core.Attempt{}
+	for _, seed := range []struct {
+		name          string
+		participantID string
+		old           bool
+	}{
+		{"old", "learner-1", true},
+		{"recent", "learner-1", false},
+		{"other", "learner-2", true},
+		{"anonymous", "", true},
+	} {
+		attempt, err := attempts.Create(ctx, &core.Attempt{ProjectID: project.ID, PublicationVersion: 1, ParticipantID: seed.participantID})
+		require.NoError(t, err)
+		if seed.old {
+			_, err = database.Exec(ctx, "attempts.backdate", `UPDATE attempts SET started_at = $2 WHERE id = $1`, attempt.ID, yearAgo)
+			require.NoError(t, err)
+		}
+		started[seed.name] = attempt
+	}
+	_, err := attempts.SaveAnswer(ctx, &core.Answer{AttemptID: started["old"].ID, ItemID: item.ID, Response: json.RawMessage(`{"choice_id":"a"}`)})
+	require.NoError(t, err)
+	_, err = attempts.Submit(ctx, started["old"].ID, []*core.ItemResult{{ItemID: item.ID, Earned: 1, Possible: 1, Correct: true}})
+	require.NoError(t, err)
+	return started, yearAgo
+}
+
+func TestAttemptStore_EraseAttempts(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection func(yearAgo time.Time) core.AttemptSelection
+		mode      core.RetentionMode
+		expected  *core.ErasureReport
+		// erased are the attempts deleted or anonymized
+		erased []string
+	}{
+		{
+			name:      "delete a participant's",
+			selection: func(time.Time) core.AttemptSelection { return core.AttemptSelection{ParticipantID: "learner-1"} },
+			mode:      core.RetentionModeDelete,
+			expected:  &core.ErasureReport{Attempts: 2, Answers: 1, Results: 1},
+			erased:    []string{"old", "recent"},
+		},
+		{
+			name:      "anonymize a participant's",
+			selection: func(time.Time) core.AttemptSelection { return core.AttemptSelection{ParticipantID: "learner-1"} },
+			mode:      core.RetentionModeAnonymize,
+			expected:  &core.ErasureReport{Attempts: 2},
+			erased:    []string{"old", "recent"},
+		},
+		{
+			name: "delete the old",
+			selection: func(yearAgo time.Time) core.AttemptSelection {
+				cutoff := yearAgo.Add(time.Hour)
+				return core.AttemptSelection{StartedBefore: &cutoff}
+			},
+			mode:     core.RetentionModeDelete,
+			expected: &core.ErasureReport{Attempts: 3, Answers: 1, Results: 1},
+			erased:   []string{"old", "other", "anonymous"},
+		},
+		{
+			name: "anonymize the old",
+			selection: func(yearAgo time.Time) core.AttemptSelection {
+				cutoff := yearAgo.Add(time.Hour)
+				return core.AttemptSelection{StartedBefore: &cutoff}
+			},
+			mode:     core.RetentionModeAnonymize,
+			expected: &core.ErasureReport{Attempts: 2},
+			erased:   []string{"old", "other"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			database := migratedDatabase(t, ctx)
+			started, yearAgo := erasureFixture(t, ctx, database)
+			attempts := store.NewAttemptStore(database)
+
+			// Act
+			report, err := attempts.EraseAttempts(ctx, tt.selection(yearAgo), tt.mode, core.ErasureBatchSize)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, report)
+			for name, attempt := range started {
+				kept, err := attempts.Get(ctx, attempt.ID)
+				erased := slices.Contains(tt.erased, name)
+				switch {
+				case erased && tt.mode == core.RetentionModeDelete:
+					assert.ErrorIs(t, err, core.ErrAttemptNotFound, name)
+				case erased:
+					require.NoError(t, err, name)
+					assert.Empty(t, kept.ParticipantID, name)
+				default:
+					require.NoError(t, err, name)
+					assert.Equal(t, attempt.ParticipantID, kept.ParticipantID, name)
+				}
+			}
+			if tt.mode == core.RetentionModeAnonymize && slices.Contains(tt.erased, "old") {
+				old, err := attempts.Get(ctx, started["old"].ID)
+				require.NoError(t, err)
+				assert.Len(t, old.Answers, 1, "anonymizing keeps the answers")
+				require.NotNil(t, old.Score, "and the score")
+				assert.Equal(t, 1, *old.Score)
+			}
+		})
+	}
+}
+
+func TestAttemptService_EraseParticipant_Batches(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, _ := publishedQuiz(t, ctx, database)
+	attempts := store.NewAttemptStore(database)
+	total := core.ErasureBatchSize + 2
+	for i := 0; i < total; i++ {
+		_, err := attempts.Create(ctx, &core.Attempt{ProjectID: project.ID, PublicationVersion: 1, ParticipantID: "learner-1"})
+		require.NoError(t, err)
+	}
+	_, err := attempts.Create(ctx, &core.Attempt{ProjectID: project.ID, PublicationVersion: 1, ParticipantID: "learner-2"})
+	require.NoError(t, err)
+	service := newAttemptService(database)
+
+	// Act
+	report, err := service.EraseParticipant(asLearner(ctx, "learner-1"), "learner-1", core.RetentionModeDelete)
+	_, deniedErr := service.EraseParticipant(asLearner(ctx, "learner-1"), "learner-2", core.RetentionModeDelete)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(total), report.Attempts, "every batch is erased")
+	assert.ErrorIs(t, deniedErr, core.ErrParticipantAccessDenied)
+	_, left, err := attempts.ListByProject(ctx, project.ID, core.AttemptFilter{}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, left, "other participants' attempts are kept")
+}
+
+// submittedFixture submits, in two published quizzes, three attempts at the
+// first, one started a year ago, and one at the second, next to an attempt
+// not submitted and a submitted preview. It returns the first quiz and when
+// the old attempt started.
+func submittedFixture(t *testing.T, ctx context.Context, database *store.Database) (*core.Project, time.Time) {
+	t.Helper()
+	project, item := publishedQuiz(t, ctx, database)
+	other, otherItem := publishedQuiz(t, ctx, database)
+	attempts := store.NewAttemptStore(database)
+	yearAgo := time.Now().AddDate(-1, 0, 0).UTC().Truncate(time.Second)
+
+	for i, seed := range []struct {
+		project   *core.Project
+		item      *core.Item
+		preview   bool
+		submitted bool
+	}{
+		{project, item, false, true},
+		{project, item, false, true},
+		{project, item, false, true},
+		{other, otherItem, false, true},
+		{project, item, false, false},
+		{project, item, true, true},
+	} {
+		version := 1
+		if seed.preview {
+			version = 0
+		}
+		attempt, err := attempts.Create(ctx, &core.Attempt{ProjectID: seed.project.ID, PublicationVersion: version, ParticipantID: "learner-1", IsPreview: seed.preview})
+		require.NoError(t, err)
+		if i == 0 {
+			_, err = database.Exec(ctx, "attempts.backdate", `UPDATE attempts SET started_at = $2 WHERE id = $1`, attempt.ID, yearAgo)
+			require.NoError(t, err)
+		}
+		if seed.submitted {
+			_, err = attempts.Submit(ctx, attempt.ID, []*core.ItemResult{{ItemID: seed.item.ID, Earned: 1, Possible: 1, Correct: true}})
+			require.NoError(t, err)
+		}
+	}
+	return project, yearAgo
+}
+
+func TestAttemptStore_ListSubmitted(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, yearAgo := submittedFixture(t, ctx, database)
+	attempts := store.NewAttemptStore(database)
+	recent := yearAgo.Add(time.Hour)
+
+	// Act
+	all, allErr := attempts.ListSubmitted(ctx, "", core.AttemptFilter{}, "", 10)
+	firstPage, firstErr := attempts.ListSubmitted(ctx, project.ID, core.AttemptFilter{}, "", 2)
+	var secondPage []*core.Attempt
+	var secondErr error
+	if len(firstPage) == 2 {
+		secondPage, secondErr = attempts.ListSubmitted(ctx, project.ID, core.AttemptFilter{}, firstPage[1].ID, 2)
+	}
+	windowed, windowErr := attempts.ListSubmitted(ctx, project.ID, core.AttemptFilter{From: &recent}, "", 10)
+
+	// Assert
+	require.NoError(t, allErr)
+	require.Len(t, all, 4, "attempts not submitted and previews are left out")
+	assert.True(t, slices.IsSortedFunc(all, func(a, b *core.Attempt) int {
+		return strings.Compare(a.ID, b.ID)
+	}), "attempts come in ID order")
+	for _, attempt := range all {
+		assert.NotNil(t, attempt.SubmittedAt)
+		require.Len(t, attempt.Results, 1, "each attempt comes with its results")
+		assert.True(t, attempt.Results[0].Correct)
+	}
+	require.NoError(t, firstErr)
+	require.NoError(t, secondErr)
+	assert.Len(t, firstPage, 2)
+	assert.Len(t, secondPage, 1, "the next page starts after the last ID")
+	require.NoError(t, windowErr)
+	assert.Len(t, windowed, 2, "the attempt started a year ago is out of the window")
+}
+
+func TestExporter_Write_StableStatementIDs(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	submittedFixture(t, ctx, database)
+	exporter := xapi.NewExporter(store.NewAttemptStore(database), xapi.NewBuilder("https://api.example.com"), xapi.NewClient("", "", nil))
+	export := func() []byte {
+		var buf bytes.Buffer
+		require.NoError(t, exporter.Write(ctx, &buf, xapi.ExportRequest{}))
+		return buf.Bytes()
+	}
+
+	// Act
+	first := export()
+	second := export()
+
+	// Assert
+	assert.Equal(t, 8, bytes.Count(first, []byte("\n")), "a completed and an answered statement for each attempt")
+	assert.Equal(t, first, second, "a second run exports the same statements")
+}
+
+func TestAttemptService_ResultsPolicy(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, item := publishedQuiz(t, ctx, database)
+	orgCtx := projectOrgScope(t, ctx, database, project.ID)
+	projects := store.NewProjectStore(database)
+	service := newAttemptService(database)
+	learnerCtx := asLearner(ctx, "learner-1")
+
+	// Act
+	practice, practiceErr := projects.UpdateResultsSettings(orgCtx, project.ID, core.ResultsSettings{Policy: core.ResultsPolicyPractice})
+	attempt, err := service.Start(learnerCtx, project.ID, "learner-1")
+	require.NoError(t, err)
+	answer, answerErr := service.SaveAnswer(learnerCtx, attempt.ID, item.ID, json.RawMessage(`{"choice_id":"a"}`), 0)
+	availableAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	closed, closedErr := projects.UpdateResultsSettings(orgCtx, project.ID, core.ResultsSettings{Policy: core.ResultsPolicyAfterCloseDate, AvailableAt: &availableAt})
+	submitted, submitErr := service.Submit(learnerCtx, attempt.ID)
+	copied, copyErr := projects.Duplicate(orgCtx, project.ID, "Star Charts (copy)")
+	_, missingErr := projects.UpdateResultsSettings(orgCtx, uuid.NewString(), core.DefaultResultsSettings())
+
+	// Assert
+	require.NoError(t, practiceErr)
+	assert.Equal(t, &core.ResultsSettings{Policy: core.ResultsPolicyPractice}, practice.ResultsSettings)
+	assert.Equal(t, project.Version+2, practice.Version, "publishing and the policy are writes")
+	require.NoError(t, answerErr)
+	require.NotNil(t, answer.Feedback, "practice grades the answer as it is saved")
+	assert.True(t, answer.Feedback.Correct)
+	require.NoError(t, closedErr)
+	require.NotNil(t, closed.ResultsSettings.AvailableAt)
+	assert.True(t, availableAt.Equal(*closed.ResultsSettings.AvailableAt))
+	require.NoError(t, submitErr)
+	assert.Nil(t, submitted.Score, "results wait for the close date")
+	assert.Nil(t, submitted.Results)
+	require.NoError(t, copyErr)
+	read, err := projects.GetByID(orgCtx, copied.ID)
+	require.NoError(t, err)
+	assert.Equal(t, core.ResultsPolicyAfterCloseDate, read.ResultsSettings.Policy, "the copy keeps the policy")
+	assert.ErrorIs(t, missingErr, core.ErrProjectNotFound)
+}
+
+// analyticsFixture seeds a published quiz with 50 attempts started over
+// the five UTC days up to lastDay, ten a day, and a submitted preview. The
+// first 40 are submitted 60 or 120 seconds after they start, scoring i%11
+// out of 10; the last 10 are unfinished.
+func analyticsFixture(t *testing.T, ctx context.Context, database *store.Database, lastDay time.Time) *core.Project {
+	t.Helper()
+	project, item := publishedQuiz(t, ctx, database)
+	attempts := store.NewAttemptStore(database)
+
+	seed := func(i int, preview bool, earned int, submit bool) {
+		attempt, err := attempts.Create(ctx, &core.Attempt{ProjectID: project.ID, PublicationVersion: 1, ParticipantID: uuid.NewString(), IsPreview: preview})
+		require.NoError(t, err)
+		if submit {
+			_, err = attempts.Submit(ctx, attempt.ID, []*core.ItemResult{{ItemID: item.ID, Earned: earned, Possible: 10, Correct: earned == 10}})
+			require.NoError(t, err)
+		}
+		startedAt := lastDay.AddDate(0, 0, -(i % 5)).Add(10 * time.Hour)
+		submittedAt := startedAt.Add(time.Duration(60*(1+i%2)) * time.Second)
+		_, err = database.Exec(ctx, "attempts.backdate", `
+			UPDATE attempts
+			SET started_at = $2, submitted_at = CASE WHEN submitted_at IS NULL THEN NULL ELSE $3 END
+			WHERE id = $1
+		`, attempt.ID, startedAt, submittedAt)
+		require.NoError(t, err)
+	}
+	for i := 0; i < 50; i++ {
+		seed(i, false, i%11, i < 40)
+	}
+	seed(0, true, 10, true)
+	return project
+}
+
+func TestAnalyticsService_Analytics(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	lastDay := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	project := analyticsFixture(t, ctx, database, lastDay)
+	authorCtx := projectOrgScope(t, ctx, database, project.ID)
+	service := core.NewAnalyticsService(store.NewStatsStore(database), store.NewProjectStore(database), store.NewItemStore(database))
+	to := lastDay.AddDate(0, 0, 1)
+
+	// Act
+	analytics, err := service.Analytics(authorCtx, project.ID, core.AttemptFilter{To: &to}, core.DefaultPassPercent)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 50, analytics.Attempts, "the preview doesn't count")
+	assert.Equal(t, 40, analytics.Submitted)
+	require.NotNil(t, analytics.CompletionRate)
+	assert.InDelta(t, 80, *analytics.CompletionRate, 0.001)
+	require.NotNil(t, analytics.AverageScore)
+	assert.InDelta(t, 46.5, *analytics.AverageScore, 0.001)
+	require.NotNil(t, analytics.MedianScore)
+	assert.InDelta(t, 45, *analytics.MedianScore, 0.001, "the 20th and 21st of 40 scores are 40% and 50%")
+	require.NotNil(t, analytics.PassRate)
+	assert.InDelta(t, 50, *analytics.PassRate, 0.001)
+	require.NotNil(t, analytics.AverageDuration)
+	assert.InDelta(t, 90, analytics.AverageDuration.Seconds(), 0.01)
+
+	require.Len(t, analytics.Daily, core.AnalyticsSeriesDays)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), analytics.Daily[0].Day)
+	for i, day := range analytics.Daily {
+		expected := 0
+		if i >= core.AnalyticsSeriesDays-5 {
+			expected = 10
+		}
+		assert.Equal(t, expected, day.Attempts, day.Day.Format(time.DateOnly))
+	}
+
+	counts := make([]int, len(analytics.ScoreBuckets))
+	for i, bucket := range analytics.ScoreBuckets {
+		counts[i] = bucket.Count
+	}
+	assert.Equal(t, []int{4, 4, 4, 4, 4, 4, 4, 3, 3, 6}, counts, "full scores fall in the last bucket")
+	assert.Equal(t, &core.ScoreBucket{From: 90, To: 100, Count: 6}, analytics.ScoreBuckets[9])
+}
+
+func TestAnalyticsService_Analytics_Window(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	lastDay := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	project := analyticsFixture(t, ctx, database, lastDay)
+	authorCtx := projectOrgScope(t, ctx, database, project.ID)
+	stats := store.NewStatsStore(database)
+	to := lastDay.AddDate(0, 0, 1)
+	seriesFrom := lastDay.AddDate(0, 0, 1-core.AnalyticsSeriesDays)
+
+	// Act
+	lastDayOnly, lastDayErr := stats.Analytics(authorCtx, project.ID, core.AnalyticsQuery{
+		Filter:      core.AttemptFilter{From: &lastDay, To: &to},
+		PassPercent: core.DefaultPassPercent,
+		SeriesFrom:  seriesFrom,
+	})
+	fullScores, fullScoresErr := stats.Analytics(authorCtx, project.ID, core.AnalyticsQuery{
+		PassPercent: 100,
+		SeriesFrom:  seriesFrom,
+	})
+	empty, emptyErr := stats.Analytics(authorCtx, project.ID, core.AnalyticsQuery{
+		Filter:      core.AttemptFilter{From: &to},
+		PassPercent: core.DefaultPassPercent,
+		SeriesFrom:  seriesFrom,
+	})
+
+	// Assert
+	require.NoError(t, lastDayErr)
+	assert.Equal(t, 10, lastDayOnly.Attempts, "every fifth attempt starts on the last day")
+	assert.Equal(t, 8, lastDayOnly.Submitted)
+	assert.Equal(t, []*core.DailyAttempts{{Day: lastDay, Attempts: 10}}, lastDayOnly.Daily)
+
+	require.NoError(t, fullScoresErr)
+	require.NotNil(t, fullScores.PassRate)
+	assert.InDelta(t, 7.5, *fullScores.PassRate, 0.001, "3 of 40 scores are full")
+
+	require.NoError(t, emptyErr)
+	assert.Equal(t, 0, empty.Attempts)
+	assert.Nil(t, empty.CompletionRate)
+	assert.Nil(t, empty.AverageScore)
+	assert.Nil(t, empty.MedianScore)
+	assert.Nil(t, empty.PassRate)
+	assert.Nil(t, empty.AverageDuration)
+	assert.Empty(t, empty.Daily)
+	assert.Empty(t, empty.ScoreBuckets)
+}
+
+// distributionFixture seeds a published project with an item of each type
+// learners answer, and five submitted attempts, an unfinished one and a
+// submitted preview answering them. It returns the project and its items
+// by type.
+func distributionFixture(t *testing.T, ctx context.Context, database *store.Database) (*core.Project, map[types.ItemType]*core.Item) {
+	t.Helper()
+	projects := store.NewProjectStore(database)
+	itemStore := store.NewItemStore(database)
+	org, err := store.NewOrganizationStore(database).Create(ctx, "Observatory", nil)
+	require.NoError(t, err)
+	orgCtx := store.SystemScope(core.WithOrgID(ctx, org.ID))
+	project, err := projects.Create(orgCtx, "Night Sky", nil, nil)
+	require.NoError(t, err)
+
+	contents := []struct {
+		itemType types.ItemType
+		content  string
+	}{
+		{types.ItemTypeChoice, `{"choices":[{"id":"a","text":"Sirius","correct":true},{"id":"b","text":"Vega"},{"id":"c","text":"Polaris"}]}`},
+		{types.ItemTypeMultiChoice, `{"choices":[{"id":"a","text":"Jupiter","correct":true},{"id":"b","text":"Mars"},{"id":"c","text":"Saturn","correct":true}]}`},
+		{types.ItemTypeTextEntry, `{"correct_answer":"Paris","multiline":false}`},
+		{types.ItemTypeOrdering, `{"items":[{"id":"x","text":"Mercury","correct_order":1},{"id":"y","text":"Venus","correct_order":2}]}`},
+		{types.ItemTypeHotspot, `{"image_url":"https://example.com/sky.png","hotspots":[{"id":"h","shape":"circle","coords":[5,5,2],"correct":true}]}`},
+	}
+	items := map[types.ItemType]*core.Item{}
+	for position, c := range contents {
+		item, err := itemStore.Create(orgCtx, project.ID, c.itemType, string(c.itemType), json.RawMessage(c.content), position, true, intPtr(1), nil)
+		require.NoError(t, err)
+		items[c.itemType] = item
+	}
+	_, err = projects.Publish(orgCtx, project.ID)
+	require.NoError(t, err)
+
+	attempts := store.NewAttemptStore(database)
+	take := func(preview, submit bool, answers map[types.ItemType]string) {
+		attempt, err := attempts.Create(ctx, &core.Attempt{ProjectID: project.ID, PublicationVersion: 1, IsPreview: preview})
+		require.NoError(t, err)
+		for itemType, response := range answers {
+			_, err := attempts.SaveAnswer(ctx, &core.Answer{AttemptID: attempt.ID, ItemID: items[itemType].ID, Response: json.RawMessage(response)})
+			require.NoError(t, err)
+		}
+		if submit {
+			_, err := attempts.Submit(ctx, attempt.ID, nil)
+			require.NoError(t, err)
+		}
+	}
+	take(false, true, map[types.ItemType]string{
+		types.ItemTypeChoice: `{"choice_id":"a"}`, types.ItemTypeMultiChoice: `{"choice_ids":["a","c"]}`,
+		types.ItemTypeTextEntry: `{"text":" Paris"}`, types.ItemTypeOrdering: `{"order":["y","x"]}`, types.ItemTypeHotspot: `{"x":0,"y":0}`,
+	})
+	take(false, true, map[types.ItemType]string{
+		types.ItemTypeChoice: `{"choice_id":"a"}`, types.ItemTypeMultiChoice: `{"choice_ids":["a"]}`,
+		types.ItemTypeTextEntry: `{"text":"paris "}`, types.ItemTypeOrdering: `{"order":["y","x"]}`, types.ItemTypeHotspot: `{"x":10,"y":10}`,
+	})
+	take(false, true, map[types.ItemType]string{
+		types.ItemTypeChoice: `{"choice_id":"b"}`, types.ItemTypeTextEntry: `{"text":"PARIS"}`,
+		types.ItemTypeOrdering: `{"order":["x","y"]}`, types.ItemTypeHotspot: `{"x":10,"y":0}`,
+	})
+	take(false, true, map[types.ItemType]string{types.ItemTypeTextEntry: `{"text":"Lyon"}`, types.ItemTypeHotspot: `{"x":5,"y":5}`})
+	take(false, true, map[types.ItemType]string{types.ItemTypeHotspot: `{"x":5,"y":5}`})
+	unfinished := map[types.ItemType]string{
+		types.ItemTypeChoice: `{"choice_id":"c"}`, types.ItemTypeMultiChoice: `{"choice_ids":["b"]}`,
+		types.ItemTypeTextEntry: `{"text":"lyon"}`, types.ItemTypeOrdering: `{"order":["x","y"]}`, types.ItemTypeHotspot: `{"x":0,"y":10}`,
+	}
+	take(false, false, unfinished)
+	take(true, true, unfinished)
+	return project, items
+}
+
+func TestAnalyticsService_AnswerDistribution(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, items := distributionFixture(t, ctx, database)
+	authorCtx := projectOrgScope(t, ctx, database, project.ID)
+	service := core.NewAnalyticsService(store.NewStatsStore(database), store.NewProjectStore(database), store.NewItemStore(database))
+	distribution := func(itemType types.ItemType, gridSize int) *core.AnswerDistribution {
+		distribution, err := service.AnswerDistribution(authorCtx, project.ID, items[itemType].ID, core.AttemptFilter{}, gridSize)
+		require.NoError(t, err)
+		return distribution
+	}
+	sirius, vega, polaris := "Sirius", "Vega", "Polaris"
+	jupiter, mars, saturn := "Jupiter", "Mars", "Saturn"
+
+	t.Run("choice", func(t *testing.T) {
+		got := distribution(types.ItemTypeChoice, core.DefaultHeatGridSize)
+		assert.Equal(t, 3, got.Answered, "unfinished attempts and previews don't count")
+		assert.Equal(t, []*core.ChoiceDistribution{
+			{ChoiceID: "a", Text: &sirius, Correct: true, Count: 2},
+			{ChoiceID: "b", Text: &vega, Count: 1},
+			{ChoiceID: "c", Text: &polaris, Count: 0},
+		}, got.Choices)
+	})
+
+	t.Run("multi_choice", func(t *testing.T) {
+		got := distribution(types.ItemTypeMultiChoice, core.DefaultHeatGridSize)
+		assert.Equal(t, 2, got.Answered)
+		assert.Equal(t, []*core.ChoiceDistribution{
+			{ChoiceID: "a", Text: &jupiter, Correct: true, Count: 2},
+			{ChoiceID: "b", Text: &mars, Count: 0},
+			{ChoiceID: "c", Text: &saturn, Correct: true, Count: 1},
+		}, got.Choices)
+	})
+
+	t.Run("text_entry", func(t *testing.T) {
+		got := distribution(types.ItemTypeTextEntry, core.DefaultHeatGridSize)
+		assert.Equal(t, 4, got.Answered)
+		assert.Equal(t, 2, got.DistinctTexts)
+		assert.Equal(t, []*core.TextCount{{Text: "paris", Count: 3, Correct: true}, {Text: "lyon", Count: 1}}, got.Texts)
+	})
+
+	t.Run("ordering", func(t *testing.T) {
+		got := distribution(types.ItemTypeOrdering, core.DefaultHeatGridSize)
+		assert.Equal(t, 3, got.Answered)
+		assert.Equal(t, []*core.SequenceCount{
+			{Order: []string{"y", "x"}, Count: 2},
+			{Order: []string{"x", "y"}, Count: 1, Correct: true},
+		}, got.Sequences)
+	})
+
+	t.Run("hotspot", func(t *testing.T) {
+		got := distribution(types.ItemTypeHotspot, 2)
+		assert.Equal(t, 5, got.Answered)
+		assert.Equal(t, &core.HeatGrid{Size: 2, MinX: 0, MinY: 0, MaxX: 10, MaxY: 10, Counts: [][]int{{1, 1}, {0, 3}}}, got.Heat,
+			"points on the far edges count in the last row and cell")
+	})
+}
+
+func TestStatsStore_AnswerDistribution_Bounded(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	project, items := distributionFixture(t, ctx, database)
+	authorCtx := projectOrgScope(t, ctx, database, project.ID)
+	attempts := store.NewAttemptStore(database)
+	for i := 0; i < 25; i++ {
+		attempt, err := attempts.Create(ctx, &core.Attempt{ProjectID: project.ID, PublicationVersion: 1})
+		require.NoError(t, err)
+		_, err = attempts.SaveAnswer(ctx, &core.Answer{AttemptID: attempt.ID, ItemID: items[types.ItemTypeTextEntry].ID, Response: json.RawMessage(fmt.Sprintf(`{"text":"star %02d"}`, i))})
+		require.NoError(t, err)
+		_, err = attempts.Submit(ctx, attempt.ID, nil)
+		require.NoError(t, err)
+	}
+	stats := store.NewStatsStore(database)
+
+	// Act
+	texts, distinct, err := stats.TextCounts(authorCtx, project.ID, items[types.ItemTypeTextEntry].ID, core.AttemptFilter{}, core.TopTextAnswers)
+	sequences, sequencesErr := stats.SequenceCounts(authorCtx, project.ID, items[types.ItemTypeOrdering].ID, core.AttemptFilter{}, 1)
+	heat, heatErr := stats.HeatGrid(authorCtx, project.ID, items[types.ItemTypeHotspot].ID, core.AttemptFilter{}, 1)
+	future := time.Now().Add(time.Hour)
+	empty, emptyErr := stats.HeatGrid(authorCtx, project.ID, items[types.ItemTypeHotspot].ID, core.AttemptFilter{From: &future}, 1)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 27, distinct)
+	require.Len(t, texts, core.TopTextAnswers)
+	assert.Equal(t, &core.TextCount{Text: "paris", Count: 3}, texts[0], "the most common answer comes first")
+	assert.Equal(t, "lyon", texts[1].Text)
+
+	require.NoError(t, sequencesErr)
+	assert.Equal(t, []*core.SequenceCount{{Order: []string{"y", "x"}, Count: 2}}, sequences)
+
+	require.NoError(t, heatErr)
+	assert.Equal(t, [][]int{{5}}, heat.Counts, "a grid of one cell counts every point")
+
+	require.NoError(t, emptyErr)
+	assert.Nil(t, empty, "no points, no grid")
+}
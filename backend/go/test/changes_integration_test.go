@@ -0,0 +1,160 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/config"
+	"github.com/provemyself/backend/internal/core"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/store"
+)
+
+// readySubscriber reports the listener's first resync, which it runs once
+// it listens
+type readySubscriber struct {
+	once  sync.Once
+	ready chan struct{}
+}
+
+func (s *readySubscriber) ApplyChange(ctx context.Context, change core.Change) error { return nil }
+
+func (s *readySubscriber) Resync(ctx context.Context) error {
+	s.once.Do(func() { close(s.ready) })
+	return nil
+}
+
+// firstPublication reports every project as published once, so the
+// response cache keeps one entry per project however it is edited
+type firstPublication struct{}
+
+func (firstPublication) PublishedVersion(ctx context.Context, projectID string) (int, error) {
+	return 1, nil
+}
+
+// newReplicas connects two databases, standing in for two replicas, to one
+// new Postgres database with the schema applied
+func newReplicas(t *testing.T, ctx context.Context) (writer, reader *store.Database) {
+	t.Helper()
+
+	if engine := os.Getenv("TEST_DB_ENGINE"); engine != "" && engine != "postgres" {
+		t.Skipf("tests Postgres notifications, running on %s", engine)
+	}
+	container, err := StartPostgreSQLContainer(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() { container.Terminate(ctx) })
+
+	writer = openTestDatabase(t, ctx, container.ConnectionString)
+	require.NoError(t, writer.Migrate(ctx))
+	return writer, openTestDatabase(t, ctx, container.ConnectionString)
+}
+
+// listen runs a change listener for database until the test ends, returning
+// once it listens
+func listen(t *testing.T, database *store.Database, subscribe func(listener *store.ChangeListener)) {
+	t.Helper()
+
+	listener := store.NewChangeListener(database)
+	subscribe(listener)
+	ready := &readySubscriber{ready: make(chan struct{})}
+	listener.Subscribe(ready)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	select {
+	case <-ready.ready:
+	case <-time.After(10 * time.Second):
+		t.Fatal("change listener did not start listening")
+	}
+}
+
+func TestChangeListener_ProjectUpdateInvalidatesOtherReplicasCache(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	// Arrange
+	ctx := context.Background()
+	writer, reader := newReplicas(t, ctx)
+	project, err := store.NewProjectStore(writer).Create(ctx, "Before", nil, nil)
+	require.NoError(t, err)
+
+	// The reader replica serves the project's title through its cache
+	cache := httpmiddleware.NewResponseCache(10, time.Hour, nil)
+	readerProjects := store.NewProjectStore(reader)
+	router := chi.NewRouter()
+	router.With(cache.Cache(firstPublication{})).Get("/projects/{projectId}", func(w http.ResponseWriter, r *http.Request) {
+		p, err := readerProjects.GetByID(r.Context(), chi.URLParam(r, "projectId"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(p.Title))
+	})
+	read := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/projects/"+project.ID, nil))
+		return rr
+	}
+	listen(t, reader, func(listener *store.ChangeListener) {
+		listener.Subscribe(cache, core.ChangeEntityProject)
+	})
+	read()
+	require.Equal(t, httpmiddleware.CacheHit, read().Header().Get("X-Cache"))
+
+	// Act
+	_, err = store.NewProjectStore(writer).Update(ctx, project.ID, "After", nil, nil, nil)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Eventually(t, func() bool {
+		return read().Body.String() == "After"
+	}, 5*time.Second, 20*time.Millisecond, "the reader's cached copy is dropped")
+}
+
+func TestChangeListener_SettingsUpdateReachesOtherReplica(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	// Arrange
+	ctx := context.Background()
+	writer, reader := newReplicas(t, ctx)
+	cfg := &config.Config{LogLevel: "info", RateLimitRequests: 100, RateLimitWindow: 60}
+	writerSettings := config.NewDynamic(cfg, store.NewSettingsStore(writer))
+	readerSettings := config.NewDynamic(cfg, store.NewSettingsStore(reader))
+	listen(t, reader, func(listener *store.ChangeListener) {
+		listener.Subscribe(readerSettings, core.ChangeEntitySettings)
+	})
+
+	// Act
+	limit := "250"
+	err := writerSettings.Update(ctx, map[string]*string{config.SettingRateLimitRequests: &limit}, "admin-1")
+	require.NoError(t, err)
+
+	// Assert
+	// The reader never polls, so only the notification can bring the change
+	assert.Eventually(t, func() bool {
+		return readerSettings.Settings().RateLimitRequests == 250
+	}, 5*time.Second, 20*time.Millisecond)
+}
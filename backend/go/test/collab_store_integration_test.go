@@ -0,0 +1,54 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/store"
+)
+
+func TestCollabStore_SaveAndLoad(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	collab := store.NewCollabStore(database)
+	project, err := store.NewProjectStore(database).Create(ctx, "Tide Tables", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	empty, err := collab.Load(ctx, project.ID)
+	require.NoError(t, err)
+	require.NoError(t, collab.Save(ctx, project.ID, []byte{1, 0, 0xff}))
+	require.NoError(t, collab.Save(ctx, project.ID, []byte{1, 2, 7, 9}))
+	state, err := collab.Load(ctx, project.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, empty, "a project without saved state loads nil")
+	assert.Equal(t, []byte{1, 2, 7, 9}, state, "a save replaces the state")
+}
+
+func TestCollabStore_PurgedProjectTakesItsDocument(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	collab := store.NewCollabStore(database)
+	project, err := store.NewProjectStore(database).Create(ctx, "Tide Tables", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, collab.Save(ctx, project.ID, []byte{1, 0}))
+
+	// Act
+	_, err = database.Exec(ctx, "projects.purge", "DELETE FROM projects WHERE id = $1", project.ID)
+	require.NoError(t, err)
+	state, err := collab.Load(ctx, project.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, state)
+	assertNoOrphans(t, store.NewIntegrityStore(database, nil))
+}
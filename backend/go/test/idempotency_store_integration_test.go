@@ -0,0 +1,101 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+)
+
+// newReservation returns a pending record for key that expires in a minute
+func newReservation(key string) *core.IdempotencyRecord {
+	return &core.IdempotencyRecord{
+		Key:         key,
+		Endpoint:    "POST /api/v1/public/projects/p-1/attempts",
+		RequestHash: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		ExpiresAt:   time.Now().UTC().Add(time.Minute),
+	}
+}
+
+func TestIdempotencyStore_ReserveCompleteAndReplay(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	idempotency := store.NewIdempotencyStore(migratedDatabase(t, ctx))
+	reservation := newReservation("key-1")
+	require.NoError(t, idempotency.Reserve(ctx, reservation))
+
+	// Act
+	pending, pendingErr := idempotency.Get(ctx, reservation.Key, reservation.Endpoint)
+	reserveAgainErr := idempotency.Reserve(ctx, newReservation("key-1"))
+	completed := *reservation
+	completed.StatusCode = http.StatusCreated
+	completed.Header = http.Header{
+		"Content-Type": {"application/json"},
+		"Location":     {"/api/v1/public/attempts/a-1"},
+		"Etag":         {`"1"`},
+	}
+	completed.Body = []byte(`{"id":"a-1"}`)
+	completed.ExpiresAt = time.Now().UTC().Add(time.Hour)
+	completeErr := idempotency.Complete(ctx, &completed)
+	completeAgainErr := idempotency.Complete(ctx, &completed)
+	releaseErr := idempotency.Release(ctx, reservation.Key, reservation.Endpoint)
+	stored, storedErr := idempotency.Get(ctx, reservation.Key, reservation.Endpoint)
+
+	// Assert
+	require.NoError(t, pendingErr)
+	assert.True(t, pending.Pending())
+	assert.Empty(t, pending.Body)
+	assert.ErrorIs(t, reserveAgainErr, core.ErrIdempotencyKeyExists)
+	require.NoError(t, completeErr)
+	assert.ErrorIs(t, completeAgainErr, core.ErrIdempotencyKeyNotFound, "a completed record isn't overwritten")
+	require.NoError(t, releaseErr)
+	require.NoError(t, storedErr, "releasing keeps a completed record")
+	assert.False(t, stored.Pending())
+	assert.Equal(t, http.StatusCreated, stored.StatusCode)
+	assert.Equal(t, completed.Header, stored.Header)
+	assert.Equal(t, completed.Body, stored.Body)
+	assert.WithinDuration(t, completed.ExpiresAt, stored.ExpiresAt, time.Second)
+}
+
+func TestIdempotencyStore_ReleaseFreesTheKey(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	idempotency := store.NewIdempotencyStore(migratedDatabase(t, ctx))
+	reservation := newReservation("key-1")
+	require.NoError(t, idempotency.Reserve(ctx, reservation))
+
+	// Act
+	err := idempotency.Release(ctx, reservation.Key, reservation.Endpoint)
+
+	// Assert
+	require.NoError(t, err)
+	_, err = idempotency.Get(ctx, reservation.Key, reservation.Endpoint)
+	assert.ErrorIs(t, err, core.ErrIdempotencyKeyNotFound)
+	assert.NoError(t, idempotency.Reserve(ctx, newReservation("key-1")))
+}
+
+func TestIdempotencyStore_ExpiredReservationCanBeTakenOver(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	idempotency := store.NewIdempotencyStore(migratedDatabase(t, ctx))
+	expired := newReservation("key-1")
+	expired.ExpiresAt = time.Now().UTC().Add(-time.Second)
+	require.NoError(t, idempotency.Reserve(ctx, expired))
+
+	// Act
+	err := idempotency.Reserve(ctx, newReservation("key-1"))
+
+	// Assert
+	require.NoError(t, err)
+	record, err := idempotency.Get(ctx, expired.Key, expired.Endpoint)
+	require.NoError(t, err)
+	assert.True(t, record.Pending())
+}
@@ -14,14 +14,14 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
-	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
-	"github.com/provemyself/backend/internal/config"
 	"github.com/provemyself/backend/internal/core"
 	"github.com/provemyself/backend/internal/http/handlers"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/store"
 	"github.com/provemyself/backend/internal/types"
 )
 
@@ -34,11 +34,12 @@ type IntegrationTestSuite struct {
 
 func (suite *IntegrationTestSuite) SetupSuite() {
 	// Initialize services
-	projectService := core.NewProjectService()
-	validate := validator.New()
+	database := migratedDatabase(suite.T(), context.Background())
+	projectService := core.NewProjectService(store.NewProjectStore(database), nil)
+	validate := httpmiddleware.NewValidator()
 
 	// Initialize handlers
-	healthHandler := handlers.NewHealthHandler()
+	healthHandler := handlers.NewHealthHandler(0)
 	projectHandler := handlers.NewProjectHandler(projectService, validate)
 
 	// Setup router
@@ -262,7 +263,7 @@ func (suite *IntegrationTestSuite) TestCORSHeaders() {
 	defer resp.Body.Close()
 
 	assert.Equal(suite.T(), http.StatusOK, resp.StatusCode)
-	assert.Contains(suite.T(), resp.Header.Get("Access-Control-Allow-Origins"), "http://localhost:3000")
+	assert.Contains(suite.T(), resp.Header.Get("Access-Control-Allow-Origin"), "http://localhost:3000")
 }
 
 // Run the integration test suite
@@ -0,0 +1,88 @@
+//go:build integration
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// assertNoOrphans checks that every integrity check passes
+func assertNoOrphans(t *testing.T, integrity *store.IntegrityStore) {
+	t.Helper()
+
+	results, err := integrity.CheckIntegrity(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	for _, result := range results {
+		assert.Zero(t, result.Orphans, "%s: %v", result.Check, result.SampleIDs)
+	}
+}
+
+func TestIntegrity_DeletedProjectLeavesNoOrphans(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	storage := store.NewLocalStorage(t.TempDir(), "/files")
+	integrity := store.NewIntegrityStore(database, storage)
+	projects := store.NewProjectStore(database)
+	items := store.NewItemStore(database)
+
+	project, err := projects.Create(ctx, "Tide Tables", nil, nil)
+	require.NoError(t, err)
+	_, err = items.Create(ctx, project.ID, types.ItemTypeTitle, "Welcome", nil, 0, false, nil, nil)
+	require.NoError(t, err)
+	assetKey := "projects/" + project.ID + "/assets/chart_1.png"
+	_, err = storage.Upload(ctx, assetKey, bytes.NewReader([]byte("png")), core.UploadOptions{})
+	require.NoError(t, err)
+	assertNoOrphans(t, integrity)
+
+	// Act: delete the project the way the API does, then purge its row
+	// and its files
+	require.NoError(t, projects.Delete(ctx, project.ID))
+	assertNoOrphans(t, integrity)
+
+	_, err = database.Exec(ctx, "projects.purge", "DELETE FROM projects WHERE id = $1", project.ID)
+	require.NoError(t, err)
+	require.NoError(t, storage.Delete(ctx, assetKey))
+
+	// Assert
+	assertNoOrphans(t, integrity)
+}
+
+func TestIntegrity_ReportsFilesOfAPurgedProject(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	storage := store.NewLocalStorage(t.TempDir(), "/files")
+	integrity := store.NewIntegrityStore(database, storage)
+
+	project, err := store.NewProjectStore(database).Create(ctx, "Tide Tables", nil, nil)
+	require.NoError(t, err)
+	assetKey := "projects/" + project.ID + "/assets/chart_1.png"
+	_, err = storage.Upload(ctx, assetKey, bytes.NewReader([]byte("png")), core.UploadOptions{})
+	require.NoError(t, err)
+
+	// Act
+	_, err = database.Exec(ctx, "projects.purge", "DELETE FROM projects WHERE id = $1", project.ID)
+	require.NoError(t, err)
+	results, err := integrity.CheckIntegrity(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	byCheck := make(map[string]core.IntegrityResult)
+	for _, result := range results {
+		byCheck[result.Check] = result
+	}
+	assert.Zero(t, byCheck["items.project_id"].Orphans, "the cascade removed the items")
+	assert.Equal(t, 1, byCheck["storage.projects"].Orphans)
+	assert.Equal(t, []string{assetKey}, byCheck["storage.projects"].SampleIDs)
+}
@@ -0,0 +1,196 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// lockedItem creates a project with one item to lock
+func lockedItem(t *testing.T, ctx context.Context, database *store.Database) *core.Item {
+	t.Helper()
+	project, err := store.NewProjectStore(database).Create(ctx, "Tide Tables", nil, nil)
+	require.NoError(t, err)
+	item, err := store.NewItemStore(database).Create(ctx, project.ID, types.ItemTypeTitle, "Welcome", nil, 0, false, nil, nil)
+	require.NoError(t, err)
+	return item
+}
+
+func newItemLock(item *core.Item, holderID string, now time.Time) *core.ItemLock {
+	return &core.ItemLock{
+		ItemID:     item.ID,
+		ProjectID:  item.ProjectID,
+		HolderID:   holderID,
+		AcquiredAt: now,
+		ExpiresAt:  now.Add(core.ItemLockTTL),
+	}
+}
+
+func TestItemLockStore_AcquireRefreshRelease(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	locks := store.NewItemLockStore(database)
+	item := lockedItem(t, ctx, database)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// Act
+	acquired, err := locks.Acquire(ctx, newItemLock(item, "alice", now), now)
+	require.NoError(t, err)
+	later := now.Add(time.Minute)
+	again, err := locks.Acquire(ctx, newItemLock(item, "alice", later), later)
+	require.NoError(t, err)
+	_, lockedErr := locks.Acquire(ctx, newItemLock(item, "bob", later), later)
+	refreshed, err := locks.Refresh(ctx, item.ID, "alice", later.Add(core.ItemLockTTL), later)
+	require.NoError(t, err)
+	_, notHeldErr := locks.Refresh(ctx, item.ID, "bob", later.Add(core.ItemLockTTL), later)
+	listed, err := locks.ListByProject(ctx, item.ProjectID, later)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, now.Add(core.ItemLockTTL), acquired.ExpiresAt)
+	assert.Equal(t, now, again.AcquiredAt, "the holder extends the lock they hold")
+	var locked *core.ItemLockedError
+	require.ErrorAs(t, lockedErr, &locked)
+	assert.Equal(t, "alice", locked.Lock.HolderID)
+	assert.Equal(t, later.Add(core.ItemLockTTL), refreshed.ExpiresAt)
+	assert.ErrorIs(t, notHeldErr, core.ErrItemLockNotHeld)
+	require.Len(t, listed, 1)
+	assert.Equal(t, item.ID, listed[0].ItemID)
+}
+
+func TestItemLockStore_ReleaseIsHolderOnly(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	locks := store.NewItemLockStore(database)
+	item := lockedItem(t, ctx, database)
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err := locks.Acquire(ctx, newItemLock(item, "alice", now), now)
+	require.NoError(t, err)
+
+	// Act
+	byOther, err := locks.Release(ctx, item.ID, "bob", now)
+	require.NoError(t, err)
+	stillHeld, err := locks.Get(ctx, item.ID, now)
+	require.NoError(t, err)
+	byHolder, err := locks.Release(ctx, item.ID, "alice", now)
+	require.NoError(t, err)
+	afterRelease, err := locks.Get(ctx, item.ID, now)
+	require.NoError(t, err)
+
+	// Assert
+	assert.False(t, byOther)
+	require.NotNil(t, stillHeld)
+	assert.Equal(t, "alice", stillHeld.HolderID)
+	assert.True(t, byHolder)
+	assert.Nil(t, afterRelease)
+}
+
+func TestItemLockStore_ExpiredLockIsClaimable(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	locks := store.NewItemLockStore(database)
+	item := lockedItem(t, ctx, database)
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err := locks.Acquire(ctx, newItemLock(item, "alice", now), now)
+	require.NoError(t, err)
+	expired := now.Add(core.ItemLockTTL)
+
+	// Act
+	expiredLock, err := locks.Get(ctx, item.ID, expired)
+	require.NoError(t, err)
+	listed, err := locks.ListByProject(ctx, item.ProjectID, expired)
+	require.NoError(t, err)
+	claimed, err := locks.Acquire(ctx, newItemLock(item, "bob", expired), expired)
+	require.NoError(t, err)
+	_, refreshErr := locks.Refresh(ctx, item.ID, "alice", expired.Add(core.ItemLockTTL), expired)
+
+	// Assert
+	assert.Nil(t, expiredLock)
+	assert.Empty(t, listed)
+	assert.Equal(t, "bob", claimed.HolderID)
+	assert.Equal(t, expired, claimed.AcquiredAt, "a claimed lock starts over")
+	assert.ErrorIs(t, refreshErr, core.ErrItemLockNotHeld)
+}
+
+func TestItemLockStore_ConcurrentClaimsOfAnExpiredLockHaveOneWinner(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	locks := store.NewItemLockStore(database)
+	item := lockedItem(t, ctx, database)
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err := locks.Acquire(ctx, newItemLock(item, "alice", now), now)
+	require.NoError(t, err)
+	expired := now.Add(core.ItemLockTTL)
+
+	const claimants = 10
+	start := make(chan struct{})
+	errs := make(chan error, claimants)
+	var wg sync.WaitGroup
+	for i := 0; i < claimants; i++ {
+		wg.Add(1)
+		go func(holderID string) {
+			defer wg.Done()
+			<-start
+			_, err := locks.Acquire(ctx, newItemLock(item, holderID, expired), expired)
+			errs <- err
+		}(fmt.Sprintf("user-%d", i))
+	}
+
+	// Act
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	// Assert
+	won, lost := 0, 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			won++
+		case errors.Is(err, core.ErrItemLocked):
+			lost++
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, 1, won)
+	assert.Equal(t, claimants-1, lost)
+
+	held, err := locks.Get(ctx, item.ID, expired)
+	require.NoError(t, err)
+	require.NotNil(t, held)
+	assert.NotEqual(t, "alice", held.HolderID)
+}
+
+func TestItemLockStore_MissingItem(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	locks := store.NewItemLockStore(database)
+	item := lockedItem(t, ctx, database)
+	item.ID = uuid.NewString()
+	now := time.Now().UTC()
+
+	// Act
+	_, err := locks.Acquire(ctx, newItemLock(item, "alice", now), now)
+
+	// Assert
+	assert.ErrorIs(t, err, core.ErrItemNotFound)
+}
@@ -0,0 +1,1290 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// newTestDatabase connects to a new database, closed when the test ends:
+// a Postgres container, terminated when the test ends, or with
+// TEST_DB_ENGINE=sqlite a SQLite file in a temporary directory
+func newTestDatabase(tb testing.TB, ctx context.Context) *store.Database {
+	tb.Helper()
+
+	return openTestDatabase(tb, ctx, newTestDatabaseURL(tb, ctx))
+}
+
+// newTestDatabaseURL returns the URL of a new database, for tests that
+// connect to it more than once (see newTestDatabase)
+func newTestDatabaseURL(tb testing.TB, ctx context.Context) string {
+	tb.Helper()
+
+	switch engine := os.Getenv("TEST_DB_ENGINE"); engine {
+	case "", "postgres":
+		container, err := StartPostgreSQLContainer(ctx)
+		require.NoError(tb, err)
+		tb.Cleanup(func() { container.Terminate(ctx) })
+		return container.ConnectionString
+	case "sqlite":
+		return "sqlite://" + filepath.Join(tb.TempDir(), "test.db")
+	default:
+		tb.Fatalf("unknown TEST_DB_ENGINE %q", engine)
+		return ""
+	}
+}
+
+// openTestDatabase connects to the database at url, closed when the test
+// ends
+func openTestDatabase(tb testing.TB, ctx context.Context, url string) *store.Database {
+	tb.Helper()
+
+	database, err := store.NewDatabase(ctx, store.DatabaseConfig{
+		URL:            url,
+		MaxOpenConns:   5,
+		MaxIdleConns:   5,
+		ConnectTimeout: 30 * time.Second,
+	})
+	require.NoError(tb, err)
+	tb.Cleanup(func() { database.Close() })
+	return database
+}
+
+// requirePostgres skips tests of Postgres-specific behavior on other engines
+func requirePostgres(tb testing.TB, database *store.Database) {
+	tb.Helper()
+
+	if name := database.Dialect().Name(); name != "postgres" {
+		tb.Skipf("tests Postgres-specific behavior, running on %s", name)
+	}
+}
+
+// migratedDatabase is newTestDatabase with the schema applied
+func migratedDatabase(tb testing.TB, ctx context.Context) *store.Database {
+	tb.Helper()
+
+	database := newTestDatabase(tb, ctx)
+	require.NoError(tb, database.Migrate(ctx))
+	return database
+}
+
+// createItems adds n choice items to a new project, worth one point each
+// except every tenth item, which is unscored
+func createItems(tb testing.TB, ctx context.Context, database *store.Database, n int) string {
+	tb.Helper()
+
+	project, err := store.NewProjectStore(database).Create(ctx, "Aggregates", nil, nil)
+	require.NoError(tb, err)
+
+	items := store.NewItemStore(database)
+	content := json.RawMessage(`{"choices":[{"id":"a","text":"A","correct":true},{"id":"b","text":"B"}]}`)
+	for i := 0; i < n; i++ {
+		var points *int
+		if i%10 != 0 {
+			one := 1
+			points = &one
+		}
+		_, err := items.Create(ctx, project.ID, types.ItemTypeChoice, fmt.Sprintf("Question %d", i), content, i*2, false, points, nil)
+		require.NoError(tb, err)
+	}
+	return project.ID
+}
+
+func TestItemStore_Aggregates(t *testing.T) {
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+
+	tests := []struct {
+		name             string
+		items            int
+		expectedCount    int
+		expectedMax      int
+		expectedHasItems bool
+		expectedPoints   int
+	}{
+		{name: "empty project"},
+		{name: "one unscored item", items: 1, expectedCount: 1, expectedMax: 0, expectedHasItems: true},
+		{name: "many items", items: 25, expectedCount: 25, expectedMax: 48, expectedHasItems: true, expectedPoints: 22},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			projectID := createItems(t, ctx, database, tt.items)
+
+			// Act
+			count, countErr := items.CountByProject(ctx, projectID)
+			max, hasItems, maxErr := items.MaxPosition(ctx, projectID)
+			points, pointsErr := items.SumPoints(ctx, projectID)
+
+			// Assert
+			require.NoError(t, countErr)
+			require.NoError(t, maxErr)
+			require.NoError(t, pointsErr)
+			assert.Equal(t, tt.expectedCount, count)
+			assert.Equal(t, tt.expectedMax, max)
+			assert.Equal(t, tt.expectedHasItems, hasItems)
+			assert.Equal(t, tt.expectedPoints, points)
+		})
+	}
+}
+
+func TestItemStore_DeletedItemFreesItsPosition(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 2)
+	listed, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	deleted := listed[0]
+
+	// Act
+	deleteErr := items.Delete(ctx, projectID, deleted.ID)
+	_, createErr := items.Create(ctx, projectID, types.ItemTypeTitle, "Replacement", json.RawMessage(`{}`), deleted.Position, false, nil, nil)
+
+	// Assert
+	require.NoError(t, deleteErr)
+	require.NoError(t, createErr, "only live items hold a position")
+	_, err = items.GetByID(ctx, projectID, deleted.ID)
+	assert.ErrorIs(t, err, core.ErrItemNotFound)
+	assert.ErrorIs(t, items.Delete(ctx, projectID, deleted.ID), core.ErrItemNotFound)
+	count, err := items.CountByProject(ctx, projectID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestItemStore_Restore(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 2)
+	listed, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	first, second := listed[0], listed[1]
+	require.NoError(t, items.Delete(ctx, projectID, first.ID))
+	require.NoError(t, items.Delete(ctx, projectID, second.ID))
+	replacement, err := items.Create(ctx, projectID, types.ItemTypeTitle, "Replacement", json.RawMessage(`{}`), first.Position, false, nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	movedBack, movedErr := items.Restore(ctx, projectID, first.ID)
+	keptBack, keptErr := items.Restore(ctx, projectID, second.ID)
+	_, liveErr := items.Restore(ctx, projectID, replacement.ID)
+	_, otherErr := items.Restore(ctx, createItems(t, ctx, database, 0), first.ID)
+
+	// Assert
+	require.NoError(t, movedErr)
+	require.NoError(t, keptErr)
+	assert.Equal(t, replacement.Position+1, movedBack.Position, "a taken position sends the item after the last live one")
+	assert.Equal(t, first.Content, movedBack.Content)
+	assert.Equal(t, second.Position, keptBack.Position, "a free position is kept")
+	assert.ErrorIs(t, liveErr, core.ErrItemNotFound, "only deleted items are restored")
+	assert.ErrorIs(t, otherErr, core.ErrItemNotFound)
+	count, err := items.CountByProject(ctx, projectID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestItemStore_PurgeDeleted(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 3)
+	listed, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	require.NoError(t, items.Delete(ctx, projectID, listed[0].ID))
+
+	// Act
+	kept, keptErr := items.PurgeDeleted(ctx, time.Now().Add(-time.Hour))
+	purged, purgedErr := items.PurgeDeleted(ctx, time.Now().Add(time.Hour))
+
+	// Assert
+	require.NoError(t, keptErr)
+	require.NoError(t, purgedErr)
+	assert.Zero(t, kept, "items deleted after the cutoff can still be restored")
+	assert.Equal(t, int64(1), purged, "live items are never purged")
+	_, err = items.Restore(ctx, projectID, listed[0].ID)
+	assert.ErrorIs(t, err, core.ErrItemNotFound)
+	count, err := items.CountByProject(ctx, projectID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestItemStore_ItemOfAnotherProjectIsNotFound(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 1)
+	otherProjectID := createItems(t, ctx, database, 0)
+	listed, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	item := listed[0]
+
+	// Act
+	_, getErr := items.GetByID(ctx, otherProjectID, item.ID)
+	_, updateErr := items.Update(ctx, otherProjectID, item.ID, types.ItemTypeTitle, "Moved", nil, 0, false, nil, nil, nil)
+	deleteErr := items.Delete(ctx, otherProjectID, item.ID)
+
+	// Assert
+	assert.ErrorIs(t, getErr, core.ErrItemNotFound)
+	assert.ErrorIs(t, updateErr, core.ErrItemNotFound)
+	assert.ErrorIs(t, deleteErr, core.ErrItemNotFound)
+	got, err := items.GetByID(ctx, projectID, item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, item, got, "the item is left as it was")
+}
+
+func TestItemStore_Versions(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 2)
+	listed, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	item, other := listed[0], listed[1]
+	stale := item.Version
+
+	// Act
+	updated, updateErr := items.Update(ctx, projectID, item.ID, item.Type, "Renamed", item.Content, item.Position, false, nil, nil, &stale)
+	_, conflictErr := items.Update(ctx, projectID, item.ID, item.Type, "Overwritten", item.Content, item.Position, false, nil, nil, &stale)
+	_, missingErr := items.Update(ctx, projectID, "00000000-0000-0000-0000-000000000000", item.Type, "Missing", nil, 0, false, nil, nil, &stale)
+	positionsErr := updatePositions(ctx, database, items, projectID, []core.PositionUpdate{{ItemID: item.ID, Position: other.Position}, {ItemID: other.ID, Position: item.Position}})
+	moved, getErr := items.GetByID(ctx, projectID, item.ID)
+	deleteErr := items.Delete(ctx, projectID, item.ID)
+	restored, restoreErr := items.Restore(ctx, projectID, item.ID)
+
+	// Assert
+	assert.Equal(t, 1, stale, "items start at version 1")
+	require.NoError(t, updateErr)
+	assert.Equal(t, 2, updated.Version)
+	assert.ErrorIs(t, conflictErr, core.ErrVersionConflict)
+	assert.ErrorIs(t, missingErr, core.ErrItemNotFound, "a missing item is not reported as a conflict")
+	require.NoError(t, positionsErr)
+	require.NoError(t, getErr)
+	assert.Equal(t, "Renamed", moved.Title, "the conflicting update is not written")
+	assert.Equal(t, 3, moved.Version, "a reorder is a write")
+	require.NoError(t, deleteErr)
+	require.NoError(t, restoreErr)
+	assert.Equal(t, 5, restored.Version, "deleting and restoring are writes")
+}
+
+func TestItemStore_Revisions(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+	items.SetRevisionLimit(2)
+	projectID := createItems(t, ctx, database, 1)
+	listed, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	item := listed[0]
+	userCtx := core.WithAccessScope(ctx, core.AccessScope{UserID: "user-1", Role: "user"})
+
+	// Act
+	_, firstErr := items.Update(userCtx, projectID, item.ID, item.Type, "Second", item.Content, item.Position, false, nil, nil, nil)
+	_, secondErr := items.Update(ctx, projectID, item.ID, item.Type, "Third", item.Content, item.Position, true, intPtr(2), nil, nil)
+	deleteErr := items.Delete(userCtx, projectID, item.ID)
+	_, restoreErr := items.Restore(ctx, projectID, item.ID)
+	revisions, total, listErr := items.ListRevisions(ctx, projectID, item.ID, 10, 0)
+	page, _, pageErr := items.ListRevisions(ctx, projectID, item.ID, 1, 1)
+	kept, getErr := items.GetRevision(ctx, projectID, item.ID, 3)
+	_, prunedErr := items.GetRevision(ctx, projectID, item.ID, 1)
+	_, _, missingErr := items.ListRevisions(ctx, projectID, "00000000-0000-0000-0000-000000000000", 10, 0)
+
+	// Assert
+	require.NoError(t, firstErr)
+	require.NoError(t, secondErr)
+	require.NoError(t, deleteErr)
+	require.NoError(t, restoreErr)
+	require.NoError(t, listErr)
+	assert.Equal(t, 2, total, "revisions beyond the limit are pruned")
+	require.Len(t, revisions, 2)
+	assert.Equal(t, 3, revisions[0].Number, "newest first")
+	assert.Equal(t, "Third", revisions[0].Item.Title, "a delete keeps the item it deleted")
+	assert.Equal(t, "user-1", revisions[0].ChangedBy)
+	assert.Equal(t, 2, revisions[1].Number)
+	assert.Equal(t, "Second", revisions[1].Item.Title)
+	assert.Empty(t, revisions[1].ChangedBy, "changes no user made have no user")
+	assert.False(t, revisions[0].ChangedAt.IsZero())
+	require.NoError(t, pageErr)
+	require.Len(t, page, 1)
+	assert.Equal(t, 2, page[0].Number)
+	require.NoError(t, getErr)
+	assert.Equal(t, item.ID, kept.Item.ID)
+	assert.Equal(t, projectID, kept.Item.ProjectID)
+	assert.True(t, kept.Item.Required)
+	assert.Equal(t, intPtr(2), kept.Item.Points)
+	assert.Equal(t, 3, kept.Item.Version)
+	assert.JSONEq(t, string(item.Content), string(kept.Item.Content))
+	assert.ErrorIs(t, prunedErr, core.ErrItemRevisionNotFound)
+	assert.ErrorIs(t, missingErr, core.ErrItemNotFound)
+}
+
+func TestItemService_RestoreRevision(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	itemStore := store.NewItemStore(database)
+	service := core.NewItemService(itemStore, store.NewProjectStore(database))
+	service.SetTransactor(database)
+	projectID := createItems(t, ctx, database, 2)
+	listed, err := itemStore.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	item, other := listed[0], listed[1]
+	_, err = itemStore.Update(ctx, projectID, item.ID, item.Type, "Renamed", item.Content, item.Position, true, intPtr(4), stringPtr("Why"), nil)
+	require.NoError(t, err)
+	require.NoError(t, updatePositions(ctx, database, itemStore, projectID, []core.PositionUpdate{{ItemID: item.ID, Position: other.Position}, {ItemID: other.ID, Position: item.Position}}))
+
+	// Act
+	restored, restoreErr := service.RestoreRevision(ctx, projectID, item.ID, 1)
+	_, missingErr := service.RestoreRevision(ctx, projectID, item.ID, 9)
+	revisions, total, listErr := service.ListRevisions(ctx, projectID, item.ID, 10, 0)
+
+	// Assert
+	require.NoError(t, restoreErr)
+	assert.Equal(t, item.Title, restored.Title)
+	assert.Equal(t, item.Required, restored.Required)
+	assert.Nil(t, restored.Points)
+	assert.Nil(t, restored.Explanation)
+	assert.JSONEq(t, string(item.Content), string(restored.Content))
+	assert.Equal(t, other.Position, restored.Position, "a restore keeps the item's position")
+	assert.Equal(t, 4, restored.Version)
+	assert.ErrorIs(t, missingErr, core.ErrItemRevisionNotFound)
+	require.NoError(t, listErr)
+	assert.Equal(t, 2, total, "a restore is kept as a revision in turn")
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "Renamed", revisions[0].Item.Title)
+}
+
+func TestItemStore_ReadsAndWritesWithinOrganization(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+	org, err := store.NewOrganizationStore(database).Create(ctx, "Lighthouse Keepers", nil)
+	require.NoError(t, err)
+	orgCtx := store.SystemScope(core.WithOrgID(ctx, org.ID))
+	project, err := store.NewProjectStore(database).Create(orgCtx, "Scoped", nil, nil)
+	require.NoError(t, err)
+	points, explanation := 3, "Basalt cools from lava"
+
+	// Act
+	created, createErr := items.Create(orgCtx, project.ID, types.ItemTypeTextEntry, "Which rock is volcanic?", nil, 0, true, &points, &explanation)
+	_, unscopedCreateErr := items.Create(ctx, project.ID, types.ItemTypeTextEntry, "Which rock is volcanic?", nil, 1, true, nil, nil)
+	_, unscopedGetErr := items.GetByID(ctx, project.ID, created.ID)
+	content := json.RawMessage(`{"correct_answer":"basalt"}`)
+	updated, updateErr := items.Update(orgCtx, project.ID, created.ID, types.ItemTypeTextEntry, created.Title, content, 0, false, nil, nil, nil)
+	got, getErr := items.GetByID(orgCtx, project.ID, created.ID)
+	unscopedDeleteErr := items.Delete(ctx, project.ID, created.ID)
+	deleteErr := items.Delete(orgCtx, project.ID, created.ID)
+	_, deletedGetErr := items.GetByID(orgCtx, project.ID, created.ID)
+
+	// Assert
+	require.NoError(t, createErr)
+	assert.Equal(t, project.ID, created.ProjectID)
+	assert.Nil(t, created.Content, "NULL content stays nil")
+	assert.True(t, created.Required)
+	assert.Equal(t, &points, created.Points)
+	assert.Equal(t, &explanation, created.Explanation)
+	assert.False(t, created.CreatedAt.IsZero())
+	assert.ErrorIs(t, unscopedCreateErr, core.ErrProjectNotFound)
+	assert.ErrorIs(t, unscopedGetErr, core.ErrItemNotFound)
+	require.NoError(t, updateErr)
+	assert.JSONEq(t, string(content), string(updated.Content))
+	assert.Nil(t, updated.Points)
+	assert.False(t, updated.UpdatedAt.Before(created.UpdatedAt))
+	require.NoError(t, getErr)
+	assert.Equal(t, updated, got)
+	assert.ErrorIs(t, unscopedDeleteErr, core.ErrItemNotFound)
+	assert.NoError(t, deleteErr)
+	assert.ErrorIs(t, deletedGetErr, core.ErrItemNotFound)
+}
+
+// BenchmarkItemStore_Aggregates compares the aggregate queries with listing
+// every item of a 1,000-item project and counting in Go
+func BenchmarkItemStore_Aggregates(b *testing.B) {
+	ctx := context.Background()
+	database := migratedDatabase(b, ctx)
+	items := store.NewItemStore(database)
+	projectID := createItems(b, ctx, database, 1000)
+
+	b.Run("aggregate queries", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := items.CountByProject(ctx, projectID); err != nil {
+				b.Fatal(err)
+			}
+			if _, _, err := items.MaxPosition(ctx, projectID); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := items.SumPoints(ctx, projectID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("list and count", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			list, err := items.ListByProject(ctx, projectID)
+			if err != nil {
+				b.Fatal(err)
+			}
+			count, highest, points := len(list), 0, 0
+			for _, item := range list {
+				highest = max(highest, item.Position)
+				if item.Points != nil {
+					points += *item.Points
+				}
+			}
+			_, _, _ = count, highest, points
+		}
+	})
+}
+
+func TestItemService_CreateMany_IsAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	itemStore := store.NewItemStore(database)
+	projectStore := store.NewProjectStore(database)
+	service := core.NewItemService(itemStore, projectStore)
+	service.SetTransactor(database)
+
+	// Arrange
+	projectID := createItems(t, ctx, database, 0)
+	_, err := service.Create(ctx, projectID, types.ItemTypeTitle, "Existing", nil, 5, false, nil, nil)
+	require.NoError(t, err)
+	inputs := []core.ItemInput{
+		{Type: types.ItemTypeTitle, Title: "Intro", Position: 0},
+		{Type: types.ItemTypeTitle, Title: "Middle", Position: 1},
+		// Collides with the existing item on UNIQUE(project_id, position)
+		{Type: types.ItemTypeTitle, Title: "Duplicate", Position: 5},
+	}
+
+	// Act
+	items, err := service.CreateMany(ctx, projectID, inputs)
+
+	// Assert
+	require.Error(t, err)
+	assert.Nil(t, items)
+	var itemErr *core.ItemBatchError
+	require.ErrorAs(t, err, &itemErr)
+	assert.Equal(t, 2, itemErr.Index)
+	assert.ErrorIs(t, err, core.ErrItemInvalidPosition)
+	count, err := itemStore.CountByProject(ctx, projectID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "the items created before the failure are rolled back")
+
+	items, err = service.CreateMany(ctx, projectID, inputs[:2])
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+}
+
+func TestItemService_CreateMany_ChecksPositionsFirst(t *testing.T) {
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	itemStore := store.NewItemStore(database)
+	service := core.NewItemService(itemStore, store.NewProjectStore(database))
+	service.SetTransactor(database)
+
+	// Arrange
+	projectID := createItems(t, ctx, database, 0)
+	inputs := []core.ItemInput{
+		{Type: types.ItemTypeTitle, Title: "Intro", Position: 0},
+		{
+			Type:     types.ItemTypeChoice,
+			Title:    "Pick one",
+			Content:  map[string]interface{}{"choices": []interface{}{map[string]interface{}{"id": "a", "text": "A", "correct": true}}},
+			Position: 1,
+		},
+		{Type: types.ItemTypeTitle, Title: "Duplicate", Position: 1},
+	}
+
+	// Act
+	_, err := service.CreateMany(ctx, projectID, inputs)
+
+	// Assert
+	var itemErr *core.ItemBatchError
+	require.ErrorAs(t, err, &itemErr)
+	assert.Equal(t, 2, itemErr.Index)
+	assert.ErrorIs(t, err, core.ErrItemDuplicatePositions)
+
+	// Content decoded from a request body is accepted for a typed item
+	items, err := service.CreateMany(ctx, projectID, inputs[:2])
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.JSONEq(t, `{"choices":[{"id":"a","text":"A","correct":true}]}`, string(items[1].Content))
+}
+
+func TestItemService_Patch(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	itemStore := store.NewItemStore(database)
+	service := core.NewItemService(itemStore, store.NewProjectStore(database))
+	service.SetTransactor(database)
+	projectID := createItems(t, ctx, database, 0)
+	explanation := "Two a day"
+	created, err := itemStore.Create(ctx, projectID, types.ItemTypeChoice, "Tides a day", json.RawMessage(`{"choices":[{"id":"a","text":"Two","correct":true}]}`), 0, false, intPtr(5), &explanation)
+	require.NoError(t, err)
+	title := "Tides per day"
+
+	// Act
+	patched, err := service.Patch(ctx, projectID, created.ID, core.ItemPatch{Title: &title})
+
+	// Assert
+	require.NoError(t, err)
+	got, err := itemStore.GetByID(ctx, projectID, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, patched, got)
+	assert.Equal(t, "Tides per day", got.Title)
+	assert.JSONEq(t, string(created.Content), string(got.Content))
+	assert.Equal(t, created.Points, got.Points)
+	assert.Equal(t, created.Explanation, got.Explanation)
+	assert.Equal(t, created.Type, got.Type)
+}
+
+func TestItemStore_CreateMany_Batch(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	requirePostgres(t, database)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 0)
+	imported := newItems(5)
+
+	// Act
+	created, err := items.CreateMany(ctx, projectID, imported)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, created, 5)
+	for i, item := range created {
+		assert.Equal(t, imported[i].Title, item.Title, "items come back in the order given")
+		assert.Equal(t, 1, item.Version)
+		assert.False(t, item.CreatedAt.IsZero())
+		got, err := items.GetByID(ctx, projectID, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, got, item, "the batch returns the rows as stored")
+	}
+}
+
+// newItems returns n choice items at positions 0 to n-1, worth one point
+// each
+func newItems(n int) []core.NewItem {
+	items := make([]core.NewItem, n)
+	one := 1
+	for i := range items {
+		items[i] = core.NewItem{
+			Type:     types.ItemTypeChoice,
+			Title:    fmt.Sprintf("Question %d", i),
+			Content:  json.RawMessage(`{"choices":[{"id":"a","text":"A","correct":true},{"id":"b","text":"B"}]}`),
+			Position: i,
+			Points:   &one,
+		}
+	}
+	return items
+}
+
+func TestItemStore_CreateBulkCopy(t *testing.T) {
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+
+	tests := []struct {
+		name  string
+		items int
+	}{
+		{"small import takes the batch", 5},
+		{"large import is copied", 250},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			projectID := createItems(t, ctx, database, 0)
+			imported := newItems(tt.items)
+
+			// Act
+			created, err := items.CreateBulkCopy(ctx, projectID, imported)
+
+			// Assert
+			require.NoError(t, err)
+			require.Len(t, created, tt.items)
+			for i, item := range created {
+				assert.NotEmpty(t, item.ID)
+				assert.Equal(t, projectID, item.ProjectID)
+				assert.Equal(t, imported[i].Title, item.Title, "items come back in the order given")
+				assert.Equal(t, i, item.Position)
+				assert.JSONEq(t, string(imported[i].Content), string(item.Content))
+				assert.False(t, item.CreatedAt.IsZero())
+			}
+			count, err := items.CountByProject(ctx, projectID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.items, count)
+			points, err := items.SumPoints(ctx, projectID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.items, points)
+		})
+	}
+}
+
+func TestItemStore_CreateBulkCopy_ConstraintViolationAbortsTheCopy(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 0)
+	imported := newItems(100)
+	// Collides with the first item on the unique position
+	imported[99].Position = 0
+
+	// Act
+	created, err := items.CreateBulkCopy(ctx, projectID, imported)
+
+	// Assert
+	require.Error(t, err)
+	assert.Nil(t, created)
+	count, err := items.CountByProject(ctx, projectID)
+	require.NoError(t, err)
+	assert.Zero(t, count, "no row of the aborted import is kept")
+
+	// The aborted COPY left no connection of the pool unusable
+	for i := 0; i < 10; i++ {
+		_, err := items.CountByProject(ctx, projectID)
+		require.NoError(t, err)
+	}
+	created, err = items.CreateBulkCopy(ctx, projectID, imported[:99])
+	require.NoError(t, err)
+	assert.Len(t, created, 99)
+}
+
+// BenchmarkItemStore_CreateBulkCopy imports 1,000 items into a new project,
+// with COPY and with the batch of inserts
+func BenchmarkItemStore_CreateBulkCopy(b *testing.B) {
+	ctx := context.Background()
+	database := migratedDatabase(b, ctx)
+	items := store.NewItemStore(database)
+	imported := newItems(1000)
+
+	for _, bench := range []struct {
+		name   string
+		create func(ctx context.Context, projectID string, items []core.NewItem) ([]*core.Item, error)
+	}{
+		{"copy", items.CreateBulkCopy},
+		{"batch", items.CreateMany},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				projectID := createItems(b, ctx, database, 0)
+				b.StartTimer()
+
+				if _, err := bench.create(ctx, projectID, imported); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// reversed returns position updates that reverse the order of items
+func reversed(items []*core.Item) []core.PositionUpdate {
+	updates := make([]core.PositionUpdate, len(items))
+	for i, item := range items {
+		updates[i] = core.PositionUpdate{ItemID: item.ID, Position: items[len(items)-1-i].Position}
+	}
+	return updates
+}
+
+// updatePositions moves items in a transaction of their own
+func updatePositions(ctx context.Context, database *store.Database, items *store.ItemStore, projectID string, updates []core.PositionUpdate) error {
+	return database.InTx(ctx, "items.update_positions", func(ctx context.Context) error {
+		return items.UpdatePositions(ctx, projectID, updates)
+	})
+}
+
+func TestItemStore_UpdatePositions(t *testing.T) {
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+
+	projectID := createItems(t, ctx, database, 500)
+	otherProjectID := createItems(t, ctx, database, 1)
+	before, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	otherItems, err := items.ListByProject(ctx, otherProjectID)
+	require.NoError(t, err)
+
+	t.Run("reverses 500 items in one statement", func(t *testing.T) {
+		// Act
+		err := updatePositions(ctx, database, items, projectID, reversed(before))
+
+		// Assert
+		require.NoError(t, err)
+		after, err := items.ListByProject(ctx, projectID)
+		require.NoError(t, err)
+		require.Len(t, after, len(before))
+		for i := range after {
+			assert.Equal(t, before[len(before)-1-i].ID, after[i].ID)
+		}
+	})
+
+	t.Run("item of another project changes nothing", func(t *testing.T) {
+		// Arrange
+		current, err := items.ListByProject(ctx, projectID)
+		require.NoError(t, err)
+		updates := append(reversed(current), core.PositionUpdate{ItemID: otherItems[0].ID, Position: 5000})
+
+		// Act
+		err = updatePositions(ctx, database, items, projectID, updates)
+
+		// Assert
+		assert.ErrorIs(t, err, core.ErrItemNotFound)
+		after, err := items.ListByProject(ctx, projectID)
+		require.NoError(t, err)
+		assert.Equal(t, current[0].ID, after[0].ID, "the statement was rolled back")
+	})
+
+	t.Run("colliding with an unmoved item", func(t *testing.T) {
+		// Arrange
+		current, err := items.ListByProject(ctx, projectID)
+		require.NoError(t, err)
+		service := core.NewItemService(items, store.NewProjectStore(database))
+		service.SetTransactor(database)
+		updates := []core.PositionUpdate{{ItemID: current[0].ID, Position: current[1].Position}}
+
+		// Act
+		err = service.UpdatePositions(ctx, projectID, updates)
+
+		// Assert
+		assert.ErrorIs(t, err, core.ErrItemInvalidPosition, "reported before the commit")
+		after, err := items.ListByProject(ctx, projectID)
+		require.NoError(t, err)
+		assert.Equal(t, current[0].ID, after[0].ID, "the move was rolled back")
+	})
+
+	t.Run("two items moved to one position", func(t *testing.T) {
+		// Arrange
+		current, err := items.ListByProject(ctx, projectID)
+		require.NoError(t, err)
+		service := core.NewItemService(items, store.NewProjectStore(database))
+		service.SetTransactor(database)
+		updates := []core.PositionUpdate{
+			{ItemID: current[0].ID, Position: current[1].Position},
+			{ItemID: current[1].ID, Position: current[1].Position},
+		}
+
+		// Act
+		err = service.UpdatePositions(ctx, projectID, updates)
+
+		// Assert
+		assert.ErrorIs(t, err, core.ErrItemDuplicatePositions)
+		after, err := items.ListByProject(ctx, projectID)
+		require.NoError(t, err)
+		assert.Equal(t, current[0].ID, after[0].ID, "nothing moved")
+	})
+
+	t.Run("outside a transaction", func(t *testing.T) {
+		// Act
+		err := items.UpdatePositions(ctx, projectID, reversed(before))
+
+		// Assert
+		assert.ErrorIs(t, err, core.ErrNoTransaction)
+	})
+}
+
+func TestItemStore_UpdatePositions_SwapsAcrossStatements(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	requirePostgres(t, database)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 2)
+	before, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	first, second := before[0], before[1]
+
+	// Act: each statement leaves two items at one position until the next
+	err = database.InTx(ctx, "items.swap", func(ctx context.Context) error {
+		if err := items.UpdatePositions(ctx, projectID, []core.PositionUpdate{{ItemID: first.ID, Position: second.Position}}); err != nil {
+			return err
+		}
+		return items.UpdatePositions(ctx, projectID, []core.PositionUpdate{{ItemID: second.ID, Position: first.Position}})
+	})
+
+	// Assert
+	require.NoError(t, err)
+	after, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	require.Len(t, after, 2)
+	assert.Equal(t, second.ID, after[0].ID)
+	assert.Equal(t, first.ID, after[1].ID)
+}
+
+// BenchmarkItemStore_UpdatePositions reverses the order of a project's
+// items, as a drag-and-drop reorder of the whole list would
+func BenchmarkItemStore_UpdatePositions(b *testing.B) {
+	ctx := context.Background()
+	database := migratedDatabase(b, ctx)
+	items := store.NewItemStore(database)
+
+	for _, n := range []int{20, 200, 500} {
+		b.Run(fmt.Sprintf("%d items", n), func(b *testing.B) {
+			projectID := createItems(b, ctx, database, n)
+			list, err := items.ListByProject(ctx, projectID)
+			require.NoError(b, err)
+			updates := reversed(list)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := updatePositions(ctx, database, items, projectID, updates); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestItemStore_Search(t *testing.T) {
+	ctx := context.Background()
+	database := newTestDatabase(t, ctx)
+	items := store.NewItemStore(database)
+
+	// Arrange: on Postgres, items created before the search vector exist
+	// are backfilled
+	m, err := database.Migrator(ctx)
+	require.NoError(t, err)
+	defer m.Close()
+	fullText := database.Capabilities().FullTextSearch
+	if fullText {
+		require.NoError(t, m.Goto(ctx, 3))
+	} else {
+		require.NoError(t, m.Up(ctx))
+	}
+
+	projectID := createItems(t, ctx, database, 0)
+	choices := json.RawMessage(`{"choices":[{"id":"a","text":"A lighthouse","correct":true},{"id":"b","text":"A windmill"}]}`)
+	choiceMatch, err := items.Create(ctx, projectID, types.ItemTypeChoice, "Which building guides ships?", choices, 0, false, nil, nil)
+	require.NoError(t, err)
+	if fullText {
+		require.NoError(t, m.Up(ctx))
+	}
+
+	other := json.RawMessage(`{"choices":[{"id":"a","text":"Granite","correct":true},{"id":"b","text":"Sandstone"}]}`)
+	titleMatch, err := items.Create(ctx, projectID, types.ItemTypeChoice, "What are lighthouses built from?", other, 1, false, nil, nil)
+	require.NoError(t, err)
+	_, err = items.Create(ctx, projectID, types.ItemTypeChoice, "Which rock is volcanic?", other, 2, false, nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	found, err := items.Search(ctx, projectID, "lighthouse")
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, titleMatch.ID, found[0].ID, "title matches rank first")
+	assert.Equal(t, choiceMatch.ID, found[1].ID, "choice texts are searched")
+
+	// Act: content updates keep the vector in sync
+	_, err = items.Update(ctx, projectID, choiceMatch.ID, types.ItemTypeChoice, choiceMatch.Title, other, 0, false, nil, nil, nil)
+	require.NoError(t, err)
+	found, err = items.Search(ctx, projectID, "lighthouse")
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, titleMatch.ID, found[0].ID)
+}
+
+func TestItemStore_List(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 0)
+	otherProjectID := createItems(t, ctx, database, 1)
+
+	create := func(itemType types.ItemType, title, content string, position int, required bool) string {
+		item, err := items.Create(ctx, projectID, itemType, title, json.RawMessage(content), position, required, nil, nil)
+		require.NoError(t, err)
+		return item.ID
+	}
+	intro := create(types.ItemTypeTitle, "Welcome aboard", `{}`, 0, false)
+	lighthouse := create(types.ItemTypeChoice, "Which building guides ships?", `{"choices":[{"id":"a","text":"A Lighthouse","correct":true},{"id":"b","text":"A windmill"}]}`, 1, true)
+	granite := create(types.ItemTypeChoice, "What are LIGHTHOUSES built from?", `{"choices":[{"id":"a","text":"Granite","correct":true},{"id":"b","text":"Sandstone"}]}`, 2, false)
+	essay := create(types.ItemTypeTextEntry, "Describe a storm at sea", `{"multiline":true}`, 3, true)
+	percent := create(types.ItemTypeTitle, "100% done", `{}`, 4, false)
+
+	required, optional := true, false
+	tests := []struct {
+		name          string
+		opts          core.ItemListOptions
+		expectedIDs   []string
+		expectedTotal int
+	}{
+		{"no filter lists every item by position", core.ItemListOptions{Limit: 50}, []string{intro, lighthouse, granite, essay, percent}, 5},
+		{"page", core.ItemListOptions{Limit: 2, Offset: 1}, []string{lighthouse, granite}, 5},
+		{"page past the end", core.ItemListOptions{Limit: 2, Offset: 10}, []string{}, 5},
+		{"type", core.ItemListOptions{Type: types.ItemTypeChoice, Limit: 50}, []string{lighthouse, granite}, 2},
+		{"required", core.ItemListOptions{Required: &required, Limit: 50}, []string{lighthouse, essay}, 2},
+		{"optional", core.ItemListOptions{Required: &optional, Limit: 50}, []string{intro, granite, percent}, 3},
+		{"short search in titles ignores case", core.ItemListOptions{Search: "WE", Limit: 50}, []string{intro}, 1},
+		{"short search in content", core.ItemListOptions{Search: "mu", Limit: 50}, []string{essay}, 1},
+		{"short search matches wildcards literally", core.ItemListOptions{Search: "0%", Limit: 50}, []string{percent}, 1},
+		{"long search ranks title matches first", core.ItemListOptions{Search: "lighthouse", Limit: 50}, []string{granite, lighthouse}, 2},
+		{"filters combine", core.ItemListOptions{Type: types.ItemTypeChoice, Required: &required, Search: "lighthouse", Limit: 50}, []string{lighthouse}, 1},
+		{"no match", core.ItemListOptions{Search: "volcano", Limit: 50}, []string{}, 0},
+		{"sort by title", core.ItemListOptions{Sort: core.ItemSortTitle, Limit: 50}, []string{percent, essay, intro, granite, lighthouse}, 5},
+		{"sort by position descending", core.ItemListOptions{Sort: core.ItemSortPositionDesc, Limit: 2}, []string{percent, essay}, 5},
+		{"sort overrides the ranking", core.ItemListOptions{Search: "lighthouse", Sort: core.ItemSortPosition, Limit: 50}, []string{lighthouse, granite}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			page, err := items.List(ctx, projectID, tt.opts)
+
+			// Assert
+			require.NoError(t, err)
+			ids := make([]string, len(page.Items))
+			for i, item := range page.Items {
+				ids[i] = item.ID
+				assert.Equal(t, projectID, item.ProjectID, "only the project's items are listed, not %s's", otherProjectID)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+			assert.Equal(t, tt.expectedTotal, page.Total)
+		})
+	}
+}
+
+// retryCounter is a store.QueryObserver counting retries
+type retryCounter struct {
+	retries atomic.Int32
+}
+
+func (c *retryCounter) ObserveQuery(string, time.Duration, error) {}
+
+func (c *retryCounter) ObserveRetry(string) { c.retries.Add(1) }
+
+func TestItemStore_Duplicate(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 2)
+	listed, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	source := listed[0]
+
+	// Act
+	copied, err := items.Duplicate(ctx, projectID, source.ID, "Question 0 (copy)")
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotEqual(t, source.ID, copied.ID)
+	assert.Equal(t, projectID, copied.ProjectID)
+	assert.Equal(t, "Question 0 (copy)", copied.Title)
+	assert.Equal(t, 3, copied.Position, "after the last item, at 2")
+	assert.Equal(t, source.Type, copied.Type)
+	assert.JSONEq(t, string(source.Content), string(copied.Content))
+	assert.Equal(t, source.Points, copied.Points)
+	got, err := items.GetByID(ctx, projectID, copied.ID)
+	require.NoError(t, err)
+	assert.Equal(t, copied.Title, got.Title)
+}
+
+func TestItemStore_Duplicate_ConcurrentCopiesTakeDistinctPositions(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 1)
+	listed, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+
+	// Act
+	const copies = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, copies)
+	for i := 0; i < copies; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := items.Duplicate(ctx, projectID, listed[0].ID, "Copy")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	// Assert
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	got, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	require.Len(t, got, copies+1)
+	for i, item := range got {
+		assert.Equal(t, i, item.Position)
+	}
+}
+
+func TestItemStore_Duplicate_NotFound(t *testing.T) {
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+	projects := store.NewProjectStore(database)
+
+	tests := []struct {
+		name        string
+		arrange     func(t *testing.T) (projectID, itemID string)
+		expectedErr error
+	}{
+		{
+			name: "missing item",
+			arrange: func(t *testing.T) (string, string) {
+				return createItems(t, ctx, database, 1), "00000000-0000-4000-8000-000000000000"
+			},
+			expectedErr: core.ErrItemNotFound,
+		},
+		{
+			name: "item of another project",
+			arrange: func(t *testing.T) (string, string) {
+				otherID := createItems(t, ctx, database, 1)
+				other, err := items.ListByProject(ctx, otherID)
+				require.NoError(t, err)
+				return createItems(t, ctx, database, 1), other[0].ID
+			},
+			expectedErr: core.ErrItemNotFound,
+		},
+		{
+			name: "deleted item",
+			arrange: func(t *testing.T) (string, string) {
+				projectID := createItems(t, ctx, database, 1)
+				listed, err := items.ListByProject(ctx, projectID)
+				require.NoError(t, err)
+				require.NoError(t, items.Delete(ctx, projectID, listed[0].ID))
+				return projectID, listed[0].ID
+			},
+			expectedErr: core.ErrItemNotFound,
+		},
+		{
+			name: "deleted project",
+			arrange: func(t *testing.T) (string, string) {
+				projectID := createItems(t, ctx, database, 1)
+				listed, err := items.ListByProject(ctx, projectID)
+				require.NoError(t, err)
+				require.NoError(t, projects.Delete(ctx, projectID))
+				return projectID, listed[0].ID
+			},
+			expectedErr: core.ErrProjectNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			projectID, itemID := tt.arrange(t)
+
+			// Act
+			_, err := items.Duplicate(ctx, projectID, itemID, "Copy")
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expectedErr)
+			count, err := items.CountByProject(ctx, projectID)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, count, 1, "nothing was copied")
+		})
+	}
+}
+
+func TestTransaction_RetriesDeadlocks(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	requirePostgres(t, database)
+	counter := &retryCounter{}
+	database.Instrument(0, counter)
+
+	projectID := createItems(t, ctx, database, 2)
+	listed, err := store.NewItemStore(database).ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+
+	// Each transaction locks one item, waits until the other has locked the
+	// other item, then updates it: whichever Postgres picks as the victim
+	// of the deadlock is rolled back and run again
+	var locked sync.WaitGroup
+	locked.Add(2)
+	rename := func(first, second string) error {
+		attempts := 0
+		return database.Transaction(ctx, "items.rename", func(tx *store.Runner) error {
+			attempts++
+			if _, err := tx.Exec(ctx, "items.rename", "UPDATE items SET title = title || '!' WHERE id = $1", first); err != nil {
+				return err
+			}
+			if attempts == 1 {
+				locked.Done()
+				locked.Wait()
+			}
+			_, err := tx.Exec(ctx, "items.rename", "UPDATE items SET title = title || '!' WHERE id = $1", second)
+			return err
+		})
+	}
+
+	// Act
+	errs := make(chan error, 2)
+	go func() { errs <- rename(listed[0].ID, listed[1].ID) }()
+	go func() { errs <- rename(listed[1].ID, listed[0].ID) }()
+
+	// Assert
+	require.NoError(t, <-errs)
+	require.NoError(t, <-errs)
+	assert.GreaterOrEqual(t, counter.retries.Load(), int32(1), "the deadlock was retried")
+
+	got, err := store.NewItemStore(database).ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	for _, item := range got {
+		assert.Equal(t, "!!", item.Title[len(item.Title)-2:], "both transactions renamed every item once")
+	}
+}
+
+// intPtr returns a pointer to a literal
+func intPtr(v int) *int { return &v }
+
+func TestItemStore_NullableColumnsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 0)
+	content := json.RawMessage(`{"choices":[{"id":"a","text":"A","correct":true},{"id":"b","text":"B"}]}`)
+
+	tests := []struct {
+		name              string
+		points            *int
+		explanation       *string
+		updatePoints      *int
+		updateExplanation *string
+	}{
+		{"zero points become null", intPtr(0), stringPtr("Because"), nil, stringPtr("Because")},
+		{"null points become zero", nil, stringPtr("Because"), intPtr(0), stringPtr("Because")},
+		{"empty explanation becomes null", intPtr(1), stringPtr(""), intPtr(1), nil},
+		{"null explanation becomes empty", intPtr(1), nil, intPtr(1), stringPtr("")},
+		{"zero and empty stay set", intPtr(0), stringPtr(""), intPtr(0), stringPtr("")},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			created, err := items.Create(ctx, projectID, types.ItemTypeChoice, tt.name, content, i, false, tt.points, tt.explanation)
+			require.NoError(t, err)
+			got, err := items.GetByID(ctx, projectID, created.ID)
+			require.NoError(t, err)
+
+			// Assert
+			assert.Equal(t, tt.points, got.Points)
+			assert.Equal(t, tt.explanation, got.Explanation)
+			assert.Equal(t, created, got, "create returns what get reads")
+
+			// Act
+			updated, err := items.Update(ctx, projectID, created.ID, types.ItemTypeChoice, tt.name, got.Content, i, false, tt.updatePoints, tt.updateExplanation, nil)
+			require.NoError(t, err)
+			got, err = items.GetByID(ctx, projectID, created.ID)
+			require.NoError(t, err)
+
+			// Assert
+			assert.Equal(t, tt.updatePoints, got.Points)
+			assert.Equal(t, tt.updateExplanation, got.Explanation)
+			assert.Equal(t, updated, got, "update returns what get reads")
+			assert.Equal(t, string(created.Content), string(got.Content))
+		})
+	}
+}
+
+// assertOwnContent checks that no two items' contents share memory, as
+// they would if a scan kept the driver's buffer past its row. It
+// overwrites the contents.
+func assertOwnContent(t *testing.T, items []*core.Item) {
+	t.Helper()
+
+	contents := make([]string, len(items))
+	for i, item := range items {
+		contents[i] = string(item.Content)
+	}
+	for i, item := range items {
+		for k := range item.Content {
+			item.Content[k] = '#'
+		}
+		for j := i + 1; j < len(items); j++ {
+			assert.Equal(t, contents[j], string(items[j].Content), "item %d shares item %d's content", j, i)
+		}
+	}
+}
+
+func TestItemStore_ListsKeepEachItemsContent(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 0)
+
+	contents := []json.RawMessage{
+		json.RawMessage(`{"text":"A lighthouse keeper's first watch"}`),
+		json.RawMessage(`{"choices":[{"id":"a","text":"Granite","correct":true},{"id":"b","text":"Sandstone"}]}`),
+		json.RawMessage(`{"correct_answer":"fresnel"}`),
+		json.RawMessage(`{"text":"Tides"}`),
+	}
+	contentByID := make(map[string]json.RawMessage, len(contents))
+	for i, content := range contents {
+		item, err := items.Create(ctx, projectID, types.ItemTypeTitle, fmt.Sprintf("Lighthouse %d", i), content, i, false, nil, nil)
+		require.NoError(t, err)
+		contentByID[item.ID] = content
+	}
+
+	lists := map[string]func() ([]*core.Item, error){
+		"list by project": func() ([]*core.Item, error) { return items.ListByProject(ctx, projectID) },
+		"search":          func() ([]*core.Item, error) { return items.Search(ctx, projectID, "lighthouse") },
+		"list": func() ([]*core.Item, error) {
+			page, err := items.List(ctx, projectID, core.ItemListOptions{Search: "lighthouse", Limit: 10})
+			if err != nil {
+				return nil, err
+			}
+			return page.Items, nil
+		},
+	}
+
+	for name, list := range lists {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			listed, err := list()
+
+			// Assert
+			require.NoError(t, err)
+			require.Len(t, listed, len(contents))
+			for _, item := range listed {
+				assert.JSONEq(t, string(contentByID[item.ID]), string(item.Content))
+			}
+			assertOwnContent(t, listed)
+		})
+	}
+}
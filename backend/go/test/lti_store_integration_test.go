@@ -0,0 +1,175 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+)
+
+func newLTIPlatform(issuer, clientID string) *core.LTIPlatform {
+	return &core.LTIPlatform{
+		Issuer:       issuer,
+		ClientID:     clientID,
+		AuthLoginURL: issuer + "/auth",
+		AuthTokenURL: issuer + "/token",
+		KeySetURL:    issuer + "/jwks",
+	}
+}
+
+func TestLTIStore_Platforms(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	lti := store.NewLTIStore(database)
+
+	// Act
+	canvas, err := lti.CreatePlatform(ctx, newLTIPlatform("https://canvas.example.edu", "c1"))
+	require.NoError(t, err)
+	_, err = lti.CreatePlatform(ctx, newLTIPlatform("https://moodle.example.edu", "m1"))
+	require.NoError(t, err)
+	_, err = lti.CreatePlatform(ctx, newLTIPlatform("https://moodle.example.edu", "m2"))
+	require.NoError(t, err)
+	_, existsErr := lti.CreatePlatform(ctx, newLTIPlatform("https://canvas.example.edu", "c1"))
+	unknownOrg := newLTIPlatform("https://d2l.example.edu", "d1")
+	orgID := uuid.NewString()
+	unknownOrg.OrgID = &orgID
+	_, orgErr := lti.CreatePlatform(ctx, unknownOrg)
+
+	found, err := lti.FindPlatform(ctx, "https://canvas.example.edu", "")
+	require.NoError(t, err)
+	_, ambiguousErr := lti.FindPlatform(ctx, "https://moodle.example.edu", "")
+	byClient, err := lti.FindPlatform(ctx, "https://moodle.example.edu", "m2")
+	require.NoError(t, err)
+	listed, err := lti.ListPlatforms(ctx)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, canvas.ID, found.ID)
+	assert.Nil(t, found.OrgID)
+	assert.ErrorIs(t, existsErr, core.ErrLTIPlatformExists)
+	assert.ErrorIs(t, orgErr, core.ErrOrganizationNotFound)
+	assert.ErrorIs(t, ambiguousErr, core.ErrLTIPlatformNotFound, "an issuer with several registrations needs a client ID")
+	assert.Equal(t, "m2", byClient.ClientID)
+	require.Len(t, listed, 3)
+	assert.Equal(t, "c1", listed[0].ClientID)
+
+	require.NoError(t, lti.DeletePlatform(ctx, canvas.ID))
+	_, err = lti.GetPlatform(ctx, canvas.ID)
+	assert.ErrorIs(t, err, core.ErrLTIPlatformNotFound)
+	assert.ErrorIs(t, lti.DeletePlatform(ctx, canvas.ID), core.ErrLTIPlatformNotFound)
+}
+
+func TestLTIStore_TakeState_OnlyOnce(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	lti := store.NewLTIStore(database)
+	platform, err := lti.CreatePlatform(ctx, newLTIPlatform("https://canvas.example.edu", "c1"))
+	require.NoError(t, err)
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, lti.SaveState(ctx, &core.LTIState{State: "s1", Nonce: "n1", PlatformID: platform.ID, ExpiresAt: now.Add(time.Minute)}))
+	require.NoError(t, lti.SaveState(ctx, &core.LTIState{State: "s2", Nonce: "n2", PlatformID: platform.ID, ExpiresAt: now.Add(time.Minute)}))
+
+	// Act: concurrent launches with the same state
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var taken int
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := lti.TakeState(ctx, "s1", now); err == nil {
+				mu.Lock()
+				taken++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	_, expiredErr := lti.TakeState(ctx, "s2", now.Add(time.Minute))
+
+	// Assert
+	assert.Equal(t, 1, taken)
+	assert.ErrorIs(t, expiredErr, core.ErrLTIInvalidLaunch)
+}
+
+func TestLTIStore_BindResourceLink_FirstBindingHolds(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	lti := store.NewLTIStore(database)
+	projects := store.NewProjectStore(database)
+	platform, err := lti.CreatePlatform(ctx, newLTIPlatform("https://canvas.example.edu", "c1"))
+	require.NoError(t, err)
+	first, err := projects.Create(ctx, "Tide Tables", nil, nil)
+	require.NoError(t, err)
+	second, err := projects.Create(ctx, "Star Charts", nil, nil)
+	require.NoError(t, err)
+	link := func(projectID string) *core.LTIResourceLink {
+		return &core.LTIResourceLink{PlatformID: platform.ID, DeploymentID: "d1", ResourceLinkID: "r1", ProjectID: projectID}
+	}
+
+	// Act
+	_, unmappedErr := lti.GetResourceLink(ctx, platform.ID, "d1", "r1")
+	bound, err := lti.BindResourceLink(ctx, link(first.ID))
+	require.NoError(t, err)
+	again, err := lti.BindResourceLink(ctx, link(second.ID))
+	require.NoError(t, err)
+	_, missingErr := lti.BindResourceLink(ctx, &core.LTIResourceLink{
+		PlatformID: platform.ID, DeploymentID: "d1", ResourceLinkID: "r2", ProjectID: uuid.NewString(),
+	})
+
+	// Assert
+	assert.ErrorIs(t, unmappedErr, core.ErrLTIResourceLinkNotMapped)
+	assert.Equal(t, first.ID, bound.ProjectID)
+	assert.Equal(t, first.ID, again.ProjectID)
+	assert.ErrorIs(t, missingErr, core.ErrProjectNotFound)
+}
+
+func TestLTIStore_Sessions(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	lti := store.NewLTIStore(database)
+	platform, err := lti.CreatePlatform(ctx, newLTIPlatform("https://canvas.example.edu", "c1"))
+	require.NoError(t, err)
+	project, err := store.NewProjectStore(database).Create(ctx, "Tide Tables", nil, nil)
+	require.NoError(t, err)
+	now := time.Now().UTC().Truncate(time.Second)
+	session := &core.LTISession{
+		ID:          strings.Repeat("a", 64),
+		PlatformID:  platform.ID,
+		ProjectID:   project.ID,
+		Subject:     "u1",
+		Name:        "Jane Doe",
+		Roles:       []string{"http://purl.imsglobal.org/vocab/lis/v2/membership#Learner"},
+		LineItemURL: "https://canvas.example.edu/lineitems/1",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	}
+	require.NoError(t, lti.CreateSession(ctx, session))
+	require.NoError(t, lti.SaveState(ctx, &core.LTIState{State: "s1", Nonce: "n1", PlatformID: platform.ID, ExpiresAt: now.Add(time.Minute)}))
+
+	// Act
+	got, err := lti.GetSession(ctx, session.ID, now)
+	require.NoError(t, err)
+	_, expiredErr := lti.GetSession(ctx, session.ID, now.Add(time.Hour))
+	deleted, err := lti.DeleteExpired(ctx, now.Add(time.Hour))
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, session, got)
+	assert.ErrorIs(t, expiredErr, core.ErrLTISessionNotFound)
+	assert.Equal(t, int64(2), deleted)
+}
@@ -0,0 +1,98 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/store"
+)
+
+func TestMigrations_RoundTripLeavesSchemaIdentical(t *testing.T) {
+	ctx := context.Background()
+
+	database := newTestDatabase(t, ctx)
+
+	migrator, err := database.Migrator(ctx)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	// Arrange
+	require.NoError(t, migrator.Up(ctx))
+	migrated := schemaSnapshot(t, ctx, database)
+	require.NotEmpty(t, migrated)
+
+	// Act
+	require.NoError(t, migrator.Goto(ctx, 0))
+	rolledBack := schemaSnapshot(t, ctx, database)
+	require.NoError(t, migrator.Up(ctx))
+	remigrated := schemaSnapshot(t, ctx, database)
+
+	// Assert
+	assert.Empty(t, rolledBack, "down migrations should remove every object")
+	assert.Equal(t, migrated, remigrated)
+
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, status.Dirty)
+	assert.Equal(t, database.LatestMigrationVersion(), status.Version)
+	for _, migration := range status.Migrations {
+		assert.True(t, migration.Applied, "migration %d", migration.Version)
+		assert.NotNil(t, migration.AppliedAt, "migration %d", migration.Version)
+	}
+}
+
+// schemaSnapshot describes the public schema's columns, constraints,
+// indexes, triggers and functions, leaving out the migrations' own tables.
+// On SQLite it lists the statements that created each object.
+func schemaSnapshot(t *testing.T, ctx context.Context, database *store.Database) []string {
+	t.Helper()
+
+	query := `
+		SELECT 'column ' || table_name || '.' || column_name || ' ' || data_type || ' ' ||
+			is_nullable || ' ' || COALESCE(column_default, '')
+		FROM information_schema.columns
+		WHERE table_schema = 'public' AND table_name NOT LIKE 'schema_migrations%'
+		UNION ALL
+		SELECT 'constraint ' || conrelid::regclass::text || ' ' || conname || ' ' || pg_get_constraintdef(oid)
+		FROM pg_constraint
+		WHERE connamespace = 'public'::regnamespace AND conrelid::regclass::text NOT LIKE 'schema_migrations%'
+		UNION ALL
+		SELECT 'index ' || indexdef
+		FROM pg_indexes
+		WHERE schemaname = 'public' AND tablename NOT LIKE 'schema_migrations%'
+		UNION ALL
+		SELECT 'trigger ' || pg_get_triggerdef(oid)
+		FROM pg_trigger
+		WHERE NOT tgisinternal
+		UNION ALL
+		SELECT 'function ' || pg_get_functiondef(p.oid)
+		FROM pg_proc p
+		WHERE p.pronamespace = 'public'::regnamespace
+		ORDER BY 1`
+	if database.Dialect().Name() == "sqlite" {
+		query = `
+			SELECT type || ' ' || name || ' ' || COALESCE(sql, '')
+			FROM sqlite_master
+			WHERE tbl_name NOT LIKE 'schema_migrations%' AND name NOT LIKE 'sqlite_%'
+			ORDER BY 1`
+	}
+
+	rows, err := database.Query(ctx, "test.schema_snapshot", query)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var snapshot []string
+	for rows.Next() {
+		var line string
+		require.NoError(t, rows.Scan(&line))
+		snapshot = append(snapshot, strings.TrimSpace(line))
+	}
+	require.NoError(t, rows.Err())
+	return snapshot
+}
@@ -0,0 +1,904 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+)
+
+func TestProjectStore_List_FiltersByTags(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+
+	for _, tags := range [][]string{{"math", "algebra"}, {"math"}, {"history"}, nil} {
+		_, err := projects.Create(ctx, "Tagged", nil, tags)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name          string
+		tags          []string
+		expectedTotal int
+	}{
+		{"one tag", []string{"math"}, 2},
+		{"every tag must match", []string{"math", "algebra"}, 1},
+		{"unknown tag", []string{"geometry"}, 0},
+		{"no tags", nil, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			page, err := projects.List(ctx, core.ListOptions{Tags: tt.tags, Limit: 10})
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedTotal, page.Total)
+			assert.Len(t, page.Projects, tt.expectedTotal)
+			for _, project := range page.Projects {
+				assert.Subset(t, project.Tags, tt.tags)
+			}
+		})
+	}
+}
+
+func TestProjectStore_List_FiltersByTagsAndStatus(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+
+	for _, fixture := range []struct {
+		tags      []string
+		published bool
+	}{
+		{[]string{"math", "algebra"}, true},
+		{[]string{"math"}, true},
+		{[]string{"math"}, false},
+		{[]string{"history"}, true},
+		{nil, false},
+	} {
+		project, err := projects.Create(ctx, "Mixed", nil, fixture.tags)
+		require.NoError(t, err)
+		if fixture.published {
+			_, err = projects.Publish(ctx, project.ID)
+			require.NoError(t, err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		tags          []string
+		status        core.ProjectStatus
+		expectedTotal int
+	}{
+		{"published", nil, core.ProjectStatusPublished, 3},
+		{"drafts", nil, core.ProjectStatusDraft, 2},
+		{"all", nil, "", 5},
+		{"published with a tag", []string{"math"}, core.ProjectStatusPublished, 2},
+		{"drafts with a tag", []string{"math"}, core.ProjectStatusDraft, 1},
+		{"drafts with another tag", []string{"history"}, core.ProjectStatusDraft, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			page, err := projects.List(ctx, core.ListOptions{Tags: tt.tags, Status: tt.status, Limit: 1})
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedTotal, page.Total, "the total counts past the page")
+			for _, project := range page.Projects {
+				assert.Subset(t, project.Tags, tt.tags)
+				if tt.status != "" {
+					assert.Equal(t, tt.status == core.ProjectStatusPublished, project.PublishedAt != nil)
+				}
+			}
+		})
+	}
+}
+
+func TestProjectStore_Delete_HidesTheProjectAndItsItems(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 3)
+	listed, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+
+	// Act
+	err = projects.Delete(ctx, projectID)
+
+	// Assert
+	require.NoError(t, err)
+	_, err = projects.GetByID(ctx, projectID)
+	assert.ErrorIs(t, err, core.ErrProjectNotFound)
+	_, err = items.GetByID(ctx, projectID, listed[0].ID)
+	assert.ErrorIs(t, err, core.ErrItemNotFound, "a deleted project's items are left out too")
+	assert.ErrorIs(t, projects.Delete(ctx, projectID), core.ErrProjectNotFound)
+
+	page, err := projects.List(ctx, core.ListOptions{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page.Projects)
+	page, err = projects.List(ctx, core.ListOptions{Limit: 10, IncludeDeleted: true})
+	require.NoError(t, err)
+	require.Len(t, page.Projects, 1)
+	assert.NotNil(t, page.Projects[0].DeletedAt)
+}
+
+func TestProjectStore_RestoreAndPurge(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+	items := store.NewItemStore(database)
+	itemService := core.NewItemService(items, projects)
+	projectID := createItems(t, ctx, database, 3)
+	live, err := projects.Create(ctx, "Live", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, projects.Delete(ctx, projectID))
+
+	// Act
+	trash, err := projects.List(ctx, core.ListOptions{Limit: 10, Deleted: true})
+	require.NoError(t, err)
+	_, createErr := itemService.Create(ctx, projectID, types.ItemTypeTitle, "Late", map[string]interface{}{}, 4, false, nil, nil)
+	_, publishErr := projects.Publish(ctx, projectID)
+	_, restoreLiveErr := projects.Restore(ctx, live.ID)
+	purgeLiveErr := projects.Purge(ctx, live.ID)
+	restored, err := projects.Restore(ctx, projectID)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, trash.Projects, 1, "the trash holds deleted projects only")
+	assert.Equal(t, projectID, trash.Projects[0].ID)
+	assert.Equal(t, 1, trash.Total)
+	assert.ErrorIs(t, createErr, core.ErrProjectNotFound, "a deleted project takes no items")
+	assert.ErrorIs(t, publishErr, core.ErrProjectNotFound)
+	assert.ErrorIs(t, restoreLiveErr, core.ErrProjectNotFound, "a live project is not in the trash")
+	assert.ErrorIs(t, purgeLiveErr, core.ErrProjectNotFound, "a live project is never purged")
+
+	assert.Nil(t, restored.DeletedAt)
+	listed, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	assert.Len(t, listed, 3, "the items come back with the project")
+
+	// A purge removes the project and its items for good
+	require.NoError(t, projects.Delete(ctx, projectID))
+	require.NoError(t, projects.Purge(ctx, projectID))
+	assert.ErrorIs(t, projects.Purge(ctx, projectID), core.ErrProjectNotFound)
+	_, err = projects.Restore(ctx, projectID)
+	assert.ErrorIs(t, err, core.ErrProjectNotFound)
+	var remaining int
+	require.NoError(t, database.DB().QueryRowContext(ctx, "SELECT COUNT(*) FROM items WHERE project_id = $1", projectID).Scan(&remaining))
+	assert.Zero(t, remaining)
+}
+
+func TestProjectStore_CountsItems(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+	items := store.NewItemStore(database)
+	projectID := createItems(t, ctx, database, 3)
+	empty, err := projects.Create(ctx, "Empty", nil, nil)
+	require.NoError(t, err)
+
+	listed, err := items.ListByProject(ctx, projectID)
+	require.NoError(t, err)
+	require.NoError(t, items.Delete(ctx, projectID, listed[0].ID))
+
+	// Act
+	project, err := projects.GetByID(ctx, projectID)
+	require.NoError(t, err)
+	page, err := projects.List(ctx, core.ListOptions{Limit: 10})
+	require.NoError(t, err)
+
+	// Assert
+	require.NotNil(t, project.ItemCount)
+	assert.Equal(t, 2, *project.ItemCount, "deleted items are not counted")
+	counts := make(map[string]int)
+	for _, listedProject := range page.Projects {
+		require.NotNil(t, listedProject.ItemCount)
+		counts[listedProject.ID] = *listedProject.ItemCount
+	}
+	assert.Equal(t, map[string]int{projectID: 2, empty.ID: 0}, counts)
+}
+
+func TestProjectStore_Duplicate(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+	items := store.NewItemStore(database)
+	description := "Tides and currents"
+	source, err := projects.Create(ctx, "Tide Tables", &description, []string{"sea", "math"})
+	require.NoError(t, err)
+	_, err = projects.Publish(ctx, source.ID)
+	require.NoError(t, err)
+	explanation := "High tide comes twice a day."
+	_, err = items.Create(ctx, source.ID, types.ItemTypeChoice, "Tides a day", json.RawMessage(`{"choices":[{"id":"a","text":"Two","correct":true}]}`), 0, true, intPtr(5), &explanation)
+	require.NoError(t, err)
+	_, err = items.Create(ctx, source.ID, types.ItemTypeTitle, "Intro", json.RawMessage(`{}`), 4, false, nil, nil)
+	require.NoError(t, err)
+	deleted, err := items.Create(ctx, source.ID, types.ItemTypeTitle, "Outro", json.RawMessage(`{}`), 7, false, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, items.Delete(ctx, source.ID, deleted.ID))
+
+	// Act
+	copied, err := projects.Duplicate(ctx, source.ID, "Tide Tables (copy)")
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotEqual(t, source.ID, copied.ID)
+	assert.Equal(t, "Tide Tables (copy)", copied.Title)
+	assert.Equal(t, &description, copied.Description)
+	assert.Equal(t, []string{"sea", "math"}, copied.Tags)
+	assert.Nil(t, copied.PublishedAt, "the copy is a draft")
+
+	original, err := items.ListByProject(ctx, source.ID)
+	require.NoError(t, err)
+	copies, err := items.ListByProject(ctx, copied.ID)
+	require.NoError(t, err)
+	require.Len(t, copies, 2, "deleted items are not copied")
+	for i, item := range copies {
+		assert.NotEqual(t, original[i].ID, item.ID)
+		assert.Equal(t, copied.ID, item.ProjectID)
+		assert.Equal(t, original[i].Type, item.Type)
+		assert.Equal(t, original[i].Title, item.Title)
+		assert.JSONEq(t, string(original[i].Content), string(item.Content))
+		assert.Equal(t, original[i].Position, item.Position)
+		assert.Equal(t, original[i].Required, item.Required)
+		assert.Equal(t, original[i].Points, item.Points)
+		assert.Equal(t, original[i].Explanation, item.Explanation)
+	}
+}
+
+func TestProjectStore_Duplicate_MissingProject(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+	deleted, err := projects.Create(ctx, "Old Charts", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, projects.Delete(ctx, deleted.ID))
+
+	// Act
+	_, deletedErr := projects.Duplicate(ctx, deleted.ID, "Old Charts (copy)")
+	_, missingErr := projects.Duplicate(ctx, uuid.NewString(), "Missing (copy)")
+
+	// Assert
+	assert.ErrorIs(t, deletedErr, core.ErrProjectNotFound)
+	assert.ErrorIs(t, missingErr, core.ErrProjectNotFound)
+	page, err := projects.List(ctx, core.ListOptions{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page.Projects)
+}
+
+func TestProjectStore_Duplicate_IsAllOrNothing(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+	projectID := createItems(t, ctx, database, 3)
+	rejectItemsTitled(t, ctx, database, "Question 2")
+
+	// Act
+	_, err := projects.Duplicate(ctx, projectID, "Aggregates (copy)")
+
+	// Assert
+	require.Error(t, err)
+	page, err := projects.List(ctx, core.ListOptions{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Projects, 1, "the copy is rolled back with its items")
+	var items int
+	require.NoError(t, database.QueryRow(ctx, "items.count_all", `SELECT COUNT(*) FROM items`).Scan(&items))
+	assert.Equal(t, 3, items)
+}
+
+// rejectItemsTitled makes every insert of an item titled title fail from
+// now on
+func rejectItemsTitled(t *testing.T, ctx context.Context, database *store.Database, title string) {
+	t.Helper()
+
+	statements := []string{`
+		CREATE TRIGGER reject_items BEFORE INSERT ON items
+		WHEN NEW.title = '` + title + `'
+		BEGIN SELECT RAISE(ABORT, 'item rejected'); END
+	`}
+	if database.Dialect().Name() == "postgres" {
+		statements = []string{`
+			CREATE FUNCTION reject_items() RETURNS trigger AS $$
+			BEGIN
+				IF NEW.title = '` + title + `' THEN
+					RAISE EXCEPTION 'item rejected';
+				END IF;
+				RETURN NEW;
+			END
+			$$ LANGUAGE plpgsql
+		`, `CREATE TRIGGER reject_items BEFORE INSERT ON items FOR EACH ROW EXECUTE FUNCTION reject_items()`}
+	}
+	for _, statement := range statements {
+		_, err := database.Exec(ctx, "items.reject_trigger", statement)
+		require.NoError(t, err)
+	}
+}
+
+func TestProjectStore_Publish_KeepsPublications(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+	items := store.NewItemStore(database)
+	project, err := projects.Create(ctx, "Tide Tables", nil, []string{"sea"})
+	require.NoError(t, err)
+	item, err := items.Create(ctx, project.ID, types.ItemTypeTextEntry, "High tides a day?", json.RawMessage(`{"correct_answer":"two"}`), 0, true, intPtr(1), nil)
+	require.NoError(t, err)
+	deleted, err := projects.Create(ctx, "Old Charts", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, projects.Delete(ctx, deleted.ID))
+
+	// Act
+	first, firstErr := projects.Publish(ctx, project.ID)
+	_, err = items.Update(ctx, project.ID, item.ID, item.Type, "Low tides a day?", item.Content, item.Position, true, intPtr(1), nil, nil)
+	require.NoError(t, err)
+	_, err = projects.Update(ctx, project.ID, "Tide Charts", nil, nil, nil)
+	require.NoError(t, err)
+	second, secondErr := projects.Publish(ctx, project.ID)
+	got, getErr := projects.GetByID(ctx, project.ID)
+	frozen, frozenErr := projects.GetPublication(ctx, project.ID, 1)
+	_, unknownErr := projects.GetPublication(ctx, project.ID, 3)
+	listed, total, listErr := projects.ListPublications(ctx, project.ID, 10, 0)
+	_, deletedErr := projects.Publish(ctx, deleted.ID)
+	_, missingErr := projects.Publish(ctx, uuid.NewString())
+	_, _, missingListErr := projects.ListPublications(ctx, uuid.NewString(), 10, 0)
+
+	// Assert
+	require.NoError(t, firstErr)
+	require.NotNil(t, first.PublishedAt)
+	require.NotNil(t, first.LatestPublishedVersion)
+	assert.Equal(t, 1, *first.LatestPublishedVersion)
+	require.NoError(t, secondErr)
+	require.NotNil(t, second.LatestPublishedVersion)
+	assert.Equal(t, 2, *second.LatestPublishedVersion, "publishing again publishes the next version")
+	assert.Equal(t, first.PublishedAt, second.PublishedAt, "published_at stays the first publication's")
+	require.NoError(t, getErr)
+	require.NotNil(t, got.LatestPublishedVersion)
+	assert.Equal(t, 2, *got.LatestPublishedVersion)
+
+	require.NoError(t, frozenErr)
+	assert.Equal(t, 1, frozen.Version)
+	assert.Equal(t, "Tide Tables", frozen.Project.Title, "later edits don't change a publication")
+	assert.Equal(t, []string{"sea"}, frozen.Project.Tags)
+	assert.Equal(t, first.Version, frozen.Project.Version)
+	require.Len(t, frozen.Items, 1)
+	assert.Equal(t, item.ID, frozen.Items[0].ID)
+	assert.Equal(t, project.ID, frozen.Items[0].ProjectID)
+	assert.Equal(t, "High tides a day?", frozen.Items[0].Title)
+	assert.JSONEq(t, `{"correct_answer":"two"}`, string(frozen.Items[0].Content))
+	assert.Equal(t, intPtr(1), frozen.Items[0].Points)
+	assert.ErrorIs(t, unknownErr, core.ErrPublicationNotFound)
+
+	require.NoError(t, listErr)
+	assert.Equal(t, 2, total)
+	require.Len(t, listed, 2)
+	assert.Equal(t, 2, listed[0].Version, "newest first")
+	assert.Nil(t, listed[0].Items, "lists leave the items out")
+	assert.False(t, listed[0].PublishedAt.Before(listed[1].PublishedAt))
+
+	assert.ErrorIs(t, deletedErr, core.ErrProjectNotFound)
+	assert.ErrorIs(t, missingErr, core.ErrProjectNotFound)
+	assert.ErrorIs(t, missingListErr, core.ErrProjectNotFound)
+}
+
+func TestProjectStore_Versions(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+	project, err := projects.Create(ctx, "Tide Tables", nil, nil)
+	require.NoError(t, err)
+	stale := project.Version
+
+	// Act
+	updated, updateErr := projects.Update(ctx, project.ID, "Tide Charts", nil, nil, &stale)
+	_, conflictErr := projects.Update(ctx, project.ID, "Overwritten", nil, nil, &stale)
+	_, missingErr := projects.Update(ctx, uuid.NewString(), "Missing", nil, nil, &stale)
+	published, publishErr := projects.Publish(ctx, project.ID)
+	deleteErr := projects.Delete(ctx, project.ID)
+	restored, restoreErr := projects.Restore(ctx, project.ID)
+
+	// Assert
+	assert.Equal(t, 1, stale, "projects start at version 1")
+	require.NoError(t, updateErr)
+	assert.Equal(t, 2, updated.Version)
+	assert.ErrorIs(t, conflictErr, core.ErrVersionConflict)
+	assert.ErrorIs(t, missingErr, core.ErrProjectNotFound, "a missing project is not reported as a conflict")
+	require.NoError(t, publishErr)
+	assert.Equal(t, "Tide Charts", published.Title, "the conflicting update is not written")
+	assert.Equal(t, 3, published.Version)
+	require.NoError(t, deleteErr)
+	require.NoError(t, restoreErr)
+	assert.Equal(t, 5, restored.Version, "deleting and restoring are writes")
+}
+
+func TestProjectService_SearchByTitle_MatchesWildcardsLiterally(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	service := core.NewProjectService(store.NewProjectStore(database), nil)
+	description := "Scores 100% of the time"
+	_, err := service.Create(ctx, "Percentages", &description, nil)
+	require.NoError(t, err)
+	for _, title := range []string{"snake_case names", "Snakes"} {
+		_, err := service.Create(ctx, title, nil, nil)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		term     string
+		expected []string
+	}{
+		{"%", []string{"Percentages"}},
+		{"100%", []string{"Percentages"}},
+		{"_", []string{"snake_case names"}},
+		{"SNAKE", []string{"Snakes", "snake_case names"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			// Act
+			projects, total, err := service.SearchByTitle(ctx, tt.term, 10, 0)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.expected), total)
+			titles := make([]string, len(projects))
+			for i, project := range projects {
+				titles[i] = project.Title
+			}
+			assert.ElementsMatch(t, tt.expected, titles)
+		})
+	}
+}
+
+func TestProjectService_Publish_ChecksItems(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projectStore := store.NewProjectStore(database)
+	itemStore := store.NewItemStore(database)
+	service := core.NewProjectService(projectStore, nil)
+	service.SetItems(itemStore)
+
+	empty, err := projectStore.Create(ctx, "Blank Slate", nil, nil)
+	require.NoError(t, err)
+	project, err := projectStore.Create(ctx, "Star Charts", nil, nil)
+	require.NoError(t, err)
+	points := 1
+	choice, err := itemStore.Create(ctx, project.ID, types.ItemTypeChoice, "Brightest star?", json.RawMessage(`{"choices":[{"id":"a","text":"Sirius"}]}`), 0, true, &points, nil)
+	require.NoError(t, err)
+
+	// Act
+	_, emptyErr := service.Publish(ctx, empty.ID)
+	_, brokenErr := service.Publish(ctx, project.ID)
+	_, err = itemStore.Update(ctx, project.ID, choice.ID, choice.Type, choice.Title, json.RawMessage(`{"choices":[{"id":"a","text":"Sirius","correct":true}]}`), 0, true, &points, nil, nil)
+	require.NoError(t, err)
+	published, err := service.Publish(ctx, project.ID)
+
+	// Assert
+	var notPublishable *core.ProjectNotPublishableError
+	require.True(t, errors.As(emptyErr, &notPublishable))
+	assert.Equal(t, []core.PublishProblem{{Reason: core.PublishProblemNoItems}}, notPublishable.Problems)
+	require.True(t, errors.As(brokenErr, &notPublishable))
+	assert.Equal(t, []core.PublishProblem{{ItemID: choice.ID, Reason: core.PublishProblemNoCorrectChoice}}, notPublishable.Problems)
+
+	require.NoError(t, err)
+	assert.NotNil(t, published.PublishedAt, "fixing the item makes the project publishable")
+	republished, err := service.Publish(ctx, project.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, *republished.LatestPublishedVersion)
+}
+
+func TestProjectService_GetPublic(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projectStore := store.NewProjectStore(database)
+	itemStore := store.NewItemStore(database)
+	service := core.NewProjectService(projectStore, nil)
+	service.SetItems(itemStore)
+	org, err := store.NewOrganizationStore(database).Create(ctx, "Observatory", nil)
+	require.NoError(t, err)
+	orgCtx := store.SystemScope(core.WithOrgID(ctx, org.ID))
+
+	project, err := projectStore.Create(orgCtx, "Star Charts", nil, nil)
+	require.NoError(t, err)
+	choice, err := itemStore.Create(orgCtx, project.ID, types.ItemTypeChoice, "Brightest star?", json.RawMessage(`{"choices":[{"id":"a","text":"Sirius","correct":true},{"id":"b","text":"Vega"}]}`), 0, true, intPtr(1), stringPtr("Sirius outshines every other star"))
+	require.NoError(t, err)
+	_, err = projectStore.Publish(orgCtx, project.ID)
+	require.NoError(t, err)
+	_, err = itemStore.Update(orgCtx, project.ID, choice.ID, choice.Type, "Dimmest star?", choice.Content, 0, true, intPtr(1), nil, nil)
+	require.NoError(t, err)
+
+	legacy, err := projectStore.Create(ctx, "Moon Phases", nil, nil)
+	require.NoError(t, err)
+	_, err = itemStore.Create(ctx, legacy.ID, types.ItemTypeTextEntry, "Phase after new moon?", json.RawMessage(`{"multiline":false,"correct_answer":"waxing crescent"}`), 0, true, intPtr(1), nil)
+	require.NoError(t, err)
+	_, err = projectStore.Publish(ctx, legacy.ID)
+	require.NoError(t, err)
+	_, err = database.Exec(ctx, "publications.forget", `DELETE FROM publications WHERE project_id = $1`, legacy.ID)
+	require.NoError(t, err)
+
+	draft, err := projectStore.Create(orgCtx, "Comet Orbits", nil, nil)
+	require.NoError(t, err)
+	deleted, err := projectStore.Create(ctx, "Old Charts", nil, nil)
+	require.NoError(t, err)
+	_, err = itemStore.Create(ctx, deleted.ID, types.ItemTypeTitle, "Welcome", nil, 0, false, nil, nil)
+	require.NoError(t, err)
+	_, err = projectStore.Publish(ctx, deleted.ID)
+	require.NoError(t, err)
+	require.NoError(t, projectStore.Delete(ctx, deleted.ID))
+
+	// Act
+	public, publicErr := service.GetPublic(ctx, project.ID)
+	fallback, fallbackErr := service.GetPublic(ctx, legacy.ID)
+	_, draftErr := service.GetPublic(ctx, draft.ID)
+	_, deletedErr := service.GetPublic(ctx, deleted.ID)
+	_, missingErr := service.GetPublic(ctx, uuid.NewString())
+
+	// Assert
+	require.NoError(t, publicErr, "learners need no membership of the project's organization")
+	assert.Equal(t, 1, public.Version)
+	require.Len(t, public.Items, 1)
+	assert.Equal(t, "Brightest star?", public.Items[0].Title, "the latest publication is served, not later edits")
+	assert.JSONEq(t, `{"choices":[{"id":"a","text":"Sirius"},{"id":"b","text":"Vega"}]}`, string(public.Items[0].Content))
+	assert.Nil(t, public.Items[0].Explanation)
+
+	require.NoError(t, fallbackErr)
+	assert.Equal(t, 0, fallback.Version, "projects without publications are served live")
+	require.Len(t, fallback.Items, 1)
+	assert.JSONEq(t, `{"multiline":false}`, string(fallback.Items[0].Content))
+
+	assert.ErrorIs(t, draftErr, core.ErrProjectNotFound)
+	assert.ErrorIs(t, deletedErr, core.ErrProjectNotFound)
+	assert.ErrorIs(t, missingErr, core.ErrProjectNotFound)
+}
+
+func TestProjectStore_Publish_ConcurrentCallsPublishOneVersionEach(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+	project, err := projects.Create(ctx, "Tide Tables", nil, nil)
+	require.NoError(t, err)
+
+	const callers = 10
+	start := make(chan struct{})
+	versions := make(chan int, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			published, err := projects.Publish(ctx, project.ID)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			versions <- *published.LatestPublishedVersion
+		}()
+	}
+
+	// Act
+	close(start)
+	wg.Wait()
+	close(versions)
+
+	// Assert
+	var got []int
+	for version := range versions {
+		got = append(got, version)
+	}
+	assert.ElementsMatch(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, got)
+	_, total, err := projects.ListPublications(ctx, project.ID, 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, callers, total)
+}
+
+func TestProjectStore_List_TagFilterUsesIndex(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	requirePostgres(t, database)
+
+	_, err := database.Exec(ctx, "projects.seed", `
+		INSERT INTO projects (title, tags_arr)
+		SELECT 'Project ' || i, CASE WHEN i % 1000 = 0 THEN ARRAY['rare'] ELSE ARRAY['common'] END
+		FROM generate_series(1, 20000) AS i`)
+	require.NoError(t, err)
+	_, err = database.Exec(ctx, "projects.analyze", `ANALYZE projects`)
+	require.NoError(t, err)
+
+	// Act
+	rows, err := database.Query(ctx, "projects.explain",
+		`EXPLAIN SELECT id FROM projects WHERE tags_arr @> $1::text[]`, []string{"rare"})
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var line string
+		require.NoError(t, rows.Scan(&line))
+		plan = append(plan, line)
+	}
+	require.NoError(t, rows.Err())
+
+	// Assert
+	assert.Contains(t, strings.Join(plan, "\n"), "idx_projects_tags_arr")
+}
+
+func TestProjectStore_TagsArrayBackfill(t *testing.T) {
+	ctx := context.Background()
+	database := newTestDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+
+	// Arrange: projects from before tags_arr, whose JSON tags may be NULL,
+	// a JSON null, which Create stored for no tags, or empty
+	migrator, err := database.Migrator(ctx)
+	require.NoError(t, err)
+	defer migrator.Close()
+	beforeTagsArray := uint(9)
+	if database.Dialect().Name() == "sqlite" {
+		beforeTagsArray = 3
+	}
+	require.NoError(t, migrator.Goto(ctx, beforeTagsArray))
+
+	legacy := []struct {
+		name     string
+		tags     interface{}
+		expected []string
+	}{
+		{"NULL", nil, []string{}},
+		{"JSON null", "null", []string{}},
+		{"empty", "[]", []string{}},
+		{"tagged", `["math","algebra"]`, []string{"math", "algebra"}},
+	}
+	ids := make([]string, len(legacy))
+	for i, project := range legacy {
+		ids[i] = uuid.NewString()
+		_, err := database.Exec(ctx, "projects.seed",
+			`INSERT INTO projects (id, title, tags) VALUES ($1, $2, $3)`, ids[i], project.name, project.tags)
+		require.NoError(t, err)
+	}
+
+	// Act
+	require.NoError(t, migrator.Up(ctx))
+
+	// Assert
+	var untagged int
+	require.NoError(t, database.QueryRow(ctx, "projects.count_null_tags",
+		`SELECT COUNT(*) FROM projects WHERE tags_arr IS NULL`).Scan(&untagged))
+	assert.Zero(t, untagged)
+	for i, project := range legacy {
+		got, err := projects.GetByID(ctx, ids[i])
+		require.NoError(t, err)
+		assert.Equal(t, project.expected, got.Tags, project.name)
+	}
+
+	page, err := projects.List(ctx, core.ListOptions{Tags: []string{"algebra"}, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Projects, 1)
+	assert.Equal(t, ids[3], page.Projects[0].ID)
+}
+
+func TestProjectStore_WritesLegacyTags(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database, err := store.NewDatabase(ctx, store.DatabaseConfig{
+		URL:             newTestDatabaseURL(t, ctx),
+		MaxOpenConns:    5,
+		MaxIdleConns:    5,
+		ConnectTimeout:  30 * time.Second,
+		WriteLegacyTags: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+	require.NoError(t, database.Migrate(ctx))
+	projects := store.NewProjectStore(database)
+
+	legacyTags := func(id string) string {
+		t.Helper()
+		var tags string
+		require.NoError(t, database.QueryRow(ctx, "projects.legacy_tags",
+			`SELECT tags FROM projects WHERE id = $1`, id).Scan(&tags))
+		return tags
+	}
+
+	// Act
+	project, err := projects.Create(ctx, "Tagged", nil, []string{"math"})
+	require.NoError(t, err)
+	created := legacyTags(project.ID)
+	_, err = projects.Update(ctx, project.ID, "Untagged", nil, nil, nil)
+	require.NoError(t, err)
+	updated := legacyTags(project.ID)
+
+	// Assert
+	assert.JSONEq(t, `["math"]`, created)
+	assert.JSONEq(t, `[]`, updated)
+}
+
+// listAfter reads every page of a project list, each after the cursor of
+// the previous one
+func listAfter(t *testing.T, ctx context.Context, projects *store.ProjectStore, opts core.ListOptions) []string {
+	t.Helper()
+
+	var ids []string
+	for {
+		page, err := projects.List(ctx, opts)
+		require.NoError(t, err)
+		require.Equal(t, -1, page.Total, "pages after a cursor are not counted")
+		for _, project := range page.Projects {
+			ids = append(ids, project.ID)
+		}
+		if !page.HasMore {
+			return ids
+		}
+		require.NotEmpty(t, page.Projects)
+		opts.After = core.CursorAfter(page.Projects[len(page.Projects)-1])
+	}
+}
+
+func TestProjectStore_List_KeysetPagesBreakTiesByID(t *testing.T) {
+	// Arrange: every project shares its sort keys
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+
+	for i := 0; i < 25; i++ {
+		_, err := projects.Create(ctx, "Project", nil, nil)
+		require.NoError(t, err)
+	}
+	tied := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	_, err := database.Exec(ctx, "projects.tie", `UPDATE projects SET title = 'Tied', created_at = $1, updated_at = $1`, tied)
+	require.NoError(t, err)
+
+	for _, sort := range []core.ProjectSort{
+		core.ProjectSortCreatedAt, core.ProjectSortUpdatedAt, core.ProjectSortTitle,
+		core.ProjectSortCreatedAtAsc, core.ProjectSortUpdatedAtAsc, core.ProjectSortTitleDesc,
+	} {
+		t.Run(string(sort), func(t *testing.T) {
+			all, err := projects.List(ctx, core.ListOptions{Sort: sort, Limit: 100})
+			require.NoError(t, err)
+			require.Len(t, all.Projects, 25)
+			require.False(t, all.HasMore)
+			var expected []string
+			for _, project := range all.Projects {
+				expected = append(expected, project.ID)
+			}
+
+			// Act
+			first, err := projects.List(ctx, core.ListOptions{Sort: sort, Limit: 4})
+			require.NoError(t, err)
+			ids := listAfter(t, ctx, projects, core.ListOptions{Sort: sort, Limit: 4, After: core.CursorAfter(first.Projects[3])})
+
+			// Assert
+			assert.Equal(t, 25, first.Total)
+			assert.True(t, first.HasMore)
+			assert.Equal(t, expected, append([]string{first.Projects[0].ID, first.Projects[1].ID, first.Projects[2].ID, first.Projects[3].ID}, ids...))
+		})
+	}
+}
+
+func TestProjectStore_List_KeysetPagesUnderConcurrentInserts(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projects := store.NewProjectStore(database)
+
+	existing := make(map[string]bool)
+	for i := 0; i < 30; i++ {
+		project, err := projects.Create(ctx, "Existing", nil, nil)
+		require.NoError(t, err)
+		existing[project.ID] = true
+	}
+
+	// Projects are inserted on another goroutine, at least one between two
+	// pages
+	insertCtx, stopInserts := context.WithCancel(ctx)
+	defer stopInserts()
+	inserted := make(chan struct{})
+	go func() {
+		for {
+			if _, err := projects.Create(insertCtx, "Inserted", nil, nil); err != nil {
+				return
+			}
+			select {
+			case inserted <- struct{}{}:
+			case <-insertCtx.Done():
+				return
+			}
+		}
+	}()
+
+	// Act
+	var ids []string
+	opts := core.ListOptions{Limit: 4}
+	for {
+		page, err := projects.List(ctx, opts)
+		require.NoError(t, err)
+		for _, project := range page.Projects {
+			ids = append(ids, project.ID)
+		}
+		if !page.HasMore {
+			break
+		}
+		opts.After = core.CursorAfter(page.Projects[len(page.Projects)-1])
+		<-inserted
+	}
+
+	// Assert: every project that existed is read exactly once
+	seen := make(map[string]int)
+	for _, id := range ids {
+		seen[id]++
+	}
+	for id, count := range seen {
+		assert.Equal(t, 1, count, "project %s repeated", id)
+	}
+	for id := range existing {
+		assert.Contains(t, seen, id, "project %s skipped", id)
+	}
+}
+
+func TestProjectService_Purge_RemovesStoredAssets(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projectStore := store.NewProjectStore(database)
+	storage := store.NewLocalStorage(t.TempDir(), "/files")
+	files := core.NewStorageService(storage, core.StorageConfig{MaxFileSize: 1024})
+	service := core.NewProjectService(projectStore, files)
+
+	project, err := projectStore.Create(ctx, "Star Charts", nil, nil)
+	require.NoError(t, err)
+	other, err := projectStore.Create(ctx, "Tide Tables", nil, nil)
+	require.NoError(t, err)
+	for _, projectID := range []string{project.ID, project.ID, other.ID} {
+		_, err := files.UploadFile(ctx, projectID, core.FileUpload{OriginalName: uuid.NewString() + ".png", ContentType: "image/png", Size: 8, Reader: strings.NewReader("\x89PNG\r\n\x1a\n")})
+		require.NoError(t, err)
+	}
+	projectPrefix := "projects/" + project.ID + "/assets/"
+
+	// Act
+	require.NoError(t, service.Delete(ctx, project.ID))
+	trashed, err := storage.List(ctx, projectPrefix, 0)
+	require.NoError(t, err)
+	require.NoError(t, service.Purge(ctx, project.ID))
+
+	// Assert
+	assert.Len(t, trashed, 2, "a project in the trash keeps its assets until it is purged")
+	purged, err := storage.List(ctx, projectPrefix, 0)
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+	kept, err := storage.List(ctx, "projects/"+other.ID+"/assets/", 0)
+	require.NoError(t, err)
+	assert.Len(t, kept, 1, "other projects keep theirs")
+}
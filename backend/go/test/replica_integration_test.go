@@ -0,0 +1,95 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// openReplicatedDatabase connects to the database at url with a read
+// replica at replicaURL, closed when the test ends
+func openReplicatedDatabase(t *testing.T, ctx context.Context, url, replicaURL string) *store.Database {
+	t.Helper()
+
+	database, err := store.NewDatabase(ctx, store.DatabaseConfig{
+		URL:            url,
+		ReplicaURL:     replicaURL,
+		MaxOpenConns:   5,
+		MaxIdleConns:   5,
+		ConnectTimeout: 30 * time.Second,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestReplica_ServesStaleReads(t *testing.T) {
+	// Arrange: the replica is a second connection to the same database, so
+	// it never lags
+	ctx := context.Background()
+	url := newTestDatabaseURL(t, ctx)
+	database := openReplicatedDatabase(t, ctx, url, url)
+	require.NoError(t, database.Migrate(ctx))
+	projects := store.NewProjectStore(database)
+	items := store.NewItemStore(database)
+
+	project, err := projects.Create(ctx, "Tide Tables", nil, nil)
+	require.NoError(t, err)
+	points := 3
+	_, err = items.Create(ctx, project.ID, types.ItemTypeTitle, "Welcome", nil, 0, false, &points, nil)
+	require.NoError(t, err)
+
+	// Act
+	page, err := projects.List(ctx, core.ListOptions{Limit: 10})
+	require.NoError(t, err)
+	count, err := items.CountByProject(ctx, project.ID)
+	require.NoError(t, err)
+	sum, err := items.SumPoints(ctx, project.ID)
+	require.NoError(t, err)
+
+	// Assert
+	require.NoError(t, database.ReplicaHealthCheck(ctx))
+	assert.NotSame(t, database.DB(), database.ReadDB())
+	require.Len(t, page.Projects, 1)
+	assert.Equal(t, project.ID, page.Projects[0].ID)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 3, sum)
+}
+
+func TestReplica_UnreachableReplicaFallsBackToPrimary(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	url := newTestDatabaseURL(t, ctx)
+	replicaURL := "postgres://provemyself@127.0.0.1:1/provemyself?sslmode=disable&connect_timeout=1"
+	if strings.HasPrefix(url, "sqlite://") {
+		replicaURL = "sqlite://" + filepath.Join(t.TempDir(), "missing", "replica.db")
+	}
+	database := openReplicatedDatabase(t, ctx, url, replicaURL)
+	require.NoError(t, database.Migrate(ctx))
+	projects := store.NewProjectStore(database)
+
+	_, err := projects.Create(ctx, "Tide Tables", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	healthErr := database.ReplicaHealthCheck(ctx)
+	page, err := projects.List(ctx, core.ListOptions{Limit: 10})
+
+	// Assert
+	var replicaErr *store.ReplicaError
+	assert.ErrorAs(t, healthErr, &replicaErr)
+	assert.Same(t, database.DB(), database.ReadDB())
+	require.NoError(t, err)
+	assert.Equal(t, 1, page.Total)
+}
@@ -0,0 +1,91 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// userScope is ctx in the organization orgID, acting for a user whose token
+// carries no organization
+func userScope(ctx context.Context, orgID, userID string) context.Context {
+	return core.WithAccessScope(core.WithOrgID(ctx, orgID), core.AccessScope{UserID: userID, Role: "user"})
+}
+
+func TestStores_NonMemberCannotReachForeignRows(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	orgs := store.NewOrganizationStore(database)
+	projects := store.NewProjectStore(database)
+	items := store.NewItemStore(database)
+
+	org, err := orgs.Create(ctx, "Lighthouse Keepers", nil)
+	require.NoError(t, err)
+	_, err = orgs.PutMember(ctx, org.ID, "member-1", core.MembershipRoleMember)
+	require.NoError(t, err)
+	memberCtx := userScope(ctx, org.ID, "member-1")
+	project, err := projects.Create(memberCtx, "Foghorns", nil, nil)
+	require.NoError(t, err)
+	item, err := items.Create(memberCtx, project.ID, types.ItemTypeTitle, "Welcome", nil, 0, false, nil, nil)
+	require.NoError(t, err)
+
+	// The handler scoped the request to the organization without checking
+	// the outsider's membership
+	outsiderCtx := userScope(ctx, org.ID, "outsider-1")
+
+	// Act
+	_, getProjectErr := projects.GetByID(outsiderCtx, project.ID)
+	page, listErr := projects.List(outsiderCtx, core.ListOptions{Limit: 10})
+	_, createProjectErr := projects.Create(outsiderCtx, "Intruder", nil, nil)
+	_, updateProjectErr := projects.Update(outsiderCtx, project.ID, "Defaced", nil, nil, nil)
+	_, publishErr := projects.Publish(outsiderCtx, project.ID)
+	deleteProjectErr := projects.Delete(outsiderCtx, project.ID)
+	_, getItemErr := items.GetByID(outsiderCtx, project.ID, item.ID)
+	listed, listItemsErr := items.ListByProject(outsiderCtx, project.ID)
+	_, createItemErr := items.Create(outsiderCtx, project.ID, types.ItemTypeTitle, "Intruder", nil, 1, false, nil, nil)
+	_, updateItemErr := items.Update(outsiderCtx, project.ID, item.ID, types.ItemTypeTitle, "Defaced", nil, 0, false, nil, nil, nil)
+	positionsErr := database.InTx(outsiderCtx, "items.update_positions", func(ctx context.Context) error {
+		return items.UpdatePositions(ctx, project.ID, []core.PositionUpdate{{ItemID: item.ID, Position: 5}})
+	})
+	deleteItemErr := items.Delete(outsiderCtx, project.ID, item.ID)
+
+	// Assert
+	assert.ErrorIs(t, getProjectErr, core.ErrProjectNotFound)
+	require.NoError(t, listErr)
+	assert.Empty(t, page.Projects)
+	assert.Zero(t, page.Total)
+	assert.ErrorIs(t, createProjectErr, core.ErrMembershipNotFound)
+	assert.ErrorIs(t, updateProjectErr, core.ErrProjectNotFound)
+	assert.ErrorIs(t, publishErr, core.ErrProjectNotFound)
+	assert.ErrorIs(t, deleteProjectErr, core.ErrProjectNotFound)
+	assert.ErrorIs(t, getItemErr, core.ErrItemNotFound)
+	require.NoError(t, listItemsErr)
+	assert.Empty(t, listed)
+	assert.ErrorIs(t, createItemErr, core.ErrProjectNotFound)
+	assert.ErrorIs(t, updateItemErr, core.ErrItemNotFound)
+	assert.ErrorIs(t, positionsErr, core.ErrItemNotFound)
+	assert.ErrorIs(t, deleteItemErr, core.ErrItemNotFound)
+
+	// Nothing the outsider tried changed the member's rows
+	got, err := projects.GetByID(memberCtx, project.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Foghorns", got.Title)
+	assert.Nil(t, got.PublishedAt)
+	gotItems, err := items.ListByProject(memberCtx, project.ID)
+	require.NoError(t, err)
+	require.Len(t, gotItems, 1)
+	assert.Equal(t, "Welcome", gotItems[0].Title)
+	assert.Equal(t, 0, gotItems[0].Position)
+	page, err = projects.List(store.SystemScope(core.WithOrgID(ctx, org.ID)), core.ListOptions{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, page.Total, "the outsider created no project")
+}
@@ -0,0 +1,127 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+	"github.com/provemyself/backend/internal/types"
+)
+
+// newSeedService seeds database through the real services, uploading the
+// sample files to a temporary directory
+func newSeedService(t *testing.T, database *store.Database, environment string) (*core.SeedService, *store.LocalStorage) {
+	t.Helper()
+
+	projectStore := store.NewProjectStore(database)
+	orgStore := store.NewOrganizationStore(database)
+	projects := core.NewProjectService(projectStore, nil)
+	projects.SetOrganizations(orgStore)
+	itemStore := store.NewItemStore(database)
+	projects.SetItems(itemStore)
+	items := core.NewItemService(itemStore, projectStore)
+	items.SetTransactor(database)
+	storage := store.NewLocalStorage(t.TempDir(), "http://localhost:8080/files")
+
+	return core.NewSeedService(environment, projects, items, orgStore, storage), storage
+}
+
+func TestSeed_IsIdempotent(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	seeder, storage := newSeedService(t, database, "development")
+
+	// Act
+	first, err := seeder.Seed(ctx)
+	require.NoError(t, err)
+	second, err := seeder.Seed(ctx)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Positive(t, first.Created)
+	assert.Zero(t, second.Created)
+	assert.Equal(t, first.Projects, second.Projects)
+	assert.Len(t, second.Projects, 10)
+	assert.Len(t, second.Users, 3)
+
+	for _, key := range second.Assets {
+		exists, err := storage.Exists(ctx, key)
+		require.NoError(t, err)
+		assert.True(t, exists, key)
+	}
+	assert.Contains(t, second.Assets, "projects/5eed0000-0000-4000-8000-000000001002/assets/plant-cell.svg")
+}
+
+func TestSeed_CreatesDocumentedFixtures(t *testing.T) {
+	// Arrange
+	ctx := core.WithAccessScope(context.Background(), core.AccessScope{System: true})
+	database := migratedDatabase(t, ctx)
+	seeder, _ := newSeedService(t, database, "development")
+	projectStore := store.NewProjectStore(database)
+	itemStore := store.NewItemStore(database)
+	orgStore := store.NewOrganizationStore(database)
+
+	// Act
+	result, err := seeder.Seed(ctx)
+	require.NoError(t, err)
+
+	// Assert
+	org, err := orgStore.GetByID(ctx, core.SeedOrganizationID)
+	require.NoError(t, err)
+	assert.Equal(t, "Harbor Point School", org.Name)
+	membership, err := orgStore.GetMembership(ctx, core.SeedOrganizationID, "dev-user-123")
+	require.NoError(t, err)
+	assert.Equal(t, core.MembershipRoleAdmin, membership.Role)
+
+	published, err := projectStore.GetByID(ctx, "5eed0000-0000-4000-8000-000000001003")
+	require.NoError(t, err)
+	assert.Equal(t, "World Capitals", published.Title)
+	assert.NotNil(t, published.PublishedAt)
+
+	itemTypes := map[types.ItemType]bool{}
+	for _, project := range result.Projects {
+		projectCtx := ctx
+		if project.ID == "5eed0000-0000-4000-8000-000000001010" {
+			// Staff Onboarding belongs to the fixture organization
+			projectCtx = core.WithOrgID(ctx, core.SeedOrganizationID)
+		}
+		items, err := itemStore.ListByProject(projectCtx, project.ID)
+		require.NoError(t, err)
+		assert.Len(t, items, project.Items, project.Title)
+		for _, item := range items {
+			itemTypes[item.Type] = true
+		}
+	}
+	assert.Len(t, itemTypes, 7)
+
+	hotspot, err := itemStore.ListByProject(ctx, "5eed0000-0000-4000-8000-000000001002")
+	require.NoError(t, err)
+	var content types.HotspotContent
+	require.NoError(t, json.Unmarshal(hotspot[1].Content, &content))
+	assert.Equal(t, "http://localhost:8080/files/projects/5eed0000-0000-4000-8000-000000001002/assets/plant-cell.svg", content.ImageURL)
+	assert.Equal(t, []float64{220, 200, 70}, content.Hotspots[0].Coords)
+}
+
+func TestSeed_RefusesProduction(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	seeder, _ := newSeedService(t, database, "production")
+
+	// Act
+	result, err := seeder.Seed(ctx)
+
+	// Assert
+	assert.ErrorIs(t, err, core.ErrSeedInProduction)
+	assert.Nil(t, result)
+	_, err = store.NewProjectStore(database).GetByID(core.WithAccessScope(ctx, core.AccessScope{System: true}), "5eed0000-0000-4000-8000-000000001001")
+	assert.ErrorIs(t, err, core.ErrProjectNotFound)
+}
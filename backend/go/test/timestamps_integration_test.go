@@ -0,0 +1,97 @@
+//go:build integration
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/http/handlers"
+	httpmiddleware "github.com/provemyself/backend/internal/http/middleware"
+	"github.com/provemyself/backend/internal/store"
+)
+
+// inTokyo runs the rest of the test with the process's local time zone,
+// which TZ sets at startup, nine hours ahead of UTC
+func inTokyo(t *testing.T) {
+	t.Helper()
+
+	local := time.Local
+	time.Local = time.FixedZone("JST", 9*60*60)
+	t.Cleanup(func() { time.Local = local })
+}
+
+// timestamped is the time fields of a project or item response, kept as
+// sent
+type timestamped struct {
+	ID        string `json:"id"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// requestJSON sends a request to handler and decodes the response
+func requestJSON(t *testing.T, handler http.Handler, method, path, body string, expectedStatus int) timestamped {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, expectedStatus, rec.Code, rec.Body.String())
+
+	var response timestamped
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	return response
+}
+
+// assertUTC checks that a response time is RFC 3339 in UTC
+func assertUTC(t *testing.T, value string) {
+	t.Helper()
+
+	assert.True(t, strings.HasSuffix(value, "Z"), "%q is not UTC", value)
+	_, err := time.Parse(time.RFC3339Nano, value)
+	assert.NoError(t, err)
+}
+
+func TestTimestamps_ResponsesAreUTCInAnyTimeZone(t *testing.T) {
+	// Arrange
+	inTokyo(t)
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	projectStore := store.NewProjectStore(database)
+	validate := httpmiddleware.NewValidator()
+	projects := handlers.NewProjectHandler(core.NewProjectService(projectStore, nil), validate)
+	items := handlers.NewItemHandler(core.NewItemService(store.NewItemStore(database), projectStore), validate)
+
+	r := chi.NewRouter()
+	r.Post("/projects", projects.CreateProject)
+	r.Get("/projects/{projectId}", projects.GetProject)
+	r.Post("/projects/{projectId}/items", items.CreateItem)
+	r.Get("/projects/{projectId}/items/{itemId}", items.GetItem)
+
+	// Act
+	createdProject := requestJSON(t, r, http.MethodPost, "/projects", `{"title":"Tide Tables"}`, http.StatusCreated)
+	gotProject := requestJSON(t, r, http.MethodGet, "/projects/"+createdProject.ID, "", http.StatusOK)
+	itemsPath := "/projects/" + createdProject.ID + "/items"
+	createdItem := requestJSON(t, r, http.MethodPost, itemsPath, `{"type":"title","title":"Welcome","content":{"text":"Hello"},"position":0}`, http.StatusCreated)
+	gotItem := requestJSON(t, r, http.MethodGet, itemsPath+"/"+createdItem.ID, "", http.StatusOK)
+
+	// Assert
+	for _, response := range []timestamped{createdProject, gotProject, createdItem, gotItem} {
+		assertUTC(t, response.CreatedAt)
+		assertUTC(t, response.UpdatedAt)
+	}
+	assert.Equal(t, createdProject, gotProject)
+	assert.Equal(t, createdItem, gotItem)
+}
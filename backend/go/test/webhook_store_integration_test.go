@@ -0,0 +1,297 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/provemyself/backend/internal/core"
+	"github.com/provemyself/backend/internal/store"
+)
+
+func newWebhook(userID, url string, events ...core.EventType) *core.Webhook {
+	return &core.Webhook{
+		UserID:     userID,
+		OwnerEmail: userID + "@example.com",
+		URL:        url,
+		Secret:     "whsec-0123456789abcdef",
+		Events:     events,
+		Active:     true,
+	}
+}
+
+func TestWebhookStore_CRUD(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	webhooks := store.NewWebhookStore(database)
+
+	// Act
+	created, err := webhooks.Create(ctx, newWebhook("alice", "https://hooks.example.com/a", core.EventProjectCreated, core.EventProjectPublished))
+	require.NoError(t, err)
+	_, err = webhooks.Create(ctx, newWebhook("bob", "https://hooks.example.com/b", core.EventProjectCreated))
+	require.NoError(t, err)
+	unknownOrg := newWebhook("alice", "https://hooks.example.com/c", core.EventProjectCreated)
+	orgID := uuid.NewString()
+	unknownOrg.OrgID = &orgID
+	_, orgErr := webhooks.Create(ctx, unknownOrg)
+
+	_, othersErr := webhooks.Get(ctx, "bob", created.ID)
+	listed, err := webhooks.List(ctx, "alice")
+	require.NoError(t, err)
+
+	created.URL = "https://hooks.example.com/renamed"
+	created.Events = []core.EventType{core.EventProjectDeleted}
+	created.Active = false
+	updated, err := webhooks.Update(ctx, created)
+	require.NoError(t, err)
+	bobs := *updated
+	bobs.UserID = "bob"
+	_, updateOthersErr := webhooks.Update(ctx, &bobs)
+
+	// Assert
+	assert.ErrorIs(t, orgErr, core.ErrOrganizationNotFound)
+	assert.ErrorIs(t, othersErr, core.ErrWebhookNotFound, "webhooks belong to their user")
+	require.Len(t, listed, 1)
+	assert.Equal(t, []core.EventType{core.EventProjectCreated, core.EventProjectPublished}, listed[0].Events)
+	assert.Equal(t, "alice@example.com", listed[0].OwnerEmail)
+	assert.True(t, listed[0].Active)
+	assert.Nil(t, listed[0].OrgID)
+
+	assert.Equal(t, "https://hooks.example.com/renamed", updated.URL)
+	assert.Equal(t, []core.EventType{core.EventProjectDeleted}, updated.Events)
+	assert.False(t, updated.Active)
+	assert.ErrorIs(t, updateOthersErr, core.ErrWebhookNotFound)
+
+	assert.ErrorIs(t, webhooks.Delete(ctx, "bob", created.ID), core.ErrWebhookNotFound)
+	require.NoError(t, webhooks.Delete(ctx, "alice", created.ID))
+	_, err = webhooks.Get(ctx, "alice", created.ID)
+	assert.ErrorIs(t, err, core.ErrWebhookNotFound)
+}
+
+func TestWebhookStore_ListSubscribed(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	webhooks := store.NewWebhookStore(database)
+	orgs := store.NewOrganizationStore(database)
+	org, err := orgs.Create(ctx, "Acme", nil)
+	require.NoError(t, err)
+
+	created, err := webhooks.Create(ctx, newWebhook("alice", "https://hooks.example.com/created", core.EventProjectCreated))
+	require.NoError(t, err)
+	_, err = webhooks.Create(ctx, newWebhook("alice", "https://hooks.example.com/published", core.EventProjectPublished))
+	require.NoError(t, err)
+	inactive := newWebhook("alice", "https://hooks.example.com/inactive", core.EventProjectCreated)
+	inactive.Active = false
+	_, err = webhooks.Create(ctx, inactive)
+	require.NoError(t, err)
+	_, err = orgs.PutMember(ctx, org.ID, "alice", core.MembershipRoleMember)
+	require.NoError(t, err)
+	acme := newWebhook("alice", "https://hooks.example.com/acme", core.EventProjectCreated)
+	acme.OrgID = &org.ID
+	acmeCreated, err := webhooks.Create(ctx, acme)
+	require.NoError(t, err)
+	former := newWebhook("bob", "https://hooks.example.com/former", core.EventProjectCreated)
+	former.OrgID = &org.ID
+	_, err = webhooks.Create(ctx, former)
+	require.NoError(t, err)
+
+	// Act
+	unscoped, err := webhooks.ListSubscribed(ctx, "", core.EventProjectCreated)
+	require.NoError(t, err)
+	scoped, err := webhooks.ListSubscribed(ctx, org.ID, core.EventProjectCreated)
+	require.NoError(t, err)
+	none, err := webhooks.ListSubscribed(ctx, org.ID, core.EventProjectDeleted)
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, unscoped, 1)
+	assert.Equal(t, created.ID, unscoped[0].ID)
+	require.Len(t, scoped, 1, "members only")
+	assert.Equal(t, acmeCreated.ID, scoped[0].ID)
+	assert.Empty(t, none)
+}
+
+func TestWebhookStore_RecordOutcome_DisablesOnce(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	webhooks := store.NewWebhookStore(database)
+	webhook, err := webhooks.Create(ctx, newWebhook("alice", "https://hooks.example.com/a", core.EventProjectCreated))
+	require.NoError(t, err)
+
+	_, err = webhooks.RecordOutcome(ctx, webhook.ID, false, 3)
+	require.NoError(t, err)
+	_, err = webhooks.RecordOutcome(ctx, webhook.ID, true, 3)
+	require.NoError(t, err)
+	_, err = webhooks.RecordOutcome(ctx, webhook.ID, false, 3)
+	require.NoError(t, err)
+
+	// Act: concurrent failures past the threshold
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var disabledCount int
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			disabled, err := webhooks.RecordOutcome(ctx, webhook.ID, false, 3)
+			assert.NoError(t, err)
+			if disabled {
+				mu.Lock()
+				disabledCount++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, 1, disabledCount, "the owner is told once")
+	got, err := webhooks.Get(ctx, "alice", webhook.ID)
+	require.NoError(t, err)
+	assert.False(t, got.Active)
+	assert.Equal(t, 6, got.FailureCount, "the success reset the count")
+	require.NotNil(t, got.DisabledAt)
+
+	got.Active = true
+	reactivated, err := webhooks.Update(ctx, got)
+	require.NoError(t, err)
+	assert.True(t, reactivated.Active)
+	assert.Zero(t, reactivated.FailureCount)
+	assert.Nil(t, reactivated.DisabledAt)
+
+	_, err = webhooks.RecordOutcome(ctx, uuid.NewString(), false, 3)
+	assert.ErrorIs(t, err, core.ErrWebhookNotFound)
+}
+
+func TestWebhookStore_Deliveries(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	webhooks := store.NewWebhookStore(database)
+	webhook, err := webhooks.Create(ctx, newWebhook("alice", "https://hooks.example.com/a", core.EventProjectCreated))
+	require.NoError(t, err)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		delivery, err := webhooks.CreateDelivery(ctx, &core.WebhookDelivery{
+			WebhookID: webhook.ID,
+			EventID:   uuid.NewString(),
+			EventType: core.EventProjectCreated,
+			Payload:   []byte(`{"type":"project.created"}`),
+			Status:    core.WebhookDeliveryPending,
+		})
+		require.NoError(t, err)
+		ids = append(ids, delivery.ID)
+		// Deliveries are listed by creation time
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, unknownErr := webhooks.CreateDelivery(ctx, &core.WebhookDelivery{
+		WebhookID: uuid.NewString(), EventID: "e", EventType: core.EventProjectCreated, Payload: []byte(`{}`), Status: core.WebhookDeliveryPending,
+	})
+
+	// Act
+	status := 502
+	completedAt := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, webhooks.CompleteDelivery(ctx, &core.WebhookDelivery{
+		ID:             ids[0],
+		Status:         core.WebhookDeliveryFailed,
+		Attempts:       4,
+		ResponseStatus: &status,
+		ResponseBody:   "Bad Gateway",
+		Latency:        250 * time.Millisecond,
+		Error:          "webhook endpoint responded 502",
+		CompletedAt:    &completedAt,
+	}))
+	got, err := webhooks.GetDelivery(ctx, webhook.ID, ids[0])
+	require.NoError(t, err)
+	_, otherErr := webhooks.GetDelivery(ctx, uuid.NewString(), ids[0])
+	page, total, err := webhooks.ListDeliveries(ctx, webhook.ID, 2, 0)
+	require.NoError(t, err)
+	purged, err := webhooks.PurgeDeliveries(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	_, afterPurge, err := webhooks.ListDeliveries(ctx, webhook.ID, 2, 0)
+	require.NoError(t, err)
+
+	// Assert
+	assert.ErrorIs(t, unknownErr, core.ErrWebhookNotFound)
+	assert.Equal(t, core.WebhookDeliveryFailed, got.Status)
+	assert.Equal(t, 4, got.Attempts)
+	require.NotNil(t, got.ResponseStatus)
+	assert.Equal(t, 502, *got.ResponseStatus)
+	assert.Equal(t, "Bad Gateway", got.ResponseBody)
+	assert.Equal(t, 250*time.Millisecond, got.Latency)
+	assert.Equal(t, `{"type":"project.created"}`, string(got.Payload))
+	require.NotNil(t, got.CompletedAt)
+	assert.WithinDuration(t, completedAt, *got.CompletedAt, time.Millisecond)
+	assert.ErrorIs(t, otherErr, core.ErrWebhookDeliveryNotFound)
+
+	assert.Equal(t, 3, total)
+	require.Len(t, page, 2)
+	assert.Equal(t, ids[2], page[0].ID, "newest first")
+	assert.Nil(t, page[0].ResponseStatus)
+
+	assert.Equal(t, int64(3), purged)
+	assert.Zero(t, afterPurge)
+}
+
+func TestWebhookStore_ListDeadLetters(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	database := migratedDatabase(t, ctx)
+	webhooks := store.NewWebhookStore(database)
+	webhook, err := webhooks.Create(ctx, newWebhook("alice", "https://hooks.example.com/a", core.EventProjectCreated))
+	require.NoError(t, err)
+	inactive := newWebhook("bob", "https://hooks.example.com/b", core.EventProjectCreated)
+	inactive.Active = false
+	inactive, err = webhooks.Create(ctx, inactive)
+	require.NoError(t, err)
+
+	deliver := func(webhookID, eventID string, status core.WebhookDeliveryStatus) string {
+		delivery, err := webhooks.CreateDelivery(ctx, &core.WebhookDelivery{
+			WebhookID: webhookID,
+			EventID:   eventID,
+			EventType: core.EventProjectCreated,
+			Payload:   []byte(`{"type":"project.created"}`),
+			Status:    status,
+		})
+		require.NoError(t, err)
+		// Dead letters are listed by creation time
+		time.Sleep(10 * time.Millisecond)
+		return delivery.ID
+	}
+	failed := deliver(webhook.ID, "event-failed", core.WebhookDeliveryFailed)
+	deliver(webhook.ID, "event-redelivered", core.WebhookDeliveryFailed)
+	deliver(webhook.ID, "event-redelivered", core.WebhookDeliverySucceeded)
+	deliver(webhook.ID, "event-failed-twice", core.WebhookDeliveryFailed)
+	failedAgain := deliver(webhook.ID, "event-failed-twice", core.WebhookDeliveryFailed)
+	deliver(webhook.ID, "event-redelivering", core.WebhookDeliveryFailed)
+	deliver(webhook.ID, "event-redelivering", core.WebhookDeliveryPending)
+	deliver(inactive.ID, "event-inactive", core.WebhookDeliveryFailed)
+
+	// Act
+	deadLetters, err := webhooks.ListDeadLetters(ctx, 10)
+	require.NoError(t, err)
+	limited, err := webhooks.ListDeadLetters(ctx, 1)
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, deadLetters, 2)
+	assert.Equal(t, failed, deadLetters[0].Delivery.ID)
+	assert.Equal(t, failedAgain, deadLetters[1].Delivery.ID)
+	assert.Equal(t, webhook.ID, deadLetters[0].Webhook.ID)
+	assert.Equal(t, webhook.URL, deadLetters[0].Webhook.URL)
+	assert.Equal(t, "whsec-0123456789abcdef", deadLetters[0].Webhook.Secret)
+	assert.Equal(t, `{"type":"project.created"}`, string(deadLetters[0].Delivery.Payload))
+	require.Len(t, limited, 1)
+	assert.Equal(t, failed, limited[0].Delivery.ID)
+}